@@ -4,17 +4,25 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	addonlib "github.com/jamesatintegratnio/hctl/internal/addon"
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/git"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // NewCmd returns the addon command group.
@@ -37,79 +45,140 @@ Addon values are resolved in three layers (last wins):
 	cmd.AddCommand(newAddonStatusCmd())
 	cmd.AddCommand(newAddonEnableCmd())
 	cmd.AddCommand(newAddonDisableCmd())
+	cmd.AddCommand(newAddonRenderCmd())
 
 	return cmd
 }
 
+// addonListItem is one addon as emitted by 'hctl addon list -o json' and
+// addressed by --columns. ArgoCD is nil when the cluster is unreachable or
+// no Application exists for the addon.
+type addonListItem struct {
+	Name        string               `json:"name" yaml:"name"`
+	Enabled     bool                 `json:"enabled" yaml:"enabled"`
+	Environment string               `json:"environment" yaml:"environment"`
+	Namespace   string               `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Chart       string               `json:"chart,omitempty" yaml:"chart,omitempty"`
+	Version     string               `json:"version,omitempty" yaml:"version,omitempty"`
+	ArgoCD      *platform.ArgoCDInfo `json:"argocd,omitempty" yaml:"argocd,omitempty"`
+	Age         string               `json:"age,omitempty" yaml:"age,omitempty"`
+}
+
 func newAddonListCmd() *cobra.Command {
-	var env string
+	var (
+		env     string
+		columns []string
+	)
 	cmd := &cobra.Command{
-		Use:     "list",
-		Short:   "List available addons",
+		Use:   "list",
+		Short: "List available addons",
+		Long: `List the addons defined for an environment with their ArgoCD status.
+
+Use -o wide to add namespace, chart version, revision, age, and message
+columns, or --columns to pick fields by their JSON path (see -o json), e.g.
+--columns name,enabled,argocd.healthStatus.`,
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.Get()
-			repoPath := cfg.RepoPath
-			if repoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
 			}
+			repoPath := cfg.RepoPath
 
 			if env == "" {
 				env = "production"
 			}
 
-			entries, err := readAddonsYAML(filepath.Join(repoPath, "addons", "environments", env, "addons", "addons.yaml"))
+			entries, err := addonlib.ReadEntries(addonlib.Layer{Kind: addonlib.LayerEnvironment, Name: env}.AddonsFile(repoPath))
 			if err != nil {
 				return err
 			}
 
-			if len(entries) == 0 {
-				fmt.Println(tui.DimStyle.Render("No addons defined"))
-				return nil
-			}
-
 			// Try to get ArgoCD app status
-			var appStatus map[string]string
+			var appStatus map[string]*unstructured.Unstructured
 			client, err := kube.NewClient(cfg.KubeContext)
 			if err == nil {
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				argoApps, err := client.ListArgoApps(ctx, "argocd")
 				if err == nil {
-					appStatus = make(map[string]string)
-					for _, app := range argoApps {
-						syncStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
-						healthStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "health", "status")
-						appStatus[app.GetName()] = fmt.Sprintf("%s/%s", syncStatus, healthStatus)
+					appStatus = make(map[string]*unstructured.Unstructured)
+					for i := range argoApps {
+						appStatus[argoApps[i].GetName()] = &argoApps[i]
 					}
 				}
 			}
 
+			names := make([]string, 0, len(entries))
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			items := []addonListItem{}
+			for _, name := range names {
+				entry := entries[name]
+				item := addonListItem{Name: name, Enabled: true, Environment: env}
+				if b, ok := entry["enabled"].(bool); ok {
+					item.Enabled = b
+				}
+				item.Namespace, _ = entry["namespace"].(string)
+				item.Chart, _ = entry["chartName"].(string)
+				item.Version, _ = entry["defaultVersion"].(string)
+				if app, ok := appStatus[name]; ok {
+					argo := platform.ArgoCDInfoFromApp(app)
+					item.ArgoCD = &argo
+					item.Age = tui.FormatAge(app.GetCreationTimestamp().Time)
+				}
+				items = append(items, item)
+			}
+
+			if len(columns) > 0 {
+				return tui.PrintColumns(items, columns)
+			}
+			if tui.PrintStructured(items) {
+				return nil
+			}
+
+			if len(items) == 0 {
+				fmt.Println(tui.DimStyle.Render("No addons defined"))
+				return nil
+			}
+
+			headers := []string{"ADDON", "ENABLED", "ENVIRONMENT", "STATUS"}
+			if tui.IsWide() {
+				headers = append(headers, "NAMESPACE", "VERSION", "REVISION", "AGE", "MESSAGE")
+			}
 			var rows [][]string
-			for name, entry := range entries {
+			for _, item := range items {
 				enabled := "yes"
-				if e, ok := entry["enabled"]; ok {
-					if b, ok := e.(bool); ok && !b {
-						enabled = tui.DimStyle.Render("no")
-					}
+				if !item.Enabled {
+					enabled = tui.DimStyle.Render("no")
 				}
 
 				status := tui.DimStyle.Render("—")
-				if appStatus != nil {
-					if s, ok := appStatus[name]; ok {
-						if s == "Synced/Healthy" {
-							status = tui.SuccessStyle.Render(s)
-						} else {
-							status = tui.WarningStyle.Render(s)
-						}
+				if item.ArgoCD != nil {
+					s := fmt.Sprintf("%s/%s", item.ArgoCD.SyncStatus, item.ArgoCD.HealthStatus)
+					if s == "Synced/Healthy" {
+						status = tui.SuccessStyle.Render(s)
+					} else {
+						status = tui.WarningStyle.Render(s)
 					}
 				}
-				rows = append(rows, []string{name, enabled, env, status})
+				row := []string{item.Name, enabled, env, status}
+				if tui.IsWide() {
+					var revision, message string
+					if item.ArgoCD != nil {
+						revision, message = tui.ShortRevision(item.ArgoCD.Revision), item.ArgoCD.Message
+					}
+					row = append(row, tui.OrDash(item.Namespace), tui.OrDash(item.Version), tui.OrDash(revision), tui.OrDash(item.Age), tui.OrDash(message))
+				}
+				rows = append(rows, row)
 			}
 
 			_, err = tui.InteractiveTable(tui.InteractiveTableConfig{
 				Title:   "Addons (" + env + ")",
-				Headers: []string{"ADDON", "ENABLED", "ENVIRONMENT", "STATUS"},
+				Headers: headers,
 				Rows:    rows,
 				OnSelect: func(row []string, index int) string {
 					if len(row) == 0 {
@@ -145,30 +214,28 @@ func newAddonListCmd() *cobra.Command {
 					// 1. Environment layer
 					layers = append(layers, valueLayer{
 						label: fmt.Sprintf("environment/%s", env),
-						path:  filepath.Join(repoPath, "addons", "environments", env, "addons", folderName, "values.yaml"),
+						path:  repopath.Abs(repoPath, repopath.Join(layout.EnvironmentAddonsDir(env), folderName, "values.yaml")),
 					})
 
 					// 2. Cluster-role layers (discover all roles)
-					rolesDir := filepath.Join(repoPath, "addons", "cluster-roles")
-					if roleDirs, err := os.ReadDir(rolesDir); err == nil {
+					if roleDirs, err := os.ReadDir(repopath.Abs(repoPath, layout.ClusterRolesDir)); err == nil {
 						for _, d := range roleDirs {
 							if d.IsDir() {
 								layers = append(layers, valueLayer{
 									label: fmt.Sprintf("cluster-role/%s", d.Name()),
-									path:  filepath.Join(rolesDir, d.Name(), "addons", folderName, "values.yaml"),
+									path:  repopath.Abs(repoPath, repopath.Join(layout.ClusterRoleAddonsDir(d.Name()), folderName, "values.yaml")),
 								})
 							}
 						}
 					}
 
 					// 3. Cluster layers (discover all clusters)
-					clustersDir := filepath.Join(repoPath, "addons", "clusters")
-					if clusterDirs, err := os.ReadDir(clustersDir); err == nil {
+					if clusterDirs, err := os.ReadDir(repopath.Abs(repoPath, layout.ClustersDir)); err == nil {
 						for _, d := range clusterDirs {
 							if d.IsDir() {
 								layers = append(layers, valueLayer{
 									label: fmt.Sprintf("cluster/%s", d.Name()),
-									path:  filepath.Join(clustersDir, d.Name(), "addons", folderName, "values.yaml"),
+									path:  repopath.Abs(repoPath, repopath.Join(layout.ClusterAddonsDir(d.Name()), folderName, "values.yaml")),
 								})
 							}
 						}
@@ -183,10 +250,9 @@ func newAddonListCmd() *cobra.Command {
 					}
 
 					// Show ArgoCD status if available
-					if appStatus != nil {
-						if s, ok := appStatus[addonName]; ok {
-							sb.WriteString(fmt.Sprintf("\n  ArgoCD: %s\n", s))
-						}
+					if app, ok := appStatus[addonName]; ok {
+						argo := platform.ArgoCDInfoFromApp(app)
+						sb.WriteString(fmt.Sprintf("\n  ArgoCD: %s/%s\n", argo.SyncStatus, argo.HealthStatus))
 					}
 					return sb.String()
 				},
@@ -196,6 +262,7 @@ func newAddonListCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&env, "environment", "", "environment to list addons for (default: production)")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "comma-separated JSON paths to show as a plain table (e.g. name,enabled,argocd.healthStatus)")
 	return cmd
 }
 
@@ -218,7 +285,7 @@ func newAddonStatusCmd() *cobra.Command {
 
 			app, err := client.GetArgoApp(ctx, "argocd", addonName)
 			if err != nil {
-				return fmt.Errorf("addon %q not found as ArgoCD application: %w", addonName, err)
+				return kube.ClassifyError(fmt.Errorf("addon %q not found as ArgoCD application: %w", addonName, err))
 			}
 
 			syncStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
@@ -238,14 +305,17 @@ func newAddonStatusCmd() *cobra.Command {
 
 func newAddonEnableCmd() *cobra.Command {
 	var (
-		env        string
-		cluster    string
+		env         string
+		cluster     string
 		clusterRole string
-		namespace  string
-		chartRepo  string
-		chartName  string
-		version    string
-		layer      string
+		namespace   string
+		chartRepo   string
+		chartName   string
+		version     string
+		layer       string
+		alsoValues  []string
+		dependsOn   []string
+		planFile    string
 	)
 	cmd := &cobra.Command{
 		Use:   "enable [addon]",
@@ -258,120 +328,100 @@ Addons can be enabled at different layers:
   --layer cluster       — affects a single cluster
 
 If the addon already exists in addons.yaml, its 'enabled' field is set to true.
-If it doesn't exist, a new entry is created with Stakater Application chart defaults.`,
-		Args: cobra.ExactArgs(1),
+If it doesn't exist, a new entry is created with Stakater Application chart defaults.
+
+An addon whose dependsOn lists other addons is enabled after them: missing
+dependencies are offered to be enabled in the same change (interactive) or
+reported (non-interactive), and the addon's sync-wave annotation is set one
+after theirs so ArgoCD applies them first. A dependency may be defined at
+the same layer or a broader one. --depends-on adds to the list.
+
+Use --also-values-layer to write values at other layers in the same change,
+e.g. --also-values-layer cluster=vcluster-media:media-values.yaml. Without a
+file, a placeholder values.yaml is scaffolded. Alternatively, --plan takes a
+YAML file listing every operation:
+
+  addon: grafana
+  operations:
+    - op: enable
+      layer: environment=production
+    - op: values
+      layer: cluster=vcluster-media
+      values:
+        replicas: 2
+
+All file changes are previewed together, written as a unit (rolled back if
+any write fails), and committed in a single git operation.`,
+		Example: `  hctl addon enable grafana
+  hctl addon enable grafana --also-values-layer cluster=vcluster-media
+  hctl addon enable nginx-gateway-fabric --depends-on gateway-api-crds
+  hctl addon enable --plan grafana-plan.yaml`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			addonName := args[0]
 			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
-			}
-
-			if env == "" {
-				env = "production"
-			}
-			if namespace == "" {
-				namespace = addonName
-			}
-			if layer == "" {
-				layer = "environment"
-			}
-
-			// Determine addons.yaml path based on layer
-			addonsPath, valuesDir, err := resolveLayerPaths(cfg.RepoPath, layer, env, clusterRole, cluster, addonName)
-			if err != nil {
-				return err
-			}
-
-			// Read or create addons.yaml
-			entries, err := readAddonsYAML(addonsPath)
-			if err != nil && !os.IsNotExist(err) {
+			if err := cfg.RequireRepoPath(); err != nil {
 				return err
 			}
-			if entries == nil {
-				entries = make(map[string]map[string]interface{})
-			}
 
-			var changedPaths []string
-
-			if existing, ok := entries[addonName]; ok {
-				// Addon exists — set enabled: true
-				existing["enabled"] = true
-				entries[addonName] = existing
-				fmt.Printf("%s Enabled %s in %s\n", tui.SuccessStyle.Render(tui.IconCheck), addonName, addonsPath)
+			var (
+				addonName string
+				ops       []addonlib.Operation
+			)
+			if planFile != "" {
+				if cmd.Flags().Changed("layer") || len(alsoValues) > 0 || len(dependsOn) > 0 {
+					return hcerrors.NewUserError("--plan cannot be combined with --layer, --also-values-layer or --depends-on")
+				}
+				name, planOps, err := loadPlan(planFile, args, addonlib.OpEnable, addonlib.OpValues)
+				if err != nil {
+					return err
+				}
+				addonName, ops = name, planOps
 			} else {
-				// Create new addon entry
-				entry := map[string]interface{}{
-					"enabled":         true,
-					"namespace":       namespace,
-					"chartRepository": chartRepo,
-					"chartName":       chartName,
-					"defaultVersion":  version,
+				if len(args) == 0 {
+					return hcerrors.NewUserError("addon name is required (or use --plan)")
 				}
-
-				// Clean up empty defaults
-				if chartRepo == "" {
-					entry["chartRepository"] = "https://stakater.github.io/stakater-charts"
+				addonName = args[0]
+				if env == "" {
+					env = "production"
 				}
-				if chartName == "" {
-					entry["chartName"] = "application"
+				l, err := addonlib.NewLayer(layer, env, clusterRole, cluster)
+				if err != nil {
+					return err
 				}
-				if version == "" {
-					entry["defaultVersion"] = "6.14.0"
+				ops = append(ops, addonlib.Operation{
+					Op:              addonlib.OpEnable,
+					Layer:           l,
+					Namespace:       namespace,
+					ChartRepository: chartRepo,
+					ChartName:       chartName,
+					Version:         version,
+					DependsOn:       dependsOn,
+				})
+				for _, spec := range alsoValues {
+					op, err := parseValuesLayer(spec)
+					if err != nil {
+						return err
+					}
+					ops = append(ops, op)
 				}
-
-				entries[addonName] = entry
-				fmt.Printf("%s Added %s to %s\n", tui.SuccessStyle.Render(tui.IconCheck), addonName, filepath.Base(filepath.Dir(addonsPath)))
 			}
 
-			// Write addons.yaml
-			if err := writeAddonsYAML(addonsPath, entries); err != nil {
+			ops, err := resolveDependencies(cfg, addonName, ops)
+			if err != nil {
 				return err
 			}
-			changedPaths = append(changedPaths, addonsPath)
-
-			// Scaffold values directory
-			if err := os.MkdirAll(valuesDir, 0o755); err != nil {
-				return fmt.Errorf("creating values directory: %w", err)
-			}
-
-			valuesFile := filepath.Join(valuesDir, "values.yaml")
-			if _, err := os.Stat(valuesFile); os.IsNotExist(err) {
-				scaffold := fmt.Sprintf("# %s values\n# Layer: %s\n# See: https://github.com/stakater/application\n", addonName, layer)
-				if err := os.WriteFile(valuesFile, []byte(scaffold), 0o644); err != nil {
-					return fmt.Errorf("writing values scaffold: %w", err)
-				}
-				changedPaths = append(changedPaths, valuesFile)
-				fmt.Printf("%s Scaffolded %s\n", tui.SuccessStyle.Render(tui.IconCheck), valuesFile)
-			}
-
-			// Git operations
-			repo, err := git.DetectRepo(cfg.RepoPath)
+			guard, err := checkPolicy(cfg, ops)
 			if err != nil {
-				return nil
+				return err
 			}
-
-			// Convert to relative paths
-			var relPaths []string
-			for _, p := range changedPaths {
-				rp, err := repo.RelPath(p)
-				if err == nil {
-					relPaths = append(relPaths, rp)
-				}
+			plan, err := addonlib.Build(cfg.RepoPath, addonName, ops)
+			if err != nil {
+				return err
 			}
-
-			if _, err := git.HandleGitWorkflow(git.WorkflowOpts{
-				RepoPath:    cfg.RepoPath,
-				Paths:       relPaths,
-				Action:      "enable addon",
-				Resource:    addonName,
-				Details:     layer + "/" + env,
-				GitMode:     cfg.GitMode,
-				Interactive: cfg.Interactive,
-			}); err != nil {
+			applied, err := applyPlan(cfg, plan, "enable addon", opsDetails(ops), guard.Override(), cfg.Interactive && len(ops) > 1)
+			if err != nil || !applied {
 				return err
 			}
-
 			fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will sync the addon on next reconciliation."))
 			return nil
 		},
@@ -385,16 +435,21 @@ If it doesn't exist, a new entry is created with Stakater Application chart defa
 	cmd.Flags().StringVar(&chartName, "chart-name", "", "Helm chart name")
 	cmd.Flags().StringVar(&version, "version", "", "chart version")
 	cmd.Flags().StringVar(&layer, "layer", "environment", "config layer: environment, cluster-role, or cluster")
+	cmd.Flags().StringArrayVar(&alsoValues, "also-values-layer", nil, "also write values at <kind>=<name>[:values-file] (repeatable)")
+	cmd.Flags().StringSliceVar(&dependsOn, "depends-on", nil, "addons to add to the entry's dependsOn list (comma-separated)")
+	cmd.Flags().StringVar(&planFile, "plan", "", "YAML file describing operations across layers")
 	return cmd
 }
 
 func newAddonDisableCmd() *cobra.Command {
 	var (
-		env        string
-		cluster    string
+		env         string
+		cluster     string
 		clusterRole string
-		layer      string
-		remove     bool
+		layer       string
+		remove      bool
+		allLayers   bool
+		planFile    string
 	)
 	cmd := &cobra.Command{
 		Use:   "disable [addon]",
@@ -402,105 +457,84 @@ func newAddonDisableCmd() *cobra.Command {
 		Long: `Disable an addon by setting enabled: false in addons.yaml.
 
 With --remove, the addon entry and its values directory are deleted entirely.
-Without --remove, the entry remains but is marked disabled.`,
-		Args: cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			addonName := args[0]
-			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
-			}
+Without --remove, the entry remains but is marked disabled.
 
-			if env == "" {
-				env = "production"
-			}
-			if layer == "" {
-				layer = "environment"
-			}
+Enabled addons that list it in dependsOn, and would be left without it, are
+reported before the change is made.
 
-			addonsPath, valuesDir, err := resolveLayerPaths(cfg.RepoPath, layer, env, clusterRole, cluster, addonName)
-			if err != nil {
+With --all-layers, every environment, cluster-role, and cluster layer that
+references the addon is changed, and all changes land in a single commit.
+--plan takes a YAML file of disable/remove operations (see 'hctl addon enable --help').`,
+		Example: `  hctl addon disable grafana
+  hctl addon disable grafana --remove --all-layers`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
 				return err
 			}
 
-			entries, err := readAddonsYAML(addonsPath)
-			if err != nil {
-				return fmt.Errorf("reading addons.yaml: %w", err)
-			}
-
-			if _, ok := entries[addonName]; !ok {
-				return fmt.Errorf("addon %q not found in %s", addonName, addonsPath)
+			kind := addonlib.OpDisable
+			action := "disable addon"
+			if remove {
+				kind = addonlib.OpRemove
+				action = "remove addon"
 			}
-
-			if cfg.Interactive {
-				action := "disable"
-				if remove {
-					action = "remove"
+			layerFlagSet := cmd.Flags().Changed("layer") || cmd.Flags().Changed("cluster") || cmd.Flags().Changed("cluster-role")
+
+			var (
+				addonName string
+				ops       []addonlib.Operation
+				err       error
+			)
+			switch {
+			case planFile != "":
+				if allLayers || layerFlagSet {
+					return hcerrors.NewUserError("--plan cannot be combined with --all-layers or layer flags")
 				}
-				label := strings.ToUpper(action[:1]) + action[1:]
-				ok, _ := tui.Confirm(fmt.Sprintf("%s addon %q from %s?", label, addonName, filepath.Base(filepath.Dir(addonsPath))))
-				if !ok {
-					fmt.Println(tui.DimStyle.Render("Cancelled"))
-					return nil
-				}
-			}
-
-			var changedPaths []string
-
-			if remove {
-				delete(entries, addonName)
-				fmt.Printf("%s Removed %s from addons.yaml\n", tui.SuccessStyle.Render(tui.IconCheck), addonName)
-
-				// Remove values directory
-				if _, err := os.Stat(valuesDir); err == nil {
-					if err := os.RemoveAll(valuesDir); err != nil {
-						fmt.Printf("%s Could not remove values directory: %v\n", tui.WarningStyle.Render(tui.IconWarn), err)
-					} else {
-						fmt.Printf("%s Removed %s\n", tui.SuccessStyle.Render(tui.IconCheck), valuesDir)
-						changedPaths = append(changedPaths, valuesDir)
+				addonName, ops, err = loadPlan(planFile, args, addonlib.OpDisable, addonlib.OpRemove)
+				action = "disable addon"
+				for _, op := range ops {
+					if op.Op == addonlib.OpRemove {
+						action = "remove addon"
 					}
 				}
-			} else {
-				entries[addonName]["enabled"] = false
-				fmt.Printf("%s Disabled %s\n", tui.SuccessStyle.Render(tui.IconCheck), addonName)
+			case len(args) == 0:
+				return hcerrors.NewUserError("addon name is required (or use --plan)")
+			case allLayers:
+				if layerFlagSet {
+					return hcerrors.NewUserError("--all-layers cannot be combined with --layer, --cluster, or --cluster-role")
+				}
+				addonName = args[0]
+				ops, err = addonlib.AllLayersOps(cfg.RepoPath, addonName, kind)
+			default:
+				addonName = args[0]
+				if env == "" {
+					env = "production"
+				}
+				var l addonlib.Layer
+				l, err = addonlib.NewLayer(layer, env, clusterRole, cluster)
+				ops = []addonlib.Operation{{Op: kind, Layer: l}}
 			}
-
-			if err := writeAddonsYAML(addonsPath, entries); err != nil {
+			if err != nil {
 				return err
 			}
-			changedPaths = append(changedPaths, addonsPath)
 
-			// Git operations
-			repo, err := git.DetectRepo(cfg.RepoPath)
+			guard, err := checkPolicy(cfg, ops)
 			if err != nil {
-				return nil
+				return err
 			}
-
-			var relPaths []string
-			for _, p := range changedPaths {
-				rp, err := repo.RelPath(p)
-				if err == nil {
-					relPaths = append(relPaths, rp)
-				}
+			plan, err := addonlib.Build(cfg.RepoPath, addonName, ops)
+			if err != nil {
+				return err
 			}
-
-			action := "disable addon"
-			if remove {
-				action = "remove addon"
+			if err := warnDependents(cfg, addonName, ops); err != nil {
+				return err
 			}
-
-			if _, err := git.HandleGitWorkflow(git.WorkflowOpts{
-				RepoPath:    cfg.RepoPath,
-				Paths:       relPaths,
-				Action:      action,
-				Resource:    addonName,
-				Details:     layer + "/" + env,
-				GitMode:     cfg.GitMode,
-				Interactive: cfg.Interactive,
-			}); err != nil {
+			applied, err := applyPlan(cfg, plan, action, opsDetails(ops), guard.Override(), cfg.Interactive)
+			if err != nil || !applied {
 				return err
 			}
-
 			fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will reflect the change on next sync."))
 			return nil
 		},
@@ -511,70 +545,165 @@ Without --remove, the entry remains but is marked disabled.`,
 	cmd.Flags().StringVar(&clusterRole, "cluster-role", "", "cluster role (for --layer cluster-role)")
 	cmd.Flags().StringVar(&layer, "layer", "environment", "config layer: environment, cluster-role, or cluster")
 	cmd.Flags().BoolVar(&remove, "remove", false, "completely remove the addon entry and values")
+	cmd.Flags().BoolVar(&allLayers, "all-layers", false, "apply to every layer that references the addon")
+	cmd.Flags().StringVar(&planFile, "plan", "", "YAML file describing operations across layers")
 	return cmd
 }
 
 // --- Helpers ---
 
-// resolveLayerPaths returns the addons.yaml path and values directory for a given layer.
-func resolveLayerPaths(repoPath, layer, env, clusterRole, cluster, addonName string) (string, string, error) {
-	var base string
-	switch layer {
-	case "environment":
-		base = filepath.Join(repoPath, "addons", "environments", env, "addons")
-	case "cluster-role":
-		if clusterRole == "" {
-			return "", "", fmt.Errorf("--cluster-role is required for layer 'cluster-role'")
+// loadPlan reads a --plan file, checks it against the addon argument (if
+// given), and rejects operations the calling command does not perform.
+func loadPlan(path string, args []string, allowed ...addonlib.OpKind) (string, []addonlib.Operation, error) {
+	name, ops, err := addonlib.LoadPlanFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(args) == 1 && args[0] != name {
+		return "", nil, hcerrors.NewUserError("plan %s is for addon %q, not %q", path, name, args[0])
+	}
+	for i, op := range ops {
+		ok := false
+		for _, a := range allowed {
+			ok = ok || op.Op == a
 		}
-		base = filepath.Join(repoPath, "addons", "cluster-roles", clusterRole, "addons")
-	case "cluster":
-		if cluster == "" {
-			return "", "", fmt.Errorf("--cluster is required for layer 'cluster'")
+		if !ok {
+			return "", nil, hcerrors.New(hcerrors.ErrValidation, "plan %s: operations[%d]: %q is not supported by this command", path, i, op.Op)
 		}
-		base = filepath.Join(repoPath, "addons", "clusters", cluster, "addons")
-	default:
-		return "", "", fmt.Errorf("invalid layer %q (must be environment, cluster-role, or cluster)", layer)
 	}
+	return name, ops, nil
+}
 
-	addonsFile := filepath.Join(base, "addons.yaml")
-	valuesDir := filepath.Join(base, addonName)
-	return addonsFile, valuesDir, nil
+// parseValuesLayer parses an --also-values-layer value: <kind>=<name>, with
+// an optional :<file> whose contents become the layer's values.yaml.
+func parseValuesLayer(spec string) (addonlib.Operation, error) {
+	layerSpec, file, hasFile := strings.Cut(spec, ":")
+	l, err := addonlib.ParseLayer(layerSpec)
+	if err != nil {
+		return addonlib.Operation{}, err
+	}
+	op := addonlib.Operation{Op: addonlib.OpValues, Layer: l}
+	if !hasFile {
+		return op, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return addonlib.Operation{}, hcerrors.NewUserError("reading values for %s: %w", l, err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return addonlib.Operation{}, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", file, err)
+	}
+	op.Values = values
+	return op, nil
 }
 
-// readAddonsYAML reads and parses an addons.yaml file into a map of addon entries.
-func readAddonsYAML(path string) (map[string]map[string]interface{}, error) {
-	data, err := os.ReadFile(path)
+// opsDetails summarises the layers touched, for the commit message.
+func opsDetails(ops []addonlib.Operation) string {
+	var layers []string
+	seen := map[string]bool{}
+	for _, op := range ops {
+		if s := op.Layer.String(); !seen[s] {
+			seen[s] = true
+			layers = append(layers, s)
+		}
+	}
+	return strings.Join(layers, ", ")
+}
+
+// opVerbs describe each operation in policy messages.
+var opVerbs = map[addonlib.OpKind]string{
+	addonlib.OpEnable:  "enabling",
+	addonlib.OpValues:  "configuring",
+	addonlib.OpDisable: "disabling",
+	addonlib.OpRemove:  "removing",
+}
+
+// checkPolicy checks every layer ops touch against the repo's tenancy
+// policy and returns the guard, whose Override is recorded with the commit.
+func checkPolicy(cfg *config.Config, ops []addonlib.Operation) (*policy.Guard, error) {
+	guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
 	if err != nil {
 		return nil, err
 	}
+	for _, op := range ops {
+		if err := guard.AddonLayer(opVerbs[op.Op], op.Layer.Kind, op.Layer.Name); err != nil {
+			return nil, err
+		}
+	}
+	return guard, nil
+}
 
-	var raw map[string]interface{}
-	if err := yaml.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parsing addons.yaml: %w", err)
+// applyPlan previews every change in the plan, optionally asks for
+// confirmation, writes the files, and commits them in one git operation.
+// It reports false when there was nothing to do or the user cancelled.
+func applyPlan(cfg *config.Config, plan *addonlib.Plan, action, details, override string, confirm bool) (bool, error) {
+	if len(plan.Changes) == 0 {
+		fmt.Println(tui.DimStyle.Render("No changes — addon configuration is already up to date"))
+		return false, nil
 	}
 
-	entries := make(map[string]map[string]interface{})
-	for name, val := range raw {
-		if m, ok := val.(map[string]interface{}); ok {
-			entries[name] = m
+	mp := mutation.New(cfg.RepoPath)
+	for _, c := range plan.Changes {
+		mp.Add(c)
+	}
+	mp.Commit(git.WorkflowOpts{
+		Action:      action,
+		Resource:    plan.Addon,
+		Details:     details,
+		GitMode:     cfg.GitMode,
+		Interactive: cfg.Interactive,
+
+		PolicyOverride: override,
+	})
+	if cfg.DryRun {
+		return false, mp.DryRun()
+	}
+
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(fmt.Sprintf("Changes for %s (%d files)", plan.Addon, len(plan.Changes))))
+	for _, c := range plan.Changes {
+		rel := mp.Display(c.Path)
+		switch {
+		case c.Before == nil:
+			fmt.Printf("%s %s %s\n", tui.SuccessStyle.Render("+"), rel, tui.DimStyle.Render("(create)"))
+		case c.After == nil:
+			fmt.Printf("%s %s %s\n", tui.ErrorStyle.Render("-"), rel, tui.DimStyle.Render("(delete)"))
+			continue
+		default:
+			fmt.Printf("%s %s %s\n", tui.WarningStyle.Render("~"), rel, tui.DimStyle.Render("(update)"))
 		}
+		printHunks(deploylib.DiffLines(string(c.Before), string(c.After)))
 	}
-	return entries, nil
-}
+	fmt.Println()
 
-// writeAddonsYAML writes addon entries back to addons.yaml.
-func writeAddonsYAML(path string, entries map[string]map[string]interface{}) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("creating addons directory: %w", err)
+	if confirm {
+		ok, _ := tui.Confirm(fmt.Sprintf("Apply %d changes for %s?", len(plan.Changes), plan.Addon))
+		if !ok {
+			fmt.Println(tui.DimStyle.Render("Cancelled"))
+			return false, nil
+		}
 	}
 
-	data, err := yaml.Marshal(entries)
-	if err != nil {
-		return fmt.Errorf("marshaling addons.yaml: %w", err)
+	// The addon plan applies the files itself so it can prune the values
+	// directories it empties.
+	if err := plan.Apply(); err != nil {
+		return false, hcerrors.New(hcerrors.ErrInternal, "%w", err).
+			WithRemediation("Check file permissions under the addons/ directory and retry")
 	}
+	fmt.Printf("%s Wrote %d files for %s\n", tui.SuccessStyle.Render(tui.IconCheck), len(plan.Changes), plan.Addon)
+	return true, mp.Finish()
+}
 
-	if err := os.WriteFile(path, data, 0o644); err != nil {
-		return fmt.Errorf("writing addons.yaml: %w", err)
+// printHunks renders diff hunks in the style of 'hctl deploy drift'.
+func printHunks(hunks []deploylib.Hunk) {
+	for _, h := range hunks {
+		fmt.Printf("  %s\n", tui.DimStyle.Render(h.Header()))
+		for _, line := range h.Lines {
+			if line[0] == '-' {
+				fmt.Printf("  %s\n", tui.ErrorStyle.Render(line))
+			} else {
+				fmt.Printf("  %s\n", tui.SuccessStyle.Render(line))
+			}
+		}
 	}
-	return nil
 }
@@ -0,0 +1,144 @@
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	addonlib "github.com/jamesatintegratnio/hctl/internal/addon"
+	"github.com/jamesatintegratnio/hctl/internal/addondeps"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// resolveDependencies checks the dependsOn list of addon at every layer ops
+// enable it. Dependencies that are defined but disabled are enabled first,
+// after confirmation when interactive; otherwise the command fails listing
+// them. The returned ops enable dependencies before the addon and carry the
+// sync-waves that make ArgoCD apply them first.
+func resolveDependencies(cfg *config.Config, addon string, ops []addonlib.Operation) ([]addonlib.Operation, error) {
+	graph, err := addondeps.Load(cfg.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if op.Op != addonlib.OpEnable {
+			continue
+		}
+		e, ok := graph.Lookup(addon, op.Layer)
+		if !ok {
+			e = addondeps.Entry{Addon: addon, Layer: op.Layer}
+		}
+		e.Enabled = true
+		for _, d := range op.DependsOn {
+			if !contains(e.DependsOn, d) {
+				e.DependsOn = append(e.DependsOn, d)
+			}
+		}
+		graph.Set(e)
+	}
+
+	var (
+		deps      []addonlib.Operation
+		missing   []string
+		undefined []string
+	)
+	for _, op := range ops {
+		if op.Op != addonlib.OpEnable {
+			continue
+		}
+		m, u, err := graph.Missing(addon, op.Layer)
+		var cycle *addondeps.CycleError
+		if errors.As(err, &cycle) {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s at %s: %w", addon, op.Layer, err).
+				WithRemediation("Remove one of the dependsOn references so the addons no longer depend on each other")
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range u {
+			if s := fmt.Sprintf("%s (from %s)", name, op.Layer); !contains(undefined, s) {
+				undefined = append(undefined, s)
+			}
+		}
+		for _, e := range m {
+			if !contains(missing, e.String()) {
+				missing = append(missing, e.String())
+				deps = append(deps, addonlib.Operation{Op: addonlib.OpEnable, Layer: e.Layer, Addon: e.Addon})
+			}
+		}
+	}
+	if len(undefined) > 0 {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "%s depends on addons with no addons.yaml entry: %s", addon, strings.Join(undefined, ", ")).
+			WithRemediation("Define them in addons.yaml at the same or a broader layer, or remove them from dependsOn")
+	}
+	if len(deps) > 0 {
+		if !cfg.Interactive {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s depends on disabled addons: %s", addon, strings.Join(missing, ", ")).
+				WithRemediation("Enable them first with 'hctl addon enable', or run interactively to enable them together")
+		}
+		fmt.Printf("%s %s depends on disabled addons: %s\n", tui.WarningStyle.Render(tui.IconWarn), addon, strings.Join(missing, ", "))
+		if ok, _ := tui.Confirm("Enable them first?"); !ok {
+			return nil, hcerrors.NewUserError("%s needs %s enabled first", addon, strings.Join(missing, ", "))
+		}
+		for _, op := range deps {
+			e, _ := graph.Lookup(op.Addon, op.Layer)
+			e.Enabled = true
+			graph.Set(e)
+		}
+	}
+
+	all := append(deps, ops...)
+	for i, op := range all {
+		if op.Op != addonlib.OpEnable {
+			continue
+		}
+		name := op.Addon
+		if name == "" {
+			name = addon
+		}
+		if wave, ok := graph.SyncWave(name, op.Layer); ok {
+			all[i].SyncWave = &wave
+			e, _ := graph.Lookup(name, op.Layer)
+			e.Wave, e.HasWave = wave, true
+			graph.Set(e)
+		}
+	}
+	return all, nil
+}
+
+// warnDependents warns about enabled addons that depend on addon at the
+// layers ops disable or remove it. It does not stop the change.
+func warnDependents(cfg *config.Config, addon string, ops []addonlib.Operation) error {
+	graph, err := addondeps.Load(cfg.RepoPath)
+	if err != nil {
+		return err
+	}
+	var dependents []string
+	for _, op := range ops {
+		if op.Op != addonlib.OpDisable && op.Op != addonlib.OpRemove {
+			continue
+		}
+		for _, e := range graph.Dependents(addon, op.Layer) {
+			if !contains(dependents, e.String()) {
+				dependents = append(dependents, e.String())
+			}
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	fmt.Printf("%s Enabled addons depend on %s: %s\n", tui.WarningStyle.Render(tui.IconWarn), addon, strings.Join(dependents, ", "))
+	fmt.Println(tui.DimStyle.Render("  Their ArgoCD Applications may fail to sync until it is enabled again."))
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
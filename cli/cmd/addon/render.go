@@ -0,0 +1,110 @@
+package addon
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jamesatintegratnio/hctl/internal/appset"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func newAddonRenderCmd() *cobra.Command {
+	var (
+		cluster       string
+		clusterSecret string
+	)
+	cmd := &cobra.Command{
+		Use:   "render [addon]",
+		Short: "Render the ArgoCD Application an addon's ApplicationSet generates",
+		Long: `Render, offline, the ArgoCD Application the application-sets chart's
+ApplicationSet generates for an addon on one cluster.
+
+The chart is rendered with the cluster's addons.yaml layers as its bootstrap
+ApplicationSet passes them, and the addon's ApplicationSet generators and
+template are evaluated against the cluster's secret. Addons not in the
+platform layers are looked up in the cluster's workloads addons.yaml.
+
+For a vcluster in platform/vclusters, the cluster secret is derived from its
+request. For any other cluster, pass the secret with --cluster-secret.`,
+		Example: `  hctl addon render cert-manager-vcluster --cluster vcluster-media
+  hctl addon render sonarr --cluster vcluster-media -o yaml
+  kubectl get secret -n argocd the-cluster -o yaml > the-cluster.yaml
+  hctl addon render metallb --cluster the-cluster --cluster-secret the-cluster.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if cluster == "" && clusterSecret == "" {
+				return hcerrors.NewUserError("--cluster is required")
+			}
+
+			var c *appset.Cluster
+			var err error
+			if clusterSecret != "" {
+				data, rerr := os.ReadFile(clusterSecret)
+				if rerr != nil {
+					return hcerrors.New(hcerrors.ErrNotFound, "reading %s: %w", clusterSecret, rerr)
+				}
+				c, err = appset.ClusterFromSecret(data, cluster)
+			} else {
+				c, err = appset.ClusterFromVCluster(cfg.RepoPath, cluster)
+			}
+			if err != nil {
+				return err
+			}
+
+			res, err := appset.Render(cfg.RepoPath, args[0], c)
+			if err != nil {
+				return err
+			}
+			if tui.PrintStructured(res) {
+				return nil
+			}
+
+			out, err := res.Marshal()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf("# ApplicationSet %s (%s addons)", res.ApplicationSet, res.Source)))
+			fmt.Print(string(out))
+
+			fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Addon layers"))
+			for _, l := range res.Layers {
+				printValueFile(l, contains(res.Defines, l.File))
+			}
+			if len(res.ValueFiles) > 0 {
+				fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Value files"))
+				for _, vf := range res.ValueFiles {
+					printValueFile(vf, false)
+				}
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cluster, "cluster", "", "Cluster to render for (its ArgoCD cluster name)")
+	cmd.Flags().StringVar(&clusterSecret, "cluster-secret", "", "ArgoCD cluster secret manifest to render against")
+	return cmd
+}
+
+// printValueFile prints a values file with whether it exists; defines marks
+// a layer holding the addon's entry.
+func printValueFile(vf appset.ValueFile, defines bool) {
+	note := ""
+	if defines {
+		note = tui.DimStyle.Render("  (defines the addon)")
+	}
+	switch {
+	case vf.File == "":
+		fmt.Printf("  %s %s%s\n", tui.DimStyle.Render(tui.IconBullet), vf.Path, tui.DimStyle.Render("  (in the chart)"))
+	case vf.Exists:
+		fmt.Printf("  %s %s%s\n", tui.SuccessStyle.Render(tui.IconCheck), vf.File, note)
+	default:
+		fmt.Printf("  %s %s%s\n", tui.DimStyle.Render("○"), vf.File, tui.DimStyle.Render("  (missing, ignored)"))
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 	batchv1 "k8s.io/api/batch/v1"
@@ -74,12 +75,22 @@ func newReindexCmd() *cobra.Command {
 				Spec: cronJob.Spec.JobTemplate.Spec,
 			}
 
-			created, err := client.Clientset.BatchV1().Jobs(aiNamespace).Create(ctx, job, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("creating job: %w", err)
+			var created *batchv1.Job
+			mp := mutation.New(cfg.RepoPath)
+			mp.Cluster(fmt.Sprintf("create job %s/%s from cronjob %s", aiNamespace, jobName, cronJobName), func() error {
+				created, err = client.Clientset.BatchV1().Jobs(aiNamespace).Create(ctx, job, metav1.CreateOptions{})
+				if err != nil {
+					return fmt.Errorf("creating job: %w", err)
+				}
+				fmt.Printf("  %s Created job %s/%s\n", tui.SuccessStyle.Render(tui.IconCheck), aiNamespace, created.Name)
+				return nil
+			})
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if err := mp.Execute(); err != nil {
+				return err
 			}
-
-			fmt.Printf("  %s Created job %s/%s\n", tui.SuccessStyle.Render(tui.IconCheck), aiNamespace, created.Name)
 
 			if !wait {
 				fmt.Println("  Use --wait to follow until completion, or:")
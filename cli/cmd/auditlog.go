@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/audit"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var auditLogCmd = &cobra.Command{
+	Use:   "audit-log",
+	Short: "Query and verify the log of hctl changes to the gitops repo",
+	Long: `Every repo-changing hctl command (deploy run/remove, vcluster
+create/delete/resize, addon enable/disable) appends an entry to .hctl/audit.log
+in the gitops repo, committed together with the change. An entry records the
+command and its arguments (secrets redacted), the git or OS user, the time,
+and the hctl version. The COMMIT column is the commit that added the entry.
+Changes made with --policy-override are marked, and the entry keeps the
+reason and the policy rules that were overridden.
+
+Each entry includes the hash of the previous one. --verify checks the whole
+chain and fails (exit code 5) if any entry was edited or removed.`,
+	Example: `  hctl audit-log
+  hctl audit-log --resource myapp --since 2026-01-01
+  hctl audit-log --user alice -o json
+  hctl audit-log --verify`,
+	Args: cobra.NoArgs,
+	RunE: runAuditLog,
+}
+
+var (
+	auditResource string
+	auditUser     string
+	auditSince    string
+	auditUntil    string
+	auditVerify   bool
+)
+
+func init() {
+	auditLogCmd.Flags().StringVar(&auditResource, "resource", "", "only entries for this resource (workload, vcluster, or addon name)")
+	auditLogCmd.Flags().StringVar(&auditUser, "user", "", "only entries whose user contains this text")
+	auditLogCmd.Flags().StringVar(&auditSince, "since", "", "only entries at or after this date (YYYY-MM-DD or RFC 3339)")
+	auditLogCmd.Flags().StringVar(&auditUntil, "until", "", "only entries at or before this date (YYYY-MM-DD is inclusive)")
+	auditLogCmd.Flags().BoolVar(&auditVerify, "verify", false, "verify the hash chain instead of listing entries")
+}
+
+// auditRecord is an entry plus the commit that added it.
+type auditRecord struct {
+	audit.Entry
+	Commit string `json:"commit,omitempty"`
+}
+
+func runAuditLog(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	if err := cfg.RequireRepoPath(); err != nil {
+		return err
+	}
+	repo, err := git.DetectRepo(cfg.RepoPath)
+	if err != nil {
+		return hcerrors.Wrap(hcerrors.ErrGit, err)
+	}
+
+	entries, err := audit.Read(repo.Root)
+	if err != nil {
+		return err
+	}
+
+	if auditVerify {
+		if err := audit.Verify(entries); err != nil {
+			return err
+		}
+		result := map[string]any{"entries": len(entries), "valid": true}
+		return tui.RenderOutput(result, fmt.Sprintf("%s %d entries, hash chain intact\n",
+			tui.SuccessStyle.Render(tui.IconCheck), len(entries)))
+	}
+
+	filter := audit.Filter{Resource: auditResource, User: auditUser}
+	if filter.Since, err = parseAuditTime("--since", auditSince, false); err != nil {
+		return err
+	}
+	if filter.Until, err = parseAuditTime("--until", auditUntil, true); err != nil {
+		return err
+	}
+
+	// Blame maps each log line to the commit that added it. The log may be
+	// uncommitted (or absent from history), in which case commits stay empty.
+	commits, _ := repo.LineCommits(audit.LogPath)
+	var records []auditRecord
+	for i, e := range entries {
+		if !filter.Match(e) {
+			continue
+		}
+		r := auditRecord{Entry: e}
+		if i < len(commits) {
+			r.Commit = commits[i]
+		}
+		records = append(records, r)
+	}
+
+	if tui.IsStructured() {
+		if records == nil {
+			records = []auditRecord{}
+		}
+		return tui.RenderOutput(records, "")
+	}
+	if len(records) == 0 {
+		fmt.Println(tui.DimStyle.Render("No audit log entries match"))
+		return nil
+	}
+	var rows [][]string
+	for _, r := range records {
+		commit := tui.DimStyle.Render("uncommitted")
+		if len(r.Commit) >= 8 {
+			commit = r.Commit[:8]
+		}
+		change := r.Action + " " + r.Resource
+		if r.PolicyOverride != "" {
+			change += " " + tui.WarningStyle.Render("(policy override)")
+		}
+		if n := len(r.Removed); n > 0 {
+			change += " " + tui.DimStyle.Render(fmt.Sprintf("(%d object(s) removed)", n))
+		}
+		rows = append(rows, []string{
+			r.Time, r.User, strings.TrimPrefix(r.Command, "hctl "),
+			change, commit,
+		})
+	}
+	fmt.Println(tui.Table([]string{"TIME", "USER", "COMMAND", "CHANGE", "COMMIT"}, rows))
+	return nil
+}
+
+// parseAuditTime parses a --since/--until value. A bare date used as an
+// upper bound covers the whole day.
+func parseAuditTime(flag, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, hcerrors.NewUserError("%s: invalid date %q (expected YYYY-MM-DD or RFC 3339)", flag, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
@@ -0,0 +1,363 @@
+package bulk
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bulklib "github.com/jamesatintegratnio/hctl/internal/bulk"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns the bulk command group.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Apply one change across many workloads, addons and vClusters",
+		Long: `Preview and apply a plan file that edits many workloads, addons and
+vClusters at once.
+
+A plan selects targets by cluster, workload and addon globs and lists edits:
+
+  description: Move media workloads to chart 6.15.0
+  selector:
+    clusters: ["vcluster-*"]
+    workloads: ["*"]
+  edits:
+    - op: bump-chart-version       # defaultVersion of each entry
+      version: 6.15.0
+    - op: set                      # in: values (default), entry, or vcluster
+      path: deployment.resources.requests.cpu
+      value: 100m
+      minimum: true                # only raise smaller values
+    - op: add-label                # ArgoCD cluster label on each vCluster
+      key: team
+      value: media
+
+Edits keep comments and layout and are idempotent: applying a plan twice
+changes nothing the second time.`,
+	}
+
+	cmd.AddCommand(newBulkPlanCmd())
+	cmd.AddCommand(newBulkApplyCmd())
+
+	return cmd
+}
+
+func newBulkPlanCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Preview the changes a bulk plan file makes",
+		Long: `Show every file a bulk plan would change with its diff, the edits
+skipped for each target, and a summary. Nothing is written.`,
+		Example: `  hctl bulk plan -f bump-media.yaml
+  hctl bulk plan -f bump-media.yaml -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			f, plan, err := load(cfg, file)
+			if err != nil {
+				return err
+			}
+			if tui.PrintStructured(structured(cfg, plan)) {
+				return nil
+			}
+			preview(cfg, f, plan)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "bulk plan file (required)")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func newBulkApplyCmd() *cobra.Command {
+	var (
+		file         string
+		splitCommits bool
+	)
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a bulk plan file and commit the changes",
+		Long: `Preview a bulk plan, ask for confirmation, write every change, and
+commit them as one change. If any file cannot be written, the files already
+written are restored.
+
+With --split-commits each cluster's files are committed separately, with
+the shared environment and cluster-role addon layers in their own commit.`,
+		Example: `  hctl bulk apply -f bump-media.yaml
+  hctl bulk apply -f bump-media.yaml --split-commits`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			f, plan, err := load(cfg, file)
+			if err != nil {
+				return err
+			}
+			guard, err := checkPolicy(cfg, plan)
+			if err != nil {
+				return err
+			}
+			resource := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			mp := mutation.New(cfg.RepoPath)
+			for _, c := range plan.Files() {
+				mp.Add(c)
+			}
+			planCommits(cfg, mp, plan, resource, guard.Override(), splitCommits)
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if !preview(cfg, f, plan) {
+				return nil
+			}
+
+			if cfg.Interactive {
+				ok, _ := tui.Confirm(fmt.Sprintf("Apply %d changes?", len(plan.Changes)))
+				if !ok {
+					fmt.Println(tui.DimStyle.Render("Cancelled"))
+					return nil
+				}
+			}
+			if err := mp.Apply(); err != nil {
+				return hcerrors.New(hcerrors.ErrInternal, "%w", err).
+					WithRemediation("Check file permissions in the repository and retry")
+			}
+			fmt.Printf("%s Wrote %d files\n", tui.SuccessStyle.Render(tui.IconCheck), len(plan.Changes))
+			return mp.Finish()
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "bulk plan file (required)")
+	cmd.Flags().BoolVar(&splitCommits, "split-commits", false, "commit each cluster's changes separately")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func load(cfg *config.Config, file string) (*bulklib.File, *bulklib.Plan, error) {
+	f, err := bulklib.LoadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := bulklib.Build(cfg.RepoPath, f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, plan, nil
+}
+
+// checkPolicy checks every target the plan edits against the repo's
+// tenancy policy and returns the guard, whose Override is recorded with the
+// commit.
+func checkPolicy(cfg *config.Config, plan *bulklib.Plan) (*policy.Guard, error) {
+	guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range plan.Targets {
+		switch t.Kind {
+		case bulklib.TargetAddon:
+			err = guard.AddonLayer("bulk editing", t.Layer.Kind, t.Layer.Name)
+		case bulklib.TargetVCluster:
+			err = guard.Cluster("bulk editing vcluster", t.Name)
+		default:
+			err = guard.Cluster("bulk editing workloads on cluster", t.Cluster)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return guard, nil
+}
+
+// preview prints every change with its diff, the skipped edits and a
+// summary. It reports false when there is nothing to change.
+func preview(cfg *config.Config, f *bulklib.File, plan *bulklib.Plan) bool {
+	if len(plan.Targets) == 0 {
+		fmt.Println(tui.DimStyle.Render("No targets match the plan's selector"))
+		return false
+	}
+	if len(plan.Changes) > 0 {
+		title := fmt.Sprintf("Bulk changes (%d files)", len(plan.Changes))
+		if f.Description != "" {
+			title = fmt.Sprintf("%s (%d files)", f.Description, len(plan.Changes))
+		}
+		fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(title))
+	}
+	for _, c := range plan.Changes {
+		fmt.Printf("%s %s %s\n", tui.WarningStyle.Render("~"), relPath(cfg, c.Path),
+			tui.DimStyle.Render("("+strings.Join(c.Targets, ", ")+")"))
+		printHunks(deploylib.DiffLines(string(c.Before), string(c.After)))
+	}
+	if len(plan.Skipped) > 0 {
+		fmt.Printf("\n%s\n", tui.TitleStyle.Render("Skipped"))
+		for _, s := range plan.Skipped {
+			fmt.Printf("  %s %s: %s %s\n", tui.MutedStyle.Render(tui.IconArrow), s.Target, s.Edit,
+				tui.DimStyle.Render("("+s.Reason+")"))
+		}
+	}
+	fmt.Println()
+	if len(plan.Changes) == 0 {
+		fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf("No changes — the plan is already applied (%d targets matched)", len(plan.Targets))))
+		return false
+	}
+	summary := fmt.Sprintf("%d files would change", len(plan.Changes))
+	if clusters := plan.Clusters(); len(clusters) > 0 {
+		summary += fmt.Sprintf(" across %d clusters", len(clusters))
+	}
+	fmt.Printf("%s (%d targets matched)\n\n", summary, len(plan.Targets))
+	return true
+}
+
+// bulkChange is one changed file as emitted by 'hctl bulk plan -o json'.
+type bulkChange struct {
+	Path    string   `json:"path" yaml:"path"`
+	Cluster string   `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Targets []string `json:"targets" yaml:"targets"`
+	Diff    string   `json:"diff" yaml:"diff"`
+}
+
+type bulkPlan struct {
+	Targets []string       `json:"targets" yaml:"targets"`
+	Changes []bulkChange   `json:"changes" yaml:"changes"`
+	Skipped []bulklib.Skip `json:"skipped" yaml:"skipped"`
+}
+
+func structured(cfg *config.Config, plan *bulklib.Plan) bulkPlan {
+	out := bulkPlan{Targets: []string{}, Changes: []bulkChange{}, Skipped: plan.Skipped}
+	if out.Skipped == nil {
+		out.Skipped = []bulklib.Skip{}
+	}
+	for _, t := range plan.Targets {
+		out.Targets = append(out.Targets, t.String())
+	}
+	for _, c := range plan.Changes {
+		var diff strings.Builder
+		for _, h := range deploylib.DiffLines(string(c.Before), string(c.After)) {
+			diff.WriteString(h.Header() + "\n")
+			for _, line := range h.Lines {
+				diff.WriteString(line + "\n")
+			}
+		}
+		out.Changes = append(out.Changes, bulkChange{
+			Path:    relPath(cfg, c.Path),
+			Cluster: c.Cluster,
+			Targets: c.Targets,
+			Diff:    diff.String(),
+		})
+	}
+	return out
+}
+
+// planCommits plans the git workflow for the written files: one commit, or
+// one per cluster with the shared addon layers first.
+func planCommits(cfg *config.Config, mp *mutation.Plan, plan *bulklib.Plan, resource, override string, split bool) {
+	repo, err := git.DetectRepo(cfg.RepoPath)
+	if err != nil {
+		return
+	}
+	groups := map[string][]bulklib.Change{"": plan.Changes}
+	if split {
+		groups = plan.ByCluster()
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, cluster := range keys {
+		changes := groups[cluster]
+		var paths []string
+		for _, c := range changes {
+			if rp, err := repo.RelPath(c.Path); err == nil {
+				paths = append(paths, rp)
+			}
+		}
+		opts := git.WorkflowOpts{
+			RepoPath:    cfg.RepoPath,
+			Paths:       paths,
+			Action:      "bulk edit",
+			Resource:    resource,
+			Details:     details(changes, cluster, split),
+			GitMode:     cfg.GitMode,
+			Interactive: cfg.Interactive,
+
+			PolicyOverride: override,
+		}
+		if split {
+			opts.ConfirmPrompt = fmt.Sprintf("Commit and push changes for %s?", orShared(cluster))
+		}
+		mp.GitStep(git.DescribeWorkflow(opts), func() error {
+			_, err := git.HandleGitWorkflow(opts)
+			return err
+		})
+	}
+}
+
+// details summarises the files and clusters in a commit, e.g.
+// "3 files across vcluster-dev, vcluster-media".
+func details(changes []bulklib.Change, cluster string, split bool) string {
+	files := fmt.Sprintf("%d files", len(changes))
+	if len(changes) == 1 {
+		files = "1 file"
+	}
+	if split {
+		return files + " in " + orShared(cluster)
+	}
+	seen := map[string]bool{}
+	var clusters []string
+	for _, c := range changes {
+		if c.Cluster != "" && !seen[c.Cluster] {
+			seen[c.Cluster] = true
+			clusters = append(clusters, c.Cluster)
+		}
+	}
+	if len(clusters) == 0 {
+		return files
+	}
+	sort.Strings(clusters)
+	return files + " across " + strings.Join(clusters, ", ")
+}
+
+func orShared(cluster string) string {
+	if cluster == "" {
+		return "shared addon layers"
+	}
+	return cluster
+}
+
+func relPath(cfg *config.Config, path string) string {
+	rel, err := filepath.Rel(cfg.RepoPath, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// printHunks renders diff hunks in the style of 'hctl deploy drift'.
+func printHunks(hunks []deploylib.Hunk) {
+	for _, h := range hunks {
+		fmt.Printf("  %s\n", tui.DimStyle.Render(h.Header()))
+		for _, line := range h.Lines {
+			if line[0] == '-' {
+				fmt.Printf("  %s\n", tui.ErrorStyle.Render(line))
+			} else {
+				fmt.Printf("  %s\n", tui.SuccessStyle.Render(line))
+			}
+		}
+	}
+}
@@ -8,16 +8,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/jamesatintegratnio/hctl/internal/config"
 	"github.com/jamesatintegratnio/hctl/internal/git"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/internal/versions"
 	"github.com/spf13/cobra"
 )
 
 func runInit(cmd *cobra.Command, args []string) error {
 	cfg := config.Default()
+	dryRun := config.Get().DryRun
+	mp := mutation.New("")
+	saveTitle := "Saving configuration"
+	if dryRun {
+		saveTitle = "Planning configuration"
+	}
 
 	results, err := tui.RunSteps(tui.IconPlay+"  Initializing hctl", []tui.Step{
 		{
@@ -53,9 +62,19 @@ func runInit(cmd *cobra.Command, args []string) error {
 			},
 		},
 		{
-			Title: "Saving configuration",
+			Title: saveTitle,
 			Run: func() (string, error) {
-				if err := config.Save(cfg); err != nil {
+				data, err := config.Marshal(cfg)
+				if err != nil {
+					return "", err
+				}
+				if err := mp.Write(config.ConfigPath(), data); err != nil {
+					return "", err
+				}
+				if dryRun {
+					return config.ConfigPath(), nil
+				}
+				if err := mp.Execute(); err != nil {
 					return "", err
 				}
 				return config.ConfigPath(), nil
@@ -74,6 +93,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if dryRun {
+		return mp.DryRun()
+	}
 	return nil
 }
 
@@ -87,6 +109,9 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 		return runStatusOnce(cfg)
 	}
+	if watchFlag {
+		return runStatusDashboardWatch(cfg)
+	}
 
 	return tui.RunDashboard(tui.IconPlay+" Platform Status", []tui.DashboardSection{
 		{
@@ -182,18 +207,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 				}
 				var rows [][]string
 				for _, p := range promises {
-					status := "Unknown"
-					conditions, _, _ := platform.UnstructuredNestedSlice(p.Object, "status", "conditions")
-					for _, c := range conditions {
-						if cm, ok := c.(map[string]interface{}); ok {
-							if cm["type"] == "Available" {
-								if cm["status"] == "True" {
-									status = tui.SuccessStyle.Render("Available")
-								} else {
-									status = tui.ErrorStyle.Render("Unavailable")
-								}
-							}
-						}
+					status := platform.PromiseStatus(p.Object)
+					switch status {
+					case platform.PromiseAvailable:
+						status = tui.SuccessStyle.Render(status)
+					case platform.PromiseUnavailable:
+						status = tui.ErrorStyle.Render(status)
 					}
 					rows = append(rows, []string{p.GetName(), status})
 				}
@@ -308,25 +327,56 @@ func runStatus(cmd *cobra.Command, args []string) error {
 				return sb.String(), nil
 			},
 		},
+		{
+			Title: "Updates",
+			Load: func() (string, error) {
+				if cfg.RepoPath == "" {
+					return tui.DimStyle.Render("  (repo path not set)"), nil
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+				defer cancel()
+
+				report, err := collectVersions(ctx, cfg, true)
+				if err != nil {
+					return "", err
+				}
+				var rows [][]string
+				for _, c := range report.Components {
+					if c.Status == versions.StatusOutdated {
+						rows = append(rows, []string{c.Name, c.Kind, c.Source, c.Current(), c.Latest, c.Delta})
+					}
+				}
+				if len(rows) == 0 {
+					return tui.DimStyle.Render(fmt.Sprintf("  (no newer releases among %d components; see 'hctl versions')", len(report.Components))), nil
+				}
+				return tui.Table([]string{"NAME", "KIND", "SOURCE", "CURRENT", "LATEST", "DELTA"}, rows), nil
+			},
+		},
 	})
 }
 
-// phaseStyled returns a styled phase string for TUI display.
-func phaseStyled(phase string) string {
-	switch phase {
-	case "Ready":
-		return tui.SuccessStyle.Render("Ready")
-	case "Progressing":
-		return tui.InfoStyle.Render("Progressing")
-	case "Degraded":
-		return tui.WarningStyle.Render("Degraded")
-	case "Failed":
-		return tui.ErrorStyle.Render("Failed")
-	case "Suspended":
-		return tui.DimStyle.Render("Suspended")
-	default:
-		return tui.DimStyle.Render(phase)
+// phaseStyles render each phase for display. TestPhaseStylesExhaustive
+// fails when a new phase is not added.
+var phaseStyles = map[string]func(...string) string{
+	phase.Scheduled:   tui.MutedStyle.Render,
+	phase.Configured:  tui.SuccessStyle.Render,
+	phase.Progressing: tui.InfoStyle.Render,
+	phase.Ready:       tui.SuccessStyle.Render,
+	phase.Degraded:    tui.WarningStyle.Render,
+	phase.Failed:      tui.ErrorStyle.Render,
+	phase.Paused:      tui.DimStyle.Render,
+	phase.Suspended:   tui.DimStyle.Render,
+	phase.Deleting:    tui.WarningStyle.Render,
+	phase.Unknown:     tui.DimStyle.Render,
+}
+
+// phaseStyled returns a styled phase string for TUI display. Legacy phase
+// strings are shown as the phase they mean.
+func phaseStyled(p string) string {
+	if render, ok := phaseStyles[phase.Normalize(p)]; ok {
+		return render(phase.Normalize(p))
 	}
+	return tui.DimStyle.Render(p)
 }
 
 // runStatusOnce collects platform status and prints it once in structured format.
@@ -404,7 +454,14 @@ func runDiagnose(cmd *cobra.Command, args []string) error {
 		}
 		if bundlePath != "" {
 			data, _ := json.MarshalIndent(bundle, "", "  ")
-			if writeErr := os.WriteFile(bundlePath, data, 0o644); writeErr != nil {
+			mp := mutation.New(cfg.RepoPath)
+			if err := mp.Write(bundlePath, data); err != nil {
+				return err
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if writeErr := mp.Execute(); writeErr != nil {
 				return fmt.Errorf("writing bundle: %w", writeErr)
 			}
 			fmt.Printf("%s Diagnostic bundle written to %s\n",
@@ -450,13 +507,18 @@ func runReconcile(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err = client.SetManualReconciliationLabel(ctx, kube.VClusterOrchestratorV2GVR, cfg.Platform.PlatformNamespace, name)
-	if err != nil {
-		return fmt.Errorf("setting reconciliation label: %w", err)
+	mp := mutation.New(cfg.RepoPath)
+	mp.Cluster("set kratix.io/manual-reconciliation=true on "+name, func() error {
+		if err := client.SetManualReconciliationLabel(ctx, kube.VClusterOrchestratorV2GVR, cfg.Platform.PlatformNamespace, name); err != nil {
+			return fmt.Errorf("setting reconciliation label: %w", err)
+		}
+		fmt.Printf("  %s Set kratix.io/manual-reconciliation=true on %s\n", tui.SuccessStyle.Render(tui.IconCheck), name)
+		return nil
+	})
+	if cfg.DryRun {
+		return mp.DryRun()
 	}
-
-	fmt.Printf("  %s Set kratix.io/manual-reconciliation=true on %s\n", tui.SuccessStyle.Render(tui.IconCheck), name)
-	return nil
+	return mp.Execute()
 }
 
 func runContext(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+)
+
+func TestPhaseStylesExhaustive(t *testing.T) {
+	for _, p := range phase.All {
+		if _, ok := phaseStyles[p]; !ok {
+			t.Errorf("phaseStyles has no style for %s", p)
+		}
+	}
+	for p := range phaseStyles {
+		if !phase.Known(p) {
+			t.Errorf("phaseStyles styles %q, which is not a phase", p)
+		}
+	}
+}
+
+func TestPhaseStyledNormalizesLegacy(t *testing.T) {
+	if got := phaseStyled("Terminating"); !strings.Contains(got, phase.Deleting) {
+		t.Errorf("phaseStyled(Terminating) = %q, want it shown as %s", got, phase.Deleting)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,9 +11,14 @@ import (
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
-	"github.com/jamesatintegratnio/hctl/internal/score"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
 	"github.com/spf13/cobra"
 )
 
@@ -52,9 +58,6 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	namespace := cluster // workloads deploy to namespace matching cluster name
 
-	fmt.Printf("\n  %s Scaling up %s in %s (replicas=%d)\n\n",
-		tui.InfoStyle.Render(tui.IconArrow), workloadName, cluster, upReplicas)
-
 	deploys, err := client.ListDeployments(ctx, namespace)
 	if err != nil {
 		return err
@@ -71,18 +74,33 @@ func runUp(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	mp := mutation.New(cfg.RepoPath)
 	for _, d := range matched {
 		if d.ArgoApp != "" {
-			fmt.Printf("    %s Re-enabling auto-sync for %s\n",
-				tui.MutedStyle.Render(tui.IconArrow), d.ArgoApp)
-			_ = client.EnableArgoAutoSync(ctx, "argocd", d.ArgoApp)
+			mp.Cluster("enable ArgoCD auto-sync for "+d.ArgoApp, func() error {
+				fmt.Printf("    %s Re-enabling auto-sync for %s\n",
+					tui.MutedStyle.Render(tui.IconArrow), d.ArgoApp)
+				_ = client.EnableArgoAutoSync(ctx, "argocd", d.ArgoApp)
+				return nil
+			})
 		}
+		mp.Cluster(fmt.Sprintf("scale deployment %s/%s to %d", namespace, d.Name, upReplicas), func() error {
+			fmt.Printf("    %s Scaling %s to %d\n",
+				tui.MutedStyle.Render(tui.IconArrow), d.Name, upReplicas)
+			if err := client.ScaleDeployment(ctx, namespace, d.Name, upReplicas); err != nil {
+				fmt.Printf("    %s Failed: %v\n", tui.WarningStyle.Render(tui.IconWarn), err)
+			}
+			return nil
+		})
+	}
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
 
-		fmt.Printf("    %s Scaling %s to %d\n",
-			tui.MutedStyle.Render(tui.IconArrow), d.Name, upReplicas)
-		if err := client.ScaleDeployment(ctx, namespace, d.Name, upReplicas); err != nil {
-			fmt.Printf("    %s Failed: %v\n", tui.WarningStyle.Render(tui.IconWarn), err)
-		}
+	fmt.Printf("\n  %s Scaling up %s in %s (replicas=%d)\n\n",
+		tui.InfoStyle.Render(tui.IconArrow), workloadName, cluster, upReplicas)
+	if err := mp.Execute(); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n  %s %s scaled up in %s\n", tui.SuccessStyle.Render(tui.IconCheck), workloadName, cluster)
@@ -108,14 +126,6 @@ func runDown(cmd *cobra.Command, args []string) error {
 	}
 
 	cfg := config.Get()
-	if cfg.Interactive {
-		ok, _ := tui.Confirm(fmt.Sprintf("Scale down %s in %s?", workloadName, cluster))
-		if !ok {
-			fmt.Println(tui.DimStyle.Render("Cancelled"))
-			return nil
-		}
-	}
-
 	client, err := kube.NewClient(cfg.KubeContext)
 	if err != nil {
 		return fmt.Errorf("connecting to cluster: %w", err)
@@ -126,9 +136,6 @@ func runDown(cmd *cobra.Command, args []string) error {
 
 	namespace := cluster
 
-	fmt.Printf("\n  %s Scaling down %s in %s\n\n",
-		tui.WarningStyle.Render(tui.IconArrow), workloadName, cluster)
-
 	deploys, err := client.ListDeployments(ctx, namespace)
 	if err != nil {
 		return err
@@ -143,20 +150,43 @@ func runDown(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	mp := mutation.New(cfg.RepoPath)
 	for _, d := range matched {
 		if d.ArgoApp != "" {
-			fmt.Printf("    %s Disabling auto-sync for %s\n",
-				tui.MutedStyle.Render(tui.IconArrow), d.ArgoApp)
-			_ = client.DisableArgoAutoSync(ctx, "argocd", d.ArgoApp)
+			mp.Cluster("disable ArgoCD auto-sync for "+d.ArgoApp, func() error {
+				fmt.Printf("    %s Disabling auto-sync for %s\n",
+					tui.MutedStyle.Render(tui.IconArrow), d.ArgoApp)
+				_ = client.DisableArgoAutoSync(ctx, "argocd", d.ArgoApp)
+				return nil
+			})
 		}
+		mp.Cluster(fmt.Sprintf("scale deployment %s/%s to 0", namespace, d.Name), func() error {
+			fmt.Printf("    %s Scaling %s to 0\n",
+				tui.MutedStyle.Render(tui.IconArrow), d.Name)
+			if err := client.ScaleDeployment(ctx, namespace, d.Name, 0); err != nil {
+				fmt.Printf("    %s Failed: %v\n", tui.WarningStyle.Render(tui.IconWarn), err)
+			}
+			return nil
+		})
+	}
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
 
-		fmt.Printf("    %s Scaling %s to 0\n",
-			tui.MutedStyle.Render(tui.IconArrow), d.Name)
-		if err := client.ScaleDeployment(ctx, namespace, d.Name, 0); err != nil {
-			fmt.Printf("    %s Failed: %v\n", tui.WarningStyle.Render(tui.IconWarn), err)
+	if cfg.Interactive {
+		ok, _ := tui.Confirm(fmt.Sprintf("Scale down %s in %s?", workloadName, cluster))
+		if !ok {
+			fmt.Println(tui.DimStyle.Render("Cancelled"))
+			return nil
 		}
 	}
 
+	fmt.Printf("\n  %s Scaling down %s in %s\n\n",
+		tui.WarningStyle.Render(tui.IconArrow), workloadName, cluster)
+	if err := mp.Execute(); err != nil {
+		return err
+	}
+
 	fmt.Printf("\n  %s %s scaled down in %s\n", tui.SuccessStyle.Render(tui.IconCheck), workloadName, cluster)
 	return nil
 }
@@ -184,7 +214,7 @@ func runOpen(cmd *cobra.Command, args []string) error {
 
 	// Try to find URL from the route resource in score.yaml
 	url := ""
-	if w, loadErr := score.LoadWorkload("score.yaml"); loadErr == nil {
+	if w, loadErr := score.FindWorkload("score.yaml", workloadName); loadErr == nil {
 		routes := w.ResourcesByType("route")
 		for _, r := range routes {
 			if host, ok := r.Params["host"].(string); ok {
@@ -196,9 +226,7 @@ func runOpen(cmd *cobra.Command, args []string) error {
 
 	// Fallback: check rendered values for httpRoute host
 	if url == "" {
-		valuesPath := fmt.Sprintf("workloads/%s/addons/%s/values.yaml", cluster, workloadName)
-		absPath := fmt.Sprintf("%s/%s", cfg.RepoPath, valuesPath)
-		if data, readErr := os.ReadFile(absPath); readErr == nil {
+		if data, readErr := os.ReadFile(repopath.Abs(cfg.RepoPath, translate.ValuesPath(cluster, workloadName))); readErr == nil {
 			content := string(data)
 			// Simple heuristic: find host in httpRoute section
 			for _, line := range strings.Split(content, "\n") {
@@ -309,7 +337,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	}
 
 	// Find pods for this workload
-	pods, err := client.ListPods(ctx, namespace, fmt.Sprintf("app.kubernetes.io/name=%s", workloadName))
+	pods, err := deploy.FindPods(ctx, client, namespace, workloadName, cluster)
 	if err != nil {
 		return fmt.Errorf("listing pods: %w", err)
 	}
@@ -345,14 +373,18 @@ func resolveWorkloadAndCluster(args []string) (workloadName, cluster string, err
 		workloadName = args[0]
 	}
 
-	// Try to load score.yaml for metadata
-	if w, loadErr := score.LoadWorkload("score.yaml"); loadErr == nil {
+	// Try to load score.yaml for metadata: the named workload's document, or
+	// the file's only workload. A file of several workloads needs a name.
+	w, loadErr := score.FindWorkload("score.yaml", workloadName)
+	if loadErr == nil {
 		if workloadName == "" {
 			workloadName = w.Metadata.Name
 		}
 		if cluster == "" {
 			cluster = w.TargetCluster()
 		}
+	} else if workloadName == "" && !errors.Is(loadErr, hcerrors.ErrNotFound) {
+		return "", "", loadErr
 	}
 
 	if workloadName == "" {
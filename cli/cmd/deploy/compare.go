@@ -0,0 +1,196 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// exitDifferences is the exit code of 'hctl deploy compare' when clusters
+// differ unexpectedly, matching 'hctl deploy diff'.
+const exitDifferences = 2
+
+// compareValueWidth truncates long values in the comparison table.
+const compareValueWidth = 48
+
+func newDeployCompareCmd() *cobra.Command {
+	var (
+		clusters []string
+		live     bool
+		all      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "compare <workload> --clusters <a>,<b>",
+		Short: "Compare a workload's configuration across clusters",
+		Long: `Compares the committed values.yaml and addons.yaml entry of a workload
+deployed to several clusters, field by field, and shows the fields that
+differ plus a diff of the unexpected differences.
+
+Differences only in per-cluster names are expected: each cluster's name,
+the workload's namespace there, and the hostname domain set for the cluster
+in .hctl/compare.yaml are normalized before comparing. Fields matching an
+ignore pattern in the same file are expected to differ too:
+
+  ignore:
+    - values.deployment.replicas
+    - "**.annotations.*"
+  domains:
+    vcluster-dev: dev.integratn.tech
+    vcluster-prod: integratn.tech
+
+With --live, compares the Helm values of the deployed ArgoCD Applications
+instead of the repo.
+
+Exit codes: 0 = only expected differences, 1 = error, 2 = unexpected differences.`,
+		Example: `  hctl deploy compare web --clusters vcluster-dev,vcluster-prod
+  hctl deploy compare web --clusters vcluster-dev,vcluster-prod --live --all`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workload := args[0]
+			if len(clusters) < 2 {
+				return hcerrors.NewUserError("--clusters needs at least two clusters, got %d", len(clusters))
+			}
+			cfg := config.Get()
+			if !live {
+				if err := cfg.RequireRepoPath(); err != nil {
+					return err
+				}
+			}
+			compareCfg, err := deploylib.LoadCompareConfig(cfg.RepoPath)
+			if err != nil {
+				return err
+			}
+
+			sources, err := compareSources(cfg, workload, clusters, live)
+			if err != nil {
+				return err
+			}
+			cmp := deploylib.CompareSources(workload, sources, compareCfg)
+
+			if !tui.PrintStructured(cmp) {
+				printComparison(cmp, all)
+			}
+			if cmp.Unexpected > 0 {
+				return &hcerrors.HctlError{
+					Code: exitDifferences,
+					Err:  fmt.Errorf("%d unexpected difference(s) between %s", cmp.Unexpected, strings.Join(cmp.Clusters, ", ")),
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&clusters, "clusters", nil, "clusters to compare, the first being the baseline")
+	cmd.Flags().BoolVar(&live, "live", false, "compare the values of the deployed ArgoCD Applications instead of the repo")
+	cmd.Flags().BoolVar(&all, "all", false, "also list fields that are the same")
+	_ = cmd.MarkFlagRequired("clusters")
+	return cmd
+}
+
+// compareSources loads the workload's configuration in each cluster, from
+// the repo or from the live ArgoCD Applications.
+func compareSources(cfg *config.Config, workload string, clusters []string, live bool) ([]*deploylib.CompareSource, error) {
+	var sources []*deploylib.CompareSource
+	if !live {
+		for _, cluster := range clusters {
+			src, err := deploylib.RepoCompareSource(cfg.RepoPath, cluster, workload)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, src)
+		}
+		return sources, nil
+	}
+
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to cluster: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, cluster := range clusters {
+		app, err := deploylib.FindApp(ctx, client, workload, cluster)
+		if err != nil {
+			return nil, hcerrors.New(hcerrors.ErrNotFound, "%v", err).
+				WithRemediation("run 'hctl deploy status " + workload + " --cluster " + cluster + "' to check the deployment")
+		}
+		src, err := deploylib.LiveCompareSource(app, cluster)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// printComparison renders the field table, then the unexpected differences
+// of each cluster against the first as diff hunks.
+func printComparison(cmp *deploylib.Comparison, all bool) {
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(cmp.Workload+": "+strings.Join(cmp.Clusters, " vs ")))
+
+	headers := append(append([]string{"FIELD"}, cmp.Clusters...), "STATUS")
+	var rows [][]string
+	expected := 0
+	for _, f := range cmp.Fields {
+		if f.Status == deploylib.FieldExpected {
+			expected++
+		}
+		if f.Status == deploylib.FieldSame && !all {
+			continue
+		}
+		row := []string{f.Field}
+		for _, v := range f.Values {
+			row = append(row, compareCell(v))
+		}
+		rows = append(rows, append(row, compareStatusCell(f.Status)))
+	}
+	if len(rows) == 0 {
+		fmt.Println(tui.DimStyle.Render("  No differences"))
+		return
+	}
+	fmt.Println(tui.Table(headers, rows))
+
+	for _, cluster := range cmp.Clusters[1:] {
+		if hunks := cmp.Diffs[cluster]; len(hunks) > 0 {
+			fmt.Printf("\n  %s\n", tui.WarningStyle.Render("unexpected differences, "+cmp.Clusters[0]+" → "+cluster))
+			printHunks(hunks)
+		}
+	}
+
+	summary := fmt.Sprintf("%d unexpected, %d expected difference(s)", cmp.Unexpected, expected)
+	if cmp.Unexpected > 0 {
+		fmt.Printf("\n%s\n", tui.WarningStyle.Render(summary))
+	} else {
+		fmt.Printf("\n%s\n", tui.SuccessStyle.Render(summary))
+	}
+}
+
+func compareCell(v *string) string {
+	if v == nil {
+		return tui.DimStyle.Render("<unset>")
+	}
+	s := strings.ReplaceAll(*v, "\n", `\n`)
+	if utf8.RuneCountInString(s) > compareValueWidth {
+		return string([]rune(s)[:compareValueWidth-1]) + "…"
+	}
+	return s
+}
+
+func compareStatusCell(status string) string {
+	switch status {
+	case deploylib.FieldDifferent:
+		return tui.WarningStyle.Render(status)
+	case deploylib.FieldExpected:
+		return tui.DimStyle.Render(status)
+	}
+	return tui.SuccessStyle.Render(status)
+}
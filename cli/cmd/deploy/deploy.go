@@ -2,24 +2,44 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/git"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/metrics"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/onepassword"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
-	"github.com/jamesatintegratnio/hctl/internal/score"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/report"
+	"github.com/jamesatintegratnio/hctl/internal/smoke"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
+// concurrency bounds how many Score resources are provisioned at once
+// (--concurrency); zero uses GOMAXPROCS.
+var concurrency int
+
+// noCache makes renders run every provisioner instead of reusing cached
+// results (--no-cache).
+var noCache bool
+
 // NewCmd returns the deploy command group.
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -34,19 +54,39 @@ ExternalSecrets, HTTPRoutes, Certificates).
 Workflow:
   1. hctl deploy init          — scaffold a score.yaml
   2. hctl deploy run           — translate and deploy to the target vCluster
+     hctl deploy profiles      — list the deploy profiles in .hctl.yaml
   3. hctl deploy render        — preview rendered manifests
   4. hctl deploy diff          — compare rendered vs on-disk
+     hctl deploy compare       — compare a workload across clusters
   5. hctl deploy status        — check deployment status
-  6. hctl deploy remove        — tear down the workload`,
+     hctl deploy history       — list a workload's deploy revisions
+  6. hctl deploy remove        — tear down the workload
+  7. hctl deploy secrets       — trace a workload's 1Password dependencies
+     hctl deploy migrate-secrets — list items environment scoping changes
+
+Workloads that do not fit Score can go through the same flow with
+'hctl deploy chart' (a third-party Helm chart) or 'hctl deploy kustomize'
+(a kustomize directory).`,
 	}
 
+	cmd.PersistentFlags().IntVar(&concurrency, "concurrency", 0, "maximum provisioners to run at once (default: number of CPUs)")
+	cmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "run every provisioner instead of reusing cached results in render, diff and run --dry-run")
+
 	cmd.AddCommand(newDeployInitCmd())
 	cmd.AddCommand(newDeployRunCmd())
+	cmd.AddCommand(newDeployProfilesCmd())
 	cmd.AddCommand(newDeployRenderCmd())
 	cmd.AddCommand(newDeployDiffCmd())
+	cmd.AddCommand(newDeployValidateCmd())
+	cmd.AddCommand(newDeployCompareCmd())
+	cmd.AddCommand(newDeployHistoryCmd())
 	cmd.AddCommand(newDeployStatusCmd())
 	cmd.AddCommand(newDeployRemoveCmd())
 	cmd.AddCommand(newDeployListCmd())
+	cmd.AddCommand(newDeploySecretsCmd())
+	cmd.AddCommand(newDeployMigrateSecretsCmd())
+	cmd.AddCommand(newDeployChartCmd())
+	cmd.AddCommand(newDeployKustomizeCmd())
 
 	return cmd
 }
@@ -73,21 +113,27 @@ Templates:
 				cluster = cfg.DefaultCluster
 			}
 
-			scorePath := "score.yaml"
-			if _, err := os.Stat(scorePath); err == nil {
-				confirmed, _ := tui.Confirm("score.yaml already exists. Overwrite?")
-				if !confirmed {
-					return nil
-				}
-			}
-
 			cwd, _ := os.Getwd()
 			workloadName := filepath.Base(cwd)
 			domain := cfg.Platform.Domain
 
 			scaffold := generateScoreTemplate(template, workloadName, cluster, domain)
 
-			if err := os.WriteFile(scorePath, []byte(scaffold), 0o644); err != nil {
+			mp := mutation.New(cwd)
+			if err := mp.Write(filepath.Join(cwd, "score.yaml"), []byte(scaffold)); err != nil {
+				return err
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if len(mp.Files) > 0 && mp.Files[0].Before != nil {
+				confirmed, _ := tui.Confirm("score.yaml already exists. Overwrite?")
+				if !confirmed {
+					return nil
+				}
+			}
+
+			if err := mp.Execute(); err != nil {
 				return fmt.Errorf("writing score.yaml: %w", err)
 			}
 
@@ -103,6 +149,17 @@ Templates:
 	return cmd
 }
 
+// ScaffoldScore returns the score.yaml scaffold that `hctl deploy init`
+// writes for tmpl, with the container image set to image. An empty image
+// keeps the "." placeholder.
+func ScaffoldScore(tmpl, name, cluster, domain, image string) string {
+	scaffold := generateScoreTemplate(tmpl, name, cluster, domain)
+	if image == "" {
+		return scaffold
+	}
+	return strings.ReplaceAll(scaffold, `image: "."`, fmt.Sprintf("image: %q", image))
+}
+
 // generateScoreTemplate returns a Score spec scaffold for the given template type.
 func generateScoreTemplate(tmpl, name, cluster, domain string) string {
 	switch tmpl {
@@ -207,6 +264,11 @@ metadata:
   name: %s
   annotations:
     hctl.integratn.tech/cluster: "%s"
+    hctl.integratn.tech/workload-type: cronjob
+    hctl.integratn.tech/schedule: "0 3 * * *"
+    # hctl.integratn.tech/concurrency-policy: Forbid
+    # hctl.integratn.tech/restart-policy: OnFailure
+    # hctl.integratn.tech/backoff-limit: "6"
 
 containers:
   job:
@@ -285,10 +347,26 @@ resources:
 func newDeployRunCmd() *cobra.Command {
 	var (
 		cluster      string
-		dryRun       bool
 		scoreFile    string
 		watchDeploy  bool
 		watchTimeout time.Duration
+
+		skipSecretCheck bool
+		waitForSecret   bool
+
+		overwriteManual bool
+		showMetrics     bool
+		reports         []string
+		allowEnv        bool
+
+		smokeTest bool
+		resolve   string
+
+		profileName string
+		namespace   string
+		sets        []string
+		pinDigests  bool
+		noValidate  bool
 	)
 	cmd := &cobra.Command{
 		Use:   "run",
@@ -301,76 +379,315 @@ Generates:
   - HTTPRoutes + TLS Certificates for ingress
   - PVCs for persistent volumes (NFS via democratic-csi)
 
-Files are written to workloads/<cluster>/addons/<workload>/ in the gitops repo.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+Files are written to workloads/<cluster>/addons/<workload>/ in the gitops repo.
+
+A score.yaml may hold several workloads as YAML documents separated by
+"---", such as an app and its worker. Each is checked and translated on its
+own and gets its own values.yaml and addons.yaml entry; all of them are
+written in one commit. Two documents with the same metadata.name are an
+error. --cluster, or a profile's cluster, then only targets the documents
+without a hctl.integratn.tech/cluster annotation.
+
+Before translating, the workload is checked against the platform's
+constraints: route hosts must be under the platform domain, resource
+requests, limits and volume sizes must be valid quantities,
+${resources.<name>.<output>} references must name a declared resource, every
+container needs an image ("." only with the hctl.integratn.tech/build
+annotation), and a pod may not request more than its vCluster's quota.
+Errors stop the deploy and warnings are printed; --no-validate skips the
+check.
+
+Before anything is written, the 1Password items and fields referenced by the
+generated ExternalSecrets are checked via 1Password Connect. Use
+--wait-for-secret to block until in-flight items appear, or
+--skip-secret-check to bypass the check.
+
+Generated files carry a provenance hash. If a generated file was edited by hand
+since hctl last wrote it, the run stops and shows the edits; pass
+--overwrite-manual-changes to discard them.
+
+With registry.pinDigests set in config, or the workload annotation
+hctl.integratn.tech/pin-digest: "true", image tags are resolved to their
+current digest (the manifest list digest for multi-arch images) and the values
+reference repository@sha256:... instead. The tagged image is kept in a
+hctl.integratn.tech/image-tag.<container> Deployment annotation. Registry
+credentials come from the docker config, then registry.pullSecret.
+
+String values in score.yaml may use ${env.NAME} placeholders, e.g. an image
+tag or git SHA set by CI. They are substituted from the environment for the
+variables listed in x-hctl.env-vars, or for any variable with --allow-env;
+unset variables fail the load, all listed together. The cluster and
+namespace annotations only take declared variables. The commit records the
+variable names in an Injected-Env trailer, never their values.
+
+Every generated object is labelled app.kubernetes.io/managed-by: hctl,
+hctl.integratn.tech/workload and hctl.integratn.tech/cluster (labels an object
+already sets are kept), and annotated with the app repo's origin URL
+(hctl.integratn.tech/source-repo). With git mode auto or generate, a
+follow-up commit records the deploy commit in an hctl.integratn.tech/commit
+annotation; it is kept until the workload changes again.
+
+--dry-run translates the way 'hctl deploy render' does, without provisioner
+side effects or the secret, image and manual-edit checks, and prints the
+files, commits and commit stamp the deploy would make. It exits 10 when there
+are changes; 'hctl deploy render' shows the generated content itself.
+
+--metrics prints a one-line timing and size summary at the end (a "metrics"
+document with -o json/yaml), for tracking deploys over time in CI logs.
+
+--report junit=<path> or --report json=<path> (repeatable) records the deploy
+stages as test cases for CI: translate, write and commit, then with --watch
+app created, synced, secrets ready, cert ready, pods ready and route
+programmed. Each case has its duration; a failed or timed-out stage carries
+the condition messages and pod events seen last. Reports are written even
+when the deploy fails.
+
+--smoke-test runs the workload's x-hctl.smokeTests once ArgoCD reports it
+Synced/Healthy (pods Ready, route programmed): an HTTPS GET of each path on
+the route's host, retried with backoff until it answers the expected status
+and body or its timeout ends. It implies --watch, and is on by default with
+--watch when smoke tests are declared (--smoke-test=false skips them).
+--resolve <ip>[:port] connects to that address, e.g. the gateway VIP,
+instead of resolving the host, for routes whose DNS record is not out yet.
+Failed smoke tests exit 9 and leave the deployment in place.
+
+--profile <name> applies a deploy profile from .hctl.yaml at the app repo
+root (see 'hctl deploy profiles'): its cluster, namespace, set overrides,
+watch, timeout, smoke-test and pin-digests settings stand in for the flags.
+Flags given on the command line win. The target cluster is the first of
+--cluster, the profile's cluster, the workload's cluster annotation and
+defaultCluster; the namespace the first of --namespace, the profile's
+namespace, the namespace annotation and the cluster name.
+
+--set <path>=<value> (repeatable) overrides a field of the generated chart
+values, e.g. --set deployment.replicas=2, after a profile's overrides.
+Values are read as YAML scalars.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			targets, err := report.ParseTargets(reports)
+			if err != nil {
+				return err
+			}
+			target := deploylib.Target{Cluster: cluster, Namespace: namespace, Set: sets}
+			explicitSmoke := cmd.Flags().Changed("smoke-test")
+			var pin *bool
+			if cmd.Flags().Changed("pin-digests") {
+				pin = &pinDigests
+			}
+			if profileName != "" {
+				profiles, err := deploylib.LoadProfiles(filepath.Dir(scoreFile))
+				if err != nil {
+					return err
+				}
+				profile, err := profiles.Get(profileName)
+				if err != nil {
+					return err
+				}
+				target = profile.Target(target)
+				if profile.Watch != nil && !cmd.Flags().Changed("watch") {
+					watchDeploy = *profile.Watch
+				}
+				if profile.Timeout > 0 && !cmd.Flags().Changed("timeout") {
+					watchTimeout = profile.Timeout
+				}
+				if profile.SmokeTest != nil && !explicitSmoke {
+					smokeTest, explicitSmoke = *profile.SmokeTest, true
+				}
+				if pin == nil {
+					pin = profile.PinDigests
+				}
+			}
+			var runner *smoke.Runner
+			if resolve != "" {
+				if explicitSmoke && !smokeTest {
+					return hcerrors.NewUserError("--resolve only applies to smoke tests and cannot be combined with --smoke-test=false")
+				}
+				if runner, err = smoke.NewRunner(resolve); err != nil {
+					return hcerrors.NewUserError("--resolve: %v", err)
+				}
+			}
 			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			dryRun := cfg.DryRun
+			if dryRun && len(targets) > 0 {
+				return hcerrors.NewUserError("--report records a deploy and cannot be combined with --dry-run")
 			}
+			timer := metrics.NewTimer(nil)
 
-			// Phase 1: Parse and translate (spinner)
-			var workload *score.Workload
-			var result *deploylib.TranslateResult
+			var rec *report.Recorder
+			if len(targets) > 0 {
+				plan := deploylib.DeployStages
+				if !watchDeploy && !smokeTest {
+					plan = plan[:len(plan)-len(deploylib.WatchStages)]
+				}
+				rec = report.NewRecorder(nil, plan...)
+				defer func() {
+					if werr := report.Write(rec, err, targets); werr != nil && err == nil {
+						err = werr
+					}
+				}()
+			}
 
-			results, err := tui.RunSteps("Preparing deployment", []tui.Step{
-				{
-					Title: "Parsing " + scoreFile,
-					Run: func() (string, error) {
-						w, err := score.LoadWorkload(scoreFile)
-						if err != nil {
-							return "", fmt.Errorf("loading score workload: %w", err)
-						}
-						workload = w
-						return workload.Metadata.Name, nil
-					},
-				},
-				{
-					Title: "Translating to platform resources",
-					Run: func() (string, error) {
-						r, err := deploylib.Translate(workload, cluster)
-						if err != nil {
-							return "", fmt.Errorf("translating workload: %w", err)
-						}
-						result = r
-						resources := []string{}
-						for name, res := range workload.Resources {
-							resources = append(resources, fmt.Sprintf("%s(%s)", name, res.Type))
-						}
-						detail := fmt.Sprintf("cluster=%s ns=%s", r.TargetCluster, r.Namespace)
-						if len(resources) > 0 {
-							detail += " resources=" + strings.Join(resources, ",")
+			// Phase 1: Parse and translate (spinner). Each workload of the
+			// file is checked and translated on its own; all of them are
+			// written in one commit.
+			endParse := timer.Phase(metrics.PhaseParse)
+			workloads, err := score.LoadWorkloadsEnv(scoreFile, score.EnvOptions{AllowAll: allowEnv})
+			endParse()
+			if err != nil {
+				return fmt.Errorf("loading score workload: %w", err)
+			}
+			rec.SetWorkload(workloadNames(workloads), workloads[0].TargetCluster())
+
+			results := make([]*deploylib.TranslateResult, len(workloads))
+			constraints := make([][]score.Problem, len(workloads))
+			var missingSecrets []onepassword.Missing
+			var manualEdits []*deploylib.FileDrift
+
+			var prepareSteps []tui.Step
+			for i, workload := range workloads {
+				target := target.For(workload, len(workloads))
+				get := func() *score.Workload { return workload }
+				var digests map[string]string
+				if !noValidate {
+					prepareSteps = append(prepareSteps, stepFor(workloads, i, constraintsStep(get, target.Cluster, &constraints[i])))
+				}
+				prepareSteps = append(prepareSteps,
+					stepFor(workloads, i, digestStep(cfg, pin, get, &digests)),
+					stepFor(workloads, i, recordStep(rec, deploylib.StageTranslate, tui.Step{
+						Title: "Translating to platform resources",
+						Run: func() (string, error) {
+							mode := provisioners.ModeDeploy
+							if dryRun {
+								mode = provisioners.ModeRender
+							}
+							r, err := deploylib.Translate(workload, scoreFile, target, deploylib.RunOptions{Concurrency: concurrency, Mode: mode, Cache: !noCache, Digests: digests, Timer: timer})
+							if err != nil {
+								return "", fmt.Errorf("translating workload: %w", err)
+							}
+							results[i] = r
+							rec.SetWorkload(workloadNames(workloads), resultClusters(results))
+							resources := []string{}
+							for name, res := range workload.Resources {
+								resources = append(resources, fmt.Sprintf("%s(%s)", name, res.Type))
+							}
+							detail := fmt.Sprintf("cluster=%s ns=%s", r.TargetCluster, r.Namespace)
+							if len(resources) > 0 {
+								detail += " resources=" + strings.Join(resources, ",")
+							}
+							return detail, nil
+						},
+					})),
+				)
+			}
+			if !skipSecretCheck && !dryRun {
+				prepareSteps = append(prepareSteps, secretCheckStep(cfg,
+					func() []provisioners.SecretRequirement {
+						var reqs []provisioners.SecretRequirement
+						for _, r := range results {
+							reqs = append(reqs, r.SecretRequirements...)
 						}
-						return detail, nil
+						return reqs
 					},
-				},
-			})
+					waitForSecret, watchTimeout, &missingSecrets))
+			}
+			if !dryRun {
+				for i := range results {
+					get := func() *deploylib.TranslateResult { return results[i] }
+					prepareSteps = append(prepareSteps,
+						stepFor(workloads, i, imageRequirementStep(cfg, get)),
+						stepFor(workloads, i, manualEditStep(get, cfg.RepoPath, overwriteManual, &manualEdits)))
+				}
+			}
+
+			stepResults, err := tui.RunSteps("Preparing deployment", prepareSteps)
 			if err != nil {
+				printTranslateError(err)
+				printMissingSecrets(missingSecrets)
+				printManualEdits(manualEdits)
 				return err
 			}
-			for _, r := range results {
+			for _, r := range stepResults {
 				if r.Err != nil {
+					printTranslateError(r.Err)
 					return r.Err
 				}
 			}
 
-			// Show what will be generated
-			fmt.Printf("\n  Files to write:\n")
-			for path := range result.Files {
-				fmt.Printf("    %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), path)
+			smokeTests := 0
+			for i, result := range results {
+				if len(results) > 1 {
+					fmt.Printf("\n%s\n", tui.TitleStyle.Render(result.WorkloadName))
+				}
+				printProblems(constraints[i])
+				printDiagnostics(result.Diagnostics)
+				printResourceExemption(result.ResourceExemption)
+				printInjectedEnv(result.InjectedEnv)
+				smokeTests += len(result.SmokeTests)
+			}
+
+			if !explicitSmoke {
+				smokeTest = watchDeploy && smokeTests > 0
+			} else if smokeTest && smokeTests == 0 {
+				return hcerrors.NewUserError("--smoke-test: %s declares no x-hctl.smokeTests", scoreFile)
+			}
+			if smokeTest && runner == nil {
+				if runner, err = smoke.NewRunner(""); err != nil {
+					return err
+				}
+			}
+
+			guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+			if err != nil {
+				return err
+			}
+			for _, result := range results {
+				if err := guard.Cluster("deploying to cluster", result.TargetCluster); err != nil {
+					return err
+				}
+				if err := guard.Namespace(result.Namespace); err != nil {
+					return err
+				}
 			}
-			fmt.Printf("    %s workloads/%s/addons.yaml\n", tui.SuccessStyle.Render(tui.IconBullet), result.TargetCluster)
 
-			// Dry-run mode — show generated values and exit
+			// Dry-run mode — show the planned changes and exit
 			if dryRun {
-				fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Generated values.yaml:"))
-				out, _ := yaml.Marshal(result.StakaterValues)
-				fmt.Println(string(out))
-				return nil
+				mp, err := planResults(cfg, results, "deploy", guard.Override(), false, cfg.GitMode)
+				if err != nil {
+					return err
+				}
+				dryErr := mp.DryRun()
+				if showMetrics {
+					if err := reportMetrics(timer.Summary(metricsCounts(workloads, results, nil))); err != nil {
+						return err
+					}
+				}
+				return dryErr
+			}
+
+			// Show what will be generated
+			fmt.Printf("\n  Files to write:\n")
+			addonsPaths := []string{}
+			for _, result := range results {
+				for path := range result.Files {
+					fmt.Printf("    %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), path)
+				}
+				if addons := deploylib.AddonsPath(result.TargetCluster); !slices.Contains(addonsPaths, addons) {
+					addonsPaths = append(addonsPaths, addons)
+				}
+			}
+			for _, addons := range addonsPaths {
+				fmt.Printf("    %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), addons)
 			}
 
 			// Confirm
 			if cfg.Interactive {
-				ok, _ := tui.Confirm("\nDeploy this workload?")
+				question := "\nDeploy this workload?"
+				if len(results) > 1 {
+					question = fmt.Sprintf("\nDeploy these %d workloads?", len(results))
+				}
+				ok, _ := tui.Confirm(question)
 				if !ok {
 					fmt.Println(tui.DimStyle.Render("Cancelled"))
 					return nil
@@ -378,113 +695,149 @@ Files are written to workloads/<cluster>/addons/<workload>/ in the gitops repo.`
 			}
 
 			// Phase 2: Write and commit (spinner)
-			var writtenPaths []string
-			deploySteps := []tui.Step{
-				{
-					Title: "Writing files",
-					Run: func() (string, error) {
-						wp, err := deploylib.WriteResult(result, cfg.RepoPath)
-						if err != nil {
-							return "", fmt.Errorf("writing files: %w", err)
-						}
-						writtenPaths = wp
-						return fmt.Sprintf("%d files", len(wp)), nil
-					},
-				},
-			}
-
-			// Add git step based on mode
-			gitMode := cfg.GitMode
-			if gitMode == "prompt" && cfg.Interactive {
-				ok, _ := tui.Confirm("Commit and push changes?")
-				if ok {
-					gitMode = "auto"
-				} else {
-					gitMode = "stage-only"
-				}
+			deploySteps, err := writeSteps(cfg, results, "deploy", guard.Override(), false, timer, rec)
+			if err != nil {
+				return err
 			}
-			gitStep := git.HandleGitWorkflowStep(git.WorkflowOpts{
-				RepoPath: cfg.RepoPath,
-				Paths:    writtenPaths,
-				Action:   "deploy",
-				Resource: workload.Metadata.Name,
-				Details:  result.TargetCluster,
-				GitMode:  gitMode,
-			})
-			deploySteps = append(deploySteps, gitStep)
 
-			results, err = tui.RunSteps("Deploying "+workload.Metadata.Name, deploySteps)
+			stepResults, err = tui.RunSteps("Deploying "+workloadNames(workloads), deploySteps)
 			if err != nil {
 				return err
 			}
-			for _, r := range results {
+			for _, r := range stepResults {
 				if r.Err != nil {
 					return fmt.Errorf("deploy failed at %q: %w", r.Title, r.Err)
 				}
 			}
+			if showMetrics {
+				extra := map[string]int{}
+				for _, addons := range addonsPaths {
+					if fi, err := os.Stat(repopath.Abs(cfg.RepoPath, addons)); err == nil {
+						extra[addons] = int(fi.Size())
+					}
+				}
+				if err := reportMetrics(timer.Summary(metricsCounts(workloads, results, extra))); err != nil {
+					return err
+				}
+			}
 
-			if !watchDeploy {
+			if !watchDeploy && !smokeTest {
 				fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will sync the workload automatically."))
-				fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf("Check status: hctl deploy status %s", workload.Metadata.Name)))
+				for _, result := range results {
+					fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf("Check status: hctl deploy status %s", result.WorkloadName)))
+				}
 				return nil
 			}
 
-			// Watch ArgoCD sync progress
-			fmt.Printf("\n%s Watching ArgoCD sync...\n\n", tui.InfoStyle.Render(tui.IconSync))
-			targetCluster := result.TargetCluster
-			client, cErr := kube.NewClient(cfg.KubeContext)
-			if cErr != nil {
-				return fmt.Errorf("connecting to cluster for watch: %w", cErr)
-			}
-
-			deadline := time.Now().Add(watchTimeout)
-			ticker := time.NewTicker(3 * time.Second)
-			defer ticker.Stop()
-
-			for {
-				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				app, aErr := client.GetArgoApp(ctx, "argocd", workload.Metadata.Name)
-				if aErr != nil {
-					app, aErr = client.GetArgoApp(ctx, "argocd", targetCluster+"-"+workload.Metadata.Name)
+			for _, result := range results {
+				if err := watchSync(cfg, result.WorkloadName, result.TargetCluster, watchTimeout, rec); err != nil {
+					return err
 				}
-				cancel()
-
-				if aErr == nil {
-					syncStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
-					healthStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "health", "status")
-					phase := fmt.Sprintf("%s/%s", syncStatus, healthStatus)
-
-					if syncStatus == "Synced" && healthStatus == "Healthy" {
-						fmt.Printf("  %s %s\n", tui.SuccessStyle.Render(tui.IconCheck), phase)
-						fmt.Printf("\n%s\n", tui.SuccessStyle.Render("Deployment healthy!"))
-						return nil
-					}
-					fmt.Printf("  %s %s\n", tui.WarningStyle.Render(tui.IconDot), phase)
-				} else {
-					fmt.Printf("  %s waiting for ArgoCD app...\n", tui.DimStyle.Render(tui.IconDot))
+			}
+			if !smokeTest {
+				return nil
+			}
+			for _, result := range results {
+				if len(result.SmokeTests) == 0 {
+					continue
 				}
-
-				if time.Now().After(deadline) {
-					return fmt.Errorf("timeout waiting for sync after %s", watchTimeout)
+				if err := runSmokeTests(runner, result.WorkloadName, result.TargetCluster, result.SmokeTests); err != nil {
+					return err
 				}
-
-				<-ticker.C
 			}
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation)")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show generated resources without writing")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation, except in documents of a multi-workload score.yaml)")
 	cmd.Flags().StringVarP(&scoreFile, "file", "f", "score.yaml", "path to score.yaml")
 	cmd.Flags().BoolVarP(&watchDeploy, "watch", "w", false, "watch ArgoCD sync after deploy")
-	cmd.Flags().DurationVar(&watchTimeout, "timeout", 5*time.Minute, "timeout for --watch")
+	cmd.Flags().DurationVar(&watchTimeout, "timeout", 5*time.Minute, "timeout for --watch and --wait-for-secret")
+	cmd.Flags().BoolVar(&skipSecretCheck, "skip-secret-check", false, "skip the 1Password item pre-flight check")
+	cmd.Flags().BoolVar(&waitForSecret, "wait-for-secret", false, "poll until referenced 1Password items and fields exist (bounded by --timeout)")
+	cmd.Flags().BoolVar(&overwriteManual, "overwrite-manual-changes", false, "regenerate files even if they were edited by hand since hctl last wrote them")
+	cmd.Flags().BoolVar(&showMetrics, "metrics", false, "print timing and size metrics for the deploy")
+	cmd.Flags().StringArrayVar(&reports, "report", nil, "write a stage report, as junit=<path> or json=<path> (repeatable)")
+	cmd.Flags().BoolVar(&allowEnv, "allow-env", false, allowEnvUsage)
+	cmd.Flags().BoolVar(&smokeTest, "smoke-test", false, "run x-hctl.smokeTests against the route once the workload is healthy (implies --watch; default on with --watch when declared)")
+	cmd.Flags().StringVar(&resolve, "resolve", "", "connect smoke tests to this IP or IP:port, e.g. the gateway VIP, instead of resolving the route host")
+	cmd.Flags().StringVar(&profileName, "profile", "", "apply a deploy profile from .hctl.yaml at the app repo root; explicit flags win")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "deployment namespace (overrides the profile and score.yaml annotation)")
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "override a generated chart value, as path=value (repeatable)")
+	cmd.Flags().BoolVar(&noValidate, "no-validate", false, "skip the platform constraint checks before translating")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "pin images to their digests (overrides the profile, score.yaml annotation and registry.pinDigests)")
 	return cmd
 }
 
+// gitOperation names what a git mode does, for the metrics git timings.
+func gitOperation(mode string) string {
+	switch mode {
+	case "auto":
+		return "commit+push"
+	case "generate":
+		return "commit"
+	}
+	return "stage"
+}
+
+// stepFor names workloads[i] in step's title when the score.yaml holds
+// several workloads.
+func stepFor(workloads []*score.Workload, i int, step tui.Step) tui.Step {
+	if len(workloads) > 1 {
+		step.Title += " (" + workloads[i].Metadata.Name + ")"
+	}
+	return step
+}
+
+// workloadNames joins the names of workloads with commas.
+func workloadNames(workloads []*score.Workload) string {
+	names := make([]string, len(workloads))
+	for i, w := range workloads {
+		names[i] = w.Metadata.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// resultClusters joins the distinct target clusters of the translated
+// results with commas.
+func resultClusters(results []*deploylib.TranslateResult) string {
+	var clusters []string
+	for _, r := range results {
+		if r != nil && !slices.Contains(clusters, r.TargetCluster) {
+			clusters = append(clusters, r.TargetCluster)
+		}
+	}
+	return strings.Join(clusters, ", ")
+}
+
+// metricsCounts sizes the results of the workloads of one score.yaml for
+// metrics; extra adds files written alongside them, such as addons.yaml.
+func metricsCounts(workloads []*score.Workload, results []*deploylib.TranslateResult, extra map[string]int) metrics.Counts {
+	c := metrics.Counts{Files: extra}
+	for i, result := range results {
+		c.Add(deploylib.MetricsCounts(workloads[i], result, nil))
+	}
+	return c
+}
+
+// reportMetrics prints a metrics summary: one line on stderr in text mode,
+// so rendered output stays pipeable, or a {"metrics": ...} document with
+// -o json/yaml.
+func reportMetrics(s metrics.Summary) error {
+	if tui.IsStructured() {
+		return tui.RenderOutput(map[string]interface{}{"metrics": s}, "")
+	}
+	fmt.Fprintln(os.Stderr, tui.DimStyle.Render(s.String()))
+	return nil
+}
+
 func newDeployRenderCmd() *cobra.Command {
 	var (
-		cluster   string
-		scoreFile string
+		cluster     string
+		scoreFile   string
+		showMetrics bool
+		allowEnv    bool
+		noValidate  bool
 	)
 	cmd := &cobra.Command{
 		Use:   "render",
@@ -493,59 +846,138 @@ func newDeployRenderCmd() *cobra.Command {
 No files are written and no git operations are performed.
 
 Useful for reviewing what will be generated before running 'hctl deploy run'.
-Supports --output json/yaml for machine-readable output.`,
+Supports --output json/yaml for machine-readable output, which includes a
+"metrics" block with parse, provisioner and translate timings and the size of
+the generated files. In text mode, --metrics prints a one-line summary to
+stderr.
+
+Provisioner results are cached between renders, keyed by each resource's
+spec; --no-cache runs every provisioner.
+
+The workload is first checked against the platform constraints 'hctl deploy
+run' checks; errors stop the render, and --no-validate skips the check.
+
+A score.yaml holding several workloads, separated by "---", renders each in
+turn; structured output then lists them under "workloads". As with 'hctl
+deploy run', --cluster only targets the documents without a cluster
+annotation.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			workload, err := score.LoadWorkload(scoreFile)
+			timer := metrics.NewTimer(nil)
+			endParse := timer.Phase(metrics.PhaseParse)
+			workloads, err := score.LoadWorkloadsEnv(scoreFile, score.EnvOptions{AllowAll: allowEnv})
+			endParse()
 			if err != nil {
 				return fmt.Errorf("loading score workload: %w", err)
 			}
 
-			result, err := deploylib.Translate(workload, cluster)
-			if err != nil {
-				return fmt.Errorf("translating workload: %w", err)
+			results := make([]*deploylib.TranslateResult, len(workloads))
+			constraints := make([][]score.Problem, len(workloads))
+			digests := make([]map[string]string, len(workloads))
+			for i, workload := range workloads {
+				target := deploylib.Target{Cluster: cluster}.For(workload, len(workloads))
+				if !noValidate {
+					constraints[i] = deploylib.Validate(workload, target.Cluster)
+					if err := deploylib.CheckConstraints(constraints[i]); err != nil {
+						printTranslateError(err)
+						return err
+					}
+				}
+
+				if digests[i], err = imageDigests(config.Get(), workload, nil); err != nil {
+					return err
+				}
+
+				if results[i], err = deploylib.Translate(workload, scoreFile, target, deploylib.RunOptions{Concurrency: concurrency, Mode: provisioners.ModeRender, Cache: !noCache, Digests: digests[i], Timer: timer}); err != nil {
+					printTranslateError(err)
+					return fmt.Errorf("translating workload: %w", err)
+				}
 			}
 
-			// Structured output: emit the full translation result
+			// Structured output: emit the full translation result, one
+			// document per workload under "workloads" when there are several
 			if tui.IsStructured() {
-				renderData := map[string]interface{}{
-					"workload":       result.WorkloadName,
-					"cluster":        result.TargetCluster,
-					"namespace":      result.Namespace,
-					"stakaterValues": result.StakaterValues,
-					"addonsEntry":    result.AddonsEntry,
-					"files":          map[string]string{},
-				}
-				filesMap := renderData["files"].(map[string]string)
-				for path, data := range result.Files {
-					filesMap[path] = string(data)
+				docs := make([]map[string]interface{}, len(results))
+				for i, result := range results {
+					docs[i] = renderDocument(result, constraints[i], digests[i])
 				}
-				return tui.RenderOutput(renderData, "")
+				summary := timer.Summary(metricsCounts(workloads, results, nil))
+				if len(docs) == 1 {
+					docs[0]["metrics"] = summary
+					return tui.RenderOutput(docs[0], "")
+				}
+				return tui.RenderOutput(map[string]interface{}{"workloads": docs, "metrics": summary}, "")
 			}
 
-			// Text output: print each file with a header
-			for path, data := range result.Files {
-				fmt.Printf("%s\n", tui.TitleStyle.Render("# "+path))
-				fmt.Println(string(data))
-			}
+			for i, result := range results {
+				// Text output: print each file with a header
+				for path, data := range result.Files {
+					fmt.Printf("%s\n", tui.TitleStyle.Render("# "+path))
+					fmt.Println(string(data))
+				}
+
+				// Show addons.yaml entry
+				fmt.Printf("%s\n", tui.TitleStyle.Render("# addons.yaml entry"))
+				entry, _ := yaml.Marshal(map[string]interface{}{result.WorkloadName: result.AddonsEntry})
+				fmt.Println(string(entry))
 
-			// Show addons.yaml entry
-			fmt.Printf("%s\n", tui.TitleStyle.Render("# addons.yaml entry"))
-			entry, _ := yaml.Marshal(map[string]interface{}{result.WorkloadName: result.AddonsEntry})
-			fmt.Println(string(entry))
+				printProblems(constraints[i])
+				printDiagnostics(result.Diagnostics)
+				printResourceExemption(result.ResourceExemption)
+				printInjectedEnv(result.InjectedEnv)
+			}
 
+			if showMetrics {
+				return reportMetrics(timer.Summary(metricsCounts(workloads, results, nil)))
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation)")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation, except in documents of a multi-workload score.yaml)")
 	cmd.Flags().StringVarP(&scoreFile, "file", "f", "score.yaml", "path to score.yaml")
+	cmd.Flags().BoolVar(&showMetrics, "metrics", false, "print a timing and size summary to stderr")
+	cmd.Flags().BoolVar(&allowEnv, "allow-env", false, allowEnvUsage)
+	cmd.Flags().BoolVar(&noValidate, "no-validate", false, "skip the platform constraint checks before translating")
 	return cmd
 }
 
+// renderDocument is the structured output of 'hctl deploy render' for one
+// workload.
+func renderDocument(result *deploylib.TranslateResult, constraints []score.Problem, digests map[string]string) map[string]interface{} {
+	doc := map[string]interface{}{
+		"workload":       result.WorkloadName,
+		"cluster":        result.TargetCluster,
+		"namespace":      result.Namespace,
+		"stakaterValues": result.Values,
+		"addonsEntry":    result.AddonsEntry,
+		"diagnostics":    result.Diagnostics,
+		"requirements":   result.Requirements,
+	}
+	if digests != nil {
+		doc["imageDigests"] = digests
+	}
+	if result.ResourceExemption != nil {
+		doc["resourceExemption"] = result.ResourceExemption
+	}
+	if len(result.InjectedEnv) > 0 {
+		doc["injectedEnv"] = result.InjectedEnv
+	}
+	if len(constraints) > 0 {
+		doc["constraints"] = constraints
+	}
+	files := map[string]string{}
+	for path, data := range result.Files {
+		files[path] = string(data)
+	}
+	doc["files"] = files
+	return doc
+}
+
 func newDeployDiffCmd() *cobra.Command {
 	var (
 		cluster   string
 		scoreFile string
+		allowEnv  bool
 	)
 	cmd := &cobra.Command{
 		Use:   "diff",
@@ -553,135 +985,253 @@ func newDeployDiffCmd() *cobra.Command {
 		Long: `Translates score.yaml and compares the output against what is currently
 on disk in the gitops repo. Shows a unified diff for each changed file.
 
-Exit codes: 0 = no changes, 1 = error, 2 = changes detected.`,
+When images are pinned to digests, they are resolved again, so a tag that now
+points at a new image shows up as a change.
+
+Objects listed in the workload's manifest inventory (.hctl-manifest.yaml) that
+the new render no longer generates are listed as removed; ArgoCD prunes them
+on the next sync.
+
+With --quiet nothing is printed and only the exit code tells whether the
+render changes anything, e.g. in a pre-commit hook.
+
+A score.yaml holding several workloads, separated by "---", compares each
+in turn; --cluster only targets the documents without a cluster annotation.
+
+Exit codes: 0 = no changes, 10 = changes detected. Errors exit with the code
+of their category, never 10; see 'hctl help exit-codes'.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
 			}
 
-			workload, err := score.LoadWorkload(scoreFile)
+			workloads, err := score.LoadWorkloadsEnv(scoreFile, score.EnvOptions{AllowAll: allowEnv})
 			if err != nil {
 				return fmt.Errorf("loading score workload: %w", err)
 			}
 
-			result, err := deploylib.Translate(workload, cluster)
-			if err != nil {
-				return fmt.Errorf("translating workload: %w", err)
-			}
-
+			// With --quiet only the exit code reports changes.
+			show := !cfg.Quiet
 			hasChanges := false
-
-			// Compare each rendered file against what's on disk
-			for relPath, newData := range result.Files {
-				absPath := filepath.Join(cfg.RepoPath, relPath)
-				existing, readErr := os.ReadFile(absPath)
-				if readErr != nil {
-					// File doesn't exist yet — show as new
-					fmt.Printf("%s %s\n", tui.SuccessStyle.Render("+ new file:"), relPath)
-					fmt.Println(string(newData))
-					hasChanges = true
-					continue
-				}
-
-				if string(existing) == string(newData) {
-					fmt.Printf("%s %s\n", tui.DimStyle.Render("  unchanged:"), relPath)
-					continue
+			for _, workload := range workloads {
+				if show && len(workloads) > 1 {
+					fmt.Printf("%s\n", tui.TitleStyle.Render(workload.Metadata.Name))
 				}
-
-				hasChanges = true
-				fmt.Printf("%s %s\n", tui.WarningStyle.Render("~ modified:"), relPath)
-				printUnifiedDiff(relPath, string(existing), string(newData))
-			}
-
-			// Check addons.yaml for changes
-			addonsPath := filepath.Join(cfg.RepoPath, "workloads", result.TargetCluster, "addons.yaml")
-			if existingAddons, readErr := os.ReadFile(addonsPath); readErr == nil {
-				var existingMap map[string]interface{}
-				if yaml.Unmarshal(existingAddons, &existingMap) == nil {
-					if existing, ok := existingMap[result.WorkloadName]; ok {
-						existingYAML, _ := yaml.Marshal(existing)
-						newYAML, _ := yaml.Marshal(result.AddonsEntry)
-						if string(existingYAML) != string(newYAML) {
-							hasChanges = true
-							relPath := filepath.Join("workloads", result.TargetCluster, "addons.yaml")
-							fmt.Printf("%s %s (entry: %s)\n", tui.WarningStyle.Render("~ modified:"), relPath, result.WorkloadName)
-							printUnifiedDiff(relPath, string(existingYAML), string(newYAML))
-						}
-					} else {
-						hasChanges = true
-						fmt.Printf("%s addons.yaml (new entry: %s)\n", tui.SuccessStyle.Render("+ new:"), result.WorkloadName)
-					}
+				changed, err := diffWorkload(cfg, workload, scoreFile, deploylib.Target{Cluster: cluster}.For(workload, len(workloads)), show)
+				if err != nil {
+					return err
 				}
-			} else {
-				hasChanges = true
-				fmt.Printf("%s addons.yaml (new file)\n", tui.SuccessStyle.Render("+ new:"))
+				hasChanges = hasChanges || changed
 			}
 
 			if !hasChanges {
-				fmt.Println(tui.DimStyle.Render("No changes detected"))
+				if show {
+					fmt.Println(tui.DimStyle.Render("No changes detected"))
+				}
+				return nil
 			}
-
-			return nil
+			return hcerrors.New(hcerrors.ErrChangesPending, "changes detected")
 		},
 	}
 
-	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation)")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation, except in documents of a multi-workload score.yaml)")
 	cmd.Flags().StringVarP(&scoreFile, "file", "f", "score.yaml", "path to score.yaml")
+	cmd.Flags().BoolVar(&allowEnv, "allow-env", false, allowEnvUsage)
 	return cmd
 }
 
-// printUnifiedDiff prints a simple line-by-line diff between two strings.
-func printUnifiedDiff(path, old, new string) {
-	oldLines := strings.Split(old, "\n")
-	newLines := strings.Split(new, "\n")
+// diffWorkload translates workload for target and compares the result
+// with the repo, printing the differences when show is set. It reports
+// whether anything changed.
+func diffWorkload(cfg *config.Config, workload *score.Workload, scoreFile string, target deploylib.Target, show bool) (bool, error) {
+	digests, err := imageDigests(cfg, workload, nil)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := deploylib.Translate(workload, scoreFile, target, deploylib.RunOptions{Concurrency: concurrency, Mode: provisioners.ModeRender, Cache: !noCache, Digests: digests})
+	if err != nil {
+		printTranslateError(err)
+		return false, fmt.Errorf("translating workload: %w", err)
+	}
+
+	hasChanges := false
 
-	// Simple line-by-line comparison (not a true unified diff, but useful)
-	maxLines := len(oldLines)
-	if len(newLines) > maxLines {
-		maxLines = len(newLines)
+	// Pinned digests are part of the rendered values, so a tag that
+	// moved in the registry shows up below as a spec change.
+	if show {
+		printDigests(digests)
 	}
 
-	for i := 0; i < maxLines; i++ {
-		var oldLine, newLine string
-		if i < len(oldLines) {
-			oldLine = oldLines[i]
+	// Compare each rendered file against what's on disk, separating
+	// hand edits (lost on the next run) from score.yaml changes.
+	drifts, err := detectFileDrift(result, cfg.RepoPath)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range drifts {
+		if !d.Exists {
+			hasChanges = true
+			if show {
+				fmt.Printf("%s %s\n", tui.SuccessStyle.Render("+ new file:"), d.Path)
+				fmt.Println(string(result.Files[d.Path]))
+			}
+			continue
 		}
-		if i < len(newLines) {
-			newLine = newLines[i]
+		if !d.Manual && !d.SpecChanged {
+			if show {
+				fmt.Printf("%s %s\n", tui.DimStyle.Render("  unchanged:"), d.Path)
+			}
+			continue
 		}
-		if oldLine != newLine {
-			if i < len(oldLines) {
-				fmt.Printf("  %s\n", tui.ErrorStyle.Render("- "+oldLine))
+
+		hasChanges = true
+		if !show {
+			continue
+		}
+		fmt.Printf("%s %s\n", tui.WarningStyle.Render("~ modified:"), d.Path)
+		if d.Manual {
+			label := "manual edit (will be lost)"
+			if !d.BaseKnown {
+				label += ", mixed with spec changes"
 			}
-			if i < len(newLines) {
-				fmt.Printf("  %s\n", tui.SuccessStyle.Render("+ "+newLine))
+			fmt.Printf("  %s\n", tui.WarningStyle.Render(label))
+			printHunks(d.ManualHunks)
+		}
+		if len(d.SpecHunks) > 0 {
+			fmt.Printf("  %s\n", tui.InfoStyle.Render("spec change"))
+			printHunks(d.SpecHunks)
+		}
+		fmt.Println()
+	}
+
+	// Check addons.yaml for changes
+	addonsRelPath := deploylib.AddonsPath(result.TargetCluster)
+	if existingAddons, readErr := os.ReadFile(repopath.Abs(cfg.RepoPath, addonsRelPath)); readErr == nil {
+		var existingMap map[string]interface{}
+		if yaml.Unmarshal(existingAddons, &existingMap) == nil {
+			if existing, ok := existingMap[result.WorkloadName]; ok {
+				existingYAML, _ := yaml.Marshal(existing)
+				newYAML, _ := yaml.Marshal(result.AddonsEntry)
+				if string(existingYAML) != string(newYAML) {
+					hasChanges = true
+					if show {
+						fmt.Printf("%s %s (entry: %s)\n", tui.WarningStyle.Render("~ modified:"), addonsRelPath, result.WorkloadName)
+						printUnifiedDiff(string(existingYAML), string(newYAML))
+					}
+				}
+			} else {
+				hasChanges = true
+				if show {
+					fmt.Printf("%s addons.yaml (new entry: %s)\n", tui.SuccessStyle.Render("+ new:"), result.WorkloadName)
+				}
 			}
 		}
+	} else {
+		hasChanges = true
+		if show {
+			fmt.Printf("%s addons.yaml (new file)\n", tui.SuccessStyle.Render("+ new:"))
+		}
+	}
+
+	// Objects the committed inventory lists that the new render no
+	// longer generates, which the file diff alone does not name.
+	removed, err := deploylib.RemovedObjects(cfg.RepoPath, result)
+	if err != nil {
+		return false, err
+	}
+	if len(removed) > 0 {
+		hasChanges = true
+		if show {
+			printRemovedObjects(removed)
+		}
 	}
+
+	return hasChanges, nil
+}
+
+// allowEnvUsage describes --allow-env on run, render and diff.
+const allowEnvUsage = "interpolate ${env.NAME} in score.yaml from any environment variable, not only those in x-hctl.env-vars"
+
+// diffContext is how many unchanged lines a diff shows around each change.
+const diffContext = 3
+
+// printUnifiedDiff prints a unified diff between two texts.
+func printUnifiedDiff(old, new string) {
+	printHunks(deploylib.UnifiedDiff(old, new, diffContext))
 	fmt.Println()
 }
 
 func newDeployStatusCmd() *cobra.Command {
-	var cluster string
+	var (
+		cluster string
+		all     bool
+		columns []string
+
+		watchStatus  bool
+		watchTimeout time.Duration
+		reports      []string
+	)
 	cmd := &cobra.Command{
 		Use:   "status [workload]",
 		Short: "Check deployment status of a workload",
 		Long: `Shows the ArgoCD sync and health status of a deployed workload.
 
-If no workload name is given, reads from score.yaml in the current directory.`,
+If no workload name is given, reads from score.yaml in the current directory.
+
+For a workload with an x-hctl.schedule, also shows whether it is currently
+scaled down and when it next scales, read from its values.yaml in the repo.
+
+With --all, shows a table of every workload deployed to the cluster. Use
+-o wide to add namespace, revision, age, and message columns, or --columns
+to pick fields by their JSON path (see -o json).
+
+With --watch, follows the ArgoCD sync until the workload is Synced and
+Healthy or --timeout passes. --report junit=<path> or --report json=<path>
+then records app created, synced, secrets ready, cert ready, pods ready and
+route programmed as test cases, as 'hctl deploy run --watch --report' does.
+
+With -o json or -o yaml, prints the workload's sync, health, revision and
+pods as one document. With --watch, it is printed once the watch ends and
+the watch progress goes to stderr.
+
+Exits 3 when the workload is Degraded, unless --watch is set.`,
 		Args: cobra.MaximumNArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			cfg := config.Get()
 
+			targets, err := report.ParseTargets(reports)
+			if err != nil {
+				return err
+			}
+			if len(targets) > 0 && !watchStatus {
+				return hcerrors.NewUserError("--report requires --watch")
+			}
+			if watchStatus && all {
+				return hcerrors.NewUserError("--watch cannot be combined with --all")
+			}
+
+			if all {
+				if len(args) > 0 {
+					return hcerrors.NewUserError("--all cannot be combined with a workload name")
+				}
+				return runDeployStatusAll(cfg, cluster, columns)
+			}
+			if len(columns) > 0 {
+				return hcerrors.NewUserError("--columns requires --all")
+			}
+
 			// Determine workload name
 			var workloadName string
 			if len(args) > 0 {
 				workloadName = args[0]
 			} else {
 				w, err := score.LoadWorkload("score.yaml")
-				if err != nil {
+				if errors.Is(err, hcerrors.ErrNotFound) {
 					return fmt.Errorf("no workload specified and no score.yaml found: %w", err)
+				} else if err != nil {
+					return err
 				}
 				workloadName = w.Metadata.Name
 				if cluster == "" {
@@ -693,7 +1243,23 @@ If no workload name is given, reads from score.yaml in the current directory.`,
 				cluster = cfg.DefaultCluster
 			}
 			if cluster == "" {
-				return fmt.Errorf("no cluster specified — use --cluster or set defaultCluster")
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+			}
+
+			if watchStatus {
+				var rec *report.Recorder
+				if len(targets) > 0 {
+					rec = report.NewRecorder(nil, deploylib.WatchStages...)
+					rec.SetWorkload(workloadName, cluster)
+					defer func() {
+						if werr := report.Write(rec, err, targets); werr != nil && err == nil {
+							err = werr
+						}
+					}()
+				}
+				if err := watchSync(cfg, workloadName, cluster, watchTimeout, rec); err != nil || !tui.IsStructured() {
+					return err
+				}
 			}
 
 			client, err := kube.NewClient(cfg.KubeContext)
@@ -704,153 +1270,477 @@ If no workload name is given, reads from score.yaml in the current directory.`,
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 
-			// Check ArgoCD app (workload apps are usually in the vCluster's ArgoCD)
-			app, err := client.GetArgoApp(ctx, "argocd", workloadName)
+			st, err := getWorkloadStatus(ctx, client, workloadName, cluster)
 			if err != nil {
-				// Try with cluster prefix
-				app, err = client.GetArgoApp(ctx, "argocd", cluster+"-"+workloadName)
-				if err != nil {
-					return fmt.Errorf("ArgoCD application not found for %q", workloadName)
-				}
+				return err
+			}
+			var degraded error
+			if !watchStatus && st.Health == "Degraded" {
+				degraded = hcerrors.NewPlatformError("workload %s is Degraded on %s", workloadName, cluster).
+					WithRemediation("check its pods with 'hctl deploy logs " + workloadName + "'")
+			}
+			if tui.PrintStructured(st) {
+				return degraded
 			}
-
-			syncStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
-			healthStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "health", "status")
-			revision, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "revision")
 
 			fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(workloadName))
 			fmt.Printf("  Cluster:  %s\n", cluster)
 
-			statusStr := fmt.Sprintf("%s/%s", syncStatus, healthStatus)
-			if syncStatus == "Synced" && healthStatus == "Healthy" {
+			statusStr := fmt.Sprintf("%s/%s", st.Sync, st.Health)
+			if st.Sync == "Synced" && st.Health == "Healthy" {
 				fmt.Printf("  Status:   %s\n", tui.SuccessStyle.Render(statusStr))
 			} else {
 				fmt.Printf("  Status:   %s\n", tui.WarningStyle.Render(statusStr))
 			}
-			if revision != "" {
-				fmt.Printf("  Revision: %s\n", tui.DimStyle.Render(revision))
+			if st.Revision != "" {
+				fmt.Printf("  Revision: %s\n", tui.DimStyle.Render(st.Revision))
+			}
+			if cfg.RepoPath != "" {
+				printScheduleState(cfg.RepoPath, cluster, workloadName, time.Now())
 			}
 
-			// Check pods
-			namespace := cluster
-			pods, err := client.ListPods(ctx, namespace, fmt.Sprintf("app.kubernetes.io/name=%s", workloadName))
-			if err == nil && len(pods) > 0 {
+			if len(st.Pods) > 0 {
 				fmt.Printf("\n  Pods:\n")
-				for _, p := range pods {
+				for _, p := range st.Pods {
 					status := tui.SuccessStyle.Render(p.Phase)
-					if p.Phase != "Running" || p.ReadyContainers < p.TotalContainers {
+					if p.Phase != "Running" || p.Ready < p.Total {
 						status = tui.WarningStyle.Render(p.Phase)
 					}
-					fmt.Printf("    %s  %d/%d  %s\n", p.Name, p.ReadyContainers, p.TotalContainers, status)
+					fmt.Printf("    %s  %d/%d  %s\n", p.Name, p.Ready, p.Total, status)
 				}
 			}
 
 			fmt.Println()
-			return nil
+			return degraded
 		},
 	}
 	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().BoolVar(&all, "all", false, "show status of every workload deployed to the cluster")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "with --all, comma-separated JSON paths to show as a plain table")
+	cmd.Flags().BoolVarP(&watchStatus, "watch", "w", false, "watch the ArgoCD sync until the workload is Synced and Healthy")
+	cmd.Flags().DurationVar(&watchTimeout, "timeout", 5*time.Minute, "timeout for --watch")
+	cmd.Flags().StringArrayVar(&reports, "report", nil, "with --watch, write a stage report, as junit=<path> or json=<path> (repeatable)")
 	return cmd
 }
 
+// printScheduleState prints where the workload's x-hctl.schedule stands,
+// e.g. "scaled down (0 replicas) until Mon 07:00 CET".
+func printScheduleState(repoPath, cluster, workload string, now time.Time) {
+	sched, err := deploylib.WorkloadSchedule(repoPath, cluster, workload)
+	if err != nil {
+		fmt.Printf("  Schedule: %s\n", tui.WarningStyle.Render(err.Error()))
+		return
+	}
+	if sched == nil {
+		return
+	}
+	st := sched.State(now)
+	state := fmt.Sprintf("up (%d replicas)", st.Replicas)
+	verb := "scales down"
+	if st.Down {
+		state = fmt.Sprintf("scaled down (%d replicas)", st.Replicas)
+		verb = "scales up"
+	}
+	if !st.Next.IsZero() {
+		state += fmt.Sprintf(", %s %s", verb, st.Next.Format("Mon 15:04 MST"))
+	}
+	fmt.Printf("  Schedule: %s %s\n", state, tui.DimStyle.Render(sched.String()))
+}
+
+// runDeployStatusAll prints live status for every workload enabled for the
+// cluster in the repo.
+func runDeployStatusAll(cfg *config.Config, cluster string, columns []string) error {
+	if err := cfg.RequireRepoPath(); err != nil {
+		return err
+	}
+	if cluster == "" {
+		cluster = cfg.DefaultCluster
+	}
+	if cluster == "" {
+		return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+	}
+
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return fmt.Errorf("connecting to cluster: %w", err)
+	}
+	items, err := listWorkloadItems(client, cfg.RepoPath, cluster)
+	if err != nil {
+		return err
+	}
+
+	if len(columns) > 0 {
+		return tui.PrintColumns(items, columns)
+	}
+	if tui.PrintStructured(items) {
+		return nil
+	}
+	if len(items) == 0 {
+		fmt.Println(tui.DimStyle.Render("No workloads deployed to " + cluster))
+		return nil
+	}
+
+	headers := []string{"WORKLOAD", "STATUS", "PODS"}
+	if tui.IsWide() {
+		headers = append(headers, "NAMESPACE", "REVISION", "AGE", "MESSAGE")
+	}
+	var rows [][]string
+	for _, item := range items {
+		row := []string{item.Name, workloadStatusCell(item), podsCell(item)}
+		if tui.IsWide() {
+			row = append(row, workloadWideCells(item)...)
+		}
+		rows = append(rows, row)
+	}
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Workloads ("+cluster+")"))
+	fmt.Println(tui.Table(headers, rows))
+	return nil
+}
+
 func newDeployRemoveCmd() *cobra.Command {
-	var cluster string
+	var (
+		cluster         string
+		purge           bool
+		deleteNamespace bool
+	)
 	cmd := &cobra.Command{
 		Use:   "remove [workload]",
 		Short: "Remove a deployed workload",
 		Long: `Removes a workload from the platform by deleting its entry from addons.yaml
-and removing its values directory. ArgoCD will clean up the resources on next sync.`,
-		Args: cobra.ExactArgs(1),
+and removing its values directory. ArgoCD will clean up the resources on next sync.
+
+With --purge, once the removal is committed, every object listed in the
+workload's manifest inventory (.hctl-manifest.yaml) is also deleted from the
+vCluster, including objects ArgoCD would leave behind. The inventory records
+what hctl generated when the workload was deployed, so workloads deployed
+before hctl wrote one cannot be purged.
+
+With --delete-namespace, once the removal is committed, the workload's
+namespace is also deleted from the vCluster, with everything left in it such
+as PVCs and ExternalSecrets. It is refused while other addons.yaml entries
+deploy to the same namespace. In interactive mode it asks first.
+
+If no workload name is given, reads from score.yaml in the current directory.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			workloadName := args[0]
 			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+
+			var workloadName string
+			if len(args) > 0 {
+				workloadName = args[0]
+			} else {
+				w, err := score.LoadWorkload("score.yaml")
+				if errors.Is(err, hcerrors.ErrNotFound) {
+					return fmt.Errorf("no workload specified and no score.yaml found: %w", err)
+				} else if err != nil {
+					return err
+				}
+				workloadName = w.Metadata.Name
+				if cluster == "" {
+					cluster = w.TargetCluster()
+				}
 			}
 
 			if cluster == "" {
 				cluster = cfg.DefaultCluster
 			}
 			if cluster == "" {
-				return fmt.Errorf("no cluster specified — use --cluster or set defaultCluster")
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
 			}
 
-			// Confirm removal
-			if cfg.Interactive {
-				ok, _ := tui.Confirm(fmt.Sprintf("Remove workload %q from cluster %q?", workloadName, cluster))
-				if !ok {
-					fmt.Println(tui.DimStyle.Render("Cancelled"))
-					return nil
-				}
+			guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+			if err != nil {
+				return err
+			}
+			if err := guard.Cluster("removing workloads from cluster", cluster); err != nil {
+				return err
 			}
 
-			removedPaths, err := deploylib.RemoveWorkload(cfg.RepoPath, cluster, workloadName)
+			consumers, err := deploylib.SharedVolumeConsumers(cfg.RepoPath, cluster, workloadName)
 			if err != nil {
 				return err
 			}
+			printSharedVolumeConsumers(workloadName, consumers)
 
-			fmt.Printf("%s Removed workload %s from %s\n",
-				tui.SuccessStyle.Render(tui.IconCheck), workloadName, cluster)
+			inventory, err := deploylib.ReadInventory(cfg.RepoPath, cluster, workloadName)
+			if err != nil {
+				return err
+			}
+			var purgeObjects []translate.InventoryObject
+			if purge {
+				if purgeObjects, err = deploylib.PurgePlan(cfg.RepoPath, cluster, workloadName); err != nil {
+					return err
+				}
+			}
+
+			var namespace string
+			if deleteNamespace {
+				if namespace, err = namespaceToDelete(cfg.RepoPath, cluster, workloadName); err != nil {
+					return err
+				}
+			}
 
-			// Git operations
-			if _, err := git.HandleGitWorkflow(git.WorkflowOpts{
-				RepoPath:      cfg.RepoPath,
-				Paths:         removedPaths,
+			addons, err := deploylib.AddonsWithoutWorkload(cfg.RepoPath, cluster, workloadName)
+			if err != nil {
+				return err
+			}
+			addonsPath := deploylib.AddonsPath(cluster)
+			mp := mutation.New(cfg.RepoPath)
+			if err := mp.Write(repopath.Abs(cfg.RepoPath, addonsPath), addons); err != nil {
+				return err
+			}
+			paths := []string{addonsPath}
+			valuesDir := deploylib.WorkloadDir(cluster, workloadName)
+			if _, err := os.Stat(repopath.Abs(cfg.RepoPath, valuesDir)); err == nil {
+				if err := mp.RemoveAll(repopath.Abs(cfg.RepoPath, valuesDir)); err != nil {
+					return err
+				}
+				paths = append(paths, valuesDir)
+			}
+			mp.Commit(git.WorkflowOpts{
+				Paths:         paths,
 				Action:        "remove",
 				Resource:      workloadName,
 				Details:       cluster,
 				GitMode:       cfg.GitMode,
 				Interactive:   cfg.Interactive,
 				ConfirmPrompt: "Commit and push removal?",
-			}); err != nil {
+
+				PolicyOverride: guard.Override(),
+				Removed:        objectRefs(inventory.Removed(nil)),
+			})
+			if purge {
+				printPurgePlan(purgeObjects)
+				mp.Cluster(fmt.Sprintf("delete the %d object(s) in %s from vCluster %s", len(purgeObjects), translate.InventoryPath(cluster, workloadName), cluster), func() error {
+					return purgeObjectsFrom(cfg, cluster, purgeObjects)
+				})
+			}
+			if deleteNamespace {
+				mp.Cluster(fmt.Sprintf("delete namespace %s from vCluster %s", namespace, cluster), func() error {
+					return deleteNamespaceFrom(cfg, cluster, namespace)
+				})
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+
+			// Confirm removal
+			if cfg.Interactive {
+				ok, _ := tui.Confirm(fmt.Sprintf("Remove workload %q from cluster %q?", workloadName, cluster))
+				if !ok {
+					fmt.Println(tui.DimStyle.Render("Cancelled"))
+					return nil
+				}
+			}
+
+			if err := mp.Apply(); err != nil {
+				return err
+			}
+			fmt.Printf("%s Removed workload %s from %s\n",
+				tui.SuccessStyle.Render(tui.IconCheck), workloadName, cluster)
+			if err := mp.Finish(); err != nil {
 				return err
 			}
 
-			fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will remove the workload on next sync."))
+			if !purge && !deleteNamespace {
+				fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will remove the workload on next sync."))
+			}
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().BoolVar(&purge, "purge", false, "also delete every object in the workload's manifest inventory from the vCluster")
+	cmd.Flags().BoolVar(&deleteNamespace, "delete-namespace", false, "also delete the workload's namespace from the vCluster")
 	return cmd
 }
 
+// protectedNamespaces are never deleted by --delete-namespace.
+var protectedNamespaces = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// namespaceToDelete returns the namespace --delete-namespace deletes along
+// with workload, refusing one other addons.yaml entries deploy to.
+func namespaceToDelete(repoPath, cluster, workload string) (string, error) {
+	namespace, sharers, err := deploylib.NamespaceSharers(repoPath, cluster, workload)
+	if err != nil {
+		return "", err
+	}
+	if protectedNamespaces[namespace] {
+		return "", hcerrors.NewUserError("refusing to delete namespace %q", namespace).
+			WithRemediation("drop --delete-namespace")
+	}
+	if len(sharers) > 0 {
+		return "", hcerrors.NewUserError("namespace %q on %s still holds %s from other addons.yaml entries", namespace, cluster, strings.Join(sharers, ", ")).
+			WithRemediation("remove them first, or drop --delete-namespace to keep the namespace")
+	}
+	return namespace, nil
+}
+
+// vclusterClient connects to the vCluster named cluster through the
+// kubeconfig the platform exports for it.
+func vclusterClient(ctx context.Context, cfg *config.Config, cluster string) (*kube.Client, error) {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to cluster: %w", err)
+	}
+	kubeconfig, secretNames := client.VClusterKubeconfig(ctx, cluster)
+	if kubeconfig == nil {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "kubeconfig secret not found for vCluster %q — tried: %v", cluster, secretNames)
+	}
+	vclient, err := kube.NewClientFromKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("vCluster %s: %w", cluster, err)
+	}
+	return vclient, nil
+}
+
+// deleteNamespaceFrom deletes namespace from the vCluster named cluster,
+// asking first in interactive mode.
+func deleteNamespaceFrom(cfg *config.Config, cluster, namespace string) error {
+	if cfg.Interactive {
+		ok, _ := tui.Confirm(fmt.Sprintf("Delete namespace %q and everything in it from vCluster %q?", namespace, cluster))
+		if !ok {
+			fmt.Printf("  %s\n", tui.DimStyle.Render("Kept namespace "+namespace))
+			return nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	vclient, err := vclusterClient(ctx, cfg, cluster)
+	if err != nil {
+		return err
+	}
+	deleted, err := vclient.DeleteNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		fmt.Printf("  %s\n", tui.DimStyle.Render("namespace "+namespace+" was already gone"))
+		return nil
+	}
+	fmt.Printf("  %s deleted namespace %s\n", tui.SuccessStyle.Render(tui.IconCheck), namespace)
+	return nil
+}
+
+// printPurgePlan lists the objects --purge deletes.
+func printPurgePlan(objects []translate.InventoryObject) {
+	fmt.Printf("\n  Objects to delete:\n")
+	for _, o := range objects {
+		fmt.Printf("    %s %s %s\n", tui.ErrorStyle.Render(tui.IconBullet), o, tui.DimStyle.Render("("+o.Provisioner+")"))
+	}
+	fmt.Println()
+}
+
+// purgeObjectsFrom deletes objects from the vCluster named cluster, through
+// the kubeconfig the platform exports for it.
+func purgeObjectsFrom(cfg *config.Config, cluster string, objects []translate.InventoryObject) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	vclient, err := vclusterClient(ctx, cfg, cluster)
+	if err != nil {
+		return err
+	}
+	deleted, err := deploylib.Purge(ctx, vclient, vclient.ResourceMapper(), objects)
+	for _, o := range deleted {
+		fmt.Printf("  %s deleted %s\n", tui.SuccessStyle.Render(tui.IconCheck), o)
+	}
+	if err != nil {
+		return err
+	}
+	if gone := len(objects) - len(deleted); gone > 0 {
+		fmt.Printf("  %s\n", tui.DimStyle.Render(fmt.Sprintf("%d object(s) were already gone", gone)))
+	}
+	return nil
+}
+
+// printSharedVolumeConsumers warns that removing workload deletes the
+// shared volumes other workloads still mount.
+func printSharedVolumeConsumers(workload string, consumers map[string][]string) {
+	volumes := make([]string, 0, len(consumers))
+	for v := range consumers {
+		volumes = append(volumes, v)
+	}
+	slices.Sort(volumes)
+	for _, v := range volumes {
+		tui.Warn("%s owns shared volume %s, still mounted by %s; ArgoCD deletes the PVC with it unless another workload claims it with params.size",
+			workload, v, strings.Join(consumers[v], ", "))
+	}
+}
+
 func newDeployListCmd() *cobra.Command {
-	var cluster string
+	var (
+		cluster string
+		columns []string
+	)
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List deployed workloads for a cluster",
+		Long: `List the workloads enabled for a cluster in the repo.
+
+Use -o wide to add live status, pods, namespace, revision, age, and message
+columns, or --columns to pick fields by their JSON path (see -o json), e.g.
+--columns name,argocd.syncStatus,pods.ready. Live status is only gathered
+for -o wide, -o json/yaml, and --columns.`,
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.Get()
-			if cfg.RepoPath == "" {
-				return fmt.Errorf("repo path not set — run 'hctl init'")
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
 			}
 
 			if cluster == "" {
 				cluster = cfg.DefaultCluster
 			}
 			if cluster == "" {
-				return fmt.Errorf("no cluster specified — use --cluster or set defaultCluster")
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
 			}
 
-			workloads, err := deploylib.ListWorkloads(cfg.RepoPath, cluster)
+			// Live status is best-effort: the list itself comes from the repo.
+			var client *kube.Client
+			if tui.IsWide() || tui.IsStructured() || len(columns) > 0 {
+				client, _ = kube.NewClient(cfg.KubeContext)
+			}
+			items, err := listWorkloadItems(client, cfg.RepoPath, cluster)
 			if err != nil {
-				return fmt.Errorf("reading workloads: %w", err)
+				return err
 			}
 
-			if len(workloads) == 0 {
+			if len(columns) > 0 {
+				return tui.PrintColumns(items, columns)
+			}
+			if tui.PrintStructured(items) {
+				return nil
+			}
+
+			if len(items) == 0 {
 				fmt.Println(tui.DimStyle.Render("No workloads deployed to " + cluster))
 				return nil
 			}
 
+			headers := []string{"WORKLOAD", "CLUSTER"}
+			if tui.IsWide() {
+				headers = append(headers, "STATUS", "PODS", "NAMESPACE", "REVISION", "AGE", "MESSAGE")
+			}
 			var rows [][]string
-			for _, name := range workloads {
-				rows = append(rows, []string{name, cluster})
+			for _, item := range items {
+				row := []string{item.Name, item.Cluster}
+				if tui.IsWide() {
+					row = append(row, workloadStatusCell(item), podsCell(item))
+					row = append(row, workloadWideCells(item)...)
+				}
+				rows = append(rows, row)
 			}
 
 			_, err = tui.InteractiveTable(tui.InteractiveTableConfig{
 				Title:   "Workloads (" + cluster + ")",
-				Headers: []string{"WORKLOAD", "CLUSTER"},
+				Headers: headers,
 				Rows:    rows,
 				OnSelect: func(row []string, index int) string {
 					if len(row) == 0 {
@@ -906,5 +1796,6 @@ func newDeployListCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "comma-separated JSON paths to show as a plain table (e.g. name,argocd.syncStatus,pods.ready)")
 	return cmd
 }
@@ -1,8 +1,23 @@
 package deploy
 
 import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestGenerateScoreTemplateWeb(t *testing.T) {
@@ -69,6 +84,18 @@ func TestGenerateScoreTemplateCron(t *testing.T) {
 	if !strings.Contains(out, "resources: {}") {
 		t.Error("cron template should have empty resources")
 	}
+
+	w, err := score.LoadWorkload(writeScore(t, ScaffoldScore("cron", "cleanup", "dev", "example.com", "busybox:1.36")))
+	if err != nil {
+		t.Fatalf("scaffold does not parse: %v", err)
+	}
+	result, err := translate.Translate(w, translate.Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if _, ok := result.Values["cronJob"]; !ok {
+		t.Error("cron template should render a cronJob section")
+	}
 }
 
 func TestGenerateScoreTemplateDefaultIsWeb(t *testing.T) {
@@ -79,6 +106,21 @@ func TestGenerateScoreTemplateDefaultIsWeb(t *testing.T) {
 	}
 }
 
+func TestScaffoldScoreSetsImage(t *testing.T) {
+	out := ScaffoldScore("web", "hello", "dev", "example.com", "ghcr.io/acme/hello:1.0")
+	w, err := score.LoadWorkload(writeScore(t, out))
+	if err != nil {
+		t.Fatalf("scaffold does not parse: %v", err)
+	}
+	if got := w.Containers["app"].Image; got != "ghcr.io/acme/hello:1.0" {
+		t.Errorf("image = %q, want ghcr.io/acme/hello:1.0", got)
+	}
+
+	if ScaffoldScore("web", "hello", "dev", "example.com", "") != generateScoreTemplate("web", "hello", "dev", "example.com") {
+		t.Error("empty image should keep the template placeholder")
+	}
+}
+
 func TestAllTemplatesHaveRequiredFields(t *testing.T) {
 	templates := []string{"web", "api", "worker", "cron"}
 	for _, tmpl := range templates {
@@ -96,3 +138,489 @@ func TestAllTemplatesHaveRequiredFields(t *testing.T) {
 		})
 	}
 }
+
+// runDeployCmd executes a deploy subcommand with the given args and config.
+func runDeployCmd(t *testing.T, cfg *config.Config, args ...string) error {
+	t.Helper()
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+
+	cmd := NewCmd()
+	cmd.SetArgs(args)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return cmd.Execute()
+}
+
+func writeScore(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "score.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExitCodeBadScoreFile(t *testing.T) {
+	path := writeScore(t, "apiVersion: score.dev/v1b1\nmetadata:\n  name: bad\n")
+	err := runDeployCmd(t, config.Default(), "render", "-f", path)
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+}
+
+func TestExitCodeMissingScoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "score.yaml")
+	err := runDeployCmd(t, config.Default(), "render", "-f", path)
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitNotFound {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitNotFound, err)
+	}
+}
+
+func TestExitCodeMissingCluster(t *testing.T) {
+	path := writeScore(t, ScaffoldScore("worker", "myapp", "", "example.com", "nginx:1.27"))
+	cfg := config.Default()
+	cfg.DefaultCluster = ""
+	err := runDeployCmd(t, cfg, "render", "-f", path)
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitUserError {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitUserError, err)
+	}
+}
+
+func TestExitCodePushRejected(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := newRejectingRepo(t)
+	addons := filepath.Join(repo, "workloads", "dev", "addons.yaml")
+	if err := os.MkdirAll(filepath.Dir(addons), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(addons, []byte("myapp:\n  enabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repo, "add", "-A")
+	gitRun(t, repo, "commit", "-m", "seed")
+
+	cfg := config.Default()
+	cfg.RepoPath = repo
+	cfg.GitMode = "auto"
+	cfg.Interactive = false
+	err := runDeployCmd(t, cfg, "remove", "myapp", "--cluster", "dev")
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitGit {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitGit, err)
+	}
+}
+
+// newRejectingRepo creates a clone whose origin is read-only: the bare remote
+// has a pre-receive hook that rejects every push (file permissions alone do
+// not stop a root test runner).
+func newRejectingRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	work := filepath.Join(dir, "work")
+	gitRun(t, dir, "init", "--bare", "-q", remote)
+	hook := filepath.Join(remote, "hooks", "pre-receive")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho 'remote is read-only' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "clone", "-q", remote, work)
+	gitRun(t, work, "config", "user.email", "test@example.com")
+	gitRun(t, work, "config", "user.name", "test")
+	return work
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// pipeStdin replaces stdin with a pipe so tui.RunSteps uses its
+// non-interactive fallback instead of opening a TTY.
+func pipeStdin(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = prev
+		r.Close()
+		w.Close()
+	})
+}
+
+func TestRunRefusesManualEdits(t *testing.T) {
+	pipeStdin(t)
+	path := writeScore(t, ScaffoldScore("worker", "myapp", "dev", "example.com", "nginx:1.27"))
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check"); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	values := filepath.Join(cfg.RepoPath, "workloads", "dev", "addons", "myapp", "values.yaml")
+	data, err := os.ReadFile(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(values, append(data, []byte("# tuned by hand\n")...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check")
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitUserError {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitUserError, err)
+	}
+
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check", "--overwrite-manual-changes"); err != nil {
+		t.Fatalf("run with --overwrite-manual-changes: %v", err)
+	}
+	after, err := os.ReadFile(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(data) {
+		t.Error("overwrite did not restore the generated content")
+	}
+}
+
+func TestDeployListUnknownColumn(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "none"))
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "workloads", "media"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "workloads", "media", "addons.yaml"), []byte("sonarr:\n  enabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.RepoPath = repo
+
+	err := runDeployCmd(t, cfg, "list", "--cluster", "media", "--columns", "name,bogus")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("err = %v, want usage error naming the column", err)
+	}
+}
+
+func TestDeployStatusAllRejectsWorkloadName(t *testing.T) {
+	err := runDeployCmd(t, config.Default(), "status", "sonarr", "--all")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("err = %v, want usage error", err)
+	}
+	err = runDeployCmd(t, config.Default(), "status", "sonarr", "--columns", "name")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("--columns without --all: err = %v, want usage error", err)
+	}
+}
+
+func TestDeployReportFlags(t *testing.T) {
+	err := runDeployCmd(t, config.Default(), "status", "sonarr", "--cluster", "media", "--report", "junit=report.xml")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("--report without --watch: err = %v, want usage error", err)
+	}
+	err = runDeployCmd(t, config.Default(), "status", "sonarr", "--watch", "--report", "tap=report.tap")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("unknown report format: err = %v, want usage error", err)
+	}
+}
+
+func TestDeployRunWritesReportOnFailure(t *testing.T) {
+	path := writeScore(t, "apiVersion: score.dev/v1b1\nmetadata:\n  name: bad\n")
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.Interactive = false
+	out := filepath.Join(t.TempDir(), "reports", "deploy.json")
+
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--report", "json="+out); err == nil {
+		t.Fatal("deploy of an invalid workload succeeded")
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	if !strings.Contains(string(data), `"passed": false`) || !strings.Contains(string(data), `"status": "failed"`) {
+		t.Errorf("report should record the failed translate stage:\n%s", data)
+	}
+}
+
+func TestDeployCompareExitCodes(t *testing.T) {
+	cfg := config.Default()
+	cfg.RepoPath = filepath.Join("..", "..", "internal", "deploy", "testdata", "compare")
+
+	err := runDeployCmd(t, cfg, "compare", "web", "--clusters", "vcluster-dev,vcluster-prod")
+	if got := hcerrors.ExitCode(err); got != exitDifferences {
+		t.Errorf("ExitCode = %d, want %d for unexpected differences (err: %v)", got, exitDifferences, err)
+	}
+
+	if err := runDeployCmd(t, cfg, "compare", "web", "--clusters", "vcluster-prod,vcluster-prod"); err != nil {
+		t.Errorf("identical clusters: err = %v, want nil", err)
+	}
+
+	err = runDeployCmd(t, cfg, "compare", "missing", "--clusters", "vcluster-dev,vcluster-prod")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrNotFound {
+		t.Errorf("missing workload: err = %v, want not found", err)
+	}
+
+	err = runDeployCmd(t, cfg, "compare", "web", "--clusters", "vcluster-dev")
+	if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("one cluster: err = %v, want usage error", err)
+	}
+}
+
+func TestDeployValidateExitCodes(t *testing.T) {
+	valid := writeScore(t, ScaffoldScore("worker", "myapp", "dev", "example.com", "nginx:1.27"))
+	if err := runDeployCmd(t, config.Default(), "validate", "-f", valid); err != nil {
+		t.Errorf("valid score.yaml: %v", err)
+	}
+
+	typo := writeScore(t, "apiVersion: score.dev/v1b1\nmetadata:\n  name: bad\ncontainers:\n  main:\n    imgae: nginx\n")
+	err := runDeployCmd(t, config.Default(), "validate", "-f", typo)
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Errorf("typo: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+
+	// deploy run reports the same located problem.
+	err = runDeployCmd(t, config.Default(), "render", "-f", typo)
+	if err == nil || !strings.Contains(err.Error(), `containers.main.imgae: unknown field (did you mean "image"?) at line 6`) {
+		t.Errorf("render: err = %v, want the located unknown field", err)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = prev }()
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-out)
+}
+
+func TestDeployDiffExitCodes(t *testing.T) {
+	pipeStdin(t)
+	path := writeScore(t, ScaffoldScore("worker", "myapp", "dev", "example.com", "nginx:1.27"))
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+
+	var err error
+	out := captureStdout(t, func() { err = runDeployCmd(t, cfg, "diff", "-f", path) })
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitChanges {
+		t.Errorf("new files: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitChanges, err)
+	}
+	if !strings.Contains(out, "+ new file:") {
+		t.Errorf("new files: output does not list them:\n%s", out)
+	}
+
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out = captureStdout(t, func() { err = runDeployCmd(t, cfg, "diff", "-f", path) })
+	if err != nil {
+		t.Errorf("unchanged: err = %v, want nil", err)
+	}
+	if !strings.Contains(out, "No changes detected") {
+		t.Errorf("unchanged: output = %q", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := strings.Replace(string(data), `memory: "512Mi"`, `memory: "1Gi"`, 1)
+	if err := os.WriteFile(path, []byte(modified), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out = captureStdout(t, func() { err = runDeployCmd(t, cfg, "diff", "-f", path) })
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitChanges {
+		t.Errorf("modified: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitChanges, err)
+	}
+	if !strings.Contains(out, "~ modified:") {
+		t.Errorf("modified: output does not show the change:\n%s", out)
+	}
+
+	cfg.Quiet = true
+	out = captureStdout(t, func() { err = runDeployCmd(t, cfg, "diff", "-f", path) })
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitChanges {
+		t.Errorf("--quiet: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitChanges, err)
+	}
+	if out != "" {
+		t.Errorf("--quiet printed:\n%s", out)
+	}
+
+	err = runDeployCmd(t, cfg, "diff", "-f", filepath.Join(t.TempDir(), "missing.yaml"))
+	if got := hcerrors.ExitCode(err); err == nil || got == hcerrors.ExitChanges {
+		t.Errorf("missing score.yaml: ExitCode = %d, want an error code other than %d (err: %v)", got, hcerrors.ExitChanges, err)
+	}
+}
+
+func TestDeployRemoveFromScoreFile(t *testing.T) {
+	pipeStdin(t)
+	path := writeScore(t, ScaffoldScore("worker", "myapp", "dev", "example.com", "nginx:1.27"))
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+	cfg.DefaultCluster = ""
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	t.Chdir(filepath.Dir(path))
+	if err := runDeployCmd(t, cfg, "remove"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.RepoPath, "workloads", "dev", "addons.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "myapp") {
+		t.Errorf("addons.yaml still has the workload:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.RepoPath, "workloads", "dev", "addons", "myapp")); !os.IsNotExist(err) {
+		t.Errorf("values directory not removed: %v", err)
+	}
+}
+
+func TestDeployRemoveDeleteNamespaceRefusesShared(t *testing.T) {
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+	addons := filepath.Join(cfg.RepoPath, "workloads", "dev", "addons.yaml")
+	if err := os.MkdirAll(filepath.Dir(addons), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := "myapp:\n  enabled: true\n  namespace: dev\nother:\n  enabled: true\n  namespace: dev\n"
+	if err := os.WriteFile(addons, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runDeployCmd(t, cfg, "remove", "myapp", "--cluster", "dev", "--delete-namespace")
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitUserError {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitUserError, err)
+	}
+	if !strings.Contains(err.Error(), "other") {
+		t.Errorf("error does not name the other entry: %v", err)
+	}
+	if after, _ := os.ReadFile(addons); string(after) != data {
+		t.Errorf("addons.yaml changed by a refused removal:\n%s", after)
+	}
+}
+
+func TestDeployRenderNoValidate(t *testing.T) {
+	path := writeScore(t, generateScoreTemplate("worker", "myapp", "dev", "example.com"))
+	cfg := config.Default()
+	cfg.Interactive = false
+
+	var err error
+	captureStdout(t, func() { err = runDeployCmd(t, cfg, "render", "-f", path) })
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Errorf("placeholder image: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+	captureStdout(t, func() { err = runDeployCmd(t, cfg, "render", "-f", path, "--no-validate") })
+	if err != nil {
+		t.Errorf("--no-validate: %v", err)
+	}
+}
+
+func TestDeployRunMultiDocument(t *testing.T) {
+	pipeStdin(t)
+	app := ScaffoldScore("worker", "myapp", "dev", "example.com", "nginx:1.27")
+	worker := strings.ReplaceAll(app, "myapp", "myapp-worker")
+	worker = strings.Replace(worker, `hctl.integratn.tech/cluster: "dev"`, `hctl.integratn.tech/cluster: "media"`, 1)
+	path := writeScore(t, app+"---\n"+worker)
+	cfg := config.Default()
+	cfg.RepoPath = t.TempDir()
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+
+	if err := runDeployCmd(t, cfg, "run", "-f", path, "--skip-secret-check", "--cluster", "dev"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, w := range []struct{ cluster, name string }{{"dev", "myapp"}, {"media", "myapp-worker"}} {
+		if _, err := os.Stat(filepath.Join(cfg.RepoPath, "workloads", w.cluster, "addons", w.name, "values.yaml")); err != nil {
+			t.Errorf("%s: values.yaml not written: %v", w.name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(cfg.RepoPath, "workloads", w.cluster, "addons.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), w.name+":") {
+			t.Errorf("%s: no entry in the addons.yaml of %s:\n%s", w.name, w.cluster, data)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(app+"---\n"+app), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := runDeployCmd(t, cfg, "render", "-f", path)
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Errorf("duplicate name: ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+}
+
+func TestDeployStatusStructured(t *testing.T) {
+	testutil.Isolate(t)
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": "myapp", "namespace": "argocd"},
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "Synced", "revision": "abc123"},
+			"health": map[string]interface{}{"status": "Degraded"},
+		},
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-0", Namespace: "dev", Labels: map[string]string{"app.kubernetes.io/name": "myapp"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "main", RestartCount: 4}},
+		},
+	}
+	testutil.NewFakeCluster(t, app, pod).Use()
+	tui.SetOutputFormat("json")
+	t.Cleanup(func() { tui.SetOutputFormat("text") })
+
+	var err error
+	out := captureStdout(t, func() { err = runDeployCmd(t, config.Default(), "status", "myapp", "--cluster", "dev") })
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitPlatformError {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitPlatformError, err)
+	}
+	var st workloadStatus
+	if err := json.Unmarshal([]byte(out), &st); err != nil {
+		t.Fatalf("output is not a status document: %v\n%s", err, out)
+	}
+	if st.Sync != "Synced" || st.Health != "Degraded" || st.Revision != "abc123" {
+		t.Errorf("status = %+v", st)
+	}
+	if len(st.Pods) != 1 || st.Pods[0].Name != "myapp-0" || st.Pods[0].Ready != 0 || st.Pods[0].Total != 1 || st.Pods[0].Restarts != 4 {
+		t.Errorf("pods = %+v", st.Pods)
+	}
+}
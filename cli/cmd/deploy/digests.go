@@ -0,0 +1,104 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/registry"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// imageDigests resolves the workload's images to digests when pinning is on
+// for it (override, from --pin-digests or a deploy profile, else the
+// pin-digest annotation, else registry.pinDigests). It returns nil, without
+// reading registry credentials, when pinning is off.
+func imageDigests(cfg *config.Config, workload *score.Workload, override *bool) (map[string]string, error) {
+	pin, err := deploylib.PinDigests(workload, override, cfg.Registry.PinDigests)
+	if err != nil || !pin {
+		return nil, err
+	}
+	resolver, err := registry.FromConfig(cfg)
+	if err != nil {
+		return nil, hcerrors.NewUserError("registry credentials: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return deploylib.ResolveImageDigests(ctx, resolver, workload)
+}
+
+// digestStep returns a step that resolves image digests into *digests once
+// the workload has been parsed. A non-nil override turns pinning on or off
+// (see imageDigests).
+func digestStep(cfg *config.Config, override *bool, workload func() *score.Workload, digests *map[string]string) tui.Step {
+	return tui.Step{
+		Title: "Resolving image digests",
+		Run: func() (string, error) {
+			d, err := imageDigests(cfg, workload(), override)
+			if err != nil {
+				return "", err
+			}
+			*digests = d
+			if d == nil {
+				return "not pinned", nil
+			}
+			return fmt.Sprintf("%d image(s) pinned", len(d)), nil
+		},
+	}
+}
+
+// printDigests lists pinned images with a shortened digest.
+func printDigests(digests map[string]string) {
+	images := make([]string, 0, len(digests))
+	for img := range digests {
+		images = append(images, img)
+	}
+	sort.Strings(images)
+	for _, img := range images {
+		d := digests[img]
+		if hex := strings.TrimPrefix(d, "sha256:"); len(hex) > 12 && hex != d {
+			d = "sha256:" + hex[:12]
+		}
+		fmt.Printf("  %s %s %s %s\n", tui.InfoStyle.Render("pinned"), img, tui.DimStyle.Render("→"), d)
+	}
+}
+
+// imageRequirementStep returns a step that checks the images provisioners
+// declared as requirements resolve in their registries, so a render-time
+// declaration is verified before anything is written.
+func imageRequirementStep(cfg *config.Config, result func() *deploylib.TranslateResult) tui.Step {
+	return tui.Step{
+		Title: "Checking required images",
+		Run: func() (string, error) {
+			var images []string
+			for _, req := range result().Requirements {
+				if req.Kind == provisioners.RequireImage {
+					images = append(images, req.Ref)
+				}
+			}
+			if len(images) == 0 {
+				return "no images required", nil
+			}
+			resolver, err := registry.FromConfig(cfg)
+			if err != nil {
+				return "", hcerrors.NewUserError("registry credentials: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			for _, img := range images {
+				if _, err := resolver.Digest(ctx, img); err != nil {
+					return "", hcerrors.New(hcerrors.ErrNotFound, "required image %s: %w", img, err).
+						WithRemediation("push the image, or fix the reference the provisioner was given")
+				}
+			}
+			return fmt.Sprintf("%d image(s) resolved", len(images)), nil
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// detectFileDrift runs provenance drift detection for every generated file,
+// in path order.
+func detectFileDrift(result *deploylib.TranslateResult, repoPath string) ([]*deploylib.FileDrift, error) {
+	paths := make([]string, 0, len(result.Files))
+	for p := range result.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var drifts []*deploylib.FileDrift
+	for _, p := range paths {
+		d, err := deploylib.DetectDrift(repoPath, p, result.Files[p])
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, nil
+}
+
+// manualEditStep returns a step that refuses to continue when generated files
+// on disk carry manual edits, unless overwrite is set. Drifted files are
+// stored in *manual for printing after the spinner exits.
+func manualEditStep(result func() *deploylib.TranslateResult, repoPath string, overwrite bool, manual *[]*deploylib.FileDrift) tui.Step {
+	return tui.Step{
+		Title: "Checking for manual edits",
+		Run: func() (string, error) {
+			drifts, err := detectFileDrift(result(), repoPath)
+			if err != nil {
+				return "", err
+			}
+			var edited []*deploylib.FileDrift
+			for _, d := range drifts {
+				if d.Manual {
+					edited = append(edited, d)
+				}
+			}
+			if len(edited) == 0 {
+				return "none", nil
+			}
+			if overwrite {
+				return fmt.Sprintf("%d file(s) with manual edits will be overwritten", len(edited)), nil
+			}
+			*manual = edited
+			return "", hcerrors.NewUserError("%d generated file(s) were edited by hand", len(edited)).
+				WithRemediation("move the edits into score.yaml, or rerun with --overwrite-manual-changes to discard them")
+		},
+	}
+}
+
+// printManualEdits shows the hand edits that a regeneration would discard.
+func printManualEdits(drifts []*deploylib.FileDrift) {
+	for _, d := range drifts {
+		fmt.Printf("\n  %s %s\n", tui.WarningStyle.Render("manual edits in"), d.Path)
+		printHunks(d.ManualHunks)
+	}
+	if len(drifts) > 0 {
+		fmt.Printf("\n%s\n", tui.DimStyle.Render("Move these edits into score.yaml, or rerun with --overwrite-manual-changes to discard them."))
+	}
+}
+
+// printHunks prints hunks with colored +/- lines and dimmed context.
+func printHunks(hunks []deploylib.Hunk) {
+	for _, h := range hunks {
+		fmt.Printf("  %s\n", tui.DimStyle.Render(h.Header()))
+		for _, line := range h.Lines {
+			switch line[0] {
+			case '-':
+				fmt.Printf("  %s\n", tui.ErrorStyle.Render(line))
+			case '+':
+				fmt.Printf("  %s\n", tui.SuccessStyle.Render(line))
+			default:
+				fmt.Printf("  %s\n", tui.DimStyle.Render(line))
+			}
+		}
+	}
+}
+
+// printDiagnostics lists translation findings with their codes.
+func printDiagnostics(diags []translate.Diagnostic) {
+	if len(diags) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, d := range diags {
+		code := tui.DimStyle.Render("[" + d.Code + "]")
+		switch d.Severity {
+		case translate.SeverityInfo:
+			fmt.Printf("  %s %s %s\n", tui.DimStyle.Render(tui.IconBullet), tui.DimStyle.Render(d.String()), code)
+		case translate.SeverityError:
+			fmt.Printf("  %s %s %s\n", tui.ErrorStyle.Render(tui.IconCross), d, code)
+		default:
+			fmt.Printf("  %s %s %s\n", tui.WarningStyle.Render(tui.IconWarn), d, code)
+		}
+	}
+}
+
+// printTranslateError lists every finding of a translation that failed on
+// its diagnostics or the platform constraints, as the error itself names
+// only the first. Structured output carries them in the error details
+// instead.
+func printTranslateError(err error) {
+	if tui.IsStructured() {
+		return
+	}
+	var diagErr *translate.DiagnosticsError
+	if errors.As(err, &diagErr) {
+		printDiagnostics(diagErr.Diagnostics)
+	}
+	var constraintsErr *deploylib.ConstraintsError
+	if errors.As(err, &constraintsErr) {
+		printProblems(constraintsErr.Problems)
+	}
+}
+
+// printResourceExemption notes a workload exempted from the resource
+// maximums; deploy records the exemption in the commit message.
+func printResourceExemption(e *translate.ResourceExemption) {
+	if e == nil {
+		return
+	}
+	fmt.Printf("  %s resource maximums lifted by %s: %s\n", tui.WarningStyle.Render(tui.IconWarn), translate.ResourceExemptionAnnotation, e)
+}
+
+// printInjectedEnv lists the environment variables interpolated into the
+// workload. Their values show in the rendered files.
+func printInjectedEnv(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Printf("  %s interpolated from the environment: %s\n", tui.DimStyle.Render(tui.IconBullet), strings.Join(names, ", "))
+}
+
+// printRemovedObjects lists the generated objects a render no longer
+// produces, with the provisioner that produced each.
+func printRemovedObjects(objects []translate.InventoryObject) {
+	fmt.Printf("%s\n", tui.ErrorStyle.Render("- no longer generated:"))
+	for _, o := range objects {
+		fmt.Printf("    %s %s\n", o, tui.DimStyle.Render("("+o.Provisioner+")"))
+	}
+	fmt.Printf("  %s\n", tui.DimStyle.Render("ArgoCD prunes these on the next sync."))
+}
+
+// objectRefs names objects for the audit log.
+func objectRefs(objects []translate.InventoryObject) []string {
+	var refs []string
+	for _, o := range objects {
+		refs = append(refs, o.String())
+	}
+	return refs
+}
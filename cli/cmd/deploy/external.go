@@ -0,0 +1,551 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/metrics"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/report"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newDeployChartCmd() *cobra.Command {
+	var (
+		cluster, namespace   string
+		repo, chart, version string
+		valuesFile           string
+		watchDeploy          bool
+		watchTimeout         time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "chart [workload]",
+		Short: "Deploy a third-party Helm chart as a workload",
+		Long: `Deploys a Helm chart that does not fit Score through the same addons.yaml
+flow as 'hctl deploy run'. The entry records chartRepository, chartName and
+defaultVersion, and --values is written to
+workloads/<cluster>/addons/<workload>/values.yaml.
+
+The chart version is checked against the repository's index.yaml first (OCI
+repositories are not checked). 'hctl deploy list', 'status' and 'remove'
+treat the workload like any other.`,
+		Example: `  hctl deploy chart uptime-kuma --repo https://dirsigler.github.io/uptime-kuma-helm \
+    --chart uptime-kuma --version 2.21.2 --values values.yaml --cluster media`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if cluster == "" {
+				cluster = cfg.DefaultCluster
+			}
+			if cluster == "" {
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+			}
+
+			w := deploylib.ChartWorkload{
+				Name: args[0], Cluster: cluster, Namespace: namespace,
+				Repository: repo, Chart: chart, Version: version,
+			}
+			if valuesFile != "" {
+				data, err := os.ReadFile(valuesFile)
+				if err != nil {
+					return hcerrors.NewUserError("reading values: %v", err)
+				}
+				w.Values = data
+			}
+			result, err := deploylib.ChartResult(w)
+			if err != nil {
+				return err
+			}
+
+			results, err := tui.RunSteps("Preparing chart", []tui.Step{{
+				Title: "Checking " + chart + " " + version,
+				Run: func() (string, error) {
+					ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+					defer cancel()
+					if err := addon.VerifyChart(ctx, repo, chart, version); err != nil {
+						return "", err
+					}
+					return repo, nil
+				},
+			}})
+			if err != nil {
+				return err
+			}
+			for _, r := range results {
+				if r.Err != nil {
+					return r.Err
+				}
+			}
+			return deployExternal(cfg, result, false, watchDeploy, watchTimeout)
+		},
+	}
+	cmd.Flags().StringVar(&repo, "repo", "", "Helm repository URL (https:// or oci://)")
+	cmd.Flags().StringVar(&chart, "chart", "", "chart name in the repository")
+	cmd.Flags().StringVar(&version, "version", "", "chart version")
+	cmd.Flags().StringVar(&valuesFile, "values", "", "values.yaml for the chart")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "target namespace (default: workload name)")
+	cmd.Flags().BoolVarP(&watchDeploy, "watch", "w", false, "watch ArgoCD sync after deploy")
+	cmd.Flags().DurationVar(&watchTimeout, "timeout", 5*time.Minute, "timeout for --watch")
+	return cmd
+}
+
+func newDeployKustomizeCmd() *cobra.Command {
+	var (
+		cluster, namespace string
+		dir                string
+		watchDeploy        bool
+		watchTimeout       time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "kustomize [workload]",
+		Short: "Deploy a kustomize directory as a workload",
+		Long: `Deploys a kustomize directory that does not fit Score through the same
+addons.yaml flow as 'hctl deploy run'. The directory is copied to
+workloads/<cluster>/addons/<workload>/ (files no longer in it are removed)
+and the addons.yaml entry points the ApplicationSet at that path with
+type: kustomize.
+
+The directory must build with kustomize, or kubectl kustomize, first.
+'hctl deploy list', 'status' and 'remove' treat the workload like any other.`,
+		Example: `  hctl deploy kustomize mqtt --path ./deploy/overlays/homelab --cluster media`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if cluster == "" {
+				cluster = cfg.DefaultCluster
+			}
+			if cluster == "" {
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+			}
+
+			var result *deploylib.TranslateResult
+			results, err := tui.RunSteps("Preparing kustomization", []tui.Step{
+				{
+					Title: "Building " + dir,
+					Run: func() (string, error) {
+						return "", deploylib.VerifyKustomize(dir)
+					},
+				},
+				{
+					Title: "Reading files",
+					Run: func() (string, error) {
+						r, err := deploylib.KustomizeResult(deploylib.KustomizeWorkload{
+							Name: args[0], Cluster: cluster, Namespace: namespace, Dir: dir,
+						})
+						if err != nil {
+							return "", err
+						}
+						result = r
+						return fmt.Sprintf("%d files", len(r.Files)), nil
+					},
+				},
+			})
+			if err != nil {
+				return err
+			}
+			for _, r := range results {
+				if r.Err != nil {
+					return r.Err
+				}
+			}
+			return deployExternal(cfg, result, true, watchDeploy, watchTimeout)
+		},
+	}
+	cmd.Flags().StringVar(&dir, "path", ".", "kustomize directory")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "target namespace (default: workload name)")
+	cmd.Flags().BoolVarP(&watchDeploy, "watch", "w", false, "watch ArgoCD sync after deploy")
+	cmd.Flags().DurationVar(&watchTimeout, "timeout", 5*time.Minute, "timeout for --watch")
+	return cmd
+}
+
+// deployExternal checks policy, confirms, then writes and commits a chart or
+// kustomize result the way 'hctl deploy run' does.
+func deployExternal(cfg *config.Config, result *deploylib.TranslateResult, prune, watch bool, timeout time.Duration) error {
+	guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+	if err != nil {
+		return err
+	}
+	if err := guard.Cluster("deploying to cluster", result.TargetCluster); err != nil {
+		return err
+	}
+	if err := guard.Namespace(result.Namespace); err != nil {
+		return err
+	}
+
+	if cfg.DryRun {
+		mp, err := planResults(cfg, []*deploylib.TranslateResult{result}, "deploy", guard.Override(), prune, cfg.GitMode)
+		if err != nil {
+			return err
+		}
+		return mp.DryRun()
+	}
+
+	paths := make([]string, 0, len(result.Files))
+	for path := range result.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fmt.Printf("\n  Files to write:\n")
+	for _, path := range paths {
+		fmt.Printf("    %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), path)
+	}
+	fmt.Printf("    %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), deploylib.AddonsPath(result.TargetCluster))
+
+	if cfg.Interactive {
+		ok, _ := tui.Confirm("\nDeploy this workload?")
+		if !ok {
+			fmt.Println(tui.DimStyle.Render("Cancelled"))
+			return nil
+		}
+	}
+
+	steps, err := writeSteps(cfg, []*deploylib.TranslateResult{result}, "deploy", guard.Override(), prune, metrics.NewTimer(nil), nil)
+	if err != nil {
+		return err
+	}
+	results, err := tui.RunSteps("Deploying "+result.WorkloadName, steps)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("deploy failed at %q: %w", r.Title, r.Err)
+		}
+	}
+
+	if !watch {
+		fmt.Printf("\n%s\n", tui.DimStyle.Render("ArgoCD will sync the workload automatically."))
+		fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf("Check status: hctl deploy status %s", result.WorkloadName)))
+		return nil
+	}
+	return watchSync(cfg, result.WorkloadName, result.TargetCluster, timeout, nil)
+}
+
+// planResults plans writing results into the repo and committing them with
+// gitMode in one commit, the way writeSteps runs it. With prune, files in a
+// workload directory that its result no longer writes are deleted too.
+// Every file a result writes is committed, changed or not, so a retry
+// commits what an earlier run left uncommitted. Resource exemptions are
+// recorded in the commit message, and the objects the inventories drop in
+// the audit log.
+func planResults(cfg *config.Config, results []*deploylib.TranslateResult, action, override string, prune bool, gitMode string) (*mutation.Plan, error) {
+	mp := mutation.New(cfg.RepoPath)
+	var paths, names, clusters, injected, exemptions []string
+	var removed []translate.InventoryObject
+	byCluster := map[string][]*deploylib.TranslateResult{}
+	for _, result := range results {
+		gone, err := deploylib.RemovedObjects(cfg.RepoPath, result)
+		if err != nil {
+			return nil, err
+		}
+		removed = append(removed, gone...)
+		if prune {
+			stale, err := deploylib.StaleFiles(cfg.RepoPath, result)
+			if err != nil {
+				return nil, err
+			}
+			for _, rel := range stale {
+				if err := mp.Remove(repopath.Abs(cfg.RepoPath, rel)); err != nil {
+					return nil, err
+				}
+			}
+			paths = append(paths, stale...)
+		}
+		files := make([]string, 0, len(result.Files))
+		for rel := range result.Files {
+			files = append(files, repopath.Join(rel))
+		}
+		sort.Strings(files)
+		for _, rel := range files {
+			if err := mp.Write(repopath.Abs(cfg.RepoPath, rel), result.Files[rel]); err != nil {
+				return nil, err
+			}
+		}
+		paths = append(paths, files...)
+
+		if _, ok := byCluster[result.TargetCluster]; !ok {
+			clusters = append(clusters, result.TargetCluster)
+		}
+		byCluster[result.TargetCluster] = append(byCluster[result.TargetCluster], result)
+		names = append(names, result.WorkloadName)
+		injected = append(injected, result.InjectedEnv...)
+		if result.ResourceExemption != nil {
+			exemptions = append(exemptions, result.ResourceExemption.String())
+		}
+	}
+	// Workloads on the same cluster share its addons.yaml.
+	for _, cluster := range clusters {
+		onCluster := byCluster[cluster]
+		addons, err := deploylib.AddonsWithWorkload(cfg.RepoPath, onCluster[0], onCluster[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		addonsPath := deploylib.AddonsPath(cluster)
+		if err := mp.Write(repopath.Abs(cfg.RepoPath, addonsPath), addons); err != nil {
+			return nil, err
+		}
+		paths = append(paths, addonsPath)
+	}
+	slices.Sort(injected)
+
+	opts := git.WorkflowOpts{
+		Paths:       paths,
+		Action:      action,
+		Resource:    strings.Join(names, ", "),
+		Details:     strings.Join(clusters, ", "),
+		GitMode:     gitMode,
+		Interactive: cfg.Interactive,
+
+		PolicyOverride:    override,
+		InjectedEnv:       slices.Compact(injected),
+		Removed:           objectRefs(removed),
+		ResourceExemption: strings.Join(exemptions, "; "),
+	}
+	mp.Commit(opts)
+	// An unchanged workload keeps the commit it records (see StampCommit).
+	if (gitMode == "auto" || gitMode == "generate") && len(mp.Files) > 0 {
+		stamp := commitStampStep(cfg, results, gitMode)
+		valuesPaths := make([]string, len(results))
+		for i, result := range results {
+			valuesPaths[i] = translate.ValuesPath(result.TargetCluster, result.WorkloadName)
+		}
+		mp.GitStep(fmt.Sprintf("record the deploy commit in %s and commit it", strings.Join(valuesPaths, ", ")), func() error {
+			_, err := stamp.Run()
+			return err
+		})
+	}
+	return mp, nil
+}
+
+// writeSteps returns the steps that run a planResults plan: write the
+// files, then stage or commit them per git mode, asking first in prompt
+// mode. When a commit is made, the commit annotation is stamped afterwards.
+func writeSteps(cfg *config.Config, results []*deploylib.TranslateResult, action, override string, prune bool, timer *metrics.Timer, rec *report.Recorder) ([]tui.Step, error) {
+	gitMode := cfg.GitMode
+	if gitMode == "prompt" && cfg.Interactive {
+		ok, _ := tui.Confirm("Commit and push changes?")
+		if ok {
+			gitMode = "auto"
+		} else {
+			gitMode = "stage-only"
+		}
+	}
+	mp, err := planResults(cfg, results, action, override, prune, gitMode)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []tui.Step{
+		recordStep(rec, deploylib.StageWrite, tui.Step{
+			Title: "Writing files",
+			Run: func() (string, error) {
+				if err := mp.Apply(); err != nil {
+					return "", fmt.Errorf("writing files: %w", err)
+				}
+				return fmt.Sprintf("%d files", len(mp.Files)), nil
+			},
+		}),
+		recordStep(rec, deploylib.StageCommit, tui.Step{
+			Title: gitStepTitle(gitMode),
+			Run: func() (string, error) {
+				step := git.HandleGitWorkflowStep(*mp.Workflow())
+				defer timer.Git(gitOperation(gitMode))()
+				return step.Run()
+			},
+		}),
+	}
+	if len(mp.Actions) > 0 {
+		steps = append(steps, commitStampStep(cfg, results, gitMode))
+	}
+	return steps, nil
+}
+
+// recordStep records the outcome of step as stage in rec, timed from when
+// the step starts.
+func recordStep(rec *report.Recorder, stage string, step tui.Step) tui.Step {
+	if rec == nil {
+		return step
+	}
+	run := step.Run
+	step.Run = func() (string, error) {
+		rec.Start()
+		detail, err := run()
+		if err != nil {
+			rec.Fail(stage, err.Error())
+		} else {
+			rec.Pass(stage, detail)
+		}
+		return detail, err
+	}
+	return step
+}
+
+// commitStampStep records the deploy commit in the generated values files
+// of results as the hctl.integratn.tech/commit annotation, in one follow-up
+// commit pushed like the first. Nothing is committed when no workload
+// changed.
+func commitStampStep(cfg *config.Config, results []*deploylib.TranslateResult, gitMode string) tui.Step {
+	return tui.Step{
+		Title: "Recording deploy commit",
+		Run: func() (string, error) {
+			repo, err := git.DetectRepo(cfg.RepoPath)
+			if err != nil {
+				return "no git repo detected", nil
+			}
+			sha := repo.Head()
+			var paths, names []string
+			for _, result := range results {
+				path, err := deploylib.StampCommit(cfg.RepoPath, result, sha)
+				if err != nil {
+					return "", err
+				}
+				if path != "" {
+					paths = append(paths, path)
+					names = append(names, result.WorkloadName)
+				}
+			}
+			if len(paths) == 0 {
+				return "unchanged", nil
+			}
+			msg := git.FormatCommitMessage("record commit for", strings.Join(names, ", "), sha)
+			if gitMode == "auto" {
+				err = repo.CommitAndPush(paths, msg)
+			} else if err = repo.Add(paths...); err == nil {
+				err = repo.Commit(msg)
+			}
+			if err != nil {
+				return "", hcerrors.Wrap(hcerrors.ErrGit, err)
+			}
+			return sha, nil
+		},
+	}
+}
+
+// gitStepTitle matches the title git.HandleGitWorkflowStep gives its step.
+func gitStepTitle(mode string) string {
+	return git.HandleGitWorkflowStep(git.WorkflowOpts{GitMode: mode}).Title
+}
+
+// watchSync polls the workload's ArgoCD Application (see deploylib.FindApp)
+// until it is Synced and Healthy or timeout passes. Each of
+// deploylib.WatchStages is recorded in rec as it completes; on timeout the
+// first pending one fails with what the last poll saw. Progress goes to
+// stderr under -o json/yaml, keeping stdout for the structured output.
+func watchSync(cfg *config.Config, name, cluster string, timeout time.Duration, rec *report.Recorder) error {
+	out := io.Writer(os.Stdout)
+	if tui.IsStructured() {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "\n%s Watching ArgoCD sync...\n\n", tui.InfoStyle.Render(tui.IconSync))
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return fmt.Errorf("connecting to cluster for watch: %w", err)
+	}
+
+	rec.Start()
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		app, aErr := deploylib.FindApp(ctx, client, name, cluster)
+		if aErr != nil {
+			app = nil
+		}
+		var states []deploylib.StageState
+		if rec != nil {
+			states = observeStages(ctx, client, app, name, cluster)
+			for _, st := range states {
+				if rec.Recorded(st.Stage) {
+					continue
+				}
+				if !st.Done {
+					break
+				}
+				rec.Pass(st.Stage, st.Message)
+			}
+		}
+		cancel()
+
+		if app != nil {
+			syncStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
+			healthStatus, _, _ := platform.UnstructuredNestedString(app.Object, "status", "health", "status")
+			phase := fmt.Sprintf("%s/%s", syncStatus, healthStatus)
+
+			if syncStatus == "Synced" && healthStatus == "Healthy" {
+				for _, stage := range deploylib.WatchStages {
+					rec.Pass(stage, "ArgoCD reports the application Synced/Healthy")
+				}
+				fmt.Fprintf(out, "  %s %s\n", tui.SuccessStyle.Render(tui.IconCheck), phase)
+				fmt.Fprintf(out, "\n%s\n", tui.SuccessStyle.Render("Deployment healthy!"))
+				return nil
+			}
+			fmt.Fprintf(out, "  %s %s\n", tui.WarningStyle.Render(tui.IconDot), phase)
+		} else {
+			fmt.Fprintf(out, "  %s waiting for ArgoCD app...\n", tui.DimStyle.Render(tui.IconDot))
+		}
+
+		if time.Now().After(deadline) {
+			for _, st := range states {
+				if !rec.Recorded(st.Stage) {
+					rec.Fail(st.Stage, fmt.Sprintf("timed out after %s: %s", timeout, st.Message), st.Details...)
+					break
+				}
+			}
+			return hcerrors.NewTimeoutError("timeout waiting for sync after %s", timeout).
+				WithRemediation("check progress with 'hctl deploy status " + name + "'")
+		}
+
+		<-ticker.C
+	}
+}
+
+// observeStages reads the workload's pods, and the Warning events of those
+// not ready, for deploylib.ObserveStages.
+func observeStages(ctx context.Context, client *kube.Client, app *unstructured.Unstructured, name, cluster string) []deploylib.StageState {
+	if app == nil {
+		return deploylib.ObserveStages(nil, nil, nil)
+	}
+	namespace, _, _ := platform.UnstructuredNestedString(app.Object, "spec", "destination", "namespace")
+	if namespace == "" {
+		namespace = cluster
+	}
+	pods, _ := deploylib.FindPods(ctx, client, namespace, name, cluster)
+	warnings := map[string][]string{}
+	for _, p := range pods {
+		if p.Phase == "Running" && p.ReadyContainers == p.TotalContainers {
+			continue
+		}
+		if w, err := client.PodWarnings(ctx, namespace, p.Name); err == nil {
+			warnings[p.Name] = w
+		}
+	}
+	return deploylib.ObserveStages(app, pods, warnings)
+}
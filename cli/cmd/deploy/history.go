@@ -0,0 +1,131 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// historySubjectWidth truncates long commit subjects in the history table.
+const historySubjectWidth = 50
+
+func newDeployHistoryCmd() *cobra.Command {
+	var (
+		cluster string
+		limit   int
+	)
+	cmd := &cobra.Command{
+		Use:   "history <workload> --cluster <cluster>",
+		Short: "Show the deploy revisions of a workload",
+		Long: `Lists the commits that touched a workload's directory in the gitops repo,
+newest first, with the date, author, subject and what each changed in the
+workload's values.yaml: image, env vars added, removed or changed,
+container resources, the route, and other sections by name. A commit that
+only reformats the file, or records the deployed commit, shows as
+"no semantic change".
+
+The revision the workload's ArgoCD Application is synced to is marked: the
+newest listed commit contained in its synced revision. When the cluster
+cannot be reached the history is still shown, without the mark.`,
+		Example: `  hctl deploy history web --cluster vcluster-dev
+  hctl deploy history web --cluster vcluster-dev --limit 5 -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workload := args[0]
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if cluster == "" {
+				cluster = cfg.DefaultCluster
+			}
+			if cluster == "" {
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+			}
+			if limit < 1 {
+				return hcerrors.NewUserError("--limit must be at least 1, got %d", limit)
+			}
+
+			synced, syncErr := syncedRevision(cfg, workload, cluster)
+			history, err := deploylib.WorkloadHistory(cfg.RepoPath, cluster, workload, limit, synced)
+			if err != nil {
+				return err
+			}
+			if len(history.Revisions) == 0 {
+				return hcerrors.New(hcerrors.ErrNotFound, "no commits touch %s", deploylib.WorkloadDir(cluster, workload)).
+					WithRemediation("run 'hctl deploy list --cluster " + cluster + "' to see deployed workloads")
+			}
+			if tui.PrintStructured(history) {
+				return nil
+			}
+			printHistory(history, syncErr)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().IntVar(&limit, "limit", deploylib.DefaultHistoryLimit, "maximum number of revisions to list")
+	return cmd
+}
+
+// syncedRevision returns the revision the workload's ArgoCD Application is
+// synced to.
+func syncedRevision(cfg *config.Config, workload, cluster string) (string, error) {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return "", fmt.Errorf("connecting to cluster: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	app, err := deploylib.FindApp(ctx, client, workload, cluster)
+	if err != nil {
+		return "", err
+	}
+	revision, _, _ := platform.UnstructuredNestedString(app.Object, "status", "sync", "revision")
+	return revision, nil
+}
+
+// printHistory renders the revisions as a table, marking the synced one.
+func printHistory(h *deploylib.History, syncErr error) {
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(h.Workload+" in "+h.Cluster))
+
+	headers := []string{"", "REVISION", "DATE", "AUTHOR", "SUBJECT", "CHANGES"}
+	var rows [][]string
+	marked := false
+	for _, rev := range h.Revisions {
+		mark := ""
+		if rev.Synced {
+			mark, marked = tui.SuccessStyle.Render("●"), true
+		}
+		subject := rev.Subject
+		if utf8.RuneCountInString(subject) > historySubjectWidth {
+			subject = string([]rune(subject)[:historySubjectWidth-1]) + "…"
+		}
+		changes := rev.Summary()
+		if len(rev.Changes) == 0 {
+			changes = tui.DimStyle.Render(changes)
+		}
+		rows = append(rows, []string{mark, tui.ShortRevision(rev.Commit), rev.Date.Local().Format("2006-01-02 15:04"), rev.Author, subject, changes})
+	}
+	fmt.Println(tui.Table(headers, rows))
+
+	switch {
+	case syncErr != nil:
+		fmt.Printf("\n%s\n", tui.WarningStyle.Render("Synced revision unknown: "+syncErr.Error()))
+	case h.SyncedRevision == "":
+		fmt.Printf("\n%s\n", tui.DimStyle.Render("The ArgoCD Application reports no synced revision."))
+	case marked:
+		fmt.Printf("\n%s\n", tui.DimStyle.Render("● synced by ArgoCD at "+tui.ShortRevision(h.SyncedRevision)))
+	default:
+		fmt.Printf("\n%s\n", tui.WarningStyle.Render("Synced revision "+tui.ShortRevision(h.SyncedRevision)+
+			" contains none of these revisions: it is older than --limit, or not in the local clone (try git fetch)."))
+	}
+}
@@ -0,0 +1,111 @@
+package deploy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// profileView is a deploy profile as printed by 'hctl deploy profiles -o
+// json|yaml', with the timeout as a duration string.
+type profileView struct {
+	Name       string   `json:"name" yaml:"name"`
+	Cluster    string   `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Namespace  string   `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Set        []string `json:"set,omitempty" yaml:"set,omitempty"`
+	Watch      *bool    `json:"watch,omitempty" yaml:"watch,omitempty"`
+	Timeout    string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	SmokeTest  *bool    `json:"smokeTest,omitempty" yaml:"smokeTest,omitempty"`
+	PinDigests *bool    `json:"pinDigests,omitempty" yaml:"pinDigests,omitempty"`
+}
+
+func newDeployProfilesCmd() *cobra.Command {
+	var scoreFile string
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the deploy profiles in the app repo's .hctl.yaml",
+		Long: `Lists the profiles defined in .hctl.yaml at the root of the app repo
+holding score.yaml, for 'hctl deploy run --profile <name>'. A profile sets
+any of cluster, namespace, set, watch, timeout, smokeTest and pinDigests:
+
+  profiles:
+    staging:
+      cluster: vcluster-staging
+      namespace: web-staging
+      set:
+        - deployment.replicas=1
+      watch: true
+      timeout: 10m
+      smokeTest: true
+      pinDigests: false
+
+Flags given to 'deploy run' win over the profile. The file is validated
+when it is read, with the line of each problem.`,
+		Example: `  hctl deploy profiles
+  hctl deploy profiles -f apps/web/score.yaml -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := deploylib.LoadProfiles(filepath.Dir(scoreFile))
+			if err != nil {
+				return err
+			}
+			names := profiles.Names()
+			views := make([]profileView, 0, len(names))
+			for _, name := range names {
+				p := profiles.Profiles[name]
+				view := profileView{
+					Name: p.Name, Cluster: p.Cluster, Namespace: p.Namespace, Set: p.Set,
+					Watch: p.Watch, SmokeTest: p.SmokeTest, PinDigests: p.PinDigests,
+				}
+				if p.Timeout > 0 {
+					view.Timeout = p.Timeout.String()
+				}
+				views = append(views, view)
+			}
+			if tui.PrintStructured(views) {
+				return nil
+			}
+
+			if profiles.Path == "" {
+				fmt.Println(tui.DimStyle.Render("No " + deploylib.ProfilesFile + " in the app repo"))
+				return nil
+			}
+			if len(views) == 0 {
+				fmt.Println(tui.DimStyle.Render("No profiles defined in " + profiles.Path))
+				return nil
+			}
+			var rows [][]string
+			for _, v := range views {
+				rows = append(rows, []string{
+					v.Name, orDash(v.Cluster), orDash(v.Namespace),
+					boolCell(v.Watch), orDash(v.Timeout), boolCell(v.SmokeTest), boolCell(v.PinDigests),
+					orDash(strings.Join(v.Set, " ")),
+				})
+			}
+			fmt.Println(tui.Table([]string{"PROFILE", "CLUSTER", "NAMESPACE", "WATCH", "TIMEOUT", "SMOKE-TEST", "PIN-DIGESTS", "SET"}, rows))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&scoreFile, "file", "f", "score.yaml", "score.yaml whose app repo holds .hctl.yaml")
+	return cmd
+}
+
+// boolCell renders an optional profile setting; unset is "-".
+func boolCell(b *bool) string {
+	if b == nil {
+		return "-"
+	}
+	return strconv.FormatBool(*b)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,226 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/onepassword"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/secrets"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// secretPollInterval is how often --wait-for-secret re-checks 1Password.
+const secretPollInterval = 10 * time.Second
+
+// secretCheckStep returns a step that verifies the 1Password items and fields
+// a workload depends on exist before anything is written. With wait set it
+// polls until they appear or timeout elapses. Unsatisfied requirements are
+// stored in *missing so the caller can report them after the spinner exits.
+func secretCheckStep(cfg *config.Config, reqs func() []provisioners.SecretRequirement, wait bool, timeout time.Duration, missing *[]onepassword.Missing) tui.Step {
+	title := "Checking 1Password items"
+	if wait {
+		title = "Waiting for 1Password items"
+	}
+	return tui.Step{
+		Title: title,
+		Run: func() (string, error) {
+			required := reqs()
+			if len(required) == 0 {
+				return "no secrets referenced", nil
+			}
+
+			client, err := onepassword.FromConfig(cfg)
+			if err != nil {
+				return "skipped: " + err.Error(), nil
+			}
+
+			vault := onepassword.ConfiguredVault(cfg)
+			var m []onepassword.Missing
+			if wait {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				m, err = client.WaitFor(ctx, vault, required, secretPollInterval)
+				if errors.Is(err, context.DeadlineExceeded) {
+					*missing = m
+					return "", hcerrors.NewTimeoutError("timed out after %s: %d item(s) still incomplete", timeout, len(m)).
+						WithDetails(m)
+				}
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				m, err = client.Check(ctx, vault, required)
+			}
+			if err != nil {
+				return "", fmt.Errorf("checking vault %q: %w (use --skip-secret-check to bypass)", vault, err)
+			}
+			if len(m) > 0 {
+				*missing = m
+				return "", hcerrors.New(hcerrors.ErrNotFound, "%d of %d item(s) incomplete in vault %q", len(m), len(required), vault).
+					WithDetails(m).
+					WithRemediation("create the items, or rerun with --wait-for-secret")
+			}
+			return fmt.Sprintf("%d item(s) present in %s", len(required), vault), nil
+		},
+	}
+}
+
+// printMissingSecrets lists unsatisfied 1Password requirements.
+func printMissingSecrets(missing []onepassword.Missing) {
+	if len(missing) == 0 {
+		return
+	}
+	fmt.Printf("\n  Missing 1Password items:\n")
+	for _, m := range missing {
+		fmt.Printf("    %s %s\n", tui.ErrorStyle.Render(tui.IconCross), m.String())
+	}
+	fmt.Printf("\n%s\n", tui.DimStyle.Render("Create the items, or rerun with --wait-for-secret to block until they appear."))
+}
+
+func newDeploySecretsCmd() *cobra.Command {
+	var (
+		cluster string
+		verify  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "secrets <workload>",
+		Short: "Show which 1Password items a workload depends on",
+		Long: `Trace a deployed workload's credentials from the committed values.yaml:
+each Secret its containers consume, the ExternalSecret that produces it, the
+1Password items and fields it reads, and the env vars, envFrom and mounts
+that use it.
+
+Live readiness of each ExternalSecret and the age of its target Secret are
+read from the current kube context when reachable. --verify also checks that
+the items and fields exist through the 1Password Connect API.
+
+Secret values are never read or printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if cluster == "" {
+				cluster = cfg.DefaultCluster
+			}
+			if cluster == "" {
+				return hcerrors.NewUserError("no cluster specified — use --cluster or set defaultCluster")
+			}
+			workload := args[0]
+
+			valuesPath := translate.ValuesPath(cluster, workload)
+			data, err := os.ReadFile(repopath.Abs(cfg.RepoPath, valuesPath))
+			if os.IsNotExist(err) {
+				return hcerrors.New(hcerrors.ErrNotFound, "workload %q has no %s", workload, valuesPath).
+					WithRemediation("run 'hctl deploy list --cluster " + cluster + "' to see deployed workloads")
+			}
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", valuesPath, err)
+			}
+			var values map[string]interface{}
+			if err := yaml.Unmarshal(data, &values); err != nil {
+				return hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", valuesPath, err)
+			}
+			links := secrets.FromValues(values)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			// Live state is best-effort: the analysis itself comes from the repo.
+			client, _ := kube.NewClient(cfg.KubeContext)
+			rows := secrets.Live(ctx, client, links, cluster)
+			if verify {
+				op, err := onepassword.FromConfig(cfg)
+				if err != nil {
+					return hcerrors.NewUserError("--verify: %v", err)
+				}
+				vault := onepassword.ConfiguredVault(cfg)
+				if err := secrets.CheckOnePassword(ctx, op, vault, rows); err != nil {
+					return fmt.Errorf("checking vault %q: %w", vault, err)
+				}
+			}
+
+			if tui.PrintStructured(rows) {
+				return nil
+			}
+			if len(rows) == 0 {
+				fmt.Println(tui.DimStyle.Render("Workload " + workload + " uses no Secrets"))
+				return nil
+			}
+			fmt.Println(tui.TitleStyle.Render(fmt.Sprintf("Secrets for %s (%s)", workload, cluster)))
+			fmt.Println(secrets.Table(rows))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster")
+	cmd.Flags().BoolVar(&verify, "verify", false, "check that the 1Password items and fields exist via Connect")
+	return cmd
+}
+
+func newDeployMigrateSecretsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-secrets",
+		Short: "List workloads whose 1Password items change with environment scoping",
+		Long: `List the 1Password items read by the workloads committed to the repo that
+environment-scoped secrets change:
+
+  - unqualified shared items (shared-postgres, shared-redis) become the
+    cluster environment's item, e.g. shared-postgres-prod;
+  - items qualified with another environment than the cluster's are refused
+    on the next deploy unless the resource sets
+    params.allowCrossEnvironment: true.
+
+Each cluster's environment comes from its vCluster request
+(integrations.argocd.environment) or platform.environments in the hctl
+config. Nothing is changed: redeploy the listed workloads once the new items
+exist in 1Password.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			envs, err := deploylib.ClusterEnvironments(cfg)
+			if err != nil {
+				return err
+			}
+			changes, err := deploylib.SecretScopeMigrations(cfg.RepoPath, envs)
+			if err != nil {
+				return err
+			}
+			if tui.PrintStructured(changes) {
+				return nil
+			}
+			if len(changes) == 0 {
+				fmt.Println(tui.SuccessStyle.Render(tui.IconCheck + " No workload reads an item that environment scoping changes"))
+				return nil
+			}
+			rows := make([][]string, 0, len(changes))
+			for _, c := range changes {
+				env, newItem := c.Environment, c.NewItem
+				if env == "" {
+					env = "-"
+				}
+				if newItem == "" {
+					newItem = "-"
+				}
+				rows = append(rows, []string{c.Cluster, env, c.Workload, c.Item, newItem, c.Reason})
+			}
+			fmt.Println(tui.TitleStyle.Render(fmt.Sprintf("%d item(s) change with environment scoping", len(changes))))
+			fmt.Println(tui.Table([]string{"CLUSTER", "ENVIRONMENT", "WORKLOAD", "ITEM", "NEW ITEM", "REASON"}, rows))
+			return nil
+		},
+	}
+}
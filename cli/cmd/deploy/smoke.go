@@ -0,0 +1,50 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/smoke"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// runSmokeTests runs the workload's x-hctl.smokeTests against its route and
+// prints each result. Failures leave the deployment in place and return an
+// ErrSmokeTest error with rollback guidance.
+func runSmokeTests(runner *smoke.Runner, name, cluster string, tests []translate.SmokeTest) error {
+	fmt.Printf("\n%s Running %d smoke test(s)...\n\n", tui.InfoStyle.Render(tui.IconPlay), len(tests))
+	checks := make([]smoke.Check, len(tests))
+	for i, t := range tests {
+		checks[i] = smoke.Check{
+			URL:                t.URL,
+			ExpectStatus:       t.ExpectStatus,
+			ExpectBodyContains: t.ExpectBodyContains,
+			Timeout:            t.Timeout,
+		}
+	}
+	results := runner.Run(context.Background(), checks, printSmokeResult)
+
+	failed := smoke.Failed(results)
+	if failed == 0 {
+		fmt.Printf("\n%s\n", tui.SuccessStyle.Render("Smoke tests passed!"))
+		return nil
+	}
+	return hcerrors.New(hcerrors.ErrSmokeTest, "%d of %d smoke tests failed", failed, len(results)).
+		WithDetails(map[string]string{"workload": name, "cluster": cluster}).
+		WithRemediation(fmt.Sprintf("the deployment was left in place; review it with 'hctl deploy history %s --cluster %s' and roll back by reverting the deploy commit (git revert) if needed", name, cluster))
+}
+
+// printSmokeResult prints one smoke test result line.
+func printSmokeResult(res smoke.Result) {
+	detail := fmt.Sprintf("%d attempt(s), %s", res.Attempts, res.Duration.Round(time.Millisecond))
+	if res.Passed {
+		fmt.Printf("  %s %s %s\n", tui.SuccessStyle.Render(tui.IconCheck), res.Check.URL,
+			tui.DimStyle.Render(fmt.Sprintf("(%d, %s)", res.Status, detail)))
+		return
+	}
+	fmt.Printf("  %s %s %s\n", tui.ErrorStyle.Render(tui.IconCross), res.Check.URL, tui.DimStyle.Render("("+detail+")"))
+	fmt.Printf("      %s\n", tui.WarningStyle.Render(res.Error))
+}
@@ -0,0 +1,273 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/spf13/cobra"
+)
+
+// validatePollInterval is how often --watch checks its inputs for changes.
+const validatePollInterval = 500 * time.Millisecond
+
+// validateReport is the structured output of 'hctl deploy validate'.
+type validateReport struct {
+	File      string          `json:"file" yaml:"file"`
+	Valid     bool            `json:"valid" yaml:"valid"`
+	Cluster   string          `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Namespace string          `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Problems  []score.Problem `json:"problems" yaml:"problems"`
+
+	// workloads are the parsed workloads, for --watch to find the files
+	// they mount; an entry is nil when its document does not parse.
+	workloads []*score.Workload
+}
+
+func newDeployValidateCmd() *cobra.Command {
+	var (
+		cluster   string
+		scoreFile string
+		watch     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check score.yaml without deploying",
+		Long: `Checks score.yaml the way 'hctl deploy run' would, without writing files,
+committing, or reaching external systems:
+
+  - schema: YAML syntax, field types, required fields, and fields Score does
+    not define, with a suggestion for likely typos
+  - constraints: the platform constraints 'hctl deploy run' checks before
+    translating, such as route hosts under the platform domain
+  - policy: the repo's resource policy, observability config and tenancy
+    policy for the target cluster and namespace
+  - references: ${resources.<name>.<output>} references that resolve to no
+    resource output
+
+Every problem is listed with its line and column in score.yaml.
+
+--watch re-runs the checks whenever score.yaml, a local file its containers
+mount, or a platform policy file changes, clearing the screen between runs.
+Stop it with Ctrl-C.
+
+Exit codes: 0 = valid (warnings allowed), 5 = errors found.
+
+Examples:
+  hctl deploy validate
+  hctl deploy validate -f apps/web/score.yaml --cluster media
+  hctl deploy validate --watch`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch {
+				return watchValidate(cmd.Context(), scoreFile, cluster)
+			}
+			report, err := validateScore(scoreFile, cluster)
+			if err != nil {
+				return err
+			}
+			if !tui.PrintStructured(report) {
+				printValidation(report)
+			}
+			if !report.Valid {
+				return hcerrors.New(hcerrors.ErrValidation, "%s has %d error(s)", scoreFile, countSeverity(report.Problems, score.SeverityError))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cluster, "cluster", "", "target vCluster (overrides score.yaml annotation)")
+	cmd.Flags().StringVarP(&scoreFile, "file", "f", "score.yaml", "path to score.yaml")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "re-validate whenever score.yaml or the files it depends on change")
+	return cmd
+}
+
+// validateScore runs deploylib.ValidateScore and, for each workload that
+// translates, the tenancy policy checks deploy run makes. Cluster and
+// Namespace are reported when score.yaml defines a single workload.
+func validateScore(scoreFile, cluster string) (*validateReport, error) {
+	v, err := deploylib.ValidateScore(scoreFile, cluster)
+	if err != nil {
+		return nil, err
+	}
+	report := &validateReport{File: scoreFile, workloads: v.Workloads}
+	if len(v.Results) == 1 && v.Results[0] != nil {
+		report.Cluster, report.Namespace = v.Results[0].TargetCluster, v.Results[0].Namespace
+	}
+	var guard *policy.Guard
+	for i, r := range v.Results {
+		if r == nil {
+			continue
+		}
+		if guard == nil {
+			cfg := config.Get()
+			g, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+			if err != nil {
+				v.AddError(i, err)
+				break
+			}
+			guard = g
+		}
+		if err := guard.Cluster("deploying to cluster", r.TargetCluster); err != nil {
+			v.Add(i, guardProblem(err, "metadata.annotations.hctl.integratn.tech/cluster"))
+		}
+		if err := guard.Namespace(r.Namespace); err != nil {
+			v.Add(i, guardProblem(err, "metadata.annotations.hctl.integratn.tech/namespace"))
+		}
+	}
+	report.Problems = v.Problems
+	if report.Problems == nil {
+		report.Problems = []score.Problem{}
+	}
+	report.Valid = !v.Failed()
+	return report, nil
+}
+
+// guardProblem places a tenancy policy error at the annotation choosing
+// what it rejects; a workload without the annotation gets no position.
+func guardProblem(err error, field string) score.Problem {
+	p := deploylib.ErrorProblem(err)
+	if p.Field == "" {
+		p.Field = field
+	}
+	return p
+}
+
+// watchValidate validates scoreFile, then again whenever one of its inputs
+// changes, until ctx is done.
+func watchValidate(ctx context.Context, scoreFile, cluster string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := time.NewTicker(validatePollInterval)
+	defer ticker.Stop()
+
+	repoPath := config.Get().RepoPath
+	inputs := deploylib.ValidateInputs(scoreFile, nil, repoPath)
+	var last [32]byte
+	first := true
+	for {
+		if fp := deploylib.Fingerprint(inputs); first || fp != last {
+			first = false
+			last = fp
+			report, err := validateScore(scoreFile, cluster)
+			if err != nil {
+				// Editors may remove the file while saving it.
+				report = &validateReport{File: scoreFile, Problems: []score.Problem{deploylib.ErrorProblem(err)}}
+			}
+			// Structured output prints one document per run.
+			if !tui.PrintStructured(report) {
+				if isTerminal(os.Stdout) {
+					fmt.Print("\033[H\033[2J")
+				}
+				printValidation(report)
+				fmt.Printf("\n  %s\n", tui.DimStyle.Render(fmt.Sprintf("%s · watching %d file(s), Ctrl-C to stop", time.Now().Format("15:04:05"), len(inputs))))
+			}
+			if next := deploylib.ValidateInputs(scoreFile, report.workloads, repoPath); !slices.Equal(next, inputs) {
+				inputs = next
+				last = deploylib.Fingerprint(inputs)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printValidation(r *validateReport) {
+	errs, warns := countSeverity(r.Problems, score.SeverityError), countSeverity(r.Problems, score.SeverityWarning)
+	fmt.Printf("\n  %s\n\n", tui.TitleStyle.Render("Validating "+r.File))
+	for _, p := range r.Problems {
+		pos := "-"
+		if p.Line > 0 {
+			pos = fmt.Sprintf("%d:%d", p.Line, p.Column)
+		}
+		pos = fmt.Sprintf("%-7s", pos)
+		switch p.Severity {
+		case score.SeverityError:
+			fmt.Printf("  %s %s %s\n", tui.ErrorStyle.Render(tui.IconCross), tui.DimStyle.Render(pos), p.Describe())
+		case score.SeverityWarning:
+			fmt.Printf("  %s %s %s\n", tui.WarningStyle.Render(tui.IconWarn), tui.DimStyle.Render(pos), p.Describe())
+		default:
+			fmt.Printf("  %s %s %s\n", tui.DimStyle.Render(tui.IconBullet), tui.DimStyle.Render(pos), tui.DimStyle.Render(p.Describe()))
+		}
+	}
+	if len(r.Problems) > 0 {
+		fmt.Println()
+	}
+	target := fmt.Sprintf("cluster=%s ns=%s", r.Cluster, r.Namespace)
+	if len(r.workloads) > 1 {
+		target = fmt.Sprintf("%d workloads", len(r.workloads))
+	}
+	switch {
+	case errs > 0:
+		fmt.Printf("  %s %d error(s), %d warning(s)\n", tui.ErrorStyle.Render(tui.IconCross), errs, warns)
+	case warns > 0:
+		fmt.Printf("  %s valid with %d warning(s) (%s)\n", tui.WarningStyle.Render(tui.IconWarn), warns, target)
+	default:
+		fmt.Printf("  %s valid (%s)\n", tui.SuccessStyle.Render(tui.IconCheck), target)
+	}
+}
+
+func countSeverity(problems []score.Problem, sev score.Severity) int {
+	n := 0
+	for _, p := range problems {
+		if p.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// isTerminal reports whether f is a terminal, where clearing the screen
+// makes sense.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// constraintsStep checks the workload against the platform constraints
+// before it is translated, keeping the problems found in problems. It
+// fails when one is an error.
+func constraintsStep(workload func() *score.Workload, cluster string, problems *[]score.Problem) tui.Step {
+	return tui.Step{
+		Title: "Checking platform constraints",
+		Run: func() (string, error) {
+			*problems = deploylib.Validate(workload(), cluster)
+			if err := deploylib.CheckConstraints(*problems); err != nil {
+				return "", err
+			}
+			if n := countSeverity(*problems, score.SeverityWarning); n > 0 {
+				return fmt.Sprintf("%d warning(s)", n), nil
+			}
+			return "ok", nil
+		},
+	}
+}
+
+// printProblems lists the problems found in a workload by field.
+func printProblems(problems []score.Problem) {
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, p := range problems {
+		switch p.Severity {
+		case score.SeverityError:
+			fmt.Printf("  %s %s\n", tui.ErrorStyle.Render(tui.IconCross), p.Describe())
+		case score.SeverityWarning:
+			fmt.Printf("  %s %s\n", tui.WarningStyle.Render(tui.IconWarn), p.Describe())
+		default:
+			fmt.Printf("  %s %s\n", tui.DimStyle.Render(tui.IconBullet), tui.DimStyle.Render(p.Describe()))
+		}
+	}
+}
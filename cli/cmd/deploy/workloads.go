@@ -0,0 +1,150 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// workloadListItem is one deployed workload as emitted by 'hctl deploy list'
+// and 'hctl deploy status --all' with -o json, and addressed by --columns.
+// ArgoCD and Pods are nil when live status was not gathered or the
+// Application was not found.
+type workloadListItem struct {
+	Name      string               `json:"name" yaml:"name"`
+	Cluster   string               `json:"cluster" yaml:"cluster"`
+	Namespace string               `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ArgoCD    *platform.ArgoCDInfo `json:"argocd,omitempty" yaml:"argocd,omitempty"`
+	Pods      *platform.PodInfo    `json:"pods,omitempty" yaml:"pods,omitempty"`
+	Age       string               `json:"age,omitempty" yaml:"age,omitempty"`
+}
+
+// listWorkloadItems returns the workloads enabled for cluster in the repo.
+// With a client, each item is filled in with its ArgoCD Application and pod
+// readiness.
+func listWorkloadItems(client *kube.Client, repoPath, cluster string) ([]workloadListItem, error) {
+	names, err := deploylib.ListWorkloads(repoPath, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("reading workloads: %w", err)
+	}
+
+	items := []workloadListItem{}
+	for _, name := range names {
+		item := workloadListItem{Name: name, Cluster: cluster}
+		if client != nil {
+			fillWorkloadStatus(client, &item)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// fillWorkloadStatus looks up the workload's ArgoCD Application and counts
+// its ready pods, finding both by their ownership labels (see FindApp).
+func fillWorkloadStatus(client *kube.Client, item *workloadListItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	app, err := deploylib.FindApp(ctx, client, item.Name, item.Cluster)
+	if err == nil {
+		argo := platform.ArgoCDInfoFromApp(app)
+		item.ArgoCD = &argo
+		item.Namespace, _, _ = platform.UnstructuredNestedString(app.Object, "spec", "destination", "namespace")
+		item.Age = tui.FormatAge(app.GetCreationTimestamp().Time)
+	}
+
+	pods, err := deploylib.FindPods(ctx, client, item.Cluster, item.Name, item.Cluster)
+	if err == nil {
+		info := &platform.PodInfo{Total: len(pods)}
+		for _, p := range pods {
+			if p.Phase == "Running" && p.ReadyContainers == p.TotalContainers {
+				info.Ready++
+			}
+		}
+		item.Pods = info
+	}
+}
+
+// workloadStatus is the status of one workload as emitted by 'hctl deploy
+// status' with -o json.
+type workloadStatus struct {
+	Name     string      `json:"name" yaml:"name"`
+	Cluster  string      `json:"cluster" yaml:"cluster"`
+	Sync     string      `json:"sync" yaml:"sync"`
+	Health   string      `json:"health" yaml:"health"`
+	Revision string      `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Pods     []podStatus `json:"pods" yaml:"pods"`
+}
+
+// podStatus is one pod of a workloadStatus.
+type podStatus struct {
+	Name     string `json:"name" yaml:"name"`
+	Phase    string `json:"phase" yaml:"phase"`
+	Ready    int    `json:"ready" yaml:"ready"`
+	Total    int    `json:"total" yaml:"total"`
+	Restarts int    `json:"restarts" yaml:"restarts"`
+	Waiting  string `json:"waiting,omitempty" yaml:"waiting,omitempty"`
+}
+
+// getWorkloadStatus reads the sync, health and revision of the workload's
+// ArgoCD Application and the readiness of its pods, finding both by their
+// ownership labels (see FindApp).
+func getWorkloadStatus(ctx context.Context, client *kube.Client, name, cluster string) (*workloadStatus, error) {
+	app, err := deploylib.FindApp(ctx, client, name, cluster)
+	if err != nil {
+		return nil, kube.ClassifyError(err)
+	}
+	st := &workloadStatus{Name: name, Cluster: cluster, Pods: []podStatus{}}
+	st.Sync, _, _ = platform.UnstructuredNestedString(app.Object, "status", "sync", "status")
+	st.Health, _, _ = platform.UnstructuredNestedString(app.Object, "status", "health", "status")
+	st.Revision, _, _ = platform.UnstructuredNestedString(app.Object, "status", "sync", "revision")
+
+	pods, err := deploylib.FindPods(ctx, client, cluster, name, cluster)
+	if err == nil {
+		for _, p := range pods {
+			st.Pods = append(st.Pods, podStatus{
+				Name:     p.Name,
+				Phase:    p.Phase,
+				Ready:    p.ReadyContainers,
+				Total:    p.TotalContainers,
+				Restarts: p.Restarts,
+				Waiting:  p.Waiting,
+			})
+		}
+	}
+	return st, nil
+}
+
+// workloadStatusCell renders the sync/health of a workload for a table.
+func workloadStatusCell(item workloadListItem) string {
+	if item.ArgoCD == nil {
+		return tui.DimStyle.Render("not found")
+	}
+	s := item.ArgoCD.SyncStatus + "/" + item.ArgoCD.HealthStatus
+	if item.ArgoCD.SyncStatus == "Synced" && item.ArgoCD.HealthStatus == "Healthy" {
+		return tui.SuccessStyle.Render(s)
+	}
+	return tui.WarningStyle.Render(s)
+}
+
+// workloadWideCells returns the extra -o wide cells: namespace, revision,
+// age, and last sync message.
+func workloadWideCells(item workloadListItem) []string {
+	var revision, message string
+	if item.ArgoCD != nil {
+		revision, message = tui.ShortRevision(item.ArgoCD.Revision), item.ArgoCD.Message
+	}
+	return []string{tui.OrDash(item.Namespace), tui.OrDash(revision), tui.OrDash(item.Age), tui.OrDash(message)}
+}
+
+func podsCell(item workloadListItem) string {
+	if item.Pods == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%d/%d", item.Pods.Ready, item.Pods.Total)
+}
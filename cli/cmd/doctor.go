@@ -2,19 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
 	"github.com/jamesatintegratnio/hctl/internal/git"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+var doctorFix bool
+
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check platform prerequisites and connectivity",
@@ -27,14 +36,50 @@ Checks include:
   - ArgoCD is accessible
   - Git repository is detected and clean
   - Platform namespace exists
-  - Kratix CRDs are installed`,
+  - Kratix CRDs are installed
+  - The status reconciler's ServiceAccount can read what it reconciles
+  - vcluster target namespaces carry the platform labels, including those
+    the ClusterSecretStores select namespaces by
+
+--fix adds missing namespace labels. RBAC is never changed: a missing
+rule is reported with a kubectl patch to apply, and belongs in the
+reconciler's ClusterRole in the repo. With --dry-run, --fix shows the
+labels it would add.`,
+	Example: `  hctl doctor
+  hctl doctor --fix
+  hctl doctor --fix --dry-run`,
 	RunE: runDoctor,
 }
 
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "apply the fixes doctor knows (missing namespace labels)")
+}
+
 // Check represents a single doctor check.
 type Check struct {
 	Name string
 	Run  func(cfg *config.Config) (string, error)
+	// Fix, when set, plans the changes that make a failing check pass.
+	// --fix runs it and then repeats the check.
+	Fix func(cfg *config.Config, mp *mutation.Plan) error
+}
+
+// checkWarning is a check error that is reported as a warning rather than
+// a failure.
+type checkWarning struct{ msg string }
+
+func (w *checkWarning) Error() string { return w.msg }
+
+func warnf(format string, args ...interface{}) error {
+	return &checkWarning{msg: fmt.Sprintf(format, args...)}
+}
+
+// checkResult is the outcome of one check: "pass", "warn", "fail", or
+// "fixed".
+type checkResult struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -49,72 +94,131 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		{Name: "Platform namespace", Run: checkPlatformNamespace},
 		{Name: "ArgoCD", Run: checkArgoCD},
 		{Name: "Kratix CRDs", Run: checkKratixCRDs},
+		{Name: "Status reconciler RBAC", Run: checkReconcilerRBAC},
+		{Name: "vcluster namespace labels", Run: checkNamespaceLabels, Fix: fixNamespaceLabels},
+	}
+
+	// Under --dry-run, fixes are collected here and printed at the end.
+	var pending *mutation.Plan
+	if cfg.DryRun {
+		pending = mutation.New(cfg.RepoPath)
 	}
 
 	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("  hctl doctor"))
 
-	passCount := 0
-	warnCount := 0
-	failCount := 0
+	counts := map[string]int{}
+	results := make([]checkResult, 0, len(checks))
+	for _, c := range checks {
+		r := runCheck(cfg, c, pending)
+		counts[r.Status]++
+		results = append(results, r)
+	}
 
 	// Structured output
 	if tui.IsStructured() {
-		type result struct {
-			Check   string `json:"check"`
-			Status  string `json:"status"`
-			Detail  string `json:"detail,omitempty"`
-		}
-		var results []result
-
-		for _, c := range checks {
-			detail, err := c.Run(cfg)
-			if err != nil {
-				results = append(results, result{Check: c.Name, Status: "fail", Detail: err.Error()})
-				failCount++
-			} else {
-				results = append(results, result{Check: c.Name, Status: "pass", Detail: detail})
-				passCount++
-			}
-		}
-		return tui.RenderOutput(map[string]interface{}{
+		out := map[string]interface{}{
 			"checks": results,
 			"summary": map[string]int{
-				"pass": passCount,
-				"warn": warnCount,
-				"fail": failCount,
+				"pass":  counts["pass"],
+				"warn":  counts["warn"],
+				"fail":  counts["fail"],
+				"fixed": counts["fixed"],
 			},
-		}, "")
-	}
-
-	for _, c := range checks {
-		detail, err := c.Run(cfg)
-		if err != nil {
-			fmt.Printf("  %s %s\n", tui.ErrorStyle.Render(tui.IconCross), c.Name)
-			fmt.Printf("    %s\n", tui.DimStyle.Render(err.Error()))
-			failCount++
-		} else {
-			fmt.Printf("  %s %s", tui.SuccessStyle.Render(tui.IconCheck), c.Name)
-			if detail != "" {
-				fmt.Printf("  %s", tui.DimStyle.Render(detail))
+		}
+		if pending != nil && !pending.Empty() {
+			out["plan"] = pending.Summary()
+		}
+		if err := tui.RenderOutput(out, ""); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			icon := tui.SuccessStyle.Render(tui.IconCheck)
+			switch r.Status {
+			case "warn":
+				icon = tui.WarningStyle.Render(tui.IconWarn)
+			case "fail":
+				icon = tui.ErrorStyle.Render(tui.IconCross)
+			}
+			fmt.Printf("  %s %s", icon, r.Check)
+			if r.Status == "pass" || r.Status == "fixed" {
+				if r.Detail != "" {
+					fmt.Printf("  %s", tui.DimStyle.Render(r.Detail))
+				}
+				fmt.Println()
+				continue
 			}
 			fmt.Println()
-			passCount++
+			for _, line := range strings.Split(r.Detail, "\n") {
+				fmt.Printf("    %s\n", tui.DimStyle.Render(line))
+			}
 		}
-	}
 
-	fmt.Printf("\n  %s %d passed", tui.SuccessStyle.Render(tui.IconCheck), passCount)
-	if failCount > 0 {
-		fmt.Printf("  %s %d failed", tui.ErrorStyle.Render(tui.IconCross), failCount)
+		fmt.Printf("\n  %s %d passed", tui.SuccessStyle.Render(tui.IconCheck), counts["pass"])
+		if counts["fixed"] > 0 {
+			fmt.Printf("  %s %d fixed", tui.SuccessStyle.Render(tui.IconCheck), counts["fixed"])
+		}
+		if counts["warn"] > 0 {
+			fmt.Printf("  %s %d warning(s)", tui.WarningStyle.Render(tui.IconWarn), counts["warn"])
+		}
+		if counts["fail"] > 0 {
+			fmt.Printf("  %s %d failed", tui.ErrorStyle.Render(tui.IconCross), counts["fail"])
+		}
+		fmt.Println()
+		fmt.Println()
+
+		if pending != nil && !pending.Empty() {
+			return pending.DryRun()
+		}
 	}
-	fmt.Println()
-	fmt.Println()
 
-	if failCount > 0 {
-		return fmt.Errorf("%d check(s) failed", failCount)
+	if pending != nil && !pending.Empty() {
+		return mutation.ChangesPending(len(pending.Actions))
+	}
+	if counts["fail"] > 0 {
+		return fmt.Errorf("%d check(s) failed", counts["fail"])
 	}
 	return nil
 }
 
+// runCheck runs c and, with --fix, its fix when it fails. Under --dry-run
+// the fix is planned into pending and the check still reports its failure.
+func runCheck(cfg *config.Config, c Check, pending *mutation.Plan) checkResult {
+	detail, err := c.Run(cfg)
+	if err == nil {
+		return checkResult{Check: c.Name, Status: "pass", Detail: detail}
+	}
+	var warning *checkWarning
+	if errors.As(err, &warning) {
+		return checkResult{Check: c.Name, Status: "warn", Detail: err.Error()}
+	}
+	failed := checkResult{Check: c.Name, Status: "fail", Detail: err.Error()}
+	if !doctorFix || c.Fix == nil {
+		return failed
+	}
+
+	mp := pending
+	if mp == nil {
+		mp = mutation.New(cfg.RepoPath)
+	}
+	if ferr := c.Fix(cfg, mp); ferr != nil {
+		failed.Detail += "\ncannot fix: " + ferr.Error()
+		return failed
+	}
+	if pending != nil {
+		return failed
+	}
+	if ferr := mp.Execute(); ferr != nil {
+		failed.Detail += "\nfix failed: " + ferr.Error()
+		return failed
+	}
+	if detail, err = c.Run(cfg); err != nil {
+		failed.Detail = err.Error()
+		return failed
+	}
+	return checkResult{Check: c.Name, Status: "fixed", Detail: detail}
+}
+
 func checkConfigFile(cfg *config.Config) (string, error) {
 	path := config.ConfigPath()
 	if _, err := os.Stat(path); err != nil {
@@ -230,5 +334,108 @@ func checkKratixCRDs(cfg *config.Config) (string, error) {
 	return "VClusterOrchestratorV2 available", nil
 }
 
-func metav1Options() metav1.GetOptions     { return metav1.GetOptions{} }
+// reconcilerClusterRolePath is where the status reconciler's ClusterRole
+// lives in the repo.
+var reconcilerClusterRolePath = repopath.Join(layout.ClusterRoleAddonsDir("control-plane"), "platform-status-reconciler", "clusterrole.yaml")
+
+func checkReconcilerRBAC(cfg *config.Config) (string, error) {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results, err := client.ServiceAccountAccess(ctx, platform.StatusReconcilerNamespace, platform.StatusReconcilerServiceAccount, platform.StatusReconcilerAccess)
+	if err != nil {
+		return "", fmt.Errorf("cannot review access: %w", err)
+	}
+	var denied []kube.AccessRule
+	var notInstalled []string
+	for _, r := range results {
+		switch r.Status {
+		case kube.AccessDenied:
+			denied = append(denied, r.Rule)
+		case kube.AccessNotInstalled:
+			notInstalled = append(notInstalled, r.Rule.String())
+		}
+	}
+	sa := platform.StatusReconcilerNamespace + "/" + platform.StatusReconcilerServiceAccount
+	if len(denied) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "ServiceAccount %s cannot:", sa)
+		for _, r := range denied {
+			fmt.Fprintf(&b, "\n  - %s", r)
+		}
+		fmt.Fprintf(&b, "\nadd the rules to %s, or patch the live ClusterRole:", reconcilerClusterRolePath)
+		fmt.Fprintf(&b, "\n  %s", kube.ClusterRolePatch(platform.StatusReconcilerClusterRole, denied))
+		return "", errors.New(b.String())
+	}
+	if len(notInstalled) > 0 {
+		return "", warnf("%s has its other %d permission(s), but these resources are not installed: %s",
+			sa, len(results)-len(notInstalled), strings.Join(notInstalled, ", "))
+	}
+	return fmt.Sprintf("%s has all %d permission(s)", sa, len(results)), nil
+}
+
+func checkNamespaceLabels(cfg *config.Config) (string, error) {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	issues, err := platform.CheckNamespaceLabels(ctx, client, cfg.Platform.PlatformNamespace)
+	if err != nil {
+		return "", err
+	}
+	if len(issues) == 0 {
+		return "all vcluster namespaces labeled", nil
+	}
+	lines := make([]string, 0, len(issues)+1)
+	fixable := false
+	for _, issue := range issues {
+		if issue.NotFound {
+			lines = append(lines, fmt.Sprintf("%s (vcluster %s): namespace not found", issue.Namespace, issue.VCluster))
+			continue
+		}
+		fixable = true
+		lines = append(lines, fmt.Sprintf("%s (vcluster %s): missing %s", issue.Namespace, issue.VCluster, labels.Set(issue.Missing)))
+	}
+	if fixable && !doctorFix {
+		lines = append(lines, "run 'hctl doctor --fix' to add the missing labels")
+	}
+	return "", errors.New(strings.Join(lines, "\n"))
+}
+
+// fixNamespaceLabels plans adding each vcluster namespace's missing labels.
+// Namespaces that do not exist are left to the promise to create.
+func fixNamespaceLabels(cfg *config.Config, mp *mutation.Plan) error {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	issues, err := platform.CheckNamespaceLabels(ctx, client, cfg.Platform.PlatformNamespace)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if issue.NotFound {
+			continue
+		}
+		ns, missing := issue.Namespace, issue.Missing
+		mp.Cluster(fmt.Sprintf("label namespace %s %s", ns, labels.Set(missing)), func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return client.LabelNamespace(ctx, ns, missing)
+		})
+	}
+	return nil
+}
+
+func metav1Options() metav1.GetOptions      { return metav1.GetOptions{} }
 func metav1ListOptions() metav1.ListOptions { return metav1.ListOptions{} }
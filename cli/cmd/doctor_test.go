@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// doctorCluster seeds a vcluster "media" whose target namespace has none of
+// the platform labels, one whose namespace does not exist yet, and a
+// ClusterSecretStore that only serves namespaces labeled for it.
+func doctorCluster(t *testing.T) *testutil.Cluster {
+	t.Helper()
+	_, cluster := newE2E(t)
+	vcluster := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "platform.integratn.tech/v1alpha1",
+			"kind":       "VClusterOrchestratorV2",
+			"metadata":   map[string]interface{}{"name": name, "namespace": config.Default().Platform.PlatformNamespace},
+			"spec":       map[string]interface{}{"name": name, "targetNamespace": name},
+		}}
+	}
+	cluster.Apply(
+		vcluster("media"),
+		vcluster("pending"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "media", Labels: map[string]string{"team": "media"}}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "external-secrets.io/v1beta1",
+			"kind":       "ClusterSecretStore",
+			"metadata": map[string]interface{}{
+				"name":   "onepassword-store",
+				"labels": map[string]interface{}{"integratn.tech/cluster-secret-store": "onepassword-store"},
+			},
+			"spec": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{
+					"namespaceSelector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{"integratn.tech/secrets": "onepassword"},
+					},
+				}},
+			},
+		}},
+	)
+	return cluster
+}
+
+func namespaceLabels(t *testing.T, cluster *testutil.Cluster, name string) map[string]string {
+	t.Helper()
+	ns, err := cluster.Client.Clientset.CoreV1().Namespaces().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns.Labels
+}
+
+func TestE2EDoctorNamespaceLabels(t *testing.T) {
+	cluster := doctorCluster(t)
+
+	res := testutil.Run(t, rootCmd, "doctor")
+	for _, want := range []string{
+		"media (vcluster media): missing integratn.tech/secrets=onepassword,platform.integratn.tech/type=vcluster,vcluster.loft.sh/namespace=true",
+		"pending (vcluster pending): namespace not found",
+		"hctl doctor --fix",
+	} {
+		if !strings.Contains(res.Stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, res.Stdout)
+		}
+	}
+	if got := namespaceLabels(t, cluster, "media"); len(got) != 1 {
+		t.Errorf("doctor without --fix labeled the namespace: %v", got)
+	}
+
+	res = testutil.Run(t, rootCmd, "doctor", "--fix", "--dry-run")
+	if res.ExitCode != hcerrors.ExitChanges {
+		t.Errorf("--fix --dry-run exit %d, want %d (err: %v)", res.ExitCode, hcerrors.ExitChanges, res.Err)
+	}
+	if !strings.Contains(res.Stdout, "label namespace media integratn.tech/secrets=onepassword") {
+		t.Errorf("dry run does not show the fix:\n%s", res.Stdout)
+	}
+	if got := namespaceLabels(t, cluster, "media"); len(got) != 1 {
+		t.Errorf("--fix --dry-run labeled the namespace: %v", got)
+	}
+
+	testutil.Run(t, rootCmd, "doctor", "--fix")
+	got := namespaceLabels(t, cluster, "media")
+	for k, v := range map[string]string{
+		"team":                         "media",
+		"integratn.tech/secrets":       "onepassword",
+		"platform.integratn.tech/type": "vcluster",
+		"vcluster.loft.sh/namespace":   "true",
+	} {
+		if got[k] != v {
+			t.Errorf("after --fix, label %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestE2EDryRun runs each mutating command with --dry-run and checks that
+// it reports its changes, exits 10, and leaves the repo and cluster alone.
+func TestE2EDryRun(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup runs for real before the dry run, e.g. to deploy a workload
+		// the dry run then removes.
+		setup [][]string
+		args  []string
+		// want appears in the dry run's output.
+		want []string
+	}{
+		{
+			name: "deploy run",
+			args: []string{"deploy", "run", "-f", "{score}", "--skip-secret-check"},
+			want: []string{"+ workloads/vcluster-dev/addons/hello/values.yaml", "+ workloads/vcluster-dev/addons/hello/.hctl-manifest.yaml", "~ workloads/vcluster-dev/addons.yaml", "commit 3 files", "record the deploy commit"},
+		},
+		{
+			name:  "deploy remove",
+			setup: [][]string{{"deploy", "run", "-f", "{score}", "--skip-secret-check"}},
+			args:  []string{"deploy", "remove", "hello", "--cluster", "vcluster-dev"},
+			want:  []string{"- workloads/vcluster-dev/addons/hello/values.yaml", "~ workloads/vcluster-dev/addons.yaml", `"hctl: remove hello (vcluster-dev)"`},
+		},
+		{
+			name:  "deploy remove --purge",
+			setup: [][]string{{"deploy", "run", "-f", "{score}", "--skip-secret-check"}},
+			args:  []string{"deploy", "remove", "hello", "--cluster", "vcluster-dev", "--purge"},
+			want:  []string{"Deployment.apps vcluster-dev/hello", "delete the 2 object(s) in workloads/vcluster-dev/addons/hello/.hctl-manifest.yaml from vCluster vcluster-dev"},
+		},
+		{
+			name: "vcluster create",
+			args: []string{"vcluster", "create", "dev2", "--wait=false"},
+			want: []string{"+ platform/vclusters/dev2.yaml", "create vcluster"},
+		},
+		{
+			name: "vcluster delete",
+			args: []string{"vcluster", "delete", "vcluster-dev"},
+			want: []string{"- platform/vclusters/vcluster-dev.yaml", "delete vcluster"},
+		},
+		{
+			name: "addon enable",
+			args: []string{"addon", "enable", "grafana", "--namespace", "monitoring"},
+			want: []string{"+ addons/environments/production/addons/grafana/values.yaml", "~ addons/environments/production/addons/addons.yaml"},
+		},
+		{
+			name: "reconcile",
+			args: []string{"reconcile", "vcluster-dev"},
+			want: []string{"Cluster:", "set kratix.io/manual-reconciliation=true on vcluster-dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cluster := newE2E(t)
+			score := writeE2EScore(t)
+			expand := func(args []string) []string {
+				out := make([]string, len(args))
+				for i, a := range args {
+					out[i] = strings.ReplaceAll(a, "{score}", score)
+				}
+				return out
+			}
+			for _, args := range tt.setup {
+				testutil.MustRun(t, rootCmd, expand(args)...)
+			}
+			subjects := repo.Subjects()
+			before := len(cluster.Mutations())
+
+			res := testutil.Run(t, rootCmd, append(expand(tt.args), "--dry-run")...)
+			if res.Category != hcerrors.ErrChangesPending || res.ExitCode != hcerrors.ExitChanges {
+				t.Fatalf("category = %q, exit %d; want %q, exit %d (err: %v)\n%s",
+					res.Category, res.ExitCode, hcerrors.ErrChangesPending, hcerrors.ExitChanges, res.Err, res.Stdout)
+			}
+			if strings.Contains(res.Stderr, "changes pending") {
+				t.Errorf("dry run printed its exit status as an error:\n%s", res.Stderr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(res.Stdout, want) {
+					t.Errorf("output missing %q:\n%s", want, res.Stdout)
+				}
+			}
+			if dirty := repo.Dirty(); len(dirty) > 0 {
+				t.Errorf("dry run changed files: %v", dirty)
+			}
+			if got := repo.Subjects(); len(got) != len(subjects) {
+				t.Errorf("dry run committed: %v", got[:len(got)-len(subjects)])
+			}
+			if got := cluster.Mutations()[before:]; len(got) > 0 {
+				t.Errorf("dry run changed the cluster: %v", got)
+			}
+		})
+	}
+}
+
+func TestE2EDryRunNoChanges(t *testing.T) {
+	repo, _ := newE2E(t)
+	score := writeE2EScore(t)
+	testutil.MustRun(t, rootCmd, "deploy", "run", "-f", score, "--skip-secret-check")
+	subjects := repo.Subjects()
+
+	res := testutil.MustRun(t, rootCmd, "deploy", "run", "-f", score, "--skip-secret-check", "--dry-run")
+	if !strings.Contains(res.Stdout, "Dry run: no changes") {
+		t.Errorf("redeploying an unchanged workload reports changes:\n%s", res.Stdout)
+	}
+	if got := repo.Subjects(); len(got) != len(subjects) {
+		t.Errorf("dry run committed: %v", got[:len(got)-len(subjects)])
+	}
+}
+
+// TestE2EDryRunUsageError checks that a bad flag on a command reporting
+// changes exits as a usage error, so scripts do not read it as changes.
+func TestE2EDryRunUsageError(t *testing.T) {
+	newE2E(t)
+	score := writeE2EScore(t)
+	for _, args := range [][]string{
+		{"deploy", "diff", "-f", score, "--no-such-flag"},
+		{"deploy", "run", "-f", score, "--dry-run", "--no-such-flag"},
+	} {
+		res := testutil.Run(t, rootCmd, args...)
+		if res.ExitCode == hcerrors.ExitChanges || res.ExitCode != hcerrors.ExitUserError {
+			t.Errorf("%s: exit %d, want %d (err: %v)", strings.Join(args[:2], " "), res.ExitCode, hcerrors.ExitUserError, res.Err)
+		}
+	}
+}
+
+func TestE2EDryRunInit(t *testing.T) {
+	testutil.Isolate(t)
+	repo := testutil.NewRepo(t, testutil.RepoOptions{})
+	testutil.NewCluster(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}).Use()
+	t.Chdir(repo.Root)
+
+	res := testutil.Run(t, rootCmd, "init", "--dry-run")
+	if res.ExitCode != hcerrors.ExitChanges {
+		t.Fatalf("exit %d, want %d (err: %v)", res.ExitCode, hcerrors.ExitChanges, res.Err)
+	}
+	if !strings.Contains(res.Stdout, config.ConfigPath()) {
+		t.Errorf("output does not name the config file:\n%s", res.Stdout)
+	}
+	if _, err := os.Stat(config.ConfigPath()); !os.IsNotExist(err) {
+		t.Errorf("init --dry-run wrote %s", config.ConfigPath())
+	}
+}
+
+func TestE2EDryRunQuickstart(t *testing.T) {
+	newE2E(t)
+
+	res := testutil.Run(t, rootCmd, "quickstart", "--dry-run")
+	if res.Category != hcerrors.ErrUsage {
+		t.Errorf("category = %q, want %q (err: %v)", res.Category, hcerrors.ErrUsage, res.Err)
+	}
+}
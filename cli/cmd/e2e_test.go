@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// e2eScore is a minimal web workload for the deploy journeys.
+const e2eScore = `apiVersion: score.dev/v1b1
+metadata:
+  name: hello
+  annotations:
+    hctl.integratn.tech/cluster: vcluster-dev
+containers:
+  web:
+    image: ghcr.io/example/hello:1.2.3
+    variables:
+      GREETING: hi
+service:
+  ports:
+    http:
+      port: 8080
+`
+
+// newE2E isolates the test and sets up a fixture repo with one vCluster,
+// a fake (or envtest) cluster with a node, and a config pointing at both.
+func newE2E(t *testing.T) (*testutil.Repo, *testutil.Cluster) {
+	t.Helper()
+	testutil.Isolate(t)
+	repo := testutil.NewRepo(t, testutil.RepoOptions{
+		Clusters: []string{"vcluster-dev"},
+		Addons:   []testutil.Addon{{Name: "argocd", Namespace: "argocd", Chart: "argo-cd", Version: "9.4.3"}},
+	})
+	cluster := testutil.NewCluster(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	cluster.Use()
+	testutil.WriteConfig(t, testutil.Config(repo))
+	return repo, cluster
+}
+
+func writeE2EScore(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "score.yaml")
+	if err := os.WriteFile(path, []byte(e2eScore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestE2EInit(t *testing.T) {
+	testutil.Isolate(t)
+	repo := testutil.NewRepo(t, testutil.RepoOptions{})
+	testutil.NewCluster(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}).Use()
+	t.Chdir(repo.Root)
+
+	res := testutil.MustRun(t, rootCmd, "init")
+	if !strings.Contains(res.Stdout, "Checking cluster access") {
+		t.Errorf("init output missing cluster check:\n%s", res.Stdout)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("init did not write a config: %v", err)
+	}
+	wantRoot, _ := filepath.EvalSymlinks(repo.Root)
+	gotRoot, _ := filepath.EvalSymlinks(cfg.RepoPath)
+	if gotRoot != wantRoot {
+		t.Errorf("repoPath = %q, want %q", cfg.RepoPath, repo.Root)
+	}
+}
+
+func TestE2EVClusterCreate(t *testing.T) {
+	repo, cluster := newE2E(t)
+
+	testutil.MustRun(t, rootCmd, "vcluster", "create", "dev2", "--wait=false", "--replicas", "2")
+
+	rel := "platform/vclusters/dev2.yaml"
+	req := repo.ReadYAML(rel)
+	if req["kind"] != "VClusterOrchestratorV2" {
+		t.Errorf("kind = %v, want VClusterOrchestratorV2", req["kind"])
+	}
+	spec, _ := req["spec"].(map[string]interface{})
+	vc, _ := spec["vcluster"].(map[string]interface{})
+	if vc["replicas"] != 2 || vc["preset"] != "dev" {
+		t.Errorf("spec.vcluster = %v, want preset dev with 2 replicas", vc)
+	}
+	if got := repo.Subjects()[0]; !strings.Contains(got, "create vcluster") || !strings.Contains(got, "dev2") {
+		t.Errorf("last commit = %q, want the vcluster request", got)
+	}
+	if dirty := repo.Dirty(); len(dirty) > 0 {
+		t.Errorf("uncommitted files after create: %v", dirty)
+	}
+
+	// Stand in for ArgoCD applying the request, then read it back.
+	cluster.ApplyYAML(repo.ReadFile(rel))
+	obj := cluster.Get(kube.VClusterOrchestratorV2GVR, "platform-requests", "dev2")
+	if obj.GetName() != "dev2" {
+		t.Errorf("created object name = %q", obj.GetName())
+	}
+	res := testutil.MustRun(t, rootCmd, "vcluster", "list", "-o", "json")
+	if !strings.Contains(res.Stdout, `"name": "dev2"`) {
+		t.Errorf("vcluster list does not show dev2:\n%s", res.Stdout)
+	}
+}
+
+func TestE2EVClusterCreateExisting(t *testing.T) {
+	newE2E(t)
+
+	res := testutil.Run(t, rootCmd, "vcluster", "create", "vcluster-dev", "--wait=false")
+	if res.Category != hcerrors.ErrUsage {
+		t.Errorf("category = %q, want %q (err: %v)", res.Category, hcerrors.ErrUsage, res.Err)
+	}
+}
+
+func TestE2EVClusterCreateWorkloadRepo(t *testing.T) {
+	repo, _ := newE2E(t)
+	// The fixture repo, with workloads/vcluster-dev on main, doubles as the
+	// workload repo.
+	remote := filepath.Join(t.TempDir(), "workloads.git")
+	repo.Git("clone", "-q", "--bare", repo.Root, remote)
+	url := "file://" + remote
+
+	res := testutil.Run(t, rootCmd, "vcluster", "create", "team-api", "--wait=false",
+		"--workload-repo-url", url, "--workload-repo-path", "deploy/k8s")
+	if res.Category != hcerrors.ErrNotFound || !strings.Contains(res.Err.Error(), "path does not exist") {
+		t.Errorf("missing path: category = %q, want %q (err: %v)", res.Category, hcerrors.ErrNotFound, res.Err)
+	}
+	res = testutil.Run(t, rootCmd, "vcluster", "create", "team-api", "--wait=false",
+		"--workload-repo-url", url, "--workload-repo-revision", "develop")
+	if res.Category != hcerrors.ErrNotFound || !strings.Contains(res.Err.Error(), "revision not found") {
+		t.Errorf("missing revision: category = %q, want %q (err: %v)", res.Category, hcerrors.ErrNotFound, res.Err)
+	}
+	res = testutil.Run(t, rootCmd, "vcluster", "create", "team-api", "--wait=false",
+		"--workload-repo-url", "file://"+filepath.Join(t.TempDir(), "missing.git"))
+	if res.Category != hcerrors.ErrGit {
+		t.Errorf("unreachable repo: category = %q, want %q (err: %v)", res.Category, hcerrors.ErrGit, res.Err)
+	}
+	if repo.Exists("platform/vclusters/team-api.yaml") {
+		t.Fatal("a failed workload repo check wrote the request")
+	}
+
+	testutil.MustRun(t, rootCmd, "vcluster", "create", "team-api", "--wait=false",
+		"--workload-repo-url", url, "--workload-repo-path", "workloads/vcluster-dev")
+	spec, _ := repo.ReadYAML("platform/vclusters/team-api.yaml")["spec"].(map[string]interface{})
+	integrations, _ := spec["integrations"].(map[string]interface{})
+	argocd, _ := integrations["argocd"].(map[string]interface{})
+	if wr, _ := argocd["workloadRepo"].(map[string]interface{}); wr["url"] != url {
+		t.Errorf("spec.integrations.argocd.workloadRepo = %v", wr)
+	}
+
+	testutil.MustRun(t, rootCmd, "vcluster", "create", "team-web", "--wait=false", "--offline",
+		"--workload-repo-url", url, "--workload-repo-path", "not-pushed-yet")
+}
+
+func TestE2EDeployRunDiffRemove(t *testing.T) {
+	repo, _ := newE2E(t)
+	score := writeE2EScore(t)
+
+	testutil.MustRun(t, rootCmd, "deploy", "run", "-f", score, "--skip-secret-check")
+
+	values := repo.ReadYAML("workloads/vcluster-dev/addons/hello/values.yaml")
+	deployment, _ := values["deployment"].(map[string]interface{})
+	image, _ := deployment["image"].(map[string]interface{})
+	if image["repository"] != "ghcr.io/example/hello" || image["tag"] != "1.2.3" {
+		t.Errorf("deployment.image = %v", image)
+	}
+	addons := repo.ReadYAML("workloads/vcluster-dev/addons.yaml")
+	if _, ok := addons["hello"]; !ok {
+		t.Errorf("addons.yaml has no hello entry: %v", addons)
+	}
+	if dirty := repo.Dirty(); len(dirty) > 0 {
+		t.Errorf("uncommitted files after deploy run: %v", dirty)
+	}
+
+	res := testutil.MustRun(t, rootCmd, "deploy", "diff", "-f", score)
+	if !strings.Contains(res.Stdout, "No changes detected") {
+		t.Errorf("diff after run reports changes:\n%s", res.Stdout)
+	}
+
+	changed := strings.Replace(e2eScore, "1.2.3", "1.3.0", 1)
+	if err := os.WriteFile(score, []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = testutil.Run(t, rootCmd, "deploy", "diff", "-f", score)
+	if res.ExitCode != hcerrors.ExitChanges {
+		t.Errorf("diff with changes exited %d, want %d", res.ExitCode, hcerrors.ExitChanges)
+	}
+	if !strings.Contains(res.Stdout, "modified:") || !strings.Contains(res.Stdout, "1.3.0") {
+		t.Errorf("diff does not show the image change:\n%s", res.Stdout)
+	}
+
+	testutil.MustRun(t, rootCmd, "deploy", "remove", "hello", "--cluster", "vcluster-dev")
+	if repo.Exists("workloads/vcluster-dev/addons/hello") {
+		t.Error("workload directory still exists after remove")
+	}
+	addons = repo.ReadYAML("workloads/vcluster-dev/addons.yaml")
+	if _, ok := addons["hello"]; ok {
+		t.Error("addons.yaml still has the hello entry after remove")
+	}
+	if addons["globalSelectors"] == nil {
+		t.Error("remove dropped the addons.yaml header")
+	}
+	if dirty := repo.Dirty(); len(dirty) > 0 {
+		t.Errorf("uncommitted files after remove: %v", dirty)
+	}
+}
+
+func TestE2EDebugLogging(t *testing.T) {
+	repo, _ := newE2E(t)
+	cfg := testutil.Config(repo)
+	cfg.OnePassword.ConnectToken = "ops_sekrit"
+	testutil.WriteConfig(t, cfg)
+	score := writeE2EScore(t)
+
+	res := testutil.MustRun(t, rootCmd, "deploy", "run", "-f", score, "--skip-secret-check")
+	if strings.Contains(res.Stderr, "level=") {
+		t.Errorf("default run wrote log records:\n%s", res.Stderr)
+	}
+
+	t.Cleanup(func() { logging.Init(false, false) })
+	res = testutil.MustRun(t, rootCmd, "deploy", "remove", "hello", "--cluster", "vcluster-dev", "--debug")
+	for _, want := range []string{`msg="resolved config"`, "msg=git", `args="commit`, `msg="wrote file"`, "connectToken=[REDACTED]"} {
+		if !strings.Contains(res.Stderr, want) {
+			t.Errorf("--debug stderr missing %q:\n%s", want, res.Stderr)
+		}
+	}
+	if strings.Contains(res.Stderr, "ops_sekrit") {
+		t.Error("--debug logged the 1Password token")
+	}
+	if strings.Contains(res.Stdout, "level=DEBUG") {
+		t.Errorf("--debug wrote log records to stdout:\n%s", res.Stdout)
+	}
+}
+
+func TestE2EDeployRunMissingScore(t *testing.T) {
+	newE2E(t)
+
+	res := testutil.Run(t, rootCmd, "deploy", "run", "-f", filepath.Join(t.TempDir(), "score.yaml"), "--skip-secret-check")
+	if res.Category != hcerrors.ErrNotFound {
+		t.Errorf("category = %q, want %q (err: %v)", res.Category, hcerrors.ErrNotFound, res.Err)
+	}
+}
+
+func TestE2EAddonEnable(t *testing.T) {
+	repo, _ := newE2E(t)
+
+	testutil.MustRun(t, rootCmd, "addon", "enable", "grafana", "--namespace", "monitoring")
+
+	addons := repo.ReadYAML("addons/environments/production/addons/addons.yaml")
+	entry, ok := addons["grafana"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("addons.yaml has no grafana entry: %v", addons)
+	}
+	if entry["enabled"] != true || entry["namespace"] != "monitoring" {
+		t.Errorf("grafana entry = %v", entry)
+	}
+	if _, ok := addons["argocd"]; !ok {
+		t.Error("enable dropped the existing argocd entry")
+	}
+	if !repo.Exists("addons/environments/production/addons/grafana/values.yaml") {
+		t.Error("enable did not scaffold values.yaml")
+	}
+	if got := repo.Subjects()[0]; !strings.Contains(got, "grafana") {
+		t.Errorf("last commit = %q, want the addon change", got)
+	}
+}
+
+func TestE2EBulkApplySplitCommits(t *testing.T) {
+	testutil.Isolate(t)
+	files := map[string]string{}
+	for _, cluster := range []string{"vcluster-dev", "vcluster-media"} {
+		files["workloads/"+cluster+"/addons.yaml"] = "globalSelectors:\n  cluster_name: " + cluster +
+			"\nwhoami:\n  enabled: true\n  namespace: whoami\n  defaultVersion: 6.14.0\n"
+	}
+	repo := testutil.NewRepo(t, testutil.RepoOptions{Clusters: []string{"vcluster-dev", "vcluster-media"}, Files: files})
+	testutil.WriteConfig(t, testutil.Config(repo))
+	plan := filepath.Join(t.TempDir(), "bump-whoami.yaml")
+	if err := os.WriteFile(plan, []byte(`selector:
+  workloads: [whoami]
+edits:
+  - op: bump-chart-version
+    version: 6.15.0
+  - op: add-label
+    key: team
+    value: web
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := testutil.MustRun(t, rootCmd, "bulk", "plan", "-f", plan)
+	if !strings.Contains(res.Stdout, "4 files would change across 2 clusters (4 targets matched)") {
+		t.Errorf("plan output missing summary:\n%s", res.Stdout)
+	}
+	if len(repo.Dirty()) != 0 {
+		t.Fatalf("bulk plan wrote files: %v", repo.Dirty())
+	}
+
+	testutil.MustRun(t, rootCmd, "bulk", "apply", "-f", plan, "--split-commits")
+	subjects := repo.Subjects()
+	for i, cluster := range []string{"vcluster-media", "vcluster-dev"} {
+		if want := "hctl: bulk edit bump-whoami (2 files in " + cluster + ")"; subjects[i] != want {
+			t.Errorf("commit %d = %q, want %q", i, subjects[i], want)
+		}
+	}
+	entry, _ := repo.ReadYAML("workloads/vcluster-media/addons.yaml")["whoami"].(map[string]interface{})
+	if entry["defaultVersion"] != "6.15.0" {
+		t.Errorf("whoami entry = %v, want defaultVersion 6.15.0", entry)
+	}
+	if !strings.Contains(repo.ReadFile("platform/vclusters/vcluster-dev.yaml"), "team: web") {
+		t.Error("vcluster-dev has no team label")
+	}
+
+	res = testutil.MustRun(t, rootCmd, "bulk", "apply", "-f", plan)
+	if !strings.Contains(res.Stdout, "already applied") {
+		t.Errorf("second apply output:\n%s", res.Stdout)
+	}
+	if got := repo.Subjects(); len(got) != len(subjects) {
+		t.Errorf("second apply committed again: %v", got[:len(got)-len(subjects)])
+	}
+}
+
+func TestE2ERepoMigrate(t *testing.T) {
+	repo, _ := newE2E(t)
+	repo.WriteFile("workloads/vcluster-dev/addons/hello/values.yaml", "replicas: 1\n")
+
+	res := testutil.Run(t, rootCmd, "repo", "migrate", "--to", "v2")
+	if res.Category != hcerrors.ErrUsage || !strings.Contains(res.Err.Error(), "uncommitted changes") {
+		t.Fatalf("migrate on a dirty tree: %v, want a refusal", res.Err)
+	}
+	repo.Commit("add hello")
+
+	res = testutil.Run(t, rootCmd, "repo", "migrate", "--to", "v2", "--dry-run")
+	if res.ExitCode != hcerrors.ExitChanges {
+		t.Errorf("dry run exited %d, want %d", res.ExitCode, hcerrors.ExitChanges)
+	}
+	if len(repo.Dirty()) != 0 {
+		t.Fatalf("dry run wrote files: %v", repo.Dirty())
+	}
+
+	commits := len(repo.Subjects())
+	testutil.MustRun(t, rootCmd, "repo", "migrate", "--to", "v2")
+	subjects := repo.Subjects()
+	if len(subjects) != commits+1 || subjects[0] != "hctl: migrate repo layout to v2 (from v1)" {
+		t.Errorf("commits after the migration = %v", subjects[:len(subjects)-commits])
+	}
+	if len(repo.Dirty()) != 0 {
+		t.Errorf("migration left changes uncommitted: %v", repo.Dirty())
+	}
+	if !repo.Exists("addons/environments/production/workloads/vcluster-dev/addons/hello/values.yaml") {
+		t.Error("hello's values were not moved")
+	}
+
+	res = testutil.MustRun(t, rootCmd, "repo", "migrate", "--to", "v2")
+	if !strings.Contains(res.Stdout, "already at layout v2") {
+		t.Errorf("second migration output:\n%s", res.Stdout)
+	}
+}
+
+func TestE2EPlatformBootstrap(t *testing.T) {
+	testutil.Isolate(t)
+	repo := testutil.NewRepo(t, testutil.RepoOptions{Files: map[string]string{
+		"promises/db/promise.yaml":               "apiVersion: platform.kratix.io/v1alpha1\nkind: Promise\nmetadata:\n  name: db\nspec:\n  destinationSelectors: []\n",
+		layout.ReconcilerDir + "/namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: psr\n",
+		layout.ReconcilerDir + "/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: psr\n  namespace: psr\ndata:\n  interval: 30s\n",
+	}})
+	cluster := testutil.NewCluster(t)
+	cluster.Use()
+	testutil.WriteConfig(t, testutil.Config(repo))
+
+	res := testutil.Run(t, rootCmd, "platform", "bootstrap", "--diff")
+	if res.ExitCode != hcerrors.ExitChanges || !strings.Contains(res.Stdout, "+ new: Promise/db") || !strings.Contains(res.Stdout, "+ new: ConfigMap/psr/psr") {
+		t.Fatalf("diff against an empty cluster exited %d (%v):\n%s", res.ExitCode, res.Err, res.Stdout)
+	}
+	if m := cluster.Mutations(); len(m) != 0 {
+		t.Fatalf("--diff changed the cluster: %v", m)
+	}
+
+	testutil.MustRun(t, rootCmd, "platform", "bootstrap", "--wait=false")
+	cluster.Get(kube.KratixPromiseGVR, "", "db")
+
+	res = testutil.MustRun(t, rootCmd, "platform", "bootstrap", "--diff")
+	if !strings.Contains(res.Stdout, "No changes detected") {
+		t.Errorf("diff after bootstrapping:\n%s", res.Stdout)
+	}
+
+	res = testutil.Run(t, rootCmd, "platform", "bootstrap", "--timeout", "crds=1m")
+	if res.Err == nil || !strings.Contains(res.Err.Error(), `unknown component "crds"`) {
+		t.Errorf("--timeout for an unknown component: %v", res.Err)
+	}
+}
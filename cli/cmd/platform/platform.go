@@ -0,0 +1,233 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	platformlib "github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns the platform command group.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "platform",
+		Short: "Install and upgrade the platform itself",
+		Long: `Manage what the platform runs on: the Kratix promises and the
+platform-status-reconciler, installed from the repo into the current
+kube context.`,
+	}
+
+	cmd.AddCommand(newBootstrapCmd())
+
+	return cmd
+}
+
+func newBootstrapCmd() *cobra.Command {
+	var (
+		only     []string
+		diff     bool
+		wait     bool
+		timeouts []string
+	)
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Install or upgrade the promises and the reconciler from the repo",
+		Long: `Apply the repo's promises and the platform-status-reconciler to the
+current kube context with server-side apply.
+
+Promises come first, one at a time, each after the promises its
+spec.requiredPromises names; with --wait each must report Available before
+the next is applied. The reconciler follows, namespace and RBAC before its
+Deployment, which --wait waits to roll out.
+
+Upgrading is the same command: objects that already match the repo are left
+alone, so only what drifted is applied. --diff (or --dry-run) previews the
+changes with a server-side dry run and exits 10 when there are any.
+
+Each component's waits share one timeout, 10m for promises and 5m for the
+reconciler by default; override them with --timeout component=duration.`,
+		Example: `  hctl platform bootstrap
+  hctl platform bootstrap --diff
+  hctl platform bootstrap --only promises --timeout promises=20m
+  hctl platform bootstrap --only reconciler --wait=false`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			limits, err := parseTimeouts(timeouts)
+			if err != nil {
+				return err
+			}
+			components, err := platformlib.LoadBootstrap(cfg.RepoPath, only)
+			if err != nil {
+				return err
+			}
+			client, err := kube.NewClient(cfg.KubeContext)
+			if err != nil {
+				return fmt.Errorf("connecting to cluster: %w", err)
+			}
+			applier := &platformlib.Applier{Client: client, Wait: wait, Timeouts: limits}
+
+			if diff || cfg.DryRun {
+				applier.DryRun = true
+				return previewBootstrap(cmd.Context(), applier, components)
+			}
+			return runBootstrap(cmd.Context(), applier, components)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&only, "only", nil, "apply only these components: "+strings.Join(platformlib.BootstrapComponents, "|"))
+	cmd.Flags().BoolVar(&diff, "diff", false, "preview the changes against the live objects without applying them")
+	cmd.Flags().BoolVar(&wait, "wait", true, "wait for each promise to be Available and the reconciler to roll out")
+	cmd.Flags().StringSliceVar(&timeouts, "timeout", nil, "wait timeout per component, e.g. promises=20m,reconciler=2m")
+	return cmd
+}
+
+// parseTimeouts parses --timeout's component=duration pairs.
+func parseTimeouts(raw []string) (map[string]time.Duration, error) {
+	limits := map[string]time.Duration{}
+	for _, pair := range raw {
+		name, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, hcerrors.NewUserError("--timeout: %q is not component=duration", pair)
+		}
+		known := false
+		for _, c := range platformlib.BootstrapComponents {
+			known = known || c == name
+		}
+		if !known {
+			return nil, hcerrors.NewUserError("--timeout: unknown component %q (known: %s)", name, strings.Join(platformlib.BootstrapComponents, ", "))
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, hcerrors.NewUserError("--timeout: %s=%s is not a positive duration", name, v)
+		}
+		limits[name] = d
+	}
+	return limits, nil
+}
+
+func runBootstrap(ctx context.Context, applier *platformlib.Applier, components []platformlib.BootstrapComponent) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var results []platformlib.ObjectResult
+	var steps []tui.Step
+	for _, c := range components {
+		var deadline time.Time
+		for i, step := range c.Steps {
+			c, step, first := c, step, i == 0
+			title := "Applying " + c.Name
+			if c.Name == platformlib.ComponentPromises {
+				title = "Applying promise " + step.Name
+			}
+			steps = append(steps, tui.Step{
+				Title: title,
+				Run: func() (string, error) {
+					if first {
+						deadline = time.Now().Add(applier.Timeout(c.Name))
+					}
+					res, detail, err := applier.ApplyStep(ctx, c.Name, step, deadline)
+					results = append(results, res...)
+					return detail, err
+				},
+			})
+		}
+	}
+	if _, err := tui.RunSteps("Bootstrapping the platform", steps); err != nil {
+		return err
+	}
+
+	if tui.PrintStructured(results) {
+		return nil
+	}
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []string{r.Component, r.Ref(), actionStyle(r.Action)})
+	}
+	fmt.Println()
+	fmt.Println(tui.Table([]string{"COMPONENT", "OBJECT", "ACTION"}, rows))
+	return nil
+}
+
+// previewBootstrap dry-runs the bootstrap and prints what it would change.
+func previewBootstrap(ctx context.Context, applier *platformlib.Applier, components []platformlib.BootstrapComponent) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results, err := applier.Apply(ctx, components)
+	if err != nil {
+		return err
+	}
+	if tui.PrintStructured(results) {
+		return changesPending(results)
+	}
+
+	counts := map[platformlib.ApplyAction]int{}
+	for _, r := range results {
+		counts[r.Action]++
+		switch r.Action {
+		case platformlib.ActionCreated:
+			fmt.Printf("%s %s\n", tui.SuccessStyle.Render("+ new:"), r.Ref())
+		case platformlib.ActionConfigured:
+			fmt.Printf("%s %s\n", tui.WarningStyle.Render("~ modified:"), r.Ref())
+			for _, h := range deploylib.DiffLines(r.Live, r.Applied) {
+				fmt.Printf("  %s\n", tui.DimStyle.Render(h.Header()))
+				for _, line := range h.Lines {
+					if line[0] == '-' {
+						fmt.Printf("  %s\n", tui.ErrorStyle.Render(line))
+					} else {
+						fmt.Printf("  %s\n", tui.SuccessStyle.Render(line))
+					}
+				}
+			}
+		case platformlib.ActionSkipped:
+			fmt.Printf("%s %s (%s)\n", tui.DimStyle.Render("- skipped:"), r.Ref(), r.Reason)
+		}
+	}
+	if err := changesPending(results); err != nil {
+		fmt.Println(tui.DimStyle.Render(fmt.Sprintf("\n%d to create, %d to configure, %d unchanged",
+			counts[platformlib.ActionCreated], counts[platformlib.ActionConfigured], counts[platformlib.ActionUnchanged])))
+		return err
+	}
+	fmt.Println(tui.DimStyle.Render("No changes detected"))
+	return nil
+}
+
+// changesPending returns ErrChangesPending when a preview would create or
+// configure anything.
+func changesPending(results []platformlib.ObjectResult) error {
+	var changed []string
+	for _, r := range results {
+		if r.Action == platformlib.ActionCreated || r.Action == platformlib.ActionConfigured {
+			changed = append(changed, r.Ref())
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	return hcerrors.New(hcerrors.ErrChangesPending, "%d objects would change", len(changed)).
+		WithDetails(changed)
+}
+
+func actionStyle(a platformlib.ApplyAction) string {
+	switch a {
+	case platformlib.ActionCreated:
+		return tui.SuccessStyle.Render(string(a))
+	case platformlib.ActionConfigured:
+		return tui.WarningStyle.Render(string(a))
+	}
+	return tui.DimStyle.Render(string(a))
+}
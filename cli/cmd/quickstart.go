@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/cmd/deploy"
+	"github.com/jamesatintegratnio/hctl/cmd/vcluster"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/quickstart"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"github.com/spf13/cobra"
+)
+
+// quickstartImage is a small public image that serves HTTP on $PORT (8080)
+// and answers every path, including the web template's health probes.
+const quickstartImage = "us-docker.pkg.dev/google-samples/containers/gke/hello-app:1.0"
+
+const quickstartWorkload = "hello"
+
+var quickstartCmd = &cobra.Command{
+	Use:   "quickstart",
+	Short: "Guided first run: init, a dev vCluster, and a sample workload",
+	Long: `Walks through the platform end to end using the regular hctl commands:
+
+  1. init      verify the config, or run 'hctl init'
+  2. vcluster  create a small dev vCluster ('hctl vcluster create --preset dev')
+  3. wait      wait for it to be provisioned
+  4. scaffold  scaffold a "hello" workload from the web template ('hctl deploy init')
+  5. deploy    deploy it and watch ArgoCD ('hctl deploy run --watch')
+
+Each step can be run, skipped, or deferred. Progress is saved to quickstart.json
+in the config directory, so re-running 'hctl quickstart' resumes at the first
+step that has not finished — failed steps are retried, finished ones are not.
+At the end, the workload URL and the individual commands are printed.`,
+	Example: `  # Start (or resume) the guided flow
+  hctl quickstart
+
+  # Use an existing cluster instead of creating one
+  hctl quickstart --skip vcluster,wait
+
+  # Run the deploy step again, or start over
+  hctl quickstart --rerun deploy
+  hctl quickstart --reset`,
+	Args: cobra.NoArgs,
+	RunE: runQuickstart,
+}
+
+var (
+	quickstartReset   bool
+	quickstartSkip    []string
+	quickstartRerun   []string
+	quickstartDir     string
+	quickstartImg     string
+	quickstartTimeout time.Duration
+)
+
+func init() {
+	quickstartCmd.Flags().BoolVar(&quickstartReset, "reset", false, "discard saved progress and start over")
+	quickstartCmd.Flags().StringSliceVar(&quickstartSkip, "skip", nil, "steps to skip without prompting (init, vcluster, wait, scaffold, deploy)")
+	quickstartCmd.Flags().StringSliceVar(&quickstartRerun, "rerun", nil, "finished steps to run again")
+	quickstartCmd.Flags().StringVar(&quickstartDir, "dir", quickstartWorkload, "directory for the sample workload's score.yaml")
+	quickstartCmd.Flags().StringVar(&quickstartImg, "image", quickstartImage, "container image for the sample workload")
+	quickstartCmd.Flags().DurationVar(&quickstartTimeout, "timeout", 10*time.Minute, "timeout for each provisioning stage and the deploy watch")
+}
+
+func runQuickstart(cmd *cobra.Command, args []string) error {
+	if config.Get().DryRun {
+		// Each step acts on what the previous one created, so there is no
+		// single plan to print.
+		return hcerrors.NewUserError("quickstart does not support --dry-run: its steps depend on each other's results").
+			WithRemediation("run the steps' commands with --dry-run instead: hctl init, hctl vcluster create, hctl deploy run")
+	}
+	steps := quickstartSteps()
+	ids := map[string]int{}
+	for i, s := range steps {
+		ids[s.ID] = i
+	}
+	for _, id := range append(append([]string{}, quickstartSkip...), quickstartRerun...) {
+		if _, ok := ids[id]; !ok {
+			return hcerrors.NewUserError("unknown quickstart step %q", id).
+				WithRemediation("steps are: init, vcluster, wait, scaffold, deploy")
+		}
+	}
+
+	statePath := quickstart.StatePath()
+	state := &quickstart.State{Steps: map[string]*quickstart.StepRecord{}}
+	if !quickstartReset {
+		loaded, err := quickstart.LoadState(statePath)
+		if err != nil {
+			return hcerrors.NewUserError("reading quickstart progress: %w", err).
+				WithRemediation("run 'hctl quickstart --reset' to start over")
+		}
+		state = loaded
+	}
+	for _, id := range quickstartRerun {
+		state.Forget(id)
+	}
+
+	skip := map[string]bool{}
+	for _, id := range quickstartSkip {
+		skip[id] = true
+	}
+
+	cfg := config.Get()
+	runner := &quickstart.Runner{
+		Steps: steps,
+		State: state,
+		Save:  func(s *quickstart.State) error { return s.Save(statePath) },
+		OnResume: func(step quickstart.Step, prev quickstart.StepRecord) {
+			fmt.Printf("%s %s %s\n", tui.DimStyle.Render(tui.IconCheck), step.Title,
+				tui.DimStyle.Render("("+string(prev.Status)+" earlier)"))
+		},
+		Decide: func(step quickstart.Step, prev quickstart.StepRecord) (quickstart.Action, error) {
+			header := fmt.Sprintf("Step %d/%d: %s", ids[step.ID]+1, len(steps), step.Title)
+			fmt.Printf("\n%s\n", tui.TitleStyle.Render(header))
+			if prev.Status == quickstart.StatusFailed {
+				fmt.Printf("  %s previous attempt failed: %s\n", tui.WarningStyle.Render(tui.IconWarn), prev.Error)
+			}
+			if skip[step.ID] {
+				fmt.Printf("  %s\n", tui.DimStyle.Render("skipped (--skip)"))
+				return quickstart.ActionSkip, nil
+			}
+			if !cfg.Interactive {
+				return quickstart.ActionRun, nil
+			}
+			idx, err := tui.Select("Run this step?", []string{
+				"Run",
+				"Skip",
+				"Stop here (resume later with 'hctl quickstart')",
+			})
+			if err != nil {
+				return quickstart.ActionStop, err
+			}
+			switch idx {
+			case 0:
+				return quickstart.ActionRun, nil
+			case 1:
+				return quickstart.ActionSkip, nil
+			default:
+				return quickstart.ActionStop, nil
+			}
+		},
+	}
+
+	fmt.Println(tui.TitleStyle.Render(tui.IconPlay + "  hctl quickstart"))
+	fmt.Println(tui.DimStyle.Render("Progress: " + statePath))
+
+	err := runner.Run()
+	if errors.Is(err, quickstart.ErrStopped) {
+		fmt.Printf("\n%s\n", tui.DimStyle.Render("Stopped. Run 'hctl quickstart' to pick up where you left off."))
+		return nil
+	}
+	if err != nil {
+		var hErr *hcerrors.HctlError
+		if errors.As(err, &hErr) && hErr.Remediation != "" {
+			return err
+		}
+		return hcerrors.New(hcerrors.CategoryOf(err), "%w", err).
+			WithRemediation("fix the problem and run 'hctl quickstart' again to retry from this step")
+	}
+
+	printQuickstartRecap(runner)
+	return nil
+}
+
+// printQuickstartRecap shows the workload URL and the commands each step
+// stands for, so the flow can be repeated by hand.
+func printQuickstartRecap(runner *quickstart.Runner) {
+	fmt.Printf("\n%s\n", tui.SuccessStyle.Render(tui.IconCheck+" Quickstart complete"))
+	if runner.State.URL != "" {
+		fmt.Printf("\n  Your workload is live at %s\n", tui.InfoStyle.Render(runner.State.URL))
+	}
+
+	fmt.Printf("\n%s\n", tui.TitleStyle.Render("What just happened"))
+	for _, e := range runner.Recap() {
+		if e.Status == quickstart.StatusSkipped {
+			fmt.Printf("  %s %s %s\n", tui.DimStyle.Render(tui.IconBullet), e.Title, tui.DimStyle.Render("(skipped)"))
+			continue
+		}
+		fmt.Printf("  %s %s\n", tui.SuccessStyle.Render(tui.IconBullet), e.Title)
+		for _, c := range e.Commands {
+			fmt.Printf("      %s\n", tui.InfoStyle.Render(c))
+		}
+	}
+	fmt.Printf("\n%s\n", tui.DimStyle.Render("Start over with 'hctl quickstart --reset'."))
+}
+
+// quickstartSteps wires the existing init, vcluster, and deploy building
+// blocks into quickstart steps.
+func quickstartSteps() []quickstart.Step {
+	return []quickstart.Step{
+		{ID: "init", Title: "Initialize hctl", Run: quickstartInit},
+		{ID: "vcluster", Title: "Create a dev vCluster", Run: quickstartVCluster},
+		{ID: "wait", Title: "Wait for provisioning", Run: quickstartWait},
+		{ID: "scaffold", Title: "Scaffold the hello workload", Run: quickstartScaffold},
+		{ID: "deploy", Title: "Deploy and watch", Run: quickstartDeploy},
+	}
+}
+
+func quickstartInit(st *quickstart.State) (quickstart.Result, error) {
+	if cfg := config.Get(); cfg.RepoPath != "" {
+		if _, err := os.Stat(cfg.RepoPath); err == nil {
+			return quickstart.Result{Detail: "already initialized: " + cfg.RepoPath}, nil
+		}
+	}
+	if err := runInit(nil, nil); err != nil {
+		return quickstart.Result{}, err
+	}
+	// Reload so later steps see the saved repo path.
+	initConfig()
+	if err := config.Get().RequireRepoPath(); err != nil {
+		return quickstart.Result{}, err
+	}
+	return quickstart.Result{Detail: config.Get().RepoPath, Commands: []string{"hctl init"}}, nil
+}
+
+func quickstartVCluster(st *quickstart.State) (quickstart.Result, error) {
+	cfg := config.Get()
+	if err := cfg.RequireRepoPath(); err != nil {
+		return quickstart.Result{}, err
+	}
+
+	// A name chosen in an earlier, failed attempt is reused.
+	if st.Cluster == "" {
+		name := quickstartClusterName()
+		if cfg.Interactive {
+			val, err := tui.Input("vCluster name", "", name)
+			if err != nil {
+				return quickstart.Result{}, err
+			}
+			if val != "" {
+				name = val
+			}
+		}
+		st.Cluster = name
+	}
+
+	hostname, _, err := vcluster.CreateDev(cfg, st.Cluster, "auto")
+	if err != nil {
+		return quickstart.Result{}, err
+	}
+	st.Hostname = hostname
+	return quickstart.Result{
+		Detail: st.Cluster,
+		Commands: []string{
+			fmt.Sprintf("hctl vcluster create %s --preset dev --auto-commit --wait=false", st.Cluster),
+		},
+	}, nil
+}
+
+func quickstartWait(st *quickstart.State) (quickstart.Result, error) {
+	if st.Record("vcluster").Status != quickstart.StatusDone {
+		return quickstart.Result{Detail: "no vCluster was created by quickstart"}, nil
+	}
+	if err := vcluster.WatchProvisioning(config.Get(), st.Cluster, st.Hostname, quickstartTimeout); err != nil {
+		return quickstart.Result{}, err
+	}
+	return quickstart.Result{
+		Detail:   st.Cluster + " ready",
+		Commands: []string{"hctl vcluster status " + st.Cluster},
+	}, nil
+}
+
+func quickstartScaffold(st *quickstart.State) (quickstart.Result, error) {
+	cfg := config.Get()
+	cluster := cfg.DefaultCluster
+	if st.Record("vcluster").Status == quickstart.StatusDone {
+		cluster = st.Cluster
+	}
+	if cluster == "" {
+		return quickstart.Result{}, hcerrors.NewUserError("no target vCluster").
+			WithRemediation("run the vcluster step ('hctl quickstart --rerun vcluster') or set defaultCluster in the config")
+	}
+
+	dir, err := filepath.Abs(quickstartDir)
+	if err != nil {
+		return quickstart.Result{}, err
+	}
+	st.Cluster = cluster
+	st.Workload = quickstartWorkload
+	st.WorkloadDir = dir
+
+	commands := []string{
+		fmt.Sprintf("mkdir -p %s && cd %s", quickstartDir, quickstartDir),
+		fmt.Sprintf("hctl deploy init --template web --cluster %s", cluster),
+		fmt.Sprintf("# then set containers.app.image to %s", quickstartImg),
+	}
+
+	scorePath := filepath.Join(dir, "score.yaml")
+	if _, err := os.Stat(scorePath); err == nil {
+		return quickstart.Result{Detail: "using existing " + scorePath, Commands: commands}, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return quickstart.Result{}, fmt.Errorf("creating %s: %w", dir, err)
+	}
+	scaffold := deploy.ScaffoldScore("web", quickstartWorkload, cluster, cfg.Platform.Domain, quickstartImg)
+	if err := os.WriteFile(scorePath, []byte(scaffold), 0o644); err != nil {
+		return quickstart.Result{}, fmt.Errorf("writing score.yaml: %w", err)
+	}
+	return quickstart.Result{Detail: scorePath, Commands: commands}, nil
+}
+
+func quickstartDeploy(st *quickstart.State) (quickstart.Result, error) {
+	cfg := config.Get()
+	if st.WorkloadDir == "" {
+		return quickstart.Result{}, hcerrors.NewUserError("no sample workload to deploy").
+			WithRemediation("run the scaffold step: hctl quickstart --rerun scaffold")
+	}
+	scorePath := filepath.Join(st.WorkloadDir, "score.yaml")
+
+	deployCmd := deploy.NewCmd()
+	deployCmd.SetArgs([]string{"run", "-f", scorePath, "--watch", "--timeout", quickstartTimeout.String()})
+	deployCmd.SilenceErrors = true
+	deployCmd.SilenceUsage = true
+	if err := deployCmd.Execute(); err != nil {
+		return quickstart.Result{}, err
+	}
+
+	// deploy run returns cleanly when the user declines its confirmation.
+	valuesPath := translate.ValuesPath(st.Cluster, st.Workload)
+	if _, err := os.Stat(repopath.Abs(cfg.RepoPath, valuesPath)); err != nil {
+		return quickstart.Result{}, hcerrors.NewUserError("%s was not written — the deploy was cancelled", valuesPath)
+	}
+
+	if w, err := score.FindWorkload(scorePath, st.Workload); err == nil {
+		st.URL = workloadURL(w)
+	}
+	return quickstart.Result{
+		Detail:   st.URL,
+		Commands: []string{"hctl deploy run --watch", "hctl open " + st.Workload},
+	}, nil
+}
+
+// workloadURL builds the public URL from the first route resource by name.
+func workloadURL(w *score.Workload) string {
+	routes := w.ResourcesByType("route")
+	names := make([]string, 0, len(routes))
+	for name := range routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		host, ok := routes[name].Params["host"].(string)
+		if !ok || host == "" {
+			continue
+		}
+		url := "https://" + host
+		if p, ok := routes[name].Params["path"].(string); ok && p != "/" {
+			url += p
+		}
+		return url
+	}
+	return ""
+}
+
+// quickstartClusterName returns a short random vCluster name such as "qs-k3x9p".
+func quickstartClusterName() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 5)
+	for i := range b {
+		b[i] = alphabet[rand.IntN(len(alphabet))]
+	}
+	return "qs-" + string(b)
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/layout/migrate"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns the repo command group.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Maintain the gitops repo's structure",
+		Long: `Inspect and change how the gitops repo is laid out.
+
+The repo records its layout version in .hctl/layout-version; a repo
+without the file is v1. Every command resolves repo paths through it.`,
+	}
+
+	cmd.AddCommand(newMigrateCmd())
+
+	return cmd
+}
+
+func newMigrateCmd() *cobra.Command {
+	var to string
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move the repo to another layout version",
+		Long: `Rewrite the repo from its layout version to another and commit the
+result as one change.
+
+  v1  workloads/<cluster>/
+  v2  addons/environments/<env>/workloads/<cluster>/
+
+<env> is the vcluster's ArgoCD environment in platform/vclusters/<cluster>.yaml
+(production when unset). The migration moves each cluster's workload files
+as git mv would, rewrites the paths addons.yaml entries embed, points the
+vcluster requests' workloadRepo.path, which the workloads ApplicationSet
+reads, at the new root, and updates .hctl/layout-version.
+
+A half-migrated repo, with workloads in both layouts or in a layout its
+marker does not record, is finished in the direction of --to. The working
+tree must be clean; use --dry-run to preview the plan.`,
+		Example: `  hctl repo migrate --to v2 --dry-run
+  hctl repo migrate --to v2
+  hctl repo migrate --to v1        # undo`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{layout.SkipLoadAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			if !cfg.DryRun {
+				if err := requireClean(cfg.RepoPath); err != nil {
+					return err
+				}
+			}
+
+			mp := mutation.New(cfg.RepoPath)
+			m, err := migrate.Plan(mp, cfg.RepoPath, to)
+			if err != nil {
+				return err
+			}
+			if mp.Empty() {
+				fmt.Printf("%s The repo is already at layout %s\n", tui.SuccessStyle.Render(tui.IconCheck), to)
+				return nil
+			}
+			mp.Commit(git.WorkflowOpts{
+				Action:      "migrate repo layout to",
+				Resource:    to,
+				Details:     fmt.Sprintf("from %s", m.From),
+				GitMode:     cfg.GitMode,
+				Interactive: cfg.Interactive,
+			})
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+
+			printMigration(m)
+			if err := mp.Apply(); err != nil {
+				return hcerrors.New(hcerrors.ErrInternal, "%w", err).
+					WithRemediation("Check file permissions in the repository and retry")
+			}
+			fmt.Printf("%s Migrated to layout %s (%d files changed)\n", tui.SuccessStyle.Render(tui.IconCheck), to, len(mp.Files))
+			return mp.Finish()
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "layout version to migrate to ("+strings.Join(layout.Versions, ", ")+")")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// requireClean refuses to migrate a repo with uncommitted changes, so the
+// migration commit holds nothing else and is easy to revert.
+func requireClean(repoPath string) error {
+	repo, err := git.DetectRepo(repoPath)
+	if err != nil {
+		return hcerrors.Wrap(hcerrors.ErrGit, err)
+	}
+	status, err := repo.Status()
+	if err != nil {
+		return hcerrors.Wrap(hcerrors.ErrGit, err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return hcerrors.NewUserError("the working tree has uncommitted changes").
+			WithDetails(map[string]string{"status": strings.TrimSpace(status)}).
+			WithRemediation("commit or stash them, then rerun the migration")
+	}
+	return nil
+}
+
+// printMigration summarises what the migration does.
+func printMigration(m *migrate.Migration) {
+	if m.HalfMigrated != "" {
+		fmt.Printf("%s Finishing a half-migrated repo: %s\n", tui.WarningStyle.Render(tui.IconWarn), m.HalfMigrated)
+	}
+	for _, mv := range m.Moves {
+		fmt.Printf("  %s → %s %s\n", mv.From, mv.To, tui.DimStyle.Render(fmt.Sprintf("(%d files)", mv.Files)))
+	}
+	for _, ref := range m.References {
+		fmt.Printf("  %s %s\n", tui.DimStyle.Render("rewrote paths in"), ref)
+	}
+}
@@ -0,0 +1,213 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// workloadReport is the structured output of --by-workload.
+type workloadReport struct {
+	Cluster     string                      `json:"cluster" yaml:"cluster"`
+	Allocatable platform.Resources          `json:"allocatable" yaml:"allocatable"`
+	Workloads   []platform.WorkloadCapacity `json:"workloads" yaml:"workloads"`
+	// Unknown lists namespaces whose pods could not be read.
+	Unknown []string `json:"unknownNamespaces,omitempty" yaml:"unknownNamespaces,omitempty"`
+}
+
+func newCapacityCmd() *cobra.Command {
+	var (
+		warnBelow  float64
+		byWorkload string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Compare resource requests with allocatable capacity",
+		Long: `Add up CPU and memory for the host cluster and each vCluster:
+
+  - allocatable: the host nodes' allocatable CPU and memory
+  - requests/limits: the pods' effective requests and limits; for a
+    vCluster, its synced workload pods in its host namespace
+  - control plane: a vCluster's control-plane StatefulSet requests, from
+    its spec (preset defaults included)
+  - headroom: the share of allocatable left after requests
+
+vClusters schedule onto the host's nodes, so a vCluster's headroom is what
+its own requests leave free; the host row accounts for every pod. The host
+comes first, then vClusters sorted by headroom, lowest first. Rows under
+--warn-below percent are flagged.
+
+Namespaces whose pods hctl may not list are reported as unknown, and their
+pods are missing from the totals.
+
+--by-workload <cluster> breaks one cluster down by the
+hctl.integratn.tech/workload label; use "host" for the host cluster.
+
+Examples:
+  hctl report capacity
+  hctl report capacity --warn-below 30
+  hctl report capacity --by-workload media
+  hctl report capacity -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if warnBelow < 0 || warnBelow > 100 {
+				return hcerrors.NewUserError("--warn-below must be between 0 and 100, got %g", warnBelow)
+			}
+			cfg := config.Get()
+			client, err := kube.NewClient(cfg.KubeContext)
+			if err != nil {
+				return fmt.Errorf("connecting to cluster: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			defer cancel()
+
+			capacity, err := platform.CollectCapacity(ctx, client, cfg.Platform.PlatformNamespace)
+			if err != nil {
+				return kube.ClassifyError(err)
+			}
+
+			if byWorkload != "" {
+				row, pods, ok := capacity.ClusterPods(byWorkload)
+				if !ok {
+					return hcerrors.New(hcerrors.ErrNotFound, "cluster %q not found", byWorkload).
+						WithRemediation("use \"host\" or a vCluster from 'hctl vcluster list'")
+				}
+				out := workloadReport{
+					Cluster:     row.Cluster,
+					Allocatable: row.Allocatable,
+					Workloads:   platform.ByWorkload(pods, row.Allocatable),
+					Unknown:     row.Unknown,
+				}
+				if out.Workloads == nil {
+					out.Workloads = []platform.WorkloadCapacity{}
+				}
+				if !tui.PrintStructured(out) {
+					printWorkloads(out)
+				}
+				return nil
+			}
+
+			report := platform.CapacityReport{WarnBelow: warnBelow, Rows: capacity.Rows}
+			report.Finish()
+			if !tui.PrintStructured(report) {
+				printCapacity(report)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&warnBelow, "warn-below", 20, "flag clusters with less CPU or memory headroom than this percentage")
+	cmd.Flags().StringVar(&byWorkload, "by-workload", "", "break one cluster down by workload (\"host\" or a vCluster name)")
+
+	return cmd
+}
+
+func printCapacity(report platform.CapacityReport) {
+	fmt.Printf("\n  %s\n\n", tui.TitleStyle.Render("Capacity"))
+	headers := []string{"CLUSTER", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM", "CONTROL PLANE", "PODS", "CPU HEADROOM", "MEM HEADROOM"}
+	var rows [][]string
+	var warnings []string
+	for _, r := range report.Rows {
+		controlPlane := "—"
+		if r.ControlPlane != nil {
+			controlPlane = formatCPU(r.ControlPlane.CPU) + " / " + formatMemory(r.ControlPlane.Memory)
+		}
+		cpu, mem := formatPercent(r.Headroom.CPU), formatPercent(r.Headroom.Memory)
+		if len(r.Unknown) > 0 {
+			cpu, mem = cpu+"?", mem+"?"
+		}
+		if r.Warning {
+			cpu, mem = tui.WarningStyle.Render(cpu), tui.WarningStyle.Render(mem)
+			warnings = append(warnings, fmt.Sprintf("%s has less than %g%% headroom", r.Cluster, report.WarnBelow))
+		}
+		rows = append(rows, []string{
+			r.Cluster,
+			formatCPU(r.Requests.CPU), formatCPU(r.Limits.CPU),
+			formatMemory(r.Requests.Memory), formatMemory(r.Limits.Memory),
+			controlPlane,
+			fmt.Sprintf("%d", r.Pods),
+			cpu, mem,
+		})
+	}
+	fmt.Println(tui.Table(headers, rows))
+
+	if len(report.Rows) > 0 {
+		host := report.Rows[0]
+		fmt.Printf("\n  %s\n", tui.DimStyle.Render(fmt.Sprintf("allocatable: %s CPU, %s memory", formatCPU(host.Allocatable.CPU), formatMemory(host.Allocatable.Memory))))
+	}
+	for _, w := range warnings {
+		fmt.Printf("  %s %s\n", tui.WarningStyle.Render(tui.IconWarn), w)
+	}
+	for _, r := range report.Rows {
+		if len(r.Unknown) > 0 {
+			fmt.Printf("  %s %s: pods in %s could not be read (?: headroom is an upper bound)\n",
+				tui.WarningStyle.Render(tui.IconWarn), r.Cluster, strings.Join(r.Unknown, ", "))
+		}
+	}
+}
+
+func printWorkloads(out workloadReport) {
+	fmt.Printf("\n  %s\n\n", tui.TitleStyle.Render("Capacity by workload: "+out.Cluster))
+	if len(out.Workloads) == 0 {
+		fmt.Println(tui.DimStyle.Render("  No running pods"))
+		return
+	}
+	headers := []string{"WORKLOAD", "PODS", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM", "CPU SHARE", "MEM SHARE"}
+	var rows [][]string
+	for _, w := range out.Workloads {
+		name := w.Workload
+		if name == "" {
+			name = tui.DimStyle.Render("(not deployed by hctl)")
+		}
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%d", w.Pods),
+			formatCPU(w.Requests.CPU), formatCPU(w.Limits.CPU),
+			formatMemory(w.Requests.Memory), formatMemory(w.Limits.Memory),
+			formatPercent(w.CPUShare), formatPercent(w.MemoryShare),
+		})
+	}
+	fmt.Println(tui.Table(headers, rows))
+	if len(out.Unknown) > 0 {
+		fmt.Printf("\n  %s pods in %s could not be read\n", tui.WarningStyle.Render(tui.IconWarn), strings.Join(out.Unknown, ", "))
+	}
+}
+
+// formatCPU shows CPU in cores, e.g. 1.5 for 1500m.
+func formatCPU(q resource.Quantity) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", float64(q.MilliValue())/1000), "0"), ".")
+}
+
+// formatMemory shows memory in the largest binary unit that keeps at least
+// one whole unit, e.g. 1.5Gi.
+func formatMemory(q resource.Quantity) string {
+	b := float64(q.Value())
+	for _, unit := range []struct {
+		suffix string
+		size   float64
+	}{{"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10}} {
+		if b >= unit.size {
+			v := strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.1f", b/unit.size), "0"), ".")
+			return v + unit.suffix
+		}
+	}
+	return fmt.Sprintf("%.0f", b)
+}
+
+func formatPercent(p *float64) string {
+	if p == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f%%", *p)
+}
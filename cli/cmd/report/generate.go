@@ -0,0 +1,119 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/inventory"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func newGenerateCmd() *cobra.Command {
+	var (
+		format  string
+		output  string
+		changes int
+		offline bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Write a markdown or HTML snapshot of the platform inventory",
+		Long: `Write a human-readable snapshot of the platform for reviews:
+
+  - clusters: environment, preset, Kubernetes and vCluster chart versions,
+    from their requests in platform/vclusters
+  - workloads per cluster: images and route hostnames, from values.yaml
+  - addons per environment, and each cluster's own addons, with versions
+  - an appendix of recent commits to the platform, addons, promises and
+    workloads directories
+
+The repo is authoritative for configuration. Phase, sync and health
+columns come from the live cluster and are marked as live as of when they
+were read; when the cluster cannot be reached, or with --offline, they are
+left out and the report says why.
+
+The report goes to stdout unless --output names a file. --output here is
+the report file, not the global output format.
+
+Examples:
+  hctl report generate --output platform-report.md
+  hctl report generate --format html --output platform-report.html
+  hctl report generate --offline --changes 50`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != inventory.FormatMarkdown && format != inventory.FormatHTML {
+				return hcerrors.NewUserError("--format must be %s or %s, got %q", inventory.FormatMarkdown, inventory.FormatHTML, format)
+			}
+			if changes < 0 {
+				return hcerrors.NewUserError("--changes must not be negative, got %d", changes)
+			}
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+
+			inv, err := inventory.FromRepo(cfg.RepoPath, changes, time.Now())
+			if err != nil {
+				return err
+			}
+			if offline {
+				inv.LiveError = "--offline"
+			} else if err := addLive(cmd.Context(), cfg, inv); err != nil {
+				inv.LiveError = "the cluster could not be read"
+				tui.Warn("Leaving live status out of the report: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := inventory.Render(&buf, inv, format); err != nil {
+				return err
+			}
+			if output == "" {
+				_, err := os.Stdout.Write(buf.Bytes())
+				return err
+			}
+			if dir := filepath.Dir(output); dir != "." {
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					return err
+				}
+			}
+			if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+				return fmt.Errorf("writing the report: %w", err)
+			}
+			tui.Success("Wrote the %s report to %s", format, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", inventory.FormatMarkdown, "report format: markdown or html")
+	cmd.Flags().StringVar(&output, "output", "", "file to write the report to (default stdout)")
+	cmd.Flags().IntVar(&changes, "changes", inventory.DefaultChangeLimit, "number of recent commits in the changes appendix")
+	cmd.Flags().BoolVar(&offline, "offline", false, "leave out the live status instead of reading the cluster")
+
+	return cmd
+}
+
+// addLive adds the live platform status to inv.
+func addLive(ctx context.Context, cfg *config.Config, inv *inventory.Inventory) error {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	ps, err := platform.CollectPlatformStatus(ctx, client, cfg.Platform.PlatformNamespace)
+	if err != nil {
+		return kube.ClassifyError(err)
+	}
+	inv.AddLive(ps, time.Now())
+	return nil
+}
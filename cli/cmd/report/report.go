@@ -0,0 +1,21 @@
+// Package report holds the 'hctl report' commands, which aggregate live
+// platform state for planning rather than for operating on it.
+package report
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns the report command group.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Platform-wide reports",
+		Long:  "Aggregate live platform state across the host cluster and every vCluster, or write a snapshot of the platform inventory for reviews.",
+	}
+
+	cmd.AddCommand(newCapacityCmd())
+	cmd.AddCommand(newGenerateCmd())
+
+	return cmd
+}
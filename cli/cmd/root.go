@@ -1,20 +1,34 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/cmd/addon"
 	"github.com/jamesatintegratnio/hctl/cmd/ai"
+	"github.com/jamesatintegratnio/hctl/cmd/bulk"
 	"github.com/jamesatintegratnio/hctl/cmd/deploy"
+	"github.com/jamesatintegratnio/hctl/cmd/platform"
+	"github.com/jamesatintegratnio/hctl/cmd/repo"
+	"github.com/jamesatintegratnio/hctl/cmd/report"
 	"github.com/jamesatintegratnio/hctl/cmd/scale"
 	"github.com/jamesatintegratnio/hctl/cmd/secret"
 	"github.com/jamesatintegratnio/hctl/cmd/vcluster"
+	"github.com/jamesatintegratnio/hctl/internal/audit"
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
 	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/provcache"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -23,14 +37,18 @@ var (
 	// Commit is set at build time via ldflags.
 	Commit = "none"
 
-	cfgFile      string
-	nonInteract  bool
-	outputFormat string
-	verboseFlag  bool
-	quietFlag    bool
-	watchFlag    bool
+	cfgFile       string
+	nonInteract   bool
+	outputFormat  string
+	verboseFlag   bool
+	debugFlag     bool
+	quietFlag     bool
+	watchFlag     bool
 	watchInterval time.Duration
 	bundlePath    string
+
+	policyOverride string
+	dryRun         bool
 )
 
 var rootCmd = &cobra.Command{
@@ -59,44 +77,146 @@ addon management, platform diagnostics, and day-to-day operational tasks.`,
   hctl trace my-vcluster         # show delivery chain`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("policy-override") && strings.TrimSpace(policyOverride) == "" {
+			return hcerrors.NewUserError("--policy-override needs a reason, e.g. --policy-override \"hotfix for INC-42\"")
+		}
+		audit.SetInvocation(cmd.CommandPath(), invocationArgs(cmd, args), Version)
+		provcache.SetVersion(Version)
+		deploylib.SetVersion(Version)
+		cmd.SetContext(logging.NewContext(cmd.Context(), logging.L()))
+		return useRepoLayout(cmd)
+	},
+}
+
+// useRepoLayout makes the configured repo's layout the one commands
+// resolve paths with. Commands annotated with layout.SkipLoadAnnotation
+// run even when it does not load.
+func useRepoLayout(cmd *cobra.Command) error {
+	repoPath := config.Get().RepoPath
+	if repoPath == "" {
+		return nil
+	}
+	l, err := layout.Load(repoPath)
+	if err != nil {
+		for c := cmd; c != nil; c = c.Parent() {
+			if c.Annotations[layout.SkipLoadAnnotation] != "" {
+				return nil
+			}
+		}
+		return err
+	}
+	layout.Use(l)
+	return nil
+}
+
+// invocationArgs normalises the command line for the audit log: positional
+// args followed by every flag that was set, as --name=value.
+func invocationArgs(cmd *cobra.Command, args []string) []string {
+	out := append([]string(nil), args...)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		out = append(out, "--"+f.Name+"="+f.Value.String())
+	})
+	return out
 }
 
 func Execute() error {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		// A dry run with changes to make has already printed them.
+		if !errors.Is(err, hcerrors.ErrChangesPending) {
+			printError(err)
+		}
 		os.Exit(hcerrors.ExitCode(err))
 	}
 	return nil
 }
 
+// printError writes err to stderr. With --output json|yaml the error is
+// emitted as a structured object so scripts can branch on its category.
+func printError(err error) {
+	if tui.IsStructured() {
+		report := hcerrors.ToReport(err)
+		if tui.FprintOutput(os.Stderr, report, "") == nil {
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	var hErr *hcerrors.HctlError
+	if errors.As(err, &hErr) && hErr.Remediation != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", hErr.Remediation)
+	}
+}
+
+// classifyUsageErrors marks flag parsing and argument validation failures as
+// usage errors so they map to ExitUserError regardless of which command raised them.
+func classifyUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return hcerrors.Wrap(hcerrors.ErrUsage, err)
+	})
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(c *cobra.Command, a []string) error {
+			return hcerrors.Wrap(hcerrors.ErrUsage, args(c, a))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		classifyUsageErrors(sub)
+	}
+}
+
+var exitCodesCmd = &cobra.Command{
+	Use:   "exit-codes",
+	Short: "Exit codes and machine-readable errors",
+	Long: `hctl exits with a code that identifies the category of failure:
+
+` + hcerrors.ExitCodeTable() + `
+changes_pending is not a failure: --dry-run, and 'hctl deploy diff', exit 10
+when there are changes to make, and 0 when there are none. Nothing is written
+to stderr for it. No failure exits 10, so a bad flag is never read as changes.
+
+With --output json (or yaml), errors are written to stderr as an object:
+
+  {"category": "validation", "exitCode": 5, "message": "...",
+   "details": {...}, "remediation": "..."}
+
+"details" and "remediation" are omitted when empty.`,
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default $XDG_CONFIG_HOME/hctl/config.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&nonInteract, "non-interactive", false, "disable interactive prompts")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "output format: text, json, yaml")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "output format: text, wide, json, yaml")
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "enable verbose/debug output")
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "log kube requests, git commands, provisioner inputs/outputs and file writes to stderr (secrets redacted)")
 	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "suppress informational output")
+	rootCmd.PersistentFlags().StringVar(&policyOverride, "policy-override", "", "proceed despite .hctl/policy.yaml; the reason is recorded in the commit and audit log")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the files, git and cluster changes a command would make, without making them (exit 10 if there are any)")
 
 	// Register sub-command groups
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(statusCmd)
-	statusCmd.Flags().BoolVarP(&watchFlag, "watch", "w", false, "continuously refresh status (structured output only)")
+	statusCmd.Flags().BoolVarP(&watchFlag, "watch", "w", false, "refresh continuously: a full-screen view highlighting changes, or a stream with -o json|yaml")
 	statusCmd.Flags().DurationVar(&watchInterval, "interval", 10*time.Second, "refresh interval for --watch")
 	rootCmd.AddCommand(diagnoseCmd)
 	diagnoseCmd.Flags().StringVar(&bundlePath, "bundle", "", "export diagnostic bundle to file (JSON)")
 	rootCmd.AddCommand(reconcileCmd)
 	rootCmd.AddCommand(contextCmd)
 	rootCmd.AddCommand(alertsCmd)
+	rootCmd.AddCommand(uiCmd)
 	rootCmd.AddCommand(completionCmd)
 
 	rootCmd.AddCommand(vcluster.NewCmd())
 	rootCmd.AddCommand(deploy.NewCmd())
 	rootCmd.AddCommand(addon.NewCmd())
+	rootCmd.AddCommand(bulk.NewCmd())
 	rootCmd.AddCommand(scale.NewCmd())
+	rootCmd.AddCommand(report.NewCmd())
 	rootCmd.AddCommand(secret.NewCmd())
 	rootCmd.AddCommand(ai.NewCmd())
+	rootCmd.AddCommand(repo.NewCmd())
+	rootCmd.AddCommand(platform.NewCmd())
 
 	// Convenience commands
 	rootCmd.AddCommand(upCmd)
@@ -104,9 +224,16 @@ func init() {
 	rootCmd.AddCommand(openCmd)
 	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(versionsCmd)
 	rootCmd.AddCommand(traceCmd)
+	rootCmd.AddCommand(quickstartCmd)
+	rootCmd.AddCommand(auditLogCmd)
+
+	// Help topics
+	rootCmd.AddCommand(exitCodesCmd)
 
 	registerCompletions()
+	classifyUsageErrors(rootCmd)
 }
 
 func initConfig() {
@@ -114,7 +241,7 @@ func initConfig() {
 	if err != nil {
 		cfg = config.Default()
 		// Auto-create config on first run if no custom path was specified
-		if cfgFile == "" {
+		if cfgFile == "" && !dryRun {
 			if saveErr := config.Save(cfg); saveErr == nil {
 				fmt.Fprintf(os.Stderr, "Created default config at %s\n", config.ConfigPath())
 			}
@@ -130,10 +257,25 @@ func initConfig() {
 	if verboseFlag {
 		cfg.Verbose = true
 	}
+	if debugFlag {
+		cfg.Debug = true
+	}
+	if cfg.Debug {
+		cfg.Verbose = true
+	}
 	if quietFlag {
 		cfg.Quiet = true
 	}
+	cfg.PolicyOverride = policyOverride
+	cfg.DryRun = dryRun
 	config.Set(cfg)
+	git.SetCommitConfig(git.CommitConfig{
+		AuthorName:  cfg.GitAuthor.Name,
+		AuthorEmail: cfg.GitAuthor.Email,
+		Signing:     cfg.GitSigning,
+		SigningKey:  cfg.GitSigningKey,
+	})
+	logResolvedConfig(logging.Init(cfg.Verbose, cfg.Debug), cfg)
 
 	// Wire output format into TUI layer
 	if cfg.OutputFormat != "" {
@@ -150,6 +292,39 @@ func initConfig() {
 	}
 }
 
+// logResolvedConfig logs the configuration after flags are applied. The
+// logger redacts the 1Password token.
+func logResolvedConfig(log *slog.Logger, cfg *config.Config) {
+	path := cfgFile
+	if path == "" {
+		path = config.ConfigPath()
+	}
+	log.Debug("resolved config",
+		"file", path,
+		"repoPath", cfg.RepoPath,
+		"defaultCluster", cfg.DefaultCluster,
+		"kubeContext", cfg.KubeContext,
+		"gitMode", cfg.GitMode,
+		"gitSigning", cfg.GitSigning,
+		"argocdURL", cfg.ArgocdURL,
+		"interactive", cfg.Interactive,
+		"outputFormat", cfg.OutputFormat,
+		"team", cfg.Team,
+		slog.Group("platform",
+			"domain", cfg.Platform.Domain,
+			"clusterSubnet", cfg.Platform.ClusterSubnet,
+			"metalLBPool", cfg.Platform.MetalLBPool,
+			"platformNamespace", cfg.Platform.PlatformNamespace),
+		slog.Group("onePassword",
+			"connectHost", cfg.OnePassword.ConnectHost,
+			"connectToken", cfg.OnePassword.ConnectToken,
+			"vault", cfg.OnePassword.Vault),
+		slog.Group("registry",
+			"pinDigests", cfg.Registry.PinDigests,
+			"pullSecret", cfg.Registry.PullSecret),
+	)
+}
+
 // --- Inline simple commands ---
 
 var versionCmd = &cobra.Command{
@@ -170,8 +345,15 @@ var initCmd = &cobra.Command{
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Platform health dashboard",
-	Long:  "Shows node health, ArgoCD application status, Kratix promises, active vClusters, workloads, and addons.",
-	RunE:  runStatus,
+	Long: `Shows node health, ArgoCD application status, Kratix promises, active vClusters, workloads, addons,
+and platform components with a newer release (see 'hctl versions').
+
+With --watch, every section refreshes each --interval in a full-screen view.
+Cells that changed since the previous refresh are shown in inverse video for
+one cycle, and each section keeps its last update time and any refresh error
+visible. Press r to refresh immediately and q or Ctrl-C to quit. With
+-o json|yaml, --watch prints a snapshot per interval instead.`,
+	RunE: runStatus,
 }
 
 var diagnoseCmd = &cobra.Command{
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -36,12 +37,6 @@ Useful for maintenance or cost-saving on idle namespaces.`,
 			ns := args[0]
 			cfg := config.Get()
 
-			confirmed, _ := tui.Confirm(fmt.Sprintf("Scale down all deployments in namespace %q?", ns))
-			if !confirmed {
-				fmt.Println("Cancelled")
-				return nil
-			}
-
 			client, err := kube.NewClient(cfg.KubeContext)
 			if err != nil {
 				return fmt.Errorf("connecting to cluster: %w", err)
@@ -50,8 +45,6 @@ Useful for maintenance or cost-saving on idle namespaces.`,
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
-			fmt.Printf("\n  %s Scaling down namespace %s\n\n", tui.WarningStyle.Render(tui.IconArrow), ns)
-
 			deploys, err := client.ListDeployments(ctx, ns)
 			if err != nil {
 				return err
@@ -62,16 +55,36 @@ Useful for maintenance or cost-saving on idle namespaces.`,
 				return nil
 			}
 
+			mp := mutation.New(cfg.RepoPath)
 			for _, deploy := range deploys {
 				if deploy.ArgoApp != "" {
-					fmt.Printf("    %s Disabling auto-sync for %s\n", tui.MutedStyle.Render(tui.IconArrow), deploy.ArgoApp)
-					_ = client.DisableArgoAutoSync(ctx, "argocd", deploy.ArgoApp)
+					mp.Cluster("disable ArgoCD auto-sync for "+deploy.ArgoApp, func() error {
+						fmt.Printf("    %s Disabling auto-sync for %s\n", tui.MutedStyle.Render(tui.IconArrow), deploy.ArgoApp)
+						_ = client.DisableArgoAutoSync(ctx, "argocd", deploy.ArgoApp)
+						return nil
+					})
 				}
+				mp.Cluster(fmt.Sprintf("scale deployment %s/%s to 0", ns, deploy.Name), func() error {
+					fmt.Printf("    %s Scaling %s to 0\n", tui.MutedStyle.Render(tui.IconArrow), deploy.Name)
+					if err := client.ScaleDeployment(ctx, ns, deploy.Name, 0); err != nil {
+						fmt.Printf("    %s Failed to scale %s: %v\n", tui.WarningStyle.Render(tui.IconWarn), deploy.Name, err)
+					}
+					return nil
+				})
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
 
-				fmt.Printf("    %s Scaling %s to 0\n", tui.MutedStyle.Render(tui.IconArrow), deploy.Name)
-				if err := client.ScaleDeployment(ctx, ns, deploy.Name, 0); err != nil {
-					fmt.Printf("    %s Failed to scale %s: %v\n", tui.WarningStyle.Render(tui.IconWarn), deploy.Name, err)
-				}
+			confirmed, _ := tui.Confirm(fmt.Sprintf("Scale down all deployments in namespace %q?", ns))
+			if !confirmed {
+				fmt.Println("Cancelled")
+				return nil
+			}
+
+			fmt.Printf("\n  %s Scaling down namespace %s\n\n", tui.WarningStyle.Render(tui.IconArrow), ns)
+			if err := mp.Execute(); err != nil {
+				return err
 			}
 
 			fmt.Printf("\n  %s All deployments in %s scaled down\n", tui.SuccessStyle.Render(tui.IconCheck), ns)
@@ -97,8 +110,6 @@ func newScaleUpCmd() *cobra.Command {
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
-			fmt.Printf("\n  %s Scaling up namespace %s\n\n", tui.InfoStyle.Render(tui.IconArrow), ns)
-
 			deploys, err := client.ListDeployments(ctx, ns)
 			if err != nil {
 				return err
@@ -109,21 +120,40 @@ func newScaleUpCmd() *cobra.Command {
 				return nil
 			}
 
+			mp := mutation.New(cfg.RepoPath)
+			var running []kube.DeploymentInfo
 			for _, deploy := range deploys {
 				if deploy.Replicas == 0 {
-					fmt.Printf("    %s Scaling %s to 1\n", tui.MutedStyle.Render(tui.IconArrow), deploy.Name)
-					if err := client.ScaleDeployment(ctx, ns, deploy.Name, 1); err != nil {
-						fmt.Printf("    %s Failed to scale %s: %v\n", tui.WarningStyle.Render(tui.IconWarn), deploy.Name, err)
-					}
+					mp.Cluster(fmt.Sprintf("scale deployment %s/%s to 1", ns, deploy.Name), func() error {
+						fmt.Printf("    %s Scaling %s to 1\n", tui.MutedStyle.Render(tui.IconArrow), deploy.Name)
+						if err := client.ScaleDeployment(ctx, ns, deploy.Name, 1); err != nil {
+							fmt.Printf("    %s Failed to scale %s: %v\n", tui.WarningStyle.Render(tui.IconWarn), deploy.Name, err)
+						}
+						return nil
+					})
 				} else {
-					fmt.Printf("    %s %s already has %d replicas\n", tui.MutedStyle.Render(tui.IconCheck), deploy.Name, deploy.Replicas)
+					running = append(running, deploy)
 				}
 
 				if deploy.ArgoApp != "" {
-					fmt.Printf("    %s Re-enabling auto-sync for %s\n", tui.MutedStyle.Render(tui.IconArrow), deploy.ArgoApp)
-					_ = client.EnableArgoAutoSync(ctx, "argocd", deploy.ArgoApp)
+					mp.Cluster("enable ArgoCD auto-sync for "+deploy.ArgoApp, func() error {
+						fmt.Printf("    %s Re-enabling auto-sync for %s\n", tui.MutedStyle.Render(tui.IconArrow), deploy.ArgoApp)
+						_ = client.EnableArgoAutoSync(ctx, "argocd", deploy.ArgoApp)
+						return nil
+					})
 				}
 			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+
+			fmt.Printf("\n  %s Scaling up namespace %s\n\n", tui.InfoStyle.Render(tui.IconArrow), ns)
+			for _, deploy := range running {
+				fmt.Printf("    %s %s already has %d replicas\n", tui.MutedStyle.Render(tui.IconCheck), deploy.Name, deploy.Replicas)
+			}
+			if err := mp.Execute(); err != nil {
+				return err
+			}
 
 			fmt.Printf("\n  %s All deployments in %s scaled up\n", tui.SuccessStyle.Render(tui.IconCheck), ns)
 			return nil
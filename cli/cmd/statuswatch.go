@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// Watch dashboard sections, in display order.
+const (
+	watchNodes = iota
+	watchArgoCD
+	watchPromises
+	watchVClusters
+	watchWorkloads
+	watchAddons
+	watchSectionCount
+)
+
+// runStatusDashboardWatch runs the full-screen 'hctl status --watch' view.
+func runStatusDashboardWatch(cfg *config.Config) error {
+	if !tui.IsInteractive() {
+		return hcerrors.NewUserError("--watch needs a terminal").
+			WithRemediation("use 'hctl status --watch -o json' for a stream of snapshots")
+	}
+	var client *kube.Client
+	return tui.RunWatchDashboard(tui.WatchOpts{
+		Title:    tui.IconPlay + " Platform Status",
+		Interval: watchInterval,
+		Sections: statusWatchSections(),
+		Collect: func() []tui.WatchData {
+			if client == nil {
+				c, err := kube.NewClient(cfg.KubeContext)
+				if err != nil {
+					return failAll(fmt.Errorf("connecting to cluster: %w", err))
+				}
+				client = c
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return collectStatusWatch(ctx, client, cfg.Platform.PlatformNamespace)
+		},
+	})
+}
+
+func statusWatchSections() []tui.WatchSection {
+	sections := make([]tui.WatchSection, watchSectionCount)
+	sections[watchNodes] = tui.WatchSection{
+		Title:   "Nodes",
+		Headers: []string{"NAME", "READY", "IP", "ROLES", "CPU", "MEMORY"},
+		Style: func(col int, v string) string {
+			if col == 1 {
+				return tui.StatusIcon(v == "Ready") + " " + v
+			}
+			return v
+		},
+	}
+	sections[watchArgoCD] = tui.WatchSection{
+		Title:   "ArgoCD (not synced or healthy)",
+		Headers: []string{"NAME", "SYNC", "HEALTH"},
+		Style:   styleArgoCell(1, 2),
+	}
+	sections[watchPromises] = tui.WatchSection{
+		Title:   "Promises",
+		Headers: []string{"PROMISE", "STATUS"},
+		Style: func(col int, v string) string {
+			switch {
+			case col != 1:
+				return v
+			case v == "Available":
+				return tui.SuccessStyle.Render(v)
+			case v == "Unavailable":
+				return tui.ErrorStyle.Render(v)
+			}
+			return tui.DimStyle.Render(v)
+		},
+	}
+	sections[watchVClusters] = tui.WatchSection{
+		Title:   "vClusters",
+		Headers: []string{"NAME", "PHASE", "SYNC", "HEALTH", "PODS"},
+		Style:   stylePhaseCell(1, styleArgoCell(2, 3)),
+	}
+	sections[watchWorkloads] = tui.WatchSection{
+		Title:   "Workloads",
+		Headers: []string{"NAME", "CLUSTER", "NAMESPACE", "SYNC", "STATUS"},
+		Key:     2,
+		Style:   stylePhaseCell(4, styleArgoCell(3, -1)),
+	}
+	sections[watchAddons] = tui.WatchSection{
+		Title:   "Addons",
+		Headers: []string{"ENVIRONMENT", "NAME", "NAMESPACE", "SYNC", "STATUS"},
+		Key:     2,
+		Style:   stylePhaseCell(4, styleArgoCell(3, -1)),
+	}
+	return sections
+}
+
+// collectStatusWatch takes one snapshot of every section. vClusters,
+// workloads and addons share a single CollectPlatformStatus call.
+func collectStatusWatch(ctx context.Context, client *kube.Client, platformNS string) []tui.WatchData {
+	data := make([]tui.WatchData, watchSectionCount)
+
+	if nodes, err := client.ListNodes(ctx); err != nil {
+		data[watchNodes].Err = err
+	} else {
+		for _, n := range nodes {
+			ready := "NotReady"
+			if n.Ready {
+				ready = "Ready"
+			}
+			data[watchNodes].Rows = append(data[watchNodes].Rows, []string{n.Name, ready, n.IP, strings.Join(n.Roles, ","), n.CPU, n.Memory})
+		}
+	}
+
+	if apps, err := client.ListArgoApps(ctx, "argocd"); err != nil {
+		data[watchArgoCD].Err = err
+	} else {
+		synced, healthy := 0, 0
+		for _, app := range apps {
+			argo := platform.ArgoCDInfoFromApp(&app)
+			if argo.SyncStatus == "Synced" {
+				synced++
+			}
+			if argo.HealthStatus == "Healthy" {
+				healthy++
+			}
+			if argo.SyncStatus != "Synced" || argo.HealthStatus != "Healthy" {
+				data[watchArgoCD].Rows = append(data[watchArgoCD].Rows, []string{app.GetName(), argo.SyncStatus, argo.HealthStatus})
+			}
+		}
+		data[watchArgoCD].Summary = fmt.Sprintf("  Total: %d  │  Synced: %d  │  Healthy: %d", len(apps), synced, healthy)
+	}
+
+	if promises, err := client.ListPromises(ctx); err != nil {
+		data[watchPromises].Err = err
+	} else {
+		for _, p := range promises {
+			data[watchPromises].Rows = append(data[watchPromises].Rows, []string{p.GetName(), platform.PromiseStatus(p.Object)})
+		}
+	}
+
+	ps, err := platform.CollectPlatformStatus(ctx, client, platformNS)
+	if err != nil {
+		// Without the ArgoCD apps the snapshot is partial; vClusters come
+		// from their CRs and are still shown.
+		data[watchWorkloads].Err = err
+		data[watchAddons].Err = err
+	}
+	if ps != nil {
+		for _, vc := range ps.VClusters {
+			pods := fmt.Sprintf("%d/%d", vc.Pods.Ready, vc.Pods.Total)
+			data[watchVClusters].Rows = append(data[watchVClusters].Rows,
+				[]string{vc.Name, vc.Phase, tui.OrDash(vc.ArgoCD.SyncStatus), tui.OrDash(vc.ArgoCD.HealthStatus), pods})
+		}
+		for _, w := range ps.Workloads {
+			data[watchWorkloads].Rows = append(data[watchWorkloads].Rows,
+				[]string{w.Name, w.Labels["clusterName"], w.Namespace, w.ArgoCD.SyncStatus, w.Phase})
+		}
+		for _, a := range ps.Addons {
+			env := a.Labels["environment"]
+			if env == "" {
+				env = "(unset)"
+			}
+			data[watchAddons].Rows = append(data[watchAddons].Rows,
+				[]string{env, a.Name, a.Namespace, a.ArgoCD.SyncStatus, a.Phase})
+		}
+	}
+
+	for i := range data {
+		sortRows(data[i].Rows)
+	}
+	return data
+}
+
+// failAll reports err for every section.
+func failAll(err error) []tui.WatchData {
+	data := make([]tui.WatchData, watchSectionCount)
+	for i := range data {
+		data[i].Err = err
+	}
+	return data
+}
+
+// sortRows orders rows by their cells so refreshes render stably.
+func sortRows(rows [][]string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return strings.Join(rows[i], "\x00") < strings.Join(rows[j], "\x00")
+	})
+}
+
+// styleArgoCell colours ArgoCD sync and health columns; -1 skips one.
+func styleArgoCell(syncCol, healthCol int) func(int, string) string {
+	return func(col int, v string) string {
+		switch {
+		case col == syncCol && v == "Synced", col == healthCol && v == "Healthy":
+			return tui.SuccessStyle.Render(v)
+		case col == healthCol && v == "Degraded":
+			return tui.ErrorStyle.Render(v)
+		case col == syncCol || col == healthCol:
+			return tui.WarningStyle.Render(v)
+		}
+		return v
+	}
+}
+
+// stylePhaseCell renders phaseCol with phaseStyled and defers other columns
+// to next.
+func stylePhaseCell(phaseCol int, next func(int, string) string) func(int, string) string {
+	return func(col int, v string) string {
+		if col == phaseCol {
+			return phaseStyled(v)
+		}
+		return next(col, v)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/jamesatintegratnio/hctl/internal/config"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
@@ -54,27 +55,31 @@ func runTrace(cmd *cobra.Command, args []string) error {
 	// Stage 1: Kratix ResourceRequest (VClusterOrchestratorV2)
 	vc, vcErr := client.GetVCluster(ctx, cfg.Platform.PlatformNamespace, name)
 	if vcErr == nil {
-		phase, _, _ := platform.UnstructuredNestedString(vc.Object, "status", "phase")
-		if phase == "" {
-			phase = "Unknown"
+		reported, pipelineMsg := platform.PhaseFromStatus(vc.Object)
+		if reported == "" {
+			reported = phase.Unknown
 		}
 		hops = append(hops, traceHop{
 			Stage:   "ResourceRequest",
-			Status:  phase,
+			Status:  reported,
 			Details: fmt.Sprintf("platform.integratn.tech/v1alpha1 VClusterOrchestratorV2 in %s", cfg.Platform.PlatformNamespace),
 		})
 
-		// Stage 2: Pipeline Job
-		pipelineMsg, _, _ := platform.UnstructuredNestedString(vc.Object, "status", "message")
-		conditions, _, _ := platform.UnstructuredNestedSlice(vc.Object, "status", "conditions")
+		// Stage 2: Pipeline Job. ResourcesRendered is the pipeline's own
+		// condition; older resources only have the aggregate list.
+		conditions := platform.StatusConditions(vc.Object)
 		pipelineStatus := "Unknown"
-		if len(conditions) > 0 {
+		if rendered, ok := platform.FindCondition(conditions, platform.ConditionResourcesRendered); ok {
+			pipelineStatus = "InProgress"
+			if rendered.Status == "True" {
+				pipelineStatus = "Completed"
+			}
+			pipelineMsg = rendered.Message
+		} else if len(conditions) > 0 {
 			pipelineStatus = "Completed"
 			for _, c := range conditions {
-				if cm, ok := c.(map[string]interface{}); ok {
-					if s, ok := cm["status"].(string); ok && s != "True" {
-						pipelineStatus = "InProgress"
-					}
+				if c.Status != "True" {
+					pipelineStatus = "InProgress"
 				}
 			}
 		}
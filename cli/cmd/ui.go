@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var uiRefresh time.Duration
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse clusters, workloads, pods and logs in a full-screen view",
+	Long: `Opens a full-screen browser of the platform. Start from the vClusters (and
+the other clusters addons target), press enter to see a cluster's workloads
+and addons with their sync and health, again for an app's pods and their
+warning events, and l to follow a pod's logs. esc goes back.
+
+From any pane, s syncs the selected app's ArgoCD Application, R re-runs the
+pipeline of the vCluster under the cursor, and o opens the app's route (or a
+vCluster's ArgoCD). Each asks for confirmation first.
+
+The pane on screen reloads every --refresh. When the cluster cannot be
+reached, the last rows loaded stay on screen, marked stale.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !tui.IsInteractive() {
+			return hcerrors.NewUserError("hctl ui needs a terminal").
+				WithRemediation("use 'hctl status' for a one-off summary")
+		}
+		cfg := config.Get()
+		src := ui.NewKubeSource(ui.KubeOptions{
+			KubeContext:       cfg.KubeContext,
+			PlatformNamespace: cfg.Platform.PlatformNamespace,
+			RepoPath:          cfg.RepoPath,
+			StatusTTL:         uiRefresh / 2,
+			Open:              openBrowser,
+		})
+		return ui.Run(src, uiRefresh)
+	},
+}
+
+func init() {
+	uiCmd.Flags().DurationVar(&uiRefresh, "refresh", 15*time.Second, "how often the pane on screen reloads")
+}
@@ -40,7 +40,7 @@ Highlights:
 			clusterName := name
 			apps, err := client.ListArgoAppsForCluster(ctx, "argocd", clusterName)
 			if err != nil {
-				return fmt.Errorf("listing apps for cluster %s: %w", clusterName, err)
+				return kube.ClassifyError(fmt.Errorf("listing apps for cluster %s: %w", clusterName, err))
 			}
 
 			if len(apps) == 0 {
@@ -2,16 +2,21 @@ package vcluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/git"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -29,10 +34,18 @@ var (
 	createK8sVersion    string
 	createIsolationMode string
 
+	// Namespace quota (strict isolation derives one when these are unset)
+	createQuotaCPU        string
+	createQuotaMemory     string
+	createQuotaPods       int
+	createQuotaMultiplier int
+
 	// Exposure
-	createSubnet  string
-	createVIP     string
-	createAPIPort int
+	createSubnet         string
+	createVIP            string
+	createIPFamilies     []string
+	createIPFamilyPolicy string
+	createAPIPort        int
 
 	// Persistence
 	createPersistence     bool
@@ -44,22 +57,35 @@ var (
 	createExtraEgress  []string // "name:cidr:port[:protocol]"
 	createCoreDNSReplicas int
 
+	// MetalLB pool inside the vCluster
+	createLBPool       string
+	createLBAutoAssign bool
+	createLBInterfaces []string
+
 	// Workload repo
 	createWorkloadRepoURL      string
 	createWorkloadRepoBasePath string
 	createWorkloadRepoPath     string
 	createWorkloadRepoRevision string
+	createOffline              bool
 
 	// ArgoCD cluster
 	createClusterLabels      []string // "key=value"
 	createClusterAnnotations []string // "key=value"
 
+	// Objects applied inside the vCluster once it is up
+	createBootstrap []string // repo-relative files or directories
+
 	// ArgoCD app overrides
 	createChartVersion string
 
 	// Provisioning wait
 	createWait    bool
 	createTimeout int // seconds
+
+	// Local pipeline run before anything is written
+	createDryRunPipeline bool
+	createPipelineImage  string
 )
 
 func newCreateCmd() *cobra.Command {
@@ -69,7 +95,23 @@ func newCreateCmd() *cobra.Command {
 		Long: `Create a new vCluster on the platform.
 
 In interactive mode (default), a guided wizard walks through configuration.
-Use flags for non-interactive/scripted usage.
+Each answer is validated as it is entered; press esc to go back a step.
+Before the manifest preview, a review screen lets you edit any field.
+Use flags for non-interactive/scripted usage — the same validation applies.
+
+With any --workload-repo-* flag, the workload repo is checked before the
+request is written: the URL must answer git ls-remote with your credentials,
+the revision must be a branch, tag or full commit hash, and the base path
+plus path must be a directory at that revision. --offline skips the check.
+
+--bootstrap names repo-relative YAML files, or directories of them, whose
+objects are inlined into spec.bootstrap.manifests. The pipeline applies them
+inside the vCluster once its API server answers, in sync-wave order.
+
+--dry-run-pipeline runs the orchestrator's configure pipeline on the request
+in its container image (docker or podman) before anything is written. It
+lists the objects the pipeline renders and its key settings next to the
+CLI's, and stops if the pipeline rejects the request.
 
 Examples:
   # Quick dev cluster
@@ -84,11 +126,33 @@ Examples:
     --workload-repo-url https://github.com/myorg/team-api-workloads \
     --workload-repo-path deploy/k8s --workload-repo-revision main
 
+  # Same, before the workload repo is pushed
+  hctl vcluster create team-api --preset dev --offline \
+    --workload-repo-url https://github.com/myorg/team-api-workloads
+
+  # Dual-stack API VIPs (the IPv4 one auto-selected from its subnet)
+  hctl vcluster create media --preset prod \
+    --subnet 10.0.4.0/24,fd00:4::/64 --vip fd00:4::10 --ip-families IPv6,IPv4
+
+  # Strict isolation with a namespace quota (unset values are derived
+  # from the control plane's requests)
+  hctl vcluster create shared --preset dev --isolation strict --quota-memory 8Gi
+
+  # Own address pool for LoadBalancer services inside the vCluster
+  hctl vcluster create media --preset prod --lb-pool 10.0.5.16/28
+
   # Custom egress rules for database access
   hctl vcluster create data-team --preset dev \
     --extra-egress postgres:10.0.1.50/32:5432 \
     --extra-egress redis:10.0.1.60/32:6379:TCP
 
+  # Bootstrap objects applied inside the vCluster once it is up
+  hctl vcluster create team-api --preset dev \
+    --bootstrap platform/bootstrap/team-api --bootstrap platform/bootstrap/oidc-rbac.yaml
+
+  # Check the request against the pipeline before committing it
+  hctl vcluster create my-prod --preset prod --dry-run-pipeline
+
   # Interactive wizard (walks through all options)
   hctl vcluster create`,
 		Args: cobra.MaximumNArgs(1),
@@ -105,10 +169,16 @@ Examples:
 	// vCluster settings
 	cmd.Flags().StringVar(&createK8sVersion, "k8s-version", "", "Kubernetes version (v1.34.3, 1.34, 1.33, 1.32)")
 	cmd.Flags().StringVar(&createIsolationMode, "isolation", "", "workload isolation mode (standard, strict)")
+	cmd.Flags().StringVar(&createQuotaCPU, "quota-cpu", "", "namespace quota on CPU requests, control plane included (default derived from the control plane)")
+	cmd.Flags().StringVar(&createQuotaMemory, "quota-memory", "", "namespace quota on memory requests, control plane included (default derived from the control plane)")
+	cmd.Flags().IntVar(&createQuotaPods, "quota-pods", 0, "namespace quota on pods, control plane included (default derived from the control plane)")
+	cmd.Flags().IntVar(&createQuotaMultiplier, "quota-multiplier", 0, "room for synced workloads in the derived quota, as a multiple of the control plane's requests (default 4)")
 
 	// Exposure
-	cmd.Flags().StringVar(&createSubnet, "subnet", "", "CIDR subnet for VIP allocation (e.g. 10.0.4.0/24)")
-	cmd.Flags().StringVar(&createVIP, "vip", "", "static VIP for the vCluster API (e.g. 10.0.4.210)")
+	cmd.Flags().StringVar(&createSubnet, "subnet", "", "CIDR subnet for VIP allocation, IPv4 or IPv6; one of each comma-separated for dual-stack (e.g. 10.0.4.0/24,fd00:4::/64)")
+	cmd.Flags().StringVar(&createVIP, "vip", "", "static VIP for the vCluster API, one per family for dual-stack (e.g. 10.0.4.210 or fd00:4::d2)")
+	cmd.Flags().StringSliceVar(&createIPFamilies, "ip-families", nil, "IP families of the API Service, primary first (IPv4, IPv6; default from the VIPs)")
+	cmd.Flags().StringVar(&createIPFamilyPolicy, "ip-family-policy", "", "IP family policy of the API Service (SingleStack, PreferDualStack, RequireDualStack)")
 	cmd.Flags().IntVar(&createAPIPort, "api-port", 443, "API port exposed by the vCluster service")
 
 	// Persistence
@@ -120,17 +190,24 @@ Examples:
 	cmd.Flags().BoolVar(&createEnableNFS, "enable-nfs", false, "enable NFS egress network policy")
 	cmd.Flags().StringSliceVar(&createExtraEgress, "extra-egress", nil, "extra egress rule as name:cidr:port[:protocol] (repeatable)")
 	cmd.Flags().IntVar(&createCoreDNSReplicas, "coredns-replicas", 0, "CoreDNS replica count (overrides preset default)")
+	cmd.Flags().StringVar(&createLBPool, "lb-pool", "", "MetalLB address pool for LoadBalancer services inside the vCluster (CIDR or first-last range)")
+	cmd.Flags().BoolVar(&createLBAutoAssign, "lb-auto-assign", true, "assign --lb-pool addresses to services that do not request one")
+	cmd.Flags().StringSliceVar(&createLBInterfaces, "lb-interface", nil, "node interface to announce --lb-pool on (repeatable; default all)")
 
 	// Workload repo
 	cmd.Flags().StringVar(&createWorkloadRepoURL, "workload-repo-url", "", "Git URL for workload definitions (default: same repo)")
 	cmd.Flags().StringVar(&createWorkloadRepoBasePath, "workload-repo-base-path", "", "base path prefix in workload repo")
 	cmd.Flags().StringVar(&createWorkloadRepoPath, "workload-repo-path", "", "path within repo to workload manifests (default: workloads)")
 	cmd.Flags().StringVar(&createWorkloadRepoRevision, "workload-repo-revision", "", "Git branch/tag for workload repo (default: main)")
+	cmd.Flags().BoolVar(&createOffline, "offline", false, "skip checking that the workload repo, revision and path exist")
 
 	// ArgoCD cluster metadata
 	cmd.Flags().StringSliceVar(&createClusterLabels, "cluster-label", nil, "additional ArgoCD cluster label as key=value (repeatable)")
 	cmd.Flags().StringSliceVar(&createClusterAnnotations, "cluster-annotation", nil, "additional ArgoCD cluster annotation as key=value (repeatable)")
 
+	// Bootstrap
+	cmd.Flags().StringSliceVar(&createBootstrap, "bootstrap", nil, "repo-relative YAML file or directory of objects to apply inside the vCluster once it is up (repeatable)")
+
 	// ArgoCD app overrides
 	cmd.Flags().StringVar(&createChartVersion, "chart-version", "", "vCluster Helm chart version (default: platform default)")
 
@@ -138,6 +215,10 @@ Examples:
 	cmd.Flags().BoolVar(&createWait, "wait", true, "wait for provisioning to complete after commit")
 	cmd.Flags().IntVar(&createTimeout, "timeout", 300, "timeout in seconds when using --wait (default: 300)")
 
+	// Local pipeline run
+	cmd.Flags().BoolVar(&createDryRunPipeline, "dry-run-pipeline", false, "run the orchestrator pipeline locally on the request before writing it")
+	cmd.Flags().StringVar(&createPipelineImage, "pipeline-image", platform.PipelineImage, "pipeline image for --dry-run-pipeline")
+
 	return cmd
 }
 
@@ -150,40 +231,28 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		name = args[0]
 	}
 
-	// ── Name ──────────────────────────────────────────────────────────
-	if interactive && name == "" {
-		val, err := tui.Input("vCluster name", "e.g. my-project", "")
-		if err != nil {
-			return err
+	if name != "" {
+		if err := platform.ValidateVClusterName(name, ""); err != nil {
+			return hcerrors.NewUserError("%v", err)
 		}
-		if val == "" {
-			return fmt.Errorf("name is required")
+	}
+
+	// ── Interactive wizard ───────────────────────────────────────────
+	// The wizard writes its answers into the flags below, so the rest of
+	// this function builds the spec the same way in both modes.
+	if interactive {
+		if err := runWizard(createWizard(cmd, cfg, &name, name != "")); err != nil {
+			return err
 		}
-		name = val
 	}
 	if name == "" {
-		return fmt.Errorf("name is required")
+		return hcerrors.NewUserError("name is required")
+	}
+	if err := validateCreateFlags(cfg, name, interactive); err != nil {
+		return err
 	}
 
-	// ── Preset ────────────────────────────────────────────────────────
 	preset := createPreset
-	if interactive && preset == "" {
-		idx, err := tui.Select("Select preset", []string{
-			"dev  — 1 replica, 768Mi, SQLite, no persistence",
-			"prod — 3 replicas, 2Gi, etcd HA, 10Gi persistence",
-		})
-		if err != nil {
-			return err
-		}
-		if idx < 0 {
-			return fmt.Errorf("cancelled")
-		}
-		if idx == 0 {
-			preset = "dev"
-		} else {
-			preset = "prod"
-		}
-	}
 	if preset == "" {
 		preset = "dev"
 	}
@@ -198,7 +267,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	if err := platform.ApplyPreset(&spec, preset); err != nil {
-		return err
+		return hcerrors.Wrap(hcerrors.ErrUsage, err)
 	}
 
 	// ── K8s version ───────────────────────────────────────────────────
@@ -216,16 +285,19 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		spec.VCluster.IsolationMode = createIsolationMode
 	}
 
-	// ── Hostname ──────────────────────────────────────────────────────
-	hostname := createHostname
-	if interactive && hostname == "" {
-		defaultHost := fmt.Sprintf("%s.%s", name, cfg.Platform.Domain)
-		val, err := tui.Input("External hostname", "e.g. "+defaultHost, defaultHost)
-		if err != nil {
-			return err
+	// ── Namespace quota ──────────────────────────────────────────────
+	// Strict isolation always gets one; the pipeline derives what is unset.
+	if createQuotaCPU != "" || createQuotaMemory != "" || createQuotaPods > 0 || createQuotaMultiplier > 0 {
+		spec.VCluster.Quota = &platform.QuotaConfig{
+			CPU:                createQuotaCPU,
+			Memory:             createQuotaMemory,
+			Pods:               createQuotaPods,
+			WorkloadMultiplier: createQuotaMultiplier,
 		}
-		hostname = val
 	}
+
+	// ── Hostname ──────────────────────────────────────────────────────
+	hostname := createHostname
 	if hostname == "" {
 		hostname = fmt.Sprintf("%s.%s", name, cfg.Platform.Domain)
 	}
@@ -241,6 +313,8 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	if createVIP != "" {
 		spec.Exposure.VIP = createVIP
 	}
+	spec.Exposure.IPFamilies = createIPFamilies
+	spec.Exposure.IPFamilyPolicy = createIPFamilyPolicy
 
 	// ── Persistence ──────────────────────────────────────────────────
 	if cmd.Flags().Changed("persistence") || createPersistenceSize != "" || createStorageClass != "" {
@@ -263,11 +337,21 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		spec.VCluster.CoreDNS = &platform.CoreDNSConfig{Replicas: createCoreDNSReplicas}
 	}
 
-	// ── NFS ──────────────────────────────────────────────────────────
-	if interactive && !cmd.Flags().Changed("enable-nfs") {
-		confirmed, _ := tui.Confirm("Enable NFS egress?")
-		createEnableNFS = confirmed
+	// ── Load balancer pool ───────────────────────────────────────────
+	if createLBPool != "" {
+		if spec.VCluster.Networking == nil {
+			spec.VCluster.Networking = &platform.NetworkingConfig{}
+		}
+		spec.VCluster.Networking.LoadBalancer = &platform.LoadBalancerConfig{
+			AddressPool:  createLBPool,
+			L2Interfaces: createLBInterfaces,
+		}
+		if cmd.Flags().Changed("lb-auto-assign") {
+			spec.VCluster.Networking.LoadBalancer.AutoAssign = &createLBAutoAssign
+		}
 	}
+
+	// ── NFS ──────────────────────────────────────────────────────────
 	spec.NetworkPolicies.EnableNFS = createEnableNFS
 
 	// ── Extra egress rules ───────────────────────────────────────────
@@ -286,124 +370,6 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		spec.Integrations.ArgoCD.Environment = createEnvironment
 	}
 
-	// ── Advanced settings gate ───────────────────────────────────────
-	// Only prompt for k8s version, isolation, environment, persistence,
-	// subnet/VIP, and CoreDNS if the user opts in. All of these can
-	// still be set directly via flags without the gate.
-	if interactive {
-		advanced, _ := tui.Confirm("Customize advanced settings? (k8s version, isolation, environment, persistence, networking)")
-		if advanced {
-			// K8s version
-			if !cmd.Flags().Changed("k8s-version") {
-				idx, err := tui.Select("Kubernetes version", []string{
-					"v1.34.3 (default)",
-					"1.33",
-					"1.32",
-				})
-				if err != nil {
-					return err
-				}
-				switch idx {
-				case 1:
-					spec.VCluster.K8sVersion = "1.33"
-				case 2:
-					spec.VCluster.K8sVersion = "1.32"
-				}
-			}
-
-			// Isolation mode
-			if !cmd.Flags().Changed("isolation") {
-				idx, err := tui.Select("Isolation mode", []string{
-					"standard — shared kernel, namespace isolation (default)",
-					"strict   — resource quotas, limit ranges, network policies",
-				})
-				if err != nil {
-					return err
-				}
-				if idx == 1 {
-					spec.VCluster.IsolationMode = "strict"
-				}
-			}
-
-			// Environment
-			if spec.Integrations.ArgoCD != nil && !cmd.Flags().Changed("environment") {
-				idx, err := tui.Select("ArgoCD environment", []string{
-					"production",
-					"staging",
-					"development",
-				})
-				if err != nil {
-					return err
-				}
-				switch idx {
-				case 0:
-					spec.Integrations.ArgoCD.Environment = "production"
-				case 1:
-					spec.Integrations.ArgoCD.Environment = "staging"
-				case 2:
-					spec.Integrations.ArgoCD.Environment = "development"
-				}
-			}
-
-			// Persistence
-			if !cmd.Flags().Changed("persistence") && !cmd.Flags().Changed("persistence-size") {
-				enablePersist, _ := tui.Confirm(fmt.Sprintf("Enable persistence? (preset default: %v)",
-					spec.VCluster.Persistence != nil && spec.VCluster.Persistence.Enabled))
-				if enablePersist {
-					if spec.VCluster.Persistence == nil {
-						spec.VCluster.Persistence = &platform.PersistenceConfig{}
-					}
-					spec.VCluster.Persistence.Enabled = true
-					size, err := tui.Input("Persistence size", "e.g. 10Gi", "10Gi")
-					if err != nil {
-						return err
-					}
-					if size != "" {
-						spec.VCluster.Persistence.Size = size
-					}
-				}
-			}
-
-			// Subnet / VIP
-			if !cmd.Flags().Changed("subnet") {
-				subnet, err := tui.Input("VIP subnet (optional)", "e.g. 10.0.4.0/24", "")
-				if err != nil {
-					return err
-				}
-				if subnet != "" {
-					spec.Exposure.Subnet = subnet
-					if !cmd.Flags().Changed("vip") {
-						vip, err := tui.Input("Static VIP (optional, auto-assigned from subnet if empty)", "e.g. 10.0.4.210", "")
-						if err != nil {
-							return err
-						}
-						if vip != "" {
-							spec.Exposure.VIP = vip
-						}
-					}
-				}
-			}
-
-			// CoreDNS replicas
-			if !cmd.Flags().Changed("coredns-replicas") {
-				currentReplicas := 1
-				if spec.VCluster.CoreDNS != nil {
-					currentReplicas = spec.VCluster.CoreDNS.Replicas
-				}
-				val, err := tui.Input("CoreDNS replicas", "", fmt.Sprintf("%d", currentReplicas))
-				if err != nil {
-					return err
-				}
-				if val != "" && val != fmt.Sprintf("%d", currentReplicas) {
-					var r int
-					if _, err := fmt.Sscanf(val, "%d", &r); err == nil && r > 0 {
-						spec.VCluster.CoreDNS = &platform.CoreDNSConfig{Replicas: r}
-					}
-				}
-			}
-		}
-	}
-
 	// ── Cluster labels / annotations ─────────────────────────────────
 	if len(createClusterLabels) > 0 && spec.Integrations.ArgoCD != nil {
 		if spec.Integrations.ArgoCD.ClusterLabels == nil {
@@ -412,7 +378,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		for _, kv := range createClusterLabels {
 			k, v, err := parseKeyValue(kv)
 			if err != nil {
-				return fmt.Errorf("invalid --cluster-label %q: %w", kv, err)
+				return hcerrors.NewUserError("invalid --cluster-label %q: %w", kv, err)
 			}
 			spec.Integrations.ArgoCD.ClusterLabels[k] = v
 		}
@@ -424,7 +390,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		for _, kv := range createClusterAnnotations {
 			k, v, err := parseKeyValue(kv)
 			if err != nil {
-				return fmt.Errorf("invalid --cluster-annotation %q: %w", kv, err)
+				return hcerrors.NewUserError("invalid --cluster-annotation %q: %w", kv, err)
 			}
 			spec.Integrations.ArgoCD.ClusterAnnotations[k] = v
 		}
@@ -434,46 +400,6 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	hasWorkloadFlags := createWorkloadRepoURL != "" || createWorkloadRepoBasePath != "" ||
 		createWorkloadRepoPath != "" || createWorkloadRepoRevision != ""
 
-	if interactive && !hasWorkloadFlags {
-		confirmed, _ := tui.Confirm("Use a custom workload repository? (default: workloads/ in this repo)")
-		if confirmed {
-			url, err := tui.Input("Workload repo URL", "e.g. https://github.com/myorg/my-workloads", "")
-			if err != nil {
-				return err
-			}
-			if url != "" {
-				createWorkloadRepoURL = url
-			}
-
-			basePath, err := tui.Input("Base path in repo (optional)", "e.g. clusters/dev-team-1", "")
-			if err != nil {
-				return err
-			}
-			if basePath != "" {
-				createWorkloadRepoBasePath = basePath
-			}
-
-			path, err := tui.Input("Workload path", "directory containing manifests", "workloads")
-			if err != nil {
-				return err
-			}
-			if path != "" {
-				createWorkloadRepoPath = path
-			}
-
-			rev, err := tui.Input("Git revision (branch/tag)", "", "main")
-			if err != nil {
-				return err
-			}
-			if rev != "" {
-				createWorkloadRepoRevision = rev
-			}
-
-			hasWorkloadFlags = createWorkloadRepoURL != "" || createWorkloadRepoBasePath != "" ||
-				createWorkloadRepoPath != "" || createWorkloadRepoRevision != ""
-		}
-	}
-
 	if hasWorkloadFlags && spec.Integrations.ArgoCD != nil {
 		spec.Integrations.ArgoCD.WorkloadRepo = &platform.WorkloadRepoConfig{}
 		if createWorkloadRepoURL != "" {
@@ -484,10 +410,35 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 		if createWorkloadRepoPath != "" {
 			spec.Integrations.ArgoCD.WorkloadRepo.Path = createWorkloadRepoPath
+		} else if createWorkloadRepoURL == "" && layout.Active().Version() != layout.V1 {
+			spec.Integrations.ArgoCD.WorkloadRepo.Path = layout.Active().WorkloadsRootFor(createEnvironment)
 		}
 		if createWorkloadRepoRevision != "" {
 			spec.Integrations.ArgoCD.WorkloadRepo.Revision = createWorkloadRepoRevision
 		}
+		if !createOffline {
+			if err := checkWorkloadRepo(*spec.Integrations.ArgoCD.WorkloadRepo); err != nil {
+				return err
+			}
+		}
+	} else if spec.Integrations.ArgoCD != nil && layout.Active().Version() != layout.V1 {
+		// The orchestrator defaults the path to the v1 workloads root; point
+		// it at the environment's workloads in this repo's layout.
+		spec.Integrations.ArgoCD.WorkloadRepo = &platform.WorkloadRepoConfig{
+			Path: layout.Active().WorkloadsRootFor(createEnvironment),
+		}
+	}
+
+	// ── Bootstrap manifests ──────────────────────────────────────────
+	if len(createBootstrap) > 0 {
+		if err := cfg.RequireRepoPath(); err != nil {
+			return err
+		}
+		manifests, err := platform.LoadBootstrapManifests(cfg.RepoPath, createBootstrap)
+		if err != nil {
+			return err
+		}
+		spec.Bootstrap = &platform.BootstrapConfig{Manifests: manifests}
 	}
 
 	// ── Chart version override ───────────────────────────────────────
@@ -497,58 +448,149 @@ func runCreate(cmd *cobra.Command, args []string) error {
 
 	// ── Prod preset extras (helmOverrides for etcd certs) ────────────
 	if preset == "prod" {
-		spec.VCluster.HelmOverrides = map[string]interface{}{
-			"controlPlane": map[string]interface{}{
-				"statefulSet": map[string]interface{}{
-					"persistence": map[string]interface{}{
-						"addVolumes": []interface{}{
-							map[string]interface{}{
-								"name": "etcd-certs",
-								"secret": map[string]interface{}{
-									"secretName": name + "-etcd-certs",
-								},
+		spec.VCluster.HelmOverrides = prodHelmOverrides(name, spec.VCluster.Replicas)
+	}
+
+	// ── Write and commit ─────────────────────────────────────────────
+	gitMode := cfg.GitMode
+	if createAutoCommit {
+		gitMode = "auto"
+	}
+
+	mp, err := requestPlan(cfg, spec, interactive, false, gitMode,
+		fmt.Sprintf("%s, %d replicas", preset, spec.VCluster.Replicas))
+	if err != nil {
+		return err
+	}
+	if createDryRunPipeline {
+		doc, err := yaml.Marshal(platform.NewVClusterResource(spec, cfg.Platform.PlatformNamespace))
+		if err != nil {
+			return fmt.Errorf("marshaling resource: %w", err)
+		}
+		if err := dryRunPipeline(spec, doc, createPipelineImage, nil); err != nil {
+			return err
+		}
+	}
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
+	gitResult, err := writeRequest(mp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", tui.DimStyle.Render("Next: ArgoCD will sync the resource and Kratix will provision the vCluster."))
+	fmt.Printf("%s\n", tui.DimStyle.Render("Monitor with: hctl vcluster status "+name))
+
+	// ── Wait for provisioning ────────────────────────────────────────
+	committed := gitResult == git.GitCommitted
+	if createWait && committed {
+		if err := WatchProvisioning(cfg, name, hostname, time.Duration(createTimeout)*time.Second); err != nil {
+			// Non-fatal — the resource was already committed
+			fmt.Printf("\n%s %s\n", tui.WarningStyle.Render(tui.IconWarn), err.Error())
+			fmt.Printf("%s\n", tui.DimStyle.Render("The request was committed. Check status later: hctl vcluster status "+name))
+		}
+	}
+
+	return nil
+}
+
+// prodHelmOverrides returns the helm overrides the prod preset adds: the
+// etcd certificates mounted into the control plane, and an HA etcd of
+// replicas members.
+func prodHelmOverrides(name string, replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"controlPlane": map[string]interface{}{
+			"statefulSet": map[string]interface{}{
+				"persistence": map[string]interface{}{
+					"addVolumes": []interface{}{
+						map[string]interface{}{
+							"name": "etcd-certs",
+							"secret": map[string]interface{}{
+								"secretName": name + "-etcd-certs",
 							},
 						},
-						"addVolumeMounts": []interface{}{
-							map[string]interface{}{
-								"name":      "etcd-certs",
-								"mountPath": "/etcd-certs",
-								"readOnly":  true,
-							},
+					},
+					"addVolumeMounts": []interface{}{
+						map[string]interface{}{
+							"name":      "etcd-certs",
+							"mountPath": "/etcd-certs",
+							"readOnly":  true,
 						},
 					},
 				},
-				"backingStore": map[string]interface{}{
-					"etcd": map[string]interface{}{
-						"deploy": map[string]interface{}{
-							"enabled": true,
-							"statefulSet": map[string]interface{}{
-								"extraArgs": []string{"--client-cert-auth=false"},
-								"highAvailability": map[string]interface{}{
-									"replicas": spec.VCluster.Replicas,
-								},
+			},
+			"backingStore": map[string]interface{}{
+				"etcd": map[string]interface{}{
+					"deploy": map[string]interface{}{
+						"enabled": true,
+						"statefulSet": map[string]interface{}{
+							"extraArgs": []string{"--client-cert-auth=false"},
+							"highAvailability": map[string]interface{}{
+								"replicas": replicas,
 							},
 						},
 					},
 				},
-				"ingress": map[string]interface{}{
-					"enabled": false,
-				},
 			},
-			"integrations": map[string]interface{}{
-				"metricsServer": map[string]interface{}{
-					"enabled": false,
-				},
+			"ingress": map[string]interface{}{
+				"enabled": false,
 			},
-		}
+		},
+		"integrations": map[string]interface{}{
+			"metricsServer": map[string]interface{}{
+				"enabled": false,
+			},
+		},
+	}
+}
+
+// baseSpec returns the spec every new vCluster starts from before presets
+// and flags are applied.
+func baseSpec(name string) platform.VClusterSpec {
+	return platform.VClusterSpec{
+		Name:            name,
+		TargetNamespace: name,
+		ProjectName:     name,
+		Integrations:    platform.DefaultIntegrations(),
+		ArgocdApp:       platform.DefaultArgocdApp(),
 	}
+}
 
-	// ── Build resource ───────────────────────────────────────────────
+// CreateDev writes a dev-preset vCluster request with default settings and
+// commits it according to gitMode, without prompting. An existing request
+// file is overwritten so a failed commit or push can be retried. It returns
+// the external hostname of the vCluster API.
+func CreateDev(cfg *config.Config, name, gitMode string) (string, git.GitResult, error) {
+	spec := baseSpec(name)
+	if err := platform.ApplyPreset(&spec, "dev"); err != nil {
+		return "", git.GitSkipped, err
+	}
+	hostname := fmt.Sprintf("%s.%s", name, cfg.Platform.Domain)
+	spec.Exposure = platform.ExposureConfig{
+		Hostname: hostname,
+		APIPort:  443,
+	}
+
+	mp, err := requestPlan(cfg, spec, false, true, gitMode,
+		fmt.Sprintf("dev, %d replicas", spec.VCluster.Replicas))
+	if err != nil {
+		return hostname, git.GitSkipped, err
+	}
+	result, err := writeRequest(mp)
+	return hostname, result, err
+}
+
+// requestPlan renders spec as a VClusterOrchestratorV2 resource and plans
+// writing it to platform/vclusters/<name>.yaml, then the git workflow. An
+// existing file is replaced when overwrite is set or the user confirms.
+func requestPlan(cfg *config.Config, spec platform.VClusterSpec, interactive, overwrite bool, gitMode, details string) (*mutation.Plan, error) {
+	name := spec.Name
 	resource := platform.NewVClusterResource(spec, cfg.Platform.PlatformNamespace)
 
 	data, err := yaml.Marshal(resource)
 	if err != nil {
-		return fmt.Errorf("marshaling resource: %w", err)
+		return nil, fmt.Errorf("marshaling resource: %w", err)
 	}
 
 	// Show preview
@@ -556,82 +598,77 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	fmt.Println(tui.DimStyle.Render("---"))
 	fmt.Println(string(data))
 
-	// Write file
 	repoPath := cfg.RepoPath
 	if repoPath == "" {
 		repo, err := git.DetectRepo("")
 		if err != nil {
-			return fmt.Errorf("cannot detect repo — run 'hctl init' first or set repoPath in config")
+			return nil, hcerrors.NewUserError("cannot detect repo — run 'hctl init' first or set repoPath in config")
 		}
 		repoPath = repo.Root
 	}
 
-	outPath := filepath.Join(repoPath, "platform", "vclusters", name+".yaml")
-	if _, err := os.Stat(outPath); err == nil {
+	guard, err := policy.ForRepo(repoPath, cfg.Team, cfg.PolicyOverride)
+	if err != nil {
+		return nil, err
+	}
+	if err := guard.VClusterCreate(); err != nil {
+		return nil, err
+	}
+	if err := guard.Cluster("creating vcluster", name); err != nil {
+		return nil, err
+	}
+
+	relPath := layout.VClusterManifest(name)
+	outPath := repopath.Abs(repoPath, relPath)
+	if _, err := os.Stat(outPath); err == nil && !overwrite {
 		if interactive {
 			confirmed, _ := tui.Confirm(fmt.Sprintf("File %s already exists. Overwrite?", outPath))
 			if !confirmed {
-				return fmt.Errorf("cancelled")
+				return nil, fmt.Errorf("cancelled")
 			}
 		} else {
-			return fmt.Errorf("file already exists: %s (use --auto-commit with caution)", outPath)
+			return nil, hcerrors.NewUserError("file already exists: %s (use --auto-commit with caution)", outPath)
 		}
 	}
 
-	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
-	}
-	if err := os.WriteFile(outPath, data, 0o644); err != nil {
-		return fmt.Errorf("writing file: %w", err)
+	mp := mutation.New(repoPath)
+	if err := mp.Write(outPath, data); err != nil {
+		return nil, err
 	}
-
-	relPath, _ := filepath.Rel(repoPath, outPath)
-	fmt.Printf("\n%s Written to %s\n", tui.SuccessStyle.Render(tui.IconCheck), relPath)
-
-	// Git handling
-	gitMode := cfg.GitMode
-	if createAutoCommit {
-		gitMode = "auto"
-	}
-
-	gitResult, err := git.HandleGitWorkflow(git.WorkflowOpts{
-		RepoPath:    repoPath,
+	// The path is listed so a retried create commits a file written, but
+	// not committed, by the last attempt.
+	mp.Commit(git.WorkflowOpts{
 		Paths:       []string{relPath},
 		Action:      "create vcluster",
 		Resource:    name,
-		Details:     fmt.Sprintf("%s, %d replicas", preset, spec.VCluster.Replicas),
+		Details:     details,
 		GitMode:     gitMode,
 		Interactive: interactive,
-	})
-	if err != nil {
-		return err
-	}
 
-	fmt.Printf("\n%s\n", tui.DimStyle.Render("Next: ArgoCD will sync the resource and Kratix will provision the vCluster."))
-	fmt.Printf("%s\n", tui.DimStyle.Render("Monitor with: hctl vcluster status "+name))
+		PolicyOverride: guard.Override(),
+	})
+	return mp, nil
+}
 
-	// ── Wait for provisioning ────────────────────────────────────────
-	committed := gitResult == git.GitCommitted
-	if createWait && committed {
-		if err := watchProvisioning(cfg, name, hostname, spec); err != nil {
-			// Non-fatal — the resource was already committed
-			fmt.Printf("\n%s %s\n", tui.WarningStyle.Render(tui.IconWarn), err.Error())
-			fmt.Printf("%s\n", tui.DimStyle.Render("The request was committed. Check status later: hctl vcluster status "+name))
-		}
+// writeRequest executes a plan from requestPlan.
+func writeRequest(mp *mutation.Plan) (git.GitResult, error) {
+	if err := mp.Apply(); err != nil {
+		return git.GitSkipped, fmt.Errorf("writing file: %w", err)
 	}
-
-	return nil
+	fmt.Printf("\n%s Written to %s\n", tui.SuccessStyle.Render(tui.IconCheck), mp.Workflow().Paths[0])
+	err := mp.Finish()
+	return mp.GitResult, err
 }
 
-// watchProvisioning runs the animated provisioning wait sequence.
-func watchProvisioning(cfg *config.Config, name, hostname string, spec platform.VClusterSpec) error {
+// WatchProvisioning runs the animated provisioning wait sequence, giving each
+// stage up to timeout to complete.
+func WatchProvisioning(cfg *config.Config, name, hostname string, timeout time.Duration) error {
 	client, err := kube.NewClient(cfg.KubeContext)
 	if err != nil {
 		return fmt.Errorf("connecting to cluster: %w", err)
 	}
 
 	ns := cfg.Platform.PlatformNamespace
-	timeout := time.Duration(createTimeout) * time.Second
 	poll := 3 * time.Second
 
 	steps := []tui.Step{
@@ -692,20 +729,111 @@ func watchProvisioning(cfg *config.Config, name, hostname string, spec platform.
 }
 
 // parseEgressRule parses "name:cidr:port[:protocol]" into an EgressRule.
+// validateCreateFlags checks flag values (and wizard answers) before the
+// manifest is rendered. Hostname warnings are printed rather than returned;
+// the wizard has already shown them in interactive mode.
+func validateCreateFlags(cfg *config.Config, name string, interactive bool) error {
+	if createHostname != "" {
+		err := platform.ValidateHostname(createHostname, cfg.Platform.Domain)
+		var warn *platform.Warning
+		switch {
+		case errors.As(err, &warn):
+			if !interactive {
+				fmt.Printf("%s %s\n", tui.WarningStyle.Render(tui.IconWarn), warn.Message)
+			}
+		case err != nil:
+			return hcerrors.NewUserError("--hostname: %v", err)
+		}
+	}
+	if createSubnet != "" {
+		if err := platform.ValidateSubnet(createSubnet); err != nil {
+			return hcerrors.NewUserError("--subnet: %v", err)
+		}
+	}
+	if createVIP != "" {
+		if err := platform.ValidateVIP(createVIP, createSubnet); err != nil {
+			return hcerrors.NewUserError("--vip: %v", err)
+		}
+	}
+	if err := platform.ValidateIPFamilies(createIPFamilies, createIPFamilyPolicy); err != nil {
+		return hcerrors.NewUserError("--ip-families/--ip-family-policy: %v", err)
+	}
+	if createLBPool != "" {
+		// Pools declared by the other vCluster manifests in the repo; the
+		// pipeline repeats this check against the live requests.
+		others := map[string]string{}
+		if cfg.RepoPath != "" {
+			pools, err := platform.DeclaredAddressPools(cfg.RepoPath)
+			if err != nil {
+				return fmt.Errorf("reading vCluster manifests: %w", err)
+			}
+			others = pools
+			delete(others, name)
+		}
+		if err := platform.ValidateAddressPool(createLBPool, createSubnet, others); err != nil {
+			return hcerrors.NewUserError("--lb-pool: %v", err)
+		}
+	} else if len(createLBInterfaces) > 0 {
+		return hcerrors.NewUserError("--lb-interface needs --lb-pool")
+	}
+	if createPersistenceSize != "" {
+		if err := platform.ValidateQuantity(createPersistenceSize); err != nil {
+			return hcerrors.NewUserError("--persistence-size: %v", err)
+		}
+	}
+	if createQuotaCPU != "" {
+		if err := platform.ValidateCPU(createQuotaCPU); err != nil {
+			return hcerrors.NewUserError("--quota-cpu: %v", err)
+		}
+	}
+	if createQuotaMemory != "" {
+		if err := platform.ValidateQuantity(createQuotaMemory); err != nil {
+			return hcerrors.NewUserError("--quota-memory: %v", err)
+		}
+	}
+	if createQuotaPods < 0 || createQuotaMultiplier < 0 {
+		return hcerrors.NewUserError("--quota-pods and --quota-multiplier must be positive")
+	}
+	return nil
+}
+
+// checkWorkloadRepo verifies that the workload repo answers and has the
+// revision and path the workloads ApplicationSet renders from. Without
+// them ArgoCD renders nothing and the vCluster still comes up Ready.
+func checkWorkloadRepo(repo platform.WorkloadRepoConfig) error {
+	repo = repo.WithDefaults()
+	path := repo.BasePath + repo.Path
+	fmt.Println(tui.DimStyle.Render(fmt.Sprintf("Checking workload repo %s (%s, %s)", repo.URL, repo.Revision, path)))
+
+	err := git.CheckRemote(repo.URL, repo.Revision, path)
+	switch {
+	case errors.Is(err, git.ErrRevisionNotFound):
+		return hcerrors.New(hcerrors.ErrNotFound, "--workload-repo-revision: %w", err).
+			WithRemediation("Push the branch or tag first, or pass --offline to create the request anyway")
+	case errors.Is(err, git.ErrPathNotFound):
+		return hcerrors.New(hcerrors.ErrNotFound, "--workload-repo-path: %w", err).
+			WithRemediation("Commit the workload directory first, fix --workload-repo-base-path/--workload-repo-path, or pass --offline to skip the check")
+	case err != nil:
+		return hcerrors.New(hcerrors.ErrGit, "workload repo %s is not reachable: %w", repo.URL, err).
+			WithRemediation("Check the URL and your git credentials, or pass --offline to skip the check")
+	}
+	return nil
+}
+
 func parseEgressRule(s string) (platform.EgressRule, error) {
 	parts := strings.SplitN(s, ":", 4)
 	if len(parts) < 3 {
-		return platform.EgressRule{}, fmt.Errorf("invalid egress rule %q: expected name:cidr:port[:protocol]", s)
+		return platform.EgressRule{}, hcerrors.NewUserError("invalid egress rule %q: expected name:cidr:port[:protocol]", s)
 	}
 	port := 0
 	if _, err := fmt.Sscanf(parts[2], "%d", &port); err != nil {
-		return platform.EgressRule{}, fmt.Errorf("invalid port in egress rule %q: %w", s, err)
+		return platform.EgressRule{}, hcerrors.NewUserError("invalid port in egress rule %q: %w", s, err)
 	}
 	protocol := "TCP"
 	if len(parts) == 4 && parts[3] != "" {
 		protocol = strings.ToUpper(parts[3])
 		if protocol != "TCP" && protocol != "UDP" {
-			return platform.EgressRule{}, fmt.Errorf("invalid protocol %q in egress rule (must be TCP or UDP)", parts[3])
+			return platform.EgressRule{}, hcerrors.NewUserError("invalid protocol %q in egress rule (must be TCP or UDP)", parts[3])
 		}
 	}
 	return platform.EgressRule{
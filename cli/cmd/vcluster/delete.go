@@ -3,10 +3,14 @@ package vcluster
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -28,14 +32,39 @@ ArgoCD will then remove the resource, triggering Kratix cleanup.`,
 			if repoPath == "" {
 				repo, err := git.DetectRepo("")
 				if err != nil {
-					return fmt.Errorf("cannot detect repo — run 'hctl init' first")
+					return hcerrors.NewUserError("cannot detect repo — run 'hctl init' first")
 				}
 				repoPath = repo.Root
 			}
 
-			filePath := filepath.Join(repoPath, "platform", "vclusters", name+".yaml")
+			relPath := layout.VClusterManifest(name)
+			filePath := repopath.Abs(repoPath, relPath)
 			if _, err := os.Stat(filePath); os.IsNotExist(err) {
-				return fmt.Errorf("vCluster file not found: %s", filePath)
+				return hcerrors.New(hcerrors.ErrNotFound, "vCluster file not found: %s", filePath)
+			}
+
+			guard, err := policy.ForRepo(repoPath, cfg.Team, cfg.PolicyOverride)
+			if err != nil {
+				return err
+			}
+			if err := guard.Cluster("deleting vcluster", name); err != nil {
+				return err
+			}
+
+			mp := mutation.New(repoPath)
+			if err := mp.Remove(filePath); err != nil {
+				return err
+			}
+			mp.Commit(git.WorkflowOpts{
+				Action:      "delete vcluster",
+				Resource:    name,
+				GitMode:     cfg.GitMode,
+				Interactive: cfg.Interactive,
+
+				PolicyOverride: guard.Override(),
+			})
+			if cfg.DryRun {
+				return mp.DryRun()
 			}
 
 			// Confirm deletion
@@ -45,22 +74,11 @@ ArgoCD will then remove the resource, triggering Kratix cleanup.`,
 				return nil
 			}
 
-			if err := os.Remove(filePath); err != nil {
+			if err := mp.Apply(); err != nil {
 				return fmt.Errorf("removing file: %w", err)
 			}
-
-			relPath, _ := filepath.Rel(repoPath, filePath)
 			fmt.Printf("%s Removed %s\n", tui.SuccessStyle.Render(tui.IconCheck), relPath)
-
-			// Git handling
-			if _, err := git.HandleGitWorkflow(git.WorkflowOpts{
-				RepoPath:    repoPath,
-				Paths:       []string{relPath},
-				Action:      "delete vcluster",
-				Resource:    name,
-				GitMode:     cfg.GitMode,
-				Interactive: cfg.Interactive,
-			}); err != nil {
+			if err := mp.Finish(); err != nil {
 				return err
 			}
 
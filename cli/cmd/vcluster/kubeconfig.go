@@ -2,13 +2,13 @@ package vcluster
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -53,59 +53,29 @@ func runKubeconfig(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Try common secret name patterns
-	secretNames := []string{
-		"vc-" + name,           // vCluster default
-		name + "-kubeconfig",   // ExternalSecret pattern
-		"vc-" + name + "-kubeconfig",
-	}
-
-	var kubeconfigData []byte
-	for _, secretName := range secretNames {
-		data, err := client.GetSecretData(ctx, name, secretName)
-		if err != nil {
-			continue
-		}
-		// Look for common kubeconfig keys
-		for _, key := range []string{"config", "value", "kubeconfig"} {
-			if v, ok := data[key]; ok {
-				kubeconfigData = v
-				break
-			}
-		}
-		if kubeconfigData != nil {
-			break
-		}
-		// If no known key, try base64 decode of first value
-		for _, v := range data {
-			decoded, err := base64.StdEncoding.DecodeString(string(v))
-			if err == nil && len(decoded) > 0 {
-				kubeconfigData = decoded
-			} else {
-				kubeconfigData = v
-			}
-			break
-		}
-		if kubeconfigData != nil {
-			break
-		}
-	}
-
+	kubeconfigData, secretNames := client.VClusterKubeconfig(ctx, name)
 	if kubeconfigData == nil {
-		return fmt.Errorf("kubeconfig secret not found for vCluster %q — tried: %v", name, secretNames)
+		return hcerrors.New(hcerrors.ErrNotFound, "kubeconfig secret not found for vCluster %q — tried: %v", name, secretNames)
 	}
 
 	// Write output
+	path := kubeconfigOutput
+	if path == "" {
+		path = kube.KubeconfigPath(name)
+	}
+	mp := mutation.New("")
+	if err := mp.WritePerm(path, kubeconfigData, 0o600); err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
+	if err := mp.Execute(); err != nil {
+		return fmt.Errorf("writing kubeconfig: %w", err)
+	}
 	if kubeconfigOutput != "" {
-		if err := writeFile(kubeconfigOutput, kubeconfigData); err != nil {
-			return err
-		}
 		fmt.Println(kubeconfigOutput)
 	} else {
-		path, err := kube.WriteKubeconfig(kubeconfigData, name)
-		if err != nil {
-			return fmt.Errorf("writing kubeconfig: %w", err)
-		}
 		fmt.Printf("%s Kubeconfig written to %s\n", tui.SuccessStyle.Render(tui.IconCheck), path)
 		fmt.Printf("\n  %s\n", tui.DimStyle.Render(fmt.Sprintf("export KUBECONFIG=%s", path)))
 	}
@@ -113,10 +83,6 @@ func runKubeconfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func writeFile(path string, data []byte) error {
-	return os.WriteFile(path, data, 0o600)
-}
-
 func newConnectCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "connect [name]",
@@ -154,11 +120,18 @@ func newConnectCmd() *cobra.Command {
 			}
 
 			if kubeconfigData == nil {
-				return fmt.Errorf("kubeconfig not found for %q", name)
+				return hcerrors.New(hcerrors.ErrNotFound, "kubeconfig not found for %q", name)
 			}
 
-			path, err := kube.WriteKubeconfig(kubeconfigData, name)
-			if err != nil {
+			path := kube.KubeconfigPath(name)
+			mp := mutation.New("")
+			if err := mp.WritePerm(path, kubeconfigData, 0o600); err != nil {
+				return err
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if err := mp.Execute(); err != nil {
 				return fmt.Errorf("writing kubeconfig: %w", err)
 			}
 
@@ -13,10 +13,31 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// vclusterListItem is one vCluster as emitted by 'hctl vcluster list -o json'
+// and addressed by --columns.
+type vclusterListItem struct {
+	Name            string              `json:"name" yaml:"name"`
+	Namespace       string              `json:"namespace" yaml:"namespace"`
+	TargetNamespace string              `json:"targetNamespace" yaml:"targetNamespace"`
+	Preset          string              `json:"preset,omitempty" yaml:"preset,omitempty"`
+	Hostname        string              `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Phase           string              `json:"phase,omitempty" yaml:"phase,omitempty"`
+	Message         string              `json:"message,omitempty" yaml:"message,omitempty"`
+	ArgoCD          platform.ArgoCDInfo `json:"argocd" yaml:"argocd"`
+	Created         time.Time           `json:"created" yaml:"created"`
+	Age             string              `json:"age" yaml:"age"`
+}
+
 func newListCmd() *cobra.Command {
-	return &cobra.Command{
+	var columns []string
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all vClusters",
+		Long: `List all vClusters with their preset, hostname, and ArgoCD health.
+
+Use -o wide to add target namespace, phase, revision, and message columns,
+or --columns to pick fields by their JSON path (see -o json), e.g.
+--columns name,phase,argocd.syncStatus.`,
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.Get()
@@ -30,45 +51,74 @@ func newListCmd() *cobra.Command {
 
 			vclusters, err := client.ListVClusters(ctx, cfg.Platform.PlatformNamespace)
 			if err != nil {
-				return fmt.Errorf("listing vclusters: %w", err)
-			}
-
-			if len(vclusters) == 0 {
-				fmt.Println(tui.DimStyle.Render("No vClusters found"))
-				return nil
+				return kube.ClassifyError(fmt.Errorf("listing vclusters: %w", err))
 			}
 
-			var rows [][]string
+			items := []vclusterListItem{}
 			for _, vc := range vclusters {
-				name := vc.GetName()
-				preset, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "vcluster", "preset")
-				hostname, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "exposure", "hostname")
-				age := time.Since(vc.GetCreationTimestamp().Time).Round(time.Minute)
+				item := vclusterListItem{
+					Name:      vc.GetName(),
+					Namespace: vc.GetNamespace(),
+					Created:   vc.GetCreationTimestamp().Time,
+				}
+				item.Preset, _, _ = platform.UnstructuredNestedString(vc.Object, "spec", "vcluster", "preset")
+				item.Hostname, _, _ = platform.UnstructuredNestedString(vc.Object, "spec", "exposure", "hostname")
+				item.TargetNamespace, _, _ = platform.UnstructuredNestedString(vc.Object, "spec", "targetNamespace")
+				if item.TargetNamespace == "" {
+					item.TargetNamespace = item.Namespace
+				}
+				item.Phase, item.Message = platform.PhaseFromStatus(vc.Object)
+				item.Age = tui.FormatAge(item.Created)
 
 				// Check ArgoCD app health
-				argoApp, err := client.GetArgoApp(ctx, "argocd", "vcluster-"+name)
+				argoApp, err := client.GetArgoApp(ctx, "argocd", "vcluster-"+item.Name)
 				if err != nil {
 					// Fallback: try just the name
-					argoApp, err = client.GetArgoApp(ctx, "argocd", name)
+					argoApp, err = client.GetArgoApp(ctx, "argocd", item.Name)
 				}
-				health := tui.DimStyle.Render("unknown")
 				if err == nil {
-					syncStatus, _, _ := platform.UnstructuredNestedString(argoApp.Object, "status", "sync", "status")
-					healthStatus, _, _ := platform.UnstructuredNestedString(argoApp.Object, "status", "health", "status")
-					if syncStatus == "Synced" && healthStatus == "Healthy" {
+					item.ArgoCD = platform.ArgoCDInfoFromApp(argoApp)
+				}
+				items = append(items, item)
+			}
+
+			if len(columns) > 0 {
+				return tui.PrintColumns(items, columns)
+			}
+			if tui.PrintStructured(items) {
+				return nil
+			}
+
+			if len(items) == 0 {
+				fmt.Println(tui.DimStyle.Render("No vClusters found"))
+				return nil
+			}
+
+			headers := []string{"NAME", "PRESET", "HOSTNAME", "STATUS", "AGE"}
+			if tui.IsWide() {
+				headers = append(headers, "TARGET NAMESPACE", "PHASE", "REVISION", "MESSAGE")
+			}
+			var rows [][]string
+			for _, item := range items {
+				health := tui.DimStyle.Render("unknown")
+				if item.ArgoCD.AppName != "" {
+					if item.ArgoCD.SyncStatus == "Synced" && item.ArgoCD.HealthStatus == "Healthy" {
 						health = tui.SuccessStyle.Render("Healthy")
 					} else {
-						health = tui.WarningStyle.Render(fmt.Sprintf("%s/%s", syncStatus, healthStatus))
+						health = tui.WarningStyle.Render(fmt.Sprintf("%s/%s", item.ArgoCD.SyncStatus, item.ArgoCD.HealthStatus))
 					}
 				}
-
-				rows = append(rows, []string{name, preset, hostname, health, formatAge(age)})
+				row := []string{item.Name, item.Preset, item.Hostname, health, item.Age}
+				if tui.IsWide() {
+					row = append(row, item.TargetNamespace, tui.OrDash(item.Phase), tui.OrDash(tui.ShortRevision(item.ArgoCD.Revision)), tui.OrDash(item.Message))
+				}
+				rows = append(rows, row)
 			}
 
 			// Interactive table: enter to show diagnostics
 			action, err := tui.InteractiveTable(tui.InteractiveTableConfig{
 				Title:   "vClusters",
-				Headers: []string{"NAME", "PRESET", "HOSTNAME", "STATUS", "AGE"},
+				Headers: headers,
 				Rows:    rows,
 				OnSelect: func(row []string, index int) string {
 					vcName := row[0]
@@ -105,14 +155,6 @@ func newListCmd() *cobra.Command {
 			return err
 		},
 	}
-}
-
-func formatAge(d time.Duration) string {
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "comma-separated JSON paths to show as a plain table (e.g. name,phase,hostname)")
+	return cmd
 }
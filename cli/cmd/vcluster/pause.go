@@ -0,0 +1,276 @@
+package vcluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// pausePoll is how often pause and resume poll the cluster while waiting.
+var pausePoll = 3 * time.Second
+
+func newPauseCmd() *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "pause [name]",
+		Short: "Scale a vCluster to zero without deleting it",
+		Long: `Pause a vCluster to free its resources while keeping its state.
+
+Pause annotates the VClusterOrchestratorV2 with platform.integratn.tech/paused,
+which makes the orchestrator pipeline render the control plane, CoreDNS, and
+etcd with zero replicas. The current replica count of every workload in the
+target namespace is recorded in the resource's status.pause, the workloads are
+scaled to zero right away, and the workload pods the vCluster synced to the
+host are deleted. PVCs and secrets are kept.
+
+The status reconciler reports phase Paused until 'hctl vcluster resume'.
+
+Examples:
+  hctl vcluster pause my-dev
+  hctl vcluster pause my-dev --wait=false`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPause(args[0], wait, timeout)
+		},
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", true, "wait for the vCluster's pods to drain")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "timeout for --wait")
+
+	return cmd
+}
+
+func newResumeCmd() *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "resume [name]",
+		Short: "Bring a paused vCluster back",
+		Long: `Resume a vCluster paused with 'hctl vcluster pause'.
+
+Resume removes the paused annotation, scales every workload back to the
+replica count recorded in status.pause, clears the record, and waits for
+the vCluster to report Ready. Synced workload pods come back once the
+vCluster's syncer is running again.
+
+Examples:
+  hctl vcluster resume my-dev`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResume(args[0], wait, timeout)
+		},
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", true, "wait for the vCluster to become Ready")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "timeout for --wait")
+
+	return cmd
+}
+
+// pauseTarget looks up the live VClusterOrchestratorV2 and runs the
+// cluster policy check for action.
+func pauseTarget(cfg *config.Config, name, action string) (*kube.Client, *unstructured.Unstructured, error) {
+	if cfg.RepoPath != "" {
+		guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := guard.Cluster(action, name); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to cluster: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	vc, err := client.GetVCluster(ctx, cfg.Platform.PlatformNamespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, hcerrors.New(hcerrors.ErrNotFound, "vCluster %q not found in %s", name, cfg.Platform.PlatformNamespace).
+			WithRemediation("run 'hctl vcluster list' to see existing vClusters")
+	}
+	if err != nil {
+		return nil, nil, kube.ClassifyError(err)
+	}
+	return client, vc, nil
+}
+
+func runPause(name string, wait bool, timeout time.Duration) error {
+	cfg := config.Get()
+	client, vc, err := pauseTarget(cfg, name, "pausing vcluster")
+	if err != nil {
+		return err
+	}
+	if platform.IsPaused(vc) {
+		fmt.Println(tui.DimStyle.Render(name + " is already paused"))
+		return nil
+	}
+	target := platform.TargetNamespace(vc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	record, err := platform.RecordPause(ctx, client, name, target, time.Now())
+	if err != nil {
+		return kube.ClassifyError(fmt.Errorf("reading workloads in %s: %w", target, err))
+	}
+
+	ns := cfg.Platform.PlatformNamespace
+	mp := mutation.New(cfg.RepoPath)
+	mp.Cluster(fmt.Sprintf("record the replicas of %d workload(s) in %s status.pause", len(record.Replicas), name), func() error {
+		return kube.ClassifyError(client.PatchStatus(ctx, kube.VClusterOrchestratorV2GVR, ns, name, map[string]interface{}{"pause": record.Status()}))
+	})
+	mp.Cluster(fmt.Sprintf("annotate %s with %s=true and request a reconcile", name, platform.PausedAnnotation), func() error {
+		if err := client.SetAnnotation(ctx, kube.VClusterOrchestratorV2GVR, ns, name, platform.PausedAnnotation, "true"); err != nil {
+			return kube.ClassifyError(err)
+		}
+		if err := client.SetManualReconciliationLabel(ctx, kube.VClusterOrchestratorV2GVR, ns, name); err != nil {
+			return kube.ClassifyError(err)
+		}
+		fmt.Printf("%s Annotated %s with %s=true\n", tui.SuccessStyle.Render(tui.IconCheck), name, platform.PausedAnnotation)
+		return nil
+	})
+	for _, key := range record.Workloads() {
+		kind, workload := platform.SplitWorkload(key)
+		mp.Cluster(fmt.Sprintf("scale %s %s/%s to 0", kind, target, workload), func() error {
+			return kube.ClassifyError(client.ScaleWorkload(ctx, target, kind, workload, 0))
+		})
+	}
+	mp.Cluster(fmt.Sprintf("delete the %d pod(s) %s synced to %s", record.SyncedPods, name, target), func() error {
+		deleted, err := client.DeletePods(ctx, target, platform.SyncedPodSelector(name))
+		if err != nil {
+			return kube.ClassifyError(err)
+		}
+		fmt.Printf("%s Scaled %d workload(s) to zero and stopped %d synced pod(s)\n",
+			tui.SuccessStyle.Render(tui.IconCheck), len(record.Replicas), deleted)
+		return nil
+	})
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
+
+	if cfg.Interactive && tui.IsInteractive() {
+		prompt := fmt.Sprintf("Pause vCluster %q? %d workload(s) and %d synced pod(s) in %s stop until resumed.",
+			name, len(record.Replicas), record.SyncedPods, target)
+		if ok, _ := tui.Confirm(prompt); !ok {
+			fmt.Println(tui.DimStyle.Render("Cancelled"))
+			return nil
+		}
+	}
+	if err := mp.Execute(); err != nil {
+		return err
+	}
+
+	if wait {
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), timeout)
+		defer waitCancel()
+		fmt.Println()
+		if _, err := tui.RunSteps("Pausing "+name, []tui.Step{{
+			Title: "Pods drained",
+			Run: func() (string, error) {
+				return platform.WaitForDrain(waitCtx, client, target, pausePoll)
+			},
+		}}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\n%s %s\n", tui.MutedStyle.Render(tui.IconPause), "Paused "+name)
+	fmt.Println(tui.DimStyle.Render("Resume with: hctl vcluster resume " + name))
+	return nil
+}
+
+func runResume(name string, wait bool, timeout time.Duration) error {
+	cfg := config.Get()
+	client, vc, err := pauseTarget(cfg, name, "resuming vcluster")
+	if err != nil {
+		return err
+	}
+	record, recorded := platform.ReadPauseRecord(vc)
+	if !platform.IsPaused(vc) && !recorded {
+		fmt.Println(tui.DimStyle.Render(name + " is not paused"))
+		return nil
+	}
+	if !recorded {
+		fmt.Printf("%s %s\n", tui.WarningStyle.Render(tui.IconWarn),
+			"No pause record in status.pause; workloads return at the replicas the pipeline renders")
+		record = &platform.PauseRecord{}
+	}
+	target := platform.TargetNamespace(vc)
+	ns := cfg.Platform.PlatformNamespace
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	mp := mutation.New(cfg.RepoPath)
+	mp.Cluster(fmt.Sprintf("remove %s from %s and request a reconcile", platform.PausedAnnotation, name), func() error {
+		if err := client.SetAnnotation(ctx, kube.VClusterOrchestratorV2GVR, ns, name, platform.PausedAnnotation, ""); err != nil {
+			return kube.ClassifyError(err)
+		}
+		if err := client.SetManualReconciliationLabel(ctx, kube.VClusterOrchestratorV2GVR, ns, name); err != nil {
+			return kube.ClassifyError(err)
+		}
+		fmt.Printf("%s Removed %s from %s\n", tui.SuccessStyle.Render(tui.IconCheck), platform.PausedAnnotation, name)
+		return nil
+	})
+	for _, key := range record.Workloads() {
+		kind, workload := platform.SplitWorkload(key)
+		mp.Cluster(fmt.Sprintf("scale %s %s/%s to %d", kind, target, workload, record.Replicas[key]), func() error {
+			return kube.ClassifyError(client.ScaleWorkload(ctx, target, kind, workload, record.Replicas[key]))
+		})
+	}
+	mp.Cluster(fmt.Sprintf("clear %s status.pause", name), func() error {
+		if err := client.PatchStatus(ctx, kube.VClusterOrchestratorV2GVR, ns, name, map[string]interface{}{"pause": nil}); err != nil {
+			return kube.ClassifyError(err)
+		}
+		fmt.Printf("%s Restored %d workload(s) to their pre-pause replicas\n", tui.SuccessStyle.Render(tui.IconCheck), len(record.Replicas))
+		return nil
+	})
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
+	if err := mp.Execute(); err != nil {
+		return err
+	}
+
+	if wait {
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), timeout)
+		defer waitCancel()
+		fmt.Println()
+		if _, err := tui.RunSteps("Resuming "+name, []tui.Step{
+			{
+				Title: "Replicas ready",
+				Run: func() (string, error) {
+					return platform.WaitForRestore(waitCtx, client, target, record, pausePoll)
+				},
+			},
+			{
+				Title: "VCluster Ready",
+				Run: func() (string, error) {
+					return platform.WaitForPhase(waitCtx, client, ns, name, phase.Ready, pausePoll)
+				},
+			},
+		}); err != nil {
+			fmt.Printf("%s\n", tui.DimStyle.Render("Check progress with: hctl vcluster status "+name))
+			return err
+		}
+	}
+
+	fmt.Printf("\n%s Resumed %s\n", tui.SuccessStyle.Render(tui.IconCheck), name)
+	return nil
+}
@@ -0,0 +1,168 @@
+package vcluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// pauseCluster seeds a running vCluster "dev" in namespace dev: a
+// 3-replica control plane, 2 CoreDNS replicas and two synced pods.
+func pauseCluster(t *testing.T) *testutil.Cluster {
+	t.Helper()
+	testutil.Isolate(t)
+	cfg := config.Default()
+	cfg.Interactive = false
+	prevCfg := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prevCfg) })
+
+	replicas := func(n int32) *int32 { return &n }
+	vc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "platform.integratn.tech/v1alpha1",
+		"kind":       "VClusterOrchestratorV2",
+		"metadata":   map[string]interface{}{"name": "dev", "namespace": cfg.Platform.PlatformNamespace},
+		"spec":       map[string]interface{}{"name": "dev", "targetNamespace": "dev"},
+		"status":     map[string]interface{}{"phase": "Ready"},
+	}}
+	objs := []runtime.Object{
+		vc,
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "dev"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: replicas(3)},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "dev-coredns", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(2)},
+		},
+	}
+	for _, name := range []string{"web-x-default-x-dev", "db-x-default-x-dev"} {
+		objs = append(objs, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "dev",
+			Labels: map[string]string{"vcluster.loft.sh/managed-by": "dev"},
+		}})
+	}
+	cluster := testutil.NewFakeCluster(t, objs...)
+	cluster.Use()
+
+	prev := pausePoll
+	pausePoll = 10 * time.Millisecond
+	t.Cleanup(func() { pausePoll = prev })
+	return cluster
+}
+
+// replicasOf returns the replicas of the StatefulSet or Deployment "Kind/name"
+// in namespace dev.
+func replicasOf(t *testing.T, cluster *testutil.Cluster, key string) int32 {
+	t.Helper()
+	rollouts, err := cluster.Client.ListRollouts(t.Context(), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rollouts {
+		if r.Kind+"/"+r.Name == key {
+			return r.Desired
+		}
+	}
+	t.Fatalf("%s not found", key)
+	return 0
+}
+
+func TestPauseResume(t *testing.T) {
+	cluster := pauseCluster(t)
+	ns := config.Get().Platform.PlatformNamespace
+
+	res := testutil.MustRun(t, NewCmd(), "pause", "dev")
+	if !strings.Contains(res.Stdout, "stopped 2 synced pod(s)") {
+		t.Errorf("pause output missing synced pods:\n%s", res.Stdout)
+	}
+
+	vc := cluster.Get(kube.VClusterOrchestratorV2GVR, ns, "dev")
+	if !platform.IsPaused(vc) {
+		t.Errorf("annotations = %v, want %s=true", vc.GetAnnotations(), platform.PausedAnnotation)
+	}
+	record, ok := platform.ReadPauseRecord(vc)
+	if !ok {
+		t.Fatalf("no status.pause after pause: %v", vc.Object["status"])
+	}
+	if record.Replicas["StatefulSet/dev"] != 3 || record.Replicas["Deployment/dev-coredns"] != 2 || record.SyncedPods != 2 {
+		t.Errorf("pause record = %+v, want StatefulSet/dev=3 Deployment/dev-coredns=2 and 2 synced pods", record)
+	}
+	if n := replicasOf(t, cluster, "StatefulSet/dev"); n != 0 {
+		t.Errorf("control plane replicas = %d after pause, want 0", n)
+	}
+	if n := replicasOf(t, cluster, "Deployment/dev-coredns"); n != 0 {
+		t.Errorf("coredns replicas = %d after pause, want 0", n)
+	}
+	if pods, _ := cluster.Client.ListPods(t.Context(), "dev", ""); len(pods) != 0 {
+		t.Errorf("pods left after pause: %v", pods)
+	}
+
+	// Pausing again leaves the record alone.
+	if res := testutil.MustRun(t, NewCmd(), "pause", "dev"); !strings.Contains(res.Stdout, "already paused") {
+		t.Errorf("second pause output:\n%s", res.Stdout)
+	}
+
+	testutil.MustRun(t, NewCmd(), "resume", "dev", "--wait=false")
+
+	vc = cluster.Get(kube.VClusterOrchestratorV2GVR, ns, "dev")
+	if _, found := vc.GetAnnotations()[platform.PausedAnnotation]; found {
+		t.Errorf("annotations = %v after resume, want %s removed", vc.GetAnnotations(), platform.PausedAnnotation)
+	}
+	if _, ok := platform.ReadPauseRecord(vc); ok {
+		t.Errorf("status.pause still set after resume: %v", vc.Object["status"])
+	}
+	if n := replicasOf(t, cluster, "StatefulSet/dev"); n != 3 {
+		t.Errorf("control plane replicas = %d after resume, want 3", n)
+	}
+	if n := replicasOf(t, cluster, "Deployment/dev-coredns"); n != 2 {
+		t.Errorf("coredns replicas = %d after resume, want 2", n)
+	}
+}
+
+func TestPauseDryRun(t *testing.T) {
+	cluster := pauseCluster(t)
+	config.Get().DryRun = true
+
+	res := testutil.Run(t, NewCmd(), "pause", "dev")
+	if res.Category != hcerrors.ErrChangesPending {
+		t.Fatalf("category = %q, want %q (err: %v)", res.Category, hcerrors.ErrChangesPending, res.Err)
+	}
+	for _, want := range []string{"scale StatefulSet dev/dev to 0", "delete the 2 pod(s) dev synced to dev"} {
+		if !strings.Contains(res.Stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, res.Stdout)
+		}
+	}
+	if got := cluster.Mutations(); len(got) > 0 {
+		t.Errorf("pause --dry-run changed the cluster: %v", got)
+	}
+}
+
+func TestResumeNotPaused(t *testing.T) {
+	pauseCluster(t)
+
+	res := testutil.MustRun(t, NewCmd(), "resume", "dev")
+	if !strings.Contains(res.Stdout, "dev is not paused") {
+		t.Errorf("resume output:\n%s", res.Stdout)
+	}
+}
+
+func TestPauseNotFound(t *testing.T) {
+	pauseCluster(t)
+
+	res := testutil.Run(t, NewCmd(), "pause", "missing")
+	if res.Category != hcerrors.ErrNotFound {
+		t.Errorf("category = %q, want %q (err: %v)", res.Category, hcerrors.ErrNotFound, res.Err)
+	}
+}
@@ -0,0 +1,94 @@
+package vcluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// pipelineRunner returns the runner --dry-run-pipeline uses; a variable so
+// tests need no container engine.
+var pipelineRunner = platform.ContainerRunner
+
+// pipelineLogLines is how much of a failed run's log is shown.
+const pipelineLogLines = 15
+
+// dryRunPipeline runs the orchestrator's configure pipeline locally, in
+// image, on doc, the request for spec, and prints the objects it rendered
+// and its key settings next to the CLI's. It fails when the pipeline rejects
+// the request; settings that differ are only highlighted. status is the
+// resource's live status, if any, so upgrades are checked as the pipeline
+// will check them.
+func dryRunPipeline(spec platform.VClusterSpec, doc []byte, image string, status map[string]interface{}) error {
+	run, err := pipelineRunner(image)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", tui.TitleStyle.Render("Running the orchestrator pipeline ("+image+")"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	result, err := platform.RunPipeline(ctx, doc, status, run)
+	var failure *platform.PipelineFailure
+	if errors.As(err, &failure) {
+		fmt.Printf("%s %s\n", tui.ErrorStyle.Render(tui.IconCross), "The pipeline failed:")
+		for _, line := range lastLines(failure.Log, pipelineLogLines) {
+			fmt.Println("  " + line)
+		}
+		return hcerrors.New(hcerrors.ErrValidation, "%v", failure).
+			WithRemediation("fix the spec, then run again; nothing was written")
+	}
+	if err != nil {
+		return fmt.Errorf("running the pipeline: %w", err)
+	}
+
+	if result.PoolSkipped {
+		fmt.Println(tui.DimStyle.Render("The load balancer pool was left out: the pipeline checks it against the vClusters in the cluster."))
+	}
+
+	var rows [][]string
+	for _, o := range result.Objects {
+		name := o.Name
+		if o.Namespace != "" {
+			name = o.Namespace + "/" + o.Name
+		}
+		rows = append(rows, []string{o.File, o.Kind, name})
+	}
+	fmt.Printf("\n%s\n", tui.TitleStyle.Render(fmt.Sprintf("Rendered %d object(s)", len(result.Objects))))
+	fmt.Println(tui.Table([]string{"FILE", "KIND", "NAME"}, rows))
+
+	rows = nil
+	differs := 0
+	for _, f := range result.Compare(spec) {
+		mark := ""
+		if f.Differs() {
+			mark = tui.IconWarn
+			differs++
+		}
+		rows = append(rows, []string{mark, f.Name, f.CLI, f.Pipeline})
+	}
+	fmt.Printf("\n%s\n", tui.TitleStyle.Render("Key settings"))
+	fmt.Println(tui.Table([]string{"", "SETTING", "CLI", "PIPELINE"}, rows))
+	if differs > 0 {
+		fmt.Printf("\n%s %d setting(s) render differently than the CLI expects (marked %s)\n",
+			tui.WarningStyle.Render(tui.IconWarn), differs, tui.IconWarn)
+	} else {
+		fmt.Printf("\n%s The pipeline accepted the request\n", tui.SuccessStyle.Render(tui.IconCheck))
+	}
+	return nil
+}
+
+// lastLines returns up to the last n lines of s.
+func lastLines(s string, n int) []string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,150 @@
+package vcluster
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/pipeline/*/object.yaml from the CLI")
+
+// The fixtures under testdata/pipeline are shared with the pipeline's
+// tests: object.yaml is the request the CLI writes for a preset, and output
+// and metadata are what the vcluster-orchestrator-v2 pipeline renders for
+// it. After changing either side, rewrite them in order:
+//
+//	go test ./cmd/vcluster -run TestDryRunPipeline -update
+//	(cd ../promises/platform-pipelines && go test -run TestCLIFixtures -update)
+
+// presetRequest returns the spec and request that 'hctl vcluster create
+// demo --preset <preset>' writes with otherwise default flags.
+func presetRequest(t *testing.T, preset string) (platform.VClusterSpec, []byte) {
+	t.Helper()
+	spec := baseSpec("demo")
+	if err := platform.ApplyPreset(&spec, preset); err != nil {
+		t.Fatal(err)
+	}
+	spec.Exposure = platform.ExposureConfig{Hostname: "demo.integratn.tech", APIPort: 443}
+	if preset == "prod" {
+		spec.VCluster.HelmOverrides = prodHelmOverrides("demo", spec.VCluster.Replicas)
+	}
+	doc, err := yaml.Marshal(platform.NewVClusterResource(spec, "platform-requests"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec, doc
+}
+
+// fixtureRunner replays the pipeline's recorded run for the request in
+// fixture, after checking it was given that request.
+func fixtureRunner(t *testing.T, fixture string) platform.PipelineRunner {
+	return func(_ context.Context, dir string) ([]byte, error) {
+		got, err := os.ReadFile(filepath.Join(dir, "input", "object.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		want, err := os.ReadFile(filepath.Join(fixture, "object.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		if string(got) != string(want) {
+			t.Errorf("pipeline input:\n%s\nwant:\n%s", got, want)
+		}
+		for _, sub := range []string{"output", "metadata"} {
+			err := filepath.Walk(filepath.Join(fixture, sub), func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(fixture, path)
+				if err != nil {
+					return err
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, rel)), 0o755); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, rel), data, 0o644)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return []byte("=== VCluster Orchestrator V2 Pipeline ===\n"), nil
+	}
+}
+
+func stubPipelineRunner(t *testing.T, run platform.PipelineRunner) {
+	t.Helper()
+	prev := pipelineRunner
+	pipelineRunner = func(string) (platform.PipelineRunner, error) { return run, nil }
+	t.Cleanup(func() { pipelineRunner = prev })
+}
+
+func TestDryRunPipeline(t *testing.T) {
+	tests := []struct {
+		preset string
+		// kinds are rendered only for this preset.
+		kinds []string
+	}{
+		{"dev", nil},
+		{"prod", []string{"Certificate", "Issuer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			fixture := filepath.Join("testdata", "pipeline", tt.preset)
+			spec, doc := presetRequest(t, tt.preset)
+			if *update {
+				if err := os.WriteFile(filepath.Join(fixture, "object.yaml"), doc, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			run := fixtureRunner(t, fixture)
+
+			result, err := platform.RunPipeline(context.Background(), doc, nil, run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kinds := map[string]bool{}
+			for _, o := range result.Objects {
+				kinds[o.Kind] = true
+			}
+			for _, kind := range append([]string{"ArgoCDApplication", "ArgoCDProject", "Namespace", "NetworkPolicy"}, tt.kinds...) {
+				if !kinds[kind] {
+					t.Errorf("no %s among the rendered objects %+v", kind, result.Objects)
+				}
+			}
+			for _, f := range result.Compare(spec) {
+				if f.Differs() {
+					t.Errorf("%s: CLI expects %q, pipeline rendered %q", f.Name, f.CLI, f.Pipeline)
+				}
+			}
+
+			stubPipelineRunner(t, run)
+			if err := dryRunPipeline(spec, doc, platform.PipelineImage, nil); err != nil {
+				t.Errorf("dryRunPipeline() = %v", err)
+			}
+		})
+	}
+}
+
+func TestDryRunPipelineFailure(t *testing.T) {
+	spec, doc := presetRequest(t, "dev")
+	stubPipelineRunner(t, func(context.Context, string) ([]byte, error) {
+		return []byte("failed to build config: spec.vcluster.preset: unknown preset\n"), errors.New("exit status 1")
+	})
+
+	err := dryRunPipeline(spec, doc, platform.PipelineImage, nil)
+	if !errors.Is(err, hcerrors.ErrValidation) {
+		t.Errorf("dryRunPipeline() = %v, want a validation error", err)
+	}
+}
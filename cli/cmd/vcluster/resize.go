@@ -0,0 +1,588 @@
+package vcluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// resizeOpts holds the flags of 'hctl vcluster resize'.
+type resizeOpts struct {
+	replicas        int
+	cpu             string
+	memory          string
+	persistence     bool
+	persistenceSize string
+	storageClass    string
+	corednsReplicas int
+	etcd            bool
+
+	acceptDisruption bool
+	autoCommit       bool
+	wait             bool
+	timeout          time.Duration
+}
+
+// resizeFlags are the flags that change sizing; when none is given in
+// interactive mode, every field is prompted for.
+var resizeFlags = []string{"replicas", "cpu", "memory", "persistence", "persistence-size", "storage-class", "coredns-replicas", "etcd"}
+
+// resizePlan is the preview emitted with -o json/yaml.
+type resizePlan struct {
+	Name      string            `json:"name" yaml:"name"`
+	File      string            `json:"file" yaml:"file"`
+	Manifest  platform.Sizing   `json:"manifest" yaml:"manifest"`
+	Live      *platform.Sizing  `json:"live,omitempty" yaml:"live,omitempty"`
+	Requested platform.Sizing   `json:"requested" yaml:"requested"`
+	Impacts   []platform.Impact `json:"impacts" yaml:"impacts"`
+	Highest   string            `json:"highestImpact,omitempty" yaml:"highestImpact,omitempty"`
+	Diff      []string          `json:"diff,omitempty" yaml:"diff,omitempty"`
+}
+
+func newResizeCmd() *cobra.Command {
+	var opts resizeOpts
+
+	cmd := &cobra.Command{
+		Use:   "resize [name]",
+		Short: "Change the size of an existing vCluster with an impact preview",
+		Long: `Resize a vCluster: control plane replicas, CPU and memory requests,
+persistence, CoreDNS replicas, and the etcd backing store.
+
+The current values come from the request in platform/vclusters/<name>.yaml
+(with preset defaults filled in) and, when the cluster is reachable, from the
+live VClusterOrchestratorV2. Before anything is written, resize shows the
+manifest diff and an impact assessment for each change:
+
+  scale                   pods are added or removed; running pods are untouched
+  rolling update          pods are replaced one at a time
+  pod restart             every pod of a component restarts at once (etcd membership)
+  volume expansion        PVCs grow in place; the StatefulSet is recreated with --cascade=orphan
+  statefulset recreation  the StatefulSet must be deleted and recreated
+  blocked                 the change cannot be applied (shrinking PVCs, HA without etcd)
+
+With no sizing flags in interactive mode, every field is prompted for. Without
+a terminal, disruptive changes (pod restart and above) need --accept-disruption.
+
+Examples:
+  # Give a dev cluster more memory
+  hctl vcluster resize my-dev --memory 1536Mi
+
+  # Move to HA: etcd backing store and 3 replicas
+  hctl vcluster resize my-dev --etcd --replicas 3 --accept-disruption
+
+  # Preview only
+  hctl vcluster resize media --persistence-size 20Gi --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResize(cmd, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.replicas, "replicas", 0, "control plane replicas")
+	cmd.Flags().StringVar(&opts.cpu, "cpu", "", "control plane CPU request (e.g. 500m)")
+	cmd.Flags().StringVar(&opts.memory, "memory", "", "control plane memory request (e.g. 2Gi); the limit is raised to match if lower")
+	cmd.Flags().BoolVar(&opts.persistence, "persistence", false, "enable control plane persistence")
+	cmd.Flags().StringVar(&opts.persistenceSize, "persistence-size", "", "persistence volume size (e.g. 20Gi)")
+	cmd.Flags().StringVar(&opts.storageClass, "storage-class", "", "storage class for the persistence volume")
+	cmd.Flags().IntVar(&opts.corednsReplicas, "coredns-replicas", 0, "CoreDNS replicas")
+	cmd.Flags().BoolVar(&opts.etcd, "etcd", false, "use a dedicated etcd backing store (etcd replicas follow --replicas)")
+	cmd.Flags().BoolVar(&opts.acceptDisruption, "accept-disruption", false, "apply pod restarts and StatefulSet recreation without prompting")
+	cmd.Flags().BoolVar(&opts.autoCommit, "auto-commit", false, "automatically commit and push (overrides gitMode)")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "after committing, wait for ArgoCD to sync and the rollout to complete")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 10*time.Minute, "timeout for --wait")
+
+	return cmd
+}
+
+func runResize(cmd *cobra.Command, name string, opts *resizeOpts) error {
+	cfg := config.Get()
+	if err := cfg.RequireRepoPath(); err != nil {
+		return err
+	}
+	interactive := cfg.Interactive && tui.IsInteractive() && !tui.IsStructured()
+
+	relPath := layout.VClusterManifest(name)
+	absPath := repopath.Abs(cfg.RepoPath, relPath)
+	doc, err := os.ReadFile(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return hcerrors.New(hcerrors.ErrNotFound, "vCluster request not found: %s", relPath).
+				WithRemediation("run 'hctl vcluster list' to see existing vClusters")
+		}
+		return fmt.Errorf("reading %s: %w", relPath, err)
+	}
+	var resource platform.VClusterResource
+	if err := yaml.Unmarshal(doc, &resource); err != nil {
+		return hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", relPath, err)
+	}
+	current := platform.SizingFromSpec(resource.Spec)
+
+	guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+	if err != nil {
+		return err
+	}
+	if err := guard.Cluster("resizing vcluster", name); err != nil {
+		return err
+	}
+
+	// The live CR and storage class are best-effort context for the preview.
+	client, _ := kube.NewClient(cfg.KubeContext)
+	live := liveSizing(client, cfg.Platform.PlatformNamespace, name)
+
+	requested, err := requestedSizing(cmd, opts, current, interactive)
+	if err != nil {
+		return err
+	}
+	if requested == current {
+		fmt.Println(tui.DimStyle.Render("No changes — " + name + " already has the requested size"))
+		return nil
+	}
+
+	env := platform.ResizeEnv{}
+	if requested.Persistence && current.Persistence && platform.QuantityLess(current.PersistenceSize, requested.PersistenceSize) && client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if _, ok, err := client.StorageClassExpansion(ctx, requested.StorageClass); err == nil {
+			env.VolumeExpansion = &ok
+		}
+		cancel()
+	}
+
+	impacts := platform.AssessResize(current, requested, env)
+	newDoc, err := platform.EditManifest(doc, platform.SizingEdits(current, requested))
+	if err != nil {
+		return hcerrors.New(hcerrors.ErrValidation, "updating %s: %w", relPath, err)
+	}
+	hunks := deploylib.DiffLines(string(doc), string(newDoc))
+	highest := platform.HighestImpact(impacts)
+
+	gitMode := cfg.GitMode
+	if opts.autoCommit {
+		gitMode = "auto"
+	}
+	mp := mutation.New(cfg.RepoPath)
+	if err := mp.Write(absPath, newDoc); err != nil {
+		return err
+	}
+	mp.Commit(git.WorkflowOpts{
+		Action:      "resize vcluster",
+		Resource:    name,
+		Details:     resizeDetails(impacts),
+		GitMode:     gitMode,
+		Interactive: interactive,
+
+		PolicyOverride: guard.Override(),
+	})
+
+	// Under --dry-run the mutation plan carries the diff.
+	if tui.IsStructured() && !cfg.DryRun {
+		plan := resizePlan{Name: name, File: relPath, Manifest: current, Live: live, Requested: requested, Impacts: impacts}
+		if highest >= 0 {
+			plan.Highest = highest.String()
+		}
+		for _, h := range hunks {
+			plan.Diff = append(plan.Diff, h.Header())
+			plan.Diff = append(plan.Diff, h.Lines...)
+		}
+		tui.PrintStructured(plan)
+	} else if !tui.IsStructured() {
+		printSizing(name, current, live, requested)
+		if !cfg.DryRun {
+			fmt.Printf("\n%s\n", tui.TitleStyle.Render(relPath))
+			printResizeHunks(hunks)
+		}
+		printImpacts(impacts)
+	}
+
+	if highest == platform.ImpactBlocked {
+		var reasons []string
+		for _, i := range impacts {
+			if i.Level == platform.ImpactBlocked {
+				reasons = append(reasons, i.Field+": "+i.Reason)
+			}
+		}
+		return hcerrors.NewUserError("resize of %s cannot be applied: %s", name, strings.Join(reasons, "; ")).
+			WithRemediation("adjust the requested values; see the impact assessment above")
+	}
+	if cfg.DryRun {
+		return mp.DryRun()
+	}
+
+	disruptive := highest >= platform.ImpactRestart
+	switch {
+	case interactive:
+		prompt := fmt.Sprintf("Apply resize of %s (%s)?", name, highest)
+		if ok, _ := tui.Confirm(prompt); !ok {
+			fmt.Println(tui.DimStyle.Render("Cancelled"))
+			return nil
+		}
+	case disruptive && !opts.acceptDisruption:
+		return hcerrors.NewUserError("resize of %s causes %s", name, highest).
+			WithRemediation("review the impact assessment and re-run with --accept-disruption")
+	}
+
+	if err := mp.Apply(); err != nil {
+		return fmt.Errorf("writing %s: %w", relPath, err)
+	}
+	fmt.Printf("\n%s Updated %s\n", tui.SuccessStyle.Render(tui.IconCheck), relPath)
+	if err := mp.Finish(); err != nil {
+		return err
+	}
+
+	if highest >= platform.ImpactVolumeExpansion {
+		target := resource.Spec.TargetNamespace
+		if target == "" {
+			target = name
+		}
+		fmt.Printf("\n%s %s\n", tui.WarningStyle.Render(tui.IconWarn),
+			"ArgoCD cannot update the StatefulSet's volumeClaimTemplates in place.")
+		fmt.Printf("%s\n", tui.DimStyle.Render(fmt.Sprintf(
+			"After the sync fails, run: kubectl -n %s delete statefulset %s --cascade=orphan", target, name)))
+	}
+
+	if opts.wait && mp.GitResult == git.GitCommitted {
+		if err := watchResize(client, name, resource.Spec.TargetNamespace, opts.timeout); err != nil {
+			fmt.Printf("\n%s %s\n", tui.WarningStyle.Render(tui.IconWarn), err.Error())
+			fmt.Printf("%s\n", tui.DimStyle.Render("The resize was committed. Check progress with: hctl vcluster status "+name))
+		}
+	}
+	return nil
+}
+
+// requestedSizing applies the sizing flags, or the interactive prompts when
+// none were given, to current. etcd replicas follow the control plane
+// replicas, and limits are raised to at least the requests.
+func requestedSizing(cmd *cobra.Command, opts *resizeOpts, current platform.Sizing, interactive bool) (platform.Sizing, error) {
+	flags := cmd.Flags()
+	anyFlag := false
+	for _, f := range resizeFlags {
+		anyFlag = anyFlag || flags.Changed(f)
+	}
+
+	s := current
+	if !anyFlag && interactive {
+		if err := runWizard(resizeWizard(&s)); err != nil {
+			return s, err
+		}
+	} else {
+		if flags.Changed("replicas") {
+			if opts.replicas < 1 {
+				return s, hcerrors.NewUserError("--replicas must be at least 1")
+			}
+			s.Replicas = opts.replicas
+		}
+		for _, q := range []struct {
+			flag, value string
+			field       *string
+		}{
+			{"cpu", opts.cpu, &s.CPU},
+			{"memory", opts.memory, &s.Memory},
+			{"persistence-size", opts.persistenceSize, &s.PersistenceSize},
+		} {
+			if !flags.Changed(q.flag) {
+				continue
+			}
+			if err := platform.ValidateQuantity(q.value); err != nil {
+				return s, hcerrors.NewUserError("--%s: %v", q.flag, err)
+			}
+			*q.field = q.value
+		}
+		if flags.Changed("persistence") {
+			s.Persistence = opts.persistence
+		}
+		if flags.Changed("storage-class") {
+			s.StorageClass = opts.storageClass
+		}
+		if flags.Changed("coredns-replicas") {
+			if opts.corednsReplicas < 1 {
+				return s, hcerrors.NewUserError("--coredns-replicas must be at least 1")
+			}
+			s.CoreDNSReplicas = opts.corednsReplicas
+		}
+		if flags.Changed("etcd") {
+			s.Etcd = opts.etcd
+		}
+	}
+	if (flags.Changed("persistence-size") || flags.Changed("storage-class")) && !s.Persistence {
+		return s, hcerrors.NewUserError("--persistence-size and --storage-class need persistence enabled").
+			WithRemediation("add --persistence")
+	}
+
+	switch {
+	case !s.Etcd:
+		s.EtcdReplicas = 0
+	case !current.Etcd || s.Replicas != current.Replicas:
+		s.EtcdReplicas = s.Replicas
+	}
+	if platform.QuantityLess(s.MemoryLimit, s.Memory) {
+		s.MemoryLimit = s.Memory
+	}
+	if platform.QuantityLess(s.CPULimit, s.CPU) {
+		s.CPULimit = s.CPU
+	}
+	return s, nil
+}
+
+// resizeWizard builds the interactive prompts for resize, editing s.
+func resizeWizard(s *platform.Sizing) []wizardStep {
+	prompt := func(title, def string, validate func(string) error) (string, error) {
+		return tui.Prompt(tui.PromptOpts{Title: title, Default: def, Validate: validate, AllowBack: true})
+	}
+	yesNo := func(title string, def bool) (bool, error) {
+		d := 0
+		if def {
+			d = 1
+		}
+		idx, err := tui.SelectWith(tui.SelectOpts{Title: title, Choices: []string{"No", "Yes"}, Default: d, AllowBack: true})
+		return idx == 1, err
+	}
+	yesNoValue := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	always := func() bool { return true }
+	quantity := func(label string, field *string) wizardStep {
+		return wizardStep{
+			label:  label,
+			active: always,
+			ask: func() error {
+				v, err := prompt(label, *field, platform.ValidateQuantity)
+				if err == nil {
+					*field = v
+				}
+				return err
+			},
+			value: func() string { return *field },
+		}
+	}
+	count := func(label string, field *int) wizardStep {
+		return wizardStep{
+			label:  label,
+			active: always,
+			ask: func() error {
+				v, err := prompt(label, strconv.Itoa(*field), func(v string) error {
+					_, err := platform.ParseReplicas(v)
+					return err
+				})
+				if err == nil {
+					*field, _ = platform.ParseReplicas(v)
+				}
+				return err
+			},
+			value: func() string { return strconv.Itoa(*field) },
+		}
+	}
+
+	return []wizardStep{
+		{
+			label:  "Dedicated etcd",
+			active: always,
+			ask: func() error {
+				v, err := yesNo("Use a dedicated etcd backing store? (needed for more than one replica)", s.Etcd)
+				if err == nil {
+					s.Etcd = v
+				}
+				return err
+			},
+			value: func() string { return yesNoValue(s.Etcd) },
+		},
+		count("Control plane replicas", &s.Replicas),
+		quantity("CPU request", &s.CPU),
+		quantity("Memory request", &s.Memory),
+		{
+			label:  "Persistence",
+			active: always,
+			ask: func() error {
+				v, err := yesNo("Enable control plane persistence?", s.Persistence)
+				if err == nil {
+					s.Persistence = v
+				}
+				return err
+			},
+			value: func() string { return yesNoValue(s.Persistence) },
+		},
+		func() wizardStep {
+			step := quantity("Persistence size", &s.PersistenceSize)
+			step.active = func() bool { return s.Persistence }
+			return step
+		}(),
+		count("CoreDNS replicas", &s.CoreDNSReplicas),
+	}
+}
+
+// liveSizing reads the sizing of the live VClusterOrchestratorV2, or nil
+// when the cluster or resource is unavailable.
+func liveSizing(client *kube.Client, namespace, name string) *platform.Sizing {
+	if client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	vc, err := client.GetVCluster(ctx, namespace, name)
+	if err != nil {
+		return nil
+	}
+	data, err := yaml.Marshal(vc.Object["spec"])
+	if err != nil {
+		return nil
+	}
+	var spec platform.VClusterSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil
+	}
+	s := platform.SizingFromSpec(spec)
+	return &s
+}
+
+// printSizing shows the manifest, live, and requested values side by side.
+func printSizing(name string, manifest platform.Sizing, live *platform.Sizing, requested platform.Sizing) {
+	rows := func(s platform.Sizing) []string {
+		etcd := "no"
+		if s.Etcd {
+			etcd = fmt.Sprintf("yes (%d)", s.EtcdReplicas)
+		}
+		persistence := "no"
+		if s.Persistence {
+			persistence = s.PersistenceSize
+			if s.StorageClass != "" {
+				persistence += " (" + s.StorageClass + ")"
+			}
+		}
+		return []string{
+			strconv.Itoa(s.Replicas),
+			s.CPU + " / " + s.CPULimit,
+			s.Memory + " / " + s.MemoryLimit,
+			persistence,
+			strconv.Itoa(s.CoreDNSReplicas),
+			etcd,
+		}
+	}
+	fields := []string{"Replicas", "CPU (req / limit)", "Memory (req / limit)", "Persistence", "CoreDNS replicas", "Etcd"}
+
+	headers := []string{"FIELD", "MANIFEST"}
+	if live != nil {
+		headers = append(headers, "LIVE")
+	}
+	headers = append(headers, "REQUESTED")
+
+	m, r := rows(manifest), rows(requested)
+	var l []string
+	if live != nil {
+		l = rows(*live)
+	}
+	var table [][]string
+	for i, f := range fields {
+		row := []string{f, m[i]}
+		if live != nil {
+			cell := l[i]
+			if cell != m[i] {
+				cell = tui.WarningStyle.Render(cell + " (not yet applied)")
+			}
+			row = append(row, cell)
+		}
+		want := r[i]
+		if want != m[i] {
+			want = tui.SuccessStyle.Render(want)
+		}
+		table = append(table, append(row, want))
+	}
+
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Resize "+name))
+	fmt.Println(tui.Table(headers, table))
+	if live == nil {
+		fmt.Println(tui.DimStyle.Render("Live values unavailable — showing the manifest only"))
+	}
+}
+
+// printResizeHunks prints a manifest diff.
+func printResizeHunks(hunks []deploylib.Hunk) {
+	for _, h := range hunks {
+		fmt.Printf("  %s\n", tui.DimStyle.Render(h.Header()))
+		for _, line := range h.Lines {
+			if line[0] == '-' {
+				fmt.Printf("  %s\n", tui.ErrorStyle.Render(line))
+			} else {
+				fmt.Printf("  %s\n", tui.SuccessStyle.Render(line))
+			}
+		}
+	}
+}
+
+// printImpacts lists each change with its assessed impact.
+func printImpacts(impacts []platform.Impact) {
+	fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Impact"))
+	for _, i := range impacts {
+		style := tui.SuccessStyle
+		switch {
+		case i.Level == platform.ImpactBlocked:
+			style = tui.ErrorStyle
+		case i.Level >= platform.ImpactRestart:
+			style = tui.WarningStyle
+		}
+		fmt.Printf("  %s %s %s → %s  %s\n", style.Render(fmt.Sprintf("%-22s", i.Level)), i.Field, i.Old, i.New,
+			tui.DimStyle.Render("("+i.Component+")"))
+		fmt.Printf("  %s %s\n", strings.Repeat(" ", 22), tui.DimStyle.Render(i.Reason))
+	}
+	fmt.Println()
+}
+
+// resizeDetails summarises the changes for the commit message.
+func resizeDetails(impacts []platform.Impact) string {
+	var parts []string
+	for _, i := range impacts {
+		parts = append(parts, fmt.Sprintf("%s %s→%s", i.Field, i.Old, i.New))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// watchResize waits for ArgoCD to apply the change and the vCluster's
+// workloads to finish rolling out.
+func watchResize(client *kube.Client, name, targetNamespace string, timeout time.Duration) error {
+	if client == nil {
+		return fmt.Errorf("cannot watch the rollout: cluster unreachable")
+	}
+	if targetNamespace == "" {
+		targetNamespace = name
+	}
+	poll := 3 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fmt.Println()
+	_, err := tui.RunSteps("Resizing "+name, []tui.Step{
+		{
+			Title: "ArgoCD syncing",
+			Run: func() (string, error) {
+				// Give ArgoCD a moment to notice the new commit before
+				// trusting a Synced status.
+				time.Sleep(2 * poll)
+				return platform.WaitForArgoSync(ctx, client, name, poll)
+			},
+		},
+		{
+			Title: "Rollout complete",
+			Run: func() (string, error) {
+				return platform.WaitForRollout(ctx, client, targetNamespace, poll)
+			},
+		},
+	})
+	return err
+}
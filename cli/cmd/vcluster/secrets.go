@@ -0,0 +1,93 @@
+package vcluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/onepassword"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/secrets"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func newSecretsCmd() *cobra.Command {
+	var verify bool
+
+	cmd := &cobra.Command{
+		Use:   "secrets <name>",
+		Short: "Show which 1Password items a vCluster depends on",
+		Long: `Trace the credentials in a vCluster's host namespace: each ExternalSecret
+rendered there by the promise pipelines, the 1Password items and fields it
+reads, its target Secret, and the pods (including those synced from the
+vCluster) whose env vars, envFrom or mounts use it.
+
+--verify also checks that the items and fields exist through the 1Password
+Connect API. Secret values are never read or printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg := config.Get()
+
+			client, err := kube.NewClient(cfg.KubeContext)
+			if err != nil {
+				return fmt.Errorf("connecting to cluster: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			vc, err := client.GetVCluster(ctx, cfg.Platform.PlatformNamespace, name)
+			if err != nil {
+				return kube.ClassifyError(err)
+			}
+			namespace, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "targetNamespace")
+			if namespace == "" {
+				namespace = vc.GetNamespace()
+			}
+
+			list, err := client.ListExternalSecrets(ctx, namespace)
+			if err != nil {
+				return kube.ClassifyError(err)
+			}
+			manifests := make([]map[string]interface{}, len(list))
+			for i := range list {
+				manifests[i] = list[i].Object
+			}
+			// Consumers are best-effort: the ExternalSecrets are the subject.
+			consumers, _ := secrets.PodConsumers(ctx, client, namespace)
+			links := secrets.Join(secrets.FromManifests(manifests), consumers)
+
+			rows := secrets.Live(ctx, client, links, namespace)
+			if verify {
+				op, err := onepassword.FromConfig(cfg)
+				if err != nil {
+					return hcerrors.NewUserError("--verify: %v", err)
+				}
+				vault := onepassword.ConfiguredVault(cfg)
+				if err := secrets.CheckOnePassword(ctx, op, vault, rows); err != nil {
+					return fmt.Errorf("checking vault %q: %w", vault, err)
+				}
+			}
+
+			if tui.PrintStructured(rows) {
+				return nil
+			}
+			if len(rows) == 0 {
+				fmt.Println(tui.DimStyle.Render("No Secrets in namespace " + namespace))
+				return nil
+			}
+			fmt.Println(tui.TitleStyle.Render(fmt.Sprintf("Secrets for vCluster %s (namespace %s)", name, namespace)))
+			fmt.Println(secrets.Table(rows))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&verify, "verify", false, "check that the 1Password items and fields exist via Connect")
+
+	return cmd
+}
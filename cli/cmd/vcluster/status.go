@@ -6,24 +6,44 @@ import (
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
 	"github.com/jamesatintegratnio/hctl/internal/platform"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/internal/versions"
 	"github.com/spf13/cobra"
 )
 
 func newStatusCmd() *cobra.Command {
-	var diagnoseFlag bool
+	var (
+		diagnoseFlag bool
+		watchFlag    bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "status [name]",
 		Short: "Show vCluster lifecycle status",
-		Long:  "Shows the status contract for a vCluster resource. Use --diagnose for the full diagnostic chain.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Shows the status contract for a vCluster resource. Use --diagnose for the full diagnostic chain.
+
+--watch follows the vCluster live: a timeline of its condition changes, its
+ArgoCD Application's sync, health and errors, and the Events in its
+namespace, under a header that tracks the phase and conditions. Dropped
+watches reconnect on their own; press q to quit. Without a terminal the
+timeline is printed as it grows (one object per entry with -o json).`,
+		Example: `  hctl vcluster status media
+  hctl vcluster status media --watch`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 			cfg := config.Get()
 
+			if watchFlag {
+				if diagnoseFlag {
+					return hcerrors.NewUserError("--watch and --diagnose cannot be combined")
+				}
+				return runStatusWatch(cfg, name)
+			}
+
 			client, err := kube.NewClient(cfg.KubeContext)
 			if err != nil {
 				return fmt.Errorf("connecting to cluster: %w", err)
@@ -38,6 +58,7 @@ func newStatusCmd() *cobra.Command {
 				if err == nil && sc.Phase != "" {
 					fmt.Println()
 					fmt.Println(platform.FormatStatusContract(name, sc))
+					printChartVersion(ctx, client, cfg.Platform.PlatformNamespace, name)
 					return nil
 				}
 				// Fall through to diagnostic chain if no status contract
@@ -46,7 +67,7 @@ func newStatusCmd() *cobra.Command {
 			// Full diagnostic chain
 			result, err := platform.DiagnoseVCluster(ctx, client, cfg.Platform.PlatformNamespace, name)
 			if err != nil {
-				return err
+				return kube.ClassifyError(err)
 			}
 
 			fmt.Printf("\n  %s\n", tui.TitleStyle.Render(name))
@@ -66,6 +87,7 @@ func newStatusCmd() *cobra.Command {
 					fmt.Printf("%s%s\n", indent, tui.DimStyle.Render(step.Details))
 				}
 			}
+			printChartVersion(ctx, client, cfg.Platform.PlatformNamespace, name)
 			fmt.Println()
 
 			return nil
@@ -73,6 +95,41 @@ func newStatusCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&diagnoseFlag, "diagnose", false, "Run full diagnostic chain instead of status contract")
+	cmd.Flags().BoolVarP(&watchFlag, "watch", "w", false, "Follow condition changes, ArgoCD and Events live")
 
 	return cmd
 }
+
+// printChartVersion prints the vcluster chart version the orchestrator last
+// rendered and, when the chart repository lists a newer one, the update. A
+// failed lookup only leaves the update out.
+func printChartVersion(ctx context.Context, client *kube.Client, namespace, name string) {
+	vc, err := client.GetVCluster(ctx, namespace, name)
+	if err != nil {
+		return
+	}
+	_, deployed := platform.RecordedVersions(vc)
+	if deployed == "" {
+		return
+	}
+	repo, chart := versions.DefaultVClusterRepository, versions.DefaultVClusterChart
+	if v, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "argocdApplication", "repoURL"); v != "" {
+		repo = v
+	}
+	if v, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "argocdApplication", "chart"); v != "" {
+		chart = v
+	}
+
+	line := fmt.Sprintf("  Chart: %s %s", chart, deployed)
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if latest, err := versions.LatestChart(lookupCtx, repo, chart); err == nil {
+		switch status, delta := versions.CompareLatest(deployed, latest.Version); status {
+		case versions.StatusOutdated:
+			line += tui.WarningStyle.Render(fmt.Sprintf(" (latest %s, %s; 'hctl vcluster update %s --chart-version %s')", latest.Version, delta, name, latest.Version))
+		case versions.StatusCurrent:
+			line += tui.DimStyle.Render(" (latest)")
+		}
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,133 @@
+package vcluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sourcedEvent is a watch event tagged with the timeline source it feeds.
+type sourcedEvent struct {
+	source string
+	ev     kube.WatchEvent
+}
+
+// watchContext bounds a --watch session; it ends on Ctrl-C. Tests swap it
+// for a timeout.
+var watchContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// runStatusWatch follows a vcluster live: its request, its ArgoCD
+// Application and the Events in its namespace, as a timeline under a
+// header that tracks the phase and conditions. Without a terminal the
+// timeline is printed as it grows.
+func runStatusWatch(cfg *config.Config, name string) error {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		return fmt.Errorf("connecting to cluster: %w", err)
+	}
+	ctx, cancel := watchContext()
+	defer cancel()
+
+	ns := cfg.Platform.PlatformNamespace
+	getCtx, getCancel := context.WithTimeout(ctx, 30*time.Second)
+	vc, err := client.GetVCluster(getCtx, ns, name)
+	getCancel()
+	if err != nil {
+		return kube.ClassifyError(err)
+	}
+
+	sw := platform.NewStatusWatch(name)
+	events := make(chan sourcedEvent)
+	follow := func(source string, ch <-chan kube.WatchEvent) {
+		for ev := range ch {
+			select {
+			case events <- sourcedEvent{source, ev}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	watches := []struct {
+		source string
+		watch  func(chan<- kube.WatchEvent)
+	}{
+		{platform.SourceVCluster, func(ch chan<- kube.WatchEvent) {
+			client.Watch(ctx, kube.VClusterOrchestratorV2GVR, ns, metav1.ListOptions{FieldSelector: "metadata.name=" + name}, ch)
+		}},
+		{platform.SourceApp, func(ch chan<- kube.WatchEvent) {
+			client.Watch(ctx, kube.ArgoCDApplicationGVR, "argocd", metav1.ListOptions{FieldSelector: "metadata.name=" + sw.AppName}, ch)
+		}},
+		{platform.SourceEvent, func(ch chan<- kube.WatchEvent) {
+			client.Watch(ctx, kube.EventGVR, platform.TargetNamespace(vc), metav1.ListOptions{}, ch)
+		}},
+	}
+	for _, w := range watches {
+		ch := make(chan kube.WatchEvent)
+		go w.watch(ch)
+		go follow(w.source, ch)
+	}
+
+	if tui.IsStructured() || !tui.IsInteractive() {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case se := <-events:
+				for _, e := range sw.Apply(se.source, se.ev, time.Now()) {
+					if !tui.PrintStructured(e) {
+						fmt.Println(formatTimelineEntry(e))
+					}
+				}
+			}
+		}
+	}
+
+	updates := make(chan tui.TimelineUpdate)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case se := <-events:
+				var u tui.TimelineUpdate
+				for _, e := range sw.Apply(se.source, se.ev, time.Now()) {
+					u.Lines = append(u.Lines, formatTimelineEntry(e))
+				}
+				if se.source != platform.SourceEvent {
+					u.Header = sw.Header()
+				}
+				select {
+				case updates <- u:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	err = tui.RunTimeline(tui.TimelineOpts{
+		Title:   tui.IconPlay + " " + name,
+		Updates: updates,
+	})
+	cancel()
+	return err
+}
+
+// formatTimelineEntry styles a timeline entry: bad news in the warning
+// color, the rest plain.
+func formatTimelineEntry(e platform.TimelineEntry) string {
+	summary := e.Summary
+	if e.Warning {
+		summary = tui.WarningStyle.Render(summary)
+	}
+	return fmt.Sprintf("%s %s %s", tui.DimStyle.Render(e.Time.Local().Format("15:04:05")), tui.MutedStyle.Render(fmt.Sprintf("%-8s", e.Source)), summary)
+}
@@ -0,0 +1,55 @@
+package vcluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStatusWatchPrintsTimeline(t *testing.T) {
+	cluster := pauseCluster(t)
+	cluster.Apply(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": "vcluster-dev", "namespace": "argocd"},
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "OutOfSync"},
+			"health": map[string]interface{}{"status": "Progressing"},
+		},
+	}})
+	event := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":     "v1",
+		"kind":           "Event",
+		"metadata":       map[string]interface{}{"name": "dev-0.1", "namespace": "dev", "uid": "1"},
+		"type":           "Warning",
+		"reason":         "BackOff",
+		"message":        "Back-off restarting failed container syncer",
+		"involvedObject": map[string]interface{}{"kind": "Pod", "name": "dev-0"},
+	}}
+	if _, err := cluster.Client.Dynamic.Resource(kube.EventGVR).Namespace("dev").Create(t.Context(), event, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := watchContext
+	watchContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 500*time.Millisecond)
+	}
+	t.Cleanup(func() { watchContext = prev })
+
+	res := testutil.MustRun(t, NewCmd(), "status", "dev", "--watch")
+	for _, want := range []string{
+		"vcluster phase Ready",
+		"app      app vcluster-dev OutOfSync/Progressing",
+		"event    event: Back-off restarting failed container syncer (Pod/dev-0)",
+	} {
+		if !strings.Contains(res.Stdout, want) {
+			t.Errorf("timeline missing %q:\n%s", want, res.Stdout)
+		}
+	}
+}
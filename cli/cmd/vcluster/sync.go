@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -40,7 +42,7 @@ sync all apps regardless of current state.`,
 
 			apps, err := client.ListArgoAppsForCluster(ctx, "argocd", name)
 			if err != nil {
-				return fmt.Errorf("listing apps for cluster %s: %w", name, err)
+				return kube.ClassifyError(fmt.Errorf("listing apps for cluster %s: %w", name, err))
 			}
 
 			if len(apps) == 0 {
@@ -58,7 +60,7 @@ sync all apps regardless of current state.`,
 					}
 				}
 				if len(filtered) == 0 {
-					return fmt.Errorf("app %q not found targeting cluster %s", appFilter, name)
+					return hcerrors.New(hcerrors.ErrNotFound, "app %q not found targeting cluster %s", appFilter, name)
 				}
 				apps = filtered
 			}
@@ -74,38 +76,51 @@ sync all apps regardless of current state.`,
 			}
 
 			cleared, synced, skipped, errors := 0, 0, 0, 0
-
-			fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(fmt.Sprintf("Syncing %s (%d apps)", name, len(apps))))
-
-			// Phase 1: CRD apps first
-			if len(crdApps) > 0 {
-				fmt.Printf("  %s\n", tui.WarningStyle.Render("Phase 1: CRD Prerequisites"))
-				for _, app := range crdApps {
+			runPhase := func(apps []kube.ArgoAppInfo) {
+				for _, app := range apps {
 					c, s, sk, e := syncApp(ctx, client, app, force)
 					cleared += c
 					synced += s
 					skipped += sk
 					errors += e
 				}
-				// Brief pause between phases to let CRDs register
-				if len(regularApps) > 0 {
-					fmt.Printf("  %s\n", tui.MutedStyle.Render("  Waiting for CRDs to register..."))
-					time.Sleep(3 * time.Second)
-				}
 			}
 
-			// Phase 2: Regular apps
-			if len(regularApps) > 0 {
-				if len(crdApps) > 0 {
-					fmt.Printf("\n  %s\n", tui.WarningStyle.Render("Phase 2: Applications"))
-				}
-				for _, app := range regularApps {
-					c, s, sk, e := syncApp(ctx, client, app, force)
-					cleared += c
-					synced += s
-					skipped += sk
-					errors += e
-				}
+			// Each phase is one planned change, listing the apps it syncs;
+			// a phase with nothing to sync is left out.
+			mp := mutation.New(cfg.RepoPath)
+			if names := appsToSync(crdApps, force); len(names) > 0 {
+				mp.Cluster("sync CRD prerequisite apps: "+strings.Join(names, ", "), func() error {
+					fmt.Printf("  %s\n", tui.WarningStyle.Render("Phase 1: CRD Prerequisites"))
+					runPhase(crdApps)
+					// Brief pause between phases to let CRDs register
+					if len(regularApps) > 0 {
+						fmt.Printf("  %s\n", tui.MutedStyle.Render("  Waiting for CRDs to register..."))
+						time.Sleep(3 * time.Second)
+					}
+					return nil
+				})
+			} else {
+				skipped += len(crdApps)
+			}
+			if names := appsToSync(regularApps, force); len(names) > 0 {
+				mp.Cluster("sync apps: "+strings.Join(names, ", "), func() error {
+					if len(crdApps) > 0 {
+						fmt.Printf("\n  %s\n", tui.WarningStyle.Render("Phase 2: Applications"))
+					}
+					runPhase(regularApps)
+					return nil
+				})
+			} else {
+				skipped += len(regularApps)
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+
+			fmt.Printf("\n%s\n\n", tui.TitleStyle.Render(fmt.Sprintf("Syncing %s (%d apps)", name, len(apps))))
+			if err := mp.Execute(); err != nil {
+				return err
 			}
 
 			fmt.Printf("\n  %s cleared: %d, synced: %d, skipped: %d, errors: %d\n\n",
@@ -136,17 +151,36 @@ func isCRDApp(name string) bool {
 	return false
 }
 
-// syncApp clears stale operation and triggers sync for a single app.
-// Returns (cleared, synced, skipped, errors) counts.
-func syncApp(ctx context.Context, client *kube.Client, app kube.ArgoAppInfo, force bool) (int, int, int, int) {
-	needsSync := force ||
+// needsSync reports whether syncApp would sync app.
+func needsSync(app kube.ArgoAppInfo, force bool) bool {
+	return force ||
 		app.OpPhase == "Failed" ||
 		app.OpPhase == "Error" ||
 		app.SyncStatus == "OutOfSync" ||
 		app.SyncStatus == "Unknown" ||
 		app.SyncStatus == ""
+}
 
-	if !needsSync {
+// appsToSync names the apps syncApp would sync, noting a failed operation
+// it would clear first.
+func appsToSync(apps []kube.ArgoAppInfo, force bool) []string {
+	var names []string
+	for _, app := range apps {
+		switch {
+		case !needsSync(app, force):
+		case app.OpPhase == "Failed" || app.OpPhase == "Error":
+			names = append(names, fmt.Sprintf("%s (clearing its %s operation)", app.Name, app.OpPhase))
+		default:
+			names = append(names, app.Name)
+		}
+	}
+	return names
+}
+
+// syncApp clears stale operation and triggers sync for a single app.
+// Returns (cleared, synced, skipped, errors) counts.
+func syncApp(ctx context.Context, client *kube.Client, app kube.ArgoAppInfo, force bool) (int, int, int, int) {
+	if !needsSync(app, force) {
 		fmt.Printf("    %s %s %s\n",
 			tui.MutedStyle.Render(tui.IconPending),
 			app.Name,
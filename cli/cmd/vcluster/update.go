@@ -0,0 +1,254 @@
+package vcluster
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/policy"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// updatePlan is the preview emitted with -o json/yaml.
+type updatePlan struct {
+	Name string `json:"name" yaml:"name"`
+	File string `json:"file" yaml:"file"`
+	// FromK8sVersion is the version the skew is checked against, and
+	// FromSource where it came from: the live status, or the manifest.
+	FromK8sVersion   string   `json:"fromK8sVersion" yaml:"fromK8sVersion"`
+	FromSource       string   `json:"fromSource" yaml:"fromSource"`
+	ToK8sVersion     string   `json:"toK8sVersion" yaml:"toK8sVersion"`
+	FromChartVersion string   `json:"fromChartVersion" yaml:"fromChartVersion"`
+	ToChartVersion   string   `json:"toChartVersion" yaml:"toChartVersion"`
+	Diff             []string `json:"diff,omitempty" yaml:"diff,omitempty"`
+}
+
+func newUpdateCmd() *cobra.Command {
+	var (
+		k8sVersion       string
+		chartVersion     string
+		autoCommit       bool
+		dryRunPipelineOn bool
+		pipelineImage    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update [name]",
+		Short: "Upgrade a vCluster's Kubernetes or chart version",
+		Long: `Upgrade a vCluster by changing spec.vcluster.k8sVersion or
+spec.argocdApplication.targetRevision in platform/vclusters/<name>.yaml.
+
+The Kubernetes version is checked against the orchestrator's upgrade policy
+before anything is written: patch changes either way, and at most one minor
+version up. The check is against the version the orchestrator last rendered
+(the live status.k8sVersion) when the cluster is reachable, and against the
+manifest otherwise. The pipeline repeats the check, so a change made by hand
+is rejected there.
+
+Once committed, the orchestrator backs up the vCluster's datastore with a
+Job that runs before ArgoCD rolls the vCluster, and records the upgrade in
+status.upgradeHistory.
+
+--dry-run-pipeline runs the orchestrator's configure pipeline on the updated
+request in its container image (docker or podman), with the live versions as
+its status, before anything is written: the pipeline's own upgrade checks run,
+and the backup Job it would render is listed.
+
+Examples:
+  # Upgrade Kubernetes one minor version
+  hctl vcluster update media --k8s-version 1.34
+
+  # Upgrade the vcluster chart
+  hctl vcluster update media --chart-version 0.31.0 --dry-run
+
+  # Check the upgrade against the pipeline before committing it
+  hctl vcluster update media --k8s-version 1.34 --dry-run-pipeline`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if k8sVersion == "" && chartVersion == "" {
+				return hcerrors.NewUserError("nothing to update").
+					WithRemediation("pass --k8s-version, --chart-version or both")
+			}
+			cfg := config.Get()
+			if err := cfg.RequireRepoPath(); err != nil {
+				return err
+			}
+			interactive := cfg.Interactive && tui.IsInteractive() && !tui.IsStructured()
+
+			relPath := layout.VClusterManifest(name)
+			absPath := repopath.Abs(cfg.RepoPath, relPath)
+			doc, err := os.ReadFile(absPath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return hcerrors.New(hcerrors.ErrNotFound, "vCluster request not found: %s", relPath).
+						WithRemediation("run 'hctl vcluster list' to see existing vClusters")
+				}
+				return fmt.Errorf("reading %s: %w", relPath, err)
+			}
+			var resource platform.VClusterResource
+			if err := yaml.Unmarshal(doc, &resource); err != nil {
+				return hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", relPath, err)
+			}
+
+			guard, err := policy.ForRepo(cfg.RepoPath, cfg.Team, cfg.PolicyOverride)
+			if err != nil {
+				return err
+			}
+			if err := guard.Cluster("updating vcluster", name); err != nil {
+				return err
+			}
+
+			plan := updatePlan{
+				Name:             name,
+				File:             relPath,
+				FromK8sVersion:   cmp.Or(resource.Spec.VCluster.K8sVersion, platform.DefaultK8sVersion),
+				FromSource:       "manifest",
+				FromChartVersion: cmp.Or(resource.Spec.ArgocdApp.TargetRevision, platform.DefaultChartVersion),
+			}
+			client, _ := kube.NewClient(cfg.KubeContext)
+			if liveK8s, liveChart := liveVersions(client, cfg.Platform.PlatformNamespace, name); liveK8s != "" {
+				plan.FromK8sVersion, plan.FromChartVersion, plan.FromSource = liveK8s, cmp.Or(liveChart, plan.FromChartVersion), "live status"
+			}
+			plan.ToK8sVersion = cmp.Or(k8sVersion, plan.FromK8sVersion)
+			plan.ToChartVersion = cmp.Or(chartVersion, plan.FromChartVersion)
+
+			if k8sVersion != "" {
+				if err := platform.CheckVersionSkew(plan.FromK8sVersion, k8sVersion); err != nil {
+					return hcerrors.NewUserError("%s (checked against the %s)", err, plan.FromSource).
+						WithRemediation("upgrade one minor version at a time, waiting for each to finish: hctl vcluster status " + name)
+				}
+			}
+
+			newDoc, err := platform.EditManifest(doc, platform.UpgradeEdits(k8sVersion, chartVersion))
+			if err != nil {
+				return hcerrors.New(hcerrors.ErrValidation, "updating %s: %w", relPath, err)
+			}
+			if string(newDoc) == string(doc) {
+				fmt.Println(tui.DimStyle.Render("No changes — " + name + " already requests these versions"))
+				return nil
+			}
+			hunks := deploylib.DiffLines(string(doc), string(newDoc))
+
+			gitMode := cfg.GitMode
+			if autoCommit {
+				gitMode = "auto"
+			}
+			mp := mutation.New(cfg.RepoPath)
+			if err := mp.Write(absPath, newDoc); err != nil {
+				return err
+			}
+			mp.Commit(git.WorkflowOpts{
+				Action:      "update vcluster",
+				Resource:    name,
+				Details:     updateDetails(plan),
+				GitMode:     gitMode,
+				Interactive: interactive,
+
+				PolicyOverride: guard.Override(),
+			})
+
+			// Under --dry-run the mutation plan carries the diff.
+			if tui.IsStructured() {
+				if !cfg.DryRun {
+					for _, h := range hunks {
+						plan.Diff = append(plan.Diff, h.Header())
+						plan.Diff = append(plan.Diff, h.Lines...)
+					}
+					tui.PrintStructured(plan)
+				}
+			} else {
+				fmt.Printf("\n%s\n\n", tui.TitleStyle.Render("Update "+name))
+				fmt.Println(tui.Table([]string{"", "CURRENT (" + strings.ToUpper(plan.FromSource) + ")", "REQUESTED"}, [][]string{
+					{"Kubernetes", plan.FromK8sVersion, plan.ToK8sVersion},
+					{"Chart", plan.FromChartVersion, plan.ToChartVersion},
+				}))
+				if !cfg.DryRun {
+					fmt.Printf("\n%s\n", tui.TitleStyle.Render(relPath))
+					printResizeHunks(hunks)
+				}
+			}
+			if dryRunPipelineOn {
+				var updated platform.VClusterResource
+				if err := yaml.Unmarshal(newDoc, &updated); err != nil {
+					return hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", relPath, err)
+				}
+				var status map[string]interface{}
+				if plan.FromSource == "live status" {
+					status = map[string]interface{}{"k8sVersion": plan.FromK8sVersion, "chartVersion": plan.FromChartVersion}
+				}
+				if err := dryRunPipeline(updated.Spec, newDoc, pipelineImage, status); err != nil {
+					return err
+				}
+			}
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if interactive {
+				if ok, _ := tui.Confirm(fmt.Sprintf("Upgrade %s?", name)); !ok {
+					fmt.Println(tui.DimStyle.Render("Cancelled"))
+					return nil
+				}
+			}
+
+			if err := mp.Apply(); err != nil {
+				return fmt.Errorf("writing %s: %w", relPath, err)
+			}
+			fmt.Printf("\n%s Updated %s\n", tui.SuccessStyle.Render(tui.IconCheck), relPath)
+			if err := mp.Finish(); err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", tui.DimStyle.Render("The orchestrator backs up the datastore before the upgrade. Follow it with: hctl vcluster status "+name))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&k8sVersion, "k8s-version", "", "Kubernetes version (e.g. 1.34 or v1.34.3)")
+	cmd.Flags().StringVar(&chartVersion, "chart-version", "", "vcluster chart version (spec.argocdApplication.targetRevision)")
+	cmd.Flags().BoolVar(&autoCommit, "auto-commit", false, "automatically commit and push (overrides gitMode)")
+	cmd.Flags().BoolVar(&dryRunPipelineOn, "dry-run-pipeline", false, "run the orchestrator pipeline locally on the updated request before writing it")
+	cmd.Flags().StringVar(&pipelineImage, "pipeline-image", platform.PipelineImage, "pipeline image for --dry-run-pipeline")
+
+	return cmd
+}
+
+// liveVersions returns the versions the orchestrator last rendered for the
+// vcluster, or empty strings when the cluster or status is unavailable.
+func liveVersions(client *kube.Client, namespace, name string) (string, string) {
+	if client == nil {
+		return "", ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	vc, err := client.GetVCluster(ctx, namespace, name)
+	if err != nil {
+		return "", ""
+	}
+	return platform.RecordedVersions(vc)
+}
+
+// updateDetails summarises the version changes for the commit message.
+func updateDetails(plan updatePlan) string {
+	var parts []string
+	if plan.FromK8sVersion != plan.ToK8sVersion {
+		parts = append(parts, fmt.Sprintf("k8sVersion %s→%s", plan.FromK8sVersion, plan.ToK8sVersion))
+	}
+	if plan.FromChartVersion != plan.ToChartVersion {
+		parts = append(parts, fmt.Sprintf("chart %s→%s", plan.FromChartVersion, plan.ToChartVersion))
+	}
+	return strings.Join(parts, ", ")
+}
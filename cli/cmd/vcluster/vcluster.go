@@ -21,6 +21,12 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(newConnectCmd())
 	cmd.AddCommand(newAppsCmd())
 	cmd.AddCommand(newSyncCmd())
+	cmd.AddCommand(newResizeCmd())
+	cmd.AddCommand(newUpdateCmd())
+	cmd.AddCommand(newPauseCmd())
+	cmd.AddCommand(newResumeCmd())
+	cmd.AddCommand(newSecretsCmd())
+	cmd.AddCommand(newVerifyCmd())
 
 	return cmd
 }
@@ -0,0 +1,143 @@
+package vcluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/internal/verify"
+	"github.com/spf13/cobra"
+)
+
+func newVerifyCmd() *cobra.Command {
+	var (
+		full    bool
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify <name>",
+		Short: "Run smoke tests against a vCluster",
+		Long: `Run a smoke test suite against a provisioned vCluster using its own kubeconfig:
+
+  - the API server answers and reports its version
+  - a Ready ClusterSecretStore and ClusterIssuer were synced in from the host
+  - a temporary ExternalSecret syncs from the vCluster's 1Password item
+  - a temporary Certificate for the vCluster hostname is issued
+  - the hostname resolves to the vCluster VIP
+
+--full also deploys an echo workload and requests it through the API
+server's service proxy. Test resources live in a scratch namespace that is
+deleted when the suite finishes. Exits non-zero if any check fails.
+
+Examples:
+  hctl vcluster verify media
+  hctl vcluster verify media --full -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg := config.Get()
+
+			client, err := kube.NewClient(cfg.KubeContext)
+			if err != nil {
+				return fmt.Errorf("connecting to cluster: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			vc, err := client.GetVCluster(ctx, cfg.Platform.PlatformNamespace, name)
+			if err != nil {
+				return kube.ClassifyError(err)
+			}
+			hostname, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "exposure", "hostname")
+			vip, _, _ := platform.UnstructuredNestedString(vc.Object, "spec", "exposure", "vip")
+			item, _, _ := platform.UnstructuredNestedString(vc.Object, "status", "credentials", "onePasswordItem")
+			if item == "" {
+				item = fmt.Sprintf("vcluster-%s-kubeconfig", name)
+			}
+
+			kubeconfigData, secretNames := client.VClusterKubeconfig(ctx, name)
+			if kubeconfigData == nil {
+				return hcerrors.New(hcerrors.ErrNotFound, "kubeconfig secret not found for vCluster %q — tried: %v", name, secretNames)
+			}
+			vclient, err := kube.NewClientFromKubeconfig(kubeconfigData)
+			if err != nil {
+				return fmt.Errorf("vCluster %s: %w", name, err)
+			}
+
+			target := &verify.Target{
+				Name:            name,
+				Hostname:        hostname,
+				VIP:             vip,
+				OnePasswordItem: item,
+				Kube:            vclient.Clientset,
+				Dynamic:         vclient.Dynamic,
+				Resolver:        net.DefaultResolver,
+				Wait:            timeout,
+			}
+
+			// The checks create their test resources as they run, so the
+			// suite is one planned cluster change.
+			var results []verify.Result
+			desc := fmt.Sprintf("create test resources in a scratch namespace in vCluster %s, run the smoke tests, then delete the namespace", name)
+			if full {
+				desc = fmt.Sprintf("create test resources and an echo workload in a scratch namespace in vCluster %s, run the smoke tests, then delete the namespace", name)
+			}
+			mp := mutation.New(cfg.RepoPath)
+			mp.Cluster(desc, func() error {
+				runCtx, runCancel := context.WithTimeout(context.Background(), 10*time.Minute)
+				defer runCancel()
+				results = verify.Run(runCtx, target, verify.Checks(), full)
+				return nil
+			})
+			if cfg.DryRun {
+				return mp.DryRun()
+			}
+			if err := mp.Execute(); err != nil {
+				return err
+			}
+			failed := verify.Failed(results)
+
+			if !tui.PrintStructured(results) {
+				printVerifyResults(name, results)
+			}
+			if failed > 0 {
+				return hcerrors.NewPlatformError("%d of %d checks failed for vCluster %s", failed, len(results), name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&full, "full", false, "also deploy an echo workload and request it")
+	cmd.Flags().DurationVar(&timeout, "timeout", verify.DefaultWait, "how long each check waits for its resources to become ready")
+
+	return cmd
+}
+
+func printVerifyResults(name string, results []verify.Result) {
+	fmt.Printf("\n  %s\n\n", tui.TitleStyle.Render("Verify "+name))
+	var rows [][]string
+	for _, r := range results {
+		status := tui.SuccessStyle.Render(tui.IconCheck + " pass")
+		switch r.Status {
+		case verify.StatusFail:
+			status = tui.ErrorStyle.Render(tui.IconCross + " fail")
+		case verify.StatusSkip:
+			status = tui.DimStyle.Render("– skip")
+		}
+		took := "—"
+		if r.Status != verify.StatusSkip {
+			took = r.Duration().Round(time.Millisecond).String()
+		}
+		rows = append(rows, []string{r.Check, status, took, tui.OrDash(r.Detail)})
+	}
+	fmt.Println(tui.Table([]string{"CHECK", "STATUS", "TIME", "DETAIL"}, rows))
+}
@@ -0,0 +1,639 @@
+package vcluster
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// wizardStep is one prompt of the interactive create wizard. Answers are
+// written to the create command's flags, so the rest of runCreate treats
+// wizard answers and flags alike.
+type wizardStep struct {
+	label string
+	// active reports whether the step applies, given the answers so far.
+	active func() bool
+	// ask prompts for the value. tui.ErrBack returns to the previous step.
+	ask func() error
+	// value renders the current answer for the review screen.
+	value func() string
+}
+
+// runWizard walks the active steps in order (esc goes back one step), then
+// shows a review screen where any answer can be edited before the manifest
+// preview.
+func runWizard(steps []wizardStep) error {
+	asked := make([]bool, len(steps))
+	if err := walkSteps(steps, 0, asked, false); err != nil {
+		return err
+	}
+
+	for {
+		var choices []string
+		var index []int
+		for i, s := range steps {
+			if s.active() {
+				choices = append(choices, fmt.Sprintf("%-26s %s", s.label, s.value()))
+				index = append(index, i)
+			}
+		}
+		choices = append(choices, tui.SuccessStyle.Render(tui.IconCheck+" Continue to manifest preview"))
+
+		sel, err := tui.SelectWith(tui.SelectOpts{
+			Title:     "Review vCluster settings — select a field to edit",
+			Choices:   choices,
+			Default:   len(choices) - 1,
+			AllowBack: true,
+		})
+		if errors.Is(err, tui.ErrBack) {
+			sel = len(index) - 1 // esc re-opens the last answer
+		} else if err != nil {
+			return err
+		}
+		if sel == len(index) {
+			return nil
+		}
+
+		i := index[sel]
+		if err := steps[i].ask(); err != nil && !errors.Is(err, tui.ErrBack) {
+			return err
+		}
+		// An edit can switch on later steps (e.g. advanced settings); ask
+		// those before returning to the review.
+		if err := walkSteps(steps, i+1, asked, true); err != nil {
+			return err
+		}
+	}
+}
+
+// walkSteps asks each active step from start onwards. With onlyNew, steps
+// asked before are passed over, and going back past the first step asked
+// returns to the caller.
+func walkSteps(steps []wizardStep, start int, asked []bool, onlyNew bool) error {
+	var history []int
+	for i := start; i < len(steps); {
+		if !steps[i].active() || (onlyNew && asked[i]) {
+			i++
+			continue
+		}
+		err := steps[i].ask()
+		if errors.Is(err, tui.ErrBack) {
+			if len(history) == 0 {
+				if onlyNew {
+					return nil
+				}
+				continue // nothing before the first step; ask again
+			}
+			i = history[len(history)-1]
+			history = history[:len(history)-1]
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		asked[i] = true
+		history = append(history, i)
+		i++
+	}
+	return nil
+}
+
+// createWizard builds the steps for 'hctl vcluster create'. Flags the user
+// passed explicitly are not prompted for. name is updated in place.
+func createWizard(cmd *cobra.Command, cfg *config.Config, name *string, nameFromArgs bool) []wizardStep {
+	flags := cmd.Flags()
+	userSet := map[string]bool{}
+	flags.Visit(func(f *pflag.Flag) { userSet[f.Name] = true })
+
+	set := func(flag, value string) error { return flags.Set(flag, value) }
+	// reset restores a flag to its default and marks it unset, so answers
+	// to steps that no longer apply do not leak into the spec.
+	reset := func(names ...string) {
+		for _, n := range names {
+			if userSet[n] {
+				continue
+			}
+			f := flags.Lookup(n)
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				_ = sv.Replace(nil) // slice flags here all default to empty
+			} else {
+				_ = f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		}
+	}
+	prompt := func(title, placeholder, def string, validate func(string) error) (string, error) {
+		return tui.Prompt(tui.PromptOpts{Title: title, Placeholder: placeholder, Default: def, Validate: validate, AllowBack: true})
+	}
+	selectOne := func(title string, choices []string, def int) (int, error) {
+		return tui.SelectWith(tui.SelectOpts{Title: title, Choices: choices, Default: def, AllowBack: true})
+	}
+	yesNo := func(title string, def bool) (bool, error) {
+		d := 0
+		if def {
+			d = 1
+		}
+		idx, err := selectOne(title, []string{"No", "Yes"}, d)
+		return idx == 1, err
+	}
+	yesNoValue := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return tui.DimStyle.Render(def)
+		}
+		return v
+	}
+
+	presetName := func() string {
+		if createPreset == "" {
+			return "dev"
+		}
+		return createPreset
+	}
+	presetSpec := func() platform.VClusterSpec {
+		spec := baseSpec(*name)
+		_ = platform.ApplyPreset(&spec, presetName())
+		return spec
+	}
+	defaultHostname := func() string { return fmt.Sprintf("%s.%s", *name, cfg.Platform.Domain) }
+	hostname := func() string {
+		if createHostname == "" {
+			return defaultHostname()
+		}
+		return createHostname
+	}
+	persistenceEnabled := func() bool {
+		if flags.Changed("persistence") {
+			return createPersistence
+		}
+		p := presetSpec().VCluster.Persistence
+		return p != nil && p.Enabled
+	}
+	replicas := func() int {
+		if createReplicas > 0 {
+			return createReplicas
+		}
+		return presetSpec().VCluster.Replicas
+	}
+	corednsReplicas := func() int {
+		if createCoreDNSReplicas > 0 {
+			return createCoreDNSReplicas
+		}
+		if c := presetSpec().VCluster.CoreDNS; c != nil {
+			return c.Replicas
+		}
+		return 1
+	}
+
+	quotaFlags := []string{"quota-cpu", "quota-memory", "quota-pods"}
+	advancedFlags := append([]string{"replicas", "k8s-version", "isolation", "environment", "persistence",
+		"persistence-size", "subnet", "vip", "ip-families", "ip-family-policy", "coredns-replicas"}, quotaFlags...)
+	workloadFlags := []string{"workload-repo-url", "workload-repo-base-path", "workload-repo-path", "workload-repo-revision"}
+
+	advanced := false
+	for _, f := range advancedFlags {
+		if userSet[f] {
+			advanced = true
+		}
+	}
+	customRepo := false
+	hasWorkloadFlags := false
+	for _, f := range workloadFlags {
+		hasWorkloadFlags = hasWorkloadFlags || userSet[f]
+	}
+
+	always := func() bool { return true }
+	unless := func(flag string) func() bool { return func() bool { return !userSet[flag] } }
+	whenAdvanced := func(flag string) func() bool { return func() bool { return advanced && !userSet[flag] } }
+	whenCustomRepo := func(flag string) func() bool { return func() bool { return customRepo && !userSet[flag] } }
+	whenStrict := func(flag string) func() bool {
+		return func() bool { return advanced && createIsolationMode == "strict" && !userSet[flag] }
+	}
+
+	return []wizardStep{
+		{
+			label:  "Name",
+			active: func() bool { return !nameFromArgs },
+			ask: func() error {
+				v, err := prompt("vCluster name", "e.g. my-project", *name, func(v string) error {
+					return platform.ValidateVClusterName(v, cfg.RepoPath)
+				})
+				if err == nil {
+					*name = v
+				}
+				return err
+			},
+			value: func() string { return *name },
+		},
+		{
+			label:  "Preset",
+			active: unless("preset"),
+			ask: func() error {
+				def := 0
+				if presetName() == "prod" {
+					def = 1
+				}
+				idx, err := selectOne("Select preset", []string{
+					"dev  — 1 replica, 768Mi, SQLite, no persistence",
+					"prod — 3 replicas, 2Gi, etcd HA, 10Gi persistence",
+				}, def)
+				if err != nil {
+					return err
+				}
+				return set("preset", []string{"dev", "prod"}[idx])
+			},
+			value: presetName,
+		},
+		{
+			label:  "Hostname",
+			active: unless("hostname"),
+			ask: func() error {
+				v, err := prompt("External hostname", "e.g. "+defaultHostname(), hostname(), func(v string) error {
+					return platform.ValidateHostname(v, cfg.Platform.Domain)
+				})
+				if err != nil {
+					return err
+				}
+				// Keep following the name while the default is accepted.
+				if v == defaultHostname() {
+					v = ""
+				}
+				return set("hostname", v)
+			},
+			value: hostname,
+		},
+		{
+			label:  "NFS egress",
+			active: unless("enable-nfs"),
+			ask: func() error {
+				yes, err := yesNo("Enable NFS egress?", createEnableNFS)
+				if err != nil {
+					return err
+				}
+				return set("enable-nfs", strconv.FormatBool(yes))
+			},
+			value: func() string { return yesNoValue(createEnableNFS) },
+		},
+		{
+			label:  "Advanced settings",
+			active: always,
+			ask: func() error {
+				yes, err := yesNo("Customize advanced settings? (replicas, k8s version, isolation, environment, persistence, networking)", advanced)
+				if err != nil {
+					return err
+				}
+				advanced = yes
+				if !yes {
+					reset(advancedFlags...)
+				}
+				return nil
+			},
+			value: func() string { return yesNoValue(advanced) },
+		},
+		{
+			label:  "  Replicas",
+			active: whenAdvanced("replicas"),
+			ask: func() error {
+				v, err := prompt("Control plane replicas", "", strconv.Itoa(replicas()), func(v string) error {
+					_, err := platform.ParseReplicas(v)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				return set("replicas", v)
+			},
+			value: func() string { return strconv.Itoa(replicas()) },
+		},
+		{
+			label:  "  Kubernetes version",
+			active: whenAdvanced("k8s-version"),
+			ask: func() error {
+				versions := []string{"", "1.33", "1.32"}
+				def := 0
+				for i, v := range versions {
+					if v == createK8sVersion {
+						def = i
+					}
+				}
+				idx, err := selectOne("Kubernetes version", []string{"v1.34.3 (default)", "1.33", "1.32"}, def)
+				if err != nil {
+					return err
+				}
+				return set("k8s-version", versions[idx])
+			},
+			value: func() string { return orDefault(createK8sVersion, "v1.34.3") },
+		},
+		{
+			label:  "  Isolation mode",
+			active: whenAdvanced("isolation"),
+			ask: func() error {
+				def := 0
+				if createIsolationMode == "strict" {
+					def = 1
+				}
+				idx, err := selectOne("Isolation mode", []string{
+					"standard — shared kernel, namespace isolation (default)",
+					"strict   — resource quotas, limit ranges, network policies",
+				}, def)
+				if err != nil {
+					return err
+				}
+				if idx == 0 {
+					reset(quotaFlags...)
+				}
+				return set("isolation", []string{"", "strict"}[idx])
+			},
+			value: func() string { return orDefault(createIsolationMode, "standard") },
+		},
+		{
+			label:  "    Quota CPU",
+			active: whenStrict("quota-cpu"),
+			ask: func() error {
+				v, err := prompt("Namespace CPU quota, control plane included (empty derives it)", "e.g. 4 or 2500m", createQuotaCPU, func(v string) error {
+					if v == "" {
+						return nil
+					}
+					return platform.ValidateCPU(v)
+				})
+				if err != nil {
+					return err
+				}
+				return set("quota-cpu", v)
+			},
+			value: func() string { return orDefault(createQuotaCPU, "derived from the control plane") },
+		},
+		{
+			label:  "    Quota memory",
+			active: whenStrict("quota-memory"),
+			ask: func() error {
+				v, err := prompt("Namespace memory quota, control plane included (empty derives it)", "e.g. 8Gi", createQuotaMemory, func(v string) error {
+					if v == "" {
+						return nil
+					}
+					return platform.ValidateQuantity(v)
+				})
+				if err != nil {
+					return err
+				}
+				return set("quota-memory", v)
+			},
+			value: func() string { return orDefault(createQuotaMemory, "derived from the control plane") },
+		},
+		{
+			label:  "    Quota pods",
+			active: whenStrict("quota-pods"),
+			ask: func() error {
+				def := ""
+				if createQuotaPods > 0 {
+					def = strconv.Itoa(createQuotaPods)
+				}
+				v, err := prompt("Namespace pod quota, control plane included (empty derives it)", "e.g. 30", def, func(v string) error {
+					if v == "" {
+						return nil
+					}
+					if n, err := strconv.Atoi(v); err != nil || n < 1 {
+						return fmt.Errorf("invalid pod count %q: must be a positive integer", v)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if v == "" {
+					reset("quota-pods")
+					return nil
+				}
+				return set("quota-pods", v)
+			},
+			value: func() string {
+				if createQuotaPods > 0 {
+					return strconv.Itoa(createQuotaPods)
+				}
+				return orDefault("", "derived from the control plane")
+			},
+		},
+		{
+			label:  "  ArgoCD environment",
+			active: whenAdvanced("environment"),
+			ask: func() error {
+				envs := []string{"production", "staging", "development"}
+				def := 0
+				for i, e := range envs {
+					if e == createEnvironment {
+						def = i
+					}
+				}
+				idx, err := selectOne("ArgoCD environment", envs, def)
+				if err != nil {
+					return err
+				}
+				return set("environment", envs[idx])
+			},
+			value: func() string { return createEnvironment },
+		},
+		{
+			label:  "  Persistence",
+			active: func() bool { return advanced && !userSet["persistence"] && !userSet["persistence-size"] },
+			ask: func() error {
+				yes, err := yesNo("Enable persistence?", persistenceEnabled())
+				if err != nil {
+					return err
+				}
+				if !yes {
+					reset("persistence-size")
+				}
+				return set("persistence", strconv.FormatBool(yes))
+			},
+			value: func() string { return yesNoValue(persistenceEnabled()) },
+		},
+		{
+			label:  "  Persistence size",
+			active: func() bool { return advanced && persistenceEnabled() && !userSet["persistence-size"] },
+			ask: func() error {
+				def := createPersistenceSize
+				if p := presetSpec().VCluster.Persistence; def == "" && p != nil && p.Size != "" {
+					def = p.Size
+				}
+				if def == "" {
+					def = "10Gi"
+				}
+				v, err := prompt("Persistence size", "e.g. 10Gi", def, platform.ValidateQuantity)
+				if err != nil {
+					return err
+				}
+				return set("persistence-size", v)
+			},
+			value: func() string { return orDefault(createPersistenceSize, "preset default") },
+		},
+		{
+			label:  "  VIP subnet",
+			active: whenAdvanced("subnet"),
+			ask: func() error {
+				v, err := prompt("VIP subnet (optional; IPv4, IPv6 or one of each)", "e.g. 10.0.4.0/24 or 10.0.4.0/24,fd00:4::/64", createSubnet, func(v string) error {
+					if v == "" {
+						return nil
+					}
+					return platform.ValidateSubnet(v)
+				})
+				if err != nil {
+					return err
+				}
+				if v == "" {
+					reset("vip", "ip-families")
+				}
+				return set("subnet", v)
+			},
+			value: func() string { return orDefault(createSubnet, "none") },
+		},
+		{
+			label:  "  Static VIP",
+			active: func() bool { return advanced && createSubnet != "" && !userSet["vip"] },
+			ask: func() error {
+				v, err := prompt("Static VIP (optional, auto-assigned from subnet if empty)", "e.g. 10.0.4.210 or fd00:4::d2", createVIP, func(v string) error {
+					if v == "" {
+						return nil
+					}
+					return platform.ValidateVIP(v, createSubnet)
+				})
+				if err != nil {
+					return err
+				}
+				return set("vip", v)
+			},
+			value: func() string { return orDefault(createVIP, "auto-assigned") },
+		},
+		{
+			label:  "  IP families",
+			active: func() bool { return advanced && createSubnet != "" && !userSet["ip-families"] },
+			ask: func() error {
+				choices := [][]string{nil, {"IPv4"}, {"IPv6"}, {"IPv4", "IPv6"}, {"IPv6", "IPv4"}}
+				def := 0
+				for i, c := range choices {
+					if strings.Join(c, ",") == strings.Join(createIPFamilies, ",") {
+						def = i
+					}
+				}
+				idx, err := selectOne("IP families of the API Service", []string{
+					"from the subnet and VIP",
+					"IPv4",
+					"IPv6",
+					"IPv4, IPv6 (dual-stack)",
+					"IPv6, IPv4 (dual-stack, IPv6 primary)",
+				}, def)
+				if err != nil {
+					return err
+				}
+				f := flags.Lookup("ip-families")
+				if err := f.Value.(pflag.SliceValue).Replace(choices[idx]); err != nil {
+					return err
+				}
+				f.Changed = idx != 0
+				return nil
+			},
+			value: func() string { return orDefault(strings.Join(createIPFamilies, ", "), "from the addresses") },
+		},
+		{
+			label:  "  CoreDNS replicas",
+			active: whenAdvanced("coredns-replicas"),
+			ask: func() error {
+				v, err := prompt("CoreDNS replicas", "", strconv.Itoa(corednsReplicas()), func(v string) error {
+					_, err := platform.ParseReplicas(v)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				return set("coredns-replicas", v)
+			},
+			value: func() string { return strconv.Itoa(corednsReplicas()) },
+		},
+		{
+			label:  "Custom workload repo",
+			active: func() bool { return !hasWorkloadFlags },
+			ask: func() error {
+				yes, err := yesNo("Use a custom workload repository? (default: workloads/ in this repo)", customRepo)
+				if err != nil {
+					return err
+				}
+				customRepo = yes
+				if !yes {
+					reset(workloadFlags...)
+				}
+				return nil
+			},
+			value: func() string { return yesNoValue(customRepo) },
+		},
+		{
+			label:  "  Repo URL",
+			active: whenCustomRepo("workload-repo-url"),
+			ask: func() error {
+				v, err := prompt("Workload repo URL", "e.g. https://github.com/myorg/my-workloads", createWorkloadRepoURL, nil)
+				if err != nil {
+					return err
+				}
+				return set("workload-repo-url", v)
+			},
+			value: func() string { return orDefault(createWorkloadRepoURL, "this repo") },
+		},
+		{
+			label:  "  Base path",
+			active: whenCustomRepo("workload-repo-base-path"),
+			ask: func() error {
+				v, err := prompt("Base path in repo (optional)", "e.g. clusters/dev-team-1", createWorkloadRepoBasePath, nil)
+				if err != nil {
+					return err
+				}
+				return set("workload-repo-base-path", v)
+			},
+			value: func() string { return orDefault(createWorkloadRepoBasePath, "none") },
+		},
+		{
+			label:  "  Workload path",
+			active: whenCustomRepo("workload-repo-path"),
+			ask: func() error {
+				def := createWorkloadRepoPath
+				if def == "" {
+					def = layout.Active().WorkloadsRootFor(createEnvironment)
+				}
+				v, err := prompt("Workload path", "directory containing manifests", def, nil)
+				if err != nil {
+					return err
+				}
+				return set("workload-repo-path", v)
+			},
+			value: func() string {
+				return orDefault(createWorkloadRepoPath, layout.Active().WorkloadsRootFor(createEnvironment))
+			},
+		},
+		{
+			label:  "  Git revision",
+			active: whenCustomRepo("workload-repo-revision"),
+			ask: func() error {
+				def := createWorkloadRepoRevision
+				if def == "" {
+					def = "main"
+				}
+				v, err := prompt("Git revision (branch/tag)", "", def, nil)
+				if err != nil {
+					return err
+				}
+				return set("workload-repo-revision", v)
+			},
+			value: func() string { return orDefault(createWorkloadRepoRevision, "main") },
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package vcluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// scriptedSteps returns steps whose ask funcs pop results from script and
+// record the order they were asked in.
+func scriptedSteps(n int, script []error, active []bool) ([]wizardStep, *[]int) {
+	var order []int
+	steps := make([]wizardStep, n)
+	for i := range steps {
+		i := i
+		steps[i] = wizardStep{
+			active: func() bool { return active == nil || active[i] },
+			ask: func() error {
+				order = append(order, i)
+				if len(script) == 0 {
+					return nil
+				}
+				err := script[0]
+				script = script[1:]
+				return err
+			},
+			value: func() string { return "" },
+		}
+	}
+	return steps, &order
+}
+
+func TestWalkStepsBack(t *testing.T) {
+	// 0 ok, 1 ok, 2 back → 1 again, back → 0 again, then all ok.
+	steps, order := scriptedSteps(3, []error{nil, nil, tui.ErrBack, tui.ErrBack, nil, nil, nil}, nil)
+	if err := walkSteps(steps, 0, make([]bool, 3), false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 1, 0, 1, 2}; !reflect.DeepEqual(*order, want) {
+		t.Errorf("order = %v, want %v", *order, want)
+	}
+}
+
+func TestWalkStepsBackSkipsInactive(t *testing.T) {
+	steps, order := scriptedSteps(3, []error{nil, tui.ErrBack, nil, nil}, []bool{true, false, true})
+	if err := walkSteps(steps, 0, make([]bool, 3), false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 2, 0, 2}; !reflect.DeepEqual(*order, want) {
+		t.Errorf("order = %v, want %v", *order, want)
+	}
+}
+
+func TestWalkStepsOnlyNew(t *testing.T) {
+	steps, order := scriptedSteps(4, nil, nil)
+	asked := []bool{true, true, false, true}
+	if err := walkSteps(steps, 1, asked, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2}; !reflect.DeepEqual(*order, want) {
+		t.Errorf("order = %v, want %v", *order, want)
+	}
+
+	// Going back from the first new step returns to the review screen.
+	steps, order = scriptedSteps(4, []error{tui.ErrBack}, nil)
+	if err := walkSteps(steps, 0, []bool{true, false, false, false}, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1}; !reflect.DeepEqual(*order, want) {
+		t.Errorf("order = %v, want %v", *order, want)
+	}
+}
+
+func TestWalkStepsCancel(t *testing.T) {
+	steps, _ := scriptedSteps(2, []error{tui.ErrCancelled}, nil)
+	if err := walkSteps(steps, 0, make([]bool, 2), false); err != tui.ErrCancelled {
+		t.Errorf("err = %v, want ErrCancelled", err)
+	}
+}
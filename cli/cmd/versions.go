@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/jamesatintegratnio/hctl/internal/versions"
+	"github.com/spf13/cobra"
+)
+
+var (
+	versionsOffline  bool
+	versionsOutdated bool
+)
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "Compare platform component versions with their latest releases",
+	Long: `Lists the versions of the platform's components three ways:
+
+  - recorded: the chart version in each addons.yaml entry and vcluster
+    request, and the pipeline image tags in each promise
+  - deployed: the targetRevision of the ArgoCD Applications rendered from
+    them, the chart version the orchestrator recorded on each vcluster, and
+    the live promises' pipeline images. For addons deployed to this
+    cluster, APP is the image tag of the main Deployment.
+  - latest: the newest release in each chart repository's index.yaml, and
+    the latest GitHub release of versions.promiseReleases
+
+Chart versions are only compared with chart versions, and image tags with
+a chart's appVersion. Prereleases count as older than their release, and
+tags that are not versions (such as "latest") are reported as unpinned.
+OCI chart repositories have no index, so their latest version is unknown.
+
+versions.ignore in the config hides rows: a name (wildcards allowed), or
+name@version to hide a component until a release newer than version.
+
+--offline skips the cluster and the upstream lookups. Failed lookups are
+listed at the end (and under errors with -o json) without failing.`,
+	Example: `  hctl versions
+  hctl versions --outdated
+  hctl versions --offline
+  hctl versions -o json > versions.json`,
+	Args: cobra.NoArgs,
+	RunE: runVersions,
+}
+
+func init() {
+	versionsCmd.Flags().BoolVar(&versionsOffline, "offline", false, "only read the repo: no cluster or upstream lookups")
+	versionsCmd.Flags().BoolVar(&versionsOutdated, "outdated", false, "only list components with a newer release")
+}
+
+func runVersions(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	if err := cfg.RequireRepoPath(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), 60*time.Second)
+	defer cancel()
+
+	report, err := collectVersions(ctx, cfg, !versionsOffline)
+	if err != nil {
+		return err
+	}
+	if versionsOutdated {
+		var outdated []versions.Component
+		for _, c := range report.Components {
+			if c.Status == versions.StatusOutdated {
+				outdated = append(outdated, c)
+			}
+		}
+		report.Components = append([]versions.Component{}, outdated...)
+	}
+	if !tui.PrintStructured(report) {
+		printVersions(report)
+	}
+	return nil
+}
+
+// collectVersions reads the repo's component versions and, when online,
+// merges in what the cluster runs and the latest upstream releases.
+func collectVersions(ctx context.Context, cfg *config.Config, online bool) (*versions.Report, error) {
+	components, err := versions.FromRepo(cfg.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		live   *versions.Live
+		latest *versions.Latest
+		errs   []error
+	)
+	if online {
+		client, err := kube.NewClient(cfg.KubeContext)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("connecting to cluster: %w", err))
+		} else {
+			var liveErrs []error
+			live, liveErrs = versions.CollectLive(ctx, client, "argocd", cfg.Platform.PlatformNamespace)
+			errs = append(errs, liveErrs...)
+		}
+		var latestErrs []error
+		latest, latestErrs = versions.FetchLatest(ctx, components, cfg.Versions.PromiseReleases)
+		errs = append(errs, latestErrs...)
+	}
+	return versions.NewReport(versions.Merge(components, live, latest), cfg.Versions.Ignore, errs), nil
+}
+
+func printVersions(report *versions.Report) {
+	fmt.Printf("\n  %s\n\n", tui.TitleStyle.Render("Platform component versions"))
+	headers := []string{"NAME", "KIND", "SOURCE", "RECORDED", "DEPLOYED", "APP", "LATEST", "STATUS"}
+	var rows [][]string
+	for _, c := range report.Components {
+		app := c.AppVersion
+		if c.LatestAppVersion != "" && !versions.Equal(c.AppVersion, c.LatestAppVersion) {
+			app = strings.TrimSpace(app + " → " + c.LatestAppVersion)
+		}
+		rows = append(rows, []string{
+			c.Name, c.Kind, c.Source, c.Recorded, dash(c.Deployed), dash(app), dash(c.Latest), versionStatus(c),
+		})
+	}
+	fmt.Println(tui.Table(headers, rows))
+	if report.Outdated > 0 {
+		fmt.Printf("\n  %s %d component(s) have a newer release\n", tui.WarningStyle.Render(tui.IconWarn), report.Outdated)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("  %s %s\n", tui.WarningStyle.Render(tui.IconWarn), e)
+	}
+}
+
+// versionStatus renders a component's status, with its delta when outdated.
+func versionStatus(c versions.Component) string {
+	switch c.Status {
+	case versions.StatusOutdated:
+		return tui.WarningStyle.Render(c.Status + " (" + c.Delta + ")")
+	case versions.StatusCurrent:
+		return tui.SuccessStyle.Render(c.Status)
+	}
+	return tui.DimStyle.Render(c.Status)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	"github.com/jamesatintegratnio/hctl/internal/versions"
+)
+
+func TestE2EVersionsOffline(t *testing.T) {
+	repo, _ := newE2E(t)
+	cfg := testutil.Config(repo)
+	cfg.Versions.Ignore = []string{"vcluster-*"}
+	testutil.WriteConfig(t, cfg)
+
+	res := testutil.MustRun(t, rootCmd, "versions", "--offline", "-o", "json")
+	var report versions.Report
+	if err := json.Unmarshal([]byte(res.Stdout), &report); err != nil {
+		t.Fatalf("output is not a versions report: %v\n%s", err, res.Stdout)
+	}
+	if len(report.Components) != 1 {
+		t.Fatalf("components = %+v, want only the argocd chart", report.Components)
+	}
+	c := report.Components[0]
+	if c.Name != "argocd" || c.Kind != versions.KindChart || c.Source != "environment/production" ||
+		c.Recorded != "9.4.3" || c.Status != versions.StatusUnknown {
+		t.Errorf("component = %+v, want argocd 9.4.3 from environment/production, latest unknown", c)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("--offline looked something up: %v", report.Errors)
+	}
+}
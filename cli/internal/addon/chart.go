@@ -0,0 +1,68 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// chartHTTP fetches chart repository indexes.
+var chartHTTP = &http.Client{Timeout: 30 * time.Second}
+
+// VerifyChart checks that a Helm repository's index.yaml lists chart at
+// version. OCI repositories (oci://) have no index and are not checked.
+func VerifyChart(ctx context.Context, repo, chart, version string) error {
+	if strings.HasPrefix(repo, "oci://") {
+		return nil
+	}
+	u := strings.TrimRight(repo, "/") + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return hcerrors.NewUserError("chart repository %q: %v", repo, err)
+	}
+	resp, err := chartHTTP.Do(req)
+	if err != nil {
+		return hcerrors.New(hcerrors.ErrClusterUnreachable, "fetching %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return hcerrors.New(hcerrors.ErrNotFound, "fetching %s: %s", u, resp.Status).
+			WithRemediation("check --repo points at a Helm repository")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", u, err)
+	}
+
+	var index struct {
+		Entries map[string][]struct {
+			Version string `yaml:"version"`
+		} `yaml:"entries"`
+	}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", u, err)
+	}
+	versions, ok := index.Entries[chart]
+	if !ok {
+		return hcerrors.New(hcerrors.ErrNotFound, "chart %q not found in %s", chart, repo)
+	}
+	var known []string
+	for _, v := range versions {
+		if v.Version == version || "v"+v.Version == version {
+			return nil
+		}
+		known = append(known, v.Version)
+	}
+	// Repositories list versions newest first.
+	if len(known) > 5 {
+		known = known[:5]
+	}
+	return hcerrors.New(hcerrors.ErrNotFound, "chart %s has no version %q in %s", chart, version, repo).
+		WithRemediation("available versions include: " + strings.Join(known, ", "))
+}
@@ -0,0 +1,55 @@
+package addon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func TestVerifyChart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/charts/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  uptime-kuma:
+    - version: 2.21.2
+    - version: 2.21.1
+`))
+	}))
+	defer srv.Close()
+	repo := srv.URL + "/charts/"
+	ctx := context.Background()
+
+	for _, version := range []string{"2.21.2", "v2.21.1"} {
+		if err := VerifyChart(ctx, repo, "uptime-kuma", version); err != nil {
+			t.Errorf("VerifyChart(%s) = %v", version, err)
+		}
+	}
+
+	err := VerifyChart(ctx, repo, "uptime-kuma", "9.9.9")
+	if !errors.Is(err, hcerrors.ErrNotFound) {
+		t.Fatalf("VerifyChart(unknown version) error = %v, want not found", err)
+	}
+	var hcErr *hcerrors.HctlError
+	if !errors.As(err, &hcErr) || !strings.Contains(hcErr.Remediation, "2.21.2, 2.21.1") {
+		t.Errorf("remediation does not list available versions: %v", err)
+	}
+
+	if err := VerifyChart(ctx, repo, "gone", "1.0.0"); !errors.Is(err, hcerrors.ErrNotFound) {
+		t.Errorf("VerifyChart(unknown chart) error = %v, want not found", err)
+	}
+	if err := VerifyChart(ctx, srv.URL+"/other", "uptime-kuma", "2.21.2"); !errors.Is(err, hcerrors.ErrNotFound) {
+		t.Errorf("VerifyChart(no index) error = %v, want not found", err)
+	}
+	if err := VerifyChart(ctx, "oci://ghcr.io/org/charts", "app", "1.0.0"); err != nil {
+		t.Errorf("VerifyChart(oci) = %v", err)
+	}
+}
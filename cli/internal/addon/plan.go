@@ -0,0 +1,579 @@
+// Package addon plans and applies addon changes across the layered addon
+// configuration (environment → cluster-role → cluster). A Plan collects every
+// file change for one addon up front so the CLI can preview them together,
+// write them as a unit, and commit them in a single git operation.
+package addon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// Layer kinds, in value-resolution order (last wins).
+const (
+	LayerEnvironment = "environment"
+	LayerClusterRole = "cluster-role"
+	LayerCluster     = "cluster"
+)
+
+// Stakater Application chart defaults for new addons.yaml entries.
+const (
+	DefaultChartRepository = "https://stakater.github.io/stakater-charts"
+	DefaultChartName       = "application"
+	DefaultChartVersion    = "6.14.0"
+)
+
+// Keys hctl reads in addons.yaml entries beyond those the ApplicationSet
+// chart renders.
+const (
+	// DependsOnKey lists the addons an entry needs synced before it.
+	DependsOnKey = "dependsOn"
+	// SyncWaveAnnotation, under annotationsApp, orders the Applications
+	// ArgoCD applies in one sync.
+	SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+)
+
+// layerDirs maps layer kinds to the repo directory holding their layers.
+var layerDirs = map[string]string{
+	LayerEnvironment: layout.EnvironmentsDir,
+	LayerClusterRole: layout.ClusterRolesDir,
+	LayerCluster:     layout.ClustersDir,
+}
+
+// Layer identifies one addon configuration layer, e.g. cluster "vcluster-media".
+type Layer struct {
+	Kind string
+	Name string
+}
+
+// NewLayer builds the layer selected by the --layer flag and its companions.
+func NewLayer(kind, env, clusterRole, cluster string) (Layer, error) {
+	switch kind {
+	case LayerEnvironment:
+		return Layer{Kind: kind, Name: env}, nil
+	case LayerClusterRole:
+		if clusterRole == "" {
+			return Layer{}, hcerrors.NewUserError("--cluster-role is required for layer 'cluster-role'")
+		}
+		return Layer{Kind: kind, Name: clusterRole}, nil
+	case LayerCluster:
+		if cluster == "" {
+			return Layer{}, hcerrors.NewUserError("--cluster is required for layer 'cluster'")
+		}
+		return Layer{Kind: kind, Name: cluster}, nil
+	default:
+		return Layer{}, hcerrors.NewUserError("invalid layer %q (must be environment, cluster-role, or cluster)", kind)
+	}
+}
+
+// ParseLayer parses a "<kind>=<name>" layer reference such as "cluster=foo".
+func ParseLayer(spec string) (Layer, error) {
+	kind, name, ok := strings.Cut(spec, "=")
+	if !ok || name == "" {
+		return Layer{}, hcerrors.NewUserError("invalid layer %q (expected <kind>=<name>, e.g. cluster=vcluster-media)", spec)
+	}
+	if _, known := layerDirs[kind]; !known {
+		return Layer{}, hcerrors.NewUserError("invalid layer %q (must be environment, cluster-role, or cluster)", kind)
+	}
+	return Layer{Kind: kind, Name: name}, nil
+}
+
+func (l Layer) String() string {
+	return l.Kind + "/" + l.Name
+}
+
+// Dir returns the layer's addons directory.
+func (l Layer) Dir(repoPath string) string {
+	return repopath.Abs(repoPath, repopath.Join(layerDirs[l.Kind], l.Name, "addons"))
+}
+
+// AddonsFile returns the layer's addons.yaml path.
+func (l Layer) AddonsFile(repoPath string) string {
+	return filepath.Join(l.Dir(repoPath), "addons.yaml")
+}
+
+// ValuesDir returns the directory holding the addon's values at this layer.
+func (l Layer) ValuesDir(repoPath, addon string) string {
+	return filepath.Join(l.Dir(repoPath), addon)
+}
+
+// DiscoverLayers lists every layer directory in the repo, in
+// value-resolution order and by name within a kind.
+func DiscoverLayers(repoPath string) ([]Layer, error) {
+	var layers []Layer
+	for _, kind := range []string{LayerEnvironment, LayerClusterRole, LayerCluster} {
+		dirs, err := os.ReadDir(repopath.Abs(repoPath, layerDirs[kind]))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dirs {
+			if d.IsDir() {
+				layers = append(layers, Layer{Kind: kind, Name: d.Name()})
+			}
+		}
+	}
+	return layers, nil
+}
+
+// ReadEntries reads and parses an addons.yaml file into a map of addon entries.
+func ReadEntries(path string) (map[string]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(data)
+}
+
+func parseEntries(data []byte) (map[string]map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing addons.yaml: %w", err)
+	}
+
+	entries := make(map[string]map[string]interface{})
+	for name, val := range raw {
+		if m, ok := val.(map[string]interface{}); ok {
+			entries[name] = m
+		}
+	}
+	return entries, nil
+}
+
+// OpKind is the kind of change an Operation makes at its layer.
+type OpKind string
+
+const (
+	// OpEnable adds or enables the addons.yaml entry and scaffolds values.
+	OpEnable OpKind = "enable"
+	// OpValues writes the layer's values.yaml without touching addons.yaml.
+	OpValues OpKind = "values"
+	// OpDisable sets enabled: false on the addons.yaml entry.
+	OpDisable OpKind = "disable"
+	// OpRemove deletes the addons.yaml entry and the layer's values directory.
+	OpRemove OpKind = "remove"
+)
+
+// Operation is one change at one layer.
+type Operation struct {
+	Op    OpKind `yaml:"op"`
+	Layer Layer  `yaml:"-"`
+	// Addon is the addon changed when it is not the plan's, such as a
+	// dependency enabled alongside it.
+	Addon string `yaml:"-"`
+	// Values replaces the layer's values.yaml (enable and values only). When
+	// nil, a placeholder is scaffolded if no values.yaml exists.
+	Values map[string]interface{} `yaml:"values,omitempty"`
+	// Entry fields for a new addons.yaml entry (enable only); empty fields
+	// take the Stakater Application chart defaults.
+	Namespace       string `yaml:"namespace,omitempty"`
+	ChartRepository string `yaml:"chartRepository,omitempty"`
+	ChartName       string `yaml:"chartName,omitempty"`
+	Version         string `yaml:"version,omitempty"`
+	// DependsOn is added to the entry's dependsOn list (enable only).
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// SyncWave, when set, becomes the entry's sync-wave annotation (enable
+	// only).
+	SyncWave *int `yaml:"-"`
+}
+
+// planFile is the --plan file format:
+//
+//	addon: grafana
+//	operations:
+//	  - op: enable
+//	    layer: environment=production
+//	  - op: values
+//	    layer: cluster=vcluster-media
+//	    values:
+//	      replicas: 2
+type planFile struct {
+	Addon      string          `yaml:"addon"`
+	Operations []planOperation `yaml:"operations"`
+}
+
+type planOperation struct {
+	Operation `yaml:",inline"`
+	LayerSpec string `yaml:"layer"`
+}
+
+// LoadPlanFile reads a plan file and resolves its layer references.
+func LoadPlanFile(path string) (string, []Operation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, hcerrors.NewUserError("reading plan: %w", err)
+	}
+	var pf planFile
+	if err := yaml.Unmarshal(data, &pf); err != nil {
+		return "", nil, hcerrors.New(hcerrors.ErrValidation, "parsing plan %s: %w", path, err)
+	}
+	if pf.Addon == "" {
+		return "", nil, hcerrors.New(hcerrors.ErrValidation, "plan %s: addon is required", path)
+	}
+	if len(pf.Operations) == 0 {
+		return "", nil, hcerrors.New(hcerrors.ErrValidation, "plan %s: no operations", path)
+	}
+	ops := make([]Operation, 0, len(pf.Operations))
+	for i, po := range pf.Operations {
+		layer, err := ParseLayer(po.LayerSpec)
+		if err != nil {
+			return "", nil, hcerrors.New(hcerrors.ErrValidation, "plan %s: operations[%d]: %w", path, i, err)
+		}
+		op := po.Operation
+		op.Layer = layer
+		ops = append(ops, op)
+	}
+	return pf.Addon, ops, nil
+}
+
+// Change is one file write or deletion in a Plan.
+type Change = mutation.File
+
+// Plan is the full set of file changes for one addon across layers.
+type Plan struct {
+	Addon   string
+	Changes []Change
+
+	// emptied are values directories whose files are all deleted; they are
+	// pruned after the deletions succeed.
+	emptied []string
+	// write and remove perform the changes; tests substitute failing ones.
+	write  func(path string, data []byte, perm os.FileMode) error
+	remove func(path string) error
+}
+
+// Build computes the changes ops make for addon without touching the disk.
+// Operations on the same file compose in order.
+func Build(repoPath, addon string, ops []Operation) (*Plan, error) {
+	b := &builder{repoPath: repoPath, addon: addon, content: map[string][]byte{}, before: map[string][]byte{}}
+	plan := &Plan{Addon: addon, write: mutation.WriteFileAtomic, remove: os.Remove}
+
+	for _, op := range ops {
+		if _, ok := layerDirs[op.Layer.Kind]; !ok || op.Layer.Name == "" {
+			return nil, hcerrors.NewUserError("%s: invalid layer %q", op.Op, op.Layer)
+		}
+		var err error
+		switch op.Op {
+		case OpEnable:
+			err = b.enable(op)
+		case OpValues:
+			err = b.values(op)
+		case OpDisable:
+			err = b.disable(op)
+		case OpRemove:
+			var dir string
+			dir, err = b.removeEntry(op)
+			if dir != "" {
+				plan.emptied = append(plan.emptied, dir)
+			}
+		default:
+			err = hcerrors.NewUserError("unknown operation %q (expected enable, values, disable, or remove)", op.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range b.order {
+		after := b.content[path]
+		before := b.before[path]
+		if after != nil && before != nil && bytes.Equal(after, before) {
+			continue
+		}
+		if after == nil && before == nil {
+			continue
+		}
+		plan.Changes = append(plan.Changes, Change{Path: path, Before: before, After: after})
+	}
+	return plan, nil
+}
+
+// builder accumulates pending file contents so later operations see the
+// effect of earlier ones.
+type builder struct {
+	repoPath string
+	addon    string
+	order    []string
+	content  map[string][]byte // pending content; nil = absent
+	before   map[string][]byte
+}
+
+// read returns the pending content of path, loading it from disk on first use.
+func (b *builder) read(path string) ([]byte, error) {
+	if _, seen := b.before[path]; seen {
+		return b.content[path], nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	b.before[path] = data
+	b.content[path] = data
+	b.order = append(b.order, path)
+	return data, nil
+}
+
+func (b *builder) set(path string, data []byte) {
+	b.content[path] = data
+}
+
+func (b *builder) entries(l Layer) (map[string]map[string]interface{}, error) {
+	data, err := b.read(l.AddonsFile(b.repoPath))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return map[string]map[string]interface{}{}, nil
+	}
+	return parseEntries(data)
+}
+
+func (b *builder) setEntries(l Layer, entries map[string]map[string]interface{}) error {
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("marshaling addons.yaml: %w", err)
+	}
+	b.set(l.AddonsFile(b.repoPath), data)
+	return nil
+}
+
+// name returns the addon op changes.
+func (b *builder) name(op Operation) string {
+	return orDefault(op.Addon, b.addon)
+}
+
+func (b *builder) enable(op Operation) error {
+	entries, err := b.entries(op.Layer)
+	if err != nil {
+		return err
+	}
+	name := b.name(op)
+	if existing, ok := entries[name]; ok {
+		changed := setDependencies(existing, op)
+		if existing["enabled"] == true && !changed {
+			// Leave the file as written rather than re-marshaling it.
+			return b.values(op)
+		}
+		existing["enabled"] = true
+	} else {
+		entry := map[string]interface{}{
+			"enabled":         true,
+			"namespace":       orDefault(op.Namespace, name),
+			"chartRepository": orDefault(op.ChartRepository, DefaultChartRepository),
+			"chartName":       orDefault(op.ChartName, DefaultChartName),
+			"defaultVersion":  orDefault(op.Version, DefaultChartVersion),
+		}
+		setDependencies(entry, op)
+		entries[name] = entry
+	}
+	if err := b.setEntries(op.Layer, entries); err != nil {
+		return err
+	}
+	return b.values(op)
+}
+
+// setDependencies adds op's dependsOn and sync-wave to entry, reporting
+// whether it changed.
+func setDependencies(entry map[string]interface{}, op Operation) bool {
+	changed := false
+	deps, _ := entry[DependsOnKey].([]interface{})
+	for _, d := range op.DependsOn {
+		found := false
+		for _, cur := range deps {
+			found = found || cur == d
+		}
+		if !found {
+			deps = append(deps, d)
+			changed = true
+		}
+	}
+	if changed {
+		entry[DependsOnKey] = deps
+	}
+	if op.SyncWave != nil {
+		wave := strconv.Itoa(*op.SyncWave)
+		ann, _ := entry["annotationsApp"].(map[string]interface{})
+		if ann == nil {
+			ann = map[string]interface{}{}
+			entry["annotationsApp"] = ann
+		}
+		if ann[SyncWaveAnnotation] != wave {
+			ann[SyncWaveAnnotation] = wave
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (b *builder) values(op Operation) error {
+	name := b.name(op)
+	path := filepath.Join(op.Layer.ValuesDir(b.repoPath, name), "values.yaml")
+	current, err := b.read(path)
+	if err != nil {
+		return err
+	}
+	if op.Values != nil {
+		data, err := yaml.Marshal(op.Values)
+		if err != nil {
+			return fmt.Errorf("marshaling values for %s: %w", op.Layer, err)
+		}
+		b.set(path, data)
+		return nil
+	}
+	if current == nil {
+		b.set(path, []byte(fmt.Sprintf("# %s values\n# Layer: %s\n# See: https://github.com/stakater/application\n", name, op.Layer.Kind)))
+	}
+	return nil
+}
+
+func (b *builder) disable(op Operation) error {
+	entries, err := b.entries(op.Layer)
+	if err != nil {
+		return err
+	}
+	name := b.name(op)
+	entry, ok := entries[name]
+	if !ok {
+		return hcerrors.New(hcerrors.ErrNotFound, "addon %q not found in %s", name, op.Layer.AddonsFile(b.repoPath))
+	}
+	entry["enabled"] = false
+	return b.setEntries(op.Layer, entries)
+}
+
+// removeEntry deletes the addons.yaml entry and every file in the layer's
+// values directory, returning the directory when it has files to delete.
+func (b *builder) removeEntry(op Operation) (string, error) {
+	entries, err := b.entries(op.Layer)
+	if err != nil {
+		return "", err
+	}
+	name := b.name(op)
+	valuesDir := op.Layer.ValuesDir(b.repoPath, name)
+	files, err := listFiles(valuesDir)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := entries[name]; !ok && len(files) == 0 {
+		return "", hcerrors.New(hcerrors.ErrNotFound, "addon %q not found in %s", name, op.Layer.AddonsFile(b.repoPath))
+	}
+	if _, ok := entries[name]; ok {
+		delete(entries, name)
+		if err := b.setEntries(op.Layer, entries); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range files {
+		if _, err := b.read(f); err != nil {
+			return "", err
+		}
+		b.set(f, nil)
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	return valuesDir, nil
+}
+
+// listFiles returns every regular file under dir, sorted. A missing dir has none.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	sort.Strings(files)
+	return files, err
+}
+
+// AllLayersOps returns an op for every layer where addon has an addons.yaml
+// entry or, for OpRemove, a values directory.
+func AllLayersOps(repoPath, addon string, kind OpKind) ([]Operation, error) {
+	layers, err := DiscoverLayers(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	for _, l := range layers {
+		entries, err := ReadEntries(l.AddonsFile(repoPath))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		_, hasEntry := entries[addon]
+		hasValues := false
+		if kind == OpRemove {
+			if _, err := os.Stat(l.ValuesDir(repoPath, addon)); err == nil {
+				hasValues = true
+			}
+		}
+		if hasEntry || hasValues {
+			ops = append(ops, Operation{Op: kind, Layer: l})
+		}
+	}
+	if len(ops) == 0 {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "addon %q not found in any layer", addon)
+	}
+	return ops, nil
+}
+
+// Paths returns the changed file paths in plan order.
+func (p *Plan) Paths() []string {
+	paths := make([]string, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+// Apply writes every change. If one fails, the changes already made are
+// reverted so the working tree is left as it was.
+func (p *Plan) Apply() error {
+	if err := mutation.ApplyFiles(p.Changes, p.write, p.remove); err != nil {
+		return err
+	}
+	for _, dir := range p.emptied {
+		pruneEmptyDirs(dir)
+	}
+	return nil
+}
+
+// pruneEmptyDirs removes dir and any empty subdirectories left behind.
+func pruneEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			pruneEmptyDirs(filepath.Join(dir, e.Name()))
+		}
+	}
+	_ = os.Remove(dir) // fails harmlessly if not empty
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
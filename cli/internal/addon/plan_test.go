@@ -0,0 +1,306 @@
+package addon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+var (
+	production = Layer{Kind: LayerEnvironment, Name: "production"}
+	mediaLayer = Layer{Kind: LayerCluster, Name: "vcluster-media"}
+	vclusters  = Layer{Kind: LayerClusterRole, Name: "vcluster"}
+)
+
+const productionAddons = `loki:
+  enabled: true
+  namespace: loki
+`
+
+func TestBuildEnableWithClusterValues(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons)
+
+	plan, err := Build(repo, "grafana", []Operation{
+		{Op: OpEnable, Layer: production},
+		{Op: OpValues, Layer: mediaLayer, Values: map[string]interface{}{"replicas": 2}},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []string{
+		production.AddonsFile(repo),
+		filepath.Join(production.ValuesDir(repo, "grafana"), "values.yaml"),
+		filepath.Join(mediaLayer.ValuesDir(repo, "grafana"), "values.yaml"),
+	}
+	if got := plan.Paths(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Paths =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// Nothing is written until Apply.
+	if exists(want[1]) || readTestFile(t, want[0]) != productionAddons {
+		t.Fatal("Build touched the working tree")
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	entries, err := ReadEntries(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries["grafana"]["enabled"] != true || entries["grafana"]["chartName"] != DefaultChartName {
+		t.Errorf("grafana entry = %v", entries["grafana"])
+	}
+	if entries["loki"]["enabled"] != true {
+		t.Errorf("existing loki entry lost: %v", entries["loki"])
+	}
+	if got := readTestFile(t, want[1]); !strings.Contains(got, "Layer: environment") {
+		t.Errorf("environment values scaffold = %q", got)
+	}
+	if got := readTestFile(t, want[2]); got != "replicas: 2\n" {
+		t.Errorf("cluster values = %q, want replicas: 2", got)
+	}
+	if exists(mediaLayer.AddonsFile(repo)) {
+		t.Error("values op should not create a cluster addons.yaml")
+	}
+}
+
+func TestBuildSkipsUnchangedFiles(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons)
+	writeTestFile(t, filepath.Join(production.ValuesDir(repo, "loki"), "values.yaml"), "retention: 7d\n")
+
+	plan, err := Build(repo, "loki", []Operation{{Op: OpEnable, Layer: production}})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("Changes = %v, want none for an already-enabled addon with values", plan.Paths())
+	}
+}
+
+func TestBuildEnableWithDependencies(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons+"gateway-api-crds:\n  enabled: false\n")
+	writeTestFile(t, filepath.Join(production.ValuesDir(repo, "gateway-api-crds"), "values.yaml"), "{}\n")
+
+	wave := 1
+	plan, err := Build(repo, "nginx-gateway-fabric", []Operation{
+		{Op: OpEnable, Layer: production, Addon: "gateway-api-crds"},
+		{Op: OpEnable, Layer: mediaLayer, DependsOn: []string{"gateway-api-crds"}, SyncWave: &wave},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	entries, err := ReadEntries(production.AddonsFile(repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries["gateway-api-crds"]["enabled"] != true || entries["nginx-gateway-fabric"] != nil {
+		t.Errorf("production entries = %v, want gateway-api-crds enabled", entries)
+	}
+	if !exists(filepath.Join(mediaLayer.ValuesDir(repo, "nginx-gateway-fabric"), "values.yaml")) {
+		t.Error("values not scaffolded for the plan's addon")
+	}
+	got := readTestFile(t, mediaLayer.AddonsFile(repo))
+	for _, want := range []string{"dependsOn:\n        - gateway-api-crds", `argocd.argoproj.io/sync-wave: "1"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("cluster addons.yaml missing %q:\n%s", want, got)
+		}
+	}
+
+	// Re-enabling with the same dependencies and wave changes nothing.
+	plan, err = Build(repo, "nginx-gateway-fabric", []Operation{
+		{Op: OpEnable, Layer: mediaLayer, DependsOn: []string{"gateway-api-crds"}, SyncWave: &wave},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("Changes = %v, want none", plan.Paths())
+	}
+}
+
+func TestRemoveAllLayers(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons+"grafana:\n  enabled: true\n")
+	writeTestFile(t, filepath.Join(production.ValuesDir(repo, "grafana"), "values.yaml"), "a: 1\n")
+	writeTestFile(t, filepath.Join(production.ValuesDir(repo, "grafana"), "dashboards", "home.json"), "{}\n")
+	writeTestFile(t, mediaLayer.AddonsFile(repo), "grafana:\n  enabled: true\n")
+	writeTestFile(t, filepath.Join(mediaLayer.ValuesDir(repo, "grafana"), "values.yaml"), "b: 2\n")
+	// Values-only layer: no addons.yaml entry, still cleaned up.
+	writeTestFile(t, filepath.Join(vclusters.ValuesDir(repo, "grafana"), "values.yaml"), "c: 3\n")
+	// Unrelated layer is left alone.
+	staging := Layer{Kind: LayerEnvironment, Name: "staging"}
+	writeTestFile(t, staging.AddonsFile(repo), productionAddons)
+
+	ops, err := AllLayersOps(repo, "grafana", OpRemove)
+	if err != nil {
+		t.Fatalf("AllLayersOps: %v", err)
+	}
+	var layers []string
+	for _, op := range ops {
+		layers = append(layers, op.Layer.String())
+	}
+	if got := strings.Join(layers, ","); got != "environment/production,cluster-role/vcluster,cluster/vcluster-media" {
+		t.Errorf("layers = %s", got)
+	}
+
+	plan, err := Build(repo, "grafana", ops)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	for _, l := range []Layer{production, mediaLayer, vclusters} {
+		if exists(l.ValuesDir(repo, "grafana")) {
+			t.Errorf("%s values directory not removed", l)
+		}
+	}
+	entries, _ := ReadEntries(production.AddonsFile(repo))
+	if _, ok := entries["grafana"]; ok {
+		t.Error("grafana still in production addons.yaml")
+	}
+	if _, ok := entries["loki"]; !ok {
+		t.Error("loki removed from production addons.yaml")
+	}
+	entries, _ = ReadEntries(mediaLayer.AddonsFile(repo))
+	if len(entries) != 0 {
+		t.Errorf("cluster addons.yaml = %v, want empty", entries)
+	}
+	if readTestFile(t, staging.AddonsFile(repo)) != productionAddons {
+		t.Error("unrelated layer was modified")
+	}
+}
+
+func TestAllLayersOpsNotFound(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons)
+	if _, err := AllLayersOps(repo, "grafana", OpDisable); err == nil {
+		t.Error("expected not-found error")
+	}
+}
+
+func TestApplyRollsBackOnFailure(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons)
+
+	plan, err := Build(repo, "grafana", []Operation{
+		{Op: OpEnable, Layer: production},
+		{Op: OpValues, Layer: mediaLayer, Values: map[string]interface{}{"replicas": 2}},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	clusterValues := filepath.Join(mediaLayer.ValuesDir(repo, "grafana"), "values.yaml")
+	plan.write = func(path string, data []byte, perm os.FileMode) error {
+		if path == clusterValues {
+			return errors.New("disk full")
+		}
+		return mutation.WriteFileAtomic(path, data, perm)
+	}
+
+	err = plan.Apply()
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Apply error = %v, want disk full", err)
+	}
+	if got := readTestFile(t, production.AddonsFile(repo)); got != productionAddons {
+		t.Errorf("addons.yaml not restored:\n%s", got)
+	}
+	if exists(filepath.Join(production.ValuesDir(repo, "grafana"), "values.yaml")) {
+		t.Error("scaffolded values.yaml not removed on rollback")
+	}
+	if exists(clusterValues) {
+		t.Error("failed write left a file behind")
+	}
+}
+
+func TestApplyRollsBackDeletions(t *testing.T) {
+	repo := t.TempDir()
+	writeTestFile(t, production.AddonsFile(repo), productionAddons)
+	values := filepath.Join(production.ValuesDir(repo, "loki"), "values.yaml")
+	writeTestFile(t, values, "retention: 7d\n")
+
+	plan, err := Build(repo, "loki", []Operation{{Op: OpRemove, Layer: production}})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	// addons.yaml is written first, then values.yaml is deleted; fail the delete.
+	plan.remove = func(path string) error { return errors.New("read-only filesystem") }
+
+	if err := plan.Apply(); err == nil {
+		t.Fatal("Apply succeeded, want failure")
+	}
+	if got := readTestFile(t, production.AddonsFile(repo)); got != productionAddons {
+		t.Errorf("addons.yaml not restored:\n%s", got)
+	}
+	if got := readTestFile(t, values); got != "retention: 7d\n" {
+		t.Errorf("values.yaml = %q, want untouched", got)
+	}
+}
+
+func TestLoadPlanFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	writeTestFile(t, path, `addon: grafana
+operations:
+  - op: enable
+    layer: environment=production
+    namespace: monitoring
+  - op: values
+    layer: cluster=vcluster-media
+    values:
+      replicas: 2
+`)
+	addon, ops, err := LoadPlanFile(path)
+	if err != nil {
+		t.Fatalf("LoadPlanFile: %v", err)
+	}
+	if addon != "grafana" || len(ops) != 2 {
+		t.Fatalf("addon=%q ops=%d", addon, len(ops))
+	}
+	if ops[0].Op != OpEnable || ops[0].Layer != production || ops[0].Namespace != "monitoring" {
+		t.Errorf("ops[0] = %+v", ops[0])
+	}
+	if ops[1].Layer != mediaLayer || ops[1].Values["replicas"] != 2 {
+		t.Errorf("ops[1] = %+v", ops[1])
+	}
+
+	writeTestFile(t, path, "addon: grafana\noperations:\n  - op: enable\n    layer: galaxy=far\n")
+	if _, _, err := LoadPlanFile(path); err == nil {
+		t.Error("expected error for unknown layer kind")
+	}
+}
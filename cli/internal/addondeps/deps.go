@@ -0,0 +1,304 @@
+// Package addondeps resolves the dependsOn lists of addons.yaml entries
+// across the layered addon configuration: which dependencies an addon is
+// missing where it is enabled, which enabled addons depend on one being
+// disabled, and the ArgoCD sync-wave that applies an addon after its
+// dependencies.
+//
+// A dependency is looked up from the layer of the entry that names it. An
+// entry applies there when it is at the same layer or at a broader kind of
+// layer (environment → cluster-role → cluster), so a cluster-layer addon may
+// depend on an environment-layer one; the most specific entry wins, as it
+// does for values.
+package addondeps
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+)
+
+// kindRank orders layer kinds from broadest to most specific.
+var kindRank = map[string]int{
+	addon.LayerEnvironment: 0,
+	addon.LayerClusterRole: 1,
+	addon.LayerCluster:     2,
+}
+
+// Entry is what the resolver reads from one addon's addons.yaml entry at
+// one layer.
+type Entry struct {
+	Addon     string
+	Layer     addon.Layer
+	Enabled   bool
+	DependsOn []string
+	// Wave is the entry's sync-wave annotation; HasWave is false without one.
+	Wave    int
+	HasWave bool
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s (%s)", e.Addon, e.Layer)
+}
+
+// EntryFrom reads an addons.yaml entry as parsed by addon.ReadEntries. An
+// entry is enabled the way the ApplicationSet chart decides it: its enabled
+// key renders as "true".
+func EntryFrom(name string, l addon.Layer, raw map[string]interface{}) Entry {
+	e := Entry{Addon: name, Layer: l, Enabled: fmt.Sprint(raw["enabled"]) == "true"}
+	if deps, ok := raw[addon.DependsOnKey].([]interface{}); ok {
+		for _, d := range deps {
+			if s, ok := d.(string); ok && s != "" {
+				e.DependsOn = append(e.DependsOn, s)
+			}
+		}
+	}
+	if ann, ok := raw["annotationsApp"].(map[string]interface{}); ok {
+		if w, err := strconv.Atoi(fmt.Sprint(ann[addon.SyncWaveAnnotation])); err == nil {
+			e.Wave, e.HasWave = w, true
+		}
+	}
+	return e
+}
+
+// Graph holds the addons.yaml entries of every layer.
+type Graph struct {
+	entries []Entry
+}
+
+// NewGraph builds a graph from entries.
+func NewGraph(entries ...Entry) *Graph {
+	g := &Graph{}
+	for _, e := range entries {
+		g.Set(e)
+	}
+	return g
+}
+
+// Load reads every layer's addons.yaml in the repo.
+func Load(repoPath string) (*Graph, error) {
+	layers, err := addon.DiscoverLayers(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{}
+	for _, l := range layers {
+		entries, err := addon.ReadEntries(l.AddonsFile(repoPath))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			g.entries = append(g.entries, EntryFrom(name, l, entries[name]))
+		}
+	}
+	return g, nil
+}
+
+// Set adds the entry, or replaces the one for the same addon and layer, so a
+// pending change resolves as if it were written.
+func (g *Graph) Set(e Entry) {
+	for i, cur := range g.entries {
+		if cur.Addon == e.Addon && cur.Layer == e.Layer {
+			g.entries[i] = e
+			return
+		}
+	}
+	g.entries = append(g.entries, e)
+}
+
+// Lookup returns the entry for name at exactly layer l.
+func (g *Graph) Lookup(name string, l addon.Layer) (Entry, bool) {
+	for _, e := range g.entries {
+		if e.Addon == name && e.Layer == l {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// visible reports whether an entry at layer l applies where from does: the
+// same layer, or a broader kind of layer.
+func visible(from, l addon.Layer) bool {
+	return l == from || kindRank[l.Kind] < kindRank[from.Kind]
+}
+
+// Resolve returns the entry for name that applies at layer from: the one at
+// the most specific visible layer, preferring an enabled entry among layers
+// of the same kind.
+func (g *Graph) Resolve(name string, from addon.Layer) (Entry, bool) {
+	var best Entry
+	found := false
+	for _, e := range g.entries {
+		if e.Addon != name || !visible(from, e.Layer) {
+			continue
+		}
+		rank, bestRank := kindRank[e.Layer.Kind], kindRank[best.Layer.Kind]
+		if !found || rank > bestRank || (rank == bestRank && e.Enabled && !best.Enabled) {
+			best, found = e, true
+		}
+	}
+	return best, found
+}
+
+// CycleError reports addons that depend on each other.
+type CycleError struct {
+	// Cycle is the path of addon names, starting and ending with the same one.
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " → ")
+}
+
+// Missing returns the dependencies of name, direct and transitive, that are
+// not enabled where they apply to layer l, in the order they must be
+// enabled: dependencies first. Each is the entry to enable. Dependencies
+// with no entry visible from l are returned in undefined. A cycle is
+// reported as a *CycleError.
+func (g *Graph) Missing(name string, l addon.Layer) (missing []Entry, undefined []string, err error) {
+	state := map[string]int{} // 1 = on the current path, 2 = done
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			start := 0
+			for i, p := range path {
+				if p == name {
+					start = i
+				}
+			}
+			return &CycleError{Cycle: append(append([]string(nil), path[start:]...), name)}
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		path = append(path, name)
+		defer func() { path = path[:len(path)-1] }()
+
+		e, _ := g.Resolve(name, l)
+		for _, dep := range e.DependsOn {
+			d, ok := g.Resolve(dep, l)
+			if !ok {
+				if !contains(undefined, dep) {
+					undefined = append(undefined, dep)
+				}
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+			if !d.Enabled && !containsEntry(missing, d) {
+				missing = append(missing, d)
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	if err := visit(name); err != nil {
+		return nil, nil, err
+	}
+	return missing, undefined, nil
+}
+
+// Dependents returns the enabled entries that depend directly on name and
+// rely on its entry at layer l: disabling or removing that entry leaves
+// them without name.
+func (g *Graph) Dependents(name string, l addon.Layer) []Entry {
+	target, ok := g.Lookup(name, l)
+	if !ok || !target.Enabled {
+		return nil
+	}
+	after := NewGraph(g.entries...)
+	target.Enabled = false
+	after.Set(target)
+
+	var out []Entry
+	for _, e := range g.entries {
+		if !e.Enabled || e.Addon == name || !contains(e.DependsOn, name) || !visible(e.Layer, l) {
+			continue
+		}
+		if before, _ := g.Resolve(name, e.Layer); !before.Enabled {
+			continue
+		}
+		if now, _ := after.Resolve(name, e.Layer); !now.Enabled {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SyncWave returns the sync-wave the entry for name at layer l needs so
+// ArgoCD applies it after its dependencies: one more than the latest wave
+// among them. ok is false when it has no dependencies, or its own wave
+// already comes after theirs. Callers resolve cycles with Missing first.
+func (g *Graph) SyncWave(name string, l addon.Layer) (wave int, ok bool) {
+	e, found := g.Lookup(name, l)
+	if !found {
+		return 0, false
+	}
+	need, hasDeps := g.after(e, l, map[string]bool{name: true})
+	if !hasDeps || (e.HasWave && e.Wave >= need) {
+		return 0, false
+	}
+	return need, true
+}
+
+// after returns one more than the latest wave among the dependencies of e
+// that resolve from l, and whether there were any.
+func (g *Graph) after(e Entry, l addon.Layer, seen map[string]bool) (int, bool) {
+	need, hasDeps := 0, false
+	for _, dep := range e.DependsOn {
+		d, ok := g.Resolve(dep, l)
+		if !ok || seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		w := g.wave(d, l, seen)
+		delete(seen, dep)
+		if !hasDeps || w+1 > need {
+			need = w + 1
+		}
+		hasDeps = true
+	}
+	return need, hasDeps
+}
+
+// wave is the sync-wave ArgoCD gives e: its annotation, else one after its
+// dependencies, else 0.
+func (g *Graph) wave(e Entry, l addon.Layer, seen map[string]bool) int {
+	if e.HasWave {
+		return e.Wave
+	}
+	w, _ := g.after(e, l, seen)
+	return w
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEntry(list []Entry, e Entry) bool {
+	for _, v := range list {
+		if v.Addon == e.Addon && v.Layer == e.Layer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package addondeps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+)
+
+var (
+	production = addon.Layer{Kind: addon.LayerEnvironment, Name: "production"}
+	staging    = addon.Layer{Kind: addon.LayerEnvironment, Name: "staging"}
+	vclusters  = addon.Layer{Kind: addon.LayerClusterRole, Name: "vcluster"}
+	media      = addon.Layer{Kind: addon.LayerCluster, Name: "vcluster-media"}
+)
+
+func entry(name string, l addon.Layer, enabled bool, deps ...string) Entry {
+	return Entry{Addon: name, Layer: l, Enabled: enabled, DependsOn: deps}
+}
+
+func names(entries []Entry) string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.String())
+	}
+	return strings.Join(out, ", ")
+}
+
+func TestMissingChain(t *testing.T) {
+	g := NewGraph(
+		entry("grafana", production, true, "kube-prometheus-stack"),
+		entry("kube-prometheus-stack", production, false, "prometheus-operator-crds"),
+		entry("prometheus-operator-crds", production, false),
+	)
+	missing, undefined, err := g.Missing("grafana", production)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "prometheus-operator-crds (environment/production), kube-prometheus-stack (environment/production)"
+	if got := names(missing); got != want || len(undefined) > 0 {
+		t.Errorf("Missing = %q, %v; want %q dependencies first", got, undefined, want)
+	}
+}
+
+func TestMissingDiamond(t *testing.T) {
+	// app → {secrets, certs} → crds: crds is listed once, before both.
+	g := NewGraph(
+		entry("app", production, true, "secrets", "certs"),
+		entry("secrets", production, false, "crds"),
+		entry("certs", production, false, "crds"),
+		entry("crds", production, false),
+	)
+	missing, _, err := g.Missing("app", production)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "crds (environment/production), secrets (environment/production), certs (environment/production)"
+	if got := names(missing); got != want {
+		t.Errorf("Missing = %q, want %q", got, want)
+	}
+
+	wave, ok := g.SyncWave("app", production)
+	if !ok || wave != 2 {
+		t.Errorf("SyncWave = %d, %v; want 2 (after crds at 0 and its dependents at 1)", wave, ok)
+	}
+}
+
+func TestMissingCycle(t *testing.T) {
+	g := NewGraph(
+		entry("a", production, true, "b"),
+		entry("b", production, true, "c"),
+		entry("c", production, true, "a"),
+	)
+	_, _, err := g.Missing("a", production)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Missing = %v, want a *CycleError", err)
+	}
+	if got := strings.Join(cycle.Cycle, " → "); got != "a → b → c → a" {
+		t.Errorf("Cycle = %s", got)
+	}
+
+	self := NewGraph(entry("a", production, true, "a"))
+	if _, _, err := self.Missing("a", production); !errors.As(err, &cycle) {
+		t.Errorf("self-dependency: Missing = %v, want a *CycleError", err)
+	}
+}
+
+func TestMissingCrossLayer(t *testing.T) {
+	g := NewGraph(
+		entry("external-secrets", production, true),
+		entry("gateway-api-crds", production, false),
+		entry("nginx-gateway-fabric", vclusters, false, "gateway-api-crds"),
+		entry("media-app", media, true, "external-secrets", "nginx-gateway-fabric", "velero"),
+	)
+	missing, undefined, err := g.Missing("media-app", media)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "gateway-api-crds (environment/production), nginx-gateway-fabric (cluster-role/vcluster)"
+	if got := names(missing); got != want {
+		t.Errorf("Missing = %q, want %q", got, want)
+	}
+	if strings.Join(undefined, ",") != "velero" {
+		t.Errorf("undefined = %v, want [velero]", undefined)
+	}
+
+	// A cluster-layer entry is not visible from the environment layer.
+	g.Set(entry("env-app", production, true, "media-app"))
+	if _, undefined, _ := g.Missing("env-app", production); strings.Join(undefined, ",") != "media-app" {
+		t.Errorf("undefined = %v, want [media-app]", undefined)
+	}
+}
+
+func TestMissingCrossLayerCycle(t *testing.T) {
+	g := NewGraph(
+		entry("a", production, true, "b"),
+		entry("b", media, true, "a"),
+	)
+	var cycle *CycleError
+	if _, _, err := g.Missing("b", media); !errors.As(err, &cycle) {
+		t.Errorf("Missing from the cluster = %v, want a *CycleError", err)
+	}
+	// From the environment, b has no entry, so there is no cycle.
+	if _, undefined, err := g.Missing("a", production); err != nil || strings.Join(undefined, ",") != "b" {
+		t.Errorf("Missing from the environment = %v, %v; want b undefined", undefined, err)
+	}
+}
+
+func TestResolveMostSpecific(t *testing.T) {
+	g := NewGraph(
+		entry("loki", production, true),
+		entry("loki", media, false),
+	)
+	if e, _ := g.Resolve("loki", media); e.Layer != media || e.Enabled {
+		t.Errorf("Resolve from the cluster = %v, want the disabled cluster entry", e)
+	}
+	if e, _ := g.Resolve("loki", vclusters); e.Layer != production || !e.Enabled {
+		t.Errorf("Resolve from the role = %v, want the production entry", e)
+	}
+
+	// Among environments, an enabled entry wins.
+	g = NewGraph(entry("loki", production, false), entry("loki", staging, true))
+	if e, _ := g.Resolve("loki", media); e.Layer != staging {
+		t.Errorf("Resolve = %v, want the enabled staging entry", e)
+	}
+}
+
+func TestSyncWave(t *testing.T) {
+	g := NewGraph(
+		Entry{Addon: "external-secrets", Layer: production, Enabled: true, Wave: -3, HasWave: true},
+		entry("gateway-api-crds", production, true),
+		entry("app", media, true, "external-secrets", "gateway-api-crds"),
+		entry("standalone", production, true),
+		Entry{Addon: "late", Layer: production, Enabled: true, DependsOn: []string{"external-secrets"}, Wave: 10, HasWave: true},
+	)
+	if wave, ok := g.SyncWave("app", media); !ok || wave != 1 {
+		t.Errorf("SyncWave(app) = %d, %v; want 1, after gateway-api-crds at the default 0", wave, ok)
+	}
+	if _, ok := g.SyncWave("standalone", production); ok {
+		t.Error("SyncWave set for an addon without dependencies")
+	}
+	if _, ok := g.SyncWave("late", production); ok {
+		t.Error("SyncWave lowered a wave that already follows its dependencies")
+	}
+
+	g.Set(Entry{Addon: "gateway-api-crds", Layer: production, Enabled: true, Wave: -5, HasWave: true})
+	if wave, _ := g.SyncWave("app", media); wave != -2 {
+		t.Errorf("SyncWave(app) = %d, want -2, after external-secrets at -3", wave)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	g := NewGraph(
+		entry("external-secrets", production, true),
+		entry("external-secrets", staging, true),
+		entry("app", media, true, "external-secrets"),
+		entry("prod-app", production, true, "external-secrets"),
+		entry("staging-app", staging, true, "external-secrets"),
+		entry("off", production, false, "external-secrets"),
+	)
+	// staging still provides external-secrets to the cluster layer.
+	if got := names(g.Dependents("external-secrets", production)); got != "prod-app (environment/production)" {
+		t.Errorf("Dependents = %q", got)
+	}
+
+	g.Set(entry("external-secrets", staging, false))
+	want := "app (cluster/vcluster-media), prod-app (environment/production)"
+	if got := names(g.Dependents("external-secrets", production)); got != want {
+		t.Errorf("Dependents = %q, want %q", got, want)
+	}
+	if got := g.Dependents("external-secrets", media); len(got) != 0 {
+		t.Errorf("Dependents of a layer without an entry = %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	repo := t.TempDir()
+	write := func(l addon.Layer, content string) {
+		path := l.AddonsFile(repo)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(production, `external-secrets:
+  enabled: true
+  annotationsApp:
+    argocd.argoproj.io/sync-wave: "-3"
+grafana:
+  enabled: "false"
+  dependsOn: [external-secrets]
+`)
+	write(media, "media-app:\n  enabled: true\n  dependsOn: [grafana]\n")
+	if err := os.MkdirAll(vclusters.Dir(repo), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := Load(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := g.Lookup("external-secrets", production); !e.Enabled || !e.HasWave || e.Wave != -3 {
+		t.Errorf("external-secrets = %+v", e)
+	}
+	if e, _ := g.Lookup("grafana", production); e.Enabled || strings.Join(e.DependsOn, ",") != "external-secrets" {
+		t.Errorf("grafana = %+v", e)
+	}
+	if missing, _, _ := g.Missing("media-app", media); names(missing) != "grafana (environment/production)" {
+		t.Errorf("Missing = %q", names(missing))
+	}
+}
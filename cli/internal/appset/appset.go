@@ -0,0 +1,400 @@
+// Package appset renders, offline, the ArgoCD Application that the
+// application-sets chart's ApplicationSet generates for one addon on one
+// cluster. It follows the chain ArgoCD does: the bootstrap ApplicationSet
+// matching the cluster renders the chart with the cluster's layered
+// addons.yaml files; the chart's ApplicationSet for the addon runs its
+// generators against the cluster secret; and the Application template is
+// rendered with the resulting parameters.
+//
+// The chart's own templates are executed, so a change to them shows in the
+// rendered Application before it is merged.
+package appset
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"sigs.k8s.io/yaml"
+)
+
+// Where an addon's addons.yaml entry is rendered from.
+const (
+	// SourcePlatform is the environment, cluster-role and cluster layers
+	// the bootstrap ApplicationSets render on the host cluster.
+	SourcePlatform = "platform"
+	// SourceWorkloads is a vcluster's workloads addons.yaml, rendered by
+	// the workloads ApplicationSet argocd-vcluster installs inside it.
+	SourceWorkloads = "workloads"
+)
+
+// ValueFile is a values file a Helm source reads.
+type ValueFile struct {
+	// Path is the file as the manifest lists it, e.g.
+	// $values/addons/clusters/vcluster-media/addons.yaml.
+	Path string `json:"path" yaml:"path"`
+	// File is the repo-relative file Path names; it is empty for a file
+	// inside a chart from a Helm repository, which is not checked.
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	Exists bool   `json:"exists" yaml:"exists"`
+}
+
+// Result is a rendered Application and what it was rendered from.
+type Result struct {
+	Addon   string `json:"addon" yaml:"addon"`
+	Cluster string `json:"cluster" yaml:"cluster"`
+	Source  string `json:"source" yaml:"source"`
+	// ApplicationSet is the name of the ApplicationSet generating the
+	// Application.
+	ApplicationSet string `json:"applicationSet" yaml:"applicationSet"`
+	// Layers are the addons.yaml files the chart is rendered with, in
+	// merge order; Defines are those holding an entry for the addon.
+	Layers  []ValueFile `json:"layers" yaml:"layers"`
+	Defines []string    `json:"defines" yaml:"defines"`
+	// Application is the Application manifest.
+	Application map[string]interface{} `json:"application" yaml:"application"`
+	// ValueFiles are the values files the Application's Helm sources read.
+	ValueFiles []ValueFile `json:"valueFiles" yaml:"valueFiles"`
+}
+
+// chartRun is one rendering of the application-sets chart: what a bootstrap
+// or workloads ApplicationSet passes it for a cluster.
+type chartRun struct {
+	source  string
+	release string
+	// chartDir is the repo-relative chart directory.
+	chartDir string
+	// valueFiles are the repo-relative addons.yaml layers.
+	valueFiles   []ValueFile
+	valuesObject map[string]interface{}
+	// cluster is the cluster the chart's ApplicationSets generate for.
+	cluster *Cluster
+}
+
+// Render renders the Application generated for addon on cluster c in the
+// repo at repoPath. The addon is looked up in the platform addon layers
+// first, then in the cluster's workloads addons.yaml.
+func Render(repoPath, addon string, c *Cluster) (*Result, error) {
+	var runs []*chartRun
+	platform, err := platformRun(repoPath, c)
+	if err != nil {
+		return nil, err
+	}
+	if platform != nil {
+		runs = append(runs, platform)
+	}
+	runs = append(runs, workloadsRun(c))
+
+	var searched []string
+	for _, run := range runs {
+		layers, defines, err := run.layers(repoPath, addon)
+		if err != nil {
+			return nil, err
+		}
+		if len(defines) == 0 {
+			for _, l := range run.valueFiles {
+				searched = append(searched, l.File)
+			}
+			continue
+		}
+		res, err := run.render(repoPath, addon, layers)
+		if err != nil {
+			return nil, err
+		}
+		res.Cluster, res.Defines = c.Name, defines
+		return res, nil
+	}
+	return nil, hcerrors.New(hcerrors.ErrNotFound, "no addons.yaml entry for %q applies to cluster %s", addon, c.Name).
+		WithDetails(map[string]interface{}{"searched": searched}).
+		WithRemediation("check the addon name, or add it with 'hctl addon enable'")
+}
+
+// platformRun returns the bootstrap ApplicationSet's rendering of the chart
+// for c, nil when no bootstrap ApplicationSet selects c.
+func platformRun(repoPath string, c *Cluster) (*chartRun, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.BootstrapDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		var set map[string]interface{}
+		if err := readYAML(f, &set); err != nil {
+			return nil, err
+		}
+		if set["kind"] != "ApplicationSet" {
+			continue
+		}
+		spec, _ := set["spec"].(map[string]interface{})
+		if !selectsCluster(spec, c) {
+			continue
+		}
+		tmpl, _ := spec["template"].(map[string]interface{})
+		meta, _ := tmpl["metadata"].(map[string]interface{})
+		tspec, _ := tmpl["spec"].(map[string]interface{})
+		sources, _ := tspec["sources"].([]interface{})
+		for _, s := range sources {
+			src, _ := s.(map[string]interface{})
+			helm, ok := src["helm"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			run := &chartRun{
+				source:   SourcePlatform,
+				release:  renderLegacy(strval(meta["name"]), c),
+				chartDir: strings.TrimSuffix(renderLegacy(fmt.Sprint(src["path"]), c), "/"),
+				cluster:  c,
+			}
+			files, _ := helm["valueFiles"].([]interface{})
+			for _, vf := range files {
+				run.valueFiles = append(run.valueFiles, valueFile(repoPath, renderLegacy(strval(vf), c), ""))
+			}
+			if obj, ok := helm["valuesObject"].(map[string]interface{}); ok {
+				run.valuesObject = legacyTree(obj, c).(map[string]interface{})
+			}
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+// selectsCluster reports whether one of the cluster generators of the
+// ApplicationSet spec selects c.
+func selectsCluster(spec map[string]interface{}, c *Cluster) bool {
+	gens, _ := spec["generators"].([]interface{})
+	for _, g := range gens {
+		gen, _ := g.(map[string]interface{})
+		clusters, ok := gen["clusters"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ok, _, err := selects(clusters["selector"], c); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyTree substitutes c's parameters into every string of v.
+func legacyTree(v interface{}, c *Cluster) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = legacyTree(e, c)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = legacyTree(e, c)
+		}
+		return out
+	case string:
+		return renderLegacy(v, c)
+	}
+	return v
+}
+
+// workloadsRun is the workloads ApplicationSet's rendering of the chart in
+// the vcluster c, which argocd-vcluster (addons/cluster-roles/vcluster)
+// configures from c's workload_repo_* annotations.
+func workloadsRun(c *Cluster) *chartRun {
+	a := c.Annotations
+	dir := a["workload_repo_path"] + "/" + c.Labels["cluster_name"]
+	return &chartRun{
+		source:     SourceWorkloads,
+		release:    "workload-" + c.Labels["environment"],
+		chartDir:   layout.ApplicationSetsChartDir,
+		valueFiles: []ValueFile{{Path: "$values/" + a["workload_repo_basepath"] + dir + "/addons.yaml"}},
+		valuesObject: map[string]interface{}{
+			"repoURLGit":          a["workload_repo_url"],
+			"repoURLGitRevision":  a["workload_repo_revision"],
+			"repoURLGitBasePath":  a["workload_repo_basepath"],
+			"useValuesFilePrefix": false,
+			"valueFiles":          []interface{}{dir + "/addons"},
+		},
+		cluster: inCluster(c),
+	}
+}
+
+// layers reads the run's addons.yaml layers, returning them checked and
+// the repo-relative ones holding an entry for addon.
+func (r *chartRun) layers(repoPath, addon string) ([]map[string]interface{}, []string, error) {
+	var layers []map[string]interface{}
+	var defines []string
+	for i, vf := range r.valueFiles {
+		vf = valueFile(repoPath, vf.Path, "")
+		r.valueFiles[i] = vf
+		if !vf.Exists {
+			continue
+		}
+		var values map[string]interface{}
+		if err := readYAML(repopath.Abs(repoPath, vf.File), &values); err != nil {
+			return nil, nil, hcerrors.New(hcerrors.ErrValidation, "%w", err)
+		}
+		if _, ok := values[addon].(map[string]interface{}); ok {
+			defines = append(defines, vf.File)
+		}
+		layers = append(layers, values)
+	}
+	return layers, defines, nil
+}
+
+// render renders the chart with layers, scoped to addon, and the
+// Application its ApplicationSet generates for the run's cluster.
+func (r *chartRun) render(repoPath, addon string, layers []map[string]interface{}) (*Result, error) {
+	ch, err := loadChart(repopath.Abs(repoPath, r.chartDir))
+	if err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "loading the %s chart: %w", r.chartDir, err)
+	}
+	values := mergeValues(map[string]interface{}{}, ch.defaults)
+	for _, l := range layers {
+		values = mergeValues(values, l)
+	}
+	values = mergeValues(values, r.valuesObject)
+	for k, v := range values {
+		if entry, ok := v.(map[string]interface{}); ok && k != addon {
+			if _, isAddon := entry["enabled"]; isAddon {
+				delete(values, k)
+			}
+		}
+	}
+
+	docs, err := ch.render(r.release, values)
+	if err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "rendering the %s chart: %w", r.chartDir, err)
+	}
+	var set map[string]interface{}
+	for _, d := range docs {
+		if d["kind"] == "ApplicationSet" {
+			set = d
+		}
+	}
+	if set == nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s is not enabled for cluster %s: the chart renders no ApplicationSet for it", addon, r.cluster.Name).
+			WithRemediation("enable it with 'hctl addon enable'")
+	}
+	meta, _ := set["metadata"].(map[string]interface{})
+	spec, _ := set["spec"].(map[string]interface{})
+	res := &Result{Addon: addon, Source: r.source, ApplicationSet: strval(meta["name"]), Layers: r.valueFiles}
+
+	var params map[string]interface{}
+	var unmet []string
+	gens, _ := spec["generators"].([]interface{})
+	for _, g := range gens {
+		gen, _ := g.(map[string]interface{})
+		sets, why, err := generate(gen, r.cluster)
+		if err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "ApplicationSet %s: %w", res.ApplicationSet, err)
+		}
+		if len(sets) > 0 {
+			params = sets[0]
+			break
+		}
+		if why != "" {
+			unmet = append(unmet, why)
+		}
+	}
+	if params == nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "ApplicationSet %s generates no Application for cluster %s", res.ApplicationSet, r.cluster.Name).
+			WithDetails(map[string]interface{}{"unmet": unmet}).
+			WithRemediation("the cluster's labels do not satisfy: " + strings.Join(unmet, "; "))
+	}
+
+	var options []string
+	if opts, ok := spec["goTemplateOptions"].([]interface{}); ok {
+		for _, o := range opts {
+			options = append(options, strval(o))
+		}
+	}
+	tmpl, err := renderTree(spec["template"], params, options)
+	if err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "ApplicationSet %s template: %w", res.ApplicationSet, err)
+	}
+	app := tmpl.(map[string]interface{})
+	appMeta, _ := app["metadata"].(map[string]interface{})
+	if appMeta == nil {
+		appMeta = map[string]interface{}{}
+	}
+	appMeta["namespace"] = meta["namespace"]
+	res.Application = map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   appMeta,
+		"spec":       app["spec"],
+	}
+	res.ValueFiles = applicationValueFiles(repoPath, app["spec"])
+	return res, nil
+}
+
+// applicationValueFiles lists the values files of the Helm sources in an
+// Application spec.
+func applicationValueFiles(repoPath string, spec interface{}) []ValueFile {
+	s, _ := spec.(map[string]interface{})
+	sources, _ := s["sources"].([]interface{})
+	if src, ok := s["source"]; ok {
+		sources = append(sources, src)
+	}
+	var files []ValueFile
+	for _, src := range sources {
+		m, _ := src.(map[string]interface{})
+		helm, _ := m["helm"].(map[string]interface{})
+		vfs, _ := helm["valueFiles"].([]interface{})
+		for _, vf := range vfs {
+			srcPath, _ := m["path"].(string)
+			files = append(files, valueFile(repoPath, strval(vf), srcPath))
+		}
+	}
+	return files
+}
+
+// valueFile resolves p, a values file of a source at the repo-relative
+// srcPath, to a repo file and checks it exists. $values/ files are in the
+// repo; files of a chart from a Helm repository (no srcPath) are not.
+func valueFile(repoPath, p, srcPath string) ValueFile {
+	vf := ValueFile{Path: p}
+	switch {
+	case strings.HasPrefix(p, "$values/"):
+		vf.File = strings.TrimPrefix(path.Clean(strings.TrimPrefix(p, "$values/")), "/")
+	case srcPath != "":
+		vf.File = path.Clean(path.Join(srcPath, p))
+	default:
+		return vf
+	}
+	_, err := os.Stat(repopath.Abs(repoPath, vf.File))
+	vf.Exists = err == nil
+	return vf
+}
+
+// mergeValues merges src into dst as Helm coalesces values files: maps are
+// merged key by key, other values replace, and null deletes.
+func mergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		if sm, ok := v.(map[string]interface{}); ok {
+			if dm, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = mergeValues(dm, sm)
+				continue
+			}
+			dst[k] = mergeValues(map[string]interface{}{}, sm)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// Marshal returns the Application as YAML.
+func (r *Result) Marshal() ([]byte, error) {
+	return yaml.Marshal(r.Application)
+}
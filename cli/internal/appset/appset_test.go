@@ -0,0 +1,245 @@
+package appset
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// repoRoot is the gitops repo this module lives in; the fixture repos copy
+// its application-sets chart and vcluster bootstrap ApplicationSet so the
+// goldens follow changes to them.
+const repoRoot = "../../.."
+
+// roleAddons is the vcluster cluster-role addons.yaml in the fixture.
+const roleAddons = `cert-manager:
+  enabled: true
+  namespace: cert-manager
+  chartName: cert-manager
+  chartRepository: https://charts.jetstack.io
+  defaultVersion: v1.19.3
+  selectorMatchLabels:
+    enable_cert_manager: "true"
+gpu-operator:
+  enabled: true
+  namespace: gpu-operator
+  chartName: gpu-operator
+  chartRepository: https://helm.ngc.nvidia.com/nvidia
+  defaultVersion: v25.3.0
+  selectorMatchLabels:
+    enable_gpu_operator: "true"
+metrics-server:
+  enabled: false
+  namespace: kube-system
+  chartName: metrics-server
+  chartRepository: https://kubernetes-sigs.github.io/metrics-server
+  defaultVersion: 3.12.2
+`
+
+// envCommon is the production environment's common.yaml in the fixture.
+const envCommon = `useValuesFilePrefix: false
+repoURLGitRevision: HEAD
+repoURLGitBasePath: "addons"
+globalSelectors:
+  environment: "production"
+`
+
+// workloadAddons is vcluster-media's workloads addons.yaml in the fixture.
+const workloadAddons = `globalSelectors:
+  cluster_name: vcluster-media
+useAddonNameForValues: true
+sonarr:
+  enabled: true
+  namespace: media
+  chartName: application
+  chartRepository: https://stakater.github.io/stakater-charts
+  defaultVersion: 6.14.0
+`
+
+func newFixtureRepo(t *testing.T) *testutil.Repo {
+	t.Helper()
+	files := map[string]string{
+		"addons/cluster-roles/vcluster/addons/addons.yaml":               roleAddons,
+		"addons/environments/production/addons/common.yaml":              envCommon,
+		"addons/environments/production/addons/cert-manager/values.yaml": "{}\n",
+		"workloads/vcluster-media/addons.yaml":                           workloadAddons,
+		"workloads/vcluster-media/addons/sonarr/values.yaml":             "{}\n",
+	}
+	copyFile(t, files, repoRoot, filepath.Join(layout.BootstrapDir, "addons-vcluster.yaml"))
+	chart, err := filepath.Glob(filepath.Join(repoRoot, layout.ApplicationSetsChartDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := filepath.Glob(filepath.Join(repoRoot, layout.ApplicationSetsChartDir, "templates", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range append(chart, templates...) {
+		rel, _ := filepath.Rel(repoRoot, p)
+		copyFile(t, files, repoRoot, rel)
+	}
+	return testutil.NewRepo(t, testutil.RepoOptions{Clusters: []string{"vcluster-media"}, Files: files})
+}
+
+func copyFile(t *testing.T, files map[string]string, root, rel string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[filepath.ToSlash(rel)] = string(data)
+}
+
+// productionCluster derives vcluster-media from the fixture with the
+// production environment label its addons are layered for.
+func productionCluster(t *testing.T, repo *testutil.Repo) *Cluster {
+	t.Helper()
+	c, err := ClusterFromVCluster(repo.Root, "vcluster-media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Labels["environment"] = "production"
+	c.Annotations["environment"] = "production"
+	return c
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Application does not match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestRenderPlatformAddon(t *testing.T) {
+	repo := newFixtureRepo(t)
+	res, err := Render(repo.Root, "cert-manager", productionCluster(t, repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != SourcePlatform || res.ApplicationSet != "bootstrap-vcluster-vcluster-media-cert-manager" {
+		t.Errorf("source = %s, ApplicationSet = %s", res.Source, res.ApplicationSet)
+	}
+	if want := "addons/cluster-roles/vcluster/addons/addons.yaml"; len(res.Defines) != 1 || res.Defines[0] != want {
+		t.Errorf("Defines = %v, want [%s]", res.Defines, want)
+	}
+	out, err := res.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "cert-manager.golden.yaml", out)
+
+	exists := map[string]bool{}
+	for _, vf := range res.ValueFiles {
+		exists[vf.File] = vf.Exists
+	}
+	if !exists["addons/environments/production/addons/cert-manager/values.yaml"] {
+		t.Errorf("environment values file not found: %+v", res.ValueFiles)
+	}
+	if e, ok := exists["addons/clusters/vcluster-media/addons/cert-manager/values.yaml"]; !ok || e {
+		t.Errorf("cluster values file should be listed as missing: %+v", res.ValueFiles)
+	}
+}
+
+func TestRenderWorkloadsAddon(t *testing.T) {
+	repo := newFixtureRepo(t)
+	res, err := Render(repo.Root, "sonarr", productionCluster(t, repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != SourceWorkloads {
+		t.Errorf("source = %s, want %s", res.Source, SourceWorkloads)
+	}
+	out, err := res.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "sonarr.golden.yaml", out)
+}
+
+func TestRenderErrors(t *testing.T) {
+	repo := newFixtureRepo(t)
+	c := productionCluster(t, repo)
+	tests := []struct {
+		addon string
+		cat   hcerrors.Category
+		want  string
+	}{
+		{"unknown", hcerrors.ErrNotFound, `no addons.yaml entry for "unknown"`},
+		{"metrics-server", hcerrors.ErrValidation, "not enabled"},
+		{"gpu-operator", hcerrors.ErrValidation, "enable_gpu_operator=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addon, func(t *testing.T) {
+			_, err := Render(repo.Root, tt.addon, c)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := hcerrors.CategoryOf(err); got != tt.cat {
+				t.Errorf("category = %v, want %v", got, tt.cat)
+			}
+			if report := hcerrors.ToReport(err); !strings.Contains(report.Message+report.Remediation, tt.want) {
+				t.Errorf("error %q / %q does not mention %q", report.Message, report.Remediation, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterFromSecret(t *testing.T) {
+	list := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: cluster-the-cluster
+    labels:
+      cluster_role: control-plane
+  data:
+    name: dGhlLWNsdXN0ZXI=
+    server: aHR0cHM6Ly8xMC4wLjQuMTAxOjY0NDM=
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: in-cluster-config
+  stringData:
+    name: in-cluster
+    server: https://kubernetes.default.svc
+`
+	c, err := ClusterFromSecret([]byte(list), "the-cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server != "https://10.0.4.101:6443" || c.Labels["cluster_role"] != "control-plane" {
+		t.Errorf("cluster = %+v", c)
+	}
+	if _, err := ClusterFromSecret([]byte(list), "nope"); hcerrors.CategoryOf(err) != hcerrors.ErrNotFound {
+		t.Errorf("err = %v, want not found", err)
+	}
+}
+
+func TestRenderLegacy(t *testing.T) {
+	c := &Cluster{Name: "Media_1", Labels: map[string]string{"environment": "production"}}
+	got := renderLegacy("{{nameNormalized}}/{{metadata.labels.environment}}/{{metadata.labels.missing}}", c)
+	if want := "media-1/production/{{metadata.labels.missing}}"; got != want {
+		t.Errorf("renderLegacy = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package appset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// chart is a Helm chart rendered without helm: its templates run through
+// text/template with the Sprig functions the application-sets chart uses,
+// and a template calling any other fails to parse.
+type chart struct {
+	name, version, appVersion string
+	defaults                  map[string]interface{}
+	tmpl                      *template.Template
+	// files are the chart's manifest templates, in render order; the
+	// partials (_*.tpl) only define named templates.
+	files []string
+}
+
+// loadChart parses the chart in dir.
+func loadChart(dir string) (*chart, error) {
+	var meta struct {
+		Name       string `json:"name"`
+		Version    string `json:"version"`
+		AppVersion string `json:"appVersion"`
+	}
+	if err := readYAML(filepath.Join(dir, "Chart.yaml"), &meta); err != nil {
+		return nil, err
+	}
+	c := &chart{name: meta.Name, version: meta.Version, appVersion: meta.AppVersion}
+	if err := readYAML(filepath.Join(dir, "values.yaml"), &c.defaults); err != nil {
+		return nil, err
+	}
+
+	c.tmpl = template.New(c.name).Option("missingkey=zero")
+	funcs := templateFuncs()
+	funcs["include"] = func(name string, data interface{}) (string, error) {
+		var b strings.Builder
+		err := c.tmpl.ExecuteTemplate(&b, name, data)
+		return b.String(), err
+	}
+	c.tmpl.Funcs(funcs)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "templates", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		base := filepath.Base(p)
+		if !strings.HasSuffix(base, ".yaml") && !strings.HasSuffix(base, ".tpl") {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		name := c.name + "/templates/" + base
+		if _, err := c.tmpl.New(name).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		if !strings.HasPrefix(base, "_") {
+			c.files = append(c.files, name)
+		}
+	}
+	return c, nil
+}
+
+// render runs the chart's manifest templates with values as helm template
+// would for release, and returns the documents they produce.
+func (c *chart) render(release string, values map[string]interface{}) ([]map[string]interface{}, error) {
+	data := map[string]interface{}{
+		"Values":  values,
+		"Chart":   map[string]interface{}{"Name": c.name, "Version": c.version, "AppVersion": c.appVersion},
+		"Release": map[string]interface{}{"Name": release, "Namespace": "argocd", "Service": "Helm"},
+	}
+	var docs []map[string]interface{}
+	for _, name := range c.files {
+		var b strings.Builder
+		if err := c.tmpl.ExecuteTemplate(&b, name, data); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", name, err)
+		}
+		// As helm does for missingkey=zero.
+		out := strings.ReplaceAll(b.String(), "<no value>", "")
+		for i, doc := range strings.Split(out, "\n---") {
+			var m map[string]interface{}
+			if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+				return nil, fmt.Errorf("%s: document %d: %w", name, i+1, err)
+			}
+			if m != nil {
+				docs = append(docs, m)
+			}
+		}
+	}
+	return docs, nil
+}
+
+func readYAML(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// templateFuncs are the Sprig functions, as Helm and ArgoCD's goTemplate
+// define them, that the application-sets chart and addons.yaml entries use.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": func(d interface{}, given ...interface{}) interface{} {
+			if len(given) == 0 || empty(given[0]) {
+				return d
+			}
+			return given[0]
+		},
+		"dict": func(kv ...interface{}) map[string]interface{} {
+			m := map[string]interface{}{}
+			for i := 0; i+1 < len(kv); i += 2 {
+				m[strval(kv[i])] = kv[i+1]
+			}
+			return m
+		},
+		"has": func(needle, haystack interface{}) bool {
+			v := reflect.ValueOf(haystack)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return false
+			}
+			for i := 0; i < v.Len(); i++ {
+				if reflect.DeepEqual(v.Index(i).Interface(), needle) {
+					return true
+				}
+			}
+			return false
+		},
+		"hasKey": func(m map[string]interface{}, key string) bool {
+			_, ok := m[key]
+			return ok
+		},
+		"indent": indent,
+		"kindIs": func(kind string, v interface{}) bool {
+			return reflect.ValueOf(v).Kind().String() == kind
+		},
+		"list":  func(v ...interface{}) []interface{} { return v },
+		"lower": strings.ToLower,
+		"merge": func(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+			for _, src := range srcs {
+				fill(dst, src)
+			}
+			return dst
+		},
+		"nindent": func(n int, s string) string {
+			return "\n" + indent(n, s)
+		},
+		"quote": func(v ...interface{}) string {
+			var out []string
+			for _, s := range v {
+				if s != nil {
+					out = append(out, fmt.Sprintf("%q", strval(s)))
+				}
+			}
+			return strings.Join(out, " ")
+		},
+		"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"squote": func(v ...interface{}) string {
+			var out []string
+			for _, s := range v {
+				if s != nil {
+					out = append(out, "'"+strval(s)+"'")
+				}
+			}
+			return strings.Join(out, " ")
+		},
+		"toString": strval,
+		"toYaml": func(v interface{}) string {
+			data, err := yaml.Marshal(v)
+			if err != nil {
+				return ""
+			}
+			return strings.TrimSuffix(string(data), "\n")
+		},
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trunc": func(n int, s string) string {
+			if n >= 0 && len(s) > n {
+				return s[:n]
+			}
+			return s
+		},
+		"upper": strings.ToUpper,
+	}
+}
+
+// fill sets the keys of src that dst lacks, recursing into maps both
+// hold, as Sprig's merge does: dst's values win.
+func fill(dst, src map[string]interface{}) {
+	for k, v := range src {
+		dm, dok := dst[k].(map[string]interface{})
+		sm, sok := v.(map[string]interface{})
+		switch {
+		case dok && sok:
+			fill(dm, sm)
+		case dst[k] == nil:
+			dst[k] = v
+		}
+	}
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// strval formats v as Sprig's toString does.
+func strval(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// empty reports whether v is empty as Sprig's default decides it.
+func empty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return rv.IsNil()
+	}
+	return false
+}
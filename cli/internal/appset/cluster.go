@@ -0,0 +1,176 @@
+package appset
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// secretRemediation tells how to render for a cluster hctl cannot derive.
+const secretRemediation = "pass its ArgoCD cluster secret with --cluster-secret, e.g. from " +
+	"'kubectl get secret -n argocd -l argocd.argoproj.io/secret-type=cluster -o yaml'"
+
+// ClusterFromSecret reads the cluster named name from an ArgoCD cluster
+// secret manifest, or from a List of them as kubectl prints it.
+func ClusterFromSecret(data []byte, name string) (*Cluster, error) {
+	var list struct {
+		Kind  string          `json:"kind"`
+		Items []corev1.Secret `json:"items"`
+	}
+	if err := sigsyaml.Unmarshal(data, &list); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing the cluster secret: %w", err)
+	}
+	secrets := list.Items
+	if list.Kind != "List" {
+		var s corev1.Secret
+		if err := sigsyaml.Unmarshal(data, &s); err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "parsing the cluster secret: %w", err)
+		}
+		secrets = []corev1.Secret{s}
+	}
+	var names []string
+	for _, s := range secrets {
+		c := &Cluster{
+			Name:        secretField(s, "name"),
+			Server:      secretField(s, "server"),
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
+		}
+		if c.Name == "" {
+			c.Name = s.Name
+		}
+		if c.Name == name || len(secrets) == 1 && name == "" {
+			return c, nil
+		}
+		names = append(names, c.Name)
+	}
+	return nil, hcerrors.New(hcerrors.ErrNotFound, "no cluster secret for %q (found: %s)", name, strings.Join(names, ", ")).
+		WithRemediation(secretRemediation)
+}
+
+func secretField(s corev1.Secret, key string) string {
+	if v, ok := s.StringData[key]; ok {
+		return v
+	}
+	return string(s.Data[key])
+}
+
+// ClusterFromVCluster derives the cluster secret the vcluster orchestrator
+// registers for the vcluster request platform/vclusters/<name>.yaml: its
+// default labels and annotations, overridden by the request's
+// integrations.argocd clusterLabels and clusterAnnotations.
+func ClusterFromVCluster(repoPath, name string) (*Cluster, error) {
+	path := repopath.Abs(repoPath, repopath.Join(layout.VClustersDir, name+".yaml"))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "%s is not a vcluster in %s", name, layout.VClustersDir).
+			WithRemediation(secretRemediation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r platform.VClusterResource
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", path, err)
+	}
+	if r.Spec.Name == "" {
+		r.Spec.Name = name
+	}
+
+	var argo platform.ArgoCDIntegration
+	if r.Spec.Integrations.ArgoCD != nil {
+		argo = *r.Spec.Integrations.ArgoCD
+	}
+	env := argo.Environment
+	if env == "" {
+		env = "development"
+		if r.Spec.VCluster.Preset == "prod" {
+			env = "production"
+		}
+	}
+	domain := r.Metadata.Annotations["platform.integratn.tech/base-domain"]
+	if domain == "" || domain == "null" {
+		domain = "integratn.tech"
+	}
+	host := r.Spec.Exposure.Hostname
+	if host == "" {
+		host = r.Spec.Name + "." + domain
+	}
+	port := r.Spec.Exposure.APIPort
+	if port == 0 {
+		port = 443
+	}
+	repo := platform.WorkloadRepoConfig{}
+	if argo.WorkloadRepo != nil {
+		repo = *argo.WorkloadRepo
+	}
+	repo = repo.WithDefaults()
+
+	c := &Cluster{
+		Name:   r.Spec.Name,
+		Server: "https://" + net.JoinHostPort(host, strconv.Itoa(port)),
+		Labels: map[string]string{
+			"argocd.argoproj.io/secret-type": "cluster",
+			"akuity.io/argo-cd-cluster-name": r.Spec.Name,
+			"cluster_name":                   r.Spec.Name,
+			"cluster_role":                   "vcluster",
+			"cluster_type":                   "vcluster",
+			"enable_argocd":                  "true",
+			"enable_gateway_api_crds":        "true",
+			"enable_nginx_gateway_fabric":    "true",
+			"enable_cert_manager":            "true",
+			"enable_external_secrets":        "true",
+			"enable_external_dns":            "true",
+			"environment":                    env,
+		},
+		Annotations: map[string]string{
+			"addons_repo_url":                               "https://github.com/jamesatintegratnio/gitops_homelab_2_0.git",
+			"addons_repo_revision":                          "main",
+			"addons_repo_basepath":                          "addons/",
+			"addons_repo_path":                              "charts/application-sets",
+			"managed-by":                                    "argocd.argoproj.io",
+			"cert_manager_namespace":                        "cert-manager",
+			"external_dns_namespace":                        "external-dns",
+			"nfs_subdir_external_provisioner_namespace":     "nfs-provisioner",
+			"cluster_name":                                  r.Spec.Name,
+			"environment":                                   env,
+			"platform.integratn.tech/base-domain":           domain,
+			"platform.integratn.tech/base-domain-sanitized": strings.ReplaceAll(domain, ".", "-"),
+			"workload_repo_url":                             repo.URL,
+			"workload_repo_basepath":                        repo.BasePath,
+			"workload_repo_path":                            repo.Path,
+			"workload_repo_revision":                        repo.Revision,
+		},
+	}
+	for k, v := range argo.ClusterLabels {
+		c.Labels[k] = v
+	}
+	for k, v := range argo.ClusterAnnotations {
+		c.Annotations[k] = v
+	}
+	return c, nil
+}
+
+// inCluster is the cluster the workloads ApplicationSet inside a vcluster
+// generates for: the in-cluster secret argocd-vcluster creates there,
+// labelled with the host cluster's name and environment.
+func inCluster(host *Cluster) *Cluster {
+	return &Cluster{
+		Name:   "in-cluster",
+		Server: "https://kubernetes.default.svc",
+		Labels: map[string]string{
+			"argocd.argoproj.io/secret-type": "cluster",
+			"cluster_name":                   host.Labels["cluster_name"],
+			"environment":                    host.Labels["environment"],
+		},
+	}
+}
@@ -0,0 +1,237 @@
+package appset
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Cluster is a cluster as ArgoCD's cluster generator sees it: the name,
+// server, labels and annotations of its cluster secret.
+type Cluster struct {
+	Name        string
+	Server      string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// params returns the cluster generator's parameters for c, without values.
+func (c *Cluster) params() map[string]interface{} {
+	return map[string]interface{}{
+		"name":           c.Name,
+		"nameNormalized": normalizeName(c.Name),
+		"server":         c.Server,
+		"metadata": map[string]interface{}{
+			"labels":      stringMap(c.Labels),
+			"annotations": stringMap(c.Annotations),
+		},
+	}
+}
+
+// invalidNameChars are what ArgoCD replaces in nameNormalized.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// normalizeName turns a cluster name into a DNS-1123 name, as ArgoCD does
+// for the nameNormalized parameter.
+func normalizeName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// generate returns the parameter sets gen produces for c: none when its
+// selector does not match c. why says which generator ruled c out.
+func generate(gen map[string]interface{}, c *Cluster) (sets []map[string]interface{}, why string, err error) {
+	switch {
+	case gen["clusters"] != nil:
+		spec, _ := gen["clusters"].(map[string]interface{})
+		ok, why, err := selects(spec["selector"], c)
+		if err != nil || !ok {
+			return nil, why, err
+		}
+		params := c.params()
+		values := map[string]interface{}{}
+		if v, ok := spec["values"].(map[string]interface{}); ok {
+			// Generator values are themselves templates over the cluster's
+			// parameters.
+			rendered, err := renderTree(v, params, nil)
+			if err != nil {
+				return nil, "", fmt.Errorf("cluster generator values: %w", err)
+			}
+			values = rendered.(map[string]interface{})
+		}
+		params["values"] = values
+		return []map[string]interface{}{params}, "", nil
+
+	case gen["merge"] != nil:
+		spec, _ := gen["merge"].(map[string]interface{})
+		gens, _ := spec["generators"].([]interface{})
+		keys, _ := spec["mergeKeys"].([]interface{})
+		if len(gens) == 0 {
+			return nil, "", nil
+		}
+		first, _ := gens[0].(map[string]interface{})
+		base, why, err := generate(first, c)
+		if err != nil || len(base) == 0 {
+			return nil, why, err
+		}
+		for _, g := range gens[1:] {
+			m, _ := g.(map[string]interface{})
+			overrides, _, err := generate(m, c)
+			if err != nil {
+				return nil, "", err
+			}
+			for _, o := range overrides {
+				for _, b := range base {
+					if sameKeys(b, o, keys) {
+						mergeParams(b, o)
+					}
+				}
+			}
+		}
+		return base, "", nil
+	}
+
+	var kinds []string
+	for k := range gen {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return nil, "", fmt.Errorf("generator %s is not supported; only clusters and merge generators are rendered", strings.Join(kinds, ", "))
+}
+
+// selects reports whether the label selector sel matches c's labels, and
+// why not when it does not.
+func selects(sel interface{}, c *Cluster) (bool, string, error) {
+	var ls metav1.LabelSelector
+	if sel != nil {
+		data, err := json.Marshal(sel)
+		if err != nil {
+			return false, "", err
+		}
+		if err := json.Unmarshal(data, &ls); err != nil {
+			return false, "", fmt.Errorf("cluster generator selector: %w", err)
+		}
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil {
+		return false, "", fmt.Errorf("cluster generator selector: %w", err)
+	}
+	set := labels.Set(c.Labels)
+	if selector.Matches(set) {
+		return true, "", nil
+	}
+	reqs, _ := selector.Requirements()
+	var unmet []string
+	for _, r := range reqs {
+		if !r.Matches(set) {
+			unmet = append(unmet, r.String())
+		}
+	}
+	return false, strings.Join(unmet, ", "), nil
+}
+
+// sameKeys reports whether a and b agree on every merge key.
+func sameKeys(a, b map[string]interface{}, keys []interface{}) bool {
+	for _, k := range keys {
+		if fmt.Sprint(a[fmt.Sprint(k)]) != fmt.Sprint(b[fmt.Sprint(k)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeParams overrides base with over, merging nested maps.
+func mergeParams(base, over map[string]interface{}) {
+	for k, v := range over {
+		if bm, ok := base[k].(map[string]interface{}); ok {
+			if om, ok := v.(map[string]interface{}); ok {
+				mergeParams(bm, om)
+				continue
+			}
+		}
+		base[k] = v
+	}
+}
+
+// renderTree executes every string in v that holds a template with params,
+// as ArgoCD renders an ApplicationSet template in goTemplate mode.
+func renderTree(v interface{}, params map[string]interface{}, options []string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			r, err := renderTree(e, params, options)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			r, err := renderTree(e, params, options)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		t, err := template.New("").Funcs(templateFuncs()).Option(options...).Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", v, err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, params); err != nil {
+			return nil, fmt.Errorf("template %q: %w", v, err)
+		}
+		return b.String(), nil
+	}
+	return v, nil
+}
+
+// legacyParam matches a parameter of an ApplicationSet without goTemplate,
+// such as {{metadata.labels.environment}}.
+var legacyParam = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// renderLegacy substitutes c's parameters into s as ArgoCD does for an
+// ApplicationSet without goTemplate; unknown parameters are left in place.
+func renderLegacy(s string, c *Cluster) string {
+	return legacyParam.ReplaceAllStringFunc(s, func(m string) string {
+		key := legacyParam.FindStringSubmatch(m)[1]
+		switch {
+		case key == "name":
+			return c.Name
+		case key == "nameNormalized":
+			return normalizeName(c.Name)
+		case key == "server":
+			return c.Server
+		case strings.HasPrefix(key, "metadata.labels."):
+			if v, ok := c.Labels[strings.TrimPrefix(key, "metadata.labels.")]; ok {
+				return v
+			}
+		case strings.HasPrefix(key, "metadata.annotations."):
+			if v, ok := c.Annotations[strings.TrimPrefix(key, "metadata.annotations.")]; ok {
+				return v
+			}
+		}
+		return m
+	})
+}
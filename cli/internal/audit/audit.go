@@ -0,0 +1,286 @@
+// Package audit maintains the append-only log of hctl mutations kept in the
+// gitops repo at .hctl/audit.log. Each line is a JSON Entry; every entry
+// carries the hash of the one before it, so editing or deleting a line
+// breaks the chain and is caught by Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// LogPath is the audit log location relative to the repo root.
+const LogPath = ".hctl/audit.log"
+
+// lockPath guards appends; it is never committed.
+const lockPath = ".hctl/audit.lock"
+
+// Lock timing. A lock older than staleLockAge is assumed to belong to a
+// crashed process and is broken.
+var (
+	lockTimeout  = 10 * time.Second
+	lockRetry    = 20 * time.Millisecond
+	staleLockAge = 2 * time.Minute
+)
+
+// Entry is one audited mutation.
+type Entry struct {
+	// Time is the UTC time of the mutation (RFC 3339).
+	Time string `json:"time"`
+	// User is the git identity ("Name <email>") or, without one, the OS user.
+	User string `json:"user"`
+	// Command is the hctl command path, e.g. "hctl deploy run".
+	Command string `json:"command"`
+	// Args is the full argument list with secret values redacted.
+	Args []string `json:"args,omitempty"`
+	// Action and Resource match the commit message, e.g. "deploy" "myapp".
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Details  string `json:"details,omitempty"`
+	// Paths are the repo-relative files committed with the entry.
+	Paths []string `json:"paths,omitempty"`
+	// Removed are the generated Kubernetes objects the change stops
+	// rendering, from the workload's manifest inventory, which ArgoCD
+	// prunes (see translate.Inventory).
+	Removed []string `json:"removed,omitempty"`
+	// Version is the hctl version that made the change.
+	Version string `json:"version"`
+	// Base is the commit the change was made on top of. The commit that
+	// contains the entry is recovered from git (see 'hctl audit-log').
+	Base string `json:"base,omitempty"`
+	// PolicyOverride is the --policy-override reason and the policy rules
+	// it overrode, when the change needed one.
+	PolicyOverride string `json:"policyOverride,omitempty"`
+	// PrevHash is the Hash of the previous entry ("" for the first).
+	PrevHash string `json:"prevHash"`
+	// Hash is the SHA-256 of the entry with Hash itself left empty.
+	Hash string `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of e, ignoring its Hash field.
+func (e Entry) ComputeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e) // Entry has only strings and string slices
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// invocation is the running command, set once by the root command.
+var invocation struct {
+	command string
+	args    []string
+	version string
+}
+
+// SetInvocation records the running command for entries appended later.
+// Args are redacted before they are stored.
+func SetInvocation(command string, args []string, version string) {
+	invocation.command = command
+	invocation.args = Redact(args)
+	invocation.version = version
+}
+
+// Append adds e to the repo's audit log under the append lock, filling in
+// the time, invocation, and chain hashes. It returns the entry as written.
+func Append(repoRoot string, e Entry) (Entry, error) {
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	if e.Command == "" {
+		e.Command = invocation.command
+		e.Args = invocation.args
+	} else {
+		e.Args = Redact(e.Args)
+	}
+	if e.Version == "" {
+		e.Version = invocation.version
+	}
+
+	path := filepath.Join(repoRoot, LogPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Entry{}, fmt.Errorf("creating audit log directory: %w", err)
+	}
+	unlock, err := lock(filepath.Join(repoRoot, lockPath))
+	if err != nil {
+		return Entry{}, err
+	}
+	defer unlock()
+
+	prev, err := lastHash(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.PrevHash = prev
+	e.Hash = e.ComputeHash()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, fmt.Errorf("encoding audit entry: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return Entry{}, fmt.Errorf("opening audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return Entry{}, fmt.Errorf("writing audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return Entry{}, fmt.Errorf("writing audit log: %w", err)
+	}
+	return e, nil
+}
+
+// lock takes the append lock by exclusively creating path, waiting for
+// another holder to release it.
+func lock(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("locking audit log: %w", err)
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, hcerrors.NewTimeoutError("timed out waiting for audit log lock %s", path).
+				WithRemediation("If no other hctl command is running, delete the lock file and retry")
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// lastHash returns the Hash of the final entry in the log, or "" when the
+// log does not exist or is empty.
+func lastHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading audit log: %w", err)
+	}
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return "", nil
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	var last Entry
+	if err := json.Unmarshal(data, &last); err != nil {
+		return "", hcerrors.New(hcerrors.ErrValidation, "audit log %s: last entry is not valid JSON: %w", path, err).
+			WithRemediation("Run 'hctl audit-log --verify' and restore the log from git history")
+	}
+	return last.Hash, nil
+}
+
+// Read parses every entry in the repo's audit log. A missing log has none.
+func Read(repoRoot string) ([]Entry, error) {
+	path := filepath.Join(repoRoot, LogPath)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s line %d: %w", LogPath, n, err).
+				WithDetails(map[string]int{"line": n})
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// verifyHint points at the git history, which holds every committed version
+// of the log.
+const verifyHint = "Compare the log with its git history: git log -p -- " + LogPath
+
+// Verify checks every entry's hash and its link to the previous entry. The
+// error names the first entry (1-based) where the chain breaks.
+func Verify(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		n := i + 1
+		if e.PrevHash != prev {
+			return hcerrors.New(hcerrors.ErrValidation, "audit log entry %d (%s %s): previous-entry hash does not match — an entry before it was changed or removed", n, e.Action, e.Resource).
+				WithDetails(map[string]int{"entry": n}).WithRemediation(verifyHint)
+		}
+		if got := e.ComputeHash(); got != e.Hash {
+			return hcerrors.New(hcerrors.ErrValidation, "audit log entry %d (%s %s): content does not match its hash — the entry was modified", n, e.Action, e.Resource).
+				WithDetails(map[string]int{"entry": n}).WithRemediation(verifyHint)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// Filter selects entries for 'hctl audit-log'. Zero fields match everything.
+type Filter struct {
+	// Resource matches the entry's resource exactly.
+	Resource string
+	// User matches a case-insensitive substring of the entry's user.
+	User string
+	// Since and Until bound the entry time, inclusive.
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether e passes the filter. Entries with an unparsable
+// time only match filters without a time bound.
+func (f Filter) Match(e Entry) bool {
+	if f.Resource != "" && e.Resource != f.Resource {
+		return false
+	}
+	if f.User != "" && !strings.Contains(strings.ToLower(e.User), strings.ToLower(f.User)) {
+		return false
+	}
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, e.Time)
+	if err != nil {
+		return false
+	}
+	if !f.Since.IsZero() && t.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && t.After(f.Until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func appendN(t *testing.T, repo string, n int) []Entry {
+	t.Helper()
+	var written []Entry
+	for i := 0; i < n; i++ {
+		e, err := Append(repo, Entry{
+			Command:  "hctl deploy run",
+			Args:     []string{"--token=abc"},
+			User:     "alice <alice@example.com>",
+			Action:   "deploy",
+			Resource: fmt.Sprintf("app-%d", i),
+		})
+		if err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+		written = append(written, e)
+	}
+	return written
+}
+
+func TestAppendChainsEntries(t *testing.T) {
+	repo := t.TempDir()
+	written := appendN(t, repo, 3)
+
+	entries, err := Read(repo)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[0].PrevHash != "" {
+		t.Errorf("first entry PrevHash = %q, want empty", entries[0].PrevHash)
+	}
+	for i := 1; i < 3; i++ {
+		if entries[i].PrevHash != entries[i-1].Hash {
+			t.Errorf("entry %d PrevHash does not link to entry %d", i, i-1)
+		}
+	}
+	if entries[2].Hash != written[2].Hash {
+		t.Error("returned entry differs from the one on disk")
+	}
+	if entries[0].Args[0] != "--token="+Redacted {
+		t.Errorf("args not redacted: %q", entries[0].Args)
+	}
+	if err := Verify(entries); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	repo := t.TempDir()
+	appendN(t, repo, 4)
+	entries, err := Read(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		mutate func([]Entry) []Entry
+		entry  int
+	}{
+		"edited field": {
+			mutate: func(es []Entry) []Entry { es[1].User = "mallory"; return es },
+			entry:  2,
+		},
+		"edited field with recomputed hash": {
+			mutate: func(es []Entry) []Entry {
+				es[1].Resource = "other"
+				es[1].Hash = es[1].ComputeHash()
+				return es
+			},
+			entry: 3,
+		},
+		"removed entry": {
+			mutate: func(es []Entry) []Entry { return append(es[:1:1], es[2:]...) },
+			entry:  2,
+		},
+		"reordered entries": {
+			mutate: func(es []Entry) []Entry { es[2], es[3] = es[3], es[2]; return es },
+			entry:  3,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			es := tc.mutate(append([]Entry(nil), entries...))
+			err := Verify(es)
+			if hcerrors.CategoryOf(err) != hcerrors.ErrValidation {
+				t.Fatalf("Verify = %v, want validation error", err)
+			}
+			if want := fmt.Sprintf("entry %d ", tc.entry); !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should name %q", err, want)
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsTamperedFile(t *testing.T) {
+	repo := t.TempDir()
+	appendN(t, repo, 2)
+	path := filepath.Join(repo, LogPath)
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "alice", "bob", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(entries); err == nil {
+		t.Error("Verify passed on an edited log")
+	}
+}
+
+func TestConcurrentAppends(t *testing.T) {
+	repo := t.TempDir()
+	const n = 25
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := Append(repo, Entry{Command: "hctl addon enable", Action: "enable addon", Resource: fmt.Sprint(i)})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	entries, err := Read(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Fatalf("got %d entries, want %d", len(entries), n)
+	}
+	if err := Verify(entries); err != nil {
+		t.Errorf("chain broken by concurrent appends: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, lockPath)); !os.IsNotExist(err) {
+		t.Error("lock file left behind")
+	}
+}
+
+func TestAppendBreaksStaleLock(t *testing.T) {
+	repo := t.TempDir()
+	lock := filepath.Join(repo, lockPath)
+	if err := os.MkdirAll(filepath.Dir(lock), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lock, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Append(repo, Entry{Action: "deploy", Resource: "app"}); err != nil {
+		t.Fatalf("Append with stale lock: %v", err)
+	}
+}
+
+func TestAppendTimesOutOnHeldLock(t *testing.T) {
+	defer func(d time.Duration) { lockTimeout = d }(lockTimeout)
+	lockTimeout = 50 * time.Millisecond
+
+	repo := t.TempDir()
+	lock := filepath.Join(repo, lockPath)
+	if err := os.MkdirAll(filepath.Dir(lock), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lock, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Append(repo, Entry{Action: "deploy", Resource: "app"})
+	if hcerrors.CategoryOf(err) != hcerrors.ErrTimeout {
+		t.Errorf("Append = %v, want timeout error", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	e := Entry{Time: "2026-03-15T10:00:00Z", User: "Alice <alice@example.com>", Resource: "myapp"}
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	cases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"resource", Filter{Resource: "myapp"}, true},
+		{"other resource", Filter{Resource: "other"}, false},
+		{"user substring, any case", Filter{User: "alice@"}, true},
+		{"other user", Filter{User: "bob"}, false},
+		{"since before", Filter{Since: day("2026-03-01")}, true},
+		{"since after", Filter{Since: day("2026-03-16")}, false},
+		{"until after", Filter{Until: day("2026-03-16")}, true},
+		{"until before", Filter{Until: day("2026-03-15")}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.filter.Match(e); got != tc.want {
+			t.Errorf("%s: Match = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secret values in recorded arguments.
+const Redacted = "[REDACTED]"
+
+// sensitiveWords mark a flag or KEY=value name as holding a secret. Names
+// are compared lowercased with "-" and "_" removed, so --api-key, API_KEY,
+// and apiKey all match "apikey".
+var sensitiveWords = []string{
+	"password", "passwd", "secret", "token", "apikey", "accesskey",
+	"privatekey", "credential", "auth", "cookie", "session",
+}
+
+func isSensitive(name string) bool {
+	n := strings.ToLower(name)
+	n = strings.NewReplacer("-", "", "_", "", ".", "").Replace(n)
+	for _, w := range sensitiveWords {
+		if strings.Contains(n, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of args with secret values replaced:
+//
+//   - the value of a sensitive flag, as --token=x or --token x
+//   - the value of a sensitive KEY=value argument, e.g. DB_PASSWORD=x
+//     (including inside a flag value such as --set db.password=x)
+//   - the password in a URL with credentials, https://user:x@host
+func Redact(args []string) []string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			redactNext = false
+			if !strings.HasPrefix(arg, "-") {
+				out[i] = Redacted
+				continue
+			}
+		}
+
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			switch {
+			case isSensitive(name) && hasValue:
+				out[i] = "--" + name + "=" + Redacted
+			case isSensitive(name):
+				out[i] = arg
+				redactNext = true
+			case hasValue:
+				out[i] = "--" + name + "=" + redactValue(value)
+			default:
+				out[i] = arg
+			}
+			continue
+		}
+		out[i] = redactValue(arg)
+	}
+	return out
+}
+
+// redactValue redacts a positional argument or flag value.
+func redactValue(v string) string {
+	if key, _, ok := strings.Cut(v, "="); ok && key != "" && !strings.Contains(key, "://") && isSensitive(key) {
+		return key + "=" + Redacted
+	}
+	if strings.Contains(v, "://") {
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				u.User = url.UserPassword(u.User.Username(), "REDACTED")
+				return strings.Replace(u.String(), "REDACTED", Redacted, 1)
+			}
+		}
+	}
+	return v
+}
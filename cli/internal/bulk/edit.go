@@ -0,0 +1,379 @@
+package bulk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// Change is one file a Plan rewrites.
+type Change struct {
+	// Path is the absolute file path.
+	Path string
+	// Cluster is the cluster the file belongs to, empty for the shared
+	// environment and cluster-role addon layers.
+	Cluster string
+	// Targets are the targets whose edits changed the file.
+	Targets []string
+	Before  []byte
+	After   []byte
+}
+
+// Skip is an edit left out for one target, and why.
+type Skip struct {
+	Target string `json:"target" yaml:"target"`
+	Edit   string `json:"edit" yaml:"edit"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// Plan is every file change a bulk plan file makes.
+type Plan struct {
+	// Targets are the selected targets at least one edit applies to.
+	Targets []Target
+	Changes []Change
+	Skipped []Skip
+
+	// write performs the changes; tests substitute a failing one.
+	write func(path string, data []byte, perm os.FileMode) error
+}
+
+// pendingFile collects the edits for one file so each file is parsed and
+// rewritten once however many targets and edits touch it.
+type pendingFile struct {
+	cluster string
+	before  []byte
+	top     *yaml.Node // nil when the file has no content yet
+	targets []string
+	keys    []string
+	edits   map[string]platform.ManifestEdit
+}
+
+func (pf *pendingFile) add(target string, e platform.ManifestEdit) {
+	key := strings.Join(e.Path, "\x00")
+	if _, ok := pf.edits[key]; !ok {
+		pf.keys = append(pf.keys, key)
+	}
+	// A later edit to the same key wins.
+	pf.edits[key] = e
+	for _, t := range pf.targets {
+		if t == target {
+			return
+		}
+	}
+	pf.targets = append(pf.targets, target)
+}
+
+type builder struct {
+	repoPath string
+	files    map[string]*pendingFile
+	order    []string
+	plan     *Plan
+	// selected holds, per addons.yaml, the entries a bump-chart-version
+	// edit selected, to decide whether a shared anchor may be bumped.
+	selected map[string]map[string]bool
+}
+
+// Build computes the changes f makes in the repo without touching the disk.
+// Edits that would not change a file are dropped, so building the same plan
+// after applying it yields no changes.
+func Build(repoPath string, f *File) (*Plan, error) {
+	targets, err := Select(repoPath, f.Selector)
+	if err != nil {
+		return nil, err
+	}
+	b := &builder{
+		repoPath: repoPath,
+		files:    map[string]*pendingFile{},
+		plan:     &Plan{write: mutation.WriteFileAtomic},
+		selected: map[string]map[string]bool{},
+	}
+	for _, t := range targets {
+		if t.Kind == TargetVCluster {
+			continue
+		}
+		file := t.entryFile(repoPath)
+		if b.selected[file] == nil {
+			b.selected[file] = map[string]bool{}
+		}
+		b.selected[file][t.Name] = true
+	}
+
+	for _, t := range targets {
+		applied := false
+		for _, e := range f.Edits {
+			if e.targetsVClusters() != (t.Kind == TargetVCluster) {
+				continue
+			}
+			applied = true
+			if err := b.edit(t, e); err != nil {
+				return nil, err
+			}
+		}
+		if applied {
+			b.plan.Targets = append(b.plan.Targets, t)
+		}
+	}
+
+	for _, path := range b.order {
+		pf := b.files[path]
+		edits := make([]platform.ManifestEdit, 0, len(pf.keys))
+		for _, k := range pf.keys {
+			edits = append(edits, pf.edits[k])
+		}
+		var after []byte
+		if pf.top == nil {
+			after, err = appendValues(pf.before, edits)
+		} else {
+			after, err = platform.EditManifest(pf.before, edits)
+		}
+		if err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "editing %s: %w", path, err)
+		}
+		if bytes.Equal(after, pf.before) {
+			continue
+		}
+		b.plan.Changes = append(b.plan.Changes, Change{
+			Path:    path,
+			Cluster: pf.cluster,
+			Targets: pf.targets,
+			Before:  pf.before,
+			After:   after,
+		})
+	}
+	return b.plan, nil
+}
+
+func (b *builder) edit(t Target, e Edit) error {
+	switch {
+	case e.Op == EditBumpChartVersion:
+		return b.bumpChartVersion(t, e)
+	case e.Op == EditAddLabel:
+		path := []string{"spec", "integrations", "argocd", "clusterLabels", e.Key}
+		return b.set(t, e, t.manifest, path, fmt.Sprint(e.Value))
+	case e.in() == InVCluster:
+		return b.set(t, e, t.manifest, strings.Split(e.Path, "."), e.Value)
+	case e.in() == InEntry:
+		return b.set(t, e, t.entryFile(b.repoPath), append([]string{t.Name}, strings.Split(e.Path, ".")...), e.Value)
+	}
+	file := t.valuesFile(b.repoPath)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		b.skip(t, e, "no values.yaml")
+		return nil
+	}
+	return b.set(t, e, file, strings.Split(e.Path, "."), e.Value)
+}
+
+func (b *builder) set(t Target, e Edit, file string, path []string, value interface{}) error {
+	pf, err := b.file(file, t.Cluster)
+	if err != nil {
+		return err
+	}
+	if e.Minimum {
+		if current := lookup(pf.top, path); current != nil && current.Kind == yaml.ScalarNode &&
+			!platform.QuantityLess(current.Value, fmt.Sprint(value)) {
+			b.skip(t, e, fmt.Sprintf("already %s", current.Value))
+			return nil
+		}
+	}
+	pf.add(t.String(), platform.ManifestEdit{Path: path, Value: value})
+	return nil
+}
+
+// bumpChartVersion sets the defaultVersion the entry resolves to. An entry
+// that inherits it through a YAML merge key (<<: *defaults) has the anchor
+// bumped when every entry sharing it is selected; otherwise the entry gets
+// its own defaultVersion so unselected entries keep theirs.
+func (b *builder) bumpChartVersion(t Target, e Edit) error {
+	file := t.entryFile(b.repoPath)
+	pf, err := b.file(file, t.Cluster)
+	if err != nil {
+		return err
+	}
+	entry := lookup(pf.top, []string{t.Name})
+	if entry == nil || entry.Kind != yaml.MappingNode {
+		b.skip(t, e, "no entry in "+filepath.Base(file))
+		return nil
+	}
+	path := []string{t.Name, "defaultVersion"}
+	if lookup(entry, []string{"defaultVersion"}) == nil {
+		anchorKey, anchor := mergedAnchor(pf.top, entry)
+		if anchor == nil || lookup(anchor, []string{"defaultVersion"}) == nil {
+			b.skip(t, e, "entry has no defaultVersion")
+			return nil
+		}
+		if b.allSelected(file, pf.top, anchor) {
+			path = []string{anchorKey, "defaultVersion"}
+		}
+	}
+	pf.add(t.String(), platform.ManifestEdit{Path: path, Value: e.Version})
+	return nil
+}
+
+// allSelected reports whether every entry inheriting anchor's defaultVersion
+// is selected.
+func (b *builder) allSelected(file string, top, anchor *yaml.Node) bool {
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		name, entry := top.Content[i].Value, top.Content[i+1]
+		if entry == anchor || entry.Kind != yaml.MappingNode || lookup(entry, []string{"defaultVersion"}) != nil {
+			continue
+		}
+		if _, a := mergedAnchor(top, entry); a == anchor && !b.selected[file][name] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergedAnchor returns the top-level key and mapping entry merges with <<,
+// or nil when it merges none.
+func mergedAnchor(top, entry *yaml.Node) (string, *yaml.Node) {
+	merge := lookup(entry, []string{"<<"})
+	if merge == nil || merge.Kind != yaml.AliasNode || merge.Alias == nil {
+		return "", nil
+	}
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i+1] == merge.Alias {
+			return top.Content[i].Value, merge.Alias
+		}
+	}
+	return "", nil
+}
+
+// file returns the pending edits for path, reading it on first use.
+func (b *builder) file(path, cluster string) (*pendingFile, error) {
+	if pf, ok := b.files[path]; ok {
+		// A file reached from several clusters belongs to none of them.
+		if pf.cluster != cluster {
+			pf.cluster = ""
+		}
+		return pf, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pf := &pendingFile{cluster: cluster, before: data, edits: map[string]platform.ManifestEdit{}}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", path, err)
+	}
+	switch {
+	case len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode:
+		pf.top = root.Content[0]
+	case len(root.Content) > 0 && root.Content[0].Tag != "!!null":
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s is not a YAML mapping", path)
+	}
+	b.files[path] = pf
+	b.order = append(b.order, path)
+	return pf, nil
+}
+
+func (b *builder) skip(t Target, e Edit, reason string) {
+	b.plan.Skipped = append(b.plan.Skipped, Skip{Target: t.String(), Edit: e.String(), Reason: reason})
+}
+
+// lookup returns the value node at path under n, or nil.
+func lookup(n *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		n = next
+	}
+	return n
+}
+
+// appendValues writes edits into a file that holds only comments (such as a
+// scaffolded values.yaml), keeping the comments above the new keys.
+func appendValues(doc []byte, edits []platform.ManifestEdit) ([]byte, error) {
+	values := map[string]interface{}{}
+	for _, e := range edits {
+		m := values
+		for _, key := range e.Path[:len(e.Path)-1] {
+			next, ok := m[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[key] = next
+			}
+			m = next
+		}
+		m[e.Path[len(e.Path)-1]] = e.Value
+	}
+	var buf bytes.Buffer
+	buf.Write(doc)
+	if len(doc) > 0 && !bytes.HasSuffix(doc, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(values); err != nil {
+		return nil, fmt.Errorf("encoding values: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding values: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Clusters returns the clusters with changed files, sorted.
+func (p *Plan) Clusters() []string {
+	seen := map[string]bool{}
+	var clusters []string
+	for _, c := range p.Changes {
+		if c.Cluster != "" && !seen[c.Cluster] {
+			seen[c.Cluster] = true
+			clusters = append(clusters, c.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// ByCluster groups the changes by cluster. Files in the shared addon layers
+// are grouped under "".
+func (p *Plan) ByCluster() map[string][]Change {
+	groups := map[string][]Change{}
+	for _, c := range p.Changes {
+		groups[c.Cluster] = append(groups[c.Cluster], c)
+	}
+	return groups
+}
+
+// Paths returns the changed file paths in plan order.
+func (p *Plan) Paths() []string {
+	paths := make([]string, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+// Files returns the changes as file writes, in plan order.
+func (p *Plan) Files() []mutation.File {
+	files := make([]mutation.File, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		files = append(files, mutation.File{Path: c.Path, Before: c.Before, After: c.After})
+	}
+	return files
+}
+
+// Apply writes every change. If one fails, the changes already made are
+// reverted so the working tree is left as it was.
+func (p *Plan) Apply() error {
+	return mutation.ApplyFiles(p.Files(), p.write, os.Remove)
+}
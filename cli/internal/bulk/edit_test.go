@@ -0,0 +1,307 @@
+package bulk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+const mediaAddons = `globalSelectors:
+  cluster_name: vcluster-media
+
+mediaAppDefaults: &mediaAppDefaults
+  namespace: media
+  defaultVersion: 6.14.0
+
+# Media apps share the defaults above.
+sonarr:
+  <<: *mediaAppDefaults
+  enabled: true
+
+radarr:
+  <<: *mediaAppDefaults
+  enabled: true
+`
+
+const devAddons = `whoami:
+  enabled: true
+  namespace: whoami
+  defaultVersion: 6.14.0
+
+old:
+  enabled: false
+  defaultVersion: 6.0.0
+`
+
+const sonarrValues = `applicationName: sonarr
+deployment:
+  resources:
+    requests:
+      cpu: 50m # bumped for imports
+      memory: 512Mi
+`
+
+const radarrValues = `applicationName: radarr
+deployment:
+  resources:
+    requests:
+      cpu: 200m
+`
+
+func vclusterManifest(name string) string {
+	return `apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: ` + name + `
+  namespace: platform-requests
+spec:
+  name: ` + name + `
+  integrations:
+    argocd:
+      clusterLabels:
+        env: dev
+`
+}
+
+// newTestRepo lays out two clusters: vcluster-media, whose workloads share a
+// merge-key anchor, and vcluster-dev with plain entries and no values.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	writeTestFile(t, filepath.Join(repo, "workloads/vcluster-media/addons.yaml"), mediaAddons)
+	writeTestFile(t, filepath.Join(repo, "workloads/vcluster-media/addons/sonarr/values.yaml"), sonarrValues)
+	writeTestFile(t, filepath.Join(repo, "workloads/vcluster-media/addons/radarr/values.yaml"), radarrValues)
+	writeTestFile(t, filepath.Join(repo, "workloads/vcluster-dev/addons.yaml"), devAddons)
+	writeTestFile(t, filepath.Join(repo, "addons/environments/production/addons/addons.yaml"), "loki:\n  enabled: true\n  defaultVersion: 1.0.0\n")
+	writeTestFile(t, filepath.Join(repo, "addons/clusters/vcluster-media/addons/addons.yaml"), "loki:\n  enabled: true\n")
+	writeTestFile(t, filepath.Join(repo, "platform/vclusters/vcluster-media.yaml"), vclusterManifest("vcluster-media"))
+	writeTestFile(t, filepath.Join(repo, "platform/vclusters/vcluster-dev.yaml"), vclusterManifest("vcluster-dev"))
+	return repo
+}
+
+func build(t *testing.T, repo string, f File) *Plan {
+	t.Helper()
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	plan, err := Build(repo, &f)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	return plan
+}
+
+func changedFiles(repo string, plan *Plan) []string {
+	var files []string
+	for _, c := range plan.Changes {
+		rel, _ := filepath.Rel(repo, c.Path)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	return files
+}
+
+func TestBumpChartVersionFollowsSharedAnchor(t *testing.T) {
+	repo := newTestRepo(t)
+	plan := build(t, repo, File{
+		Selector: Selector{Workloads: []string{"*"}},
+		Edits:    []Edit{{Op: EditBumpChartVersion, Version: "6.15.0"}},
+	})
+
+	got := strings.Join(changedFiles(repo, plan), ",")
+	if got != "workloads/vcluster-dev/addons.yaml,workloads/vcluster-media/addons.yaml" {
+		t.Fatalf("changed files = %s", got)
+	}
+	media := string(plan.Changes[1].After)
+	want := strings.Replace(mediaAddons, "defaultVersion: 6.14.0", "defaultVersion: 6.15.0", 1)
+	if media != want {
+		t.Errorf("media addons.yaml =\n%s\nwant the anchor bumped in place:\n%s", media, want)
+	}
+	dev := string(plan.Changes[0].After)
+	if !strings.Contains(dev, "defaultVersion: 6.15.0") || !strings.Contains(dev, "defaultVersion: 6.0.0") {
+		t.Errorf("dev addons.yaml =\n%s\nwant whoami bumped and the disabled entry untouched", dev)
+	}
+	if len(plan.Targets) != 3 {
+		t.Errorf("targets = %v, want the three enabled workloads", plan.Targets)
+	}
+}
+
+func TestBumpChartVersionOverridesPartiallySelectedAnchor(t *testing.T) {
+	repo := newTestRepo(t)
+	plan := build(t, repo, File{
+		Selector: Selector{Clusters: []string{"vcluster-media"}, Workloads: []string{"sonarr"}},
+		Edits:    []Edit{{Op: EditBumpChartVersion, Version: "6.15.0"}},
+	})
+
+	if len(plan.Changes) != 1 {
+		t.Fatalf("changes = %v, want only the media addons.yaml", changedFiles(repo, plan))
+	}
+	after := string(plan.Changes[0].After)
+	if !strings.Contains(after, "defaultVersion: 6.14.0") {
+		t.Errorf("anchor was bumped although radarr is not selected:\n%s", after)
+	}
+	if !strings.Contains(after, "<<: *mediaAppDefaults\n  enabled: true\n  defaultVersion: 6.15.0") {
+		t.Errorf("sonarr has no defaultVersion override:\n%s", after)
+	}
+}
+
+func TestSetMinimumKeepsLargerValues(t *testing.T) {
+	repo := newTestRepo(t)
+	plan := build(t, repo, File{
+		Selector: Selector{Workloads: []string{"*"}},
+		Edits: []Edit{{
+			Op: EditSet, Path: "deployment.resources.requests.cpu", Value: "100m", Minimum: true,
+		}},
+	})
+
+	got := strings.Join(changedFiles(repo, plan), ",")
+	if got != "workloads/vcluster-media/addons/sonarr/values.yaml" {
+		t.Fatalf("changed files = %s, want only sonarr's values", got)
+	}
+	want := strings.Replace(sonarrValues, "cpu: 50m", "cpu: 100m", 1)
+	if string(plan.Changes[0].After) != want {
+		t.Errorf("sonarr values =\n%s\nwant the comment kept:\n%s", plan.Changes[0].After, want)
+	}
+	reasons := map[string]string{}
+	for _, s := range plan.Skipped {
+		reasons[s.Target] = s.Reason
+	}
+	if reasons["workload vcluster-media/radarr"] != "already 200m" || reasons["workload vcluster-dev/whoami"] != "no values.yaml" {
+		t.Errorf("skipped = %+v", plan.Skipped)
+	}
+}
+
+func TestSetEntryAndAddLabel(t *testing.T) {
+	repo := newTestRepo(t)
+	plan := build(t, repo, File{
+		Selector: Selector{Clusters: []string{"vcluster-d*"}, Workloads: []string{"whoami"}},
+		Edits: []Edit{
+			{Op: EditSet, In: InEntry, Path: "namespace", Value: "web"},
+			{Op: EditAddLabel, Key: "tier", Value: 2},
+		},
+	})
+
+	got := strings.Join(changedFiles(repo, plan), ",")
+	if got != "workloads/vcluster-dev/addons.yaml,platform/vclusters/vcluster-dev.yaml" {
+		t.Fatalf("changed files = %s", got)
+	}
+	if !strings.Contains(string(plan.Changes[0].After), "namespace: web") {
+		t.Errorf("entry not edited:\n%s", plan.Changes[0].After)
+	}
+	if !strings.Contains(string(plan.Changes[1].After), "env: dev\n        tier: \"2\"\n") {
+		t.Errorf("label not added as a string:\n%s", plan.Changes[1].After)
+	}
+	for _, c := range plan.Changes {
+		if c.Cluster != "vcluster-dev" {
+			t.Errorf("%s: cluster = %q", c.Path, c.Cluster)
+		}
+	}
+}
+
+func TestSetIntoCommentOnlyValues(t *testing.T) {
+	repo := newTestRepo(t)
+	values := filepath.Join(repo, "workloads/vcluster-dev/addons/whoami/values.yaml")
+	writeTestFile(t, values, "# whoami values\n")
+	plan := build(t, repo, File{
+		Selector: Selector{Workloads: []string{"whoami"}},
+		Edits:    []Edit{{Op: EditSet, Path: "deployment.replicas", Value: 2}},
+	})
+
+	if len(plan.Changes) != 1 {
+		t.Fatalf("changes = %v", changedFiles(repo, plan))
+	}
+	want := "# whoami values\ndeployment:\n  replicas: 2\n"
+	if got := string(plan.Changes[0].After); got != want {
+		t.Errorf("values =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	repo := newTestRepo(t)
+	f := File{
+		Selector: Selector{Workloads: []string{"*"}, Addons: []string{"loki"}},
+		Edits: []Edit{
+			{Op: EditBumpChartVersion, Version: "6.15.0"},
+			{Op: EditSet, Path: "deployment.resources.requests.cpu", Value: "100m", Minimum: true},
+			{Op: EditSet, In: InEntry, Path: "syncWave", Value: 3},
+			{Op: EditAddLabel, Key: "team", Value: "media"},
+		},
+	}
+	plan := build(t, repo, f)
+	if len(plan.Changes) == 0 {
+		t.Fatal("first build has no changes")
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	again := build(t, repo, f)
+	if len(again.Changes) != 0 {
+		for _, c := range again.Changes {
+			t.Errorf("%s changed again:\n%s", c.Path, c.After)
+		}
+	}
+}
+
+func TestApplyRollsBackOnFailure(t *testing.T) {
+	repo := newTestRepo(t)
+	plan := build(t, repo, File{
+		Selector: Selector{Workloads: []string{"*"}},
+		Edits:    []Edit{{Op: EditBumpChartVersion, Version: "6.15.0"}},
+	})
+	if len(plan.Changes) != 2 {
+		t.Fatalf("changes = %v, want two files", changedFiles(repo, plan))
+	}
+	writes := 0
+	plan.write = func(path string, data []byte, perm os.FileMode) error {
+		if writes++; writes == 2 {
+			return errors.New("disk full")
+		}
+		return mutation.WriteFileAtomic(path, data, perm)
+	}
+
+	err := plan.Apply()
+	if err == nil || !strings.Contains(err.Error(), "all changes rolled back") {
+		t.Fatalf("Apply error = %v, want a rolled-back failure", err)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "workloads/vcluster-dev/addons.yaml")); got != devAddons {
+		t.Errorf("first file not restored:\n%s", got)
+	}
+	if got := readTestFile(t, filepath.Join(repo, "workloads/vcluster-media/addons.yaml")); got != mediaAddons {
+		t.Errorf("failed file changed:\n%s", got)
+	}
+}
+
+func TestBuildRejectsNonMappingFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	writeTestFile(t, filepath.Join(repo, "workloads/vcluster-media/addons/sonarr/values.yaml"), "- a\n- b\n")
+	_, err := Build(repo, &File{
+		Selector: Selector{Workloads: []string{"sonarr"}},
+		Edits:    []Edit{{Op: EditSet, Path: "a", Value: "b"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not a YAML mapping") {
+		t.Fatalf("Build error = %v, want a not-a-mapping error", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Package bulk applies one change across many workloads, addons and
+// vClusters. A plan file selects the targets and lists structured edits;
+// Build computes every file change up front so 'hctl bulk plan' can preview
+// them and 'hctl bulk apply' can write them as a unit. Edits go through the
+// order-preserving manifest editor (platform.EditManifest) and are
+// idempotent: building a plan again after applying it yields no changes.
+package bulk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EditKind is the kind of change an Edit makes to each target.
+type EditKind string
+
+const (
+	// EditSet sets a value at a dotted path.
+	EditSet EditKind = "set"
+	// EditAddLabel adds an ArgoCD cluster label to vClusters.
+	EditAddLabel EditKind = "add-label"
+	// EditBumpChartVersion sets the chart defaultVersion of workload and
+	// addon entries.
+	EditBumpChartVersion EditKind = "bump-chart-version"
+)
+
+// Files a set edit can change, chosen with its "in" field.
+const (
+	// InValues is the target's values.yaml (workloads and addons).
+	InValues = "values"
+	// InEntry is the target's addons.yaml entry (workloads and addons).
+	InEntry = "entry"
+	// InVCluster is the vCluster request under platform/vclusters.
+	InVCluster = "vcluster"
+)
+
+// File is a bulk plan file:
+//
+//	description: Move media workloads to chart 6.15.0
+//	selector:
+//	  clusters: ["vcluster-*"]
+//	  workloads: ["*"]
+//	edits:
+//	  - op: bump-chart-version
+//	    version: 6.15.0
+//	  - op: set
+//	    path: deployment.resources.requests.cpu
+//	    value: 100m
+//	    minimum: true
+//	  - op: add-label
+//	    key: team
+//	    value: media
+type File struct {
+	// Description is shown in the preview.
+	Description string   `yaml:"description,omitempty"`
+	Selector    Selector `yaml:"selector"`
+	Edits       []Edit   `yaml:"edits"`
+}
+
+// Selector picks the targets edits apply to. Every field is a list of
+// globs (path.Match syntax).
+type Selector struct {
+	// Clusters restricts workloads, cluster-layer addons and vClusters to
+	// matching clusters. Empty matches every cluster, and then addons at the
+	// environment and cluster-role layers are selected too.
+	Clusters []string `yaml:"clusters,omitempty"`
+	// Workloads selects enabled workloads under workloads/<cluster>.
+	Workloads []string `yaml:"workloads,omitempty"`
+	// Addons selects addon entries in the addons/ layers.
+	Addons []string `yaml:"addons,omitempty"`
+}
+
+// Edit is one structured change, applied to every selected target it
+// concerns: set with in "values" or "entry" and bump-chart-version to
+// workloads and addons, add-label and set with in "vcluster" to vClusters.
+type Edit struct {
+	Op EditKind `yaml:"op"`
+	// Path is the dotted key path a set edit writes, from the root of the
+	// file or, for in "entry", from the entry.
+	Path string `yaml:"path,omitempty"`
+	// Value is the string, integer or boolean to set, or the label value.
+	Value interface{} `yaml:"value,omitempty"`
+	// In is the file a set edit changes: values (default), entry or vcluster.
+	In string `yaml:"in,omitempty"`
+	// Minimum makes a set edit only raise the value: a current value that
+	// is already at least Value (compared as Kubernetes quantities) is kept.
+	Minimum bool `yaml:"minimum,omitempty"`
+	// Key is the label an add-label edit sets.
+	Key string `yaml:"key,omitempty"`
+	// Version is the chart version a bump-chart-version edit sets.
+	Version string `yaml:"version,omitempty"`
+}
+
+// String describes the edit in previews and skip reasons.
+func (e Edit) String() string {
+	switch e.Op {
+	case EditSet:
+		verb := "set"
+		if e.Minimum {
+			verb = "raise"
+		}
+		return fmt.Sprintf("%s %s %s=%v", verb, e.in(), e.Path, e.Value)
+	case EditAddLabel:
+		return fmt.Sprintf("add-label %s=%v", e.Key, e.Value)
+	case EditBumpChartVersion:
+		return "bump-chart-version " + e.Version
+	}
+	return string(e.Op)
+}
+
+func (e Edit) in() string {
+	if e.In == "" {
+		return InValues
+	}
+	return e.In
+}
+
+// targetsVClusters reports whether the edit applies to vClusters rather
+// than workloads and addons.
+func (e Edit) targetsVClusters() bool {
+	return e.Op == EditAddLabel || (e.Op == EditSet && e.in() == InVCluster)
+}
+
+// LoadFile reads and validates a plan file. Unknown fields are rejected so
+// a misspelled key fails instead of silently doing nothing.
+func LoadFile(file string) (*File, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, hcerrors.NewUserError("reading plan: %w", err)
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing plan %s: %w", file, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "plan %s: %w", file, err)
+	}
+	return &f, nil
+}
+
+// Validate checks the selector globs and every edit.
+func (f *File) Validate() error {
+	for _, globs := range [][]string{f.Selector.Clusters, f.Selector.Workloads, f.Selector.Addons} {
+		for _, g := range globs {
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("selector: invalid glob %q", g)
+			}
+		}
+	}
+	if len(f.Edits) == 0 {
+		return fmt.Errorf("no edits")
+	}
+	entryTargets := len(f.Selector.Workloads) > 0 || len(f.Selector.Addons) > 0
+	for i, e := range f.Edits {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("edits[%d]: %w", i, err)
+		}
+		if !e.targetsVClusters() && !entryTargets {
+			return fmt.Errorf("edits[%d]: %s applies to workloads and addons; the selector names neither", i, e.Op)
+		}
+	}
+	return nil
+}
+
+func (e Edit) validate() error {
+	switch e.Op {
+	case EditSet:
+		if e.Path == "" || strings.Contains(e.Path, "..") || strings.HasPrefix(e.Path, ".") || strings.HasSuffix(e.Path, ".") {
+			return fmt.Errorf("set: invalid path %q", e.Path)
+		}
+		switch e.in() {
+		case InValues, InEntry, InVCluster:
+		default:
+			return fmt.Errorf("set: in must be values, entry or vcluster, not %q", e.In)
+		}
+		if err := checkScalar(e.Value); err != nil {
+			return fmt.Errorf("set %s: %w", e.Path, err)
+		}
+		if _, isBool := e.Value.(bool); e.Minimum && isBool {
+			return fmt.Errorf("set %s: minimum needs a quantity, not %v", e.Path, e.Value)
+		}
+	case EditAddLabel:
+		if e.Key == "" {
+			return fmt.Errorf("add-label: key is required")
+		}
+		if err := checkScalar(e.Value); err != nil {
+			return fmt.Errorf("add-label %s: %w", e.Key, err)
+		}
+	case EditBumpChartVersion:
+		if e.Version == "" {
+			return fmt.Errorf("bump-chart-version: version is required")
+		}
+	default:
+		return fmt.Errorf("unknown op %q (expected set, add-label, or bump-chart-version)", e.Op)
+	}
+	return nil
+}
+
+// checkScalar accepts the value types the manifest editor writes.
+func checkScalar(v interface{}) error {
+	switch v.(type) {
+	case string, int, bool:
+		return nil
+	case nil:
+		return fmt.Errorf("value is required")
+	}
+	return fmt.Errorf("value must be a string, integer or boolean, not %T", v)
+}
@@ -0,0 +1,202 @@
+package bulk
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// TargetKind is the kind of thing a Target is.
+type TargetKind string
+
+const (
+	TargetWorkload TargetKind = "workload"
+	TargetAddon    TargetKind = "addon"
+	TargetVCluster TargetKind = "vcluster"
+)
+
+// Target is one workload, addon entry or vCluster the selector matched.
+type Target struct {
+	Kind TargetKind
+	Name string
+	// Cluster is the cluster the target belongs to. It is empty for addons
+	// at the environment and cluster-role layers, which reach many clusters.
+	Cluster string
+	// Layer is the addon layer of an addon target.
+	Layer addon.Layer
+
+	// manifest is a vCluster target's request file.
+	manifest string
+}
+
+func (t Target) String() string {
+	switch t.Kind {
+	case TargetWorkload:
+		return "workload " + t.Cluster + "/" + t.Name
+	case TargetAddon:
+		return "addon " + t.Layer.String() + "/" + t.Name
+	}
+	return "vcluster " + t.Name
+}
+
+// entryFile returns the addons.yaml holding the target's entry.
+func (t Target) entryFile(repoPath string) string {
+	if t.Kind == TargetWorkload {
+		return repopath.Abs(repoPath, deploylib.AddonsPath(t.Cluster))
+	}
+	return t.Layer.AddonsFile(repoPath)
+}
+
+// valuesFile returns the target's values.yaml.
+func (t Target) valuesFile(repoPath string) string {
+	if t.Kind == TargetWorkload {
+		return repopath.Abs(repoPath, layout.Active().ValuesFile(t.Cluster, t.Name))
+	}
+	return filepath.Join(t.Layer.ValuesDir(repoPath, t.Name), "values.yaml")
+}
+
+// Select returns the targets sel matches: enabled workloads, addon entries
+// and vCluster requests, in that order and sorted within each kind.
+// vClusters are always candidates, filtered by the cluster globs; whether an
+// edit applies to them is up to the edit.
+func Select(repoPath string, sel Selector) ([]Target, error) {
+	var targets []Target
+	if len(sel.Workloads) > 0 {
+		ws, err := selectWorkloads(repoPath, sel)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, ws...)
+	}
+	if len(sel.Addons) > 0 {
+		as, err := selectAddons(repoPath, sel)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, as...)
+	}
+	vs, err := selectVClusters(repoPath, sel)
+	if err != nil {
+		return nil, err
+	}
+	return append(targets, vs...), nil
+}
+
+func selectWorkloads(repoPath string, sel Selector) ([]Target, error) {
+	clusters, err := layout.Active().Clusters(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	for _, cluster := range clusters {
+		if !matchAny(sel.Clusters, cluster, true) {
+			continue
+		}
+		names, err := deploylib.ListWorkloads(repoPath, cluster)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if matchAny(sel.Workloads, name, false) {
+				targets = append(targets, Target{Kind: TargetWorkload, Name: name, Cluster: cluster})
+			}
+		}
+	}
+	return targets, nil
+}
+
+// selectAddons matches addon entries. A cluster selector limits them to the
+// matching cluster layers; without one, every layer is searched.
+func selectAddons(repoPath string, sel Selector) ([]Target, error) {
+	layers, err := addon.DiscoverLayers(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	for _, l := range layers {
+		cluster := ""
+		if l.Kind == addon.LayerCluster {
+			cluster = l.Name
+		} else if len(sel.Clusters) > 0 {
+			continue
+		}
+		if cluster != "" && !matchAny(sel.Clusters, cluster, true) {
+			continue
+		}
+		entries, err := addon.ReadEntries(l.AddonsFile(repoPath))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(entries))
+		for name, entry := range entries {
+			// Shared defaults such as globalSelectors are not addons.
+			if _, ok := entry["enabled"]; ok && matchAny(sel.Addons, name, false) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			targets = append(targets, Target{Kind: TargetAddon, Name: name, Cluster: cluster, Layer: l})
+		}
+	}
+	return targets, nil
+}
+
+func selectVClusters(repoPath string, sel Selector) ([]Target, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var doc struct {
+			Kind string `yaml:"kind"`
+			Spec struct {
+				Name string `yaml:"name"`
+			} `yaml:"spec"`
+		}
+		if yaml.Unmarshal(data, &doc) != nil || doc.Kind != "VClusterOrchestratorV2" {
+			continue
+		}
+		name := doc.Spec.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(f), ".yaml")
+		}
+		if matchAny(sel.Clusters, name, true) {
+			targets = append(targets, Target{Kind: TargetVCluster, Name: name, Cluster: name, manifest: f})
+		}
+	}
+	return targets, nil
+}
+
+// matchAny reports whether name matches one of globs. An empty list matches
+// everything when emptyMatches is set and nothing otherwise.
+func matchAny(globs []string, name string, emptyMatches bool) bool {
+	if len(globs) == 0 {
+		return emptyMatches
+	}
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
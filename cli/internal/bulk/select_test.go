@@ -0,0 +1,123 @@
+package bulk
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func targetNames(targets []Target) []string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.String())
+	}
+	return names
+}
+
+func TestSelect(t *testing.T) {
+	repo := newTestRepo(t)
+	tests := []struct {
+		name string
+		sel  Selector
+		want []string
+	}{
+		{
+			name: "all workloads",
+			sel:  Selector{Workloads: []string{"*"}},
+			want: []string{
+				"workload vcluster-dev/whoami",
+				"workload vcluster-media/radarr",
+				"workload vcluster-media/sonarr",
+				"vcluster vcluster-dev",
+				"vcluster vcluster-media",
+			},
+		},
+		{
+			name: "cluster and name globs",
+			sel:  Selector{Clusters: []string{"*-media"}, Workloads: []string{"s*", "whoami"}},
+			want: []string{"workload vcluster-media/sonarr", "vcluster vcluster-media"},
+		},
+		{
+			name: "addons in every layer without a cluster selector",
+			sel:  Selector{Addons: []string{"loki"}},
+			want: []string{
+				"addon environment/production/loki",
+				"addon cluster/vcluster-media/loki",
+				"vcluster vcluster-dev",
+				"vcluster vcluster-media",
+			},
+		},
+		{
+			name: "addons in cluster layers only with a cluster selector",
+			sel:  Selector{Clusters: []string{"vcluster-*"}, Addons: []string{"loki"}},
+			want: []string{
+				"addon cluster/vcluster-media/loki",
+				"vcluster vcluster-dev",
+				"vcluster vcluster-media",
+			},
+		},
+		{
+			name: "disabled entries and shared defaults are not targets",
+			sel:  Selector{Workloads: []string{"old", "mediaAppDefaults", "globalSelectors"}, Clusters: []string{"none"}},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := Select(repo, tt.sel)
+			if err != nil {
+				t.Fatalf("Select: %v", err)
+			}
+			if got := targetNames(targets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectSkipsOtherManifests(t *testing.T) {
+	repo := newTestRepo(t)
+	writeTestFile(t, filepath.Join(repo, "platform/vclusters/00-namespace.yaml"), "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: platform-requests\n")
+	targets, err := Select(repo, Selector{Clusters: []string{"*"}})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if got := strings.Join(targetNames(targets), ","); got != "vcluster vcluster-dev,vcluster vcluster-media" {
+		t.Errorf("Select() = %s", got)
+	}
+}
+
+func TestLoadFileValidation(t *testing.T) {
+	tests := []struct {
+		name, plan, wantErr string
+	}{
+		{"unknown field", "selector: {}\nedits:\n  - op: set\n    pth: a\n", "field pth not found"},
+		{"no edits", "selector:\n  workloads: [\"*\"]\n", "no edits"},
+		{"unknown op", "selector:\n  workloads: [\"*\"]\nedits:\n  - op: delete\n", "unknown op"},
+		{"bad glob", "selector:\n  clusters: [\"[\"]\nedits:\n  - op: add-label\n    key: a\n    value: b\n", "invalid glob"},
+		{"map value", "selector:\n  workloads: [\"*\"]\nedits:\n  - op: set\n    path: a\n    value: {b: c}\n", "must be a string"},
+		{"no entry selector", "selector:\n  clusters: [\"*\"]\nedits:\n  - op: bump-chart-version\n    version: 1.0.0\n", "names neither"},
+		{"bad path", "selector:\n  workloads: [\"*\"]\nedits:\n  - op: set\n    path: a..b\n    value: 1\n", "invalid path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.yaml")
+			writeTestFile(t, path, tt.plan)
+			_, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	writeTestFile(t, path, "selector:\n  clusters: [\"vcluster-*\"]\nedits:\n  - op: add-label\n    key: team\n    value: media\n")
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if len(f.Edits) != 1 || f.Edits[0].Op != EditAddLabel {
+		t.Errorf("edits = %+v", f.Edits)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,20 +18,48 @@ type Config struct {
 	DefaultCluster string `yaml:"defaultCluster,omitempty"`
 	// GitMode controls git behavior: "auto", "generate", or "prompt".
 	GitMode string `yaml:"gitMode"`
+	// GitSigning signs hctl's commits: "ssh", "gpg" or "off". Empty leaves
+	// it to git's commit.gpgsign. HCTL_GIT_SIGNING takes precedence.
+	GitSigning string `yaml:"gitSigning,omitempty"`
+	// GitSigningKey is the signing key: an SSH key file or a GPG key ID.
+	// Empty uses git's user.signingkey. HCTL_GIT_SIGNING_KEY takes
+	// precedence.
+	GitSigningKey string `yaml:"gitSigningKey,omitempty"`
+	// GitAuthor overrides git's user.name and user.email for hctl's
+	// commits. HCTL_GIT_AUTHOR_NAME and HCTL_GIT_AUTHOR_EMAIL take
+	// precedence.
+	GitAuthor GitAuthorConfig `yaml:"gitAuthor,omitempty"`
 	// ArgocdURL is the ArgoCD server URL.
 	ArgocdURL string `yaml:"argocdURL"`
 	// Interactive controls whether TUI wizards are used.
 	Interactive bool `yaml:"interactive"`
 	// KubeContext is the kubectl context to use.
 	KubeContext string `yaml:"kubeContext,omitempty"`
-	// OutputFormat controls output rendering: "text" (default), "json", or "yaml".
+	// OutputFormat controls output rendering: "text" (default), "wide", "json", or "yaml".
 	OutputFormat string `yaml:"outputFormat,omitempty"`
 	// Verbose enables debug-level output.
 	Verbose bool `yaml:"verbose,omitempty"`
+	// Debug enables debug-level diagnostic logs on stderr: resolved config,
+	// kube requests, git commands, provisioner inputs and outputs, and file
+	// writes. It implies Verbose.
+	Debug bool `yaml:"debug,omitempty"`
 	// Quiet suppresses informational output, showing only results and errors.
 	Quiet bool `yaml:"quiet,omitempty"`
+	// Team selects this user's rules in the repo's .hctl/policy.yaml.
+	Team string `yaml:"team,omitempty"`
+	// PolicyOverride is the --policy-override reason for this invocation.
+	PolicyOverride string `yaml:"-"`
+	// DryRun is --dry-run: mutating commands print their mutation plan (see
+	// internal/mutation) instead of executing it.
+	DryRun bool `yaml:"-"`
 	// Platform holds platform-specific settings.
 	Platform PlatformConfig `yaml:"platform"`
+	// OnePassword holds 1Password Connect settings used for secret pre-flight checks.
+	OnePassword OnePasswordConfig `yaml:"onePassword,omitempty"`
+	// Registry holds container registry settings used for image digest pinning.
+	Registry RegistryConfig `yaml:"registry,omitempty"`
+	// Versions holds settings for 'hctl versions'.
+	Versions VersionsConfig `yaml:"versions,omitempty"`
 }
 
 // PlatformConfig holds settings specific to the homelab platform.
@@ -45,6 +74,65 @@ type PlatformConfig struct {
 	PlatformNamespace string `yaml:"platformNamespace"`
 	// StateRepo is the kratix-platform-state repository URL.
 	StateRepo string `yaml:"stateRepo,omitempty"`
+	// NodePoolLabel is the node label key matched by the
+	// hctl.integratn.tech/node-pool workload annotation.
+	NodePoolLabel string `yaml:"nodePoolLabel,omitempty"`
+	// KubernetesVersion is the Kubernetes version deploys assume the target
+	// cluster runs (e.g. "v1.28.9"), which decides whether sidecar
+	// containers render as native sidecars. Empty reads the version the
+	// vcluster orchestrator recorded on the target vcluster.
+	KubernetesVersion string `yaml:"kubernetesVersion,omitempty"`
+	// Environments maps cluster names to their environment (dev, staging,
+	// prod, ...), over the integrations.argocd.environment their vCluster
+	// requests declare. Shared-class resources read the 1Password items of
+	// their cluster's environment.
+	Environments map[string]string `yaml:"environments,omitempty"`
+}
+
+// GitAuthorConfig is the identity hctl's commits are attributed to.
+type GitAuthorConfig struct {
+	Name  string `yaml:"name,omitempty"`
+	Email string `yaml:"email,omitempty"`
+}
+
+// OnePasswordConfig holds settings for reaching the 1Password Connect API.
+type OnePasswordConfig struct {
+	// ConnectHost is the 1Password Connect server URL.
+	ConnectHost string `yaml:"connectHost,omitempty"`
+	// ConnectToken is the Connect API token. OP_CONNECT_TOKEN takes precedence.
+	ConnectToken string `yaml:"connectToken,omitempty"`
+	// TokenSecret is the in-cluster Secret ("namespace/name") holding the
+	// Connect token under the "token" key, used when no token is configured.
+	TokenSecret string `yaml:"tokenSecret,omitempty"`
+	// Vault is the vault (name or ID) that platform ExternalSecrets resolve
+	// items from. OP_VAULT takes precedence.
+	Vault string `yaml:"vault,omitempty"`
+}
+
+// RegistryConfig holds settings for resolving image tags to digests.
+type RegistryConfig struct {
+	// PinDigests makes deploys pin images to their current digest unless a
+	// workload sets hctl.integratn.tech/pin-digest: "false".
+	PinDigests bool `yaml:"pinDigests,omitempty"`
+	// DockerConfig is the docker config.json read for registry credentials.
+	// Defaults to $DOCKER_CONFIG/config.json, then ~/.docker/config.json.
+	DockerConfig string `yaml:"dockerConfig,omitempty"`
+	// PullSecret is an in-cluster kubernetes.io/dockerconfigjson Secret
+	// ("namespace/name"), such as one synced by an ExternalSecret, used for
+	// registries the docker config has no credentials for.
+	PullSecret string `yaml:"pullSecret,omitempty"`
+}
+
+// VersionsConfig holds settings for comparing platform component versions
+// with their upstream releases.
+type VersionsConfig struct {
+	// PromiseReleases is the GitHub repository ("owner/name") whose latest
+	// release the promise pipeline images are compared with. Empty skips
+	// the lookup.
+	PromiseReleases string `yaml:"promiseReleases,omitempty"`
+	// Ignore hides components: a name, which may use * wildcards, or
+	// "name@version" to hide it only while that is the latest release.
+	Ignore []string `yaml:"ignore,omitempty"`
 }
 
 var (
@@ -63,6 +151,15 @@ func Default() *Config {
 			ClusterSubnet:     "10.0.4.0/24",
 			MetalLBPool:       "10.0.4.200-253",
 			PlatformNamespace: "platform-requests",
+			NodePoolLabel:     "platform.integratn.tech/node-pool",
+		},
+		OnePassword: OnePasswordConfig{
+			ConnectHost: "https://connect.integratn.tech",
+			TokenSecret: "external-secrets/eso-onepassword-token",
+			Vault:       "homelab",
+		},
+		Versions: VersionsConfig{
+			PromiseReleases: "JamesAtIntegratnIO/gitops_homelab_2_0",
 		},
 	}
 }
@@ -107,7 +204,7 @@ func Save(cfg *Config) error {
 		return err
 	}
 
-	data, err := yaml.Marshal(cfg)
+	data, err := Marshal(cfg)
 	if err != nil {
 		return err
 	}
@@ -115,6 +212,11 @@ func Save(cfg *Config) error {
 	return os.WriteFile(ConfigPath(), data, 0o644)
 }
 
+// Marshal returns cfg as Save writes it.
+func Marshal(cfg *Config) ([]byte, error) {
+	return yaml.Marshal(cfg)
+}
+
 // Set stores the active configuration.
 func Set(cfg *Config) {
 	mu.Lock()
@@ -122,6 +224,15 @@ func Set(cfg *Config) {
 	current = cfg
 }
 
+// RequireRepoPath returns a usage error if the gitops repo path is not configured.
+func (c *Config) RequireRepoPath() error {
+	if c.RepoPath == "" {
+		return hcerrors.NewUserError("repo path not set — run 'hctl init'").
+			WithRemediation("run 'hctl init' or set repoPath in " + ConfigPath())
+	}
+	return nil
+}
+
 // Get returns the active configuration.
 func Get() *Config {
 	mu.RLock()
@@ -156,12 +267,20 @@ func Validate(cfg *Config) []ValidationError {
 		errs = append(errs, ValidationError{"gitMode", fmt.Sprintf("invalid value %q — must be auto, prompt, generate, or stage-only", cfg.GitMode)})
 	}
 
+	// Check gitSigning
+	switch cfg.GitSigning {
+	case "", "ssh", "gpg", "off":
+		// valid
+	default:
+		errs = append(errs, ValidationError{"gitSigning", fmt.Sprintf("invalid value %q — must be ssh, gpg, or off", cfg.GitSigning)})
+	}
+
 	// Check outputFormat
 	switch cfg.OutputFormat {
-	case "", "text", "json", "yaml":
+	case "", "text", "wide", "json", "yaml":
 		// valid
 	default:
-		errs = append(errs, ValidationError{"outputFormat", fmt.Sprintf("invalid value %q — must be text, json, or yaml", cfg.OutputFormat)})
+		errs = append(errs, ValidationError{"outputFormat", fmt.Sprintf("invalid value %q — must be text, wide, json, or yaml", cfg.OutputFormat)})
 	}
 
 	// Check repoPath
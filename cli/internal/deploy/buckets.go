@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// CheckBucketCollisions fails when the result declares an s3 bucket that
+// another workload on the same cluster already declares in the repo, or
+// declares one bucket twice. Buckets are found by the bucket annotation on
+// the generated credentials ExternalSecrets.
+func CheckBucketCollisions(repoPath string, result *TranslateResult) error {
+	buckets := declaredBuckets(result.Values)
+	if len(buckets) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, b := range buckets {
+		if seen[b] {
+			return hcerrors.New(hcerrors.ErrValidation, "bucket %q is declared by two s3 resources of workload %q", b, result.WorkloadName).
+				WithRemediation("give each s3 resource its own params.bucketName")
+		}
+		seen[b] = true
+	}
+	if repoPath == "" {
+		return nil
+	}
+
+	addonsDir := repopath.Abs(repoPath, layout.Active().AddonsDir(result.TargetCluster))
+	entries, err := os.ReadDir(addonsDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", addonsDir, err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == result.WorkloadName {
+			continue
+		}
+		data, err := os.ReadFile(repopath.Abs(repoPath, translate.ValuesPath(result.TargetCluster, e.Name())))
+		if err != nil {
+			continue
+		}
+		values, err := parseValues(data)
+		if err != nil {
+			continue
+		}
+		for _, b := range declaredBuckets(values) {
+			if seen[b] {
+				return hcerrors.New(hcerrors.ErrValidation, "bucket %q is already declared by workload %q on cluster %s", b, e.Name(), result.TargetCluster).
+					WithRemediation("set params.bucketName to a bucket no other workload uses, or share credentials with class: external").
+					WithDetails(map[string]string{"bucket": b, "workload": e.Name()})
+			}
+		}
+	}
+	return nil
+}
+
+// declaredBuckets returns the buckets recorded on values' extraObjects,
+// sorted.
+func declaredBuckets(values map[string]interface{}) []string {
+	var buckets []string
+	objects, _ := values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		m, _ := obj.(map[string]interface{})
+		meta, _ := m["metadata"].(map[string]interface{})
+		annotations, _ := meta["annotations"].(map[string]interface{})
+		if b, ok := annotations[provisioners.BucketAnnotation].(string); ok && b != "" {
+			buckets = append(buckets, b)
+		}
+	}
+	sort.Strings(buckets)
+	return buckets
+}
@@ -0,0 +1,66 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+func translateS3(t *testing.T, workload, bucketParams string) *TranslateResult {
+	t.Helper()
+	w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: ` + workload + `
+containers:
+  web:
+    image: nginx:1.27
+resources:
+` + bucketParams))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "dev",
+		Registry: provisioners.NewRegistry(),
+		Chart:    translate.DefaultChart(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCheckBucketCollisions(t *testing.T) {
+	repo := t.TempDir()
+	media := `  media:
+    type: s3
+    params:
+      bucketName: shared-media
+`
+	if _, err := WriteResult(translateS3(t, "gallery", media), repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redeploying the workload that owns the bucket is fine.
+	if err := CheckBucketCollisions(repo, translateS3(t, "gallery", media)); err != nil {
+		t.Errorf("redeploying the owner: %v", err)
+	}
+
+	err := CheckBucketCollisions(repo, translateS3(t, "uploader", media))
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation || !strings.Contains(err.Error(), `declared by workload "gallery"`) {
+		t.Errorf("colliding bucket: err = %v (exit %d)", err, got)
+	}
+
+	// Default names include the workload, so they never collide.
+	if err := CheckBucketCollisions(repo, translateS3(t, "uploader", "  media:\n    type: s3\n")); err != nil {
+		t.Errorf("default bucket name: %v", err)
+	}
+
+	twice := media + "  archive:\n    type: s3\n    params:\n      bucketName: shared-media\n"
+	if err := CheckBucketCollisions("", translateS3(t, "gallery", twice)); err == nil || !strings.Contains(err.Error(), "two s3 resources") {
+		t.Errorf("bucket declared twice: err = %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+// The commit annotation names the gitops commit that last changed a
+// workload, so it can only be written after that commit exists: the deploy
+// commits the values without it, then StampCommit records HEAD in a
+// follow-up commit. Regenerating an unchanged workload keeps the recorded
+// commit (see carryCommit) rather than reporting it as a change.
+
+// StampCommit records sha as the commit annotation in result's generated
+// values file on disk. It returns the file's path, or "" when result has no
+// generated values file or the file already records a commit.
+func StampCommit(repoPath string, result *TranslateResult, sha string) (string, error) {
+	relPath := translate.ValuesPath(result.TargetCluster, result.WorkloadName)
+	if _, ok := result.Files[relPath]; !ok || sha == "" {
+		return "", nil
+	}
+	absPath := repopath.Abs(repoPath, relPath)
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", relPath, err)
+	}
+	body, _, stamped := translate.ParseGenerated(data)
+	if !stamped {
+		return "", nil
+	}
+	values, err := parseValues(body)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", relPath, err)
+	}
+	if translate.RecordedCommit(values) != "" {
+		return "", nil
+	}
+	out, err := withCommit(values, sha)
+	if err != nil {
+		return "", fmt.Errorf("stamping %s: %w", relPath, err)
+	}
+	if err := os.WriteFile(absPath, out, 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", relPath, err)
+	}
+	logging.L().Debug("wrote file", "path", absPath, "bytes", len(out), "commit", sha)
+	return relPath, nil
+}
+
+// carryCommit keeps the commit recorded in the on-disk values file when the
+// regenerated values differ from it only by that annotation, so an
+// unchanged workload stays unchanged.
+func carryCommit(repoPath string, result *TranslateResult) error {
+	if repoPath == "" {
+		return nil
+	}
+	relPath := translate.ValuesPath(result.TargetCluster, result.WorkloadName)
+	generated, ok := result.Files[relPath]
+	if !ok {
+		return nil
+	}
+	disk, err := os.ReadFile(repopath.Abs(repoPath, relPath))
+	if err != nil {
+		return nil
+	}
+	diskBody, recorded, stamped := translate.ParseGenerated(disk)
+	if !stamped || translate.ContentHash(diskBody) != recorded {
+		return nil
+	}
+	diskValues, err := parseValues(diskBody)
+	if err != nil {
+		return nil
+	}
+	sha := translate.RecordedCommit(diskValues)
+	if sha == "" {
+		return nil
+	}
+
+	body, _, _ := translate.ParseGenerated(generated)
+	values, err := parseValues(body)
+	if err != nil {
+		return fmt.Errorf("parsing generated %s: %w", relPath, err)
+	}
+	out, err := withCommit(values, sha)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(out, disk) {
+		result.Files[relPath] = out
+		translate.StampCommit(result.Values, sha)
+	}
+	return nil
+}
+
+// withCommit stamps sha on values and returns the stamped values file.
+func withCommit(values map[string]interface{}, sha string) ([]byte, error) {
+	translate.StampCommit(values, sha)
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling values: %w", err)
+	}
+	return translate.StampGenerated(out), nil
+}
+
+func parseValues(body []byte) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,68 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+func TestStampCommitAndCarry(t *testing.T) {
+	repo := t.TempDir()
+	result := translateHello(t)
+	if _, err := WriteResult(result, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	valuesPath := translate.ValuesPath("dev", "hello")
+	path, err := StampCommit(repo, result, "abc123")
+	if err != nil || path != valuesPath {
+		t.Fatalf("StampCommit() = %q, %v, want %s", path, err, valuesPath)
+	}
+	stamped, err := os.ReadFile(repopath.Abs(repo, valuesPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(stamped, []byte(translate.CommitAnnotation+": abc123")) {
+		t.Errorf("values file has no commit annotation:\n%s", stamped)
+	}
+
+	// Regenerating the unchanged workload keeps the recorded commit, so it
+	// is neither a change nor a manual edit, and is not stamped again.
+	again := translateHello(t)
+	if err := carryCommit(repo, again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Files[valuesPath], stamped) {
+		t.Errorf("regenerated values differ from the stamped file:\n%s", again.Files[valuesPath])
+	}
+	drift, err := DetectDrift(repo, valuesPath, again.Files[valuesPath])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drift.Manual || drift.SpecChanged {
+		t.Errorf("drift = manual %v, spec changed %v, want neither", drift.Manual, drift.SpecChanged)
+	}
+	if path, err := StampCommit(repo, again, "def456"); err != nil || path != "" {
+		t.Errorf("StampCommit() on a recorded commit = %q, %v, want no change", path, err)
+	}
+
+	// A real change drops the old commit, to be stamped after the next deploy
+	// commit.
+	changed := translateHello(t)
+	changed.Values["deployment"].(map[string]interface{})["replicas"] = 2
+	body, err := yaml.Marshal(changed.Values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed.Files[valuesPath] = translate.StampGenerated(body)
+	if err := carryCommit(repo, changed); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(changed.Files[valuesPath], []byte(translate.CommitAnnotation)) {
+		t.Error("changed values kept the old commit annotation")
+	}
+}
@@ -0,0 +1,449 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CompareConfigPath is the repo file listing the differences between
+// clusters that 'hctl deploy compare' treats as expected.
+const CompareConfigPath = ".hctl/compare.yaml"
+
+// Field statuses in a Comparison.
+const (
+	// FieldSame means every cluster has the same value.
+	FieldSame = "same"
+	// FieldExpected means the values differ only in per-cluster names, or
+	// the field is on the ignore list.
+	FieldExpected = "expected"
+	// FieldDifferent means the values differ unexpectedly.
+	FieldDifferent = "different"
+)
+
+// CompareConfig tunes which differences between clusters are expected.
+type CompareConfig struct {
+	// Ignore holds field path patterns whose differences are expected. "*"
+	// matches within one path segment and a "**" segment matches any
+	// number of segments, e.g. values.deployment.replicas or
+	// "**.annotations.*".
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Domains maps a cluster to its hostname domain. Values containing it
+	// compare with "<domain>" in its place.
+	Domains map[string]string `yaml:"domains,omitempty"`
+}
+
+// defaultCompareIgnore lists the fields expected to differ in every repo:
+// the commit each cluster was last deployed from.
+var defaultCompareIgnore = []string{`**.annotations."` + translate.CommitAnnotation + `"`}
+
+// LoadCompareConfig reads .hctl/compare.yaml from the repo. A missing file
+// yields an empty config.
+func LoadCompareConfig(repoPath string) (*CompareConfig, error) {
+	cfg := &CompareConfig{}
+	if repoPath == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(repopath.Abs(repoPath, CompareConfigPath))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", CompareConfigPath, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", CompareConfigPath, err).
+			WithDetails(map[string]string{"file": CompareConfigPath})
+	}
+	return cfg, nil
+}
+
+// CompareSource is one cluster's configuration of a workload.
+type CompareSource struct {
+	Cluster   string
+	Namespace string
+	// Values are the chart values.
+	Values map[string]interface{}
+	// Addons is the workload's addons.yaml entry; nil for live sources.
+	Addons map[string]interface{}
+}
+
+// RepoCompareSource reads a workload's committed values.yaml and addons.yaml
+// entry for cluster.
+func RepoCompareSource(repoPath, cluster, workload string) (*CompareSource, error) {
+	src := &CompareSource{Cluster: cluster, Namespace: workload}
+
+	addonsRel := AddonsPath(cluster)
+	data, err := os.ReadFile(repopath.Abs(repoPath, addonsRel))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", addonsRel, err)
+	}
+	var addons map[string]interface{}
+	if err := yaml.Unmarshal(data, &addons); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", addonsRel, err)
+	}
+	entry, ok := addons[workload].(map[string]interface{})
+	if !ok {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "workload %q not found in %s", workload, addonsRel).
+			WithRemediation("run 'hctl deploy list --cluster " + cluster + "' to see deployed workloads")
+	}
+	src.Addons = entry
+	if ns, ok := entry["namespace"].(string); ok && ns != "" {
+		src.Namespace = ns
+	}
+
+	valuesRel := translate.ValuesPath(cluster, workload)
+	data, err = os.ReadFile(repopath.Abs(repoPath, valuesRel))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", valuesRel, err)
+	}
+	body, _, _ := translate.ParseGenerated(data)
+	if src.Values, err = parseValues(body); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", valuesRel, err)
+	}
+	return src, nil
+}
+
+// LiveCompareSource reads the Helm values a workload's ArgoCD Application
+// is deployed with: its valuesObject, or its values string.
+func LiveCompareSource(app *unstructured.Unstructured, cluster string) (*CompareSource, error) {
+	src := &CompareSource{Cluster: cluster, Namespace: app.GetName()}
+	if ns, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace"); ns != "" {
+		src.Namespace = ns
+	}
+
+	helmSources := []map[string]interface{}{}
+	if h, ok, _ := unstructured.NestedMap(app.Object, "spec", "source", "helm"); ok {
+		helmSources = append(helmSources, h)
+	}
+	sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+	for _, s := range sources {
+		if h, ok, _ := unstructured.NestedMap(asMap(s), "helm"); ok {
+			helmSources = append(helmSources, h)
+		}
+	}
+	for _, h := range helmSources {
+		if v, ok := h["valuesObject"].(map[string]interface{}); ok {
+			src.Values = v
+			return src, nil
+		}
+		if v, ok := h["values"].(string); ok && v != "" {
+			values, err := parseValues([]byte(v))
+			if err != nil {
+				return nil, fmt.Errorf("parsing the helm values of Application %s: %w", app.GetName(), err)
+			}
+			src.Values = values
+			return src, nil
+		}
+	}
+	return src, nil
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// Fields flattens the source into leaf fields under "values" and "addons".
+func (s *CompareSource) Fields() map[string]string {
+	fields := ExtractFields("values", s.Values)
+	for k, v := range ExtractFields("addons", s.Addons) {
+		fields[k] = v
+	}
+	return fields
+}
+
+// ExtractFields flattens v into leaf values keyed by field path. Map keys
+// are joined with "." and quoted when they contain ".", "[" or `"`. List
+// items whose maps all carry a distinct name are keyed [name=<name>] so
+// reordering does not show up as a difference; other items are keyed by
+// index. Empty maps and lists are leaves rendered as {} and [].
+func ExtractFields(prefix string, v interface{}) map[string]string {
+	fields := map[string]string{}
+	var walk func(path []string, v interface{})
+	walk = func(path []string, v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if len(t) == 0 {
+				if len(path) > 1 {
+					fields[joinPath(path)] = "{}"
+				}
+				return
+			}
+			for k, child := range t {
+				walk(append(path[:len(path):len(path)], k), child)
+			}
+		case []interface{}:
+			if len(t) == 0 {
+				fields[joinPath(path)] = "[]"
+				return
+			}
+			keys := listKeys(t)
+			for i, child := range t {
+				walk(append(path[:len(path):len(path)], keys[i]), child)
+			}
+		case nil:
+			fields[joinPath(path)] = "null"
+		default:
+			fields[joinPath(path)] = fmt.Sprint(t)
+		}
+	}
+	walk([]string{prefix}, v)
+	return fields
+}
+
+// listKeys returns the path segment of each list item.
+func listKeys(items []interface{}) []string {
+	keys := make([]string, len(items))
+	seen := map[string]bool{}
+	for i, item := range items {
+		name, _ := asMap(item)["name"].(string)
+		if name == "" || seen[name] {
+			for i := range items {
+				keys[i] = fmt.Sprintf("[%d]", i)
+			}
+			return keys
+		}
+		seen[name] = true
+		keys[i] = "[name=" + name + "]"
+	}
+	return keys
+}
+
+// joinPath renders path segments as a field path.
+func joinPath(segments []string) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "["):
+			b.WriteString(seg)
+			continue
+		case i > 0:
+			b.WriteByte('.')
+		}
+		if strings.ContainsAny(seg, `.["`) {
+			seg = `"` + strings.ReplaceAll(seg, `"`, `\"`) + `"`
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// splitPath parses a field path, or an ignore pattern, into segments.
+func splitPath(p string) []string {
+	var segments []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			segments = append(segments, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '"':
+			for i++; i < len(p) && p[i] != '"'; i++ {
+				if p[i] == '\\' && i+1 < len(p) {
+					i++
+				}
+				cur.WriteByte(p[i])
+			}
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				end = len(p) - i - 1
+			}
+			segments = append(segments, p[i:i+end+1])
+			i += end
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return segments
+}
+
+// MatchField reports whether the field path matches the ignore pattern.
+func MatchField(pattern, field string) bool {
+	return matchSegments(splitPath(pattern), splitPath(field))
+}
+
+func matchSegments(pattern, field []string) bool {
+	if len(pattern) == 0 {
+		return len(field) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(field); i++ {
+			if matchSegments(pattern[1:], field[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(field) == 0 || !globSegment(pattern[0], field[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], field[1:])
+}
+
+// globSegment matches one segment, where "*" matches any run of characters.
+func globSegment(pattern, segment string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == segment
+	}
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(segment)
+}
+
+// ClusterTokens are the per-cluster names expected to differ between
+// otherwise identical configurations.
+type ClusterTokens struct {
+	Cluster   string
+	Namespace string
+	Domain    string
+}
+
+// NormalizeFields replaces the cluster's tokens in field values with
+// <cluster>, <namespace> and <domain>. A token only matches as a whole
+// DNS label run, so namespace "web" leaves "webhook" alone. Longer tokens
+// are replaced first, so a domain containing the cluster name normalizes
+// as a domain.
+func NormalizeFields(fields map[string]string, t ClusterTokens) map[string]string {
+	type token struct{ value, placeholder string }
+	var tokens []token
+	for _, tok := range []token{{t.Domain, "<domain>"}, {t.Cluster, "<cluster>"}, {t.Namespace, "<namespace>"}} {
+		if tok.value != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	sort.SliceStable(tokens, func(i, j int) bool { return len(tokens[i].value) > len(tokens[j].value) })
+
+	res := make([]*regexp.Regexp, len(tokens))
+	for i, tok := range tokens {
+		res[i] = regexp.MustCompile(`(^|[^A-Za-z0-9-])` + regexp.QuoteMeta(tok.value) + `($|[^A-Za-z0-9-])`)
+	}
+	out := make(map[string]string, len(fields))
+	for path, v := range fields {
+		for i, tok := range tokens {
+			v = res[i].ReplaceAllString(v, "${1}"+tok.placeholder+"${2}")
+		}
+		out[path] = v
+	}
+	return out
+}
+
+// FieldComparison is one field across the compared clusters.
+type FieldComparison struct {
+	Field string `json:"field" yaml:"field"`
+	// Values holds each cluster's value, in cluster order; nil when unset.
+	Values []*string `json:"values" yaml:"values"`
+	Status string    `json:"status" yaml:"status"`
+}
+
+// Comparison is a field-level comparison of one workload across clusters.
+type Comparison struct {
+	Workload string            `json:"workload" yaml:"workload"`
+	Clusters []string          `json:"clusters" yaml:"clusters"`
+	Fields   []FieldComparison `json:"fields" yaml:"fields"`
+	// Unexpected counts the fields with status different.
+	Unexpected int `json:"unexpected" yaml:"unexpected"`
+	// Diffs holds, for each cluster after the first, the normalized
+	// unexpected differences against the first cluster.
+	Diffs map[string][]Hunk `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+}
+
+// CompareSources compares the sources field by field. Values are compared
+// after normalizing each cluster's tokens; fields matching an ignore
+// pattern, built in or from cfg, are expected to differ.
+func CompareSources(workload string, sources []*CompareSource, cfg *CompareConfig) *Comparison {
+	cmp := &Comparison{Workload: workload, Diffs: map[string][]Hunk{}}
+	ignore := append(append([]string{}, defaultCompareIgnore...), cfg.Ignore...)
+
+	raw := make([]map[string]string, len(sources))
+	norm := make([]map[string]string, len(sources))
+	paths := map[string]bool{}
+	for i, src := range sources {
+		cmp.Clusters = append(cmp.Clusters, src.Cluster)
+		raw[i] = src.Fields()
+		norm[i] = NormalizeFields(raw[i], ClusterTokens{
+			Cluster:   src.Cluster,
+			Namespace: src.Namespace,
+			Domain:    cfg.Domains[src.Cluster],
+		})
+		for p := range raw[i] {
+			paths[p] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	diffText := make([]strings.Builder, len(sources))
+	for _, p := range sorted {
+		fc := FieldComparison{Field: p, Status: FieldSame}
+		for i := range sources {
+			if v, ok := raw[i][p]; ok {
+				fc.Values = append(fc.Values, &v)
+			} else {
+				fc.Values = append(fc.Values, nil)
+			}
+		}
+		switch {
+		case allEqual(raw, p):
+		case ignored(ignore, p) || allEqual(norm, p):
+			fc.Status = FieldExpected
+		default:
+			fc.Status = FieldDifferent
+			cmp.Unexpected++
+			for i := range sources {
+				if v, ok := norm[i][p]; ok {
+					fmt.Fprintf(&diffText[i], "%s: %s\n", p, v)
+				}
+			}
+		}
+		cmp.Fields = append(cmp.Fields, fc)
+	}
+	for i := 1; i < len(sources); i++ {
+		if hunks := DiffLines(diffText[0].String(), diffText[i].String()); len(hunks) > 0 {
+			cmp.Diffs[sources[i].Cluster] = hunks
+		}
+	}
+	return cmp
+}
+
+// allEqual reports whether every field set has the same value, or is unset,
+// at path.
+func allEqual(sets []map[string]string, path string) bool {
+	first, firstOK := sets[0][path]
+	for _, set := range sets[1:] {
+		if v, ok := set[path]; ok != firstOK || v != first {
+			return false
+		}
+	}
+	return true
+}
+
+func ignored(patterns []string, field string) bool {
+	for _, p := range patterns {
+		if MatchField(p, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// compareFixture is a repo with web deployed to vcluster-dev and
+// vcluster-prod. The clusters differ benignly (namespace, hostname domain,
+// cluster label, commit, env order, ignored replicas) and meaningfully
+// (image tag, LOG_LEVEL, memory limit).
+const compareFixture = "testdata/compare"
+
+func loadFixtureComparison(t *testing.T) *Comparison {
+	t.Helper()
+	cfg, err := LoadCompareConfig(compareFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []*CompareSource
+	for _, cluster := range []string{"vcluster-dev", "vcluster-prod"} {
+		src, err := RepoCompareSource(compareFixture, cluster, "web")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, src)
+	}
+	return CompareSources("web", sources, cfg)
+}
+
+func TestCompareSourcesFixture(t *testing.T) {
+	cmp := loadFixtureComparison(t)
+
+	status := map[string]string{}
+	for _, f := range cmp.Fields {
+		status[f.Field] = f.Status
+	}
+	want := map[string]string{
+		"values.applicationName":                                               FieldSame,
+		"values.deployment.image.repository":                                   FieldSame,
+		"values.deployment.image.tag":                                          FieldDifferent,
+		"values.deployment.resources.limits.memory":                            FieldDifferent,
+		"values.deployment.containers[name=web].env[name=LOG_LEVEL].value":     FieldDifferent,
+		"values.deployment.containers[name=web].env[name=DATABASE_HOST].value": FieldExpected,
+		"values.deployment.replicas":                                           FieldExpected,
+		`values.deployment.annotations."hctl.integratn.tech/commit"`:           FieldExpected,
+		`values.deployment.labels."hctl.integratn.tech/cluster"`:               FieldExpected,
+		"values.httpRoute.hostnames[0]":                                        FieldExpected,
+		"addons.namespace":                                                     FieldExpected,
+		"addons.chartName":                                                     FieldSame,
+		"addons.enabled":                                                       FieldSame,
+	}
+	for field, s := range want {
+		if status[field] != s {
+			t.Errorf("%s: status %q, want %q", field, status[field], s)
+		}
+	}
+	if cmp.Unexpected != 3 {
+		t.Errorf("unexpected = %d, want 3 (image tag, LOG_LEVEL, memory)", cmp.Unexpected)
+	}
+
+	diff := hunkLines(cmp.Diffs["vcluster-prod"])
+	for _, line := range []string{
+		"-values.deployment.image.tag: 1.4.2",
+		"+values.deployment.image.tag: 1.4.0",
+		"+values.deployment.resources.limits.memory: 1Gi",
+	} {
+		if !strings.Contains(diff, line) {
+			t.Errorf("diff missing %q:\n%s", line, diff)
+		}
+	}
+	if strings.Contains(diff, "hostnames") || strings.Contains(diff, "namespace") {
+		t.Errorf("diff includes expected differences:\n%s", diff)
+	}
+}
+
+func TestCompareSourcesIdenticalAfterNormalizing(t *testing.T) {
+	values := func(domain string) map[string]interface{} {
+		return map[string]interface{}{
+			"httpRoute": map[string]interface{}{"hostnames": []interface{}{"web." + domain}},
+			"image":     "nginx:1.27",
+		}
+	}
+	cmp := CompareSources("web", []*CompareSource{
+		{Cluster: "dev", Namespace: "web", Values: values("dev.example.com")},
+		{Cluster: "prod", Namespace: "web", Values: values("example.com")},
+	}, &CompareConfig{Domains: map[string]string{"dev": "dev.example.com", "prod": "example.com"}})
+	if cmp.Unexpected != 0 || len(cmp.Diffs) != 0 {
+		t.Errorf("unexpected = %d, diffs = %v; want none", cmp.Unexpected, cmp.Diffs)
+	}
+}
+
+func TestExtractFields(t *testing.T) {
+	fields := ExtractFields("values", map[string]interface{}{
+		"replicas": 2,
+		"env": []interface{}{
+			map[string]interface{}{"name": "B", "value": "2"},
+			map[string]interface{}{"name": "A", "value": "1"},
+		},
+		"args":        []interface{}{"--port", 8080},
+		"annotations": map[string]interface{}{"example.com/team": "web"},
+		"tolerations": []interface{}{},
+		"podLabels":   map[string]interface{}{},
+		"command":     nil,
+	})
+	want := map[string]string{
+		"values.replicas":                       "2",
+		"values.env[name=A].value":              "1",
+		"values.env[name=A].name":               "A",
+		"values.env[name=B].value":              "2",
+		"values.env[name=B].name":               "B",
+		"values.args[0]":                        "--port",
+		"values.args[1]":                        "8080",
+		`values.annotations."example.com/team"`: "web",
+		"values.tolerations":                    "[]",
+		"values.podLabels":                      "{}",
+		"values.command":                        "null",
+	}
+	if len(fields) != len(want) {
+		t.Errorf("got %d fields, want %d: %v", len(fields), len(want), fields)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %q, want %q", k, fields[k], v)
+		}
+	}
+}
+
+func TestNormalizeFields(t *testing.T) {
+	got := NormalizeFields(map[string]string{
+		"host":    "web.dev.example.com",
+		"db":      "db.shop.svc.cluster.local",
+		"hook":    "shopping",
+		"cluster": "dev",
+		"label":   "team-dev-ops",
+	}, ClusterTokens{Cluster: "dev", Namespace: "shop", Domain: "dev.example.com"})
+	want := map[string]string{
+		"host":    "web.<domain>",
+		"db":      "db.<namespace>.svc.cluster.local",
+		"hook":    "shopping",
+		"cluster": "<cluster>",
+		"label":   "team-dev-ops",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestMatchField(t *testing.T) {
+	tests := []struct {
+		pattern, field string
+		want           bool
+	}{
+		{"values.deployment.replicas", "values.deployment.replicas", true},
+		{"values.deployment.replicas", "values.deployment.replicas.x", false},
+		{"values.*.replicas", "values.statefulset.replicas", true},
+		{"**.annotations.*", `values.deployment.annotations."example.com/team"`, true},
+		{`**.annotations."hctl.integratn.tech/commit"`, `values.deployment.annotations."hctl.integratn.tech/commit"`, true},
+		{"values.env[*].value", "values.env[name=A].value", true},
+		{"values.env[0].value", "values.env[name=A].value", false},
+		{"values.image*", "values.imagePullPolicy", true},
+		{"values.image*", "values.imagePullSecrets[0]", false},
+	}
+	for _, tt := range tests {
+		if got := MatchField(tt.pattern, tt.field); got != tt.want {
+			t.Errorf("MatchField(%q, %q) = %v, want %v", tt.pattern, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestLiveCompareSource(t *testing.T) {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "vcluster-dev-web"},
+		"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"namespace": "web-dev"},
+			"sources": []interface{}{
+				map[string]interface{}{"repoURL": "https://example.com/charts"},
+				map[string]interface{}{"helm": map[string]interface{}{
+					"valuesObject": map[string]interface{}{"replicas": int64(2)},
+				}},
+			},
+		},
+	}}
+	src, err := LiveCompareSource(app, "vcluster-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Namespace != "web-dev" || src.Values["replicas"] != int64(2) || src.Addons != nil {
+		t.Errorf("source = %+v", src)
+	}
+
+	app.Object["spec"] = map[string]interface{}{
+		"source": map[string]interface{}{"helm": map[string]interface{}{"values": "replicas: 3\n"}},
+	}
+	if src, err = LiveCompareSource(app, "vcluster-dev"); err != nil {
+		t.Fatal(err)
+	}
+	if src.Values["replicas"] != 3 {
+		t.Errorf("values from the helm values string = %v", src.Values)
+	}
+}
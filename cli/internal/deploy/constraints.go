@@ -0,0 +1,271 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Validate checks a workload against the platform's constraints before it
+// is translated, so what would only fail once deployed fails first: route
+// hosts outside the platform domain, resource quantities that do not parse,
+// references to resources the workload does not declare, containers
+// without an image, and pods requesting more than the target vCluster's
+// quota. Each problem names the Score field it is about; errors stop a
+// deploy, warnings do not. Problems are sorted by field.
+func Validate(w *score.Workload, cluster string) []score.Problem {
+	cfg := config.Get()
+	var problems []score.Problem
+	problems = append(problems, routeHostProblems(w, cfg.Platform.Domain)...)
+	problems = append(problems, quantityProblems(w)...)
+	problems = append(problems, referenceProblems(w)...)
+	problems = append(problems, imageProblems(w)...)
+	if cfg.RepoPath != "" {
+		problems = append(problems, quotaProblems(w, cfg.RepoPath, targetCluster(w, cluster, cfg))...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return problems
+}
+
+// ConstraintsError is the error of a workload Validate found errors in. It
+// holds every problem found, errors first, so they can be reported
+// together.
+type ConstraintsError struct {
+	Problems []score.Problem
+}
+
+func (e *ConstraintsError) Error() string {
+	var errs int
+	for _, p := range e.Problems {
+		if p.Severity == score.SeverityError {
+			errs++
+		}
+	}
+	if errs == 0 {
+		return "platform constraints not met"
+	}
+	msg := e.Problems[0].Describe()
+	if errs > 1 {
+		msg += fmt.Sprintf(" (and %d more errors)", errs-1)
+	}
+	return msg
+}
+
+// CheckConstraints returns an ErrValidation error wrapping a
+// *ConstraintsError when one of problems is an error, and nil otherwise.
+func CheckConstraints(problems []score.Problem) error {
+	sorted := make([]score.Problem, 0, len(problems))
+	for _, p := range problems {
+		if p.Severity == score.SeverityError {
+			sorted = append(sorted, p)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	for _, p := range problems {
+		if p.Severity != score.SeverityError {
+			sorted = append(sorted, p)
+		}
+	}
+	return hcerrors.New(hcerrors.ErrValidation, "%w", &ConstraintsError{Problems: sorted}).
+		WithRemediation("fix score.yaml, or pass --no-validate to translate it anyway").
+		WithDetails(map[string]any{"field": sorted[0].Field, "problems": sorted})
+}
+
+// routeHostProblems reports route hosts outside domain, which the
+// platform's gateway and certificates do not serve.
+func routeHostProblems(w *score.Workload, domain string) []score.Problem {
+	if domain == "" {
+		return nil
+	}
+	var problems []score.Problem
+	for _, name := range sortedResourceNames(w, "route") {
+		host, _ := w.Resources[name].Params["host"].(string)
+		if host == "" || host == domain || strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		problems = append(problems, score.Problem{
+			Severity: score.SeverityError,
+			Field:    "resources." + name + ".params.host",
+			Message:  fmt.Sprintf("host %q is not under the platform domain %q", host, domain),
+		})
+	}
+	return problems
+}
+
+// quantityProblems reports container requests and limits, and volume
+// sizes, that are not Kubernetes quantities.
+func quantityProblems(w *score.Workload) []score.Problem {
+	var problems []score.Problem
+	check := func(field, v string) {
+		if _, err := k8sresource.ParseQuantity(v); err != nil {
+			problems = append(problems, score.Problem{
+				Severity: score.SeverityError,
+				Field:    field,
+				Message:  fmt.Sprintf("%q is not a valid quantity", v),
+			})
+		}
+	}
+	for _, cname := range sortedContainerNames(w) {
+		r := w.Containers[cname].Resources
+		if r == nil {
+			continue
+		}
+		for _, list := range []struct {
+			kind   string
+			values map[string]string
+		}{{"requests", r.Requests}, {"limits", r.Limits}} {
+			for _, k := range sortedKeys(list.values) {
+				check(fmt.Sprintf("containers.%s.resources.%s.%s", cname, list.kind, k), list.values[k])
+			}
+		}
+	}
+	for _, name := range sortedResourceNames(w, "volume") {
+		if size, ok := w.Resources[name].Params["size"]; ok {
+			check("resources."+name+".params.size", fmt.Sprint(size))
+		}
+	}
+	return problems
+}
+
+// referenceProblems reports ${resources.<name>.<output>} references in
+// container variables, commands, arguments and file contents to resources
+// the workload does not declare, unless listed as external. The content of
+// noExpand files is not resolved, so it is not checked.
+func referenceProblems(w *score.Workload) []score.Problem {
+	var problems []score.Problem
+	check := func(field, s string) {
+		for _, m := range translate.ResourceReferences(s) {
+			if _, ok := w.Resources[m[0]]; ok || translate.IsExternalReference(w, m[0], m[1]) {
+				continue
+			}
+			problems = append(problems, score.Problem{
+				Severity: score.SeverityError,
+				Field:    field,
+				Message:  fmt.Sprintf("reference ${resources.%s.%s}: no resource %q is declared", m[0], m[1], m[0]),
+			})
+		}
+	}
+	for _, cname := range sortedContainerNames(w) {
+		c := w.Containers[cname]
+		prefix := "containers." + cname
+		for _, v := range sortedKeys(c.Variables) {
+			check(prefix+".variables."+v, c.Variables[v])
+		}
+		for i, s := range c.Command {
+			check(fmt.Sprintf("%s.command[%d]", prefix, i), s)
+		}
+		for i, s := range c.Args {
+			check(fmt.Sprintf("%s.args[%d]", prefix, i), s)
+		}
+		for _, path := range sortedKeys(c.Files) {
+			if !c.Files[path].NoExpand {
+				check(prefix+".files."+path+".content", c.Files[path].Content)
+			}
+		}
+	}
+	return problems
+}
+
+// imageProblems reports containers without an image, "." images without
+// the build annotation saying where they are built, and untagged images,
+// which the chart deploys as :latest.
+func imageProblems(w *score.Workload) []score.Problem {
+	var problems []score.Problem
+	for _, cname := range sortedContainerNames(w) {
+		image := w.Containers[cname].Image
+		field := "containers." + cname + ".image"
+		switch {
+		case image == "":
+			problems = append(problems, score.Problem{Severity: score.SeverityError, Field: field, Message: "no image is set"})
+		case image == ".":
+			if w.Metadata.Annotations[translate.BuildAnnotation] == "" {
+				problems = append(problems, score.Problem{
+					Severity: score.SeverityError,
+					Field:    field,
+					Message:  fmt.Sprintf(`image "." is built elsewhere, but the workload has no %s annotation saying where`, translate.BuildAnnotation),
+				})
+			}
+		case !strings.Contains(image, "@") && !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":"):
+			problems = append(problems, score.Problem{
+				Severity: score.SeverityWarning,
+				Field:    field,
+				Message:  fmt.Sprintf("image %q has no tag and deploys as :latest, which changes under the workload", image),
+			})
+		}
+	}
+	return problems
+}
+
+// quotaProblems reports a pod whose containers together request more cpu or
+// memory than the ResourceQuota of the target vCluster, when its request in
+// platform/vclusters sets the quota explicitly. Such a pod never schedules.
+func quotaProblems(w *score.Workload, repoPath, cluster string) []score.Problem {
+	if cluster == "" {
+		return nil
+	}
+	data, err := os.ReadFile(repopath.Abs(repoPath, layout.VClusterManifest(cluster)))
+	if err != nil {
+		return nil
+	}
+	var vc platform.VClusterResource
+	if err := yaml.Unmarshal(data, &vc); err != nil || vc.Spec.VCluster.Quota == nil {
+		return nil
+	}
+	quota := vc.Spec.VCluster.Quota
+
+	var problems []score.Problem
+	for _, q := range []struct{ name, hard string }{{"cpu", quota.CPU}, {"memory", quota.Memory}} {
+		hard, err := k8sresource.ParseQuantity(q.hard)
+		if q.hard == "" || err != nil {
+			continue
+		}
+		total := k8sresource.Quantity{}
+		for _, cname := range sortedContainerNames(w) {
+			r := w.Containers[cname].Resources
+			if r == nil {
+				continue
+			}
+			if v, err := k8sresource.ParseQuantity(r.Requests[q.name]); err == nil {
+				total.Add(v)
+			}
+		}
+		if total.Cmp(hard) > 0 {
+			problems = append(problems, score.Problem{
+				Severity: score.SeverityError,
+				Field:    "containers",
+				Message:  fmt.Sprintf("the containers request %s %s together, more than the %s quota of vCluster %s", total.String(), q.name, q.hard, cluster),
+			})
+		}
+	}
+	return problems
+}
+
+func sortedContainerNames(w *score.Workload) []string {
+	return sortedKeys(w.Containers)
+}
+
+// sortedResourceNames returns the names of the workload's resources of
+// type typ, sorted.
+func sortedResourceNames(w *score.Workload, typ string) []string {
+	var names []string
+	for name, r := range w.Resources {
+		if r.Type == typ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,141 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func TestValidate(t *testing.T) {
+	repo := t.TempDir()
+	manifest := repopath.Abs(repo, layout.VClusterManifest("media"))
+	if err := os.MkdirAll(filepath.Dir(manifest), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vc := "apiVersion: platform.integratn.tech/v1alpha1\nkind: VClusterOrchestratorV2\nspec:\n  name: media\n  vcluster:\n    preset: dev\n    quota:\n      memory: 4Gi\n"
+	if err := os.WriteFile(manifest, []byte(vc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.RepoPath = repo
+	cfg.Platform.Domain = "example.com"
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+
+	w, err := score.Decode(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  web:
+    image: nginx
+    variables:
+      DB_HOST: ${resources.db.host}
+      CACHE: ${resources.cache.host}
+    args: ["--queue=${resources.queue.url}"]
+    resources:
+      requests:
+        memory: 3Gi
+        cpu: lots
+  worker:
+    image: "."
+    resources:
+      requests:
+        memory: 2Gi
+resources:
+  cache:
+    type: redis
+  site:
+    type: route
+    params:
+      host: shop.other.org
+      path: /
+      port: 80
+  api:
+    type: route
+    params:
+      host: api.example.com
+      path: /
+      port: 80
+  data:
+    type: volume
+    params:
+      size: ten-gigs
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := Validate(w, "media")
+	want := []score.Problem{
+		{Severity: score.SeverityError, Field: "containers", Message: "the containers request 5Gi memory together, more than the 4Gi quota of vCluster media"},
+		{Severity: score.SeverityError, Field: "containers.web.args[0]", Message: "reference ${resources.queue.url}: no resource \"queue\" is declared"},
+		{Severity: score.SeverityWarning, Field: "containers.web.image", Message: "image \"nginx\" has no tag and deploys as :latest, which changes under the workload"},
+		{Severity: score.SeverityError, Field: "containers.web.resources.requests.cpu", Message: "\"lots\" is not a valid quantity"},
+		{Severity: score.SeverityError, Field: "containers.web.variables.DB_HOST", Message: "reference ${resources.db.host}: no resource \"db\" is declared"},
+		{Severity: score.SeverityError, Field: "containers.worker.image", Message: "image \".\" is built elsewhere, but the workload has no hctl.integratn.tech/build annotation saying where"},
+		{Severity: score.SeverityError, Field: "resources.data.params.size", Message: "\"ten-gigs\" is not a valid quantity"},
+		{Severity: score.SeverityError, Field: "resources.site.params.host", Message: "host \"shop.other.org\" is not under the platform domain \"example.com\""},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Validate() = %d problems, want %d:\n%v", len(problems), len(want), problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("problem %d = %+v, want %+v", i, problems[i], want[i])
+		}
+	}
+
+	err = CheckConstraints(problems)
+	if hcerrors.ExitCode(err) != hcerrors.ExitValidation {
+		t.Fatalf("CheckConstraints() = %v, want a validation error", err)
+	}
+	if !strings.HasPrefix(err.Error(), "the containers request 5Gi memory") || !strings.HasSuffix(err.Error(), "(and 6 more errors)") {
+		t.Errorf("CheckConstraints() = %q", err)
+	}
+}
+
+func TestValidateAcceptsPlatformWorkload(t *testing.T) {
+	prev := config.Get()
+	config.Set(config.Default())
+	t.Cleanup(func() { config.Set(prev) })
+
+	w, err := score.Decode(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/build: ci
+    hctl.integratn.tech/external-references: vault.token
+containers:
+  web:
+    image: "."
+    variables:
+      TOKEN: ${resources.vault.token}
+      DB_HOST: ${resources.db.host}
+  proxy:
+    image: registry.local:5000/proxy@sha256:0123
+resources:
+  db:
+    type: postgres
+  web:
+    type: route
+    params:
+      host: shop.cluster.integratn.tech
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := Validate(w, ""); len(problems) != 0 {
+		t.Errorf("Validate() = %v, want no problems", problems)
+	}
+	if err := CheckConstraints(nil); err != nil {
+		t.Errorf("CheckConstraints(nil) = %v", err)
+	}
+}
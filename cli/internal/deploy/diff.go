@@ -0,0 +1,322 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hunk is a contiguous block of changed lines. Lines carry a "-" (removed)
+// or "+" (added) prefix; the hunks of UnifiedDiff also carry " " (context)
+// lines and "\ No newline at end of file" markers.
+type Hunk struct {
+	// OldStart and NewStart are 1-based line numbers where the hunk begins.
+	OldStart int
+	NewStart int
+	// OldLines and NewLines count the hunk's lines in each text.
+	OldLines int
+	NewLines int
+	Lines    []string
+}
+
+// Header renders the hunk's "@@ -a,b +c,d @@" position line. As in diff -u,
+// a side with no lines names the line before the hunk.
+func (h Hunk) Header() string {
+	oldStart, newStart := h.OldStart, h.NewStart
+	if h.OldLines == 0 {
+		oldStart--
+	}
+	if h.NewLines == 0 {
+		newStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, h.OldLines, newStart, h.NewLines)
+}
+
+// noNewline marks a last line that has no trailing newline.
+const noNewline = `\ No newline at end of file`
+
+// DiffLines returns the changed hunks between two texts, without context.
+// A missing trailing newline is not a change.
+func DiffLines(oldText, newText string) []Hunk {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	return hunks(a, b, editScript(a, b), 0, func(line string) []string { return []string{line} })
+}
+
+// UnifiedDiff returns the hunks of a unified diff between two texts, each
+// with up to context unchanged lines around its changes. A last line
+// without a trailing newline differs from the same line with one, and is
+// followed by a "\ No newline at end of file" marker.
+func UnifiedDiff(oldText, newText string, context int) []Hunk {
+	a := splitLinesKeepEnds(oldText)
+	b := splitLinesKeepEnds(newText)
+	return hunks(a, b, editScript(a, b), context, func(line string) []string {
+		if text, ok := strings.CutSuffix(line, "\n"); ok {
+			return []string{text}
+		}
+		return []string{line, noNewline}
+	})
+}
+
+// edit is one step of an edit script.
+type edit byte
+
+const (
+	editKeep   edit = ' '
+	editDelete edit = '-'
+	editInsert edit = '+'
+)
+
+// editScript returns the shortest edit script turning a into b, deletions
+// before insertions within each change.
+func editScript(a, b []string) []edit {
+	deleted, inserted := make([]bool, len(a)), make([]bool, len(b))
+
+	// A line only one side has is always an edit. Leaving such lines out of
+	// the search keeps rewritten files, where most lines are, linear.
+	inA, inB := make(map[string]bool, len(a)), make(map[string]bool, len(b))
+	for _, line := range a {
+		inA[line] = true
+	}
+	for _, line := range b {
+		inB[line] = true
+	}
+	var aIdx, bIdx []int
+	for i, line := range a {
+		if inB[line] {
+			aIdx = append(aIdx, i)
+		} else {
+			deleted[i] = true
+		}
+	}
+	for j, line := range b {
+		if inA[line] {
+			bIdx = append(bIdx, j)
+		} else {
+			inserted[j] = true
+		}
+	}
+	d := &differ{a: pick(a, aIdx), b: pick(b, bIdx), deleted: make([]bool, len(aIdx)), inserted: make([]bool, len(bIdx))}
+	d.compare(0, len(aIdx), 0, len(bIdx))
+	for i, del := range d.deleted {
+		deleted[aIdx[i]] = del
+	}
+	for j, ins := range d.inserted {
+		inserted[bIdx[j]] = ins
+	}
+
+	script := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && deleted[i]:
+			script = append(script, editDelete)
+			i++
+		case j < len(b) && inserted[j]:
+			script = append(script, editInsert)
+			j++
+		default:
+			script = append(script, editKeep)
+			i++
+			j++
+		}
+	}
+	return script
+}
+
+// hunks groups an edit script into hunks with up to context kept lines
+// around each change. render turns a line into the hunk lines that show it,
+// without the prefix.
+func hunks(a, b []string, script []edit, context int, render func(string) []string) []Hunk {
+	var out []Hunk
+	var cur *Hunk
+	// trailing counts the kept lines since the last change of cur.
+	trailing := 0
+	add := func(prefix edit, line string) {
+		for _, l := range render(line) {
+			if l == noNewline {
+				cur.Lines = append(cur.Lines, l)
+				continue
+			}
+			cur.Lines = append(cur.Lines, string(prefix)+l)
+		}
+	}
+
+	i, j := 0, 0
+	for n, e := range script {
+		if e == editKeep {
+			if cur != nil {
+				if trailing < context {
+					add(editKeep, a[i])
+					cur.OldLines++
+					cur.NewLines++
+					trailing++
+				} else if !changeWithin(script[n:], context) {
+					out = append(out, *cur)
+					cur = nil
+				} else {
+					add(editKeep, a[i])
+					cur.OldLines++
+					cur.NewLines++
+				}
+			}
+			i++
+			j++
+			continue
+		}
+
+		if cur == nil {
+			lead := min(context, i, j)
+			cur = &Hunk{OldStart: i - lead + 1, NewStart: j - lead + 1}
+			for k := lead; k > 0; k-- {
+				add(editKeep, a[i-k])
+				cur.OldLines++
+				cur.NewLines++
+			}
+		}
+		trailing = 0
+		if e == editDelete {
+			add(editDelete, a[i])
+			cur.OldLines++
+			i++
+		} else {
+			add(editInsert, b[j])
+			cur.NewLines++
+			j++
+		}
+	}
+	if cur != nil {
+		out = append(out, *cur)
+	}
+	return out
+}
+
+// changeWithin reports whether script has a change within the first
+// context+1 steps, close enough that the kept lines before it join the
+// current hunk rather than start a new one.
+func changeWithin(script []edit, context int) bool {
+	for n := 0; n < len(script) && n <= context; n++ {
+		if script[n] != editKeep {
+			return true
+		}
+	}
+	return false
+}
+
+// differ finds a shortest edit script with Myers' linear-space algorithm:
+// O((N+M)·D) time for texts of N and M lines that differ in D lines, and
+// O(N+M) memory, so large files with few changes stay cheap.
+type differ struct {
+	a, b     []string
+	deleted  []bool
+	inserted []bool
+}
+
+// compare marks the lines of a[aLo:aHi] deleted and b[bLo:bHi] inserted
+// that are not on a longest common subsequence.
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.inserted[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.deleted[i] = true
+		}
+	default:
+		x0, y0, x1, y1 := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x0, bLo, y0)
+		d.compare(x1, aHi, y1, bHi)
+	}
+}
+
+// middleSnake returns the start and end of the snake in the middle of a
+// shortest edit path from (aLo, bLo) to (aHi, bHi), found by searching
+// forward from the start and backward from the end until the two meet.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x0, y0, x1, y1 int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	// forward[k] is the furthest x reached on diagonal k = x-y from the
+	// start; backward[k] is how far back from the end diagonal
+	// k = (n-x)-(m-y) reaches.
+	forward := make([]int, 2*off+1)
+	backward := make([]int, 2*off+1)
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[off+k-1] < forward[off+k+1]) {
+				x = forward[off+k+1]
+			} else {
+				x = forward[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[off+k] = x
+			if c := delta - k; odd && c >= -(step-1) && c <= step-1 && x+backward[off+c] >= n {
+				return aLo + sx, bLo + sy, aLo + x, bLo + y
+			}
+		}
+		for c := -step; c <= step; c += 2 {
+			var x int
+			if c == -step || (c != step && backward[off+c-1] < backward[off+c+1]) {
+				x = backward[off+c+1]
+			} else {
+				x = backward[off+c-1] + 1
+			}
+			y := x - c
+			sx, sy := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[off+c] = x
+			if k := delta - c; !odd && k >= -step && k <= step && x+forward[off+k] >= n {
+				return aHi - x, bHi - y, aHi - sx, bHi - sy
+			}
+		}
+	}
+	// Unreachable: the searches meet within maxD steps.
+	return aLo, bLo, aHi, bHi
+}
+
+// pick returns the lines at idx.
+func pick(lines []string, idx []int) []string {
+	out := make([]string, len(idx))
+	for n, i := range idx {
+		out[n] = lines[i]
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// splitLinesKeepEnds splits s after each newline; the last line has none
+// when s does not end with one.
+func splitLinesKeepEnds(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,157 @@
+package deploy
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffInsertion(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	new := "a\nnew\nb\nc\nd\ne\nf\ng\nh\n"
+	hunks := UnifiedDiff(old, new, 3)
+	if len(hunks) != 1 {
+		t.Fatalf("hunks = %+v, want one", hunks)
+	}
+	h := hunks[0]
+	if got := h.Header(); got != "@@ -1,4 +1,5 @@" {
+		t.Errorf("Header = %s", got)
+	}
+	if got := strings.Join(h.Lines, "|"); got != " a|+new| b| c| d" {
+		t.Errorf("Lines = %s, want the insertion with three lines of context", got)
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	var old, new []string
+	for i := 1; i <= 20; i++ {
+		old = append(old, fmt.Sprint(i))
+		switch i {
+		case 2:
+			new = append(new, "two")
+		case 7:
+			// Within 2*context of line 2: same hunk.
+		case 18:
+			new = append(new, "18", "18b")
+		default:
+			new = append(new, fmt.Sprint(i))
+		}
+	}
+	hunks := UnifiedDiff(strings.Join(old, "\n")+"\n", strings.Join(new, "\n")+"\n", 3)
+	var headers []string
+	for _, h := range hunks {
+		headers = append(headers, h.Header())
+	}
+	if got := strings.Join(headers, " "); got != "@@ -1,10 +1,9 @@ @@ -16,5 +15,6 @@" {
+		t.Errorf("headers = %s", got)
+	}
+}
+
+func TestUnifiedDiffNoTrailingNewline(t *testing.T) {
+	hunks := UnifiedDiff("a\nb\n", "a\nb", 3)
+	if len(hunks) != 1 {
+		t.Fatalf("hunks = %+v, want the missing newline as a change", hunks)
+	}
+	want := ` a|-b|+b|\ No newline at end of file`
+	if got := strings.Join(hunks[0].Lines, "|"); got != want {
+		t.Errorf("Lines = %s, want %s", got, want)
+	}
+	if DiffLines("a\nb\n", "a\nb") != nil {
+		t.Error("DiffLines should ignore a missing trailing newline")
+	}
+	if hunks := UnifiedDiff("", "x\n", 3); len(hunks) != 1 || hunks[0].Header() != "@@ -0,0 +1,1 @@" {
+		t.Errorf("diff from empty = %+v", hunks)
+	}
+}
+
+// TestEditScriptMinimal checks, on random texts, that the edit script turns
+// a into b and is as short as a longest common subsequence allows.
+func TestEditScriptMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for n := 0; n < 500; n++ {
+		a, b := random(), random()
+		script := editScript(a, b)
+
+		var got []string
+		i, j, edits := 0, 0, 0
+		for _, e := range script {
+			switch e {
+			case editKeep:
+				if a[i] != b[j] {
+					t.Fatalf("%v -> %v: kept %q against %q", a, b, a[i], b[j])
+				}
+				got = append(got, a[i])
+				i++
+				j++
+			case editDelete:
+				i++
+				edits++
+			case editInsert:
+				got = append(got, b[j])
+				j++
+				edits++
+			}
+		}
+		if strings.Join(got, "") != strings.Join(b, "") || i != len(a) {
+			t.Fatalf("%v -> %v: script %q does not produce b", a, b, script)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("%v -> %v: %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
+
+func TestUnifiedDiffLargeFile(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&sb, "key%d: value%d\n", i, i)
+	}
+	old := sb.String()
+	new := strings.Replace(old, "key10: value10\n", "key10: changed\n", 1)
+	new = strings.Replace(new, "key150000: value150000\n", "", 1)
+
+	hunks := UnifiedDiff(old, new, 3)
+	if len(hunks) != 2 {
+		t.Fatalf("hunks = %d, want 2", len(hunks))
+	}
+	if got := hunks[1].Header(); got != "@@ -149998,7 +149998,6 @@" {
+		t.Errorf("second hunk = %s", got)
+	}
+}
+
+func TestUnifiedDiffRewrite(t *testing.T) {
+	var old, new strings.Builder
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&old, "old%d\n", i)
+		fmt.Fprintf(&new, "new%d\n", i)
+	}
+	hunks := UnifiedDiff(old.String(), new.String(), 3)
+	if len(hunks) != 1 || hunks[0].OldLines != 50000 || hunks[0].NewLines != 50000 {
+		t.Errorf("rewrite = %d hunks, want one replacing every line", len(hunks))
+	}
+}
@@ -0,0 +1,32 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/registry"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// ResolveImageDigests resolves each pinnable image of a workload to its
+// current digest, for Translate. Callers decide whether pinning is on with
+// translate.PinDigest.
+func ResolveImageDigests(ctx context.Context, resolver registry.Resolver, w *score.Workload) (map[string]string, error) {
+	digests := map[string]string{}
+	for _, img := range translate.PinnableImages(w) {
+		d, err := resolver.Digest(ctx, img)
+		switch {
+		case errors.Is(err, registry.ErrUnauthorized):
+			return nil, hcerrors.New(hcerrors.ErrUsage, "pinning %s: %v", img, err).
+				WithRemediation("run 'docker login', set registry.pullSecret, or opt out with the " + translate.PinDigestAnnotation + ` annotation set to "false"`)
+		case errors.Is(err, registry.ErrNotFound):
+			return nil, hcerrors.New(hcerrors.ErrNotFound, "pinning %s: %v", img, err)
+		case err != nil:
+			return nil, hcerrors.New(hcerrors.ErrClusterUnreachable, "pinning %s: %v", img, err)
+		}
+		digests[img] = d
+	}
+	return digests, nil
+}
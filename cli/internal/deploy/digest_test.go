@@ -0,0 +1,53 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/registry"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Digest(_ context.Context, image string) (string, error) {
+	if d, ok := f[image]; ok {
+		return d, nil
+	}
+	if image == "private/app:main" {
+		return "", fmt.Errorf("registry-1.docker.io/private/app: %w", registry.ErrUnauthorized)
+	}
+	return "", fmt.Errorf("%s: %w", image, registry.ErrNotFound)
+}
+
+func TestResolveImageDigests(t *testing.T) {
+	w := &score.Workload{Containers: map[string]score.Container{
+		"app":  {Image: "ghcr.io/org/app:main"},
+		"side": {Image: "ghcr.io/org/app:main"},
+	}}
+	got, err := ResolveImageDigests(context.Background(), fakeResolver{"ghcr.io/org/app:main": "sha256:abc"}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"ghcr.io/org/app:main": "sha256:abc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveImageDigests() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		image string
+		want  hcerrors.Category
+	}{
+		{"private/app:main", hcerrors.ErrUsage},
+		{"ghcr.io/org/gone:main", hcerrors.ErrNotFound},
+	}
+	for _, tt := range tests {
+		w := &score.Workload{Containers: map[string]score.Container{"app": {Image: tt.image}}}
+		if _, err := ResolveImageDigests(context.Background(), fakeResolver{}, w); !errors.Is(err, tt.want) {
+			t.Errorf("ResolveImageDigests(%s) error = %v, want %s", tt.image, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FindApp returns a workload's ArgoCD Application: the one carrying its
+// ownership labels, or for entries deployed before hctl set them, the one
+// named <workload> or <cluster>-<workload>.
+func FindApp(ctx context.Context, client *kube.Client, workload, cluster string) (*unstructured.Unstructured, error) {
+	apps, err := client.ListArgoAppsBySelector(ctx, "argocd", translate.WorkloadSelector(workload, cluster))
+	if err == nil && len(apps) > 0 {
+		return &apps[0], nil
+	}
+	app, err := client.GetArgoApp(ctx, "argocd", workload)
+	if err != nil {
+		app, err = client.GetArgoApp(ctx, "argocd", cluster+"-"+workload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ArgoCD application not found for %q: %w", workload, err)
+	}
+	return app, nil
+}
+
+// FindPods returns a workload's pods in namespace: those carrying its
+// ownership labels, or failing that, those labelled
+// app.kubernetes.io/name=<workload>.
+func FindPods(ctx context.Context, client *kube.Client, namespace, workload, cluster string) ([]kube.PodInfo, error) {
+	pods, err := client.ListPods(ctx, namespace, translate.WorkloadSelector(workload, cluster))
+	if err != nil || len(pods) > 0 {
+		return pods, err
+	}
+	return client.ListPods(ctx, namespace, "app.kubernetes.io/name="+workload)
+}
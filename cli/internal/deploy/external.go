@@ -0,0 +1,216 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// KustomizeType marks an addons.yaml entry whose path is a kustomize
+// directory in the workloads repo rather than a Helm chart.
+const KustomizeType = "kustomize"
+
+// ChartWorkload is a third-party Helm chart deployed as a workload.
+type ChartWorkload struct {
+	Name       string
+	Cluster    string
+	Namespace  string
+	Repository string
+	Chart      string
+	Version    string
+	// Values is the chart values.yaml; nil writes none.
+	Values []byte
+}
+
+// KustomizeWorkload is a kustomize directory deployed as a workload.
+type KustomizeWorkload struct {
+	Name      string
+	Cluster   string
+	Namespace string
+	// Dir is the local kustomize directory copied into the repo.
+	Dir string
+}
+
+// WorkloadDir returns the repo-relative directory holding a workload's
+// values or manifests.
+func WorkloadDir(cluster, workload string) string {
+	return layout.Active().WorkloadDir(cluster, workload)
+}
+
+// ChartResult builds the files and addons.yaml entry for a chart workload,
+// in the same shape as a Score translation so WriteResult, list, status and
+// remove treat both alike. The namespace defaults to the workload name.
+func ChartResult(c ChartWorkload) (*TranslateResult, error) {
+	if c.Repository == "" || c.Chart == "" || c.Version == "" {
+		return nil, hcerrors.NewUserError("--repo, --chart and --version are required")
+	}
+	ns := orName(c.Namespace, c.Name)
+	result := &TranslateResult{
+		WorkloadName:  c.Name,
+		TargetCluster: c.Cluster,
+		Namespace:     ns,
+		AddonsEntry: map[string]interface{}{
+			"enabled":         true,
+			"namespace":       ns,
+			"chartRepository": c.Repository,
+			"chartName":       c.Chart,
+			"defaultVersion":  c.Version,
+			"labelsApp":       translate.OwnershipLabels(c.Name, c.Cluster),
+		},
+		Files: map[string][]byte{},
+	}
+	if c.Values != nil {
+		result.Files[translate.ValuesPath(c.Cluster, c.Name)] = c.Values
+	}
+	return result, nil
+}
+
+// KustomizeResult copies a kustomize directory into the workload's
+// directory and builds a path-based addons.yaml entry for it. Hidden files
+// and directories are skipped.
+func KustomizeResult(k KustomizeWorkload) (*TranslateResult, error) {
+	if !hasKustomization(k.Dir) {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s has no kustomization.yaml", k.Dir)
+	}
+	ns := orName(k.Namespace, k.Name)
+	dir := WorkloadDir(k.Cluster, k.Name)
+	result := &TranslateResult{
+		WorkloadName:  k.Name,
+		TargetCluster: k.Cluster,
+		Namespace:     ns,
+		AddonsEntry: map[string]interface{}{
+			"enabled":   true,
+			"namespace": ns,
+			"type":      KustomizeType,
+			"path":      dir,
+			"labelsApp": translate.OwnershipLabels(k.Name, k.Cluster),
+		},
+		Files: map[string][]byte{},
+	}
+
+	err := filepath.WalkDir(k.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != k.Dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(k.Dir, p)
+		if err != nil {
+			return err
+		}
+		result.Files[repopath.Join(dir, rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", k.Dir, err)
+	}
+	return result, nil
+}
+
+func hasKustomization(dir string) bool {
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// kustomizeBuild renders a kustomize directory; a variable so tests need
+// no kustomize binary.
+var kustomizeBuild = func(dir string) ([]byte, error) {
+	name, args := "kustomize", []string{"build", dir}
+	if _, err := exec.LookPath(name); err != nil {
+		name, args = "kubectl", []string{"kustomize", dir}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// VerifyKustomize checks a kustomize directory builds, with kustomize or
+// kubectl kustomize.
+func VerifyKustomize(dir string) error {
+	if _, err := kustomizeBuild(dir); err != nil {
+		return hcerrors.New(hcerrors.ErrValidation, "kustomize build failed: %v", err).
+			WithRemediation("fix the kustomization, or check kustomize or kubectl is installed")
+	}
+	return nil
+}
+
+// PruneWorkloadDir deletes files in the workload's directory that result
+// does not write, so a re-deployed kustomize directory does not keep files
+// removed from the source. It returns the deleted paths, slash-separated.
+func PruneWorkloadDir(repoPath string, result *TranslateResult) ([]string, error) {
+	stale, err := StaleFiles(repoPath, result)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range stale {
+		if err := os.Remove(repopath.Abs(repoPath, rel)); err != nil {
+			return nil, fmt.Errorf("pruning %s: %w", rel, err)
+		}
+	}
+	return stale, nil
+}
+
+// StaleFiles returns the files in the workload's directory that result does
+// not write, repo-relative and sorted: what PruneWorkloadDir deletes.
+func StaleFiles(repoPath string, result *TranslateResult) ([]string, error) {
+	root := repopath.Abs(repoPath, WorkloadDir(result.TargetCluster, result.WorkloadName))
+	var stale []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := repopath.Rel(repoPath, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := result.Files[rel]; !ok {
+			stale = append(stale, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pruning %s: %w", root, err)
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+func orName(v, name string) string {
+	if v == "" {
+		return name
+	}
+	return v
+}
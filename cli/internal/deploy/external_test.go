@@ -0,0 +1,179 @@
+package deploy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// kustomizeDir writes a kustomize directory with a hidden file that must
+// not be copied.
+func kustomizeDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"kustomization.yaml":   "resources:\n  - base/deployment.yaml\n",
+		"base/deployment.yaml": "kind: Deployment\n",
+		".env":                 "SECRET=1\n",
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestChartResult(t *testing.T) {
+	result, err := ChartResult(ChartWorkload{
+		Name: "kuma", Cluster: "dev",
+		Repository: "https://charts.example", Chart: "uptime-kuma", Version: "2.21.2",
+		Values: []byte("replicas: 1\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"enabled":         true,
+		"namespace":       "kuma",
+		"chartRepository": "https://charts.example",
+		"chartName":       "uptime-kuma",
+		"defaultVersion":  "2.21.2",
+		"labelsApp":       translate.OwnershipLabels("kuma", "dev"),
+	}
+	if !reflect.DeepEqual(result.AddonsEntry, want) {
+		t.Errorf("AddonsEntry = %v, want %v", result.AddonsEntry, want)
+	}
+	if _, ok := result.Files["workloads/dev/addons/kuma/values.yaml"]; !ok {
+		t.Errorf("Files = %v, want the values file", result.Files)
+	}
+
+	if _, err := ChartResult(ChartWorkload{Name: "kuma", Cluster: "dev", Chart: "uptime-kuma"}); !errors.Is(err, hcerrors.ErrUsage) {
+		t.Errorf("ChartResult() without --repo error = %v, want usage error", err)
+	}
+}
+
+func TestKustomizeResult(t *testing.T) {
+	result, err := KustomizeResult(KustomizeWorkload{Name: "mqtt", Cluster: "dev", Namespace: "iot", Dir: kustomizeDir(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"enabled":   true,
+		"namespace": "iot",
+		"type":      KustomizeType,
+		"path":      "workloads/dev/addons/mqtt",
+		"labelsApp": translate.OwnershipLabels("mqtt", "dev"),
+	}
+	if !reflect.DeepEqual(result.AddonsEntry, want) {
+		t.Errorf("AddonsEntry = %v, want %v", result.AddonsEntry, want)
+	}
+	var files []string
+	for p := range result.Files {
+		files = append(files, p)
+	}
+	wantFiles := map[string]bool{
+		"workloads/dev/addons/mqtt/kustomization.yaml":   true,
+		"workloads/dev/addons/mqtt/base/deployment.yaml": true,
+	}
+	if len(files) != len(wantFiles) {
+		t.Errorf("Files = %q, want %v", files, wantFiles)
+	}
+	for _, p := range files {
+		if !wantFiles[p] {
+			t.Errorf("unexpected file %s", p)
+		}
+	}
+
+	if _, err := KustomizeResult(KustomizeWorkload{Name: "mqtt", Cluster: "dev", Dir: t.TempDir()}); !errors.Is(err, hcerrors.ErrValidation) {
+		t.Errorf("KustomizeResult() without kustomization.yaml error = %v, want validation error", err)
+	}
+}
+
+func TestRemoveExternalWorkloads(t *testing.T) {
+	repo := t.TempDir()
+	chart, err := ChartResult(ChartWorkload{
+		Name: "kuma", Cluster: "dev",
+		Repository: "https://charts.example", Chart: "uptime-kuma", Version: "2.21.2",
+		Values: []byte("replicas: 1\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kust, err := KustomizeResult(KustomizeWorkload{Name: "mqtt", Cluster: "dev", Dir: kustomizeDir(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*TranslateResult{chart, kust} {
+		if _, err := WriteResult(r, repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workloads, err := ListWorkloads(repo, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"kuma", "mqtt"}; !reflect.DeepEqual(workloads, want) {
+		t.Errorf("ListWorkloads() = %v, want %v", workloads, want)
+	}
+
+	for _, name := range workloads {
+		removed, err := RemoveWorkload(repo, "dev", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"workloads/dev/addons.yaml", WorkloadDir("dev", name)}
+		if !reflect.DeepEqual(removed, want) {
+			t.Errorf("RemoveWorkload(%s) = %q, want %q", name, removed, want)
+		}
+		if _, err := os.Stat(repopath.Abs(repo, WorkloadDir("dev", name))); !os.IsNotExist(err) {
+			t.Errorf("%s directory still exists: %v", name, err)
+		}
+	}
+}
+
+func TestPruneWorkloadDir(t *testing.T) {
+	repo := t.TempDir()
+	result, err := KustomizeResult(KustomizeWorkload{Name: "mqtt", Cluster: "dev", Dir: kustomizeDir(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := PruneWorkloadDir(repo, result); err != nil || removed != nil {
+		t.Errorf("PruneWorkloadDir() before first write = %q, %v", removed, err)
+	}
+	if _, err := WriteResult(result, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(result.Files, "workloads/dev/addons/mqtt/base/deployment.yaml")
+	removed, err := PruneWorkloadDir(repo, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"workloads/dev/addons/mqtt/base/deployment.yaml"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("PruneWorkloadDir() = %q, want %q", removed, want)
+	}
+}
+
+func TestVerifyKustomize(t *testing.T) {
+	defer func(orig func(string) ([]byte, error)) { kustomizeBuild = orig }(kustomizeBuild)
+
+	kustomizeBuild = func(string) ([]byte, error) { return []byte("kind: Deployment\n"), nil }
+	if err := VerifyKustomize("dir"); err != nil {
+		t.Errorf("VerifyKustomize() = %v", err)
+	}
+	kustomizeBuild = func(string) ([]byte, error) { return nil, errors.New("missing resource") }
+	if err := VerifyKustomize("dir"); !errors.Is(err, hcerrors.ErrValidation) {
+		t.Errorf("VerifyKustomize() error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,436 @@
+package deploy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// DefaultHistoryLimit is how many revisions 'hctl deploy history' lists
+// without --limit.
+const DefaultHistoryLimit = 20
+
+// Kinds of ValuesChange, in the order a revision lists them.
+const (
+	ChangeCreated   = "created"
+	ChangeRemoved   = "removed"
+	ChangeImage     = "image"
+	ChangeEnv       = "env"
+	ChangeResources = "resources"
+	ChangeRoute     = "route"
+	ChangeOther     = "other"
+)
+
+// NoSemanticChange summarizes a revision whose values did not change
+// beyond formatting, comments or the recorded commit.
+const NoSemanticChange = "no semantic change"
+
+// ValuesChange is one semantic change between two revisions of a
+// workload's values.yaml.
+type ValuesChange struct {
+	Kind    string `json:"kind" yaml:"kind"`
+	Summary string `json:"summary" yaml:"summary"`
+}
+
+// Revision is a commit that touched a workload's files.
+type Revision struct {
+	Commit  string         `json:"commit" yaml:"commit"`
+	Date    time.Time      `json:"date" yaml:"date"`
+	Author  string         `json:"author" yaml:"author"`
+	Subject string         `json:"subject" yaml:"subject"`
+	Changes []ValuesChange `json:"changes" yaml:"changes"`
+	// Synced marks the newest revision contained in the commit the
+	// workload's ArgoCD Application is synced to.
+	Synced bool `json:"synced,omitempty" yaml:"synced,omitempty"`
+}
+
+// Summary joins the revision's changes into one line.
+func (r Revision) Summary() string {
+	if len(r.Changes) == 0 {
+		return NoSemanticChange
+	}
+	parts := make([]string, len(r.Changes))
+	for i, c := range r.Changes {
+		parts[i] = c.Summary
+	}
+	return strings.Join(parts, "; ")
+}
+
+// History is the deploy history of a workload in a cluster.
+type History struct {
+	Workload string `json:"workload" yaml:"workload"`
+	Cluster  string `json:"cluster" yaml:"cluster"`
+	// SyncedRevision is the commit the ArgoCD Application is synced to; ""
+	// when unknown.
+	SyncedRevision string     `json:"syncedRevision,omitempty" yaml:"syncedRevision,omitempty"`
+	Revisions      []Revision `json:"revisions" yaml:"revisions"`
+}
+
+// WorkloadHistory lists up to limit commits that touched the workload's
+// directory in the repo, newest first, each with the semantic changes it
+// made to values.yaml. When synced is set, the newest revision it contains
+// is marked Synced: ArgoCD syncs the whole repo, so its revision is rarely
+// a commit of the workload itself.
+func WorkloadHistory(repoPath, cluster, workload string, limit int, synced string) (*History, error) {
+	repo, err := git.DetectRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	// The workload paths are relative to repoPath, which may be below the
+	// repo root.
+	repoRel := func(rel string) (string, error) {
+		abs, err := filepath.Abs(repopath.Abs(repoPath, rel))
+		if err != nil {
+			return "", err
+		}
+		return repo.RelPath(abs)
+	}
+	dir, err := repoRel(WorkloadDir(cluster, workload))
+	if err != nil {
+		return nil, err
+	}
+	valuesPath, err := repoRel(translate.ValuesPath(cluster, workload))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := repo.PathLog(limit, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading the history of %s: %w", dir, err)
+	}
+	h := &History{Workload: workload, Cluster: cluster, SyncedRevision: synced}
+	for _, e := range entries {
+		rev := Revision{Commit: e.Hash, Date: e.Date, Author: e.Author, Subject: e.Subject}
+		before, beforeErr := valuesAt(repo, e.Hash+"^", valuesPath)
+		after, afterErr := valuesAt(repo, e.Hash, valuesPath)
+		if beforeErr != nil || afterErr != nil {
+			rev.Changes = []ValuesChange{{Kind: ChangeOther, Summary: "values.yaml does not parse"}}
+		} else {
+			rev.Changes = ClassifyValuesChange(before, after)
+		}
+		h.Revisions = append(h.Revisions, rev)
+	}
+
+	if synced != "" {
+		for i := range h.Revisions {
+			if ok, err := repo.IsAncestor(h.Revisions[i].Commit, synced); err != nil {
+				break
+			} else if ok {
+				h.Revisions[i].Synced = true
+				break
+			}
+		}
+	}
+	return h, nil
+}
+
+// valuesAt parses the values file at rev. A file missing at rev yields nil
+// values.
+func valuesAt(repo *git.Repo, rev, path string) (map[string]interface{}, error) {
+	data, err := repo.ShowFile(rev, path)
+	if err != nil {
+		return nil, nil
+	}
+	body, _, _ := translate.ParseGenerated(data)
+	values, err := parseValues(body)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// ClassifyValuesChange describes what changed between two revisions of a
+// workload's values at the level of the workload: image, env vars,
+// container resources and route, with the remaining changes grouped by
+// top-level section. A nil before or after means the file did not exist.
+// Changes only to formatting, key order or the recorded commit yield none.
+func ClassifyValuesChange(before, after map[string]interface{}) []ValuesChange {
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		return []ValuesChange{{Kind: ChangeCreated, Summary: "created" + imageSuffix(after)}}
+	case after == nil:
+		return []ValuesChange{{Kind: ChangeRemoved, Summary: "removed"}}
+	}
+
+	old, cur := historyFields(before), historyFields(after)
+	changed := map[string]bool{}
+	for p, v := range old {
+		if w, ok := cur[p]; !ok || w != v {
+			changed[p] = true
+		}
+	}
+	for p := range cur {
+		if _, ok := old[p]; !ok {
+			changed[p] = true
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var (
+		images    = map[string]bool{}
+		env       = map[string]map[string]string{}
+		resources = map[string][]string{}
+		route     bool
+		other     = map[string]bool{}
+	)
+	for p := range changed {
+		segs := splitPath(p)
+		if len(segs) < 2 {
+			continue
+		}
+		if segs[1] == "httpRoute" {
+			route = true
+			continue
+		}
+		c, ok := containerField(segs)
+		if !ok {
+			other[segs[1]] = true
+			continue
+		}
+		switch c.field {
+		case "image":
+			images[c.container] = true
+		case "env":
+			if c.envKey == "" {
+				// An emptied env is an empty leaf; its vars are listed
+				// on the other side.
+				continue
+			}
+			if env[c.container] == nil {
+				env[c.container] = map[string]string{}
+			}
+			verb := "changed"
+			if !hasPrefixField(old, c.envPrefix) {
+				verb = "added"
+			} else if !hasPrefixField(cur, c.envPrefix) {
+				verb = "removed"
+			}
+			env[c.container][c.envKey] = verb
+		case "resources":
+			resources[c.container] = append(resources[c.container],
+				fmt.Sprintf("%s %s → %s", c.rest, fieldOrNone(old, p), fieldOrNone(cur, p)))
+		default:
+			other[segs[1]] = true
+		}
+	}
+
+	var changes []ValuesChange
+	for _, container := range sortedKeys(images) {
+		changes = append(changes, ValuesChange{Kind: ChangeImage, Summary: fmt.Sprintf("%simage %s → %s",
+			containerPrefix(container), orNone(imageRef(before, container)), orNone(imageRef(after, container)))})
+	}
+	for _, container := range sortedKeys(env) {
+		byVerb := map[string][]string{}
+		for key, verb := range env[container] {
+			byVerb[verb] = append(byVerb[verb], key)
+		}
+		for _, verb := range []string{"added", "removed", "changed"} {
+			if keys := byVerb[verb]; len(keys) > 0 {
+				sort.Strings(keys)
+				changes = append(changes, ValuesChange{Kind: ChangeEnv,
+					Summary: fmt.Sprintf("%senv %s %s", containerPrefix(container), strings.Join(keys, ", "), verb)})
+			}
+		}
+	}
+	for _, container := range sortedKeys(resources) {
+		diffs := resources[container]
+		sort.Strings(diffs)
+		changes = append(changes, ValuesChange{Kind: ChangeResources,
+			Summary: fmt.Sprintf("%sresources %s", containerPrefix(container), strings.Join(diffs, ", "))})
+	}
+	if route {
+		changes = append(changes, ValuesChange{Kind: ChangeRoute, Summary: routeSummary(old, cur)})
+	}
+	if len(other) > 0 {
+		changes = append(changes, ValuesChange{Kind: ChangeOther, Summary: strings.Join(sortedKeys(other), ", ") + " changed"})
+	}
+	return changes
+}
+
+// historyFields flattens values for comparison, without the fields that
+// change on every deploy.
+func historyFields(values map[string]interface{}) map[string]string {
+	fields := ExtractFields("values", values)
+	for p := range fields {
+		for _, pattern := range defaultCompareIgnore {
+			if MatchField(pattern, p) {
+				delete(fields, p)
+			}
+		}
+	}
+	return fields
+}
+
+// containerFieldRef locates a changed field within a container spec.
+type containerFieldRef struct {
+	// container is "" for the primary container, else the name of an
+	// additional container.
+	container string
+	// field is the container spec field: image, env, resources, ...
+	field string
+	// envKey and envPrefix name the env var for env fields.
+	envKey    string
+	envPrefix string
+	// rest is the path below field, e.g. requests.memory.
+	rest string
+}
+
+// containerField resolves a field path under values.deployment or
+// values.statefulset to the container it belongs to.
+func containerField(segs []string) (containerFieldRef, bool) {
+	if len(segs) < 3 || (segs[1] != "deployment" && segs[1] != "statefulset") {
+		return containerFieldRef{}, false
+	}
+	var ref containerFieldRef
+	prefix, rest := segs[:2], segs[2:]
+	if rest[0] == "additionalContainers" {
+		if len(rest) < 3 {
+			return containerFieldRef{}, false
+		}
+		ref.container = strings.TrimSuffix(strings.TrimPrefix(rest[1], "[name="), "]")
+		prefix, rest = segs[:4], rest[2:]
+	}
+	ref.field = rest[0]
+	ref.rest = joinPath(rest[1:])
+	if ref.field == "env" && len(rest) > 1 {
+		// The primary container's env is a map by name, an additional
+		// container's a list of name/value items.
+		ref.envKey = strings.TrimSuffix(strings.TrimPrefix(rest[1], "[name="), "]")
+		ref.envPrefix = joinPath(append(append([]string{}, prefix...), rest[:2]...))
+	}
+	return ref, true
+}
+
+// hasPrefixField reports whether any field is at or below prefix.
+func hasPrefixField(fields map[string]string, prefix string) bool {
+	for p := range fields {
+		if p == prefix || strings.HasPrefix(p, prefix+".") || strings.HasPrefix(p, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// imageRef renders a container's image from values, "" when unset.
+func imageRef(values map[string]interface{}, container string) string {
+	spec := workloadSpec(values)
+	if container != "" {
+		spec = nil
+		items, _ := workloadSpec(values)["additionalContainers"].([]interface{})
+		for _, item := range items {
+			if m := asMap(item); m["name"] == container {
+				spec = m
+			}
+		}
+	}
+	switch img := spec["image"].(type) {
+	case string:
+		return img
+	case map[string]interface{}:
+		ref := fmt.Sprint(img["repository"])
+		if tag, ok := img["tag"]; ok {
+			ref += ":" + fmt.Sprint(tag)
+		}
+		if digest, ok := img["digest"]; ok {
+			ref += "@" + fmt.Sprint(digest)
+		}
+		return ref
+	}
+	return ""
+}
+
+// workloadSpec returns the pod's section of values: the enabled
+// deployment or statefulset section, or else the workload's cronJob job.
+func workloadSpec(values map[string]interface{}) map[string]interface{} {
+	for _, section := range []string{"deployment", "statefulset"} {
+		if spec := asMap(values[section]); spec != nil && spec["enabled"] != false {
+			return spec
+		}
+	}
+	name, _ := values["applicationName"].(string)
+	return asMap(asMap(asMap(values["cronJob"])["jobs"])[name])
+}
+
+func imageSuffix(values map[string]interface{}) string {
+	if ref := imageRef(values, ""); ref != "" {
+		return " with image " + ref
+	}
+	return ""
+}
+
+// routeSummary describes a change under values.httpRoute.
+func routeSummary(old, cur map[string]string) string {
+	wasOn, isOn := old["values.httpRoute.enabled"] == "true", cur["values.httpRoute.enabled"] == "true"
+	switch {
+	case !wasOn && isOn:
+		return "route added" + hostnamesSuffix(cur)
+	case wasOn && !isOn:
+		return "route removed"
+	}
+	if before, after := hostnames(old), hostnames(cur); before != after {
+		return fmt.Sprintf("route %s → %s", orNone(before), orNone(after))
+	}
+	return "route changed"
+}
+
+func hostnamesSuffix(fields map[string]string) string {
+	if h := hostnames(fields); h != "" {
+		return " (" + h + ")"
+	}
+	return ""
+}
+
+// hostnames joins the route hostnames in fields.
+func hostnames(fields map[string]string) string {
+	var names []string
+	for p, v := range fields {
+		if strings.HasPrefix(p, "values.httpRoute.hostnames[") {
+			names = append(names, v)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func containerPrefix(container string) string {
+	if container == "" {
+		return ""
+	}
+	return container + " "
+}
+
+func fieldOrNone(fields map[string]string, p string) string {
+	if v, ok := fields[p]; ok {
+		return v
+	}
+	return "<none>"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
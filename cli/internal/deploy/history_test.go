@@ -0,0 +1,204 @@
+package deploy
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+const historyBase = `deployment:
+  image:
+    repository: ghcr.io/acme/web
+    tag: "1.4"
+  env:
+    LOG_LEVEL:
+      value: info
+    PORT:
+      value: "8080"
+  resources:
+    requests:
+      cpu: 100m
+      memory: 128Mi
+  additionalContainers:
+    - name: exporter
+      image: ghcr.io/acme/exporter:v1
+      env:
+        - name: URL
+          value: http://localhost:8080
+service:
+  ports:
+    - name: http
+      port: 8080
+httpRoute:
+  enabled: true
+  hostnames:
+    - web.dev.integratn.tech
+`
+
+func mustValues(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	values, err := parseValues([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestClassifyValuesChange(t *testing.T) {
+	tests := []struct {
+		name  string
+		after string
+		// stamp records a commit annotation in after.
+		stamp bool
+		want  []ValuesChange
+	}{
+		{
+			name:  "image tag",
+			after: strings.Replace(historyBase, `tag: "1.4"`, `tag: "1.5"`, 1),
+			want:  []ValuesChange{{ChangeImage, "image ghcr.io/acme/web:1.4 → ghcr.io/acme/web:1.5"}},
+		},
+		{
+			name:  "sidecar image",
+			after: strings.Replace(historyBase, "exporter:v1", "exporter:v2", 1),
+			want:  []ValuesChange{{ChangeImage, "exporter image ghcr.io/acme/exporter:v1 → ghcr.io/acme/exporter:v2"}},
+		},
+		{
+			name:  "new env key",
+			after: strings.Replace(historyBase, "    PORT:\n", "    FEATURE_X:\n      value: \"on\"\n    PORT:\n", 1),
+			want:  []ValuesChange{{ChangeEnv, "env FEATURE_X added"}},
+		},
+		{
+			name: "env removed and changed",
+			after: strings.Replace(strings.Replace(historyBase, "    LOG_LEVEL:\n      value: info\n", "", 1),
+				`value: "8080"`, `value: "9090"`, 1),
+			want: []ValuesChange{{ChangeEnv, "env LOG_LEVEL removed"}, {ChangeEnv, "env PORT changed"}},
+		},
+		{
+			name:  "sidecar env",
+			after: strings.Replace(historyBase, "value: http://localhost:8080", "value: http://127.0.0.1:8080", 1),
+			want:  []ValuesChange{{ChangeEnv, "exporter env URL changed"}},
+		},
+		{
+			name:  "resources",
+			after: strings.Replace(historyBase, "memory: 128Mi", "memory: 256Mi", 1),
+			want:  []ValuesChange{{ChangeResources, "resources requests.memory 128Mi → 256Mi"}},
+		},
+		{
+			name:  "removed route",
+			after: strings.Replace(historyBase, "  enabled: true\n  hostnames:\n    - web.dev.integratn.tech\n", "  enabled: false\n", 1),
+			want:  []ValuesChange{{ChangeRoute, "route removed"}},
+		},
+		{
+			name:  "route hostname",
+			after: strings.Replace(historyBase, "web.dev.", "www.dev.", 1),
+			want:  []ValuesChange{{ChangeRoute, "route web.dev.integratn.tech → www.dev.integratn.tech"}},
+		},
+		{
+			name:  "unrelated sections",
+			after: strings.Replace(historyBase, "      port: 8080", "      port: 80", 1) + "podDisruptionBudget:\n  enabled: true\n",
+			want:  []ValuesChange{{ChangeOther, "podDisruptionBudget, service changed"}},
+		},
+		{
+			name: "formatting only",
+			after: `# reformatted
+httpRoute: {enabled: true, hostnames: [web.dev.integratn.tech]}
+service:
+  ports: [{name: http, port: 8080}]
+deployment:
+  resources: {requests: {memory: 128Mi, cpu: 100m}}
+  env:
+    PORT: {value: "8080"}
+    LOG_LEVEL: {value: info}
+  image: {tag: "1.4", repository: ghcr.io/acme/web}
+  additionalContainers:
+    - {name: exporter, image: "ghcr.io/acme/exporter:v1", env: [{name: URL, value: "http://localhost:8080"}]}
+`,
+		},
+		{
+			name:  "recorded commit only",
+			after: historyBase,
+			stamp: true,
+		},
+	}
+	before := mustValues(t, historyBase)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := mustValues(t, tt.after)
+			if tt.stamp {
+				translate.StampCommit(after, "0123abcd")
+			}
+			got := ClassifyValuesChange(before, after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyValuesChange() = %v, want %v", got, tt.want)
+			}
+			if len(tt.want) == 0 && (Revision{Changes: got}).Summary() != NoSemanticChange {
+				t.Errorf("Summary() = %q, want %q", Revision{Changes: got}.Summary(), NoSemanticChange)
+			}
+		})
+	}
+}
+
+func TestClassifyValuesChangeCreatedAndRemoved(t *testing.T) {
+	values := mustValues(t, historyBase)
+	if got := ClassifyValuesChange(nil, values); !reflect.DeepEqual(got, []ValuesChange{{ChangeCreated, "created with image ghcr.io/acme/web:1.4"}}) {
+		t.Errorf("created = %v", got)
+	}
+	if got := ClassifyValuesChange(values, nil); !reflect.DeepEqual(got, []ValuesChange{{ChangeRemoved, "removed"}}) {
+		t.Errorf("removed = %v", got)
+	}
+}
+
+func TestWorkloadHistory(t *testing.T) {
+	repo := t.TempDir()
+	gitIn(t, repo, "init", "-q")
+	commit := func(values, message string) string {
+		writeRepoFile(t, repo, valuesRel, []byte(values))
+		gitIn(t, repo, "add", "-A")
+		gitIn(t, repo, "commit", "-q", "-m", message)
+		out, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	first := commit(historyBase, "hctl: deploy myapp")
+	commit(strings.Replace(historyBase, `tag: "1.4"`, `tag: "1.5"`, 1), "hctl: deploy myapp (1.5)")
+	// A later commit elsewhere in the repo, which ArgoCD is synced to.
+	writeRepoFile(t, repo, "workloads/dev/addons/other/values.yaml", []byte("{}\n"))
+	synced := commit(strings.Replace(historyBase, `tag: "1.4"`, `tag: "1.5"`, 1), "hctl: deploy other")
+	commit(strings.Replace(historyBase, `tag: "1.4"`, `tag: "1.6"`, 1), "hctl: deploy myapp (1.6)")
+
+	h, err := WorkloadHistory(repo, "dev", "myapp", 10, synced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rev := range h.Revisions {
+		got = append(got, rev.Subject+": "+rev.Summary())
+	}
+	want := []string{
+		"hctl: deploy myapp (1.6): image ghcr.io/acme/web:1.5 → ghcr.io/acme/web:1.6",
+		"hctl: deploy myapp (1.5): image ghcr.io/acme/web:1.4 → ghcr.io/acme/web:1.5",
+		"hctl: deploy myapp: created with image ghcr.io/acme/web:1.4",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("revisions = %q, want %q", got, want)
+	}
+	if h.Revisions[0].Synced || !h.Revisions[1].Synced || h.Revisions[2].Synced {
+		t.Errorf("synced marks = %v %v %v, want only the 1.5 revision", h.Revisions[0].Synced, h.Revisions[1].Synced, h.Revisions[2].Synced)
+	}
+	if h.Revisions[2].Commit != first || h.Revisions[2].Author != "test" || h.Revisions[2].Date.IsZero() {
+		t.Errorf("first revision = %+v", h.Revisions[2])
+	}
+
+	h, err = WorkloadHistory(repo, "dev", "myapp", 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Revisions) != 1 || h.Revisions[0].Synced {
+		t.Errorf("limit 1 = %+v", h.Revisions)
+	}
+}
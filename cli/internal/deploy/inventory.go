@@ -0,0 +1,88 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ReadInventory returns the manifest inventory on disk for a workload, or
+// nil when it has none: it was deployed by an hctl that wrote no inventory,
+// or from plain manifests.
+func ReadInventory(repoPath, cluster, workload string) (*translate.Inventory, error) {
+	relPath := translate.InventoryPath(cluster, workload)
+	data, err := os.ReadFile(repopath.Abs(repoPath, relPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", relPath, err)
+	}
+	inv, err := translate.ParseInventory(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", relPath, err)
+	}
+	return inv, nil
+}
+
+// RemovedObjects returns the objects the workload's inventory on disk lists
+// that result no longer generates, so ArgoCD prunes them on the next sync.
+// It is empty when either side has no inventory.
+func RemovedObjects(repoPath string, result *TranslateResult) ([]translate.InventoryObject, error) {
+	if result.Inventory == nil {
+		return nil, nil
+	}
+	old, err := ReadInventory(repoPath, result.TargetCluster, result.WorkloadName)
+	if err != nil {
+		return nil, err
+	}
+	return old.Removed(result.Inventory), nil
+}
+
+// PurgePlan returns the objects 'hctl deploy remove --purge' deletes: every
+// object the workload's inventory lists. The inventory is authoritative, as
+// the current generation logic may no longer produce what an older hctl
+// did. A workload without one fails with ErrNotFound.
+func PurgePlan(repoPath, cluster, workload string) ([]translate.InventoryObject, error) {
+	inv, err := ReadInventory(repoPath, cluster, workload)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "workload %q has no manifest inventory (%s)", workload, translate.InventoryPath(cluster, workload)).
+			WithRemediation("run 'hctl deploy run' for the workload to record one, or remove it without --purge and let ArgoCD prune its objects")
+	}
+	return inv.Objects, nil
+}
+
+// Purge deletes objects from the cluster client reaches, resolving their
+// kinds with mapper, and returns those it deleted. Objects already gone and
+// kinds the cluster no longer serves are skipped; the first other failure
+// stops the purge.
+func Purge(ctx context.Context, client *kube.Client, mapper meta.RESTMapper, objects []translate.InventoryObject) ([]translate.InventoryObject, error) {
+	var deleted []translate.InventoryObject
+	for _, o := range objects {
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: o.Group, Kind: o.Kind})
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("resolving %s: %w", o, err)
+		}
+		ok, err := client.DeleteObject(ctx, mapping, o.Namespace, o.Name)
+		if err != nil {
+			return deleted, fmt.Errorf("deleting %s: %w", o, err)
+		}
+		if ok {
+			deleted = append(deleted, o)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,106 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const bucketParams = `  media:
+    type: s3
+`
+
+func TestPurgePlan(t *testing.T) {
+	repo := t.TempDir()
+	if _, err := PurgePlan(repo, "dev", "library"); !errors.Is(err, hcerrors.ErrNotFound) {
+		t.Errorf("PurgePlan without an inventory: error = %v, want ErrNotFound", err)
+	}
+
+	result := translateS3(t, "library", bucketParams)
+	if _, err := WriteResult(result, repo); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := PurgePlan(repo, "dev", "library")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(objects, result.Inventory.Objects) {
+		t.Errorf("PurgePlan = %+v, want the written inventory %+v", objects, result.Inventory.Objects)
+	}
+	var bucket bool
+	for _, o := range objects {
+		bucket = bucket || o.Provisioner == "s3" && o.Resource == "media"
+	}
+	if !bucket {
+		t.Errorf("PurgePlan = %+v, want the s3 provisioner's objects", objects)
+	}
+}
+
+func TestRemovedObjects(t *testing.T) {
+	repo := t.TempDir()
+	old := translateS3(t, "library", bucketParams)
+	if removed, err := RemovedObjects(repo, old); err != nil || len(removed) != 0 {
+		t.Errorf("RemovedObjects before the first deploy = %+v, %v; want none", removed, err)
+	}
+	if _, err := WriteResult(old, repo); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := RemovedObjects(repo, old); err != nil || len(removed) != 0 {
+		t.Errorf("RemovedObjects of an unchanged render = %+v, %v; want none", removed, err)
+	}
+
+	next := translateS3(t, "library", "")
+	removed, err := RemovedObjects(repo, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) == 0 {
+		t.Fatal("RemovedObjects = none, want the bucket's objects")
+	}
+	for _, o := range removed {
+		if o.Provisioner != "s3" {
+			t.Errorf("removed %s from %s, want only the s3 provisioner's objects", o, o.Provisioner)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	configMaps := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(configMaps, meta.RESTScopeNamespace)
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(configMaps)
+	live.SetNamespace("dev")
+	live.SetName("library-config")
+	dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), live)
+	client := &kube.Client{Dynamic: dyn}
+
+	objects := []translate.InventoryObject{
+		{Kind: "ConfigMap", Namespace: "dev", Name: "library-config"},
+		{Kind: "ConfigMap", Namespace: "dev", Name: "already-gone"},
+		{Group: "example.com", Kind: "Unserved", Namespace: "dev", Name: "library"},
+	}
+	deleted, err := Purge(context.Background(), client, mapper, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deleted, objects[:1]) {
+		t.Errorf("Purge deleted %+v, want only the live ConfigMap", deleted)
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	if _, err := dyn.Resource(gvr).Namespace("dev").Get(context.Background(), "library-config", metav1.GetOptions{}); err == nil {
+		t.Error("library-config still exists after Purge")
+	}
+}
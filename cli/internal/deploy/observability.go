@@ -0,0 +1,45 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+// ObservabilityPath is the repo file declaring the platform observability
+// sidecar: its image, base config, and each cluster's collector endpoint.
+const ObservabilityPath = layout.ObservabilitySidecarPath
+
+// LoadObservability reads platform/observability/sidecar.yaml from the repo.
+// A missing file, or no repo, yields nil: no sidecar is injected.
+func LoadObservability(repoPath string) (*translate.Observability, error) {
+	if repoPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(repopath.Abs(repoPath, ObservabilityPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ObservabilityPath, err)
+	}
+	obs := &translate.Observability{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(obs); err != nil && err != io.EOF {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", ObservabilityPath, err).
+			WithDetails(map[string]string{"file": ObservabilityPath})
+	}
+	if obs.Sidecar.Image == "" {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s: sidecar.image is required", ObservabilityPath).
+			WithDetails(map[string]string{"file": ObservabilityPath, "field": "sidecar.image"})
+	}
+	return obs, nil
+}
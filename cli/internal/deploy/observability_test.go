@@ -0,0 +1,57 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func writeObservability(t *testing.T, content string) string {
+	t.Helper()
+	repo := t.TempDir()
+	path := filepath.Join(repo, filepath.FromSlash(ObservabilityPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestLoadObservability(t *testing.T) {
+	obs, err := LoadObservability(t.TempDir())
+	if err != nil || obs != nil {
+		t.Fatalf("missing file: obs = %v, err = %v; want nil, nil", obs, err)
+	}
+
+	repo := writeObservability(t, `sidecar:
+  image: otel/opentelemetry-collector-contrib:0.110.0
+  resources:
+    limits:
+      memory: 128Mi
+clusters:
+  vcluster-media:
+    endpoint: http://otel-collector.observability.svc:4317
+    default: true
+`)
+	obs, err = LoadObservability(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs.Sidecar.Image != "otel/opentelemetry-collector-contrib:0.110.0" || !obs.Clusters["vcluster-media"].Default {
+		t.Errorf("loaded %+v", obs)
+	}
+
+	for name, content := range map[string]string{
+		"unknown key":   "sidecar:\n  image: otel:1\n  imagee: otel:2\n",
+		"missing image": "clusters:\n  dev:\n    endpoint: http://collector:4317\n",
+	} {
+		_, err := LoadObservability(writeObservability(t, content))
+		if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+			t.Errorf("%s: ExitCode = %d, want %d (err: %v)", name, got, hcerrors.ExitValidation, err)
+		}
+	}
+}
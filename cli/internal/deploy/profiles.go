@@ -0,0 +1,267 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfilesFile is the file at an app repo's root that defines named deploy
+// profiles for 'hctl deploy run --profile'. It is separate from the user
+// config: it travels with the app and holds no credentials.
+const ProfilesFile = ".hctl.yaml"
+
+// Profile bundles the 'hctl deploy run' flags for one environment. Flags
+// given on the command line win over the profile's; unset fields leave the
+// flag defaults.
+type Profile struct {
+	Name string
+	// Cluster is the target vCluster; it wins over the workload's cluster
+	// annotation and the config's defaultCluster.
+	Cluster string
+	// Namespace is the deployment namespace; it wins over the workload's
+	// namespace annotation.
+	Namespace string
+	// Set are path=value overrides of the generated chart values, applied
+	// before those given with --set.
+	Set []string
+	// Watch, Timeout and SmokeTest stand in for --watch, --timeout and
+	// --smoke-test.
+	Watch     *bool
+	Timeout   time.Duration
+	SmokeTest *bool
+	// PinDigests stands in for --pin-digests: it wins over the workload's
+	// pin-digest annotation and registry.pinDigests.
+	PinDigests *bool
+}
+
+// Target returns target with the profile's cluster, namespace and value
+// overrides filled in where target leaves them unset. The profile's
+// overrides come first, so those in target win on the same path.
+func (p *Profile) Target(target Target) Target {
+	if p == nil {
+		return target
+	}
+	if target.Cluster == "" {
+		target.Cluster = p.Cluster
+	}
+	if target.Namespace == "" {
+		target.Namespace = p.Namespace
+	}
+	target.Set = append(append([]string(nil), p.Set...), target.Set...)
+	return target
+}
+
+// PinDigests reports whether to pin the workload's images to digests:
+// override (from --pin-digests or a profile) when set, else the workload's
+// pin-digest annotation, else def (registry.pinDigests).
+func PinDigests(workload *score.Workload, override *bool, def bool) (bool, error) {
+	if override != nil {
+		return *override, nil
+	}
+	return translate.PinDigest(workload, def)
+}
+
+// Profiles are the profiles defined in an app repo's ProfilesFile.
+type Profiles struct {
+	// Path is the file the profiles were read from; empty when there is
+	// none.
+	Path     string
+	Profiles map[string]*Profile
+}
+
+// Names returns the profile names, sorted.
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named profile. An unknown name is a validation error
+// listing the defined ones.
+func (p *Profiles) Get(name string) (*Profile, error) {
+	if profile, ok := p.Profiles[name]; ok {
+		return profile, nil
+	}
+	if p.Path == "" {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "unknown profile %q: no %s found in the app repo", name, ProfilesFile).
+			WithRemediation("define profiles under 'profiles:' in " + ProfilesFile + " at the app repo root")
+	}
+	names := p.Names()
+	available := "none are defined"
+	if len(names) > 0 {
+		available = "available: " + strings.Join(names, ", ")
+	}
+	return nil, hcerrors.New(hcerrors.ErrValidation, "unknown profile %q in %s; %s", name, p.Path, available).
+		WithDetails(map[string]any{"file": p.Path, "profile": name, "available": names})
+}
+
+// LoadProfiles reads ProfilesFile from the root of the git repo containing
+// dir, or from dir itself outside a git repo. A missing file yields no
+// profiles.
+func LoadProfiles(dir string) (*Profiles, error) {
+	root := dir
+	if repo, err := git.DetectRepo(dir); err == nil {
+		root = repo.Root
+	}
+	path := filepath.Join(root, ProfilesFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Profiles{Profiles: map[string]*Profile{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return ParseProfiles(path, data)
+}
+
+// profileFields are the keys a profile may set, for unknown-key errors.
+var profileFields = []string{"cluster", "namespace", "set", "watch", "timeout", "smokeTest", "pinDigests"}
+
+// profileProblem is one problem in a ProfilesFile.
+type profileProblem struct {
+	line  int
+	field string
+	msg   string
+}
+
+func (p profileProblem) String() string {
+	if p.field == "" {
+		return fmt.Sprintf("%s at line %d", p.msg, p.line)
+	}
+	return fmt.Sprintf("%s: %s at line %d", p.field, p.msg, p.line)
+}
+
+// profileParser collects the problems found while parsing a ProfilesFile.
+type profileParser struct {
+	problems []profileProblem
+}
+
+func (pp *profileParser) problem(field string, n *yaml.Node, format string, args ...any) {
+	pp.problems = append(pp.problems, profileProblem{line: n.Line, field: field, msg: fmt.Sprintf(format, args...)})
+}
+
+// ParseProfiles parses a ProfilesFile read from path. Every problem is
+// reported, as "field: message at line N", in one validation error.
+func ParseProfiles(path string, data []byte) (*Profiles, error) {
+	profiles := &Profiles{Path: path, Profiles: map[string]*Profile{}}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", path, err).
+			WithDetails(map[string]string{"file": path})
+	}
+	if len(doc.Content) == 0 {
+		return profiles, nil
+	}
+
+	pp := &profileParser{}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		pp.problem("", root, "expected a mapping with a profiles key")
+	}
+	for i := 0; root.Kind == yaml.MappingNode && i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "profiles" {
+			pp.problem(key.Value, key, "unknown field (expected profiles)")
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			pp.problem("profiles", value, "expected a mapping of profile names to profiles")
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			profile := &Profile{Name: value.Content[j].Value}
+			pp.profile(profile, "profiles."+profile.Name, value.Content[j+1])
+			profiles.Profiles[profile.Name] = profile
+		}
+	}
+
+	if len(pp.problems) > 0 {
+		sort.SliceStable(pp.problems, func(i, j int) bool { return pp.problems[i].line < pp.problems[j].line })
+		lines := make([]string, len(pp.problems))
+		for i, p := range pp.problems {
+			lines[i] = p.String()
+		}
+		first := pp.problems[0]
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s is invalid:\n  %s", path, strings.Join(lines, "\n  ")).
+			WithDetails(map[string]any{"file": path, "line": first.line, "field": first.field, "problems": lines})
+	}
+	return profiles, nil
+}
+
+// profile decodes the profile at prefix from body.
+func (pp *profileParser) profile(profile *Profile, prefix string, body *yaml.Node) {
+	if body.Kind != yaml.MappingNode {
+		pp.problem(prefix, body, "expected a mapping of %s", strings.Join(profileFields, ", "))
+		return
+	}
+	boolField := func(field string, n *yaml.Node) *bool {
+		var b bool
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!bool" || n.Decode(&b) != nil {
+			pp.problem(field, n, "expected true or false, got %q", n.Value)
+			return nil
+		}
+		return &b
+	}
+	stringField := func(field string, n *yaml.Node) string {
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!str" || n.Value == "" {
+			pp.problem(field, n, "expected a non-empty string")
+			return ""
+		}
+		return n.Value
+	}
+	for i := 0; i+1 < len(body.Content); i += 2 {
+		key, value := body.Content[i], body.Content[i+1]
+		field := prefix + "." + key.Value
+		switch key.Value {
+		case "cluster":
+			profile.Cluster = stringField(field, value)
+		case "namespace":
+			profile.Namespace = stringField(field, value)
+		case "watch":
+			profile.Watch = boolField(field, value)
+		case "smokeTest":
+			profile.SmokeTest = boolField(field, value)
+		case "pinDigests":
+			profile.PinDigests = boolField(field, value)
+		case "timeout":
+			d, err := time.ParseDuration(value.Value)
+			if value.Kind != yaml.ScalarNode || err != nil || d <= 0 {
+				pp.problem(field, value, "expected a positive duration such as 10m, got %q", value.Value)
+				continue
+			}
+			profile.Timeout = d
+		case "set":
+			if value.Kind != yaml.SequenceNode {
+				pp.problem(field, value, "expected a list of path=value overrides")
+				continue
+			}
+			for k, item := range value.Content {
+				itemField := fmt.Sprintf("%s[%d]", field, k)
+				if item.Kind != yaml.ScalarNode {
+					pp.problem(itemField, item, "expected a path=value string")
+					continue
+				}
+				if _, _, err := translate.ParseSet(item.Value); err != nil {
+					pp.problem(itemField, item, "%v", err)
+					continue
+				}
+				profile.Set = append(profile.Set, item.Value)
+			}
+		default:
+			pp.problem(field, key, "unknown field (expected one of %s)", strings.Join(profileFields, ", "))
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+const testProfiles = `profiles:
+  staging:
+    cluster: vcluster-staging
+    namespace: web-staging
+    set:
+      - deployment.replicas=1
+      - service.enabled=false
+    watch: true
+    timeout: 10m
+    smokeTest: false
+    pinDigests: true
+  prod: {}
+`
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	profiles, err := LoadProfiles(dir)
+	if err != nil || profiles.Path != "" || len(profiles.Profiles) != 0 {
+		t.Fatalf("no %s: %+v, %v", ProfilesFile, profiles, err)
+	}
+	if _, err := profiles.Get("staging"); err == nil || !strings.Contains(err.Error(), "no .hctl.yaml found") {
+		t.Errorf("profile without a file: err = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ProfilesFile), []byte(testProfiles), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = LoadProfiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profiles.Names(); !reflect.DeepEqual(got, []string{"prod", "staging"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	staging, err := profiles.Get("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Cluster != "vcluster-staging" || staging.Namespace != "web-staging" || staging.Timeout != 10*time.Minute ||
+		staging.Watch == nil || !*staging.Watch || staging.SmokeTest == nil || *staging.SmokeTest ||
+		staging.PinDigests == nil || !*staging.PinDigests ||
+		!reflect.DeepEqual(staging.Set, []string{"deployment.replicas=1", "service.enabled=false"}) {
+		t.Errorf("staging = %+v", staging)
+	}
+
+	_, err = profiles.Get("qa")
+	if hcerrors.ExitCode(err) != hcerrors.ExitValidation || !strings.Contains(err.Error(), "available: prod, staging") {
+		t.Errorf("unknown profile: err = %v, want the available profiles listed", err)
+	}
+}
+
+func TestParseProfilesValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "not a mapping",
+			data: "- staging\n",
+			want: []string{"expected a mapping with a profiles key at line 1"},
+		},
+		{
+			name: "unknown top-level field",
+			data: "profile:\n  staging: {}\n",
+			want: []string{"profile: unknown field (expected profiles) at line 1"},
+		},
+		{
+			name: "every problem reported with its line",
+			data: `profiles:
+  staging:
+    cluster: ""
+    watch: yes please
+    timeout: 0s
+    set:
+      - replicas
+      - deployment.replicas=1
+    smokeTests: true
+  prod: vcluster-prod
+`,
+			want: []string{
+				`profiles.staging.cluster: expected a non-empty string at line 3`,
+				`profiles.staging.watch: expected true or false, got "yes please" at line 4`,
+				`profiles.staging.timeout: expected a positive duration such as 10m, got "0s" at line 5`,
+				`profiles.staging.set[0]: "replicas" is not a path=value override at line 7`,
+				`profiles.staging.smokeTests: unknown field (expected one of cluster, namespace, set, watch, timeout, smokeTest, pinDigests) at line 9`,
+				`profiles.prod: expected a mapping of cluster, namespace, set, watch, timeout, smokeTest, pinDigests at line 10`,
+			},
+		},
+		{
+			name: "set value must be a scalar",
+			data: "profiles:\n  staging:\n    set:\n      - \"deployment.labels={a: b}\"\n",
+			want: []string{`profiles.staging.set[0]: "deployment.labels={a: b}": the value must be a scalar at line 4`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProfiles(".hctl.yaml", []byte(tt.data))
+			if hcerrors.ExitCode(err) != hcerrors.ExitValidation {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+			want := ".hctl.yaml is invalid:\n  " + strings.Join(tt.want, "\n  ")
+			if err.Error() != want {
+				t.Errorf("err =\n%s\nwant\n%s", err, want)
+			}
+		})
+	}
+}
+
+// TestProfilePrecedence checks flag > profile > annotation > config default
+// for the cluster, namespace and digest pinning.
+func TestProfilePrecedence(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name       string
+		flag       Target
+		flagPin    *bool
+		profile    *Profile
+		annotated  bool
+		defCluster string
+		defPin     bool
+
+		wantCluster, wantNamespace string
+		wantPin                    bool
+	}{
+		{
+			name:       "config default",
+			defCluster: "vcluster-default", defPin: true,
+			wantCluster: "vcluster-default", wantNamespace: "vcluster-default", wantPin: true,
+		},
+		{
+			name:       "annotation over config",
+			annotated:  true,
+			defCluster: "vcluster-default", defPin: true,
+			wantCluster: "vcluster-annotated", wantNamespace: "annotated-ns", wantPin: false,
+		},
+		{
+			name:        "profile over annotation",
+			annotated:   true,
+			profile:     &Profile{Cluster: "vcluster-profile", Namespace: "profile-ns", PinDigests: &yes},
+			wantCluster: "vcluster-profile", wantNamespace: "profile-ns", wantPin: true,
+		},
+		{
+			name:        "profile leaves unset fields to the annotation",
+			annotated:   true,
+			profile:     &Profile{Namespace: "profile-ns"},
+			wantCluster: "vcluster-annotated", wantNamespace: "profile-ns", wantPin: false,
+		},
+		{
+			name:        "flag over profile",
+			annotated:   true,
+			flag:        Target{Cluster: "vcluster-flag", Namespace: "flag-ns"},
+			flagPin:     &no,
+			profile:     &Profile{Cluster: "vcluster-profile", Namespace: "profile-ns", PinDigests: &yes},
+			wantCluster: "vcluster-flag", wantNamespace: "flag-ns", wantPin: false,
+		},
+		{
+			name:        "flag alone",
+			flag:        Target{Cluster: "vcluster-flag"},
+			flagPin:     &yes,
+			defCluster:  "vcluster-default",
+			wantCluster: "vcluster-flag", wantNamespace: "vcluster-flag", wantPin: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := ""
+			if tt.annotated {
+				annotations = `  annotations:
+    hctl.integratn.tech/cluster: vcluster-annotated
+    hctl.integratn.tech/namespace: annotated-ns
+    hctl.integratn.tech/pin-digest: "false"
+`
+			}
+			w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: web
+` + annotations + `containers:
+  main:
+    image: nginx:1.27
+`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{DefaultCluster: tt.defCluster}
+
+			target := tt.profile.Target(tt.flag)
+			result, err := translate.Translate(w, translate.Options{
+				Cluster:   targetCluster(w, target.Cluster, cfg),
+				Namespace: target.Namespace,
+				Registry:  provisioners.NewRegistry(),
+				Chart:     translate.DefaultChart(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.TargetCluster != tt.wantCluster || result.Namespace != tt.wantNamespace {
+				t.Errorf("cluster, namespace = %s, %s; want %s, %s", result.TargetCluster, result.Namespace, tt.wantCluster, tt.wantNamespace)
+			}
+
+			override := tt.flagPin
+			if override == nil && tt.profile != nil {
+				override = tt.profile.PinDigests
+			}
+			pin, err := PinDigests(w, override, tt.defPin)
+			if err != nil || pin != tt.wantPin {
+				t.Errorf("PinDigests = %v, %v; want %v", pin, err, tt.wantPin)
+			}
+		})
+	}
+}
+
+func TestProfileSetOverrides(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  main:
+    image: nginx:1.27
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := &Profile{Set: []string{"deployment.replicas=1", "deployment.revisionHistoryLimit=2"}}
+	target := profile.Target(Target{Set: []string{"deployment.replicas=3", "extra.note='007'"}})
+	if want := []string{"deployment.replicas=1", "deployment.revisionHistoryLimit=2", "deployment.replicas=3", "extra.note='007'"}; !reflect.DeepEqual(target.Set, want) {
+		t.Fatalf("Set = %v, want %v", target.Set, want)
+	}
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "vcluster-dev",
+		Set:      target.Set,
+		Registry: provisioners.NewRegistry(),
+		Chart:    translate.DefaultChart(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := result.Values["deployment"].(map[string]interface{})
+	if deployment["replicas"] != 3 || deployment["revisionHistoryLimit"] != 2 {
+		t.Errorf("deployment = %v, want the --set replicas over the profile's", deployment)
+	}
+	if note := result.Values["extra"].(map[string]interface{})["note"]; note != "007" {
+		t.Errorf("extra.note = %#v, want the quoted string", note)
+	}
+
+	_, err = translate.Translate(w, translate.Options{
+		Cluster:  "vcluster-dev",
+		Set:      []string{"deployment.replicas=2", "deployment.replicas.count=2"},
+		Registry: provisioners.NewRegistry(),
+		Chart:    translate.DefaultChart(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "deployment.replicas is not a map") {
+		t.Errorf("set through a scalar: err = %v", err)
+	}
+}
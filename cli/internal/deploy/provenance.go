@@ -0,0 +1,127 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// provenanceHistoryDepth bounds how far back git history is searched for the
+// pristine generated version of a file.
+const provenanceHistoryDepth = 50
+
+// FileDrift describes how an on-disk generated file relates to what hctl
+// last generated (the base) and what it would generate now.
+type FileDrift struct {
+	// Path is the file path relative to the repo root.
+	Path string
+	// Exists is false when the file is not on disk yet.
+	Exists bool
+	// Stamped is false for files without a provenance header (written by an
+	// older hctl or by hand); manual edits cannot be detected for them.
+	Stamped bool
+	// Manual is true when the on-disk body no longer matches its recorded hash.
+	Manual bool
+	// SpecChanged is true when regenerating would change the file's content
+	// relative to the base.
+	SpecChanged bool
+	// BaseKnown is false when the pristine generated content could not be
+	// recovered; ManualHunks then compare against the new output instead.
+	BaseKnown bool
+	// ManualHunks are the hand edits (base → disk) that regeneration discards.
+	ManualHunks []Hunk
+	// SpecHunks are the changes caused by score.yaml (base → new).
+	SpecHunks []Hunk
+}
+
+// DetectDrift compares the on-disk file at relPath against the newly
+// generated (stamped) content. The pristine base is the on-disk body when it
+// is untouched, the new output when score.yaml has not changed, or otherwise
+// the most recent git revision whose body matches the recorded hash.
+func DetectDrift(repoPath, relPath string, generated []byte) (*FileDrift, error) {
+	drift := &FileDrift{Path: relPath}
+	newBody, _, _ := translate.ParseGenerated(generated)
+
+	disk, err := os.ReadFile(repopath.Abs(repoPath, relPath))
+	if os.IsNotExist(err) {
+		drift.SpecChanged = true
+		drift.SpecHunks = DiffLines("", string(newBody))
+		return drift, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", relPath, err)
+	}
+	drift.Exists = true
+
+	diskBody, recorded, stamped := translate.ParseGenerated(disk)
+	drift.Stamped = stamped
+	if !stamped {
+		drift.BaseKnown = true
+		drift.SpecHunks = DiffLines(string(diskBody), string(newBody))
+		drift.SpecChanged = len(drift.SpecHunks) > 0
+		return drift, nil
+	}
+
+	var base []byte
+	switch {
+	case translate.ContentHash(diskBody) == recorded:
+		base = diskBody
+	case translate.ContentHash(newBody) == recorded:
+		base = newBody
+		drift.Manual = true
+	default:
+		drift.Manual = true
+		base = pristineFromHistory(repoPath, relPath, recorded)
+	}
+
+	if base == nil {
+		drift.ManualHunks = DiffLines(string(newBody), string(diskBody))
+		drift.SpecChanged = true
+		return drift, nil
+	}
+
+	drift.BaseKnown = true
+	if drift.Manual {
+		drift.ManualHunks = DiffLines(string(base), string(diskBody))
+	}
+	drift.SpecHunks = DiffLines(string(base), string(newBody))
+	drift.SpecChanged = len(drift.SpecHunks) > 0
+	return drift, nil
+}
+
+// pristineFromHistory searches the file's git history for the body that
+// hashes to recorded. Returns nil if none is found or there is no repo.
+func pristineFromHistory(repoPath, relPath, recorded string) []byte {
+	repo, err := git.DetectRepo(repoPath)
+	if err != nil {
+		return nil
+	}
+	// relPath is relative to repoPath, which may be below the repo root.
+	absPath, err := filepath.Abs(repopath.Abs(repoPath, relPath))
+	if err != nil {
+		return nil
+	}
+	repoRel, err := repo.RelPath(absPath)
+	if err != nil {
+		return nil
+	}
+	revs, err := repo.FileRevisions(repoRel, provenanceHistoryDepth)
+	if err != nil {
+		return nil
+	}
+	for _, rev := range revs {
+		data, err := repo.ShowFile(rev, repoRel)
+		if err != nil {
+			continue
+		}
+		body, _, _ := translate.ParseGenerated(data)
+		if translate.ContentHash(body) == recorded {
+			return body
+		}
+	}
+	return nil
+}
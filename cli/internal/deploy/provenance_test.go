@@ -0,0 +1,168 @@
+package deploy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+const valuesRel = "workloads/dev/addons/myapp/values.yaml"
+
+func writeRepoFile(t *testing.T, repo, rel string, data []byte) {
+	t.Helper()
+	p := filepath.Join(repo, rel)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func hunkLines(hunks []Hunk) string {
+	var lines []string
+	for _, h := range hunks {
+		lines = append(lines, h.Lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDetectDriftCleanRegeneration(t *testing.T) {
+	repo := t.TempDir()
+	v1 := translate.StampGenerated([]byte("image: nginx:1.26\nreplicas: 1\n"))
+	writeRepoFile(t, repo, valuesRel, v1)
+
+	d, err := DetectDrift(repo, valuesRel, v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Manual || d.SpecChanged {
+		t.Errorf("unchanged file: Manual=%v SpecChanged=%v", d.Manual, d.SpecChanged)
+	}
+
+	v2 := translate.StampGenerated([]byte("image: nginx:1.27\nreplicas: 1\n"))
+	d, err = DetectDrift(repo, valuesRel, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Manual {
+		t.Error("spec-only change reported as manual edit")
+	}
+	if want := "-image: nginx:1.26\n+image: nginx:1.27"; hunkLines(d.SpecHunks) != want {
+		t.Errorf("spec hunks = %q, want %q", hunkLines(d.SpecHunks), want)
+	}
+}
+
+func TestDetectDriftManualEdit(t *testing.T) {
+	repo := t.TempDir()
+	body := "image: nginx:1.27\nreplicas: 1\n"
+	generated := translate.StampGenerated([]byte(body))
+	edited := strings.Replace(string(generated), "replicas: 1", "replicas: 3", 1)
+	writeRepoFile(t, repo, valuesRel, []byte(edited))
+
+	d, err := DetectDrift(repo, valuesRel, generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Manual || !d.BaseKnown {
+		t.Fatalf("Manual=%v BaseKnown=%v, want both true", d.Manual, d.BaseKnown)
+	}
+	if d.SpecChanged || len(d.SpecHunks) != 0 {
+		t.Errorf("unexpected spec hunks: %q", hunkLines(d.SpecHunks))
+	}
+	if want := "-replicas: 1\n+replicas: 3"; hunkLines(d.ManualHunks) != want {
+		t.Errorf("manual hunks = %q, want %q", hunkLines(d.ManualHunks), want)
+	}
+	if d.ManualHunks[0].OldStart != 2 {
+		t.Errorf("hunk starts at line %d, want 2", d.ManualHunks[0].OldStart)
+	}
+}
+
+func TestDetectDriftManualAndSpecChange(t *testing.T) {
+	repo := t.TempDir()
+	gitIn(t, repo, "init", "-q")
+
+	v1 := translate.StampGenerated([]byte("image: nginx:1.26\nreplicas: 1\n"))
+	writeRepoFile(t, repo, valuesRel, v1)
+	gitIn(t, repo, "add", "-A")
+	gitIn(t, repo, "commit", "-q", "-m", "deploy myapp")
+
+	edited := strings.Replace(string(v1), "replicas: 1", "replicas: 3", 1)
+	writeRepoFile(t, repo, valuesRel, []byte(edited))
+
+	v2 := translate.StampGenerated([]byte("image: nginx:1.27\nreplicas: 1\n"))
+	d, err := DetectDrift(repo, valuesRel, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Manual || !d.BaseKnown || !d.SpecChanged {
+		t.Fatalf("Manual=%v BaseKnown=%v SpecChanged=%v, want all true", d.Manual, d.BaseKnown, d.SpecChanged)
+	}
+	if want := "-replicas: 1\n+replicas: 3"; hunkLines(d.ManualHunks) != want {
+		t.Errorf("manual hunks = %q, want %q", hunkLines(d.ManualHunks), want)
+	}
+	if want := "-image: nginx:1.26\n+image: nginx:1.27"; hunkLines(d.SpecHunks) != want {
+		t.Errorf("spec hunks = %q, want %q", hunkLines(d.SpecHunks), want)
+	}
+}
+
+func TestDetectDriftManualAndSpecChangeWithoutHistory(t *testing.T) {
+	repo := t.TempDir()
+	v1 := translate.StampGenerated([]byte("image: nginx:1.26\nreplicas: 1\n"))
+	edited := strings.Replace(string(v1), "replicas: 1", "replicas: 3", 1)
+	writeRepoFile(t, repo, valuesRel, []byte(edited))
+
+	v2 := translate.StampGenerated([]byte("image: nginx:1.27\nreplicas: 1\n"))
+	d, err := DetectDrift(repo, valuesRel, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Manual || d.BaseKnown {
+		t.Fatalf("Manual=%v BaseKnown=%v, want true/false", d.Manual, d.BaseKnown)
+	}
+	if len(d.ManualHunks) == 0 {
+		t.Error("expected manual hunks against the new output")
+	}
+}
+
+func TestDetectDriftUnstampedFile(t *testing.T) {
+	repo := t.TempDir()
+	writeRepoFile(t, repo, valuesRel, []byte("image: nginx:1.26\n"))
+
+	d, err := DetectDrift(repo, valuesRel, translate.StampGenerated([]byte("image: nginx:1.27\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Stamped || d.Manual {
+		t.Errorf("Stamped=%v Manual=%v, want false/false", d.Stamped, d.Manual)
+	}
+	if !d.SpecChanged {
+		t.Error("expected spec change for legacy file")
+	}
+}
+
+func TestDetectDriftNewFile(t *testing.T) {
+	d, err := DetectDrift(t.TempDir(), valuesRel, translate.StampGenerated([]byte("image: nginx\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Exists || d.Manual || !d.SpecChanged {
+		t.Errorf("Exists=%v Manual=%v SpecChanged=%v", d.Exists, d.Manual, d.SpecChanged)
+	}
+}
@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+// ResourcePolicyPath is the repo file declaring the platform's default
+// container resources and per-container maximums.
+const ResourcePolicyPath = layout.ResourcePolicyPath
+
+// LoadResourcePolicy reads platform/policies/resources.yaml from the repo,
+// placing each vCluster in the environment its request declares. A missing
+// file, or no repo, yields nil: no defaults and no maximums apply.
+func LoadResourcePolicy(repoPath string) (*translate.ResourcePolicy, error) {
+	if repoPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(repopath.Abs(repoPath, ResourcePolicyPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ResourcePolicyPath, err)
+	}
+	p, err := parseResourcePolicy(data)
+	if err != nil {
+		return nil, err
+	}
+	if p.ClusterEnvironments, err = layout.DeclaredEnvironments(repoPath); err != nil {
+		return nil, fmt.Errorf("reading vCluster environments: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s: %v", ResourcePolicyPath, err).
+			WithDetails(map[string]string{"file": ResourcePolicyPath})
+	}
+	return p, nil
+}
+
+// parseResourcePolicy decodes the contents of platform/policies/resources.yaml,
+// rejecting unknown fields. Call Validate once ClusterEnvironments is set.
+func parseResourcePolicy(data []byte) (*translate.ResourcePolicy, error) {
+	p := &translate.ResourcePolicy{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && err != io.EOF {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", ResourcePolicyPath, err).
+			WithDetails(map[string]string{"file": ResourcePolicyPath})
+	}
+	return p, nil
+}
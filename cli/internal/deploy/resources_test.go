@@ -0,0 +1,65 @@
+package deploy
+
+import (
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func TestLoadResourcePolicy(t *testing.T) {
+	p, err := LoadResourcePolicy(t.TempDir())
+	if err != nil || p != nil {
+		t.Fatalf("missing file: policy = %v, err = %v; want nil, nil", p, err)
+	}
+	if p, err := LoadResourcePolicy(""); err != nil || p != nil {
+		t.Fatalf("no repo: policy = %v, err = %v; want nil, nil", p, err)
+	}
+
+	repo := t.TempDir()
+	writeRepoFile(t, repo, ResourcePolicyPath, []byte(`default:
+  defaults:
+    requests:
+      cpu: 100m
+      memory: 128Mi
+  maximums:
+    cpu: "2"
+    memory: 4Gi
+environments:
+  production:
+    maximums:
+      memory: 8Gi
+`))
+	writeRepoFile(t, repo, "platform/vclusters/media.yaml", []byte(`apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: media
+spec:
+  name: media
+  integrations:
+    argocd:
+      environment: production
+`))
+	p, err = LoadResourcePolicy(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Rules("media").Maximums["memory"]; got != "8Gi" {
+		t.Errorf("media memory maximum = %q, want the production environment's 8Gi", got)
+	}
+	if got := p.Rules("dev").Maximums["memory"]; got != "4Gi" {
+		t.Errorf("dev memory maximum = %q, want the default 4Gi", got)
+	}
+
+	for name, content := range map[string]string{
+		"unknown key":      "default:\n  maximum:\n    cpu: \"2\"\n",
+		"bad quantity":     "default:\n  maximums:\n    memory: plenty\n",
+		"default over max": "default:\n  defaults:\n    limits:\n      memory: 1Gi\n  maximums:\n    memory: 512Mi\n",
+	} {
+		repo := t.TempDir()
+		writeRepoFile(t, repo, ResourcePolicyPath, []byte(content))
+		_, err := LoadResourcePolicy(repo)
+		if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+			t.Errorf("%s: ExitCode = %d, want %d (err: %v)", name, got, hcerrors.ExitValidation, err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// WorkloadSchedule reads the x-hctl.schedule of a deployed workload back
+// from its generated values file. It returns nil when the workload has no
+// schedule or no values file.
+func WorkloadSchedule(repoPath, cluster, workload string) (*translate.Schedule, error) {
+	relPath := translate.ValuesPath(cluster, workload)
+	data, err := os.ReadFile(repopath.Abs(repoPath, relPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", relPath, err)
+	}
+	body, _, _ := translate.ParseGenerated(data)
+	values, err := parseValues(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", relPath, err)
+	}
+	return translate.ScheduleFromValues(values)
+}
@@ -0,0 +1,131 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// ClusterEnvironments maps clusters to their environment: the one their
+// vCluster request declares in the repo, overridden by
+// platform.environments in the hctl config.
+func ClusterEnvironments(cfg *config.Config) (map[string]string, error) {
+	envs := map[string]string{}
+	if cfg.RepoPath != "" {
+		declared, err := layout.DeclaredEnvironments(cfg.RepoPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading vCluster environments: %w", err)
+		}
+		for cluster, env := range declared {
+			envs[cluster] = env
+		}
+	}
+	for cluster, env := range cfg.Platform.Environments {
+		envs[cluster] = env
+	}
+	return envs, nil
+}
+
+// SecretScopeChange is a 1Password item a workload in the repo reads that
+// environment-scoped naming renames or refuses.
+type SecretScopeChange struct {
+	Cluster     string `json:"cluster" yaml:"cluster"`
+	Environment string `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Workload    string `json:"workload" yaml:"workload"`
+	// Item is the item the rendered ExternalSecret reads today.
+	Item string `json:"item" yaml:"item"`
+	// NewItem is the item the next deploy reads; empty when there is none.
+	NewItem string `json:"newItem,omitempty" yaml:"newItem,omitempty"`
+	// Reason says why the item changes.
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// SecretScopeMigrations lists the 1Password items read by the workloads
+// rendered in the repo that change under environment-scoped naming:
+// unqualified shared items (shared-postgres), which become the cluster
+// environment's, and items qualified with another environment, which
+// deploys refuse without params.allowCrossEnvironment. The list is sorted
+// by cluster, workload and item.
+func SecretScopeMigrations(repoPath string, environments map[string]string) ([]SecretScopeChange, error) {
+	clusters, err := layout.Active().Clusters(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("listing clusters: %w", err)
+	}
+	known := make([]string, 0, len(environments))
+	for _, env := range environments {
+		known = append(known, env)
+	}
+	legacy := map[string]string{}
+	for _, typ := range provisioners.SharedTypes() {
+		legacy["shared-"+typ] = typ
+	}
+
+	var changes []SecretScopeChange
+	for _, cluster := range clusters {
+		workloads, err := ListWorkloads(repoPath, cluster)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", AddonsPath(cluster), err)
+		}
+		env := environments[cluster]
+		for _, workload := range workloads {
+			data, err := os.ReadFile(repopath.Abs(repoPath, translate.ValuesPath(cluster, workload)))
+			if err != nil {
+				continue
+			}
+			values, err := parseValues(data)
+			if err != nil {
+				continue
+			}
+			for _, req := range valuesSecrets(values) {
+				c := SecretScopeChange{Cluster: cluster, Environment: env, Workload: workload, Item: req.Item}
+				if typ, ok := legacy[req.Item]; ok {
+					if env == "" {
+						c.Reason = "shared item without an environment, and the cluster has none; set platform.environments"
+					} else {
+						c.NewItem = provisioners.SharedItem(typ, env)
+						c.Reason = "shared item is now per environment"
+					}
+					changes = append(changes, c)
+					continue
+				}
+				if other := provisioners.ItemEnvironment(req.Item, known); env != "" && other != "" && other != env {
+					c.Reason = fmt.Sprintf("item of environment %s is refused without params.allowCrossEnvironment", other)
+					changes = append(changes, c)
+				}
+			}
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Item < b.Item
+	})
+	return changes, nil
+}
+
+// valuesSecrets returns the 1Password items read by the ExternalSecrets in
+// values' extraObjects.
+func valuesSecrets(values map[string]interface{}) []provisioners.SecretRequirement {
+	objects, _ := values["extraObjects"].([]interface{})
+	result := &provisioners.ProvisionResult{}
+	for _, obj := range objects {
+		if m, ok := obj.(map[string]interface{}); ok {
+			result.Manifests = append(result.Manifests, m)
+		}
+	}
+	return result.SecretRequirements()
+}
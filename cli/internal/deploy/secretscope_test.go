@@ -0,0 +1,67 @@
+package deploy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+func TestSecretScopeMigrations(t *testing.T) {
+	repo := t.TempDir()
+	deployDB := func(cluster, workload, resource string) {
+		t.Helper()
+		w, err := translate.Load(strings.NewReader("apiVersion: score.dev/v1b1\nmetadata:\n  name: " + workload +
+			"\ncontainers:\n  web:\n    image: nginx:1.27\nresources:\n  db:\n" + resource))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := translate.Translate(w, translate.Options{Cluster: cluster, Registry: provisioners.NewRegistry(), Chart: translate.DefaultChart()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := WriteResult(result, repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deployDB("dev", "wiki", "    type: postgres\n    params:\n      item: shared-postgres\n")
+	deployDB("dev", "cache", "    type: redis\n    params:\n      item: shared-redis-prod\n")
+	deployDB("dev", "blog", "    type: postgres\n")
+	deployDB("prod", "wiki", "    type: postgres\n    params:\n      item: shared-postgres-prod\n")
+	deployDB("lab", "wiki", "    type: postgres\n    params:\n      item: shared-postgres\n")
+
+	changes, err := SecretScopeMigrations(repo, map[string]string{"dev": "dev", "prod": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Cluster+"/"+c.Workload+": "+c.Item+" → "+c.NewItem)
+	}
+	want := []string{
+		"dev/cache: shared-redis-prod → ",
+		"dev/wiki: shared-postgres → shared-postgres-dev",
+		"lab/wiki: shared-postgres → ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretScopeMigrations() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(changes) == 3 && !strings.Contains(changes[0].Reason, "allowCrossEnvironment") {
+		t.Errorf("cross-environment reason = %q", changes[0].Reason)
+	}
+}
+
+func TestClusterEnvironments(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Platform.Environments = map[string]string{"media": "prod"}
+	envs, err := ClusterEnvironments(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(envs, map[string]string{"media": "prod"}) {
+		t.Errorf("ClusterEnvironments() = %v", envs)
+	}
+}
@@ -0,0 +1,63 @@
+package deploy
+
+import (
+	"os"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// WorkloadSummary is what a workload's values.yaml says it runs.
+type WorkloadSummary struct {
+	Name string
+	// Images are the primary container's image, then each additional
+	// container's, in values order.
+	Images []string
+	// Routes are the HTTPRoute hostnames, sorted; none when the route is
+	// disabled.
+	Routes []string
+}
+
+// SummarizeWorkload reads the images and routes of a workload in cluster
+// from its values.yaml. A workload without one, such as a chart workload,
+// has neither.
+func SummarizeWorkload(repoPath, cluster, workload string) (WorkloadSummary, error) {
+	s := WorkloadSummary{Name: workload}
+	data, err := os.ReadFile(repopath.Abs(repoPath, translate.ValuesPath(cluster, workload)))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	body, _, _ := translate.ParseGenerated(data)
+	values, err := parseValues(body)
+	if err != nil {
+		return s, err
+	}
+
+	if ref := imageRef(values, ""); ref != "" {
+		s.Images = append(s.Images, ref)
+	}
+	items, _ := workloadSpec(values)["additionalContainers"].([]interface{})
+	for _, item := range items {
+		if name, ok := asMap(item)["name"].(string); ok {
+			if ref := imageRef(values, name); ref != "" {
+				s.Images = append(s.Images, ref)
+			}
+		}
+	}
+
+	route := asMap(values["httpRoute"])
+	if enabled, _ := route["enabled"].(bool); enabled {
+		hosts, _ := route["hostnames"].([]interface{})
+		for _, h := range hosts {
+			if host, ok := h.(string); ok {
+				s.Routes = append(s.Routes, host)
+			}
+		}
+		sort.Strings(s.Routes)
+	}
+	return s, nil
+}
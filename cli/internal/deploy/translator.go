@@ -1,499 +1,343 @@
-// Package deploy translates Score workloads into platform-native Stakater Application
-// chart values and supporting resources (ExternalSecrets, HTTPRoutes, PVCs, Certificates).
+// Package deploy connects Score translation (pkg/translate) to the gitops repo:
+// it fills translation options from hctl config, writes results into the
+// workloads tree, and detects drift in previously generated files.
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
-	"strings"
+	"time"
 
 	"github.com/jamesatintegratnio/hctl/internal/config"
-	"github.com/jamesatintegratnio/hctl/internal/score"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/metrics"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/provcache"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
 	"gopkg.in/yaml.v3"
 )
 
 // TranslateResult holds the output of a Score-to-Stakater translation.
-type TranslateResult struct {
-	// WorkloadName is the name of the workload from score.yaml metadata.
-	WorkloadName string
-	// TargetCluster is the vCluster this workload targets.
-	TargetCluster string
-	// Namespace is the deployment namespace.
+type TranslateResult = translate.Result
+
+// Target is where a workload deploys and how its values are overridden,
+// from flags or a deploy profile. Empty fields fall back to the workload's
+// cluster and namespace annotations, then to the config's defaultCluster.
+type Target struct {
+	Cluster   string
 	Namespace string
-	// StakaterValues is the Stakater Application chart values.yaml content.
-	StakaterValues map[string]interface{}
-	// AddonsEntry is the entry for workloads/<cluster>/addons.yaml.
-	AddonsEntry map[string]interface{}
-	// Files maps relative file paths to their content for writing.
-	Files map[string][]byte
+	// Set are path=value overrides of the generated chart values.
+	Set []string
 }
 
-// secretRefRegex matches provisioner output patterns like $(secret-name:key).
-var secretRefRegex = regexp.MustCompile(`^\$\(([^:]+):([^)]+)\)$`)
-
-// scoreVarRegex matches Score resource reference patterns like ${resources.db.host}.
-var scoreVarRegex = regexp.MustCompile(`\$\{resources\.([^.]+)\.([^}]+)\}`)
-
-// Translate converts a Score workload into platform resources.
-func Translate(workload *score.Workload, cluster string) (*TranslateResult, error) {
-	cfg := config.Get()
-
-	if cluster == "" {
-		cluster = workload.TargetCluster()
-	}
-	if cluster == "" {
-		cluster = cfg.DefaultCluster
+// For returns the target of w, loaded from a score.yaml holding documents
+// workloads. With a single document that is t; with several, the cluster
+// annotation of w wins over t.Cluster, so a --cluster flag or profile
+// cluster only targets the documents that do not name their own.
+func (t Target) For(w *score.Workload, documents int) Target {
+	if documents > 1 && w.TargetCluster() != "" {
+		t.Cluster = ""
 	}
-	if cluster == "" {
-		return nil, fmt.Errorf("no target cluster specified — use --cluster, set hctl.integratn.tech/cluster annotation, or configure defaultCluster")
-	}
-
-	namespace := cluster // workload namespace defaults to cluster name
-	if ns, ok := workload.Metadata.Annotations["hctl.integratn.tech/namespace"]; ok && ns != "" {
-		namespace = ns
-	}
-
-	// Run provisioners for all resources
-	registry := provisioners.NewRegistry()
-	allOutputs := make(map[string]map[string]string) // resource-name → key → value
-	var extraObjects []map[string]interface{}
-
-	for resName, res := range workload.Resources {
-		prov, err := registry.Get(res.Type)
-		if err != nil {
-			return nil, fmt.Errorf("resource %q: %w", resName, err)
-		}
-
-		result, err := prov.Provision(resName, res, workload.Metadata.Name)
-		if err != nil {
-			return nil, fmt.Errorf("provisioning resource %q: %w", resName, err)
-		}
+	return t
+}
 
-		allOutputs[resName] = result.Outputs
+// RunOptions is how a translation runs, as opposed to where its workload
+// deploys.
+type RunOptions struct {
+	// Concurrency bounds the provisioners run at once; zero uses GOMAXPROCS.
+	Concurrency int
+	// Mode is ModeRender for render and diff, which must not reach external
+	// systems.
+	Mode provisioners.Mode
+	// Cache lets ModeRender translations reuse provisioner results from the
+	// user cache directory (see provcache).
+	Cache bool
+	// Digests pins images, as returned by ResolveImageDigests; nil keeps tags.
+	Digests map[string]string
+	// Timer, when set, records the translation and each provisioner.
+	Timer *metrics.Timer
+}
 
-		// Add namespace to manifests
-		for _, m := range result.Manifests {
-			if meta, ok := m["metadata"].(map[string]interface{}); ok {
-				if _, hasNs := meta["namespace"]; !hasNs {
-					meta["namespace"] = namespace
-				}
-			}
-			extraObjects = append(extraObjects, m)
+// Translate converts a Score workload into platform resources for target,
+// filling the translation options from the hctl config and run. scoreFile
+// locates the app repo whose origin URL is recorded on the generated
+// objects.
+//
+// From the repo, Translate reads the observability sidecar in
+// platform/observability/sidecar.yaml and the container resource defaults
+// and maximums in platform/policies/resources.yaml, when present. s3
+// buckets already declared by another workload on the cluster fail the
+// translation, and shared volumes resolve to their owner on the cluster
+// (see SharedVolumeOwners). For workloads with sidecar containers, the
+// target cluster's Kubernetes version comes from platform.kubernetesVersion
+// or, when that is unset, from the live vcluster.
+func Translate(workload *score.Workload, scoreFile string, target Target, run RunOptions) (*TranslateResult, error) {
+	cfg := config.Get()
+	cluster := target.Cluster
+	opts := TranslateOptions(cfg, cluster)
+	opts.Namespace = target.Namespace
+	opts.Set = target.Set
+	opts.Concurrency = run.Concurrency
+	opts.Mode = run.Mode
+	if run.Cache && run.Mode == provisioners.ModeRender {
+		if c := openCache(run.Timer); c != nil {
+			opts.Cache = c
 		}
 	}
-
-	// Build Stakater values
-	values := buildStakaterValues(workload, allOutputs, namespace, extraObjects)
-
-	// Build addons.yaml entry
-	addonsEntry := map[string]interface{}{
-		"enabled":         true,
-		"namespace":       namespace,
-		"chartRepository": "https://stakater.github.io/stakater-charts",
-		"chartName":       "application",
-		"defaultVersion":  "6.14.0",
-	}
-
-	// Build file map
-	result := &TranslateResult{
-		WorkloadName:   workload.Metadata.Name,
-		TargetCluster:  cluster,
-		Namespace:      namespace,
-		StakaterValues: values,
-		AddonsEntry:    addonsEntry,
-		Files:          make(map[string][]byte),
+	if opts.KubernetesVersion == "" && hasSidecars(workload) {
+		opts.KubernetesVersion = liveKubernetesVersion(cfg, targetCluster(workload, cluster, cfg))
 	}
-
-	valuesData, err := yaml.Marshal(values)
+	opts.ImageDigests = run.Digests
+	opts.SourceRepo = git.OriginURL(filepath.Dir(scoreFile))
+	obs, err := LoadObservability(cfg.RepoPath)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling values: %w", err)
+		return nil, err
 	}
-
-	valuesPath := filepath.Join("workloads", cluster, "addons", workload.Metadata.Name, "values.yaml")
-	result.Files[valuesPath] = valuesData
-
-	return result, nil
-}
-
-// buildStakaterValues creates the Stakater Application chart values.
-func buildStakaterValues(w *score.Workload, allOutputs map[string]map[string]string, namespace string, extraObjects []map[string]interface{}) map[string]interface{} {
-	values := map[string]interface{}{
-		"applicationName": w.Metadata.Name,
+	opts.Observability = obs
+	if opts.ResourcePolicy, err = LoadResourcePolicy(cfg.RepoPath); err != nil {
+		return nil, err
 	}
-
-	// --- Deployment section ---
-	deployment := map[string]interface{}{}
-
-	// Use the first (or only) container for the primary deployment
-	var primaryContainer score.Container
-	var containerName string
-	var additionalContainers []map[string]interface{}
-
-	// Sort container names for deterministic output
-	containerNames := make([]string, 0, len(w.Containers))
-	for name := range w.Containers {
-		containerNames = append(containerNames, name)
+	if opts.Environments, err = ClusterEnvironments(cfg); err != nil {
+		return nil, err
 	}
-	sort.Strings(containerNames)
-
-	for i, name := range containerNames {
-		c := w.Containers[name]
-		if i == 0 {
-			primaryContainer = c
-			containerName = name
-			_ = containerName
-		} else {
-			additionalContainers = append(additionalContainers, buildContainerSpec(name, c, allOutputs))
+	if cfg.RepoPath != "" {
+		if opts.SharedVolumes, err = SharedVolumeOwners(cfg.RepoPath, targetCluster(workload, cluster, cfg)); err != nil {
+			return nil, err
 		}
 	}
-
-	// Image
-	if primaryContainer.Image != "" && primaryContainer.Image != "." {
-		parts := strings.SplitN(primaryContainer.Image, ":", 2)
-		deployment["image"] = map[string]interface{}{
-			"repository": parts[0],
-		}
-		if len(parts) == 2 {
-			deployment["image"].(map[string]interface{})["tag"] = parts[1]
-		} else {
-			deployment["image"].(map[string]interface{})["tag"] = "latest"
-		}
+	if run.Timer != nil {
+		opts.OnProvision = run.Timer.Provisioner
+		defer run.Timer.Phase(metrics.PhaseTranslate)()
 	}
-
-	// Ports from service
-	if w.Service != nil && len(w.Service.Ports) > 0 {
-		var ports []map[string]interface{}
-		portNames := make([]string, 0, len(w.Service.Ports))
-		for name := range w.Service.Ports {
-			portNames = append(portNames, name)
-		}
-		sort.Strings(portNames)
-
-		for _, name := range portNames {
-			p := w.Service.Ports[name]
-			port := map[string]interface{}{
-				"name":          name,
-				"containerPort": p.Port,
-				"protocol":      "TCP",
-			}
-			if p.Protocol != "" {
-				port["protocol"] = p.Protocol
-			}
-			ports = append(ports, port)
-		}
-		deployment["ports"] = ports
+	result, err := translate.Translate(workload, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	// Environment variables — resolve Score resource references
-	if len(primaryContainer.Variables) > 0 {
-		env := map[string]interface{}{}
-		varNames := make([]string, 0, len(primaryContainer.Variables))
-		for name := range primaryContainer.Variables {
-			varNames = append(varNames, name)
-		}
-		sort.Strings(varNames)
-
-		for _, name := range varNames {
-			val := primaryContainer.Variables[name]
-			resolved := resolveVariableValue(val, allOutputs)
-			env[name] = resolved
-		}
-		deployment["env"] = env
+	if err := carryCommit(cfg.RepoPath, result); err != nil {
+		return nil, err
 	}
-
-	// Resources
-	if primaryContainer.Resources != nil {
-		resources := map[string]interface{}{}
-		if primaryContainer.Resources.Requests != nil {
-			resources["requests"] = primaryContainer.Resources.Requests
-		}
-		if primaryContainer.Resources.Limits != nil {
-			resources["limits"] = primaryContainer.Resources.Limits
-		}
-		deployment["resources"] = resources
+	if err := CheckBucketCollisions(cfg.RepoPath, result); err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	// Volume mounts
-	if len(primaryContainer.Volumes) > 0 {
-		volumes := map[string]interface{}{}
-		volumeMounts := map[string]interface{}{}
-
-		volNames := make([]string, 0, len(primaryContainer.Volumes))
-		for name := range primaryContainer.Volumes {
-			volNames = append(volNames, name)
-		}
-		sort.Strings(volNames)
-
-		for _, name := range volNames {
-			vol := primaryContainer.Volumes[name]
-			// source refers to a Score resource, resolve to PVC name
-			pvcName := vol.Source
-			if outputs, ok := allOutputs[vol.Source]; ok {
-				if src, ok := outputs["source"]; ok {
-					pvcName = src
-				}
-			}
-			volumes[name] = map[string]interface{}{
-				"persistentVolumeClaim": map[string]interface{}{
-					"claimName": pvcName,
-				},
-			}
-			mount := map[string]interface{}{
-				"mountPath": vol.Path,
-			}
-			if vol.ReadOnly {
-				mount["readOnly"] = true
-			}
-			volumeMounts[name] = mount
-		}
-		deployment["volumes"] = volumes
-		deployment["volumeMounts"] = volumeMounts
+// openCache opens the provisioner cache, counting its lookups in timer when
+// one is set. It returns nil for development builds, and when the cache
+// cannot be opened: rendering without it only takes longer.
+func openCache(timer *metrics.Timer) *provcache.Cache {
+	c, err := provcache.Open()
+	if err != nil {
+		logging.L().Debug("provisioner cache disabled", "error", err)
+		return nil
 	}
-
-	// Additional containers
-	if len(additionalContainers) > 0 {
-		deployment["additionalContainers"] = additionalContainers
+	if c != nil && timer != nil {
+		c.OnLookup = timer.CacheLookup
 	}
+	return c
+}
 
-	values["deployment"] = deployment
-
-	// --- Service section ---
-	if w.Service != nil && len(w.Service.Ports) > 0 {
-		var servicePorts []map[string]interface{}
-		portNames := make([]string, 0, len(w.Service.Ports))
-		for name := range w.Service.Ports {
-			portNames = append(portNames, name)
-		}
-		sort.Strings(portNames)
-
-		for _, name := range portNames {
-			p := w.Service.Ports[name]
-			sp := map[string]interface{}{
-				"name":       name,
-				"port":       p.Port,
-				"targetPort": p.Port,
-				"protocol":   "TCP",
-			}
-			if p.TargetPort > 0 {
-				sp["targetPort"] = p.TargetPort
-			}
-			if p.Protocol != "" {
-				sp["protocol"] = p.Protocol
-			}
-			servicePorts = append(servicePorts, sp)
-		}
-		values["service"] = map[string]interface{}{
-			"ports": servicePorts,
+// MetricsCounts sizes a translation result for metrics.Timer.Summary:
+// generated files and bytes, and extra objects by kind. extra adds files
+// written alongside the result, such as addons.yaml.
+func MetricsCounts(workload *score.Workload, result *TranslateResult, extra map[string]int) metrics.Counts {
+	c := metrics.Counts{
+		Workloads: 1,
+		Resources: len(workload.Resources),
+		Files:     make(map[string]int, len(result.Files)+len(extra)),
+		Kinds:     map[string]int{},
+	}
+	for path, data := range result.Files {
+		c.Files[path] = len(data)
+	}
+	for path, n := range extra {
+		c.Files[path] = n
+	}
+	objects, _ := result.Values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		if m, ok := obj.(map[string]interface{}); ok {
+			kind, _ := m["kind"].(string)
+			c.Kinds[kind]++
 		}
 	}
+	return c
+}
 
-	// --- Persistence (disabled, managed via extraObjects) ---
-	values["persistence"] = map[string]interface{}{
-		"enabled": false,
-	}
+// hctlVersion is the running hctl version, set by SetVersion.
+var hctlVersion = "dev"
 
-	// --- HTTPRoute and Certificate from route resources ---
-	for _, res := range w.Resources {
-		if res.Type == "route" {
-			host, _ := res.Params["host"].(string)
-			port := 8080
-			if p, ok := res.Params["port"]; ok {
-				if pi, ok := p.(int); ok {
-					port = pi
-				}
-				if pf, ok := p.(float64); ok {
-					port = int(pf)
-				}
-			}
-			path := "/"
-			if p, ok := res.Params["path"].(string); ok {
-				path = p
-			}
+// SetVersion records the running hctl version for the manifest inventory.
+func SetVersion(version string) {
+	hctlVersion = version
+}
 
-			if host != "" {
-				values["httpRoute"] = map[string]interface{}{
-					"enabled": true,
-					"parentRefs": []map[string]interface{}{
-						{
-							"name":        "nginx-gateway",
-							"namespace":   "nginx-gateway",
-							"sectionName": "https-public",
-						},
-					},
-					"hostnames": []string{host},
-					"rules": []map[string]interface{}{
-						{
-							"backendRefs": []map[string]interface{}{
-								{
-									"name": w.Metadata.Name,
-									"port": port,
-								},
-							},
-							"matches": []map[string]interface{}{
-								{
-									"path": map[string]interface{}{
-										"type":  "PathPrefix",
-										"value": path,
-									},
-								},
-							},
-						},
-					},
-				}
-
-				// Auto-generate certificate
-				values["certificate"] = map[string]interface{}{
-					"enabled":    true,
-					"secretName": w.Metadata.Name + "-tls",
-					"dnsNames":   []string{host},
-					"commonName": host,
-					"usages":     []string{"digital signature", "key encipherment", "server auth"},
-					"issuerRef": map[string]interface{}{
-						"name": "letsencrypt-prod",
-						"kind": "ClusterIssuer",
-					},
-				}
-			}
-			break // only use the first route resource
-		}
+// TranslateOptions maps hctl config onto translate.Options.
+func TranslateOptions(cfg *config.Config, cluster string) translate.Options {
+	return translate.Options{
+		Cluster:           cluster,
+		HctlVersion:       hctlVersion,
+		DefaultCluster:    cfg.DefaultCluster,
+		Domain:            cfg.Platform.Domain,
+		NodePoolLabel:     cfg.Platform.NodePoolLabel,
+		Registry:          provisioners.NewRegistry(),
+		Chart:             translate.DefaultChart(),
+		Logger:            logging.L(),
+		KubernetesVersion: cfg.Platform.KubernetesVersion,
 	}
+}
 
-	// --- Extra objects (provisioner manifests: ExternalSecrets, PVCs) ---
-	if len(extraObjects) > 0 {
-		var extras []interface{}
-		for _, obj := range extraObjects {
-			extras = append(extras, obj)
+// hasSidecars reports whether any container has x-hctl.role: sidecar.
+func hasSidecars(workload *score.Workload) bool {
+	for _, c := range workload.Containers {
+		if c.Extensions != nil && c.Extensions.Role == score.ContainerRoleSidecar {
+			return true
 		}
-		values["extraObjects"] = extras
 	}
-
-	return values
+	return false
 }
 
-// buildContainerSpec converts a Score container to a Stakater additional container spec.
-func buildContainerSpec(name string, c score.Container, allOutputs map[string]map[string]string) map[string]interface{} {
-	spec := map[string]interface{}{
-		"name":  name,
-		"image": c.Image,
-	}
-	if len(c.Command) > 0 {
-		spec["command"] = c.Command
+// targetCluster resolves the cluster a workload deploys to the way
+// translate.Translate does.
+func targetCluster(workload *score.Workload, cluster string, cfg *config.Config) string {
+	if cluster != "" {
+		return cluster
 	}
-	if len(c.Args) > 0 {
-		spec["args"] = c.Args
-	}
-	if len(c.Variables) > 0 {
-		var envList []map[string]interface{}
-		varNames := make([]string, 0, len(c.Variables))
-		for name := range c.Variables {
-			varNames = append(varNames, name)
-		}
-		sort.Strings(varNames)
-		for _, name := range varNames {
-			val := c.Variables[name]
-			envEntry := map[string]interface{}{"name": name}
-			resolved := resolveVariableValue(val, allOutputs)
-			if valMap, ok := resolved.(map[string]interface{}); ok {
-				if vf, ok := valMap["valueFrom"]; ok {
-					envEntry["valueFrom"] = vf
-				} else if v, ok := valMap["value"]; ok {
-					envEntry["value"] = v
-				}
-			}
-			envList = append(envList, envEntry)
-		}
-		spec["env"] = envList
+	if c := workload.TargetCluster(); c != "" {
+		return c
 	}
-	return spec
+	return cfg.DefaultCluster
 }
 
-// resolveVariableValue translates Score variable references to Stakater env format.
-// Handles:
-//   - ${resources.db.host} → secretKeyRef if the resource output is $(secret:key)
-//   - $(secret-name:key) → secretKeyRef
-//   - literal values → { value: "..." }
-func resolveVariableValue(val string, allOutputs map[string]map[string]string) interface{} {
-	// Check for Score resource reference: ${resources.<name>.<key>}
-	if matches := scoreVarRegex.FindStringSubmatch(val); len(matches) == 3 {
-		resName := matches[1]
-		resKey := matches[2]
-
-		if outputs, ok := allOutputs[resName]; ok {
-			if output, ok := outputs[resKey]; ok {
-				// Check if the output is a secret reference
-				if ref := secretRefRegex.FindStringSubmatch(output); len(ref) == 3 {
-					return map[string]interface{}{
-						"valueFrom": map[string]interface{}{
-							"secretKeyRef": map[string]interface{}{
-								"name": ref[1],
-								"key":  ref[2],
-							},
-						},
-					}
-				}
-				// Literal provisioner output
-				return map[string]interface{}{"value": output}
-			}
-		}
-		// Unresolved reference — leave as placeholder
-		return map[string]interface{}{"value": val}
-	}
-
-	// Direct secret reference: $(secret-name:key)
-	if ref := secretRefRegex.FindStringSubmatch(val); len(ref) == 3 {
-		return map[string]interface{}{
-			"valueFrom": map[string]interface{}{
-				"secretKeyRef": map[string]interface{}{
-					"name": ref[1],
-					"key":  ref[2],
-				},
-			},
-		}
+// liveKubernetesVersion returns the Kubernetes version the vcluster
+// orchestrator recorded on the cluster's VClusterOrchestratorV2, or "" when
+// the platform cannot be reached or has not recorded one.
+func liveKubernetesVersion(cfg *config.Config, cluster string) string {
+	client, err := kube.NewClient(cfg.KubeContext)
+	if err != nil {
+		logging.L().Debug("kubernetes version unknown", "cluster", cluster, "error", err)
+		return ""
 	}
-
-	// Literal value
-	return map[string]interface{}{"value": val}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	vc, err := client.GetVCluster(ctx, cfg.Platform.PlatformNamespace, cluster)
+	if err != nil {
+		logging.L().Debug("kubernetes version unknown", "cluster", cluster, "error", err)
+		return ""
+	}
+	version, _ := platform.RecordedVersions(vc)
+	return version
 }
 
-// WriteResult writes the translation result to the gitops repo.
+// WriteResult writes the translation result to the gitops repo and returns
+// the written paths, relative to repoPath and slash-separated on every OS.
+// Each file is replaced atomically, and the manifest inventory last, so it
+// never lists objects of values that were not written.
 func WriteResult(result *TranslateResult, repoPath string) ([]string, error) {
 	var writtenPaths []string
 
-	for relPath, data := range result.Files {
-		absPath := filepath.Join(repoPath, relPath)
+	inventory := translate.InventoryPath(result.TargetCluster, result.WorkloadName)
+	paths := make([]string, 0, len(result.Files))
+	for relPath := range result.Files {
+		paths = append(paths, relPath)
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		if (paths[i] == inventory) != (paths[j] == inventory) {
+			return paths[j] == inventory
+		}
+		return paths[i] < paths[j]
+	})
+	for _, relPath := range paths {
+		data := result.Files[relPath]
+		relPath = repopath.Join(relPath)
+		absPath := repopath.Abs(repoPath, relPath)
 		dir := filepath.Dir(absPath)
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return nil, fmt.Errorf("creating directory %s: %w", dir, err)
 		}
-		if err := os.WriteFile(absPath, data, 0o644); err != nil {
+		if err := writeFileAtomic(absPath, data); err != nil {
 			return nil, fmt.Errorf("writing %s: %w", relPath, err)
 		}
+		logging.L().Debug("wrote file", "path", absPath, "bytes", len(data))
 		writtenPaths = append(writtenPaths, relPath)
 	}
 
 	// Update addons.yaml
-	addonsPath := filepath.Join(repoPath, "workloads", result.TargetCluster, "addons.yaml")
+	addonsRelPath := AddonsPath(result.TargetCluster)
+	addonsPath := repopath.Abs(repoPath, addonsRelPath)
 	if err := updateAddonsYAML(addonsPath, result.WorkloadName, result.AddonsEntry, result.TargetCluster); err != nil {
 		return nil, fmt.Errorf("updating addons.yaml: %w", err)
 	}
-	addonsRelPath := filepath.Join("workloads", result.TargetCluster, "addons.yaml")
 	writtenPaths = append(writtenPaths, addonsRelPath)
 
 	return writtenPaths, nil
 }
 
+// writeFileAtomic writes data to a temporary file beside path and renames
+// it into place, so a failed write leaves the previous file intact.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".hctl-tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddonsPath returns the repo-relative path of a cluster's addons.yaml.
+func AddonsPath(cluster string) string {
+	return layout.Active().AddonsFile(cluster)
+}
+
 // updateAddonsYAML reads or creates the addons.yaml and adds/updates the workload entry.
 func updateAddonsYAML(path, workloadName string, entry map[string]interface{}, clusterName string) error {
+	out, err := addonsWith(path, map[string]map[string]interface{}{workloadName: entry}, clusterName)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return err
+	}
+	logging.L().Debug("wrote file", "path", path, "bytes", len(out), "entry", workloadName)
+	return nil
+}
+
+// AddonsWithWorkload returns the content of the cluster's addons.yaml with
+// the workload entries of result and more added or updated, without
+// writing it. All of them must target the cluster of result.
+func AddonsWithWorkload(repoPath string, result *TranslateResult, more ...*TranslateResult) ([]byte, error) {
+	entries := map[string]map[string]interface{}{result.WorkloadName: result.AddonsEntry}
+	for _, r := range more {
+		entries[r.WorkloadName] = r.AddonsEntry
+	}
+	out, err := addonsWith(repopath.Abs(repoPath, AddonsPath(result.TargetCluster)), entries, result.TargetCluster)
+	if err != nil {
+		return nil, fmt.Errorf("updating addons.yaml: %w", err)
+	}
+	return out, nil
+}
+
+func addonsWith(path string, entries map[string]map[string]interface{}, clusterName string) ([]byte, error) {
 	var existing map[string]interface{}
 
 	data, err := os.ReadFile(path)
 	if err == nil {
 		if err := yaml.Unmarshal(data, &existing); err != nil {
-			return fmt.Errorf("parsing existing addons.yaml: %w", err)
+			return nil, fmt.Errorf("parsing existing addons.yaml: %w", err)
 		}
 	}
 
@@ -506,30 +350,56 @@ func updateAddonsYAML(path, workloadName string, entry map[string]interface{}, c
 		}
 	}
 
-	// Add or update the workload entry
-	existing[workloadName] = entry
+	// Add or update the workload entries
+	for name, entry := range entries {
+		existing[name] = entry
+	}
 
 	out, err := yaml.Marshal(existing)
 	if err != nil {
-		return fmt.Errorf("marshaling addons.yaml: %w", err)
-	}
-
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
+		return nil, fmt.Errorf("marshaling addons.yaml: %w", err)
 	}
-
-	return os.WriteFile(path, out, 0o644)
+	return out, nil
 }
 
-// RemoveWorkload removes a workload from the addons.yaml and deletes its values directory.
+// RemoveWorkload removes a workload from the addons.yaml and deletes its
+// values directory. The returned paths are slash-separated, like WriteResult's.
 func RemoveWorkload(repoPath, cluster, workloadName string) ([]string, error) {
 	var removedPaths []string
 
-	// Remove from addons.yaml
-	addonsPath := filepath.Join(repoPath, "workloads", cluster, "addons.yaml")
-	data, err := os.ReadFile(addonsPath)
+	out, err := AddonsWithoutWorkload(repoPath, cluster, workloadName)
 	if err != nil {
+		return nil, err
+	}
+	addonsPath := repopath.Abs(repoPath, AddonsPath(cluster))
+	if err := os.WriteFile(addonsPath, out, 0o644); err != nil {
+		return nil, fmt.Errorf("writing addons.yaml: %w", err)
+	}
+	logging.L().Debug("wrote file", "path", addonsPath, "bytes", len(out), "removed", workloadName)
+	removedPaths = append(removedPaths, AddonsPath(cluster))
+
+	// Remove values directory
+	valuesRel := WorkloadDir(cluster, workloadName)
+	valuesDir := repopath.Abs(repoPath, valuesRel)
+	if _, err := os.Stat(valuesDir); err == nil {
+		if err := os.RemoveAll(valuesDir); err != nil {
+			return nil, fmt.Errorf("removing values directory: %w", err)
+		}
+		removedPaths = append(removedPaths, valuesRel)
+	}
+
+	return removedPaths, nil
+}
+
+// AddonsWithoutWorkload returns the content of the cluster's addons.yaml with
+// the workload's entry removed, without writing it. It fails with
+// ErrNotFound when the cluster has no addons.yaml or the workload no entry.
+func AddonsWithoutWorkload(repoPath, cluster, workloadName string) ([]byte, error) {
+	data, err := os.ReadFile(repopath.Abs(repoPath, AddonsPath(cluster)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, hcerrors.New(hcerrors.ErrNotFound, "cluster %q has no addons.yaml", cluster)
+		}
 		return nil, fmt.Errorf("reading addons.yaml: %w", err)
 	}
 
@@ -539,7 +409,8 @@ func RemoveWorkload(repoPath, cluster, workloadName string) ([]string, error) {
 	}
 
 	if _, ok := existing[workloadName]; !ok {
-		return nil, fmt.Errorf("workload %q not found in addons.yaml", workloadName)
+		return nil, hcerrors.New(hcerrors.ErrNotFound, "workload %q not found in addons.yaml", workloadName).
+			WithRemediation("run 'hctl deploy list --cluster " + cluster + "' to see deployed workloads")
 	}
 
 	delete(existing, workloadName)
@@ -548,28 +419,63 @@ func RemoveWorkload(repoPath, cluster, workloadName string) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("marshaling addons.yaml: %w", err)
 	}
+	return out, nil
+}
 
-	if err := os.WriteFile(addonsPath, out, 0o644); err != nil {
-		return nil, fmt.Errorf("writing addons.yaml: %w", err)
-	}
-	removedPaths = append(removedPaths, filepath.Join("workloads", cluster, "addons.yaml"))
+// addonsSettings are the keys of addons.yaml that configure the
+// ApplicationSet rather than name an addon.
+var addonsSettings = map[string]bool{
+	"globalSelectors":       true,
+	"useAddonNameForValues": true,
+	"appsetPrefix":          true,
+}
 
-	// Remove values directory
-	valuesDir := filepath.Join(repoPath, "workloads", cluster, "addons", workloadName)
-	if _, err := os.Stat(valuesDir); err == nil {
-		if err := os.RemoveAll(valuesDir); err != nil {
-			return nil, fmt.Errorf("removing values directory: %w", err)
+// NamespaceSharers returns the namespace a workload's addons.yaml entry
+// deploys to, and the other entries of the cluster's addons.yaml, enabled or
+// not, that deploy to the same namespace, sorted. An entry without a
+// namespace deploys to the one named after the cluster, as translation
+// defaults it.
+func NamespaceSharers(repoPath, cluster, workloadName string) (string, []string, error) {
+	data, err := os.ReadFile(repopath.Abs(repoPath, AddonsPath(cluster)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, hcerrors.New(hcerrors.ErrNotFound, "cluster %q has no addons.yaml", cluster)
 		}
-		removedPaths = append(removedPaths, filepath.Join("workloads", cluster, "addons", workloadName))
+		return "", nil, fmt.Errorf("reading addons.yaml: %w", err)
+	}
+	var existing map[string]interface{}
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return "", nil, fmt.Errorf("parsing addons.yaml: %w", err)
+	}
+	namespaceOf := func(entry map[string]interface{}) string {
+		if ns, ok := entry["namespace"].(string); ok && ns != "" {
+			return ns
+		}
+		return cluster
 	}
 
-	return removedPaths, nil
+	entry, ok := existing[workloadName].(map[string]interface{})
+	if !ok {
+		return "", nil, hcerrors.New(hcerrors.ErrNotFound, "workload %q not found in addons.yaml", workloadName).
+			WithRemediation("run 'hctl deploy list --cluster " + cluster + "' to see deployed workloads")
+	}
+	namespace := namespaceOf(entry)
+	var sharers []string
+	for name, val := range existing {
+		if addonsSettings[name] || name == workloadName {
+			continue
+		}
+		if other, ok := val.(map[string]interface{}); ok && namespaceOf(other) == namespace {
+			sharers = append(sharers, name)
+		}
+	}
+	sort.Strings(sharers)
+	return namespace, sharers, nil
 }
 
 // ListWorkloads reads a cluster's addons.yaml and returns all enabled workload names.
 func ListWorkloads(repoPath, cluster string) ([]string, error) {
-	addonsPath := filepath.Join(repoPath, "workloads", cluster, "addons.yaml")
-	data, err := os.ReadFile(addonsPath)
+	data, err := os.ReadFile(repopath.Abs(repoPath, AddonsPath(cluster)))
 	if err != nil {
 		return nil, err
 	}
@@ -579,16 +485,9 @@ func ListWorkloads(repoPath, cluster string) ([]string, error) {
 		return nil, err
 	}
 
-	// Skip non-addon keys
-	skipKeys := map[string]bool{
-		"globalSelectors":       true,
-		"useAddonNameForValues": true,
-		"appsetPrefix":          true,
-	}
-
 	var workloads []string
 	for name, val := range existing {
-		if skipKeys[name] {
+		if addonsSettings[name] {
 			continue
 		}
 		if entry, ok := val.(map[string]interface{}); ok {
@@ -0,0 +1,158 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+func translateHello(t *testing.T) *TranslateResult {
+	t.Helper()
+	w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: hello
+containers:
+  web:
+    image: nginx:1.27
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "dev",
+		Domain:   "cluster.integratn.tech",
+		Registry: provisioners.NewRegistry(),
+		Chart:    translate.DefaultChart(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// TestWriteResultWindowsPaths runs the translator and WriteResult as on
+// Windows: the paths returned for committing must stay slash-separated,
+// including keys a provisioner built with OS separators.
+func TestWriteResultWindowsPaths(t *testing.T) {
+	defer repopath.Simulate('\\')()
+
+	result := translateHello(t)
+	result.Files[`workloads\dev\addons\hello\extra.yaml`] = []byte("extra: true\n")
+	repo := t.TempDir()
+
+	written, err := WriteResult(result, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(written)
+	want := []string{
+		"workloads/dev/addons.yaml",
+		"workloads/dev/addons/hello/.hctl-manifest.yaml",
+		"workloads/dev/addons/hello/extra.yaml",
+		"workloads/dev/addons/hello/values.yaml",
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("WriteResult() paths = %q, want %q", written, want)
+	}
+	for _, p := range written {
+		if _, err := os.Stat(repopath.Abs(repo, p)); err != nil {
+			t.Errorf("%s not written: %v", p, err)
+		}
+	}
+
+	addons, err := os.ReadFile(repopath.Abs(repo, AddonsPath("dev")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(addons), `\`) {
+		t.Errorf("addons.yaml contains a backslash:\n%s", addons)
+	}
+
+	removed, err := RemoveWorkload(repo, "dev", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range removed {
+		if strings.Contains(p, `\`) {
+			t.Errorf("RemoveWorkload() path %q is not slash-separated", p)
+		}
+	}
+}
+
+func TestWriteResultLayout(t *testing.T) {
+	repo := t.TempDir()
+	if _, err := WriteResult(translateHello(t), repo); err != nil {
+		t.Fatal(err)
+	}
+	workloads, err := ListWorkloads(repo, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(workloads, []string{"hello"}) {
+		t.Errorf("ListWorkloads() = %v, want [hello]", workloads)
+	}
+
+	removed, err := RemoveWorkload(repo, "dev", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"workloads/dev/addons.yaml", "workloads/dev/addons/hello"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveWorkload() = %q, want %q", removed, want)
+	}
+}
+
+func TestNamespaceSharers(t *testing.T) {
+	repo := t.TempDir()
+	addons := repopath.Abs(repo, AddonsPath("dev"))
+	if err := os.MkdirAll(filepath.Dir(addons), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `globalSelectors:
+  cluster_name: dev
+useAddonNameForValues: true
+hello:
+  enabled: true
+  namespace: dev
+worker:
+  enabled: true
+cache:
+  enabled: false
+  namespace: dev
+media:
+  enabled: true
+  namespace: media
+`
+	if err := os.WriteFile(addons, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		workload  string
+		namespace string
+		sharers   []string
+	}{
+		{"hello", "dev", []string{"cache", "worker"}},
+		{"worker", "dev", []string{"cache", "hello"}},
+		{"media", "media", nil},
+	} {
+		namespace, sharers, err := NamespaceSharers(repo, "dev", tt.workload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if namespace != tt.namespace || !reflect.DeepEqual(sharers, tt.sharers) {
+			t.Errorf("NamespaceSharers(%s) = %q, %q, want %q, %q", tt.workload, namespace, sharers, tt.namespace, tt.sharers)
+		}
+	}
+	if _, _, err := NamespaceSharers(repo, "dev", "missing"); hcerrors.ExitCode(err) != hcerrors.ExitNotFound {
+		t.Errorf("missing workload: err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,210 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// Validation is the outcome of ValidateScore.
+type Validation struct {
+	// Workloads are the parsed workloads, one per document of score.yaml.
+	Workloads []*score.Workload
+	// Results are the render-mode translations, by document; an entry is
+	// nil when its workload did not parse or translate.
+	Results []*TranslateResult
+	// Problems are every finding, located in score.yaml where possible and
+	// sorted by line.
+	Problems []score.Problem
+	// docs locate problems found after parsing, by document.
+	docs []*score.Document
+}
+
+// Failed reports whether any problem is an error.
+func (v *Validation) Failed() bool {
+	for _, p := range v.Problems {
+		if p.Severity == score.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a problem the caller found in the workload of document i,
+// such as a tenancy policy check, locating it by its field and keeping the
+// problems sorted.
+func (v *Validation) Add(i int, p score.Problem) {
+	if i < len(v.docs) {
+		p = v.docs[i].Locate(p)
+	}
+	v.Problems = append(v.Problems, p)
+	score.SortProblems(v.Problems)
+}
+
+// AddError adds err as an error problem of document i, at the field its
+// details name.
+func (v *Validation) AddError(i int, err error) {
+	v.Add(i, ErrorProblem(err))
+}
+
+// ValidateScore checks every document of scoreFile as 'hctl deploy run'
+// would, without writing anything or reaching external systems: it parses
+// each workload, keeping every field's position, then translates it in
+// render mode, which applies the repo's platform policies and resolves
+// resource references. The platform constraints Validate checks are
+// reported alongside. A workload is translated only when its document
+// parses. The error is for a scoreFile that cannot be read; everything else
+// is a problem.
+func ValidateScore(scoreFile, cluster string) (*Validation, error) {
+	data, err := os.ReadFile(scoreFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, hcerrors.New(hcerrors.ErrNotFound, "reading %s: %w", scoreFile, err).
+				WithRemediation("run 'hctl deploy init' or pass --file")
+		}
+		return nil, fmt.Errorf("reading %s: %w", scoreFile, err)
+	}
+	docs := score.ParseAll(data, score.EnvOptions{})
+	v := &Validation{
+		Workloads: make([]*score.Workload, len(docs)),
+		Results:   make([]*TranslateResult, len(docs)),
+		docs:      docs,
+	}
+	defined := map[string]int{}
+	for i, doc := range docs {
+		v.Workloads[i] = doc.Workload
+		v.Problems = append(v.Problems, doc.Problems...)
+		if doc.Err() != nil {
+			continue
+		}
+		name := doc.Workload.Metadata.Name
+		if first, ok := defined[name]; ok {
+			v.Add(i, score.Problem{Severity: score.SeverityError, Field: "metadata.name",
+				Message: fmt.Sprintf("workload %q is already defined by document %d", name, first)})
+			continue
+		}
+		defined[name] = i + 1
+
+		target := Target{Cluster: cluster}.For(doc.Workload, len(docs))
+		constraints := Validate(doc.Workload, target.Cluster)
+		result, err := Translate(doc.Workload, scoreFile, target, RunOptions{Mode: provisioners.ModeRender, Cache: true})
+		var diagErr *translate.DiagnosticsError
+		switch {
+		case errors.As(err, &diagErr):
+			v.addFindings(i, constraints, diagErr.Diagnostics)
+		case err != nil:
+			v.addFindings(i, constraints, nil)
+			v.AddError(i, err)
+		default:
+			v.Results[i] = result
+			v.addFindings(i, constraints, result.Diagnostics)
+		}
+	}
+	score.SortProblems(v.Problems)
+	return v, nil
+}
+
+// severityRank orders severities, least severe first.
+var severityRank = map[score.Severity]int{score.SeverityInfo: 0, score.SeverityWarning: 1, score.SeverityError: 2}
+
+// addFindings adds constraint problems and translation diagnostics as
+// problems at their fields in document i. A constraint problem and a diagnostic on the
+// same field are one finding reported twice: the more severe is kept, the
+// diagnostic on a tie, since translation knows the resources' outputs.
+func (v *Validation) addFindings(i int, constraints []score.Problem, diags []translate.Diagnostic) {
+	worst := map[string]score.Severity{}
+	for _, p := range constraints {
+		if p.Field != "" && severityRank[p.Severity] >= severityRank[worst[p.Field]] {
+			worst[p.Field] = p.Severity
+		}
+	}
+	diagnosed := map[string]bool{}
+	for _, d := range diags {
+		sev := score.SeverityWarning
+		switch d.Severity {
+		case translate.SeverityError:
+			sev = score.SeverityError
+		case translate.SeverityInfo:
+			sev = score.SeverityInfo
+		}
+		if c, ok := worst[d.Field]; ok && severityRank[c] > severityRank[sev] {
+			continue
+		}
+		diagnosed[d.Field] = true
+		v.Add(i, score.Problem{Severity: sev, Field: d.Field, Message: d.Message})
+	}
+	for _, p := range constraints {
+		if p.Field == "" || !diagnosed[p.Field] {
+			v.Add(i, p)
+		}
+	}
+}
+
+// ErrorProblem converts err into an error problem, taking its field from
+// the "field" entry of its details, if any.
+func ErrorProblem(err error) score.Problem {
+	p := score.Problem{Severity: score.SeverityError, Message: err.Error()}
+	if details, ok := hcerrors.ToReport(err).Details.(map[string]string); ok {
+		p.Field = details["field"]
+	}
+	return p
+}
+
+// ValidateInputs lists the files a validation of scoreFile reads, for
+// 'hctl deploy validate --watch' to poll: score.yaml, the local files the
+// containers of its workloads mount, and the repo's platform policy files.
+func ValidateInputs(scoreFile string, workloads []*score.Workload, repoPath string) []string {
+	paths := []string{scoreFile}
+	dir := filepath.Dir(scoreFile)
+	for _, w := range workloads {
+		if w == nil {
+			continue
+		}
+		for _, c := range w.Containers {
+			for _, f := range c.Files {
+				if f.Source == "" {
+					continue
+				}
+				src := f.Source
+				if !filepath.IsAbs(src) {
+					src = filepath.Join(dir, src)
+				}
+				paths = append(paths, src)
+			}
+		}
+	}
+	if repoPath != "" {
+		for _, rel := range []string{ObservabilityPath, ResourcePolicyPath} {
+			paths = append(paths, repopath.Abs(repoPath, rel))
+		}
+	}
+	sort.Strings(paths[1:])
+	return paths
+}
+
+// Fingerprint hashes the contents of paths, so a poll can tell whether any
+// of them changed. A missing or unreadable file hashes as absent.
+func Fingerprint(paths []string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s\x00", p)
+		if data, err := os.ReadFile(p); err == nil {
+			fmt.Fprintf(h, "%d\x00", len(data))
+			h.Write(data)
+		} else {
+			h.Write([]byte("-\x00"))
+		}
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
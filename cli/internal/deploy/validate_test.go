@@ -0,0 +1,215 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func TestValidateScore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "score.yaml")
+	write := func(doc string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  main:
+    image: nginx:1.27
+    variables:
+      DB_HOST: ${resources.db.host}
+`)
+	v, err := ValidateScore(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := score.Problem{
+		Severity: score.SeverityError,
+		Field:    "containers.main.variables.DB_HOST",
+		Position: score.Position{Line: 10, Column: 7},
+		Message:  `reference ${resources.db.host}: no resource "db" is declared; the workload declares no resources; list it in hctl.integratn.tech/external-references if something else resolves it`,
+	}
+	if !v.Failed() || len(v.Problems) != 1 || v.Problems[0] != want {
+		t.Errorf("unresolved reference: Problems = %+v, want [%+v]", v.Problems, want)
+	}
+
+	// A reference listed as external is passed through with a warning.
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+    hctl.integratn.tech/external-references: db
+containers:
+  main:
+    image: nginx:1.27
+    variables:
+      DB_HOST: ${resources.db.host}
+`)
+	if v, err = ValidateScore(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if v.Failed() || len(v.Problems) != 1 || v.Problems[0].Severity != score.SeverityWarning || v.Problems[0].Position.Line != 11 {
+		t.Errorf("external reference: Problems = %+v, want one warning at line 11", v.Problems)
+	}
+	if len(v.Results) != 1 || v.Results[0] == nil || v.Results[0].TargetCluster != "dev" {
+		t.Errorf("Results = %+v, want the dev translation", v.Results)
+	}
+
+	// A translation error is placed at the field its details name.
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+    hctl.integratn.tech/otel: "yes"
+containers:
+  main:
+    image: nginx:1.27
+`)
+	if v, err = ValidateScore(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Failed() || len(v.Problems) != 1 || v.Problems[0].Line != 6 || v.Results[0] != nil {
+		t.Errorf("bad annotation: Problems = %+v, Results = %v; want one error at line 6", v.Problems, v.Results)
+	}
+
+	// Schema problems stop before translation.
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: nginx:1.27
+    imag: nginx:1.28
+`)
+	if v, err = ValidateScore(path, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Failed() || v.Results[0] != nil || v.Problems[0].Field != "containers.main.imag" {
+		t.Errorf("unknown field: Problems = %+v, Results = %v", v.Problems, v.Results)
+	}
+
+	// Every document is checked, not only the first: a second workload
+	// deploy run would reject fails validation at its own lines.
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  main:
+    image: nginx:1.27
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: worker
+  annotations:
+    hctl.integratn.tech/cluster: dev
+    hctl.integratn.tech/otel: "yes"
+containers:
+  main:
+    image: nginx:1.27
+`)
+	if v, err = ValidateScore(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Failed() || len(v.Problems) != 1 || v.Problems[0].Line != 15 {
+		t.Errorf("invalid second document: Problems = %+v, want one error at line 15", v.Problems)
+	}
+	if len(v.Results) != 2 || v.Results[0] == nil || v.Results[1] != nil {
+		t.Errorf("invalid second document: Results = %v, want only the first translated", v.Results)
+	}
+
+	// Two documents defining the same workload are rejected at the second.
+	write(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: nginx:1.27
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: nginx:1.28
+`)
+	if v, err = ValidateScore(path, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Failed() || len(v.Problems) != 1 || v.Problems[0].Field != "metadata.name" || v.Problems[0].Line != 10 {
+		t.Errorf("duplicate workload: Problems = %+v, want one error at line 10", v.Problems)
+	}
+
+	if _, err := ValidateScore(filepath.Join(dir, "missing.yaml"), "dev"); err == nil {
+		t.Error("ValidateScore of a missing file succeeded")
+	}
+}
+
+func TestValidateInputs(t *testing.T) {
+	w := &score.Workload{Containers: map[string]score.Container{
+		"main": {Files: map[string]score.File{
+			"/etc/app/config.yaml": {Source: "config/app.yaml"},
+			"/etc/app/inline":      {Content: "x"},
+		}},
+	}}
+	got := ValidateInputs(filepath.Join("apps", "shop", "score.yaml"), []*score.Workload{nil, w}, "/repo")
+	want := []string{
+		filepath.Join("apps", "shop", "score.yaml"),
+		filepath.Join("/repo", "platform", "observability", "sidecar.yaml"),
+		filepath.Join("/repo", "platform", "policies", "resources.yaml"),
+		filepath.Join("apps", "shop", "config", "app.yaml"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ValidateInputs = %q, want %q", got, want)
+	}
+	for _, p := range want {
+		found := false
+		for _, g := range got {
+			found = found || g == p
+		}
+		if !found {
+			t.Errorf("ValidateInputs = %q, missing %q", got, p)
+		}
+	}
+	if got[0] != want[0] {
+		t.Errorf("ValidateInputs[0] = %q, want score.yaml first", got[0])
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(a, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := Fingerprint([]string{a, b})
+	if Fingerprint([]string{a, b}) != before {
+		t.Error("Fingerprint is not stable")
+	}
+	if err := os.WriteFile(b, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	created := Fingerprint([]string{a, b})
+	if created == before {
+		t.Error("creating an empty file did not change the fingerprint")
+	}
+	if err := os.WriteFile(a, []byte("two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint([]string{a, b}) == created {
+		t.Error("editing a file did not change the fingerprint")
+	}
+}
@@ -0,0 +1,133 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// SharedVolumeOwners maps the shared volumes committed for cluster to the
+// workload owning each: the one whose values render the volume's PVC with
+// the owner annotation. Two workloads rendering the same shared volume is
+// an error, since their ArgoCD Applications would fight over the PVC.
+func SharedVolumeOwners(repoPath, cluster string) (map[string]string, error) {
+	owners := map[string]string{}
+	err := eachWorkloadValues(repoPath, cluster, func(workload string, values map[string]interface{}) error {
+		for _, volume := range ownedVolumes(values) {
+			if other, ok := owners[volume]; ok && other != workload {
+				a, b := other, workload
+				if b < a {
+					a, b = b, a
+				}
+				return hcerrors.New(hcerrors.ErrValidation, "shared volume %q on cluster %s is owned by both %q and %q", volume, cluster, a, b).
+					WithRemediation("remove params.size from the shared volume of the workload that should only mount it, and redeploy it").
+					WithDetails(map[string]string{"volume": volume, "workload": a, "otherWorkload": b})
+			}
+			owners[volume] = workload
+		}
+		return nil
+	})
+	return owners, err
+}
+
+// SharedVolumeConsumers returns, by volume, the other workloads on cluster
+// that mount a shared volume owner owns. Removing owner deletes the PVC
+// from under them.
+func SharedVolumeConsumers(repoPath, cluster, owner string) (map[string][]string, error) {
+	var owned []string
+	consumers := map[string][]string{}
+	err := eachWorkloadValues(repoPath, cluster, func(workload string, values map[string]interface{}) error {
+		if workload == owner {
+			owned = ownedVolumes(values)
+			return nil
+		}
+		for _, claim := range mountedClaims(values) {
+			if n := len(consumers[claim]); n == 0 || consumers[claim][n-1] != workload {
+				consumers[claim] = append(consumers[claim], workload)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]string{}
+	for _, volume := range owned {
+		if workloads := consumers[volume]; len(workloads) > 0 {
+			result[volume] = workloads
+		}
+	}
+	return result, nil
+}
+
+// eachWorkloadValues calls fn with the committed values of every workload
+// in cluster's addons.yaml that has them.
+func eachWorkloadValues(repoPath, cluster string, fn func(workload string, values map[string]interface{}) error) error {
+	workloads, err := ListWorkloads(repoPath, cluster)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", AddonsPath(cluster), err)
+	}
+	sort.Strings(workloads)
+	for _, workload := range workloads {
+		data, err := os.ReadFile(repopath.Abs(repoPath, translate.ValuesPath(cluster, workload)))
+		if err != nil {
+			continue
+		}
+		values, err := parseValues(data)
+		if err != nil {
+			continue
+		}
+		if err := fn(workload, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownedVolumes returns the shared volumes whose PVC values render, by the
+// owner annotation on the PVCs in extraObjects.
+func ownedVolumes(values map[string]interface{}) []string {
+	var volumes []string
+	objects, _ := values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		m, _ := obj.(map[string]interface{})
+		if m["kind"] != "PersistentVolumeClaim" {
+			continue
+		}
+		meta, _ := m["metadata"].(map[string]interface{})
+		annotations, _ := meta["annotations"].(map[string]interface{})
+		if owner, _ := annotations[provisioners.SharedVolumeOwnerAnnotation].(string); owner != "" {
+			if name, _ := meta["name"].(string); name != "" {
+				volumes = append(volumes, name)
+			}
+		}
+	}
+	return volumes
+}
+
+// mountedClaims returns the PVCs values' Deployment or StatefulSet pods
+// mount.
+func mountedClaims(values map[string]interface{}) []string {
+	var claims []string
+	for _, section := range []string{"deployment", "statefulset"} {
+		pod, _ := values[section].(map[string]interface{})
+		volumes, _ := pod["volumes"].(map[string]interface{})
+		for _, v := range volumes {
+			vol, _ := v.(map[string]interface{})
+			pvc, _ := vol["persistentVolumeClaim"].(map[string]interface{})
+			if claim, _ := pvc["claimName"].(string); claim != "" {
+				claims = append(claims, claim)
+			}
+		}
+	}
+	sort.Strings(claims)
+	return claims
+}
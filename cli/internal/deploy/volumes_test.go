@@ -0,0 +1,118 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// translateLibrary translates a workload mounting the shared volume
+// media-library, seeing the owners committed to repo.
+func translateLibrary(t *testing.T, repo, workload, params string) (*TranslateResult, error) {
+	t.Helper()
+	w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: ` + workload + `
+containers:
+  main:
+    image: ` + workload + `:1
+    volumes:
+      library:
+        source: library
+        path: /media
+resources:
+  library:
+    type: volume
+    id: media-library
+    params:
+` + params))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners, err := SharedVolumeOwners(repo, "media")
+	if err != nil {
+		return nil, err
+	}
+	return translate.Translate(w, translate.Options{
+		Cluster:       "media",
+		Registry:      provisioners.NewRegistry(),
+		Chart:         translate.DefaultChart(),
+		SharedVolumes: owners,
+	})
+}
+
+func TestSharedVolumes(t *testing.T) {
+	repo := t.TempDir()
+	const claim = "      shared: true\n      size: 50Gi\n"
+	const mount = "      shared: true\n"
+
+	// Mounting a volume nobody owns yet fails.
+	if _, err := translateLibrary(t, repo, "exporter", mount); err == nil || !strings.Contains(err.Error(), `shared volume "media-library" does not exist on media yet`) {
+		t.Fatalf("dangling mount: err = %v", err)
+	}
+
+	owner, err := translateLibrary(t, repo, "jellyfin", claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ownedVolumes(owner.Values); len(got) != 1 || got[0] != "media-library" {
+		t.Fatalf("owner renders shared volumes %v, want media-library", got)
+	}
+	if _, err := WriteResult(owner, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer, err := translateLibrary(t, repo, "exporter", mount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ownedVolumes(consumer.Values); len(got) != 0 {
+		t.Errorf("consumer renders the PVC of %v", got)
+	}
+	if got := mountedClaims(consumer.Values); len(got) != 1 || got[0] != "media-library" {
+		t.Errorf("consumer mounts %v, want media-library", got)
+	}
+	if _, err := WriteResult(consumer, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redeploying the owner keeps it the owner.
+	if again, err := translateLibrary(t, repo, "jellyfin", claim); err != nil || len(ownedVolumes(again.Values)) != 1 {
+		t.Errorf("redeploying the owner: err = %v", err)
+	}
+	if _, err := translateLibrary(t, repo, "exporter", claim); err == nil || !strings.Contains(err.Error(), `owned by workload "jellyfin"`) {
+		t.Errorf("second claim: err = %v", err)
+	}
+
+	consumers, err := SharedVolumeConsumers(repo, "media", "jellyfin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(consumers["media-library"], ","); len(consumers) != 1 || got != "exporter" {
+		t.Errorf("consumers = %v, want exporter on media-library", consumers)
+	}
+	if consumers, _ := SharedVolumeConsumers(repo, "media", "exporter"); len(consumers) != 0 {
+		t.Errorf("a consumer has consumers: %v", consumers)
+	}
+}
+
+func TestSharedVolumeDoubleOwner(t *testing.T) {
+	repo := t.TempDir()
+	for _, workload := range []string{"jellyfin", "exporter"} {
+		result, err := translateLibrary(t, t.TempDir(), workload, "      shared: true\n      size: 50Gi\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := WriteResult(result, repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := SharedVolumeOwners(repo, "media")
+	if hcerrors.ExitCode(err) != hcerrors.ExitValidation || !strings.Contains(err.Error(), `owned by both "exporter" and "jellyfin"`) {
+		t.Errorf("two committed owners: err = %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package deploy
+
+import (
+	"fmt"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Deploy stages, in the order a deploy passes them. 'hctl deploy run
+// --report' records each as a test case.
+const (
+	StageTranslate       = "translate"
+	StageWrite           = "write"
+	StageCommit          = "commit"
+	StageAppCreated      = "app created"
+	StageSynced          = "synced"
+	StageSecretsReady    = "secrets ready"
+	StageCertReady       = "cert ready"
+	StagePodsReady       = "pods ready"
+	StageRouteProgrammed = "route programmed"
+)
+
+// WatchStages are the stages observed while watching a deployed workload.
+var WatchStages = []string{StageAppCreated, StageSynced, StageSecretsReady, StageCertReady, StagePodsReady, StageRouteProgrammed}
+
+// DeployStages are all the stages of 'hctl deploy run --watch'.
+var DeployStages = append([]string{StageTranslate, StageWrite, StageCommit}, WatchStages...)
+
+// StageState is what one poll shows of a watch stage.
+type StageState struct {
+	Stage string
+	Done  bool
+	// Message summarises the stage: what is pending, or what passed.
+	Message string
+	// Details are the condition messages and pod events behind Message.
+	Details []string
+}
+
+// ObserveStages evaluates WatchStages, in order, from the workload's ArgoCD
+// Application, nil when it does not exist yet, and its pods. warnings maps
+// a pod name to its Warning events. Secrets, certificates and routes are
+// read from the health ArgoCD reports for the resources it manages; a kind
+// the workload does not use is done at once.
+func ObserveStages(app *unstructured.Unstructured, pods []kube.PodInfo, warnings map[string][]string) []StageState {
+	if app == nil {
+		states := []StageState{{Stage: StageAppCreated, Message: "ArgoCD Application not found"}}
+		for _, stage := range WatchStages[1:] {
+			states = append(states, StageState{Stage: stage, Message: "waiting for the ArgoCD Application"})
+		}
+		return states
+	}
+	return []StageState{
+		{Stage: StageAppCreated, Done: true, Message: app.GetName()},
+		syncState(app),
+		resourceState(app, StageSecretsReady, "ExternalSecret"),
+		resourceState(app, StageCertReady, "Certificate"),
+		podsState(pods, warnings),
+		resourceState(app, StageRouteProgrammed, "HTTPRoute"),
+	}
+}
+
+func syncState(app *unstructured.Unstructured) StageState {
+	st := StageState{Stage: StageSynced}
+	status, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	revision, _, _ := unstructured.NestedString(app.Object, "status", "sync", "revision")
+	if status == "Synced" {
+		st.Done = true
+		st.Message = "revision " + revision
+		return st
+	}
+	st.Message = "sync status " + orUnknown(status)
+	if msg, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "message"); msg != "" {
+		st.Details = append(st.Details, msg)
+	}
+	conditions, _, _ := unstructured.NestedSlice(app.Object, "status", "conditions")
+	for _, c := range conditions {
+		cm, _ := c.(map[string]interface{})
+		typ, _ := cm["type"].(string)
+		msg, _ := cm["message"].(string)
+		if msg != "" {
+			st.Details = append(st.Details, typ+": "+msg)
+		}
+	}
+	return st
+}
+
+// resourceState is done when every managed resource of kind is Healthy, or
+// synced without a health check (ArgoCD has none for HTTPRoute).
+func resourceState(app *unstructured.Unstructured, stage, kind string) StageState {
+	st := StageState{Stage: stage}
+	resources, _, _ := unstructured.NestedSlice(app.Object, "status", "resources")
+	total := 0
+	for _, r := range resources {
+		rm, _ := r.(map[string]interface{})
+		if k, _ := rm["kind"].(string); k != kind {
+			continue
+		}
+		total++
+		name, _ := rm["name"].(string)
+		sync, _ := rm["status"].(string)
+		health, _, _ := unstructured.NestedString(rm, "health", "status")
+		msg, _, _ := unstructured.NestedString(rm, "health", "message")
+		if health == "Healthy" || (health == "" && sync == "Synced") {
+			continue
+		}
+		line := fmt.Sprintf("%s %s: %s", kind, name, orUnknown(health))
+		if msg != "" {
+			line += ": " + msg
+		}
+		st.Details = append(st.Details, line)
+	}
+	switch {
+	case total == 0:
+		st.Done = true
+		st.Message = "no " + kind + " declared"
+	case len(st.Details) == 0:
+		st.Done = true
+		st.Message = fmt.Sprintf("%d %s ready", total, kind)
+	default:
+		st.Message = st.Details[0]
+	}
+	return st
+}
+
+func podsState(pods []kube.PodInfo, warnings map[string][]string) StageState {
+	st := StageState{Stage: StagePodsReady}
+	if len(pods) == 0 {
+		st.Message = "no pods yet"
+		return st
+	}
+	ready := 0
+	for _, p := range pods {
+		if p.Phase == "Running" && p.ReadyContainers == p.TotalContainers {
+			ready++
+			continue
+		}
+		line := fmt.Sprintf("pod %s: %s, %d/%d ready", p.Name, p.Phase, p.ReadyContainers, p.TotalContainers)
+		if p.Waiting != "" {
+			line = fmt.Sprintf("pod %s: %s", p.Name, p.Waiting)
+			if p.WaitingMessage != "" {
+				line += ": " + p.WaitingMessage
+			}
+		}
+		if p.Restarts > 0 {
+			line += fmt.Sprintf(" (%d restarts)", p.Restarts)
+		}
+		st.Details = append(st.Details, line)
+		for _, w := range warnings[p.Name] {
+			st.Details = append(st.Details, "  "+w)
+		}
+	}
+	st.Message = fmt.Sprintf("%d/%d pods ready", ready, len(pods))
+	if ready == len(pods) {
+		st.Done = true
+	} else if len(st.Details) > 0 {
+		st.Message += "; " + st.Details[0]
+	}
+	return st
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func watchedApp(resources ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"status": map[string]interface{}{
+			"sync":      map[string]interface{}{"status": "Synced", "revision": "abc123"},
+			"resources": resources,
+		},
+	}}
+}
+
+func resource(kind, name, health, message string) interface{} {
+	r := map[string]interface{}{"kind": kind, "name": name, "status": "Synced"}
+	if health != "" {
+		r["health"] = map[string]interface{}{"status": health, "message": message}
+	}
+	return r
+}
+
+func stageByName(t *testing.T, states []StageState, stage string) StageState {
+	t.Helper()
+	for _, st := range states {
+		if st.Stage == stage {
+			return st
+		}
+	}
+	t.Fatalf("no %q stage", stage)
+	return StageState{}
+}
+
+func TestObserveStagesNoApp(t *testing.T) {
+	states := ObserveStages(nil, nil, nil)
+	if len(states) != len(WatchStages) {
+		t.Fatalf("got %d stages, want %d", len(states), len(WatchStages))
+	}
+	for _, st := range states {
+		if st.Done {
+			t.Errorf("stage %q done without an Application", st.Stage)
+		}
+	}
+}
+
+func TestObserveStagesCertPending(t *testing.T) {
+	app := watchedApp(
+		resource("ExternalSecret", "web-db", "Healthy", ""),
+		resource("Certificate", "web-tls", "Progressing", "Issuing certificate as Secret does not exist"),
+		resource("HTTPRoute", "web", "", ""),
+	)
+	pods := []kube.PodInfo{{Name: "web-1", Phase: "Running", ReadyContainers: 1, TotalContainers: 1}}
+	states := ObserveStages(app, pods, nil)
+
+	for _, stage := range []string{StageAppCreated, StageSynced, StageSecretsReady, StagePodsReady, StageRouteProgrammed} {
+		if st := stageByName(t, states, stage); !st.Done {
+			t.Errorf("stage %q pending: %s", stage, st.Message)
+		}
+	}
+	cert := stageByName(t, states, StageCertReady)
+	if cert.Done || cert.Message != "Certificate web-tls: Progressing: Issuing certificate as Secret does not exist" {
+		t.Errorf("cert ready = %+v, want pending with the Certificate's health message", cert)
+	}
+}
+
+func TestObserveStagesCrashLoop(t *testing.T) {
+	pods := []kube.PodInfo{{
+		Name: "web-1", Phase: "Running", ReadyContainers: 0, TotalContainers: 1, Restarts: 5,
+		Waiting: "CrashLoopBackOff", WaitingMessage: "back-off 2m40s restarting failed container=web",
+	}}
+	warnings := map[string][]string{"web-1": {"BackOff: Back-off restarting failed container web (x12)"}}
+	if st := stageByName(t, ObserveStages(watchedApp(), nil, nil), StagePodsReady); st.Done {
+		t.Error("pods ready done with no pods")
+	}
+
+	st := stageByName(t, ObserveStages(watchedApp(), pods, warnings), StagePodsReady)
+	if st.Done {
+		t.Fatal("pods ready done with a crash-looping pod")
+	}
+	if !strings.Contains(st.Message, "CrashLoopBackOff") || !strings.Contains(st.Message, "(5 restarts)") {
+		t.Errorf("Message = %q, want the waiting reason and restarts", st.Message)
+	}
+	if len(st.Details) != 2 || !strings.Contains(st.Details[1], "BackOff") {
+		t.Errorf("Details = %q, want the pod line and its warning event", st.Details)
+	}
+	if st := stageByName(t, ObserveStages(watchedApp(), pods, warnings), StageCertReady); !st.Done {
+		t.Error("cert ready pending for a workload without certificates")
+	}
+}
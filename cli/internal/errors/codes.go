@@ -18,14 +18,107 @@ const (
 	ExitPlatformError = 3
 	// ExitTimeout indicates an operation timed out.
 	ExitTimeout = 4
+	// ExitValidation indicates an input file (e.g. score.yaml) failed validation.
+	ExitValidation = 5
+	// ExitGit indicates a git operation (commit, push) failed.
+	ExitGit = 6
+	// ExitNotFound indicates a referenced resource does not exist.
+	ExitNotFound = 7
+	// ExitPolicy indicates the repo's tenancy policy forbids the operation.
+	ExitPolicy = 8
+	// ExitSmokeTest indicates a deploy succeeded but its smoke tests failed.
+	ExitSmokeTest = 9
+	// ExitChanges indicates --dry-run or 'hctl deploy diff' found changes
+	// to make. It is a code no error uses, so scripts checking only the exit
+	// status cannot mistake a failure, such as a mistyped flag, for changes.
+	ExitChanges = 10
 )
 
-// HctlError is an error that carries an exit code.
+// Category classifies an error for exit codes and structured error output.
+// Categories implement error so they can be used as errors.Is targets:
+//
+//	if errors.Is(err, hcerrors.ErrNotFound) { ... }
+type Category string
+
+const (
+	// ErrUsage is a bad flag, argument, or missing configuration.
+	ErrUsage Category = "usage"
+	// ErrValidation is an input file that failed parsing or validation.
+	ErrValidation Category = "validation"
+	// ErrClusterUnreachable is a failure to reach or query the cluster.
+	ErrClusterUnreachable Category = "cluster_unreachable"
+	// ErrGit is a failed git operation.
+	ErrGit Category = "git"
+	// ErrNotFound is a missing resource, file, or entry.
+	ErrNotFound Category = "not_found"
+	// ErrPolicy is an operation forbidden by .hctl/policy.yaml.
+	ErrPolicy Category = "policy"
+	// ErrSmokeTest is a deployed workload that failed its smoke tests.
+	ErrSmokeTest Category = "smoke_test"
+	// ErrTimeout is an operation that did not finish in time.
+	ErrTimeout Category = "timeout"
+	// ErrChangesPending is a dry run or diff that found changes to make.
+	// It is not a failure: hctl exits with ExitChanges and prints nothing.
+	ErrChangesPending Category = "changes_pending"
+	// ErrInternal is an unclassified error.
+	ErrInternal Category = "internal"
+)
+
+func (c Category) Error() string { return string(c) }
+
+// categoryInfo describes a category for exit-code mapping and help output.
+type categoryInfo struct {
+	Category    Category
+	Code        int
+	Description string
+}
+
+// categories is the single source of truth for category → exit code mapping.
+var categories = []categoryInfo{
+	{ErrInternal, ExitError, "unclassified error"},
+	{ErrUsage, ExitUserError, "invalid flags, arguments, or missing configuration"},
+	{ErrClusterUnreachable, ExitPlatformError, "cluster unreachable or API request failed"},
+	{ErrTimeout, ExitTimeout, "operation timed out"},
+	{ErrValidation, ExitValidation, "input failed validation (e.g. score.yaml)"},
+	{ErrGit, ExitGit, "git commit or push failed"},
+	{ErrNotFound, ExitNotFound, "referenced resource not found"},
+	{ErrPolicy, ExitPolicy, "forbidden by the repo's tenancy policy"},
+	{ErrSmokeTest, ExitSmokeTest, "deployed, but the smoke tests failed"},
+	{ErrChangesPending, ExitChanges, "--dry-run or diff found changes to make"},
+}
+
+// CategoryCode returns the exit code for a category.
+func CategoryCode(c Category) int {
+	for _, info := range categories {
+		if info.Category == c {
+			return info.Code
+		}
+	}
+	return ExitError
+}
+
+// ExitCodeTable renders the category → exit code mapping for help output.
+func ExitCodeTable() string {
+	out := fmt.Sprintf("  %-4s %-20s %s\n", "CODE", "CATEGORY", "MEANING")
+	out += fmt.Sprintf("  %-4d %-20s %s\n", ExitOK, "-", "success")
+	for _, info := range categories {
+		out += fmt.Sprintf("  %-4d %-20s %s\n", info.Code, info.Category, info.Description)
+	}
+	return out
+}
+
+// HctlError is an error that carries a category and exit code.
 type HctlError struct {
-	// Code is the process exit code.
+	// Code is the process exit code. Derived from Category when set.
 	Code int
+	// Category classifies the error.
+	Category Category
 	// Err is the underlying error.
 	Err error
+	// Details holds optional structured context (e.g. missing fields).
+	Details any
+	// Remediation is an optional hint telling the user how to fix the problem.
+	Remediation string
 }
 
 func (e *HctlError) Error() string {
@@ -36,19 +129,55 @@ func (e *HctlError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is this error's category.
+func (e *HctlError) Is(target error) bool {
+	c, ok := target.(Category)
+	return ok && e.Category != "" && c == e.Category
+}
+
+// WithRemediation sets a remediation hint and returns the error.
+func (e *HctlError) WithRemediation(hint string) *HctlError {
+	e.Remediation = hint
+	return e
+}
+
+// WithDetails attaches structured details and returns the error.
+func (e *HctlError) WithDetails(details any) *HctlError {
+	e.Details = details
+	return e
+}
+
+// New creates a categorized error from a format string.
+func New(c Category, format string, args ...any) *HctlError {
+	return &HctlError{Code: CategoryCode(c), Category: c, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap categorizes an existing error. Returns nil if err is nil. If err is
+// already categorized, its category is preserved.
+func Wrap(c Category, err error) error {
+	if err == nil {
+		return nil
+	}
+	var hErr *HctlError
+	if errors.As(err, &hErr) && hErr.Category != "" {
+		return err
+	}
+	return &HctlError{Code: CategoryCode(c), Category: c, Err: err}
+}
+
 // NewUserError wraps an error with ExitUserError code.
 func NewUserError(format string, args ...any) *HctlError {
-	return &HctlError{Code: ExitUserError, Err: fmt.Errorf(format, args...)}
+	return New(ErrUsage, format, args...)
 }
 
 // NewPlatformError wraps an error with ExitPlatformError code.
 func NewPlatformError(format string, args ...any) *HctlError {
-	return &HctlError{Code: ExitPlatformError, Err: fmt.Errorf(format, args...)}
+	return New(ErrClusterUnreachable, format, args...)
 }
 
 // NewTimeoutError wraps an error with ExitTimeout code.
 func NewTimeoutError(format string, args ...any) *HctlError {
-	return &HctlError{Code: ExitTimeout, Err: fmt.Errorf(format, args...)}
+	return New(ErrTimeout, format, args...)
 }
 
 // ExitCode extracts the exit code from an error. Defaults to ExitError for
@@ -59,7 +188,44 @@ func ExitCode(err error) int {
 	}
 	var hErr *HctlError
 	if errors.As(err, &hErr) {
+		if hErr.Category != "" {
+			return CategoryCode(hErr.Category)
+		}
 		return hErr.Code
 	}
 	return ExitError
 }
+
+// CategoryOf returns the category of an error, or ErrInternal if unclassified.
+func CategoryOf(err error) Category {
+	var hErr *HctlError
+	if errors.As(err, &hErr) && hErr.Category != "" {
+		return hErr.Category
+	}
+	return ErrInternal
+}
+
+// Report is the structured form of an error, emitted on stderr when
+// --output json or yaml is active.
+type Report struct {
+	Category    Category `json:"category" yaml:"category"`
+	ExitCode    int      `json:"exitCode" yaml:"exitCode"`
+	Message     string   `json:"message" yaml:"message"`
+	Details     any      `json:"details,omitempty" yaml:"details,omitempty"`
+	Remediation string   `json:"remediation,omitempty" yaml:"remediation,omitempty"`
+}
+
+// ToReport converts an error into its structured representation.
+func ToReport(err error) Report {
+	r := Report{
+		Category: CategoryOf(err),
+		ExitCode: ExitCode(err),
+		Message:  err.Error(),
+	}
+	var hErr *HctlError
+	if errors.As(err, &hErr) {
+		r.Details = hErr.Details
+		r.Remediation = hErr.Remediation
+	}
+	return r
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -57,3 +58,68 @@ func TestExitCodeWrappedHctlError(t *testing.T) {
 		t.Errorf("ExitCode(wrapped HctlError) = %d, want %d", got, ExitUserError)
 	}
 }
+
+func TestCategoryExitCodesDistinct(t *testing.T) {
+	seen := map[int]Category{}
+	for _, info := range categories {
+		if prev, ok := seen[info.Code]; ok {
+			t.Errorf("categories %s and %s share exit code %d", prev, info.Category, info.Code)
+		}
+		seen[info.Code] = info.Category
+	}
+}
+
+func TestExitCodeByCategory(t *testing.T) {
+	tests := []struct {
+		cat  Category
+		want int
+	}{
+		{ErrUsage, ExitUserError},
+		{ErrValidation, ExitValidation},
+		{ErrClusterUnreachable, ExitPlatformError},
+		{ErrGit, ExitGit},
+		{ErrNotFound, ExitNotFound},
+		{ErrTimeout, ExitTimeout},
+		{ErrPolicy, ExitPolicy},
+		{ErrSmokeTest, ExitSmokeTest},
+		{ErrChangesPending, ExitChanges},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("outer: %w", New(tt.cat, "boom"))
+		if got := ExitCode(err); got != tt.want {
+			t.Errorf("ExitCode(%s) = %d, want %d", tt.cat, got, tt.want)
+		}
+		if !errors.Is(err, tt.cat) {
+			t.Errorf("errors.Is(err, %s) = false", tt.cat)
+		}
+	}
+}
+
+func TestWrapPreservesCategory(t *testing.T) {
+	inner := New(ErrNotFound, "missing")
+	err := Wrap(ErrGit, fmt.Errorf("context: %w", inner))
+	if got := CategoryOf(err); got != ErrNotFound {
+		t.Errorf("CategoryOf = %s, want %s", got, ErrNotFound)
+	}
+	if Wrap(ErrGit, nil) != nil {
+		t.Error("Wrap(nil) should return nil")
+	}
+}
+
+func TestToReport(t *testing.T) {
+	err := New(ErrValidation, "bad field").
+		WithDetails(map[string]string{"field": "metadata.name"}).
+		WithRemediation("set metadata.name")
+	r := ToReport(err)
+	if r.Category != ErrValidation || r.ExitCode != ExitValidation {
+		t.Errorf("report = %+v", r)
+	}
+	if r.Message != "bad field" || r.Remediation != "set metadata.name" || r.Details == nil {
+		t.Errorf("report = %+v", r)
+	}
+
+	plain := ToReport(fmt.Errorf("boom"))
+	if plain.Category != ErrInternal || plain.ExitCode != ExitError {
+		t.Errorf("plain report = %+v", plain)
+	}
+}
@@ -1,12 +1,17 @@
 package git
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"os/user"
 	"strings"
 	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 )
 
 // Repo provides git operations scoped to a repository.
@@ -63,9 +68,10 @@ func (r *Repo) Add(paths ...string) error {
 	return err
 }
 
-// Commit creates a commit with the given message.
+// Commit creates a commit with the given message, with the identity and
+// signing set by SetCommitConfig.
 func (r *Repo) Commit(message string) error {
-	_, err := runGit(r.Root, "commit", "-m", message)
+	_, err := runGit(r.Root, append(commitConfig.args(), "commit", "-m", message)...)
 	return err
 }
 
@@ -96,6 +102,144 @@ func (r *Repo) CommitAndPush(paths []string, message string) error {
 	return nil
 }
 
+// FileRevisions returns up to limit commit hashes that touched path (relative
+// to the repo root), newest first.
+func (r *Repo) FileRevisions(path string, limit int) ([]string, error) {
+	out, err := runGit(r.Root, "log", fmt.Sprintf("-n%d", limit), "--format=%H", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// LogEntry is one commit in a path's history.
+type LogEntry struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// PathLog returns up to limit commits that touched any of paths (relative
+// to the repo root), newest first.
+func (r *Repo) PathLog(limit int, paths ...string) ([]LogEntry, error) {
+	args := []string{"log", fmt.Sprintf("-n%d", limit), "--format=%H%x1f%an%x1f%aI%x1f%s%x1e", "--"}
+	for _, p := range paths {
+		args = append(args, repopath.ToSlash(p))
+	}
+	out, err := runGit(r.Root, args...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LogEntry
+	for _, record := range strings.Split(out, "\x1e") {
+		f := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(f) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, f[2])
+		entries = append(entries, LogEntry{Hash: f[0], Author: f[1], Date: date, Subject: f[3]})
+	}
+	return entries, nil
+}
+
+// IsAncestor reports whether commit ancestor is rev or one of its
+// ancestors. It fails when either is not in the repo.
+func (r *Repo) IsAncestor(ancestor, rev string) (bool, error) {
+	_, err := runGit(r.Root, "merge-base", "--is-ancestor", ancestor, rev)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ShowFile returns the content of path (relative to the repo root) at rev.
+func (r *Repo) ShowFile(rev, path string) ([]byte, error) {
+	out, err := runGit(r.Root, "show", rev+":"+repopath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// Head returns the commit hash of HEAD, or "" before the first commit.
+func (r *Repo) Head() string {
+	out, err := runGit(r.Root, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// OriginURL returns the URL of the origin remote of the repo containing
+// dir, with any credentials removed, or "" when there is none.
+func OriginURL(dir string) string {
+	out, err := runGit(dir, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return redactURL(strings.TrimSpace(out))
+}
+
+// redactURL drops the user info from an https remote URL so tokens are not
+// copied into generated files. scp-style URLs (git@host:path) are returned
+// unchanged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// User returns the commit identity as "Name <email>": the SetCommitConfig
+// override, then git's config, falling back to the OS user when neither
+// has one.
+func (r *Repo) User() string {
+	name, email := commitConfig.AuthorName, commitConfig.AuthorEmail
+	if name == "" {
+		name = r.config("user.name")
+	}
+	if email == "" {
+		email = r.config("user.email")
+	}
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case name != "" || email != "":
+		return name + email
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// LineCommits returns, for each line of path (relative to the repo root),
+// the hash of the commit that last changed it. Uncommitted lines map to "".
+func (r *Repo) LineCommits(path string) ([]string, error) {
+	out, err := runGit(r.Root, "blame", "--line-porcelain", "--", repopath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	// Each line is introduced by "<sha> <orig-line> <final-line>[ <count>]".
+	var commits []string
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if strings.HasPrefix(line, "\t") || len(f) < 3 || len(f[0]) != 40 || strings.Trim(f[0], "0123456789abcdef") != "" {
+			continue
+		}
+		sha := f[0]
+		if strings.Trim(sha, "0") == "" {
+			sha = ""
+		}
+		commits = append(commits, sha)
+	}
+	return commits, nil
+}
+
 // FormatCommitMessage creates a standardized hctl commit message.
 func FormatCommitMessage(action, resource, details string) string {
 	msg := fmt.Sprintf("hctl: %s %s", action, resource)
@@ -105,9 +249,10 @@ func FormatCommitMessage(action, resource, details string) string {
 	return msg
 }
 
-// RelPath returns a path relative to the repo root.
+// RelPath returns a path relative to the repo root, slash-separated on
+// every OS.
 func (r *Repo) RelPath(absPath string) (string, error) {
-	return filepath.Rel(r.Root, absPath)
+	return repopath.Rel(r.Root, absPath)
 }
 
 // ReconcileAnnotation returns the current timestamp string for reconcile annotations.
@@ -118,7 +263,17 @@ func ReconcileAnnotation() string {
 func runGit(dir string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
+	return runCmd(cmd)
+}
+
+// runCmd runs a prepared git command, logging it and folding its output
+// into the error.
+func runCmd(cmd *exec.Cmd) (string, error) {
+	args, dir := cmd.Args[1:], cmd.Dir
+	start := time.Now()
 	out, err := cmd.CombinedOutput()
+	logging.L().Debug("git", "args", strings.Join(args, " "), "dir", dir,
+		"duration", time.Since(start).Round(time.Millisecond), "output", strings.TrimSpace(string(out)), "error", err)
 	if err != nil {
 		return "", fmt.Errorf("git %s: %s: %w", strings.Join(args, " "), strings.TrimSpace(string(out)), err)
 	}
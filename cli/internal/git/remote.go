@@ -0,0 +1,102 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Errors CheckRemote wraps when the remote answers but does not have what
+// was asked for.
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrPathNotFound     = errors.New("path does not exist")
+)
+
+// remoteTimeout bounds each network git command CheckRemote runs.
+const remoteTimeout = 30 * time.Second
+
+// CheckRemote verifies that a repository can be rendered from: url
+// answers, revision is one of its branches or tags (or a commit it serves),
+// and path is a directory at that revision. path is skipped when empty.
+//
+// It runs with the caller's git credentials and never prompts, so a repo
+// that only the caller can read still passes; the status reconciler reports
+// what ArgoCD itself sees.
+func CheckRemote(url, revision, path string) error {
+	out, err := remoteGit("", "ls-remote", url)
+	if err != nil {
+		return err
+	}
+	ref := matchRef(out, revision)
+	if ref == "" && !isCommitHash(revision) {
+		return fmt.Errorf("%w: %s has no branch or tag %q", ErrRevisionNotFound, url, revision)
+	}
+	if ref == "" {
+		ref = revision
+	}
+
+	// Fetch the single commit without file contents; trees are enough to
+	// look the path up.
+	dir, err := os.MkdirTemp("", "hctl-remote-")
+	if err != nil {
+		return fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := runGit(dir, "init", "-q", "--bare"); err != nil {
+		return err
+	}
+	if _, err := remoteGit(dir, "fetch", "-q", "--depth", "1", "--filter=blob:none", url, ref); err != nil {
+		if ref == revision {
+			return fmt.Errorf("%w: %s does not serve commit %s: %v", ErrRevisionNotFound, url, revision, err)
+		}
+		return err
+	}
+	if path = strings.Trim(path, "/"); path == "" {
+		return nil
+	}
+	kind, err := runGit(dir, "cat-file", "-t", "FETCH_HEAD:"+path)
+	if err != nil || strings.TrimSpace(kind) != "tree" {
+		return fmt.Errorf("%w: %s at %s in %s", ErrPathNotFound, path, revision, url)
+	}
+	return nil
+}
+
+// remoteGit runs a git command that talks to a remote, failing instead of
+// prompting for credentials.
+func remoteGit(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	return runCmd(cmd)
+}
+
+// matchRef returns the ref in ls-remote output that revision names: the
+// ref itself, a branch or a tag. It returns "" when there is none.
+func matchRef(lsRemote, revision string) string {
+	candidates := []string{revision, "refs/heads/" + revision, "refs/tags/" + revision}
+	for _, line := range strings.Split(lsRemote, "\n") {
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			continue
+		}
+		for _, c := range candidates {
+			if f[1] == c {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// isCommitHash reports whether revision is a full commit hash, which can
+// be fetched without naming a ref.
+func isCommitHash(revision string) bool {
+	return len(revision) == 40 && strings.Trim(revision, "0123456789abcdef") == ""
+}
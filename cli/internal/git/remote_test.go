@@ -0,0 +1,80 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newBareRemote returns a bare repo whose main branch and v1 tag hold
+// workloads/vcluster-media/addons.yaml, and the commit hash of main.
+func newBareRemote(t *testing.T) (string, string) {
+	t.Helper()
+	dir := newTestRepo(t)
+	if err := os.MkdirAll(filepath.Join(dir, "workloads", "vcluster-media"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "workloads", "vcluster-media", "addons.yaml"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bare := filepath.Join(t.TempDir(), "workloads.git")
+	for _, args := range [][]string{
+		{"-C", dir, "checkout", "-q", "-b", "main"},
+		{"-C", dir, "add", "-A"},
+		{"-C", dir, "commit", "-q", "-m", "workloads"},
+		{"-C", dir, "tag", "v1"},
+		{"clone", "-q", "--bare", dir, bare},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	head, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + bare, strings.TrimSpace(string(head))
+}
+
+func TestCheckRemote(t *testing.T) {
+	url, head := newBareRemote(t)
+
+	tests := []struct {
+		name, url, revision, path string
+		want                      error
+	}{
+		{name: "branch and path", url: url, revision: "main", path: "workloads"},
+		{name: "nested path with slashes", url: url, revision: "main", path: "/workloads/vcluster-media/"},
+		{name: "tag", url: url, revision: "v1", path: "workloads"},
+		{name: "commit hash", url: url, revision: head, path: "workloads"},
+		{name: "no path", url: url, revision: "main"},
+		{name: "missing branch", url: url, revision: "develop", path: "workloads", want: ErrRevisionNotFound},
+		{name: "missing path", url: url, revision: "main", path: "apps", want: ErrPathNotFound},
+		{name: "file instead of directory", url: url, revision: "main", path: "workloads/vcluster-media/addons.yaml", want: ErrPathNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRemote(tt.url, tt.revision, tt.path)
+			if tt.want == nil && err != nil {
+				t.Fatalf("CheckRemote() = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("CheckRemote() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRemoteUnreachable(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	err := CheckRemote("file://"+filepath.Join(t.TempDir(), "missing.git"), "main", "workloads")
+	if err == nil {
+		t.Fatal("CheckRemote() of a missing repo succeeded")
+	}
+	if errors.Is(err, ErrRevisionNotFound) || errors.Is(err, ErrPathNotFound) {
+		t.Errorf("CheckRemote() = %v, want an unreachable error", err)
+	}
+}
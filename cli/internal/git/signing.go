@@ -0,0 +1,180 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// Signing modes for CommitConfig.Signing.
+const (
+	// SigningSSH signs commits with an SSH key (git's gpg.format=ssh).
+	SigningSSH = "ssh"
+	// SigningGPG signs commits with a GPG key.
+	SigningGPG = "gpg"
+	// SigningOff never signs, whatever git's commit.gpgsign says.
+	SigningOff = "off"
+)
+
+// CommitConfig sets the identity and signing of the commits hctl makes.
+// Empty fields leave git's own config in effect.
+type CommitConfig struct {
+	// AuthorName and AuthorEmail override user.name and user.email, for
+	// both author and committer.
+	AuthorName  string
+	AuthorEmail string
+	// Signing is SigningSSH, SigningGPG or SigningOff.
+	Signing string
+	// SigningKey is the key to sign with: for ssh a key file (private, or
+	// public with the private key in ssh-agent) or a "key::" literal, for
+	// gpg a key ID. Empty uses git's user.signingkey.
+	SigningKey string
+}
+
+// commitConfig applies to every commit; see SetCommitConfig.
+var commitConfig CommitConfig
+
+// SetCommitConfig sets the identity and signing of every later commit.
+// HCTL_GIT_AUTHOR_NAME, HCTL_GIT_AUTHOR_EMAIL, HCTL_GIT_SIGNING and
+// HCTL_GIT_SIGNING_KEY take precedence over cfg's fields, so CI can commit
+// as a bot with the same config file.
+func SetCommitConfig(cfg CommitConfig) {
+	for env, field := range map[string]*string{
+		"HCTL_GIT_AUTHOR_NAME":  &cfg.AuthorName,
+		"HCTL_GIT_AUTHOR_EMAIL": &cfg.AuthorEmail,
+		"HCTL_GIT_SIGNING":      &cfg.Signing,
+		"HCTL_GIT_SIGNING_KEY":  &cfg.SigningKey,
+	} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			*field = v
+		}
+	}
+	commitConfig = cfg
+}
+
+// args returns the "-c" options that apply c to a git command.
+func (c CommitConfig) args() []string {
+	var args []string
+	set := func(key, value string) { args = append(args, "-c", key+"="+value) }
+	if c.AuthorName != "" {
+		set("user.name", c.AuthorName)
+	}
+	if c.AuthorEmail != "" {
+		set("user.email", c.AuthorEmail)
+	}
+	switch c.Signing {
+	case SigningSSH, SigningGPG:
+		format := "openpgp"
+		if c.Signing == SigningSSH {
+			format = "ssh"
+		}
+		set("gpg.format", format)
+		if c.SigningKey != "" {
+			set("user.signingkey", expandHome(c.SigningKey))
+		}
+		set("commit.gpgsign", "true")
+	case SigningOff:
+		set("commit.gpgsign", "false")
+	}
+	return args
+}
+
+// CheckSigning fails when commits are to be signed but cannot be: an
+// unknown signing mode, no key, a missing key file, or a GPG secret key
+// the keyring lacks. Workflows call it before changing anything, rather
+// than leave an unsigned commit for the remote to reject.
+func (r *Repo) CheckSigning() error {
+	c := commitConfig
+	switch c.Signing {
+	case "", SigningOff:
+		return nil
+	case SigningSSH, SigningGPG:
+	default:
+		return hcerrors.New(hcerrors.ErrGit, "gitSigning %q: expected ssh, gpg or off", c.Signing).
+			WithRemediation("set gitSigning in the hctl config, or HCTL_GIT_SIGNING")
+	}
+	key := c.SigningKey
+	if key == "" {
+		key = r.config("user.signingkey")
+	}
+	program, programKey := "gpg", "gpg.program"
+	if c.Signing == SigningSSH {
+		program, programKey = "ssh-keygen", "gpg.ssh.program"
+	}
+	if p := r.config(programKey); p != "" {
+		program = p
+	}
+	if _, err := exec.LookPath(program); err != nil {
+		return hcerrors.New(hcerrors.ErrGit, "%s commit signing needs %s, which is not installed", c.Signing, program)
+	}
+
+	if c.Signing == SigningSSH {
+		if key == "" {
+			return hcerrors.New(hcerrors.ErrGit, "ssh commit signing is on but no signing key is set").
+				WithRemediation("set gitSigningKey (or HCTL_GIT_SIGNING_KEY) to a key file, or git's user.signingkey")
+		}
+		if strings.HasPrefix(key, "key::") || strings.HasPrefix(key, "ssh-") {
+			return nil
+		}
+		if _, err := os.Stat(expandHome(key)); err != nil {
+			return hcerrors.New(hcerrors.ErrGit, "ssh signing key %s is not readable: %v", key, err).
+				WithRemediation("point gitSigningKey (or HCTL_GIT_SIGNING_KEY) at an existing key file")
+		}
+		return nil
+	}
+
+	// gpg picks the key matching the committer email when none is set.
+	if key == "" {
+		if key = c.AuthorEmail; key == "" {
+			key = r.config("user.email")
+		}
+	}
+	if out, err := exec.Command(program, "--batch", "--list-secret-keys", key).CombinedOutput(); err != nil {
+		return hcerrors.New(hcerrors.ErrGit, "gpg secret key %q is not available: %s", key, strings.TrimSpace(string(out))).
+			WithRemediation("import the key, or set gitSigningKey (or HCTL_GIT_SIGNING_KEY) to a key in the keyring")
+	}
+	return nil
+}
+
+// CommitIdentity describes who commits are attributed to and how they are
+// signed, e.g. "Bot <bot@example.com>, signed (ssh ~/.ssh/id_ed25519)".
+func (r *Repo) CommitIdentity() string {
+	c := commitConfig
+	signing := "unsigned"
+	switch c.Signing {
+	case SigningSSH, SigningGPG:
+		key := c.SigningKey
+		if key == "" {
+			key = r.config("user.signingkey")
+		}
+		signing = "signed (" + c.Signing
+		if key != "" {
+			signing += " " + key
+		}
+		signing += ")"
+	case "":
+		if r.config("commit.gpgsign") == "true" {
+			signing = "signed (git config)"
+		}
+	}
+	return r.User() + ", " + signing
+}
+
+// config returns a git config value for the repo, or "".
+func (r *Repo) config(key string) string {
+	out, _ := runGit(r.Root, "config", key)
+	return strings.TrimSpace(out)
+}
+
+// expandHome expands a leading "~/" to the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
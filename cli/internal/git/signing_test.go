@@ -0,0 +1,106 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/audit"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// setCommitConfig sets cfg for the test and restores the default after.
+func setCommitConfig(t *testing.T, cfg CommitConfig) {
+	t.Helper()
+	SetCommitConfig(cfg)
+	t.Cleanup(func() { commitConfig = CommitConfig{} })
+}
+
+// newSSHKey generates an unencrypted ed25519 key and returns its private
+// key path and public key.
+func newSSHKey(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "hctl-test", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, strings.TrimSpace(string(pub))
+}
+
+func TestHandleGitWorkflowSSHSigning(t *testing.T) {
+	dir := newTestRepo(t)
+	key, pub := newSSHKey(t)
+	setCommitConfig(t, CommitConfig{AuthorName: "Platform Bot", AuthorEmail: "bot@example.com", Signing: SigningSSH, SigningKey: key})
+
+	if _, err := HandleGitWorkflow(WorkflowOpts{RepoPath: dir, Paths: []string{"file.yaml"}, Action: "deploy", Resource: "myapp", GitMode: "generate"}); err != nil {
+		t.Fatalf("HandleGitWorkflow: %v", err)
+	}
+
+	author, err := runGit(dir, "log", "-1", "--format=%an <%ae>|%cn <%ce>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(author); got != "Platform Bot <bot@example.com>|Platform Bot <bot@example.com>" {
+		t.Errorf("author|committer = %q, want the bot for both", got)
+	}
+	signers := filepath.Join(t.TempDir(), "allowed_signers")
+	if err := os.WriteFile(signers, []byte("bot@example.com "+pub+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runGit(dir, "-c", "gpg.ssh.allowedSignersFile="+signers, "verify-commit", "HEAD"); err != nil {
+		t.Errorf("verify-commit: %v\n%s", err, out)
+	}
+	entries, err := audit.Read(dir)
+	if err != nil || len(entries) != 1 || entries[0].User != "Platform Bot <bot@example.com>" {
+		t.Errorf("audit entries = %+v, %v; want one by the bot", entries, err)
+	}
+	repo := &Repo{Root: dir}
+	if got, want := repo.CommitIdentity(), "Platform Bot <bot@example.com>, signed (ssh "+key+")"; got != want {
+		t.Errorf("CommitIdentity = %q, want %q", got, want)
+	}
+}
+
+func TestHandleGitWorkflowSigningKeyMissing(t *testing.T) {
+	dir := newTestRepo(t)
+	setCommitConfig(t, CommitConfig{Signing: SigningSSH, SigningKey: filepath.Join(t.TempDir(), "absent")})
+
+	_, err := HandleGitWorkflow(WorkflowOpts{RepoPath: dir, Paths: []string{"file.yaml"}, Action: "deploy", Resource: "myapp", GitMode: "auto"})
+	var he *hcerrors.HctlError
+	if !errors.As(err, &he) || he.Category != hcerrors.ErrGit || !strings.Contains(err.Error(), "not readable") {
+		t.Fatalf("err = %v, want a git error about the key", err)
+	}
+	if head := (&Repo{Root: dir}).Head(); head != "" {
+		t.Errorf("HEAD = %s, want no commit", head)
+	}
+	if _, err := os.Stat(filepath.Join(dir, audit.LogPath)); !os.IsNotExist(err) {
+		t.Errorf("audit log written before the signing check: %v", err)
+	}
+}
+
+func TestSetCommitConfigEnv(t *testing.T) {
+	t.Setenv("HCTL_GIT_AUTHOR_EMAIL", "ci@example.com")
+	t.Setenv("HCTL_GIT_SIGNING", SigningOff)
+	setCommitConfig(t, CommitConfig{AuthorName: "Alice", AuthorEmail: "alice@example.com", Signing: SigningSSH})
+
+	want := []string{"-c", "user.name=Alice", "-c", "user.email=ci@example.com", "-c", "commit.gpgsign=false"}
+	if got := commitConfig.args(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+}
+
+func TestCheckSigningUnknownMode(t *testing.T) {
+	setCommitConfig(t, CommitConfig{Signing: "x509"})
+	if err := (&Repo{Root: newTestRepo(t)}).CheckSigning(); err == nil || !strings.Contains(err.Error(), `"x509"`) {
+		t.Errorf("CheckSigning = %v, want an unknown mode error", err)
+	}
+}
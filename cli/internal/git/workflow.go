@@ -2,7 +2,11 @@ package git
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/jamesatintegratnio/hctl/internal/audit"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 )
 
@@ -26,7 +30,8 @@ const (
 type WorkflowOpts struct {
 	// RepoPath is the working directory or path inside the repo.
 	RepoPath string
-	// Paths are relative file paths (to repo root) to stage.
+	// Paths are relative file paths (to repo root) to stage. OS separators
+	// are normalized to forward slashes before they are recorded or staged.
 	Paths []string
 	// Action is the verb for the commit message (e.g. "create vcluster").
 	Action string
@@ -40,6 +45,41 @@ type WorkflowOpts struct {
 	Interactive bool
 	// ConfirmPrompt overrides the default "Commit and push?" prompt.
 	ConfirmPrompt string
+	// PolicyOverride records a --policy-override (see policy.Guard.Override)
+	// in the commit message and audit log.
+	PolicyOverride string
+	// ResourceExemption records a workload's resource-exemption annotation
+	// (see translate.ResourceExemption) in the commit message for review.
+	ResourceExemption string
+	// InjectedEnv names the environment variables interpolated into the
+	// workload (see score.EnvOptions); values are never recorded.
+	InjectedEnv []string
+	// Removed names the generated objects the change stops rendering, for
+	// the audit log.
+	Removed []string
+}
+
+// commitMessage formats the commit message for opts, with a
+// Policy-Override trailer when the change overrode the repo policy, a
+// Resource-Exemption trailer when a workload is exempt from the resource
+// maximums, and an Injected-Env trailer naming the environment variables
+// interpolated into the workload.
+func commitMessage(opts WorkflowOpts) string {
+	msg := FormatCommitMessage(opts.Action, opts.Resource, opts.Details)
+	var trailers []string
+	if opts.PolicyOverride != "" {
+		trailers = append(trailers, "Policy-Override: "+opts.PolicyOverride)
+	}
+	if opts.ResourceExemption != "" {
+		trailers = append(trailers, "Resource-Exemption: "+opts.ResourceExemption)
+	}
+	if len(opts.InjectedEnv) > 0 {
+		trailers = append(trailers, "Injected-Env: "+strings.Join(opts.InjectedEnv, ", "))
+	}
+	if len(trailers) > 0 {
+		msg += "\n\n" + strings.Join(trailers, "\n")
+	}
+	return msg
 }
 
 // HandleGitWorkflow executes the standard git commit/push workflow based on
@@ -47,14 +87,31 @@ type WorkflowOpts struct {
 // found across vcluster create, vcluster delete, deploy run, deploy remove,
 // addon enable, and addon disable.
 //
-// Returns the GitResult describing what happened and any error.
+// Every call appends an entry to the repo's audit log (.hctl/audit.log),
+// which is staged and committed together with opts.Paths.
+//
+// Returns the GitResult describing what happened and any error. Errors are
+// categorized as hcerrors.ErrGit.
 func HandleGitWorkflow(opts WorkflowOpts) (GitResult, error) {
+	result, err := handleGitWorkflow(opts)
+	return result, hcerrors.Wrap(hcerrors.ErrGit, err)
+}
+
+func handleGitWorkflow(opts WorkflowOpts) (GitResult, error) {
 	repo, err := DetectRepo(opts.RepoPath)
 	if err != nil {
 		return GitNoRepo, nil // non-fatal: user can commit manually
 	}
+	if commits(opts) {
+		if err := repo.CheckSigning(); err != nil {
+			return GitSkipped, err
+		}
+	}
+	if opts.Paths, err = recordAudit(repo, opts); err != nil {
+		return GitSkipped, err
+	}
 
-	msg := FormatCommitMessage(opts.Action, opts.Resource, opts.Details)
+	msg := commitMessage(opts)
 	prompt := opts.ConfirmPrompt
 	if prompt == "" {
 		prompt = "Commit and push?"
@@ -82,6 +139,7 @@ func HandleGitWorkflow(opts WorkflowOpts) (GitResult, error) {
 		if !opts.Interactive {
 			return GitSkipped, nil
 		}
+		fmt.Printf("  %s %s\n", tui.DimStyle.Render("Committing as"), repo.CommitIdentity())
 		confirmed, _ := tui.Confirm(prompt)
 		if !confirmed {
 			// Best-effort stage so files aren't lost
@@ -101,6 +159,48 @@ func HandleGitWorkflow(opts WorkflowOpts) (GitResult, error) {
 	}
 }
 
+// DescribeWorkflow says what HandleGitWorkflow would do with opts, for
+// --dry-run output. It only reads the repo.
+func DescribeWorkflow(opts WorkflowOpts) string {
+	if _, err := DetectRepo(opts.RepoPath); err != nil {
+		return "none: no git repository detected, commit manually"
+	}
+	files := fmt.Sprintf("%d %s and %s", len(opts.Paths), plural(len(opts.Paths), "file", "files"), audit.LogPath)
+	subject, _, _ := strings.Cut(commitMessage(opts), "\n")
+	switch opts.GitMode {
+	case "auto":
+		return fmt.Sprintf("commit and push %s: %q", files, subject)
+	case "generate":
+		return fmt.Sprintf("commit %s locally: %q", files, subject)
+	case "prompt":
+		if !opts.Interactive {
+			return fmt.Sprintf("append to %s; no commit (git mode prompt, non-interactive)", audit.LogPath)
+		}
+		return fmt.Sprintf("ask, then commit and push %s: %q", files, subject)
+	default:
+		return fmt.Sprintf("append to %s; no commit (git mode %q)", audit.LogPath, opts.GitMode)
+	}
+}
+
+// commits reports whether the workflow for opts makes, or may make, a
+// commit.
+func commits(opts WorkflowOpts) bool {
+	switch opts.GitMode {
+	case "auto", "generate":
+		return true
+	case "prompt":
+		return opts.Interactive
+	}
+	return false
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
 // HandleGitWorkflowStep returns a tui.Step for use inside tui.RunSteps.
 // If gitMode is "prompt", the prompt is shown *before* calling RunSteps,
 // so this should only be used with "auto" or "generate" modes.
@@ -122,21 +222,29 @@ func HandleGitWorkflowStep(opts WorkflowOpts) tui.Step {
 			if err != nil {
 				return "no git repo detected", nil
 			}
+			if commits(opts) {
+				if err := repo.CheckSigning(); err != nil {
+					return "", err
+				}
+			}
+			if opts.Paths, err = recordAudit(repo, opts); err != nil {
+				return "", hcerrors.Wrap(hcerrors.ErrGit, err)
+			}
 
-			msg := FormatCommitMessage(opts.Action, opts.Resource, opts.Details)
+			msg := commitMessage(opts)
 
 			switch opts.GitMode {
 			case "auto":
 				if err := repo.CommitAndPush(opts.Paths, msg); err != nil {
-					return "", err
+					return "", hcerrors.Wrap(hcerrors.ErrGit, err)
 				}
 				return msg, nil
 			case "generate":
 				if err := repo.Add(opts.Paths...); err != nil {
-					return "", err
+					return "", hcerrors.Wrap(hcerrors.ErrGit, err)
 				}
 				if err := repo.Commit(msg); err != nil {
-					return "", err
+					return "", hcerrors.Wrap(hcerrors.ErrGit, err)
 				}
 				return msg + " (push manually)", nil
 			default:
@@ -146,3 +254,28 @@ func HandleGitWorkflowStep(opts WorkflowOpts) tui.Step {
 		},
 	}
 }
+
+// recordAudit appends the mutation to the repo's audit log and returns the
+// paths to stage, slash-normalized and including the log.
+func recordAudit(repo *Repo, opts WorkflowOpts) ([]string, error) {
+	paths := make([]string, len(opts.Paths))
+	for i, p := range opts.Paths {
+		paths[i] = repopath.ToSlash(p)
+	}
+	opts.Paths = paths
+	_, err := audit.Append(repo.Root, audit.Entry{
+		User:     repo.User(),
+		Action:   opts.Action,
+		Resource: opts.Resource,
+		Details:  opts.Details,
+		Paths:    opts.Paths,
+		Removed:  opts.Removed,
+		Base:     repo.Head(),
+
+		PolicyOverride: opts.PolicyOverride,
+	})
+	if err != nil {
+		return opts.Paths, fmt.Errorf("recording audit log entry: %w", err)
+	}
+	return append(append([]string(nil), opts.Paths...), audit.LogPath), nil
+}
@@ -0,0 +1,331 @@
+// Package inventory assembles a snapshot of the platform for 'hctl report
+// generate': the clusters, their workloads and the addons per environment
+// as the repo configures them, the repo's recent changes, and optionally
+// the live status of each. It renders the snapshot as markdown or HTML.
+package inventory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+	"github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultChangeLimit is how many commits the recent changes appendix lists
+// without --changes.
+const DefaultChangeLimit = 20
+
+// Inventory is a snapshot of the platform.
+type Inventory struct {
+	GeneratedAt time.Time
+	Clusters    []Cluster
+	// Environments are the addon environment layers, by name.
+	Environments []Environment
+	// Changes are the recent commits to platform paths, newest first.
+	Changes []Change
+	// LiveAt is when the live status was collected; nil when it was not,
+	// and the report leaves the live columns out.
+	LiveAt *time.Time
+	// LiveError says why the live status is missing.
+	LiveError string
+}
+
+// Cluster is a vCluster as its request in platform/vclusters configures it.
+type Cluster struct {
+	Name         string
+	Environment  string
+	Preset       string
+	K8sVersion   string
+	ChartVersion string
+	// Replicas is the control plane replica override; "" for the preset's.
+	Replicas  string
+	Hostname  string
+	Workloads []Workload
+	// Addons are the entries of the cluster's own addon layer.
+	Addons []Addon
+	Live   *Live
+}
+
+// Workload is a workload enabled in a cluster's addons.yaml.
+type Workload struct {
+	Name   string
+	Images []string
+	Routes []string
+	Live   *Live
+}
+
+// Environment is an addon environment layer.
+type Environment struct {
+	Name   string
+	Addons []Addon
+}
+
+// Addon is an addons.yaml entry.
+type Addon struct {
+	Name      string
+	Namespace string
+	Chart     string
+	Version   string
+	Enabled   bool
+	// Live sums up the addon's Applications in the environment.
+	Live *Live
+}
+
+// Live is the live status of a cluster, workload or addon.
+type Live struct {
+	Phase  string
+	Sync   string
+	Health string
+	// Apps and Healthy count an addon's Applications across clusters.
+	Apps    int
+	Healthy int
+}
+
+// Change is a commit to a platform path.
+type Change struct {
+	Commit  string
+	Date    time.Time
+	Author  string
+	Subject string
+}
+
+// platformPaths are the repo directories whose commits the changes
+// appendix lists, besides each cluster's workloads root.
+var platformPaths = []string{"platform", "addons", layout.PromisesDir}
+
+// FromRepo reads the inventory the repo at repoPath configures, with up to
+// changeLimit recent changes. The repo is authoritative for configuration;
+// AddLive adds the live status.
+func FromRepo(repoPath string, changeLimit int, now time.Time) (*Inventory, error) {
+	inv := &Inventory{GeneratedAt: now}
+	clusters, err := readClusters(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		names, err := deploy.ListWorkloads(repoPath, c.Name)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("listing the workloads of %s: %w", c.Name, err)
+		}
+		for _, name := range names {
+			s, err := deploy.SummarizeWorkload(repoPath, c.Name, name)
+			if err != nil {
+				return nil, fmt.Errorf("reading workload %s in %s: %w", name, c.Name, err)
+			}
+			c.Workloads = append(c.Workloads, Workload{Name: name, Images: s.Images, Routes: s.Routes})
+		}
+		c.Addons, err = readAddons(repoPath, addon.Layer{Kind: addon.LayerCluster, Name: c.Name})
+		if err != nil {
+			return nil, err
+		}
+		inv.Clusters = append(inv.Clusters, c)
+	}
+
+	layers, err := addon.DiscoverLayers(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range layers {
+		if l.Kind != addon.LayerEnvironment {
+			continue
+		}
+		addons, err := readAddons(repoPath, l)
+		if err != nil {
+			return nil, err
+		}
+		inv.Environments = append(inv.Environments, Environment{Name: l.Name, Addons: addons})
+	}
+
+	if changeLimit > 0 {
+		if inv.Changes, err = recentChanges(repoPath, inv.Clusters, changeLimit); err != nil {
+			return nil, err
+		}
+	}
+	return inv, nil
+}
+
+// readClusters reads the vCluster requests in platform/vclusters, sorted
+// by name.
+func readClusters(repoPath string) ([]Cluster, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Cluster
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		kind, _, _ := platform.UnstructuredNestedString(doc, "kind")
+		name, _, _ := platform.UnstructuredNestedString(doc, "metadata", "name")
+		if kind != "VClusterOrchestratorV2" || name == "" {
+			continue
+		}
+		c := Cluster{Name: name, Environment: layout.DefaultEnvironment, ChartVersion: platform.DefaultChartVersion}
+		c.Preset, _, _ = platform.UnstructuredNestedString(doc, "spec", "vcluster", "preset")
+		c.K8sVersion, _, _ = platform.UnstructuredNestedString(doc, "spec", "vcluster", "k8sVersion")
+		c.Hostname, _, _ = platform.UnstructuredNestedString(doc, "spec", "exposure", "hostname")
+		if v, _, _ := platform.UnstructuredNestedString(doc, "spec", "integrations", "argocd", "environment"); v != "" {
+			c.Environment = v
+		}
+		if v, _, _ := platform.UnstructuredNestedString(doc, "spec", "argocdApplication", "targetRevision"); v != "" {
+			c.ChartVersion = v
+		}
+		if vc, ok := nested(doc, "spec", "vcluster").(map[string]interface{}); ok && vc["replicas"] != nil {
+			c.Replicas = fmt.Sprint(vc["replicas"])
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// readAddons reads a layer's addons.yaml entries, sorted by name; none when
+// the layer has no addons.yaml.
+func readAddons(repoPath string, l addon.Layer) ([]Addon, error) {
+	entries, err := addon.ReadEntries(l.AddonsFile(repoPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", l, err)
+	}
+	var out []Addon
+	for name, e := range entries {
+		a := Addon{Name: name, Namespace: str(e["namespace"]), Chart: str(e["chartName"]), Version: str(e["defaultVersion"]), Enabled: true}
+		if a.Chart == "" && str(e["chartRepository"]) != "" {
+			a.Chart = name
+		}
+		if enabled, ok := e["enabled"].(bool); ok {
+			a.Enabled = enabled
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// recentChanges lists the commits to the platform paths and the clusters'
+// workloads roots. The paths are relative to repoPath, which may be below
+// the repo root.
+func recentChanges(repoPath string, clusters []Cluster, limit int) ([]Change, error) {
+	repo, err := git.DetectRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	dirs := append([]string{}, platformPaths...)
+	seen := map[string]bool{}
+	for _, c := range clusters {
+		root := layout.Active().WorkloadsRoot(c.Name)
+		if !seen[root] {
+			seen[root] = true
+			dirs = append(dirs, root)
+		}
+	}
+	var paths []string
+	for _, d := range dirs {
+		abs, err := filepath.Abs(repopath.Abs(repoPath, d))
+		if err != nil {
+			return nil, err
+		}
+		rel, err := repo.RelPath(abs)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, rel)
+	}
+	entries, err := repo.PathLog(limit, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("reading the repo history: %w", err)
+	}
+	changes := make([]Change, len(entries))
+	for i, e := range entries {
+		changes[i] = Change{Commit: e.Hash, Date: e.Date, Author: e.Author, Subject: e.Subject}
+	}
+	return changes, nil
+}
+
+// AddLive adds the live status collected at at to the inventory: each
+// cluster's and workload's Application, and per environment how many of
+// each addon's Applications are healthy.
+func (inv *Inventory) AddLive(ps *platform.PlatformStatus, at time.Time) {
+	inv.LiveAt = &at
+	inv.LiveError = ""
+
+	vclusters := map[string]platform.ResourceStatus{}
+	for _, rs := range ps.VClusters {
+		vclusters[rs.Name] = rs
+	}
+	workloads := map[[2]string]platform.ResourceStatus{}
+	for _, rs := range ps.Workloads {
+		workloads[[2]string{rs.Labels["clusterName"], rs.Name}] = rs
+	}
+	for i := range inv.Clusters {
+		c := &inv.Clusters[i]
+		if rs, ok := vclusters[c.Name]; ok {
+			c.Live = live(rs)
+		}
+		for j := range c.Workloads {
+			if rs, ok := workloads[[2]string{c.Name, c.Workloads[j].Name}]; ok {
+				c.Workloads[j].Live = live(rs)
+			}
+		}
+	}
+
+	for i := range inv.Environments {
+		env := &inv.Environments[i]
+		for j := range env.Addons {
+			a := &env.Addons[j]
+			for _, rs := range ps.Addons {
+				if rs.Name != a.Name || rs.Labels["environment"] != env.Name {
+					continue
+				}
+				if a.Live == nil {
+					a.Live = &Live{}
+				}
+				a.Live.Apps++
+				if rs.ArgoCD.HealthStatus == "Healthy" {
+					a.Live.Healthy++
+				}
+			}
+		}
+	}
+}
+
+func live(rs platform.ResourceStatus) *Live {
+	return &Live{Phase: rs.Phase, Sync: rs.ArgoCD.SyncStatus, Health: rs.ArgoCD.HealthStatus}
+}
+
+// nested returns the value at path in doc, nil when any step is missing.
+func nested(doc map[string]interface{}, path ...string) interface{} {
+	var cur interface{} = doc
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[p]
+	}
+	return cur
+}
+
+func str(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package inventory
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("report does not match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+var generatedAt = time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+// newFixtureRepo lays out two clusters with a handful of workloads, with
+// fixed commit dates so the changes appendix is stable.
+func newFixtureRepo(t *testing.T) *testutil.Repo {
+	t.Setenv("GIT_AUTHOR_DATE", "2026-03-01T12:00:00Z")
+	t.Setenv("GIT_COMMITTER_DATE", "2026-03-01T12:00:00Z")
+	repo := testutil.NewRepo(t, testutil.RepoOptions{
+		Clusters: []string{"vcluster-media", "vcluster-dev"},
+		Addons: []testutil.Addon{
+			{Name: "cert-manager", Chart: "cert-manager", Version: "v1.16.2"},
+			{Name: "loki", Version: "6.24.0", Disabled: true},
+		},
+		Files: map[string]string{
+			"addons/clusters/vcluster-media/addons/addons.yaml": "intel-gpu:\n  enabled: true\n  namespace: gpu\n  chartName: intel-device-plugins-gpu\n  chartRepository: https://intel.github.io/helm-charts\n  defaultVersion: 0.31.1\n",
+		},
+	})
+	workloads := func(cluster string, names ...string) {
+		addons := "globalSelectors:\n  cluster_name: " + cluster + "\nuseAddonNameForValues: true\n"
+		for _, n := range names {
+			addons += n + ":\n  enabled: true\n  namespace: " + n + "\n"
+		}
+		repo.WriteFile(deploy.AddonsPath(cluster), addons)
+	}
+	workloads("vcluster-media", "jellyfin", "sonarr")
+	repo.WriteFile(translate.ValuesPath("vcluster-media", "jellyfin"), `# Generated by hctl from score.yaml
+deployment:
+  image:
+    repository: jellyfin/jellyfin
+    tag: "10.10.3"
+  additionalContainers:
+    - name: exporter
+      image: rebelcore/jellyfin-exporter:1.3.0
+httpRoute:
+  enabled: true
+  hostnames:
+    - watch.integratn.tech
+    - jellyfin.integratn.tech
+`)
+	repo.WriteFile(translate.ValuesPath("vcluster-media", "sonarr"), `deployment:
+  image: lscr.io/linuxserver/sonarr:4.0.11
+httpRoute:
+  enabled: false
+  hostnames:
+    - sonarr.integratn.tech
+`)
+	repo.Commit("Deploy jellyfin and sonarr to vcluster-media")
+	workloads("vcluster-dev", "echo")
+	repo.WriteFile(translate.ValuesPath("vcluster-dev", "echo"), `statefulset:
+  image:
+    repository: ealen/echo-server
+    tag: "0.9.2"
+    digest: sha256:4b8e
+httpRoute:
+  enabled: true
+  hostnames:
+    - echo.dev.integratn.tech
+`)
+	repo.Commit("Deploy echo to vcluster-dev")
+	repo.WriteFile("README.md", "# homelab\n")
+	repo.Commit("Update README")
+	return repo
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	repo := newFixtureRepo(t)
+	inv, err := FromRepo(repo.Root, DefaultChangeLimit, generatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, inv, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.md.golden", buf.Bytes())
+}
+
+func TestRenderMarkdownLive(t *testing.T) {
+	repo := newFixtureRepo(t)
+	inv, err := FromRepo(repo.Root, 0, generatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := func(name, cluster, env, sync, health string) platform.ResourceStatus {
+		return platform.ResourceStatus{
+			Name:   name,
+			Phase:  platform.PhaseFromArgoCD(sync, health),
+			ArgoCD: platform.ArgoCDInfo{SyncStatus: sync, HealthStatus: health},
+			Labels: map[string]string{"clusterName": cluster, "environment": env, "addonName": name},
+		}
+	}
+	inv.AddLive(&platform.PlatformStatus{
+		VClusters: []platform.ResourceStatus{{
+			Name: "vcluster-media", Phase: "Ready",
+			ArgoCD: platform.ArgoCDInfo{SyncStatus: "Synced", HealthStatus: "Healthy"},
+		}},
+		Workloads: []platform.ResourceStatus{
+			app("jellyfin", "vcluster-media", "production", "Synced", "Healthy"),
+			app("sonarr", "vcluster-media", "production", "OutOfSync", "Degraded"),
+		},
+		Addons: []platform.ResourceStatus{
+			app("cert-manager", "the-cluster", "production", "Synced", "Healthy"),
+			app("cert-manager", "vcluster-media", "production", "Synced", "Progressing"),
+			app("cert-manager", "vcluster-staging", "staging", "Synced", "Healthy"),
+		},
+	}, generatedAt.Add(-time.Minute))
+
+	var buf bytes.Buffer
+	if err := Render(&buf, inv, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report-live.md.golden", buf.Bytes())
+}
+
+func TestRenderHTML(t *testing.T) {
+	repo := newFixtureRepo(t)
+	inv, err := FromRepo(repo.Root, DefaultChangeLimit, generatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv.LiveError = "cluster unreachable"
+
+	var buf bytes.Buffer
+	if err := Render(&buf, inv, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<a href="#cluster-vcluster-media">Cluster vcluster-media</a>`,
+		`<h3 id="cluster-vcluster-media">Cluster vcluster-media</h3>`,
+		`<code>jellyfin/jellyfin:10.10.3</code><br><code>rebelcore/jellyfin-exporter:1.3.0</code>`,
+		`Live status is omitted: cluster unreachable.`,
+		`Deploy echo to vcluster-dev`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML report is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "(live)") {
+		t.Errorf("offline HTML report has live columns:\n%s", out)
+	}
+
+	if err := Render(&buf, inv, "pdf"); err == nil {
+		t.Error("Render accepted format pdf")
+	}
+}
@@ -0,0 +1,85 @@
+package inventory
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// Report formats accepted by Render.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Formats lists the report formats, for flag help and completion.
+var Formats = []string{FormatMarkdown, FormatHTML}
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]interface{}{
+	"anchor": anchor,
+	"cell":   cell,
+	"date":   func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+	"day":    func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	"join":   strings.Join,
+	"short": func(hash string) string {
+		if len(hash) > 7 {
+			return hash[:7]
+		}
+		return hash
+	},
+	"dash": func(s string) string {
+		if s == "" {
+			return "—"
+		}
+		return s
+	},
+	"yesno": func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	},
+}
+
+// Render writes inv to w in format.
+func Render(w io.Writer, inv *Inventory, format string) error {
+	switch format {
+	case FormatMarkdown:
+		t, err := template.New("report.md.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.md.tmpl")
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, inv)
+	case FormatHTML:
+		t, err := htmltemplate.New("report.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.html.tmpl")
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, inv)
+	}
+	return hcerrors.NewUserError("unknown report format %q (must be %s)", format, strings.Join(Formats, " or "))
+}
+
+var nonAnchor = regexp.MustCompile(`[^a-z0-9 _-]`)
+
+// anchor is the id GitHub gives a markdown heading.
+func anchor(heading string) string {
+	return strings.ReplaceAll(nonAnchor.ReplaceAllString(strings.ToLower(heading), ""), " ", "-")
+}
+
+// cell makes s safe in a markdown table cell, "—" when empty.
+func cell(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,146 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AccessRule is a permission a service account needs: one verb on one
+// resource, optionally a subresource such as "status".
+type AccessRule struct {
+	GVR         schema.GroupVersionResource
+	Subresource string
+	Verb        string
+}
+
+// Resource returns the rule's resource as RBAC names it, e.g.
+// "vclusterorchestratorv2s/status".
+func (r AccessRule) Resource() string {
+	if r.Subresource != "" {
+		return r.GVR.Resource + "/" + r.Subresource
+	}
+	return r.GVR.Resource
+}
+
+// String returns the rule as "verb resource.group", e.g.
+// "list works.platform.kratix.io".
+func (r AccessRule) String() string {
+	if r.GVR.Group == "" {
+		return r.Verb + " " + r.Resource()
+	}
+	return r.Verb + " " + r.Resource() + "." + r.GVR.Group
+}
+
+// AccessStatus is the outcome of checking one AccessRule.
+type AccessStatus string
+
+const (
+	AccessAllowed AccessStatus = "allowed"
+	AccessDenied  AccessStatus = "denied"
+	// AccessNotInstalled means the API server does not serve the resource,
+	// typically because its CRD is not installed.
+	AccessNotInstalled AccessStatus = "not-installed"
+)
+
+// AccessResult is the outcome of checking one AccessRule.
+type AccessResult struct {
+	Rule   AccessRule
+	Status AccessStatus
+	// Reason is the authorizer's explanation, when it gave one.
+	Reason string
+}
+
+// ServiceAccountAccess checks whether the service account namespace/name
+// holds each rule, cluster-wide. Rules on resources the API server does not
+// serve are reported as AccessNotInstalled without a review. It needs
+// permission to create SubjectAccessReviews.
+func (c *Client) ServiceAccountAccess(ctx context.Context, namespace, name string, rules []AccessRule) ([]AccessResult, error) {
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+	groups := []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+
+	served := map[schema.GroupVersion]map[string]bool{}
+	results := make([]AccessResult, 0, len(rules))
+	for _, rule := range rules {
+		gv := rule.GVR.GroupVersion()
+		resources, ok := served[gv]
+		if !ok {
+			var err error
+			if resources, err = c.servedResources(gv); err != nil {
+				return nil, err
+			}
+			served[gv] = resources
+		}
+		if !resources[rule.Resource()] {
+			results = append(results, AccessResult{Rule: rule, Status: AccessNotInstalled})
+			continue
+		}
+
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user,
+				Groups: groups,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:       rule.GVR.Group,
+					Version:     rule.GVR.Version,
+					Resource:    rule.GVR.Resource,
+					Subresource: rule.Subresource,
+					Verb:        rule.Verb,
+				},
+			},
+		}
+		got, err := c.Clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("reviewing %s for %s: %w", rule, user, ClassifyError(err))
+		}
+		status := AccessDenied
+		if got.Status.Allowed {
+			status = AccessAllowed
+		}
+		results = append(results, AccessResult{Rule: rule, Status: status, Reason: got.Status.Reason})
+	}
+	return results, nil
+}
+
+// servedResources returns the resources (and subresources) the API server
+// serves in gv. A group version it does not serve has none.
+func (c *Client) servedResources(gv schema.GroupVersion) (map[string]bool, error) {
+	list, err := c.Clientset.Discovery().ServerResourcesForGroupVersion(gv.String())
+	if apierrors.IsNotFound(err) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", gv, ClassifyError(err))
+	}
+	resources := map[string]bool{}
+	for _, r := range list.APIResources {
+		resources[r.Name] = true
+	}
+	return resources, nil
+}
+
+// ClusterRolePatch returns a kubectl command appending rules that grant
+// denied to the ClusterRole name, one rule per API group and resource.
+func ClusterRolePatch(name string, denied []AccessRule) string {
+	type key struct{ group, resource string }
+	var order []key
+	verbs := map[key][]string{}
+	for _, r := range denied {
+		k := key{r.GVR.Group, r.Resource()}
+		if _, ok := verbs[k]; !ok {
+			order = append(order, k)
+		}
+		verbs[k] = append(verbs[k], r.Verb)
+	}
+	ops := make([]string, 0, len(order))
+	for _, k := range order {
+		ops = append(ops, fmt.Sprintf(`{"op":"add","path":"/rules/-","value":{"apiGroups":[%q],"resources":[%q],"verbs":["%s"]}}`,
+			k.group, k.resource, strings.Join(verbs[k], `","`)))
+	}
+	return fmt.Sprintf("kubectl patch clusterrole %s --type=json -p '[%s]'", name, strings.Join(ops, ","))
+}
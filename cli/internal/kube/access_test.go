@@ -0,0 +1,112 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// accessClient returns a client serving the platform.kratix.io works and
+// the core pods resources, whose authorizer allows exactly the rules in
+// granted ("verb resource").
+func accessClient(granted ...string) (*Client, *[]authorizationv1.SubjectAccessReviewSpec) {
+	clientset := fake.NewClientset()
+	clientset.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "platform.kratix.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "works"}}},
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods"}, {Name: "pods/log"}}},
+	}
+	var reviews []authorizationv1.SubjectAccessReviewSpec
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		reviews = append(reviews, review.Spec)
+		attrs := review.Spec.ResourceAttributes
+		resource := attrs.Resource
+		if attrs.Subresource != "" {
+			resource += "/" + attrs.Subresource
+		}
+		for _, g := range granted {
+			if g == attrs.Verb+" "+resource {
+				review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: true, Reason: "granted"}
+				return true, review, nil
+			}
+		}
+		return true, review, nil
+	})
+	return &Client{Clientset: clientset}, &reviews
+}
+
+var worksGVR = schema.GroupVersionResource{Group: "platform.kratix.io", Version: "v1alpha1", Resource: "works"}
+
+func TestServiceAccountAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		rule    AccessRule
+		want    AccessStatus
+		// reviewed is whether a SubjectAccessReview is sent.
+		reviewed bool
+	}{
+		{name: "allowed", granted: []string{"list works"}, rule: AccessRule{GVR: worksGVR, Verb: "list"}, want: AccessAllowed, reviewed: true},
+		{name: "denied", granted: []string{"get works"}, rule: AccessRule{GVR: worksGVR, Verb: "list"}, want: AccessDenied, reviewed: true},
+		{name: "subresource allowed", granted: []string{"get pods/log"}, rule: AccessRule{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Subresource: "log", Verb: "get"}, want: AccessAllowed, reviewed: true},
+		{name: "group not installed", rule: AccessRule{GVR: schema.GroupVersionResource{Group: "registration.integratn.tech", Version: "v1alpha1", Resource: "registrations"}, Verb: "list"}, want: AccessNotInstalled},
+		{name: "resource not installed", rule: AccessRule{GVR: schema.GroupVersionResource{Group: "platform.kratix.io", Version: "v1alpha1", Resource: "workplacements"}, Verb: "list"}, want: AccessNotInstalled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, reviews := accessClient(tt.granted...)
+			results, err := client.ServiceAccountAccess(context.Background(), "psr", "reconciler", []AccessRule{tt.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 || results[0].Status != tt.want {
+				t.Fatalf("results = %+v, want one %s", results, tt.want)
+			}
+			if got := len(*reviews) > 0; got != tt.reviewed {
+				t.Fatalf("reviewed = %v, want %v", got, tt.reviewed)
+			}
+			if tt.reviewed {
+				spec := (*reviews)[0]
+				if spec.User != "system:serviceaccount:psr:reconciler" {
+					t.Errorf("reviewed user %q", spec.User)
+				}
+				if spec.ResourceAttributes.Group != tt.rule.GVR.Group || spec.ResourceAttributes.Verb != tt.rule.Verb {
+					t.Errorf("reviewed %+v, want %s", spec.ResourceAttributes, tt.rule)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceAccountAccessReviewForbidden(t *testing.T) {
+	client, _ := accessClient()
+	client.Clientset.(*fake.Clientset).PrependReactor("create", "subjectaccessreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("subjectaccessreviews is forbidden")
+	})
+	_, err := client.ServiceAccountAccess(context.Background(), "psr", "reconciler", []AccessRule{{GVR: worksGVR, Verb: "list"}})
+	if err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("err = %v, want the review's error", err)
+	}
+}
+
+func TestClusterRolePatch(t *testing.T) {
+	got := ClusterRolePatch("psr", []AccessRule{
+		{GVR: worksGVR, Verb: "get"},
+		{GVR: worksGVR, Verb: "list"},
+		{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Subresource: "status", Verb: "patch"},
+	})
+	want := `kubectl patch clusterrole psr --type=json -p '[` +
+		`{"op":"add","path":"/rules/-","value":{"apiGroups":["platform.kratix.io"],"resources":["works"],"verbs":["get","list"]}},` +
+		`{"op":"add","path":"/rules/-","value":{"apiGroups":[""],"resources":["pods/status"],"verbs":["patch"]}}]'`
+	if got != want {
+		t.Errorf("ClusterRolePatch =\n%s\nwant\n%s", got, want)
+	}
+}
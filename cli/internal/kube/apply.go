@@ -0,0 +1,73 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// FieldManager owns the fields hctl writes with server-side apply.
+const FieldManager = "hctl"
+
+// ResourceMapper maps kinds to the resources the API server serves them
+// as, from discovery. Reset it after installing CRDs so their kinds
+// resolve.
+func (c *Client) ResourceMapper() meta.ResettableRESTMapper {
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.Clientset.Discovery()))
+}
+
+// ApplyObject server-side applies obj as FieldManager, taking over fields
+// other managers own, and creates it when it does not exist. With dryRun
+// the API server validates and defaults the change without persisting it.
+// It returns the live object before the apply, nil when there was none,
+// and the object the apply produced.
+func (c *Client) ApplyObject(ctx context.Context, mapping *meta.RESTMapping, obj *unstructured.Unstructured, dryRun bool) (before, after *unstructured.Unstructured, err error) {
+	var ri dynamic.ResourceInterface = c.Dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = c.Dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	}
+	var dry []string
+	if dryRun {
+		dry = []string{metav1.DryRunAll}
+	}
+
+	before, err = ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		after, err = ri.Create(ctx, obj, metav1.CreateOptions{FieldManager: FieldManager, DryRun: dry})
+		return nil, after, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+	force := true
+	after, err = ri.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: FieldManager, Force: &force, DryRun: dry})
+	return before, after, err
+}
+
+// DeleteObject deletes the object named name, in namespace when mapping is
+// namespaced, letting the garbage collector remove its dependents. It
+// reports false, and no error, when the object does not exist.
+func (c *Client) DeleteObject(ctx context.Context, mapping *meta.RESTMapping, namespace, name string) (bool, error) {
+	var ri dynamic.ResourceInterface = c.Dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = c.Dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+	policy := metav1.DeletePropagationBackground
+	err := ri.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &policy})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
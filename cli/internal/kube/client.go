@@ -3,13 +3,22 @@ package kube
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -22,23 +31,62 @@ import (
 
 // Client wraps Kubernetes client-go for platform operations.
 type Client struct {
-	Clientset *kubernetes.Clientset
+	Clientset kubernetes.Interface
 	Dynamic   dynamic.Interface
-	Config    *rest.Config
+	// Config is the REST config the clients were built from. It is nil for
+	// clients assembled from fakes.
+	Config *rest.Config
+}
+
+// clientFactory builds the client NewClient returns. Tests swap it with
+// SetClientFactory to point commands at a fake or envtest cluster.
+var clientFactory = newClientForContext
+
+// SetClientFactory replaces the constructor behind NewClient and returns a
+// function that restores the previous one.
+func SetClientFactory(f func(kubeContext string) (*Client, error)) (restore func()) {
+	prev := clientFactory
+	clientFactory = f
+	return func() { clientFactory = prev }
 }
 
 // NewClient creates a new Kubernetes client, optionally targeting a specific context.
 func NewClient(kubeContext string) (*Client, error) {
+	return clientFactory(kubeContext)
+}
+
+func newClientForContext(kubeContext string) (*Client, error) {
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	overrides := &clientcmd.ConfigOverrides{}
 	if kubeContext != "" {
 		overrides.CurrentContext = kubeContext
 	}
+	logging.L().Debug("loading kubeconfig", "context", kubeContext, "files", rules.GetLoadingPrecedence())
 
 	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+		return nil, hcerrors.New(hcerrors.ErrClusterUnreachable, "loading kubeconfig: %w", err).
+			WithRemediation("check KUBECONFIG or set kubeContext in the hctl config")
 	}
+	return NewClientForConfig(cfg)
+}
+
+// NewClientFromKubeconfig creates a client from raw kubeconfig bytes, such
+// as a vCluster's kubeconfig secret.
+func NewClientFromKubeconfig(data []byte) (*Client, error) {
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	return NewClientForConfig(cfg)
+}
+
+// NewClientForConfig creates a client from a REST config. Requests are
+// logged at debug level.
+func NewClientForConfig(cfg *rest.Config) (*Client, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper { return &loggingTransport{next: rt} })
+	logging.L().Debug("kube client", "host", cfg.Host)
 
 	clientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
@@ -57,6 +105,15 @@ func NewClient(kubeContext string) (*Client, error) {
 	}, nil
 }
 
+// ClassifyError categorizes an API error: NotFound responses become
+// hcerrors.ErrNotFound, everything else hcerrors.ErrClusterUnreachable.
+func ClassifyError(err error) error {
+	if apierrors.IsNotFound(err) {
+		return hcerrors.Wrap(hcerrors.ErrNotFound, err)
+	}
+	return hcerrors.Wrap(hcerrors.ErrClusterUnreachable, err)
+}
+
 // --- Kratix Resource Helpers ---
 
 var (
@@ -94,6 +151,34 @@ var (
 		Version:  "v1alpha1",
 		Resource: "workplacements",
 	}
+
+	// ExternalSecretGVR is the GroupVersionResource for External Secrets Operator ExternalSecrets.
+	ExternalSecretGVR = schema.GroupVersionResource{
+		Group:    "external-secrets.io",
+		Version:  "v1beta1",
+		Resource: "externalsecrets",
+	}
+
+	// ClusterSecretStoreGVR is the GroupVersionResource for External Secrets Operator ClusterSecretStores.
+	ClusterSecretStoreGVR = schema.GroupVersionResource{
+		Group:    "external-secrets.io",
+		Version:  "v1beta1",
+		Resource: "clustersecretstores",
+	}
+
+	// ClusterIssuerGVR is the GroupVersionResource for cert-manager ClusterIssuers.
+	ClusterIssuerGVR = schema.GroupVersionResource{
+		Group:    "cert-manager.io",
+		Version:  "v1",
+		Resource: "clusterissuers",
+	}
+
+	// CertificateGVR is the GroupVersionResource for cert-manager Certificates.
+	CertificateGVR = schema.GroupVersionResource{
+		Group:    "cert-manager.io",
+		Version:  "v1",
+		Resource: "certificates",
+	}
 )
 
 // ListVClusters returns all VClusterOrchestratorV2 resources.
@@ -123,6 +208,16 @@ func (c *Client) ListArgoApps(ctx context.Context, namespace string) ([]unstruct
 	return list.Items, nil
 }
 
+// ListArgoAppsBySelector returns the ArgoCD Applications in a namespace
+// matching a label selector.
+func (c *Client) ListArgoAppsBySelector(ctx context.Context, namespace, selector string) ([]unstructured.Unstructured, error) {
+	list, err := c.Dynamic.Resource(ArgoCDApplicationGVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("listing argocd applications: %w", err)
+	}
+	return list.Items, nil
+}
+
 // GetArgoApp returns a specific ArgoCD Application.
 func (c *Client) GetArgoApp(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
 	obj, err := c.Dynamic.Resource(ArgoCDApplicationGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -150,6 +245,34 @@ func (c *Client) GetSecretData(ctx context.Context, namespace, name string) (map
 	return secret.Data, nil
 }
 
+// SecretCreated returns when a Secret was created. Only metadata is used;
+// the data is never returned.
+func (c *Client) SecretCreated(ctx context.Context, namespace, name string) (time.Time, error) {
+	secret, err := c.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting secret %s/%s: %w", namespace, name, err)
+	}
+	return secret.CreationTimestamp.Time, nil
+}
+
+// ListExternalSecrets returns the ExternalSecrets in a namespace.
+func (c *Client) ListExternalSecrets(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := c.Dynamic.Resource(ExternalSecretGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing externalsecrets: %w", err)
+	}
+	return list.Items, nil
+}
+
+// GetExternalSecret returns a specific ExternalSecret.
+func (c *Client) GetExternalSecret(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	obj, err := c.Dynamic.Resource(ExternalSecretGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting externalsecret %s/%s: %w", namespace, name, err)
+	}
+	return obj, nil
+}
+
 // ListNodes returns the cluster nodes.
 func (c *Client) ListNodes(ctx context.Context) ([]NodeInfo, error) {
 	nodes, err := c.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
@@ -251,6 +374,66 @@ func (c *Client) SetManualReconciliationLabel(ctx context.Context, gvr schema.Gr
 	return nil
 }
 
+// SetAnnotation sets an annotation on a resource with a merge patch. An
+// empty value removes it.
+func (c *Client) SetAnnotation(ctx context.Context, gvr schema.GroupVersionResource, namespace, name, key, value string) error {
+	var v interface{}
+	if value != "" {
+		v = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{key: v}},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.Dynamic.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("annotating %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// LabelNamespace adds labels to a namespace with a merge patch, leaving its
+// other labels alone.
+func (c *Client) LabelNamespace(ctx context.Context, name string, labels map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.Clientset.CoreV1().Namespaces().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("labeling namespace %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteNamespace deletes a namespace, and with it everything in it. It
+// reports false, and no error, when the namespace does not exist.
+func (c *Client) DeleteNamespace(ctx context.Context, name string) (bool, error) {
+	err := c.Clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("deleting namespace %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// PatchStatus merge-patches a resource's status subresource. A nil value
+// removes the field.
+func (c *Client) PatchStatus(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, status map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+	if _, err := c.Dynamic.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		return fmt.Errorf("patching status of %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
 // ArgoAppInfo holds parsed ArgoCD application status for display.
 type ArgoAppInfo struct {
 	Name         string
@@ -338,7 +521,8 @@ func (c *Client) TriggerArgoAppSync(ctx context.Context, namespace, name string)
 	return nil
 }
 
-// GetPodResourceInfo returns resource allocation info for pods matching a selector.
+// GetPodResourceInfo returns resource allocation info for pods matching a
+// selector.
 func (c *Client) GetPodResourceInfo(ctx context.Context, namespace, labelSelector string) ([]PodResourceInfo, error) {
 	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -350,25 +534,14 @@ func (c *Client) GetPodResourceInfo(ctx context.Context, namespace, labelSelecto
 	var result []PodResourceInfo
 	for _, p := range pods.Items {
 		info := PodResourceInfo{
-			Name:      p.Name,
-			Namespace: p.Namespace,
-			Phase:     string(p.Status.Phase),
-		}
-
-		// Get resource requests/limits from spec
-		for _, container := range p.Spec.Containers {
-			if req, ok := container.Resources.Requests["memory"]; ok {
-				info.MemoryRequest = req.String()
-			}
-			if lim, ok := container.Resources.Limits["memory"]; ok {
-				info.MemoryLimit = lim.String()
-			}
-			if req, ok := container.Resources.Requests["cpu"]; ok {
-				info.CPURequest = req.String()
-			}
-			if lim, ok := container.Resources.Limits["cpu"]; ok {
-				info.CPULimit = lim.String()
-			}
+			Name:          p.Name,
+			Namespace:     p.Namespace,
+			Phase:         string(p.Status.Phase),
+			Labels:        p.Labels,
+			MemoryRequest: podResource(p.Spec, corev1.ResourceMemory, false),
+			MemoryLimit:   podResource(p.Spec, corev1.ResourceMemory, true),
+			CPURequest:    podResource(p.Spec, corev1.ResourceCPU, false),
+			CPULimit:      podResource(p.Spec, corev1.ResourceCPU, true),
 		}
 
 		// Restart count
@@ -381,15 +554,53 @@ func (c *Client) GetPodResourceInfo(ctx context.Context, namespace, labelSelecto
 	return result, nil
 }
 
-// PodResourceInfo holds pod resource allocation info.
+// podResource returns the request, or with limit the limit, the scheduler
+// accounts a pod for: its containers and sidecar init containers added up,
+// or the largest other init container when that is more, plus the pod
+// overhead. Containers that set none count as zero.
+func podResource(spec corev1.PodSpec, name corev1.ResourceName, limit bool) resource.Quantity {
+	amount := func(r corev1.ResourceRequirements) resource.Quantity {
+		list := r.Requests
+		if limit {
+			list = r.Limits
+		}
+		return list[name]
+	}
+
+	var total, init resource.Quantity
+	for _, c := range spec.Containers {
+		total.Add(amount(c.Resources))
+	}
+	for _, c := range spec.InitContainers {
+		q := amount(c.Resources)
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			total.Add(q)
+		} else if q.Cmp(init) > 0 {
+			init = q
+		}
+	}
+	if init.Cmp(total) > 0 {
+		total = init
+	}
+	// Overhead counts towards limits only of pods that set them.
+	if q, ok := spec.Overhead[name]; ok && (!limit || !total.IsZero()) {
+		total.Add(q)
+	}
+	return total
+}
+
+// PodResourceInfo holds pod resource allocation info. Requests and limits
+// are for the whole pod (see podResource); zero when no container sets
+// them.
 type PodResourceInfo struct {
 	Name          string
 	Namespace     string
 	Phase         string
-	MemoryRequest string
-	MemoryLimit   string
-	CPURequest    string
-	CPULimit      string
+	Labels        map[string]string
+	MemoryRequest resource.Quantity
+	MemoryLimit   resource.Quantity
+	CPURequest    resource.Quantity
+	CPULimit      resource.Quantity
 	Restarts      int
 }
 
@@ -465,6 +676,11 @@ func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string)
 			if cs.Ready {
 				ready++
 			}
+			info.Restarts += int(cs.RestartCount)
+			if w := cs.State.Waiting; w != nil && info.Waiting == "" {
+				info.Waiting = w.Reason
+				info.WaitingMessage = w.Message
+			}
 		}
 		info.ReadyContainers = ready
 		info.TotalContainers = len(p.Spec.Containers)
@@ -480,21 +696,104 @@ type PodInfo struct {
 	Phase           string
 	ReadyContainers int
 	TotalContainers int
+	// Restarts is the sum of the containers' restart counts.
+	Restarts int
+	// Waiting is the reason the first waiting container gives, e.g.
+	// CrashLoopBackOff, with its message.
+	Waiting        string
+	WaitingMessage string
+}
+
+// PodWarnings returns the messages of the Warning events for a pod, oldest
+// first, e.g. "Back-off restarting failed container".
+func (c *Client) PodWarnings(ctx context.Context, namespace, pod string) ([]string, error) {
+	events, err := c.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=" + pod + ",type=" + corev1.EventTypeWarning,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing events for pod %s: %w", pod, err)
+	}
+	// Filter again: not every client honours field selectors (fakes don't).
+	var items []corev1.Event
+	for _, e := range events.Items {
+		if e.InvolvedObject.Name == pod && e.Type == corev1.EventTypeWarning {
+			items = append(items, e)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].LastTimestamp.Before(&items[j].LastTimestamp) })
+	var out []string
+	for _, e := range items {
+		msg := e.Reason + ": " + e.Message
+		if e.Count > 1 {
+			msg += fmt.Sprintf(" (x%d)", e.Count)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// VClusterKubeconfig reads a vCluster's kubeconfig from the first of its known
+// secret names that holds one. It returns nil and the names tried when none
+// does.
+func (c *Client) VClusterKubeconfig(ctx context.Context, name string) ([]byte, []string) {
+	// Try common secret name patterns
+	secretNames := []string{
+		"vc-" + name,         // vCluster default
+		name + "-kubeconfig", // ExternalSecret pattern
+		"vc-" + name + "-kubeconfig",
+	}
+
+	var kubeconfigData []byte
+	for _, secretName := range secretNames {
+		data, err := c.GetSecretData(ctx, name, secretName)
+		if err != nil {
+			continue
+		}
+		// Look for common kubeconfig keys
+		for _, key := range []string{"config", "value", "kubeconfig"} {
+			if v, ok := data[key]; ok {
+				kubeconfigData = v
+				break
+			}
+		}
+		if kubeconfigData != nil {
+			break
+		}
+		// If no known key, try base64 decode of first value
+		for _, v := range data {
+			decoded, err := base64.StdEncoding.DecodeString(string(v))
+			if err == nil && len(decoded) > 0 {
+				kubeconfigData = decoded
+			} else {
+				kubeconfigData = v
+			}
+			break
+		}
+		if kubeconfigData != nil {
+			break
+		}
+	}
+
+	return kubeconfigData, secretNames
 }
 
 // WriteKubeconfig writes kubeconfig data to a file.
 func WriteKubeconfig(data []byte, name string) (string, error) {
-	dir := filepath.Join(os.Getenv("HOME"), ".kube", "hctl")
-	if err := os.MkdirAll(dir, 0o700); err != nil {
+	path := KubeconfigPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		return "", err
 	}
-	path := filepath.Join(dir, name+".yaml")
 	if err := os.WriteFile(path, data, 0o600); err != nil {
 		return "", err
 	}
 	return path, nil
 }
 
+// KubeconfigPath is where WriteKubeconfig writes the named kubeconfig.
+func KubeconfigPath(name string) string {
+	return filepath.Join(os.Getenv("HOME"), ".kube", "hctl", name+".yaml")
+}
+
 // --- Prometheus Query ---
 
 // PrometheusAlert represents a single firing alert from Prometheus.
@@ -582,7 +881,8 @@ func (c *Client) QueryPrometheusRaw(ctx context.Context, promNamespace, promServ
 type DeploymentInfo struct {
 	Name     string
 	Replicas int32
-	ArgoApp  string // ArgoCD app name from tracking annotation, if any
+	ArgoApp  string   // ArgoCD app name from tracking annotation, if any
+	Images   []string // container images, in pod spec order
 }
 
 // ListDeployments returns deployment info for all deployments in a namespace.
@@ -598,6 +898,9 @@ func (c *Client) ListDeployments(ctx context.Context, namespace string) ([]Deplo
 			Name:     d.Name,
 			Replicas: *d.Spec.Replicas,
 		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			info.Images = append(info.Images, c.Image)
+		}
 		if tracking, ok := d.Annotations["argocd.argoproj.io/tracking-id"]; ok {
 			parts := splitFirst(tracking, ":")
 			info.ArgoApp = parts
@@ -607,6 +910,87 @@ func (c *Client) ListDeployments(ctx context.Context, namespace string) ([]Deplo
 	return result, nil
 }
 
+// RolloutInfo is the rollout progress of a Deployment or StatefulSet.
+type RolloutInfo struct {
+	Kind    string
+	Name    string
+	Desired int32
+	Ready   int32
+	Updated int32
+}
+
+// Done reports whether every desired replica runs the current template and
+// is ready.
+func (r RolloutInfo) Done() bool {
+	return r.Updated == r.Desired && r.Ready == r.Desired
+}
+
+// ListRollouts returns the rollout progress of every Deployment and
+// StatefulSet in a namespace.
+func (c *Client) ListRollouts(ctx context.Context, namespace string) ([]RolloutInfo, error) {
+	var result []RolloutInfo
+	sets, err := c.Clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing statefulsets: %w", err)
+	}
+	for _, s := range sets.Items {
+		desired := int32(1)
+		if s.Spec.Replicas != nil {
+			desired = *s.Spec.Replicas
+		}
+		updated := s.Status.UpdatedReplicas
+		if s.Status.ObservedGeneration < s.Generation || s.Status.UpdateRevision != s.Status.CurrentRevision {
+			// The controller has not finished moving pods to the new revision.
+			updated = min(updated, desired-1)
+		}
+		result = append(result, RolloutInfo{Kind: "StatefulSet", Name: s.Name, Desired: desired, Ready: s.Status.ReadyReplicas, Updated: max(updated, 0)})
+	}
+	deploys, err := c.Clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	for i := range deploys.Items {
+		result = append(result, DeploymentRollout(&deploys.Items[i]))
+	}
+	return result, nil
+}
+
+// DeploymentRollout returns the rollout progress of a Deployment.
+func DeploymentRollout(d *appsv1.Deployment) RolloutInfo {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	updated := d.Status.UpdatedReplicas
+	if d.Status.ObservedGeneration < d.Generation || d.Status.Replicas > desired {
+		// Old pods are still being scaled down.
+		updated = min(updated, desired-1)
+	}
+	return RolloutInfo{Kind: "Deployment", Name: d.Name, Desired: desired, Ready: d.Status.ReadyReplicas, Updated: max(updated, 0)}
+}
+
+// StorageClassExpansion resolves a storage class, or the cluster default
+// when name is empty, and reports whether it allows volume expansion.
+func (c *Client) StorageClassExpansion(ctx context.Context, name string) (string, bool, error) {
+	if name == "" {
+		classes, err := c.Clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", false, fmt.Errorf("listing storage classes: %w", err)
+		}
+		for _, sc := range classes.Items {
+			if sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+				return sc.Name, sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+			}
+		}
+		return "", false, fmt.Errorf("no default storage class")
+	}
+	sc, err := c.Clientset.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, fmt.Errorf("getting storage class %s: %w", name, err)
+	}
+	return sc.Name, sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
 // ScaleDeployment sets the replica count for a deployment.
 func (c *Client) ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error {
 	scale, err := c.Clientset.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
@@ -621,6 +1005,39 @@ func (c *Client) ScaleDeployment(ctx context.Context, namespace, name string, re
 	return nil
 }
 
+// ScaleWorkload sets the replica count of a Deployment or StatefulSet.
+func (c *Client) ScaleWorkload(ctx context.Context, namespace, kind, name string, replicas int32) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	var err error
+	switch kind {
+	case "StatefulSet":
+		_, err = c.Clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "Deployment":
+		_, err = c.Clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	default:
+		return fmt.Errorf("cannot scale %s %s", kind, name)
+	}
+	if err != nil {
+		return fmt.Errorf("scaling %s %s: %w", kind, name, err)
+	}
+	return nil
+}
+
+// DeletePods deletes the pods matching a label selector in a namespace and
+// returns how many there were.
+func (c *Client) DeletePods(ctx context.Context, namespace, labelSelector string) (int, error) {
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return 0, fmt.Errorf("listing pods: %w", err)
+	}
+	for _, p := range pods.Items {
+		if err := c.Clientset.CoreV1().Pods(namespace).Delete(ctx, p.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return 0, fmt.Errorf("deleting pod %s: %w", p.Name, err)
+		}
+	}
+	return len(pods.Items), nil
+}
+
 // DisableArgoAutoSync removes the syncPolicy from an ArgoCD application.
 func (c *Client) DisableArgoAutoSync(ctx context.Context, argoNamespace, appName string) error {
 	patch := []byte(`{"spec":{"syncPolicy":null}}`)
@@ -1,6 +1,11 @@
 package kube
 
-import "testing"
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
 
 func TestSplitFirst(t *testing.T) {
 	tests := []struct {
@@ -37,3 +42,68 @@ func TestDeploymentInfoStruct(t *testing.T) {
 		t.Errorf("ArgoApp = %q, want %q", info.ArgoApp, "my-app")
 	}
 }
+
+func TestPodResource(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	res := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)},
+		}
+	}
+	tests := []struct {
+		name   string
+		spec   corev1.PodSpec
+		cpu    string
+		memory string
+	}{
+		{
+			name: "containers add up across units",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Resources: res("500m", "512Mi")},
+				{Resources: res("0.5", "0.5Gi")},
+			}},
+			cpu: "1", memory: "1Gi",
+		},
+		{
+			name: "container without requests",
+			spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: res("100m", "64Mi")}, {}}},
+			cpu:  "100m", memory: "64Mi",
+		},
+		{
+			name: "larger init container wins",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Resources: res("2", "128Mi")}},
+				Containers:     []corev1.Container{{Resources: res("250m", "256Mi")}},
+			},
+			cpu: "2", memory: "256Mi",
+		},
+		{
+			name: "sidecars add to the containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{RestartPolicy: &always, Resources: res("50m", "32Mi")}},
+				Containers:     []corev1.Container{{Resources: res("250m", "256Mi")}},
+			},
+			cpu: "300m", memory: "288Mi",
+		},
+		{
+			name: "pod overhead",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Resources: res("250m", "256Mi")}},
+				Overhead:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("120Mi")},
+			},
+			cpu: "500m", memory: "376Mi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu := podResource(tt.spec, corev1.ResourceCPU, false)
+			memory := podResource(tt.spec, corev1.ResourceMemory, false)
+			if cpu.Cmp(resource.MustParse(tt.cpu)) != 0 || memory.Cmp(resource.MustParse(tt.memory)) != 0 {
+				t.Errorf("requests = %s / %s, want %s / %s", cpu.String(), memory.String(), tt.cpu, tt.memory)
+			}
+			if limit := podResource(tt.spec, corev1.ResourceCPU, true); !limit.IsZero() {
+				t.Errorf("CPU limit = %s, want 0 when no container sets one", limit.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+)
+
+// loggingTransport logs a debug summary of every API request: verb,
+// resource, namespace, name, status and duration. Bodies and headers are
+// never logged.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	log := logging.FromContext(req.Context())
+	if !log.Enabled(req.Context(), slog.LevelDebug) {
+		return t.next.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	s := summarizeRequest(req.Method, req.URL.Path, req.URL.Query().Get("watch") == "true")
+	attrs := []any{
+		"verb", s.Verb, "resource", s.Resource,
+		"duration", time.Since(start).Round(time.Millisecond),
+	}
+	if s.Namespace != "" {
+		attrs = append(attrs, "namespace", s.Namespace)
+	}
+	if s.Name != "" {
+		attrs = append(attrs, "name", s.Name)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else {
+		attrs = append(attrs, "status", resp.StatusCode)
+	}
+	log.DebugContext(req.Context(), "kube request", attrs...)
+	return resp, err
+}
+
+// requestSummary is what a request path says about the call.
+type requestSummary struct {
+	Verb string
+	// Resource is group/version/resource, or version/resource for the core
+	// group; the raw path for non-resource URLs such as /version.
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// summarizeRequest reads the API verb and resource from a request's method
+// and path, e.g. GET /apis/argoproj.io/v1alpha1/namespaces/argocd/applications/web
+// is a get of argoproj.io/v1alpha1/applications argocd/web.
+func summarizeRequest(method, path string, watch bool) requestSummary {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var gv []string
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		gv, parts = parts[1:2], parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		gv, parts = parts[1:3], parts[3:]
+	default:
+		return requestSummary{Verb: strings.ToLower(method), Resource: path}
+	}
+	var s requestSummary
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		if len(parts) == 2 {
+			// /api/v1/namespaces/<name> is the namespace itself.
+			parts = []string{"namespaces", parts[1]}
+		} else {
+			s.Namespace, parts = parts[1], parts[2:]
+		}
+	}
+	if len(parts) > 0 {
+		s.Resource = strings.Join(append(gv, parts[0]), "/")
+	}
+	if len(parts) > 1 {
+		s.Name = parts[1]
+	}
+	if len(parts) > 2 {
+		s.Resource += "/" + strings.Join(parts[2:], "/") // subresource
+	}
+
+	switch method {
+	case http.MethodGet:
+		switch {
+		case watch:
+			s.Verb = "watch"
+		case s.Name == "":
+			s.Verb = "list"
+		default:
+			s.Verb = "get"
+		}
+	case http.MethodPost:
+		s.Verb = "create"
+	case http.MethodPut:
+		s.Verb = "update"
+	case http.MethodPatch:
+		s.Verb = "patch"
+	case http.MethodDelete:
+		if s.Name == "" {
+			s.Verb = "deletecollection"
+		} else {
+			s.Verb = "delete"
+		}
+	default:
+		s.Verb = strings.ToLower(method)
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+)
+
+func TestSummarizeRequest(t *testing.T) {
+	tests := []struct {
+		method, path string
+		watch        bool
+		want         requestSummary
+	}{
+		{"GET", "/apis/argoproj.io/v1alpha1/namespaces/argocd/applications/web", false,
+			requestSummary{Verb: "get", Resource: "argoproj.io/v1alpha1/applications", Namespace: "argocd", Name: "web"}},
+		{"GET", "/api/v1/namespaces/media/pods", false,
+			requestSummary{Verb: "list", Resource: "v1/pods", Namespace: "media"}},
+		{"GET", "/api/v1/namespaces/media/pods", true,
+			requestSummary{Verb: "watch", Resource: "v1/pods", Namespace: "media"}},
+		{"GET", "/api/v1/namespaces/media", false,
+			requestSummary{Verb: "get", Resource: "v1/namespaces", Name: "media"}},
+		{"PATCH", "/apis/apps/v1/namespaces/media/deployments/web/scale", false,
+			requestSummary{Verb: "patch", Resource: "apps/v1/deployments/scale", Namespace: "media", Name: "web"}},
+		{"POST", "/apis/platform.integratn.tech/v1alpha1/namespaces/platform-requests/vclusterorchestratorv2s", false,
+			requestSummary{Verb: "create", Resource: "platform.integratn.tech/v1alpha1/vclusterorchestratorv2s", Namespace: "platform-requests"}},
+		{"DELETE", "/api/v1/nodes/node-1", false,
+			requestSummary{Verb: "delete", Resource: "v1/nodes", Name: "node-1"}},
+		{"GET", "/version", false, requestSummary{Verb: "get", Resource: "/version"}},
+	}
+	for _, tt := range tests {
+		if got := summarizeRequest(tt.method, tt.path, tt.watch); got != tt.want {
+			t.Errorf("summarizeRequest(%s %s) = %+v, want %+v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestLoggingTransport(t *testing.T) {
+	transport := &loggingTransport{next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+	})}
+	request := func(level slog.Level) string {
+		var buf bytes.Buffer
+		ctx := logging.NewContext(context.Background(), logging.New(&buf, level))
+		req, _ := http.NewRequestWithContext(ctx, "GET", "https://10.0.4.1/api/v1/namespaces/media/secrets/db", nil)
+		req.Header.Set("Authorization", "Bearer sekrit")
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	out := request(slog.LevelDebug)
+	for _, want := range []string{`msg="kube request"`, "verb=get", "resource=v1/secrets", "namespace=media", "name=db", "status=404", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "sekrit") {
+		t.Errorf("log leaks the bearer token:\n%s", out)
+	}
+	if out := request(logging.Level(false, false)); out != "" {
+		t.Errorf("default level logged:\n%s", out)
+	}
+}
@@ -0,0 +1,140 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// EventGVR is core/v1 Events, for watching them through the dynamic client.
+var EventGVR = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// WatchEvent is one change delivered by Watch. Err is set, with no object,
+// when the watch dropped; Watch reconnects on its own and the error is only
+// for display.
+type WatchEvent struct {
+	Type   watch.EventType
+	Object *unstructured.Unstructured
+	Err    error
+}
+
+// watchBackoff bounds the wait between reconnect attempts.
+var watchBackoff = struct{ initial, max time.Duration }{time.Second, 30 * time.Second}
+
+// Watch streams the resources of gvr in namespace matching opts to out
+// until ctx is done. It lists first, sending every match as Added, then
+// watches from the list's resource version. A watch the server closes is
+// resumed from the last version seen; when that version has expired the
+// resources are listed again, so consumers see Added for objects they may
+// already know and should treat it as an update. Errors are sent on out and
+// retried with backoff. Watch closes out when it returns.
+func (c *Client) Watch(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions, out chan<- WatchEvent) {
+	defer close(out)
+	send := func(ev WatchEvent) bool {
+		select {
+		case out <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	res := c.Dynamic.Resource(gvr).Namespace(namespace)
+	backoff := watchBackoff.initial
+	retry := func(err error) bool {
+		logging.L().Debug("watch dropped", "resource", gvr.Resource, "namespace", namespace, "err", err)
+		if !send(WatchEvent{Err: fmt.Errorf("watching %s: %w", gvr.Resource, err)}) {
+			return false
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return false
+		}
+		backoff = min(2*backoff, watchBackoff.max)
+		return true
+	}
+
+	version := ""
+	for ctx.Err() == nil {
+		if version == "" {
+			list, err := res.List(ctx, opts)
+			if err != nil {
+				if ctx.Err() != nil || !retry(err) {
+					return
+				}
+				continue
+			}
+			for i := range list.Items {
+				if !send(WatchEvent{Type: watch.Added, Object: &list.Items[i]}) {
+					return
+				}
+			}
+			version = list.GetResourceVersion()
+		}
+
+		wopts := opts
+		wopts.ResourceVersion = version
+		wopts.AllowWatchBookmarks = true
+		w, err := res.Watch(ctx, wopts)
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				version = ""
+			}
+			if ctx.Err() != nil || !retry(err) {
+				return
+			}
+			continue
+		}
+		var dropped error
+		version, dropped = drainWatch(ctx, w, version, send)
+		if dropped != nil {
+			if !retry(dropped) {
+				return
+			}
+			continue
+		}
+		backoff = watchBackoff.initial
+	}
+}
+
+// drainWatch forwards w's events until it closes, ctx ends or the server
+// reports an error, returning the resource version to resume from and the
+// error, if any; an empty version means list again.
+func drainWatch(ctx context.Context, w watch.Interface, version string, send func(WatchEvent) bool) (string, error) {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return version, nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return version, nil
+			}
+			if ev.Type == watch.Error {
+				err := apierrors.FromObject(ev.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return "", err
+				}
+				return version, err
+			}
+			obj, ok := ev.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			version = obj.GetResourceVersion()
+			if ev.Type == watch.Bookmark {
+				continue
+			}
+			if !send(WatchEvent{Type: ev.Type, Object: obj}) {
+				return version, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func vclusterObject(name, version string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	obj.SetKind("VClusterOrchestratorV2")
+	obj.SetNamespace("platform-requests")
+	obj.SetName(name)
+	obj.SetResourceVersion(version)
+	return obj
+}
+
+func TestWatchReconnects(t *testing.T) {
+	defer func(prev struct{ initial, max time.Duration }) { watchBackoff = prev }(watchBackoff)
+	watchBackoff.initial, watchBackoff.max = time.Millisecond, time.Millisecond
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{VClusterOrchestratorV2GVR: "VClusterOrchestratorV2List"},
+		vclusterObject("media", "1"))
+	watchers := make(chan *watch.FakeWatcher, 3)
+	versions := make(chan string, 3)
+	dyn.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		versions <- action.(clienttesting.WatchActionImpl).WatchRestrictions.ResourceVersion
+		w := watch.NewFake()
+		watchers <- w
+		return true, w, nil
+	})
+	client := &Client{Dynamic: dyn}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan WatchEvent)
+	go client.Watch(ctx, VClusterOrchestratorV2GVR, "platform-requests", metav1.ListOptions{}, out)
+
+	next := func() WatchEvent {
+		t.Helper()
+		select {
+		case ev := <-out:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a watch event")
+			return WatchEvent{}
+		}
+	}
+
+	if ev := next(); ev.Type != watch.Added || ev.Object.GetName() != "media" {
+		t.Fatalf("first event = %s %v, want the listed object as Added", ev.Type, ev.Object)
+	}
+
+	// The server closes the watch; it resumes from the last version seen.
+	w := <-watchers
+	<-versions
+	w.Modify(vclusterObject("media", "7"))
+	if ev := next(); ev.Type != watch.Modified || ev.Object.GetResourceVersion() != "7" {
+		t.Fatalf("event = %s %v, want Modified at version 7", ev.Type, ev.Object)
+	}
+	w.Stop()
+	if got := <-versions; got != "7" {
+		t.Errorf("rewatched from version %q, want 7", got)
+	}
+
+	// An expired version is reported, then the resources are listed again.
+	w = <-watchers
+	w.Error(&metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+	if ev := next(); ev.Err == nil {
+		t.Fatalf("event = %s, want the dropped watch reported", ev.Type)
+	}
+	if ev := next(); ev.Type != watch.Added || ev.Object.GetName() != "media" {
+		t.Fatalf("event = %s %v, want the object listed again", ev.Type, ev.Object)
+	}
+
+	cancel()
+	for range out {
+	}
+}
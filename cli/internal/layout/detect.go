@@ -0,0 +1,211 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// State is what a repo's tree holds, which can disagree with its marker
+// when a migration was finished by hand or only partly committed.
+type State struct {
+	// Marker is the version in MarkerPath, "" when there is none.
+	Marker string
+	// V1Clusters are the clusters with a directory under workloads/.
+	V1Clusters []string
+	// V2Clusters maps the clusters with a directory under
+	// addons/environments/<env>/workloads/ to that <env>.
+	V2Clusters map[string]string
+	// Environments maps each vcluster in platform/vclusters to its
+	// spec.integrations.argocd.environment, where set.
+	Environments map[string]string
+}
+
+// Version is the version the marker records, v1 without one.
+func (s *State) Version() string {
+	if s.Marker == "" {
+		return V1
+	}
+	return s.Marker
+}
+
+// HalfMigrated describes why the tree does not match its marker, or
+// returns "" when it does.
+func (s *State) HalfMigrated() string {
+	var v2 []string
+	for c := range s.V2Clusters {
+		v2 = append(v2, c)
+	}
+	sort.Strings(v2)
+	switch {
+	case len(s.V1Clusters) > 0 && len(v2) > 0:
+		return fmt.Sprintf("workloads of %s are in the v1 layout and of %s in the v2 layout",
+			strings.Join(s.V1Clusters, ", "), strings.Join(v2, ", "))
+	case s.Version() == V1 && len(v2) > 0:
+		return fmt.Sprintf("the repo is marked %s but the workloads of %s are in the v2 layout", V1, strings.Join(v2, ", "))
+	case s.Version() == V2 && len(s.V1Clusters) > 0:
+		return fmt.Sprintf("the repo is marked %s but the workloads of %s are in the v1 layout", V2, strings.Join(s.V1Clusters, ", "))
+	}
+	return ""
+}
+
+// Inspect reads the layout marker and finds where the repo's workloads
+// are, without judging whether they agree.
+func Inspect(repoPath string) (*State, error) {
+	s := &State{V2Clusters: map[string]string{}, Environments: map[string]string{}}
+
+	data, err := os.ReadFile(repopath.Abs(repoPath, MarkerPath))
+	switch {
+	case err == nil:
+		s.Marker = strings.TrimSpace(string(data))
+		if !Known(s.Marker) {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s records unknown layout version %q", MarkerPath, s.Marker).
+				WithRemediation("upgrade hctl, or set the file to one of " + strings.Join(Versions, ", "))
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("reading %s: %w", MarkerPath, err)
+	}
+
+	if s.V1Clusters, err = subdirs(repopath.Abs(repoPath, v1WorkloadsRoot)); err != nil {
+		return nil, err
+	}
+	envs, err := subdirs(repopath.Abs(repoPath, EnvironmentsDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		clusters, err := subdirs(repopath.Abs(repoPath, repopath.Join(EnvironmentsDir, env, "workloads")))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clusters {
+			if other, dup := s.V2Clusters[c]; dup {
+				return nil, hcerrors.New(hcerrors.ErrValidation, "workloads of %s are in both the %s and %s environments", c, other, env).
+					WithRemediation("keep one of " + repopath.Join(EnvironmentsDir, other, "workloads", c) + " and " +
+						repopath.Join(EnvironmentsDir, env, "workloads", c))
+			}
+			s.V2Clusters[c] = env
+		}
+	}
+
+	if s.Environments, err = DeclaredEnvironments(repoPath); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SkipLoadAnnotation is the cobra annotation of commands that must run on
+// a repo whose layout does not load, such as 'hctl repo migrate' finishing
+// a half-migrated one.
+const SkipLoadAnnotation = "hctl/skip-layout"
+
+// Load inspects the repo at repoPath and returns its layout. A repo whose
+// tree does not match its marker is an error, so nothing is written to
+// the wrong place; 'hctl repo migrate' finishes the migration.
+func Load(repoPath string) (*Layout, error) {
+	s, err := Inspect(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if why := s.HalfMigrated(); why != "" {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "the repo layout is half-migrated: %s", why).
+			WithRemediation(fmt.Sprintf("finish the migration with 'hctl repo migrate --to %s' (or --to the other version to undo it)", s.Version()))
+	}
+	return s.Layout(s.Version())
+}
+
+// Layout returns the layout for version with the clusters' environments:
+// where their workloads already are in v2, else their vcluster request's.
+func (s *State) Layout(version string) (*Layout, error) {
+	envs := map[string]string{}
+	for c, env := range s.Environments {
+		envs[c] = env
+	}
+	for c, env := range s.V2Clusters {
+		envs[c] = env
+	}
+	return New(version, envs)
+}
+
+// subdirs lists the directories in dir, sorted; none when dir does not
+// exist.
+func subdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// DeclaredEnvironments returns the ArgoCD environment of the vCluster
+// manifests under platform/vclusters in repoPath, by vCluster name.
+// vClusters without one are left out.
+func DeclaredEnvironments(repoPath string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	envs := make(map[string]string)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var r struct {
+			Spec struct {
+				Name         string `yaml:"name"`
+				Integrations struct {
+					ArgoCD *struct {
+						Environment string `yaml:"environment"`
+					} `yaml:"argocd"`
+				} `yaml:"integrations"`
+			} `yaml:"spec"`
+		}
+		if err := yaml.Unmarshal(data, &r); err != nil {
+			continue // not a vCluster request
+		}
+		if a := r.Spec.Integrations.ArgoCD; a != nil && a.Environment != "" && r.Spec.Name != "" {
+			envs[r.Spec.Name] = a.Environment
+		}
+	}
+	return envs, nil
+}
+
+// Clusters lists the clusters with a workloads directory in the repo at
+// repoPath, sorted.
+func (l *Layout) Clusters(repoPath string) ([]string, error) {
+	if l.version == V1 {
+		return subdirs(repopath.Abs(repoPath, v1WorkloadsRoot))
+	}
+	envs, err := subdirs(repopath.Abs(repoPath, EnvironmentsDir))
+	if err != nil {
+		return nil, err
+	}
+	var clusters []string
+	for _, env := range envs {
+		names, err := subdirs(repopath.Abs(repoPath, l.WorkloadsRootFor(env)))
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, names...)
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
@@ -0,0 +1,189 @@
+// Package layout is the single source of truth for where things live in
+// the gitops repo. Every repo-relative path hctl reads or writes is built
+// here, so a change to the repo's structure is a new layout version rather
+// than edits scattered across commands.
+//
+// A repo records its version in .hctl/layout-version; a repo without the
+// marker is v1. The versions differ in where a cluster's workloads live:
+//
+//	v1  workloads/<cluster>/addons.yaml
+//	    workloads/<cluster>/addons/<workload>/values.yaml
+//	v2  addons/environments/<env>/workloads/<cluster>/addons.yaml
+//	    addons/environments/<env>/workloads/<cluster>/addons/<workload>/values.yaml
+//
+// In v2 <env> is the vcluster's spec.integrations.argocd.environment in
+// platform/vclusters/<cluster>.yaml, so workloads sit beside the addons of
+// the environment they are deployed to. 'hctl repo migrate' moves a repo
+// between versions.
+package layout
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+)
+
+// Layout versions.
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Versions lists the known layout versions, oldest first.
+var Versions = []string{V1, V2}
+
+// MarkerPath is the repo-relative file recording the layout version.
+const MarkerPath = ".hctl/layout-version"
+
+// DefaultEnvironment is the environment of a cluster whose vcluster request
+// sets none, as 'hctl vcluster create' defaults it.
+const DefaultEnvironment = "production"
+
+// Paths that are the same in every layout version.
+const (
+	// VClustersDir holds the VClusterOrchestratorV2 requests, one per file.
+	VClustersDir = "platform/vclusters"
+	// ResourcePolicyPath holds the workload resource defaults and maximums.
+	ResourcePolicyPath = "platform/policies/resources.yaml"
+	// ObservabilitySidecarPath holds the platform observability sidecar.
+	ObservabilitySidecarPath = "platform/observability/sidecar.yaml"
+	// EnvironmentsDir, ClusterRolesDir and ClustersDir hold the addon
+	// layers, one directory per environment, cluster role or cluster.
+	EnvironmentsDir = "addons/environments"
+	ClusterRolesDir = "addons/cluster-roles"
+	ClustersDir     = "addons/clusters"
+	// ApplicationSetsChartDir is the chart rendering an ApplicationSet per
+	// addons.yaml entry.
+	ApplicationSetsChartDir = "addons/charts/application-sets"
+	// BootstrapDir holds the ApplicationSets that render the
+	// application-sets chart with each cluster's addon layers.
+	BootstrapDir = "terraform/cluster/bootstrap"
+	// PromisesDir holds the Kratix promises, one directory each with its
+	// promise.yaml.
+	PromisesDir = "promises"
+	// ReconcilerDir holds the platform-status-reconciler manifests: its
+	// Deployment, RBAC and monitoring.
+	ReconcilerDir = "addons/cluster-roles/control-plane/addons/platform-status-reconciler"
+
+	// v1WorkloadsRoot is the v1 directory of per-cluster workloads.
+	v1WorkloadsRoot = "workloads"
+)
+
+// Layout builds repo-relative paths for one layout version.
+type Layout struct {
+	version string
+	// envs maps clusters to their environment, for v2.
+	envs map[string]string
+}
+
+// New returns the layout for version. envs maps clusters to their
+// environment; clusters not in it are in DefaultEnvironment.
+func New(version string, envs map[string]string) (*Layout, error) {
+	if !Known(version) {
+		return nil, fmt.Errorf("unknown layout version %q (known: %s, %s)", version, V1, V2)
+	}
+	return &Layout{version: version, envs: envs}, nil
+}
+
+// Known reports whether version is a layout version.
+func Known(version string) bool {
+	for _, v := range Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the layout version.
+func (l *Layout) Version() string {
+	return l.version
+}
+
+// Environment returns the environment a cluster's workloads are filed under.
+func (l *Layout) Environment(cluster string) string {
+	if env := l.envs[cluster]; env != "" {
+		return env
+	}
+	return DefaultEnvironment
+}
+
+// WorkloadsRoot returns the directory holding a directory per cluster that
+// contains cluster's: the vcluster's workloadRepo.path.
+func (l *Layout) WorkloadsRoot(cluster string) string {
+	return l.WorkloadsRootFor(l.Environment(cluster))
+}
+
+// WorkloadsRootFor returns the workloads root of clusters in env.
+func (l *Layout) WorkloadsRootFor(env string) string {
+	if l.version == V1 {
+		return v1WorkloadsRoot
+	}
+	return repopath.Join(EnvironmentsDir, env, "workloads")
+}
+
+// ClusterDir returns the directory of cluster's workloads.
+func (l *Layout) ClusterDir(cluster string) string {
+	return repopath.Join(l.WorkloadsRoot(cluster), cluster)
+}
+
+// AddonsFile returns the addons.yaml listing cluster's workloads.
+func (l *Layout) AddonsFile(cluster string) string {
+	return repopath.Join(l.ClusterDir(cluster), "addons.yaml")
+}
+
+// AddonsDir returns the directory holding a directory per workload of
+// cluster.
+func (l *Layout) AddonsDir(cluster string) string {
+	return repopath.Join(l.ClusterDir(cluster), "addons")
+}
+
+// WorkloadDir returns the directory holding a workload's values or
+// manifests.
+func (l *Layout) WorkloadDir(cluster, workload string) string {
+	return repopath.Join(l.AddonsDir(cluster), workload)
+}
+
+// ValuesFile returns a workload's values.yaml.
+func (l *Layout) ValuesFile(cluster, workload string) string {
+	return repopath.Join(l.WorkloadDir(cluster, workload), "values.yaml")
+}
+
+// VClusterManifest returns the VClusterOrchestratorV2 request of a vcluster.
+func VClusterManifest(name string) string {
+	return repopath.Join(VClustersDir, name+".yaml")
+}
+
+// EnvironmentAddonsDir returns the addons directory of an environment layer.
+func EnvironmentAddonsDir(env string) string {
+	return repopath.Join(EnvironmentsDir, env, "addons")
+}
+
+// ClusterRoleAddonsDir returns the addons directory of a cluster-role layer.
+func ClusterRoleAddonsDir(role string) string {
+	return repopath.Join(ClusterRolesDir, role, "addons")
+}
+
+// ClusterAddonsDir returns the addons directory of a cluster layer.
+func ClusterAddonsDir(cluster string) string {
+	return repopath.Join(ClustersDir, cluster, "addons")
+}
+
+// active is the layout of the repo the command works on.
+var active atomic.Pointer[Layout]
+
+func init() {
+	active.Store(&Layout{version: V1})
+}
+
+// Active returns the layout commands resolve paths with: the one set by
+// Use, or v1 before any repo is loaded.
+func Active() *Layout {
+	return active.Load()
+}
+
+// Use makes l the active layout.
+func Use(l *Layout) {
+	active.Store(l)
+}
@@ -0,0 +1,188 @@
+package layout
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func TestPaths(t *testing.T) {
+	envs := map[string]string{"vcluster-media": "staging"}
+	tests := []struct {
+		version, cluster string
+		root, values     string
+	}{
+		{V1, "vcluster-media", "workloads", "workloads/vcluster-media/addons/sonarr/values.yaml"},
+		{V2, "vcluster-media", "addons/environments/staging/workloads",
+			"addons/environments/staging/workloads/vcluster-media/addons/sonarr/values.yaml"},
+		{V2, "vcluster-dev", "addons/environments/production/workloads",
+			"addons/environments/production/workloads/vcluster-dev/addons/sonarr/values.yaml"},
+	}
+	for _, tt := range tests {
+		l, err := New(tt.version, envs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.WorkloadsRoot(tt.cluster); got != tt.root {
+			t.Errorf("%s WorkloadsRoot(%s) = %q, want %q", tt.version, tt.cluster, got, tt.root)
+		}
+		if got := l.ValuesFile(tt.cluster, "sonarr"); got != tt.values {
+			t.Errorf("%s ValuesFile(%s) = %q, want %q", tt.version, tt.cluster, got, tt.values)
+		}
+		if got, want := l.AddonsFile(tt.cluster), tt.root+"/"+tt.cluster+"/addons.yaml"; got != want {
+			t.Errorf("%s AddonsFile(%s) = %q, want %q", tt.version, tt.cluster, got, want)
+		}
+	}
+	if _, err := New("v3", nil); err == nil {
+		t.Error("New(v3) succeeded")
+	}
+}
+
+// writeRepo writes files, keyed by repo-relative path, into a new repo.
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const stagingRequest = `apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: vcluster-media
+spec:
+  name: vcluster-media
+  integrations:
+    argocd:
+      environment: staging
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		version string
+		values  string
+		err     string
+	}{
+		{
+			name:    "v1 without marker",
+			files:   map[string]string{"workloads/vcluster-media/addons.yaml": "{}\n"},
+			version: V1,
+			values:  "workloads/vcluster-media/addons/app/values.yaml",
+		},
+		{
+			name: "v2 takes the environment from the request",
+			files: map[string]string{
+				MarkerPath: "v2\n",
+				"addons/environments/staging/workloads/vcluster-media/addons.yaml": "{}\n",
+				"platform/vclusters/vcluster-media.yaml":                           stagingRequest,
+			},
+			version: V2,
+			values:  "addons/environments/staging/workloads/vcluster-media/addons/app/values.yaml",
+		},
+		{
+			name: "v2 directory wins over the request",
+			files: map[string]string{
+				MarkerPath: "v2\n",
+				"addons/environments/production/workloads/vcluster-media/addons.yaml": "{}\n",
+				"platform/vclusters/vcluster-media.yaml":                              stagingRequest,
+			},
+			version: V2,
+			values:  "addons/environments/production/workloads/vcluster-media/addons/app/values.yaml",
+		},
+		{
+			name:  "unknown marker",
+			files: map[string]string{MarkerPath: "v9\n"},
+			err:   `unknown layout version "v9"`,
+		},
+		{
+			name: "workloads in both layouts",
+			files: map[string]string{
+				MarkerPath:                           "v2\n",
+				"workloads/vcluster-dev/addons.yaml": "{}\n",
+				"addons/environments/production/workloads/vcluster-media/addons.yaml": "{}\n",
+			},
+			err: "half-migrated: workloads of vcluster-dev are in the v1 layout and of vcluster-media in the v2 layout",
+		},
+		{
+			name: "moved without the marker",
+			files: map[string]string{
+				"addons/environments/production/workloads/vcluster-media/addons.yaml": "{}\n",
+			},
+			err: "half-migrated: the repo is marked v1 but the workloads of vcluster-media are in the v2 layout",
+		},
+		{
+			name: "marker without the move",
+			files: map[string]string{
+				MarkerPath:                             "v2\n",
+				"workloads/vcluster-media/addons.yaml": "{}\n",
+			},
+			err: "half-migrated: the repo is marked v2 but the workloads of vcluster-media are in the v1 layout",
+		},
+		{
+			name: "cluster in two environments",
+			files: map[string]string{
+				MarkerPath: "v2\n",
+				"addons/environments/production/workloads/vcluster-media/addons.yaml": "{}\n",
+				"addons/environments/staging/workloads/vcluster-media/addons.yaml":    "{}\n",
+			},
+			err: "workloads of vcluster-media are in both the production and staging environments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := Load(writeRepo(t, tt.files))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.err)
+				}
+				if !errors.Is(err, hcerrors.ErrValidation) {
+					t.Errorf("Load() error category = %v, want validation", hcerrors.CategoryOf(err))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Version() != tt.version {
+				t.Errorf("Version() = %q, want %q", l.Version(), tt.version)
+			}
+			if got := l.ValuesFile("vcluster-media", "app"); got != tt.values {
+				t.Errorf("ValuesFile() = %q, want %q", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestClusters(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		MarkerPath: "v2\n",
+		"addons/environments/staging/workloads/vcluster-b/addons.yaml":    "{}\n",
+		"addons/environments/production/workloads/vcluster-a/addons.yaml": "{}\n",
+		"addons/environments/production/addons/addons.yaml":               "{}\n",
+	})
+	l, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Clusters(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "vcluster-a,vcluster-b" {
+		t.Errorf("Clusters() = %v, want [vcluster-a vcluster-b]", got)
+	}
+}
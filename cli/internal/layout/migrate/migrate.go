@@ -0,0 +1,267 @@
+// Package migrate plans moving a gitops repo between layout versions: the
+// clusters' workload directories are moved, the paths addons.yaml entries
+// and vcluster requests embed are rewritten, and the layout marker is
+// updated, all in one mutation plan committed as a single change.
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// workloadRepoPath is where a vcluster request embeds its workloads root,
+// which the workloads ApplicationSet reads through the cluster secret.
+var workloadRepoPath = []string{"spec", "integrations", "argocd", "workloadRepo", "path"}
+
+// Move is one cluster's workloads directory changing place.
+type Move struct {
+	Cluster string
+	From    string
+	To      string
+	// Files is the number of files moved.
+	Files int
+}
+
+// Migration is what Plan found to do.
+type Migration struct {
+	// From is the repo's layout version before the migration.
+	From string
+	// To is the version migrated to.
+	To string
+	// Moves are the workload directories that change place.
+	Moves []Move
+	// References are the repo-relative files whose embedded paths are
+	// rewritten: addons.yaml entries and vcluster requests.
+	References []string
+	// HalfMigrated is why the repo did not match its marker before the
+	// migration, "" when it did.
+	HalfMigrated string
+}
+
+// Plan adds to mp the changes that move the repo at repoPath to layout
+// version to. A repo already at to, including one that is half-migrated
+// towards it, is finished; one fully at to plans nothing.
+func Plan(mp *mutation.Plan, repoPath, to string) (*Migration, error) {
+	if !layout.Known(to) {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "unknown layout version %q", to).
+			WithRemediation("use one of " + strings.Join(layout.Versions, ", "))
+	}
+	state, err := layout.Inspect(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	current, err := state.Layout(state.Version())
+	if err != nil {
+		return nil, err
+	}
+	target, err := state.Layout(to)
+	if err != nil {
+		return nil, err
+	}
+	v1, _ := layout.New(layout.V1, nil)
+	v2, _ := layout.New(layout.V2, state.V2Clusters)
+
+	m := &Migration{From: state.Version(), To: to, HalfMigrated: state.HalfMigrated()}
+
+	// A half-migrated repo can hold a cluster's workloads in both layouts;
+	// each directory not already where target puts it is moved.
+	type source struct{ cluster, dir string }
+	var sources []source
+	roots := map[string]string{}
+	for _, c := range state.V1Clusters {
+		sources = append(sources, source{c, v1.ClusterDir(c)})
+		roots[c] = v1.WorkloadsRoot(c)
+	}
+	var v2Clusters []string
+	for c := range state.V2Clusters {
+		v2Clusters = append(v2Clusters, c)
+	}
+	sort.Strings(v2Clusters)
+	for _, c := range v2Clusters {
+		sources = append(sources, source{c, v2.ClusterDir(c)})
+		roots[c] = v2.WorkloadsRoot(c)
+	}
+
+	for _, src := range sources {
+		to := target.ClusterDir(src.cluster)
+		if src.dir == to {
+			continue
+		}
+		n, rewritten, err := moveDir(mp, repoPath, src.dir, to)
+		if err != nil {
+			return nil, err
+		}
+		m.Moves = append(m.Moves, Move{Cluster: src.cluster, From: src.dir, To: to, Files: n})
+		if rewritten {
+			m.References = append(m.References, target.AddonsFile(src.cluster))
+		}
+	}
+
+	edited, err := rewriteVClusters(mp, repoPath, roots, current, target)
+	if err != nil {
+		return nil, err
+	}
+	m.References = append(m.References, edited...)
+
+	marker := repopath.Abs(repoPath, layout.MarkerPath)
+	if to == layout.V1 {
+		// A repo without the marker is v1, as it was before any migration.
+		err = mp.Remove(marker)
+	} else {
+		err = mp.Write(marker, []byte(to+"\n"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// moveDir plans moving every file under the repo-relative directory from
+// to the same place under to, as git mv would, keeping each file's mode.
+// Path references in the cluster's addons.yaml are rewritten on the way;
+// rewritten reports whether there were any.
+func moveDir(mp *mutation.Plan, repoPath, from, to string) (n int, rewritten bool, err error) {
+	src := repopath.Abs(repoPath, from)
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(repopath.Abs(repoPath, to), rel)
+		if _, err := os.Stat(dest); err == nil {
+			return hcerrors.New(hcerrors.ErrValidation, "%s already exists", repopath.Join(to, filepath.ToSlash(rel))).
+				WithRemediation(fmt.Sprintf("merge %s into %s by hand, then rerun the migration", from, to))
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if rel == "addons.yaml" {
+			out, changed, err := rewriteAddons(data, from, to)
+			if err != nil {
+				return fmt.Errorf("%s: %w", repopath.Join(from, "addons.yaml"), err)
+			}
+			data, rewritten = out, changed
+		}
+		if err := mp.WritePerm(dest, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		n++
+		return mp.Remove(p)
+	})
+	return n, rewritten, err
+}
+
+// rewriteAddons points the path of each addons.yaml entry under the
+// directory from, such as a kustomize workload's, at the same place
+// under to.
+func rewriteAddons(data []byte, from, to string) ([]byte, bool, error) {
+	var entries map[string]any
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, false, fmt.Errorf("parsing: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var edits []platform.ManifestEdit
+	for _, name := range names {
+		entry, ok := entries[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		p, ok := entry["path"].(string)
+		if !ok {
+			continue
+		}
+		if p == from || strings.HasPrefix(p, from+"/") {
+			edits = append(edits, platform.ManifestEdit{Path: []string{name, "path"}, Value: to + strings.TrimPrefix(p, from)})
+		}
+	}
+	if len(edits) == 0 {
+		return data, false, nil
+	}
+	out, err := platform.EditManifest(data, edits)
+	return out, err == nil, err
+}
+
+// rewriteVClusters plans pointing the workloadRepo.path of each vcluster
+// request whose workloads are in this repo at the cluster's root in the
+// target layout, and returns the requests it edits. Requests reading
+// another repo or base path, or a path of their own, are left alone.
+func rewriteVClusters(mp *mutation.Plan, repoPath string, roots map[string]string, current, target *layout.Layout) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var edited []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var r platform.VClusterResource
+		if err := yaml.Unmarshal(data, &r); err != nil || r.Spec.Name == "" {
+			continue // not a vCluster request
+		}
+		argo := r.Spec.Integrations.ArgoCD
+		if argo == nil {
+			continue
+		}
+		var repo platform.WorkloadRepoConfig
+		if argo.WorkloadRepo != nil {
+			repo = *argo.WorkloadRepo
+		}
+		if (repo.URL != "" && repo.URL != platform.DefaultWorkloadRepoURL) || repo.BasePath != "" {
+			continue
+		}
+		root, ok := roots[r.Spec.Name]
+		if !ok {
+			root = current.WorkloadsRoot(r.Spec.Name)
+		}
+		want := target.WorkloadsRoot(r.Spec.Name)
+		got := repo.WithDefaults().Path
+		if got != root || got == want {
+			continue
+		}
+		// The orchestrator's default is the v1 root, so it needs no key.
+		edit := platform.ManifestEdit{Path: workloadRepoPath, Value: want, IfExists: want == platform.DefaultWorkloadRepoPath}
+		out, err := platform.EditManifest(data, []platform.ManifestEdit{edit})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		if bytes.Equal(out, data) {
+			continue
+		}
+		if err := mp.Write(f, out); err != nil {
+			return nil, err
+		}
+		rel, err := repopath.Rel(repoPath, f)
+		if err != nil {
+			return nil, err
+		}
+		edited = append(edited, rel)
+	}
+	return edited, nil
+}
@@ -0,0 +1,182 @@
+package migrate
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/mutation"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+)
+
+// newV1Repo lays out a v1 repo with a production and a staging cluster,
+// each with a Helm workload, and a kustomize workload whose addons.yaml
+// entry embeds its directory.
+func newV1Repo(t *testing.T) *testutil.Repo {
+	t.Helper()
+	repo := testutil.NewRepo(t, testutil.RepoOptions{
+		Clusters: []string{"vcluster-dev", "vcluster-media"},
+		Files: map[string]string{
+			"workloads/vcluster-media/addons.yaml": `globalSelectors:
+  cluster_name: vcluster-media
+sonarr:
+  enabled: true # media
+  namespace: media
+wiki:
+  enabled: true
+  type: kustomize
+  path: workloads/vcluster-media/addons/wiki
+`,
+			"workloads/vcluster-media/addons/sonarr/values.yaml":      "replicas: 1\n",
+			"workloads/vcluster-media/addons/wiki/kustomization.yaml": "resources: [deployment.yaml]\n",
+			"workloads/vcluster-dev/addons/api/values.yaml":           "replicas: 2\n",
+			"workloads/vcluster-dev/addons/api/scripts/check.sh":      "#!/bin/sh\n",
+			"addons/environments/staging/addons/addons.yaml":          "{}\n",
+			"addons/environments/production/workloads/README.md":      "Workloads of production clusters.\n",
+		},
+	})
+	if err := os.Chmod(repo.Path("workloads/vcluster-dev/addons/api/scripts/check.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	request := strings.Replace(repo.ReadFile("platform/vclusters/vcluster-dev.yaml"),
+		"environment: production", "environment: staging", 1)
+	repo.WriteFile("platform/vclusters/vcluster-dev.yaml", request)
+	repo.Commit("fixture workloads")
+	return repo
+}
+
+// migrate plans and applies a migration of repo to version.
+func migrate(t *testing.T, repo *testutil.Repo, to string) *Migration {
+	t.Helper()
+	mp := mutation.New(repo.Root)
+	m, err := Plan(mp, repo.Root, to)
+	if err != nil {
+		t.Fatalf("Plan(%s): %v", to, err)
+	}
+	if err := mp.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMigrateV1ToV2(t *testing.T) {
+	repo := newV1Repo(t)
+
+	m := migrate(t, repo, layout.V2)
+	if m.From != layout.V1 || m.HalfMigrated != "" || len(m.Moves) != 2 {
+		t.Fatalf("migration = %+v", m)
+	}
+
+	for rel, want := range map[string]string{
+		"addons/environments/staging/workloads/vcluster-dev/addons/api/values.yaml":         "replicas: 2\n",
+		"addons/environments/production/workloads/vcluster-media/addons/sonarr/values.yaml": "replicas: 1\n",
+		layout.MarkerPath: "v2\n",
+	} {
+		if got := repo.ReadFile(rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+	if repo.Exists("workloads") {
+		t.Error("workloads/ was left behind")
+	}
+	info, err := os.Stat(repo.Path("addons/environments/staging/workloads/vcluster-dev/addons/api/scripts/check.sh"))
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("check.sh lost its mode: %v %v", info, err)
+	}
+
+	addons := repo.ReadFile("addons/environments/production/workloads/vcluster-media/addons.yaml")
+	if !strings.Contains(addons, "path: addons/environments/production/workloads/vcluster-media/addons/wiki\n") ||
+		!strings.Contains(addons, "enabled: true # media") {
+		t.Errorf("addons.yaml not rewritten in place:\n%s", addons)
+	}
+	for cluster, root := range map[string]string{
+		"vcluster-dev":   "addons/environments/staging/workloads",
+		"vcluster-media": "addons/environments/production/workloads",
+	} {
+		if got := repo.ReadFile("platform/vclusters/" + cluster + ".yaml"); !strings.Contains(got, "path: "+root+"\n") {
+			t.Errorf("%s workloadRepo.path not set to %s:\n%s", cluster, root, got)
+		}
+	}
+
+	l, err := layout.Load(repo.Root)
+	if err != nil {
+		t.Fatalf("Load() after the migration: %v", err)
+	}
+	if got := l.ValuesFile("vcluster-dev", "api"); got != "addons/environments/staging/workloads/vcluster-dev/addons/api/values.yaml" {
+		t.Errorf("ValuesFile() = %q", got)
+	}
+
+	mp := mutation.New(repo.Root)
+	if _, err := Plan(mp, repo.Root, layout.V2); err != nil {
+		t.Fatal(err)
+	}
+	if !mp.Empty() {
+		t.Errorf("migrating again plans %v", mp.Paths())
+	}
+}
+
+func TestMigrateV2ToV1(t *testing.T) {
+	repo := newV1Repo(t)
+	before := map[string]string{}
+	for _, rel := range []string{
+		"workloads/vcluster-media/addons.yaml",
+		"workloads/vcluster-media/addons/sonarr/values.yaml",
+		"workloads/vcluster-dev/addons/api/values.yaml",
+	} {
+		before[rel] = repo.ReadFile(rel)
+	}
+	migrate(t, repo, layout.V2)
+	repo.Commit("migrate")
+
+	m := migrate(t, repo, layout.V1)
+	if m.From != layout.V2 || len(m.Moves) != 2 {
+		t.Fatalf("migration = %+v", m)
+	}
+	for rel, want := range before {
+		if got := repo.ReadFile(rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+	if repo.Exists(layout.MarkerPath) || repo.Exists("addons/environments/staging/workloads") {
+		t.Error("the v2 marker or workloads were left behind")
+	}
+	if !repo.Exists("addons/environments/production/workloads/README.md") {
+		t.Error("files beside the v2 workloads were moved")
+	}
+	if got := repo.ReadFile("platform/vclusters/vcluster-dev.yaml"); !strings.Contains(got, "path: workloads\n") {
+		t.Errorf("workloadRepo.path not reset:\n%s", got)
+	}
+	if l, err := layout.Load(repo.Root); err != nil || l.Version() != layout.V1 {
+		t.Errorf("Load() = %v, %v, want v1", l, err)
+	}
+}
+
+func TestMigrateHalfMigrated(t *testing.T) {
+	repo := newV1Repo(t)
+	repo.Git("mv", "workloads/vcluster-media", "addons/environments/production/workloads/vcluster-media")
+	repo.Commit("move media by hand")
+
+	if _, err := layout.Load(repo.Root); err == nil || !strings.Contains(err.Error(), "half-migrated") {
+		t.Fatalf("Load() error = %v, want half-migrated", err)
+	}
+
+	m := migrate(t, repo, layout.V2)
+	if m.HalfMigrated == "" || len(m.Moves) != 1 || m.Moves[0].Cluster != "vcluster-dev" {
+		t.Fatalf("migration = %+v, want only vcluster-dev moved", m)
+	}
+	if _, err := layout.Load(repo.Root); err != nil {
+		t.Errorf("Load() after finishing: %v", err)
+	}
+}
+
+func TestMigrateConflict(t *testing.T) {
+	repo := newV1Repo(t)
+	repo.WriteFile("addons/environments/staging/workloads/vcluster-dev/addons/api/values.yaml", "replicas: 3\n")
+	repo.Commit("conflicting copy")
+
+	_, err := Plan(mutation.New(repo.Root), repo.Root, layout.V2)
+	if err == nil || !strings.Contains(err.Error(), "vcluster-dev/addons/api/values.yaml already exists") {
+		t.Fatalf("Plan() error = %v, want a conflict", err)
+	}
+}
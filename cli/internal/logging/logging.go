@@ -0,0 +1,95 @@
+// Package logging is hctl's leveled diagnostic log. It writes slog text
+// records to stderr, so structured stdout output stays clean, and redacts
+// anything that looks like a credential before it is written: attributes
+// with secret-like keys (token, password, kubeconfig, ...) and values that
+// carry kubeconfig content, private keys, bearer tokens or URL credentials.
+//
+// The level follows the global flags: --debug logs Debug and above,
+// -v/--verbose Info and above, and a default run only warnings, which the
+// internal packages do not emit. Packages log through L, or FromContext when
+// they hold a request context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+var (
+	mu      sync.RWMutex
+	current = New(Stderr, slog.LevelWarn)
+)
+
+// Stderr writes to whatever os.Stderr is at the time of the write, so a
+// logger outlives redirection of stderr, as in tests.
+var Stderr io.Writer = stderr{}
+
+type stderr struct{}
+
+func (stderr) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+// Level returns the log level for the --verbose and --debug flags.
+func Level(verbose, debug bool) slog.Level {
+	switch {
+	case debug:
+		return slog.LevelDebug
+	case verbose:
+		return slog.LevelInfo
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// New returns a logger writing redacted text records at level and above to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	}))
+}
+
+// Init installs the logger for the --verbose and --debug flags, writing to
+// Stderr.
+func Init(verbose, debug bool) *slog.Logger {
+	l := New(Stderr, Level(verbose, debug))
+	Set(l)
+	return l
+}
+
+// Set makes l the logger returned by L and slog.Default, so public packages
+// that log through slog.Default share it.
+func Set(l *slog.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = l
+	slog.SetDefault(l)
+}
+
+// L returns the process logger.
+func L() *slog.Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or L when it has none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return L()
+}
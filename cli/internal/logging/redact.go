@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces every redacted value.
+const Redacted = "[REDACTED]"
+
+// secretKeys are substrings of attribute and map keys whose values are
+// never logged, compared case-insensitively with - and _ removed.
+var secretKeys = []string{
+	"token", "password", "passwd", "secret", "kubeconfig", "credential",
+	"apikey", "authorization", "privatekey", "keydata", "certificatedata",
+}
+
+// IsSecretKey reports whether values under key are redacted.
+func IsSecretKey(key string) bool {
+	k := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	for _, s := range secretKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// wholeValue matches content that is redacted entirely: kubeconfigs and
+	// PEM blocks.
+	wholeValue = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]+-----|client-(key|certificate)-data|certificate-authority-data|kind:\s*Config\b.*\bclusters:|"kind":\s*"Config"`)
+	// bearer matches an Authorization header value.
+	bearer = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// urlUserinfo matches user:password@ in a URL.
+	urlUserinfo = regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`)
+	// inlineSecret matches key=value and key: value pairs with secret-like keys.
+	inlineSecret = regexp.MustCompile(`(?i)\b([a-z_-]*(?:token|password|passwd|secret|apikey)[a-z_-]*)(["']?\s*[:=]\s*["']?)[^\s"',}]+`)
+)
+
+// RedactString removes credentials from s. Kubeconfigs and PEM blocks are
+// replaced entirely; bearer tokens, URL passwords and key=value secrets are
+// replaced in place.
+func RedactString(s string) string {
+	if wholeValue.MatchString(s) {
+		return Redacted
+	}
+	s = bearer.ReplaceAllString(s, "$1 "+Redacted)
+	s = urlUserinfo.ReplaceAllString(s, "${1}"+Redacted+"@")
+	return inlineSecret.ReplaceAllString(s, "${1}${2}"+Redacted)
+}
+
+// RedactValue returns v with secrets removed: strings as RedactString, and
+// maps and slices element by element, dropping values under secret keys.
+// Other values are returned unchanged.
+func RedactValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return RedactString(val)
+	case []byte:
+		return RedactString(string(val))
+	case error:
+		return RedactString(val.Error())
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, s := range val {
+			if IsSecretKey(k) {
+				out[k] = Redacted
+			} else {
+				out[k] = RedactString(s)
+			}
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			if IsSecretKey(k) {
+				out[k] = Redacted
+			} else {
+				out[k] = RedactValue(e)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = RedactValue(e)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, e := range val {
+			out[i] = RedactString(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// replaceAttr redacts each attribute before the handler writes it.
+func replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if IsSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(RedactString(a.Value.String()))
+	case slog.KindAny:
+		a.Value = slog.AnyValue(RedactValue(a.Value.Any()))
+	}
+	return a
+}
@@ -0,0 +1,213 @@
+// Package metrics times the phases of an hctl operation and summarises them
+// for CI logs. 'hctl deploy run/render' use it for --metrics and the
+// "metrics" block of their structured output.
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase names recorded by the deploy commands.
+const (
+	PhaseParse     = "parse"
+	PhaseTranslate = "translate"
+)
+
+// Timer records phase, provisioner and git operation durations against a
+// clock, and provisioner cache lookups. It is safe for concurrent use; provisioners are timed from the
+// translation worker pool.
+type Timer struct {
+	now   func() time.Time
+	start time.Time
+
+	mu           sync.Mutex
+	phases       map[string]time.Duration
+	provisioners []Provision
+	git          []Operation
+	cache        CacheStats
+}
+
+// NewTimer starts a timer reading now, or time.Now when now is nil. The
+// end-to-end time is measured from this call.
+func NewTimer(now func() time.Time) *Timer {
+	if now == nil {
+		now = time.Now
+	}
+	return &Timer{now: now, start: now(), phases: map[string]time.Duration{}}
+}
+
+// Phase starts timing the named phase; call the returned func when it ends.
+// Timing a phase twice adds the durations.
+func (t *Timer) Phase(name string) func() {
+	start := t.now()
+	return func() {
+		d := t.now().Sub(start)
+		t.mu.Lock()
+		t.phases[name] += d
+		t.mu.Unlock()
+	}
+}
+
+// Provisioner starts timing the provisioner of a Score resource.
+func (t *Timer) Provisioner(resource, resourceType string) func() {
+	start := t.now()
+	return func() {
+		d := t.now().Sub(start)
+		t.mu.Lock()
+		t.provisioners = append(t.provisioners, Provision{Resource: resource, Type: resourceType, Millis: millis(d)})
+		t.mu.Unlock()
+	}
+}
+
+// Git starts timing a git operation.
+func (t *Timer) Git(operation string) func() {
+	start := t.now()
+	return func() {
+		d := t.now().Sub(start)
+		t.mu.Lock()
+		t.git = append(t.git, Operation{Name: operation, Millis: millis(d)})
+		t.mu.Unlock()
+	}
+}
+
+// CacheLookup records a provisioner cache lookup. It has the signature of
+// provcache.Cache.OnLookup.
+func (t *Timer) CacheLookup(hit bool) {
+	t.mu.Lock()
+	if hit {
+		t.cache.Hits++
+	} else {
+		t.cache.Misses++
+	}
+	t.mu.Unlock()
+}
+
+// Counts describes the size of what was generated.
+type Counts struct {
+	Workloads int
+	Resources int
+	// Files maps each generated file to its size in bytes.
+	Files map[string]int
+	// Kinds counts generated extra objects by kind.
+	Kinds map[string]int
+}
+
+// Add adds the counts of o to c, for a run over several workloads.
+func (c *Counts) Add(o Counts) {
+	c.Workloads += o.Workloads
+	c.Resources += o.Resources
+	if c.Files == nil {
+		c.Files = map[string]int{}
+	}
+	for path, n := range o.Files {
+		c.Files[path] = n
+	}
+	if c.Kinds == nil {
+		c.Kinds = map[string]int{}
+	}
+	for kind, n := range o.Kinds {
+		c.Kinds[kind] += n
+	}
+}
+
+// Summary is the metrics block of structured output. Durations are whole
+// milliseconds.
+type Summary struct {
+	Workloads     int            `json:"workloads" yaml:"workloads"`
+	Resources     int            `json:"resources" yaml:"resources"`
+	Objects       int            `json:"objects" yaml:"objects"`
+	ObjectsByKind map[string]int `json:"objectsByKind" yaml:"objectsByKind"`
+	Files         int            `json:"files" yaml:"files"`
+	Bytes         int            `json:"bytes" yaml:"bytes"`
+	ParseMillis   int64          `json:"parseMs" yaml:"parseMs"`
+	// TranslateMillis covers the whole translation, provisioners included.
+	TranslateMillis int64       `json:"translateMs" yaml:"translateMs"`
+	Provisioners    []Provision `json:"provisioners" yaml:"provisioners"`
+	Git             []Operation `json:"git" yaml:"git"`
+	// Cache counts provisioner cache lookups; both are zero when the cache
+	// was not used.
+	Cache       CacheStats `json:"cache" yaml:"cache"`
+	TotalMillis int64      `json:"totalMs" yaml:"totalMs"`
+}
+
+// Provision is the time one Score resource's provisioner took.
+type Provision struct {
+	Resource string `json:"resource" yaml:"resource"`
+	Type     string `json:"type" yaml:"type"`
+	Millis   int64  `json:"ms" yaml:"ms"`
+}
+
+// CacheStats counts provisioner results served from the cache (hits) and
+// provisioners run because no entry matched (misses).
+type CacheStats struct {
+	Hits   int `json:"hits" yaml:"hits"`
+	Misses int `json:"misses" yaml:"misses"`
+}
+
+// Operation is the time one git operation took.
+type Operation struct {
+	Name   string `json:"operation" yaml:"operation"`
+	Millis int64  `json:"ms" yaml:"ms"`
+}
+
+// Summary returns the recorded timings with counts, measuring the total up
+// to now. Provisioners are sorted by resource name, since they finish in
+// scheduling order.
+func (t *Timer) Summary(c Counts) Summary {
+	total := t.now().Sub(t.start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Summary{
+		Workloads:       c.Workloads,
+		Resources:       c.Resources,
+		ObjectsByKind:   map[string]int{},
+		Files:           len(c.Files),
+		ParseMillis:     millis(t.phases[PhaseParse]),
+		TranslateMillis: millis(t.phases[PhaseTranslate]),
+		Provisioners:    append([]Provision{}, t.provisioners...),
+		Git:             append([]Operation{}, t.git...),
+		Cache:           t.cache,
+		TotalMillis:     millis(total),
+	}
+	for kind, n := range c.Kinds {
+		s.ObjectsByKind[kind] = n
+		s.Objects += n
+	}
+	for _, n := range c.Files {
+		s.Bytes += n
+	}
+	sort.SliceStable(s.Provisioners, func(i, j int) bool { return s.Provisioners[i].Resource < s.Provisioners[j].Resource })
+	return s
+}
+
+// String is the one-line text summary, e.g. "translated 1 workload,
+// 4 resources, 7 objects in 840ms".
+func (s Summary) String() string {
+	line := fmt.Sprintf("translated %s, %s, %s in %dms",
+		plural(s.Workloads, "workload"), plural(s.Resources, "resource"), plural(s.Objects, "object"), s.TotalMillis)
+	if len(s.Git) > 0 {
+		var ops []string
+		for _, op := range s.Git {
+			ops = append(ops, fmt.Sprintf("%s %dms", op.Name, op.Millis))
+		}
+		line += " (git: " + strings.Join(ops, ", ") + ")"
+	}
+	return line
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func millis(d time.Duration) int64 {
+	return d.Milliseconds()
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// fakeClock returns the current time and advances it by step on each read.
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	t := c.t
+	c.t = c.t.Add(c.step)
+	return t
+}
+
+func TestTimerPhases(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), step: 10 * time.Millisecond}
+	timer := NewTimer(clock.now) // start: 0ms
+
+	endParse := timer.Phase(PhaseParse) // 10ms
+	endParse()                          // 20ms
+	endParse = timer.Phase(PhaseParse)  // 30ms
+	endParse()                          // 40ms
+
+	s := timer.Summary(Counts{}) // 50ms
+	if s.ParseMillis != 20 {
+		t.Errorf("ParseMillis = %d, want both phases added (20)", s.ParseMillis)
+	}
+	if s.TotalMillis != 50 {
+		t.Errorf("TotalMillis = %d, want 50", s.TotalMillis)
+	}
+	if s.Provisioners == nil || s.Git == nil || s.ObjectsByKind == nil {
+		t.Error("empty lists and maps should be non-nil so JSON has [] and {}")
+	}
+}
+
+func TestSummaryString(t *testing.T) {
+	tests := []struct {
+		s    Summary
+		want string
+	}{
+		{
+			Summary{Workloads: 1, Resources: 4, Objects: 7, TotalMillis: 840},
+			"translated 1 workload, 4 resources, 7 objects in 840ms",
+		},
+		{
+			Summary{Workloads: 1, Resources: 1, Objects: 0, TotalMillis: 12, Git: []Operation{{Name: "commit+push", Millis: 900}}},
+			"translated 1 workload, 1 resource, 0 objects in 12ms (git: commit+push 900ms)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// TestSummaryJSONGolden pins the JSON schema of the metrics block documented
+// in the README. Run with -update after an intended change.
+func TestSummaryJSONGolden(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), step: 5 * time.Millisecond}
+	timer := NewTimer(clock.now)
+
+	timer.Phase(PhaseParse)()
+	endTranslate := timer.Phase(PhaseTranslate)
+	// Provisioners finish out of name order; the summary sorts them.
+	endWeb := timer.Provisioner("web", "route")
+	endDB := timer.Provisioner("db", "postgres")
+	endDB()
+	endWeb()
+	endTranslate()
+	// One provisioner cache lookup hit, one missed.
+	timer.CacheLookup(true)
+	timer.CacheLookup(false)
+	timer.Git("commit+push")()
+
+	s := timer.Summary(Counts{
+		Workloads: 1,
+		Resources: 2,
+		Files: map[string]int{
+			"workloads/media/addons/web/values.yaml": 1840,
+			"workloads/media/addons.yaml":            312,
+		},
+		Kinds: map[string]int{"ExternalSecret": 1, "HTTPRoute": 1},
+	})
+	got, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "summary.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("metrics JSON does not match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
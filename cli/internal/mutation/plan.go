@@ -0,0 +1,385 @@
+// Package mutation collects what a command would change — file writes and
+// deletions, the git workflow that commits them, and changes to cluster
+// state — into a Plan that is then either printed (--dry-run) or executed.
+// Every mutating command builds one, so what --dry-run reports and what the
+// command does come from the same list and cannot drift apart.
+package mutation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+)
+
+// DefaultPerm is the mode of files written without an explicit one.
+const DefaultPerm os.FileMode = 0o644
+
+// File is one planned file write or deletion.
+type File struct {
+	// Path is the absolute file path.
+	Path string
+	// Before is the current content, nil when the file does not exist.
+	Before []byte
+	// After is the new content, nil when the file is deleted.
+	After []byte
+	// Perm is the mode for a written file; zero means DefaultPerm.
+	Perm os.FileMode
+}
+
+// Op names the change: "create", "update" or "delete".
+func (f File) Op() string {
+	switch {
+	case f.After == nil:
+		return "delete"
+	case f.Before == nil:
+		return "create"
+	}
+	return "update"
+}
+
+// ActionKind says what an Action changes.
+type ActionKind string
+
+const (
+	// ActionGit is a git operation beyond the plan's workflow, such as a
+	// follow-up commit.
+	ActionGit ActionKind = "git"
+	// ActionCluster changes live cluster state.
+	ActionCluster ActionKind = "cluster"
+)
+
+// Action is a planned change that is not a file write: it runs after the
+// files are written and the workflow has committed them, in plan order.
+type Action struct {
+	Kind        ActionKind
+	Description string
+	Run         func() error
+}
+
+// Plan is everything one command invocation would change.
+type Plan struct {
+	// Root is the gitops repo path. Files under it are shown, and committed,
+	// by their repo-relative path.
+	Root    string
+	Files   []File
+	Actions []Action
+
+	// workflow commits the files, when set (see Commit).
+	workflow *git.WorkflowOpts
+	// GitResult is what the workflow did once Finish has run it, and
+	// git.GitSkipped until then.
+	GitResult git.GitResult
+
+	// write and remove perform file changes; tests substitute failing ones.
+	write  func(path string, data []byte, perm os.FileMode) error
+	remove func(path string) error
+}
+
+// New returns an empty plan for the repo at root.
+func New(root string) *Plan {
+	return &Plan{Root: root, GitResult: git.GitSkipped, write: WriteFileAtomic, remove: os.Remove}
+}
+
+// Write plans writing data to path with DefaultPerm.
+func (p *Plan) Write(path string, data []byte) error {
+	return p.WritePerm(path, data, DefaultPerm)
+}
+
+// WritePerm plans writing data to path with perm. Nothing is planned when
+// the file already holds data; writing a path the plan already changes
+// replaces the earlier content.
+func (p *Plan) WritePerm(path string, data []byte, perm os.FileMode) error {
+	if data == nil {
+		data = []byte{}
+	}
+	return p.set(path, data, perm)
+}
+
+// Remove plans deleting path. Nothing is planned when it does not exist.
+func (p *Plan) Remove(path string) error {
+	return p.set(path, nil, 0)
+}
+
+// RemoveAll plans deleting every file under dir. Directories left empty are
+// removed when the plan is applied.
+func (p *Plan) RemoveAll(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return p.Remove(path)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Add plans a change computed elsewhere, such as an addon or bulk plan's.
+func (p *Plan) Add(f File) {
+	p.Files = append(p.Files, f)
+}
+
+func (p *Plan) set(path string, data []byte, perm os.FileMode) error {
+	for i := range p.Files {
+		if p.Files[i].Path == path {
+			p.Files[i].After, p.Files[i].Perm = data, perm
+			if same(p.Files[i].Before, data) {
+				p.Files = append(p.Files[:i], p.Files[i+1:]...)
+			}
+			return nil
+		}
+	}
+	before, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if same(before, data) {
+		return nil
+	}
+	p.Files = append(p.Files, File{Path: path, Before: before, After: data, Perm: perm})
+	return nil
+}
+
+// same reports whether before and after are the same file state: both
+// absent, or both present with equal content.
+func same(before, after []byte) bool {
+	return (before == nil) == (after == nil) && bytes.Equal(before, after)
+}
+
+// Commit plans opts as the git workflow that follows the file changes.
+// When opts.Paths is empty the plan's repo-relative paths are committed.
+func (p *Plan) Commit(opts git.WorkflowOpts) {
+	if opts.RepoPath == "" {
+		opts.RepoPath = p.Root
+	}
+	p.workflow = &opts
+}
+
+// Workflow returns the planned git workflow, with its paths filled in, or
+// nil when the plan commits nothing.
+func (p *Plan) Workflow() *git.WorkflowOpts {
+	if p.workflow == nil {
+		return nil
+	}
+	opts := *p.workflow
+	if len(opts.Paths) == 0 {
+		opts.Paths = p.Paths()
+	}
+	return &opts
+}
+
+// GitStep plans a git operation that runs after the workflow.
+func (p *Plan) GitStep(description string, run func() error) {
+	p.Actions = append(p.Actions, Action{Kind: ActionGit, Description: description, Run: run})
+}
+
+// Cluster plans a change to live cluster state.
+func (p *Plan) Cluster(description string, run func() error) {
+	p.Actions = append(p.Actions, Action{Kind: ActionCluster, Description: description, Run: run})
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Files) == 0 && len(p.Actions) == 0
+}
+
+// Paths returns the changed file paths under Root, repo-relative and
+// slash-separated, in plan order.
+func (p *Plan) Paths() []string {
+	var paths []string
+	for _, f := range p.Files {
+		if rel, ok := p.rel(f.Path); ok {
+			paths = append(paths, rel)
+		}
+	}
+	return paths
+}
+
+// Display returns how path is shown: repo-relative under Root, else as is.
+func (p *Plan) Display(path string) string {
+	if rel, ok := p.rel(path); ok {
+		return rel
+	}
+	return path
+}
+
+func (p *Plan) rel(path string) (string, bool) {
+	if p.Root == "" {
+		return "", false
+	}
+	rel, err := repopath.Rel(p.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+// DryRun prints the plan instead of executing it. It returns an
+// hcerrors.ErrChangesPending error when the plan would change something, so
+// the command exits with hcerrors.ExitChanges, and nil otherwise.
+func (p *Plan) DryRun() error {
+	p.print()
+	if p.Empty() {
+		return nil
+	}
+	return ChangesPending(len(p.Files) + len(p.Actions))
+}
+
+// ChangesPending is the error a dry run returns when there are n changes.
+func ChangesPending(n int) error {
+	noun := "changes"
+	if n == 1 {
+		noun = "change"
+	}
+	return hcerrors.New(hcerrors.ErrChangesPending, "dry run: %d %s pending", n, noun)
+}
+
+// Apply writes and deletes the plan's files. If one fails, the changes
+// already made are reverted so the working tree is left as it was.
+// Directories under Root emptied by the deletions are removed.
+func (p *Plan) Apply() error {
+	if err := ApplyFiles(p.Files, p.write, p.remove); err != nil {
+		return err
+	}
+	for _, f := range p.Files {
+		if f.After == nil {
+			p.pruneEmptyParents(filepath.Dir(f.Path))
+		}
+	}
+	return nil
+}
+
+// Execute applies the files, then finishes the plan (see Finish).
+func (p *Plan) Execute() error {
+	if err := p.Apply(); err != nil {
+		return err
+	}
+	return p.Finish()
+}
+
+// Finish runs what follows the file changes: the git workflow, then the
+// actions in order, stopping at the first failure. Commands that report
+// between writing and committing call Apply and Finish themselves.
+func (p *Plan) Finish() error {
+	if opts := p.Workflow(); opts != nil {
+		result, err := git.HandleGitWorkflow(*opts)
+		p.GitResult = result
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range p.Actions {
+		if err := a.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyFiles writes or deletes each file with write and remove. If one
+// fails, the changes already made are reverted, most recent first, so the
+// disk is left as it was.
+func ApplyFiles(files []File, write func(path string, data []byte, perm os.FileMode) error, remove func(path string) error) error {
+	for i, f := range files {
+		if err := applyFile(f, write, remove); err != nil {
+			if rbErr := rollback(files[:i]); rbErr != nil {
+				return fmt.Errorf("applying %s: %w (rollback also failed: %v)", f.Path, err, rbErr)
+			}
+			return fmt.Errorf("applying %s: %w (all changes rolled back)", f.Path, err)
+		}
+	}
+	return nil
+}
+
+func applyFile(f File, write func(string, []byte, os.FileMode) error, remove func(string) error) error {
+	if f.After == nil {
+		if err := remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		logging.L().Debug("removed file", "path", f.Path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), dirPerm(f)); err != nil {
+		return err
+	}
+	if err := write(f.Path, f.After, perm(f)); err != nil {
+		return err
+	}
+	logging.L().Debug("wrote file", "path", f.Path, "bytes", len(f.After))
+	return nil
+}
+
+// rollback restores applied files, most recent first.
+func rollback(applied []File) error {
+	var errs []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		f := applied[i]
+		var err error
+		if f.Before == nil {
+			err = os.Remove(f.Path)
+			if errors.Is(err, fs.ErrNotExist) {
+				err = nil
+			}
+		} else if err = os.MkdirAll(filepath.Dir(f.Path), dirPerm(f)); err == nil {
+			err = WriteFileAtomic(f.Path, f.Before, perm(f))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.Path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// pruneEmptyParents removes dir and its parents below Root for as long as
+// they are empty.
+func (p *Plan) pruneEmptyParents(dir string) {
+	for {
+		rel, ok := p.rel(dir)
+		if !ok || rel == "." {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return // not empty, or already gone
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func perm(f File) os.FileMode {
+	if f.Perm == 0 {
+		return DefaultPerm
+	}
+	return f.Perm
+}
+
+// dirPerm is the mode for directories created for f: private when the file
+// itself is, such as a kubeconfig.
+func dirPerm(f File) os.FileMode {
+	if perm(f)&0o077 == 0 {
+		return 0o700
+	}
+	return 0o755
+}
+
+// WriteFileAtomic writes data to a temporary file beside path and renames
+// it into place, so readers never see a partial file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".hctl-tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
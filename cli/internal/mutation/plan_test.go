@@ -0,0 +1,170 @@
+package mutation
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "same.yaml"), "a: 1\n")
+	writeFile(t, filepath.Join(root, "old.yaml"), "a: 1\n")
+	writeFile(t, filepath.Join(root, "gone.yaml"), "a: 1\n")
+
+	p := New(root)
+	for path, data := range map[string]string{"same.yaml": "a: 1\n", "old.yaml": "a: 2\n", "dir/new.yaml": "b: 1\n"} {
+		if err := p.Write(filepath.Join(root, path), []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Remove(filepath.Join(root, "gone.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Remove(filepath.Join(root, "missing.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := map[string]string{}
+	for _, f := range p.Files {
+		ops[p.Display(f.Path)] = f.Op()
+	}
+	want := map[string]string{"old.yaml": "update", "dir/new.yaml": "create", "gone.yaml": "delete"}
+	if len(ops) != len(want) {
+		t.Errorf("planned %v, want %v", ops, want)
+	}
+	for path, op := range want {
+		if ops[path] != op {
+			t.Errorf("%s: op = %q, want %q", path, ops[path], op)
+		}
+	}
+
+	// Writing a planned path back to its current content drops it.
+	if err := p.Write(filepath.Join(root, "old.yaml"), []byte("a: 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 2 {
+		t.Errorf("after reverting old.yaml, planned %d files, want 2", len(p.Files))
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	root := t.TempDir()
+	p := New(root)
+	if err := p.DryRun(); err != nil {
+		t.Errorf("empty plan: DryRun() = %v, want nil", err)
+	}
+
+	path := filepath.Join(root, "new.yaml")
+	if err := p.Write(path, []byte("a: 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	p.Cluster("scale web to 0", func() error { ran = true; return nil })
+
+	err := p.DryRun()
+	if hcerrors.CategoryOf(err) != hcerrors.ErrChangesPending || hcerrors.ExitCode(err) != hcerrors.ExitChanges {
+		t.Errorf("DryRun() = %v, want a changes_pending error exiting %d", err, hcerrors.ExitChanges)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("DryRun wrote the file")
+	}
+	if ran {
+		t.Error("DryRun ran the cluster action")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "values.yaml"), "replicas: 1\nimage: web\n")
+	writeFile(t, filepath.Join(root, "old.yaml"), "x\n")
+	writeFile(t, filepath.Join(root, "kubeconfig"), "token: a\n")
+
+	p := New(root)
+	_ = p.Write(filepath.Join(root, "values.yaml"), []byte("replicas: 2\nimage: web\n"))
+	_ = p.Write(filepath.Join(root, "new.yaml"), []byte("12345"))
+	_ = p.Remove(filepath.Join(root, "old.yaml"))
+	_ = p.WritePerm(filepath.Join(root, "kubeconfig"), []byte("token: b\n"), 0o600)
+	p.Cluster("scale web to 0", func() error { return nil })
+
+	var buf bytes.Buffer
+	p.Print(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"~ values.yaml", "-replicas: 1", "+replicas: 2",
+		"+ new.yaml (create, 5 bytes)",
+		"- old.yaml (delete, 2 bytes)",
+		"Cluster:", "scale web to 0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "token:") {
+		t.Errorf("output shows the diff of a private file:\n%s", out)
+	}
+}
+
+func TestApplyRollsBack(t *testing.T) {
+	root := t.TempDir()
+	kept := filepath.Join(root, "kept.yaml")
+	writeFile(t, kept, "before\n")
+
+	p := New(root)
+	_ = p.Write(kept, []byte("after\n"))
+	_ = p.Write(filepath.Join(root, "new.yaml"), []byte("new\n"))
+	_ = p.Write(filepath.Join(root, "fails.yaml"), []byte("x\n"))
+	p.write = func(path string, data []byte, perm os.FileMode) error {
+		if filepath.Base(path) == "fails.yaml" {
+			return errors.New("disk full")
+		}
+		return WriteFileAtomic(path, data, perm)
+	}
+
+	err := p.Apply()
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("Apply() = %v, want a rolled-back error", err)
+	}
+	if got, _ := os.ReadFile(kept); string(got) != "before\n" {
+		t.Errorf("kept.yaml = %q after rollback, want its old content", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.yaml")); !os.IsNotExist(err) {
+		t.Error("new.yaml left behind after rollback")
+	}
+}
+
+func TestApplyPrunesBelowRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "repo")
+	values := filepath.Join(root, "workloads", "dev", "hello", "values.yaml")
+	writeFile(t, values, "a: 1\n")
+
+	p := New(root)
+	if err := p.RemoveAll(filepath.Join(root, "workloads", "dev", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "workloads")); !os.IsNotExist(err) {
+		t.Error("emptied directories were not removed")
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("Apply removed the root: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package mutation
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	deploylib "github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/git"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// maxDiffLines caps the diff lines shown for one updated file.
+const maxDiffLines = 20
+
+// Summary is the structured form of a dry run, emitted with -o json/yaml.
+type Summary struct {
+	DryRun  bool          `json:"dryRun" yaml:"dryRun"`
+	Files   []FileSummary `json:"files,omitempty" yaml:"files,omitempty"`
+	Git     []string      `json:"git,omitempty" yaml:"git,omitempty"`
+	Cluster []string      `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+}
+
+// FileSummary is one planned file change in a Summary.
+type FileSummary struct {
+	Path string `json:"path" yaml:"path"`
+	Op   string `json:"op" yaml:"op"`
+	// Bytes is the size written, or deleted for a deletion.
+	Bytes int `json:"bytes" yaml:"bytes"`
+	// Diff holds the hunks of an update, headers included. It is left out
+	// for private (0600) files.
+	Diff []string `json:"diff,omitempty" yaml:"diff,omitempty"`
+}
+
+// Summary describes the plan without executing it.
+func (p *Plan) Summary() Summary {
+	s := Summary{DryRun: true}
+	for _, f := range p.Files {
+		fs := FileSummary{Path: p.Display(f.Path), Op: f.Op(), Bytes: len(f.After)}
+		switch f.Op() {
+		case "delete":
+			fs.Bytes = len(f.Before)
+		case "update":
+			if perm(f)&0o077 == 0 {
+				// Private files, such as kubeconfigs, hold credentials.
+				break
+			}
+			for _, h := range deploylib.DiffLines(string(f.Before), string(f.After)) {
+				fs.Diff = append(fs.Diff, h.Header())
+				fs.Diff = append(fs.Diff, h.Lines...)
+			}
+		}
+		s.Files = append(s.Files, fs)
+	}
+	if opts := p.Workflow(); opts != nil {
+		s.Git = append(s.Git, git.DescribeWorkflow(*opts))
+	}
+	for _, a := range p.Actions {
+		if a.Kind == ActionGit {
+			s.Git = append(s.Git, a.Description)
+		} else {
+			s.Cluster = append(s.Cluster, a.Description)
+		}
+	}
+	return s
+}
+
+func (p *Plan) print() {
+	if tui.PrintStructured(p.Summary()) {
+		return
+	}
+	p.Print(os.Stdout)
+}
+
+// Print renders the plan for --dry-run: each file with its operation and
+// size, a short diff for updates, then the git and cluster actions that
+// would follow.
+func (p *Plan) Print(w io.Writer) {
+	s := p.Summary()
+	if p.Empty() {
+		fmt.Fprintf(w, "\n%s\n", tui.DimStyle.Render("Dry run: no changes"))
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", tui.TitleStyle.Render("Dry run: nothing was changed"))
+
+	if len(s.Files) > 0 {
+		fmt.Fprintf(w, "\n  Files:\n")
+	}
+	for _, f := range s.Files {
+		switch f.Op {
+		case "create":
+			fmt.Fprintf(w, "    %s %s %s\n", tui.SuccessStyle.Render("+"), f.Path, tui.DimStyle.Render(fmt.Sprintf("(create, %d bytes)", f.Bytes)))
+		case "delete":
+			fmt.Fprintf(w, "    %s %s %s\n", tui.ErrorStyle.Render("-"), f.Path, tui.DimStyle.Render(fmt.Sprintf("(delete, %d bytes)", f.Bytes)))
+		default:
+			fmt.Fprintf(w, "    %s %s %s\n", tui.WarningStyle.Render("~"), f.Path, tui.DimStyle.Render("(update)"))
+			printDiff(w, f.Diff)
+		}
+	}
+	printActions(w, "Git", s.Git)
+	printActions(w, "Cluster", s.Cluster)
+}
+
+// printDiff renders up to maxDiffLines of diff.
+func printDiff(w io.Writer, diff []string) {
+	for i, line := range diff {
+		if i == maxDiffLines {
+			fmt.Fprintf(w, "      %s\n", tui.DimStyle.Render(fmt.Sprintf("… %d more lines", len(diff)-i)))
+			return
+		}
+		switch line[0] {
+		case '-':
+			fmt.Fprintf(w, "      %s\n", tui.ErrorStyle.Render(line))
+		case '+':
+			fmt.Fprintf(w, "      %s\n", tui.SuccessStyle.Render(line))
+		default:
+			fmt.Fprintf(w, "      %s\n", tui.DimStyle.Render(line))
+		}
+	}
+}
+
+func printActions(w io.Writer, title string, actions []string) {
+	if len(actions) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n  %s:\n", title)
+	for _, a := range actions {
+		fmt.Fprintf(w, "    %s %s\n", tui.DimStyle.Render(tui.IconBullet), a)
+	}
+}
@@ -0,0 +1,60 @@
+package onepassword
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+)
+
+// ConfiguredVault returns the vault name or ID that secret checks use: OP_VAULT, then
+// onePassword.vault from config, then the default vault.
+func ConfiguredVault(cfg *config.Config) string {
+	if v := strings.TrimSpace(os.Getenv("OP_VAULT")); v != "" {
+		return v
+	}
+	if cfg.OnePassword.Vault != "" {
+		return cfg.OnePassword.Vault
+	}
+	return config.Default().OnePassword.Vault
+}
+
+// FromConfig builds a Connect client from config. The token is taken from
+// OP_CONNECT_TOKEN, then the config file, then the in-cluster token Secret.
+func FromConfig(cfg *config.Config) (*Client, error) {
+	opCfg := cfg.OnePassword
+	if opCfg.ConnectHost == "" {
+		return nil, fmt.Errorf("onePassword.connectHost not configured")
+	}
+
+	token := os.Getenv("OP_CONNECT_TOKEN")
+	if token == "" {
+		token = opCfg.ConnectToken
+	}
+	if token == "" && opCfg.TokenSecret != "" {
+		ns, name, ok := strings.Cut(opCfg.TokenSecret, "/")
+		if !ok {
+			return nil, fmt.Errorf("onePassword.tokenSecret must be namespace/name, got %q", opCfg.TokenSecret)
+		}
+		client, err := kube.NewClient(cfg.KubeContext)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to cluster for Connect token: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		data, err := client.GetSecretData(ctx, ns, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading Connect token: %w", err)
+		}
+		token = strings.TrimSpace(string(data["token"]))
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no 1Password Connect token (set OP_CONNECT_TOKEN or onePassword.connectToken)")
+	}
+
+	return NewClient(opCfg.ConnectHost, token), nil
+}
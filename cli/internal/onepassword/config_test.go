@@ -0,0 +1,29 @@
+package onepassword
+
+import (
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+)
+
+func TestConfiguredVault(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		config string
+		want   string
+	}{
+		{name: "default", want: "homelab"},
+		{name: "config", config: "homelab-prod", want: "homelab-prod"},
+		{name: "env overrides config", env: "4s2kvm3xq7nqbfcfhkqjvrv5aq", config: "homelab-prod", want: "4s2kvm3xq7nqbfcfhkqjvrv5aq"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OP_VAULT", tt.env)
+			cfg := &config.Config{OnePassword: config.OnePasswordConfig{Vault: tt.config}}
+			if got := ConfiguredVault(cfg); got != tt.want {
+				t.Errorf("ConfiguredVault() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
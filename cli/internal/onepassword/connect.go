@@ -0,0 +1,225 @@
+// Package onepassword is a minimal client for the 1Password Connect API.
+//
+// hctl only reads item metadata (titles and field labels) to pre-flight the
+// items that generated ExternalSecrets reference. Field values are never
+// decoded or stored.
+package onepassword
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// ErrNotFound is returned when a vault or item does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Client talks to a 1Password Connect server.
+type Client struct {
+	host  string
+	token string
+	http  *http.Client
+}
+
+// NewClient creates a Connect API client for the given host and bearer token.
+func NewClient(host, token string) *Client {
+	return &Client{
+		host:  strings.TrimRight(host, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Vault is a 1Password vault summary.
+type Vault struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item is a 1Password item. Only identity and field labels are decoded.
+type Item struct {
+	ID     string  `json:"id"`
+	Title  string  `json:"title"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Field is a single item field. The value is deliberately not decoded.
+type Field struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// HasField returns true if the item has a field whose label or ID matches name.
+func (i *Item) HasField(name string) bool {
+	for _, f := range i.Fields {
+		if f.Label == name || f.ID == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing describes a secret requirement that the vault does not satisfy.
+type Missing struct {
+	// Item is the 1Password item title.
+	Item string `json:"item"`
+	// ItemMissing is true when the whole item is absent.
+	ItemMissing bool `json:"itemMissing"`
+	// Fields lists the absent fields when the item exists.
+	Fields []string `json:"fields,omitempty"`
+}
+
+func (m Missing) String() string {
+	if m.ItemMissing {
+		return fmt.Sprintf("item %q not found", m.Item)
+	}
+	return fmt.Sprintf("item %q missing fields: %s", m.Item, strings.Join(m.Fields, ", "))
+}
+
+// VaultID resolves a vault name or ID to its ID. A value that names no
+// vault is looked up as an ID.
+func (c *Client) VaultID(ctx context.Context, nameOrID string) (string, error) {
+	var vaults []Vault
+	q := url.Values{"filter": {fmt.Sprintf("name eq %q", nameOrID)}}
+	if err := c.get(ctx, "/v1/vaults", q, &vaults); err != nil {
+		return "", fmt.Errorf("listing vaults: %w", err)
+	}
+	for _, v := range vaults {
+		if v.Name == nameOrID {
+			return v.ID, nil
+		}
+	}
+
+	var vault Vault
+	err := c.get(ctx, "/v1/vaults/"+url.PathEscape(nameOrID), nil, &vault)
+	if err == nil && vault.ID != "" {
+		return vault.ID, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("getting vault: %w", err)
+	}
+	return "", fmt.Errorf("vault %q: %w", nameOrID, ErrNotFound)
+}
+
+// GetItemByTitle returns the full item with the given title from a vault.
+func (c *Client) GetItemByTitle(ctx context.Context, vaultID, title string) (*Item, error) {
+	var items []Item
+	q := url.Values{"filter": {fmt.Sprintf("title eq %q", title)}}
+	if err := c.get(ctx, "/v1/vaults/"+url.PathEscape(vaultID)+"/items", q, &items); err != nil {
+		return nil, fmt.Errorf("listing items: %w", err)
+	}
+
+	var id string
+	for _, it := range items {
+		if it.Title == title {
+			id = it.ID
+			break
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("item %q: %w", title, ErrNotFound)
+	}
+
+	var item Item
+	if err := c.get(ctx, "/v1/vaults/"+url.PathEscape(vaultID)+"/items/"+url.PathEscape(id), nil, &item); err != nil {
+		return nil, fmt.Errorf("getting item %q: %w", title, err)
+	}
+	return &item, nil
+}
+
+// Check verifies that every required item and field exists in the named vault.
+// It returns the unsatisfied requirements sorted by item title.
+func (c *Client) Check(ctx context.Context, vault string, reqs []provisioners.SecretRequirement) ([]Missing, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	vaultID, err := c.VaultID(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []Missing
+	for _, req := range reqs {
+		item, err := c.GetItemByTitle(ctx, vaultID, req.Item)
+		if errors.Is(err, ErrNotFound) {
+			missing = append(missing, Missing{Item: req.Item, ItemMissing: true})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var absent []string
+		for _, f := range req.Fields {
+			if !item.HasField(f) {
+				absent = append(absent, f)
+			}
+		}
+		if len(absent) > 0 {
+			missing = append(missing, Missing{Item: req.Item, Fields: absent})
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Item < missing[j].Item })
+	return missing, nil
+}
+
+// WaitFor polls Check until all requirements are satisfied or ctx is done.
+// On timeout it returns the last set of missing requirements with the context error.
+func (c *Client) WaitFor(ctx context.Context, vault string, reqs []provisioners.SecretRequirement, poll time.Duration) ([]Missing, error) {
+	for {
+		missing, err := c.Check(ctx, vault, reqs)
+		if err == nil && len(missing) == 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return nil, err
+			}
+			return missing, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	u := c.host + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("1Password Connect rejected the token (HTTP %d)", resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("1Password Connect returned HTTP %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
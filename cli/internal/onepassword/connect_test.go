@@ -0,0 +1,181 @@
+package onepassword
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// fakeConnect serves a single "homelab" vault containing the given items.
+func fakeConnect(t *testing.T, items []Item) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/vaults", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]Vault{{ID: "v1", Name: "homelab"}})
+	})
+	mux.HandleFunc("/v1/vaults/v1", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Vault{ID: "v1", Name: "homelab"})
+	})
+	mux.HandleFunc("/v1/vaults/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		filter := r.URL.Query().Get("filter")
+		var out []Item
+		for _, it := range items {
+			if strings.Contains(filter, `"`+it.Title+`"`) {
+				out = append(out, Item{ID: it.ID, Title: it.Title})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("/v1/vaults/v1/items/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v1/vaults/v1/items/")
+		for _, it := range items {
+			if it.ID == id {
+				_ = json.NewEncoder(w).Encode(it)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var dbItem = Item{
+	ID:    "i1",
+	Title: "myapp-db-db",
+	Fields: []Field{
+		{ID: "host", Label: "host"},
+		{ID: "port", Label: "port"},
+		{ID: "f3", Label: "password"},
+	},
+}
+
+func TestCheckAllPresent(t *testing.T) {
+	srv := fakeConnect(t, []Item{dbItem})
+	c := NewClient(srv.URL, "test-token")
+
+	missing, err := c.Check(context.Background(), "homelab", []provisioners.SecretRequirement{
+		{Item: "myapp-db-db", Fields: []string{"host", "port", "password"}},
+	})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected nothing missing, got %v", missing)
+	}
+}
+
+func TestCheckMissingItem(t *testing.T) {
+	srv := fakeConnect(t, []Item{dbItem})
+	c := NewClient(srv.URL, "test-token")
+
+	missing, err := c.Check(context.Background(), "homelab", []provisioners.SecretRequirement{
+		{Item: "myapp-db-db", Fields: []string{"host"}},
+		{Item: "myapp-cache-redis", Fields: []string{"password"}},
+	})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(missing) != 1 {
+		t.Fatalf("expected 1 missing, got %v", missing)
+	}
+	if missing[0].Item != "myapp-cache-redis" || !missing[0].ItemMissing {
+		t.Errorf("unexpected missing entry: %+v", missing[0])
+	}
+}
+
+func TestCheckMissingField(t *testing.T) {
+	srv := fakeConnect(t, []Item{dbItem})
+	c := NewClient(srv.URL, "test-token")
+
+	missing, err := c.Check(context.Background(), "homelab", []provisioners.SecretRequirement{
+		{Item: "myapp-db-db", Fields: []string{"host", "username", "database"}},
+	})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(missing) != 1 {
+		t.Fatalf("expected 1 missing, got %v", missing)
+	}
+	m := missing[0]
+	if m.ItemMissing {
+		t.Error("item exists, ItemMissing should be false")
+	}
+	if strings.Join(m.Fields, ",") != "username,database" {
+		t.Errorf("missing fields = %v, want [username database]", m.Fields)
+	}
+}
+
+func TestCheckUnknownVault(t *testing.T) {
+	srv := fakeConnect(t, nil)
+	c := NewClient(srv.URL, "test-token")
+
+	_, err := c.Check(context.Background(), "nope", []provisioners.SecretRequirement{{Item: "x"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestVaultID(t *testing.T) {
+	srv := fakeConnect(t, nil)
+	c := NewClient(srv.URL, "test-token")
+
+	for _, nameOrID := range []string{"homelab", "v1"} {
+		id, err := c.VaultID(context.Background(), nameOrID)
+		if err != nil || id != "v1" {
+			t.Errorf("VaultID(%q) = %q, %v; want v1", nameOrID, id, err)
+		}
+	}
+	if _, err := c.VaultID(context.Background(), "v2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("VaultID(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCheckByVaultID(t *testing.T) {
+	srv := fakeConnect(t, []Item{dbItem})
+	c := NewClient(srv.URL, "test-token")
+
+	missing, err := c.Check(context.Background(), "v1", []provisioners.SecretRequirement{{Item: "myapp-db-db", Fields: []string{"host"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected nothing missing, got %+v", missing)
+	}
+}
+
+func TestCheckBadToken(t *testing.T) {
+	srv := fakeConnect(t, nil)
+	c := NewClient(srv.URL, "wrong")
+
+	if _, err := c.Check(context.Background(), "homelab", []provisioners.SecretRequirement{{Item: "x"}}); err == nil {
+		t.Error("expected error for rejected token")
+	}
+}
+
+func TestWaitForTimesOut(t *testing.T) {
+	srv := fakeConnect(t, nil)
+	c := NewClient(srv.URL, "test-token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	missing, err := c.WaitFor(ctx, "homelab", []provisioners.SecretRequirement{{Item: "late"}}, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if len(missing) != 1 || missing[0].Item != "late" {
+		t.Errorf("expected last missing set to be reported, got %v", missing)
+	}
+}
@@ -0,0 +1,237 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// bootstrapPoll is how often Applier re-checks a step it waits for.
+var bootstrapPoll = 2 * time.Second
+
+// ApplyAction is what applying an object did, or would do in a dry run.
+type ApplyAction string
+
+// Apply actions.
+const (
+	ActionCreated    ApplyAction = "created"
+	ActionConfigured ApplyAction = "configured"
+	ActionUnchanged  ApplyAction = "unchanged"
+	// ActionSkipped is an optional kind the cluster does not serve.
+	ActionSkipped ApplyAction = "skipped"
+)
+
+// ObjectResult is the outcome of applying one bootstrap object.
+type ObjectResult struct {
+	Component string      `json:"component" yaml:"component"`
+	Step      string      `json:"step" yaml:"step"`
+	Kind      string      `json:"kind" yaml:"kind"`
+	Namespace string      `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string      `json:"name" yaml:"name"`
+	Action    ApplyAction `json:"action" yaml:"action"`
+	// Reason says why an object was skipped.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Live and Applied are a configured object before and after the apply,
+	// as YAML without server-managed metadata, for diffs.
+	Live    string `json:"-" yaml:"-"`
+	Applied string `json:"-" yaml:"-"`
+}
+
+// Ref renders the object as Kind/name or Kind/namespace/name.
+func (r ObjectResult) Ref() string {
+	if r.Namespace != "" {
+		return r.Kind + "/" + r.Namespace + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Name
+}
+
+// Applier installs bootstrap components with server-side apply. Objects
+// that already match the repo are left alone, so running it against an
+// installed platform only applies what drifted.
+type Applier struct {
+	Client *kube.Client
+	// Mapper resolves kinds to resources. Nil uses the client's discovery.
+	Mapper meta.RESTMapper
+	// DryRun previews every apply with a server-side dry run. Nothing is
+	// persisted and nothing is waited for.
+	DryRun bool
+	// Wait makes ApplyStep wait for the step to be ready, so each step
+	// only starts once the ones before it are up.
+	Wait bool
+	// Timeouts bound the waits of each component, by name. Components
+	// without one use their default.
+	Timeouts map[string]time.Duration
+
+	// newNamespaces are namespaces a dry run would create; objects in them
+	// cannot be dry-run against the server.
+	newNamespaces map[string]bool
+}
+
+// Timeout returns how long the waits of a component may take in total.
+func (a *Applier) Timeout(component string) time.Duration {
+	if d, ok := a.Timeouts[component]; ok && d > 0 {
+		return d
+	}
+	return componentTimeouts[component]
+}
+
+// Apply applies every step of components in order and returns what each
+// object did, stopping at the first failure.
+func (a *Applier) Apply(ctx context.Context, components []BootstrapComponent) ([]ObjectResult, error) {
+	var all []ObjectResult
+	for _, c := range components {
+		deadline := time.Now().Add(a.Timeout(c.Name))
+		for _, step := range c.Steps {
+			results, _, err := a.ApplyStep(ctx, c.Name, step, deadline)
+			all = append(all, results...)
+			if err != nil {
+				return all, err
+			}
+		}
+	}
+	return all, nil
+}
+
+// ApplyStep applies the objects of one step that differ from the cluster,
+// then, with Wait, polls until the step is ready or deadline passes. The
+// returned detail summarizes the step.
+func (a *Applier) ApplyStep(ctx context.Context, component string, step BootstrapStep, deadline time.Time) ([]ObjectResult, string, error) {
+	mapper := a.mapper()
+	var results []ObjectResult
+	counts := map[ApplyAction]int{}
+	installsKinds := false
+	for _, obj := range step.Objects {
+		res, err := a.applyObject(ctx, mapper, obj)
+		res.Component, res.Step = component, step.Name
+		if err != nil {
+			return results, "", err
+		}
+		results = append(results, res)
+		counts[res.Action]++
+		if kind := obj.GetKind(); res.Action != ActionUnchanged && (kind == "CustomResourceDefinition" || kind == "Promise") {
+			installsKinds = true
+		}
+	}
+	if r, ok := mapper.(meta.ResettableRESTMapper); ok && installsKinds && !a.DryRun {
+		r.Reset()
+	}
+
+	detail := fmt.Sprintf("%d created, %d configured, %d unchanged", counts[ActionCreated], counts[ActionConfigured], counts[ActionUnchanged])
+	if n := counts[ActionSkipped]; n > 0 {
+		detail += fmt.Sprintf(", %d skipped", n)
+	}
+	if a.DryRun || !a.Wait || step.Ready == nil {
+		return results, detail, nil
+	}
+	ready, err := a.wait(ctx, step, deadline)
+	if err != nil {
+		return results, "", err
+	}
+	return results, detail + "; " + ready, nil
+}
+
+func (a *Applier) mapper() meta.RESTMapper {
+	if a.Mapper == nil {
+		a.Mapper = a.Client.ResourceMapper()
+	}
+	return a.Mapper
+}
+
+// applyObject dry-runs obj against the cluster to see whether it changes
+// anything, then applies it for real when it does.
+func (a *Applier) applyObject(ctx context.Context, mapper meta.RESTMapper, obj *unstructured.Unstructured) (ObjectResult, error) {
+	res := ObjectResult{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if optionalKinds[gvk.GroupKind()] {
+			res.Action, res.Reason = ActionSkipped, "the cluster does not serve "+gvk.GroupKind().String()
+			return res, nil
+		}
+		return res, hcerrors.New(hcerrors.ErrValidation, "the cluster does not serve %s, needed by %s", gvk.GroupKind(), res.Ref()).
+			WithRemediation("install the controller that provides it, e.g. Kratix for promises, then rerun")
+	}
+	if err != nil {
+		return res, fmt.Errorf("resolving %s: %w", gvk.Kind, err)
+	}
+	if a.DryRun && a.newNamespaces[obj.GetNamespace()] {
+		// Its namespace does not exist yet, so the server cannot dry-run it.
+		res.Action = ActionCreated
+		return res, nil
+	}
+
+	before, after, err := a.Client.ApplyObject(ctx, mapping, obj, true)
+	if err != nil {
+		return res, fmt.Errorf("applying %s: %w", res.Ref(), err)
+	}
+	switch {
+	case before == nil:
+		res.Action = ActionCreated
+		if a.DryRun && obj.GetKind() == "Namespace" {
+			if a.newNamespaces == nil {
+				a.newNamespaces = map[string]bool{}
+			}
+			a.newNamespaces[obj.GetName()] = true
+		}
+	case reflect.DeepEqual(withoutServerFields(before), withoutServerFields(after)):
+		res.Action = ActionUnchanged
+		return res, nil
+	default:
+		res.Action = ActionConfigured
+		res.Live, res.Applied = toYAML(withoutServerFields(before)), toYAML(withoutServerFields(after))
+	}
+	if a.DryRun {
+		return res, nil
+	}
+	if _, _, err := a.Client.ApplyObject(ctx, mapping, obj, false); err != nil {
+		return res, fmt.Errorf("applying %s: %w", res.Ref(), err)
+	}
+	return res, nil
+}
+
+// wait polls step.Ready until it reports ready or deadline passes.
+func (a *Applier) wait(ctx context.Context, step BootstrapStep, deadline time.Time) (string, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	for {
+		ready, detail, err := step.Ready(ctx, a.Client)
+		if err == nil && ready {
+			return detail, nil
+		}
+		if err != nil {
+			detail = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return "", hcerrors.NewTimeoutError("timed out waiting for %s: %s", step.Name, detail).
+				WithRemediation("raise the component's --timeout, or check its controller's logs")
+		case <-time.After(bootstrapPoll):
+		}
+	}
+}
+
+// withoutServerFields strips what the server manages from obj, leaving what an
+// apply can change.
+func withoutServerFields(obj *unstructured.Unstructured) map[string]interface{} {
+	c := obj.DeepCopy()
+	for _, f := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(c.Object, "metadata", f)
+	}
+	delete(c.Object, "status")
+	return c.Object
+}
+
+func toYAML(obj map[string]interface{}) string {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Sprint(obj)
+	}
+	return string(data)
+}
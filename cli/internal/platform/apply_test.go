@@ -0,0 +1,267 @@
+package platform_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+const reconcilerManifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: psr
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: psr
+  namespace: psr
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: psr
+  template:
+    metadata:
+      labels:
+        app: psr
+    spec:
+      containers:
+        - name: psr
+          image: psr:1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: psr
+  namespace: psr
+data:
+  interval: 30s
+---
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: psr
+  namespace: psr
+spec: {}
+`
+
+func bootstrapRepo(t *testing.T, reconciler string) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"promises/db/promise.yaml":         "apiVersion: platform.kratix.io/v1alpha1\nkind: Promise\nmetadata:\n  name: db\nspec:\n  destinationSelectors: []\n",
+		"promises/app/promise.yaml":        "apiVersion: platform.kratix.io/v1alpha1\nkind: Promise\nmetadata:\n  name: app\nspec:\n  requiredPromises:\n    - name: db\n",
+		layout.ReconcilerDir + "/all.yaml": reconciler,
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func actions(results []platform.ObjectResult) string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Ref()+"="+string(r.Action))
+	}
+	return strings.Join(out, " ")
+}
+
+// setStatus writes status onto a live object, standing in for its
+// controller.
+func setStatus(t *testing.T, cluster *testutil.Cluster, gvr schema.GroupVersionResource, namespace, name string, status map[string]interface{}) {
+	t.Helper()
+	obj := cluster.Get(gvr, namespace, name)
+	obj.Object["status"] = status
+	if _, err := cluster.Client.Dynamic.Resource(gvr).Namespace(namespace).UpdateStatus(context.Background(), obj, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplierInstallsAndUpgrades(t *testing.T) {
+	cluster := testutil.NewCluster(t)
+	root := bootstrapRepo(t, reconcilerManifests)
+	components, err := platform.LoadBootstrap(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	a := &platform.Applier{Client: cluster.Client}
+	results, err := a.Apply(ctx, components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Promise/db=created Promise/app=created Namespace/psr=created ConfigMap/psr/psr=created Deployment/psr/psr=created ServiceMonitor/psr/psr=skipped"
+	if got := actions(results); got != want {
+		t.Errorf("first run:\n got %s\nwant %s", got, want)
+	}
+	cluster.Get(deploymentsGVR, "psr", "psr")
+
+	// A rerun against an up-to-date cluster changes nothing.
+	results, err = (&platform.Applier{Client: cluster.Client}).Apply(ctx, components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := actions(results); strings.Contains(got, "=created") || strings.Contains(got, "=configured") {
+		t.Errorf("rerun: %s, want everything unchanged", got)
+	}
+
+	// Only the object that drifted from the repo is applied.
+	drifted := bootstrapRepo(t, strings.Replace(reconcilerManifests, "interval: 30s", "interval: 1m", 1))
+	components, err = platform.LoadBootstrap(drifted, []string{platform.ComponentReconciler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = (&platform.Applier{Client: cluster.Client}).Apply(ctx, components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "Namespace/psr=unchanged ConfigMap/psr/psr=configured Deployment/psr/psr=unchanged ServiceMonitor/psr/psr=skipped"
+	if got := actions(results); got != want {
+		t.Errorf("upgrade:\n got %s\nwant %s", got, want)
+	}
+	if v, _, _ := unstructured.NestedString(cluster.Get(configMapsGVR, "psr", "psr").Object, "data", "interval"); v != "1m" {
+		t.Errorf("configmap interval = %q, want 1m", v)
+	}
+}
+
+func TestApplierWaits(t *testing.T) {
+	cluster := testutil.NewCluster(t)
+	components, err := platform.LoadBootstrap(bootstrapRepo(t, reconcilerManifests), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Kratix has not reported the first promise Available, so the wait
+	// times out before the promise that requires it is applied.
+	a := &platform.Applier{Client: cluster.Client}
+	a.Wait = true
+	a.Timeouts = map[string]time.Duration{platform.ComponentPromises: 50 * time.Millisecond}
+	results, err := a.Apply(ctx, components)
+	if !errors.Is(err, hcerrors.ErrTimeout) || !strings.Contains(err.Error(), "promise db unknown") {
+		t.Fatalf("err = %v, want a timeout on promise db", err)
+	}
+	if got := actions(results); got != "Promise/db=created" {
+		t.Errorf("applied %s before the timeout, want only Promise/db", got)
+	}
+
+	available := map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Available", "status": "True"},
+	}}
+	setStatus(t, cluster, kube.KratixPromiseGVR, "", "db", available)
+	_, _, err = a.ApplyStep(ctx, platform.ComponentPromises, components[0].Steps[1], time.Now().Add(50*time.Millisecond))
+	if !errors.Is(err, hcerrors.ErrTimeout) {
+		t.Fatalf("promise app: err = %v, want a timeout", err)
+	}
+	setStatus(t, cluster, kube.KratixPromiseGVR, "", "app", available)
+
+	// The reconciler is ready once its Deployment has rolled out.
+	a.Timeouts = map[string]time.Duration{platform.ComponentReconciler: 50 * time.Millisecond}
+	if _, err := a.Apply(ctx, components); !errors.Is(err, hcerrors.ErrTimeout) || !strings.Contains(err.Error(), "deployment psr 0/1 ready") {
+		t.Fatalf("err = %v, want a timeout on the reconciler rollout", err)
+	}
+	dep := cluster.Get(deploymentsGVR, "psr", "psr")
+	setStatus(t, cluster, deploymentsGVR, "psr", "psr", map[string]interface{}{
+		"observedGeneration": dep.GetGeneration(),
+		"replicas":           int64(1),
+		"updatedReplicas":    int64(1),
+		"readyReplicas":      int64(1),
+		"availableReplicas":  int64(1),
+	})
+	_, detail, err := a.ApplyStep(ctx, platform.ComponentReconciler, components[1].Steps[0], time.Now().Add(a.Timeout(platform.ComponentReconciler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 created, 0 configured, 3 unchanged, 1 skipped; rolled out"; detail != want {
+		t.Errorf("detail = %q, want %q", detail, want)
+	}
+}
+
+func TestApplierDryRun(t *testing.T) {
+	cluster := testutil.NewCluster(t)
+	root := bootstrapRepo(t, reconcilerManifests)
+	components, err := platform.LoadBootstrap(root, []string{platform.ComponentReconciler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Against an empty cluster everything would be created, including the
+	// objects in a namespace that does not exist yet, and nothing is.
+	a := &platform.Applier{Client: cluster.Client}
+	a.DryRun = true
+	results, err := a.Apply(ctx, components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Namespace/psr=created ConfigMap/psr/psr=created Deployment/psr/psr=created ServiceMonitor/psr/psr=skipped"
+	if got := actions(results); got != want {
+		t.Errorf("dry run:\n got %s\nwant %s", got, want)
+	}
+	if _, err := cluster.Client.Dynamic.Resource(deploymentsGVR).Namespace("psr").Get(ctx, "psr", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("dry run created the deployment: err = %v", err)
+	}
+}
+
+func TestApplierDiff(t *testing.T) {
+	cluster := testutil.NewCluster(t)
+	components, err := platform.LoadBootstrap(bootstrapRepo(t, reconcilerManifests), []string{platform.ComponentReconciler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := (&platform.Applier{Client: cluster.Client}).Apply(ctx, components); err != nil {
+		t.Fatal(err)
+	}
+
+	drifted := bootstrapRepo(t, strings.Replace(reconcilerManifests, "interval: 30s", "interval: 1m", 1))
+	components, err = platform.LoadBootstrap(drifted, []string{platform.ComponentReconciler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &platform.Applier{Client: cluster.Client}
+	a.DryRun = true
+	results, err := a.Apply(ctx, components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Kind != "ConfigMap" {
+			continue
+		}
+		if r.Action != platform.ActionConfigured || !strings.Contains(r.Live, "interval: 30s") || !strings.Contains(r.Applied, "interval: 1m") {
+			t.Errorf("configmap = %s, live:\n%s\napplied:\n%s", r.Action, r.Live, r.Applied)
+		}
+	}
+	if v, _, _ := unstructured.NestedString(cluster.Get(configMapsGVR, "psr", "psr").Object, "data", "interval"); v != "30s" {
+		t.Errorf("dry run changed the configmap to %q", v)
+	}
+}
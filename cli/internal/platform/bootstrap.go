@@ -0,0 +1,331 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Bootstrap components, in the order they are applied.
+const (
+	// ComponentPromises is the Kratix promises under promises/.
+	ComponentPromises = "promises"
+	// ComponentReconciler is the platform-status-reconciler and its RBAC.
+	ComponentReconciler = "reconciler"
+)
+
+// BootstrapComponents lists the components in the order they are applied.
+var BootstrapComponents = []string{ComponentPromises, ComponentReconciler}
+
+// Default waits of each component, overridden by Applier.Timeouts.
+var componentTimeouts = map[string]time.Duration{
+	ComponentPromises:   10 * time.Minute,
+	ComponentReconciler: 5 * time.Minute,
+}
+
+// kindOrder is the order objects of a step are applied in: namespaces and
+// CRDs before what lives in or uses them, RBAC before the workloads that
+// need it. Kinds not listed follow, in file order.
+var kindOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"Promise",
+	"ServiceAccount",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"ConfigMap",
+	"Secret",
+	"Service",
+	"Deployment",
+}
+
+// optionalKinds are applied only when the cluster serves them: the
+// monitoring CRDs come with kube-prometheus-stack, which a new cluster may
+// not have yet.
+var optionalKinds = map[schema.GroupKind]bool{
+	{Group: "monitoring.coreos.com", Kind: "ServiceMonitor"}: true,
+	{Group: "monitoring.coreos.com", Kind: "PrometheusRule"}: true,
+	{Group: "monitoring.coreos.com", Kind: "PodMonitor"}:     true,
+}
+
+// BootstrapStep is a set of objects applied together, then waited for
+// before the next step starts.
+type BootstrapStep struct {
+	// Name identifies the step, e.g. the promise it installs.
+	Name    string
+	Objects []*unstructured.Unstructured
+	// Ready reports whether the applied objects are ready, with a short
+	// detail of what is still pending. Nil means ready once applied.
+	Ready func(ctx context.Context, client *kube.Client) (bool, string, error)
+}
+
+// BootstrapComponent is one part of the platform bootstrap.
+type BootstrapComponent struct {
+	Name  string
+	Steps []BootstrapStep
+}
+
+// LoadBootstrap reads the components named in only, or all of them when
+// only is empty, from the repo, with their steps in dependency order.
+func LoadBootstrap(repoPath string, only []string) ([]BootstrapComponent, error) {
+	for _, name := range only {
+		if !contains(BootstrapComponents, name) {
+			return nil, hcerrors.NewUserError("unknown component %q (known: %s)", name, strings.Join(BootstrapComponents, ", "))
+		}
+	}
+	var components []BootstrapComponent
+	for _, name := range BootstrapComponents {
+		if len(only) > 0 && !contains(only, name) {
+			continue
+		}
+		var c BootstrapComponent
+		var err error
+		switch name {
+		case ComponentPromises:
+			c, err = loadPromises(repoPath)
+		case ComponentReconciler:
+			c, err = loadReconciler(repoPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// loadPromises reads promises/*/promise.yaml, one step per promise so each
+// is Available before the promises that build on it are applied.
+func loadPromises(repoPath string) (BootstrapComponent, error) {
+	paths, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.PromisesDir), "*", "promise.yaml"))
+	if err != nil {
+		return BootstrapComponent{}, err
+	}
+	var promises []*unstructured.Unstructured
+	for _, path := range paths {
+		objs, err := readManifests(repoPath, path)
+		if err != nil {
+			return BootstrapComponent{}, err
+		}
+		promises = append(promises, objs...)
+	}
+	if len(promises) == 0 {
+		return BootstrapComponent{}, hcerrors.New(hcerrors.ErrNotFound, "no promises found under %s", layout.PromisesDir)
+	}
+	ordered, err := orderPromises(promises)
+	if err != nil {
+		return BootstrapComponent{}, err
+	}
+	c := BootstrapComponent{Name: ComponentPromises}
+	for _, p := range ordered {
+		name := p.GetName()
+		c.Steps = append(c.Steps, BootstrapStep{
+			Name:    name,
+			Objects: []*unstructured.Unstructured{p},
+			Ready: func(ctx context.Context, client *kube.Client) (bool, string, error) {
+				live, err := client.Dynamic.Resource(kube.KratixPromiseGVR).Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					return false, "", err
+				}
+				status := PromiseStatus(live.Object)
+				return status == PromiseAvailable, "promise " + name + " " + strings.ToLower(status), nil
+			},
+		})
+	}
+	return c, nil
+}
+
+// loadReconciler reads the reconciler manifests as one step, ready once
+// its Deployments have rolled out.
+func loadReconciler(repoPath string) (BootstrapComponent, error) {
+	paths, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.ReconcilerDir), "*.yaml"))
+	if err != nil {
+		return BootstrapComponent{}, err
+	}
+	sort.Strings(paths)
+	var objs []*unstructured.Unstructured
+	for _, path := range paths {
+		docs, err := readManifests(repoPath, path)
+		if err != nil {
+			return BootstrapComponent{}, err
+		}
+		objs = append(objs, docs...)
+	}
+	if len(objs) == 0 {
+		return BootstrapComponent{}, hcerrors.New(hcerrors.ErrNotFound, "no reconciler manifests found under %s", layout.ReconcilerDir)
+	}
+	orderObjects(objs)
+
+	var deployments []*unstructured.Unstructured
+	for _, o := range objs {
+		if o.GetKind() == "Deployment" {
+			deployments = append(deployments, o)
+		}
+	}
+	return BootstrapComponent{Name: ComponentReconciler, Steps: []BootstrapStep{{
+		Name:    "platform-status-reconciler",
+		Objects: objs,
+		Ready: func(ctx context.Context, client *kube.Client) (bool, string, error) {
+			for _, d := range deployments {
+				live, err := client.Dynamic.Resource(appsv1.SchemeGroupVersion.WithResource("deployments")).
+					Namespace(d.GetNamespace()).Get(ctx, d.GetName(), metav1.GetOptions{})
+				if err != nil {
+					return false, "", err
+				}
+				var typed appsv1.Deployment
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, &typed); err != nil {
+					return false, "", err
+				}
+				if r := kube.DeploymentRollout(&typed); !r.Done() {
+					return false, fmt.Sprintf("deployment %s %d/%d ready", d.GetName(), r.Ready, r.Desired), nil
+				}
+			}
+			return true, "rolled out", nil
+		},
+	}}}, nil
+}
+
+// readManifests decodes every non-empty document of a YAML file.
+func readManifests(repoPath, path string) ([]*unstructured.Unstructured, error) {
+	rel, err := repopath.Rel(repoPath, path)
+	if err != nil {
+		rel = path
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	var objs []*unstructured.Unstructured
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := dec.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", rel, err)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		if u.GetKind() == "" || u.GetName() == "" {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: a document has no kind or metadata.name", rel)
+		}
+		objs = append(objs, u)
+	}
+}
+
+// orderObjects sorts objects by kindOrder, keeping file order otherwise.
+func orderObjects(objs []*unstructured.Unstructured) {
+	rank := func(kind string) int {
+		for i, k := range kindOrder {
+			if k == kind {
+				return i
+			}
+		}
+		return len(kindOrder)
+	}
+	sort.SliceStable(objs, func(i, j int) bool { return rank(objs[i].GetKind()) < rank(objs[j].GetKind()) })
+}
+
+// orderPromises sorts promises so each comes after the promises its
+// spec.requiredPromises names, platform promises before the product
+// promises composed from them, then by name.
+func orderPromises(promises []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	byName := map[string]*unstructured.Unstructured{}
+	for _, p := range promises {
+		byName[p.GetName()] = p
+	}
+	sorted := append([]*unstructured.Unstructured(nil), promises...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := sorted[i].GetLabels()["kratix.io/promise-type"] == "product", sorted[j].GetLabels()["kratix.io/promise-type"] == "product"
+		if pi != pj {
+			return pj
+		}
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	var out []*unstructured.Unstructured
+	state := map[string]int{} // 1 visiting, 2 done
+	var visit func(p *unstructured.Unstructured, path []string) error
+	visit = func(p *unstructured.Unstructured, path []string) error {
+		name := p.GetName()
+		switch state[name] {
+		case 2:
+			return nil
+		case 1:
+			return hcerrors.New(hcerrors.ErrValidation, "promises require each other: %s", strings.Join(append(path, name), " → "))
+		}
+		state[name] = 1
+		required, _, _ := unstructured.NestedSlice(p.Object, "spec", "requiredPromises")
+		for _, r := range required {
+			m, _ := r.(map[string]interface{})
+			dep, _ := m["name"].(string)
+			if d, ok := byName[dep]; ok {
+				if err := visit(d, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = 2
+		out = append(out, p)
+		return nil
+	}
+	for _, p := range sorted {
+		if err := visit(p, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Promise statuses reported by PromiseStatus.
+const (
+	PromiseAvailable   = "Available"
+	PromiseUnavailable = "Unavailable"
+	PromiseUnknown     = "Unknown"
+)
+
+// PromiseStatus reads a Promise's Available condition: PromiseAvailable,
+// PromiseUnavailable, or PromiseUnknown before Kratix has reported one.
+func PromiseStatus(obj map[string]interface{}) string {
+	conditions, _, _ := UnstructuredNestedSlice(obj, "status", "conditions")
+	for _, c := range conditions {
+		if cm, ok := c.(map[string]interface{}); ok && cm["type"] == "Available" {
+			if cm["status"] == "True" {
+				return PromiseAvailable
+			}
+			return PromiseUnavailable
+		}
+	}
+	return PromiseUnknown
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+)
+
+func writeBootstrapRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func promiseYAML(name, promiseType string, requires ...string) string {
+	s := "apiVersion: platform.kratix.io/v1alpha1\nkind: Promise\nmetadata:\n  name: " + name +
+		"\n  labels:\n    kratix.io/promise-type: " + promiseType + "\nspec:\n"
+	if len(requires) == 0 {
+		return s + "  destinationSelectors: []\n"
+	}
+	s += "  requiredPromises:\n"
+	for _, r := range requires {
+		s += "    - name: " + r + "\n      version: v1alpha1\n"
+	}
+	return s
+}
+
+func stepNames(c BootstrapComponent) []string {
+	var names []string
+	for _, s := range c.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestLoadBootstrapOrder(t *testing.T) {
+	root := writeBootstrapRepo(t, map[string]string{
+		"promises/web-app/promise.yaml":    promiseYAML("web-app", "product", "gateway", "database"),
+		"promises/gateway/promise.yaml":    promiseYAML("gateway", "platform"),
+		"promises/database/promise.yaml":   promiseYAML("database", "platform", "secrets"),
+		"promises/secrets/promise.yaml":    promiseYAML("secrets", "platform"),
+		"promises/analytics/promise.yaml":  promiseYAML("analytics", "product"),
+		layout.ReconcilerDir + "/all.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: r\n  namespace: ns\n---\napiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: r\n  namespace: ns\n---\n",
+		layout.ReconcilerDir + "/ns.yaml":  "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns\n",
+	})
+
+	components, err := LoadBootstrap(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 2 || components[0].Name != ComponentPromises || components[1].Name != ComponentReconciler {
+		t.Fatalf("components = %+v, want promises then reconciler", components)
+	}
+	// Platform promises by name, each after what it requires, then products.
+	if got, want := strings.Join(stepNames(components[0]), ","), "secrets,database,gateway,analytics,web-app"; got != want {
+		t.Errorf("promise order = %s, want %s", got, want)
+	}
+	var kinds []string
+	for _, o := range components[1].Steps[0].Objects {
+		kinds = append(kinds, o.GetKind())
+	}
+	if got, want := strings.Join(kinds, ","), "Namespace,ServiceAccount,Deployment"; got != want {
+		t.Errorf("reconciler kinds = %s, want %s", got, want)
+	}
+
+	only, err := LoadBootstrap(root, []string{ComponentReconciler})
+	if err != nil || len(only) != 1 || only[0].Name != ComponentReconciler {
+		t.Errorf("--only reconciler = %+v, %v", only, err)
+	}
+	if _, err := LoadBootstrap(root, []string{"crds"}); err == nil || !strings.Contains(err.Error(), `unknown component "crds"`) {
+		t.Errorf("unknown component: err = %v", err)
+	}
+}
+
+func TestLoadBootstrapErrors(t *testing.T) {
+	cycle := writeBootstrapRepo(t, map[string]string{
+		"promises/a/promise.yaml": promiseYAML("a", "platform", "b"),
+		"promises/b/promise.yaml": promiseYAML("b", "platform", "a"),
+	})
+	if _, err := LoadBootstrap(cycle, []string{ComponentPromises}); err == nil || !strings.Contains(err.Error(), "a → b → a") {
+		t.Errorf("cycle: err = %v, want the cycle named", err)
+	}
+
+	unnamed := writeBootstrapRepo(t, map[string]string{
+		"promises/a/promise.yaml": "apiVersion: platform.kratix.io/v1alpha1\nkind: Promise\n",
+	})
+	if _, err := LoadBootstrap(unnamed, []string{ComponentPromises}); err == nil || !strings.Contains(err.Error(), "promises/a/promise.yaml") {
+		t.Errorf("document without a name: err = %v, want the file named", err)
+	}
+
+	if _, err := LoadBootstrap(t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "no promises found") {
+		t.Errorf("empty repo: err = %v", err)
+	}
+}
+
+// TestLoadBootstrapRepo loads the repo's own promises and reconciler.
+func TestLoadBootstrapRepo(t *testing.T) {
+	components, err := LoadBootstrap(filepath.Join("..", "..", ".."), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := stepNames(components[0])
+	if len(names) == 0 || names[len(names)-1] != "http-service" {
+		t.Errorf("promise order = %v, want the http-service product promise last", names)
+	}
+	objs := components[1].Steps[0].Objects
+	if objs[0].GetKind() != "Namespace" || objs[len(objs)-1].GetKind() == "Namespace" {
+		t.Errorf("reconciler objects start with %s, want the Namespace first", objs[0].GetKind())
+	}
+}
+
+func TestPromiseStatus(t *testing.T) {
+	condition := func(status string) map[string]interface{} {
+		return map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Reconciled", "status": "True"},
+			map[string]interface{}{"type": "Available", "status": status},
+		}}}
+	}
+	for _, tc := range []struct {
+		obj  map[string]interface{}
+		want string
+	}{
+		{condition("True"), PromiseAvailable},
+		{condition("False"), PromiseUnavailable},
+		{map[string]interface{}{}, PromiseUnknown},
+	} {
+		if got := PromiseStatus(tc.obj); got != tc.want {
+			t.Errorf("PromiseStatus(%v) = %s, want %s", tc.obj, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HostCluster is the Cluster of the host row in a CapacityReport.
+const HostCluster = "host"
+
+// Resources is an amount of CPU and memory.
+type Resources struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// Add adds o to r.
+func (r *Resources) Add(o Resources) {
+	r.CPU.Add(o.CPU)
+	r.Memory.Add(o.Memory)
+}
+
+// resourcesJSON is how Resources appear in structured output: whole
+// millicores and bytes, for dashboards.
+type resourcesJSON struct {
+	CPUMillis   int64 `json:"cpuMillis" yaml:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes" yaml:"memoryBytes"`
+}
+
+func (r Resources) out() resourcesJSON {
+	return resourcesJSON{CPUMillis: r.CPU.MilliValue(), MemoryBytes: r.Memory.Value()}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Resources) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.out())
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (r Resources) MarshalYAML() (interface{}, error) {
+	return r.out(), nil
+}
+
+// Headroom is the percentage of allocatable CPU and memory left after
+// requests. A nil field means it cannot be computed: nothing is allocatable.
+type Headroom struct {
+	CPU    *float64 `json:"cpuPercent" yaml:"cpuPercent"`
+	Memory *float64 `json:"memoryPercent" yaml:"memoryPercent"`
+}
+
+// Min returns the lower of the two percentages, or false when neither is
+// known.
+func (h Headroom) Min() (float64, bool) {
+	switch {
+	case h.CPU == nil && h.Memory == nil:
+		return 0, false
+	case h.CPU == nil:
+		return *h.Memory, true
+	case h.Memory == nil:
+		return *h.CPU, true
+	}
+	return min(*h.CPU, *h.Memory), true
+}
+
+// ComputeHeadroom returns the share of allocatable that used leaves free,
+// in percent. It is negative when used exceeds allocatable.
+func ComputeHeadroom(allocatable, used Resources) Headroom {
+	percent := func(alloc, used int64) *float64 {
+		if alloc <= 0 {
+			return nil
+		}
+		p := float64(alloc-used) / float64(alloc) * 100
+		return &p
+	}
+	return Headroom{
+		CPU:    percent(allocatable.CPU.MilliValue(), used.CPU.MilliValue()),
+		Memory: percent(allocatable.Memory.Value(), used.Memory.Value()),
+	}
+}
+
+// PodTotals sums the requests and limits of pods that hold resources on
+// their node, skipping Succeeded and Failed ones.
+func PodTotals(pods []kube.PodResourceInfo) (requests, limits Resources, count int) {
+	for _, p := range pods {
+		if p.Phase == "Succeeded" || p.Phase == "Failed" {
+			continue
+		}
+		requests.Add(Resources{CPU: p.CPURequest, Memory: p.MemoryRequest})
+		limits.Add(Resources{CPU: p.CPULimit, Memory: p.MemoryLimit})
+		count++
+	}
+	return requests, limits, count
+}
+
+// ControlPlaneResources returns the requests and limits of a vCluster's
+// control-plane StatefulSet: the per-replica sizing times the replicas.
+func ControlPlaneResources(s Sizing) (requests, limits Resources, err error) {
+	parse := func(field, v string) (resource.Quantity, error) {
+		if v == "" {
+			return resource.Quantity{}, nil
+		}
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return q, fmt.Errorf("%s %q: %w", field, v, err)
+		}
+		var total resource.Quantity
+		for range s.Replicas {
+			total.Add(q)
+		}
+		return total, nil
+	}
+	if requests.CPU, err = parse("cpu", s.CPU); err != nil {
+		return
+	}
+	if requests.Memory, err = parse("memory", s.Memory); err != nil {
+		return
+	}
+	if limits.CPU, err = parse("cpu limit", s.CPULimit); err != nil {
+		return
+	}
+	limits.Memory, err = parse("memory limit", s.MemoryLimit)
+	return
+}
+
+// CapacityRow is the capacity of the host cluster or of one vCluster.
+// vClusters schedule onto the host's nodes, so a vCluster's Allocatable is
+// the host's and its Headroom is what its own requests, control plane
+// included, leave free; the host row's Headroom accounts for every pod.
+type CapacityRow struct {
+	Cluster string `json:"cluster" yaml:"cluster"`
+	// Namespace is the host namespace a vCluster's pods run in.
+	Namespace   string    `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Allocatable Resources `json:"allocatable" yaml:"allocatable"`
+	// Requests and Limits add up the pods; for a vCluster, its synced
+	// workload pods without the control plane.
+	Requests Resources `json:"requests" yaml:"requests"`
+	Limits   Resources `json:"limits" yaml:"limits"`
+	// ControlPlane is a vCluster's control-plane requests, from its spec.
+	ControlPlane *Resources `json:"controlPlane,omitempty" yaml:"controlPlane,omitempty"`
+	Pods         int        `json:"pods" yaml:"pods"`
+	Headroom     Headroom   `json:"headroom" yaml:"headroom"`
+	// Unknown lists namespaces whose pods could not be read; their requests
+	// are missing from the totals.
+	Unknown []string `json:"unknownNamespaces,omitempty" yaml:"unknownNamespaces,omitempty"`
+	// Warning is set when Headroom is below the report's threshold.
+	Warning bool `json:"warning" yaml:"warning"`
+}
+
+// Used is what the row takes out of Allocatable: its pods' requests plus
+// its control plane.
+func (r CapacityRow) Used() Resources {
+	used := Resources{CPU: r.Requests.CPU.DeepCopy(), Memory: r.Requests.Memory.DeepCopy()}
+	if r.ControlPlane != nil {
+		used.Add(*r.ControlPlane)
+	}
+	return used
+}
+
+// CapacityReport is the output of 'hctl report capacity'.
+type CapacityReport struct {
+	// WarnBelow is the headroom percentage under which a row is flagged.
+	WarnBelow float64 `json:"warnBelowPercent" yaml:"warnBelowPercent"`
+	// Rows holds the host first, then vClusters by headroom, lowest first.
+	Rows []CapacityRow `json:"clusters" yaml:"clusters"`
+}
+
+// Finish computes each row's headroom and warning and sorts the vCluster
+// rows by headroom, lowest first; rows whose headroom is unknown go last.
+func (r *CapacityReport) Finish() {
+	for i := range r.Rows {
+		row := &r.Rows[i]
+		row.Headroom = ComputeHeadroom(row.Allocatable, row.Used())
+		if m, ok := row.Headroom.Min(); ok && m < r.WarnBelow {
+			row.Warning = true
+		}
+	}
+	rest := r.Rows
+	if len(rest) > 0 && rest[0].Cluster == HostCluster {
+		rest = rest[1:]
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		a, aok := rest[i].Headroom.Min()
+		b, bok := rest[j].Headroom.Min()
+		if aok != bok {
+			return aok
+		}
+		if a != b {
+			return a < b
+		}
+		return rest[i].Cluster < rest[j].Cluster
+	})
+}
+
+// WorkloadCapacity is one workload's share of a cluster in the
+// --by-workload drill-down.
+type WorkloadCapacity struct {
+	// Workload is the hctl.integratn.tech/workload label; empty for pods
+	// hctl did not deploy.
+	Workload string    `json:"workload" yaml:"workload"`
+	Pods     int       `json:"pods" yaml:"pods"`
+	Requests Resources `json:"requests" yaml:"requests"`
+	Limits   Resources `json:"limits" yaml:"limits"`
+	// CPUShare and MemoryShare are the workload's requests as a percentage
+	// of the cluster's allocatable; nil when nothing is allocatable.
+	CPUShare    *float64 `json:"cpuSharePercent" yaml:"cpuSharePercent"`
+	MemoryShare *float64 `json:"memorySharePercent" yaml:"memorySharePercent"`
+}
+
+// ByWorkload groups pods by their workload label and sizes each group
+// against allocatable. Groups are sorted by CPU then memory requests,
+// largest first.
+func ByWorkload(pods []kube.PodResourceInfo, allocatable Resources) []WorkloadCapacity {
+	groups := map[string][]kube.PodResourceInfo{}
+	for _, p := range pods {
+		name := p.Labels[translate.WorkloadLabel]
+		groups[name] = append(groups[name], p)
+	}
+	var out []WorkloadCapacity
+	for name, group := range groups {
+		requests, limits, n := PodTotals(group)
+		if n == 0 {
+			continue
+		}
+		h := ComputeHeadroom(allocatable, requests)
+		w := WorkloadCapacity{Workload: name, Pods: n, Requests: requests, Limits: limits}
+		if h.CPU != nil {
+			share := 100 - *h.CPU
+			w.CPUShare = &share
+		}
+		if h.Memory != nil {
+			share := 100 - *h.Memory
+			w.MemoryShare = &share
+		}
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := out[i].Requests.CPU.Cmp(out[j].Requests.CPU); c != 0 {
+			return c > 0
+		}
+		if c := out[i].Requests.Memory.Cmp(out[j].Requests.Memory); c != 0 {
+			return c > 0
+		}
+		return out[i].Workload < out[j].Workload
+	})
+	return out
+}
+
+// Capacity is what CollectCapacity read from the host cluster.
+type Capacity struct {
+	// Rows are the unsorted rows of a CapacityReport, host first.
+	Rows []CapacityRow
+	// Pods holds the pods of each readable host namespace.
+	Pods map[string][]kube.PodResourceInfo
+}
+
+// ClusterPods returns a row and the pods counted in it: every pod for the
+// host, the synced workload pods for a vCluster. ok is false when there is
+// no such cluster.
+func (c *Capacity) ClusterPods(cluster string) (row CapacityRow, pods []kube.PodResourceInfo, ok bool) {
+	for _, r := range c.Rows {
+		if r.Cluster != cluster {
+			continue
+		}
+		if cluster == HostCluster {
+			for _, ns := range sortedKeys(c.Pods) {
+				pods = append(pods, c.Pods[ns]...)
+			}
+			return r, pods, true
+		}
+		return r, WorkloadPods(c.Pods[r.Namespace]), true
+	}
+	return CapacityRow{}, nil, false
+}
+
+// CollectCapacity reads node allocatable, pod requests and vCluster specs.
+// Namespaces whose pods cannot be listed are reported as unknown rather
+// than failing the report.
+func CollectCapacity(ctx context.Context, client *kube.Client, platformNS string) (*Capacity, error) {
+	log := logging.FromContext(ctx)
+	host := CapacityRow{Cluster: HostCluster}
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+	for _, n := range nodes.Items {
+		host.Allocatable.Add(Resources{CPU: n.Status.Allocatable.Cpu().DeepCopy(), Memory: n.Status.Allocatable.Memory().DeepCopy()})
+	}
+
+	pods, unknown, err := readPods(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	c := &Capacity{Pods: pods}
+	host.Unknown = unknown
+	c.Rows = []CapacityRow{host}
+	_, all, _ := c.ClusterPods(HostCluster)
+	c.Rows[0].Requests, c.Rows[0].Limits, c.Rows[0].Pods = PodTotals(all)
+
+	vclusters, err := client.ListVClusters(ctx, platformNS)
+	if err != nil {
+		log.Debug("skipping vClusters in capacity report", "namespace", platformNS, "error", err)
+		return c, nil
+	}
+	unreadable := map[string]bool{}
+	for _, ns := range unknown {
+		unreadable[ns] = true
+	}
+	for _, vc := range vclusters {
+		spec, err := vclusterSpec(vc.Object["spec"])
+		if err != nil {
+			return nil, fmt.Errorf("vCluster %s: %w", vc.GetName(), err)
+		}
+		spec.Name = vc.GetName()
+		ns := spec.TargetNamespace
+		if ns == "" {
+			ns = spec.Name
+		}
+		row := CapacityRow{Cluster: spec.Name, Namespace: ns, Allocatable: host.Allocatable}
+		cpReq, _, err := ControlPlaneResources(SizingFromSpec(spec))
+		if err != nil {
+			return nil, fmt.Errorf("vCluster %s control plane: %w", spec.Name, err)
+		}
+		row.ControlPlane = &cpReq
+		if unreadable[ns] {
+			row.Unknown = []string{ns}
+		} else {
+			row.Requests, row.Limits, row.Pods = PodTotals(WorkloadPods(pods[ns]))
+		}
+		c.Rows = append(c.Rows, row)
+	}
+	return c, nil
+}
+
+// WorkloadPods drops a vCluster's control-plane pods from the pods in its
+// host namespace, leaving the synced workload pods.
+func WorkloadPods(pods []kube.PodResourceInfo) []kube.PodResourceInfo {
+	var out []kube.PodResourceInfo
+	for _, p := range pods {
+		if p.Labels["app"] == "vcluster" {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// readPods lists pods by namespace. It tries one cluster-wide list and,
+// when that is forbidden, lists each namespace on its own, returning the
+// namespaces it may not read as unknown.
+func readPods(ctx context.Context, client *kube.Client) (map[string][]kube.PodResourceInfo, []string, error) {
+	byNS := map[string][]kube.PodResourceInfo{}
+	all, err := client.GetPodResourceInfo(ctx, "", "")
+	if err == nil {
+		for _, p := range all {
+			byNS[p.Namespace] = append(byNS[p.Namespace], p)
+		}
+		return byNS, nil, nil
+	}
+	if !apierrors.IsForbidden(err) {
+		return nil, nil, err
+	}
+
+	namespaces, err := client.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing namespaces: %w", err)
+	}
+	var unknown []string
+	for _, ns := range namespaces.Items {
+		pods, err := client.GetPodResourceInfo(ctx, ns.Name, "")
+		switch {
+		case apierrors.IsForbidden(err):
+			unknown = append(unknown, ns.Name)
+		case err != nil:
+			return nil, nil, fmt.Errorf("namespace %s: %w", ns.Name, err)
+		default:
+			byNS[ns.Name] = pods
+		}
+	}
+	sort.Strings(unknown)
+	return byNS, unknown, nil
+}
+
+// vclusterSpec decodes the spec of a live VClusterOrchestratorV2.
+func vclusterSpec(obj interface{}) (VClusterSpec, error) {
+	var spec VClusterSpec
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return spec, err
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return spec, fmt.Errorf("parsing spec: %w", err)
+	}
+	return spec, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
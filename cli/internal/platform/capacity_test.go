@@ -0,0 +1,273 @@
+package platform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func q(s string) resource.Quantity { return resource.MustParse(s) }
+
+func pod(ns, name, phase, cpu, memory string, labels map[string]string) kube.PodResourceInfo {
+	return kube.PodResourceInfo{
+		Name: name, Namespace: ns, Phase: phase, Labels: labels,
+		CPURequest: q(cpu), MemoryRequest: q(memory),
+		CPULimit: q(cpu), MemoryLimit: q(memory),
+	}
+}
+
+func TestPodTotalsMixedUnits(t *testing.T) {
+	tests := []struct {
+		name             string
+		cpus, mems       []string
+		wantCPU, wantMem string
+		wantMilli        int64
+		wantBytes        int64
+	}{
+		{name: "millicores and decimal cores", cpus: []string{"500m", "0.5"}, mems: []string{"0", "0"}, wantCPU: "1", wantMem: "0", wantMilli: 1000},
+		{name: "fractional cores", cpus: []string{"0.25", "250m", "1.5"}, mems: []string{"0", "0", "0"}, wantCPU: "2", wantMem: "0", wantMilli: 2000},
+		{name: "Mi and Gi", cpus: []string{"0", "0"}, mems: []string{"512Mi", "0.5Gi"}, wantCPU: "0", wantMem: "1Gi", wantBytes: 1 << 30},
+		{name: "binary and decimal memory", cpus: []string{"0", "0"}, mems: []string{"1Gi", "1G"}, wantCPU: "0", wantMem: "2073741824", wantBytes: 1<<30 + 1e9},
+		{name: "Ki and Mi", cpus: []string{"0", "0"}, mems: []string{"1536Ki", "1Mi"}, wantCPU: "0", wantMem: "2560Ki", wantBytes: 2560 << 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pods []kube.PodResourceInfo
+			for i := range tt.cpus {
+				pods = append(pods, pod("ns", "p", "Running", tt.cpus[i], tt.mems[i], nil))
+			}
+			requests, _, n := PodTotals(pods)
+			if n != len(pods) {
+				t.Errorf("counted %d pods, want %d", n, len(pods))
+			}
+			if requests.CPU.Cmp(q(tt.wantCPU)) != 0 || requests.CPU.MilliValue() != tt.wantMilli {
+				t.Errorf("CPU = %s (%dm), want %s", requests.CPU.String(), requests.CPU.MilliValue(), tt.wantCPU)
+			}
+			if requests.Memory.Cmp(q(tt.wantMem)) != 0 || requests.Memory.Value() != tt.wantBytes {
+				t.Errorf("memory = %s (%d bytes), want %s", requests.Memory.String(), requests.Memory.Value(), tt.wantMem)
+			}
+		})
+	}
+}
+
+func TestPodTotalsSkipsFinishedPods(t *testing.T) {
+	requests, limits, n := PodTotals([]kube.PodResourceInfo{
+		pod("ns", "web", "Running", "200m", "256Mi", nil),
+		pod("ns", "starting", "Pending", "100m", "128Mi", nil),
+		pod("ns", "job", "Succeeded", "4", "8Gi", nil),
+		pod("ns", "crashed", "Failed", "4", "8Gi", nil),
+	})
+	if n != 2 || requests.CPU.MilliValue() != 300 || requests.Memory.Cmp(q("384Mi")) != 0 {
+		t.Errorf("totals = %d pods, %s CPU, %s memory; want 2, 300m, 384Mi", n, requests.CPU.String(), requests.Memory.String())
+	}
+	if limits.CPU.MilliValue() != 300 {
+		t.Errorf("CPU limits = %s, want 300m", limits.CPU.String())
+	}
+}
+
+func TestComputeHeadroom(t *testing.T) {
+	h := ComputeHeadroom(Resources{CPU: q("4"), Memory: q("8Gi")}, Resources{CPU: q("1500m"), Memory: q("6Gi")})
+	if h.CPU == nil || *h.CPU != 62.5 {
+		t.Errorf("CPU headroom = %v, want 62.5", h.CPU)
+	}
+	if h.Memory == nil || *h.Memory != 25 {
+		t.Errorf("memory headroom = %v, want 25", h.Memory)
+	}
+	if m, ok := h.Min(); !ok || m != 25 {
+		t.Errorf("Min() = %v, %v, want 25", m, ok)
+	}
+
+	over := ComputeHeadroom(Resources{CPU: q("1"), Memory: q("1Gi")}, Resources{CPU: q("1500m"), Memory: q("512Mi")})
+	if *over.CPU != -50 {
+		t.Errorf("overcommitted CPU headroom = %v, want -50", *over.CPU)
+	}
+
+	none := ComputeHeadroom(Resources{}, Resources{CPU: q("1")})
+	if none.CPU != nil || none.Memory != nil {
+		t.Errorf("headroom without allocatable = %+v, want unknown", none)
+	}
+	if _, ok := none.Min(); ok {
+		t.Error("Min() of unknown headroom reported a value")
+	}
+}
+
+func TestControlPlaneResources(t *testing.T) {
+	requests, limits, err := ControlPlaneResources(SizingFromSpec(VClusterSpec{VCluster: VClusterConfig{Preset: "prod"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.CPU.MilliValue() != 1500 || requests.Memory.Cmp(q("3Gi")) != 0 {
+		t.Errorf("prod requests = %s / %s, want 3 × 500m / 3 × 1Gi", requests.CPU.String(), requests.Memory.String())
+	}
+	if limits.CPU.MilliValue() != 6000 || limits.Memory.Cmp(q("6Gi")) != 0 {
+		t.Errorf("prod limits = %s / %s, want 3 × 2 / 3 × 2Gi", limits.CPU.String(), limits.Memory.String())
+	}
+
+	spec := VClusterSpec{VCluster: VClusterConfig{Preset: "dev", Resources: &ResourceRequirements{
+		Requests: map[string]string{"cpu": "0.25", "memory": "1.5Gi"},
+	}}}
+	requests, _, err = ControlPlaneResources(SizingFromSpec(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.CPU.MilliValue() != 250 || requests.Memory.Cmp(q("1536Mi")) != 0 {
+		t.Errorf("dev override = %s / %s, want 250m / 1536Mi", requests.CPU.String(), requests.Memory.String())
+	}
+
+	if _, _, err := ControlPlaneResources(Sizing{Replicas: 1, CPU: "lots"}); err == nil {
+		t.Error("invalid quantity accepted")
+	}
+}
+
+func TestCapacityReportFinish(t *testing.T) {
+	alloc := Resources{CPU: q("10"), Memory: q("10Gi")}
+	cp := Resources{CPU: q("500m"), Memory: q("1Gi")}
+	report := CapacityReport{WarnBelow: 20, Rows: []CapacityRow{
+		{Cluster: HostCluster, Allocatable: alloc, Requests: Resources{CPU: q("9"), Memory: q("5Gi")}},
+		{Cluster: "media", Allocatable: alloc, Requests: Resources{CPU: q("2"), Memory: q("2Gi")}, ControlPlane: &cp},
+		{Cluster: "dev", Allocatable: alloc, Requests: Resources{CPU: q("500m"), Memory: q("512Mi")}, ControlPlane: &cp},
+		{Cluster: "ai", Allocatable: alloc, Requests: Resources{CPU: q("1"), Memory: q("8.5Gi")}, ControlPlane: &cp},
+	}}
+	report.Finish()
+
+	var order []string
+	for _, r := range report.Rows {
+		order = append(order, r.Cluster)
+	}
+	if got := strings.Join(order, " "); got != "host ai media dev" {
+		t.Errorf("order = %s, want host first, then vClusters by headroom", got)
+	}
+	if !report.Rows[0].Warning || !report.Rows[1].Warning || report.Rows[2].Warning {
+		t.Errorf("warnings = %v %v %v, want host and ai only", report.Rows[0].Warning, report.Rows[1].Warning, report.Rows[2].Warning)
+	}
+	// media: 2 + 0.5 of 10 cores, 2Gi + 1Gi of 10Gi.
+	if h := report.Rows[2].Headroom; *h.CPU != 75 || *h.Memory != 70 {
+		t.Errorf("media headroom = %v / %v, want the control plane counted (75 / 70)", *h.CPU, *h.Memory)
+	}
+	if report.Rows[2].Requests.CPU.MilliValue() != 2000 {
+		t.Error("Finish changed the row's requests")
+	}
+}
+
+func TestByWorkload(t *testing.T) {
+	shop := map[string]string{"hctl.integratn.tech/workload": "shop"}
+	pods := []kube.PodResourceInfo{
+		pod("media", "shop-1", "Running", "500m", "512Mi", shop),
+		pod("media", "shop-2", "Running", "0.5", "0.5Gi", shop),
+		pod("media", "blog-1", "Running", "250m", "1Gi", map[string]string{"hctl.integratn.tech/workload": "blog"}),
+		pod("media", "coredns", "Running", "100m", "70Mi", nil),
+		pod("media", "migrate", "Succeeded", "2", "2Gi", shop),
+	}
+	got := ByWorkload(pods, Resources{CPU: q("4"), Memory: q("4Gi")})
+	if len(got) != 3 {
+		t.Fatalf("got %d groups, want shop, blog and unlabelled", len(got))
+	}
+	if got[0].Workload != "shop" || got[0].Pods != 2 || got[0].Requests.CPU.MilliValue() != 1000 || got[0].Requests.Memory.Cmp(q("1Gi")) != 0 {
+		t.Errorf("first group = %+v, want shop with 2 pods, 1 core, 1Gi", got[0])
+	}
+	if *got[0].CPUShare != 25 || *got[0].MemoryShare != 25 {
+		t.Errorf("shop share = %v / %v, want 25 / 25", *got[0].CPUShare, *got[0].MemoryShare)
+	}
+	if got[1].Workload != "blog" || got[2].Workload != "" {
+		t.Errorf("order = %q %q, want blog then unlabelled", got[1].Workload, got[2].Workload)
+	}
+}
+
+func TestCollectCapacity(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    q("3900m"),
+			corev1.ResourceMemory: q("15Gi"),
+		}},
+	}
+	node2 := node.DeepCopy()
+	node2.Name = "node-2"
+	node2.Status.Allocatable[corev1.ResourceCPU] = q("4")
+	podObj := func(ns, name, cpu, memory string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: q(cpu), corev1.ResourceMemory: q(memory)},
+			}}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	ns := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	clientset := fake.NewSimpleClientset(node, node2,
+		ns("media"), ns("kube-system"), ns("restricted"),
+		podObj("media", "media-0", "200m", "768Mi", map[string]string{"app": "vcluster"}),
+		podObj("media", "jellyfin-x-media", "1", "2Gi", map[string]string{"hctl.integratn.tech/workload": "jellyfin"}),
+		podObj("kube-system", "coredns", "100m", "70Mi", nil),
+		podObj("restricted", "secret-app", "2", "4Gi", nil),
+	)
+	// hctl may not list pods cluster-wide nor in the restricted namespace.
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		switch ns := action.GetNamespace(); ns {
+		case "", "restricted":
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", nil)
+		}
+		return false, nil, nil
+	})
+	vc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "platform.integratn.tech/v1alpha1",
+		"kind":       "VClusterOrchestratorV2",
+		"metadata":   map[string]interface{}{"name": "media", "namespace": "platform-requests"},
+		"spec": map[string]interface{}{
+			"targetNamespace": "media",
+			"vcluster":        map[string]interface{}{"preset": "dev"},
+		},
+	}}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kube.VClusterOrchestratorV2GVR: "VClusterOrchestratorV2List"}, vc)
+
+	c, err := CollectCapacity(context.Background(), &kube.Client{Clientset: clientset, Dynamic: dyn}, "platform-requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Rows) != 2 {
+		t.Fatalf("got %d rows, want host and media", len(c.Rows))
+	}
+	host, media := c.Rows[0], c.Rows[1]
+	if host.Allocatable.CPU.MilliValue() != 7900 || host.Allocatable.Memory.Cmp(q("30Gi")) != 0 {
+		t.Errorf("allocatable = %s / %s, want 7900m / 30Gi", host.Allocatable.CPU.String(), host.Allocatable.Memory.String())
+	}
+	if host.Pods != 3 || host.Requests.CPU.MilliValue() != 1300 {
+		t.Errorf("host = %d pods, %s CPU; want the 3 readable pods, 1300m", host.Pods, host.Requests.CPU.String())
+	}
+	if len(host.Unknown) != 1 || host.Unknown[0] != "restricted" {
+		t.Errorf("unknown namespaces = %v, want [restricted]", host.Unknown)
+	}
+	if media.Cluster != "media" || media.Pods != 1 || media.Requests.CPU.MilliValue() != 1000 {
+		t.Errorf("media = %+v, want the jellyfin pod without the control plane", media)
+	}
+	if media.ControlPlane == nil || media.ControlPlane.CPU.MilliValue() != 200 || media.ControlPlane.Memory.Cmp(q("768Mi")) != 0 {
+		t.Errorf("media control plane = %+v, want the dev preset (200m / 768Mi)", media.ControlPlane)
+	}
+
+	row, pods, ok := c.ClusterPods("media")
+	if !ok || row.Cluster != "media" || len(pods) != 1 || pods[0].Name != "jellyfin-x-media" {
+		t.Errorf("ClusterPods(media) = %v, %d pods, want the workload pod", ok, len(pods))
+	}
+	if _, pods, _ := c.ClusterPods(HostCluster); len(pods) != 3 {
+		t.Errorf("ClusterPods(host) = %d pods, want 3", len(pods))
+	}
+	if _, _, ok := c.ClusterPods("missing"); ok {
+		t.Error("ClusterPods found a cluster that does not exist")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/logging"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -15,9 +17,12 @@ func CollectPlatformStatus(ctx context.Context, client *kube.Client, platformNS
 	ps := &PlatformStatus{}
 
 	// vClusters from CRs
+	log := logging.FromContext(ctx)
 	vclusters, err := CollectVClusterStatus(ctx, client, platformNS)
 	if err == nil {
 		ps.VClusters = vclusters
+	} else {
+		log.Debug("skipping vCluster status", "namespace", platformNS, "error", err)
 	}
 
 	// All ArgoCD apps (single query, classify by labels)
@@ -51,6 +56,7 @@ func CollectPlatformStatus(ctx context.Context, client *kube.Client, platformNS
 		}
 	}
 
+	log.Debug("collected platform status", "vclusters", len(ps.VClusters), "workloads", len(ps.Workloads), "addons", len(ps.Addons))
 	return ps, nil
 }
 
@@ -74,12 +80,11 @@ func CollectVClusterStatus(ctx context.Context, client *kube.Client, namespace s
 		}
 
 		// Try to read from status contract (set by reconciler)
-		phase, _, _ := unstructured.NestedString(vc.Object, "status", "phase")
-		message, _, _ := unstructured.NestedString(vc.Object, "status", "message")
+		reported, message := PhaseFromStatus(vc.Object)
 		lastReconciled, _, _ := unstructured.NestedString(vc.Object, "status", "lastReconciled")
 
-		if phase != "" {
-			rs.Phase = phase
+		if reported != "" {
+			rs.Phase = reported
 			rs.Message = message
 			if t, err := time.Parse(time.RFC3339, lastReconciled); err == nil {
 				rs.LastChecked = t
@@ -116,7 +121,7 @@ func CollectVClusterStatus(ctx context.Context, client *kube.Client, namespace s
 		// If no reconciler phase, derive from spec
 		if rs.Phase == "" {
 			preset, _, _ := unstructured.NestedString(vc.Object, "spec", "vcluster", "preset")
-			rs.Phase = "Unknown"
+			rs.Phase = phase.Unknown
 			rs.Message = fmt.Sprintf("preset=%s (reconciler not reporting)", preset)
 		}
 
@@ -166,10 +171,14 @@ func CollectAddonStatus(ctx context.Context, client *kube.Client, clusterName st
 	return result, nil
 }
 
-// argoAppToResourceStatus converts an ArgoCD Application unstructured object to ResourceStatus.
-func argoAppToResourceStatus(app unstructured.Unstructured) ResourceStatus {
+// ArgoCDInfoFromApp reads sync, health, revision, and last operation
+// message from an ArgoCD Application. Missing statuses become "Unknown".
+func ArgoCDInfoFromApp(app *unstructured.Unstructured) ArgoCDInfo {
 	syncStatus, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
 	healthStatus, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	revision, _, _ := unstructured.NestedString(app.Object, "status", "sync", "revision")
+	message, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "message")
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
 
 	if syncStatus == "" {
 		syncStatus = "Unknown"
@@ -178,20 +187,27 @@ func argoAppToResourceStatus(app unstructured.Unstructured) ResourceStatus {
 		healthStatus = "Unknown"
 	}
 
+	return ArgoCDInfo{
+		AppName:      app.GetName(),
+		SyncStatus:   syncStatus,
+		HealthStatus: healthStatus,
+		Project:      project,
+		Revision:     revision,
+		Message:      message,
+	}
+}
+
+// argoAppToResourceStatus converts an ArgoCD Application unstructured object to ResourceStatus.
+func argoAppToResourceStatus(app unstructured.Unstructured) ResourceStatus {
+	argo := ArgoCDInfoFromApp(&app)
 	labels := app.GetLabels()
-	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
 	destNS, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
 
 	return ResourceStatus{
-		Name:      labels["addonName"],
-		Namespace: destNS,
-		Phase:     PhaseFromArgoCD(syncStatus, healthStatus),
-		ArgoCD: ArgoCDInfo{
-			AppName:      app.GetName(),
-			SyncStatus:   syncStatus,
-			HealthStatus: healthStatus,
-			Project:      project,
-		},
+		Name:        labels["addonName"],
+		Namespace:   destNS,
+		Phase:       PhaseFromArgoCD(argo.SyncStatus, argo.HealthStatus),
+		ArgoCD:      argo,
 		Labels:      labels,
 		LastChecked: time.Now(),
 	}
@@ -280,7 +280,7 @@ func DiagnoseVCluster(ctx context.Context, client *kube.Client, namespace, name
 				Name:    "Resources",
 				Status:  status,
 				Message: fmt.Sprintf("%s: mem req=%s lim=%s, cpu req=%s lim=%s, restarts=%d",
-					pr.Name, pr.MemoryRequest, pr.MemoryLimit, pr.CPURequest, pr.CPULimit, pr.Restarts),
+					pr.Name, pr.MemoryRequest.String(), pr.MemoryLimit.String(), pr.CPURequest.String(), pr.CPULimit.String(), pr.Restarts),
 				Details: details,
 			})
 		}
@@ -302,7 +302,7 @@ func DiagnoseVCluster(ctx context.Context, client *kube.Client, namespace, name
 					Name:    "Resources",
 					Status:  status,
 					Message: fmt.Sprintf("%s: mem req=%s lim=%s, restarts=%d",
-						pr.Name, pr.MemoryRequest, pr.MemoryLimit, pr.Restarts),
+						pr.Name, pr.MemoryRequest.String(), pr.MemoryLimit.String(), pr.Restarts),
 					Details: details,
 				})
 			}
@@ -0,0 +1,196 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The status reconciler's identity on the host cluster, as deployed from
+// addons/cluster-roles/control-plane/addons/platform-status-reconciler.
+const (
+	StatusReconcilerNamespace      = "platform-status-reconciler"
+	StatusReconcilerServiceAccount = "platform-status-reconciler"
+	StatusReconcilerClusterRole    = "platform-status-reconciler"
+)
+
+// StatusReconcilerAccess is what the status reconciler does on the host
+// cluster. Keep it in step with the reconciler's ClusterRole: a rule missing
+// there makes the reconciler's calls fail and statuses silently go stale.
+var StatusReconcilerAccess = []kube.AccessRule{
+	{GVR: kube.VClusterOrchestratorV2GVR, Verb: "get"},
+	{GVR: kube.VClusterOrchestratorV2GVR, Verb: "list"},
+	{GVR: kube.VClusterOrchestratorV2GVR, Verb: "watch"},
+	{GVR: kube.VClusterOrchestratorV2GVR, Subresource: "status", Verb: "patch"},
+	{GVR: kube.ArgoCDApplicationGVR, Verb: "get"},
+	{GVR: kube.ArgoCDApplicationGVR, Verb: "list"},
+	{GVR: kube.WorkGVR, Verb: "list"},
+	{GVR: kube.WorkPlacementGVR, Verb: "list"},
+	{GVR: coreGVR("pods"), Verb: "list"},
+	{GVR: coreGVR("secrets"), Verb: "get"},
+	{GVR: coreGVR("namespaces"), Verb: "get"},
+	// Listed to explain namespaces stuck Terminating.
+	{GVR: coreGVR("persistentvolumeclaims"), Verb: "list"},
+	{GVR: coreGVR("services"), Verb: "list"},
+	{GVR: coreGVR("configmaps"), Verb: "list"},
+	{GVR: coreGVR("serviceaccounts"), Verb: "list"},
+	{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, Verb: "list"},
+	{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, Verb: "list"},
+	{GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, Verb: "list"},
+	{GVR: kube.ExternalSecretGVR, Verb: "list"},
+	{GVR: kube.CertificateGVR, Verb: "list"},
+	{GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}, Verb: "list"},
+}
+
+func coreGVR(resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Version: "v1", Resource: resource}
+}
+
+// VClusterNamespaceLabels are the labels the vcluster promise sets on a
+// vcluster's target namespace.
+var VClusterNamespaceLabels = map[string]string{
+	"vcluster.loft.sh/namespace":   "true",
+	"platform.integratn.tech/type": "vcluster",
+}
+
+// NamespaceLabelIssue is a vcluster target namespace that lacks labels the
+// platform relies on.
+type NamespaceLabelIssue struct {
+	VCluster  string
+	Namespace string
+	// Missing maps each absent or mismatched label to the value it needs.
+	Missing map[string]string
+	// NotFound means the namespace does not exist, so it cannot be labeled.
+	NotFound bool
+}
+
+// CheckNamespaceLabels returns the vcluster target namespaces missing the
+// promise's namespace labels or the labels the ClusterSecretStores their
+// vcluster syncs from select namespaces by. Without the latter, the
+// store refuses the namespace and credentials never sync. Vclusters that run
+// in their request's namespace are skipped: the promise does not manage it.
+func CheckNamespaceLabels(ctx context.Context, client *kube.Client, platformNamespace string) ([]NamespaceLabelIssue, error) {
+	vclusters, err := client.ListVClusters(ctx, platformNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("listing vclusters: %w", err)
+	}
+	stores, err := client.Dynamic.Resource(kube.ClusterSecretStoreGVR).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("listing ClusterSecretStores: %w", kube.ClassifyError(err))
+	}
+	var storeItems []unstructured.Unstructured
+	if stores != nil {
+		storeItems = stores.Items
+	}
+
+	var issues []NamespaceLabelIssue
+	for i := range vclusters {
+		vc := &vclusters[i]
+		target := TargetNamespace(vc)
+		if target == vc.GetNamespace() {
+			continue
+		}
+		issue := NamespaceLabelIssue{VCluster: vc.GetName(), Namespace: target, Missing: map[string]string{}}
+		var labels map[string]string
+		ns, err := client.Clientset.CoreV1().Namespaces().Get(ctx, target, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			issue.NotFound = true
+		case err != nil:
+			return nil, fmt.Errorf("getting namespace %s: %w", target, kube.ClassifyError(err))
+		default:
+			labels = ns.Labels
+		}
+
+		required := map[string]string{}
+		for k, v := range VClusterNamespaceLabels {
+			required[k] = v
+		}
+		for k, v := range storeNamespaceLabels(storeItems, storeSelector(vc), target, labels) {
+			required[k] = v
+		}
+		for k, v := range required {
+			if labels[k] != v {
+				issue.Missing[k] = v
+			}
+		}
+		if issue.NotFound || len(issue.Missing) > 0 {
+			issues = append(issues, issue)
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Namespace < issues[j].Namespace })
+	return issues, nil
+}
+
+// storeSelector returns the labels vc selects host ClusterSecretStores by.
+func storeSelector(vc *unstructured.Unstructured) map[string]string {
+	if sel, ok, _ := unstructured.NestedStringMap(vc.Object, "spec", "integrations", "externalSecrets", "clusterStoreSelectorLabels"); ok && len(sel) > 0 {
+		return sel
+	}
+	return DefaultIntegrations().ExternalSecrets.ClusterStoreSelectorLabels
+}
+
+// storeNamespaceLabels returns the labels namespace needs before the stores
+// matching selector serve it. A store without conditions serves every
+// namespace, and one whose conditions already admit the namespace needs
+// nothing; otherwise the first condition's namespaceSelector labels are
+// required. Selectors using only matchExpressions are not handled.
+func storeNamespaceLabels(stores []unstructured.Unstructured, selector map[string]string, namespace string, labels map[string]string) map[string]string {
+	required := map[string]string{}
+	for _, store := range stores {
+		if !subset(selector, store.GetLabels()) {
+			continue
+		}
+		conditions, _, _ := unstructured.NestedSlice(store.Object, "spec", "conditions")
+		if len(conditions) == 0 {
+			continue
+		}
+		var want map[string]string
+		admitted := false
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			names, _, _ := unstructured.NestedStringSlice(cond, "namespaces")
+			for _, n := range names {
+				if n == namespace {
+					admitted = true
+				}
+			}
+			match, ok, _ := unstructured.NestedStringMap(cond, "namespaceSelector", "matchLabels")
+			if !ok || len(match) == 0 {
+				continue
+			}
+			if subset(match, labels) {
+				admitted = true
+			}
+			if want == nil {
+				want = match
+			}
+		}
+		if admitted {
+			continue
+		}
+		for k, v := range want {
+			required[k] = v
+		}
+	}
+	return required
+}
+
+// subset reports whether every label in want is set in have.
+func subset(want, have map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,311 @@
+package platform
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PipelineImage is the image the orchestrator promise runs its configure
+// pipeline from, and PipelineCommand the entrypoint that selects the
+// vcluster-orchestrator-v2 pipeline in it.
+const (
+	PipelineImage   = "ghcr.io/jamesatintegratnio/platform-pipelines:latest"
+	PipelineCommand = "/usr/local/bin/vcluster-orchestrator-v2"
+)
+
+// pipelineUser is the uid the pipeline image runs as.
+const pipelineUser = "65532"
+
+// PipelineRunner runs the orchestrator's configure pipeline with the input,
+// output and metadata subdirectories of dir as the Kratix directories, and
+// returns the pipeline's log.
+type PipelineRunner func(ctx context.Context, dir string) ([]byte, error)
+
+// ContainerRunner returns a PipelineRunner that runs image with docker, or
+// podman when docker is not installed.
+func ContainerRunner(image string) (PipelineRunner, error) {
+	engine := ""
+	for _, name := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(name); err == nil {
+			engine = name
+			break
+		}
+	}
+	if engine == "" {
+		return nil, hcerrors.NewUserError("running the pipeline locally needs docker or podman").
+			WithRemediation("install docker or podman, or drop --dry-run-pipeline")
+	}
+	return func(ctx context.Context, dir string) ([]byte, error) {
+		args := []string{
+			"run", "--rm", "--user", pipelineUser,
+			"--entrypoint", PipelineCommand,
+			"-e", "KRATIX_WORKFLOW_ACTION=configure",
+			"-e", "KRATIX_WORKFLOW_TYPE=resource",
+			"-e", "KRATIX_PROMISE_NAME=vcluster-orchestrator-v2",
+			"-v", filepath.Join(dir, "input") + ":/kratix/input:ro",
+			"-v", filepath.Join(dir, "output") + ":/kratix/output",
+			"-v", filepath.Join(dir, "metadata") + ":/kratix/metadata",
+			image,
+		}
+		return exec.CommandContext(ctx, engine, args...).CombinedOutput()
+	}, nil
+}
+
+// PipelineObject is one object the pipeline wrote to its output directory.
+type PipelineObject struct {
+	// File is the path under the output directory.
+	File      string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// PipelineRun is what one local run of the configure pipeline produced.
+type PipelineRun struct {
+	Objects []PipelineObject
+	// Status is the status the pipeline wrote for the resource.
+	Status map[string]interface{}
+	// Values is the vcluster chart's valuesObject in the ArgoCD application
+	// request.
+	Values map[string]interface{}
+	Log    string
+	// PoolSkipped is set when the request's load balancer pool was left
+	// out of the run.
+	PoolSkipped bool
+}
+
+// PipelineFailure is a pipeline run that exited with an error: the spec
+// failed the pipeline's validation, or rendering it did.
+type PipelineFailure struct {
+	Err error
+	Log string
+}
+
+func (f *PipelineFailure) Error() string {
+	return fmt.Sprintf("the orchestrator pipeline rejected the request: %v", f.Err)
+}
+
+func (f *PipelineFailure) Unwrap() error { return f.Err }
+
+// RunPipeline runs the configure pipeline with run on doc, a
+// VClusterOrchestratorV2 manifest, as Kratix would once it is committed.
+// status is the status the resource already has, if any; the pipeline
+// checks upgrades against it. A load balancer pool is left out, as the
+// pipeline checks it against the vClusters in the cluster.
+func RunPipeline(ctx context.Context, doc []byte, status map[string]interface{}, run PipelineRunner) (*PipelineRun, error) {
+	input, poolSkipped, err := pipelineInput(doc, status)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "hctl-pipeline-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	for _, sub := range []string{"input", "output", "metadata"} {
+		// The pipeline runs as its own uid, which must write output and
+		// metadata; Chmod so the umask does not take that away.
+		path := filepath.Join(dir, sub)
+		if err := os.Mkdir(path, 0o777); err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0o777); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "input", "object.yaml"), input, 0o644); err != nil {
+		return nil, err
+	}
+
+	out, err := run(ctx, dir)
+	if err != nil {
+		return nil, &PipelineFailure{Err: err, Log: string(out)}
+	}
+	result, err := readPipelineRun(dir)
+	if err != nil {
+		return nil, err
+	}
+	result.Log = string(out)
+	result.PoolSkipped = poolSkipped
+	return result, nil
+}
+
+// pipelineInput renders doc as the pipeline's object.yaml: with status when
+// it is set, and without a load balancer pool, which it reports.
+func pipelineInput(doc []byte, status map[string]interface{}) ([]byte, bool, error) {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(doc, &obj); err != nil {
+		return nil, false, hcerrors.New(hcerrors.ErrValidation, "parsing the request: %v", err)
+	}
+	networking, _ := nestedValue(obj, "spec.vcluster.networking").(map[string]interface{})
+	_, poolSkipped := networking["loadBalancer"]
+	if !poolSkipped && len(status) == 0 {
+		return doc, false, nil
+	}
+	delete(networking, "loadBalancer")
+	if len(status) > 0 {
+		obj["status"] = status
+	}
+	input, err := yaml.Marshal(obj)
+	return input, poolSkipped, err
+}
+
+// readPipelineRun collects the objects and status a pipeline run wrote
+// under dir.
+func readPipelineRun(dir string) (*PipelineRun, error) {
+	result := &PipelineRun{}
+	outDir := filepath.Join(dir, "output")
+	err := filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var obj map[string]interface{}
+			if err := dec.Decode(&obj); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return fmt.Errorf("parsing pipeline output %s: %w", rel, err)
+			}
+			if obj == nil {
+				continue
+			}
+			kind, _ := obj["kind"].(string)
+			name, _ := nestedValue(obj, "metadata.name").(string)
+			namespace, _ := nestedValue(obj, "metadata.namespace").(string)
+			result.Objects = append(result.Objects, PipelineObject{
+				File: filepath.ToSlash(rel), Kind: kind, Namespace: namespace, Name: name,
+			})
+			if kind == "ArgoCDApplication" && result.Values == nil {
+				result.Values, _ = nestedValue(obj, "spec.source.helm.valuesObject").(map[string]interface{})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result.Objects, func(i, j int) bool { return result.Objects[i].File < result.Objects[j].File })
+
+	data, err := os.ReadFile(filepath.Join(dir, "metadata", "status.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &result.Status); err != nil {
+		return nil, fmt.Errorf("parsing pipeline status: %w", err)
+	}
+	return result, nil
+}
+
+// PipelineField is a setting the CLI expects the pipeline to render from
+// the spec, next to what it rendered.
+type PipelineField struct {
+	Name     string
+	CLI      string
+	Pipeline string
+}
+
+// Differs reports whether the pipeline rendered something other than the
+// CLI expected.
+func (f PipelineField) Differs() bool { return f.CLI != f.Pipeline }
+
+// Compare lists the key settings of spec next to the status and chart
+// values the pipeline rendered for it. Settings spec leaves to the
+// pipeline are listed with an empty CLI value and never differ.
+func (r *PipelineRun) Compare(spec VClusterSpec) []PipelineField {
+	environment := ""
+	if spec.Integrations.ArgoCD != nil {
+		environment = spec.Integrations.ArgoCD.Environment
+	}
+	var requests, limits, persistence, coreDNS, backingStore string
+	if r := spec.VCluster.Resources; r != nil {
+		requests, limits = r.Requests["memory"], r.Limits["memory"]
+	}
+	if p := spec.VCluster.Persistence; p != nil && p.Enabled {
+		persistence = p.Size
+	}
+	if c := spec.VCluster.CoreDNS; c != nil && c.Replicas > 0 {
+		coreDNS = fmt.Sprint(c.Replicas)
+	}
+	if spec.VCluster.BackingStore != nil {
+		backingStore = backingStoreName(spec.VCluster.BackingStore)
+	}
+	replicas := ""
+	if spec.VCluster.Replicas > 0 {
+		replicas = fmt.Sprint(spec.VCluster.Replicas)
+	}
+
+	fields := []struct {
+		name, cli string
+		got       interface{}
+	}{
+		{"vClusterName", spec.Name, r.Status["vclusterName"]},
+		{"targetNamespace", spec.TargetNamespace, r.Status["targetNamespace"]},
+		{"hostname", spec.Exposure.Hostname, r.Status["hostname"]},
+		{"environment", environment, r.Status["environment"]},
+		{"k8sVersion", cmp.Or(spec.VCluster.K8sVersion, DefaultK8sVersion), r.Status["k8sVersion"]},
+		{"chartVersion", cmp.Or(spec.ArgocdApp.TargetRevision, DefaultChartVersion), r.Status["chartVersion"]},
+		{"replicas", replicas, nestedValue(r.Values, "controlPlane.statefulSet.highAvailability.replicas")},
+		{"memory request", requests, nestedValue(r.Values, "controlPlane.statefulSet.resources.requests.memory")},
+		{"memory limit", limits, nestedValue(r.Values, "controlPlane.statefulSet.resources.limits.memory")},
+		{"persistence", persistence, nestedValue(r.Values, "controlPlane.statefulSet.persistence.volumeClaim.size")},
+		{"coreDNS replicas", coreDNS, nestedValue(r.Values, "controlPlane.coredns.deployment.replicas")},
+		{"backingStore", backingStore, backingStoreName(nestedValue(r.Values, "controlPlane.backingStore"))},
+	}
+	out := make([]PipelineField, 0, len(fields))
+	for _, f := range fields {
+		got := ""
+		if f.got != nil {
+			got = fmt.Sprint(f.got)
+		}
+		cli := f.cli
+		if cli == "" {
+			cli = got
+		}
+		out = append(out, PipelineField{Name: f.name, CLI: cli, Pipeline: got})
+	}
+	return out
+}
+
+// backingStoreName is the datastore a backingStore section selects:
+// "etcd" for a deployed etcd, or "sqlite", the chart's default.
+func backingStoreName(v interface{}) string {
+	m, _ := v.(map[string]interface{})
+	if enabled, _ := nestedValue(m, "etcd.deploy.enabled").(bool); enabled {
+		return "etcd"
+	}
+	return "sqlite"
+}
+
+// nestedValue returns the value at a dotted path in m, or nil.
+func nestedValue(m map[string]interface{}, path string) interface{} {
+	var cur interface{} = m
+	for _, key := range strings.Split(path, ".") {
+		next, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = next[key]
+	}
+	return cur
+}
@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRunPipelineInput(t *testing.T) {
+	doc := []byte(`apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: media
+spec:
+  name: media
+  vcluster:
+    preset: prod
+    networking:
+      clusterDomain: cluster.local
+      loadBalancer:
+        addressPool: 10.0.5.16/28
+`)
+	var input map[string]interface{}
+	run := func(_ context.Context, dir string) ([]byte, error) {
+		data, err := os.ReadFile(filepath.Join(dir, "input", "object.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		return nil, yaml.Unmarshal(data, &input)
+	}
+
+	status := map[string]interface{}{"k8sVersion": "v1.33.2"}
+	result, err := RunPipeline(context.Background(), doc, status, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.PoolSkipped {
+		t.Error("PoolSkipped = false, want the load balancer pool left out")
+	}
+	if lb := nestedValue(input, "spec.vcluster.networking.loadBalancer"); lb != nil {
+		t.Errorf("pipeline input kept the load balancer pool: %v", lb)
+	}
+	if d := nestedValue(input, "spec.vcluster.networking.clusterDomain"); d != "cluster.local" {
+		t.Errorf("clusterDomain = %v, want it kept", d)
+	}
+	if v := nestedValue(input, "status.k8sVersion"); v != "v1.33.2" {
+		t.Errorf("status.k8sVersion = %v, want the live status passed in", v)
+	}
+}
+
+func TestRunPipelineFailure(t *testing.T) {
+	run := func(context.Context, string) ([]byte, error) {
+		return []byte("spec.vcluster.k8sVersion: cannot downgrade\n"), os.ErrInvalid
+	}
+	_, err := RunPipeline(context.Background(), []byte("spec:\n  name: media\n"), nil, run)
+	f, ok := err.(*PipelineFailure)
+	if !ok {
+		t.Fatalf("RunPipeline() = %v, want a *PipelineFailure", err)
+	}
+	if !strings.Contains(f.Log, "cannot downgrade") {
+		t.Errorf("Log = %q, want the pipeline's output", f.Log)
+	}
+}
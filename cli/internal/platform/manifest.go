@@ -0,0 +1,233 @@
+package platform
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestEdit sets one value in a YAML manifest.
+type ManifestEdit struct {
+	// Path is the chain of mapping keys from the document root.
+	Path []string
+	// Value is a string, int, or bool.
+	Value any
+	// IfExists skips the edit when the path is not already present.
+	IfExists bool
+}
+
+// EditManifest applies edits to a YAML document. When every edit replaces
+// an existing scalar, the values are rewritten in place so comments, quoting
+// and layout are untouched. Otherwise missing keys are created and the
+// document is re-encoded with its original indentation.
+func EditManifest(doc []byte, edits []ManifestEdit) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("manifest is not a YAML mapping")
+	}
+	top := root.Content[0]
+
+	var applicable []ManifestEdit
+	for _, e := range edits {
+		if e.IfExists && findNode(top, e.Path) == nil {
+			continue
+		}
+		applicable = append(applicable, e)
+	}
+
+	if out, ok := editInPlace(doc, top, applicable); ok {
+		return out, nil
+	}
+
+	for _, e := range applicable {
+		setNode(top, e.Path, e.Value)
+	}
+	untagMergeKeys(&root)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indentOf(top))
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// editInPlace rewrites existing scalar values on their source lines. It
+// reports false when an edit targets a missing key or a value it cannot
+// locate exactly.
+func editInPlace(doc []byte, top *yaml.Node, edits []ManifestEdit) ([]byte, bool) {
+	lines := strings.SplitAfter(string(doc), "\n")
+	type span struct {
+		line, start, end int
+		text             string
+	}
+	var spans []span
+	for _, e := range edits {
+		n := findNode(top, e.Path)
+		if n == nil || n.Kind != yaml.ScalarNode || n.Line < 1 || n.Line > len(lines) {
+			return nil, false
+		}
+		line := lines[n.Line-1]
+		start := n.Column - 1
+		end, ok := scalarEnd(line, start, n)
+		if !ok {
+			return nil, false
+		}
+		spans = append(spans, span{line: n.Line - 1, start: start, end: end, text: renderScalar(e.Value, n.Style)})
+	}
+	// Apply right to left so earlier columns on the same line stay valid.
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].line != spans[j].line {
+			return spans[i].line < spans[j].line
+		}
+		return spans[i].start < spans[j].start
+	})
+	for i := len(spans) - 1; i >= 0; i-- {
+		s := spans[i]
+		lines[s.line] = lines[s.line][:s.start] + s.text + lines[s.line][s.end:]
+	}
+	return []byte(strings.Join(lines, "")), true
+}
+
+// scalarEnd finds where the scalar n starting at start ends on line, and
+// checks that the source text there is the value yaml parsed.
+func scalarEnd(line string, start int, n *yaml.Node) (int, bool) {
+	if start < 0 || start >= len(line) {
+		return 0, false
+	}
+	rest := line[start:]
+	switch n.Style {
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		q := rest[0]
+		end := strings.IndexByte(rest[1:], q)
+		if end < 0 || rest[1:1+end] != n.Value {
+			return 0, false
+		}
+		return start + end + 2, true
+	case 0:
+		text := strings.TrimRight(rest, "\r\n")
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimRight(text, " \t")
+		if text != n.Value {
+			return 0, false
+		}
+		return start + len(text), true
+	}
+	return 0, false
+}
+
+// renderScalar formats v for the source. Strings keep the original quoting,
+// and are quoted when they would otherwise read back as another type.
+func renderScalar(v any, style yaml.Style) string {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	switch {
+	case style == yaml.SingleQuotedStyle:
+		return "'" + str + "'"
+	case style == yaml.DoubleQuotedStyle, str == "", scalarTag(str) != "!!str":
+		return `"` + str + `"`
+	}
+	return str
+}
+
+// scalarTag returns the tag yaml resolves a plain scalar to.
+func scalarTag(s string) string {
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &n); err != nil || len(n.Content) == 0 {
+		return "!!str"
+	}
+	return n.Content[0].ShortTag()
+}
+
+// findNode returns the value node at path, or nil.
+func findNode(n *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
+
+// setNode sets the value at path, creating mappings along the way.
+func setNode(n *yaml.Node, path []string, v any) {
+	for i, key := range path {
+		var next *yaml.Node
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			if n.Content[j].Value == key {
+				next = n.Content[j+1]
+				break
+			}
+		}
+		if next == nil || (i < len(path)-1 && next.Kind != yaml.MappingNode) {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+		}
+		n = next
+	}
+	var tag string
+	switch v.(type) {
+	case int:
+		tag = "!!int"
+	case bool:
+		tag = "!!bool"
+	default:
+		tag = "!!str"
+	}
+	var style yaml.Style
+	if tag == "!!str" {
+		style = n.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+		if style == 0 && scalarTag(fmt.Sprint(v)) != "!!str" {
+			style = yaml.DoubleQuotedStyle
+		}
+	}
+	*n = yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: fmt.Sprint(v), Style: style}
+}
+
+// untagMergeKeys clears the !!merge tag yaml gives "<<" keys on decode,
+// which it would otherwise write back out as "!!merge <<".
+func untagMergeKeys(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!merge" {
+		n.Tag = ""
+	}
+	for _, c := range n.Content {
+		untagMergeKeys(c)
+	}
+}
+
+// indentOf infers the document's indentation from the first nested mapping.
+func indentOf(top *yaml.Node) int {
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		v := top.Content[i+1]
+		if v.Kind == yaml.MappingNode && len(v.Content) > 0 {
+			if indent := v.Content[0].Column - top.Content[i].Column; indent > 0 {
+				return indent
+			}
+		}
+	}
+	return 4
+}
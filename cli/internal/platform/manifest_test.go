@@ -0,0 +1,80 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+)
+
+const manifestFixture = `apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: media
+  namespace: platform-requests
+spec:
+  vcluster:
+    preset: dev
+    replicas: 1 # single node for now
+    resources:
+      requests:
+        memory: "768Mi"
+        cpu: '200m'
+`
+
+func TestEditManifestInPlace(t *testing.T) {
+	out, err := EditManifest([]byte(manifestFixture), []ManifestEdit{
+		{Path: []string{"spec", "vcluster", "replicas"}, Value: 3},
+		{Path: []string{"spec", "vcluster", "resources", "requests", "memory"}, Value: "2Gi"},
+		{Path: []string{"spec", "vcluster", "resources", "requests", "cpu"}, Value: "500m"},
+		{Path: []string{"spec", "vcluster", "helmOverrides", "x"}, Value: 1, IfExists: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer(
+		"replicas: 1 #", "replicas: 3 #",
+		`"768Mi"`, `"2Gi"`,
+		"'200m'", "'500m'",
+	).Replace(manifestFixture)
+	if string(out) != want {
+		t.Errorf("EditManifest() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestEditManifestCreatesKeys(t *testing.T) {
+	out, err := EditManifest([]byte(manifestFixture), []ManifestEdit{
+		{Path: []string{"spec", "vcluster", "persistence", "enabled"}, Value: true},
+		{Path: []string{"spec", "vcluster", "persistence", "size"}, Value: "10Gi"},
+		{Path: []string{"spec", "vcluster", "coredns", "replicas"}, Value: 2},
+		{Path: []string{"spec", "vcluster", "persistence", "storageClass"}, Value: "true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"    persistence:\n      enabled: true\n      size: 10Gi\n      storageClass: \"true\"\n",
+		"    coredns:\n      replicas: 2\n",
+		`        memory: "768Mi"`,
+	} {
+		if !strings.Contains(string(out), line) {
+			t.Errorf("EditManifest() output missing %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestEditManifestKeepsMergeKeys(t *testing.T) {
+	doc := "defaults: &defaults\n  version: 1.0.0\nsonarr:\n  <<: *defaults\n  enabled: true\n"
+	out, err := EditManifest([]byte(doc), []ManifestEdit{{Path: []string{"sonarr", "syncWave"}, Value: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "defaults: &defaults\n  version: 1.0.0\nsonarr:\n  <<: *defaults\n  enabled: true\n  syncWave: 3\n"
+	if string(out) != want {
+		t.Errorf("EditManifest() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestEditManifestRejectsNonMapping(t *testing.T) {
+	if _, err := EditManifest([]byte("- a\n- b\n"), nil); err == nil {
+		t.Error("expected an error for a sequence document")
+	}
+}
@@ -0,0 +1,196 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PausedAnnotation on a VClusterOrchestratorV2 makes the orchestrator
+// pipeline render the vcluster scaled to zero, and the status reconciler
+// report phase Paused.
+const PausedAnnotation = "platform.integratn.tech/paused"
+
+// PauseRecord is what 'hctl vcluster pause' stores in status.pause so
+// 'hctl vcluster resume' can bring the vcluster back as it was.
+type PauseRecord struct {
+	PausedAt string `json:"pausedAt" yaml:"pausedAt"`
+	// Replicas maps each Deployment and StatefulSet in the target namespace,
+	// as "Kind/name", to its replica count before the pause.
+	Replicas map[string]int32 `json:"replicas" yaml:"replicas"`
+	// SyncedPods is how many workload pods the vcluster had synced to the
+	// host.
+	SyncedPods int `json:"syncedPods" yaml:"syncedPods"`
+}
+
+// IsPaused reports whether vc carries the paused annotation.
+func IsPaused(vc *unstructured.Unstructured) bool {
+	return vc.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// TargetNamespace returns the host namespace a vcluster runs in.
+func TargetNamespace(vc *unstructured.Unstructured) string {
+	if ns, ok, _ := UnstructuredNestedString(vc.Object, "spec", "targetNamespace"); ok && ns != "" {
+		return ns
+	}
+	return vc.GetName()
+}
+
+// SyncedPodSelector selects the workload pods a vcluster syncs to the host.
+func SyncedPodSelector(name string) string {
+	return "vcluster.loft.sh/managed-by=" + name
+}
+
+// ReadPauseRecord returns the record in vc's status.pause, if any.
+func ReadPauseRecord(vc *unstructured.Unstructured) (*PauseRecord, bool) {
+	m, ok, _ := unstructured.NestedMap(vc.Object, "status", "pause")
+	if !ok {
+		return nil, false
+	}
+	r := &PauseRecord{Replicas: map[string]int32{}}
+	r.PausedAt, _ = m["pausedAt"].(string)
+	r.SyncedPods = int(toInt64(m["syncedPods"]))
+	replicas, _ := m["replicas"].(map[string]interface{})
+	for k, v := range replicas {
+		r.Replicas[k] = int32(toInt64(v))
+	}
+	return r, true
+}
+
+// Status returns r as the status.pause value.
+func (r *PauseRecord) Status() map[string]interface{} {
+	replicas := map[string]interface{}{}
+	for k, v := range r.Replicas {
+		replicas[k] = int64(v)
+	}
+	return map[string]interface{}{
+		"pausedAt":   r.PausedAt,
+		"replicas":   replicas,
+		"syncedPods": int64(r.SyncedPods),
+	}
+}
+
+// Workloads returns the recorded "Kind/name" keys, sorted.
+func (r *PauseRecord) Workloads() []string {
+	keys := make([]string, 0, len(r.Replicas))
+	for k := range r.Replicas {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SplitWorkload splits a "Kind/name" record key.
+func SplitWorkload(key string) (kind, name string) {
+	kind, name, _ = strings.Cut(key, "/")
+	return kind, name
+}
+
+// RecordPause captures the replica counts and synced pods of the vcluster
+// running in namespace.
+func RecordPause(ctx context.Context, client *kube.Client, name, namespace string, now time.Time) (*PauseRecord, error) {
+	rollouts, err := client.ListRollouts(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := client.ListPods(ctx, namespace, SyncedPodSelector(name))
+	if err != nil {
+		return nil, err
+	}
+	r := &PauseRecord{
+		PausedAt:   now.UTC().Format(time.RFC3339),
+		Replicas:   map[string]int32{},
+		SyncedPods: len(pods),
+	}
+	for _, ro := range rollouts {
+		r.Replicas[ro.Kind+"/"+ro.Name] = ro.Desired
+	}
+	return r, nil
+}
+
+// WaitForDrain polls until no pods are left in namespace.
+func WaitForDrain(ctx context.Context, client *kube.Client, namespace string, pollInterval time.Duration) (string, error) {
+	for {
+		pods, err := client.ListPods(ctx, namespace, "")
+		if err == nil && len(pods) == 0 {
+			return "no pods running", nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for %d pod(s) in %s to stop", len(pods), namespace)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitForRestore polls until every workload in the record runs its
+// recorded replica count, all ready.
+func WaitForRestore(ctx context.Context, client *kube.Client, namespace string, record *PauseRecord, pollInterval time.Duration) (string, error) {
+	for {
+		var pending []string
+		rollouts, err := client.ListRollouts(ctx, namespace)
+		if err == nil {
+			byKey := map[string]kube.RolloutInfo{}
+			for _, ro := range rollouts {
+				byKey[ro.Kind+"/"+ro.Name] = ro
+			}
+			for _, key := range record.Workloads() {
+				ro, ok := byKey[key]
+				if want := record.Replicas[key]; !ok || ro.Desired != want || ro.Ready != want {
+					pending = append(pending, fmt.Sprintf("%s %d/%d", key, ro.Ready, want))
+				}
+			}
+			if len(pending) == 0 {
+				return fmt.Sprintf("%d workload(s) at their pre-pause replicas", len(record.Replicas)), nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if len(pending) > 0 {
+				return "", fmt.Errorf("timed out waiting for replicas to come back: %s", strings.Join(pending, ", "))
+			}
+			return "", fmt.Errorf("timed out waiting for replicas to come back")
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitForPhase polls until the VClusterOrchestratorV2's status.phase is
+// want, reading legacy phase strings as the phase they mean.
+func WaitForPhase(ctx context.Context, client *kube.Client, namespace, name, want string, pollInterval time.Duration) (string, error) {
+	last := ""
+	for {
+		if vc, err := client.GetVCluster(ctx, namespace, name); err == nil {
+			last, _, _ = UnstructuredNestedString(vc.Object, "status", "phase")
+			if phase.Normalize(last) == want {
+				return "phase " + want, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if last != "" {
+				return "", fmt.Errorf("timed out waiting for phase %s (currently %s)", want, last)
+			}
+			return "", fmt.Errorf("timed out waiting for phase %s", want)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +30,9 @@ type ProvisionResult struct {
 	Endpoints ProvisionEndpoints
 	Health   ProvisionHealth
 	Error    string
+	// Duration is how long the reconciler measured provisioning took, from
+	// the request to its first Ready; zero when it has not recorded one.
+	Duration time.Duration
 }
 
 // ProvisionEndpoints holds the discovered endpoints.
@@ -175,6 +179,35 @@ func WaitForClusterReady(ctx context.Context, client *kube.Client, name string,
 	}
 }
 
+// WaitForRollout polls until every Deployment and StatefulSet in namespace
+// runs its current template with all replicas ready. Rollouts are only
+// judged after ArgoCD has applied the change, so callers wait for the sync
+// first.
+func WaitForRollout(ctx context.Context, client *kube.Client, namespace string, pollInterval time.Duration) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for the rollout to complete")
+		default:
+		}
+
+		rollouts, err := client.ListRollouts(ctx, namespace)
+		if err == nil && len(rollouts) > 0 {
+			done := 0
+			for _, r := range rollouts {
+				if r.Done() {
+					done++
+				}
+			}
+			if done == len(rollouts) {
+				return fmt.Sprintf("%d/%d workloads rolled out", done, len(rollouts)), nil
+			}
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
 // CollectProvisionResult gathers the final result after provisioning completes.
 func CollectProvisionResult(ctx context.Context, client *kube.Client, namespace, name string) (*ProvisionResult, error) {
 	result := &ProvisionResult{Name: name}
@@ -190,12 +223,13 @@ func CollectProvisionResult(ctx context.Context, client *kube.Client, namespace,
 		result.Health.SubAppsHealthy = int(sc.Health.SubAppsHealthy)
 		result.Health.SubAppsTotal = int(sc.Health.SubAppsTotal)
 		result.Health.Unhealthy = sc.Health.SubAppsUnhealthy
-		result.Healthy = sc.Phase == "Ready"
+		result.Healthy = sc.Phase == phase.Ready
+		result.Duration, _ = sc.Provisioning.Duration()
 		return result, nil
 	}
 
 	// Fallback: assemble from individual queries
-	result.Phase = "Ready"
+	result.Phase = phase.Ready
 	result.Healthy = true
 
 	// Pods
@@ -209,7 +243,7 @@ func CollectProvisionResult(ctx context.Context, client *kube.Client, namespace,
 		}
 		if result.Health.ComponentsReady < result.Health.ComponentsTotal {
 			result.Healthy = false
-			result.Phase = "Progressing"
+			result.Phase = phase.Progressing
 		}
 	}
 
@@ -235,7 +269,10 @@ func FormatProvisionSummary(result *ProvisionResult, hostname string) string {
 	var sb strings.Builder
 
 	// Status line
-	if result.Healthy {
+	if result.Healthy && result.Duration > 0 {
+		sb.WriteString(fmt.Sprintf("\n  %s %s is ready! %s\n", tui.SuccessStyle.Render(tui.IconCheck), result.Name,
+			tui.MutedStyle.Render(fmt.Sprintf("(provisioned in %s)", result.Duration))))
+	} else if result.Healthy {
 		sb.WriteString(fmt.Sprintf("\n  %s %s is ready!\n", tui.SuccessStyle.Render(tui.IconCheck), result.Name))
 	} else {
 		sb.WriteString(fmt.Sprintf("\n  %s %s is provisioning (may take a few more minutes)\n", tui.WarningStyle.Render(tui.IconWarn), result.Name))
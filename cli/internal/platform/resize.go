@@ -0,0 +1,323 @@
+package platform
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Sizing is the part of a vCluster spec that 'hctl vcluster resize' changes.
+// Values are effective: fields the request leaves unset carry the preset
+// default the pipeline would apply.
+type Sizing struct {
+	Replicas        int    `json:"replicas" yaml:"replicas"`
+	CPU             string `json:"cpu" yaml:"cpu"`
+	Memory          string `json:"memory" yaml:"memory"`
+	CPULimit        string `json:"cpuLimit" yaml:"cpuLimit"`
+	MemoryLimit     string `json:"memoryLimit" yaml:"memoryLimit"`
+	Persistence     bool   `json:"persistence" yaml:"persistence"`
+	PersistenceSize string `json:"persistenceSize" yaml:"persistenceSize"`
+	StorageClass    string `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+	CoreDNSReplicas int    `json:"corednsReplicas" yaml:"corednsReplicas"`
+	// Etcd is set when the spec deploys a dedicated etcd backing store;
+	// EtcdReplicas is then its member count.
+	Etcd         bool `json:"etcd" yaml:"etcd"`
+	EtcdReplicas int  `json:"etcdReplicas,omitempty" yaml:"etcdReplicas,omitempty"`
+}
+
+// sizingDefaults mirrors applyPresetDefaults in the vcluster-orchestrator-v2
+// configure pipeline, which fills these in when the request omits them.
+var sizingDefaults = map[string]Sizing{
+	"dev": {
+		Replicas: 1, CPU: "200m", Memory: "768Mi", CPULimit: "1000m", MemoryLimit: "1536Mi",
+		Persistence: false, PersistenceSize: "5Gi", CoreDNSReplicas: 1,
+	},
+	"prod": {
+		Replicas: 3, CPU: "500m", Memory: "1Gi", CPULimit: "2", MemoryLimit: "2Gi",
+		Persistence: true, PersistenceSize: "10Gi", CoreDNSReplicas: 2,
+	},
+}
+
+// SizingFromSpec returns the effective sizing of spec.
+func SizingFromSpec(spec VClusterSpec) Sizing {
+	d, ok := sizingDefaults[spec.VCluster.Preset]
+	if !ok {
+		d = sizingDefaults["dev"]
+	}
+	s := d
+	v := spec.VCluster
+	if v.Replicas > 0 {
+		s.Replicas = v.Replicas
+	}
+	if r := v.Resources; r != nil {
+		s.CPU = orDefault(r.Requests["cpu"], s.CPU)
+		s.Memory = orDefault(r.Requests["memory"], s.Memory)
+		s.CPULimit = orDefault(r.Limits["cpu"], s.CPULimit)
+		s.MemoryLimit = orDefault(r.Limits["memory"], s.MemoryLimit)
+	}
+	if p := v.Persistence; p != nil {
+		s.Persistence = p.Enabled
+		s.PersistenceSize = orDefault(p.Size, s.PersistenceSize)
+		s.StorageClass = p.StorageClass
+	}
+	if v.CoreDNS != nil && v.CoreDNS.Replicas > 0 {
+		s.CoreDNSReplicas = v.CoreDNS.Replicas
+	}
+	etcd := nestedMap(v.BackingStore, "etcd", "deploy")
+	if enabled, _ := etcd["enabled"].(bool); enabled {
+		s.Etcd = true
+		s.EtcdReplicas = 1
+		if n, ok := nestedMap(etcd, "statefulSet", "highAvailability")["replicas"].(int); ok && n > 0 {
+			s.EtcdReplicas = n
+		}
+	}
+	return s
+}
+
+// ImpactLevel ranks how disruptive applying a change is, least first.
+type ImpactLevel int
+
+const (
+	// ImpactScale adds or removes pods; running pods are untouched.
+	ImpactScale ImpactLevel = iota
+	// ImpactRollingUpdate replaces pods one at a time.
+	ImpactRollingUpdate
+	// ImpactRestart restarts every pod of a component at once.
+	ImpactRestart
+	// ImpactVolumeExpansion grows existing PVCs in place.
+	ImpactVolumeExpansion
+	// ImpactRecreate requires deleting and recreating the StatefulSet.
+	ImpactRecreate
+	// ImpactBlocked cannot be applied as requested.
+	ImpactBlocked
+)
+
+func (l ImpactLevel) String() string {
+	switch l {
+	case ImpactScale:
+		return "scale"
+	case ImpactRollingUpdate:
+		return "rolling update"
+	case ImpactRestart:
+		return "pod restart"
+	case ImpactVolumeExpansion:
+		return "volume expansion"
+	case ImpactRecreate:
+		return "statefulset recreation"
+	case ImpactBlocked:
+		return "blocked"
+	}
+	return fmt.Sprintf("ImpactLevel(%d)", int(l))
+}
+
+// MarshalText renders the level by name in JSON and YAML output.
+func (l ImpactLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Impact is the assessed effect of one field change.
+type Impact struct {
+	Field     string      `json:"field" yaml:"field"`
+	Old       string      `json:"old" yaml:"old"`
+	New       string      `json:"new" yaml:"new"`
+	Level     ImpactLevel `json:"level" yaml:"level"`
+	Component string      `json:"component" yaml:"component"`
+	Reason    string      `json:"reason" yaml:"reason"`
+}
+
+// ResizeEnv carries cluster facts the impact rules depend on.
+type ResizeEnv struct {
+	// VolumeExpansion reports whether the persistence storage class allows
+	// volume expansion; nil when it could not be determined.
+	VolumeExpansion *bool
+}
+
+// AssessResize describes the effect of moving a vCluster from old to new
+// sizing, one Impact per changed field, in a fixed field order.
+func AssessResize(old, new Sizing, env ResizeEnv) []Impact {
+	var impacts []Impact
+	add := func(field, o, n string, level ImpactLevel, component, reason string) {
+		impacts = append(impacts, Impact{Field: field, Old: o, New: n, Level: level, Component: component, Reason: reason})
+	}
+
+	if old.Etcd != new.Etcd {
+		from, to := "embedded SQLite", "dedicated etcd"
+		if old.Etcd {
+			from, to = to, from
+		}
+		add("etcd", fmt.Sprint(old.Etcd), fmt.Sprint(new.Etcd), ImpactRecreate, "control plane",
+			fmt.Sprintf("the backing store moves from %s to %s and data is not migrated; back up the vCluster before applying", from, to))
+	}
+
+	if old.Replicas != new.Replicas {
+		o, n := fmt.Sprint(old.Replicas), fmt.Sprint(new.Replicas)
+		if new.Replicas > 1 && !new.Etcd {
+			add("replicas", o, n, ImpactBlocked, "control plane",
+				"more than one control plane replica needs the etcd backing store; embedded SQLite cannot be shared")
+		} else {
+			add("replicas", o, n, ImpactScale, "control plane",
+				fmt.Sprintf("the control plane StatefulSet scales from %d to %d; running pods are not restarted", old.Replicas, new.Replicas))
+		}
+	}
+
+	if old.Etcd && new.Etcd && old.EtcdReplicas != new.EtcdReplicas {
+		add("etcd replicas", fmt.Sprint(old.EtcdReplicas), fmt.Sprint(new.EtcdReplicas), ImpactRestart, "etcd",
+			fmt.Sprintf("etcd membership changes from %d to %d members; every etcd pod restarts with the new initial cluster and the API is unavailable until quorum re-forms", old.EtcdReplicas, new.EtcdReplicas))
+	}
+
+	resourceReason := "control plane pods are replaced one at a time"
+	if new.Replicas <= 1 {
+		resourceReason = "the single control plane pod is replaced; the API is briefly unavailable"
+	}
+	for _, f := range []struct{ field, o, n string }{
+		{"cpu", old.CPU, new.CPU},
+		{"memory", old.Memory, new.Memory},
+		{"cpu limit", old.CPULimit, new.CPULimit},
+		{"memory limit", old.MemoryLimit, new.MemoryLimit},
+	} {
+		if !sameQuantity(f.o, f.n) {
+			add(f.field, f.o, f.n, ImpactRollingUpdate, "control plane", resourceReason)
+		}
+	}
+
+	switch {
+	case old.Persistence != new.Persistence:
+		reason := "volumeClaimTemplates are immutable, so the StatefulSet is deleted and recreated; state on the pods' ephemeral storage is lost"
+		if old.Persistence {
+			reason = "volumeClaimTemplates are immutable, so the StatefulSet is deleted and recreated; the existing PVCs are left behind unmounted"
+		}
+		add("persistence", fmt.Sprint(old.Persistence), fmt.Sprint(new.Persistence), ImpactRecreate, "control plane", reason)
+	case new.Persistence && old.StorageClass != new.StorageClass:
+		add("storage class", old.StorageClass, new.StorageClass, ImpactRecreate, "control plane",
+			"existing PVCs keep their storage class; the StatefulSet is recreated and data must be migrated to new claims")
+	case new.Persistence && !sameQuantity(old.PersistenceSize, new.PersistenceSize):
+		level, reason := volumeResize(old.PersistenceSize, new.PersistenceSize, new.StorageClass, env)
+		add("persistence size", old.PersistenceSize, new.PersistenceSize, level, "control plane", reason)
+	}
+
+	if old.CoreDNSReplicas != new.CoreDNSReplicas {
+		add("coredns replicas", fmt.Sprint(old.CoreDNSReplicas), fmt.Sprint(new.CoreDNSReplicas), ImpactScale, "coredns",
+			fmt.Sprintf("the CoreDNS Deployment scales from %d to %d", old.CoreDNSReplicas, new.CoreDNSReplicas))
+	}
+
+	return impacts
+}
+
+// volumeResize assesses a persistence size change on an enabled volume.
+func volumeResize(oldSize, newSize, storageClass string, env ResizeEnv) (ImpactLevel, string) {
+	o, errOld := resource.ParseQuantity(oldSize)
+	n, errNew := resource.ParseQuantity(newSize)
+	if errOld == nil && errNew == nil && n.Cmp(o) < 0 {
+		return ImpactBlocked, "PVCs cannot shrink"
+	}
+	class := storageClass
+	if class == "" {
+		class = "the default storage class"
+	}
+	steps := "the existing PVCs are expanded in place and, because volumeClaimTemplates are immutable, the StatefulSet is recreated with --cascade=orphan so pods keep running"
+	switch {
+	case env.VolumeExpansion == nil:
+		return ImpactVolumeExpansion, fmt.Sprintf("could not check whether %s allows volume expansion; if it does, %s", class, steps)
+	case !*env.VolumeExpansion:
+		return ImpactBlocked, fmt.Sprintf("%s does not allow volume expansion (allowVolumeExpansion is not set)", class)
+	}
+	return ImpactVolumeExpansion, steps
+}
+
+// HighestImpact returns the most disruptive level in impacts, or -1 when
+// there are none.
+func HighestImpact(impacts []Impact) ImpactLevel {
+	highest := ImpactLevel(-1)
+	for _, i := range impacts {
+		highest = max(highest, i.Level)
+	}
+	return highest
+}
+
+// SizingEdits returns the manifest edits that move a request from old to
+// new sizing. Only changed fields are written, and etcd replicas are also
+// updated in helmOverrides when the request pins them there, since the
+// overrides win over the generated values.
+func SizingEdits(old, new Sizing) []ManifestEdit {
+	var edits []ManifestEdit
+	set := func(value any, path ...string) {
+		edits = append(edits, ManifestEdit{Path: append([]string{"spec", "vcluster"}, path...), Value: value})
+	}
+	if old.Replicas != new.Replicas {
+		set(new.Replicas, "replicas")
+	}
+	if !sameQuantity(old.CPU, new.CPU) {
+		set(new.CPU, "resources", "requests", "cpu")
+	}
+	if !sameQuantity(old.Memory, new.Memory) {
+		set(new.Memory, "resources", "requests", "memory")
+	}
+	if !sameQuantity(old.CPULimit, new.CPULimit) {
+		set(new.CPULimit, "resources", "limits", "cpu")
+	}
+	if !sameQuantity(old.MemoryLimit, new.MemoryLimit) {
+		set(new.MemoryLimit, "resources", "limits", "memory")
+	}
+	if old.Persistence != new.Persistence {
+		set(new.Persistence, "persistence", "enabled")
+	}
+	if !sameQuantity(old.PersistenceSize, new.PersistenceSize) {
+		set(new.PersistenceSize, "persistence", "size")
+	}
+	if old.StorageClass != new.StorageClass {
+		set(new.StorageClass, "persistence", "storageClass")
+	}
+	if old.CoreDNSReplicas != new.CoreDNSReplicas {
+		set(new.CoreDNSReplicas, "coredns", "replicas")
+	}
+	if old.Etcd != new.Etcd {
+		set(new.Etcd, "backingStore", "etcd", "deploy", "enabled")
+	}
+	if new.Etcd && old.EtcdReplicas != new.EtcdReplicas {
+		set(new.EtcdReplicas, "backingStore", "etcd", "deploy", "statefulSet", "highAvailability", "replicas")
+		edits = append(edits, ManifestEdit{
+			Path:     []string{"spec", "vcluster", "helmOverrides", "controlPlane", "backingStore", "etcd", "deploy", "statefulSet", "highAvailability", "replicas"},
+			Value:    new.EtcdReplicas,
+			IfExists: true,
+		})
+	}
+	return edits
+}
+
+// sameQuantity compares two resource quantities, falling back to string
+// equality when either does not parse.
+func sameQuantity(a, b string) bool {
+	qa, errA := resource.ParseQuantity(a)
+	qb, errB := resource.ParseQuantity(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return qa.Cmp(qb) == 0
+}
+
+// QuantityLess reports whether quantity a is smaller than b. Unparseable
+// quantities compare as not less.
+func QuantityLess(a, b string) bool {
+	qa, errA := resource.ParseQuantity(a)
+	qb, errB := resource.ParseQuantity(b)
+	return errA == nil && errB == nil && qa.Cmp(qb) < 0
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// nestedMap walks string-keyed maps, returning nil when a level is missing.
+func nestedMap(m map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, k := range keys {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
+}
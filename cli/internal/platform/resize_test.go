@@ -0,0 +1,269 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSizingFromSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want Sizing
+	}{
+		{
+			name: "dev defaults",
+			spec: `vcluster: {preset: dev}`,
+			want: sizingDefaults["dev"],
+		},
+		{
+			name: "unknown preset falls back to dev",
+			spec: `vcluster: {preset: staging}`,
+			want: sizingDefaults["dev"],
+		},
+		{
+			name: "request overrides prod defaults",
+			spec: `
+vcluster:
+  preset: prod
+  resources:
+    requests: {memory: 4Gi}
+  persistence: {enabled: true, storageClass: fast}
+  coredns: {replicas: 3}
+  backingStore:
+    etcd:
+      deploy:
+        enabled: true
+        statefulSet:
+          highAvailability: {replicas: 3}
+`,
+			want: Sizing{
+				Replicas: 3, CPU: "500m", Memory: "4Gi", CPULimit: "2", MemoryLimit: "2Gi",
+				Persistence: true, PersistenceSize: "10Gi", StorageClass: "fast", CoreDNSReplicas: 3,
+				Etcd: true, EtcdReplicas: 3,
+			},
+		},
+		{
+			name: "etcd without HA has one member",
+			spec: `
+vcluster:
+  preset: dev
+  backingStore: {etcd: {deploy: {enabled: true}}}
+`,
+			want: func() Sizing {
+				s := sizingDefaults["dev"]
+				s.Etcd, s.EtcdReplicas = true, 1
+				return s
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spec VClusterSpec
+			if err := yaml.Unmarshal([]byte(tt.spec), &spec); err != nil {
+				t.Fatal(err)
+			}
+			if got := SizingFromSpec(spec); got != tt.want {
+				t.Errorf("SizingFromSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssessResize(t *testing.T) {
+	yes, no := true, false
+	dev := sizingDefaults["dev"]
+	persistent := dev
+	persistent.Persistence, persistent.PersistenceSize, persistent.StorageClass = true, "5Gi", "local-path"
+	ha := sizingDefaults["prod"]
+	ha.Etcd, ha.EtcdReplicas = true, 3
+
+	with := func(s Sizing, f func(*Sizing)) Sizing {
+		f(&s)
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		old, new   Sizing
+		env        ResizeEnv
+		want       []ImpactLevel
+		wantFields []string
+		reason     string
+	}{
+		{
+			name: "no change",
+			old:  dev, new: dev,
+		},
+		{
+			name:       "memory request is a rolling update",
+			old:        dev,
+			new:        with(dev, func(s *Sizing) { s.Memory = "1Gi" }),
+			want:       []ImpactLevel{ImpactRollingUpdate},
+			wantFields: []string{"memory"},
+			reason:     "single control plane pod",
+		},
+		{
+			name: "equal quantities in another unit are unchanged",
+			old:  dev,
+			new:  with(dev, func(s *Sizing) { s.CPU = "0.2" }),
+		},
+		{
+			name:       "replicas without etcd are blocked",
+			old:        dev,
+			new:        with(dev, func(s *Sizing) { s.Replicas = 3 }),
+			want:       []ImpactLevel{ImpactBlocked},
+			wantFields: []string{"replicas"},
+			reason:     "etcd backing store",
+		},
+		{
+			name:       "enabling etcd for HA",
+			old:        dev,
+			new:        with(dev, func(s *Sizing) { s.Etcd, s.EtcdReplicas, s.Replicas = true, 3, 3 }),
+			want:       []ImpactLevel{ImpactRecreate, ImpactScale},
+			wantFields: []string{"etcd", "replicas"},
+		},
+		{
+			name:       "etcd member change restarts etcd",
+			old:        ha,
+			new:        with(ha, func(s *Sizing) { s.Replicas, s.EtcdReplicas = 5, 5 }),
+			want:       []ImpactLevel{ImpactScale, ImpactRestart},
+			wantFields: []string{"replicas", "etcd replicas"},
+		},
+		{
+			name:       "enabling persistence recreates the statefulset",
+			old:        dev,
+			new:        with(dev, func(s *Sizing) { s.Persistence = true }),
+			want:       []ImpactLevel{ImpactRecreate},
+			wantFields: []string{"persistence"},
+		},
+		{
+			name:       "changing storage class recreates the statefulset",
+			old:        persistent,
+			new:        with(persistent, func(s *Sizing) { s.StorageClass = "ceph" }),
+			want:       []ImpactLevel{ImpactRecreate},
+			wantFields: []string{"storage class"},
+		},
+		{
+			name:       "growing a volume that can expand",
+			old:        persistent,
+			new:        with(persistent, func(s *Sizing) { s.PersistenceSize = "20Gi" }),
+			env:        ResizeEnv{VolumeExpansion: &yes},
+			want:       []ImpactLevel{ImpactVolumeExpansion},
+			wantFields: []string{"persistence size"},
+			reason:     "--cascade=orphan",
+		},
+		{
+			name:   "growing a volume whose class cannot expand",
+			old:    persistent,
+			new:    with(persistent, func(s *Sizing) { s.PersistenceSize = "20Gi" }),
+			env:    ResizeEnv{VolumeExpansion: &no},
+			want:   []ImpactLevel{ImpactBlocked},
+			reason: "local-path does not allow volume expansion",
+		},
+		{
+			name:   "growing a volume with unknown expansion support",
+			old:    persistent,
+			new:    with(persistent, func(s *Sizing) { s.PersistenceSize = "20Gi" }),
+			want:   []ImpactLevel{ImpactVolumeExpansion},
+			reason: "could not check",
+		},
+		{
+			name:   "shrinking a volume is blocked",
+			old:    persistent,
+			new:    with(persistent, func(s *Sizing) { s.PersistenceSize = "1Gi" }),
+			env:    ResizeEnv{VolumeExpansion: &yes},
+			want:   []ImpactLevel{ImpactBlocked},
+			reason: "cannot shrink",
+		},
+		{
+			name: "size change with persistence off is ignored",
+			old:  dev,
+			new:  with(dev, func(s *Sizing) { s.PersistenceSize = "20Gi" }),
+		},
+		{
+			name:       "coredns replicas scale",
+			old:        dev,
+			new:        with(dev, func(s *Sizing) { s.CoreDNSReplicas = 2 }),
+			want:       []ImpactLevel{ImpactScale},
+			wantFields: []string{"coredns replicas"},
+		},
+		{
+			name:   "HA resource change rolls pods one at a time",
+			old:    ha,
+			new:    with(ha, func(s *Sizing) { s.CPULimit = "4" }),
+			want:   []ImpactLevel{ImpactRollingUpdate},
+			reason: "one at a time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impacts := AssessResize(tt.old, tt.new, tt.env)
+			var levels []ImpactLevel
+			var fields []string
+			for _, i := range impacts {
+				levels = append(levels, i.Level)
+				fields = append(fields, i.Field)
+			}
+			if !equalSlices(levels, tt.want) {
+				t.Fatalf("levels = %v, want %v (%+v)", levels, tt.want, impacts)
+			}
+			if tt.wantFields != nil && !equalSlices(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+			if tt.reason != "" && !strings.Contains(impacts[len(impacts)-1].Reason, tt.reason) {
+				t.Errorf("reason = %q, want it to mention %q", impacts[len(impacts)-1].Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestHighestImpact(t *testing.T) {
+	if got := HighestImpact(nil); got != -1 {
+		t.Errorf("HighestImpact(nil) = %v, want -1", got)
+	}
+	impacts := []Impact{{Level: ImpactScale}, {Level: ImpactRecreate}, {Level: ImpactRollingUpdate}}
+	if got := HighestImpact(impacts); got != ImpactRecreate {
+		t.Errorf("HighestImpact() = %v, want %v", got, ImpactRecreate)
+	}
+}
+
+func TestSizingEdits(t *testing.T) {
+	old := sizingDefaults["dev"]
+	new := old
+	new.Memory = "1Gi"
+	new.CPU = "0.2" // same quantity, not written
+	new.Etcd, new.EtcdReplicas = true, 3
+
+	var got []string
+	for _, e := range SizingEdits(old, new) {
+		path := strings.Join(e.Path, ".")
+		if e.IfExists {
+			path += "?"
+		}
+		got = append(got, path)
+	}
+	want := []string{
+		"spec.vcluster.resources.requests.memory",
+		"spec.vcluster.backingStore.etcd.deploy.enabled",
+		"spec.vcluster.backingStore.etcd.deploy.statefulSet.highAvailability.replicas",
+		"spec.vcluster.helmOverrides.controlPlane.backingStore.etcd.deploy.statefulSet.highAvailability.replicas?",
+	}
+	if !equalSlices(got, want) {
+		t.Errorf("SizingEdits() paths = %v, want %v", got, want)
+	}
+}
+
+func equalSlices[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
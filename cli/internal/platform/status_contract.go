@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/jamesatintegratnio/hctl/internal/kube"
 	"github.com/jamesatintegratnio/hctl/internal/tui"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -21,6 +22,8 @@ type StatusContract struct {
 	Credentials StatusCredentials
 	Health      StatusHealth
 	Conditions  []StatusCondition
+
+	Provisioning StatusProvisioning
 }
 
 // StatusEndpoints holds discoverable URLs.
@@ -46,15 +49,119 @@ type StatusHealth struct {
 	SubAppsUnhealthy []string
 }
 
+// StatusProvisioning records how long the vcluster took to become Ready
+// for the spec generation it was last provisioned for. Recoveries from
+// Degraded do not change it.
+type StatusProvisioning struct {
+	Generation      int64
+	StartedAt       string
+	ReadyAt         string
+	DurationSeconds int64
+}
+
+// Duration returns the time provisioning took, and false while it is still
+// running or the reconciler has not recorded it.
+func (p StatusProvisioning) Duration() (time.Duration, bool) {
+	started, err := time.Parse(time.RFC3339, p.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+	ready, err := time.Parse(time.RFC3339, p.ReadyAt)
+	if err != nil || ready.Before(started) {
+		return 0, false
+	}
+	return time.Duration(p.DurationSeconds) * time.Second, true
+}
+
 // StatusCondition represents a Kubernetes-style condition.
 type StatusCondition struct {
 	Type               string
 	Status             string
+	ObservedGeneration int64
 	Reason             string
 	Message            string
 	LastTransitionTime string
 }
 
+// Condition types written by the promise pipelines. The status reconciler
+// also owns Ready for vclusters.
+const (
+	ConditionReady             = phase.ConditionReady
+	ConditionValidated         = phase.ConditionValidated
+	ConditionResourcesRendered = phase.ConditionResourcesRendered
+	// ConditionWorkloadRepoAccessible is set by the status reconciler from
+	// the vCluster ArgoCD's workloads ApplicationSet and the Applications it
+	// generates; False names the repository error ArgoCD reported.
+	ConditionWorkloadRepoAccessible = phase.ConditionWorkloadRepoAccessible
+	// ConditionSpecDrift is set by the status reconciler when the vCluster's
+	// ArgoCD Application no longer matches the CR spec, e.g. after a hand
+	// edit; True lists the differing fields.
+	ConditionSpecDrift = phase.ConditionSpecDrift
+	// ConditionBootstrapApplied is set by the status reconciler from the
+	// Job applying spec.bootstrap.manifests; False names the object that
+	// failed and its error.
+	ConditionBootstrapApplied = phase.ConditionBootstrapApplied
+)
+
+// StatusConditions reads status.conditions from a resource object, skipping
+// malformed entries.
+func StatusConditions(obj map[string]interface{}) []StatusCondition {
+	condSlice, _, _ := UnstructuredNestedSlice(obj, "status", "conditions")
+	var conditions []StatusCondition
+	for _, c := range condSlice {
+		condMap, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cond := StatusCondition{}
+		cond.Type, _ = condMap["type"].(string)
+		if cond.Type == "" {
+			continue
+		}
+		cond.Status, _ = condMap["status"].(string)
+		cond.Reason, _ = condMap["reason"].(string)
+		cond.Message, _ = condMap["message"].(string)
+		cond.LastTransitionTime, _ = condMap["lastTransitionTime"].(string)
+		switch g := condMap["observedGeneration"].(type) {
+		case int64:
+			cond.ObservedGeneration = g
+		case float64:
+			cond.ObservedGeneration = int64(g)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions
+}
+
+// FindCondition returns the condition of the given type.
+func FindCondition(conditions []StatusCondition, condType string) (StatusCondition, bool) {
+	for _, c := range conditions {
+		if c.Type == condType {
+			return c, true
+		}
+	}
+	return StatusCondition{}, false
+}
+
+// PhaseFromStatus returns a resource's phase and message. The Ready
+// condition wins when present: True is Ready, otherwise its reason is the
+// phase (Scheduled, Progressing, Deleting, ...). Resources without
+// conditions fall back to status.phase and status.message. The phase is
+// normalized, so legacy strings in older statuses read as today's phases.
+func PhaseFromStatus(obj map[string]interface{}) (p, message string) {
+	if ready, ok := FindCondition(StatusConditions(obj), ConditionReady); ok {
+		switch {
+		case ready.Status == "True":
+			return phase.Ready, ready.Message
+		case ready.Reason != "":
+			return phase.Normalize(ready.Reason), ready.Message
+		}
+	}
+	p, _, _ = UnstructuredNestedString(obj, "status", "phase")
+	message, _, _ = UnstructuredNestedString(obj, "status", "message")
+	return phase.Normalize(p), message
+}
+
 // GetStatusContract reads the .status contract from a VClusterOrchestratorV2 resource.
 func GetStatusContract(ctx context.Context, client *kube.Client, namespace, name string) (*StatusContract, error) {
 	vc, err := client.GetVCluster(ctx, namespace, name)
@@ -69,8 +176,7 @@ func parseStatusContract(vc *unstructured.Unstructured) (*StatusContract, error)
 	sc := &StatusContract{}
 
 	// Top-level fields
-	sc.Phase, _, _ = unstructured.NestedString(vc.Object, "status", "phase")
-	sc.Message, _, _ = unstructured.NestedString(vc.Object, "status", "message")
+	sc.Phase, sc.Message = PhaseFromStatus(vc.Object)
 	sc.LastReconciled, _, _ = unstructured.NestedString(vc.Object, "status", "lastReconciled")
 
 	// Endpoints
@@ -98,29 +204,13 @@ func parseStatusContract(vc *unstructured.Unstructured) (*StatusContract, error)
 	}
 
 	// Conditions
-	if condSlice, found, _ := unstructured.NestedSlice(vc.Object, "status", "conditions"); found {
-		for _, c := range condSlice {
-			if condMap, ok := c.(map[string]interface{}); ok {
-				cond := StatusCondition{}
-				if v, ok := condMap["type"].(string); ok {
-					cond.Type = v
-				}
-				if v, ok := condMap["status"].(string); ok {
-					cond.Status = v
-				}
-				if v, ok := condMap["reason"].(string); ok {
-					cond.Reason = v
-				}
-				if v, ok := condMap["message"].(string); ok {
-					cond.Message = v
-				}
-				if v, ok := condMap["lastTransitionTime"].(string); ok {
-					cond.LastTransitionTime = v
-				}
-				sc.Conditions = append(sc.Conditions, cond)
-			}
-		}
-	}
+	sc.Conditions = StatusConditions(vc.Object)
+
+	// Provisioning
+	sc.Provisioning.Generation, _, _ = unstructured.NestedInt64(vc.Object, "status", "provisioning", "generation")
+	sc.Provisioning.StartedAt, _, _ = unstructured.NestedString(vc.Object, "status", "provisioning", "startedAt")
+	sc.Provisioning.ReadyAt, _, _ = unstructured.NestedString(vc.Object, "status", "provisioning", "readyAt")
+	sc.Provisioning.DurationSeconds, _, _ = unstructured.NestedInt64(vc.Object, "status", "provisioning", "durationSeconds")
 
 	return sc, nil
 }
@@ -139,6 +229,11 @@ func FormatStatusContract(name string, sc *StatusContract) string {
 	if sc.LastReconciled != "" {
 		sb.WriteString(tui.KeyValue("Last Check", formatTimeAgo(sc.LastReconciled)) + "\n")
 	}
+	if d, ok := sc.Provisioning.Duration(); ok {
+		sb.WriteString(tui.KeyValue("Provisioned", fmt.Sprintf("in %s %s", d, tui.MutedStyle.Render("(ready "+formatTimeAgo(sc.Provisioning.ReadyAt)+")"))) + "\n")
+	} else if sc.Provisioning.StartedAt != "" {
+		sb.WriteString(tui.KeyValue("Provisioning", "started "+formatTimeAgo(sc.Provisioning.StartedAt)) + "\n")
+	}
 
 	// Endpoints
 	if sc.Endpoints.API != "" || sc.Endpoints.ArgoCD != "" {
@@ -195,31 +290,51 @@ func FormatStatusContract(name string, sc *StatusContract) string {
 		sb.WriteString(tui.SectionHeader("Conditions") + "\n")
 		for _, c := range sc.Conditions {
 			icon := tui.SuccessStyle.Render(tui.IconCheck)
-			if c.Status != "True" {
+			switch {
+			case c.Type == ConditionSpecDrift && c.Status == "True":
+				icon = tui.WarningStyle.Render(tui.IconWarn)
+			case c.Type == ConditionSpecDrift && c.Status == "False":
+				// No drift is the healthy state.
+			case c.Status != "True":
 				icon = tui.ErrorStyle.Render(tui.IconCross)
 			}
 			ago := formatTimeAgo(c.LastTransitionTime)
 			sb.WriteString(fmt.Sprintf("  %s %-22s %s\n", icon, c.Type, tui.MutedStyle.Render(fmt.Sprintf("(%s, %s)", c.Reason, ago))))
+			// The repo error is otherwise only in the vCluster's ArgoCD, and
+			// the bootstrap error in a Job pod's termination message.
+			if (c.Type == ConditionWorkloadRepoAccessible || c.Type == ConditionBootstrapApplied) && c.Status == "False" && c.Message != "" {
+				sb.WriteString(fmt.Sprintf("    %s\n", tui.ErrorStyle.Render(c.Message)))
+			}
+			// Nothing reconverges a hand-edited Application until the
+			// pipeline runs again.
+			if c.Type == ConditionSpecDrift && c.Status == "True" {
+				sb.WriteString(fmt.Sprintf("    %s\n", tui.WarningStyle.Render(c.Message)))
+				sb.WriteString(fmt.Sprintf("    %s\n", tui.MutedStyle.Render(fmt.Sprintf("Run 'hctl reconcile %s' to re-render the Application from the spec", name))))
+			}
 		}
 	}
 
 	return tui.Box(sb.String())
 }
 
-func phaseStyledIcon(phase string) string {
-	switch phase {
-	case "Ready":
+// phaseStyledIcon returns the icon for a phase. Every phase but Unknown
+// has its own; TestPhaseIconsExhaustive fails when a new one does not.
+func phaseStyledIcon(p string) string {
+	switch p {
+	case phase.Ready, phase.Configured:
 		return tui.SuccessStyle.Render(tui.IconCheck)
-	case "Progressing":
+	case phase.Progressing:
 		return tui.WarningStyle.Render(tui.IconSync)
-	case "Degraded":
+	case phase.Degraded:
 		return tui.WarningStyle.Render(tui.IconWarn)
-	case "Failed":
+	case phase.Failed:
 		return tui.ErrorStyle.Render(tui.IconCross)
-	case "Scheduled":
+	case phase.Scheduled:
 		return tui.MutedStyle.Render(tui.IconPending)
-	case "Deleting":
+	case phase.Deleting:
 		return tui.WarningStyle.Render(tui.IconCross)
+	case phase.Paused, phase.Suspended:
+		return tui.MutedStyle.Render(tui.IconPause)
 	default:
 		return tui.MutedStyle.Render("?")
 	}
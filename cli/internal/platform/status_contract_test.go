@@ -0,0 +1,182 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func statusObject(status map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"status": status}
+}
+
+func TestPhaseFromStatus(t *testing.T) {
+	ready := func(status, reason, message string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"type": "Validated", "status": "True", "reason": "SpecValid"},
+			map[string]interface{}{"type": "Ready", "status": status, "reason": reason, "message": message},
+		}
+	}
+	tests := []struct {
+		name        string
+		status      map[string]interface{}
+		wantPhase   string
+		wantMessage string
+	}{
+		{
+			name:        "ready condition true",
+			status:      map[string]interface{}{"phase": "Configured", "message": "web configured", "conditions": ready("True", "Configured", "web configured")},
+			wantPhase:   "Ready",
+			wantMessage: "web configured",
+		},
+		{
+			name:        "ready condition false uses reason",
+			status:      map[string]interface{}{"phase": "Ready", "message": "stale", "conditions": ready("False", "Deleting", "vc scheduled for deletion")},
+			wantPhase:   "Deleting",
+			wantMessage: "vc scheduled for deletion",
+		},
+		{
+			name:        "no ready condition falls back to phase",
+			status:      map[string]interface{}{"phase": "Configured", "message": "ok", "conditions": []interface{}{map[string]interface{}{"type": "Validated", "status": "True"}}},
+			wantPhase:   "Configured",
+			wantMessage: "ok",
+		},
+		{
+			name:        "no conditions",
+			status:      map[string]interface{}{"phase": "Progressing", "message": "provisioning"},
+			wantPhase:   "Progressing",
+			wantMessage: "provisioning",
+		},
+		{
+			name:        "legacy phase is normalized",
+			status:      map[string]interface{}{"phase": "Terminating", "message": "going"},
+			wantPhase:   "Deleting",
+			wantMessage: "going",
+		},
+		{
+			name:        "pipeline failure reason reads as failed",
+			status:      map[string]interface{}{"phase": "Failed", "message": "bad", "conditions": ready("False", "ValidateFailed", "spec.name is required")},
+			wantPhase:   "Failed",
+			wantMessage: "spec.name is required",
+		},
+		{
+			name:      "empty status",
+			status:    map[string]interface{}{},
+			wantPhase: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, message := PhaseFromStatus(statusObject(tt.status))
+			if phase != tt.wantPhase || message != tt.wantMessage {
+				t.Errorf("PhaseFromStatus() = (%q, %q), want (%q, %q)", phase, message, tt.wantPhase, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestPhaseIconsExhaustive(t *testing.T) {
+	unknown := phaseStyledIcon(phase.Unknown)
+	for _, p := range phase.All {
+		if p != phase.Unknown && phaseStyledIcon(p) == unknown {
+			t.Errorf("phaseStyledIcon has no icon for %s", p)
+		}
+	}
+}
+
+func TestStatusConditions(t *testing.T) {
+	obj := statusObject(map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True", "reason": "AllHealthy", "observedGeneration": int64(4), "lastTransitionTime": "2026-03-01T08:00:00Z"},
+			map[string]interface{}{"status": "True"},
+			"garbage",
+			map[string]interface{}{"type": "ResourcesRendered", "status": "True", "observedGeneration": float64(3)},
+		},
+	})
+	conds := StatusConditions(obj)
+	if len(conds) != 2 {
+		t.Fatalf("got %d conditions, want 2: %+v", len(conds), conds)
+	}
+	if conds[0].ObservedGeneration != 4 || conds[0].LastTransitionTime != "2026-03-01T08:00:00Z" {
+		t.Errorf("Ready = %+v", conds[0])
+	}
+	rendered, ok := FindCondition(conds, ConditionResourcesRendered)
+	if !ok || rendered.ObservedGeneration != 3 {
+		t.Errorf("ResourcesRendered = %+v, %v", rendered, ok)
+	}
+	if _, ok := FindCondition(conds, ConditionValidated); ok {
+		t.Error("found a Validated condition that is not there")
+	}
+}
+
+func TestFormatStatusContractWorkloadRepo(t *testing.T) {
+	sc := &StatusContract{
+		Phase: "Ready",
+		Conditions: []StatusCondition{
+			{Type: ConditionReady, Status: "True", Reason: "AllHealthy", Message: "All components healthy"},
+			{Type: ConditionWorkloadRepoAccessible, Status: "False", Reason: "RepositoryNotFound",
+				Message: "ApplicationSet workloads: repository not found"},
+		},
+	}
+	out := FormatStatusContract("media", sc)
+	if !strings.Contains(out, "WorkloadRepoAccessible") || !strings.Contains(out, "repository not found") {
+		t.Errorf("output does not show the workload repo error:\n%s", out)
+	}
+	if strings.Contains(out, "All components healthy") {
+		t.Errorf("output shows the message of a True condition:\n%s", out)
+	}
+}
+
+func TestFormatStatusContractSpecDrift(t *testing.T) {
+	sc := &StatusContract{
+		Phase: "Ready",
+		Conditions: []StatusCondition{
+			{Type: ConditionReady, Status: "True", Reason: "AllHealthy", Message: "All components healthy"},
+			{Type: ConditionSpecDrift, Status: "True", Reason: "ApplicationDrifted",
+				Message: "ArgoCD Application differs from the CR spec: targetRevision is 0.31.0, spec wants 0.30.4"},
+		},
+	}
+	out := FormatStatusContract("media", sc)
+	if !strings.Contains(out, "targetRevision is 0.31.0, spec wants 0.30.4") || !strings.Contains(out, "hctl reconcile media") {
+		t.Errorf("output does not show the drift and the reconcile hint:\n%s", out)
+	}
+
+	sc.Conditions[1] = StatusCondition{Type: ConditionSpecDrift, Status: "False", Reason: "InSync", Message: "ArgoCD Application matches the CR spec"}
+	if out := FormatStatusContract("media", sc); strings.Contains(out, "hctl reconcile") {
+		t.Errorf("output hints a reconcile without drift:\n%s", out)
+	}
+}
+
+func TestStatusContractProvisioning(t *testing.T) {
+	vc := &unstructured.Unstructured{Object: statusObject(map[string]interface{}{
+		"phase": "Ready",
+		"provisioning": map[string]interface{}{
+			"generation":      int64(2),
+			"startedAt":       "2026-03-01T08:00:00Z",
+			"readyAt":         "2026-03-01T08:07:12Z",
+			"durationSeconds": int64(432),
+		},
+	})}
+	sc, err := parseStatusContract(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := sc.Provisioning.Duration(); !ok || d != 7*time.Minute+12*time.Second {
+		t.Fatalf("Duration() = %s, %v, want 7m12s", d, ok)
+	}
+	if out := FormatStatusContract("media", sc); !strings.Contains(out, "in 7m12s") {
+		t.Errorf("output does not show the provisioning duration:\n%s", out)
+	}
+
+	// A re-provisioning that has not reached Ready yet has no duration.
+	sc.Provisioning.StartedAt = "2026-03-02T08:00:00Z"
+	if _, ok := sc.Provisioning.Duration(); ok {
+		t.Error("a running re-provisioning reports the previous duration")
+	}
+	if out := FormatStatusContract("media", sc); strings.Contains(out, "7m12s") || !strings.Contains(out, "Provisioning") {
+		t.Errorf("output for a running re-provisioning:\n%s", out)
+	}
+}
@@ -0,0 +1,88 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+)
+
+// StatusCache shares one CollectPlatformStatus between views that read the
+// platform's status repeatedly, such as the panes of 'hctl ui'. A status
+// younger than its TTL is served from memory, and concurrent reads of a
+// stale one wait on a single collection. It is safe for concurrent use.
+type StatusCache struct {
+	collect func(ctx context.Context) (*PlatformStatus, error)
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	status   *PlatformStatus
+	err      error
+	at       time.Time
+	inflight chan struct{}
+}
+
+// NewStatusCache returns a StatusCache collecting from client with vClusters
+// in platformNS, and keeping each status for ttl.
+func NewStatusCache(client *kube.Client, platformNS string, ttl time.Duration) *StatusCache {
+	return newStatusCache(func(ctx context.Context) (*PlatformStatus, error) {
+		return CollectPlatformStatus(ctx, client, platformNS)
+	}, ttl)
+}
+
+func newStatusCache(collect func(context.Context) (*PlatformStatus, error), ttl time.Duration) *StatusCache {
+	return &StatusCache{collect: collect, ttl: ttl, now: time.Now}
+}
+
+// Get returns the platform status, collecting it when the cached one is
+// older than the TTL. When a collection fails, the last status collected is
+// returned with the error, so a view can keep showing it while offline; it
+// is nil when there is none.
+func (c *StatusCache) Get(ctx context.Context) (*PlatformStatus, error) {
+	for {
+		c.mu.Lock()
+		if !c.at.IsZero() && c.now().Sub(c.at) < c.ttl {
+			status, err := c.status, c.err
+			c.mu.Unlock()
+			return status, err
+		}
+		if wait := c.inflight; wait != nil {
+			c.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		c.inflight = done
+		c.mu.Unlock()
+
+		status, err := c.collect(ctx)
+
+		c.mu.Lock()
+		if status != nil && err == nil {
+			c.status = status
+		} else if c.status == nil {
+			c.status = status
+		}
+		c.err = err
+		c.at = c.now()
+		c.inflight = nil
+		status = c.status
+		c.mu.Unlock()
+		close(done)
+		return status, err
+	}
+}
+
+// Invalidate drops the cached status, so the next Get collects a new one.
+// The last status is still returned should that collection fail.
+func (c *StatusCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.at = time.Time{}
+}
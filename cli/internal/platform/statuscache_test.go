@@ -0,0 +1,78 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatusCache(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	calls := 0
+	var fail error
+	c := newStatusCache(func(context.Context) (*PlatformStatus, error) {
+		calls++
+		if fail != nil {
+			return nil, fail
+		}
+		return &PlatformStatus{VClusters: []ResourceStatus{{Name: "media"}}}, nil
+	}, 10*time.Second)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(5 * time.Second)
+	if _, err := c.Get(ctx); err != nil || calls != 1 {
+		t.Errorf("Get within the TTL: calls = %d, err = %v, want the cached status", calls, err)
+	}
+
+	now = now.Add(10 * time.Second)
+	fail = errors.New("connection refused")
+	status, err := c.Get(ctx)
+	if err == nil || calls != 2 {
+		t.Errorf("Get after the TTL: calls = %d, err = %v, want a failed collection", calls, err)
+	}
+	if status == nil || status.VClusters[0].Name != "media" {
+		t.Errorf("status after a failed collection = %+v, want the last one", status)
+	}
+
+	fail = nil
+	c.Invalidate()
+	if _, err := c.Get(ctx); err != nil || calls != 3 {
+		t.Errorf("Get after Invalidate: calls = %d, err = %v, want a new collection", calls, err)
+	}
+}
+
+func TestStatusCacheConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	c := newStatusCache(func(context.Context) (*PlatformStatus, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return &PlatformStatus{}, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("collections = %d, want concurrent Gets to share one", calls)
+	}
+}
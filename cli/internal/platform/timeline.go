@@ -0,0 +1,403 @@
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Timeline entry sources.
+const (
+	SourceVCluster = "vcluster"
+	SourceApp      = "app"
+	SourceEvent    = "event"
+	SourceWatch    = "watch"
+)
+
+// maxTrackedEvents bounds how many Events a StatusWatch remembers the count
+// of; past it the oldest are forgotten, and a repeat of one shows again.
+const maxTrackedEvents = 2000
+
+// TimelineEntry is one line of the 'hctl vcluster status --watch' timeline.
+type TimelineEntry struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	Source  string    `json:"source" yaml:"source"`
+	Summary string    `json:"summary" yaml:"summary"`
+	// Warning marks bad news: a condition turning False, a Warning Event,
+	// a dropped watch.
+	Warning bool `json:"warning" yaml:"warning"`
+}
+
+// StatusChanges describes how a resource's status moved from prev to next:
+// its phase and every condition that appeared, disappeared or changed
+// status or reason, as in "condition PodsReady False→True (AllPodsRunning)".
+// warning is true when a condition turned False or the phase worsened to
+// Degraded or Failed. A nil prev is the first sighting and has no changes.
+func StatusChanges(prev, next map[string]interface{}) (changes []string, warning bool) {
+	if prev == nil || next == nil {
+		return nil, false
+	}
+	if before, after := phaseOf(prev), phaseOf(next); before != after {
+		changes = append(changes, fmt.Sprintf("phase %s→%s", orNone(before), orNone(after)))
+		warning = after == phase.Degraded || after == phase.Failed
+	}
+
+	old := map[string]StatusCondition{}
+	for _, c := range StatusConditions(prev) {
+		old[c.Type] = c
+	}
+	for _, c := range StatusConditions(next) {
+		o, seen := old[c.Type]
+		delete(old, c.Type)
+		switch {
+		case !seen:
+			changes = append(changes, fmt.Sprintf("condition %s %s%s", c.Type, c.Status, reasonSuffix(c)))
+		case o.Status != c.Status:
+			changes = append(changes, fmt.Sprintf("condition %s %s→%s%s", c.Type, o.Status, c.Status, reasonSuffix(c)))
+		case o.Reason != c.Reason:
+			changes = append(changes, fmt.Sprintf("condition %s %s: %s→%s", c.Type, c.Status, orNone(o.Reason), orNone(c.Reason)))
+		default:
+			continue
+		}
+		if c.Status == "False" {
+			warning = true
+		}
+	}
+	removed := make([]string, 0, len(old))
+	for t := range old {
+		removed = append(removed, t)
+	}
+	sort.Strings(removed)
+	for _, t := range removed {
+		changes = append(changes, fmt.Sprintf("condition %s removed", t))
+	}
+	return changes, warning
+}
+
+// phaseOf is the phase hctl shows for a status, as PhaseFromStatus reads it.
+func phaseOf(obj map[string]interface{}) string {
+	p, _ := PhaseFromStatus(obj)
+	return p
+}
+
+func reasonSuffix(c StatusCondition) string {
+	msg := c.Reason
+	if c.Status == "False" && c.Message != "" {
+		msg = strings.TrimPrefix(msg+": "+c.Message, ": ")
+	}
+	if msg == "" {
+		return ""
+	}
+	return " (" + msg + ")"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// AppChanges describes how an ArgoCD Application moved from prev to next:
+// its sync and health status, its last operation, and every condition
+// (ComparisonError, SyncError, ...) that appeared, as in
+// "app OutOfSync: ComparisonError: failed to load target state". Argo CD
+// conditions carry no status, so a condition that clears is reported as
+// resolved. A nil prev is the first sighting and has no changes.
+func AppChanges(prev, next map[string]interface{}) (changes []string, warning bool) {
+	if prev == nil || next == nil {
+		return nil, false
+	}
+	operation := func(obj map[string]interface{}) (string, string) {
+		p, _, _ := UnstructuredNestedString(obj, "status", "operationState", "phase")
+		m, _, _ := UnstructuredNestedString(obj, "status", "operationState", "message")
+		return p, m
+	}
+
+	prevSync, prevHealth := appStatus(prev)
+	sync, health := appStatus(next)
+	if prevSync != sync {
+		changes = append(changes, fmt.Sprintf("app sync %s→%s", orNone(prevSync), orNone(sync)))
+	}
+	if prevHealth != health {
+		changes = append(changes, fmt.Sprintf("app health %s→%s", orNone(prevHealth), orNone(health)))
+		warning = health == "Degraded" || health == "Missing"
+	}
+	before, _ := operation(prev)
+	if after, msg := operation(next); before != after && after != "" {
+		line := "app operation " + after
+		if after != "Running" && after != "Succeeded" && msg != "" {
+			line += ": " + firstLine(msg)
+		}
+		changes = append(changes, line)
+		warning = warning || after == "Failed" || after == "Error"
+	}
+
+	old := map[string]bool{}
+	for _, c := range appConditions(prev) {
+		old[c] = true
+	}
+	types := map[string]bool{}
+	for _, c := range appConditions(next) {
+		types[conditionType(c)] = true
+		if !old[c] {
+			changes = append(changes, fmt.Sprintf("app %s: %s", orNone(sync), c))
+			warning = warning || strings.Contains(c, "Error")
+		}
+	}
+	for _, c := range appConditions(prev) {
+		if t := conditionType(c); !types[t] {
+			types[t] = true
+			changes = append(changes, "app "+t+" resolved")
+		}
+	}
+	return changes, warning
+}
+
+func conditionType(c string) string {
+	t, _, _ := strings.Cut(c, ":")
+	return t
+}
+
+// appConditions returns an Application's conditions as "Type: message".
+func appConditions(obj map[string]interface{}) []string {
+	conds, _, _ := UnstructuredNestedSlice(obj, "status", "conditions")
+	var out []string
+	for _, c := range conds {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t, _ := m["type"].(string)
+		msg, _ := m["message"].(string)
+		if t == "" {
+			continue
+		}
+		out = append(out, strings.TrimSuffix(t+": "+firstLine(msg), ": "))
+	}
+	return out
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+// EventSummary describes a core/v1 Event as "event: <message> (Kind/name)",
+// with the repeat count when it has one. warning is true for Warning
+// Events.
+func EventSummary(obj map[string]interface{}) (summary string, warning bool) {
+	msg, _, _ := UnstructuredNestedString(obj, "message")
+	reason, _, _ := UnstructuredNestedString(obj, "reason")
+	kind, _, _ := UnstructuredNestedString(obj, "involvedObject", "kind")
+	name, _, _ := UnstructuredNestedString(obj, "involvedObject", "name")
+	typ, _, _ := UnstructuredNestedString(obj, "type")
+
+	msg = firstLine(msg)
+	if msg == "" {
+		msg = reason
+	}
+	summary = "event: " + msg
+	if kind != "" && name != "" {
+		summary += fmt.Sprintf(" (%s/%s)", kind, name)
+	}
+	if count := eventCount(obj); count > 1 {
+		summary += fmt.Sprintf(" x%d", count)
+	}
+	return summary, typ == "Warning"
+}
+
+func eventCount(obj map[string]interface{}) int64 {
+	if c := toInt64(obj["count"]); c > 0 {
+		return c
+	}
+	if c, ok, _ := unstructured.NestedInt64(obj, "series", "count"); ok {
+		return c
+	}
+	return 1
+}
+
+// StatusWatch folds the watch streams behind 'hctl vcluster status
+// --watch' — the vcluster request, its ArgoCD Application and the Events in
+// its namespace — into timeline entries, remembering what it last saw of
+// each. It holds no references to the watches, so it is driven and tested
+// with plain events.
+type StatusWatch struct {
+	// Name is the vcluster; AppName its ArgoCD Application.
+	Name    string
+	AppName string
+
+	vcluster *unstructured.Unstructured
+	app      map[string]interface{}
+	// events maps an Event's UID to the count last shown; order is the
+	// UIDs oldest first, for forgetting past maxTrackedEvents.
+	events map[string]int64
+	order  []string
+}
+
+// NewStatusWatch returns a StatusWatch for the vcluster name.
+func NewStatusWatch(name string) *StatusWatch {
+	return &StatusWatch{Name: name, AppName: "vcluster-" + name, events: map[string]int64{}}
+}
+
+// VCluster returns the latest sighting of the vcluster request, or nil.
+func (w *StatusWatch) VCluster() *unstructured.Unstructured {
+	return w.vcluster
+}
+
+// Apply folds one event from the watch of source into the timeline entries
+// it produces. Dropped watches become a warning entry.
+func (w *StatusWatch) Apply(source string, ev kube.WatchEvent, now time.Time) []TimelineEntry {
+	if ev.Err != nil {
+		return []TimelineEntry{{Time: now, Source: SourceWatch, Summary: ev.Err.Error() + "; reconnecting", Warning: true}}
+	}
+	if ev.Object == nil {
+		return nil
+	}
+	entries := func(changes []string, warning bool) []TimelineEntry {
+		out := make([]TimelineEntry, 0, len(changes))
+		for _, c := range changes {
+			out = append(out, TimelineEntry{Time: now, Source: source, Summary: c, Warning: warning})
+		}
+		return out
+	}
+
+	switch source {
+	case SourceVCluster:
+		if ev.Object.GetName() != w.Name {
+			return nil
+		}
+		if ev.Type == watch.Deleted {
+			w.vcluster = nil
+			return []TimelineEntry{{Time: now, Source: source, Summary: "request deleted", Warning: true}}
+		}
+		if w.vcluster == nil {
+			w.vcluster = ev.Object
+			p, msg := PhaseFromStatus(ev.Object.Object)
+			return []TimelineEntry{{Time: now, Source: source, Summary: strings.TrimSuffix("phase "+orNone(p)+": "+msg, ": ")}}
+		}
+		prev := w.vcluster.Object
+		w.vcluster = ev.Object
+		return entries(StatusChanges(prev, ev.Object.Object))
+
+	case SourceApp:
+		if ev.Object.GetName() != w.AppName {
+			return nil
+		}
+		if ev.Type == watch.Deleted {
+			w.app = nil
+			return []TimelineEntry{{Time: now, Source: source, Summary: "app " + w.AppName + " deleted", Warning: true}}
+		}
+		prev := w.app
+		w.app = ev.Object.Object
+		if prev == nil {
+			sync, health := appStatus(w.app)
+			return []TimelineEntry{{Time: now, Source: source, Summary: fmt.Sprintf("app %s %s/%s", w.AppName, orNone(sync), orNone(health))}}
+		}
+		return entries(AppChanges(prev, ev.Object.Object))
+
+	case SourceEvent:
+		return w.applyEvent(ev, now)
+	}
+	return nil
+}
+
+// applyEvent shows an Event the first time it is seen and again each time
+// its count goes up; a relist replaying it unchanged shows nothing.
+func (w *StatusWatch) applyEvent(ev kube.WatchEvent, now time.Time) []TimelineEntry {
+	uid := string(ev.Object.GetUID())
+	if uid == "" {
+		uid = ev.Object.GetNamespace() + "/" + ev.Object.GetName()
+	}
+	if ev.Type == watch.Deleted {
+		// Expired Events are deleted; keep the count so a relist does not
+		// show it again before it ages out of the map.
+		return nil
+	}
+	count := eventCount(ev.Object.Object)
+	last, seen := w.events[uid]
+	if seen && count <= last {
+		return nil
+	}
+	if !seen {
+		w.order = append(w.order, uid)
+		for len(w.order) > maxTrackedEvents {
+			delete(w.events, w.order[0])
+			w.order = w.order[1:]
+		}
+	}
+	w.events[uid] = count
+	summary, warning := EventSummary(ev.Object.Object)
+	return []TimelineEntry{{Time: eventTime(ev.Object.Object, now), Source: SourceEvent, Summary: summary, Warning: warning}}
+}
+
+// eventTime is when an Event last happened, or now when it does not say.
+func eventTime(obj map[string]interface{}, now time.Time) time.Time {
+	for _, field := range [][]string{{"lastTimestamp"}, {"series", "lastObservedTime"}, {"eventTime"}} {
+		if s, _, _ := UnstructuredNestedString(obj, field...); s != "" {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				return t
+			}
+		}
+	}
+	return now
+}
+
+// Header formats what the watch last saw — phase, ArgoCD and pod health,
+// and the conditions — for the sticky header above the timeline.
+func (w *StatusWatch) Header() string {
+	if w.vcluster == nil {
+		return tui.KeyValue("Phase", tui.DimStyle.Render("waiting for "+w.Name+"…"))
+	}
+	sc, _ := parseStatusContract(w.vcluster)
+
+	var sb strings.Builder
+	line := sc.Phase + " " + phaseStyledIcon(sc.Phase)
+	if sc.Message != "" {
+		line += "  " + tui.MutedStyle.Render(sc.Message)
+	}
+	sb.WriteString(tui.KeyValue("Phase", line) + "\n")
+	// The Application is watched directly, so it is fresher than the
+	// reconciler's copy in status.health.
+	sync, health := sc.Health.ArgoCDSync, sc.Health.ArgoCDHealth
+	if w.app != nil {
+		sync, health = appStatus(w.app)
+	}
+	if sync != "" || health != "" {
+		sb.WriteString(tui.KeyValue("ArgoCD", orNone(sync)+" / "+orNone(health)) + "\n")
+	}
+	if sc.Health.PodsTotal > 0 {
+		sb.WriteString(tui.KeyValue("Pods", fmt.Sprintf("%d/%d Ready", sc.Health.PodsReady, sc.Health.PodsTotal)) + "\n")
+	}
+	if len(sc.Conditions) > 0 {
+		rows := make([][]string, 0, len(sc.Conditions))
+		for _, c := range sc.Conditions {
+			rows = append(rows, []string{c.Type, tui.StatusIcon(c.Status == "True") + " " + c.Status, c.Reason, transitionClock(c.LastTransitionTime)})
+		}
+		sb.WriteString("\n" + tui.Table([]string{"CONDITION", "STATUS", "REASON", "SINCE"}, rows))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func appStatus(app map[string]interface{}) (sync, health string) {
+	sync, _, _ = UnstructuredNestedString(app, "status", "sync", "status")
+	health, _, _ = UnstructuredNestedString(app, "status", "health", "status")
+	return sync, health
+}
+
+// transitionClock shows a condition's lastTransitionTime as local time; the
+// header is only redrawn on changes, so a relative age would go stale.
+func transitionClock(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("Jan 02 15:04:05")
+}
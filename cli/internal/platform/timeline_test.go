@@ -0,0 +1,267 @@
+package platform
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func conditions(conds ...map[string]interface{}) map[string]interface{} {
+	list := make([]interface{}, len(conds))
+	for i, c := range conds {
+		list[i] = c
+	}
+	return statusObject(map[string]interface{}{"conditions": list})
+}
+
+func cond(typ, status, reason string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "status": status, "reason": reason}
+}
+
+func TestStatusChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev, next  map[string]interface{}
+		want        []string
+		wantWarning bool
+	}{
+		{
+			name: "first sighting",
+			next: conditions(cond("Ready", "True", "AllHealthy")),
+		},
+		{
+			name: "unchanged",
+			prev: conditions(cond("Ready", "True", "AllHealthy"), cond("PodsReady", "True", "AllPodsRunning")),
+			next: conditions(cond("PodsReady", "True", "AllPodsRunning"), cond("Ready", "True", "AllHealthy")),
+		},
+		{
+			name: "condition recovers",
+			prev: conditions(cond("Ready", "False", "Progressing"), cond("PodsReady", "False", "PodsNotReady")),
+			next: conditions(cond("Ready", "True", "AllHealthy"), cond("PodsReady", "True", "AllPodsRunning")),
+			want: []string{
+				"phase Progressing→Ready",
+				"condition Ready False→True (AllHealthy)",
+				"condition PodsReady False→True (AllPodsRunning)",
+			},
+		},
+		{
+			name: "condition fails with its message",
+			prev: conditions(cond("Ready", "True", "AllHealthy"), cond("CertificatesValid", "True", "CertificatesValid")),
+			next: conditions(cond("Ready", "True", "AllHealthy"), map[string]interface{}{
+				"type": "CertificatesValid", "status": "False", "reason": "Expiring", "message": "etcd-server expires in 3d",
+			}),
+			want:        []string{"condition CertificatesValid True→False (Expiring: etcd-server expires in 3d)"},
+			wantWarning: true,
+		},
+		{
+			name: "reason changes, status does not",
+			prev: conditions(cond("Ready", "False", "Progressing")),
+			next: conditions(cond("Ready", "False", "Degraded")),
+			want: []string{
+				"phase Progressing→Degraded",
+				"condition Ready False: Progressing→Degraded",
+			},
+			wantWarning: true,
+		},
+		{
+			name: "added and removed",
+			prev: conditions(cond("Ready", "True", "AllHealthy"), cond("NamespaceStuck", "True", "Finalizers")),
+			next: conditions(cond("Ready", "True", "AllHealthy"), cond("ArgoSynced", "True", "Synced")),
+			want: []string{
+				"condition ArgoSynced True (Synced)",
+				"condition NamespaceStuck removed",
+			},
+		},
+		{
+			name: "legacy phase normalizes to no change",
+			prev: statusObject(map[string]interface{}{"phase": "Terminating"}),
+			next: statusObject(map[string]interface{}{"phase": "Deleting"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warning := StatusChanges(tt.prev, tt.next)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+			if warning != tt.wantWarning {
+				t.Errorf("warning = %v, want %v", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func argoApp(sync, health string, conds ...string) map[string]interface{} {
+	status := map[string]interface{}{
+		"sync":   map[string]interface{}{"status": sync},
+		"health": map[string]interface{}{"status": health},
+	}
+	var list []interface{}
+	for _, c := range conds {
+		typ, msg, _ := strings.Cut(c, ": ")
+		list = append(list, map[string]interface{}{"type": typ, "message": msg})
+	}
+	if list != nil {
+		status["conditions"] = list
+	}
+	return map[string]interface{}{"status": status}
+}
+
+func TestAppChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev, next  map[string]interface{}
+		want        []string
+		wantWarning bool
+	}{
+		{
+			name: "first sighting",
+			next: argoApp("Synced", "Healthy"),
+		},
+		{
+			name: "comparison error",
+			prev: argoApp("Synced", "Healthy"),
+			next: argoApp("OutOfSync", "Healthy", "ComparisonError: failed to load target state: chart not found\nmore detail"),
+			want: []string{
+				"app sync Synced→OutOfSync",
+				"app OutOfSync: ComparisonError: failed to load target state: chart not found",
+			},
+			wantWarning: true,
+		},
+		{
+			name:        "condition message changes",
+			prev:        argoApp("OutOfSync", "Healthy", "ComparisonError: timeout"),
+			next:        argoApp("OutOfSync", "Healthy", "ComparisonError: chart not found"),
+			want:        []string{"app OutOfSync: ComparisonError: chart not found"},
+			wantWarning: true,
+		},
+		{
+			name: "condition clears",
+			prev: argoApp("OutOfSync", "Progressing", "ComparisonError: timeout"),
+			next: argoApp("Synced", "Healthy"),
+			want: []string{
+				"app sync OutOfSync→Synced",
+				"app health Progressing→Healthy",
+				"app ComparisonError resolved",
+			},
+		},
+		{
+			name:        "degraded",
+			prev:        argoApp("Synced", "Healthy"),
+			next:        argoApp("Synced", "Degraded"),
+			want:        []string{"app health Healthy→Degraded"},
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warning := AppChanges(tt.prev, tt.next)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+			if warning != tt.wantWarning {
+				t.Errorf("warning = %v, want %v", warning, tt.wantWarning)
+			}
+		})
+	}
+
+	prev := argoApp("Synced", "Healthy")
+	next := argoApp("Synced", "Healthy")
+	next["status"].(map[string]interface{})["operationState"] = map[string]interface{}{"phase": "Failed", "message": "one or more objects failed to apply"}
+	if got, warning := AppChanges(prev, next); !reflect.DeepEqual(got, []string{"app operation Failed: one or more objects failed to apply"}) || !warning {
+		t.Errorf("failed operation = %q, %v", got, warning)
+	}
+}
+
+func event(uid, typ, message string, count int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"type":           typ,
+		"reason":         "BackOff",
+		"message":        message,
+		"count":          count,
+		"lastTimestamp":  "2026-03-01T10:00:00Z",
+		"involvedObject": map[string]interface{}{"kind": "Pod", "name": "media-0"},
+	}}
+	obj.SetName("media-0." + uid)
+	obj.SetUID(types.UID(uid))
+	return obj
+}
+
+func TestEventSummary(t *testing.T) {
+	got, warning := EventSummary(event("1", "Warning", "Back-off restarting failed container syncer", 4).Object)
+	if want := "event: Back-off restarting failed container syncer (Pod/media-0) x4"; got != want || !warning {
+		t.Errorf("EventSummary = %q, %v; want %q, true", got, warning, want)
+	}
+	got, warning = EventSummary(map[string]interface{}{"type": "Normal", "reason": "Scheduled"})
+	if got != "event: Scheduled" || warning {
+		t.Errorf("EventSummary without a message = %q, %v", got, warning)
+	}
+}
+
+func TestStatusWatchApply(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC)
+	w := NewStatusWatch("media")
+	summaries := func(entries []TimelineEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Source+" "+e.Summary)
+		}
+		return out
+	}
+	vc := func(name string, status map[string]interface{}) kube.WatchEvent {
+		obj := &unstructured.Unstructured{Object: status}
+		obj.SetName(name)
+		return kube.WatchEvent{Type: watch.Modified, Object: obj}
+	}
+
+	if got := summaries(w.Apply(SourceVCluster, vc("media", conditions(cond("Ready", "False", "Progressing"))), now)); !reflect.DeepEqual(got, []string{"vcluster phase Progressing"}) {
+		t.Errorf("first sighting = %q", got)
+	}
+	if got := w.Apply(SourceVCluster, vc("other", conditions(cond("Ready", "True", "AllHealthy"))), now); got != nil {
+		t.Errorf("another vcluster should be ignored, got %q", summaries(got))
+	}
+	if got := summaries(w.Apply(SourceVCluster, vc("media", conditions(cond("Ready", "True", "AllHealthy"))), now)); !reflect.DeepEqual(got, []string{"vcluster phase Progressing→Ready", "vcluster condition Ready False→True (AllHealthy)"}) {
+		t.Errorf("change = %q", got)
+	}
+	if !strings.Contains(w.Header(), "Ready") {
+		t.Errorf("header does not show the phase:\n%s", w.Header())
+	}
+
+	// Events show once, then again only when their count goes up.
+	apply := func(e *unstructured.Unstructured) []TimelineEntry {
+		return w.Apply(SourceEvent, kube.WatchEvent{Type: watch.Added, Object: e}, now)
+	}
+	got := apply(event("1", "Warning", "Back-off restarting failed container syncer", 1))
+	if len(got) != 1 || !got[0].Warning || !got[0].Time.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("new event = %+v, want one warning at its lastTimestamp", got)
+	}
+	if got := apply(event("1", "Warning", "Back-off restarting failed container syncer", 1)); got != nil {
+		t.Errorf("replayed event = %q, want nothing", summaries(got))
+	}
+	if got := apply(event("1", "Warning", "Back-off restarting failed container syncer", 2)); len(got) != 1 {
+		t.Errorf("repeated event = %q, want it shown again", summaries(got))
+	}
+
+	got = w.Apply(SourceApp, kube.WatchEvent{Err: errors.New("watching applications: connection reset")}, now)
+	if len(got) != 1 || got[0].Source != SourceWatch || !got[0].Warning {
+		t.Errorf("dropped watch = %+v", got)
+	}
+}
+
+func TestStatusWatchForgetsOldEvents(t *testing.T) {
+	w := NewStatusWatch("media")
+	for i := 0; i < maxTrackedEvents+10; i++ {
+		w.Apply(SourceEvent, kube.WatchEvent{Type: watch.Added, Object: event(strconv.Itoa(i), "Normal", "Pulled", 1)}, time.Now())
+	}
+	if len(w.events) != maxTrackedEvents || len(w.order) != maxTrackedEvents {
+		t.Errorf("tracking %d events (%d ordered), want at most %d", len(w.events), len(w.order), maxTrackedEvents)
+	}
+}
@@ -1,6 +1,10 @@
 package platform
 
-import "time"
+import (
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+)
 
 // ResourceKind identifies the category of platform resource.
 type ResourceKind string
@@ -45,6 +49,9 @@ type ArgoCDInfo struct {
 	SyncStatus   string `json:"syncStatus" yaml:"syncStatus"`
 	HealthStatus string `json:"healthStatus" yaml:"healthStatus"`
 	Project      string `json:"project,omitempty" yaml:"project,omitempty"`
+	Revision     string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	// Message is the last sync operation's message.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
 }
 
 // PodInfo reflects pod readiness counts.
@@ -70,16 +77,16 @@ type PlatformStatus struct {
 func PhaseFromArgoCD(syncStatus, healthStatus string) string {
 	switch {
 	case syncStatus == "Synced" && healthStatus == "Healthy":
-		return "Ready"
+		return phase.Ready
 	case healthStatus == "Degraded":
-		return "Degraded"
+		return phase.Degraded
 	case healthStatus == "Missing" || syncStatus == "Unknown":
-		return "Unknown"
+		return phase.Unknown
 	case healthStatus == "Progressing" || syncStatus == "OutOfSync":
-		return "Progressing"
+		return phase.Progressing
 	case healthStatus == "Suspended":
-		return "Suspended"
+		return phase.Suspended
 	default:
-		return "Progressing"
+		return phase.Progressing
 	}
 }
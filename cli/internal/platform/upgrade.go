@@ -0,0 +1,77 @@
+package platform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The orchestrator's defaults for spec.vcluster.k8sVersion and
+// spec.argocdApplication.targetRevision.
+const (
+	DefaultK8sVersion   = "v1.34.3"
+	DefaultChartVersion = "0.30.4"
+)
+
+// CheckVersionSkew applies the orchestrator pipeline's upgrade policy to a
+// Kubernetes version change: patch changes either way, and at most one
+// minor version up. A major change or minor downgrade is rejected.
+func CheckVersionSkew(from, to string) error {
+	fromMajor, fromMinor, err := parseMinorVersion(from)
+	if err != nil {
+		return fmt.Errorf("current version: %w", err)
+	}
+	toMajor, toMinor, err := parseMinorVersion(to)
+	if err != nil {
+		return err
+	}
+	switch {
+	case fromMajor != toMajor:
+		return fmt.Errorf("cannot change the Kubernetes major version from %s to %s", from, to)
+	case toMinor < fromMinor:
+		return fmt.Errorf("cannot downgrade from %s to %s: the vcluster's datastore is already migrated to %d.%d",
+			from, to, fromMajor, fromMinor)
+	case toMinor > fromMinor+1:
+		return fmt.Errorf("cannot upgrade from %s to %s: vcluster upgrades one minor version at a time, so upgrade to %d.%d first",
+			from, to, fromMajor, fromMinor+1)
+	}
+	return nil
+}
+
+// parseMinorVersion reads the major and minor of a version such as v1.34.3
+// or 1.33.
+func parseMinorVersion(v string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) >= 2 {
+		major, errMajor := strconv.Atoi(parts[0])
+		minor, errMinor := strconv.Atoi(parts[1])
+		if errMajor == nil && errMinor == nil {
+			return major, minor, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("version %q is not <major>.<minor>[.<patch>]", v)
+}
+
+// RecordedVersions returns the Kubernetes and chart versions the
+// orchestrator pipeline last rendered for vc, from status.k8sVersion and
+// status.chartVersion; empty before its first run.
+func RecordedVersions(vc *unstructured.Unstructured) (k8s, chart string) {
+	k8s, _, _ = UnstructuredNestedString(vc.Object, "status", "k8sVersion")
+	chart, _, _ = UnstructuredNestedString(vc.Object, "status", "chartVersion")
+	return k8s, chart
+}
+
+// UpgradeEdits returns the manifest edits that request the given versions;
+// an empty version is left as it is.
+func UpgradeEdits(k8sVersion, chartVersion string) []ManifestEdit {
+	var edits []ManifestEdit
+	if k8sVersion != "" {
+		edits = append(edits, ManifestEdit{Path: []string{"spec", "vcluster", "k8sVersion"}, Value: k8sVersion})
+	}
+	if chartVersion != "" {
+		edits = append(edits, ManifestEdit{Path: []string{"spec", "argocdApplication", "targetRevision"}, Value: chartVersion})
+	}
+	return edits
+}
@@ -0,0 +1,66 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  string
+	}{
+		{"v1.33.2", "v1.34.3", ""},
+		{"v1.34.3", "v1.34.1", ""},
+		{"1.33", "v1.33.4", ""},
+		{"v1.32.1", "v1.34.3", "upgrade to 1.33 first"},
+		{"v1.34.3", "1.33", "cannot downgrade"},
+		{"v1.34.3", "v2.0.0", "major version"},
+		{"v1.34.3", "latest", "not <major>.<minor>"},
+		{"", "v1.34.3", "current version"},
+	}
+	for _, tt := range tests {
+		err := CheckVersionSkew(tt.from, tt.to)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s → %s: %v", tt.from, tt.to, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s → %s: error %v, want %q", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUpgradeEdits(t *testing.T) {
+	doc := []byte(`apiVersion: platform.integratn.tech/v1alpha1
+kind: VClusterOrchestratorV2
+metadata:
+  name: media
+spec:
+  name: media
+  vcluster:
+    preset: prod # sized for media
+  argocdApplication:
+    targetRevision: 0.30.4
+`)
+	out, err := EditManifest(doc, UpgradeEdits("1.35", "0.31.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"preset: prod # sized for media", "k8sVersion: \"1.35\"", "targetRevision: 0.31.0"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("edited manifest lacks %q:\n%s", want, out)
+		}
+	}
+	if len(UpgradeEdits("", "0.31.0")) != 1 {
+		t.Error("an empty version was edited")
+	}
+
+	vc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"k8sVersion": "v1.33.2", "chartVersion": "0.30.4"},
+	}}
+	if k8s, chart := RecordedVersions(vc); k8s != "v1.33.2" || chart != "0.30.4" {
+		t.Errorf("RecordedVersions = %s, %s", k8s, chart)
+	}
+}
@@ -0,0 +1,349 @@
+package platform
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Validation functions for vCluster create inputs. Each returns an error
+// suitable for showing inline next to the prompt; hctl vcluster create also
+// applies them to flag values so mistakes fail before anything is written.
+
+// Warning is a validation result that does not block the value: the input
+// is usable but probably not what the user meant.
+type Warning struct {
+	Message string
+}
+
+func (w *Warning) Error() string { return w.Message }
+
+// IsWarning marks the error as non-blocking for tui.Prompt.
+func (w *Warning) IsWarning() bool { return true }
+
+// ValidateVClusterName checks that name is a DNS-1123 label (it becomes the
+// namespace and release name) and, when repoPath is set, that no request
+// manifest already exists for it.
+func ValidateVClusterName(name, repoPath string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", name, errs[0])
+	}
+	if repoPath != "" {
+		manifest := layout.VClusterManifest(name)
+		if _, err := os.Stat(repopath.Abs(repoPath, manifest)); err == nil {
+			return fmt.Errorf("vCluster %q already exists (%s)", name, manifest)
+		}
+	}
+	return nil
+}
+
+// ValidateHostname checks that host is a valid DNS name. A name outside the
+// platform domain returns a *Warning, since its certificate and DNS record
+// will not be managed by the platform.
+func ValidateHostname(host, domain string) error {
+	if host == "" {
+		return fmt.Errorf("hostname is required")
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return fmt.Errorf("invalid hostname %q: %s", host, errs[0])
+	}
+	if domain != "" && !strings.HasSuffix(host, "."+domain) {
+		return &Warning{Message: fmt.Sprintf("%s is not under the platform domain %s — DNS and TLS will not be managed for it", host, domain)}
+	}
+	return nil
+}
+
+// IP families of a vCluster's API Service (spec.exposure.ipFamilies).
+const (
+	IPFamilyIPv4 = "IPv4"
+	IPFamilyIPv6 = "IPv6"
+)
+
+// IPFamilyPolicies are the accepted spec.exposure.ipFamilyPolicy values.
+var IPFamilyPolicies = []string{"SingleStack", "PreferDualStack", "RequireDualStack"}
+
+func addrFamily(a netip.Addr) string {
+	if a.Unmap().Is4() {
+		return IPFamilyIPv4
+	}
+	return IPFamilyIPv6
+}
+
+// splitDualStack splits a comma-separated dual-stack value, parses each
+// entry and checks there is at most one per family.
+func splitDualStack[T any](value string, parse func(string) (T, error), family func(T) string) ([]T, error) {
+	var out []T
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ",") {
+		v, err := parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		f := family(v)
+		if seen[f] {
+			return nil, fmt.Errorf("%q lists two %s entries; give at most one %s and one %s", value, f, IPFamilyIPv4, IPFamilyIPv6)
+		}
+		seen[f] = true
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func parseSubnets(subnet string) ([]netip.Prefix, error) {
+	return splitDualStack(subnet, func(s string) (netip.Prefix, error) {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid subnet %q: expected CIDR notation such as 10.0.4.0/24 or fd00:4::/64", s)
+		}
+		return p.Masked(), nil
+	}, func(p netip.Prefix) string { return addrFamily(p.Addr()) })
+}
+
+// ValidateSubnet checks that subnet is an IPv4 or IPv6 CIDR, or one of each
+// separated by a comma for dual-stack.
+func ValidateSubnet(subnet string) error {
+	_, err := parseSubnets(subnet)
+	return err
+}
+
+// ValidateVIP checks that vip is an IP address inside the subnet of its
+// family, or one address per family for dual-stack. The network and (IPv4)
+// broadcast addresses are rejected.
+func ValidateVIP(vip, subnet string) error {
+	vips, err := splitDualStack(vip, func(s string) (netip.Addr, error) {
+		a, err := netip.ParseAddr(s)
+		if err != nil || a.Zone() != "" {
+			return netip.Addr{}, fmt.Errorf("invalid VIP %q: expected an IP address such as 10.0.4.210 or fd00:4::d2", s)
+		}
+		return a.Unmap(), nil
+	}, addrFamily)
+	if err != nil {
+		return err
+	}
+	if subnet == "" {
+		return nil
+	}
+	subnets, err := parseSubnets(subnet)
+	if err != nil {
+		return err
+	}
+	for _, ip := range vips {
+		network := subnets[len(subnets)-1]
+		for _, s := range subnets {
+			if addrFamily(s.Addr()) == addrFamily(ip) {
+				network = s
+			}
+		}
+		if vf, sf := addrFamily(ip), addrFamily(network.Addr()); vf != sf {
+			return fmt.Errorf("VIP %s is an %s address but subnet %s is %s", ip, vf, subnet, sf)
+		}
+		if !network.Contains(ip) {
+			return fmt.Errorf("VIP %s is outside subnet %s", ip, network)
+		}
+		if ip == network.Addr() {
+			return fmt.Errorf("VIP %s is the network address of %s", ip, network)
+		}
+		if ip.Is4() && ip == prefixRange(network).last {
+			return fmt.Errorf("VIP %s is the broadcast address of %s", ip, network)
+		}
+	}
+	return nil
+}
+
+// ValidateIPFamilies checks spec.exposure.ipFamilies and ipFamilyPolicy as
+// the Service API would: at most one of each family, and no SingleStack
+// policy for two.
+func ValidateIPFamilies(families []string, policy string) error {
+	seen := map[string]bool{}
+	for _, f := range families {
+		if f != IPFamilyIPv4 && f != IPFamilyIPv6 {
+			return fmt.Errorf("invalid IP family %q: expected %s or %s", f, IPFamilyIPv4, IPFamilyIPv6)
+		}
+		if seen[f] {
+			return fmt.Errorf("IP family %s is listed twice", f)
+		}
+		seen[f] = true
+	}
+	if policy == "" {
+		return nil
+	}
+	valid := false
+	for _, p := range IPFamilyPolicies {
+		valid = valid || p == policy
+	}
+	if !valid {
+		return fmt.Errorf("invalid IP family policy %q: expected one of %s", policy, strings.Join(IPFamilyPolicies, ", "))
+	}
+	if policy == "SingleStack" && len(families) > 1 {
+		return fmt.Errorf("IP family policy SingleStack cannot serve both %s", strings.Join(families, " and "))
+	}
+	return nil
+}
+
+// ValidateAddressPool checks that pool is a CIDR or range, or one of each
+// family for dual-stack, that overlaps neither subnet (the exposure subnet,
+// when set) nor the pools of other vClusters, given by name.
+func ValidateAddressPool(pool, subnet string, others map[string]string) error {
+	r, err := parseAddressRanges(pool)
+	if err != nil {
+		return err
+	}
+	if subnet != "" {
+		if s, err := parseAddressRanges(subnet); err == nil && rangesOverlap(r, s) {
+			return fmt.Errorf("address pool %s overlaps the exposure subnet %s", pool, subnet)
+		}
+	}
+	names := make([]string, 0, len(others))
+	for name := range others {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if o, err := parseAddressRanges(others[name]); err == nil && rangesOverlap(r, o) {
+			return fmt.Errorf("address pool %s overlaps vCluster %s's pool %s", pool, name, others[name])
+		}
+	}
+	return nil
+}
+
+// DeclaredAddressPools returns the load balancer pools of the vCluster
+// manifests under platform/vclusters in repoPath, by vCluster name.
+func DeclaredAddressPools(repoPath string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	pools := make(map[string]string)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var r VClusterResource
+		if err := yaml.Unmarshal(data, &r); err != nil {
+			continue // not a vCluster request
+		}
+		if n := r.Spec.VCluster.Networking; n != nil && n.LoadBalancer != nil && n.LoadBalancer.AddressPool != "" {
+			pools[r.Spec.Name] = n.LoadBalancer.AddressPool
+		}
+	}
+	return pools, nil
+}
+
+// addressRange is an inclusive range of IPv4 or IPv6 addresses.
+type addressRange struct {
+	first, last netip.Addr
+}
+
+// overlaps reports whether r and o share an address; ranges of different
+// families never do.
+func (r addressRange) overlaps(o addressRange) bool {
+	return r.first.Is4() == o.first.Is4() && r.first.Compare(o.last) <= 0 && o.first.Compare(r.last) <= 0
+}
+
+func rangesOverlap(a, b []addressRange) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.overlaps(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prefixRange is the range of addresses in p.
+func prefixRange(p netip.Prefix) addressRange {
+	first := p.Masked().Addr()
+	last := first.AsSlice()
+	for bit := p.Bits(); bit < len(last)*8; bit++ {
+		last[bit/8] |= 0x80 >> (bit % 8)
+	}
+	l, _ := netip.AddrFromSlice(last)
+	return addressRange{first: first, last: l}
+}
+
+// parseAddressRanges parses a MetalLB address pool, or an exposure subnet:
+// a CIDR or an inclusive first-last range, or one of each family separated
+// by a comma.
+func parseAddressRanges(pool string) ([]addressRange, error) {
+	return splitDualStack(pool, func(s string) (addressRange, error) {
+		return parseAddressRange(pool, s)
+	}, func(r addressRange) string { return addrFamily(r.first) })
+}
+
+func parseAddressRange(pool, s string) (addressRange, error) {
+	invalid := fmt.Errorf("invalid address pool %q: expected a CIDR such as 10.0.5.16/28 or fd00:5::/120, or a range such as 10.0.5.10-10.0.5.20", pool)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return addressRange{}, invalid
+		}
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return prefixRange(p), nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	start, err1 := netip.ParseAddr(strings.TrimSpace(from))
+	end, err2 := netip.ParseAddr(strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil {
+		return addressRange{}, invalid
+	}
+	start, end = start.Unmap(), end.Unmap()
+	if addrFamily(start) != addrFamily(end) {
+		return addressRange{}, fmt.Errorf("invalid address pool %q: %s is %s but %s is %s", pool, start, addrFamily(start), end, addrFamily(end))
+	}
+	r := addressRange{first: start, last: end}
+	if r.first.Compare(r.last) > 0 {
+		return addressRange{}, fmt.Errorf("invalid address pool %q: ends before it starts", pool)
+	}
+	return r, nil
+}
+
+// ValidateQuantity checks that size parses as a positive Kubernetes
+// quantity such as 10Gi.
+func ValidateQuantity(size string) error {
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: expected a quantity such as 10Gi or 500Mi", size)
+	}
+	if q.Sign() <= 0 {
+		return fmt.Errorf("invalid size %q: must be greater than zero", size)
+	}
+	return nil
+}
+
+// ValidateCPU checks a CPU quantity such as 2 or 500m.
+func ValidateCPU(cpu string) error {
+	q, err := resource.ParseQuantity(cpu)
+	if err != nil {
+		return fmt.Errorf("invalid CPU %q: expected cores or millicores such as 2 or 500m", cpu)
+	}
+	if q.Sign() <= 0 {
+		return fmt.Errorf("invalid CPU %q: must be greater than zero", cpu)
+	}
+	return nil
+}
+
+// ParseReplicas parses a replica count, which must be a positive integer.
+func ParseReplicas(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid replica count %q: must be a positive integer", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,227 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateVClusterName(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "platform", "vclusters"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "platform", "vclusters", "media.yaml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"my-project", false},
+		{"dev1", false},
+		{"", true},
+		{"My-Project", true},
+		{"-dev", true},
+		{"dev_1", true},
+		{"a.b", true},
+		{"media", true}, // manifest exists
+	}
+	for _, tt := range tests {
+		err := ValidateVClusterName(tt.name, repo)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVClusterName(%q) = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if err := ValidateVClusterName("media", ""); err != nil {
+		t.Errorf("without a repo path the existence check is skipped, got %v", err)
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		host     string
+		wantErr  bool
+		wantWarn bool
+	}{
+		{"media.integratn.tech", false, false},
+		{"api.media.integratn.tech", false, false},
+		{"media.example.com", false, true},
+		{"integratn.tech", false, true},
+		{"", true, false},
+		{"Media.integratn.tech", true, false},
+		{"media..integratn.tech", true, false},
+		{"media_1.integratn.tech", true, false},
+	}
+	for _, tt := range tests {
+		err := ValidateHostname(tt.host, "integratn.tech")
+		var warn *Warning
+		isWarn := errors.As(err, &warn)
+		if isWarn != tt.wantWarn || (err != nil && !isWarn) != tt.wantErr {
+			t.Errorf("ValidateHostname(%q) = %v, wantErr %v wantWarn %v", tt.host, err, tt.wantErr, tt.wantWarn)
+		}
+	}
+}
+
+func TestValidateSubnet(t *testing.T) {
+	for _, s := range []string{"10.0.4.0/24", "192.168.1.0/28", "fd00::/64", "10.0.4.0/24,fd00:4::/64", "fd00:4::/64, 10.0.4.0/24"} {
+		if err := ValidateSubnet(s); err != nil {
+			t.Errorf("ValidateSubnet(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "10.0.4.0", "10.0.4.0/33", "10.0.4/24", "subnet", "fd00::/129", "10.0.4.0/24,10.0.5.0/24"} {
+		if err := ValidateSubnet(s); err == nil {
+			t.Errorf("ValidateSubnet(%q) = nil, want error", s)
+		}
+	}
+}
+
+func TestValidateVIP(t *testing.T) {
+	tests := []struct {
+		vip, subnet string
+		wantErr     bool
+	}{
+		{"10.0.4.210", "10.0.4.0/24", false},
+		{"10.0.4.210", "", false},
+		{"10.0.5.1", "10.0.4.0/24", true},
+		{"10.0.4.0", "10.0.4.0/24", true},
+		{"10.0.4.255", "10.0.4.0/24", true},
+		{"10.0.4.256", "10.0.4.0/24", true},
+		{"fd00::10", "fd00::/64", false},
+		{"fd00::", "fd00::/64", true},
+		{"fd00:1::10", "fd00::/64", true},
+		{"fd00::10", "10.0.4.0/24", true}, // family mismatch
+		{"10.0.4.210", "fd00::/64", true},
+		{"10.0.4.210,fd00::10", "10.0.4.0/24,fd00::/64", false},
+		{"fd00::10", "10.0.4.0/24,fd00::/64", false},
+		{"10.0.4.210,10.0.4.211", "10.0.4.0/24", true},
+		{"fd00::10%eth0", "fd00::/64", true},
+		{"", "10.0.4.0/24", true},
+		{"10.0.4.10", "bogus", true},
+	}
+	for _, tt := range tests {
+		err := ValidateVIP(tt.vip, tt.subnet)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVIP(%q, %q) = %v, wantErr %v", tt.vip, tt.subnet, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateVIPFamilyMismatchMessage(t *testing.T) {
+	err := ValidateVIP("fd00::10", "10.0.4.0/24")
+	if err == nil || err.Error() != "VIP fd00::10 is an IPv6 address but subnet 10.0.4.0/24 is IPv4" {
+		t.Errorf("ValidateVIP = %v, want the family mismatch named", err)
+	}
+}
+
+func TestValidateIPFamilies(t *testing.T) {
+	tests := []struct {
+		families []string
+		policy   string
+		wantErr  bool
+	}{
+		{nil, "", false},
+		{[]string{"IPv6"}, "", false},
+		{[]string{"IPv4", "IPv6"}, "PreferDualStack", false},
+		{[]string{"IPv6", "IPv4"}, "RequireDualStack", false},
+		{[]string{"IPv4", "IPv6"}, "SingleStack", true},
+		{[]string{"IPv4", "IPv4"}, "", true},
+		{[]string{"ipv6"}, "", true},
+		{nil, "DualStack", true},
+	}
+	for _, tt := range tests {
+		err := ValidateIPFamilies(tt.families, tt.policy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateIPFamilies(%v, %q) = %v, wantErr %v", tt.families, tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateQuantity(t *testing.T) {
+	for _, s := range []string{"10Gi", "500Mi", "1G", "1024"} {
+		if err := ValidateQuantity(s); err != nil {
+			t.Errorf("ValidateQuantity(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "10GB", "ten", "0", "-1Gi"} {
+		if err := ValidateQuantity(s); err == nil {
+			t.Errorf("ValidateQuantity(%q) = nil, want error", s)
+		}
+	}
+}
+
+func TestValidateCPU(t *testing.T) {
+	for _, s := range []string{"2", "500m", "1.5"} {
+		if err := ValidateCPU(s); err != nil {
+			t.Errorf("ValidateCPU(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "two", "0", "-500m"} {
+		if err := ValidateCPU(s); err == nil {
+			t.Errorf("ValidateCPU(%q) = nil, want error", s)
+		}
+	}
+}
+
+func TestParseReplicas(t *testing.T) {
+	if n, err := ParseReplicas(" 3 "); err != nil || n != 3 {
+		t.Errorf("ParseReplicas(3) = %d, %v", n, err)
+	}
+	for _, s := range []string{"", "0", "-1", "two", "1.5"} {
+		if _, err := ParseReplicas(s); err == nil {
+			t.Errorf("ParseReplicas(%q) = nil error, want error", s)
+		}
+	}
+}
+
+func TestValidateAddressPool(t *testing.T) {
+	others := map[string]string{"media": "10.0.5.32/28", "dev": "10.0.5.60-10.0.5.62"}
+	tests := []struct {
+		pool    string
+		wantErr bool
+	}{
+		{"10.0.5.16/28", false},
+		{"10.0.5.48-10.0.5.59", false},
+		{"10.0.4.10-10.0.4.20", true}, // inside the exposure subnet
+		{"10.0.5.40/29", true},        // inside media's pool
+		{"10.0.5.62-10.0.5.70", true}, // tail of dev's pool
+		{"10.0.5.20-10.0.5.10", true},
+		{"fd00:5::/120", false},
+		{"10.0.5.16/28,fd00:5::/120", false},
+		{"fd00:5::/120,10.0.5.40/29", true}, // IPv4 half inside media's pool
+		{"10.0.5.16/28,10.0.5.48/28", true},
+		{"10.0.5.10-fd00::1", true},
+		{"not-a-pool", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAddressPool(tt.pool, "10.0.4.0/24", others)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAddressPool(%q) error = %v, wantErr %v", tt.pool, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeclaredAddressPools(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "platform", "vclusters")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"media.yaml": "spec:\n  name: media\n  vcluster:\n    networking:\n      loadBalancer:\n        addressPool: 10.0.5.32/28\n",
+		"dev.yaml":   "spec:\n  name: dev\n  vcluster:\n    preset: dev\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pools, err := DeclaredAddressPools(filepath.Dir(filepath.Dir(dir)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 || pools["media"] != "10.0.5.32/28" {
+		t.Errorf("pools = %v, want only media's", pools)
+	}
+}
@@ -17,6 +17,7 @@ type VClusterSpec struct {
 	Integrations    IntegrationsCfg  `yaml:"integrations"`
 	ArgocdApp       ArgocdAppConfig  `yaml:"argocdApplication"`
 	NetworkPolicies NetworkPolConfig `yaml:"networkPolicies"`
+	Bootstrap       *BootstrapConfig `yaml:"bootstrap,omitempty"`
 }
 
 // VClusterConfig holds vCluster-specific settings.
@@ -25,6 +26,7 @@ type VClusterConfig struct {
 	Replicas       int                    `yaml:"replicas,omitempty"`
 	K8sVersion     string                 `yaml:"k8sVersion,omitempty"`
 	IsolationMode  string                 `yaml:"isolationMode,omitempty"`
+	Quota          *QuotaConfig           `yaml:"quota,omitempty"`
 	HelmOverrides  map[string]interface{} `yaml:"helmOverrides,omitempty"`
 	Resources      *ResourceRequirements  `yaml:"resources,omitempty"`
 	Persistence    *PersistenceConfig     `yaml:"persistence,omitempty"`
@@ -34,6 +36,15 @@ type VClusterConfig struct {
 	ExportKubeConfig map[string]interface{} `yaml:"exportKubeConfig,omitempty"`
 }
 
+// QuotaConfig is the ResourceQuota of the target namespace. Unset fields
+// are derived by the pipeline from the control plane's requests.
+type QuotaConfig struct {
+	CPU                string `yaml:"cpu,omitempty"`
+	Memory             string `yaml:"memory,omitempty"`
+	Pods               int    `yaml:"pods,omitempty"`
+	WorkloadMultiplier int    `yaml:"workloadMultiplier,omitempty"`
+}
+
 // PersistenceConfig holds vCluster persistence settings.
 type PersistenceConfig struct {
 	Enabled      bool   `yaml:"enabled"`
@@ -48,7 +59,17 @@ type CoreDNSConfig struct {
 
 // NetworkingConfig holds networking settings for the virtual cluster.
 type NetworkingConfig struct {
-	ClusterDomain string `yaml:"clusterDomain,omitempty"`
+	ClusterDomain string              `yaml:"clusterDomain,omitempty"`
+	LoadBalancer  *LoadBalancerConfig `yaml:"loadBalancer,omitempty"`
+}
+
+// LoadBalancerConfig is the MetalLB address pool for LoadBalancer services
+// inside the virtual cluster.
+type LoadBalancerConfig struct {
+	// AddressPool is a CIDR or an inclusive range such as 10.0.5.10-10.0.5.20.
+	AddressPool  string   `yaml:"addressPool"`
+	AutoAssign   *bool    `yaml:"autoAssign,omitempty"`
+	L2Interfaces []string `yaml:"l2Interfaces,omitempty"`
 }
 
 // ResourceRequirements holds resource requests and limits.
@@ -60,9 +81,13 @@ type ResourceRequirements struct {
 // ExposureConfig holds network exposure settings.
 type ExposureConfig struct {
 	Hostname string `yaml:"hostname"`
-	Subnet   string `yaml:"subnet,omitempty"`
-	VIP      string `yaml:"vip,omitempty"`
-	APIPort  int    `yaml:"apiPort,omitempty"`
+	// Subnet and VIP are IPv4 or IPv6, or one of each separated by a comma
+	// for dual-stack.
+	Subnet         string   `yaml:"subnet,omitempty"`
+	VIP            string   `yaml:"vip,omitempty"`
+	IPFamilies     []string `yaml:"ipFamilies,omitempty"`
+	IPFamilyPolicy string   `yaml:"ipFamilyPolicy,omitempty"`
+	APIPort        int      `yaml:"apiPort,omitempty"`
 }
 
 // IntegrationsCfg holds platform integration settings.
@@ -98,6 +123,27 @@ type WorkloadRepoConfig struct {
 	Revision string `yaml:"revision,omitempty"`
 }
 
+// Workload repo settings the orchestrator pipeline uses for unset fields.
+const (
+	DefaultWorkloadRepoURL      = "https://github.com/jamesatintegratnio/gitops_homelab_2_0"
+	DefaultWorkloadRepoPath     = "workloads"
+	DefaultWorkloadRepoRevision = "main"
+)
+
+// WithDefaults returns w with unset fields filled in as the pipeline does.
+func (w WorkloadRepoConfig) WithDefaults() WorkloadRepoConfig {
+	if w.URL == "" {
+		w.URL = DefaultWorkloadRepoURL
+	}
+	if w.Path == "" {
+		w.Path = DefaultWorkloadRepoPath
+	}
+	if w.Revision == "" {
+		w.Revision = DefaultWorkloadRepoRevision
+	}
+	return w
+}
+
 // ArgocdAppConfig holds ArgoCD Application deployment config.
 type ArgocdAppConfig struct {
 	RepoURL           string                 `yaml:"repoURL"`
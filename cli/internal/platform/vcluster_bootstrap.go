@@ -0,0 +1,96 @@
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// BootstrapConfig is spec.bootstrap: objects the orchestrator pipeline
+// applies inside the vCluster once its API server answers.
+type BootstrapConfig struct {
+	Manifests []map[string]interface{} `yaml:"manifests"`
+}
+
+// LoadBootstrapManifests reads the objects for spec.bootstrap.manifests from
+// repo-relative YAML files, or directories of them, in the order given. The
+// pipeline cannot read the repo, so the objects are inlined into the request;
+// the pipeline orders them by their sync-wave annotations.
+func LoadBootstrapManifests(repoPath string, paths []string) ([]map[string]interface{}, error) {
+	var manifests []map[string]interface{}
+	for _, p := range paths {
+		abs := repopath.Abs(repoPath, p)
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, hcerrors.NewUserError("bootstrap manifest %s: not found in the repo", p)
+		}
+		files := []string{abs}
+		if info.IsDir() {
+			files = nil
+			for _, pattern := range []string{"*.yaml", "*.yml"} {
+				matches, err := filepath.Glob(filepath.Join(abs, pattern))
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, matches...)
+			}
+			sort.Strings(files)
+			if len(files) == 0 {
+				return nil, hcerrors.NewUserError("bootstrap manifest directory %s has no YAML files", p)
+			}
+		}
+		for _, f := range files {
+			objs, err := readBootstrapManifests(repoPath, f)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, objs...)
+		}
+	}
+	return manifests, nil
+}
+
+// readBootstrapManifests decodes every non-empty document of a YAML file.
+// Unlike readManifests it keeps yaml.v3 types, so integers are written back
+// into the request as integers.
+func readBootstrapManifests(repoPath, path string) ([]map[string]interface{}, error) {
+	rel, err := repopath.Rel(repoPath, path)
+	if err != nil {
+		rel = path
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	var objs []map[string]interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %v", rel, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetKind() == "" || u.GetName() == "" {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: a document has no kind or metadata.name", rel)
+		}
+		if u.GetAPIVersion() == "" {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: %s %s has no apiVersion", rel, u.GetKind(), u.GetName())
+		}
+		objs = append(objs, obj)
+	}
+}
@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadBootstrapManifests(t *testing.T) {
+	root := writeBootstrapRepo(t, map[string]string{
+		"platform/bootstrap/team-api/b-rbac.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRoleBinding\nmetadata:\n  name: team-api-admins\n",
+		"platform/bootstrap/team-api/a-ns.yml":    "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: team-api\n---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: team-api-jobs\n",
+		"platform/bootstrap/team-api/README.md":   "not a manifest\n",
+		"platform/bootstrap/priority.yaml":        "apiVersion: scheduling.k8s.io/v1\nkind: PriorityClass\nmetadata:\n  name: platform-critical\n  annotations:\n    argocd.argoproj.io/sync-wave: \"-1\"\nvalue: 1000000\n",
+	})
+
+	manifests, err := LoadBootstrapManifests(root, []string{"platform/bootstrap/priority.yaml", "platform/bootstrap/team-api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range manifests {
+		names = append(names, m["metadata"].(map[string]interface{})["name"].(string))
+	}
+	want := []string{"platform-critical", "team-api", "team-api-jobs", "team-api-admins"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("manifests = %v, want %v (argument order, then file order)", names, want)
+	}
+	if v := manifests[0]["value"]; v != 1000000 {
+		t.Errorf("PriorityClass value = %#v, want the inlined object unchanged", v)
+	}
+}
+
+func TestLoadBootstrapManifestsErrors(t *testing.T) {
+	root := writeBootstrapRepo(t, map[string]string{
+		"bootstrap/noversion.yaml": "kind: Namespace\nmetadata:\n  name: x\n",
+		"bootstrap/noname.yaml":    "apiVersion: v1\nkind: Namespace\n",
+		"empty/README.md":          "nothing here\n",
+	})
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{"bootstrap/missing.yaml", "not found"},
+		{"bootstrap/noversion.yaml", "no apiVersion"},
+		{"bootstrap/noname.yaml", "no kind or metadata.name"},
+		{"empty", "has no YAML files"},
+	} {
+		_, err := LoadBootstrapManifests(root, []string{tc.path})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("LoadBootstrapManifests(%s) error = %v, want %q", tc.path, err, tc.want)
+		}
+	}
+}
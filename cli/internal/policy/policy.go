@@ -0,0 +1,232 @@
+// Package policy enforces the tenancy guardrails committed to the gitops repo
+// at .hctl/policy.yaml. The file defines rules per team; the team an hctl
+// user belongs to comes from the "team" key in their config. Commands that
+// write to the repo ask a Guard before writing, and a violation fails with
+// ErrPolicy unless the user passes --policy-override with a reason, which is
+// then recorded in the commit message and the audit log.
+//
+//	default:
+//	  vclusterCreate: false
+//	teams:
+//	  team-a:
+//	    clusters: ["team-a-*"]
+//	    namespaces: ["team-a-*", "shared"]
+//	    addonLayers: [cluster]
+//	    vclusterCreate: false
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"gopkg.in/yaml.v3"
+)
+
+// Path is the policy location relative to the repo root.
+const Path = ".hctl/policy.yaml"
+
+// File is the parsed policy.
+type File struct {
+	// Default applies when the config names no team.
+	Default *Rules `yaml:"default,omitempty"`
+	// Teams maps team names to their rules.
+	Teams map[string]Rules `yaml:"teams,omitempty"`
+}
+
+// Rules restrict what one team may change. An empty list allows anything.
+type Rules struct {
+	// Clusters are globs (path.Match syntax) of the clusters and vClusters
+	// the team may deploy to, manage, or configure addons for.
+	Clusters []string `yaml:"clusters,omitempty"`
+	// Namespaces are globs of the namespaces workloads may deploy into.
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// AddonLayers are the addon layers (environment, cluster-role,
+	// cluster) the team may change.
+	AddonLayers []string `yaml:"addonLayers,omitempty"`
+	// VClusterCreate allows 'hctl vcluster create'; unset means allowed.
+	VClusterCreate *bool `yaml:"vclusterCreate,omitempty"`
+}
+
+// validate checks every glob so a typo fails loudly instead of matching
+// nothing.
+func (r Rules) validate() error {
+	for _, globs := range [][]string{r.Clusters, r.Namespaces} {
+		for _, g := range globs {
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("invalid glob %q: %w", g, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Load reads the policy from repoRoot. A repo without one has no
+// restrictions, reported as a nil File.
+func Load(repoRoot string) (*File, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, Path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", Path, err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "parsing %s: %w", Path, err)
+	}
+	if f.Default != nil {
+		if err := f.Default.validate(); err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: default: %w", Path, err)
+		}
+	}
+	for name, r := range f.Teams {
+		if err := r.validate(); err != nil {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: teams.%s: %w", Path, name, err)
+		}
+	}
+	return &f, nil
+}
+
+// Guard checks operations against one team's rules and collects the
+// violations an override let through.
+type Guard struct {
+	team       string
+	rules      *Rules
+	override   string
+	overridden []string
+}
+
+// New returns the guard for team under f. override is the --policy-override
+// reason; when non-empty, violations are allowed and recorded instead of
+// failing. A nil f, or no team and no default rules, restricts nothing.
+func New(f *File, team, override string) (*Guard, error) {
+	g := &Guard{team: team, override: strings.TrimSpace(override)}
+	if f == nil {
+		return g, nil
+	}
+	if team == "" {
+		g.rules = f.Default
+		return g, nil
+	}
+	r, ok := f.Teams[team]
+	if !ok {
+		teams := make([]string, 0, len(f.Teams))
+		for name := range f.Teams {
+			teams = append(teams, name)
+		}
+		sort.Strings(teams)
+		return nil, hcerrors.New(hcerrors.ErrPolicy, "team %q is not defined in %s", team, Path).
+			WithRemediation(fmt.Sprintf("set team in your hctl config to one of: %s", strings.Join(teams, ", ")))
+	}
+	g.rules = &r
+	return g, nil
+}
+
+// ForRepo loads the policy from repoRoot and returns the guard for team.
+func ForRepo(repoRoot, team, override string) (*Guard, error) {
+	f, err := Load(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return New(f, team, override)
+}
+
+// Cluster checks that the team may act on cluster. what describes the
+// operation and the kind of cluster, e.g. "deploying to cluster".
+func (g *Guard) Cluster(what, cluster string) error {
+	if g.rules == nil || matchAny(g.rules.Clusters, cluster) {
+		return nil
+	}
+	return g.deny(fmt.Sprintf("%s %s", what, cluster), g.rules.Clusters)
+}
+
+// Namespace checks that the team may deploy workloads into namespace.
+func (g *Guard) Namespace(namespace string) error {
+	if g.rules == nil || matchAny(g.rules.Namespaces, namespace) {
+		return nil
+	}
+	return g.deny("deploying to namespace "+namespace, g.rules.Namespaces)
+}
+
+// AddonLayer checks that the team may change addons at the layer of the
+// given kind. At the cluster layer, name is the cluster and must also be
+// allowed.
+func (g *Guard) AddonLayer(verb, kind, name string) error {
+	if g.rules == nil {
+		return nil
+	}
+	if len(g.rules.AddonLayers) > 0 && !contains(g.rules.AddonLayers, kind) {
+		if err := g.deny(fmt.Sprintf("%s addons at the %s layer", verb, kind), g.rules.AddonLayers); err != nil {
+			return err
+		}
+	}
+	if kind == "cluster" {
+		return g.Cluster(verb+" addons for cluster", name)
+	}
+	return nil
+}
+
+// VClusterCreate checks that the team may create vClusters.
+func (g *Guard) VClusterCreate() error {
+	if g.rules == nil || g.rules.VClusterCreate == nil || *g.rules.VClusterCreate {
+		return nil
+	}
+	return g.deny("creating vclusters", nil)
+}
+
+// Override returns the text to record for a change that went through
+// because of --policy-override: the reason and what it overrode. It is
+// empty when no check was overridden.
+func (g *Guard) Override() string {
+	if len(g.overridden) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (overrides: %s)", g.override, strings.Join(g.overridden, "; "))
+}
+
+// deny fails a check or, with an override, records and allows it.
+func (g *Guard) deny(what string, allowed []string) error {
+	msg := "policy forbids " + what
+	if len(allowed) > 0 {
+		msg += " (allowed: " + strings.Join(allowed, ", ") + ")"
+	}
+	if g.override != "" {
+		g.overridden = append(g.overridden, msg)
+		fmt.Fprintf(os.Stderr, "%s %s — overridden: %s\n", tui.WarningStyle.Render(tui.IconWarn), msg, g.override)
+		return nil
+	}
+	who := "this repo"
+	if g.team != "" {
+		who = "team " + g.team
+	}
+	return hcerrors.New(hcerrors.ErrPolicy, "%s", msg).
+		WithRemediation(fmt.Sprintf("%s sets the rules for %s; to proceed anyway, re-run with --policy-override \"<reason>\" (recorded in the commit and audit log)", Path, who))
+}
+
+// matchAny reports whether s matches one of globs; no globs match anything.
+func matchAny(globs []string, s string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if ok, _ := path.Match(g, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
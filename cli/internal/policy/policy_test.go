@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const testPolicy = `
+default:
+  vclusterCreate: false
+teams:
+  team-a:
+    clusters: ["team-a-*", "shared"]
+    namespaces: ["team-a-*"]
+    addonLayers: [cluster]
+    vclusterCreate: false
+  platform: {}
+`
+
+// writePolicy returns a repo root holding content as its policy.
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".hctl"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, Path), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func loadTestPolicy(t *testing.T, content string) *File {
+	t.Helper()
+	f, err := Load(writePolicy(t, content))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return f
+}
+
+func TestLoad(t *testing.T) {
+	f, err := Load(t.TempDir())
+	if err != nil || f != nil {
+		t.Errorf("Load without a policy = %v, %v; want nil, nil", f, err)
+	}
+
+	repo := writePolicy(t, "teams:\n  a:\n    clusters: [\"team-[a\"]\n")
+	if _, err := Load(repo); !errors.Is(err, hcerrors.ErrValidation) || !strings.Contains(err.Error(), "teams.a") {
+		t.Errorf("Load with a bad glob = %v, want a validation error naming teams.a", err)
+	}
+}
+
+func TestClusterGlobs(t *testing.T) {
+	f := loadTestPolicy(t, testPolicy)
+	g, err := New(f, "team-a", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cluster string
+		allowed bool
+	}{
+		{"team-a-dev", true},
+		{"team-a-", true},
+		{"shared", true},
+		{"team-b-dev", false},
+		{"prod", false},
+		{"shared-2", false},
+	}
+	for _, tt := range tests {
+		err := g.Cluster("deploying to cluster", tt.cluster)
+		if (err == nil) != tt.allowed {
+			t.Errorf("Cluster(%q) = %v, allowed %v", tt.cluster, err, tt.allowed)
+		}
+	}
+
+	err = g.Cluster("deploying to cluster", "prod")
+	if want := "policy forbids deploying to cluster prod (allowed: team-a-*, shared)"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+	if !errors.Is(err, hcerrors.ErrPolicy) {
+		t.Errorf("error category = %s, want %s", hcerrors.CategoryOf(err), hcerrors.ErrPolicy)
+	}
+
+	if err := g.Namespace("team-a-api"); err != nil {
+		t.Errorf("Namespace(team-a-api) = %v", err)
+	}
+	if err := g.Namespace("kube-system"); err == nil {
+		t.Error("Namespace(kube-system) should be forbidden")
+	}
+}
+
+func TestAddonLayers(t *testing.T) {
+	f := loadTestPolicy(t, testPolicy)
+	g, _ := New(f, "team-a", "")
+
+	tests := []struct {
+		kind, name string
+		want       string // expected error, "" when allowed
+	}{
+		{"cluster", "team-a-dev", ""},
+		{"cluster", "prod", "policy forbids enabling addons for cluster prod (allowed: team-a-*, shared)"},
+		{"environment", "production", "policy forbids enabling addons at the environment layer (allowed: cluster)"},
+		{"cluster-role", "media", "policy forbids enabling addons at the cluster-role layer (allowed: cluster)"},
+	}
+	for _, tt := range tests {
+		err := g.AddonLayer("enabling", tt.kind, tt.name)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("AddonLayer(%s=%s) = %q, want %q", tt.kind, tt.name, got, tt.want)
+		}
+	}
+
+	// A team without addonLayers may change any layer.
+	p, _ := New(f, "platform", "")
+	if err := p.AddonLayer("enabling", "environment", "production"); err != nil {
+		t.Errorf("platform team: %v", err)
+	}
+}
+
+func TestTeamSelection(t *testing.T) {
+	f := loadTestPolicy(t, testPolicy)
+
+	if _, err := New(f, "team-z", ""); !errors.Is(err, hcerrors.ErrPolicy) {
+		t.Errorf("unknown team = %v, want a policy error", err)
+	}
+
+	// No team: the default rules apply.
+	g, _ := New(f, "", "")
+	if err := g.VClusterCreate(); err == nil {
+		t.Error("default rules should forbid vcluster create")
+	}
+	if err := g.Cluster("deploying to cluster", "anything"); err != nil {
+		t.Errorf("default rules do not restrict clusters, got %v", err)
+	}
+
+	// No policy file: nothing is restricted.
+	g, _ = New(nil, "team-a", "")
+	if err := g.VClusterCreate(); err != nil {
+		t.Errorf("without a policy: %v", err)
+	}
+
+	g, _ = New(f, "platform", "")
+	if err := g.VClusterCreate(); err != nil {
+		t.Errorf("unset vclusterCreate should allow create, got %v", err)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	f := loadTestPolicy(t, testPolicy)
+
+	g, _ := New(f, "team-a", "  INC-42 hotfix ")
+	if err := g.Cluster("deploying to cluster", "team-a-dev"); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Override(); got != "" {
+		t.Errorf("Override() with nothing overridden = %q, want empty", got)
+	}
+
+	if err := g.Cluster("deploying to cluster", "prod"); err != nil {
+		t.Errorf("override should allow the violation, got %v", err)
+	}
+	if err := g.VClusterCreate(); err != nil {
+		t.Errorf("override should allow the violation, got %v", err)
+	}
+	want := "INC-42 hotfix (overrides: policy forbids deploying to cluster prod (allowed: team-a-*, shared); policy forbids creating vclusters)"
+	if got := g.Override(); got != want {
+		t.Errorf("Override() = %q, want %q", got, want)
+	}
+}
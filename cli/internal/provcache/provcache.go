@@ -0,0 +1,123 @@
+// Package provcache is the on-disk translate.ResultCache behind 'hctl deploy
+// render' and 'diff'. Entries are JSON files named by their key, in a
+// directory per provisioner and hctl version, so upgrading either starts
+// from an empty cache and the old entries are removed.
+package provcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/logging"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// hctlVersion is the running hctl version, set by SetVersion.
+var hctlVersion = "dev"
+
+// SetVersion records the running hctl version for Open.
+func SetVersion(version string) {
+	hctlVersion = version
+}
+
+// Cache is a translate.ResultCache in one directory. It is safe for
+// concurrent use, including by several hctl processes.
+type Cache struct {
+	dir string
+	// OnLookup, when set, is called after each Get with whether it hit.
+	// Calls may be concurrent.
+	OnLookup func(hit bool)
+}
+
+// Open returns the cache for the running hctl version under the user cache
+// directory, e.g. ~/.cache/hctl/provisioners. Development builds get a nil
+// cache and no error: their provisioners change without a version bump.
+func Open() (*Cache, error) {
+	if hctlVersion == "dev" {
+		return nil, nil
+	}
+	root, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("locating the user cache directory: %w", err)
+	}
+	return OpenDir(filepath.Join(root, "hctl", "provisioners"), hctlVersion)
+}
+
+// OpenDir returns the cache for hctl version under root, removing the
+// directories other provisioner or hctl versions left there.
+func OpenDir(root, version string) (*Cache, error) {
+	name := strings.ReplaceAll(provisioners.Version+"-"+version, string(filepath.Separator), "_")
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading provisioner cache: %w", err)
+	}
+	for _, e := range entries {
+		if e.Name() == name {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, e.Name())); err != nil {
+			return nil, fmt.Errorf("removing stale provisioner cache: %w", err)
+		}
+	}
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating provisioner cache: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Get returns the result stored under key. Unreadable entries are misses.
+func (c *Cache) Get(key string) (*provisioners.ProvisionResult, bool) {
+	result, ok := c.read(key)
+	if c.OnLookup != nil {
+		c.OnLookup(ok)
+	}
+	return result, ok
+}
+
+func (c *Cache) read(key string) (*provisioners.ProvisionResult, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var result provisioners.ProvisionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		logging.L().Debug("ignoring corrupt provisioner cache entry", "key", key, "error", err)
+		return nil, false
+	}
+	return &result, true
+}
+
+// Put stores result under key. Failures are logged and otherwise ignored:
+// the next render runs the provisioner again.
+func (c *Cache) Put(key string, result *provisioners.ProvisionResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		logging.L().Debug("not caching provisioner result", "key", key, "error", err)
+		return
+	}
+	// Write then rename so a concurrent Get never reads a partial entry.
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		logging.L().Debug("writing provisioner cache", "key", key, "error", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		logging.L().Debug("writing provisioner cache", "key", key, "error", err)
+	}
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
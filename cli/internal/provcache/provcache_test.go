@@ -0,0 +1,176 @@
+package provcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// everyType uses each built-in provisioner.
+const everyType = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: ghcr.io/example/shop:2.0.0
+    variables:
+      DB_HOST: ${resources.db.host}
+      CACHE_HOST: ${resources.cache.host}
+      BUCKET: ${resources.media.bucket}
+    volumes:
+      /data:
+        source: ${resources.data}
+resources:
+  db:
+    type: postgres
+  cache:
+    type: redis
+  data:
+    type: volume
+    params:
+      size: 5Gi
+  dns:
+    type: dns
+  web:
+    type: route
+    params:
+      host: shop.example.com
+      port: 8080
+  reader:
+    type: rbac
+    params:
+      rules:
+        - apiGroups: [""]
+          resources: [configmaps]
+          verbs: [get, list]
+  media:
+    type: s3
+`
+
+// render translates spec with cache, returning the result and the number
+// of cache hits and misses.
+func render(t *testing.T, spec string, cache *Cache) (*translate.Result, int32, int32) {
+	t.Helper()
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits, misses atomic.Int32
+	cache.OnLookup = func(hit bool) {
+		if hit {
+			hits.Add(1)
+		} else {
+			misses.Add(1)
+		}
+	}
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "media",
+		Registry: provisioners.NewRegistry(),
+		Mode:     provisioners.ModeRender,
+		Cache:    cache,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, hits.Load(), misses.Load()
+}
+
+func TestCacheHit(t *testing.T) {
+	root := t.TempDir()
+	cache, err := OpenDir(root, "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, hits, misses := render(t, everyType, cache)
+	if hits != 0 || misses != 7 {
+		t.Fatalf("first render: %d hits, %d misses, want 0 and 7", hits, misses)
+	}
+
+	// A fresh process reading the entries back renders the same bytes.
+	cache, err = OpenDir(root, "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, hits, misses := render(t, everyType, cache)
+	if hits != 7 || misses != 0 {
+		t.Fatalf("second render: %d hits, %d misses, want 7 and 0", hits, misses)
+	}
+	for path, want := range first.Files {
+		if !bytes.Equal(second.Files[path], want) {
+			t.Errorf("%s from the cache:\n%s\nwant:\n%s", path, second.Files[path], want)
+		}
+	}
+	if !reflect.DeepEqual(second.Requirements, first.Requirements) {
+		t.Errorf("requirements from the cache = %+v, want %+v", second.Requirements, first.Requirements)
+	}
+}
+
+func TestCacheMissOnParamChange(t *testing.T) {
+	cache, err := OpenDir(t.TempDir(), "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	render(t, everyType, cache)
+
+	changed := strings.Replace(everyType, "size: 5Gi", "size: 10Gi", 1)
+	result, hits, misses := render(t, changed, cache)
+	if hits != 6 || misses != 1 {
+		t.Errorf("after changing the volume size: %d hits, %d misses, want 6 and 1", hits, misses)
+	}
+	if !strings.Contains(string(result.Files[translate.ValuesPath("media", "shop")]), "10Gi") {
+		t.Error("values.yaml does not carry the new volume size")
+	}
+}
+
+func TestCacheVersionInvalidation(t *testing.T) {
+	root := t.TempDir()
+	cache, err := OpenDir(root, "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	render(t, everyType, cache)
+	old := filepath.Join(root, provisioners.Version+"-v1.4.0")
+	if _, err := os.Stat(old); err != nil {
+		t.Fatalf("entries not written under %s: %v", old, err)
+	}
+
+	cache, err = OpenDir(root, "v1.5.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("entries of the previous hctl version were kept: %v", err)
+	}
+	if _, hits, misses := render(t, everyType, cache); hits != 0 || misses != 7 {
+		t.Errorf("after an upgrade: %d hits, %d misses, want 0 and 7", hits, misses)
+	}
+}
+
+func TestCacheCorruptEntry(t *testing.T) {
+	cache, err := OpenDir(t.TempDir(), "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.path("abc"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("abc"); ok {
+		t.Error("corrupt entry was a hit")
+	}
+}
+
+func TestOpenDevBuild(t *testing.T) {
+	defer SetVersion(hctlVersion)
+	SetVersion("dev")
+	if cache, err := Open(); cache != nil || err != nil {
+		t.Errorf("Open() in a dev build = %v, %v, want no cache", cache, err)
+	}
+}
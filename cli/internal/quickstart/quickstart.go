@@ -0,0 +1,252 @@
+// Package quickstart drives the guided first-run flow behind
+// `hctl quickstart`. It runs an ordered list of steps, records each outcome in
+// a JSON state file, and on the next invocation resumes at the first step
+// that has neither completed nor been skipped.
+package quickstart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+)
+
+// Status is the recorded outcome of a step.
+type Status string
+
+const (
+	// StatusPending means the step has not run yet.
+	StatusPending Status = ""
+	// StatusDone means the step completed successfully.
+	StatusDone Status = "done"
+	// StatusSkipped means the user chose not to run the step.
+	StatusSkipped Status = "skipped"
+	// StatusFailed means the last attempt failed; it is retried on resume.
+	StatusFailed Status = "failed"
+)
+
+// Finished reports whether a step in this status is passed over on resume.
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusSkipped
+}
+
+// StepRecord is the persisted outcome of one step.
+type StepRecord struct {
+	Status    Status    `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Commands  []string  `json:"commands,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// State is the progress of a quickstart run, shared between steps and
+// persisted after every transition.
+type State struct {
+	// Cluster is the vCluster the sample workload targets.
+	Cluster string `json:"cluster,omitempty"`
+	// Hostname is the external API hostname of a vCluster created by quickstart.
+	Hostname string `json:"hostname,omitempty"`
+	// Workload is the sample workload name.
+	Workload string `json:"workload,omitempty"`
+	// WorkloadDir is the directory holding the sample score.yaml.
+	WorkloadDir string `json:"workloadDir,omitempty"`
+	// URL is the sample workload's route URL once deployed.
+	URL string `json:"url,omitempty"`
+	// Steps maps step IDs to their recorded outcome.
+	Steps map[string]*StepRecord `json:"steps"`
+}
+
+// StatePath returns the location of quickstart.json in the config directory.
+func StatePath() string {
+	return filepath.Join(config.ConfigDir(), "quickstart.json")
+}
+
+// LoadState reads the state file at path. A missing file yields empty state.
+func LoadState(path string) (*State, error) {
+	st := &State{Steps: map[string]*StepRecord{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if st.Steps == nil {
+		st.Steps = map[string]*StepRecord{}
+	}
+	return st, nil
+}
+
+// Save writes the state to path, replacing it atomically.
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record returns the record for step id, or an empty pending record.
+func (s *State) Record(id string) StepRecord {
+	if rec, ok := s.Steps[id]; ok && rec != nil {
+		return *rec
+	}
+	return StepRecord{}
+}
+
+// Forget clears the record for step id so it runs again on the next pass.
+func (s *State) Forget(id string) {
+	delete(s.Steps, id)
+}
+
+func (s *State) set(id string, rec StepRecord) {
+	rec.UpdatedAt = time.Now().UTC()
+	s.Steps[id] = &rec
+}
+
+// Result is what a successful step reports.
+type Result struct {
+	// Detail is a short summary shown next to the step.
+	Detail string
+	// Commands are the standalone hctl invocations the step performed,
+	// listed in the recap so users learn the individual pieces.
+	Commands []string
+}
+
+// Step is one stage of the quickstart.
+type Step struct {
+	// ID identifies the step in the state file and on the command line.
+	ID string
+	// Title is the human-readable step name.
+	Title string
+	// Run performs the step. It may read and update st; changes are saved
+	// whether or not it succeeds.
+	Run func(st *State) (Result, error)
+}
+
+// Action is the decision taken for a step that has not finished.
+type Action int
+
+const (
+	// ActionRun runs the step.
+	ActionRun Action = iota
+	// ActionSkip records the step as skipped and moves on.
+	ActionSkip
+	// ActionStop ends the run, leaving the step pending for a later resume.
+	ActionStop
+)
+
+// ErrStopped is returned by Run when Decide chose ActionStop.
+var ErrStopped = errors.New("quickstart stopped")
+
+// Runner executes steps in order against a persisted State.
+type Runner struct {
+	Steps []Step
+	State *State
+	// Save persists State after every transition. Nil disables persistence.
+	Save func(*State) error
+	// Decide chooses what to do with an unfinished step. Nil runs every step.
+	Decide func(step Step, prev StepRecord) (Action, error)
+	// OnResume is called for each step passed over because it already
+	// finished in an earlier run. Optional.
+	OnResume func(step Step, prev StepRecord)
+}
+
+// Run executes every unfinished step in order. It stops at the first failing
+// step, recording the failure so the next Run retries it, and returns the
+// step error wrapped with the step title.
+func (r *Runner) Run() error {
+	if r.State.Steps == nil {
+		r.State.Steps = map[string]*StepRecord{}
+	}
+	for _, step := range r.Steps {
+		prev := r.State.Record(step.ID)
+		if prev.Status.Finished() {
+			if r.OnResume != nil {
+				r.OnResume(step, prev)
+			}
+			continue
+		}
+
+		action := ActionRun
+		if r.Decide != nil {
+			a, err := r.Decide(step, prev)
+			if err != nil {
+				return err
+			}
+			action = a
+		}
+
+		switch action {
+		case ActionStop:
+			return ErrStopped
+		case ActionSkip:
+			r.State.set(step.ID, StepRecord{Status: StatusSkipped})
+			if err := r.save(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		res, runErr := step.Run(r.State)
+		if runErr != nil {
+			r.State.set(step.ID, StepRecord{Status: StatusFailed, Error: runErr.Error()})
+			if err := r.save(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%s: %w", step.Title, runErr)
+		}
+		r.State.set(step.ID, StepRecord{Status: StatusDone, Detail: res.Detail, Commands: res.Commands})
+		if err := r.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) save() error {
+	if r.Save == nil {
+		return nil
+	}
+	if err := r.Save(r.State); err != nil {
+		return fmt.Errorf("saving quickstart state: %w", err)
+	}
+	return nil
+}
+
+// RecapEntry summarizes one step for the closing recap.
+type RecapEntry struct {
+	ID       string
+	Title    string
+	Status   Status
+	Commands []string
+}
+
+// Recap lists every step with its recorded status and commands, in order.
+func (r *Runner) Recap() []RecapEntry {
+	entries := make([]RecapEntry, 0, len(r.Steps))
+	for _, step := range r.Steps {
+		rec := r.State.Record(step.ID)
+		entries = append(entries, RecapEntry{
+			ID:       step.ID,
+			Title:    step.Title,
+			Status:   rec.Status,
+			Commands: rec.Commands,
+		})
+	}
+	return entries
+}
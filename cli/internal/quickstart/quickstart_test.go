@@ -0,0 +1,228 @@
+package quickstart
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSteps returns steps "a", "b", "c" that count their invocations. A step
+// listed in failing returns an error instead of succeeding.
+func fakeSteps(calls map[string]int, failing map[string]bool) []Step {
+	var steps []Step
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		steps = append(steps, Step{
+			ID:    id,
+			Title: "step " + id,
+			Run: func(st *State) (Result, error) {
+				calls[id]++
+				if failing[id] {
+					return Result{}, errors.New(id + " broke")
+				}
+				st.Workload += id
+				return Result{Detail: id + " ok", Commands: []string{"hctl " + id}}, nil
+			},
+		})
+	}
+	return steps
+}
+
+func newRunner(t *testing.T, path string, steps []Step) *Runner {
+	t.Helper()
+	st, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	return &Runner{
+		Steps: steps,
+		State: st,
+		Save:  func(s *State) error { return s.Save(path) },
+	}
+}
+
+func TestRunCompletesAllSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+	r := newRunner(t, path, fakeSteps(calls, nil))
+
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(calls, map[string]int{"a": 1, "b": 1, "c": 1}) {
+		t.Errorf("calls = %v, want each step once", calls)
+	}
+
+	saved, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Workload != "abc" {
+		t.Errorf("Workload = %q, want state changes from every step persisted", saved.Workload)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		rec := saved.Record(id)
+		if rec.Status != StatusDone {
+			t.Errorf("step %s status = %q, want done", id, rec.Status)
+		}
+		if len(rec.Commands) != 1 || rec.Commands[0] != "hctl "+id {
+			t.Errorf("step %s commands = %v", id, rec.Commands)
+		}
+	}
+}
+
+func TestRunResumesAfterFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+
+	r := newRunner(t, path, fakeSteps(calls, map[string]bool{"b": true}))
+	err := r.Run()
+	if err == nil {
+		t.Fatal("Run succeeded, want failure at step b")
+	}
+	if calls["c"] != 0 {
+		t.Errorf("step c ran after b failed")
+	}
+	saved, _ := LoadState(path)
+	if rec := saved.Record("b"); rec.Status != StatusFailed || rec.Error != "b broke" {
+		t.Errorf("step b record = %+v, want failed with error", rec)
+	}
+	if saved.Workload != "a" {
+		t.Errorf("Workload = %q, want progress from step a kept", saved.Workload)
+	}
+
+	// Second invocation with b fixed: a is not repeated.
+	var resumed []string
+	r = newRunner(t, path, fakeSteps(calls, nil))
+	r.OnResume = func(step Step, prev StepRecord) { resumed = append(resumed, step.ID) }
+	if err := r.Run(); err != nil {
+		t.Fatalf("resumed Run: %v", err)
+	}
+	if !reflect.DeepEqual(calls, map[string]int{"a": 1, "b": 2, "c": 1}) {
+		t.Errorf("calls = %v, want a once, b retried, c once", calls)
+	}
+	if !reflect.DeepEqual(resumed, []string{"a"}) {
+		t.Errorf("resumed = %v, want [a]", resumed)
+	}
+	if r.State.Workload != "abc" {
+		t.Errorf("Workload = %q, want abc", r.State.Workload)
+	}
+}
+
+func TestRunSkipsDecidedSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+	asked := map[string]int{}
+
+	r := newRunner(t, path, fakeSteps(calls, nil))
+	r.Decide = func(step Step, prev StepRecord) (Action, error) {
+		asked[step.ID]++
+		if step.ID == "b" {
+			return ActionSkip, nil
+		}
+		return ActionRun, nil
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls["b"] != 0 {
+		t.Errorf("skipped step b ran")
+	}
+	if got := r.State.Record("b").Status; got != StatusSkipped {
+		t.Errorf("step b status = %q, want skipped", got)
+	}
+
+	// A skipped step is not offered again on resume.
+	r = newRunner(t, path, fakeSteps(calls, nil))
+	r.Decide = func(step Step, prev StepRecord) (Action, error) {
+		t.Errorf("Decide called for finished step %s", step.ID)
+		return ActionRun, nil
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("resumed Run: %v", err)
+	}
+
+	recap := r.Recap()
+	var statuses []Status
+	for _, e := range recap {
+		statuses = append(statuses, e.Status)
+	}
+	if !reflect.DeepEqual(statuses, []Status{StatusDone, StatusSkipped, StatusDone}) {
+		t.Errorf("recap statuses = %v", statuses)
+	}
+}
+
+func TestRunStopLeavesStepPending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+
+	r := newRunner(t, path, fakeSteps(calls, nil))
+	r.Decide = func(step Step, prev StepRecord) (Action, error) {
+		if step.ID == "b" {
+			return ActionStop, nil
+		}
+		return ActionRun, nil
+	}
+	if err := r.Run(); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Run error = %v, want ErrStopped", err)
+	}
+
+	saved, _ := LoadState(path)
+	if got := saved.Record("a").Status; got != StatusDone {
+		t.Errorf("step a status = %q, want done", got)
+	}
+	if got := saved.Record("b").Status; got != StatusPending {
+		t.Errorf("step b status = %q, want pending", got)
+	}
+}
+
+func TestRunDecideSeesPreviousFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+
+	r := newRunner(t, path, fakeSteps(calls, map[string]bool{"a": true}))
+	_ = r.Run()
+
+	r = newRunner(t, path, fakeSteps(calls, nil))
+	var prevA StepRecord
+	r.Decide = func(step Step, prev StepRecord) (Action, error) {
+		if step.ID == "a" {
+			prevA = prev
+		}
+		return ActionRun, nil
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if prevA.Status != StatusFailed || prevA.Error != "a broke" {
+		t.Errorf("Decide saw %+v for a, want the earlier failure", prevA)
+	}
+}
+
+func TestForgetRerunsStep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickstart.json")
+	calls := map[string]int{}
+
+	r := newRunner(t, path, fakeSteps(calls, nil))
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.State.Forget("c")
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, map[string]int{"a": 1, "b": 1, "c": 2}) {
+		t.Errorf("calls = %v, want only c rerun", calls)
+	}
+}
+
+func TestLoadStateMissingFile(t *testing.T) {
+	st, err := LoadState(filepath.Join(t.TempDir(), "absent.json"))
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if st.Steps == nil || len(st.Steps) != 0 {
+		t.Errorf("Steps = %v, want empty map", st.Steps)
+	}
+}
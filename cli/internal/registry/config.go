@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+)
+
+// DockerConfigPath returns the docker config.json read for credentials:
+// registry.dockerConfig, then $DOCKER_CONFIG/config.json, then
+// ~/.docker/config.json.
+func DockerConfigPath(cfg *config.Config) string {
+	if cfg.Registry.DockerConfig != "" {
+		return cfg.Registry.DockerConfig
+	}
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// FromConfig builds a registry client from config. Credentials come from
+// the docker config file, then the in-cluster pull Secret; a missing docker
+// config is not an error.
+func FromConfig(cfg *config.Config) (*Client, error) {
+	creds := Credentials{}
+
+	path := DockerConfigPath(cfg)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		fileCreds, err := ParseDockerConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		creds.Merge(fileCreds)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("reading docker config: %w", err)
+	}
+
+	if cfg.Registry.PullSecret != "" {
+		ns, name, ok := strings.Cut(cfg.Registry.PullSecret, "/")
+		if !ok {
+			return nil, fmt.Errorf("registry.pullSecret must be namespace/name, got %q", cfg.Registry.PullSecret)
+		}
+		client, err := kube.NewClient(cfg.KubeContext)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to cluster for registry pull secret: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		secret, err := client.GetSecretData(ctx, ns, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading registry pull secret: %w", err)
+		}
+		secretCreds, err := ParseDockerConfig(secret[".dockerconfigjson"])
+		if err != nil {
+			return nil, fmt.Errorf("registry pull secret %s: %w", cfg.Registry.PullSecret, err)
+		}
+		creds.Merge(secretCreds)
+	}
+
+	return NewClient(creds), nil
+}
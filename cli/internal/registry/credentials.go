@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Credential is a registry username and password (or token).
+type Credential struct {
+	Username string
+	Password string
+}
+
+func (c Credential) basic() string {
+	return base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+}
+
+// Credentials maps registry hosts to credentials.
+type Credentials map[string]Credential
+
+// dockerHubHosts are the names Docker Hub credentials are stored under.
+var dockerHubHosts = []string{"registry-1.docker.io", "index.docker.io", "docker.io"}
+
+// Lookup returns the credential for a registry API host. Docker Hub
+// credentials match under any of its names.
+func (c Credentials) Lookup(host string) (Credential, bool) {
+	if cred, ok := c[host]; ok {
+		return cred, true
+	}
+	for _, h := range dockerHubHosts {
+		if h != host {
+			continue
+		}
+		for _, alias := range dockerHubHosts {
+			if cred, ok := c[alias]; ok {
+				return cred, true
+			}
+		}
+	}
+	return Credential{}, false
+}
+
+// Merge adds other's hosts that c does not already have.
+func (c Credentials) Merge(other Credentials) {
+	for host, cred := range other {
+		if _, ok := c[host]; !ok {
+			c[host] = cred
+		}
+	}
+}
+
+// ParseDockerConfig reads the "auths" of a Docker config.json or a
+// kubernetes.io/dockerconfigjson Secret's .dockerconfigjson. Keys may be
+// URLs such as "https://index.docker.io/v1/"; they are reduced to the host.
+// Credential helpers (credsStore, credHelpers) are not consulted.
+func ParseDockerConfig(data []byte) (Credentials, error) {
+	var cfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing docker config: %w", err)
+	}
+
+	creds := Credentials{}
+	for key, a := range cfg.Auths {
+		cred := Credential{Username: a.Username, Password: a.Password}
+		if a.Auth != "" {
+			raw, err := base64.StdEncoding.DecodeString(a.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("docker config auth for %s: %w", key, err)
+			}
+			user, pass, ok := strings.Cut(string(raw), ":")
+			if !ok {
+				return nil, fmt.Errorf("docker config auth for %s is not user:password", key)
+			}
+			cred = Credential{Username: user, Password: pass}
+		}
+		if cred.Username == "" && cred.Password == "" {
+			continue
+		}
+		creds[configHost(key)] = cred
+	}
+	return creds, nil
+}
+
+// configHost reduces a docker config auths key to a host.
+func configHost(key string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	host, _, _ := strings.Cut(key, "/")
+	return host
+}
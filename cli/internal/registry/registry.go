@@ -0,0 +1,283 @@
+// Package registry resolves container image tags to digests through the
+// OCI distribution (Docker Registry v2) manifest API.
+//
+// Only manifests are read, never blobs. A multi-arch image resolves to the
+// digest of its manifest list (or OCI index), so every architecture stays
+// pinned to the same release.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUnauthorized is returned when the registry rejects the credentials, or
+// requires credentials that are not configured.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrNotFound is returned when the repository or tag does not exist.
+var ErrNotFound = errors.New("not found")
+
+// manifestAccept lists the manifest media types requested, index types
+// first so multi-arch images resolve to their list digest.
+var manifestAccept = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// Resolver resolves an image reference to the digest of its manifest.
+type Resolver interface {
+	Digest(ctx context.Context, image string) (string, error)
+}
+
+// Client is a Resolver that talks to registries over HTTPS.
+type Client struct {
+	creds Credentials
+	http  *http.Client
+}
+
+// NewClient creates a registry client authenticating with creds, which may
+// be nil for anonymous pulls.
+func NewClient(creds Credentials) *Client {
+	return &Client{
+		creds: creds,
+		http:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Name is the repository as written, without tag or digest, e.g.
+	// "nginx" or "ghcr.io/org/app".
+	Name string
+	// Host is the registry API host, e.g. "registry-1.docker.io".
+	Host string
+	// Path is the repository path on Host, e.g. "library/nginx".
+	Path string
+	// Tag defaults to "latest" when neither a tag nor a digest is given.
+	Tag    string
+	Digest string
+}
+
+// ParseReference splits an image reference into registry host, repository
+// path, tag and digest, applying Docker Hub's defaults.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Name: strings.TrimSpace(image)}
+	if ref.Name == "" {
+		return Reference{}, fmt.Errorf("empty image reference")
+	}
+	if name, digest, ok := strings.Cut(ref.Name, "@"); ok {
+		ref.Name, ref.Digest = name, digest
+	}
+	if i := strings.LastIndex(ref.Name, ":"); i > strings.LastIndex(ref.Name, "/") {
+		ref.Name, ref.Tag = ref.Name[:i], ref.Name[i+1:]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	first, rest, hasSlash := strings.Cut(ref.Name, "/")
+	if hasSlash && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Host, ref.Path = first, rest
+	} else {
+		ref.Host, ref.Path = "docker.io", ref.Name
+	}
+	if ref.Host == "docker.io" || ref.Host == "index.docker.io" {
+		ref.Host = "registry-1.docker.io"
+		if !strings.Contains(ref.Path, "/") {
+			ref.Path = "library/" + ref.Path
+		}
+	}
+	if ref.Path == "" || ref.Path != strings.ToLower(ref.Path) {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	return ref, nil
+}
+
+// Digest returns the manifest digest for image. An image that already
+// carries a digest is returned unchanged without contacting the registry.
+func (c *Client) Digest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Host, ref.Path, url.PathEscape(ref.Tag))
+	resp, err := c.manifest(ctx, http.MethodHead, u, ref)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+		return d, nil
+	}
+
+	// Not every registry sends the digest header on HEAD: hash the manifest.
+	resp, err = c.manifest(ctx, http.MethodGet, u, ref)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+		return d, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", fmt.Errorf("reading manifest for %s: %w", image, err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// manifest requests a manifest, answering an auth challenge once. The
+// caller closes the body of a successful response.
+func (c *Client) manifest(ctx context.Context, method, u string, ref Reference) (*http.Response, error) {
+	resp, err := c.do(ctx, method, u, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		auth, err := c.authorize(ctx, challenge, ref)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, method, u, auth); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s/%s: %w", ref.Host, ref.Path, ErrUnauthorized)
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s:%s in %s: %w", ref.Name, ref.Tag, ref.Host, ErrNotFound)
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("%s %s: unexpected status %s", method, u, resp.Status)
+}
+
+func (c *Client) do(ctx context.Context, method, u, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("contacting registry: %w", err)
+	}
+	return resp, nil
+}
+
+// authorize answers a WWW-Authenticate challenge with an Authorization
+// header value: configured credentials for Basic, or a pull token fetched
+// from the realm for Bearer.
+func (c *Client) authorize(ctx context.Context, challenge string, ref Reference) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	cred, hasCred := c.creds.Lookup(ref.Host)
+
+	switch scheme {
+	case "basic":
+		if !hasCred {
+			return "", fmt.Errorf("%s requires credentials: %w", ref.Host, ErrUnauthorized)
+		}
+		return "Basic " + cred.basic(), nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("%s: bearer challenge without realm", ref.Host)
+		}
+		q := url.Values{}
+		if s := params["service"]; s != "" {
+			q.Set("service", s)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = "repository:" + ref.Path + ":pull"
+		}
+		q.Set("scope", scope)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCred {
+			req.Header.Set("Authorization", "Basic "+cred.basic())
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("requesting registry token: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return "", fmt.Errorf("%s token: %w", ref.Host, ErrUnauthorized)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("requesting registry token: unexpected status %s", resp.Status)
+		}
+		var tok struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+			return "", fmt.Errorf("decoding registry token: %w", err)
+		}
+		if tok.Token == "" {
+			tok.Token = tok.AccessToken
+		}
+		if tok.Token == "" {
+			return "", fmt.Errorf("%s returned an empty token", realm)
+		}
+		return "Bearer " + tok.Token, nil
+	}
+	return "", fmt.Errorf("%s: unsupported auth challenge %q", ref.Host, challenge)
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example",service="registry",scope="..."`.
+func parseChallenge(h string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var kv string
+		rest = strings.TrimLeft(rest, " ,")
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				params[strings.ToLower(strings.TrimSpace(key))] = after[1:]
+				break
+			}
+			kv, rest = after[1:end+1], after[end+2:]
+		} else {
+			kv, rest, _ = strings.Cut(after, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = kv
+	}
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,203 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	listDigest     = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	manifestDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeRegistry serves team/app:main. When the client accepts manifest
+// lists it gets the list digest, as a multi-arch image would. With user
+// set, manifests need a bearer token that the /token realm only issues for
+// that user's basic credentials.
+type fakeRegistry struct {
+	user, pass string
+	// noDigestHeader drops Docker-Content-Digest so clients hash the body.
+	noDigestHeader bool
+	manifest       string
+}
+
+func (f *fakeRegistry) start(t *testing.T) (*httptest.Server, *Client, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != f.user || pass != f.pass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query().Get("scope"); got != "repository:team/app:pull" {
+			t.Errorf("token scope = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "pull-token"})
+	})
+	mux.HandleFunc("/v2/team/app/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if f.user != "" && r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="fake",scope="repository:team/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/main") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		digest := manifestDigest
+		if strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json") {
+			digest = listDigest
+		}
+		if !f.noDigestHeader {
+			w.Header().Set("Docker-Content-Digest", digest)
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(f.manifest))
+		}
+	})
+	srv = httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	c := NewClient(Credentials{})
+	c.http = srv.Client()
+	return srv, c, srv.Listener.Addr().String() + "/team/app"
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Name: "nginx", Host: "registry-1.docker.io", Path: "library/nginx", Tag: "latest"}},
+		{"traefik/whoami:v1.10", Reference{Name: "traefik/whoami", Host: "registry-1.docker.io", Path: "traefik/whoami", Tag: "v1.10"}},
+		{"ghcr.io/org/app:main", Reference{Name: "ghcr.io/org/app", Host: "ghcr.io", Path: "org/app", Tag: "main"}},
+		{"localhost:5000/app", Reference{Name: "localhost:5000/app", Host: "localhost:5000", Path: "app", Tag: "latest"}},
+		{"ghcr.io/org/app@" + listDigest, Reference{Name: "ghcr.io/org/app", Host: "ghcr.io", Path: "org/app", Digest: listDigest}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := ParseReference(tt.image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if _, err := ParseReference("ghcr.io/Org/App:main"); err == nil {
+		t.Error("ParseReference() accepted an upper-case repository")
+	}
+}
+
+func TestDigestPinsManifestList(t *testing.T) {
+	_, c, repo := (&fakeRegistry{}).start(t)
+	got, err := c.Digest(context.Background(), repo+":main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != listDigest {
+		t.Errorf("Digest() = %s, want the manifest list digest %s", got, listDigest)
+	}
+}
+
+func TestDigestHashesManifestWithoutHeader(t *testing.T) {
+	manifest := `{"schemaVersion":2}`
+	_, c, repo := (&fakeRegistry{noDigestHeader: true, manifest: manifest}).start(t)
+	got, err := c.Digest(context.Background(), repo+":main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(manifest))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("Digest() = %s, want %s", got, want)
+	}
+}
+
+func TestDigestBearerAuth(t *testing.T) {
+	_, c, repo := (&fakeRegistry{user: "ci", pass: "s3cret"}).start(t)
+	host, _, _ := strings.Cut(repo, "/")
+
+	if _, err := c.Digest(context.Background(), repo+":main"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Digest() without credentials error = %v, want ErrUnauthorized", err)
+	}
+
+	c.creds = Credentials{host: {Username: "ci", Password: "wrong"}}
+	if _, err := c.Digest(context.Background(), repo+":main"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Digest() with bad credentials error = %v, want ErrUnauthorized", err)
+	}
+
+	c.creds = Credentials{host: {Username: "ci", Password: "s3cret"}}
+	got, err := c.Digest(context.Background(), repo+":main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != listDigest {
+		t.Errorf("Digest() = %s, want %s", got, listDigest)
+	}
+}
+
+func TestDigestNotFound(t *testing.T) {
+	_, c, repo := (&fakeRegistry{}).start(t)
+	if _, err := c.Digest(context.Background(), repo+":missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Digest() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDigestAlreadyPinned(t *testing.T) {
+	c := NewClient(nil)
+	c.http = nil // any request would panic
+	got, err := c.Digest(context.Background(), "ghcr.io/org/app@"+manifestDigest)
+	if err != nil || got != manifestDigest {
+		t.Errorf("Digest() = %s, %v", got, err)
+	}
+}
+
+func TestParseDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("hub-user:hub-pass"))
+	data := []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"ghcr.io": {"username": "gh", "password": "token"},
+		"quay.io": {}
+	}, "credsStore": "desktop"}`)
+	creds, err := ParseDockerConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Credentials{
+		"index.docker.io": {Username: "hub-user", Password: "hub-pass"},
+		"ghcr.io":         {Username: "gh", Password: "token"},
+	}
+	if !reflect.DeepEqual(creds, want) {
+		t.Errorf("ParseDockerConfig() = %+v, want %+v", creds, want)
+	}
+	if got, ok := creds.Lookup("registry-1.docker.io"); !ok || got.Username != "hub-user" {
+		t.Errorf("Lookup(registry-1.docker.io) = %+v, %v", got, ok)
+	}
+	if _, ok := creds.Lookup("quay.io"); ok {
+		t.Error("Lookup(quay.io) found an empty credential")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example/token",service="registry.example",scope="repository:a/b:pull,push"`)
+	want := map[string]string{
+		"realm":   "https://auth.example/token",
+		"service": "registry.example",
+		"scope":   "repository:a/b:pull,push",
+	}
+	if scheme != "bearer" || !reflect.DeepEqual(params, want) {
+		t.Errorf("parseChallenge() = %q, %v", scheme, params)
+	}
+}
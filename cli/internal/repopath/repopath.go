@@ -0,0 +1,68 @@
+// Package repopath converts between repo-relative paths and OS paths.
+//
+// Repo-relative paths are what hctl commits, records in the audit log and
+// writes into files such as addons.yaml, so they always use forward slashes
+// whatever the OS. OS paths, built with Abs, are only for file access.
+package repopath
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// separator is the OS path separator. Simulate replaces it in tests.
+var separator = filepath.Separator
+
+// Simulate makes the package behave as on an OS whose path separator is
+// sep, and returns a function that restores the real one. It lets tests
+// check Windows path handling on any OS.
+func Simulate(sep rune) (restore func()) {
+	old := separator
+	separator = sep
+	return func() { separator = old }
+}
+
+// ToSlash returns p with OS separators and backslashes replaced by forward
+// slashes. Repo paths never contain a literal backslash, so both are
+// treated as separators regardless of the OS.
+func ToSlash(p string) string {
+	if separator != '/' {
+		p = strings.ReplaceAll(p, string(separator), "/")
+	}
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// Join joins elements into a clean, slash-separated repo-relative path.
+func Join(elem ...string) string {
+	parts := make([]string, len(elem))
+	for i, e := range elem {
+		parts[i] = ToSlash(e)
+	}
+	return path.Join(parts...)
+}
+
+// Abs returns the OS path of the repo-relative path rel under root.
+func Abs(root, rel string) string {
+	rel = Join(rel)
+	if separator == filepath.Separator {
+		return filepath.Join(root, filepath.FromSlash(rel))
+	}
+	sep := string(separator)
+	return strings.TrimSuffix(root, sep) + sep + strings.ReplaceAll(rel, "/", sep)
+}
+
+// Rel returns absPath relative to root as a repo-relative path.
+func Rel(root, absPath string) (string, error) {
+	if separator != filepath.Separator {
+		sep := string(separator)
+		if rel, ok := strings.CutPrefix(absPath, strings.TrimSuffix(root, sep)+sep); ok {
+			return Join(rel), nil
+		}
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return "", err
+	}
+	return Join(rel), nil
+}
@@ -0,0 +1,40 @@
+package repopath
+
+import "testing"
+
+func TestToSlashAndJoin(t *testing.T) {
+	tests := []struct {
+		elem []string
+		want string
+	}{
+		{[]string{"workloads", "dev", "addons.yaml"}, "workloads/dev/addons.yaml"},
+		{[]string{`workloads\dev`, "addons", "api/values.yaml"}, "workloads/dev/addons/api/values.yaml"},
+		{[]string{`platform\vclusters\`, "media.yaml"}, "platform/vclusters/media.yaml"},
+		{[]string{"./addons/", "../addons/x"}, "addons/x"},
+	}
+	for _, tt := range tests {
+		if got := Join(tt.elem...); got != tt.want {
+			t.Errorf("Join(%q) = %q, want %q", tt.elem, got, tt.want)
+		}
+	}
+}
+
+func TestSimulatedWindows(t *testing.T) {
+	defer Simulate('\\')()
+
+	root := `C:\repo`
+	abs := Abs(root, "workloads/dev/addons.yaml")
+	if want := `C:\repo\workloads\dev\addons.yaml`; abs != want {
+		t.Errorf("Abs() = %q, want %q", abs, want)
+	}
+	rel, err := Rel(root, abs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "workloads/dev/addons.yaml"; rel != want {
+		t.Errorf("Rel() = %q, want %q", rel, want)
+	}
+	if got := ToSlash(`addons\clusters\dev`); got != "addons/clusters/dev" {
+		t.Errorf("ToSlash() = %q", got)
+	}
+}
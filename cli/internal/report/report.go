@@ -0,0 +1,346 @@
+// Package report records the stages of an hctl deploy as test cases and
+// writes them as JUnit XML or JSON, so CI can show which stage failed and
+// why. 'hctl deploy run --report' and 'hctl deploy status --watch --report'
+// use it.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// Stage outcomes.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Report formats accepted by ParseTargets.
+const (
+	FormatJUnit = "junit"
+	FormatJSON  = "json"
+)
+
+// Stage is one recorded stage.
+type Stage struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Millis int64  `json:"durationMs"`
+	// Message says why the stage failed or was skipped, or what it did.
+	Message string `json:"message,omitempty"`
+	// Details are supporting lines: condition messages, pod events.
+	Details []string `json:"details,omitempty"`
+}
+
+// Report is the stages of one workload's deploy, in plan order.
+type Report struct {
+	Workload  string    `json:"workload"`
+	Cluster   string    `json:"cluster"`
+	Timestamp time.Time `json:"timestamp"`
+	Millis    int64     `json:"durationMs"`
+	Passed    bool      `json:"passed"`
+	Stages    []Stage   `json:"stages"`
+}
+
+// Recorder times stages against a clock. Each stage lasts from the end of
+// the previous one, or from Start, until it passes or fails. A nil
+// *Recorder records nothing, so callers need not check whether a report was
+// asked for.
+type Recorder struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	start    time.Time
+	mark     time.Time
+	workload string
+	cluster  string
+	plan     []string
+	stages   map[string]Stage
+}
+
+// NewRecorder starts a recorder for the planned stages, reading now, or
+// time.Now when now is nil. Stages recorded outside the plan are appended
+// after it.
+func NewRecorder(now func() time.Time, plan ...string) *Recorder {
+	if now == nil {
+		now = time.Now
+	}
+	t := now()
+	return &Recorder{now: now, start: t, mark: t, plan: append([]string(nil), plan...), stages: map[string]Stage{}}
+}
+
+// SetWorkload names the workload and cluster the report is for.
+func (r *Recorder) SetWorkload(workload, cluster string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workload, r.cluster = workload, cluster
+}
+
+// Start begins the next stage now, so time spent since the last stage, such
+// as waiting for confirmation, is not counted.
+func (r *Recorder) Start() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mark = r.now()
+}
+
+// Pass records stage as passed.
+func (r *Recorder) Pass(stage, message string) {
+	r.record(stage, StatusPassed, message, nil)
+}
+
+// Fail records stage as failed with the reason and supporting details.
+func (r *Recorder) Fail(stage, message string, details ...string) {
+	r.record(stage, StatusFailed, message, details)
+}
+
+// Done records stage as passed when err is nil, or failed with err.
+func (r *Recorder) Done(stage string, err error) {
+	if err != nil {
+		r.Fail(stage, err.Error())
+		return
+	}
+	r.Pass(stage, "")
+}
+
+// Recorded reports whether stage has been recorded.
+func (r *Recorder) Recorded(stage string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.stages[stage]
+	return ok
+}
+
+func (r *Recorder) record(stage, status, message string, details []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.stages[stage]; ok {
+		return
+	}
+	now := r.now()
+	d := now.Sub(r.mark)
+	r.mark = now
+	r.stages[stage] = Stage{Name: stage, Status: status, Millis: d.Milliseconds(), Message: message, Details: details}
+	if !r.planned(stage) {
+		r.plan = append(r.plan, stage)
+	}
+}
+
+func (r *Recorder) planned(stage string) bool {
+	for _, s := range r.plan {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Report returns the stages so far. When err is non-nil and no stage
+// failed, the first unrecorded stage fails with it; the remaining
+// unrecorded stages are skipped.
+func (r *Recorder) Report(err error) *Report {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := &Report{
+		Workload:  r.workload,
+		Cluster:   r.cluster,
+		Timestamp: r.start,
+		Millis:    r.now().Sub(r.start).Milliseconds(),
+		Passed:    err == nil,
+	}
+	failed := false
+	for _, s := range r.stages {
+		failed = failed || s.Status == StatusFailed
+	}
+	for _, name := range r.plan {
+		s, ok := r.stages[name]
+		switch {
+		case ok:
+		case err != nil && !failed:
+			failed = true
+			s = Stage{Name: name, Status: StatusFailed, Message: err.Error()}
+		default:
+			s = Stage{Name: name, Status: StatusSkipped, Message: "not reached"}
+		}
+		rep.Passed = rep.Passed && s.Status != StatusFailed
+		rep.Stages = append(rep.Stages, s)
+	}
+	return rep
+}
+
+// Target is a report file to write.
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTargets reads --report values of the form format=path, where format
+// is junit or json.
+func ParseTargets(specs []string) ([]Target, error) {
+	var targets []Target
+	for _, spec := range specs {
+		format, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, hcerrors.NewUserError("--report %q: expected format=path, e.g. junit=report.xml", spec)
+		}
+		if format != FormatJUnit && format != FormatJSON {
+			return nil, hcerrors.NewUserError("--report %q: unknown format %q (expected junit or json)", spec, format)
+		}
+		targets = append(targets, Target{Format: format, Path: path})
+	}
+	return targets, nil
+}
+
+// Write writes the report of r, with the command's final err, to every
+// target. It is meant to run deferred, so reports exist even when the
+// command fails or times out.
+func Write(r *Recorder, err error, targets []Target) error {
+	if r == nil || len(targets) == 0 {
+		return nil
+	}
+	rep := r.Report(err)
+	for _, t := range targets {
+		if err := writeFile(t, rep); err != nil {
+			return fmt.Errorf("writing %s report: %w", t.Format, err)
+		}
+	}
+	return nil
+}
+
+func writeFile(t Target, rep *Report) error {
+	if dir := filepath.Dir(t.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(t.Path)
+	if err != nil {
+		return err
+	}
+	if t.Format == FormatJUnit {
+		err = WriteJUnit(f, rep)
+	} else {
+		err = WriteJSON(f, rep)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteJSON writes rep as indented JSON.
+func WriteJSON(w io.Writer, rep *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes rep as a JUnit XML test suite, one test case per stage.
+func WriteJUnit(w io.Writer, rep *Report) error {
+	suite := junitSuite{
+		Name:      "hctl deploy " + rep.Workload,
+		Time:      seconds(rep.Millis),
+		Timestamp: rep.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if rep.Cluster != "" {
+		suite.Name += " (" + rep.Cluster + ")"
+	}
+	for _, s := range rep.Stages {
+		c := junitCase{Name: s.Name, Classname: "hctl.deploy." + rep.Workload, Time: seconds(s.Millis)}
+		body := strings.Join(s.Details, "\n")
+		switch s.Status {
+		case StatusFailed:
+			suite.Failures++
+			c.Failure = &junitMessage{Message: s.Message, Body: body}
+		case StatusSkipped:
+			suite.Skipped++
+			c.Skipped = &junitMessage{Message: s.Message}
+		default:
+			c.SystemOut = strings.TrimSpace(s.Message + "\n" + body)
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+	suites := junitSuites{
+		Name:     "hctl",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// seconds formats milliseconds as JUnit's decimal seconds.
+func seconds(ms int64) string {
+	return fmt.Sprintf("%d.%03d", ms/1000, ms%1000)
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// fakeClock returns the current time and advances it by step on each read.
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	t := c.t
+	c.t = c.t.Add(c.step)
+	return t
+}
+
+var plan = []string{
+	"translate", "write", "commit",
+	"app created", "synced", "secrets ready", "cert ready", "pods ready", "route programmed",
+}
+
+func newTestRecorder() *Recorder {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), step: 1500 * time.Millisecond}
+	rec := NewRecorder(clock.now, plan...)
+	rec.SetWorkload("web", "vcluster-media")
+	return rec
+}
+
+// passUntil passes the planned stages before stop.
+func passUntil(rec *Recorder, stop string) {
+	for _, stage := range plan {
+		if stage == stop {
+			return
+		}
+		rec.Pass(stage, "")
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	timeout := hcerrors.NewTimeoutError("timeout waiting for sync after 5m0s")
+	tests := []struct {
+		name string
+		run  func(rec *Recorder) error
+	}{
+		{
+			name: "passing",
+			run: func(rec *Recorder) error {
+				rec.Pass("translate", "cluster=vcluster-media ns=media resources=db(postgres),route(route)")
+				rec.Pass("write", "3 files")
+				passUntil(rec, "")
+				return nil
+			},
+		},
+		{
+			name: "cert-timeout",
+			run: func(rec *Recorder) error {
+				passUntil(rec, "cert ready")
+				rec.Fail("cert ready",
+					"timed out after 5m0s: Certificate web-tls: Progressing: Issuing certificate as Secret does not exist",
+					"Certificate web-tls: Progressing: Issuing certificate as Secret does not exist")
+				return timeout
+			},
+		},
+		{
+			name: "crashloop",
+			run: func(rec *Recorder) error {
+				passUntil(rec, "pods ready")
+				rec.Fail("pods ready",
+					"timed out after 5m0s: 0/1 pods ready; pod web-6d9f7c-x2x: CrashLoopBackOff: back-off 2m40s restarting failed container=web (5 restarts)",
+					"pod web-6d9f7c-x2x: CrashLoopBackOff: back-off 2m40s restarting failed container=web (5 restarts)",
+					"  BackOff: Back-off restarting failed container web (x12)")
+				return timeout
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newTestRecorder()
+			rep := rec.Report(tt.run(rec))
+
+			var junit, js bytes.Buffer
+			if err := WriteJUnit(&junit, rep); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteJSON(&js, rep); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".golden.xml", junit.Bytes())
+			checkGolden(t, tt.name+".golden.json", js.Bytes())
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("report does not match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestReportFailsFirstPendingStage(t *testing.T) {
+	rec := newTestRecorder()
+	rec.Pass("translate", "")
+	rep := rec.Report(errors.New("1Password item web-db not found"))
+
+	if rep.Passed {
+		t.Error("Passed = true, want false")
+	}
+	if s := rep.Stages[1]; s.Status != StatusFailed || s.Message != "1Password item web-db not found" {
+		t.Errorf("stage %q = %s %q, want failed with the error", s.Name, s.Status, s.Message)
+	}
+	for _, s := range rep.Stages[2:] {
+		if s.Status != StatusSkipped {
+			t.Errorf("stage %q = %s, want skipped", s.Name, s.Status)
+		}
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var rec *Recorder
+	rec.Start()
+	rec.Pass("translate", "")
+	if rec.Recorded("translate") {
+		t.Error("nil recorder recorded a stage")
+	}
+	if err := Write(rec, nil, []Target{{Format: FormatJSON, Path: filepath.Join(t.TempDir(), "r.json")}}); err != nil {
+		t.Errorf("Write(nil) = %v", err)
+	}
+}
+
+func TestWriteCreatesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	targets := []Target{
+		{Format: FormatJUnit, Path: filepath.Join(dir, "reports", "deploy.xml")},
+		{Format: FormatJSON, Path: filepath.Join(dir, "reports", "deploy.json")},
+	}
+	rec := newTestRecorder()
+	if err := Write(rec, errors.New("boom"), targets); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range targets {
+		if _, err := os.Stat(target.Path); err != nil {
+			t.Errorf("%s report not written: %v", target.Format, err)
+		}
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got, err := ParseTargets([]string{"junit=out/deploy.xml", "json=deploy.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{{FormatJUnit, "out/deploy.xml"}, {FormatJSON, "deploy.json"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ParseTargets = %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"deploy.xml", "junit=", "tap=deploy.tap"} {
+		_, err := ParseTargets([]string{spec})
+		if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+			t.Errorf("ParseTargets(%q) = %v, want a usage error", spec, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/onepassword"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NotFound marks an ExternalSecret or Secret missing from the cluster.
+const NotFound = "NotFound"
+
+// Row is a Link with its live state, as printed by the secrets commands.
+type Row struct {
+	Link `yaml:",inline"`
+	// Ready is the ExternalSecret's Ready condition status, or NotFound.
+	Ready   string `json:"ready,omitempty" yaml:"ready,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// SecretAge is how long ago the target Secret was created, or NotFound.
+	SecretAge string `json:"secretAge,omitempty" yaml:"secretAge,omitempty"`
+	// Verified is true when the Sources were checked against 1Password;
+	// Missing then lists the items and fields that were not found.
+	Verified bool     `json:"verified,omitempty" yaml:"verified,omitempty"`
+	Missing  []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+}
+
+// Live looks up each link's ExternalSecret readiness and target Secret age.
+// namespace is used for links that do not name one. Lookups are
+// best-effort: with a nil client the rows carry no live state.
+func Live(ctx context.Context, client *kube.Client, links []Link, namespace string) []Row {
+	rows := make([]Row, len(links))
+	for i, l := range links {
+		rows[i] = Row{Link: l}
+		if client == nil {
+			continue
+		}
+		ns := l.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+		if l.ExternalSecret != "" {
+			if es, err := client.GetExternalSecret(ctx, ns, l.ExternalSecret); err == nil {
+				rows[i].Ready, rows[i].Message = externalSecretReady(es.Object)
+			} else {
+				rows[i].Ready = NotFound
+			}
+		}
+		if created, err := client.SecretCreated(ctx, ns, l.Secret); err == nil {
+			rows[i].SecretAge = tui.FormatAge(created)
+		} else {
+			rows[i].SecretAge = NotFound
+		}
+	}
+	return rows
+}
+
+// Verify records the result of checking the rows' Sources against
+// 1Password: missing is what onepassword.Client.Check returned for
+// Requirements of the same links.
+func Verify(rows []Row, missing []onepassword.Missing) {
+	byItem := map[string]onepassword.Missing{}
+	for _, m := range missing {
+		byItem[m.Item] = m
+	}
+	for i := range rows {
+		if len(rows[i].Sources) == 0 {
+			continue
+		}
+		rows[i].Verified = true
+		rows[i].Missing = nil
+		seen := map[string]bool{}
+		for _, src := range rows[i].Sources {
+			m, ok := byItem[src.Item]
+			if !ok {
+				continue
+			}
+			entry := ""
+			switch {
+			case m.ItemMissing:
+				entry = src.Item
+			case src.Property != "" && containsString(m.Fields, src.Property):
+				entry = src.Item + "/" + src.Property
+			}
+			if entry != "" && !seen[entry] {
+				seen[entry] = true
+				rows[i].Missing = append(rows[i].Missing, entry)
+			}
+		}
+	}
+}
+
+// CheckOnePassword checks the rows' Sources against a vault and records
+// the result with Verify. Only item titles and field labels are read.
+func CheckOnePassword(ctx context.Context, client *onepassword.Client, vault string, rows []Row) error {
+	links := make([]Link, len(rows))
+	for i, r := range rows {
+		links[i] = r.Link
+	}
+	missing, err := client.Check(ctx, vault, Requirements(links))
+	if err != nil {
+		return err
+	}
+	Verify(rows, missing)
+	return nil
+}
+
+// PodConsumers returns the Secrets used by the pods in a namespace, keyed
+// by Secret name. Containers are named <app>/<container>, where app is the
+// pod's app.kubernetes.io/name or app label, falling back to the pod name.
+func PodConsumers(ctx context.Context, client *kube.Client, namespace string) (map[string][]Consumer, error) {
+	pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	out := map[string][]Consumer{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		app := pod.Labels["app.kubernetes.io/name"]
+		if app == "" {
+			app = pod.Labels["app"]
+		}
+		if app == "" {
+			app = pod.Name
+		}
+		spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pod.Spec)
+		if err != nil {
+			continue
+		}
+		for secret, cs := range PodSpecConsumers(spec) {
+			for _, c := range cs {
+				c.Container = app + "/" + c.Container
+				out[secret] = append(out[secret], c)
+			}
+		}
+	}
+	return out, nil
+}
+
+// externalSecretReady reads an ExternalSecret's Ready condition.
+func externalSecretReady(obj map[string]interface{}) (status, message string) {
+	for _, c := range platform.StatusConditions(obj) {
+		if c.Type == platform.ConditionReady {
+			message = c.Message
+			if c.Status != "True" && c.Reason != "" {
+				message = strings.TrimSpace(c.Reason + ": " + c.Message)
+			}
+			return c.Status, message
+		}
+	}
+	return "Unknown", ""
+}
+
+// Table renders rows for the terminal. The VERIFIED column is added when
+// the rows were checked against 1Password.
+func Table(rows []Row) string {
+	verified := false
+	for _, r := range rows {
+		verified = verified || r.Verified
+	}
+
+	headers := []string{"SECRET", "EXTERNALSECRET", "1PASSWORD", "CONSUMERS", "READY", "AGE"}
+	if verified {
+		headers = append(headers, "VERIFIED")
+	}
+	var cells [][]string
+	for _, r := range rows {
+		consumers := make([]string, len(r.Consumers))
+		for i, c := range r.Consumers {
+			consumers[i] = c.String()
+		}
+		es := r.ExternalSecret
+		if r.Templated {
+			es += " (templated)"
+		}
+		row := []string{
+			r.Secret,
+			tui.OrDash(es),
+			tui.OrDash(r.SourceSummary()),
+			tui.OrDash(strings.Join(consumers, "\n")),
+			readyCell(r),
+			tui.OrDash(r.SecretAge),
+		}
+		if verified {
+			row = append(row, verifiedCell(r))
+		}
+		cells = append(cells, row)
+	}
+	return tui.Table(headers, cells)
+}
+
+func readyCell(r Row) string {
+	switch r.Ready {
+	case "":
+		return "—"
+	case "True":
+		return tui.SuccessStyle.Render(tui.IconCheck + " Ready")
+	case NotFound:
+		return tui.ErrorStyle.Render(tui.IconCross + " " + NotFound)
+	}
+	cell := tui.WarningStyle.Render(tui.IconWarn + " " + r.Ready)
+	if r.Message != "" {
+		cell += "\n" + tui.DimStyle.Render(r.Message)
+	}
+	return cell
+}
+
+func verifiedCell(r Row) string {
+	switch {
+	case !r.Verified:
+		return "—"
+	case len(r.Missing) == 0:
+		return tui.SuccessStyle.Render(tui.IconCheck)
+	}
+	return tui.ErrorStyle.Render(tui.IconCross + " missing " + strings.Join(r.Missing, ", "))
+}
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/onepassword"
+)
+
+func TestVerify(t *testing.T) {
+	rows := []Row{
+		{Link: Link{Secret: "db", Sources: []Source{
+			{Item: "app-db", Property: "host"},
+			{Item: "app-db", Property: "password"},
+		}}},
+		{Link: Link{Secret: "admin", Sources: []Source{{Item: "app-admin"}}}},
+		{Link: Link{Secret: "ok", Sources: []Source{{Item: "app-ok", Property: "token"}}}},
+		{Link: Link{Secret: "tls"}},
+	}
+	Verify(rows, []onepassword.Missing{
+		{Item: "app-admin", ItemMissing: true},
+		{Item: "app-db", Fields: []string{"password"}},
+	})
+
+	want := []struct {
+		verified bool
+		missing  []string
+	}{
+		{true, []string{"app-db/password"}},
+		{true, []string{"app-admin"}},
+		{true, nil},
+		{false, nil},
+	}
+	for i, w := range want {
+		if rows[i].Verified != w.verified || !reflect.DeepEqual(rows[i].Missing, w.missing) {
+			t.Errorf("%s: verified=%v missing=%v, want %v %v", rows[i].Secret, rows[i].Verified, rows[i].Missing, w.verified, w.missing)
+		}
+	}
+}
+
+func TestExternalSecretReady(t *testing.T) {
+	obj := map[string]interface{}{"status": map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "reason": "SecretSyncedError", "message": "could not get secret data from provider"},
+		},
+	}}
+	status, message := externalSecretReady(obj)
+	if status != "False" || message != "SecretSyncedError: could not get secret data from provider" {
+		t.Errorf("externalSecretReady() = %q, %q", status, message)
+	}
+	if status, _ := externalSecretReady(map[string]interface{}{}); status != "Unknown" {
+		t.Errorf("no conditions: status = %q, want Unknown", status)
+	}
+}
@@ -0,0 +1,396 @@
+// Package secrets traces where a workload's credentials come from: the
+// Kubernetes Secrets its containers consume, the ExternalSecrets that
+// produce them, and the 1Password items those read.
+//
+// It works on manifests and chart values only. Secret data is never read,
+// so nothing here can print a secret value.
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// Source is a 1Password item (and field) an ExternalSecret reads.
+type Source struct {
+	// Item is the 1Password item title (remoteRef.key or extract.key).
+	Item string `json:"item" yaml:"item"`
+	// Property is the item field. Empty when the whole item is extracted.
+	Property string `json:"property,omitempty" yaml:"property,omitempty"`
+	// Key is the Secret key the field lands in. Empty for dataFrom.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+func (s Source) String() string {
+	if s.Property == "" {
+		return s.Item + " (all fields)"
+	}
+	return s.Item + "/" + s.Property
+}
+
+// Consumer is a container's use of a Secret.
+type Consumer struct {
+	Container string `json:"container" yaml:"container"`
+	// Env is the environment variable set from Key. Empty for envFrom and
+	// mounts.
+	Env string `json:"env,omitempty" yaml:"env,omitempty"`
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// EnvFrom is true when every key is imported as an environment variable.
+	EnvFrom bool `json:"envFrom,omitempty" yaml:"envFrom,omitempty"`
+	// Mount is the path the Secret is mounted at.
+	Mount string `json:"mount,omitempty" yaml:"mount,omitempty"`
+}
+
+func (c Consumer) String() string {
+	switch {
+	case c.Env != "":
+		return fmt.Sprintf("%s: $%s", c.Container, c.Env)
+	case c.EnvFrom:
+		return c.Container + ": envFrom"
+	case c.Mount != "":
+		return c.Container + ": " + c.Mount
+	}
+	return c.Container
+}
+
+// Link ties a Secret to the ExternalSecret that produces it and the
+// containers that consume it. Secrets consumed but not produced by an
+// ExternalSecret (TLS Secrets from cert-manager, hand-made Secrets) have no
+// ExternalSecret or Sources.
+type Link struct {
+	Secret         string `json:"secret" yaml:"secret"`
+	Namespace      string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ExternalSecret string `json:"externalSecret,omitempty" yaml:"externalSecret,omitempty"`
+	Store          string `json:"store,omitempty" yaml:"store,omitempty"`
+	// Templated is true when the ExternalSecret renders the Secret through
+	// target.template, so its keys are not the remote fields one to one.
+	Templated bool       `json:"templated,omitempty" yaml:"templated,omitempty"`
+	Sources   []Source   `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Consumers []Consumer `json:"consumers,omitempty" yaml:"consumers,omitempty"`
+}
+
+// SourceSummary lists the 1Password items the link reads, each with its
+// fields: "app-db: host, password".
+func (l Link) SourceSummary() string {
+	var items []string
+	fields := map[string][]string{}
+	for _, src := range l.Sources {
+		if _, ok := fields[src.Item]; !ok {
+			items = append(items, src.Item)
+			fields[src.Item] = nil
+		}
+		if src.Property != "" && !containsString(fields[src.Item], src.Property) {
+			fields[src.Item] = append(fields[src.Item], src.Property)
+		}
+	}
+	lines := make([]string, len(items))
+	for i, item := range items {
+		if len(fields[item]) == 0 {
+			lines[i] = item + " (all fields)"
+			continue
+		}
+		lines[i] = item + ": " + strings.Join(fields[item], ", ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Requirements returns the 1Password items and fields links read, for
+// onepassword.Client.Check.
+func Requirements(links []Link) []provisioners.SecretRequirement {
+	var reqs []provisioners.SecretRequirement
+	index := map[string]int{}
+	for _, l := range links {
+		for _, s := range l.Sources {
+			i, ok := index[s.Item]
+			if !ok {
+				i = len(reqs)
+				index[s.Item] = i
+				reqs = append(reqs, provisioners.SecretRequirement{Item: s.Item})
+			}
+			if s.Property != "" && !containsString(reqs[i].Fields, s.Property) {
+				reqs[i].Fields = append(reqs[i].Fields, s.Property)
+			}
+		}
+	}
+	return reqs
+}
+
+// FromValues analyzes Stakater Application chart values as written by hctl
+// deploy: ExternalSecrets in extraObjects, and the main and additional
+// containers' env, envFrom and volume mounts.
+func FromValues(values map[string]interface{}) []Link {
+	var manifests []map[string]interface{}
+	for _, obj := range asMapSlice(values["extraObjects"]) {
+		manifests = append(manifests, obj)
+	}
+
+	deployment, _ := values["deployment"].(map[string]interface{})
+	container, _ := values["applicationName"].(string)
+	// A cronjob workload's pod is the cronJob job named after it.
+	if cron, ok := values["cronJob"].(map[string]interface{}); ok && cron["enabled"] == true {
+		jobs, _ := cron["jobs"].(map[string]interface{})
+		deployment, _ = jobs[container].(map[string]interface{})
+	}
+	if container == "" {
+		container = "main"
+	}
+	consumers := chartConsumers(container, deployment)
+	for _, c := range asMapSlice(deployment["additionalContainers"]) {
+		addConsumers(consumers, containerConsumers(c, podVolumes(deployment["volumes"])))
+	}
+	return Join(FromManifests(manifests), consumers)
+}
+
+// FromManifests returns a Link per ExternalSecret in manifests, named after
+// its target Secret. Other kinds are ignored.
+func FromManifests(manifests []map[string]interface{}) []Link {
+	var links []Link
+	for _, m := range manifests {
+		if kind, _ := m["kind"].(string); kind != "ExternalSecret" {
+			continue
+		}
+		meta, _ := m["metadata"].(map[string]interface{})
+		spec, _ := m["spec"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		l := Link{ExternalSecret: name, Secret: name}
+		l.Namespace, _ = meta["namespace"].(string)
+
+		if store, ok := spec["secretStoreRef"].(map[string]interface{}); ok {
+			l.Store, _ = store["name"].(string)
+		}
+		if target, ok := spec["target"].(map[string]interface{}); ok {
+			// ESO names the Secret after the ExternalSecret unless told otherwise.
+			if t, _ := target["name"].(string); t != "" {
+				l.Secret = t
+			}
+			_, l.Templated = target["template"].(map[string]interface{})
+		}
+
+		for _, entry := range asMapSlice(spec["data"]) {
+			ref, _ := entry["remoteRef"].(map[string]interface{})
+			src := Source{}
+			src.Item, _ = ref["key"].(string)
+			src.Property, _ = ref["property"].(string)
+			src.Key, _ = entry["secretKey"].(string)
+			if src.Item != "" {
+				l.Sources = append(l.Sources, src)
+			}
+		}
+		for _, entry := range asMapSlice(spec["dataFrom"]) {
+			extract, _ := entry["extract"].(map[string]interface{})
+			src := Source{}
+			src.Item, _ = extract["key"].(string)
+			src.Property, _ = extract["property"].(string)
+			if src.Item != "" {
+				l.Sources = append(l.Sources, src)
+			}
+		}
+		links = append(links, l)
+	}
+	return links
+}
+
+// PodSpecConsumers returns the Secrets used by a Kubernetes pod spec's
+// containers and init containers, keyed by Secret name.
+func PodSpecConsumers(spec map[string]interface{}) map[string][]Consumer {
+	volumes := podVolumes(spec["volumes"])
+	out := map[string][]Consumer{}
+	for _, field := range []string{"initContainers", "containers"} {
+		for _, c := range asMapSlice(spec[field]) {
+			addConsumers(out, containerConsumers(c, volumes))
+		}
+	}
+	return out
+}
+
+// Join attaches consumers to the links producing their Secrets, adds a
+// link for each consumed Secret nothing produces, and sorts by Secret name.
+func Join(links []Link, consumers map[string][]Consumer) []Link {
+	out := make([]Link, len(links))
+	copy(out, links)
+	produced := map[string]bool{}
+	for i := range out {
+		produced[out[i].Secret] = true
+		out[i].Consumers = append(out[i].Consumers, consumers[out[i].Secret]...)
+	}
+	for secret, cs := range consumers {
+		if !produced[secret] {
+			out = append(out, Link{Secret: secret, Consumers: cs})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Secret < out[j].Secret })
+	for i := range out {
+		out[i].Consumers = sortConsumers(out[i].Consumers)
+	}
+	return out
+}
+
+// chartConsumers reads the chart's main container settings, where env,
+// envFrom, volumes and volumeMounts are maps keyed by name.
+func chartConsumers(container string, deployment map[string]interface{}) map[string][]Consumer {
+	out := map[string][]Consumer{}
+	if env, ok := deployment["env"].(map[string]interface{}); ok {
+		for name, v := range env {
+			entry, _ := v.(map[string]interface{})
+			if secret, key := secretKeyRef(entry); secret != "" {
+				out[secret] = append(out[secret], Consumer{Container: container, Env: name, Key: key})
+			}
+		}
+	}
+	switch envFrom := deployment["envFrom"].(type) {
+	case map[string]interface{}:
+		for name, v := range envFrom {
+			entry, _ := v.(map[string]interface{})
+			if t, _ := entry["type"].(string); t != "secret" {
+				continue
+			}
+			secret, _ := entry["name"].(string)
+			if secret == "" {
+				secret = name
+			}
+			out[secret] = append(out[secret], Consumer{Container: container, EnvFrom: true})
+		}
+	case []interface{}:
+		addConsumers(out, containerConsumers(map[string]interface{}{"name": container, "envFrom": envFrom}, nil))
+	}
+
+	volumes := podVolumes(deployment["volumes"])
+	if mounts, ok := deployment["volumeMounts"].(map[string]interface{}); ok {
+		for name, v := range mounts {
+			mount, _ := v.(map[string]interface{})
+			path, _ := mount["mountPath"].(string)
+			if secret := volumes[name]; secret != "" {
+				out[secret] = append(out[secret], Consumer{Container: container, Mount: path})
+			}
+		}
+	}
+	return out
+}
+
+// containerConsumers reads a Kubernetes container spec. volumes maps volume
+// names to the Secrets they project.
+func containerConsumers(c map[string]interface{}, volumes map[string]string) map[string][]Consumer {
+	name, _ := c["name"].(string)
+	out := map[string][]Consumer{}
+	for _, env := range asMapSlice(c["env"]) {
+		if secret, key := secretKeyRef(env); secret != "" {
+			envName, _ := env["name"].(string)
+			out[secret] = append(out[secret], Consumer{Container: name, Env: envName, Key: key})
+		}
+	}
+	for _, from := range asMapSlice(c["envFrom"]) {
+		ref, _ := from["secretRef"].(map[string]interface{})
+		if secret, _ := ref["name"].(string); secret != "" {
+			out[secret] = append(out[secret], Consumer{Container: name, EnvFrom: true})
+		}
+	}
+	for _, mount := range asMapSlice(c["volumeMounts"]) {
+		volume, _ := mount["name"].(string)
+		path, _ := mount["mountPath"].(string)
+		if secret := volumes[volume]; secret != "" {
+			out[secret] = append(out[secret], Consumer{Container: name, Mount: path})
+		}
+	}
+	return out
+}
+
+// podVolumes maps volume names to the Secret each projects. It accepts
+// both a pod spec's list and the chart's map keyed by volume name, and
+// covers secret volumes and secret sources of projected volumes.
+func podVolumes(v interface{}) map[string]string {
+	out := map[string]string{}
+	add := func(name string, vol map[string]interface{}) {
+		if s, ok := vol["secret"].(map[string]interface{}); ok {
+			if secret, _ := s["secretName"].(string); secret != "" {
+				out[name] = secret
+			}
+		}
+		if p, ok := vol["projected"].(map[string]interface{}); ok {
+			for _, src := range asMapSlice(p["sources"]) {
+				if s, ok := src["secret"].(map[string]interface{}); ok {
+					if secret, _ := s["name"].(string); secret != "" {
+						out[name] = secret
+					}
+				}
+			}
+		}
+	}
+	switch vols := v.(type) {
+	case map[string]interface{}:
+		for name, vol := range vols {
+			if m, ok := vol.(map[string]interface{}); ok {
+				add(name, m)
+			}
+		}
+	default:
+		for _, vol := range asMapSlice(v) {
+			name, _ := vol["name"].(string)
+			add(name, vol)
+		}
+	}
+	return out
+}
+
+// secretKeyRef returns the Secret and key of an env entry's
+// valueFrom.secretKeyRef.
+func secretKeyRef(env map[string]interface{}) (secret, key string) {
+	from, _ := env["valueFrom"].(map[string]interface{})
+	ref, _ := from["secretKeyRef"].(map[string]interface{})
+	secret, _ = ref["name"].(string)
+	key, _ = ref["key"].(string)
+	return secret, key
+}
+
+func addConsumers(dst, src map[string][]Consumer) {
+	for secret, cs := range src {
+		dst[secret] = append(dst[secret], cs...)
+	}
+}
+
+// sortConsumers orders consumers by container and drops duplicates, such
+// as the same container seen in several replicas.
+func sortConsumers(cs []Consumer) []Consumer {
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].Container != cs[j].Container {
+			return cs[i].Container < cs[j].Container
+		}
+		return cs[i].String() < cs[j].String()
+	})
+	out := cs[:0]
+	for i, c := range cs {
+		if i == 0 || c != cs[i-1] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// asMapSlice normalizes a manifest list field to a slice of maps.
+func asMapSlice(v interface{}) []map[string]interface{} {
+	switch list := v.(type) {
+	case []map[string]interface{}:
+		return list
+	case []interface{}:
+		out := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
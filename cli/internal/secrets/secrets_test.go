@@ -0,0 +1,180 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"gopkg.in/yaml.v3"
+)
+
+func loadValues(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestFromValues(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Link
+	}{
+		{
+			file: "generated.values.yaml",
+			want: []Link{{
+				Secret:         "api-db-credentials",
+				Namespace:      "apps",
+				ExternalSecret: "api-db-credentials",
+				Store:          "onepassword-connect",
+				Sources: []Source{
+					{Item: "api-db-db", Property: "host", Key: "host"},
+					{Item: "api-db-db", Property: "username", Key: "username"},
+					{Item: "api-db-db", Property: "password", Key: "password"},
+				},
+				Consumers: []Consumer{
+					{Container: "api", Env: "DB_HOST", Key: "host"},
+					{Container: "api", Env: "DB_PASSWORD", Key: "password"},
+					{Container: "migrate", Env: "DATABASE_USER", Key: "username"},
+				},
+			}},
+		},
+		{
+			file: "extract.values.yaml",
+			want: []Link{{
+				Secret:         "otterwiki-secret",
+				Namespace:      "media",
+				ExternalSecret: "eso-otterwiki-secret",
+				Store:          "onepassword-store",
+				Sources:        []Source{{Item: "otterwiki-secret"}},
+				Consumers: []Consumer{
+					{Container: "otterwiki", Env: "OTTERWIKI_SECRET_KEY", Key: "SECRET_KEY"},
+					{Container: "otterwiki", EnvFrom: true},
+				},
+			}},
+		},
+		{
+			file: "templated.values.yaml",
+			want: []Link{
+				{
+					Secret:         "grafana-admin",
+					Namespace:      "monitoring",
+					ExternalSecret: "grafana-admin",
+					Store:          "onepassword-store",
+					Sources:        []Source{{Item: "grafana-admin"}},
+					Consumers:      []Consumer{{Container: "sidecar", EnvFrom: true}},
+				},
+				{
+					Secret:         "grafana-datasources",
+					Namespace:      "monitoring",
+					ExternalSecret: "grafana-datasources",
+					Store:          "onepassword-store",
+					Templated:      true,
+					Sources: []Source{
+						{Item: "grafana-prometheus", Property: "url", Key: "url"},
+						{Item: "grafana-prometheus", Property: "password", Key: "password"},
+					},
+					Consumers: []Consumer{{Container: "grafana", Mount: "/etc/grafana/provisioning/datasources"}},
+				},
+				{
+					Secret:    "grafana-tls",
+					Consumers: []Consumer{{Container: "grafana", Mount: "/etc/tls"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := FromValues(loadValues(t, tt.file))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromValues() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromValuesEmpty(t *testing.T) {
+	if got := FromValues(map[string]interface{}{}); len(got) != 0 {
+		t.Errorf("FromValues(empty) = %+v, want none", got)
+	}
+}
+
+func TestPodSpecConsumers(t *testing.T) {
+	spec := map[string]interface{}{
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "certs", "secret": map[string]interface{}{"secretName": "web-tls"}},
+			map[string]interface{}{"name": "bundle", "projected": map[string]interface{}{
+				"sources": []interface{}{
+					map[string]interface{}{"secret": map[string]interface{}{"name": "ca-bundle"}},
+				},
+			}},
+			map[string]interface{}{"name": "scratch", "emptyDir": map[string]interface{}{}},
+		},
+		"initContainers": []interface{}{
+			map[string]interface{}{"name": "init", "envFrom": []interface{}{
+				map[string]interface{}{"secretRef": map[string]interface{}{"name": "web-env"}},
+				map[string]interface{}{"configMapRef": map[string]interface{}{"name": "web-config"}},
+			}},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name": "web",
+				"env": []interface{}{
+					map[string]interface{}{"name": "TOKEN", "valueFrom": map[string]interface{}{
+						"secretKeyRef": map[string]interface{}{"name": "web-env", "key": "token"},
+					}},
+					map[string]interface{}{"name": "PLAIN", "value": "x"},
+				},
+				"volumeMounts": []interface{}{
+					map[string]interface{}{"name": "certs", "mountPath": "/tls"},
+					map[string]interface{}{"name": "bundle", "mountPath": "/ca"},
+					map[string]interface{}{"name": "scratch", "mountPath": "/tmp"},
+				},
+			},
+		},
+	}
+	want := map[string][]Consumer{
+		"web-env": {
+			{Container: "init", EnvFrom: true},
+			{Container: "web", Env: "TOKEN", Key: "token"},
+		},
+		"web-tls":   {{Container: "web", Mount: "/tls"}},
+		"ca-bundle": {{Container: "web", Mount: "/ca"}},
+	}
+	if got := PodSpecConsumers(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("PodSpecConsumers() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	links := FromValues(loadValues(t, "templated.values.yaml"))
+	links = append(links, FromValues(loadValues(t, "generated.values.yaml"))...)
+	want := []provisioners.SecretRequirement{
+		{Item: "grafana-admin"},
+		{Item: "grafana-prometheus", Fields: []string{"url", "password"}},
+		{Item: "api-db-db", Fields: []string{"host", "username", "password"}},
+	}
+	if got := Requirements(links); !reflect.DeepEqual(got, want) {
+		t.Errorf("Requirements() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSourceSummary(t *testing.T) {
+	l := Link{Sources: []Source{
+		{Item: "app-db", Property: "host"},
+		{Item: "app-admin"},
+		{Item: "app-db", Property: "password"},
+		{Item: "app-db", Property: "host"},
+	}}
+	if got, want := l.SourceSummary(), "app-db: host, password\napp-admin (all fields)"; got != want {
+		t.Errorf("SourceSummary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+// Package smoke runs HTTP smoke tests against a deployed workload's route.
+// Each check is retried with backoff until it passes or its timeout ends,
+// so a route whose DNS record or certificate is still propagating is given
+// time to come up.
+package smoke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a check, retries included, that sets none.
+	DefaultTimeout = 30 * time.Second
+	// DefaultStatus is the status a check expects when it sets none.
+	DefaultStatus = http.StatusOK
+
+	// maxBody is how much of a response body is read and matched.
+	maxBody = 1 << 20
+	// attemptTimeout bounds a single request.
+	attemptTimeout = 10 * time.Second
+)
+
+// Check is one HTTP GET and what its response must be.
+type Check struct {
+	URL                string        `json:"url"`
+	ExpectStatus       int           `json:"expectStatus"`
+	ExpectBodyContains string        `json:"expectBodyContains,omitempty"`
+	Timeout            time.Duration `json:"timeout"`
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Check  Check `json:"check"`
+	Passed bool  `json:"passed"`
+	// Status is the last response status; 0 when no response came.
+	Status   int           `json:"status,omitempty"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+	// Error is why the last attempt failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Runner runs checks with an HTTP client.
+type Runner struct {
+	Client *http.Client
+	// Backoff is the wait after the first failed attempt. It doubles after
+	// each further one, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewRunner returns a Runner that connects to resolve instead of the
+// address the check URL's host resolves to, when set: an IP or IP:port,
+// e.g. the gateway VIP before the route's DNS record exists. The URL's
+// host is still sent as the TLS server name and Host header.
+func NewRunner(resolve string) (*Runner, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if resolve != "" {
+		addr, port, err := parseResolve(resolve)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, target string) (net.Conn, error) {
+			p := port
+			if p == "" {
+				_, p, _ = net.SplitHostPort(target)
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(addr, p))
+		}
+	}
+	return &Runner{
+		Client:     &http.Client{Transport: transport},
+		Backoff:    500 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}, nil
+}
+
+// parseResolve splits an IP or IP:port; port is "" when not given.
+func parseResolve(resolve string) (addr, port string, err error) {
+	if a, err := netip.ParseAddr(strings.Trim(resolve, "[]")); err == nil {
+		return a.String(), "", nil
+	}
+	host, port, err := net.SplitHostPort(resolve)
+	if err == nil {
+		if _, perr := netip.ParseAddr(host); perr == nil {
+			return host, port, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid resolve address %q: expected an IP or IP:port", resolve)
+}
+
+// Run runs checks in order and returns their results. onResult, when not
+// nil, is called as each finishes.
+func (r *Runner) Run(ctx context.Context, checks []Check, onResult func(Result)) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		res := r.run(ctx, c)
+		if onResult != nil {
+			onResult(res)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// Failed counts the results that did not pass.
+func Failed(results []Result) int {
+	n := 0
+	for _, res := range results {
+		if !res.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// run retries c until it passes or its timeout ends.
+func (r *Runner) run(ctx context.Context, c Check) Result {
+	if c.ExpectStatus == 0 {
+		c.ExpectStatus = DefaultStatus
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	res := Result{Check: c}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	backoff := r.Backoff
+	for {
+		res.Attempts++
+		status, err := r.attempt(ctx, c)
+		res.Status = status
+		if err == nil {
+			res.Passed, res.Error = true, ""
+			break
+		}
+		// A request cut short by the check's deadline keeps the reason the
+		// attempt before it failed, which says more than the deadline.
+		if ctx.Err() == nil || res.Error == "" {
+			res.Error = err.Error()
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			res.Duration = time.Since(start)
+			return res
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > r.MaxBackoff {
+			backoff = r.MaxBackoff
+		}
+	}
+	res.Duration = time.Since(start)
+	return res
+}
+
+// attempt makes one request and checks the response.
+func (r *Runner) attempt(ctx context.Context, c Check) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return 0, fmt.Errorf("%s does not resolve yet: %v", dnsErr.Name, dnsErr.Err)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("reading the response: %w", err)
+	}
+	if resp.StatusCode != c.ExpectStatus {
+		return resp.StatusCode, fmt.Errorf("status %d, want %d", resp.StatusCode, c.ExpectStatus)
+	}
+	if c.ExpectBodyContains != "" && !strings.Contains(string(body), c.ExpectBodyContains) {
+		return resp.StatusCode, fmt.Errorf("body does not contain %q", c.ExpectBodyContains)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,135 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRunner returns a Runner that retries without real waits.
+func fastRunner(t *testing.T, resolve string) *Runner {
+	t.Helper()
+	r, err := NewRunner(resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Backoff, r.MaxBackoff = time.Millisecond, 5*time.Millisecond
+	return r
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/healthz":
+			fmt.Fprint(w, `{"status":"ok"}`)
+		case "/broken":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunPasses(t *testing.T) {
+	srv := newServer(t)
+	results := fastRunner(t, "").Run(context.Background(), []Check{
+		{URL: srv.URL + "/healthz", ExpectBodyContains: `"ok"`},
+		{URL: srv.URL + "/missing", ExpectStatus: http.StatusNotFound, Timeout: time.Second},
+	}, nil)
+	for _, res := range results {
+		if !res.Passed || res.Attempts != 1 {
+			t.Errorf("%s: passed = %v after %d attempts (%s), want a pass at once", res.Check.URL, res.Passed, res.Attempts, res.Error)
+		}
+	}
+	if results[0].Check.ExpectStatus != DefaultStatus || results[0].Status != http.StatusOK {
+		t.Errorf("result = %+v, want the default status expected and seen", results[0])
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	srv := newServer(t)
+	tests := []struct {
+		check Check
+		want  string
+	}{
+		{Check{URL: srv.URL + "/broken"}, "status 500, want 200"},
+		{Check{URL: srv.URL + "/healthz", ExpectBodyContains: "ready"}, `body does not contain "ready"`},
+	}
+	for _, tt := range tests {
+		tt.check.Timeout = 50 * time.Millisecond
+		var reported []Result
+		results := fastRunner(t, "").Run(context.Background(), []Check{tt.check}, func(r Result) { reported = append(reported, r) })
+		res := results[0]
+		if res.Passed || !strings.Contains(res.Error, tt.want) {
+			t.Errorf("%s: result = %+v, want a failure with %q", tt.check.URL, res, tt.want)
+		}
+		if res.Attempts < 2 {
+			t.Errorf("%s: %d attempts, want retries until the timeout", tt.check.URL, res.Attempts)
+		}
+		if len(reported) != 1 || Failed(results) != 1 {
+			t.Errorf("reported %d results, %d failed; want one failure", len(reported), Failed(results))
+		}
+	}
+}
+
+func TestRunRetriesUntilDNSResolves(t *testing.T) {
+	srv := newServer(t)
+	r := fastRunner(t, "")
+	var lookups atomic.Int32
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		if lookups.Add(1) <= 2 {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	r.Client = &http.Client{Transport: transport}
+
+	res := r.Run(context.Background(), []Check{{URL: "http://web.example.test/healthz", Timeout: 5 * time.Second}}, nil)[0]
+	if !res.Passed || res.Attempts != 3 {
+		t.Errorf("result = %+v, want a pass on the third attempt", res)
+	}
+
+	// DNS that never resolves fails with the lookup, not the deadline.
+	never := http.DefaultTransport.(*http.Transport).Clone()
+	never.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	r.Client = &http.Client{Transport: never}
+	res = r.Run(context.Background(), []Check{{URL: "http://web.example.test/healthz", Timeout: 30 * time.Millisecond}}, nil)[0]
+	if res.Passed || !strings.Contains(res.Error, "web.example.test does not resolve yet") {
+		t.Errorf("result = %+v, want a DNS failure", res)
+	}
+}
+
+func TestNewRunnerResolve(t *testing.T) {
+	srv := newServer(t)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// The host has no DNS record; the override connects to the server.
+	res := fastRunner(t, "127.0.0.1").Run(context.Background(), []Check{{URL: "http://web.example.test:" + port + "/healthz", Timeout: time.Second}}, nil)[0]
+	if !res.Passed {
+		t.Errorf("resolve IP: result = %+v, want a pass", res)
+	}
+	res = fastRunner(t, "127.0.0.1:"+port).Run(context.Background(), []Check{{URL: "http://web.example.test/healthz", Timeout: time.Second}}, nil)[0]
+	if !res.Passed {
+		t.Errorf("resolve IP:port: result = %+v, want a pass", res)
+	}
+
+	for _, bad := range []string{"gateway", "gateway:443", "10.0.0.300"} {
+		if _, err := NewRunner(bad); err == nil {
+			t.Errorf("NewRunner(%q) = nil error, want one", bad)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package testutil
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/config"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Isolate points HOME, the hctl config directory, git's global config and
+// KUBECONFIG at empty locations for the rest of the test, so nothing from
+// the developer's machine leaks into a run. Tests using it cannot run in
+// parallel.
+func Isolate(t testing.TB) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("KUBECONFIG", filepath.Join(home, ".kube", "config"))
+	t.Setenv("DOCKER_CONFIG", filepath.Join(home, ".docker"))
+	for _, key := range []string{"OP_CONNECT_TOKEN", "OP_VAULT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// Config returns the configuration the end-to-end tests run with: repo at
+// repo.Root, git mode "generate" (commit locally, never push) and no
+// prompts.
+func Config(repo *Repo) *config.Config {
+	cfg := config.Default()
+	cfg.RepoPath = repo.Root
+	cfg.GitMode = "generate"
+	cfg.Interactive = false
+	return cfg
+}
+
+// WriteConfig saves cfg as the user's hctl config, where the root command
+// loads it from. Call Isolate first.
+func WriteConfig(t testing.TB, cfg *config.Config) {
+	t.Helper()
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Result is the outcome of a command run.
+type Result struct {
+	Stdout string
+	Stderr string
+	// Err is the error the command returned.
+	Err error
+	// Category and ExitCode classify Err as the hctl binary would.
+	Category hcerrors.Category
+	ExitCode int
+}
+
+// Output returns stdout and stderr together, for failure messages.
+func (r Result) Output() string {
+	return r.Stdout + r.Stderr
+}
+
+// Run executes cmd with args, capturing everything written to stdout and
+// stderr. Stdin is an empty pipe, so prompts and spinners take their
+// non-interactive paths. Flags across cmd's tree are reset to their defaults
+// first, which lets tests run the same command tree (such as hctl's root
+// command) several times.
+func Run(t testing.TB, cmd *cobra.Command, args ...string) Result {
+	t.Helper()
+	resetFlags(t, cmd)
+	tui.SetOutputFormat("")
+	prevCfg := config.Get()
+	defer config.Set(prevCfg)
+	prevLayout := layout.Active()
+	defer layout.Use(prevLayout)
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdinW.Close()
+	defer stdinR.Close()
+
+	prevIn, prevOut, prevErr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin = stdinR
+	stdout := capture(t, &os.Stdout)
+	stderr := capture(t, &os.Stderr)
+	restore := func() { os.Stdin, os.Stdout, os.Stderr = prevIn, prevOut, prevErr }
+	defer restore()
+
+	cmd.SetArgs(args)
+	cmd.SetIn(stdinR)
+	cmd.SetOut(os.Stdout)
+	cmd.SetErr(os.Stderr)
+	_, runErr := cmd.ExecuteC()
+
+	restore()
+	res := Result{
+		Stdout: stdout(),
+		Stderr: stderr(),
+		Err:    runErr,
+	}
+	if runErr != nil {
+		res.Category = hcerrors.CategoryOf(runErr)
+		res.ExitCode = hcerrors.ExitCode(runErr)
+	}
+	return res
+}
+
+// MustRun is Run that fails the test if the command returns an error.
+func MustRun(t testing.TB, cmd *cobra.Command, args ...string) Result {
+	t.Helper()
+	res := Run(t, cmd, args...)
+	if res.Err != nil {
+		t.Fatalf("hctl %s: %v\n%s", strings.Join(args, " "), res.Err, res.Output())
+	}
+	return res
+}
+
+// capture redirects *f into a pipe and returns a function that closes the
+// pipe and returns what was written.
+func capture(t testing.TB, f **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	*f = w
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+	return func() string {
+		w.Close()
+		<-done
+		r.Close()
+		return buf.String()
+	}
+}
+
+// resetFlags sets every flag in cmd's tree back to its default and clears
+// its changed state. Cobra parses into the same flag sets on every Execute,
+// so without this a flag given to one run would stick to the next.
+func resetFlags(t testing.TB, cmd *cobra.Command) {
+	t.Helper()
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			var vals []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				vals = strings.Split(def, ",")
+			}
+			if err := sv.Replace(vals); err != nil {
+				t.Fatalf("resetting --%s: %v", f.Name, err)
+			}
+		} else if err := f.Value.Set(f.DefValue); err != nil {
+			t.Fatalf("resetting --%s: %v", f.Name, err)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(t, sub)
+	}
+}
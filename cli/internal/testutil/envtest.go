@@ -0,0 +1,148 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/yaml"
+)
+
+// NewEnvtestCluster starts a kube-apiserver and etcd with envtest, serving
+// the platform CRDs, and seeds it with objs. The test is skipped when
+// KUBEBUILDER_ASSETS is not set.
+func NewEnvtestCluster(t testing.TB, objs ...runtime.Object) *Cluster {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS not set; run setup-envtest to use the envtest backend")
+	}
+
+	env := &envtest.Environment{CRDs: PlatformCRDs(t)}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("starting envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("stopping envtest: %v", err)
+		}
+	})
+
+	kc, err := kube.NewClientForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl, err := client.New(cfg, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Cluster{Client: kc, Backend: "envtest", t: t, ctrl: ctrl}
+	c.Apply(objs...)
+	return c
+}
+
+// PlatformCRDs returns the CRDs of every promise under the repo's promises/
+// directory, plus a schemaless CRD for each PlatformKinds entry no promise
+// defines (ArgoCD, Kratix, External Secrets and cert-manager kinds).
+func PlatformCRDs(t testing.TB) []*apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	dir := promisesDir(t)
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "promise.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	defined := map[string]bool{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var promise struct {
+			Spec struct {
+				API *apiextensionsv1.CustomResourceDefinition `json:"api"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal(data, &promise); err != nil {
+			t.Fatalf("parsing %s: %v", path, err)
+		}
+		if crd := promise.Spec.API; crd != nil {
+			crds = append(crds, crd)
+			defined[crd.Name] = true
+		}
+	}
+	for _, k := range PlatformKinds {
+		if name := k.GVR.Resource + "." + k.GVR.Group; !defined[name] {
+			crds = append(crds, schemalessCRD(k))
+		}
+	}
+	return crds
+}
+
+// promisesDir finds the promises/ directory by walking up from the working
+// directory, which go test sets to the package under test.
+func promisesDir(t testing.TB) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		candidate := filepath.Join(dir, "promises")
+		if fi, err := os.Stat(candidate); err == nil && fi.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("promises/ directory not found above the working directory")
+		}
+		dir = parent
+	}
+}
+
+// schemalessCRD stands in for a third-party CRD: it serves k with any
+// fields and a status subresource.
+func schemalessCRD(k Kind) *apiextensionsv1.CustomResourceDefinition {
+	scope := apiextensionsv1.ClusterScoped
+	if k.Namespaced {
+		scope = apiextensionsv1.NamespaceScoped
+	}
+	preserve := true
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: k.GVR.Resource + "." + k.GVR.Group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: k.GVR.Group,
+			Scope: scope,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   k.GVR.Resource,
+				Singular: strings.ToLower(k.Kind),
+				Kind:     k.Kind,
+				ListKind: k.Kind + "List",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    k.GVR.Version,
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type:                   "object",
+						XPreserveUnknownFields: &preserve,
+					},
+				},
+				Subresources: &apiextensionsv1.CustomResourceSubresources{
+					Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,383 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackendEnv selects the backend NewCluster uses: "fake" (default) or
+// "envtest". envtest needs KUBEBUILDER_ASSETS pointing at kube-apiserver
+// and etcd binaries, e.g. from setup-envtest.
+const BackendEnv = "HCTL_TEST_KUBE"
+
+// Kind is a custom resource kind hctl reads from the platform cluster.
+type Kind struct {
+	GVR        schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+}
+
+// PlatformKinds are the custom resources hctl reads. The fake backend
+// registers their list kinds; envtest serves them from the promise CRDs or,
+// for third-party kinds, from schemaless stand-in CRDs.
+var PlatformKinds = []Kind{
+	{kube.VClusterOrchestratorV2GVR, "VClusterOrchestratorV2", true},
+	{kube.ArgoCDApplicationGVR, "Application", true},
+	{kube.KratixPromiseGVR, "Promise", false},
+	{kube.WorkGVR, "Work", true},
+	{kube.WorkPlacementGVR, "WorkPlacement", true},
+	{kube.ExternalSecretGVR, "ExternalSecret", true},
+	{kube.ClusterSecretStoreGVR, "ClusterSecretStore", false},
+	{kube.ClusterIssuerGVR, "ClusterIssuer", false},
+	{kube.CertificateGVR, "Certificate", true},
+}
+
+// Cluster is a Kubernetes API for tests, served by fake clients or by an
+// envtest API server.
+type Cluster struct {
+	// Client talks to the cluster. Commands get it from kube.NewClient once
+	// Use has been called.
+	Client *kube.Client
+	// Backend is "fake" or "envtest".
+	Backend string
+
+	t       testing.TB
+	typed   clienttesting.ObjectTracker
+	dynamic clienttesting.ObjectTracker
+	ctrl    client.Client
+	// actions returns the requests the fake clients served.
+	actions func() []clienttesting.Action
+}
+
+// NewCluster starts a cluster on the backend selected by $HCTL_TEST_KUBE,
+// seeded with objs. Typed objects (corev1.Node, ...) and
+// *unstructured.Unstructured platform resources may be mixed.
+func NewCluster(t testing.TB, objs ...runtime.Object) *Cluster {
+	t.Helper()
+	if os.Getenv(BackendEnv) == "envtest" {
+		return NewEnvtestCluster(t, objs...)
+	}
+	return NewFakeCluster(t, objs...)
+}
+
+// NewFakeCluster returns a cluster backed by client-go's fake clientset and
+// fake dynamic client.
+func NewFakeCluster(t testing.TB, objs ...runtime.Object) *Cluster {
+	t.Helper()
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, k := range PlatformKinds {
+		listKinds[k.GVR] = k.Kind + "List"
+	}
+	// Events are also watched through the dynamic client.
+	listKinds[kube.EventGVR] = "EventList"
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = discoveryResources()
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	// The fake dynamic client persists dry-run creates and applies
+	// server-side apply patches as strategic merge patches, which it cannot
+	// compute for unstructured objects; approximate the API server instead.
+	dyn.PrependReactor("create", "*", dryRunCreate(dyn.Tracker()))
+	dyn.PrependReactor("patch", "*", applyPatch(dyn.Tracker()))
+	c := &Cluster{
+		Client:  &kube.Client{Clientset: clientset, Dynamic: dyn},
+		Backend: "fake",
+		t:       t,
+		typed:   clientset.Tracker(),
+		dynamic: dyn.Tracker(),
+		actions: func() []clienttesting.Action {
+			return append(clientset.Actions(), dyn.Actions()...)
+		},
+	}
+	c.Apply(objs...)
+	return c
+}
+
+// Use makes kube.NewClient return this cluster's client, whatever the
+// context, until the test ends.
+func (c *Cluster) Use() {
+	restore := kube.SetClientFactory(func(string) (*kube.Client, error) {
+		return c.Client, nil
+	})
+	c.t.Cleanup(restore)
+}
+
+// Apply creates objs in the cluster, creating the namespaces they live in
+// when the backend requires it.
+func (c *Cluster) Apply(objs ...runtime.Object) {
+	c.t.Helper()
+	for _, obj := range objs {
+		if err := c.create(obj); err != nil {
+			c.t.Fatalf("creating %s: %v", describe(obj), err)
+		}
+	}
+}
+
+func (c *Cluster) create(obj runtime.Object) error {
+	if c.ctrl != nil {
+		cobj, ok := obj.(client.Object)
+		if !ok {
+			return errors.New("not a client.Object")
+		}
+		if ns := cobj.GetNamespace(); ns != "" {
+			nsObj := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+			if err := c.ctrl.Create(context.Background(), nsObj); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+		}
+		err := c.ctrl.Create(context.Background(), cobj)
+		if apierrors.IsAlreadyExists(err) && isNamespace(obj) {
+			return nil // created above for an earlier object
+		}
+		return err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return c.typed.Add(obj)
+	}
+	if gvr, err := resourceFor(u.GroupVersionKind()); err == nil {
+		return c.dynamic.Create(gvr, u, u.GetNamespace())
+	}
+	// Built-in kinds go to the typed tracker, which only serves typed objects.
+	typed, err := scheme.Scheme.New(u.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+		return err
+	}
+	return c.typed.Add(typed)
+}
+
+// ApplyYAML creates every document of a multi-document YAML manifest, such
+// as a request file hctl wrote to the repo.
+func (c *Cluster) ApplyYAML(manifest string) {
+	c.t.Helper()
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := dec.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			c.t.Fatalf("decoding manifest: %v", err)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		c.Apply(u)
+	}
+}
+
+// Get returns a platform resource, failing the test if it does not exist.
+func (c *Cluster) Get(gvr schema.GroupVersionResource, namespace, name string) *unstructured.Unstructured {
+	c.t.Helper()
+	obj, err := c.Client.Dynamic.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		c.t.Fatalf("getting %s %s/%s: %v", gvr.Resource, namespace, name, err)
+	}
+	return obj
+}
+
+// Mutations lists the writes commands made through the cluster's clients,
+// as "verb resource namespace/name", for asserting that a command changed
+// nothing. Objects seeded with Apply are not included. The test is skipped
+// on the envtest backend, which does not record requests.
+func (c *Cluster) Mutations() []string {
+	c.t.Helper()
+	if c.actions == nil {
+		c.t.Skip("Mutations needs the fake backend")
+	}
+	var out []string
+	for _, a := range c.actions() {
+		switch a.GetVerb() {
+		case "get", "list", "watch":
+			continue
+		}
+		if isDryRun(a) {
+			continue
+		}
+		name := ""
+		if n, ok := a.(interface{ GetName() string }); ok {
+			name = n.GetName()
+		}
+		out = append(out, a.GetVerb()+" "+a.GetResource().Resource+" "+a.GetNamespace()+"/"+name)
+	}
+	return out
+}
+
+// builtinKinds are the built-in kinds fake discovery serves besides
+// PlatformKinds: what hctl applies when bootstrapping the platform.
+var builtinKinds = []Kind{
+	{schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "Namespace", false},
+	{schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "Secret", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "services"}, "Service", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, "ServiceAccount", true},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "Deployment", true},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, "ClusterRole", false},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, "ClusterRoleBinding", false},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, "Role", true},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, "RoleBinding", true},
+}
+
+// discoveryResources lists builtinKinds and PlatformKinds by group version,
+// for RESTMappers built from the fake clientset's discovery.
+func discoveryResources() []*metav1.APIResourceList {
+	var lists []*metav1.APIResourceList
+	byGV := map[string]*metav1.APIResourceList{}
+	for _, k := range append(append([]Kind(nil), builtinKinds...), PlatformKinds...) {
+		gv := k.GVR.GroupVersion().String()
+		list, ok := byGV[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			byGV[gv] = list
+			lists = append(lists, list)
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       k.GVR.Resource,
+			Kind:       k.Kind,
+			Namespaced: k.Namespaced,
+			Verbs:      metav1.Verbs{"get", "list", "watch", "create", "update", "patch", "delete"},
+		})
+	}
+	return lists
+}
+
+// dryRunCreate answers dry-run creates without storing the object.
+func dryRunCreate(tracker clienttesting.ObjectTracker) clienttesting.ReactionFunc {
+	return func(action clienttesting.Action) (bool, runtime.Object, error) {
+		create, ok := action.(clienttesting.CreateActionImpl)
+		if !ok || len(create.CreateOptions.DryRun) == 0 {
+			return false, nil, nil
+		}
+		m, err := meta.Accessor(create.GetObject())
+		if err != nil {
+			return true, nil, err
+		}
+		if _, err := tracker.Get(action.GetResource(), action.GetNamespace(), m.GetName()); err == nil {
+			return true, nil, apierrors.NewAlreadyExists(action.GetResource().GroupResource(), m.GetName())
+		}
+		return true, create.GetObject(), nil
+	}
+}
+
+// applyPatch answers server-side apply patches by merging the applied
+// configuration into the live object, creating it when there is none. Dry
+// runs return the result without storing it.
+func applyPatch(tracker clienttesting.ObjectTracker) clienttesting.ReactionFunc {
+	return func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(clienttesting.PatchActionImpl)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		applied := map[string]interface{}{}
+		if err := json.Unmarshal(patch.GetPatch(), &applied); err != nil {
+			return true, nil, err
+		}
+		merged := applied
+		live, err := tracker.Get(gvr, ns, patch.GetName())
+		switch {
+		case err == nil:
+			data, err := json.Marshal(live)
+			if err != nil {
+				return true, nil, err
+			}
+			merged = map[string]interface{}{}
+			if err := json.Unmarshal(data, &merged); err != nil {
+				return true, nil, err
+			}
+			mergeApplied(merged, applied)
+		case !apierrors.IsNotFound(err):
+			return true, nil, err
+		}
+		obj := &unstructured.Unstructured{Object: merged}
+		if len(patch.PatchOptions.DryRun) > 0 {
+			return true, obj, nil
+		}
+		if live == nil {
+			err = tracker.Create(gvr, obj, ns)
+		} else {
+			err = tracker.Update(gvr, obj, ns)
+		}
+		if err != nil {
+			return true, nil, err
+		}
+		stored, err := tracker.Get(gvr, ns, patch.GetName())
+		return true, stored, err
+	}
+}
+
+// mergeApplied sets every field of applied in live, merging maps and
+// replacing everything else.
+func mergeApplied(live, applied map[string]interface{}) {
+	for k, v := range applied {
+		if am, ok := v.(map[string]interface{}); ok {
+			if lm, ok := live[k].(map[string]interface{}); ok {
+				mergeApplied(lm, am)
+				continue
+			}
+		}
+		live[k] = v
+	}
+}
+
+func isDryRun(a clienttesting.Action) bool {
+	switch a := a.(type) {
+	case clienttesting.CreateActionImpl:
+		return len(a.CreateOptions.DryRun) > 0
+	case clienttesting.PatchActionImpl:
+		return len(a.PatchOptions.DryRun) > 0
+	}
+	return false
+}
+
+// resourceFor maps a platform kind to its resource.
+func resourceFor(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	for _, k := range PlatformKinds {
+		if k.GVR.GroupVersion() == gvk.GroupVersion() && k.Kind == gvk.Kind {
+			return k.GVR, nil
+		}
+	}
+	return schema.GroupVersionResource{}, errors.New("not a platform kind: " + gvk.String())
+}
+
+func isNamespace(obj runtime.Object) bool {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		return true
+	}
+	return obj.GetObjectKind().GroupVersionKind().Kind == "Namespace"
+}
+
+func describe(obj runtime.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if m, ok := obj.(metav1.Object); ok {
+		if m.GetNamespace() != "" {
+			return kind + " " + m.GetNamespace() + "/" + m.GetName()
+		}
+		return kind + " " + m.GetName()
+	}
+	return kind
+}
@@ -0,0 +1,240 @@
+// Package testutil holds the harness for hctl's end-to-end tests: a fixture
+// gitops repo, a kube layer backed by fake clients or envtest, and helpers
+// that run cobra commands with captured output.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	addonlib "github.com/jamesatintegratnio/hctl/internal/addon"
+	"github.com/jamesatintegratnio/hctl/internal/deploy"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoOptions describes the gitops repo NewRepo lays out.
+type RepoOptions struct {
+	// Clusters are the vClusters in the repo. Each gets a request under
+	// platform/vclusters/, a workloads/<cluster>/addons.yaml and a cluster
+	// addons layer.
+	Clusters []string
+	// Environments are the addon environments. Defaults to production.
+	Environments []string
+	// Addons are entries in every environment's addons.yaml.
+	Addons []Addon
+	// Files are extra files keyed by repo-relative path.
+	Files map[string]string
+}
+
+// Addon is an addons.yaml entry in a fixture environment layer.
+type Addon struct {
+	Name      string
+	Namespace string
+	Chart     string
+	Version   string
+	// Disabled writes enabled: false.
+	Disabled bool
+}
+
+// Repo is a fixture gitops repo in a temporary directory.
+type Repo struct {
+	// Root is the absolute path of the repo's working tree.
+	Root string
+
+	t testing.TB
+}
+
+// NewRepo creates a gitops repo with the workloads/, addons/ and platform/
+// layout described by opts, initialized as a git repo with one commit.
+func NewRepo(t testing.TB, opts RepoOptions) *Repo {
+	t.Helper()
+	r := &Repo{Root: t.TempDir(), t: t}
+
+	envs := opts.Environments
+	if len(envs) == 0 {
+		envs = []string{"production"}
+	}
+	entries := map[string]map[string]interface{}{}
+	for _, a := range opts.Addons {
+		entries[a.Name] = addonEntry(a)
+	}
+	for _, env := range envs {
+		l := addonlib.Layer{Kind: addonlib.LayerEnvironment, Name: env}
+		r.writeYAML(l.AddonsFile(r.Root), entries)
+		for _, a := range opts.Addons {
+			r.WriteFile(r.rel(filepath.Join(l.ValuesDir(r.Root, a.Name), "values.yaml")), "{}\n")
+		}
+	}
+	role := addonlib.Layer{Kind: addonlib.LayerClusterRole, Name: "vcluster"}
+	r.writeYAML(role.AddonsFile(r.Root), map[string]interface{}{})
+
+	for _, cluster := range opts.Clusters {
+		l := addonlib.Layer{Kind: addonlib.LayerCluster, Name: cluster}
+		r.writeYAML(l.AddonsFile(r.Root), map[string]interface{}{})
+		r.writeYAML(repopath.Abs(r.Root, deploy.AddonsPath(cluster)), map[string]interface{}{
+			"globalSelectors":       map[string]interface{}{"cluster_name": cluster},
+			"useAddonNameForValues": true,
+		})
+		r.WriteFile(layout.VClusterManifest(cluster), vclusterRequest(t, cluster))
+	}
+	r.WriteFile(repopath.Join(layout.VClustersDir, "00-namespace.yaml"),
+		"apiVersion: v1\nkind: Namespace\nmetadata:\n  name: platform-requests\n")
+
+	for path, content := range opts.Files {
+		r.WriteFile(path, content)
+	}
+
+	r.Git("init", "-q", "-b", "main")
+	r.Git("config", "user.name", "hctl-test")
+	r.Git("config", "user.email", "hctl-test@example.com")
+	r.Commit("Initial fixture")
+	return r
+}
+
+func addonEntry(a Addon) map[string]interface{} {
+	entry := map[string]interface{}{
+		"enabled":         !a.Disabled,
+		"namespace":       a.Name,
+		"chartName":       addonlib.DefaultChartName,
+		"chartRepository": addonlib.DefaultChartRepository,
+		"defaultVersion":  addonlib.DefaultChartVersion,
+	}
+	if a.Namespace != "" {
+		entry["namespace"] = a.Namespace
+	}
+	if a.Chart != "" {
+		entry["chartName"] = a.Chart
+	}
+	if a.Version != "" {
+		entry["defaultVersion"] = a.Version
+	}
+	return entry
+}
+
+// vclusterRequest renders the dev-preset request hctl vcluster create
+// would write for name.
+func vclusterRequest(t testing.TB, name string) string {
+	t.Helper()
+	spec := platform.VClusterSpec{
+		Name:         name,
+		Integrations: platform.DefaultIntegrations(),
+		ArgocdApp:    platform.DefaultArgocdApp(),
+	}
+	if err := platform.ApplyPreset(&spec, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := platform.NewVClusterResource(spec, "platform-requests").Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// Path returns the absolute path of a repo-relative path.
+func (r *Repo) Path(rel string) string {
+	return repopath.Abs(r.Root, rel)
+}
+
+func (r *Repo) rel(abs string) string {
+	rel, err := filepath.Rel(r.Root, abs)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// WriteFile writes content to a repo-relative path, creating directories.
+func (r *Repo) WriteFile(rel, content string) {
+	r.t.Helper()
+	path := r.Path(rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func (r *Repo) writeYAML(abs string, v interface{}) {
+	r.t.Helper()
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.WriteFile(r.rel(abs), string(data))
+}
+
+// ReadFile returns the content of a repo-relative path, failing the test
+// if it cannot be read.
+func (r *Repo) ReadFile(rel string) string {
+	r.t.Helper()
+	data, err := os.ReadFile(r.Path(rel))
+	if err != nil {
+		r.t.Fatalf("reading %s: %v", rel, err)
+	}
+	return string(data)
+}
+
+// ReadYAML unmarshals a repo-relative YAML file into a map.
+func (r *Repo) ReadYAML(rel string) map[string]interface{} {
+	r.t.Helper()
+	var out map[string]interface{}
+	if err := yaml.Unmarshal([]byte(r.ReadFile(rel)), &out); err != nil {
+		r.t.Fatalf("parsing %s: %v", rel, err)
+	}
+	return out
+}
+
+// Exists reports whether a repo-relative path exists.
+func (r *Repo) Exists(rel string) bool {
+	_, err := os.Stat(r.Path(rel))
+	return err == nil
+}
+
+// Git runs git in the repo and returns its trimmed output.
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Root
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Commit stages everything and commits it.
+func (r *Repo) Commit(message string) {
+	r.t.Helper()
+	r.Git("add", "-A")
+	r.Git("commit", "-q", "--allow-empty", "-m", message)
+}
+
+// Subjects returns the commit subjects, newest first.
+func (r *Repo) Subjects() []string {
+	r.t.Helper()
+	return strings.Split(r.Git("log", "--format=%s"), "\n")
+}
+
+// Dirty returns the paths git reports as changed or untracked, sorted.
+func (r *Repo) Dirty() []string {
+	r.t.Helper()
+	out := r.Git("status", "--porcelain", "--untracked-files=all")
+	if out == "" {
+		return nil
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		paths = append(paths, fields[len(fields)-1])
+	}
+	sort.Strings(paths)
+	return paths
+}
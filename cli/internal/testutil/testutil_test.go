@@ -0,0 +1,151 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewRepoLayout(t *testing.T) {
+	Isolate(t)
+	repo := NewRepo(t, RepoOptions{
+		Clusters:     []string{"vcluster-a"},
+		Environments: []string{"production", "staging"},
+		Addons:       []Addon{{Name: "grafana", Disabled: true}},
+		Files:        map[string]string{"README.md": "fixture\n"},
+	})
+
+	for _, rel := range []string{
+		"addons/environments/production/addons/addons.yaml",
+		"addons/environments/staging/addons/grafana/values.yaml",
+		"addons/clusters/vcluster-a/addons/addons.yaml",
+		"addons/cluster-roles/vcluster/addons/addons.yaml",
+		"workloads/vcluster-a/addons.yaml",
+		"platform/vclusters/vcluster-a.yaml",
+		"README.md",
+	} {
+		if !repo.Exists(rel) {
+			t.Errorf("%s missing", rel)
+		}
+	}
+	grafana, _ := repo.ReadYAML("addons/environments/staging/addons/addons.yaml")["grafana"].(map[string]interface{})
+	if grafana["enabled"] != false {
+		t.Errorf("grafana entry = %v, want enabled: false", grafana)
+	}
+	if dirty := repo.Dirty(); len(dirty) > 0 {
+		t.Errorf("fixture not committed: %v", dirty)
+	}
+	if got := repo.Subjects(); len(got) != 1 {
+		t.Errorf("commits = %v, want one", got)
+	}
+}
+
+func TestRunResetsFlags(t *testing.T) {
+	var name string
+	var tags []string
+	cmd := &cobra.Command{
+		Use: "greet",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Printf("hello %s %v\n", name, tags)
+			if name == "fail" {
+				return fmt.Errorf("bad name")
+			}
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().StringVar(&name, "name", "world", "")
+	cmd.Flags().StringSliceVar(&tags, "tag", []string{"a"}, "")
+
+	res := MustRun(t, cmd, "--name", "there", "--tag", "b", "--tag", "c")
+	if res.Stdout != "hello there [b c]\n" {
+		t.Errorf("stdout = %q", res.Stdout)
+	}
+	res = MustRun(t, cmd)
+	if res.Stdout != "hello world [a]\n" {
+		t.Errorf("second run stdout = %q, want defaults", res.Stdout)
+	}
+	if cmd.Flags().Changed("name") {
+		t.Error("--name still marked changed")
+	}
+
+	res = Run(t, cmd, "--name", "fail")
+	if res.Err == nil || res.ExitCode != 1 {
+		t.Errorf("Err = %v, ExitCode = %d, want an internal error", res.Err, res.ExitCode)
+	}
+}
+
+func TestFakeClusterApplyYAML(t *testing.T) {
+	Isolate(t)
+	repo := NewRepo(t, RepoOptions{Clusters: []string{"vcluster-a"}})
+	c := NewFakeCluster(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	c.Use()
+
+	c.ApplyYAML(repo.ReadFile("platform/vclusters/00-namespace.yaml") + "---\n" +
+		repo.ReadFile("platform/vclusters/vcluster-a.yaml"))
+
+	client, err := kube.NewClient("any-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vcs, err := client.ListVClusters(context.Background(), "platform-requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vcs) != 1 || vcs[0].GetName() != "vcluster-a" {
+		t.Errorf("ListVClusters = %v, want vcluster-a", vcs)
+	}
+	nodes, err := client.ListNodes(context.Background())
+	if err != nil || len(nodes) != 1 {
+		t.Errorf("ListNodes = %v, %v; want node-1", nodes, err)
+	}
+	if _, err := client.Clientset.CoreV1().Namespaces().Get(context.Background(), "platform-requests", metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace from manifest not created: %v", err)
+	}
+}
+
+func TestPlatformCRDs(t *testing.T) {
+	crds := PlatformCRDs(t)
+	byName := map[string]bool{}
+	for _, crd := range crds {
+		byName[crd.Name] = true
+	}
+	for _, k := range PlatformKinds {
+		if name := k.GVR.Resource + "." + k.GVR.Group; !byName[name] {
+			t.Errorf("no CRD for %s", name)
+		}
+	}
+	for _, crd := range crds {
+		if crd.Name != "vclusterorchestratorv2s.platform.integratn.tech" {
+			continue
+		}
+		schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+		if _, ok := schema.Properties["spec"]; !ok {
+			t.Error("vcluster CRD should come from the promise, with its spec schema")
+		}
+	}
+}
+
+func TestFakeClusterMutations(t *testing.T) {
+	c := NewFakeCluster(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	if got := c.Mutations(); len(got) != 0 {
+		t.Errorf("seeded objects recorded as mutations: %v", got)
+	}
+
+	ctx := context.Background()
+	if _, err := c.Client.ListNodes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Client.Clientset.CoreV1().Nodes().Delete(ctx, "node-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Mutations(); len(got) != 1 || got[0] != "delete nodes /node-1" {
+		t.Errorf("Mutations() = %v, want [delete nodes /node-1]", got)
+	}
+}
@@ -0,0 +1,316 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// Column selection for list commands (--columns name,phase,argocd.syncStatus).
+// Columns are JSON paths into the same items the command emits with -o json,
+// so a path that works in jq works here.
+
+// noValue is shown for paths that are absent or null on an item.
+const noValue = "<none>"
+
+// PrintColumns prints the selected columns of items to stdout. It is a usage
+// error to combine --columns with -o json/yaml, which already emit every field.
+func PrintColumns(items any, paths []string) error {
+	if IsStructured() {
+		return hcerrors.NewUserError("--columns cannot be combined with -o %s", outputFormat).
+			WithRemediation("use -o json with jq to select fields, or drop -o to get a table")
+	}
+	out, err := SelectColumns(items, paths)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// SelectColumns renders the given JSON paths of each item in items (a slice)
+// as a plain, space-aligned table with one row per item. Paths are validated
+// against the item type first; an unknown path is a usage error listing the
+// paths available for that type.
+func SelectColumns(items any, paths []string) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return "", fmt.Errorf("SelectColumns: items must be a slice, got %T", items)
+	}
+	elem := v.Type().Elem()
+	for _, p := range paths {
+		if !validPath(elem, splitPath(p)) {
+			available := ColumnPaths(elem)
+			return "", hcerrors.NewUserError("unknown column %q", p).
+				WithRemediation("available columns: " + strings.Join(available, ", ")).
+				WithDetails(map[string]any{"available": available})
+		}
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("encoding items: %w", err)
+	}
+	var decoded []any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "", fmt.Errorf("decoding items: %w", err)
+	}
+
+	headers := make([]string, len(paths))
+	for i, p := range paths {
+		headers[i] = ColumnHeader(p)
+	}
+	rows := make([][]string, 0, len(decoded))
+	for _, item := range decoded {
+		row := make([]string, len(paths))
+		for i, p := range paths {
+			row[i] = formatValue(lookupPath(item, splitPath(p)))
+		}
+		rows = append(rows, row)
+	}
+	return PlainTable(headers, rows), nil
+}
+
+// ColumnPaths lists the selectable JSON paths of t, sorted. Nested structs
+// contribute their leaf fields; a map field is listed as "field.<key>".
+func ColumnPaths(t reflect.Type) []string {
+	var paths []string
+	collectPaths(t, "", &paths, map[reflect.Type]bool{})
+	sort.Strings(paths)
+	return paths
+}
+
+func collectPaths(t reflect.Type, prefix string, paths *[]string, seen map[reflect.Type]bool) {
+	t = deref(t)
+	if t.Kind() != reflect.Struct || isOpaque(t) {
+		if prefix != "" {
+			*paths = append(*paths, prefix)
+		}
+		return
+	}
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for _, f := range jsonFields(t) {
+		name := f.name
+		if prefix != "" {
+			name = prefix + "." + f.name
+		}
+		ft := deref(f.typ)
+		switch {
+		case ft.Kind() == reflect.Map:
+			*paths = append(*paths, name+".<key>")
+		case ft.Kind() == reflect.Struct && !isOpaque(ft):
+			collectPaths(ft, name, paths, seen)
+		default:
+			*paths = append(*paths, name)
+		}
+	}
+}
+
+// validPath reports whether segs resolves against t. Any segment may index
+// a map; numeric segments index a slice. A path may stop at a struct, which
+// renders as compact JSON.
+func validPath(t reflect.Type, segs []string) bool {
+	if len(segs) == 0 {
+		return false
+	}
+	for _, seg := range segs {
+		t = deref(t)
+		switch t.Kind() {
+		case reflect.Struct:
+			if isOpaque(t) {
+				return false
+			}
+			found := false
+			for _, f := range jsonFields(t) {
+				if f.name == seg {
+					t, found = f.typ, true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(seg); err != nil {
+				return false
+			}
+			t = t.Elem()
+		case reflect.Interface:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields returns the exported fields of struct t under their JSON
+// names, flattening embedded structs the way encoding/json does.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && deref(f.Type).Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(deref(f.Type))...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name: name, typ: f.Type})
+	}
+	return fields
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isOpaque reports whether t marshals itself (time.Time and friends), so
+// its fields are not addressable by path.
+func isOpaque(t reflect.Type) bool {
+	return t.Implements(reflect.TypeFor[json.Marshaler]()) ||
+		reflect.PointerTo(t).Implements(reflect.TypeFor[json.Marshaler]())
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.TrimPrefix(strings.TrimSpace(p), "."), ".")
+}
+
+// lookupPath walks decoded JSON along segs. Missing keys yield nil.
+func lookupPath(v any, segs []string) any {
+	for _, seg := range segs {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// formatValue renders a decoded JSON value as a single table cell.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return noValue
+	case string:
+		if val == "" {
+			return noValue
+		}
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case []any:
+		if len(val) == 0 {
+			return noValue
+		}
+		parts := make([]string, len(val))
+		for i, e := range val {
+			switch e.(type) {
+			case map[string]any, []any:
+				b, _ := json.Marshal(val)
+				return string(b)
+			}
+			parts[i] = formatValue(e)
+		}
+		return strings.Join(parts, ",")
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// ColumnHeader derives a table header from a JSON path: the last segment,
+// split at camelCase boundaries and upper-cased with underscores, so
+// "argocd.syncStatus" becomes "SYNC_STATUS".
+func ColumnHeader(path string) string {
+	segs := splitPath(path)
+	last := segs[len(segs)-1]
+	var b strings.Builder
+	prev := rune(0)
+	for i, r := range last {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteByte('_')
+		}
+		if r == '-' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+	return b.String()
+}
+
+// PlainTable renders rows as space-aligned columns without borders or
+// styling, suitable for piping into awk or cut.
+func PlainTable(headers []string, rows [][]string) string {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+3))
+		}
+		b.WriteByte('\n')
+	}
+	writeRow(headers)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return b.String()
+}
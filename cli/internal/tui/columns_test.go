@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+type columnsItem struct {
+	Name    string            `json:"name"`
+	Phase   string            `json:"phase,omitempty"`
+	Replica int               `json:"replicas"`
+	Ready   bool              `json:"ready"`
+	Created time.Time         `json:"created"`
+	ArgoCD  columnsArgo       `json:"argocd"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	hidden  string
+}
+
+type columnsArgo struct {
+	SyncStatus string `json:"syncStatus"`
+	Revision   string `json:"revision,omitempty"`
+}
+
+func TestSelectColumns(t *testing.T) {
+	items := []columnsItem{
+		{Name: "media", Phase: "Ready", Replica: 3, Ready: true, ArgoCD: columnsArgo{SyncStatus: "Synced"},
+			Labels: map[string]string{"env": "prod"}, Tags: []string{"a", "b"}},
+		{Name: "dev-longer-name", ArgoCD: columnsArgo{SyncStatus: "OutOfSync"}},
+	}
+	got, err := SelectColumns(items, []string{"name", "phase", "argocd.syncStatus", "replicas", "ready", "labels.env", "tags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"NAME              PHASE    SYNC_STATUS   REPLICAS   READY   ENV      TAGS\n" +
+		"media             Ready    Synced        3          true    prod     a,b\n" +
+		"dev-longer-name   <none>   OutOfSync     0          false   <none>   <none>\n"
+	if got != want {
+		t.Errorf("SelectColumns =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSelectColumnsStructValue(t *testing.T) {
+	got, err := SelectColumns([]columnsItem{{Name: "a", ArgoCD: columnsArgo{SyncStatus: "Synced"}}}, []string{"argocd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `{"syncStatus":"Synced"}`) {
+		t.Errorf("struct column should render as compact JSON, got %q", got)
+	}
+}
+
+func TestSelectColumnsUnknown(t *testing.T) {
+	for _, col := range []string{"nope", "argocd.nope", "name.sub", "created.year", "hidden", "tags.x"} {
+		_, err := SelectColumns([]columnsItem{}, []string{"name", col})
+		if hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+			t.Errorf("column %q: err = %v, want usage error", col, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), col) {
+			t.Errorf("error %q should name the column %q", err, col)
+		}
+		var he *hcerrors.HctlError
+		if !errors.As(err, &he) || !strings.Contains(he.Remediation, "argocd.syncStatus") {
+			t.Errorf("column %q: remediation should list available paths, got %+v", col, he)
+		}
+	}
+}
+
+func TestColumnPaths(t *testing.T) {
+	got := ColumnPaths(reflect.TypeOf(columnsItem{}))
+	want := []string{"argocd.revision", "argocd.syncStatus", "created", "labels.<key>", "name", "phase", "ready", "replicas", "tags"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ColumnPaths = %v, want %v", got, want)
+	}
+}
+
+func TestColumnHeader(t *testing.T) {
+	tests := map[string]string{
+		"name":                   "NAME",
+		"argocd.syncStatus":      "SYNC_STATUS",
+		"targetNamespace":        "TARGET_NAMESPACE",
+		"labels.app-name":        "APP_NAME",
+		"pods.ready":             "READY",
+		"k8sVersion":             "K8S_VERSION",
+		".status.lastReconciled": "LAST_RECONCILED",
+	}
+	for in, want := range tests {
+		if got := ColumnHeader(in); got != want {
+			t.Errorf("ColumnHeader(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlainTableAlignsUnicode(t *testing.T) {
+	got := PlainTable([]string{"A", "B"}, [][]string{{"—", "x"}, {"long", "y"}})
+	want := "A      B\n—      x\nlong   y\n"
+	if got != want {
+		t.Errorf("PlainTable =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPrintColumnsRejectsStructured(t *testing.T) {
+	SetOutputFormat("json")
+	defer SetOutputFormat("text")
+	if err := PrintColumns([]columnsItem{}, []string{"name"}); hcerrors.CategoryOf(err) != hcerrors.ErrUsage {
+		t.Errorf("PrintColumns with -o json = %v, want usage error", err)
+	}
+}
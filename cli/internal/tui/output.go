@@ -19,6 +19,9 @@ const (
 	FormatJSON OutputFormat = "json"
 	// FormatYAML renders YAML output.
 	FormatYAML OutputFormat = "yaml"
+	// FormatWide renders text output with the extra columns list commands
+	// define for -o wide.
+	FormatWide OutputFormat = "wide"
 )
 
 // outputFormat is the currently configured output format.
@@ -31,6 +34,8 @@ func SetOutputFormat(format string) {
 		outputFormat = FormatJSON
 	case "yaml":
 		outputFormat = FormatYAML
+	case "wide":
+		outputFormat = FormatWide
 	default:
 		outputFormat = FormatText
 	}
@@ -46,6 +51,11 @@ func IsStructured() bool {
 	return outputFormat == FormatJSON || outputFormat == FormatYAML
 }
 
+// IsWide returns true when list commands should add their wide columns.
+func IsWide() bool {
+	return outputFormat == FormatWide
+}
+
 // RenderOutput prints data in the configured output format.
 // For text format, it prints the textOutput string as-is.
 // For JSON/YAML, it marshals the data parameter.
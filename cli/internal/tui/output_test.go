@@ -16,6 +16,7 @@ func TestSetOutputFormat(t *testing.T) {
 	}{
 		{"json", FormatJSON},
 		{"yaml", FormatYAML},
+		{"wide", FormatWide},
 		{"text", FormatText},
 		{"", FormatText},
 		{"unknown", FormatText},
@@ -44,6 +45,11 @@ func TestIsStructured(t *testing.T) {
 	if IsStructured() {
 		t.Error("expected IsStructured=false for text")
 	}
+	SetOutputFormat("wide")
+	defer SetOutputFormat("text")
+	if IsStructured() || !IsWide() {
+		t.Error("expected IsStructured=false, IsWide=true for wide")
+	}
 }
 
 func TestFprintOutputJSON(t *testing.T) {
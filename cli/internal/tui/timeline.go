@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultTimelineLines is how many timeline lines RunTimeline keeps when
+// TimelineOpts.MaxLines is unset.
+const defaultTimelineLines = 5000
+
+// TimelineUpdate is one message to RunTimeline.
+type TimelineUpdate struct {
+	// Header, when non-empty, replaces the sticky header.
+	Header string
+	// Lines are appended to the timeline, already styled.
+	Lines []string
+}
+
+// TimelineOpts configures RunTimeline.
+type TimelineOpts struct {
+	Title string
+	// Updates feeds the view; closing it leaves the last state on screen
+	// until the user quits.
+	Updates <-chan TimelineUpdate
+	// MaxLines caps the timeline; the oldest lines are dropped past it, so
+	// long sessions stay bounded.
+	MaxLines int
+}
+
+type timelineDoneMsg struct{}
+
+type timelineKeyMap struct {
+	Scroll key.Binding
+	Follow key.Binding
+	Quit   key.Binding
+}
+
+func (k timelineKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Scroll, k.Follow, k.Quit}
+}
+
+func (k timelineKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type timelineModel struct {
+	opts     TimelineOpts
+	header   string
+	lines    []string
+	dropped  int
+	done     bool
+	viewport viewport.Model
+	help     help.Model
+	keys     timelineKeyMap
+	width    int
+	height   int
+}
+
+func newTimelineModel(opts TimelineOpts) timelineModel {
+	if opts.MaxLines <= 0 {
+		opts.MaxLines = defaultTimelineLines
+	}
+	h := help.New()
+	h.Styles.ShortKey = lipgloss.NewStyle().Foreground(ColorAccent)
+	h.Styles.ShortDesc = lipgloss.NewStyle().Foreground(ColorGray)
+
+	return timelineModel{
+		opts:     opts,
+		viewport: viewport.New(80, 10),
+		help:     h,
+		keys: timelineKeyMap{
+			Scroll: key.NewBinding(key.WithKeys("up", "down", "pgup", "pgdown"), key.WithHelp("↑/↓", "scroll")),
+			Follow: key.NewBinding(key.WithKeys("end", "G"), key.WithHelp("G", "follow")),
+			Quit:   key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+		},
+		width:  80,
+		height: 24,
+	}
+}
+
+func (m timelineModel) Init() tea.Cmd {
+	return m.next()
+}
+
+// next waits for the next update off the UI goroutine.
+func (m timelineModel) next() tea.Cmd {
+	updates := m.opts.Updates
+	return func() tea.Msg {
+		u, ok := <-updates
+		if !ok {
+			return timelineDoneMsg{}
+		}
+		return u
+	}
+}
+
+func (m timelineModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		m.viewport.Width = msg.Width
+		m.layout()
+		return m, nil
+
+	case TimelineUpdate:
+		m.apply(msg)
+		return m, m.next()
+
+	case timelineDoneMsg:
+		m.done = true
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Follow):
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+
+	case tea.MouseMsg:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// apply records an update, dropping the oldest lines past MaxLines. The
+// view keeps following new lines while it is scrolled to the bottom.
+func (m *timelineModel) apply(u TimelineUpdate) {
+	follow := m.viewport.AtBottom()
+	if u.Header != "" {
+		m.header = u.Header
+	}
+	m.lines = append(m.lines, u.Lines...)
+	if over := len(m.lines) - m.opts.MaxLines; over > 0 {
+		// Copy so the dropped lines are not kept alive by the backing array.
+		m.lines = append([]string(nil), m.lines[over:]...)
+		m.dropped += over
+	}
+	m.layout()
+	if follow {
+		m.viewport.GotoBottom()
+	}
+}
+
+// layout sizes the timeline to the space the title, header and footer
+// leave, and refreshes its content.
+func (m *timelineModel) layout() {
+	used := 3 + lipgloss.Height(m.header) + 1
+	m.viewport.Height = max(1, m.height-used)
+	m.viewport.SetContent(strings.Join(m.lines, "\n"))
+}
+
+func (m timelineModel) View() string {
+	info := ""
+	switch {
+	case m.done:
+		info = "  watch ended"
+	case m.dropped > 0:
+		info = "  older lines dropped"
+	}
+	rule := SubtleStyle.Render(strings.Repeat("─", max(0, m.width)))
+	return BannerStyle.Render(m.opts.Title) + MutedStyle.Render(info) + "\n\n" +
+		m.header + "\n" + rule + "\n" +
+		m.viewport.View() + "\n" + m.help.View(m.keys)
+}
+
+// RunTimeline shows a sticky header above a scrolling timeline, both fed
+// by opts.Updates, in a full-screen view. Blocks until the user quits with
+// q or Ctrl-C.
+func RunTimeline(opts TimelineOpts) error {
+	p := tea.NewProgram(newTimelineModel(opts), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	_, err := p.Run()
+	return err
+}
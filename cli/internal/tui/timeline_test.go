@@ -0,0 +1,22 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTimelineModelApply(t *testing.T) {
+	m := newTimelineModel(TimelineOpts{MaxLines: 3})
+	m.apply(TimelineUpdate{Header: "Phase: Progressing", Lines: []string{"a", "b"}})
+	m.apply(TimelineUpdate{Lines: []string{"c", "d"}})
+	if m.header != "Phase: Progressing" {
+		t.Errorf("header = %q, an update without one should keep it", m.header)
+	}
+	if !reflect.DeepEqual(m.lines, []string{"b", "c", "d"}) || m.dropped != 1 {
+		t.Errorf("lines = %v, dropped = %d; want the newest 3 kept", m.lines, m.dropped)
+	}
+	m.apply(TimelineUpdate{Header: "Phase: Ready"})
+	if m.header != "Phase: Ready" {
+		t.Errorf("header = %q, want it replaced", m.header)
+	}
+}
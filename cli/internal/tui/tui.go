@@ -1,9 +1,11 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -56,6 +58,39 @@ func Table(headers []string, rows [][]string) string {
 	return t.Render()
 }
 
+// FormatAge renders the time since t compactly (45m, 3h, 12d), as in the
+// AGE column of list commands. A zero time renders as "—".
+func FormatAge(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	d := time.Since(t)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// OrDash returns s, or "—" for an empty table cell.
+func OrDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// ShortRevision shortens a 40-character git SHA to 8 characters for table
+// cells. Branches, tags, and chart versions are returned unchanged.
+func ShortRevision(rev string) string {
+	if len(rev) == 40 && strings.Trim(rev, "0123456789abcdef") == "" {
+		return rev[:8]
+	}
+	return rev
+}
+
 // TreeNode renders a tree-style status display.
 func TreeNode(name, status, message string, isLast bool) string {
 	prefix := SubtleStyle.Render("├── ")
@@ -80,14 +115,23 @@ func Confirm(prompt string) (bool, error) {
 	return strings.ToLower(strings.TrimSpace(response)) == "y", nil
 }
 
+// ErrBack is returned by SelectWith and Prompt when the user presses esc to
+// return to the previous step of a multi-step flow.
+var ErrBack = errors.New("back")
+
+// ErrCancelled is returned by SelectWith and Prompt when the user cancels.
+var ErrCancelled = errors.New("cancelled")
+
 // --- Select model for interactive selection ---
 
 type selectModel struct {
-	title   string
-	choices []string
-	cursor  int
-	chosen  int
-	done    bool
+	title     string
+	choices   []string
+	cursor    int
+	chosen    int
+	done      bool
+	allowBack bool
+	back      bool
 }
 
 func (m selectModel) Init() tea.Cmd {
@@ -102,6 +146,13 @@ func (m selectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.chosen = -1
 			m.done = true
 			return m, tea.Quit
+		case "esc":
+			if m.allowBack {
+				m.chosen = -1
+				m.back = true
+				m.done = true
+				return m, tea.Quit
+			}
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
@@ -131,29 +182,78 @@ func (m selectModel) View() string {
 		}
 		sb.WriteString(fmt.Sprintf("%s%s\n", cursor, style.Render(choice)))
 	}
-	sb.WriteString(DimStyle.Render("\n↑/↓ navigate • enter select • q quit"))
+	help := "\n↑/↓ navigate • enter select • q quit"
+	if m.allowBack {
+		help = "\n↑/↓ navigate • enter select • esc back • q quit"
+	}
+	sb.WriteString(DimStyle.Render(help))
 	return sb.String()
 }
 
 // Select presents an interactive selection list. Returns the index of the chosen item, or -1 if cancelled.
 func Select(title string, choices []string) (int, error) {
-	m := selectModel{title: title, choices: choices, chosen: -1}
+	idx, err := SelectWith(SelectOpts{Title: title, Choices: choices})
+	if errors.Is(err, ErrCancelled) {
+		return -1, nil
+	}
+	return idx, err
+}
+
+// SelectOpts configures SelectWith.
+type SelectOpts struct {
+	Title   string
+	Choices []string
+	// Default is the index the cursor starts on.
+	Default int
+	// AllowBack lets esc return ErrBack.
+	AllowBack bool
+}
+
+// SelectWith presents a selection list. It returns ErrCancelled if the user
+// quits and, with AllowBack, ErrBack if they press esc.
+func SelectWith(opts SelectOpts) (int, error) {
+	cursor := opts.Default
+	if cursor < 0 || cursor >= len(opts.Choices) {
+		cursor = 0
+	}
+	m := selectModel{title: opts.Title, choices: opts.Choices, cursor: cursor, chosen: -1, allowBack: opts.AllowBack}
 	p := tea.NewProgram(m)
 	result, err := p.Run()
 	if err != nil {
 		return -1, err
 	}
-	return result.(selectModel).chosen, nil
+	rm := result.(selectModel)
+	switch {
+	case rm.back:
+		return -1, ErrBack
+	case rm.chosen < 0:
+		return -1, ErrCancelled
+	}
+	return rm.chosen, nil
 }
 
 // --- Text input model ---
 
+// warning is implemented by validation errors that inform rather than
+// block, such as platform.Warning. The first enter shows the message; a
+// second enter on the same value accepts it.
+type warning interface {
+	IsWarning() bool
+}
+
 type inputModel struct {
-	title    string
-	input    textinput.Model
-	done     bool
-	value    string
-	canceled bool
+	title     string
+	input     textinput.Model
+	done      bool
+	value     string
+	canceled  bool
+	validate  func(string) error
+	allowBack bool
+	back      bool
+	// errMsg is the message from the last failed validation; warned is set
+	// when it was a warning for the value still in the input.
+	errMsg string
+	warned bool
 }
 
 func newInputModel(title, placeholder, defaultVal string) inputModel {
@@ -178,36 +278,96 @@ func (m inputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.canceled = true
 			m.done = true
 			return m, tea.Quit
+		case "esc":
+			if m.allowBack {
+				m.back = true
+				m.done = true
+				return m, tea.Quit
+			}
 		case "enter":
-			m.value = m.input.Value()
+			value := strings.TrimSpace(m.input.Value())
+			if m.validate != nil && !m.warned {
+				if err := m.validate(value); err != nil {
+					m.errMsg = err.Error()
+					var w warning
+					m.warned = errors.As(err, &w) && w.IsWarning()
+					return m, nil
+				}
+			}
+			m.value = value
 			m.done = true
 			return m, tea.Quit
 		}
 	}
 	var cmd tea.Cmd
+	before := m.input.Value()
 	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.errMsg = ""
+		m.warned = false
+	}
 	return m, cmd
 }
 
 func (m inputModel) View() string {
-	return fmt.Sprintf("%s\n\n%s\n\n%s",
+	var msg string
+	switch {
+	case m.errMsg != "" && m.warned:
+		msg = "\n\n" + WarningStyle.Render(IconWarn+" "+m.errMsg) + "\n" + DimStyle.Render("press enter again to keep it")
+	case m.errMsg != "":
+		msg = "\n\n" + ErrorStyle.Render(IconCross+" "+m.errMsg)
+	}
+	help := "enter confirm • ctrl+c cancel"
+	if m.allowBack {
+		help = "enter confirm • esc back • ctrl+c cancel"
+	}
+	return fmt.Sprintf("%s\n\n%s%s\n\n%s",
 		TitleStyle.Render(m.title),
 		m.input.View(),
-		DimStyle.Render("enter confirm • ctrl+c cancel"),
+		msg,
+		DimStyle.Render(help),
 	)
 }
 
 // Input presents an interactive text input. Returns empty string if cancelled.
 func Input(title, placeholder, defaultVal string) (string, error) {
-	m := newInputModel(title, placeholder, defaultVal)
+	val, err := Prompt(PromptOpts{Title: title, Placeholder: placeholder, Default: defaultVal})
+	if errors.Is(err, ErrCancelled) {
+		return "", nil
+	}
+	return val, err
+}
+
+// PromptOpts configures Prompt.
+type PromptOpts struct {
+	Title       string
+	Placeholder string
+	Default     string
+	// Validate is called on enter with the trimmed value. An error is shown
+	// under the input and the prompt stays open; see warning for errors
+	// that can be accepted anyway.
+	Validate func(string) error
+	// AllowBack lets esc return ErrBack.
+	AllowBack bool
+}
+
+// Prompt presents a text input that re-prompts until Validate accepts the
+// value. It returns ErrCancelled on ctrl+c and, with AllowBack, ErrBack on esc.
+func Prompt(opts PromptOpts) (string, error) {
+	m := newInputModel(opts.Title, opts.Placeholder, opts.Default)
+	m.validate = opts.Validate
+	m.allowBack = opts.AllowBack
 	p := tea.NewProgram(m)
 	result, err := p.Run()
 	if err != nil {
 		return "", err
 	}
 	rm := result.(inputModel)
-	if rm.canceled {
-		return "", nil
+	switch {
+	case rm.back:
+		return "", ErrBack
+	case rm.canceled:
+		return "", ErrCancelled
 	}
 	return rm.value, nil
 }
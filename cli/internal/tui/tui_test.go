@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type testWarning struct{}
+
+func (testWarning) Error() string   { return "unusual value" }
+func (testWarning) IsWarning() bool { return true }
+
+func press(m inputModel, key string) inputModel {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, _ := m.Update(msg)
+	return next.(inputModel)
+}
+
+func TestInputValidationBlocksUntilValid(t *testing.T) {
+	m := newInputModel("Replicas", "", "x")
+	m.validate = func(v string) error {
+		if v != "x3" {
+			return errors.New("must be x3")
+		}
+		return nil
+	}
+
+	m = press(m, "enter")
+	if m.done || m.errMsg != "must be x3" {
+		t.Fatalf("invalid value accepted: done=%v errMsg=%q", m.done, m.errMsg)
+	}
+	m = press(m, "3")
+	if m.errMsg != "" {
+		t.Errorf("error not cleared after editing: %q", m.errMsg)
+	}
+	m = press(m, "enter")
+	if !m.done || m.value != "x3" {
+		t.Errorf("valid value not accepted: done=%v value=%q", m.done, m.value)
+	}
+}
+
+func TestInputWarningAcceptedOnSecondEnter(t *testing.T) {
+	m := newInputModel("Hostname", "", "a.example.com")
+	m.validate = func(string) error { return testWarning{} }
+
+	m = press(m, "enter")
+	if m.done || !m.warned {
+		t.Fatalf("first enter should show the warning: done=%v warned=%v", m.done, m.warned)
+	}
+	m = press(m, "enter")
+	if !m.done || m.value != "a.example.com" {
+		t.Errorf("second enter should accept: done=%v value=%q", m.done, m.value)
+	}
+}
+
+func TestInputBack(t *testing.T) {
+	m := newInputModel("Name", "", "")
+	if m = press(m, "esc"); m.done {
+		t.Error("esc should be ignored without allowBack")
+	}
+	m.allowBack = true
+	if m = press(m, "esc"); !m.done || !m.back {
+		t.Errorf("esc with allowBack: done=%v back=%v", m.done, m.back)
+	}
+}
@@ -0,0 +1,356 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ChangedStyle marks a cell that changed since the previous refresh.
+var ChangedStyle = lipgloss.NewStyle().Reverse(true)
+
+// RowDiff describes how a table changed between two refreshes.
+type RowDiff struct {
+	// Changed marks, for each row and column of the new table, cells whose
+	// value differs from the matching row of the old one.
+	Changed [][]bool
+	// Added marks rows of the new table whose key was not in the old one.
+	Added []bool
+	// Removed lists the keys of old rows missing from the new table, in
+	// their old order; key columns are joined with "/".
+	Removed []string
+}
+
+// Any reports whether anything changed.
+func (d RowDiff) Any() bool {
+	if len(d.Removed) > 0 {
+		return true
+	}
+	for i, row := range d.Changed {
+		if d.Added[i] {
+			return true
+		}
+		for _, c := range row {
+			if c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DiffRows compares two snapshots of a table. Rows are matched by their
+// first key columns (at least one), so reordering is not a change; rows with
+// the same key are matched in order.
+func DiffRows(prev, next [][]string, key int) RowDiff {
+	if key < 1 {
+		key = 1
+	}
+	rowKey := func(row []string) string {
+		return strings.Join(row[:min(key, len(row))], "/")
+	}
+
+	old := make(map[string][]int, len(prev))
+	for i, row := range prev {
+		k := rowKey(row)
+		old[k] = append(old[k], i)
+	}
+
+	d := RowDiff{Changed: make([][]bool, len(next)), Added: make([]bool, len(next))}
+	matched := make([]bool, len(prev))
+	for i, row := range next {
+		d.Changed[i] = make([]bool, len(row))
+		k := rowKey(row)
+		if len(old[k]) == 0 {
+			d.Added[i] = true
+			continue
+		}
+		j := old[k][0]
+		old[k] = old[k][1:]
+		matched[j] = true
+		for c, v := range row {
+			d.Changed[i][c] = c >= len(prev[j]) || prev[j][c] != v
+		}
+	}
+	for j, row := range prev {
+		if !matched[j] {
+			d.Removed = append(d.Removed, rowKey(row))
+		}
+	}
+	return d
+}
+
+// WatchSection is one table of the watch dashboard.
+type WatchSection struct {
+	Title   string
+	Headers []string
+	// Key is how many leading columns identify a row across refreshes
+	// (default 1).
+	Key int
+	// Style optionally renders a cell value for display; highlighted cells
+	// are shown unstyled in ChangedStyle.
+	Style func(col int, value string) string
+}
+
+// WatchData is one section's result from a refresh.
+type WatchData struct {
+	// Summary is an optional line shown above the table.
+	Summary string
+	Rows    [][]string
+	Err     error
+}
+
+// WatchOpts configures RunWatchDashboard.
+type WatchOpts struct {
+	Title    string
+	Sections []WatchSection
+	Interval time.Duration
+	// Collect refreshes every section at once, returning data in Sections
+	// order. It runs off the UI goroutine and should bound its own time.
+	Collect func() []WatchData
+}
+
+// watchSectionState is what the dashboard shows for a section: the last
+// successful data, the diff against the data before it, and the most
+// recent error.
+type watchSectionState struct {
+	data    WatchData
+	loaded  bool
+	diff    RowDiff
+	updated time.Time
+	err     error
+	errAt   time.Time
+}
+
+type watchTickMsg struct{ gen int }
+
+type watchDataMsg struct {
+	data []WatchData
+	at   time.Time
+}
+
+type watchKeyMap struct {
+	Refresh key.Binding
+	Scroll  key.Binding
+	Quit    key.Binding
+}
+
+func (k watchKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Scroll, k.Refresh, k.Quit}
+}
+
+func (k watchKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type watchModel struct {
+	opts       WatchOpts
+	sections   []watchSectionState
+	viewport   viewport.Model
+	spinner    spinner.Model
+	help       help.Model
+	keys       watchKeyMap
+	width      int
+	refreshing bool
+	// gen numbers refresh cycles so a tick scheduled before a manual
+	// refresh does not start a second, overlapping cycle.
+	gen         int
+	lastRefresh time.Time
+}
+
+func newWatchModel(opts WatchOpts) watchModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(ColorAccent)
+
+	h := help.New()
+	h.Styles.ShortKey = lipgloss.NewStyle().Foreground(ColorAccent)
+	h.Styles.ShortDesc = lipgloss.NewStyle().Foreground(ColorGray)
+
+	return watchModel{
+		opts:     opts,
+		sections: make([]watchSectionState, len(opts.Sections)),
+		viewport: viewport.New(80, 20),
+		spinner:  s,
+		help:     h,
+		keys: watchKeyMap{
+			Refresh: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh now")),
+			Scroll:  key.NewBinding(key.WithKeys("up", "down", "pgup", "pgdown"), key.WithHelp("↑/↓", "scroll")),
+			Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+		},
+		width:      80,
+		refreshing: true,
+	}
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.collect())
+}
+
+func (m watchModel) collect() tea.Cmd {
+	collect := m.opts.Collect
+	return func() tea.Msg {
+		return watchDataMsg{data: collect(), at: time.Now()}
+	}
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.help.Width = msg.Width
+		m.viewport.Width = msg.Width
+		// Two header lines and one footer line.
+		m.viewport.Height = max(1, msg.Height-3)
+		m.viewport.SetContent(m.renderSections())
+		return m, nil
+
+	case watchTickMsg:
+		if msg.gen != m.gen || m.refreshing {
+			return m, nil
+		}
+		m.refreshing = true
+		return m, m.collect()
+
+	case watchDataMsg:
+		m.apply(msg)
+		m.refreshing = false
+		m.gen++
+		m.viewport.SetContent(m.renderSections())
+		gen := m.gen
+		return m, tea.Tick(m.opts.Interval, func(time.Time) tea.Msg { return watchTickMsg{gen: gen} })
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Refresh):
+			if !m.refreshing {
+				m.refreshing = true
+				return m, m.collect()
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+
+	case tea.MouseMsg:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// apply records a refresh. A failed section keeps its last good data and
+// shows the error alongside; highlights last for exactly one refresh.
+func (m *watchModel) apply(msg watchDataMsg) {
+	m.lastRefresh = msg.at
+	for i := range m.sections {
+		s := &m.sections[i]
+		if i >= len(msg.data) {
+			break
+		}
+		d := msg.data[i]
+		if d.Err != nil {
+			s.err, s.errAt = d.Err, msg.at
+			s.diff = RowDiff{}
+			continue
+		}
+		if s.loaded {
+			s.diff = DiffRows(s.data.Rows, d.Rows, m.opts.Sections[i].Key)
+		}
+		s.data, s.loaded, s.updated, s.err = d, true, msg.at, nil
+	}
+}
+
+func (m watchModel) renderSections() string {
+	var sb strings.Builder
+	for i, sec := range m.opts.Sections {
+		s := m.sections[i]
+
+		status := DimStyle.Render("loading…")
+		switch {
+		case s.err != nil:
+			status = ErrorStyle.Render(fmt.Sprintf("error at %s", s.errAt.Format("15:04:05")))
+			if s.loaded {
+				status += DimStyle.Render(fmt.Sprintf(" · showing %s", s.updated.Format("15:04:05")))
+			}
+		case s.loaded:
+			status = DimStyle.Render("updated " + s.updated.Format("15:04:05"))
+		}
+		heading := TitleStyle.Render(sec.Title) + "  " + status
+		rule := max(0, m.width-lipgloss.Width(heading)-4)
+		sb.WriteString(SubtleStyle.Render("── ") + heading + " " + SubtleStyle.Render(strings.Repeat("─", rule)) + "\n")
+
+		if s.err != nil {
+			sb.WriteString(ErrorStyle.Render("  "+s.err.Error()) + "\n")
+		}
+		if s.loaded {
+			if s.data.Summary != "" {
+				sb.WriteString(s.data.Summary + "\n")
+			}
+			sb.WriteString(Table(sec.Headers, m.styledRows(i)) + "\n")
+			if len(s.diff.Removed) > 0 {
+				sb.WriteString(ChangedStyle.Render("  removed: "+strings.Join(s.diff.Removed, ", ")) + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// styledRows renders section i's rows, highlighting changed cells.
+func (m watchModel) styledRows(i int) [][]string {
+	sec, s := m.opts.Sections[i], m.sections[i]
+	rows := make([][]string, len(s.data.Rows))
+	for r, row := range s.data.Rows {
+		rows[r] = make([]string, len(row))
+		for c, v := range row {
+			switch {
+			case r < len(s.diff.Added) && (s.diff.Added[r] || s.diff.Changed[r][c]):
+				rows[r][c] = ChangedStyle.Render(v)
+			case sec.Style != nil:
+				rows[r][c] = sec.Style(c, v)
+			default:
+				rows[r][c] = v
+			}
+		}
+	}
+	return rows
+}
+
+func (m watchModel) View() string {
+	title := BannerStyle.Render(m.opts.Title)
+	info := fmt.Sprintf("  every %s", m.opts.Interval)
+	if !m.lastRefresh.IsZero() {
+		info += " · refreshed " + m.lastRefresh.Format("15:04:05")
+	}
+	if m.refreshing {
+		info += " " + m.spinner.View()
+	}
+	return title + MutedStyle.Render(info) + "\n\n" + m.viewport.View() + "\n" + m.help.View(m.keys)
+}
+
+// RunWatchDashboard shows every section in a full-screen view, refreshing
+// them together each interval and highlighting cells that changed since the
+// previous refresh. Blocks until the user quits with q or Ctrl-C.
+func RunWatchDashboard(opts WatchOpts) error {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	p := tea.NewProgram(newWatchModel(opts), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	_, err := p.Run()
+	return err
+}
@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffRows(t *testing.T) {
+	prev := [][]string{
+		{"grafana", "Synced", "Healthy"},
+		{"loki", "Synced", "Healthy"},
+		{"tempo", "OutOfSync", "Progressing"},
+	}
+
+	tests := []struct {
+		name        string
+		next        [][]string
+		key         int
+		wantChanged map[[2]int]bool
+		wantAdded   []int
+		wantRemoved []string
+	}{
+		{
+			name: "unchanged",
+			next: prev,
+		},
+		{
+			name: "reordered rows are not changes",
+			next: [][]string{prev[2], prev[0], prev[1]},
+		},
+		{
+			name: "app flips OutOfSync",
+			next: [][]string{
+				{"grafana", "OutOfSync", "Healthy"},
+				prev[1],
+				prev[2],
+			},
+			wantChanged: map[[2]int]bool{{0, 1}: true},
+		},
+		{
+			name: "added and removed rows",
+			next: [][]string{
+				prev[0],
+				{"mimir", "Synced", "Healthy"},
+			},
+			wantAdded:   []int{1},
+			wantRemoved: []string{"loki", "tempo"},
+		},
+		{
+			name: "composite key",
+			next: [][]string{
+				{"grafana", "Healthy", "Healthy"},
+			},
+			key:         2,
+			wantAdded:   []int{0},
+			wantRemoved: []string{"grafana/Synced", "loki/Synced", "tempo/OutOfSync"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiffRows(prev, tt.next, tt.key)
+			for r, row := range d.Changed {
+				for c, changed := range row {
+					if changed != tt.wantChanged[[2]int{r, c}] {
+						t.Errorf("Changed[%d][%d] = %v", r, c, changed)
+					}
+				}
+			}
+			var added []int
+			for r, a := range d.Added {
+				if a {
+					added = append(added, r)
+				}
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("Added rows = %v, want %v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(d.Removed, tt.wantRemoved) {
+				t.Errorf("Removed = %v, want %v", d.Removed, tt.wantRemoved)
+			}
+			wantAny := tt.wantChanged != nil || tt.wantAdded != nil || tt.wantRemoved != nil
+			if d.Any() != wantAny {
+				t.Errorf("Any() = %v, want %v", d.Any(), wantAny)
+			}
+		})
+	}
+}
+
+func TestDiffRowsDuplicateKeys(t *testing.T) {
+	prev := [][]string{{"web", "1"}, {"web", "2"}}
+	next := [][]string{{"web", "1"}, {"web", "3"}}
+	d := DiffRows(prev, next, 1)
+	if d.Changed[0][1] || !d.Changed[1][1] || d.Added[1] || len(d.Removed) != 0 {
+		t.Errorf("duplicate keys should pair in order, got %+v", d)
+	}
+}
+
+func TestWatchModelApply(t *testing.T) {
+	m := newWatchModel(WatchOpts{Sections: []WatchSection{{Title: "Apps"}}})
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	refresh := func(at time.Time, d WatchData) {
+		m.apply(watchDataMsg{data: []WatchData{d}, at: at})
+	}
+
+	refresh(t0, WatchData{Rows: [][]string{{"grafana", "Synced"}}})
+	if s := m.sections[0]; !s.loaded || s.diff.Any() {
+		t.Fatalf("first load should not highlight anything: %+v", s)
+	}
+
+	refresh(t0.Add(10*time.Second), WatchData{Rows: [][]string{{"grafana", "OutOfSync"}}})
+	if !m.sections[0].diff.Changed[0][1] {
+		t.Error("status change should be highlighted")
+	}
+
+	// A failed refresh keeps the data, records the error, and drops the
+	// highlight.
+	t2 := t0.Add(20 * time.Second)
+	refresh(t2, WatchData{Err: errors.New("timeout")})
+	s := m.sections[0]
+	if s.err == nil || !s.errAt.Equal(t2) || s.data.Rows[0][1] != "OutOfSync" || s.diff.Any() {
+		t.Errorf("after error: %+v", s)
+	}
+	if !s.updated.Equal(t0.Add(10 * time.Second)) {
+		t.Errorf("updated = %v, want the last successful refresh", s.updated)
+	}
+
+	// Highlights last one cycle.
+	refresh(t0.Add(30*time.Second), WatchData{Rows: [][]string{{"grafana", "OutOfSync"}}})
+	if s := m.sections[0]; s.err != nil || s.diff.Any() {
+		t.Errorf("unchanged refresh should clear error and highlights: %+v", s)
+	}
+}
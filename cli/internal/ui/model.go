@@ -0,0 +1,681 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+// level is a step of the drill-down.
+type level int
+
+const (
+	levelClusters level = iota
+	levelApps
+	levelPods
+	levelLogs
+)
+
+// queryTimeout bounds each query a pane makes.
+const queryTimeout = 10 * time.Second
+
+// maxLogLines is how many log lines the logs pane keeps.
+const maxLogLines = 1000
+
+// pane is the load state of one level. Its rows stay on screen while it
+// reloads, and after a failed load, marked stale.
+type pane struct {
+	cursor   int
+	loading  bool
+	err      error
+	loadedAt time.Time
+}
+
+// pendingAction is an action waiting for the user to confirm it.
+type pendingAction struct {
+	prompt string
+	run    func(ctx context.Context) (string, error)
+}
+
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Enter     key.Binding
+	Back      key.Binding
+	Refresh   key.Binding
+	Logs      key.Binding
+	Sync      key.Binding
+	Reconcile key.Binding
+	Open      key.Binding
+	Help      key.Binding
+	Quit      key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Enter, k.Back, k.Logs, k.Sync, k.Reconcile, k.Open, k.Refresh, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Enter, k.Back},
+		{k.Logs, k.Sync, k.Reconcile, k.Open},
+		{k.Refresh, k.Help, k.Quit},
+	}
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Up:        key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:      key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Enter:     key.NewBinding(key.WithKeys("enter", "right"), key.WithHelp("enter", "open")),
+		Back:      key.NewBinding(key.WithKeys("esc", "left", "backspace"), key.WithHelp("esc", "back")),
+		Refresh:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+		Logs:      key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "logs")),
+		Sync:      key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sync app")),
+		Reconcile: key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "reconcile")),
+		Open:      key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open URL")),
+		Help:      key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Quit:      key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	}
+}
+
+// Messages carrying a pane's data name what they were loaded for, so a
+// response that arrives after the user moved on is dropped.
+type (
+	clustersMsg struct {
+		clusters []Cluster
+		err      error
+	}
+	appsMsg struct {
+		cluster string
+		apps    []platform.ResourceStatus
+		err     error
+	}
+	podsMsg struct {
+		cluster, app string
+		pods         []kube.PodInfo
+		err          error
+	}
+	eventsMsg struct {
+		namespace, pod string
+		events         []string
+		err            error
+	}
+	logMsg struct {
+		stream *logStream
+		lines  []string
+	}
+	logEndMsg struct {
+		stream *logStream
+		err    error
+	}
+	actionMsg struct {
+		notice string
+		err    error
+	}
+	refreshMsg struct{}
+)
+
+// Model is the browser's bubbletea model.
+type Model struct {
+	src     Source
+	refresh time.Duration
+	level   level
+	panes   [levelLogs + 1]pane
+
+	clusters []Cluster
+	apps     []platform.ResourceStatus
+	pods     []kube.PodInfo
+
+	// cluster, app and pod are what was drilled into.
+	cluster Cluster
+	app     platform.ResourceStatus
+	pod     kube.PodInfo
+
+	// events are the warnings of the pod under the cursor, loaded on their
+	// own so a slow event query does not hold up the pod list.
+	events        []string
+	eventsFor     string
+	eventsLoading bool
+	eventsErr     error
+
+	logs      []string
+	logStream *logStream
+	logErr    error
+
+	confirm *pendingAction
+	notice  string
+
+	spinner spinner.Model
+	keys    keyMap
+	help    help.Model
+	width   int
+	height  int
+}
+
+// New returns the browser over src, reloading the pane on screen every
+// refresh.
+func New(src Source, refresh time.Duration) Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(tui.ColorAccent)
+
+	h := help.New()
+	h.Styles.ShortKey = lipgloss.NewStyle().Foreground(tui.ColorAccent)
+	h.Styles.ShortDesc = lipgloss.NewStyle().Foreground(tui.ColorGray)
+
+	m := Model{src: src, refresh: refresh, spinner: s, keys: newKeyMap(), help: h}
+	m.panes[levelClusters].loading = true
+	return m
+}
+
+// Run shows the browser full-screen until the user quits.
+func Run(src Source, refresh time.Duration) error {
+	m, err := tea.NewProgram(New(src, refresh), tea.WithAltScreen()).Run()
+	if final, ok := m.(Model); ok {
+		final.stopLogs()
+	}
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.loadClusters(), m.tick())
+}
+
+func (m Model) tick() tea.Cmd {
+	return tea.Tick(m.refresh, func(time.Time) tea.Msg { return refreshMsg{} })
+}
+
+func query[T any](fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func (m Model) loadClusters() tea.Cmd {
+	src := m.src
+	return func() tea.Msg {
+		clusters, err := query(src.Clusters)
+		return clustersMsg{clusters: clusters, err: err}
+	}
+}
+
+func (m Model) loadApps() tea.Cmd {
+	src, cluster := m.src, m.cluster.Name
+	return func() tea.Msg {
+		apps, err := query(func(ctx context.Context) ([]platform.ResourceStatus, error) { return src.Apps(ctx, cluster) })
+		return appsMsg{cluster: cluster, apps: apps, err: err}
+	}
+}
+
+func (m Model) loadPods() tea.Cmd {
+	src, cluster, app := m.src, m.cluster.Name, m.app
+	return func() tea.Msg {
+		pods, err := query(func(ctx context.Context) ([]kube.PodInfo, error) { return src.Pods(ctx, cluster, app) })
+		return podsMsg{cluster: cluster, app: app.Name, pods: pods, err: err}
+	}
+}
+
+// loadEvents loads the warnings of the pod under the cursor, if they are
+// not already loading.
+func (m *Model) loadEvents() tea.Cmd {
+	p, ok := m.selectedPod()
+	if !ok || (m.eventsFor == p.Name && m.eventsLoading) {
+		return nil
+	}
+	if m.eventsFor != p.Name {
+		m.events, m.eventsErr = nil, nil
+	}
+	m.eventsFor, m.eventsLoading = p.Name, true
+	src := m.src
+	return func() tea.Msg {
+		events, err := query(func(ctx context.Context) ([]string, error) { return src.PodEvents(ctx, p.Namespace, p.Name) })
+		return eventsMsg{namespace: p.Namespace, pod: p.Name, events: events, err: err}
+	}
+}
+
+// reload reloads the pane on screen; the panes behind it reload when the
+// user goes back to them.
+func (m *Model) reload() tea.Cmd {
+	if m.level == levelLogs {
+		return nil
+	}
+	m.panes[m.level].loading = true
+	switch m.level {
+	case levelApps:
+		return m.loadApps()
+	case levelPods:
+		m.eventsLoading = false
+		return tea.Batch(m.loadPods(), m.loadEvents())
+	default:
+		return m.loadClusters()
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		return m, nil
+
+	case refreshMsg:
+		return m, tea.Batch(m.reload(), m.tick())
+
+	case clustersMsg:
+		p := &m.panes[levelClusters]
+		p.loading = false
+		if p.err = msg.err; msg.clusters != nil || msg.err == nil {
+			m.clusters = msg.clusters
+			p.loadedAt = time.Now()
+		}
+		p.cursor = clamp(p.cursor, len(m.clusters))
+		return m, nil
+
+	case appsMsg:
+		if msg.cluster != m.cluster.Name || m.level < levelApps {
+			return m, nil
+		}
+		p := &m.panes[levelApps]
+		p.loading = false
+		if p.err = msg.err; msg.apps != nil || msg.err == nil {
+			m.apps = msg.apps
+			p.loadedAt = time.Now()
+		}
+		p.cursor = clamp(p.cursor, len(m.apps))
+		return m, nil
+
+	case podsMsg:
+		if msg.cluster != m.cluster.Name || msg.app != m.app.Name || m.level < levelPods {
+			return m, nil
+		}
+		p := &m.panes[levelPods]
+		p.loading = false
+		if p.err = msg.err; msg.err == nil {
+			m.pods = msg.pods
+			p.loadedAt = time.Now()
+		}
+		p.cursor = clamp(p.cursor, len(m.pods))
+		return m, m.loadEvents()
+
+	case eventsMsg:
+		if msg.pod != m.eventsFor {
+			return m, nil
+		}
+		m.eventsLoading = false
+		m.eventsErr = msg.err
+		if msg.err == nil {
+			m.events = msg.events
+		}
+		return m, nil
+
+	case logMsg:
+		if msg.stream != m.logStream {
+			return m, nil
+		}
+		m.logs = append(m.logs, msg.lines...)
+		if over := len(m.logs) - maxLogLines; over > 0 {
+			m.logs = m.logs[over:]
+		}
+		return m, msg.stream.next()
+
+	case logEndMsg:
+		if msg.stream == m.logStream {
+			m.logErr = msg.err
+		}
+		return m, nil
+
+	case actionMsg:
+		if msg.err != nil {
+			m.notice = tui.ErrorStyle.Render(tui.IconCross + " " + msg.err.Error())
+			return m, nil
+		}
+		m.notice = tui.SuccessStyle.Render(tui.IconCheck + " " + msg.notice)
+		return m, m.reload()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirm != nil {
+		action := m.confirm
+		m.confirm = nil
+		switch msg.String() {
+		case "y", "Y", "enter":
+			m.notice = ""
+			return m, func() tea.Msg {
+				notice, err := query(action.run)
+				return actionMsg{notice: notice, err: err}
+			}
+		default:
+			m.notice = tui.MutedStyle.Render("Cancelled")
+			return m, nil
+		}
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		m.stopLogs()
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+	case key.Matches(msg, m.keys.Up):
+		return m, m.move(-1)
+	case key.Matches(msg, m.keys.Down):
+		return m, m.move(1)
+	case key.Matches(msg, m.keys.Enter):
+		return m.drill()
+	case key.Matches(msg, m.keys.Logs):
+		if m.level == levelPods {
+			return m.drill()
+		}
+	case key.Matches(msg, m.keys.Back):
+		return m.back(), nil
+	case key.Matches(msg, m.keys.Refresh):
+		return m, m.reload()
+	case key.Matches(msg, m.keys.Sync):
+		m.confirmSync()
+	case key.Matches(msg, m.keys.Reconcile):
+		m.confirmReconcile()
+	case key.Matches(msg, m.keys.Open):
+		m.confirmOpen()
+	}
+	return m, nil
+}
+
+// move moves the cursor of the pane on screen.
+func (m *Model) move(delta int) tea.Cmd {
+	if m.level == levelLogs {
+		return nil
+	}
+	p := &m.panes[m.level]
+	p.cursor = clamp(p.cursor+delta, m.rowCount(m.level))
+	if m.level == levelPods {
+		return m.loadEvents()
+	}
+	return nil
+}
+
+// drill opens the row under the cursor: a cluster's apps, an app's pods, or
+// a pod's logs.
+func (m Model) drill() (tea.Model, tea.Cmd) {
+	cursor := m.panes[m.level].cursor
+	switch m.level {
+	case levelClusters:
+		if cursor >= len(m.clusters) {
+			return m, nil
+		}
+		m.cluster = m.clusters[cursor]
+		m.apps = nil
+		m.panes[levelApps] = pane{}
+		m.level = levelApps
+	case levelApps:
+		if cursor >= len(m.apps) {
+			return m, nil
+		}
+		m.app = m.apps[cursor]
+		m.pods, m.events, m.eventsFor, m.eventsErr = nil, nil, "", nil
+		m.panes[levelPods] = pane{}
+		m.level = levelPods
+	case levelPods:
+		if cursor >= len(m.pods) {
+			return m, nil
+		}
+		m.pod = m.pods[cursor]
+		m.level = levelLogs
+		m.logs, m.logErr = nil, nil
+		m.logStream = startLogs(m.src, m.pod.Namespace, m.pod.Name)
+		return m, m.logStream.next()
+	default:
+		return m, nil
+	}
+	m.notice = ""
+	return m, m.reload()
+}
+
+// back returns to the previous level, stopping a log stream.
+func (m Model) back() Model {
+	if m.level == levelClusters {
+		return m
+	}
+	if m.level == levelLogs {
+		m.stopLogs()
+	}
+	m.level--
+	m.notice = ""
+	return m
+}
+
+func (m *Model) stopLogs() {
+	if m.logStream != nil {
+		m.logStream.cancel()
+		m.logStream = nil
+	}
+}
+
+// selectedApp is the app the sync and open actions act on: the one drilled
+// into, or the one under the cursor in the apps pane.
+func (m Model) selectedApp() (platform.ResourceStatus, bool) {
+	switch m.level {
+	case levelApps:
+		if c := m.panes[levelApps].cursor; c < len(m.apps) {
+			return m.apps[c], true
+		}
+		return platform.ResourceStatus{}, false
+	case levelPods, levelLogs:
+		return m.app, true
+	}
+	return platform.ResourceStatus{}, false
+}
+
+func (m Model) selectedPod() (kube.PodInfo, bool) {
+	if c := m.panes[levelPods].cursor; m.level == levelPods && c < len(m.pods) {
+		return m.pods[c], true
+	}
+	return kube.PodInfo{}, false
+}
+
+func (m *Model) confirmSync() {
+	app, ok := m.selectedApp()
+	if !ok {
+		return
+	}
+	src, name := m.src, app.ArgoCD.AppName
+	m.confirm = &pendingAction{
+		prompt: fmt.Sprintf("Sync ArgoCD application %s?", name),
+		run: func(ctx context.Context) (string, error) {
+			if err := src.Sync(ctx, name); err != nil {
+				return "", fmt.Errorf("syncing %s: %w", name, err)
+			}
+			return "Sync triggered for " + name, nil
+		},
+	}
+}
+
+func (m *Model) confirmReconcile() {
+	if m.level != levelClusters {
+		return
+	}
+	c := m.panes[levelClusters].cursor
+	if c >= len(m.clusters) || !m.clusters[c].VCluster {
+		m.notice = tui.MutedStyle.Render("Only vClusters can be reconciled")
+		return
+	}
+	src, name := m.src, m.clusters[c].Name
+	m.confirm = &pendingAction{
+		prompt: fmt.Sprintf("Re-run the pipeline of vCluster %s?", name),
+		run: func(ctx context.Context) (string, error) {
+			if err := src.Reconcile(ctx, name); err != nil {
+				return "", fmt.Errorf("reconciling %s: %w", name, err)
+			}
+			return "Set kratix.io/manual-reconciliation=true on " + name, nil
+		},
+	}
+}
+
+// confirmOpen offers to open the route of the selected app, or the ArgoCD
+// page of the vCluster under the cursor.
+func (m *Model) confirmOpen() {
+	src := m.src
+	if m.level == levelClusters {
+		c := m.panes[levelClusters].cursor
+		if c >= len(m.clusters) {
+			return
+		}
+		for _, e := range m.clusters[c].Endpoints {
+			if e.Name == "ArgoCD" {
+				url := e.URL
+				m.confirm = &pendingAction{
+					prompt: "Open " + url + "?",
+					run: func(context.Context) (string, error) {
+						return "Opened " + url, src.Open(url)
+					},
+				}
+				return
+			}
+		}
+		m.notice = tui.MutedStyle.Render("No ArgoCD URL for " + m.clusters[c].Name)
+		return
+	}
+	app, ok := m.selectedApp()
+	if !ok {
+		return
+	}
+	cluster := m.cluster.Name
+	m.confirm = &pendingAction{
+		prompt: fmt.Sprintf("Open the route of %s?", app.Name),
+		run: func(ctx context.Context) (string, error) {
+			url, err := src.RouteURL(ctx, cluster, app)
+			if err != nil {
+				return "", err
+			}
+			return "Opened " + url, src.Open(url)
+		},
+	}
+}
+
+func (m Model) rowCount(l level) int {
+	switch l {
+	case levelClusters:
+		return len(m.clusters)
+	case levelApps:
+		return len(m.apps)
+	case levelPods:
+		return len(m.pods)
+	}
+	return 0
+}
+
+// clamp keeps a cursor within n rows.
+func clamp(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
+
+// logStream follows one pod's logs in the background and hands the model
+// its lines as they arrive.
+type logStream struct {
+	lines  chan string
+	cancel context.CancelFunc
+	// err is set before lines is closed.
+	err error
+}
+
+func startLogs(src Source, namespace, pod string) *logStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &logStream{lines: make(chan string, 256), cancel: cancel}
+	go func() {
+		w := &lineWriter{ctx: ctx, lines: s.lines}
+		s.err = src.Logs(ctx, namespace, pod, w)
+		w.flush()
+		if ctx.Err() != nil {
+			s.err = nil
+		}
+		close(s.lines)
+	}()
+	return s
+}
+
+// next waits for the stream's next lines, and takes whatever else has
+// already arrived with them.
+func (s *logStream) next() tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-s.lines
+		if !ok {
+			return logEndMsg{stream: s, err: s.err}
+		}
+		lines := []string{line}
+		for len(lines) < 100 {
+			select {
+			case line, ok := <-s.lines:
+				if !ok {
+					return logMsg{stream: s, lines: lines}
+				}
+				lines = append(lines, line)
+			default:
+				return logMsg{stream: s, lines: lines}
+			}
+		}
+		return logMsg{stream: s, lines: lines}
+	}
+}
+
+// lineWriter splits what it is written into lines for a logStream.
+type lineWriter struct {
+	ctx     context.Context
+	lines   chan<- string
+	partial string
+}
+
+var _ io.Writer = (*lineWriter)(nil)
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	data := w.partial + string(p)
+	for {
+		i := strings.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		select {
+		case w.lines <- strings.TrimRight(data[:i], "\r"):
+		case <-w.ctx.Done():
+			return 0, w.ctx.Err()
+		}
+		data = data[i+1:]
+	}
+	w.partial = data
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	if w.partial == "" {
+		return
+	}
+	select {
+	case w.lines <- w.partial:
+	case <-w.ctx.Done():
+	}
+	w.partial = ""
+}
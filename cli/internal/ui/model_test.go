@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+)
+
+type fakeSource struct {
+	mu          sync.Mutex
+	clustersErr error
+	synced      []string
+	logsDone    chan struct{}
+}
+
+func (f *fakeSource) Clusters(context.Context) ([]Cluster, error) {
+	clusters := []Cluster{
+		{Name: "media", Phase: "Ready", VCluster: true},
+		{Name: "the-cluster", Phase: "Ready", Message: "2/2 addons ready"},
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clustersErr != nil {
+		return nil, f.clustersErr
+	}
+	return clusters, nil
+}
+
+func (f *fakeSource) Apps(_ context.Context, cluster string) ([]platform.ResourceStatus, error) {
+	return []platform.ResourceStatus{
+		{Kind: platform.KindWorkload, Name: "jellyfin", Namespace: cluster, ArgoCD: platform.ArgoCDInfo{AppName: cluster + "-jellyfin"}},
+		{Kind: platform.KindWorkload, Name: "sonarr", Namespace: cluster, ArgoCD: platform.ArgoCDInfo{AppName: cluster + "-sonarr"}},
+	}, nil
+}
+
+func (f *fakeSource) Pods(_ context.Context, cluster string, app platform.ResourceStatus) ([]kube.PodInfo, error) {
+	return []kube.PodInfo{
+		{Name: app.Name + "-0", Namespace: cluster, Phase: "Running", ReadyContainers: 1, TotalContainers: 1},
+		{Name: app.Name + "-1", Namespace: cluster, Phase: "Pending", TotalContainers: 1, Waiting: "ImagePullBackOff"},
+	}, nil
+}
+
+func (f *fakeSource) PodEvents(_ context.Context, _, pod string) ([]string, error) {
+	return []string{"BackOff: pulling image for " + pod}, nil
+}
+
+func (f *fakeSource) Logs(ctx context.Context, _, pod string, w io.Writer) error {
+	fmt.Fprintf(w, "starting %s\nlistening on :8096\n", pod)
+	<-ctx.Done()
+	close(f.logsDone)
+	return ctx.Err()
+}
+
+func (f *fakeSource) RouteURL(_ context.Context, _ string, app platform.ResourceStatus) (string, error) {
+	return "https://" + app.Name + ".example.com", nil
+}
+
+func (f *fakeSource) Sync(_ context.Context, app string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.synced = append(f.synced, app)
+	return nil
+}
+
+func (f *fakeSource) Reconcile(context.Context, string) error { return nil }
+
+func (f *fakeSource) Open(string) error { return nil }
+
+// run runs cmd and feeds the messages it produces into the model, following
+// the commands those return. Commands still waiting after a moment, such as
+// the refresh tick or a log stream with nothing new, are dropped.
+func run(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(50 * time.Millisecond):
+		return m
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = run(t, m, c)
+		}
+		return m
+	}
+	next, cmd := m.Update(msg)
+	return run(t, next.(Model), cmd)
+}
+
+func press(t *testing.T, m Model, key string) Model {
+	t.Helper()
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, cmd := m.Update(msg)
+	return run(t, next.(Model), cmd)
+}
+
+func start(t *testing.T, src Source) Model {
+	t.Helper()
+	m := New(src, time.Hour)
+	return run(t, m, m.loadClusters())
+}
+
+func TestDrillDown(t *testing.T) {
+	src := &fakeSource{logsDone: make(chan struct{})}
+	m := start(t, src)
+	if len(m.clusters) != 2 || m.panes[levelClusters].loading {
+		t.Fatalf("clusters = %+v, loading = %v, want both clusters loaded", m.clusters, m.panes[levelClusters].loading)
+	}
+
+	m = press(t, m, "enter")
+	if m.level != levelApps || m.cluster.Name != "media" {
+		t.Fatalf("after enter: level = %d, cluster = %q, want the apps of media", m.level, m.cluster.Name)
+	}
+	if len(m.apps) != 2 {
+		t.Fatalf("apps = %+v, want media's two workloads", m.apps)
+	}
+
+	m = press(t, m, "down")
+	m = press(t, m, "enter")
+	if m.level != levelPods || m.app.Name != "sonarr" {
+		t.Fatalf("after down, enter: level = %d, app = %q, want the pods of sonarr", m.level, m.app.Name)
+	}
+	if len(m.pods) != 2 || m.eventsFor != "sonarr-0" || len(m.events) != 1 {
+		t.Fatalf("pods = %+v, events for %q = %v, want sonarr's pods and the first one's events", m.pods, m.eventsFor, m.events)
+	}
+	view := m.View()
+	for _, want := range []string{"media", "sonarr-1", "ImagePullBackOff", "BackOff: pulling image for sonarr-0"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("pods view does not show %q:\n%s", want, view)
+		}
+	}
+
+	m = press(t, m, "down")
+	if m.eventsFor != "sonarr-1" || len(m.events) != 1 || !strings.Contains(m.events[0], "sonarr-1") {
+		t.Errorf("events after down = %v for %q, want sonarr-1's", m.events, m.eventsFor)
+	}
+
+	m = press(t, m, "l")
+	if m.level != levelLogs || m.pod.Name != "sonarr-1" {
+		t.Fatalf("after l: level = %d, pod = %q, want the logs of sonarr-1", m.level, m.pod.Name)
+	}
+	if got := strings.Join(m.logs, "\n"); got != "starting sonarr-1\nlistening on :8096" {
+		t.Errorf("logs = %q", got)
+	}
+
+	m = press(t, m, "esc")
+	if m.level != levelPods || m.logStream != nil {
+		t.Errorf("after esc: level = %d, stream = %v, want the pods with the stream stopped", m.level, m.logStream)
+	}
+	select {
+	case <-src.logsDone:
+	case <-time.After(time.Second):
+		t.Error("log stream still running after esc")
+	}
+	if m.panes[levelPods].cursor != 1 {
+		t.Errorf("pods cursor = %d, want it kept on sonarr-1", m.panes[levelPods].cursor)
+	}
+
+	m = press(t, m, "esc")
+	m = press(t, m, "esc")
+	if m.level != levelClusters || m.panes[levelApps].cursor != 1 {
+		t.Errorf("after esc, esc: level = %d, apps cursor = %d", m.level, m.panes[levelApps].cursor)
+	}
+}
+
+func TestStaleResponsesDropped(t *testing.T) {
+	m := start(t, &fakeSource{})
+	m = press(t, m, "enter")
+
+	next, _ := m.Update(appsMsg{cluster: "the-cluster", apps: []platform.ResourceStatus{{Name: "traefik"}}})
+	if m = next.(Model); len(m.apps) != 2 {
+		t.Errorf("apps = %+v, want the apps of another cluster ignored", m.apps)
+	}
+	next, _ = m.Update(podsMsg{cluster: "media", app: "jellyfin", pods: []kube.PodInfo{{Name: "late"}}})
+	if m = next.(Model); m.pods != nil {
+		t.Errorf("pods = %+v, want pods arriving after going back ignored", m.pods)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	src := &fakeSource{clustersErr: errors.New("connection refused")}
+	m := start(t, src)
+	if view := m.View(); !strings.Contains(view, "connection refused") || !strings.Contains(view, "press r to retry") {
+		t.Errorf("offline view does not show the error:\n%s", view)
+	}
+
+	src.clustersErr = nil
+	m = press(t, m, "r")
+	if len(m.clusters) != 2 || m.panes[levelClusters].err != nil {
+		t.Fatalf("after r: clusters = %+v, err = %v", m.clusters, m.panes[levelClusters].err)
+	}
+
+	src.clustersErr = errors.New("connection refused")
+	m = press(t, m, "r")
+	view := m.View()
+	if len(m.clusters) != 2 || !strings.Contains(view, "showing clusters from") {
+		t.Errorf("a failed refresh should keep the clusters, marked stale:\n%s", view)
+	}
+}
+
+func TestActionsConfirm(t *testing.T) {
+	src := &fakeSource{}
+	m := start(t, src)
+
+	m = press(t, m, "down")
+	m = press(t, m, "R")
+	if m.confirm != nil || !strings.Contains(m.notice, "Only vClusters") {
+		t.Errorf("reconcile of a host cluster: confirm = %v, notice = %q", m.confirm, m.notice)
+	}
+
+	m = press(t, m, "enter")
+	m = press(t, m, "s")
+	if m.confirm == nil || !strings.Contains(m.confirm.prompt, "the-cluster-jellyfin") {
+		t.Fatalf("s should ask to sync the-cluster-jellyfin, confirm = %+v", m.confirm)
+	}
+	m = press(t, m, "n")
+	if m.confirm != nil || len(src.synced) != 0 {
+		t.Errorf("n should cancel: confirm = %v, synced = %v", m.confirm, src.synced)
+	}
+
+	m = press(t, m, "s")
+	m = press(t, m, "y")
+	if len(src.synced) != 1 || src.synced[0] != "the-cluster-jellyfin" {
+		t.Errorf("synced = %v, want the-cluster-jellyfin", src.synced)
+	}
+	if !strings.Contains(m.notice, "Sync triggered for the-cluster-jellyfin") {
+		t.Errorf("notice = %q", m.notice)
+	}
+}
@@ -0,0 +1,258 @@
+// Package ui is the full-screen platform browser of 'hctl ui': clusters,
+// then a cluster's workloads and addons, then an app's pods, then a pod's
+// logs.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/jamesatintegratnio/hctl/internal/deploy"
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+// Cluster is a row of the clusters pane.
+type Cluster struct {
+	Name    string
+	Phase   string
+	Message string
+	// VCluster is false for a cluster the addons target that is not a
+	// vCluster, such as the host cluster.
+	VCluster  bool
+	Endpoints []platform.Endpoint
+}
+
+// Source is the data and actions behind the browser. The model calls it
+// from tea.Cmds, so a slow query only holds up the pane waiting on it.
+type Source interface {
+	// Clusters lists the vClusters, and the other clusters addons target.
+	Clusters(ctx context.Context) ([]Cluster, error)
+	// Apps lists the workloads and addons deployed to a cluster.
+	Apps(ctx context.Context, cluster string) ([]platform.ResourceStatus, error)
+	// Pods lists an app's pods.
+	Pods(ctx context.Context, cluster string, app platform.ResourceStatus) ([]kube.PodInfo, error)
+	// PodEvents returns a pod's recent warning events, oldest first.
+	PodEvents(ctx context.Context, namespace, pod string) ([]string, error)
+	// Logs follows a pod's logs into w until ctx is done.
+	Logs(ctx context.Context, namespace, pod string, w io.Writer) error
+	// RouteURL returns the URL an app's route serves.
+	RouteURL(ctx context.Context, cluster string, app platform.ResourceStatus) (string, error)
+	// Sync triggers a sync of an ArgoCD Application.
+	Sync(ctx context.Context, app string) error
+	// Reconcile re-runs a vCluster's Kratix pipeline.
+	Reconcile(ctx context.Context, cluster string) error
+	// Open opens url in a browser.
+	Open(url string) error
+}
+
+// KubeOptions configures a KubeSource.
+type KubeOptions struct {
+	KubeContext       string
+	PlatformNamespace string
+	// RepoPath is the platform repo, where route URLs are read from the
+	// workloads' values. Empty leaves routes unknown.
+	RepoPath string
+	// StatusTTL is how long a platform status is reused across panes.
+	StatusTTL time.Duration
+	// Open opens a URL in a browser.
+	Open func(url string) error
+}
+
+// KubeSource is the Source for the cluster in a kube context. The clusters
+// and apps panes read one platform status through a StatusCache, so moving
+// between them does not query the cluster again.
+type KubeSource struct {
+	opts KubeOptions
+
+	mu     sync.Mutex
+	client *kube.Client
+	status *platform.StatusCache
+}
+
+// NewKubeSource returns a KubeSource. A cluster that cannot be reached is
+// not an error here: each query tries to connect, and the browser shows why
+// it could not.
+func NewKubeSource(opts KubeOptions) *KubeSource {
+	return &KubeSource{opts: opts}
+}
+
+// connect returns the client, connecting on first use.
+func (s *KubeSource) connect() (*kube.Client, *platform.StatusCache, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		client, err := kube.NewClient(s.opts.KubeContext)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to cluster: %w", err)
+		}
+		s.client = client
+		s.status = platform.NewStatusCache(client, s.opts.PlatformNamespace, s.opts.StatusTTL)
+	}
+	return s.client, s.status, nil
+}
+
+// Clusters lists the vClusters with their reported phase, then every other
+// cluster an addon targets, which is Ready when all its addons are.
+func (s *KubeSource) Clusters(ctx context.Context) ([]Cluster, error) {
+	_, status, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	ps, err := status.Get(ctx)
+	if ps == nil {
+		return nil, err
+	}
+	var clusters []Cluster
+	for _, vc := range ps.VClusters {
+		clusters = append(clusters, Cluster{
+			Name: vc.Name, Phase: vc.Phase, Message: vc.Message, VCluster: true, Endpoints: vc.Endpoints,
+		})
+	}
+	hosts := map[string][2]int{}
+	for _, a := range ps.Addons {
+		n := hosts[a.Labels["clusterName"]]
+		n[1]++
+		if a.Phase == phase.Ready {
+			n[0]++
+		}
+		hosts[a.Labels["clusterName"]] = n
+	}
+	var names []string
+	for name := range hosts {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n := hosts[name]
+		c := Cluster{Name: name, Phase: phase.Ready, Message: fmt.Sprintf("%d/%d addons ready", n[0], n[1])}
+		if n[0] < n[1] {
+			c.Phase = phase.Degraded
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, err
+}
+
+// Apps lists the cluster's workloads, then its addons, each by name.
+func (s *KubeSource) Apps(ctx context.Context, cluster string) ([]platform.ResourceStatus, error) {
+	_, status, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	ps, err := status.Get(ctx)
+	if ps == nil {
+		return nil, err
+	}
+	var apps []platform.ResourceStatus
+	for _, group := range [][]platform.ResourceStatus{ps.Workloads, ps.Addons} {
+		start := len(apps)
+		for _, a := range group {
+			if a.Labels["clusterName"] == cluster {
+				apps = append(apps, a)
+			}
+		}
+		sort.SliceStable(apps[start:], func(i, j int) bool { return apps[start+i].Name < apps[start+j].Name })
+	}
+	return apps, err
+}
+
+// Pods finds a workload's pods the way 'hctl logs' does, in the namespace
+// named after its vCluster; an addon's are those ArgoCD labels with its
+// Application in the destination namespace.
+func (s *KubeSource) Pods(ctx context.Context, cluster string, app platform.ResourceStatus) ([]kube.PodInfo, error) {
+	client, _, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	if app.Kind == platform.KindWorkload {
+		return deploy.FindPods(ctx, client, cluster, app.Name, cluster)
+	}
+	return client.ListPods(ctx, app.Namespace, "app.kubernetes.io/instance="+app.ArgoCD.AppName)
+}
+
+// PodEvents returns the pod's warning events.
+func (s *KubeSource) PodEvents(ctx context.Context, namespace, pod string) ([]string, error) {
+	client, _, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return client.PodWarnings(ctx, namespace, pod)
+}
+
+// logTail is how many earlier lines a log stream starts with.
+const logTail = 200
+
+// Logs follows the pod's logs with StreamPodLogs.
+func (s *KubeSource) Logs(ctx context.Context, namespace, pod string, w io.Writer) error {
+	client, _, err := s.connect()
+	if err != nil {
+		return err
+	}
+	return client.StreamPodLogs(ctx, namespace, pod, "", true, logTail, w)
+}
+
+// RouteURL reads the host of the workload's HTTPRoute from its values.yaml.
+func (s *KubeSource) RouteURL(_ context.Context, cluster string, app platform.ResourceStatus) (string, error) {
+	if app.Kind != platform.KindWorkload || s.opts.RepoPath == "" {
+		return "", hcerrors.New(hcerrors.ErrNotFound, "no route known for %s", app.Name)
+	}
+	data, err := os.ReadFile(repopath.Abs(s.opts.RepoPath, translate.ValuesPath(cluster, app.Name)))
+	if err != nil {
+		return "", hcerrors.New(hcerrors.ErrNotFound, "no values for %s in the repo: %v", app.Name, err)
+	}
+	var values struct {
+		HTTPRoute struct {
+			Hostnames []string `yaml:"hostnames"`
+		} `yaml:"httpRoute"`
+	}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return "", fmt.Errorf("parsing the values of %s: %w", app.Name, err)
+	}
+	if len(values.HTTPRoute.Hostnames) == 0 {
+		return "", hcerrors.New(hcerrors.ErrNotFound, "%s has no route", app.Name)
+	}
+	return "https://" + values.HTTPRoute.Hostnames[0], nil
+}
+
+// Sync triggers the Application's sync, and drops the cached status so the
+// panes show its effect.
+func (s *KubeSource) Sync(ctx context.Context, app string) error {
+	client, status, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer status.Invalidate()
+	return client.TriggerArgoAppSync(ctx, "argocd", app)
+}
+
+// Reconcile sets kratix.io/manual-reconciliation on the vCluster's request,
+// as 'hctl reconcile' does.
+func (s *KubeSource) Reconcile(ctx context.Context, cluster string) error {
+	client, status, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer status.Invalidate()
+	return client.SetManualReconciliationLabel(ctx, kube.VClusterOrchestratorV2GVR, s.opts.PlatformNamespace, cluster)
+}
+
+// Open opens url with the configured opener.
+func (s *KubeSource) Open(url string) error {
+	if s.opts.Open == nil {
+		return fmt.Errorf("cannot open %s here", url)
+	}
+	return s.opts.Open(url)
+}
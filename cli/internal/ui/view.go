@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/jamesatintegratnio/hctl/internal/tui"
+)
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(tui.ColorAccent)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+)
+
+func (m Model) View() string {
+	var sb strings.Builder
+	sb.WriteString(tui.BannerStyle.Render(tui.IconPlay+" hctl ui") + "  " + m.breadcrumb() + "\n")
+	width := m.width
+	if width == 0 {
+		width = 80
+	}
+	sb.WriteString(tui.SubtleStyle.Render(strings.Repeat("─", width)) + "\n\n")
+
+	switch m.level {
+	case levelClusters:
+		sb.WriteString(m.paneStatus(levelClusters, "clusters"))
+		sb.WriteString(m.clustersView())
+	case levelApps:
+		sb.WriteString(m.paneStatus(levelApps, "apps"))
+		sb.WriteString(m.appsView())
+	case levelPods:
+		sb.WriteString(m.appHeader())
+		sb.WriteString(m.paneStatus(levelPods, "pods"))
+		sb.WriteString(m.podsView())
+		sb.WriteString("\n" + m.eventsView())
+	case levelLogs:
+		sb.WriteString(m.logsView())
+	}
+
+	sb.WriteString("\n")
+	switch {
+	case m.confirm != nil:
+		sb.WriteString(tui.WarningStyle.Render(m.confirm.prompt) + tui.MutedStyle.Render(" [y/N]") + "\n")
+	case m.notice != "":
+		sb.WriteString(m.notice + "\n")
+	}
+	sb.WriteString(m.help.View(m.keys))
+	return sb.String()
+}
+
+// breadcrumb is the path drilled into.
+func (m Model) breadcrumb() string {
+	parts := []string{"clusters"}
+	if m.level >= levelApps {
+		parts = append(parts, m.cluster.Name)
+	}
+	if m.level >= levelPods {
+		parts = append(parts, m.app.Name)
+	}
+	if m.level >= levelLogs {
+		parts = append(parts, m.pod.Name)
+	}
+	return tui.MutedStyle.Render(strings.Join(parts, " "+tui.IconArrow+" "))
+}
+
+// paneStatus is the line above a pane: loading, or why the rows below are
+// stale or missing.
+func (m Model) paneStatus(l level, what string) string {
+	p := m.panes[l]
+	switch {
+	case p.err != nil && m.rowCount(l) > 0:
+		return tui.WarningStyle.Render(fmt.Sprintf("%s %v — showing %s from %s ago", tui.IconWarn, p.err, what, time.Since(p.loadedAt).Round(time.Second))) + "\n\n"
+	case p.err != nil:
+		return tui.ErrorStyle.Render(fmt.Sprintf("%s %v", tui.IconCross, p.err)) + "\n" +
+			tui.MutedStyle.Render("  press r to retry") + "\n"
+	case p.loading && m.rowCount(l) == 0:
+		return fmt.Sprintf("%s Loading %s...\n", m.spinner.View(), what)
+	case p.loading:
+		return m.spinner.View() + tui.MutedStyle.Render(" refreshing") + "\n\n"
+	}
+	return ""
+}
+
+func (m Model) clustersView() string {
+	if len(m.clusters) == 0 {
+		return m.empty(levelClusters, "No clusters found")
+	}
+	rows := make([][]string, len(m.clusters))
+	for i, c := range m.clusters {
+		kind := "vcluster"
+		if !c.VCluster {
+			kind = "cluster"
+		}
+		rows[i] = []string{c.Name, kind, phaseBadge(c.Phase), c.Message}
+	}
+	return renderRows([]string{"NAME", "KIND", "PHASE", "MESSAGE"}, rows, m.panes[levelClusters].cursor)
+}
+
+func (m Model) appsView() string {
+	if len(m.apps) == 0 {
+		return m.empty(levelApps, "No workloads or addons target "+m.cluster.Name)
+	}
+	rows := make([][]string, len(m.apps))
+	for i, a := range m.apps {
+		rows[i] = []string{a.Name, string(a.Kind), tui.SyncBadge(a.ArgoCD.SyncStatus), tui.HealthBadge(a.ArgoCD.HealthStatus), tui.OrDash(a.Namespace)}
+	}
+	return renderRows([]string{"NAME", "KIND", "SYNC", "HEALTH", "NAMESPACE"}, rows, m.panes[levelApps].cursor)
+}
+
+// appHeader sums up the app drilled into: its ArgoCD state, and the last
+// operation's message when there is one.
+func (m Model) appHeader() string {
+	a := m.app.ArgoCD
+	s := fmt.Sprintf("%s  %s  %s\n", headerStyle.Render(a.AppName), tui.SyncBadge(a.SyncStatus), tui.HealthBadge(a.HealthStatus))
+	if a.Message != "" {
+		s += tui.MutedStyle.Render("  "+a.Message) + "\n"
+	}
+	return s + "\n"
+}
+
+func (m Model) podsView() string {
+	if len(m.pods) == 0 {
+		return m.empty(levelPods, "No pods found for "+m.app.Name)
+	}
+	rows := make([][]string, len(m.pods))
+	for i, p := range m.pods {
+		status := p.Phase
+		if p.Waiting != "" {
+			status = tui.WarningStyle.Render(p.Waiting)
+		}
+		rows[i] = []string{p.Name, fmt.Sprintf("%d/%d", p.ReadyContainers, p.TotalContainers), status, fmt.Sprint(p.Restarts)}
+	}
+	return renderRows([]string{"POD", "READY", "STATUS", "RESTARTS"}, rows, m.panes[levelPods].cursor)
+}
+
+// eventsView lists the warnings of the pod under the cursor.
+func (m Model) eventsView() string {
+	if m.eventsFor == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render("Warning events") + tui.MutedStyle.Render(" "+m.eventsFor) + "\n")
+	switch {
+	case m.eventsErr != nil:
+		sb.WriteString(tui.ErrorStyle.Render("  "+m.eventsErr.Error()) + "\n")
+	case m.eventsLoading && len(m.events) == 0:
+		sb.WriteString("  " + m.spinner.View() + " Loading events...\n")
+	case len(m.events) == 0:
+		sb.WriteString(tui.MutedStyle.Render("  none") + "\n")
+	}
+	events := m.events
+	if len(events) > 5 {
+		events = events[len(events)-5:]
+	}
+	for _, e := range events {
+		sb.WriteString("  " + tui.WarningStyle.Render(tui.IconWarn) + " " + e + "\n")
+	}
+	return sb.String()
+}
+
+// logsView shows as many of the latest log lines as fit.
+func (m Model) logsView() string {
+	var sb strings.Builder
+	visible := m.height - 8
+	if visible < 5 {
+		visible = 20
+	}
+	lines := m.logs
+	if len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+	if len(lines) == 0 && m.logStream != nil && m.logErr == nil {
+		sb.WriteString(m.spinner.View() + " Waiting for logs...\n")
+	}
+	for _, l := range lines {
+		sb.WriteString(l + "\n")
+	}
+	if m.logErr != nil {
+		sb.WriteString(tui.ErrorStyle.Render(tui.IconCross+" "+m.logErr.Error()) + "\n")
+	}
+	return sb.String()
+}
+
+// empty is what a pane without rows shows once loaded.
+func (m Model) empty(l level, message string) string {
+	if m.panes[l].loading || m.panes[l].err != nil {
+		return ""
+	}
+	return tui.MutedStyle.Render("  "+message) + "\n"
+}
+
+// renderRows lays out rows in columns under headers, highlighting the row
+// under the cursor.
+func renderRows(headers []string, rows [][]string, cursor int) string {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = lipgloss.Width(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+	line := func(cells []string) string {
+		var parts []string
+		for i, cell := range cells {
+			parts = append(parts, cell+strings.Repeat(" ", widths[i]-lipgloss.Width(cell)))
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("  " + headerStyle.Render(line(headers)) + "\n")
+	for i, row := range rows {
+		if i == cursor {
+			plain := make([]string, len(row))
+			for j, cell := range row {
+				plain[j] = ansi.Strip(cell)
+			}
+			sb.WriteString(tui.InfoStyle.Render(tui.IconPlay) + " " + selectedStyle.Render(line(plain)) + "\n")
+			continue
+		}
+		sb.WriteString("  " + line(row) + "\n")
+	}
+	return sb.String()
+}
+
+// phaseBadge colours a phase by how healthy it is.
+func phaseBadge(p string) string {
+	switch p {
+	case phase.Ready:
+		return tui.SuccessStyle.Render(p)
+	case phase.Degraded, phase.Failed:
+		return tui.ErrorStyle.Render(p)
+	case phase.Unknown, "":
+		return tui.MutedStyle.Render(tui.OrDash(p))
+	default:
+		return tui.WarningStyle.Render(p)
+	}
+}
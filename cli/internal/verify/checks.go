@@ -0,0 +1,237 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Names of the resources the checks create in the scratch namespace.
+const (
+	testExternalSecret = "hctl-verify"
+	testCertificate    = "hctl-verify"
+	echoName           = "hctl-verify-echo"
+	echoImage          = "traefik/whoami:v1.10.3"
+)
+
+// CheckAPIServer checks the vCluster API answers and reports its version.
+func CheckAPIServer(ctx context.Context, t *Target) (string, error) {
+	v, err := t.Kube.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("API server unreachable: %w", err)
+	}
+	return "Kubernetes " + v.GitVersion, nil
+}
+
+// CheckClusterSecretStore checks a Ready ClusterSecretStore was synced in
+// from the host.
+func CheckClusterSecretStore(ctx context.Context, t *Target) (string, error) {
+	name, err := t.readyClusterObject(ctx, kube.ClusterSecretStoreGVR)
+	if err != nil {
+		return "", err
+	}
+	return name + " ready", nil
+}
+
+// CheckClusterIssuer checks a Ready ClusterIssuer was synced in from the host.
+func CheckClusterIssuer(ctx context.Context, t *Target) (string, error) {
+	name, err := t.readyClusterObject(ctx, kube.ClusterIssuerGVR)
+	if err != nil {
+		return "", err
+	}
+	return name + " ready", nil
+}
+
+// CheckExternalSecret creates an ExternalSecret reading one field of the
+// vCluster's own 1Password item and waits for it to sync.
+func CheckExternalSecret(ctx context.Context, t *Target) (string, error) {
+	store, err := t.readyClusterObject(ctx, kube.ClusterSecretStoreGVR)
+	if err != nil {
+		return "", err
+	}
+	ns, err := t.scratch(ctx)
+	if err != nil {
+		return "", err
+	}
+	es := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "external-secrets.io/v1beta1",
+		"kind":       "ExternalSecret",
+		"metadata":   map[string]interface{}{"name": testExternalSecret, "namespace": ns},
+		"spec": map[string]interface{}{
+			"refreshInterval": "1h",
+			"secretStoreRef":  map[string]interface{}{"name": store, "kind": "ClusterSecretStore"},
+			"target":          map[string]interface{}{"name": testExternalSecret},
+			"data": []interface{}{
+				map[string]interface{}{
+					"secretKey": "value",
+					"remoteRef": map[string]interface{}{"key": t.OnePasswordItem, "property": "argocd-name"},
+				},
+			},
+		},
+	}}
+	if _, err := t.Dynamic.Resource(kube.ExternalSecretGVR).Namespace(ns).Create(ctx, es, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating ExternalSecret: %w", err)
+	}
+	if err := t.waitReady(ctx, kube.ExternalSecretGVR, ns, testExternalSecret); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("synced %s via %s", t.OnePasswordItem, store), nil
+}
+
+// CheckCertificate requests a Certificate for the vCluster hostname from a
+// Ready ClusterIssuer and waits for it to be issued.
+func CheckCertificate(ctx context.Context, t *Target) (string, error) {
+	if t.Hostname == "" {
+		return "", fmt.Errorf("vCluster has no hostname to request a certificate for")
+	}
+	issuer, err := t.readyClusterObject(ctx, kube.ClusterIssuerGVR)
+	if err != nil {
+		return "", err
+	}
+	ns, err := t.scratch(ctx)
+	if err != nil {
+		return "", err
+	}
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": testCertificate, "namespace": ns},
+		"spec": map[string]interface{}{
+			"secretName": testCertificate + "-tls",
+			"dnsNames":   []interface{}{t.Hostname},
+			"issuerRef":  map[string]interface{}{"name": issuer, "kind": "ClusterIssuer"},
+		},
+	}}
+	if _, err := t.Dynamic.Resource(kube.CertificateGVR).Namespace(ns).Create(ctx, cert, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating Certificate: %w", err)
+	}
+	if err := t.waitReady(ctx, kube.CertificateGVR, ns, testCertificate); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("issued for %s by %s", t.Hostname, issuer), nil
+}
+
+// CheckDNS checks the vCluster hostname resolves to its VIP, or to both
+// VIPs of a dual-stack vCluster.
+func CheckDNS(ctx context.Context, t *Target) (string, error) {
+	if t.Hostname == "" {
+		return "", fmt.Errorf("vCluster has no hostname")
+	}
+	addrs, err := t.Resolver.LookupHost(ctx, t.Hostname)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", t.Hostname, err)
+	}
+	if t.VIP == "" {
+		return fmt.Sprintf("%s → %s (no VIP to compare)", t.Hostname, strings.Join(addrs, ", ")), nil
+	}
+	for _, vip := range strings.Split(t.VIP, ",") {
+		if !resolvesTo(addrs, strings.TrimSpace(vip)) {
+			return "", fmt.Errorf("%s resolves to %s, want %s", t.Hostname, strings.Join(addrs, ", "), t.VIP)
+		}
+	}
+	return fmt.Sprintf("%s → %s", t.Hostname, t.VIP), nil
+}
+
+// resolvesTo reports whether addrs contains vip, comparing IPv6 addresses
+// by value rather than spelling.
+func resolvesTo(addrs []string, vip string) bool {
+	want, err := netip.ParseAddr(vip)
+	for _, a := range addrs {
+		if a == vip {
+			return true
+		}
+		if got, perr := netip.ParseAddr(a); err == nil && perr == nil && got.Unmap() == want.Unmap() {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEchoWorkload deploys a small echo server, waits for it to become
+// available and requests it through the API server's service proxy.
+func CheckEchoWorkload(ctx context.Context, t *Target) (string, error) {
+	ns, err := t.scratch(ctx)
+	if err != nil {
+		return "", err
+	}
+	labels := map[string]string{"app.kubernetes.io/name": echoName}
+	replicas := int32(1)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: echoName, Namespace: ns, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  "echo",
+					Image: echoImage,
+					Ports: []corev1.ContainerPort{{ContainerPort: 80}},
+				}}},
+			},
+		},
+	}
+	if _, err := t.Kube.AppsV1().Deployments(ns).Create(ctx, deploy, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating Deployment: %w", err)
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: echoName, Namespace: ns, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt32(80)}},
+		},
+	}
+	if _, err := t.Kube.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating Service: %w", err)
+	}
+
+	err = t.waitFor(ctx, "deployment/"+echoName, func(ctx context.Context) (bool, string, error) {
+		d, err := t.Kube.AppsV1().Deployments(ns).Get(ctx, echoName, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		return d.Status.AvailableReplicas > 0, fmt.Sprintf("%d/%d available", d.Status.AvailableReplicas, replicas), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := t.Kube.CoreV1().Services(ns).ProxyGet("http", echoName, "80", "/", nil).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("requesting echo service: %w", err)
+	}
+	if !strings.Contains(string(body), "Hostname:") {
+		return "", fmt.Errorf("unexpected echo response: %.80q", body)
+	}
+	return "served through service " + echoName, nil
+}
+
+// readyClusterObject returns the name of the first cluster-scoped object of
+// gvr whose Ready condition is True.
+func (t *Target) readyClusterObject(ctx context.Context, gvr schema.GroupVersionResource) (string, error) {
+	list, err := t.Dynamic.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("listing %s: %w", gvr.Resource, err)
+	}
+	if len(list.Items) == 0 {
+		return "", fmt.Errorf("no %s synced into the vCluster", gvr.Resource)
+	}
+	var notReady []string
+	for _, item := range list.Items {
+		ok, msg := readyCondition(item.Object)
+		if ok {
+			return item.GetName(), nil
+		}
+		notReady = append(notReady, fmt.Sprintf("%s (%s)", item.GetName(), msg))
+	}
+	return "", fmt.Errorf("no ready %s: %s", gvr.Resource, strings.Join(notReady, ", "))
+}
@@ -0,0 +1,221 @@
+// Package verify runs smoke checks against a provisioned vCluster: the
+// paths that have to work before workloads can land on it. Checks are plain
+// functions over a Target, so new ones can be added to the suite without
+// touching the runner.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Default timings for waiting on resources created by the checks.
+const (
+	DefaultWait = 2 * time.Minute
+	DefaultPoll = 2 * time.Second
+)
+
+// Resolver looks up host addresses. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Target is the vCluster under test, reached through its own kubeconfig.
+type Target struct {
+	Name     string
+	Hostname string
+	VIP      string
+	// OnePasswordItem is read by the ExternalSecret check. The vCluster's
+	// kubeconfig item always exists once it is registered.
+	OnePasswordItem string
+
+	Kube     kubernetes.Interface
+	Dynamic  dynamic.Interface
+	Resolver Resolver
+
+	// Namespace is the scratch namespace for test resources. It is created
+	// on first use and deleted when the suite finishes.
+	Namespace string
+	// Wait bounds how long a check waits for a resource to become ready;
+	// Poll is the interval between looks.
+	Wait time.Duration
+	Poll time.Duration
+
+	scratchCreated bool
+}
+
+// Check is one smoke test. Run returns a short detail on success.
+type Check struct {
+	Name string
+	// Full marks checks that only run with --full.
+	Full bool
+	Run  func(ctx context.Context, t *Target) (string, error)
+}
+
+// Result statuses.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Check      string `json:"check" yaml:"check"`
+	Status     string `json:"status" yaml:"status"`
+	Detail     string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs" yaml:"durationMs"`
+}
+
+// Duration returns how long the check took.
+func (r Result) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Checks returns the default suite, in the order it runs.
+func Checks() []Check {
+	return []Check{
+		{Name: "API server", Run: CheckAPIServer},
+		{Name: "ClusterSecretStore", Run: CheckClusterSecretStore},
+		{Name: "ClusterIssuer", Run: CheckClusterIssuer},
+		{Name: "ExternalSecret", Run: CheckExternalSecret},
+		{Name: "Certificate", Run: CheckCertificate},
+		{Name: "DNS", Run: CheckDNS},
+		{Name: "Echo workload", Full: true, Run: CheckEchoWorkload},
+	}
+}
+
+// Run runs checks in order and then deletes the scratch namespace if one
+// was created, reporting the cleanup as a final result. Full checks are
+// skipped unless full is set.
+func Run(ctx context.Context, t *Target, checks []Check, full bool) []Result {
+	if t.Namespace == "" {
+		t.Namespace = fmt.Sprintf("hctl-verify-%d", time.Now().Unix())
+	}
+
+	var results []Result
+	for _, c := range checks {
+		if c.Full && !full {
+			results = append(results, Result{Check: c.Name, Status: StatusSkip, Detail: "needs --full"})
+			continue
+		}
+		start := time.Now()
+		detail, err := c.Run(ctx, t)
+		r := Result{Check: c.Name, Status: StatusPass, Detail: detail, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			r.Status, r.Detail = StatusFail, err.Error()
+		}
+		results = append(results, r)
+	}
+
+	if t.scratchCreated {
+		start := time.Now()
+		r := Result{Check: "Cleanup", Status: StatusPass, Detail: "deleted namespace " + t.Namespace}
+		err := t.Kube.CoreV1().Namespaces().Delete(ctx, t.Namespace, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			r.Status, r.Detail = StatusFail, fmt.Sprintf("deleting namespace %s: %v", t.Namespace, err)
+		}
+		r.DurationMs = time.Since(start).Milliseconds()
+		results = append(results, r)
+	}
+	return results
+}
+
+// Failed returns the number of failed results.
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// scratch returns the scratch namespace, creating it on first use.
+func (t *Target) scratch(ctx context.Context) (string, error) {
+	if t.scratchCreated {
+		return t.Namespace, nil
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   t.Namespace,
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "hctl"},
+	}}
+	if _, err := t.Kube.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("creating scratch namespace %s: %w", t.Namespace, err)
+	}
+	t.scratchCreated = true
+	return t.Namespace, nil
+}
+
+// waitFor polls ready until it reports true or t.Wait elapses. The last
+// message or error from ready is included in the timeout error.
+func (t *Target) waitFor(ctx context.Context, what string, ready func(context.Context) (bool, string, error)) error {
+	wait, poll := t.Wait, t.Poll
+	if wait <= 0 {
+		wait = DefaultWait
+	}
+	if poll <= 0 {
+		poll = DefaultPoll
+	}
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	last := ""
+	for {
+		ok, msg, err := ready(ctx)
+		switch {
+		case err != nil:
+			last = err.Error()
+		case ok:
+			return nil
+		case msg != "":
+			last = msg
+		}
+		select {
+		case <-ctx.Done():
+			if last == "" {
+				return fmt.Errorf("%s not ready after %s", what, wait)
+			}
+			return fmt.Errorf("%s not ready after %s: %s", what, wait, last)
+		case <-time.After(poll):
+		}
+	}
+}
+
+// readyCondition reports an object's Ready condition, with the reason and
+// message when it is not True.
+func readyCondition(obj map[string]interface{}) (bool, string) {
+	c, ok := platform.FindCondition(platform.StatusConditions(obj), platform.ConditionReady)
+	if !ok {
+		return false, "no Ready condition"
+	}
+	if c.Status == "True" {
+		return true, ""
+	}
+	if c.Reason != "" {
+		return false, c.Reason + ": " + c.Message
+	}
+	return false, c.Message
+}
+
+// waitReady waits for a namespaced custom resource's Ready condition.
+func (t *Target) waitReady(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) error {
+	return t.waitFor(ctx, gvr.Resource+"/"+name, func(ctx context.Context) (bool, string, error) {
+		obj, err := t.Dynamic.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		ok, msg := readyCondition(obj.Object)
+		return ok, msg, nil
+	})
+}
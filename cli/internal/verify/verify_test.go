@@ -0,0 +1,312 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dfake "k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+type fakeResponse string
+
+func (r fakeResponse) DoRaw(context.Context) ([]byte, error) { return []byte(r), nil }
+func (r fakeResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r))), nil
+}
+
+func clusterObject(gvr schema.GroupVersionResource, kind, name, ready string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name},
+	}}
+	setReady(obj, ready)
+	return obj
+}
+
+func setReady(obj *unstructured.Unstructured, status string) {
+	_ = unstructured.SetNestedSlice(obj.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": status, "reason": "Test", "message": "set by test"},
+	}, "status", "conditions")
+}
+
+// readyOnCreate makes the fake dynamic client mark created objects of a
+// resource Ready, standing in for the controller that would.
+func readyOnCreate(client *dfake.FakeDynamicClient, resource string) {
+	client.PrependReactor("create", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		setReady(action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured), "True")
+		return false, nil, nil
+	})
+}
+
+// newTarget returns a Target backed by fake clients in which every check
+// passes: the synced store and issuer are Ready, created ExternalSecrets and
+// Certificates become Ready, and the echo Deployment becomes available.
+func newTarget(objects ...runtime.Object) *Target {
+	if objects == nil {
+		objects = []runtime.Object{
+			clusterObject(kube.ClusterSecretStoreGVR, "ClusterSecretStore", "onepassword-store", "True"),
+			clusterObject(kube.ClusterIssuerGVR, "ClusterIssuer", "letsencrypt", "True"),
+		}
+	}
+	dyn := dfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		kube.ClusterSecretStoreGVR: "ClusterSecretStoreList",
+		kube.ClusterIssuerGVR:      "ClusterIssuerList",
+		kube.ExternalSecretGVR:     "ExternalSecretList",
+		kube.CertificateGVR:        "CertificateList",
+	}, objects...)
+	readyOnCreate(dyn, "externalsecrets")
+	readyOnCreate(dyn, "certificates")
+
+	cs := kfake.NewSimpleClientset()
+	cs.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.34.1"}
+	cs.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		action.(k8stesting.CreateAction).GetObject().(*appsv1.Deployment).Status.AvailableReplicas = 1
+		return false, nil, nil
+	})
+	cs.AddProxyReactor("services", func(k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		return true, fakeResponse("Hostname: hctl-verify-echo-abc\nIP: 10.0.0.12\n"), nil
+	})
+
+	return &Target{
+		Name:            "media",
+		Hostname:        "media.cluster.integratn.tech",
+		VIP:             "10.0.4.210",
+		OnePasswordItem: "vcluster-media-kubeconfig",
+		Kube:            cs,
+		Dynamic:         dyn,
+		Resolver:        fakeResolver{"media.cluster.integratn.tech": {"10.0.4.210"}},
+		Namespace:       "hctl-verify-test",
+		Wait:            50 * time.Millisecond,
+		Poll:            5 * time.Millisecond,
+	}
+}
+
+func TestRunAllPass(t *testing.T) {
+	target := newTarget()
+	results := Run(context.Background(), target, Checks(), true)
+
+	want := []string{"API server", "ClusterSecretStore", "ClusterIssuer", "ExternalSecret", "Certificate", "DNS", "Echo workload", "Cleanup"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, r := range results {
+		if r.Check != want[i] || r.Status != StatusPass {
+			t.Errorf("result %d = %+v, want %s pass", i, r, want[i])
+		}
+	}
+	if n := Failed(results); n != 0 {
+		t.Errorf("Failed() = %d, want 0", n)
+	}
+	if _, err := target.Kube.CoreV1().Namespaces().Get(context.Background(), target.Namespace, metav1.GetOptions{}); err == nil {
+		t.Error("scratch namespace was not deleted")
+	}
+}
+
+func TestRunSkipsFullChecks(t *testing.T) {
+	results := Run(context.Background(), newTarget(), Checks(), false)
+	var echo Result
+	for _, r := range results {
+		if r.Check == "Echo workload" {
+			echo = r
+		}
+	}
+	if echo.Status != StatusSkip {
+		t.Errorf("echo workload = %+v, want skipped without --full", echo)
+	}
+}
+
+func TestRunNoScratchNoCleanup(t *testing.T) {
+	checks := []Check{{Name: "API server", Run: CheckAPIServer}}
+	results := Run(context.Background(), newTarget(), checks, false)
+	if len(results) != 1 {
+		t.Errorf("got %+v, want only the API server result", results)
+	}
+}
+
+func TestCheckAPIServer(t *testing.T) {
+	target := newTarget()
+	detail, err := CheckAPIServer(context.Background(), target)
+	if err != nil || detail != "Kubernetes v1.34.1" {
+		t.Errorf("CheckAPIServer() = %q, %v", detail, err)
+	}
+
+	target.Kube.(*kfake.Clientset).PrependReactor("get", "version", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	if _, err := CheckAPIServer(context.Background(), target); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("CheckAPIServer() error = %v, want connection refused", err)
+	}
+}
+
+func TestCheckClusterSecretStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    string
+		wantErr string
+	}{
+		{
+			name: "ready",
+			objects: []runtime.Object{
+				clusterObject(kube.ClusterSecretStoreGVR, "ClusterSecretStore", "broken", "False"),
+				clusterObject(kube.ClusterSecretStoreGVR, "ClusterSecretStore", "onepassword-store", "True"),
+			},
+			want: "onepassword-store ready",
+		},
+		{
+			name: "not ready",
+			objects: []runtime.Object{
+				clusterObject(kube.ClusterSecretStoreGVR, "ClusterSecretStore", "onepassword-store", "False"),
+			},
+			wantErr: "no ready clustersecretstores: onepassword-store (Test: set by test)",
+		},
+		{
+			name:    "not synced",
+			objects: []runtime.Object{clusterObject(kube.ClusterIssuerGVR, "ClusterIssuer", "letsencrypt", "True")},
+			wantErr: "no clustersecretstores synced into the vCluster",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckClusterSecretStore(context.Background(), newTarget(tt.objects...))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CheckClusterSecretStore() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckClusterIssuer(t *testing.T) {
+	got, err := CheckClusterIssuer(context.Background(), newTarget())
+	if err != nil || got != "letsencrypt ready" {
+		t.Errorf("CheckClusterIssuer() = %q, %v", got, err)
+	}
+}
+
+func TestCheckExternalSecret(t *testing.T) {
+	target := newTarget()
+	ctx := context.Background()
+	if _, err := CheckExternalSecret(ctx, target); err != nil {
+		t.Fatalf("CheckExternalSecret() error = %v", err)
+	}
+	es, err := target.Dynamic.Resource(kube.ExternalSecretGVR).Namespace(target.Namespace).Get(ctx, testExternalSecret, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, _, _ := unstructured.NestedString(es.Object, "spec", "secretStoreRef", "name")
+	data, _, _ := unstructured.NestedSlice(es.Object, "spec", "data")
+	key, _, _ := unstructured.NestedString(data[0].(map[string]interface{}), "remoteRef", "key")
+	if store != "onepassword-store" || key != "vcluster-media-kubeconfig" {
+		t.Errorf("ExternalSecret store = %q, key = %q", store, key)
+	}
+}
+
+func TestCheckExternalSecretTimeout(t *testing.T) {
+	target := newTarget()
+	// Drop the reactors standing in for the ESO and cert-manager controllers.
+	dyn := target.Dynamic.(*dfake.FakeDynamicClient)
+	dyn.ReactionChain = dyn.ReactionChain[2:]
+
+	_, err := CheckExternalSecret(context.Background(), target)
+	if err == nil || !strings.Contains(err.Error(), "externalsecrets/hctl-verify not ready after") {
+		t.Errorf("CheckExternalSecret() error = %v, want timeout", err)
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	target := newTarget()
+	ctx := context.Background()
+	got, err := CheckCertificate(ctx, target)
+	if err != nil || got != "issued for media.cluster.integratn.tech by letsencrypt" {
+		t.Fatalf("CheckCertificate() = %q, %v", got, err)
+	}
+	cert, err := target.Dynamic.Resource(kube.CertificateGVR).Namespace(target.Namespace).Get(ctx, testCertificate, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	if len(names) != 1 || names[0] != target.Hostname {
+		t.Errorf("dnsNames = %v", names)
+	}
+
+	target.Hostname = ""
+	if _, err := CheckCertificate(ctx, target); err == nil {
+		t.Error("CheckCertificate() without hostname should fail")
+	}
+}
+
+func TestCheckDNS(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver fakeResolver
+		vip      string
+		wantErr  bool
+	}{
+		{name: "matches VIP", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.210"}}, vip: "10.0.4.210"},
+		{name: "one of several", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.1", "10.0.4.210"}}, vip: "10.0.4.210"},
+		{name: "wrong address", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.1"}}, vip: "10.0.4.210", wantErr: true},
+		{name: "unresolvable", resolver: fakeResolver{}, vip: "10.0.4.210", wantErr: true},
+		{name: "no VIP", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.1"}}},
+		{name: "IPv6 spelled differently", resolver: fakeResolver{"media.cluster.integratn.tech": {"fd00:4:0:0::c8"}}, vip: "fd00:4::c8"},
+		{name: "dual-stack", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.200", "fd00:4::c8"}}, vip: "10.0.4.200,fd00:4::c8"},
+		{name: "dual-stack missing AAAA", resolver: fakeResolver{"media.cluster.integratn.tech": {"10.0.4.200"}}, vip: "10.0.4.200,fd00:4::c8", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTarget()
+			target.Resolver, target.VIP = tt.resolver, tt.vip
+			_, err := CheckDNS(context.Background(), target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckDNS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEchoWorkload(t *testing.T) {
+	target := newTarget()
+	if _, err := CheckEchoWorkload(context.Background(), target); err != nil {
+		t.Fatalf("CheckEchoWorkload() error = %v", err)
+	}
+
+	target = newTarget()
+	cs := target.Kube.(*kfake.Clientset)
+	cs.PrependProxyReactor("services", func(k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+		return true, fakeResponse("404 page not found"), nil
+	})
+	if _, err := CheckEchoWorkload(context.Background(), target); err == nil {
+		t.Error("CheckEchoWorkload() should fail on an unexpected response")
+	}
+}
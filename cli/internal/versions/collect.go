@@ -0,0 +1,381 @@
+package versions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/internal/addon"
+	"github.com/jamesatintegratnio/hctl/internal/kube"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/internal/platform"
+	"github.com/jamesatintegratnio/hctl/internal/registry"
+	"github.com/jamesatintegratnio/hctl/internal/repopath"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The orchestrator's defaults for a vcluster request's
+// spec.argocdApplication.
+const (
+	DefaultVClusterRepository = "https://charts.loft.sh"
+	DefaultVClusterChart      = "vcluster"
+)
+
+// FromRepo returns the components the repo records versions for: every
+// addons.yaml entry with a chart version, each vcluster request in
+// platform/vclusters, and the pipeline images of each promise.
+func FromRepo(repoPath string) ([]Component, error) {
+	charts, err := addonCharts(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	vclusters, err := vclusterCharts(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	promises, err := promiseImages(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(charts, vclusters...), promises...), nil
+}
+
+func addonCharts(repoPath string) ([]Component, error) {
+	layers, err := addon.DiscoverLayers(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var out []Component
+	for _, l := range layers {
+		entries, err := addon.ReadEntries(l.AddonsFile(repoPath))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l, err)
+		}
+		for name, e := range entries {
+			version, repo := str(e["defaultVersion"]), str(e["chartRepository"])
+			if version == "" || repo == "" {
+				continue
+			}
+			chart := str(e["chartName"])
+			if chart == "" {
+				chart = name
+			}
+			out = append(out, Component{
+				Name: name, Kind: KindChart, Source: l.String(),
+				Repository: repo, Chart: chart, Recorded: version,
+			})
+		}
+	}
+	return out, nil
+}
+
+func vclusterCharts(repoPath string) ([]Component, error) {
+	files, err := filepath.Glob(filepath.Join(repopath.Abs(repoPath, layout.VClustersDir), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Component
+	for _, f := range files {
+		var doc map[string]interface{}
+		if err := readYAML(f, &doc); err != nil {
+			return nil, err
+		}
+		kind, _, _ := platform.UnstructuredNestedString(doc, "kind")
+		name, _, _ := platform.UnstructuredNestedString(doc, "metadata", "name")
+		if kind != "VClusterOrchestratorV2" || name == "" {
+			continue
+		}
+		c := Component{
+			Name: name, Kind: KindVCluster, Source: rel(repoPath, f),
+			Repository: DefaultVClusterRepository, Chart: DefaultVClusterChart, Recorded: platform.DefaultChartVersion,
+		}
+		if v, _, _ := platform.UnstructuredNestedString(doc, "spec", "argocdApplication", "repoURL"); v != "" {
+			c.Repository = v
+		}
+		if v, _, _ := platform.UnstructuredNestedString(doc, "spec", "argocdApplication", "chart"); v != "" {
+			c.Chart = v
+		}
+		if v, _, _ := platform.UnstructuredNestedString(doc, "spec", "argocdApplication", "targetRevision"); v != "" {
+			c.Recorded = v
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func promiseImages(repoPath string) ([]Component, error) {
+	files, err := filepath.Glob(filepath.Join(repoPath, "promises", "*", "promise.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Component
+	for _, f := range files {
+		var doc map[string]interface{}
+		if err := readYAML(f, &doc); err != nil {
+			return nil, err
+		}
+		name, _, _ := platform.UnstructuredNestedString(doc, "metadata", "name")
+		for _, image := range pipelineImages(doc) {
+			ref, err := registry.ParseReference(image)
+			if err != nil {
+				continue
+			}
+			out = append(out, Component{
+				Name: name, Kind: KindPromise, Source: rel(repoPath, f),
+				Repository: ref.Name, Recorded: ref.Tag,
+			})
+		}
+	}
+	return out, nil
+}
+
+// pipelineImages returns the distinct container images of a promise's
+// workflows, in order.
+func pipelineImages(promise map[string]interface{}) []string {
+	spec, _ := promise["spec"].(map[string]interface{})
+	var images []string
+	seen := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if s, ok := v[k].(string); ok && k == "image" && !seen[s] {
+					seen[s] = true
+					images = append(images, s)
+				}
+				walk(v[k])
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(spec["workflows"])
+	return images
+}
+
+// Live is what the cluster runs.
+type Live struct {
+	// Apps are the ArgoCD Applications.
+	Apps []unstructured.Unstructured
+	// VClusters are the vcluster requests, whose status records the chart
+	// version the orchestrator last rendered.
+	VClusters []unstructured.Unstructured
+	Promises  []unstructured.Unstructured
+	// AppImages maps an addon Application to the image tag of the first
+	// Deployment it manages, by name.
+	AppImages map[string]string
+}
+
+// CollectLive reads the Applications, vcluster requests and promises, and
+// the images of the Deployments that addon Applications manage on this
+// cluster. A part that cannot be read is left empty and returned as an
+// error; the others are still read.
+func CollectLive(ctx context.Context, client *kube.Client, argoNamespace, platformNamespace string) (*Live, []error) {
+	live := &Live{AppImages: map[string]string{}}
+	var errs []error
+	var err error
+	if live.Apps, err = client.ListArgoApps(ctx, argoNamespace); err != nil {
+		errs = append(errs, fmt.Errorf("listing ArgoCD applications: %w", err))
+	}
+	if live.VClusters, err = client.ListVClusters(ctx, platformNamespace); err != nil {
+		errs = append(errs, fmt.Errorf("listing vclusters: %w", err))
+	}
+	if live.Promises, err = client.ListPromises(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("listing promises: %w", err))
+	}
+
+	deployments := map[string][]kube.DeploymentInfo{}
+	for _, app := range live.Apps {
+		if app.GetLabels()["addon"] != "true" || !inCluster(app) {
+			continue
+		}
+		ns, _, _ := platform.UnstructuredNestedString(app.Object, "spec", "destination", "namespace")
+		if _, ok := deployments[ns]; !ok {
+			// Unreadable namespaces just leave the app version unknown.
+			deployments[ns], _ = client.ListDeployments(ctx, ns)
+		}
+		for _, d := range deployments[ns] {
+			if d.ArgoApp != app.GetName() || len(d.Images) == 0 {
+				continue
+			}
+			if ref, err := registry.ParseReference(d.Images[0]); err == nil && ref.Digest == "" {
+				live.AppImages[app.GetName()] = ref.Tag
+			}
+			break
+		}
+	}
+	return live, errs
+}
+
+// inCluster reports whether app deploys to the cluster ArgoCD runs in.
+func inCluster(app unstructured.Unstructured) bool {
+	server, _, _ := platform.UnstructuredNestedString(app.Object, "spec", "destination", "server")
+	name, _, _ := platform.UnstructuredNestedString(app.Object, "spec", "destination", "name")
+	return server == "https://kubernetes.default.svc" || name == "in-cluster"
+}
+
+// Merge fills in the deployed and latest versions of components.
+//
+// An addon Application (labeled addon, addonName, clusterName and
+// environment by the ApplicationSet) counts towards the entry for its
+// addon at the most specific layer that applies to it: its cluster's,
+// then its environment's, then any cluster role's.
+func Merge(components []Component, live *Live, latest *Latest) []Component {
+	out := append([]Component(nil), components...)
+	index := func(kind, name string) []int {
+		var idx []int
+		for i, c := range out {
+			if c.Kind == kind && c.Name == name {
+				idx = append(idx, i)
+			}
+		}
+		return idx
+	}
+
+	if live != nil {
+		for _, app := range live.Apps {
+			labels := app.GetLabels()
+			if labels["addon"] != "true" {
+				continue
+			}
+			i := appEntry(out, index(KindChart, labels["addonName"]), labels["clusterName"], labels["environment"])
+			if i < 0 {
+				continue
+			}
+			revision := chartRevision(app)
+			if revision == "" {
+				continue
+			}
+			c := &out[i]
+			if c.Deployed == "" || Less(revision, c.Deployed) {
+				c.Deployed = revision
+			}
+			if tag := live.AppImages[app.GetName()]; tag != "" && (c.AppVersion == "" || Less(tag, c.AppVersion)) {
+				c.AppVersion = tag
+			}
+			c.Clusters = appendUnique(c.Clusters, labels["clusterName"])
+		}
+		for _, vc := range live.VClusters {
+			_, chart := platform.RecordedVersions(&vc)
+			for _, i := range index(KindVCluster, vc.GetName()) {
+				out[i].Deployed = chart
+			}
+		}
+		for _, p := range live.Promises {
+			for _, image := range pipelineImages(p.Object) {
+				ref, err := registry.ParseReference(image)
+				if err != nil {
+					continue
+				}
+				for _, i := range index(KindPromise, p.GetName()) {
+					if out[i].Repository == ref.Name {
+						out[i].Deployed = ref.Tag
+					}
+				}
+			}
+		}
+	}
+
+	if latest != nil {
+		for i := range out {
+			c := &out[i]
+			switch c.Kind {
+			case KindChart, KindVCluster:
+				if rel, ok := latest.Charts[ChartKey(c.Repository, c.Chart)]; ok {
+					c.Latest, c.LatestAppVersion = rel.Version, rel.AppVersion
+				}
+			case KindPromise:
+				c.Latest = latest.PromiseRelease
+			}
+		}
+	}
+	return out
+}
+
+// appEntry picks the chart entry an addon Application is rendered from.
+func appEntry(components []Component, idx []int, cluster, environment string) int {
+	for _, want := range []string{
+		addon.Layer{Kind: addon.LayerCluster, Name: cluster}.String(),
+		addon.Layer{Kind: addon.LayerEnvironment, Name: environment}.String(),
+	} {
+		for _, i := range idx {
+			if components[i].Source == want {
+				return i
+			}
+		}
+	}
+	for _, i := range idx {
+		if strings.HasPrefix(components[i].Source, addon.LayerClusterRole+"/") {
+			return i
+		}
+	}
+	return -1
+}
+
+// chartRevision returns the targetRevision of app's Helm chart source.
+func chartRevision(app unstructured.Unstructured) string {
+	sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+	if source, ok, _ := unstructured.NestedMap(app.Object, "spec", "source"); ok {
+		sources = append(sources, source)
+	}
+	for _, s := range sources {
+		m, ok := s.(map[string]interface{})
+		if ok && str(m["chart"]) != "" {
+			return str(m["targetRevision"])
+		}
+	}
+	return ""
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func readYAML(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+func rel(repoPath, path string) string {
+	if r, err := filepath.Rel(repoPath, path); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return path
+}
+
+// str returns v as a string; YAML may read versions like 1.10 as numbers.
+func str(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,145 @@
+package versions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// addonApp is an ApplicationSet-rendered addon Application.
+func addonApp(name, addonName, cluster, env, revision string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				"addon": "true", "addonName": addonName, "clusterName": cluster, "environment": env,
+			},
+		},
+		"spec": map[string]interface{}{
+			"sources": []interface{}{
+				map[string]interface{}{"repoURL": "https://github.com/org/repo", "targetRevision": "main", "ref": "values"},
+				map[string]interface{}{"repoURL": "https://charts.jetstack.io", "chart": addonName, "targetRevision": revision},
+			},
+		},
+	}}
+}
+
+func TestMerge(t *testing.T) {
+	components, err := FromRepo("testdata/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := &Live{
+		Apps: []unstructured.Unstructured{
+			// Two production clusters on different versions: the lowest counts.
+			addonApp("cert-manager-the-cluster", "cert-manager", "the-cluster", "production", "v1.16.2"),
+			addonApp("cert-manager-other", "cert-manager", "other", "production", "v1.14.0"),
+			// vcluster-media has its own entry.
+			addonApp("cert-manager-vcluster-media", "cert-manager", "vcluster-media", "production", "v1.15.0"),
+		},
+		VClusters: []unstructured.Unstructured{{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "vcluster-media"},
+			"status":   map[string]interface{}{"chartVersion": "0.30.4", "k8sVersion": "v1.34.3"},
+		}}},
+		Promises: []unstructured.Unstructured{{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "external-secret"},
+			"spec": map[string]interface{}{"workflows": map[string]interface{}{"resource": map[string]interface{}{
+				"configure": []interface{}{map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"image": "ghcr.io/jamesatintegratnio/external-secret-configure:v0.3.1"},
+				}}}},
+			}}},
+		}}},
+		AppImages: map[string]string{"cert-manager-the-cluster": "v1.16.2", "cert-manager-other": "v1.14.0"},
+	}
+	latest := &Latest{
+		Charts: map[string]ChartRelease{
+			ChartKey("https://charts.jetstack.io", "cert-manager"): {Version: "v1.16.3", AppVersion: "v1.16.3"},
+			ChartKey("https://charts.loft.sh", "vcluster"):         {Version: "0.31.1", AppVersion: "0.31.1"},
+			// The app version is ahead of the chart version: never compared.
+			ChartKey("https://kubernetes-sigs.github.io/metrics-server", "metrics-server"): {Version: "3.12.2", AppVersion: "0.7.2"},
+		},
+		PromiseRelease: "v0.4.0",
+	}
+	report := NewReport(Merge(components, live, latest), []string{"karpenter"}, nil)
+
+	type row struct{ deployed, app, latest, status, delta string }
+	got := map[string]row{}
+	for _, c := range report.Components {
+		got[c.Name+" "+c.Source+" "+c.Repository] = row{c.Deployed, c.AppVersion, c.Latest, c.Status, c.Delta}
+	}
+	want := map[string]row{
+		"cert-manager environment/production https://charts.jetstack.io": {"v1.14.0", "v1.14.0", "v1.16.3", StatusOutdated, "minor +2"},
+		"cert-manager cluster/vcluster-media https://charts.jetstack.io": {"v1.15.0", "", "v1.16.3", StatusOutdated, "minor +1"},
+		"metrics-server environment/production https://kubernetes-sigs.github.io/metrics-server": {
+			"", "", "3.12.2", StatusOutdated, "patch +2"},
+		"vcluster-dev platform/vclusters/vcluster-dev.yaml https://charts.loft.sh":     {"", "", "0.31.1", StatusOutdated, "minor +1"},
+		"vcluster-media platform/vclusters/vcluster-media.yaml https://charts.loft.sh": {"0.30.4", "", "0.31.1", StatusOutdated, "minor +1"},
+		"external-secret promises/external-secret/promise.yaml ghcr.io/jamesatintegratnio/external-secret-configure": {
+			"v0.3.1", "", "v0.4.0", StatusOutdated, "minor +1"},
+		"external-secret promises/external-secret/promise.yaml ghcr.io/jamesatintegratnio/notify": {
+			"", "", "v0.4.0", StatusUnpinned, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged =\n%v\nwant\n%v", got, want)
+	}
+	if report.Outdated != 6 {
+		t.Errorf("Outdated = %d, want 6", report.Outdated)
+	}
+	for _, c := range report.Components {
+		if c.Name == "cert-manager" && c.Source == "environment/production" &&
+			!reflect.DeepEqual(c.Clusters, []string{"the-cluster", "other"}) {
+			t.Errorf("cert-manager clusters = %v", c.Clusters)
+		}
+	}
+}
+
+func TestFetchLatest(t *testing.T) {
+	index, err := os.ReadFile("testdata/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			_, _ = w.Write(index)
+		case "/repos/org/platform/releases/latest":
+			_, _ = w.Write([]byte(`{"tag_name": "v0.5.0", "name": "Platform v0.5.0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer func(orig string) { githubAPI = orig }(githubAPI)
+	githubAPI = srv.URL
+
+	components := []Component{
+		{Name: "cert-manager", Kind: KindChart, Repository: srv.URL + "/charts/", Chart: "cert-manager"},
+		{Name: "cert-manager", Kind: KindChart, Repository: srv.URL + "/charts", Chart: "cert-manager"},
+		{Name: "gone", Kind: KindChart, Repository: srv.URL + "/charts", Chart: "gone"},
+		{Name: "broken", Kind: KindChart, Repository: srv.URL + "/broken", Chart: "broken"},
+		{Name: "karpenter", Kind: KindChart, Repository: "public.ecr.aws", Chart: "karpenter/karpenter"},
+	}
+	latest, errs := FetchLatest(context.Background(), components, "org/platform")
+
+	want := &Latest{
+		Charts:         map[string]ChartRelease{ChartKey(srv.URL+"/charts", "cert-manager"): {Version: "v1.16.10", AppVersion: "v1.16.10"}},
+		PromiseRelease: "v0.5.0",
+	}
+	if !reflect.DeepEqual(latest, want) {
+		t.Errorf("FetchLatest = %+v, want %+v", latest, want)
+	}
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "chart gone not found") || !strings.Contains(errs[1].Error(), "404") {
+		t.Errorf("errors = %v, want gone not found and broken 404", errs)
+	}
+	if want := []string{"/charts/index.yaml", "/broken/index.yaml", "/repos/org/platform/releases/latest"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want each index once: %v", requests, want)
+	}
+}
@@ -0,0 +1,204 @@
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// httpClient fetches chart repository indexes and GitHub releases.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// githubAPI is the GitHub REST API base URL; tests point it at a fake.
+var githubAPI = "https://api.github.com"
+
+// ChartRelease is one version of a chart in a repository index. Version is
+// the chart's own version, which ArgoCD's targetRevision pins; AppVersion
+// is the version of the software it deploys, which image tags follow.
+type ChartRelease struct {
+	Version    string
+	AppVersion string
+}
+
+// Latest holds the upstream versions components are compared against.
+type Latest struct {
+	// Charts maps ChartKey(repository, chart) to its latest release.
+	Charts map[string]ChartRelease
+	// PromiseRelease is the latest GitHub release of the repo that builds
+	// the promise pipeline images.
+	PromiseRelease string
+}
+
+// ChartKey identifies a chart across repositories.
+func ChartKey(repository, chart string) string {
+	return strings.TrimRight(repository, "/") + " " + chart
+}
+
+// FetchLatest looks up the latest release of every chart among components
+// in its repository's index.yaml, fetching each index once, and the latest
+// GitHub release of promiseRepo ("owner/name"; empty skips it). OCI
+// repositories have no index and are skipped. Failed lookups are returned
+// as errors, and their components are left without a latest version.
+func FetchLatest(ctx context.Context, components []Component, promiseRepo string) (*Latest, []error) {
+	latest := &Latest{Charts: map[string]ChartRelease{}}
+	var errs []error
+
+	charts := map[string][]string{}
+	var repos []string
+	for _, c := range components {
+		if c.Kind != KindChart && c.Kind != KindVCluster || !hasIndex(c.Repository) {
+			continue
+		}
+		repo := strings.TrimRight(c.Repository, "/")
+		if _, ok := charts[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		charts[repo] = appendUnique(charts[repo], c.Chart)
+	}
+	for _, repo := range repos {
+		index, err := fetchIndex(ctx, repo)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, chart := range charts[repo] {
+			rel, ok := index.latest(chart)
+			if !ok {
+				errs = append(errs, fmt.Errorf("chart %s not found in %s", chart, repo))
+				continue
+			}
+			latest.Charts[ChartKey(repo, chart)] = rel
+		}
+	}
+
+	if promiseRepo != "" {
+		tag, err := LatestGitHubRelease(ctx, promiseRepo)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		latest.PromiseRelease = tag
+	}
+	return latest, errs
+}
+
+// LatestChart returns the latest release of chart in the Helm repository
+// at repo.
+func LatestChart(ctx context.Context, repo, chart string) (ChartRelease, error) {
+	if !hasIndex(repo) {
+		return ChartRelease{}, fmt.Errorf("%s is not a Helm repository with an index", repo)
+	}
+	index, err := fetchIndex(ctx, strings.TrimRight(repo, "/"))
+	if err != nil {
+		return ChartRelease{}, err
+	}
+	rel, ok := index.latest(chart)
+	if !ok {
+		return ChartRelease{}, fmt.Errorf("chart %s not found in %s", chart, repo)
+	}
+	return rel, nil
+}
+
+// hasIndex reports whether repo is an HTTP Helm repository rather than an
+// OCI registry.
+func hasIndex(repo string) bool {
+	return strings.HasPrefix(repo, "https://") || strings.HasPrefix(repo, "http://")
+}
+
+// chartIndex is a Helm repository's index.yaml.
+type chartIndex struct {
+	Entries map[string][]struct {
+		Version    string `yaml:"version"`
+		AppVersion string `yaml:"appVersion"`
+	} `yaml:"entries"`
+}
+
+// parseIndex reads a Helm repository index.
+func parseIndex(data []byte) (*chartIndex, error) {
+	var index chartIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// latest returns chart's highest release, ignoring prereleases unless it
+// has nothing else. Indexes usually list newest first, but that is not
+// guaranteed, so every entry is compared.
+func (idx *chartIndex) latest(chart string) (ChartRelease, bool) {
+	var best, bestPre *Version
+	var rel, relPre ChartRelease
+	for _, e := range idx.Entries[chart] {
+		v, ok := Parse(e.Version)
+		if !ok {
+			continue
+		}
+		if v.Prerelease == "" {
+			if best == nil || v.Compare(*best) > 0 {
+				best, rel = &v, ChartRelease{Version: e.Version, AppVersion: e.AppVersion}
+			}
+		} else if bestPre == nil || v.Compare(*bestPre) > 0 {
+			bestPre, relPre = &v, ChartRelease{Version: e.Version, AppVersion: e.AppVersion}
+		}
+	}
+	switch {
+	case best != nil:
+		return rel, true
+	case bestPre != nil:
+		return relPre, true
+	}
+	return ChartRelease{}, false
+}
+
+func fetchIndex(ctx context.Context, repo string) (*chartIndex, error) {
+	u := repo + "/index.yaml"
+	data, err := get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	index, err := parseIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", u, err)
+	}
+	return index, nil
+}
+
+// LatestGitHubRelease returns the tag of repo's latest published release.
+func LatestGitHubRelease(ctx context.Context, repo string) (string, error) {
+	data, err := get(ctx, githubAPI+"/repos/"+repo+"/releases/latest")
+	if err != nil {
+		return "", err
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return "", fmt.Errorf("parsing the latest release of %s: %w", repo, err)
+	}
+	return release.TagName, nil
+}
+
+func get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", u, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,289 @@
+// Package versions compares the versions of platform components — addon
+// charts, vcluster charts and promise pipeline images — as the repo records
+// them, as the cluster runs them, and as their upstreams last released them.
+// 'hctl versions', the Updates section of 'hctl status' and 'hctl vcluster
+// status' use it.
+package versions
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Component kinds.
+const (
+	// KindChart is an addons.yaml entry's Helm chart.
+	KindChart = "chart"
+	// KindVCluster is a vcluster request's chart.
+	KindVCluster = "vcluster"
+	// KindPromise is a promise's pipeline image.
+	KindPromise = "promise"
+)
+
+// Component statuses, comparing the deployed version (or the recorded one,
+// when nothing is deployed) with the latest.
+const (
+	StatusCurrent  = "current"
+	StatusOutdated = "outdated"
+	StatusAhead    = "ahead"
+	// StatusUnpinned is a version that is not semver, such as an image's
+	// "latest" tag, so it cannot be compared.
+	StatusUnpinned = "unpinned"
+	// StatusUnknown is a component whose latest version was not looked up
+	// or could not be found.
+	StatusUnknown = "unknown"
+)
+
+// Component is one versioned platform component.
+type Component struct {
+	Name string `json:"name" yaml:"name"`
+	Kind string `json:"kind" yaml:"kind"`
+	// Source is where the repo records the version: an addon layer such as
+	// "environment/production", or a file path.
+	Source string `json:"source" yaml:"source"`
+	// Repository is the chart repository URL, or the image repository of a
+	// promise pipeline.
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Chart      string `json:"chart,omitempty" yaml:"chart,omitempty"`
+	// Recorded is the chart version or image tag in the repo.
+	Recorded string `json:"recorded" yaml:"recorded"`
+	// Deployed is the chart version or image tag the cluster runs: for a
+	// chart deployed to several clusters, the lowest.
+	Deployed string   `json:"deployed,omitempty" yaml:"deployed,omitempty"`
+	Clusters []string `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+	// AppVersion is the image tag of the chart's main deployment. It
+	// follows the chart's appVersion, not its version.
+	AppVersion string `json:"appVersion,omitempty" yaml:"appVersion,omitempty"`
+	Latest     string `json:"latest,omitempty" yaml:"latest,omitempty"`
+	// LatestAppVersion is the appVersion of the latest chart.
+	LatestAppVersion string `json:"latestAppVersion,omitempty" yaml:"latestAppVersion,omitempty"`
+	Status           string `json:"status" yaml:"status"`
+	// Delta is how far Latest is ahead, e.g. "minor +2".
+	Delta string `json:"delta,omitempty" yaml:"delta,omitempty"`
+}
+
+// Current returns the version the component runs: Deployed, or Recorded
+// when it is not deployed.
+func (c Component) Current() string {
+	if c.Deployed != "" {
+		return c.Deployed
+	}
+	return c.Recorded
+}
+
+// Report is the output of 'hctl versions'.
+type Report struct {
+	Components []Component `json:"components" yaml:"components"`
+	// Outdated counts the components with a newer release.
+	Outdated int `json:"outdated" yaml:"outdated"`
+	// Errors are the cluster reads and upstream lookups that failed; the
+	// affected components lack a deployed or latest version.
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// NewReport drops the ignored components, sets each one's status and
+// delta, and sorts them by kind, name and source.
+func NewReport(components []Component, ignore []string, errs []error) *Report {
+	r := &Report{Components: []Component{}}
+	for _, c := range components {
+		if Ignored(c, ignore) {
+			continue
+		}
+		c.Status, c.Delta = CompareLatest(c.Current(), c.Latest)
+		if c.Status == StatusOutdated {
+			r.Outdated++
+		}
+		r.Components = append(r.Components, c)
+	}
+	sort.SliceStable(r.Components, func(i, j int) bool {
+		a, b := r.Components[i], r.Components[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Source < b.Source
+	})
+	for _, err := range errs {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	return r
+}
+
+var kindOrder = map[string]int{KindChart: 0, KindVCluster: 1, KindPromise: 2}
+
+// Ignored reports whether an ignore pattern matches c. A pattern is a
+// component name, which may use path.Match wildcards, optionally followed
+// by "@<version>" to ignore only that latest version, e.g.
+// "cert-manager@v1.17.0" hides cert-manager until a newer release.
+func Ignored(c Component, ignore []string) bool {
+	for _, pattern := range ignore {
+		name, version, pinned := strings.Cut(pattern, "@")
+		if ok, _ := path.Match(name, c.Name); !ok {
+			continue
+		}
+		if !pinned || Equal(version, c.Latest) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareLatest returns the status of current against latest and, when
+// latest is newer, the delta.
+func CompareLatest(current, latest string) (string, string) {
+	cur, ok := Parse(current)
+	if !ok {
+		return StatusUnpinned, ""
+	}
+	if latest == "" {
+		return StatusUnknown, ""
+	}
+	lat, ok := Parse(latest)
+	if !ok {
+		return StatusUnknown, ""
+	}
+	switch cmp := cur.Compare(lat); {
+	case cmp < 0:
+		return StatusOutdated, cur.Delta(lat)
+	case cmp > 0:
+		return StatusAhead, ""
+	}
+	return StatusCurrent, ""
+}
+
+// Version is a parsed semantic version. Missing minor and patch numbers
+// are zero, and a "v" prefix and build metadata are dropped.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// Parse reads a version such as "1.2.3", "v0.30.4", "1.17" or
+// "2.0.0-rc.1+build.5".
+func Parse(s string) (Version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ := strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, false
+	}
+	nums := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, false
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Prerelease: pre}, true
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is older than, the same as, or newer
+// than o. A prerelease is older than its release.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// Delta describes how far newer is ahead of v by its most significant
+// changed field, e.g. "major +1" or "patch +3".
+func (v Version) Delta(newer Version) string {
+	switch {
+	case newer.Major != v.Major:
+		return fmt.Sprintf("major %+d", newer.Major-v.Major)
+	case newer.Minor != v.Minor:
+		return fmt.Sprintf("minor %+d", newer.Minor-v.Minor)
+	case newer.Patch != v.Patch:
+		return fmt.Sprintf("patch %+d", newer.Patch-v.Patch)
+	case newer.Prerelease != v.Prerelease:
+		return "prerelease"
+	}
+	return ""
+}
+
+// Equal reports whether a and b are the same version, ignoring a "v"
+// prefix; versions that do not parse must match exactly.
+func Equal(a, b string) bool {
+	va, okA := Parse(a)
+	vb, okB := Parse(b)
+	if okA && okB {
+		return va.Compare(vb) == 0
+	}
+	return a == b
+}
+
+// Less orders versions oldest first, with versions that do not parse
+// before all others.
+func Less(a, b string) bool {
+	va, okA := Parse(a)
+	vb, okB := Parse(b)
+	switch {
+	case okA && okB:
+		return va.Compare(vb) < 0
+	case okA != okB:
+		return !okA
+	}
+	return a < b
+}
+
+// comparePrerelease compares dot-separated prerelease identifiers as
+// semver does: numerically when both are numbers, numbers before words,
+// and a shorter list first when one is a prefix of the other.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		na, errA := strconv.Atoi(as[i])
+		nb, errB := strconv.Atoi(bs[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return sign(na - nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
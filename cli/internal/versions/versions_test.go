@@ -0,0 +1,105 @@
+package versions
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCompareLatest(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		status, delta   string
+	}{
+		{"v1.16.2", "v1.16.10", StatusOutdated, "patch +8"},
+		{"1.16.2", "v1.18.0", StatusOutdated, "minor +2"},
+		{"0.30.4", "1.0.0", StatusOutdated, "major +1"},
+		{"3.12", "3.12.0", StatusCurrent, ""},
+		{"v2.0.0-rc.1", "2.0.0", StatusOutdated, "prerelease"},
+		{"2.0.0-rc.2", "2.0.0-rc.10", StatusOutdated, "prerelease"},
+		{"2.0.0-alpha", "2.0.0-alpha.1", StatusOutdated, "prerelease"},
+		{"1.2.0", "1.1.9", StatusAhead, ""},
+		{"latest", "v0.5.0", StatusUnpinned, ""},
+		{"1.2.0", "", StatusUnknown, ""},
+		{"1.2.0", "nightly", StatusUnknown, ""},
+	}
+	for _, tt := range tests {
+		status, delta := CompareLatest(tt.current, tt.latest)
+		if status != tt.status || delta != tt.delta {
+			t.Errorf("CompareLatest(%q, %q) = %s, %q; want %s, %q", tt.current, tt.latest, status, delta, tt.status, tt.delta)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	c := Component{Name: "cert-manager", Latest: "v1.17.0"}
+	for pattern, want := range map[string]bool{
+		"cert-manager":         true,
+		"cert-*":               true,
+		"cert-manager@1.17.0":  true,
+		"cert-manager@v1.16.0": false,
+		"external-*":           false,
+	} {
+		if got := Ignored(c, []string{pattern}); got != want {
+			t.Errorf("Ignored(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestIndexLatest(t *testing.T) {
+	data, err := os.ReadFile("testdata/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := parseIndex(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chart, want := range map[string]ChartRelease{
+		// Out of order, with a newer prerelease that is skipped.
+		"cert-manager": {Version: "v1.16.10", AppVersion: "v1.16.10"},
+		// Only prereleases: the highest, numerically.
+		"preview-only": {Version: "0.1.0-rc.10", AppVersion: "0.1"},
+	} {
+		if got, ok := index.latest(chart); !ok || got != want {
+			t.Errorf("latest(%s) = %+v, %v; want %+v", chart, got, ok, want)
+		}
+	}
+	if _, ok := index.latest("missing"); ok {
+		t.Error("latest(missing) found a release")
+	}
+}
+
+func TestFromRepo(t *testing.T) {
+	components, err := FromRepo("testdata/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewReport(components, nil, nil).Components
+	want := []Component{
+		{Name: "cert-manager", Kind: KindChart, Source: "cluster/vcluster-media", Repository: "https://charts.jetstack.io", Chart: "cert-manager", Recorded: "v1.15.0"},
+		{Name: "cert-manager", Kind: KindChart, Source: "environment/production", Repository: "https://charts.jetstack.io", Chart: "cert-manager", Recorded: "v1.16.2"},
+		{Name: "karpenter", Kind: KindChart, Source: "environment/production", Repository: "public.ecr.aws", Chart: "karpenter/karpenter", Recorded: "1.0.4"},
+		{Name: "metrics-server", Kind: KindChart, Source: "environment/production", Repository: "https://kubernetes-sigs.github.io/metrics-server", Chart: "metrics-server", Recorded: "3.12"},
+		{Name: "vcluster-dev", Kind: KindVCluster, Source: "platform/vclusters/vcluster-dev.yaml", Repository: DefaultVClusterRepository, Chart: "vcluster", Recorded: "0.30.4"},
+		{Name: "vcluster-media", Kind: KindVCluster, Source: "platform/vclusters/vcluster-media.yaml", Repository: "https://charts.loft.sh", Chart: "vcluster", Recorded: "0.31.1"},
+		{Name: "external-secret", Kind: KindPromise, Source: "promises/external-secret/promise.yaml", Repository: "ghcr.io/jamesatintegratnio/external-secret-configure", Recorded: "v0.4.0"},
+		{Name: "external-secret", Kind: KindPromise, Source: "promises/external-secret/promise.yaml", Repository: "ghcr.io/jamesatintegratnio/notify", Recorded: "latest", Status: StatusUnpinned},
+	}
+	for i := range want {
+		if want[i].Status == "" {
+			want[i].Status = StatusUnknown
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromRepo =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestNewReportErrors(t *testing.T) {
+	r := NewReport(nil, nil, []error{errors.New("fetching https://charts.jetstack.io/index.yaml: 503 Service Unavailable")})
+	if len(r.Components) != 0 || len(r.Errors) != 1 {
+		t.Errorf("report = %+v, want no components and one error", r)
+	}
+}
@@ -0,0 +1,79 @@
+package provisioners
+
+import (
+	"fmt"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ClassDedicated is the Score resource class of a postgres resource that
+// gets its own single-instance CloudNativePG cluster instead of credentials
+// from 1Password.
+const ClassDedicated = "dedicated"
+
+const (
+	// dedicatedPostgresSize is the storage of a dedicated cluster without
+	// params.size.
+	dedicatedPostgresSize = "5Gi"
+	// dedicatedPostgresDatabase is the database and owner CloudNativePG
+	// bootstraps by default.
+	dedicatedPostgresDatabase = "app"
+)
+
+// dedicatedPostgres renders a CloudNativePG Cluster named
+// <workload>-<resource> on the NFS StorageClass. CloudNativePG generates the
+// <cluster>-app secret with the owner's credentials, which the outputs
+// reference, and serves the primary as the <cluster>-rw Service.
+func dedicatedPostgres(ctx Context, name string, res score.Resource) (*ProvisionResult, error) {
+	for _, param := range []string{"item", "allowCrossEnvironment"} {
+		if _, ok := res.Params[param]; ok {
+			return nil, fmt.Errorf("postgres resource %q: params.%s only applies to 1Password credentials, not class %s", name, param, ClassDedicated)
+		}
+	}
+	size := dedicatedPostgresSize
+	if v, ok := res.Params["size"]; ok {
+		s, ok := v.(string)
+		q, err := resource.ParseQuantity(s)
+		if !ok || err != nil || q.Sign() <= 0 {
+			return nil, fmt.Errorf("postgres resource %q: params.size must be a quantity such as 10Gi, not %v", name, v)
+		}
+		size = s
+	}
+
+	clusterName := fmt.Sprintf("%s-%s", ctx.Workload, name)
+	secretName := clusterName + "-app"
+	cluster := map[string]interface{}{
+		"apiVersion": "postgresql.cnpg.io/v1",
+		"kind":       "Cluster",
+		"metadata": map[string]interface{}{
+			"name": clusterName,
+		},
+		"spec": map[string]interface{}{
+			"instances": 1,
+			"storage": map[string]interface{}{
+				"size":         size,
+				"storageClass": "democratic-csi-nfs",
+			},
+			"bootstrap": map[string]interface{}{
+				"initdb": map[string]interface{}{
+					"database": dedicatedPostgresDatabase,
+					"owner":    dedicatedPostgresDatabase,
+				},
+			},
+		},
+	}
+
+	return &ProvisionResult{
+		Outputs: map[string]string{
+			"host":     clusterName + "-rw",
+			"port":     "5432",
+			"name":     dedicatedPostgresDatabase,
+			"database": dedicatedPostgresDatabase,
+			"username": fmt.Sprintf("$(%s:username)", secretName),
+			"password": fmt.Sprintf("$(%s:password)", secretName),
+			"uri":      fmt.Sprintf("$(%s:uri)", secretName),
+		},
+		Manifests: []map[string]interface{}{cluster},
+	}, nil
+}
@@ -0,0 +1,181 @@
+package provisioners
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// Mode is how a translation runs: rendering for review or deploying.
+type Mode string
+
+const (
+	// ModeDeploy is the translation behind 'hctl deploy run'. Provisioners may
+	// contact external systems.
+	ModeDeploy Mode = "deploy"
+	// ModeRender is the translation behind 'hctl deploy render' and 'diff',
+	// which must be safe to run anywhere, including offline CI. Provisioners
+	// must not touch the network or write files; anything they would check
+	// or resolve is returned as a Requirement for the deploy path instead.
+	ModeRender Mode = "render"
+)
+
+// Context is what a ContextProvisioner knows about the translation it is
+// part of.
+type Context struct {
+	Mode Mode
+	// Workload is the name of the workload being translated.
+	Workload string
+	// Cluster is the cluster the workload is deployed to; empty when the
+	// provisioner is called through Provision.
+	Cluster string
+	// Environment is the target cluster's environment (dev, staging,
+	// prod, ...). Shared-class resources read that environment's
+	// 1Password item; empty when it is unknown.
+	Environment string
+	// SharedVolumes maps the shared volumes already on the target cluster
+	// to the workload that owns each. Nil means there are none.
+	SharedVolumes map[string]string
+	// Logger receives a debug record of each provisioner's input and
+	// output. Nil uses slog.Default().
+	Logger *slog.Logger
+}
+
+func (c Context) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+// Rendering reports whether the provisioner must stay side-effect free.
+func (c Context) Rendering() bool {
+	return c.Mode == ModeRender
+}
+
+// RequirementKind is the kind of external check a Requirement asks for.
+type RequirementKind string
+
+const (
+	// RequireSecret is a 1Password item, with the fields read from it, that
+	// must exist before the workload can start.
+	RequireSecret RequirementKind = "secret"
+	// RequireImage is a container image that must resolve in its registry.
+	RequireImage RequirementKind = "image"
+)
+
+// Requirement is an external precondition a provisioner declares instead of
+// checking it itself. Render reports them; 'hctl deploy run' verifies them
+// before writing anything.
+type Requirement struct {
+	Kind RequirementKind `json:"kind"`
+	// Ref is the 1Password item title or the image reference.
+	Ref string `json:"ref"`
+	// Fields are the item fields a secret requirement reads.
+	Fields []string `json:"fields,omitempty"`
+	// Resource is the Score resource that declared the requirement.
+	Resource string `json:"resource,omitempty"`
+}
+
+// ContextProvisioner is a Provisioner that knows the translation's Mode.
+// The registry calls ProvisionContext instead of Provision when a
+// provisioner implements it.
+type ContextProvisioner interface {
+	Provisioner
+	// ProvisionContext generates platform resources for the resource. In
+	// ModeRender it must not perform network calls or write files.
+	ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error)
+}
+
+// RenderGuard runs one ModeRender provisioner call and fails it if the call
+// had side effects. Tests install one with SetRenderGuard; see the
+// provisionerstest package.
+type RenderGuard func(resourceType string, provision func() error) error
+
+// SetRenderGuard makes the registry run every ModeRender provisioner call
+// through g. A nil g removes the guard.
+func (r *Registry) SetRenderGuard(g RenderGuard) {
+	r.renderGuard = g
+}
+
+// Provision runs the provisioner for resource's type in ctx. Provisioners
+// that do not implement ContextProvisioner get Provision in both modes. In
+// ModeRender the call runs inside the render guard, when one is set.
+func (r *Registry) Provision(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	if ctx.Mode == "" {
+		ctx.Mode = ModeDeploy
+	}
+	p, err := r.Get(resource.Type)
+	if err != nil {
+		return nil, err
+	}
+	log := ctx.logger().With("resource", name, "type", resource.Type, "workload", ctx.Workload, "mode", ctx.Mode)
+	log.Debug("provisioning", "params", resource.Params)
+	var result *ProvisionResult
+	run := func() error {
+		var err error
+		if cp, ok := p.(ContextProvisioner); ok {
+			result, err = cp.ProvisionContext(ctx, name, resource)
+		} else {
+			result, err = p.Provision(name, resource, ctx.Workload)
+		}
+		return err
+	}
+	if ctx.Rendering() && r.renderGuard != nil {
+		err = r.renderGuard(resource.Type, run)
+	} else {
+		err = run()
+	}
+	if err != nil {
+		log.Debug("provisioner failed", "error", err)
+		return nil, err
+	}
+	for i := range result.Requirements {
+		if result.Requirements[i].Resource == "" {
+			result.Requirements[i].Resource = name
+		}
+	}
+	log.Debug("provisioned", "outputs", result.Outputs, "manifests", len(result.Manifests), "requirements", len(result.Requirements))
+	return result, nil
+}
+
+// requireSecrets declares the 1Password items the result's ExternalSecrets
+// read as secret requirements.
+func (r *ProvisionResult) requireSecrets() *ProvisionResult {
+	for _, s := range r.SecretRequirements() {
+		r.Requirements = append(r.Requirements, Requirement{Kind: RequireSecret, Ref: s.Item, Fields: s.Fields})
+	}
+	return r
+}
+
+// SortRequirements merges requirements with the same kind and ref, keeping
+// the first resource to declare them and every field, and sorts them by
+// kind then ref.
+func SortRequirements(reqs []Requirement) []Requirement {
+	index := map[string]int{}
+	var out []Requirement
+	for _, req := range reqs {
+		key := fmt.Sprintf("%s\x00%s", req.Kind, req.Ref)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			req.Fields = append([]string(nil), req.Fields...)
+			out = append(out, req)
+			continue
+		}
+		for _, f := range req.Fields {
+			if !contains(out[i].Fields, f) {
+				out[i].Fields = append(out[i].Fields, f)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Ref < out[j].Ref
+	})
+	return out
+}
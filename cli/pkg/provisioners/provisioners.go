@@ -2,9 +2,10 @@ package provisioners
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
-	"github.com/jamesatintegratnio/hctl/internal/score"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,6 +15,81 @@ type ProvisionResult struct {
 	Outputs map[string]string
 	// Manifests are additional Kubernetes manifests to deploy alongside the workload.
 	Manifests []map[string]interface{}
+	// Requirements are external preconditions for the deploy path to verify.
+	Requirements []Requirement
+}
+
+// SecretRequirement names a 1Password item and the fields a workload reads from it.
+type SecretRequirement struct {
+	// Item is the 1Password item title (ExternalSecret remoteRef.key).
+	Item string `json:"item"`
+	// Fields are the item fields (ExternalSecret remoteRef.property).
+	Fields []string `json:"fields,omitempty"`
+}
+
+// SecretRequirements returns the 1Password items and fields referenced by the
+// ExternalSecret manifests in this result, in the order they first appear.
+func (r *ProvisionResult) SecretRequirements() []SecretRequirement {
+	var reqs []SecretRequirement
+	index := make(map[string]int)
+
+	add := func(item, field string) {
+		if item == "" {
+			return
+		}
+		i, ok := index[item]
+		if !ok {
+			i = len(reqs)
+			index[item] = i
+			reqs = append(reqs, SecretRequirement{Item: item})
+		}
+		if field == "" {
+			return
+		}
+		for _, f := range reqs[i].Fields {
+			if f == field {
+				return
+			}
+		}
+		reqs[i].Fields = append(reqs[i].Fields, field)
+	}
+
+	for _, m := range r.Manifests {
+		if kind, _ := m["kind"].(string); kind != "ExternalSecret" {
+			continue
+		}
+		spec, _ := m["spec"].(map[string]interface{})
+		for _, entry := range asMapSlice(spec["data"]) {
+			ref, _ := entry["remoteRef"].(map[string]interface{})
+			key, _ := ref["key"].(string)
+			property, _ := ref["property"].(string)
+			add(key, property)
+		}
+		for _, entry := range asMapSlice(spec["dataFrom"]) {
+			extract, _ := entry["extract"].(map[string]interface{})
+			key, _ := extract["key"].(string)
+			add(key, "")
+		}
+	}
+	return reqs
+}
+
+// asMapSlice normalizes a manifest list field to a slice of maps.
+func asMapSlice(v interface{}) []map[string]interface{} {
+	switch list := v.(type) {
+	case []map[string]interface{}:
+		return list
+	case []interface{}:
+		out := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
 }
 
 // Provisioner translates a Score resource into platform-native resources.
@@ -21,12 +97,28 @@ type Provisioner interface {
 	// Type returns the Score resource type this provisioner handles.
 	Type() string
 	// Provision generates platform resources for the given Score resource.
+	// It may be called concurrently for different resources and workloads.
 	Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error)
 }
 
+// Version identifies the output of the built-in provisioners. It is part of
+// every translate.ResultCache key, so bump it whenever a provisioner's
+// result for the same resource changes.
+const Version = "4"
+
+// Versioned is implemented by a provisioner outside this package whose
+// results may be cached. Version identifies its output as the package
+// Version does for the built-in provisioners, so change it whenever its
+// result for the same resource changes. Results of other provisioners are
+// never cached.
+type Versioned interface {
+	Version() string
+}
+
 // Registry holds all available provisioners.
 type Registry struct {
 	provisioners map[string]Provisioner
+	renderGuard  RenderGuard
 }
 
 // NewRegistry creates a registry with all platform provisioners registered.
@@ -35,10 +127,13 @@ func NewRegistry() *Registry {
 		provisioners: make(map[string]Provisioner),
 	}
 	r.Register(&PostgresProvisioner{})
+	r.Register(&MySQLProvisioner{})
 	r.Register(&RedisProvisioner{})
 	r.Register(&RouteProvisioner{})
 	r.Register(&VolumeProvisioner{})
 	r.Register(&DNSProvisioner{})
+	r.Register(&RBACProvisioner{})
+	r.Register(&S3Provisioner{})
 	return r
 }
 
@@ -57,25 +152,40 @@ func (r *Registry) Get(resourceType string) (Provisioner, error) {
 	return p, nil
 }
 
-// Types returns all registered provisioner types.
+// Types returns all registered provisioner types, sorted.
 func (r *Registry) Types() []string {
 	types := make([]string, 0, len(r.provisioners))
 	for t := range r.provisioners {
 		types = append(types, t)
 	}
+	sort.Strings(types)
 	return types
 }
 
 // --- Postgres Provisioner ---
 
-// PostgresProvisioner generates ExternalSecret resources for PostgreSQL credentials.
+// PostgresProvisioner generates ExternalSecret resources for PostgreSQL
+// credentials, or a CloudNativePG cluster for class dedicated.
 type PostgresProvisioner struct{}
 
 func (p *PostgresProvisioner) Type() string { return "postgres" }
 
+// Provision is ProvisionContext in deploy mode.
 func (p *PostgresProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *PostgresProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	if resource.Class == ClassDedicated {
+		return dedicatedPostgres(ctx, name, resource)
+	}
+	workloadName := ctx.Workload
 	secretName := fmt.Sprintf("%s-%s-credentials", workloadName, name)
-	opItem := fmt.Sprintf("%s-%s-db", workloadName, name)
+	opItem, err := credentialsItem(ctx, name, resource, "db")
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate ExternalSecret
 	externalSecret := map[string]interface{}{
@@ -132,7 +242,7 @@ func (p *PostgresProvisioner) Provision(name string, resource score.Resource, wo
 		},
 	}
 
-	return &ProvisionResult{
+	return (&ProvisionResult{
 		Outputs: map[string]string{
 			"host":     fmt.Sprintf("$(%s:host)", secretName),
 			"port":     fmt.Sprintf("$(%s:port)", secretName),
@@ -142,7 +252,67 @@ func (p *PostgresProvisioner) Provision(name string, resource score.Resource, wo
 			"password": fmt.Sprintf("$(%s:password)", secretName),
 		},
 		Manifests: []map[string]interface{}{externalSecret},
-	}, nil
+	}).requireSecrets(), nil
+}
+
+// --- MySQL Provisioner ---
+
+// MySQLProvisioner generates ExternalSecret resources for MySQL and MariaDB
+// credentials.
+type MySQLProvisioner struct{}
+
+func (p *MySQLProvisioner) Type() string { return "mysql" }
+
+// Provision is ProvisionContext in deploy mode.
+func (p *MySQLProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *MySQLProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
+	secretName := fmt.Sprintf("%s-%s-mysql", workloadName, name)
+	opItem, err := credentialsItem(ctx, name, resource, "mysql")
+	if err != nil {
+		return nil, err
+	}
+
+	var data []interface{}
+	for _, key := range []string{"host", "port", "database", "username", "password"} {
+		data = append(data, map[string]interface{}{
+			"secretKey": key,
+			"remoteRef": map[string]interface{}{"key": opItem, "property": key},
+		})
+	}
+	externalSecret := map[string]interface{}{
+		"apiVersion": "external-secrets.io/v1beta1",
+		"kind":       "ExternalSecret",
+		"metadata": map[string]interface{}{
+			"name": secretName,
+		},
+		"spec": map[string]interface{}{
+			"secretStoreRef": map[string]interface{}{
+				"name": "onepassword-connect",
+				"kind": "ClusterSecretStore",
+			},
+			"target": map[string]interface{}{
+				"name": secretName,
+			},
+			"data": data,
+		},
+	}
+
+	return (&ProvisionResult{
+		Outputs: map[string]string{
+			"host":     fmt.Sprintf("$(%s:host)", secretName),
+			"port":     fmt.Sprintf("$(%s:port)", secretName),
+			"name":     fmt.Sprintf("$(%s:database)", secretName),
+			"database": fmt.Sprintf("$(%s:database)", secretName),
+			"username": fmt.Sprintf("$(%s:username)", secretName),
+			"password": fmt.Sprintf("$(%s:password)", secretName),
+		},
+		Manifests: []map[string]interface{}{externalSecret},
+	}).requireSecrets(), nil
 }
 
 // --- Redis Provisioner ---
@@ -152,9 +322,19 @@ type RedisProvisioner struct{}
 
 func (p *RedisProvisioner) Type() string { return "redis" }
 
+// Provision is ProvisionContext in deploy mode.
 func (p *RedisProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *RedisProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
 	secretName := fmt.Sprintf("%s-%s-credentials", workloadName, name)
-	opItem := fmt.Sprintf("%s-%s-redis", workloadName, name)
+	opItem, err := credentialsItem(ctx, name, resource, "redis")
+	if err != nil {
+		return nil, err
+	}
 
 	externalSecret := map[string]interface{}{
 		"apiVersion": "external-secrets.io/v1beta1",
@@ -187,79 +367,14 @@ func (p *RedisProvisioner) Provision(name string, resource score.Resource, workl
 		},
 	}
 
-	return &ProvisionResult{
+	return (&ProvisionResult{
 		Outputs: map[string]string{
 			"host":     fmt.Sprintf("$(%s:host)", secretName),
 			"port":     fmt.Sprintf("$(%s:port)", secretName),
 			"password": fmt.Sprintf("$(%s:password)", secretName),
 		},
 		Manifests: []map[string]interface{}{externalSecret},
-	}, nil
-}
-
-// --- Route Provisioner ---
-
-// RouteProvisioner generates HTTPRoute resources for Gateway API.
-type RouteProvisioner struct{}
-
-func (p *RouteProvisioner) Type() string { return "route" }
-
-func (p *RouteProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
-	host, _ := resource.Params["host"].(string)
-	path, _ := resource.Params["path"].(string)
-	port := 8080
-	if p, ok := resource.Params["port"]; ok {
-		if pi, ok := p.(int); ok {
-			port = pi
-		}
-	}
-
-	if host == "" {
-		return nil, fmt.Errorf("route resource %q requires params.host", name)
-	}
-	if path == "" {
-		path = "/"
-	}
-
-	httpRoute := map[string]interface{}{
-		"apiVersion": "gateway.networking.k8s.io/v1",
-		"kind":       "HTTPRoute",
-		"metadata": map[string]interface{}{
-			"name": fmt.Sprintf("%s-%s", workloadName, name),
-		},
-		"spec": map[string]interface{}{
-			"parentRefs": []interface{}{
-				map[string]interface{}{
-					"name":      "nginx",
-					"namespace": "nginx-gateway",
-				},
-			},
-			"hostnames": []string{host},
-			"rules": []interface{}{
-				map[string]interface{}{
-					"matches": []interface{}{
-						map[string]interface{}{
-							"path": map[string]interface{}{
-								"type":  "PathPrefix",
-								"value": path,
-							},
-						},
-					},
-					"backendRefs": []interface{}{
-						map[string]interface{}{
-							"name": workloadName,
-							"port": port,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	return &ProvisionResult{
-		Outputs:   map[string]string{},
-		Manifests: []map[string]interface{}{httpRoute},
-	}, nil
+	}).requireSecrets(), nil
 }
 
 // --- Volume Provisioner ---
@@ -269,19 +384,45 @@ type VolumeProvisioner struct{}
 
 func (p *VolumeProvisioner) Type() string { return "volume" }
 
+// Provision is ProvisionContext in deploy mode.
 func (p *VolumeProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *VolumeProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
 	pvcName := fmt.Sprintf("%s-%s", workloadName, name)
 	size := "1Gi"
 	if s, ok := resource.Params["size"].(string); ok {
 		size = s
 	}
+	metadata := map[string]interface{}{
+		"name": pvcName,
+	}
+
+	volume, shared, err := SharedVolume(name, resource)
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		owner, err := sharedVolumeOwner(ctx, name, volume, resource)
+		if err != nil {
+			return nil, err
+		}
+		pvcName = volume
+		if !owner {
+			// Mounted only: the owner's Application manages the PVC.
+			return &ProvisionResult{Outputs: map[string]string{"source": pvcName}}, nil
+		}
+		metadata["name"] = pvcName
+		metadata["annotations"] = map[string]interface{}{SharedVolumeOwnerAnnotation: workloadName}
+	}
 
 	pvc := map[string]interface{}{
 		"apiVersion": "v1",
 		"kind":       "PersistentVolumeClaim",
-		"metadata": map[string]interface{}{
-			"name": pvcName,
-		},
+		"metadata":   metadata,
 		"spec": map[string]interface{}{
 			"accessModes": []string{"ReadWriteMany"},
 			"storageClassName": "democratic-csi-nfs",
@@ -308,7 +449,14 @@ type DNSProvisioner struct{}
 
 func (p *DNSProvisioner) Type() string { return "dns" }
 
+// Provision is ProvisionContext in deploy mode.
 func (p *DNSProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *DNSProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
 	host, _ := resource.Params["host"].(string)
 	if host == "" {
 		host = fmt.Sprintf("%s.cluster.integratn.tech", workloadName)
@@ -0,0 +1,790 @@
+package provisioners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecretRequirementsPostgres(t *testing.T) {
+	res, err := (&PostgresProvisioner{}).Provision("db", score.Resource{Type: "postgres"}, "myapp")
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	reqs := res.SecretRequirements()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 requirement, got %d", len(reqs))
+	}
+	if reqs[0].Item != "myapp-db-db" {
+		t.Errorf("Item = %q, want myapp-db-db", reqs[0].Item)
+	}
+	if got := strings.Join(reqs[0].Fields, ","); got != "host,port,database,username,password" {
+		t.Errorf("Fields = %s", got)
+	}
+}
+
+func TestMySQL(t *testing.T) {
+	ctx := Context{Mode: ModeRender, Workload: "myapp", Cluster: "media", Environment: "prod"}
+	for _, tt := range []struct {
+		name  string
+		class string
+		item  string
+	}{
+		{"default", "", "myapp-db-mysql"},
+		{"dedicated", ClassDedicated, "myapp-db-mysql"},
+		{"shared", ClassShared, "shared-mysql-prod"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := (&MySQLProvisioner{}).ProvisionContext(ctx, "db", score.Resource{Type: "mysql", Class: tt.class})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Manifests) != 1 || res.Manifests[0]["kind"] != "ExternalSecret" {
+				t.Fatalf("Manifests = %v, want one ExternalSecret", res.Manifests)
+			}
+			target := res.Manifests[0]["spec"].(map[string]interface{})["target"].(map[string]interface{})
+			if target["name"] != "myapp-db-mysql" {
+				t.Errorf("secret name = %v, want myapp-db-mysql", target["name"])
+			}
+			reqs := res.SecretRequirements()
+			if len(reqs) != 1 || reqs[0].Item != tt.item {
+				t.Fatalf("SecretRequirements = %+v, want item %s", reqs, tt.item)
+			}
+			if got := strings.Join(reqs[0].Fields, ","); got != "host,port,database,username,password" {
+				t.Errorf("Fields = %s", got)
+			}
+			for output, want := range map[string]string{
+				"host":     "$(myapp-db-mysql:host)",
+				"port":     "$(myapp-db-mysql:port)",
+				"name":     "$(myapp-db-mysql:database)",
+				"username": "$(myapp-db-mysql:username)",
+				"password": "$(myapp-db-mysql:password)",
+			} {
+				if got := res.Outputs[output]; got != want {
+					t.Errorf("Outputs[%s] = %q, want %q", output, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretRequirementsNoSecrets(t *testing.T) {
+	res, err := (&VolumeProvisioner{}).Provision("data", score.Resource{Type: "volume"}, "myapp")
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	if reqs := res.SecretRequirements(); len(reqs) != 0 {
+		t.Errorf("volume should not require secrets, got %v", reqs)
+	}
+}
+
+func rbacResource(params map[string]interface{}) score.Resource {
+	return score.Resource{Type: "rbac", Params: params}
+}
+
+func rule(scope string, resources, verbs []interface{}) map[string]interface{} {
+	r := map[string]interface{}{"resources": resources, "verbs": verbs}
+	if scope != "" {
+		r["scope"] = scope
+	}
+	return r
+}
+
+func TestRBACNamespacedRules(t *testing.T) {
+	res, err := (&RBACProvisioner{}).Provision("api", rbacResource(map[string]interface{}{
+		"rules": []interface{}{rule("", []interface{}{"configmaps"}, []interface{}{"get", "watch"})},
+	}), "backup")
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	if res.Outputs["serviceAccountName"] != "backup" {
+		t.Errorf("serviceAccountName output = %q, want backup", res.Outputs["serviceAccountName"])
+	}
+	var kinds []string
+	for _, m := range res.Manifests {
+		kinds = append(kinds, m["kind"].(string))
+		if name := m["metadata"].(map[string]interface{})["name"]; name != "backup" {
+			t.Errorf("%s named %v, want backup", m["kind"], name)
+		}
+	}
+	if got := strings.Join(kinds, ","); got != "ServiceAccount,Role,RoleBinding" {
+		t.Errorf("kinds = %s", got)
+	}
+	r := res.Manifests[1]["rules"].([]interface{})[0].(map[string]interface{})
+	if groups := r["apiGroups"].([]string); len(groups) != 1 || groups[0] != "" {
+		t.Errorf("apiGroups = %v, want the core group by default", groups)
+	}
+}
+
+func TestRBACClusterWildcardGuardrail(t *testing.T) {
+	params := map[string]interface{}{
+		"rules": []interface{}{
+			rule("cluster", []interface{}{"*"}, []interface{}{"get"}),
+			rule("", []interface{}{"*"}, []interface{}{"*"}),
+		},
+	}
+	_, err := (&RBACProvisioner{}).Provision("api", rbacResource(params), "ctl")
+	if err == nil || !strings.Contains(err.Error(), "allowWildcards") || !strings.Contains(err.Error(), "rules[0] resources") {
+		t.Fatalf("err = %v, want wildcard guardrail naming rules[0]", err)
+	}
+
+	params["allowWildcards"] = true
+	res, err := (&RBACProvisioner{}).Provision("api", rbacResource(params), "ctl")
+	if err != nil {
+		t.Fatalf("Provision with allowWildcards: %v", err)
+	}
+	var kinds []string
+	for _, m := range res.Manifests {
+		kinds = append(kinds, m["kind"].(string))
+	}
+	if got := strings.Join(kinds, ","); got != "ServiceAccount,Role,RoleBinding,ClusterRole,ClusterRoleBinding" {
+		t.Errorf("kinds = %s", got)
+	}
+}
+
+func TestParseRBACValidation(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no rules":        {},
+		"no verbs":        {"rules": []interface{}{map[string]interface{}{"resources": []interface{}{"pods"}}}},
+		"bad scope":       {"rules": []interface{}{rule("global", []interface{}{"pods"}, []interface{}{"get"})}},
+		"non-string verb": {"rules": []interface{}{rule("", []interface{}{"pods"}, []interface{}{1})}},
+	}
+	for name, params := range tests {
+		if _, err := ParseRBAC("api", rbacResource(params)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func provisionRoute(t *testing.T, params map[string]interface{}) *ProvisionResult {
+	t.Helper()
+	res, err := (&RouteProvisioner{}).Provision("public", score.Resource{Type: "route", Params: params}, "web")
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	return res
+}
+
+// renderedYAML marshals the first manifest of kind, or just its HTTPRoute
+// rules when kind is HTTPRoute.
+func renderedYAML(t *testing.T, res *ProvisionResult, kind string) string {
+	t.Helper()
+	for _, m := range res.Manifests {
+		if m["kind"] != kind {
+			continue
+		}
+		var v interface{} = m
+		if kind == "HTTPRoute" {
+			v = m["spec"].(map[string]interface{})["rules"]
+		}
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	t.Fatalf("no %s manifest", kind)
+	return ""
+}
+
+func TestRouteRedirectWithHeaders(t *testing.T) {
+	res := provisionRoute(t, map[string]interface{}{
+		"host":       "www.example.com",
+		"redirectTo": "https://example.com",
+		"headers": map[string]interface{}{
+			"set": map[string]interface{}{"Strict-Transport-Security": "max-age=31536000"},
+		},
+	})
+	// The redirect rule has no backendRefs.
+	want := `- filters:
+    - requestRedirect:
+        hostname: example.com
+        scheme: https
+        statusCode: 301
+      type: RequestRedirect
+    - responseHeaderModifier:
+        set:
+            - name: Strict-Transport-Security
+              value: max-age=31536000
+      type: ResponseHeaderModifier
+  matches:
+    - path:
+        type: PathPrefix
+        value: /
+`
+	if got := renderedYAML(t, res, "HTTPRoute"); got != want {
+		t.Errorf("rules:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRouteRedirectTargets(t *testing.T) {
+	tests := []struct {
+		target string
+		code   interface{}
+		want   RouteRedirect
+	}{
+		{"example.com", nil, RouteRedirect{Hostname: "example.com", StatusCode: 301}},
+		{"http://example.com:8080/new", 302, RouteRedirect{Scheme: "http", Hostname: "example.com", Port: 8080, Path: "/new", StatusCode: 302}},
+	}
+	for _, tt := range tests {
+		params := map[string]interface{}{"host": "old.example.com", "redirectTo": tt.target}
+		if tt.code != nil {
+			params["redirectCode"] = tt.code
+		}
+		spec, err := ParseRoute("public", score.Resource{Type: "route", Params: params})
+		if err != nil {
+			t.Fatalf("ParseRoute(%s): %v", tt.target, err)
+		}
+		if *spec.Redirect != tt.want {
+			t.Errorf("redirect to %s = %+v, want %+v", tt.target, *spec.Redirect, tt.want)
+		}
+	}
+}
+
+func TestRouteHeaders(t *testing.T) {
+	res := provisionRoute(t, map[string]interface{}{
+		"host": "web.example.com",
+		"headers": map[string]interface{}{
+			"set":    map[string]interface{}{"X-Frame-Options": "DENY", "Referrer-Policy": "no-referrer"},
+			"add":    map[string]interface{}{"X-Served-By": "hctl"},
+			"remove": []interface{}{"Server"},
+		},
+	})
+	want := `- backendRefs:
+    - name: web
+      port: 8080
+  filters:
+    - responseHeaderModifier:
+        add:
+            - name: X-Served-By
+              value: hctl
+        remove:
+            - Server
+        set:
+            - name: Referrer-Policy
+              value: no-referrer
+            - name: X-Frame-Options
+              value: DENY
+      type: ResponseHeaderModifier
+  matches:
+    - path:
+        type: PathPrefix
+        value: /
+`
+	if got := renderedYAML(t, res, "HTTPRoute"); got != want {
+		t.Errorf("rules:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRouteBasicAuth(t *testing.T) {
+	res := provisionRoute(t, map[string]interface{}{
+		"host":      "staging.example.com",
+		"basicAuth": map[string]interface{}{"item": "web-staging-htpasswd", "realm": "Staging"},
+	})
+	want := `apiVersion: gateway.nginx.org/v1alpha1
+kind: AuthenticationFilter
+metadata:
+    name: web-public-basic-auth
+spec:
+    basic:
+        realm: Staging
+        secretRef:
+            name: web-public-basic-auth
+    type: Basic
+`
+	if got := renderedYAML(t, res, "AuthenticationFilter"); got != want {
+		t.Errorf("AuthenticationFilter:\n%s\nwant:\n%s", got, want)
+	}
+	if rules := renderedYAML(t, res, "HTTPRoute"); !strings.Contains(rules, "kind: AuthenticationFilter\n        name: web-public-basic-auth") {
+		t.Errorf("route should reference the filter:\n%s", rules)
+	}
+	reqs := res.SecretRequirements()
+	if len(reqs) != 1 || reqs[0].Item != "web-staging-htpasswd" || strings.Join(reqs[0].Fields, ",") != "htpasswd" {
+		t.Errorf("SecretRequirements = %+v, want web-staging-htpasswd/htpasswd", reqs)
+	}
+	if got := renderedYAML(t, res, "ExternalSecret"); !strings.Contains(got, "template:\n            type: nginx.org/htpasswd") {
+		t.Errorf("ExternalSecret should create an htpasswd Secret:\n%s", got)
+	}
+
+	// An existing Secret is referenced directly.
+	res = provisionRoute(t, map[string]interface{}{
+		"host":      "staging.example.com",
+		"basicAuth": map[string]interface{}{"secret": "staging-htpasswd"},
+	})
+	if len(res.Manifests) != 2 || res.Manifests[1]["kind"] != "AuthenticationFilter" {
+		t.Fatalf("want HTTPRoute and AuthenticationFilter only, got %d manifests", len(res.Manifests))
+	}
+	if got := renderedYAML(t, res, "AuthenticationFilter"); !strings.Contains(got, "realm: Restricted\n        secretRef:\n            name: staging-htpasswd") {
+		t.Errorf("AuthenticationFilter:\n%s", got)
+	}
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	res := provisionRoute(t, map[string]interface{}{
+		"host":      "api.example.com",
+		"rateLimit": map[string]interface{}{"requestsPerSecond": 10, "burst": 20},
+	})
+	want := `apiVersion: gateway.nginx.org/v1alpha1
+kind: RateLimitPolicy
+metadata:
+    name: web-public-rate-limit
+spec:
+    rateLimit:
+        local:
+            rules:
+                - burst: 20
+                  key: $binary_remote_addr
+                  rate: 10r/s
+                  zoneSize: 10m
+    targetRefs:
+        - group: gateway.networking.k8s.io
+          kind: HTTPRoute
+          name: web
+        - group: gateway.networking.k8s.io
+          kind: HTTPRoute
+          name: web-public
+`
+	if got := renderedYAML(t, res, "RateLimitPolicy"); got != want {
+		t.Errorf("RateLimitPolicy:\n%s\nwant:\n%s", got, want)
+	}
+
+	spec, err := ParseRoute("public", score.Resource{Type: "route", Params: map[string]interface{}{"host": "a.example.com", "rateLimit": 5}})
+	if err != nil || spec.RateLimit.RequestsPerSecond != 5 || spec.RateLimit.Burst != 0 {
+		t.Errorf("scalar rateLimit = %+v, %v", spec, err)
+	}
+}
+
+func TestParseRouteValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"no host", map[string]interface{}{}, "requires params.host"},
+		{"bad port", map[string]interface{}{"host": "a", "port": "http"}, "params.port must be a port number"},
+		{"redirect scheme", map[string]interface{}{"host": "a", "redirectTo": "ftp://b"}, "scheme must be http or https"},
+		{"redirect code", map[string]interface{}{"host": "a", "redirectTo": "b", "redirectCode": 307}, "redirectCode must be 301 or 302"},
+		{"code without redirect", map[string]interface{}{"host": "a", "redirectCode": 302}, "needs params.redirectTo"},
+		{"header name", map[string]interface{}{"host": "a", "headers": map[string]interface{}{"set": map[string]interface{}{"Bad Header": "x"}}}, `"Bad Header" is not a valid header name`},
+		{"empty headers", map[string]interface{}{"host": "a", "headers": map[string]interface{}{}}, "sets, adds or removes no headers"},
+		{"auth source", map[string]interface{}{"host": "a", "basicAuth": map[string]interface{}{"secret": "s", "item": "i"}}, "exactly one of secret"},
+		{"redirect with auth", map[string]interface{}{"host": "a", "redirectTo": "b", "basicAuth": map[string]interface{}{"item": "i"}}, "cannot be combined with basicAuth"},
+		{"zero rate", map[string]interface{}{"host": "a", "rateLimit": 0}, "at least 1"},
+		{"rules with port", map[string]interface{}{"host": "a", "port": 80, "rules": []interface{}{pathRule("/", 80)}}, "cannot be combined with params.port"},
+		{"empty rules", map[string]interface{}{"host": "a", "rules": []interface{}{}}, "non-empty list"},
+		{"relative rule path", map[string]interface{}{"host": "a", "rules": []interface{}{pathRule("api", 80)}}, "rules[0].path must be an absolute path"},
+		{"duplicate rule path", map[string]interface{}{"host": "a", "rules": []interface{}{pathRule("/api", 80), pathRule("/api", 81)}}, "rules[1]: duplicate PathPrefix path /api"},
+		{"rule path type", map[string]interface{}{"host": "a", "rules": []interface{}{map[string]interface{}{"path": "/", "port": 80, "pathType": "Regex"}}}, "pathType must be PathPrefix or Exact"},
+		{"rule service", map[string]interface{}{"host": "a", "rules": []interface{}{map[string]interface{}{"path": "/", "port": 80, "serviceNameOverride": "Assets"}}}, "not a valid Service name"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRoute("web", score.Resource{Type: "route", Params: tt.params})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func pathRule(path string, port int) map[string]interface{} {
+	return map[string]interface{}{"path": path, "port": port}
+}
+
+func TestRouteRules(t *testing.T) {
+	res := provisionRoute(t, map[string]interface{}{
+		"host": "www.example.com",
+		"rules": []interface{}{
+			map[string]interface{}{"path": "/", "port": 8081, "serviceNameOverride": "web-assets"},
+			pathRule("/api", 8080),
+			map[string]interface{}{"path": "/healthz", "port": 8080, "pathType": "Exact"},
+		},
+		"headers": map[string]interface{}{"remove": []interface{}{"Server"}},
+	})
+	// One HTTPRoute; every rule carries the filters, most specific first.
+	want := `- backendRefs:
+    - name: web
+      port: 8080
+  filters:
+    - responseHeaderModifier:
+        remove:
+            - Server
+      type: ResponseHeaderModifier
+  matches:
+    - path:
+        type: Exact
+        value: /healthz
+- backendRefs:
+    - name: web
+      port: 8080
+  filters:
+    - responseHeaderModifier:
+        remove:
+            - Server
+      type: ResponseHeaderModifier
+  matches:
+    - path:
+        type: PathPrefix
+        value: /api
+- backendRefs:
+    - name: web-assets
+      port: 8081
+  filters:
+    - responseHeaderModifier:
+        remove:
+            - Server
+      type: ResponseHeaderModifier
+  matches:
+    - path:
+        type: PathPrefix
+        value: /
+`
+	if got := renderedYAML(t, res, "HTTPRoute"); got != want {
+		t.Errorf("rules:\n%s\nwant:\n%s", got, want)
+	}
+	routes := 0
+	for _, m := range res.Manifests {
+		if m["kind"] == "HTTPRoute" {
+			routes++
+		}
+	}
+	if routes != 1 {
+		t.Errorf("rendered %d HTTPRoutes, want 1", routes)
+	}
+}
+
+func TestRouteRulesOrder(t *testing.T) {
+	rules := []interface{}{
+		pathRule("/", 8081),
+		pathRule("/api", 8080),
+		pathRule("/api/v2", 8082),
+		map[string]interface{}{"path": "/", "port": 8080, "pathType": "Exact"},
+		pathRule("/docs", 8081),
+	}
+	var first string
+	for i := range rules {
+		// Rotate the declaration order; the rendering must not change.
+		rotated := append(append([]interface{}{}, rules[i:]...), rules[:i]...)
+		got := renderedYAML(t, provisionRoute(t, map[string]interface{}{"host": "a.example.com", "rules": rotated}), "HTTPRoute")
+		if i == 0 {
+			first = got
+			continue
+		}
+		if got != first {
+			t.Errorf("rotation %d rendered:\n%s\nwant:\n%s", i, got, first)
+		}
+	}
+
+	spec, err := ParseRoute("public", score.Resource{Type: "route", Params: map[string]interface{}{"host": "a.example.com", "rules": rules}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, r := range spec.Backends() {
+		order = append(order, r.PathType+":"+r.Path)
+	}
+	want := "Exact:/ PathPrefix:/api/v2 PathPrefix:/docs PathPrefix:/api PathPrefix:/"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("rule order = %s, want %s", got, want)
+	}
+}
+
+func TestRouteUnsupportedGateway(t *testing.T) {
+	p := &RouteProvisioner{Gateway: "envoy-gateway"}
+	_, err := p.Provision("public", score.Resource{Type: "route", Params: map[string]interface{}{
+		"host":      "a.example.com",
+		"basicAuth": map[string]interface{}{"item": "i"},
+		"rateLimit": 5,
+	}}, "web")
+	want := `route resource "public" uses basicAuth and rateLimit, which need nginx-gateway-fabric; the gateway is envoy-gateway`
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+
+	// Core Gateway API filters work anywhere.
+	if _, err := p.Provision("public", score.Resource{Type: "route", Params: map[string]interface{}{
+		"host": "a.example.com", "redirectTo": "b.example.com",
+	}}, "web"); err != nil {
+		t.Errorf("redirect on %s: %v", p.Gateway, err)
+	}
+}
+
+func provisionS3(t *testing.T, params map[string]interface{}) *ProvisionResult {
+	t.Helper()
+	ctx := Context{Mode: ModeRender, Workload: "web", Cluster: "vcluster-media"}
+	res, err := (&S3Provisioner{}).ProvisionContext(ctx, "media", score.Resource{Type: "s3", Params: params})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	return res
+}
+
+func manifestKinds(res *ProvisionResult) []string {
+	var kinds []string
+	for _, m := range res.Manifests {
+		kinds = append(kinds, m["kind"].(string))
+	}
+	return kinds
+}
+
+func TestS3Platform(t *testing.T) {
+	res := provisionS3(t, map[string]interface{}{"versioning": true, "quota": "10Gi"})
+
+	want := map[string]string{
+		"bucket":    "vcluster-media-web-media",
+		"endpoint":  DefaultS3Endpoint,
+		"region":    DefaultS3Region,
+		"accessKey": "$(web-media-credentials:accessKey)",
+		"secretKey": "$(web-media-credentials:secretKey)",
+	}
+	for k, v := range want {
+		if res.Outputs[k] != v {
+			t.Errorf("output %s = %q, want %q", k, res.Outputs[k], v)
+		}
+	}
+	if got := strings.Join(manifestKinds(res), ","); got != "Password,Password,ExternalSecret,ExternalSecret,Job" {
+		t.Errorf("manifests = %s", got)
+	}
+
+	creds := renderedYAML(t, res, "ExternalSecret")
+	for _, s := range []string{
+		"hctl.integratn.tech/s3-bucket: vcluster-media-web-media",
+		"name: web-media-s3-access-key",
+		`refreshInterval: "0"`,
+		"target: accessKey",
+	} {
+		if !strings.Contains(creds, s) {
+			t.Errorf("credentials ExternalSecret missing %q:\n%s", s, creds)
+		}
+	}
+	job := renderedYAML(t, res, "Job")
+	for _, s := range []string{
+		"argocd.argoproj.io/hook: PostSync",
+		"value: vcluster-media-web-media",
+		`value: "10737418240"`,
+		`value: "true"`,
+	} {
+		if !strings.Contains(job, s) {
+			t.Errorf("bucket Job missing %q:\n%s", s, job)
+		}
+	}
+
+	// Generated keys need nothing in 1Password; only the admin login does.
+	reqs := res.SecretRequirements()
+	if len(reqs) != 1 || reqs[0].Item != "minio-admin" {
+		t.Errorf("SecretRequirements = %+v, want minio-admin only", reqs)
+	}
+}
+
+func TestS3External(t *testing.T) {
+	res := provisionS3(t, map[string]interface{}{"class": "external", "bucketName": "shared-media", "endpoint": "https://s3.example.com"})
+
+	if got := strings.Join(manifestKinds(res), ","); got != "ExternalSecret" {
+		t.Errorf("manifests = %s, want the credentials ExternalSecret only", got)
+	}
+	if res.Outputs["bucket"] != "shared-media" || res.Outputs["endpoint"] != "https://s3.example.com" || res.Outputs["secretKey"] != "$(web-media-credentials:secretKey)" {
+		t.Errorf("outputs = %v", res.Outputs)
+	}
+	reqs := res.SecretRequirements()
+	if len(reqs) != 1 || reqs[0].Item != "web-media-s3" || strings.Join(reqs[0].Fields, ",") != "accessKey,secretKey" {
+		t.Errorf("SecretRequirements = %+v, want web-media-s3/accessKey,secretKey", reqs)
+	}
+}
+
+func TestParseS3Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"class", map[string]interface{}{"class": "aws"}, `params.class "aws" is not platform or external`},
+		{"uppercase", map[string]interface{}{"bucketName": "Media"}, "lowercase letters"},
+		{"short", map[string]interface{}{"bucketName": "ab"}, "3 to 63 characters"},
+		{"adjacent dots", map[string]interface{}{"bucketName": "a..b"}, "adjacent dots"},
+		{"ip address", map[string]interface{}{"bucketName": "192.168.1.10"}, "IP address"},
+		{"reserved prefix", map[string]interface{}{"bucketName": "xn--media"}, "must not start with"},
+		{"reserved suffix", map[string]interface{}{"bucketName": "media-s3alias"}, "must not end with"},
+		{"quota", map[string]interface{}{"quota": "ten gigs"}, "params.quota must be a quantity"},
+		{"zero quota", map[string]interface{}{"quota": "0"}, "params.quota must be positive"},
+		{"versioning type", map[string]interface{}{"versioning": "yes"}, "params.versioning must be a boolean"},
+		{"external quota", map[string]interface{}{"class": "external", "quota": "1Gi"}, "params.quota needs class: platform"},
+		{"platform item", map[string]interface{}{"item": "media-s3"}, "params.item is only used with class: external"},
+	}
+	ctx := Context{Workload: "web", Cluster: "vcluster-media"}
+	for _, tt := range tests {
+		_, err := ParseS3(ctx, "media", score.Resource{Type: "s3", Params: tt.params})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+
+	long := Context{Workload: strings.Repeat("w", 50), Cluster: "vcluster-media"}
+	if _, err := ParseS3(long, "media", score.Resource{Type: "s3"}); err == nil || !strings.Contains(err.Error(), "set params.bucketName") {
+		t.Errorf("long default name: err = %v", err)
+	}
+}
+
+func TestSharedCredentialsItem(t *testing.T) {
+	ctx := Context{Mode: ModeRender, Workload: "myapp", Cluster: "media", Environment: "prod"}
+	tests := []struct {
+		name     string
+		provider ContextProvisioner
+		resource score.Resource
+		want     string
+		wantErr  string
+	}{
+		{"own postgres", &PostgresProvisioner{}, score.Resource{Type: "postgres"}, "myapp-db-db", ""},
+		{"shared postgres", &PostgresProvisioner{}, score.Resource{Type: "postgres", Class: ClassShared}, "shared-postgres-prod", ""},
+		{"shared mysql", &MySQLProvisioner{}, score.Resource{Type: "mysql", Class: ClassShared}, "shared-mysql-prod", ""},
+		{"shared redis", &RedisProvisioner{}, score.Resource{Type: "redis", Class: ClassShared}, "shared-redis-prod", ""},
+		{"explicit item", &PostgresProvisioner{}, score.Resource{Type: "postgres", Class: ClassShared, Params: map[string]interface{}{"item": "legacy-db"}}, "legacy-db", ""},
+		{"bad allowCrossEnvironment", &PostgresProvisioner{}, score.Resource{Type: "postgres", Params: map[string]interface{}{"allowCrossEnvironment": "yes"}}, "", "must be a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.provider.ProvisionContext(ctx, "db", tt.resource)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reqs := res.SecretRequirements(); len(reqs) != 1 || reqs[0].Item != tt.want {
+				t.Errorf("SecretRequirements = %+v, want item %s", reqs, tt.want)
+			}
+		})
+	}
+
+	_, err := (&PostgresProvisioner{}).ProvisionContext(Context{Workload: "myapp", Cluster: "media"}, "db", score.Resource{Type: "postgres", Class: ClassShared})
+	if err == nil || !strings.Contains(err.Error(), "class shared needs the environment of media") {
+		t.Errorf("shared without an environment: err = %v", err)
+	}
+}
+
+func TestPostgresClasses(t *testing.T) {
+	ctx := Context{Mode: ModeRender, Workload: "myapp", Cluster: "media", Environment: "prod"}
+
+	shared, err := (&PostgresProvisioner{}).ProvisionContext(ctx, "db", score.Resource{Type: "postgres", Class: ClassShared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shared.Manifests) != 1 || shared.Manifests[0]["kind"] != "ExternalSecret" || len(shared.Requirements) != 1 {
+		t.Errorf("shared: Manifests = %v, Requirements = %v, want one ExternalSecret and its item", shared.Manifests, shared.Requirements)
+	}
+
+	res, err := (&PostgresProvisioner{}).ProvisionContext(ctx, "db", score.Resource{Type: "postgres", Class: ClassDedicated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Manifests) != 1 || res.Manifests[0]["kind"] != "Cluster" || res.Manifests[0]["apiVersion"] != "postgresql.cnpg.io/v1" {
+		t.Fatalf("Manifests = %v, want one CloudNativePG Cluster", res.Manifests)
+	}
+	if len(res.Requirements) != 0 || len(res.SecretRequirements()) != 0 {
+		t.Errorf("Requirements = %v, want none: CloudNativePG generates the credentials", res.Requirements)
+	}
+	spec := res.Manifests[0]["spec"].(map[string]interface{})
+	storage := spec["storage"].(map[string]interface{})
+	if spec["instances"] != 1 || storage["size"] != "5Gi" || storage["storageClass"] != "democratic-csi-nfs" {
+		t.Errorf("spec = %v, want one instance with 5Gi of NFS storage", spec)
+	}
+	for output, want := range map[string]string{
+		"host":     "myapp-db-rw",
+		"port":     "5432",
+		"database": "app",
+		"username": "$(myapp-db-app:username)",
+		"password": "$(myapp-db-app:password)",
+	} {
+		if got := res.Outputs[output]; got != want {
+			t.Errorf("output %s = %q, want %q", output, got, want)
+		}
+	}
+
+	res, err = (&PostgresProvisioner{}).ProvisionContext(ctx, "db", score.Resource{Type: "postgres", Class: ClassDedicated, Params: map[string]interface{}{"size": "20Gi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := res.Manifests[0]["spec"].(map[string]interface{})["storage"].(map[string]interface{})["size"]; size != "20Gi" {
+		t.Errorf("size = %v, want 20Gi", size)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		params  map[string]interface{}
+		wantErr string
+	}{
+		{"invalid size", map[string]interface{}{"size": "lots"}, "params.size must be a quantity"},
+		{"zero size", map[string]interface{}{"size": "0"}, "params.size must be a quantity"},
+		{"numeric size", map[string]interface{}{"size": 5}, "params.size must be a quantity"},
+		{"item", map[string]interface{}{"item": "legacy-db"}, "params.item only applies to 1Password credentials"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&PostgresProvisioner{}).ProvisionContext(ctx, "db", score.Resource{Type: "postgres", Class: ClassDedicated, Params: tt.params})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestItemEnvironment(t *testing.T) {
+	envs := []string{"prod", "eu", "staging-eu"}
+	for item, want := range map[string]string{
+		"shared-postgres-prod":       "prod",
+		"shared-postgres-staging-eu": "staging-eu",
+		"shared-redis-eu":            "eu",
+		"myapp-db-db":                "",
+		"production":                 "",
+	} {
+		if got := ItemEnvironment(item, envs); got != want {
+			t.Errorf("ItemEnvironment(%q) = %q, want %q", item, got, want)
+		}
+	}
+}
+
+func TestSharedVolume(t *testing.T) {
+	provision := func(workload string, owners map[string]string, params map[string]interface{}) (*ProvisionResult, error) {
+		ctx := Context{Mode: ModeDeploy, Workload: workload, Cluster: "media", SharedVolumes: owners}
+		return (&VolumeProvisioner{}).ProvisionContext(ctx, "library", score.Resource{Type: "volume", ID: "media-library", Params: params})
+	}
+	owner := map[string]interface{}{"shared": true, "size": "50Gi"}
+	mount := map[string]interface{}{"shared": true}
+
+	// The first workload to claim the volume renders its PVC, named by the
+	// resource id and annotated with the owner; so does a redeploy.
+	for _, owners := range []map[string]string{nil, {"media-library": "jellyfin"}} {
+		res, err := provision("jellyfin", owners, owner)
+		if err != nil {
+			t.Fatalf("owner with owners %v: %v", owners, err)
+		}
+		if len(res.Manifests) != 1 || res.Outputs["source"] != "media-library" {
+			t.Fatalf("owner rendered %v with source %q", res.Manifests, res.Outputs["source"])
+		}
+		meta := res.Manifests[0]["metadata"].(map[string]interface{})
+		annotations, _ := meta["annotations"].(map[string]interface{})
+		if meta["name"] != "media-library" || annotations[SharedVolumeOwnerAnnotation] != "jellyfin" {
+			t.Errorf("owner PVC metadata = %v", meta)
+		}
+	}
+
+	// Other workloads only mount it.
+	res, err := provision("exporter", map[string]string{"media-library": "jellyfin"}, mount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Manifests) != 0 || res.Outputs["source"] != "media-library" {
+		t.Errorf("consumer rendered %v with source %q, want only the mount", res.Manifests, res.Outputs["source"])
+	}
+
+	if _, err := provision("exporter", map[string]string{"media-library": "jellyfin"}, owner); err == nil || !strings.Contains(err.Error(), `owned by workload "jellyfin"`) {
+		t.Errorf("second owner: err = %v", err)
+	}
+	if _, err := provision("exporter", nil, mount); err == nil || !strings.Contains(err.Error(), "does not exist on media yet") {
+		t.Errorf("dangling mount: err = %v", err)
+	}
+	if _, err := provision("exporter", nil, map[string]interface{}{"shared": "yes"}); err == nil || !strings.Contains(err.Error(), "params.shared must be a boolean") {
+		t.Errorf("non-boolean shared: err = %v", err)
+	}
+}
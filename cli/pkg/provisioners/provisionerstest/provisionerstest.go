@@ -0,0 +1,93 @@
+// Package provisionerstest checks that provisioners keep the render-mode
+// contract: no network calls and no file writes while rendering.
+//
+// GuardRender disables networking through http.DefaultTransport and moves
+// the test into an empty sandbox directory (also HOME and TMPDIR) for its
+// duration, then makes the registry fail any render-mode provisioner call
+// that tried to reach the network or left a file behind. Because it swaps
+// process-wide state, tests that use it must not run in parallel.
+package provisionerstest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// ErrNetworkDisabled is returned for every request made through
+// http.DefaultTransport while a guard is installed.
+var ErrNetworkDisabled = errors.New("network access is disabled while rendering")
+
+// Sandbox is the state GuardRender watches.
+type Sandbox struct {
+	// Dir is the empty working directory the test runs in.
+	Dir string
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// RoundTrip records and refuses the request.
+func (s *Sandbox) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, req.Method+" "+req.URL.String())
+	s.mu.Unlock()
+	return nil, ErrNetworkDisabled
+}
+
+// Calls returns the requests refused so far.
+func (s *Sandbox) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// Files returns the files written under Dir, relative and sorted.
+func (s *Sandbox) Files() []string {
+	var files []string
+	_ = filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && path != s.Dir {
+			rel, _ := filepath.Rel(s.Dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// GuardRender installs the sandbox for the rest of t and a render guard on
+// r that fails each ModeRender provisioner call with side effects.
+func GuardRender(t testing.TB, r *provisioners.Registry) *Sandbox {
+	t.Helper()
+	s := &Sandbox{Dir: t.TempDir()}
+
+	prev := http.DefaultTransport
+	http.DefaultTransport = s
+	t.Cleanup(func() { http.DefaultTransport = prev })
+	t.Chdir(s.Dir)
+	t.Setenv("HOME", s.Dir)
+	t.Setenv("TMPDIR", s.Dir)
+
+	r.SetRenderGuard(func(resourceType string, provision func() error) error {
+		calls := len(s.Calls())
+		err := provision()
+		if made := s.Calls()[calls:]; len(made) > 0 {
+			return fmt.Errorf("%s provisioner made network calls in render mode: %s", resourceType, strings.Join(made, ", "))
+		}
+		if files := s.Files(); len(files) > 0 {
+			return fmt.Errorf("%s provisioner wrote files in render mode: %s", resourceType, strings.Join(files, ", "))
+		}
+		return err
+	})
+	t.Cleanup(func() { r.SetRenderGuard(nil) })
+	return s
+}
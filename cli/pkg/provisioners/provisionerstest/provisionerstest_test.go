@@ -0,0 +1,103 @@
+package provisionerstest
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// leakyProvisioner checks a chart index over HTTP and caches it on disk,
+// the kind of side effect render mode forbids.
+type leakyProvisioner struct {
+	network, write bool
+}
+
+func (leakyProvisioner) Type() string { return "leaky" }
+
+func (p leakyProvisioner) Provision(name string, _ score.Resource, _ string) (*provisioners.ProvisionResult, error) {
+	if p.network {
+		if resp, err := http.Get("https://charts.example.com/index.yaml"); err == nil {
+			resp.Body.Close()
+		}
+	}
+	if p.write {
+		if err := os.WriteFile("index-cache.yaml", []byte("{}"), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return &provisioners.ProvisionResult{Outputs: map[string]string{"name": name}}, nil
+}
+
+func TestGuardRenderRejectsSideEffects(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       leakyProvisioner
+		wantErr string
+	}{
+		{"network", leakyProvisioner{network: true}, "network calls in render mode: GET https://charts.example.com/index.yaml"},
+		{"file write", leakyProvisioner{write: true}, "wrote files in render mode: index-cache.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := provisioners.NewRegistry()
+			r.Register(tt.p)
+			GuardRender(t, r)
+
+			_, err := r.Provision(provisioners.Context{Mode: provisioners.ModeRender, Workload: "app"}, "idx", score.Resource{Type: "leaky"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Provision() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuardRenderIgnoresDeployMode(t *testing.T) {
+	r := provisioners.NewRegistry()
+	r.Register(leakyProvisioner{write: true})
+	s := GuardRender(t, r)
+
+	if _, err := r.Provision(provisioners.Context{Mode: provisioners.ModeDeploy, Workload: "app"}, "idx", score.Resource{Type: "leaky"}); err != nil {
+		t.Fatalf("Provision() in deploy mode: %v", err)
+	}
+	if files := s.Files(); len(files) != 1 {
+		t.Errorf("sandbox files = %v, want the deploy-mode write", files)
+	}
+}
+
+func TestBuiltinsRenderWithoutSideEffects(t *testing.T) {
+	r := provisioners.NewRegistry()
+	GuardRender(t, r)
+	resources := map[string]score.Resource{
+		"db":     {Type: "postgres"},
+		"cache":  {Type: "redis"},
+		"data":   {Type: "volume"},
+		"dns":    {Type: "dns"},
+		"access": {Type: "rbac", Params: map[string]interface{}{"rules": []interface{}{map[string]interface{}{"apiGroups": []interface{}{""}, "resources": []interface{}{"pods"}, "verbs": []interface{}{"get"}}}}},
+		"web": {Type: "route", Params: map[string]interface{}{
+			"host": "app.example.com", "port": 8080,
+			"basicAuth": map[string]interface{}{"item": "app-htpasswd"},
+		}},
+	}
+	ctx := provisioners.Context{Mode: provisioners.ModeRender, Workload: "app"}
+	var reqs []provisioners.Requirement
+	for name, res := range resources {
+		result, err := r.Provision(ctx, name, res)
+		if err != nil {
+			t.Fatalf("%s: Provision() in render mode: %v", res.Type, err)
+		}
+		reqs = append(reqs, result.Requirements...)
+	}
+
+	var got []string
+	for _, req := range provisioners.SortRequirements(reqs) {
+		got = append(got, string(req.Kind)+":"+req.Ref+"@"+req.Resource)
+	}
+	want := "secret:app-cache-redis@cache secret:app-db-db@db secret:app-htpasswd@web"
+	if strings.Join(got, " ") != want {
+		t.Errorf("requirements = %v, want %s", got, want)
+	}
+}
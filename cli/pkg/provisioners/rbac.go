@@ -0,0 +1,259 @@
+package provisioners
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// --- RBAC Provisioner ---
+
+const (
+	// RBACScopeNamespaced renders a rule into a Role in the workload namespace.
+	RBACScopeNamespaced = "namespaced"
+	// RBACScopeCluster renders a rule into a ClusterRole.
+	RBACScopeCluster = "cluster"
+)
+
+// RBACRule is one entry of an rbac resource's params.rules.
+type RBACRule struct {
+	APIGroups []string
+	Resources []string
+	Verbs     []string
+	// Scope is RBACScopeNamespaced (default) or RBACScopeCluster.
+	Scope string
+}
+
+// RBACSpec is the parsed params of an rbac resource.
+type RBACSpec struct {
+	Rules []RBACRule
+	// AllowWildcards permits "*" verbs or resources in cluster-scoped rules.
+	AllowWildcards bool
+}
+
+// ParseRBAC reads and validates the params of an rbac resource:
+//
+//	params:
+//	  allowWildcards: false
+//	  rules:
+//	    - apiGroups: [""]          # default: core group
+//	      resources: [pods]
+//	      verbs: [get, list, watch]
+//	      scope: namespaced         # namespaced (default) | cluster
+func ParseRBAC(name string, resource score.Resource) (*RBACSpec, error) {
+	spec := &RBACSpec{}
+	if v, ok := resource.Params["allowWildcards"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("rbac resource %q: params.allowWildcards must be a boolean", name)
+		}
+		spec.AllowWildcards = b
+	}
+
+	raw, ok := resource.Params["rules"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("rbac resource %q requires params.rules", name)
+	}
+	for i, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rbac resource %q: params.rules[%d] must be a mapping", name, i)
+		}
+		field := func(key string) ([]string, error) {
+			list, err := stringList(m[key])
+			if err != nil {
+				return nil, fmt.Errorf("rbac resource %q: params.rules[%d].%s %v", name, i, key, err)
+			}
+			return list, nil
+		}
+		var rule RBACRule
+		var err error
+		if rule.APIGroups, err = field("apiGroups"); err != nil {
+			return nil, err
+		}
+		if rule.Resources, err = field("resources"); err != nil {
+			return nil, err
+		}
+		if rule.Verbs, err = field("verbs"); err != nil {
+			return nil, err
+		}
+		if len(rule.APIGroups) == 0 {
+			rule.APIGroups = []string{""}
+		}
+		if len(rule.Resources) == 0 || len(rule.Verbs) == 0 {
+			return nil, fmt.Errorf("rbac resource %q: params.rules[%d] requires resources and verbs", name, i)
+		}
+		rule.Scope, _ = m["scope"].(string)
+		switch rule.Scope {
+		case "":
+			rule.Scope = RBACScopeNamespaced
+		case RBACScopeNamespaced, RBACScopeCluster:
+		default:
+			return nil, fmt.Errorf("rbac resource %q: params.rules[%d].scope %q is not namespaced or cluster", name, i, rule.Scope)
+		}
+		spec.Rules = append(spec.Rules, rule)
+	}
+
+	if wild := spec.ClusterWildcards(); len(wild) > 0 && !spec.AllowWildcards {
+		return nil, fmt.Errorf("rbac resource %q grants cluster-wide wildcards (%s); list verbs and resources explicitly or set params.allowWildcards: true",
+			name, strings.Join(wild, "; "))
+	}
+	return spec, nil
+}
+
+// ClusterWildcards describes the cluster-scoped rules that use "*" in verbs
+// or resources, in rule order.
+func (s *RBACSpec) ClusterWildcards() []string {
+	var out []string
+	for i, r := range s.Rules {
+		if r.Scope != RBACScopeCluster {
+			continue
+		}
+		var what []string
+		if contains(r.Verbs, "*") {
+			what = append(what, "verbs")
+		}
+		if contains(r.Resources, "*") {
+			what = append(what, "resources")
+		}
+		if len(what) > 0 {
+			out = append(out, fmt.Sprintf("rules[%d] %s", i, strings.Join(what, " and ")))
+		}
+	}
+	return out
+}
+
+// RBACProvisioner generates a ServiceAccount for the workload plus a Role
+// and/or ClusterRole with bindings for the declared rules. All are named
+// after the workload; the translator points the pods at the ServiceAccount.
+type RBACProvisioner struct{}
+
+func (p *RBACProvisioner) Type() string { return "rbac" }
+
+// Provision is ProvisionContext in deploy mode.
+func (p *RBACProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *RBACProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
+	spec, err := ParseRBAC(name, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaced, cluster []interface{}
+	for _, r := range spec.Rules {
+		rule := map[string]interface{}{
+			"apiGroups": r.APIGroups,
+			"resources": r.Resources,
+			"verbs":     r.Verbs,
+		}
+		if r.Scope == RBACScopeCluster {
+			cluster = append(cluster, rule)
+		} else {
+			namespaced = append(namespaced, rule)
+		}
+	}
+
+	// The binding subject's namespace is filled in by the translator along
+	// with the metadata namespace of the namespaced objects.
+	subject := func() []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"kind": "ServiceAccount",
+				"name": workloadName,
+			},
+		}
+	}
+
+	manifests := []map[string]interface{}{{
+		"apiVersion": "v1",
+		"kind":       "ServiceAccount",
+		"metadata": map[string]interface{}{
+			"name": workloadName,
+		},
+	}}
+	if len(namespaced) > 0 {
+		manifests = append(manifests,
+			map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "Role",
+				"metadata":   map[string]interface{}{"name": workloadName},
+				"rules":      namespaced,
+			},
+			map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "RoleBinding",
+				"metadata":   map[string]interface{}{"name": workloadName},
+				"roleRef": map[string]interface{}{
+					"apiGroup": "rbac.authorization.k8s.io",
+					"kind":     "Role",
+					"name":     workloadName,
+				},
+				"subjects": subject(),
+			},
+		)
+	}
+	if len(cluster) > 0 {
+		manifests = append(manifests,
+			map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "ClusterRole",
+				"metadata":   map[string]interface{}{"name": workloadName},
+				"rules":      cluster,
+			},
+			map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "ClusterRoleBinding",
+				"metadata":   map[string]interface{}{"name": workloadName},
+				"roleRef": map[string]interface{}{
+					"apiGroup": "rbac.authorization.k8s.io",
+					"kind":     "ClusterRole",
+					"name":     workloadName,
+				},
+				"subjects": subject(),
+			},
+		)
+	}
+
+	return &ProvisionResult{
+		Outputs: map[string]string{
+			"serviceAccountName": workloadName,
+		},
+		Manifests: manifests,
+	}, nil
+}
+
+// stringList converts a decoded YAML list of strings.
+func stringList(v interface{}) ([]string, error) {
+	switch list := v.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return list, nil
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of strings")
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("must be a list of strings")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,672 @@
+package provisioners
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// --- Route Provisioner ---
+
+// GatewayNGINXFabric is the Gateway API implementation the platform runs.
+// Basic auth and rate limits are rendered as its policy and filter CRs.
+const GatewayNGINXFabric = "nginx-gateway-fabric"
+
+// RouteSpec is the parsed params of a route resource.
+type RouteSpec struct {
+	// Name is the route resource name.
+	Name string
+	Host string
+	// Path and Port are the single-rule shorthand; both are empty when the
+	// route declares PathRules.
+	Path string
+	Port int
+	// PathRules route each path to its own port, and optionally its own
+	// Service, within one HTTPRoute.
+	PathRules []RouteRule
+	// Redirect, when set, answers every request with a redirect instead of
+	// forwarding it to the workload.
+	Redirect  *RouteRedirect
+	Headers   *RouteHeaders
+	BasicAuth *RouteBasicAuth
+	RateLimit *RouteRateLimit
+}
+
+// Path match types of a RouteRule.
+const (
+	PathTypePrefix = "PathPrefix"
+	PathTypeExact  = "Exact"
+)
+
+// RouteRule sends one path to a Service port.
+type RouteRule struct {
+	Path     string
+	PathType string
+	Port     int
+	// Service names the backend Service; empty means the workload's own.
+	Service string
+}
+
+// RouteRedirect is a RequestRedirect filter. Empty fields keep the value of
+// the original request.
+type RouteRedirect struct {
+	Scheme   string
+	Hostname string
+	Port     int
+	// Path replaces the matched path prefix; empty keeps the request path.
+	Path       string
+	StatusCode int
+}
+
+// RouteHeaders is a ResponseHeaderModifier filter.
+type RouteHeaders struct {
+	Set    map[string]string
+	Add    map[string]string
+	Remove []string
+}
+
+// RouteBasicAuth gates the route behind HTTP basic auth. Exactly one of
+// Secret and Item is set.
+type RouteBasicAuth struct {
+	// Secret is an existing Secret of type nginx.org/htpasswd.
+	Secret string
+	// Item is a 1Password item whose htpasswd field holds the users.
+	Item  string
+	Realm string
+}
+
+// RouteRateLimit limits requests per client address.
+type RouteRateLimit struct {
+	RequestsPerSecond int
+	Burst             int
+}
+
+// headerName matches an HTTP header name as Gateway API accepts it.
+var headerName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// ParseRoute reads and validates the params of a route resource:
+//
+//	params:
+//	  host: www.example.com       # required
+//	  path: /                     # default: /
+//	  port: 8080                  # default: 8080
+//	  redirectTo: https://example.com   # redirect instead of serving
+//	  redirectCode: 301           # 301 (default) | 302
+//	  headers:                    # response headers
+//	    set: {Strict-Transport-Security: max-age=31536000}
+//	    add: {X-Frame-Options: DENY}
+//	    remove: [Server]
+//	  basicAuth:
+//	    item: web-staging-htpasswd  # 1Password item with an htpasswd field
+//	    # secret: staging-htpasswd  # or an existing nginx.org/htpasswd Secret
+//	    realm: Staging
+//	  rateLimit: 10               # requests per second, or {requestsPerSecond: 10, burst: 20}
+//
+// Instead of path and port, rules routes several paths of the host, each to
+// its own port, in one HTTPRoute:
+//
+//	rules:
+//	  - path: /api
+//	    port: 8080
+//	  - path: /
+//	    port: 8081
+//	    pathType: PathPrefix          # PathPrefix (default) | Exact
+//	    serviceNameOverride: web-assets   # default: the workload's Service
+//
+// Paths must be unique per path type. Rules are rendered most specific
+// first (Exact before PathPrefix, then longer paths), whatever order they are
+// declared in.
+func ParseRoute(name string, resource score.Resource) (*RouteSpec, error) {
+	params := resource.Params
+	spec := &RouteSpec{Name: name, Port: 8080, Path: "/"}
+	spec.Host, _ = params["host"].(string)
+	if spec.Host == "" {
+		return nil, fmt.Errorf("route resource %q requires params.host", name)
+	}
+	if p, ok := params["path"].(string); ok && p != "" {
+		spec.Path = p
+	}
+	if v, ok := params["port"]; ok {
+		port, ok := intValue(v)
+		if !ok || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("route resource %q: params.port must be a port number", name)
+		}
+		spec.Port = port
+	}
+
+	var err error
+	if v, ok := params["rules"]; ok {
+		for _, key := range []string{"path", "port", "redirectTo"} {
+			if _, set := params[key]; set {
+				return nil, fmt.Errorf("route resource %q: params.rules cannot be combined with params.%s", name, key)
+			}
+		}
+		if spec.PathRules, err = parseRouteRules(v); err != nil {
+			return nil, fmt.Errorf("route resource %q: params.rules%v", name, err)
+		}
+		spec.Path, spec.Port = "", 0
+	}
+	if v, ok := params["redirectTo"]; ok {
+		target, _ := v.(string)
+		code := 301
+		if c, ok := params["redirectCode"]; ok {
+			if code, ok = intValue(c); !ok || (code != 301 && code != 302) {
+				return nil, fmt.Errorf("route resource %q: params.redirectCode must be 301 or 302", name)
+			}
+		}
+		if spec.Redirect, err = parseRedirect(target, code); err != nil {
+			return nil, fmt.Errorf("route resource %q: params.redirectTo %v", name, err)
+		}
+	} else if _, ok := params["redirectCode"]; ok {
+		return nil, fmt.Errorf("route resource %q: params.redirectCode needs params.redirectTo", name)
+	}
+	if v, ok := params["headers"]; ok {
+		if spec.Headers, err = parseHeaders(v); err != nil {
+			return nil, fmt.Errorf("route resource %q: params.headers%v", name, err)
+		}
+	}
+	if v, ok := params["basicAuth"]; ok {
+		if spec.BasicAuth, err = parseBasicAuth(v); err != nil {
+			return nil, fmt.Errorf("route resource %q: params.basicAuth %v", name, err)
+		}
+	}
+	if v, ok := params["rateLimit"]; ok {
+		if spec.RateLimit, err = parseRateLimit(v); err != nil {
+			return nil, fmt.Errorf("route resource %q: params.rateLimit %v", name, err)
+		}
+	}
+
+	if spec.Redirect != nil && spec.BasicAuth != nil {
+		return nil, fmt.Errorf("route resource %q: params.redirectTo cannot be combined with basicAuth; a redirect never reaches the workload, so there is nothing to protect", name)
+	}
+	return spec, nil
+}
+
+// Supported checks that gateway implements every option the route uses.
+func (s *RouteSpec) Supported(gateway string) error {
+	if gateway == GatewayNGINXFabric {
+		return nil
+	}
+	var unsupported []string
+	if s.BasicAuth != nil {
+		unsupported = append(unsupported, "basicAuth")
+	}
+	if s.RateLimit != nil {
+		unsupported = append(unsupported, "rateLimit")
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("route resource %q uses %s, which need %s; the gateway is %s",
+			s.Name, strings.Join(unsupported, " and "), GatewayNGINXFabric, gateway)
+	}
+	return nil
+}
+
+// ObjectName is the name of the HTTPRoute and the objects generated with it.
+func (s *RouteSpec) ObjectName(workloadName string) string {
+	return fmt.Sprintf("%s-%s", workloadName, s.Name)
+}
+
+// Backends returns the route's path rules: PathRules, or the shorthand path
+// and port as a single PathPrefix rule.
+func (s *RouteSpec) Backends() []RouteRule {
+	if len(s.PathRules) > 0 {
+		return s.PathRules
+	}
+	return []RouteRule{{Path: s.Path, PathType: PathTypePrefix, Port: s.Port}}
+}
+
+// Rules returns the HTTPRoute rules for the route, one per path rule, each
+// sending traffic to its Service unless the route redirects. Filters apply
+// to every rule.
+func (s *RouteSpec) Rules(workloadName string) []interface{} {
+	filters := s.filters(workloadName)
+	var rules []interface{}
+	for _, b := range s.Backends() {
+		rule := map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":  b.PathType,
+						"value": b.Path,
+					},
+				},
+			},
+		}
+		if len(filters) > 0 {
+			rule["filters"] = filters
+		}
+		// Gateway API rejects a rule that both redirects and has backends.
+		if s.Redirect == nil {
+			service := b.Service
+			if service == "" {
+				service = workloadName
+			}
+			rule["backendRefs"] = []interface{}{
+				map[string]interface{}{
+					"name": service,
+					"port": b.Port,
+				},
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// filters returns the HTTPRoute filters shared by every rule of the route.
+func (s *RouteSpec) filters(workloadName string) []interface{} {
+	var filters []interface{}
+	if r := s.Redirect; r != nil {
+		redirect := map[string]interface{}{"statusCode": r.StatusCode}
+		if r.Scheme != "" {
+			redirect["scheme"] = r.Scheme
+		}
+		if r.Hostname != "" {
+			redirect["hostname"] = r.Hostname
+		}
+		if r.Port != 0 {
+			redirect["port"] = r.Port
+		}
+		if r.Path != "" {
+			redirect["path"] = map[string]interface{}{
+				"type":               "ReplacePrefixMatch",
+				"replacePrefixMatch": r.Path,
+			}
+		}
+		filters = append(filters, map[string]interface{}{
+			"type":            "RequestRedirect",
+			"requestRedirect": redirect,
+		})
+	}
+	if h := s.Headers; h != nil {
+		modifier := map[string]interface{}{}
+		if len(h.Set) > 0 {
+			modifier["set"] = headerList(h.Set)
+		}
+		if len(h.Add) > 0 {
+			modifier["add"] = headerList(h.Add)
+		}
+		if len(h.Remove) > 0 {
+			modifier["remove"] = h.Remove
+		}
+		filters = append(filters, map[string]interface{}{
+			"type":                   "ResponseHeaderModifier",
+			"responseHeaderModifier": modifier,
+		})
+	}
+	if s.BasicAuth != nil {
+		filters = append(filters, map[string]interface{}{
+			"type": "ExtensionRef",
+			"extensionRef": map[string]interface{}{
+				"group": "gateway.nginx.org",
+				"kind":  "AuthenticationFilter",
+				"name":  s.ObjectName(workloadName) + "-basic-auth",
+			},
+		})
+	}
+	return filters
+}
+
+// Manifests returns the HTTPRoute plus the filter and policy objects its
+// options need. The rate-limit policy also targets the HTTPRoute named after
+// the workload, which the application chart renders from the same route.
+func (s *RouteSpec) Manifests(workloadName string) []map[string]interface{} {
+	routeName := s.ObjectName(workloadName)
+	manifests := []map[string]interface{}{{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata": map[string]interface{}{
+			"name": routeName,
+		},
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{
+					"name":      "nginx",
+					"namespace": "nginx-gateway",
+				},
+			},
+			"hostnames": []string{s.Host},
+			"rules":     s.Rules(workloadName),
+		},
+	}}
+
+	if a := s.BasicAuth; a != nil {
+		authName := routeName + "-basic-auth"
+		secret := a.Secret
+		if a.Item != "" {
+			secret = authName
+			manifests = append(manifests, map[string]interface{}{
+				"apiVersion": "external-secrets.io/v1beta1",
+				"kind":       "ExternalSecret",
+				"metadata": map[string]interface{}{
+					"name": authName,
+				},
+				"spec": map[string]interface{}{
+					"secretStoreRef": map[string]interface{}{
+						"name": "onepassword-connect",
+						"kind": "ClusterSecretStore",
+					},
+					"target": map[string]interface{}{
+						"name": authName,
+						"template": map[string]interface{}{
+							"type": "nginx.org/htpasswd",
+						},
+					},
+					"data": []interface{}{
+						map[string]interface{}{
+							"secretKey": "auth",
+							"remoteRef": map[string]interface{}{"key": a.Item, "property": "htpasswd"},
+						},
+					},
+				},
+			})
+		}
+		manifests = append(manifests, map[string]interface{}{
+			"apiVersion": "gateway.nginx.org/v1alpha1",
+			"kind":       "AuthenticationFilter",
+			"metadata": map[string]interface{}{
+				"name": authName,
+			},
+			"spec": map[string]interface{}{
+				"type": "Basic",
+				"basic": map[string]interface{}{
+					"secretRef": map[string]interface{}{"name": secret},
+					"realm":     a.Realm,
+				},
+			},
+		})
+	}
+
+	if r := s.RateLimit; r != nil {
+		rule := map[string]interface{}{
+			"key":      "$binary_remote_addr",
+			"rate":     fmt.Sprintf("%dr/s", r.RequestsPerSecond),
+			"zoneSize": "10m",
+		}
+		if r.Burst > 0 {
+			rule["burst"] = r.Burst
+		}
+		targets := []interface{}{}
+		for _, target := range []string{workloadName, routeName} {
+			targets = append(targets, map[string]interface{}{
+				"group": "gateway.networking.k8s.io",
+				"kind":  "HTTPRoute",
+				"name":  target,
+			})
+		}
+		manifests = append(manifests, map[string]interface{}{
+			"apiVersion": "gateway.nginx.org/v1alpha1",
+			"kind":       "RateLimitPolicy",
+			"metadata": map[string]interface{}{
+				"name": routeName + "-rate-limit",
+			},
+			"spec": map[string]interface{}{
+				"targetRefs": targets,
+				"rateLimit": map[string]interface{}{
+					"local": map[string]interface{}{
+						"rules": []interface{}{rule},
+					},
+				},
+			},
+		})
+	}
+	return manifests
+}
+
+// RouteProvisioner generates HTTPRoute resources for Gateway API.
+type RouteProvisioner struct {
+	// Gateway is the Gateway API implementation serving routes; empty means
+	// GatewayNGINXFabric.
+	Gateway string
+}
+
+func (p *RouteProvisioner) Type() string { return "route" }
+
+// Provision is ProvisionContext in deploy mode.
+func (p *RouteProvisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *RouteProvisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	workloadName := ctx.Workload
+	spec, err := ParseRoute(name, resource)
+	if err != nil {
+		return nil, err
+	}
+	gateway := p.Gateway
+	if gateway == "" {
+		gateway = GatewayNGINXFabric
+	}
+	if err := spec.Supported(gateway); err != nil {
+		return nil, err
+	}
+
+	// Basic auth reads its htpasswd from 1Password.
+	return (&ProvisionResult{
+		Outputs:   map[string]string{},
+		Manifests: spec.Manifests(workloadName),
+	}).requireSecrets(), nil
+}
+
+// parseRedirect reads a redirect target: a URL, or a bare host that keeps
+// the request's scheme.
+func parseRedirect(target string, code int) (*RouteRedirect, error) {
+	if target == "" {
+		return nil, fmt.Errorf("must be a URL or host name")
+	}
+	raw := target
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("%q must be a URL or host name", target)
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q: scheme must be http or https", target)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, fmt.Errorf("%q: only scheme, host, port and path can be redirected", target)
+	}
+	r := &RouteRedirect{Scheme: u.Scheme, Hostname: u.Hostname(), StatusCode: code}
+	if p := u.Port(); p != "" {
+		r.Port, _ = strconv.Atoi(p)
+	}
+	if u.Path != "" && u.Path != "/" {
+		r.Path = u.Path
+	}
+	return r, nil
+}
+
+// parseRouteRules reads params.rules and sorts the rules most specific
+// first, so the rendered HTTPRoute does not depend on declaration order.
+func parseRouteRules(v interface{}) ([]RouteRule, error) {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf(" must be a non-empty list of {path, port}")
+	}
+	var rules []RouteRule
+	seen := map[string]bool{}
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("[%d] must be a mapping with path and port", i)
+		}
+		for key := range m {
+			switch key {
+			case "path", "port", "pathType", "serviceNameOverride":
+			default:
+				return nil, fmt.Errorf("[%d]: unknown key %q (want path, port, pathType or serviceNameOverride)", i, key)
+			}
+		}
+		r := RouteRule{PathType: PathTypePrefix}
+		r.Path, _ = m["path"].(string)
+		if !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("[%d].path must be an absolute path", i)
+		}
+		port, ok := intValue(m["port"])
+		if !ok || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("[%d].port must be a port number", i)
+		}
+		r.Port = port
+		if pt, present := m["pathType"]; present {
+			r.PathType, _ = pt.(string)
+			if r.PathType != PathTypePrefix && r.PathType != PathTypeExact {
+				return nil, fmt.Errorf("[%d].pathType must be %s or %s", i, PathTypePrefix, PathTypeExact)
+			}
+		}
+		if svc, present := m["serviceNameOverride"]; present {
+			r.Service, _ = svc.(string)
+			if errs := validation.IsDNS1035Label(r.Service); len(errs) > 0 {
+				return nil, fmt.Errorf("[%d].serviceNameOverride %q is not a valid Service name: %s", i, r.Service, strings.Join(errs, "; "))
+			}
+		}
+		key := r.PathType + " " + r.Path
+		if seen[key] {
+			return nil, fmt.Errorf("[%d]: duplicate %s path %s", i, r.PathType, r.Path)
+		}
+		seen[key] = true
+		rules = append(rules, r)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.PathType != b.PathType {
+			return a.PathType == PathTypeExact
+		}
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) > len(b.Path)
+		}
+		return a.Path < b.Path
+	})
+	return rules, nil
+}
+
+func parseHeaders(v interface{}) (*RouteHeaders, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(" must be a mapping of set, add and remove")
+	}
+	h := &RouteHeaders{}
+	for key, val := range m {
+		switch key {
+		case "set", "add":
+			values, ok := val.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf(".%s must map header names to values", key)
+			}
+			headers := make(map[string]string, len(values))
+			for name, hv := range values {
+				if !headerName.MatchString(name) {
+					return nil, fmt.Errorf(".%s: %q is not a valid header name", key, name)
+				}
+				switch hv := hv.(type) {
+				case string:
+					headers[name] = hv
+				case int, bool:
+					headers[name] = fmt.Sprint(hv)
+				default:
+					return nil, fmt.Errorf(".%s.%s must be a string", key, name)
+				}
+			}
+			if key == "set" {
+				h.Set = headers
+			} else {
+				h.Add = headers
+			}
+		case "remove":
+			names, err := stringList(val)
+			if err != nil {
+				return nil, fmt.Errorf(".remove %v", err)
+			}
+			for _, name := range names {
+				if !headerName.MatchString(name) {
+					return nil, fmt.Errorf(".remove: %q is not a valid header name", name)
+				}
+			}
+			h.Remove = names
+		default:
+			return nil, fmt.Errorf(": unknown key %q (want set, add or remove)", key)
+		}
+	}
+	if len(h.Set)+len(h.Add)+len(h.Remove) == 0 {
+		return nil, fmt.Errorf(" sets, adds or removes no headers")
+	}
+	return h, nil
+}
+
+func parseBasicAuth(v interface{}) (*RouteBasicAuth, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a mapping with secret or item")
+	}
+	a := &RouteBasicAuth{Realm: "Restricted"}
+	a.Secret, _ = m["secret"].(string)
+	a.Item, _ = m["item"].(string)
+	if realm, ok := m["realm"].(string); ok && realm != "" {
+		a.Realm = realm
+	}
+	if (a.Secret == "") == (a.Item == "") {
+		return nil, fmt.Errorf("needs exactly one of secret (an nginx.org/htpasswd Secret) or item (a 1Password item)")
+	}
+	return a, nil
+}
+
+func parseRateLimit(v interface{}) (*RouteRateLimit, error) {
+	r := &RouteRateLimit{}
+	if m, ok := v.(map[string]interface{}); ok {
+		r.RequestsPerSecond, ok = intValue(m["requestsPerSecond"])
+		if !ok {
+			return nil, fmt.Errorf("needs requestsPerSecond")
+		}
+		if b, present := m["burst"]; present {
+			if r.Burst, ok = intValue(b); !ok || r.Burst < 0 {
+				return nil, fmt.Errorf("burst must be a non-negative integer")
+			}
+		}
+	} else if rps, ok := intValue(v); ok {
+		r.RequestsPerSecond = rps
+	} else {
+		return nil, fmt.Errorf("must be requests per second or {requestsPerSecond, burst}")
+	}
+	if r.RequestsPerSecond < 1 {
+		return nil, fmt.Errorf("requestsPerSecond must be at least 1")
+	}
+	return r, nil
+}
+
+// headerList renders headers as Gateway API name/value pairs, by name.
+func headerList(headers map[string]string) []interface{} {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string]interface{}{"name": name, "value": headers[name]})
+	}
+	return out
+}
+
+// intValue converts a decoded YAML or JSON number to an int.
+func intValue(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,394 @@
+package provisioners
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// --- S3 Provisioner ---
+
+const (
+	// S3ClassPlatform creates the bucket and its credentials in the
+	// platform MinIO (default).
+	S3ClassPlatform = "platform"
+	// S3ClassExternal only reads existing credentials from 1Password.
+	S3ClassExternal = "external"
+
+	// BucketAnnotation records the bucket on the credentials ExternalSecret,
+	// so buckets declared by other workloads can be found in the repo.
+	BucketAnnotation = "hctl.integratn.tech/s3-bucket"
+
+	// DefaultS3Endpoint is the platform MinIO S3 API.
+	DefaultS3Endpoint = "https://minio.integratn.tech"
+	// DefaultS3Region is the region MinIO reports unless configured.
+	DefaultS3Region = "us-east-1"
+	// minioAdminItem is the 1Password item holding the MinIO admin
+	// credentials the bucket Job uses.
+	minioAdminItem = "minio-admin"
+	// minioClientImage runs the bucket Job.
+	minioClientImage = "minio/mc:RELEASE.2024-10-02T08-27-28Z"
+)
+
+// S3Spec is the parsed params of an s3 resource.
+type S3Spec struct {
+	Class  string
+	Bucket string
+	// Versioning enables object versioning on the bucket.
+	Versioning bool
+	// Quota is the bucket's hard size limit; empty means none.
+	Quota string
+	// Endpoint and Region are the S3 API the workload connects to.
+	Endpoint string
+	Region   string
+	// Item is the 1Password item with the accessKey and secretKey fields of
+	// an external bucket.
+	Item string
+}
+
+// bucketNameRegex matches the characters allowed in a bucket name.
+var bucketNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`)
+
+// ParseS3 reads and validates the params of an s3 resource:
+//
+//	params:
+//	  class: platform        # platform (default) | external
+//	  bucketName: media      # default: <cluster>-<workload>-<resource>
+//	  versioning: true       # platform only
+//	  quota: 10Gi            # platform only; a Kubernetes quantity
+//	  endpoint: https://...  # default: DefaultS3Endpoint
+//	  region: us-east-1
+//	  item: media-s3         # external only; default: <workload>-<resource>-s3
+func ParseS3(ctx Context, name string, resource score.Resource) (*S3Spec, error) {
+	params := resource.Params
+	spec := &S3Spec{Class: S3ClassPlatform, Endpoint: DefaultS3Endpoint, Region: DefaultS3Region}
+	str := func(key string, dst *string) error {
+		v, ok := params[key]
+		if !ok {
+			return nil
+		}
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return fmt.Errorf("s3 resource %q: params.%s must be a non-empty string", name, key)
+		}
+		*dst = s
+		return nil
+	}
+	for _, f := range []struct {
+		key string
+		dst *string
+	}{
+		{"class", &spec.Class},
+		{"bucketName", &spec.Bucket},
+		{"endpoint", &spec.Endpoint},
+		{"region", &spec.Region},
+		{"item", &spec.Item},
+	} {
+		if err := str(f.key, f.dst); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := params["versioning"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("s3 resource %q: params.versioning must be a boolean", name)
+		}
+		spec.Versioning = b
+	}
+	if v, ok := params["quota"]; ok {
+		q, err := parseQuota(v)
+		if err != nil {
+			return nil, fmt.Errorf("s3 resource %q: params.quota %v", name, err)
+		}
+		spec.Quota = q
+	}
+
+	switch spec.Class {
+	case S3ClassPlatform:
+		if spec.Item != "" {
+			return nil, fmt.Errorf("s3 resource %q: params.item is only used with class: external; platform buckets get generated credentials", name)
+		}
+	case S3ClassExternal:
+		for _, key := range []string{"versioning", "quota"} {
+			if _, set := params[key]; set {
+				return nil, fmt.Errorf("s3 resource %q: params.%s needs class: platform; hctl does not manage external buckets", name, key)
+			}
+		}
+		if spec.Item == "" {
+			spec.Item = fmt.Sprintf("%s-%s-s3", ctx.Workload, name)
+		}
+	default:
+		return nil, fmt.Errorf("s3 resource %q: params.class %q is not platform or external", name, spec.Class)
+	}
+
+	if spec.Bucket == "" {
+		spec.Bucket = strings.Join(nonEmpty(ctx.Cluster, ctx.Workload, name), "-")
+		if err := ValidateBucketName(spec.Bucket); err != nil {
+			return nil, fmt.Errorf("s3 resource %q: default bucket name %q %v; set params.bucketName", name, spec.Bucket, err)
+		}
+	} else if err := ValidateBucketName(spec.Bucket); err != nil {
+		return nil, fmt.Errorf("s3 resource %q: params.bucketName %q %v", name, spec.Bucket, err)
+	}
+	return spec, nil
+}
+
+// ValidateBucketName checks name against the S3 bucket naming rules.
+func ValidateBucketName(name string) error {
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		return fmt.Errorf("must be 3 to 63 characters long")
+	case !bucketNameRegex.MatchString(name):
+		return fmt.Errorf("must contain only lowercase letters, digits, dots and hyphens, and start and end with a letter or digit")
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("must not contain adjacent dots")
+	case net.ParseIP(name) != nil:
+		return fmt.Errorf("must not be formatted as an IP address")
+	case strings.HasPrefix(name, "xn--") || strings.HasPrefix(name, "sthree-"):
+		return fmt.Errorf("must not start with xn-- or sthree-")
+	case strings.HasSuffix(name, "-s3alias") || strings.HasSuffix(name, "--ol-s3"):
+		return fmt.Errorf("must not end with -s3alias or --ol-s3")
+	}
+	return nil
+}
+
+// parseQuota reads a positive quantity and returns it in bytes.
+func parseQuota(v interface{}) (string, error) {
+	var s string
+	switch q := v.(type) {
+	case string:
+		s = q
+	case int:
+		s = fmt.Sprint(q)
+	default:
+		return "", fmt.Errorf("must be a quantity such as 10Gi")
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return "", fmt.Errorf("must be a quantity such as 10Gi: %v", err)
+	}
+	if q.Sign() <= 0 {
+		return "", fmt.Errorf("must be positive")
+	}
+	return fmt.Sprint(q.Value()), nil
+}
+
+// nonEmpty returns the non-empty strings, in order.
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// S3Provisioner generates the credentials Secret for an S3 bucket and, for
+// platform buckets, the Job that creates the bucket and its user in MinIO.
+type S3Provisioner struct{}
+
+func (p *S3Provisioner) Type() string { return "s3" }
+
+// Provision is ProvisionContext in deploy mode.
+func (p *S3Provisioner) Provision(name string, resource score.Resource, workloadName string) (*ProvisionResult, error) {
+	return p.ProvisionContext(Context{Mode: ModeDeploy, Workload: workloadName}, name, resource)
+}
+
+// ProvisionContext renders the same resources in every mode without I/O.
+func (p *S3Provisioner) ProvisionContext(ctx Context, name string, resource score.Resource) (*ProvisionResult, error) {
+	spec, err := ParseS3(ctx, name, resource)
+	if err != nil {
+		return nil, err
+	}
+	secretName := fmt.Sprintf("%s-%s-credentials", ctx.Workload, name)
+
+	var manifests []map[string]interface{}
+	if spec.Class == S3ClassExternal {
+		manifests = append(manifests, s3ExternalSecret(secretName, spec, map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{
+					"secretKey": "accessKey",
+					"remoteRef": map[string]interface{}{"key": spec.Item, "property": "accessKey"},
+				},
+				map[string]interface{}{
+					"secretKey": "secretKey",
+					"remoteRef": map[string]interface{}{"key": spec.Item, "property": "secretKey"},
+				},
+			},
+		}))
+	} else {
+		prefix := fmt.Sprintf("%s-%s-s3", ctx.Workload, name)
+		manifests = append(manifests,
+			s3KeyGenerator(prefix+"-access-key", 20),
+			s3KeyGenerator(prefix+"-secret-key", 40),
+			s3ExternalSecret(secretName, spec, map[string]interface{}{
+				// Generated once; later refreshes would rotate the keys
+				// out from under the MinIO user.
+				"refreshInterval": "0",
+				"dataFrom": []interface{}{
+					s3GeneratedKey(prefix+"-access-key", "accessKey"),
+					s3GeneratedKey(prefix+"-secret-key", "secretKey"),
+				},
+			}),
+			s3ExternalSecret(prefix+"-admin", nil, map[string]interface{}{
+				"data": []interface{}{
+					map[string]interface{}{
+						"secretKey": "username",
+						"remoteRef": map[string]interface{}{"key": minioAdminItem, "property": "username"},
+					},
+					map[string]interface{}{
+						"secretKey": "password",
+						"remoteRef": map[string]interface{}{"key": minioAdminItem, "property": "password"},
+					},
+				},
+			}),
+			s3BucketJob(prefix+"-bucket", secretName, prefix+"-admin", spec),
+		)
+	}
+
+	return (&ProvisionResult{
+		Outputs: map[string]string{
+			"bucket":    spec.Bucket,
+			"endpoint":  spec.Endpoint,
+			"region":    spec.Region,
+			"accessKey": fmt.Sprintf("$(%s:accessKey)", secretName),
+			"secretKey": fmt.Sprintf("$(%s:secretKey)", secretName),
+		},
+		Manifests: manifests,
+	}).requireSecrets(), nil
+}
+
+// s3ExternalSecret builds an ExternalSecret from the 1Password store with
+// the given spec fields. A non-nil spec records its bucket in
+// BucketAnnotation.
+func s3ExternalSecret(name string, spec *S3Spec, fields map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name}
+	if spec != nil {
+		metadata["annotations"] = map[string]interface{}{BucketAnnotation: spec.Bucket}
+	}
+	esSpec := map[string]interface{}{
+		"secretStoreRef": map[string]interface{}{
+			"name": "onepassword-connect",
+			"kind": "ClusterSecretStore",
+		},
+		"target": map[string]interface{}{
+			"name": name,
+		},
+	}
+	for k, v := range fields {
+		esSpec[k] = v
+	}
+	return map[string]interface{}{
+		"apiVersion": "external-secrets.io/v1beta1",
+		"kind":       "ExternalSecret",
+		"metadata":   metadata,
+		"spec":       esSpec,
+	}
+}
+
+// s3KeyGenerator is an ESO Password generator for an alphanumeric key.
+func s3KeyGenerator(name string, length int) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "generators.external-secrets.io/v1alpha1",
+		"kind":       "Password",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"length":      length,
+			"digits":      6,
+			"symbols":     0,
+			"noUpper":     false,
+			"allowRepeat": true,
+		},
+	}
+}
+
+// s3GeneratedKey is a dataFrom entry storing a Password generator's output
+// under key.
+func s3GeneratedKey(generator, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"sourceRef": map[string]interface{}{
+			"generatorRef": map[string]interface{}{
+				"apiVersion": "generators.external-secrets.io/v1alpha1",
+				"kind":       "Password",
+				"name":       generator,
+			},
+		},
+		"rewrite": []interface{}{
+			map[string]interface{}{
+				"regexp": map[string]interface{}{"source": "^password$", "target": key},
+			},
+		},
+	}
+}
+
+// s3BucketScript creates the bucket and a user limited to it. Every step is
+// idempotent, so the Job can run on each sync.
+const s3BucketScript = `set -eu
+mc alias set platform "$S3_ENDPOINT" "$MINIO_ADMIN_USER" "$MINIO_ADMIN_PASSWORD"
+mc mb --ignore-existing "platform/$BUCKET"
+if [ "$VERSIONING" = "true" ]; then mc version enable "platform/$BUCKET"; fi
+if [ -n "$QUOTA_BYTES" ]; then mc quota set "platform/$BUCKET" --size "$QUOTA_BYTES"; else mc quota clear "platform/$BUCKET"; fi
+cat > /tmp/policy.json <<EOF
+{"Version": "2012-10-17", "Statement": [
+  {"Effect": "Allow", "Action": ["s3:*"], "Resource": ["arn:aws:s3:::$BUCKET", "arn:aws:s3:::$BUCKET/*"]}
+]}
+EOF
+mc admin policy create platform "$BUCKET" /tmp/policy.json
+mc admin user add platform "$ACCESS_KEY" "$SECRET_KEY"
+mc admin policy attach platform "$BUCKET" --user "$ACCESS_KEY" || true
+`
+
+// s3BucketJob is the ArgoCD PostSync Job that applies spec in MinIO.
+func s3BucketJob(name, credentials, admin string, spec *S3Spec) map[string]interface{} {
+	secretEnv := func(env, secret, key string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": env,
+			"valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]interface{}{"name": secret, "key": key},
+			},
+		}
+	}
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"annotations": map[string]interface{}{
+				"argocd.argoproj.io/hook":               "PostSync",
+				"argocd.argoproj.io/hook-delete-policy": "BeforeHookCreation",
+			},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit": 6,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "OnFailure",
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":    "mc",
+							"image":   minioClientImage,
+							"command": []string{"/bin/sh", "-c", s3BucketScript},
+							"env": []interface{}{
+								map[string]interface{}{"name": "S3_ENDPOINT", "value": spec.Endpoint},
+								map[string]interface{}{"name": "BUCKET", "value": spec.Bucket},
+								map[string]interface{}{"name": "VERSIONING", "value": fmt.Sprint(spec.Versioning)},
+								map[string]interface{}{"name": "QUOTA_BYTES", "value": spec.Quota},
+								map[string]interface{}{"name": "MC_CONFIG_DIR", "value": "/tmp/.mc"},
+								secretEnv("MINIO_ADMIN_USER", admin, "username"),
+								secretEnv("MINIO_ADMIN_PASSWORD", admin, "password"),
+								secretEnv("ACCESS_KEY", credentials, "accessKey"),
+								secretEnv("SECRET_KEY", credentials, "secretKey"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package provisioners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// ClassShared is the Score resource class of a postgres, mysql or redis
+// resource that uses the platform's shared instance instead of its own. The
+// shared instances differ per environment, so their 1Password items are
+// named by SharedItem and a workload only ever reads its own environment's.
+const ClassShared = "shared"
+
+// SharedTypes returns the resource types that support class shared.
+func SharedTypes() []string {
+	return []string{"postgres", "mysql", "redis"}
+}
+
+// SharedItem returns the 1Password item of the shared resourceType
+// instance in environment, e.g. "shared-postgres-prod".
+func SharedItem(resourceType, environment string) string {
+	return "shared-" + resourceType + "-" + environment
+}
+
+// ItemEnvironment returns the environment among environments that item is
+// qualified with — its "-<environment>" suffix — or "" when it has none.
+// The longest match wins, so "staging-eu" beats "eu".
+func ItemEnvironment(item string, environments []string) string {
+	envs := append([]string(nil), environments...)
+	sort.Slice(envs, func(i, j int) bool { return len(envs[i]) > len(envs[j]) })
+	for _, env := range envs {
+		if env != "" && strings.HasSuffix(item, "-"+env) {
+			return env
+		}
+	}
+	return ""
+}
+
+// AllowsCrossEnvironment reports whether a resource opts in to reading
+// another environment's 1Password item with
+// params.allowCrossEnvironment: true.
+func AllowsCrossEnvironment(resource score.Resource) bool {
+	allow, _ := resource.Params["allowCrossEnvironment"].(bool)
+	return allow
+}
+
+// credentialsItem returns the 1Password item a postgres, mysql or redis
+// resource reads: params.item when set, the environment's shared item for
+// class shared, and otherwise <workload>-<resource>-<suffix>.
+func credentialsItem(ctx Context, name string, resource score.Resource, suffix string) (string, error) {
+	if v, ok := resource.Params["allowCrossEnvironment"]; ok {
+		if _, ok := v.(bool); !ok {
+			return "", fmt.Errorf("%s resource %q: params.allowCrossEnvironment must be a boolean", resource.Type, name)
+		}
+	}
+	if v, ok := resource.Params["item"]; ok {
+		item, ok := v.(string)
+		if !ok || item == "" {
+			return "", fmt.Errorf("%s resource %q: params.item must be a non-empty string", resource.Type, name)
+		}
+		return item, nil
+	}
+	if resource.Class != ClassShared {
+		return fmt.Sprintf("%s-%s-%s", ctx.Workload, name, suffix), nil
+	}
+	if ctx.Environment == "" {
+		cluster := ctx.Cluster
+		if cluster == "" {
+			cluster = "the target cluster"
+		}
+		return "", fmt.Errorf("%s resource %q: class shared needs the environment of %s; set integrations.argocd.environment on its vCluster or platform.environments in the hctl config", resource.Type, name, cluster)
+	}
+	return SharedItem(resource.Type, ctx.Environment), nil
+}
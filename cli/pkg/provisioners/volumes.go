@@ -0,0 +1,67 @@
+package provisioners
+
+import (
+	"fmt"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// SharedVolumeOwnerAnnotation is set on the PVC of a shared volume to the
+// workload that owns it. Only the owner renders the PVC; the other
+// workloads mounting the volume render none, so a single ArgoCD
+// Application manages it.
+const SharedVolumeOwnerAnnotation = "hctl.integratn.tech/shared-volume-owner"
+
+// SharedVolume returns the name of the volume a volume resource shares
+// with other workloads on its cluster — the resource's id, or its name
+// when it has none — and whether it is shared (params.shared: true).
+func SharedVolume(name string, resource score.Resource) (string, bool, error) {
+	v, ok := resource.Params["shared"]
+	if !ok {
+		return "", false, nil
+	}
+	shared, ok := v.(bool)
+	if !ok {
+		return "", false, fmt.Errorf("volume resource %q: params.shared must be a boolean", name)
+	}
+	if !shared {
+		return "", false, nil
+	}
+	if resource.ID != "" {
+		return resource.ID, true, nil
+	}
+	return name, true, nil
+}
+
+// ClaimsSharedVolume reports whether a shared volume resource claims
+// ownership of the volume, which it does by sizing it with params.size.
+// Workloads that only mount a volume another workload owns leave it out.
+func ClaimsSharedVolume(resource score.Resource) bool {
+	_, ok := resource.Params["size"]
+	return ok
+}
+
+// sharedVolumeOwner decides whether ctx's workload owns the shared volume:
+// it does when it already owned it, or when nobody does yet and the
+// resource claims it. A second claim and a mount of a volume nobody owns
+// are errors.
+func sharedVolumeOwner(ctx Context, name, volume string, resource score.Resource) (bool, error) {
+	cluster := ctx.Cluster
+	if cluster == "" {
+		cluster = "the target cluster"
+	}
+	owner := ctx.SharedVolumes[volume]
+	switch {
+	case owner != "" && owner == ctx.Workload:
+		return true, nil
+	case owner != "" && ClaimsSharedVolume(resource):
+		return false, fmt.Errorf("volume resource %q: shared volume %q on %s is owned by workload %q; drop params.size to mount it, or give this volume its own id",
+			name, volume, cluster, owner)
+	case owner != "":
+		return false, nil
+	case ClaimsSharedVolume(resource):
+		return true, nil
+	}
+	return false, fmt.Errorf("volume resource %q: shared volume %q does not exist on %s yet; deploy the workload that owns it first, or set params.size to own it",
+		name, volume, cluster)
+}
@@ -6,8 +6,10 @@
 // to translate Score resource types into platform resources:
 //
 //   - postgres → ExternalSecret (credentials from 1Password)
+//   - mysql → ExternalSecret (MySQL/MariaDB credentials from 1Password)
 //   - redis → ExternalSecret
-//   - route → HTTPRoute (Gateway API via nginx-gateway-fabric)
+//   - route → HTTPRoute (Gateway API via nginx-gateway-fabric), with redirect,
+//     header, basic-auth and rate-limit options
 //   - volume → PVC with NFS StorageClass
 //   - dns → DNS record configuration
 //
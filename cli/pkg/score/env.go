@@ -0,0 +1,159 @@
+package score
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvOptions controls ${env.NAME} interpolation. Interpolation is off
+// unless AllowAll is set or the workload declares x-hctl.env-vars.
+type EnvOptions struct {
+	// AllowAll (--allow-env) resolves any variable. Without it only those
+	// listed in x-hctl.env-vars are resolved, and others are an error.
+	AllowAll bool
+}
+
+// envRefRegex matches ${env.NAME} placeholders.
+var envRefRegex = regexp.MustCompile(`\$\{env\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// targetingAnnotations decide where a workload is deployed. They are only
+// interpolated with variables declared in x-hctl.env-vars, even with
+// AllowAll, so a stray CI variable cannot redirect a deploy.
+var targetingAnnotations = map[string]bool{
+	"hctl.integratn.tech/cluster":   true,
+	"hctl.integratn.tech/namespace": true,
+}
+
+// interpolateEnv substitutes ${env.NAME} in the string values under top
+// from the process environment, in place, before the workload is decoded.
+// It returns the names it substituted, sorted, and records the lines of
+// placeholders it could not resolve in d.unresolved. Undeclared variables are
+// reported where they are used; variables that are not set are reported
+// together in one problem.
+func (d *Document) interpolateEnv(top *yaml.Node, opts EnvOptions) []string {
+	declared := declaredEnvVars(top)
+	if !opts.AllowAll && declared == nil {
+		return nil
+	}
+
+	used := map[string]bool{}
+	var missing []string
+	var missingAt Position
+	var visit func(n *yaml.Node, path string)
+	visit = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				visit(n.Content[i+1], join(path, n.Content[i].Value))
+			}
+			return
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				visit(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+			return
+		case yaml.ScalarNode:
+		default:
+			return
+		}
+		if !strings.Contains(n.Value, "${env.") {
+			return
+		}
+		pos := Position{Line: n.Line, Column: n.Column}
+		targeting := targetingAnnotations[strings.TrimPrefix(path, "metadata.annotations.")]
+		ok := true
+		value := envRefRegex.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			name := envRefRegex.FindStringSubmatch(ref)[1]
+			switch {
+			case !opts.AllowAll && !declared[name]:
+				d.add(SeverityError, path, pos, fmt.Sprintf("%s is not declared in x-hctl.env-vars (or pass --allow-env)", ref))
+				ok = false
+				return ref
+			case targeting && !declared[name]:
+				d.add(SeverityError, path, pos, fmt.Sprintf("%s selects the deploy target; declare %s in x-hctl.env-vars to interpolate it here", ref, name))
+				ok = false
+				return ref
+			}
+			v, set := os.LookupEnv(name)
+			if !set {
+				if len(missing) == 0 {
+					missingAt = pos
+				}
+				missing = append(missing, name)
+				ok = false
+				return ref
+			}
+			used[name] = true
+			return v
+		})
+		if !ok {
+			// The placeholder stays; a type error on it would only repeat
+			// the problem reported above.
+			d.unresolved[n.Line] = true
+			return
+		}
+		n.Value = value
+		if n.Style == 0 {
+			// Let a plain value such as ${env.PORT} decode as a number.
+			n.Tag = ""
+		}
+	}
+	visit(top, "")
+
+	if len(missing) > 0 {
+		d.add(SeverityError, "", missingAt, "environment variables not set: "+strings.Join(uniqueSorted(missing), ", "))
+	}
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// declaredEnvVars reads x-hctl.env-vars from the workload's top-level
+// mapping; nil when it is not declared.
+func declaredEnvVars(top *yaml.Node) map[string]bool {
+	ext := mappingValue(top, "x-hctl")
+	if ext == nil {
+		return nil
+	}
+	list := mappingValue(ext, "env-vars")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil
+	}
+	declared := map[string]bool{}
+	for _, item := range list.Content {
+		declared[item.Value] = true
+	}
+	return declared
+}
+
+// mappingValue returns the value of key in mapping n, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func uniqueSorted(s []string) []string {
+	sort.Strings(s)
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package score
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const envWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: ${env.TARGET}
+containers:
+  main:
+    image: ghcr.io/example/shop:${env.IMAGE_TAG}
+    variables:
+      GIT_SHA: "${env.GIT_SHA}"
+      GREETING: hello ${env.GIT_SHA}
+      DB_HOST: ${resources.db.host}
+service:
+  ports:
+    web:
+      port: ${env.PORT}
+x-hctl:
+  env-vars: [IMAGE_TAG, GIT_SHA, PORT, TARGET]
+`
+
+func TestParseEnvSubstitutes(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "2.1.0")
+	t.Setenv("GIT_SHA", "abc123")
+	t.Setenv("PORT", "8080")
+	t.Setenv("TARGET", "vcluster-media")
+
+	d := Parse([]byte(envWorkload))
+	if p := d.Err(); p != nil {
+		t.Fatalf("Parse: %s", p)
+	}
+	w := d.Workload
+	main := w.Containers["main"]
+	if main.Image != "ghcr.io/example/shop:2.1.0" || main.Variables["GIT_SHA"] != "abc123" || main.Variables["GREETING"] != "hello abc123" {
+		t.Errorf("container = %+v", main)
+	}
+	if main.Variables["DB_HOST"] != "${resources.db.host}" {
+		t.Errorf("DB_HOST = %q, want the resource reference untouched", main.Variables["DB_HOST"])
+	}
+	if w.Service.Ports["web"].Port != 8080 {
+		t.Errorf("port = %d, want 8080", w.Service.Ports["web"].Port)
+	}
+	if w.TargetCluster() != "vcluster-media" {
+		t.Errorf("cluster = %q, want the declared TARGET", w.TargetCluster())
+	}
+	if want := []string{"GIT_SHA", "IMAGE_TAG", "PORT", "TARGET"}; !reflect.DeepEqual(w.InjectedEnv, want) {
+		t.Errorf("InjectedEnv = %v, want %v", w.InjectedEnv, want)
+	}
+}
+
+func TestParseEnvMissingAggregated(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "2.1.0")
+	d := Parse([]byte(envWorkload))
+	var msgs []string
+	for _, p := range d.Problems {
+		msgs = append(msgs, p.String())
+	}
+	if len(msgs) != 1 || msgs[0] != "environment variables not set: GIT_SHA, PORT, TARGET at line 5" {
+		t.Errorf("problems = %q, want one listing every missing variable", msgs)
+	}
+}
+
+func TestParseEnvWhitelist(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "2.1.0")
+	t.Setenv("TARGET", "prod")
+	spec := strings.Replace(envWorkload, "env-vars: [IMAGE_TAG, GIT_SHA, PORT, TARGET]", "env-vars: [IMAGE_TAG]", 1)
+	spec = strings.NewReplacer("${env.GIT_SHA}", "x", "${env.PORT}", "80").Replace(spec)
+
+	tests := []struct {
+		name string
+		opts EnvOptions
+		want string
+	}{
+		{"undeclared variable", EnvOptions{}, "${env.TARGET} is not declared in x-hctl.env-vars"},
+		{"targeting annotation with --allow-env", EnvOptions{AllowAll: true}, "${env.TARGET} selects the deploy target; declare TARGET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ParseEnv([]byte(spec), tt.opts).Err()
+			if p == nil || p.Field != "metadata.annotations.hctl.integratn.tech/cluster" || !strings.Contains(p.Message, tt.want) {
+				t.Errorf("Err = %+v, want %q on the cluster annotation", p, tt.want)
+			}
+		})
+	}
+
+	// --allow-env resolves undeclared variables elsewhere.
+	spec = strings.Replace(spec, "${env.TARGET}", "dev", 1)
+	spec = strings.Replace(spec, "GREETING: hello x", "GREETING: ${env.TARGET}", 1)
+	d := ParseEnv([]byte(spec), EnvOptions{AllowAll: true})
+	if p := d.Err(); p != nil || d.Workload.Containers["main"].Variables["GREETING"] != "prod" {
+		t.Errorf("with --allow-env: %v, GREETING = %q", p, d.Workload.Containers["main"].Variables["GREETING"])
+	}
+}
+
+func TestParseEnvOff(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "2.1.0")
+	spec := "apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: shop:${env.IMAGE_TAG}\n"
+	d := Parse([]byte(spec))
+	if p := d.Err(); p != nil {
+		t.Fatal(p)
+	}
+	if got := d.Workload.Containers["main"].Image; got != "shop:${env.IMAGE_TAG}" || d.Workload.InjectedEnv != nil {
+		t.Errorf("image = %q, InjectedEnv = %v; want no interpolation", got, d.Workload.InjectedEnv)
+	}
+}
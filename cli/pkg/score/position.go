@@ -0,0 +1,386 @@
+package score
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is where a field appears in a score.yaml. Line and Column are
+// 1-based; the zero Position means unknown.
+type Position struct {
+	Line   int `json:"line" yaml:"line"`
+	Column int `json:"column" yaml:"column"`
+}
+
+// Severity classifies a Problem.
+type Severity string
+
+const (
+	// SeverityError marks a problem that stops a deploy.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a likely mistake that does not stop a deploy.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks a note, such as a default that was applied.
+	SeverityInfo Severity = "info"
+)
+
+// Problem is a finding about a score.yaml, located in it when possible.
+type Problem struct {
+	Severity Severity `json:"severity" yaml:"severity"`
+	// Field is the Score path the problem refers to, e.g. "resources.db.type".
+	Field    string `json:"field,omitempty" yaml:"field,omitempty"`
+	Position `yaml:",inline"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// Describe formats p without its position, as "resources.db.typ: unknown
+// field (did you mean \"type\"?)". The field is left out when the message
+// already names it.
+func (p Problem) Describe() string {
+	if p.Field != "" && !strings.Contains(p.Message, p.Field) {
+		return p.Field + ": " + p.Message
+	}
+	return p.Message
+}
+
+// String formats p as "resources.db.typ: unknown field (did you mean
+// \"type\"?) at line 42".
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s at line %d", p.Describe(), p.Line)
+	}
+	return p.Describe()
+}
+
+// Document is a score.yaml parsed with the position of every field.
+type Document struct {
+	// Workload is the decoded workload; nil when the YAML does not parse.
+	Workload *Workload
+	// Problems are what parsing and validation found, in source order.
+	// Any error means Workload must not be deployed.
+	Problems []Problem
+
+	positions map[string]Position
+	// unresolved holds the lines of ${env.NAME} placeholders that were
+	// not substituted, whose type errors are not reported.
+	unresolved map[int]bool
+}
+
+// Err returns the first error problem, or nil.
+func (d *Document) Err() *Problem {
+	for i := range d.Problems {
+		if d.Problems[i].Severity == SeverityError {
+			return &d.Problems[i]
+		}
+	}
+	return nil
+}
+
+// Position returns where field, a dotted Score path such as
+// "containers.app.variables.DB_HOST", appears. A field missing from the
+// source is placed at its nearest parent that is present.
+func (d *Document) Position(field string) (Position, bool) {
+	for field != "" {
+		if pos, ok := d.positions[field]; ok {
+			return pos, true
+		}
+		i := strings.LastIndexAny(field, ".[")
+		if i < 0 {
+			break
+		}
+		field = field[:i]
+	}
+	return Position{}, false
+}
+
+// Locate returns p with its position filled from the field, when it has
+// none yet.
+func (d *Document) Locate(p Problem) Problem {
+	if p.Line == 0 && p.Field != "" {
+		p.Position, _ = d.Position(p.Field)
+	}
+	return p
+}
+
+// yamlLineRegex extracts the line from yaml.v3 syntax and type errors.
+var yamlLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// Parse parses data as a Score workload, recording the position of every
+// field and collecting every problem rather than stopping at the first:
+// YAML syntax and type errors, fields Score does not define (with a
+// suggestion when one is close), and missing required fields. Variables
+// declared in x-hctl.env-vars are interpolated (see ParseEnv).
+func Parse(data []byte) *Document {
+	return ParseEnv(data, EnvOptions{})
+}
+
+// ParseEnv is Parse with ${env.NAME} placeholders in string values
+// substituted from the process environment as env allows, before the
+// workload is decoded and validated.
+func ParseEnv(data []byte, env EnvOptions) *Document {
+	d := newDocument()
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		d.addYAMLError(err)
+		return d
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		d.add(SeverityError, "", Position{}, "empty document")
+		return d
+	}
+	d.parse(root.Content[0], env)
+	return d
+}
+
+// ParseAll is ParseEnv for every document of a multi-document file, in
+// file order, with positions relative to the whole file. Empty documents,
+// such as after a trailing "---", are skipped. A YAML syntax error ends
+// the list with a document holding it.
+func ParseAll(data []byte, env EnvOptions) []*Document {
+	var docs []*Document
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var root yaml.Node
+		err := dec.Decode(&root)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			d := newDocument()
+			d.addYAMLError(err)
+			return append(docs, d)
+		}
+		if len(root.Content) == 0 || root.Content[0].Tag == "!!null" {
+			continue
+		}
+		d := newDocument()
+		d.parse(root.Content[0], env)
+		docs = append(docs, d)
+	}
+	if len(docs) == 0 {
+		d := newDocument()
+		d.add(SeverityError, "", Position{}, "empty document")
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+func newDocument() *Document {
+	return &Document{positions: map[string]Position{}, unresolved: map[int]bool{}}
+}
+
+// parse decodes and validates the workload whose top-level mapping is top.
+func (d *Document) parse(top *yaml.Node, env EnvOptions) {
+	injected := d.interpolateEnv(top, env)
+
+	var w Workload
+	if err := top.Decode(&w); err != nil {
+		d.addYAMLError(err)
+	}
+	w.InjectedEnv = injected
+	d.walk(top, reflect.TypeOf(w), "")
+
+	if w.APIVersion != "score.dev/v1b1" {
+		d.add(SeverityError, "apiVersion", d.mustPosition("apiVersion"),
+			fmt.Sprintf("unsupported Score API version: %q (expected score.dev/v1b1)", w.APIVersion))
+	}
+	if w.Metadata.Name == "" {
+		d.add(SeverityError, "metadata.name", d.mustPosition("metadata.name"), "metadata.name is required")
+	}
+	if len(w.Containers) == 0 {
+		d.add(SeverityError, "containers", d.mustPosition("containers"), "at least one container is required")
+	}
+
+	SortProblems(d.Problems)
+	d.Workload = &w
+}
+
+// SortProblems orders problems by position, keeping the order of those on
+// the same spot; problems with no position come last.
+func SortProblems(problems []Problem) {
+	key := func(p Position) (int, int) {
+		if p.Line == 0 {
+			return int(^uint(0) >> 1), 0
+		}
+		return p.Line, p.Column
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		li, ci := key(problems[i].Position)
+		lj, cj := key(problems[j].Position)
+		return li < lj || li == lj && ci < cj
+	})
+}
+
+func (d *Document) add(sev Severity, field string, pos Position, msg string) {
+	d.Problems = append(d.Problems, Problem{Severity: sev, Field: field, Position: pos, Message: msg})
+}
+
+func (d *Document) mustPosition(field string) Position {
+	pos, _ := d.Position(field)
+	return pos
+}
+
+// addYAMLError records a yaml.v3 error, one problem per type error.
+func (d *Document) addYAMLError(err error) {
+	msgs := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+	for _, msg := range msgs {
+		var pos Position
+		if m := yamlLineRegex.FindStringSubmatch(msg); m != nil {
+			pos.Line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		if d.unresolved[pos.Line] {
+			continue
+		}
+		d.add(SeverityError, "", pos, msg)
+	}
+}
+
+// walk records the positions of n's fields under path, and reports keys t
+// does not define.
+func (d *Document) walk(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field := join(path, key.Value)
+			d.positions[field] = Position{Line: key.Line, Column: key.Column}
+			ft, ok := fields[key.Value]
+			switch {
+			case ok:
+				d.walk(value, ft, field)
+			case path == "" && strings.HasPrefix(key.Value, "x-"):
+				// Extensions for other Score implementations.
+			case t == reflect.TypeOf(WorkloadMetadata{}):
+				// Score allows additional metadata.
+				d.walk(value, reflect.TypeOf((*interface{})(nil)).Elem(), field)
+			default:
+				d.add(SeverityError, field, Position{Line: key.Line, Column: key.Column}, unknownField(key.Value, fields))
+			}
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field := join(path, key.Value)
+			d.positions[field] = Position{Line: key.Line, Column: key.Column}
+			d.walk(value, t.Elem(), field)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			field := fmt.Sprintf("%s[%d]", path, i)
+			d.positions[field] = Position{Line: item.Line, Column: item.Column}
+			d.walk(item, t.Elem(), field)
+		}
+	case t.Kind() == reflect.Interface:
+		// Free-form values such as resource params: positions only.
+		switch n.Kind {
+		case yaml.MappingNode:
+			d.walk(n, reflect.TypeOf(map[string]interface{}{}), path)
+		case yaml.SequenceNode:
+			d.walk(n, reflect.TypeOf([]interface{}{}), path)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of struct type t to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownField describes a key the Score schema does not define, suggesting
+// the closest known one.
+func unknownField(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if s := suggest(key, names); s != "" {
+		return fmt.Sprintf("unknown field (did you mean %q?)", s)
+	}
+	return fmt.Sprintf("unknown field (expected one of: %s)", strings.Join(names, ", "))
+}
+
+// suggest returns the candidate closest to s, ignoring case, when it is
+// within a third of s's length in edits (at least one); "" otherwise.
+func suggest(s string, candidates []string) string {
+	best, bestDist := "", len(s)/3
+	if bestDist < 1 {
+		bestDist = 1
+	}
+	bestDist++
+	for _, c := range candidates {
+		if dist := editDistance(strings.ToLower(s), strings.ToLower(c)); dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// the insertions, deletions, substitutions and adjacent transpositions
+// that turn one into the other.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	d := make([][]int, len(ar)+1)
+	for i := range d {
+		d[i] = make([]int, len(br)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ar)][len(br)]
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,181 @@
+package score
+
+import (
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const positioned = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  labels:
+    team: web
+containers:
+  main:
+    image: ghcr.io/example/shop:2.0.0
+    variables:
+      DB_HOST: ${resources.db.host}
+    resources:
+      limits:
+        memory: 512Mi
+resources:
+  db:
+    type: postgres
+  web:
+    type: route
+    params:
+      host: shop.example.com
+      rules:
+        - path: /
+x-other:
+  anything: goes
+`
+
+func TestParsePositions(t *testing.T) {
+	d := Parse([]byte(positioned))
+	if len(d.Problems) != 0 {
+		t.Fatalf("Problems = %v, want none", d.Problems)
+	}
+	for field, want := range map[string]Position{
+		"apiVersion":                              {1, 1},
+		"metadata.labels.team":                    {5, 5},
+		"containers.main.variables.DB_HOST":       {10, 7},
+		"containers.main.resources.limits.memory": {13, 9},
+		"resources.db.type":                       {16, 5},
+		"resources.web.params.host":               {20, 7},
+		"resources.web.params.rules[0]":           {22, 11},
+		"resources.web.params.rules[0].path":      {22, 11},
+		"x-other":                                 {23, 1},
+		// Missing fields are placed at their nearest parent.
+		"containers.main.resources.limits.cpu": {12, 7},
+		"resources.db.params.size":             {15, 3},
+	} {
+		if got, ok := d.Position(field); !ok || got != want {
+			t.Errorf("Position(%q) = %v, %v; want %v", field, got, ok, want)
+		}
+	}
+	if _, ok := d.Position("service.ports"); ok {
+		t.Error("Position(service.ports) found a field that is not in the source")
+	}
+	if d.Workload == nil || d.Workload.Resources["web"].Params["host"] != "shop.example.com" {
+		t.Errorf("Workload = %+v, want the decoded workload", d.Workload)
+	}
+}
+
+func TestParseUnknownFields(t *testing.T) {
+	d := Parse([]byte(`apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: nginx:1.27
+    varaibles:
+      A: b
+    volumes:
+      /data:
+        source: data
+        readonly: true
+resources:
+  db:
+    typ: postgres
+  cache:
+    type: redis
+    flavour: small
+`))
+	want := []Problem{
+		{SeverityError, "containers.main.varaibles", Position{7, 5}, `unknown field (did you mean "variables"?)`},
+		{SeverityError, "containers.main.volumes./data.readonly", Position{12, 9}, `unknown field (did you mean "readOnly"?)`},
+		{SeverityError, "resources.db.typ", Position{15, 5}, `unknown field (did you mean "type"?)`},
+		{SeverityError, "resources.cache.flavour", Position{18, 5}, "unknown field (expected one of: class, id, metadata, params, type)"},
+	}
+	if len(d.Problems) != len(want) {
+		t.Fatalf("Problems = %v, want %v", d.Problems, want)
+	}
+	for i := range want {
+		if d.Problems[i] != want[i] {
+			t.Errorf("Problems[%d] = %+v, want %+v", i, d.Problems[i], want[i])
+		}
+	}
+	if got := d.Problems[2].String(); got != `resources.db.typ: unknown field (did you mean "type"?) at line 15` {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseErrorsAreLocated(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      Problem
+	}{
+		{
+			"syntax error",
+			"apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\n containers: [\n",
+			Problem{Severity: SeverityError, Position: Position{Line: 3}},
+		},
+		{
+			"type error",
+			"apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: nginx\nservice:\n  ports:\n    web:\n      port: eighty\n",
+			Problem{Severity: SeverityError, Position: Position{Line: 10}},
+		},
+		{
+			"missing name",
+			"apiVersion: score.dev/v1b1\nmetadata:\n  annotations: {}\ncontainers:\n  main:\n    image: nginx\n",
+			Problem{Severity: SeverityError, Field: "metadata.name", Position: Position{2, 1}, Message: "metadata.name is required"},
+		},
+		{
+			"wrong apiVersion",
+			"apiVersion: score.dev/v1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: nginx\n",
+			Problem{Severity: SeverityError, Field: "apiVersion", Position: Position{1, 1}, Message: `unsupported Score API version: "score.dev/v1" (expected score.dev/v1b1)`},
+		},
+	}
+	for _, tt := range tests {
+		p := Parse([]byte(tt.doc)).Err()
+		if p == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if tt.want.Message == "" {
+			tt.want.Message = p.Message // yaml.v3's wording
+		}
+		if p.Severity != tt.want.Severity || p.Field != tt.want.Field || p.Line != tt.want.Line ||
+			(tt.want.Column != 0 && p.Column != tt.want.Column) || p.Message != tt.want.Message {
+			t.Errorf("%s: Err() = %+v, want %+v", tt.name, *p, tt.want)
+		}
+	}
+}
+
+func TestDecodeReportsLine(t *testing.T) {
+	_, err := Decode(strings.NewReader("apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: nginx\nresources:\n  db:\n    typ: postgres\n"))
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+	if want := `resources.db.typ: unknown field (did you mean "type"?) at line 9`; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+	details := hcerrors.ToReport(err).Details.(map[string]string)
+	if details["field"] != "resources.db.typ" || details["line"] != "9" {
+		t.Errorf("details = %v", details)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"annotations", "class", "id", "image", "metadata", "params", "readOnly", "type", "variables"}
+	for in, want := range map[string]string{
+		"typ":        "type",
+		"tpye":       "type",
+		"Type":       "type",
+		"readonly":   "readOnly",
+		"varaibles":  "variables",
+		"annotation": "annotations",
+		"parms":      "params",
+		"ids":        "id",
+		"x":          "",
+		"flavour":    "",
+		"replicas":   "",
+	} {
+		if got := suggest(in, candidates); got != want {
+			t.Errorf("suggest(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package score
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// Workload represents a parsed Score workload specification (score.dev/v1b1).
+type Workload struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Metadata   WorkloadMetadata  `yaml:"metadata"`
+	Containers map[string]Container `yaml:"containers"`
+	Service    *Service          `yaml:"service,omitempty"`
+	Resources  map[string]Resource `yaml:"resources,omitempty"`
+	// Extensions holds hctl-specific settings from the top-level x-hctl key.
+	Extensions *Extensions `yaml:"x-hctl,omitempty"`
+	// InjectedEnv names the ${env.NAME} variables substituted when the
+	// workload was loaded, sorted. It is not part of the spec.
+	InjectedEnv []string `yaml:"-"`
+}
+
+// WorkloadMetadata holds workload identity and annotations.
+type WorkloadMetadata struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Container represents a Score container spec.
+type Container struct {
+	Image     string            `yaml:"image"`
+	Command   []string          `yaml:"command,omitempty"`
+	Args      []string          `yaml:"args,omitempty"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Files     map[string]File   `yaml:"files,omitempty"`
+	Volumes   map[string]Volume `yaml:"volumes,omitempty"`
+	Resources *ComputeResources `yaml:"resources,omitempty"`
+	LivenessProbe  *Probe       `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe *Probe       `yaml:"readinessProbe,omitempty"`
+	// Extensions holds hctl-specific settings from the container's x-hctl key.
+	Extensions *ContainerExtensions `yaml:"x-hctl,omitempty"`
+}
+
+// ComputeResources holds resource requests and limits.
+type ComputeResources struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+// File represents a Score file mount.
+type File struct {
+	Mode          string `yaml:"mode,omitempty"`
+	Content       string `yaml:"content,omitempty"`
+	BinaryContent string `yaml:"binaryContent,omitempty"`
+	Source        string `yaml:"source,omitempty"`
+	NoExpand      bool   `yaml:"noExpand,omitempty"`
+}
+
+// Volume represents a Score volume mount.
+type Volume struct {
+	Source   string `yaml:"source"`
+	Path    string `yaml:"path,omitempty"`
+	ReadOnly bool  `yaml:"readOnly,omitempty"`
+}
+
+// Service represents a Score service spec.
+type Service struct {
+	Ports map[string]Port `yaml:"ports"`
+}
+
+// Port represents a Score port spec.
+type Port struct {
+	Port       int    `yaml:"port"`
+	Protocol   string `yaml:"protocol,omitempty"`
+	TargetPort int    `yaml:"targetPort,omitempty"`
+}
+
+// Extensions are hctl-specific workload settings. Score ignores x- prefixed
+// keys, so a workload using them stays valid for other Score implementations.
+type Extensions struct {
+	// WorkloadKind selects the controller: "deployment" (default) or "statefulset".
+	WorkloadKind string `yaml:"workloadKind,omitempty"`
+	// Service adjusts the Service rendered from service.ports.
+	Service *ServiceExtension `yaml:"service,omitempty"`
+	// Autoscaling renders a HorizontalPodAutoscaler for the Deployment.
+	Autoscaling *AutoscalingExtension `yaml:"autoscaling,omitempty"`
+	// Schedule scales the workload down and back up on a timetable.
+	Schedule *ScheduleExtension `yaml:"schedule,omitempty"`
+	// EnvVars lists the environment variables ${env.NAME} placeholders
+	// may use. Declaring it turns interpolation on; see EnvOptions.
+	EnvVars []string `yaml:"env-vars,omitempty"`
+	// SmokeTests are HTTP checks 'hctl deploy run' makes against the
+	// workload's route once it is deployed.
+	SmokeTests []SmokeTest `yaml:"smokeTests,omitempty"`
+}
+
+// SmokeTest is one HTTP GET against the workload's route.
+type SmokeTest struct {
+	// Path is requested on the route's host, e.g. /healthz.
+	Path string `yaml:"path"`
+	// ExpectStatus defaults to 200.
+	ExpectStatus int `yaml:"expectStatus,omitempty"`
+	// ExpectBodyContains, when set, must appear in the response body.
+	ExpectBodyContains string `yaml:"expectBodyContains,omitempty"`
+	// Timeout bounds the check, retries included, as a duration such as
+	// 30s. Defaults to 30s.
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// Container roles for ContainerExtensions.Role.
+const (
+	// ContainerRoleSidecar runs for the pod's lifetime and is started before
+	// the containers that depend on it.
+	ContainerRoleSidecar = "sidecar"
+	// ContainerRoleInit runs to completion before the containers that
+	// depend on it, and before all main containers.
+	ContainerRoleInit = "init"
+)
+
+// ContainerExtensions are hctl-specific container settings.
+type ContainerExtensions struct {
+	// Role is ContainerRoleSidecar or ContainerRoleInit. Empty is a main
+	// container; main containers start together.
+	Role string `yaml:"role,omitempty"`
+	// DependsOn names the sidecar or init containers that must be started,
+	// or have finished, before this one starts.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// ServiceExtension adjusts how the workload's Service is rendered.
+type ServiceExtension struct {
+	// Enabled set to false renders no Service at all. Defaults to true.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Headless renders the Service with clusterIP None for per-pod DNS.
+	Headless bool `yaml:"headless,omitempty"`
+}
+
+// AutoscalingExtension configures CPU-based horizontal pod autoscaling.
+type AutoscalingExtension struct {
+	// MinReplicas defaults to 1.
+	MinReplicas int `yaml:"minReplicas,omitempty"`
+	MaxReplicas int `yaml:"maxReplicas"`
+	// TargetCPUUtilizationPercentage defaults to 80.
+	TargetCPUUtilizationPercentage int `yaml:"targetCPUUtilizationPercentage,omitempty"`
+}
+
+// ScheduleExtension scales the workload to DowntimeReplicas when Downscale
+// fires and back to Replicas when Upscale fires. Both are five-field cron
+// expressions evaluated in Timezone.
+type ScheduleExtension struct {
+	Downscale string `yaml:"downscale"`
+	Upscale   string `yaml:"upscale"`
+	// Timezone is an IANA time zone name. Defaults to UTC.
+	Timezone string `yaml:"timezone,omitempty"`
+	// DowntimeReplicas defaults to 0.
+	DowntimeReplicas int `yaml:"downtimeReplicas,omitempty"`
+	// Replicas outside the downtime window. Defaults to 1.
+	Replicas int `yaml:"replicas,omitempty"`
+}
+
+// Resource represents a Score resource dependency.
+type Resource struct {
+	Type     string                 `yaml:"type"`
+	Class    string                 `yaml:"class,omitempty"`
+	ID       string                 `yaml:"id,omitempty"`
+	Metadata map[string]interface{} `yaml:"metadata,omitempty"`
+	Params   map[string]interface{} `yaml:"params,omitempty"`
+}
+
+// Probe represents a health check probe.
+type Probe struct {
+	HTTPGet *HTTPGetProbe `yaml:"httpGet,omitempty"`
+	Exec    *ExecProbe    `yaml:"exec,omitempty"`
+}
+
+// HTTPGetProbe represents an HTTP health check.
+type HTTPGetProbe struct {
+	Scheme      string       `yaml:"scheme,omitempty"`
+	Host        string       `yaml:"host,omitempty"`
+	Path        string       `yaml:"path"`
+	Port        int          `yaml:"port"`
+	HTTPHeaders []HTTPHeader `yaml:"httpHeaders,omitempty"`
+}
+
+// ExecProbe represents a command health check.
+type ExecProbe struct {
+	Command []string `yaml:"command"`
+}
+
+// HTTPHeader represents an HTTP header.
+type HTTPHeader struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// LoadWorkload reads and parses a score.yaml file that defines one
+// workload. A file with several documents fails rather than yielding the
+// first; see FindWorkload and LoadWorkloads.
+func LoadWorkload(path string) (*Workload, error) {
+	return LoadWorkloadEnv(path, EnvOptions{})
+}
+
+// LoadWorkloadEnv is LoadWorkload with ${env.NAME} interpolation as env
+// allows.
+func LoadWorkloadEnv(path string, env EnvOptions) (*Workload, error) {
+	workloads, err := LoadWorkloadsEnv(path, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) > 1 {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%s defines %d workloads (%s); name the one to act on", path, len(workloads), strings.Join(workloadNames(workloads), ", ")).
+			WithRemediation("pass the workload name as an argument").
+			WithDetails(map[string]string{"file": path})
+	}
+	return workloads[0], nil
+}
+
+// FindWorkload loads the workload called name from the documents of path,
+// or, when name is empty, the file's only workload as LoadWorkload does. A
+// name no document defines is an ErrNotFound.
+func FindWorkload(path, name string) (*Workload, error) {
+	if name == "" {
+		return LoadWorkload(path)
+	}
+	workloads, err := LoadWorkloads(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workloads {
+		if w.Metadata.Name == name {
+			return w, nil
+		}
+	}
+	return nil, hcerrors.New(hcerrors.ErrNotFound, "%s defines no workload %q (it defines %s)", path, name, strings.Join(workloadNames(workloads), ", "))
+}
+
+func workloadNames(workloads []*Workload) []string {
+	names := make([]string, len(workloads))
+	for i, w := range workloads {
+		names[i] = w.Metadata.Name
+	}
+	return names
+}
+
+// LoadWorkloads reads every workload of a score.yaml that holds several
+// documents separated by "---", in file order. A file with one document
+// yields one workload. Two documents naming the same workload are an error.
+func LoadWorkloads(path string) ([]*Workload, error) {
+	return LoadWorkloadsEnv(path, EnvOptions{})
+}
+
+// LoadWorkloadsEnv is LoadWorkloads with ${env.NAME} interpolation as env
+// allows.
+func LoadWorkloadsEnv(path string, env EnvOptions) ([]*Workload, error) {
+	data, err := readWorkloadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	docs := ParseAll(data, env)
+	workloads := make([]*Workload, 0, len(docs))
+	defined := map[string]int{}
+	for i, doc := range docs {
+		if err := documentError(doc, path); err != nil {
+			if len(docs) > 1 {
+				err.Details.(map[string]string)["document"] = strconv.Itoa(i + 1)
+			}
+			return nil, err
+		}
+		w := doc.Workload
+		if first, ok := defined[w.Metadata.Name]; ok {
+			details := map[string]string{"field": "metadata.name", "file": path, "document": strconv.Itoa(i + 1)}
+			if pos, ok := doc.Position("metadata.name"); ok {
+				details["line"] = strconv.Itoa(pos.Line)
+			}
+			return nil, hcerrors.New(hcerrors.ErrValidation, "%s: workload %q is defined by documents %d and %d", path, w.Metadata.Name, first, i+1).
+				WithRemediation("give each document its own metadata.name").
+				WithDetails(details)
+		}
+		defined[w.Metadata.Name] = i + 1
+		workloads = append(workloads, w)
+	}
+	abs, _ := filepath.Abs(path)
+	for _, w := range workloads {
+		slog.Debug("loaded score workload", "path", abs, "name", w.Metadata.Name, "containers", len(w.Containers), "resources", len(w.Resources), "injectedEnv", w.InjectedEnv)
+	}
+	return workloads, nil
+}
+
+func readWorkloadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, hcerrors.New(hcerrors.ErrNotFound, "reading %s: %w", path, err).
+				WithRemediation("run 'hctl deploy init' or pass --file")
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Decode parses and validates a Score workload from r.
+func Decode(r io.Reader) (*Workload, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading score workload: %w", err)
+	}
+	return parseWorkload(data, "", EnvOptions{})
+}
+
+// parseWorkload unmarshals and validates a workload, failing with the first
+// problem Parse finds, located by line. source names the file in errors; it
+// is empty when the workload did not come from a file.
+func parseWorkload(data []byte, source string, env EnvOptions) (*Workload, error) {
+	doc := ParseEnv(data, env)
+	if err := documentError(doc, source); err != nil {
+		return nil, err
+	}
+	return doc.Workload, nil
+}
+
+// documentError returns the first problem of doc as an ErrValidation error
+// whose details are a map[string]string, or nil when doc has none.
+func documentError(doc *Document, source string) *hcerrors.HctlError {
+	p := doc.Err()
+	if p == nil {
+		return nil
+	}
+	name := source
+	if name == "" {
+		name = "score workload"
+	}
+	details := map[string]string{}
+	if p.Field != "" {
+		details["field"] = p.Field
+	}
+	if source != "" {
+		details["file"] = source
+	}
+	if p.Line > 0 {
+		details["line"] = strconv.Itoa(p.Line)
+	}
+	msg := p.String()
+	if p.Field == "" {
+		// YAML syntax and type errors name no field.
+		msg = "parsing " + name + ": " + msg
+	}
+	return hcerrors.New(hcerrors.ErrValidation, "%s", msg).WithDetails(details)
+}
+
+// TargetCluster returns the target vCluster from workload annotations.
+func (w *Workload) TargetCluster() string {
+	if w.Metadata.Annotations != nil {
+		return w.Metadata.Annotations["hctl.integratn.tech/cluster"]
+	}
+	return ""
+}
+
+// ResourcesByType returns all resources matching the given type.
+func (w *Workload) ResourcesByType(resourceType string) map[string]Resource {
+	result := make(map[string]Resource)
+	for name, r := range w.Resources {
+		if r.Type == resourceType {
+			result[name] = r
+		}
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package score
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const appAndWorker = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: ghcr.io/example/shop:2.0.0
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: shop-worker
+  annotations:
+    hctl.integratn.tech/cluster: batch
+containers:
+  main:
+    image: ghcr.io/example/shop:2.0.0
+    args: [worker]
+---
+`
+
+func writeWorkloadFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "score.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWorkloads(t *testing.T) {
+	workloads, err := LoadWorkloads(writeWorkloadFile(t, appAndWorker))
+	if err != nil {
+		t.Fatalf("LoadWorkloads: %v", err)
+	}
+	if len(workloads) != 2 {
+		t.Fatalf("LoadWorkloads() = %d workloads, want 2", len(workloads))
+	}
+	if workloads[0].Metadata.Name != "shop" || workloads[1].Metadata.Name != "shop-worker" {
+		t.Errorf("names = %s, %s", workloads[0].Metadata.Name, workloads[1].Metadata.Name)
+	}
+	if got := workloads[1].TargetCluster(); got != "batch" {
+		t.Errorf("second TargetCluster() = %q, want batch", got)
+	}
+
+	single, err := LoadWorkloads(writeWorkloadFile(t, positioned))
+	if err != nil || len(single) != 1 {
+		t.Fatalf("LoadWorkloads(single document) = %d, %v", len(single), err)
+	}
+}
+
+func TestLoadWorkloadsErrors(t *testing.T) {
+	tests := []struct {
+		name, content string
+		want          map[string]string
+	}{
+		{"name collision", appAndWorker + "apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: nginx\n",
+			map[string]string{"field": "metadata.name", "document": "3", "line": "20"}},
+		{"invalid second document", "apiVersion: score.dev/v1b1\nmetadata:\n  name: shop\ncontainers:\n  main:\n    image: nginx\n---\napiVersion: score.dev/v1b1\nmetadata:\n  name: worker\ncontainers:\n  main:\n    imag: nginx\n",
+			map[string]string{"field": "containers.main.imag", "document": "2", "line": "13"}},
+		{"empty file", "---\n", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeWorkloadFile(t, tt.content)
+			_, err := LoadWorkloads(path)
+			if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+				t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+			}
+			details := hcerrors.ToReport(err).Details.(map[string]string)
+			for k, v := range tt.want {
+				if details[k] != v {
+					t.Errorf("details[%s] = %q, want %q (%v)", k, details[k], v, details)
+				}
+			}
+		})
+	}
+}
+
+func TestFindWorkload(t *testing.T) {
+	path := writeWorkloadFile(t, appAndWorker)
+	w, err := FindWorkload(path, "shop-worker")
+	if err != nil || w.Metadata.Name != "shop-worker" {
+		t.Fatalf("FindWorkload(shop-worker) = %v, %v", w, err)
+	}
+	if _, err := FindWorkload(path, "cart"); !errors.Is(err, hcerrors.ErrNotFound) {
+		t.Errorf("FindWorkload(cart) error = %v, want ErrNotFound", err)
+	}
+	// Without a name, a file holding several workloads is ambiguous.
+	if _, err := FindWorkload(path, ""); hcerrors.ExitCode(err) != hcerrors.ExitValidation {
+		t.Errorf("FindWorkload(\"\") error = %v, want a validation error", err)
+	}
+	if _, err := LoadWorkload(path); hcerrors.ExitCode(err) != hcerrors.ExitValidation {
+		t.Errorf("LoadWorkload error = %v, want a validation error", err)
+	}
+
+	single := writeWorkloadFile(t, positioned)
+	if w, err := FindWorkload(single, ""); err != nil || w == nil {
+		t.Errorf("FindWorkload(single document) = %v, %v", w, err)
+	}
+}
@@ -0,0 +1,275 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// AlertsAnnotation opts a workload in to a generated PrometheusRule:
+	// "basic" or "strict".
+	AlertsAnnotation = "hctl.integratn.tech/alerts"
+	// AlertsBasic alerts on sustained failures only.
+	AlertsBasic = "basic"
+	// AlertsStrict alerts sooner, on tighter thresholds.
+	AlertsStrict = "strict"
+
+	// alertThresholdPrefix prefixes the threshold overrides, e.g.
+	// hctl.integratn.tech/alerts.error-rate-threshold: "2".
+	alertThresholdPrefix = AlertsAnnotation + "."
+
+	// metricsPortName is the service port the platform scrapes. The HTTP
+	// alerts need it.
+	metricsPortName = "metrics"
+	// The platform's standard HTTP server metrics. The scrape config copies
+	// the pod's workload label onto every series as "workload".
+	httpRequestsMetric  = "http_requests_total"
+	httpDurationMetric  = "http_request_duration_seconds_bucket"
+	workloadMetricLabel = "workload"
+)
+
+// Alert thresholds, settable as alerts.<name> annotations.
+const (
+	// ThresholdRestarts is the container restarts in 15 minutes.
+	ThresholdRestarts = "restart-threshold"
+	// ThresholdAvailability is the percentage of desired replicas that
+	// must be available.
+	ThresholdAvailability = "availability-threshold"
+	// ThresholdErrorRate is the percentage of requests answered with a 5xx.
+	ThresholdErrorRate = "error-rate-threshold"
+	// ThresholdLatency is the p99 request latency in seconds.
+	ThresholdLatency = "latency-threshold"
+)
+
+// alertModes holds each mode's thresholds, how long a condition must hold
+// before it fires, and the severity of the resulting alerts.
+var alertModes = map[string]struct {
+	thresholds map[string]float64
+	pending    string
+	severity   string
+}{
+	AlertsBasic: {
+		thresholds: map[string]float64{
+			ThresholdRestarts:     5,
+			ThresholdAvailability: 50,
+			ThresholdErrorRate:    5,
+			ThresholdLatency:      2,
+		},
+		pending:  "15m",
+		severity: "warning",
+	},
+	AlertsStrict: {
+		thresholds: map[string]float64{
+			ThresholdRestarts:     1,
+			ThresholdAvailability: 100,
+			ThresholdErrorRate:    1,
+			ThresholdLatency:      0.5,
+		},
+		pending:  "5m",
+		severity: "critical",
+	},
+}
+
+// alerts is the alerting resolved for one workload.
+type alerts struct {
+	mode       string
+	thresholds map[string]float64
+	metrics    bool
+}
+
+// parseAlerts reads the alerts annotation and its threshold overrides.
+// Returns nil when the workload does not opt in.
+func parseAlerts(w *score.Workload) (*alerts, error) {
+	annotations := w.Metadata.Annotations
+	mode := strings.TrimSpace(annotations[AlertsAnnotation])
+	var overrides []string
+	for key := range annotations {
+		if strings.HasPrefix(key, alertThresholdPrefix) {
+			overrides = append(overrides, key)
+		}
+	}
+	sort.Strings(overrides)
+
+	if mode == "" {
+		if len(overrides) > 0 {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s has no effect without %s", overrides[0], AlertsAnnotation).
+				WithDetails(map[string]string{"field": "metadata.annotations." + overrides[0]})
+		}
+		return nil, nil
+	}
+	defaults, ok := alertModes[mode]
+	if !ok {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: invalid value %q (expected %q or %q)", AlertsAnnotation, mode, AlertsBasic, AlertsStrict).
+			WithDetails(map[string]string{"field": "metadata.annotations." + AlertsAnnotation})
+	}
+
+	a := &alerts{mode: mode, thresholds: map[string]float64{}}
+	for name, v := range defaults.thresholds {
+		a.thresholds[name] = v
+	}
+	for _, key := range overrides {
+		name := strings.TrimPrefix(key, alertThresholdPrefix)
+		field := map[string]string{"field": "metadata.annotations." + key}
+		if _, ok := a.thresholds[name]; !ok {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: unknown alert threshold %q (expected %s, %s, %s or %s)",
+				key, name, ThresholdRestarts, ThresholdAvailability, ThresholdErrorRate, ThresholdLatency).
+				WithDetails(field)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(annotations[key]), 64)
+		if err != nil || v <= 0 {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: invalid value %q (expected a positive number)", key, annotations[key]).
+				WithDetails(field)
+		}
+		if name == ThresholdAvailability && v > 100 {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: invalid value %q (expected a percentage up to 100)", key, annotations[key]).
+				WithDetails(field)
+		}
+		a.thresholds[name] = v
+	}
+	if w.Service != nil {
+		_, a.metrics = w.Service.Ports[metricsPortName]
+	}
+	return a, nil
+}
+
+// diags warns about HTTP threshold overrides that have no metrics port to
+// alert on.
+func (a *alerts) diags(w *score.Workload) []Diagnostic {
+	if a == nil || a.metrics {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, name := range []string{ThresholdErrorRate, ThresholdLatency} {
+		if _, ok := w.Metadata.Annotations[alertThresholdPrefix+name]; ok {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagUnusedAlertThreshold,
+				Field:    "metadata.annotations." + alertThresholdPrefix + name,
+				Message:  fmt.Sprintf("no %q service port, so the HTTP alerts this threshold applies to are not generated", metricsPortName),
+			})
+		}
+	}
+	return diags
+}
+
+// rule renders the PrometheusRule for the workload's scale target. Alert
+// names carry the workload and cluster, so they are unique across every
+// workload the platform's Prometheus evaluates.
+func (a *alerts) rule(workload, cluster, namespace string, target scaleTarget) map[string]interface{} {
+	if a == nil {
+		return nil
+	}
+	mode := alertModes[a.mode]
+	prefix := alertName(workload) + alertName(cluster)
+	pods := fmt.Sprintf(`namespace=%q, pod=~%q`, namespace, workload+"-.*")
+	http := fmt.Sprintf(`namespace=%q, %s=%q`, namespace, workloadMetricLabel, workload)
+	t := a.thresholds
+	labels := alertLabels{severity: mode.severity, workload: workload, cluster: cluster}
+
+	rules := []interface{}{
+		alert(labels, prefix+"PodRestarting",
+			fmt.Sprintf(`sum by (pod, container) (increase(kube_pod_container_status_restarts_total{%s}[15m])) >= %s`, pods, num(t[ThresholdRestarts])),
+			mode.pending,
+			fmt.Sprintf("%s containers are restarting", workload),
+			fmt.Sprintf("Container %s of pod %s restarted %s times in 15 minutes (threshold %s).", promVar("$labels.container"), promVar("$labels.pod"), promVar("$value"), num(t[ThresholdRestarts]))),
+		alert(labels, prefix+"OOMKilled",
+			fmt.Sprintf(`max by (pod, container) (kube_pod_container_status_last_terminated_reason{%s, reason="OOMKilled"}) * on (pod, container) group_left sum by (pod, container) (increase(kube_pod_container_status_restarts_total{%s}[15m])) > 0`, pods, pods),
+			"0m",
+			fmt.Sprintf("%s container was OOMKilled", workload),
+			fmt.Sprintf("Container %s of pod %s was killed for exceeding its memory limit.", promVar("$labels.container"), promVar("$labels.pod"))),
+		alert(labels, prefix+"ReplicasUnavailable",
+			availabilityExpr(target, namespace, t[ThresholdAvailability]),
+			mode.pending,
+			fmt.Sprintf("%s has fewer available replicas than desired", workload),
+			fmt.Sprintf("%s of the desired %s replicas are available (threshold %s%%).", promVar("$value | humanizePercentage"), target.kind, num(t[ThresholdAvailability]))),
+	}
+	if a.metrics {
+		rules = append(rules,
+			alert(labels, prefix+"HighErrorRate",
+				fmt.Sprintf(`sum(rate(%s{%s, code=~"5.."}[5m])) / sum(rate(%s{%s}[5m])) * 100 > %s`, httpRequestsMetric, http, httpRequestsMetric, http, num(t[ThresholdErrorRate])),
+				mode.pending,
+				fmt.Sprintf("%s is answering with 5xx errors", workload),
+				fmt.Sprintf("%s%% of requests failed with a 5xx over 5 minutes (threshold %s%%).", promVar("$value | humanize"), num(t[ThresholdErrorRate]))),
+			alert(labels, prefix+"HighLatency",
+				fmt.Sprintf(`histogram_quantile(0.99, sum by (le) (rate(%s{%s}[5m]))) > %s`, httpDurationMetric, http, num(t[ThresholdLatency])),
+				mode.pending,
+				fmt.Sprintf("%s p99 latency is high", workload),
+				fmt.Sprintf("p99 request latency is %s (threshold %ss).", promVar("$value | humanizeDuration"), num(t[ThresholdLatency]))),
+		)
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]interface{}{
+			"name":   workload + "-alerts",
+			"labels": map[string]interface{}{"release": "kube-prometheus-stack"},
+		},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  workload + "." + cluster,
+					"rules": rules,
+				},
+			},
+		},
+	}
+}
+
+// alert renders one rule, labelled with the workload and cluster for
+// routing.
+func alert(labels alertLabels, name, expr, pending, summary, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"for":   pending,
+		"labels": map[string]interface{}{
+			"severity": labels.severity,
+			"workload": labels.workload,
+			"cluster":  labels.cluster,
+		},
+		"annotations": map[string]interface{}{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+}
+
+type alertLabels struct{ severity, workload, cluster string }
+
+// availabilityExpr compares the target's available replicas with the
+// desired count. A workload scaled to zero never fires.
+func availabilityExpr(target scaleTarget, namespace string, percent float64) string {
+	available, desired, label := "kube_deployment_status_replicas_available", "kube_deployment_spec_replicas", "deployment"
+	if target.kind == "StatefulSet" {
+		available, desired, label = "kube_statefulset_status_replicas_ready", "kube_statefulset_replicas", "statefulset"
+	}
+	sel := fmt.Sprintf(`namespace=%q, %s=%q`, namespace, label, target.name)
+	return fmt.Sprintf(`%s{%s} / %s{%s} < %s and %s{%s} > 0`, available, sel, desired, sel, num(percent/100), desired, sel)
+}
+
+// alertName turns a DNS label such as "vcluster-media" into the CamelCase
+// of an alert name, "VclusterMedia".
+func alertName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '.' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// promVar renders a Prometheus template action in an alert annotation,
+// escaped for the chart, which passes extraObjects through Helm's tpl.
+func promVar(action string) string {
+	return `{{ "{{" }} ` + action + ` {{ "}}" }}`
+}
+
+// num formats a threshold without trailing zeros.
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,181 @@
+package translate
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+func alertsWorkload(annotations map[string]string) *score.Workload {
+	w := placementWorkload(annotations)
+	w.Service = &score.Service{Ports: map[string]score.Port{
+		"web":     {Port: 80, TargetPort: 8080},
+		"metrics": {Port: 9090},
+	}}
+	return w
+}
+
+// alertRule returns the generated PrometheusRule, or nil.
+func alertRule(t *testing.T, w *score.Workload) map[string]interface{} {
+	t.Helper()
+	result, err := Translate(w, Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	extras, _ := result.Values["extraObjects"].([]interface{})
+	for _, obj := range extras {
+		if m := obj.(map[string]interface{}); m["kind"] == "PrometheusRule" {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestAlertsGolden(t *testing.T) {
+	for _, mode := range []string{AlertsBasic, AlertsStrict} {
+		t.Run(mode, func(t *testing.T) {
+			rule := alertRule(t, alertsWorkload(map[string]string{AlertsAnnotation: mode}))
+			if rule == nil {
+				t.Fatal("no PrometheusRule generated")
+			}
+			if ns := rule["metadata"].(map[string]interface{})["namespace"]; ns != "dev" {
+				t.Errorf("namespace = %v, want dev", ns)
+			}
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			if err := enc.Encode(rule["spec"].(map[string]interface{})["groups"]); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "alerts-"+mode+".golden.yaml", buf.Bytes())
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("rule groups do not match %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+// ruleExprs maps alert names, less the workload and cluster prefix, to
+// their expressions.
+func ruleExprs(rule map[string]interface{}) map[string]string {
+	exprs := map[string]string{}
+	group := rule["spec"].(map[string]interface{})["groups"].([]interface{})[0].(map[string]interface{})
+	for _, r := range group["rules"].([]interface{}) {
+		r := r.(map[string]interface{})
+		exprs[strings.TrimPrefix(r["alert"].(string), "MyappDev")] = r["expr"].(string)
+	}
+	return exprs
+}
+
+func TestAlertsThresholdOverrides(t *testing.T) {
+	rule := alertRule(t, alertsWorkload(map[string]string{
+		AlertsAnnotation: AlertsStrict,
+		AlertsAnnotation + "." + ThresholdErrorRate:    "2",
+		AlertsAnnotation + "." + ThresholdLatency:      "0.75",
+		AlertsAnnotation + "." + ThresholdAvailability: "80",
+	}))
+	exprs := ruleExprs(rule)
+	for alert, suffix := range map[string]string{
+		"HighErrorRate":       "* 100 > 2",
+		"HighLatency":         "> 0.75",
+		"ReplicasUnavailable": `< 0.8 and kube_deployment_spec_replicas{namespace="dev", deployment="myapp"} > 0`,
+		// Not overridden: the strict default.
+		"PodRestarting": ">= 1",
+	} {
+		if !strings.HasSuffix(exprs[alert], suffix) {
+			t.Errorf("%s expr = %q, want suffix %q", alert, exprs[alert], suffix)
+		}
+	}
+}
+
+func TestAlertsWithoutMetricsPort(t *testing.T) {
+	w := placementWorkload(map[string]string{
+		AlertsAnnotation: AlertsBasic,
+		AlertsAnnotation + "." + ThresholdLatency: "1",
+	})
+	result, err := Translate(w, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extras, _ := result.Values["extraObjects"].([]interface{})
+	if len(extras) != 1 {
+		t.Fatalf("extraObjects = %v, want the PrometheusRule", extras)
+	}
+	exprs := ruleExprs(extras[0].(map[string]interface{}))
+	if _, ok := exprs["HighErrorRate"]; ok || len(exprs) != 3 {
+		t.Errorf("alerts = %v, want only the pod and availability alerts", exprs)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Field != "metadata.annotations."+AlertsAnnotation+"."+ThresholdLatency {
+		t.Errorf("diagnostics = %v, want a warning about the unused latency threshold", result.Diagnostics)
+	}
+}
+
+func TestAlertsNamesUniquePerWorkloadAndCluster(t *testing.T) {
+	names := map[string]bool{}
+	for _, tt := range []struct{ workload, cluster string }{{"myapp", "dev"}, {"myapp", "vcluster-media"}, {"shop", "dev"}} {
+		w := alertsWorkload(map[string]string{AlertsAnnotation: AlertsBasic})
+		w.Metadata.Name = tt.workload
+		w.Metadata.Annotations["hctl.integratn.tech/cluster"] = tt.cluster
+		group := alertRule(t, w)["spec"].(map[string]interface{})["groups"].([]interface{})[0].(map[string]interface{})
+		for _, r := range group["rules"].([]interface{}) {
+			name := r.(map[string]interface{})["alert"].(string)
+			if names[name] {
+				t.Errorf("alert %s generated twice", name)
+			}
+			names[name] = true
+		}
+	}
+	if !names["MyappVclusterMediaOOMKilled"] {
+		t.Errorf("alert names = %v, want MyappVclusterMediaOOMKilled", names)
+	}
+}
+
+func TestAlertsValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		field       string
+	}{
+		{"unknown mode", map[string]string{AlertsAnnotation: "paranoid"}, AlertsAnnotation},
+		{"unknown threshold", map[string]string{AlertsAnnotation: AlertsBasic, AlertsAnnotation + ".cpu-threshold": "80"}, AlertsAnnotation + ".cpu-threshold"},
+		{"not a number", map[string]string{AlertsAnnotation: AlertsBasic, AlertsAnnotation + "." + ThresholdErrorRate: "2%"}, AlertsAnnotation + "." + ThresholdErrorRate},
+		{"availability over 100", map[string]string{AlertsAnnotation: AlertsBasic, AlertsAnnotation + "." + ThresholdAvailability: "150"}, AlertsAnnotation + "." + ThresholdAvailability},
+		{"override without a mode", map[string]string{AlertsAnnotation + "." + ThresholdRestarts: "3"}, AlertsAnnotation + "." + ThresholdRestarts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Translate(alertsWorkload(tt.annotations), Options{})
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || he.Category != hcerrors.ErrValidation {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+			if got := he.Details.(map[string]string)["field"]; got != "metadata.annotations."+tt.field {
+				t.Errorf("field = %q, want metadata.annotations.%s", got, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package translate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// ResultCache stores provisioner results between translations, keyed by a
+// hash of everything a provisioner sees. See Options.Cache.
+type ResultCache interface {
+	// Get returns the result stored under key, if any.
+	Get(key string) (*provisioners.ProvisionResult, bool)
+	// Put stores result under key. A cache that cannot store it drops it;
+	// the translation does not fail.
+	Put(key string, result *provisioners.ProvisionResult)
+}
+
+// cacheKey hashes the inputs of one provisioner call: the provisioner p
+// (see provisionerVersion), the resource spec, the workload, cluster and
+// environment it is provisioned for, and the shared volume owners it sees.
+// ok is false when p has no version or the params cannot be encoded, and
+// the call is not cached.
+func cacheKey(p provisioners.Provisioner, pctx provisioners.Context, name string, res score.Resource) (key string, ok bool) {
+	version, ok := provisionerVersion(p)
+	if !ok {
+		return "", false
+	}
+	data, err := json.Marshal(struct {
+		Provisioner   string                 `json:"provisioner"`
+		Mode          provisioners.Mode      `json:"mode"`
+		Workload      string                 `json:"workload"`
+		Cluster       string                 `json:"cluster"`
+		Environment   string                 `json:"environment"`
+		SharedVolumes map[string]string      `json:"sharedVolumes"`
+		Name          string                 `json:"name"`
+		Type          string                 `json:"type"`
+		Class         string                 `json:"class"`
+		ID            string                 `json:"id"`
+		Metadata      map[string]interface{} `json:"metadata"`
+		Params        map[string]interface{} `json:"params"`
+	}{version, pctx.Mode, pctx.Workload, pctx.Cluster, pctx.Environment, pctx.SharedVolumes, name, res.Type, res.Class, res.ID, res.Metadata, res.Params})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// builtinPkg is the import path of the built-in provisioners.
+var builtinPkg = reflect.TypeOf(provisioners.Registry{}).PkgPath()
+
+// provisionerVersion identifies the output of p: its package and type name,
+// and provisioners.Version for a built-in provisioner or its own
+// provisioners.Versioned version for any other. A registry passed in
+// Options.Registry may replace a built-in type with its own provisioner, so
+// the type name alone cannot tell their results apart. ok is false for a
+// provisioner that is neither.
+func provisionerVersion(p provisioners.Provisioner) (version string, ok bool) {
+	t := reflect.TypeOf(p)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	id := t.PkgPath() + "." + t.Name()
+	if v, ok := p.(provisioners.Versioned); ok {
+		return id + "@" + v.Version(), true
+	}
+	if t.PkgPath() == builtinPkg {
+		return id + "@" + provisioners.Version, true
+	}
+	return "", false
+}
+
+// cacheable reports whether result may be stored: it must not carry secret
+// values, which only a Secret manifest's data or stringData can hold.
+// ExternalSecrets and outputs reference secrets by name only.
+func cacheable(result *provisioners.ProvisionResult) bool {
+	for _, m := range result.Manifests {
+		if kind, _ := m["kind"].(string); kind != "Secret" {
+			continue
+		}
+		if m["data"] != nil || m["stringData"] != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package translate_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// memCache is an in-memory translate.ResultCache that counts lookups.
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string]*provisioners.ProvisionResult
+	gets    int
+}
+
+func (c *memCache) Get(key string) (*provisioners.ProvisionResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gets++
+	r, ok := c.entries[key]
+	return r, ok
+}
+
+func (c *memCache) Put(key string, result *provisioners.ProvisionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*provisioners.ProvisionResult{}
+	}
+	c.entries[key] = result
+}
+
+// Version makes slowProvisioner's results cacheable.
+func (p *slowProvisioner) Version() string { return "1" }
+
+func TestTranslateCache(t *testing.T) {
+	p := &slowProvisioner{}
+	cache := &memCache{}
+	opts := translate.Options{Cluster: "media", Registry: slowRegistry(p), Mode: provisioners.ModeRender, Cache: cache}
+	w := slowWorkload("cached", 3)
+
+	first, err := translate.Translate(w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.calls.Load(); got != 3 {
+		t.Fatalf("first render ran %d provisioners, want 3", got)
+	}
+
+	second, err := translate.Translate(w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.calls.Load(); got != 3 {
+		t.Errorf("second render ran %d more provisioners, want every result from the cache", got-3)
+	}
+	path := translate.ValuesPath("media", "cached")
+	if !bytes.Equal(first.Files[path], second.Files[path]) {
+		t.Errorf("cached render differs:\n%s\nwant:\n%s", second.Files[path], first.Files[path])
+	}
+
+	w.Resources["res01"] = score.Resource{Type: "slow", Params: map[string]interface{}{"size": "10Gi"}}
+	if _, err := translate.Translate(w, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.calls.Load(); got != 4 {
+		t.Errorf("after a param change %d provisioners ran, want only res01's (4 in total)", got)
+	}
+
+	opts.Cluster = "dev"
+	if _, err := translate.Translate(w, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.calls.Load(); got != 7 {
+		t.Errorf("for another cluster %d provisioners ran, want all three again (7 in total)", got)
+	}
+
+	// Deploys run every provisioner and leave the cache alone.
+	gets := cache.gets
+	opts.Mode = provisioners.ModeDeploy
+	if _, err := translate.Translate(w, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.calls.Load(); got != 10 {
+		t.Errorf("deploy ran %d provisioners, want all three (10 in total)", got-7)
+	}
+	if cache.gets != gets {
+		t.Errorf("deploy read the cache %d times, want 0", cache.gets-gets)
+	}
+}
+
+// secretProvisioner returns a Secret carrying a generated value.
+type secretProvisioner struct{}
+
+func (secretProvisioner) Type() string { return "token" }
+
+func (secretProvisioner) Provision(name string, _ score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	return &provisioners.ProvisionResult{
+		Manifests: []map[string]interface{}{{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": workload + "-" + name},
+			"stringData": map[string]interface{}{"token": "s3cr3t"},
+		}},
+	}, nil
+}
+
+func TestTranslateCacheSkipsSecretValues(t *testing.T) {
+	registry := provisioners.NewRegistry()
+	registry.Register(secretProvisioner{})
+	cache := &memCache{}
+	w := &translate.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata:   score.WorkloadMetadata{Name: "api"},
+		Containers: map[string]score.Container{"main": {Image: "app:1"}},
+		Resources: map[string]score.Resource{
+			"token": {Type: "token"},
+			"data":  {Type: "volume"},
+		},
+	}
+	_, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: registry, Mode: provisioners.ModeRender, Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("cache holds %d entries, want only the volume's", len(cache.entries))
+	}
+	for _, r := range cache.entries {
+		for _, m := range r.Manifests {
+			if m["kind"] == "Secret" {
+				t.Errorf("cache stored a Secret with values: %v", m)
+			}
+		}
+	}
+}
+
+// volumeOverride replaces the built-in volume provisioner.
+type volumeOverride struct{}
+
+func (volumeOverride) Type() string { return "volume" }
+
+func (volumeOverride) Provision(name string, _ score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	return &provisioners.ProvisionResult{Outputs: map[string]string{"source": workload + "-" + name + "-custom"}}, nil
+}
+
+// versionedVolumeOverride is a volumeOverride with a version.
+type versionedVolumeOverride struct {
+	volumeOverride
+	version string
+}
+
+func (p versionedVolumeOverride) Version() string { return p.version }
+
+func TestTranslateCacheKeysOnProvisioner(t *testing.T) {
+	cache := &memCache{}
+	w := &translate.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata:   score.WorkloadMetadata{Name: "api"},
+		Containers: map[string]score.Container{"main": {Image: "app:1"}},
+		Resources:  map[string]score.Resource{"data": {Type: "volume"}},
+	}
+	// ran renders w with p in place of the built-in volume provisioner, or
+	// the built-in for nil, and reports whether the provisioner ran.
+	ran := func(p provisioners.Provisioner) bool {
+		t.Helper()
+		registry := provisioners.NewRegistry()
+		if p != nil {
+			registry.Register(p)
+		}
+		var calls int
+		_, err := translate.Translate(w, translate.Options{
+			Cluster: "media", Registry: registry, Mode: provisioners.ModeRender, Cache: cache,
+			OnProvision: func(string, string) func() { calls++; return nil },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return calls > 0
+	}
+
+	if !ran(nil) || ran(nil) {
+		t.Fatal("the built-in provisioner's result was not cached")
+	}
+	// A replacement gets neither the built-in's result nor its own.
+	if !ran(volumeOverride{}) || !ran(volumeOverride{}) {
+		t.Error("an unversioned provisioner's result came from the cache")
+	}
+	if !ran(versionedVolumeOverride{version: "1"}) {
+		t.Error("a versioned replacement got the built-in's cached result")
+	}
+	if ran(versionedVolumeOverride{version: "1"}) {
+		t.Error("a versioned replacement's result was not cached")
+	}
+	if !ran(versionedVolumeOverride{version: "2"}) {
+		t.Error("a new version got the old version's cached result")
+	}
+	if ran(nil) {
+		t.Error("the built-in provisioner's cached result was lost")
+	}
+}
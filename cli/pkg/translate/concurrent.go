@@ -0,0 +1,152 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"golang.org/x/sync/errgroup"
+)
+
+// concurrency returns the provisioner worker limit for opts.
+func (o Options) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// slots returns the semaphore bounding provisioner calls: the one shared
+// by TranslateAll, or a fresh one sized by Concurrency.
+func (o Options) slots() chan struct{} {
+	if o.sem != nil {
+		return o.sem
+	}
+	return make(chan struct{}, o.concurrency())
+}
+
+// provisionAll runs the provisioner of each named resource, except those
+// skip reports, with at most cap(sem) running at once. A provisioner sees
+// only its own resource, so the calls are independent and may run in any
+// order; results are returned indexed like names so the assembled output
+// does not depend on scheduling. The first failure keeps resources that
+// have not started from running, and every error collected by then is
+// returned together in names order. In ModeRender, results found in cache
+// are used without running their provisioner. observe, when set, brackets
+// each provisioner call, which runs in pctx.
+func provisionAll(w *Workload, names []string, registry *provisioners.Registry, pctx provisioners.Context, skip func(string) bool, sem chan struct{}, cache ResultCache, observe func(string, string) func()) ([]*provisioners.ProvisionResult, error) {
+	results := make([]*provisioners.ProvisionResult, len(names))
+	errs := make([]error, len(names))
+
+	g, ctx := errgroup.WithContext(context.Background())
+	for i, name := range names {
+		if skip(name) {
+			continue
+		}
+		g.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return nil
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			res := w.Resources[name]
+			p, err := registry.Get(res.Type)
+			if err != nil {
+				errs[i] = hcerrors.New(hcerrors.ErrValidation, "resource %q: %w", name, err)
+				return errs[i]
+			}
+			key, useCache := "", cache != nil && pctx.Rendering()
+			if useCache {
+				key, useCache = cacheKey(p, pctx, name, res)
+			}
+			if useCache {
+				if cached, ok := cache.Get(key); ok {
+					results[i] = cached
+					return nil
+				}
+			}
+			if observe != nil {
+				if done := observe(name, res.Type); done != nil {
+					defer done()
+				}
+			}
+			result, err := registry.Provision(pctx, name, res)
+			if err != nil {
+				errs[i] = hcerrors.New(hcerrors.ErrValidation, "provisioning resource %q: %w", name, err)
+				return errs[i]
+			}
+			if useCache && cacheable(result) {
+				cache.Put(key, result)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	_ = g.Wait() // errors are collected per resource above
+	if err := joinErrors(errs); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// TranslateAll translates several workloads with the same options. Up to
+// opts.Concurrency workloads are translated at once, and their provisioner
+// calls share a single pool of that size, so the total stays bounded however
+// the resources are spread across workloads. Results are in workloads order.
+// The first failure keeps workloads that have not started from running;
+// every error collected by then is returned together.
+func TranslateAll(workloads []*Workload, opts Options) ([]*Result, error) {
+	limit := opts.concurrency()
+	opts.sem = make(chan struct{}, limit)
+
+	results := make([]*Result, len(workloads))
+	errs := make([]error, len(workloads))
+
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(limit)
+	for i, w := range workloads {
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			result, err := Translate(w, opts)
+			if err != nil {
+				errs[i] = fmt.Errorf("workload %q: %w", w.Metadata.Name, err)
+				return errs[i]
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := joinErrors(errs); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// joinErrors returns nil, the single non-nil error unchanged, or all of
+// them joined in order.
+func joinErrors(errs []error) error {
+	var found []error
+	for _, err := range errs {
+		if err != nil {
+			found = append(found, err)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil
+	case 1:
+		return found[0]
+	}
+	return errors.Join(found...)
+}
@@ -0,0 +1,196 @@
+package translate_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+// slowProvisioner simulates a provisioner doing I/O. It records how many
+// calls overlap and fails for resources named in fail.
+type slowProvisioner struct {
+	delay    time.Duration
+	fail     map[string]bool
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	mu       sync.Mutex
+	peak     int32
+}
+
+func (p *slowProvisioner) Type() string { return "slow" }
+
+func (p *slowProvisioner) Provision(name string, _ score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	p.calls.Add(1)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	p.mu.Lock()
+	p.peak = max(p.peak, n)
+	p.mu.Unlock()
+
+	time.Sleep(p.delay)
+	if p.fail[name] {
+		return nil, fmt.Errorf("backend unavailable")
+	}
+	return &provisioners.ProvisionResult{
+		Outputs: map[string]string{"url": fmt.Sprintf("slow://%s-%s", workload, name)},
+		Manifests: []map[string]interface{}{{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": workload + "-" + name},
+		}},
+	}, nil
+}
+
+func slowRegistry(p *slowProvisioner) *provisioners.Registry {
+	r := provisioners.NewRegistry()
+	r.Register(p)
+	return r
+}
+
+// slowWorkload returns a workload with n slow resources, each referenced
+// from a container variable.
+func slowWorkload(name string, n int) *translate.Workload {
+	w := &translate.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata:   score.WorkloadMetadata{Name: name},
+		Containers: map[string]score.Container{"main": {Image: "app:1", Variables: map[string]string{}}},
+		Resources:  map[string]score.Resource{},
+	}
+	for i := range n {
+		res := fmt.Sprintf("res%02d", i)
+		w.Resources[res] = score.Resource{Type: "slow"}
+		w.Containers["main"].Variables[strings.ToUpper(res)] = "${resources." + res + ".url}"
+	}
+	return w
+}
+
+func TestTranslateConcurrentProvisioning(t *testing.T) {
+	w := slowWorkload("fan", 8)
+
+	seq := &slowProvisioner{delay: 5 * time.Millisecond}
+	want, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: slowRegistry(seq), Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq.peak != 1 {
+		t.Errorf("Concurrency 1 ran %d provisioners at once", seq.peak)
+	}
+
+	par := &slowProvisioner{delay: 5 * time.Millisecond}
+	got, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: slowRegistry(par), Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if par.peak < 2 || par.peak > 4 {
+		t.Errorf("Concurrency 4 peaked at %d provisioners, want 2..4", par.peak)
+	}
+
+	for path, data := range want.Files {
+		if !bytes.Equal(got.Files[path], data) {
+			t.Errorf("%s differs between sequential and concurrent translation", path)
+		}
+	}
+}
+
+func TestTranslateConcurrentErrors(t *testing.T) {
+	w := slowWorkload("fan", 6)
+
+	// Every failure that ran is reported, in resource order.
+	p := &slowProvisioner{delay: time.Millisecond, fail: map[string]bool{"res01": true, "res04": true}}
+	_, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: slowRegistry(p), Concurrency: 6})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	msg := err.Error()
+	if i, j := strings.Index(msg, `"res01"`), strings.Index(msg, `"res04"`); i < 0 || j < i {
+		t.Errorf("error should report res01 then res04, got %q", msg)
+	}
+
+	// The first failure stops resources that have not started.
+	p = &slowProvisioner{fail: map[string]bool{"res00": true}}
+	if _, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: slowRegistry(p), Concurrency: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := p.calls.Load(); n >= 6 {
+		t.Errorf("provisioned %d resources after the first failure, want outstanding work cancelled", n)
+	}
+}
+
+func TestTranslateOnProvision(t *testing.T) {
+	w := slowWorkload("fan", 4)
+	var mu sync.Mutex
+	started, finished := map[string]string{}, 0
+	opts := translate.Options{
+		Cluster:     "media",
+		Registry:    slowRegistry(&slowProvisioner{}),
+		Concurrency: 2,
+		OnProvision: func(resource, resourceType string) func() {
+			mu.Lock()
+			started[resource] = resourceType
+			mu.Unlock()
+			return func() {
+				mu.Lock()
+				finished++
+				mu.Unlock()
+			}
+		},
+	}
+	if _, err := translate.Translate(w, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(started) != 4 || finished != 4 || started["res03"] != "slow" {
+		t.Errorf("started %v, finished %d; want every resource bracketed once", started, finished)
+	}
+}
+
+func TestTranslateAll(t *testing.T) {
+	p := &slowProvisioner{delay: time.Millisecond}
+	var workloads []*translate.Workload
+	for i := range 5 {
+		workloads = append(workloads, slowWorkload(fmt.Sprintf("app%d", i), 4))
+	}
+
+	results, err := translate.TranslateAll(workloads, translate.Options{Cluster: "media", Registry: slowRegistry(p), Concurrency: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if want := fmt.Sprintf("app%d", i); r.WorkloadName != want {
+			t.Errorf("results[%d] = %s, want %s", i, r.WorkloadName, want)
+		}
+	}
+	if p.peak > 3 {
+		t.Errorf("provisioner calls across workloads peaked at %d, want at most 3", p.peak)
+	}
+
+	p = &slowProvisioner{fail: map[string]bool{"res02": true}}
+	_, err = translate.TranslateAll(workloads[:2], translate.Options{Cluster: "media", Registry: slowRegistry(p), Concurrency: 2})
+	if err == nil || !strings.Contains(err.Error(), `workload "app`) {
+		t.Errorf("err = %v, want errors prefixed with the workload name", err)
+	}
+}
+
+func benchmarkTranslate(b *testing.B, concurrency int) {
+	w := slowWorkload("bench", 20)
+	opts := translate.Options{
+		Cluster:     "media",
+		Registry:    slowRegistry(&slowProvisioner{delay: time.Millisecond}),
+		Concurrency: concurrency,
+	}
+	for b.Loop() {
+		if _, err := translate.Translate(w, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTranslateSequential(b *testing.B) { benchmarkTranslate(b, 1) }
+func BenchmarkTranslateParallel(b *testing.B)   { benchmarkTranslate(b, 8) }
@@ -0,0 +1,222 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// BuildAnnotation says where the image of a workload's "." containers is
+// built, e.g. "ci", for workloads whose image is set outside score.yaml.
+// hctl only checks that it is set.
+const BuildAnnotation = "hctl.integratn.tech/build"
+
+// nativeSidecarMinor is the first Kubernetes 1.x minor version with native
+// sidecars (init containers with restartPolicy Always) enabled by default.
+const nativeSidecarMinor = 29
+
+// podLayout places the workload's containers in the pod, from their
+// x-hctl.role and x-hctl.dependsOn.
+type podLayout struct {
+	// primary is the chart's main container: the first, by name, without
+	// a role.
+	primary string
+	// additional are the other main containers, by name.
+	additional []string
+	// init are the containers started before the main ones: init
+	// containers, and sidecars when they are native.
+	init []string
+	// native renders sidecars as native sidecars. Without it they fall back
+	// to main containers.
+	native bool
+	roles  map[string]string
+	diags  []Diagnostic
+}
+
+// parseContainers validates container roles and dependencies against
+// kubeVersion, the target cluster's Kubernetes version (empty assumes a
+// current one). A container can only depend on init and sidecar containers,
+// which all start before the main containers; main containers start
+// together. Dependency cycles are rejected. The chart starts init and
+// sidecar containers in name order, so each must sort after those it
+// depends on.
+func parseContainers(w *score.Workload, kubeVersion string) (*podLayout, error) {
+	native, err := supportsNativeSidecars(kubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(w.Containers))
+	for name := range w.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	l := &podLayout{native: native, roles: map[string]string{}}
+	deps := map[string][]string{}
+	for _, name := range names {
+		ext := w.Containers[name].Extensions
+		if ext == nil {
+			continue
+		}
+		switch ext.Role {
+		case "", score.ContainerRoleSidecar, score.ContainerRoleInit:
+			l.roles[name] = ext.Role
+		default:
+			return nil, hcerrors.New(hcerrors.ErrValidation, "containers.%s.x-hctl.role: unsupported role %q (expected sidecar or init)", name, ext.Role).
+				WithDetails(map[string]string{"field": "containers." + name + ".x-hctl.role"})
+		}
+		for _, dep := range ext.DependsOn {
+			if _, ok := w.Containers[dep]; !ok || dep == name {
+				return nil, hcerrors.New(hcerrors.ErrValidation, "containers.%s.x-hctl.dependsOn: %q is not another container of this workload", name, dep).
+					WithDetails(map[string]string{"field": "containers." + name + ".x-hctl.dependsOn"})
+			}
+		}
+		deps[name] = ext.DependsOn
+	}
+	if cycle := dependencyCycle(names, deps); cycle != nil {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "containers.%s.x-hctl.dependsOn: dependency cycle %s", cycle[0], strings.Join(cycle, " -> ")).
+			WithDetails(map[string]string{"field": "containers." + cycle[0] + ".x-hctl.dependsOn"})
+	}
+
+	for _, name := range names {
+		for _, dep := range deps[name] {
+			if l.roles[dep] == "" {
+				return nil, hcerrors.New(hcerrors.ErrValidation, "containers.%s.x-hctl.dependsOn: %q is a main container, and main containers start together", name, dep).
+					WithDetails(map[string]string{"field": "containers." + name + ".x-hctl.dependsOn"}).
+					WithRemediation(fmt.Sprintf("set x-hctl.role: sidecar (or init) on %q", dep))
+			}
+		}
+	}
+
+	for _, name := range names {
+		switch role := l.roles[name]; {
+		case role == score.ContainerRoleInit, role == score.ContainerRoleSidecar && native:
+			l.init = append(l.init, name)
+		case l.primary == "" && role == "":
+			l.primary = name
+		default:
+			l.additional = append(l.additional, name)
+		}
+	}
+	if l.primary == "" {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "containers: every container has an x-hctl.role; at least one must be a main container").
+			WithDetails(map[string]string{"field": "containers"})
+	}
+
+	for _, name := range l.init {
+		for _, dep := range deps[name] {
+			if l.isInit(dep) && dep > name {
+				return nil, hcerrors.New(hcerrors.ErrValidation, "containers.%s.x-hctl.dependsOn: %q must start first, but init and sidecar containers start in name order", name, dep).
+					WithDetails(map[string]string{"field": "containers." + name + ".x-hctl.dependsOn"}).
+					WithRemediation("rename the containers so each sorts after those it depends on, e.g. with a numeric prefix")
+			}
+		}
+	}
+
+	if !native {
+		for _, name := range names {
+			if l.roles[name] != score.ContainerRoleSidecar {
+				continue
+			}
+			l.diags = append(l.diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagSidecarUnsupported,
+				Field:    "containers." + name + ".x-hctl.role",
+				Message: fmt.Sprintf("Kubernetes %s predates native sidecars (1.%d); rendered as a plain container, so it is not started before the containers depending on it",
+					kubeVersion, nativeSidecarMinor),
+			})
+		}
+	}
+	return l, nil
+}
+
+// isInit reports whether the named container starts before the main ones.
+func (l *podLayout) isInit(name string) bool {
+	for _, n := range l.init {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// apply renders the init and sidecar containers as the chart's
+// initContainers, keyed by name. Native sidecars get restartPolicy Always.
+func (l *podLayout) apply(deployment map[string]interface{}, w *score.Workload, allOutputs map[string]map[string]string) {
+	if len(l.init) == 0 {
+		return
+	}
+	initContainers := map[string]interface{}{}
+	for _, name := range l.init {
+		spec := buildContainerSpec(name, w.Containers[name], allOutputs)
+		delete(spec, "name")
+		if l.roles[name] == score.ContainerRoleSidecar {
+			spec["restartPolicy"] = "Always"
+		}
+		initContainers[name] = spec
+	}
+	deployment["initContainers"] = initContainers
+}
+
+// dependencyCycle returns a dependency cycle among names as the path from
+// its first container back to it, or nil when there is none.
+func dependencyCycle(names []string, deps map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// supportsNativeSidecars reports whether Kubernetes version v, such as
+// "v1.30.2" or "1.28", has native sidecars. An empty version is assumed to.
+func supportsNativeSidecars(v string) (bool, error) {
+	if v == "" {
+		return true, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) >= 2 {
+		major, errMajor := strconv.Atoi(parts[0])
+		// Minor versions may carry a suffix, as in EKS's "1.29+".
+		minor, errMinor := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+		if errMajor == nil && errMinor == nil {
+			return major > 1 || major == 1 && minor >= nativeSidecarMinor, nil
+		}
+	}
+	return false, hcerrors.NewUserError("Kubernetes version %q is not <major>.<minor>[.<patch>]", v)
+}
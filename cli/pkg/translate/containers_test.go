@@ -0,0 +1,164 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+// orderedWorkload has a main container, a proxy sidecar, and a migrations
+// init container that needs the proxy; the app depends on both.
+const orderedWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  app:
+    image: shop:1.0
+    x-hctl:
+      dependsOn: [b-proxy, c-migrate]
+  b-proxy:
+    image: envoy:1.31
+    x-hctl:
+      role: sidecar
+  c-migrate:
+    image: shop:1.0
+    command: [./migrate]
+    x-hctl:
+      role: init
+      dependsOn: [b-proxy]
+`
+
+func TestContainerOrderNativeSidecars(t *testing.T) {
+	for _, version := range []string{"", "v1.29.0", "1.34"} {
+		result, err := Translate(loadExtensionWorkload(t, orderedWorkload), Options{KubernetesVersion: version})
+		if err != nil {
+			t.Fatalf("%q: %v", version, err)
+		}
+		d := result.Values["deployment"].(map[string]interface{})
+		want := map[string]interface{}{
+			"b-proxy":   map[string]interface{}{"image": "envoy:1.31", "restartPolicy": "Always"},
+			"c-migrate": map[string]interface{}{"image": "shop:1.0", "command": []string{"./migrate"}},
+		}
+		if !reflect.DeepEqual(d["initContainers"], want) {
+			t.Errorf("%q: initContainers = %v, want %v", version, d["initContainers"], want)
+		}
+		if _, ok := d["additionalContainers"]; ok {
+			t.Errorf("%q: additionalContainers = %v, want none", version, d["additionalContainers"])
+		}
+		if d["image"].(map[string]interface{})["repository"] != "shop" {
+			t.Errorf("%q: primary image = %v, want the app container's", version, d["image"])
+		}
+		if len(result.Diagnostics) != 0 {
+			t.Errorf("%q: diagnostics = %v", version, result.Diagnostics)
+		}
+	}
+}
+
+func TestContainerOrderBeforeNativeSidecars(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, orderedWorkload), Options{KubernetesVersion: "v1.28.9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := result.Values["deployment"].(map[string]interface{})
+	init := d["initContainers"].(map[string]interface{})
+	if len(init) != 1 || init["c-migrate"] == nil {
+		t.Errorf("initContainers = %v, want only c-migrate", init)
+	}
+	additional := d["additionalContainers"].([]map[string]interface{})
+	if len(additional) != 1 || additional[0]["name"] != "b-proxy" || additional[0]["restartPolicy"] != nil {
+		t.Errorf("additionalContainers = %v, want b-proxy as a plain container", additional)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Severity != SeverityWarning ||
+		result.Diagnostics[0].Field != "containers.b-proxy.x-hctl.role" ||
+		!strings.Contains(result.Diagnostics[0].Message, "predates native sidecars") {
+		t.Errorf("diagnostics = %v, want a native sidecar warning for b-proxy", result.Diagnostics)
+	}
+}
+
+func TestContainerOrderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		version   string
+		field     string
+		wantError string
+	}{
+		{
+			name: "cycle",
+			doc: `    x-hctl: {role: init, dependsOn: [b]}
+  b:
+    image: b
+    x-hctl: {role: sidecar, dependsOn: [c]}
+  c:
+    image: c
+    x-hctl: {role: init, dependsOn: [a]}
+`,
+			field:     "containers.a.x-hctl.dependsOn",
+			wantError: "dependency cycle a -> b -> c -> a",
+		},
+		{
+			name:      "unknown container",
+			doc:       "    x-hctl: {role: init, dependsOn: [nope]}\n",
+			field:     "containers.a.x-hctl.dependsOn",
+			wantError: `"nope" is not another container`,
+		},
+		{
+			name:      "unknown role",
+			doc:       "    x-hctl: {role: daemon}\n",
+			field:     "containers.a.x-hctl.role",
+			wantError: `unsupported role "daemon"`,
+		},
+		{
+			name:      "depends on a main container",
+			doc:       "  b:\n    image: b\n    x-hctl: {dependsOn: [app]}\n",
+			field:     "containers.b.x-hctl.dependsOn",
+			wantError: `"app" is a main container`,
+		},
+		{
+			name:      "sorts before its dependency",
+			doc:       "    x-hctl: {role: init, dependsOn: [b]}\n  b:\n    image: b\n    x-hctl: {role: sidecar}\n",
+			field:     "containers.a.x-hctl.dependsOn",
+			wantError: `"b" must start first`,
+		},
+		{
+			name:      "bad version",
+			doc:       "    x-hctl: {role: sidecar}\n",
+			version:   "latest",
+			wantError: `Kubernetes version "latest"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  app:
+    image: shop:1.0
+  a:
+    image: a
+` + tt.doc
+			_, err := Translate(loadExtensionWorkload(t, doc), Options{KubernetesVersion: tt.version})
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("err = %v, want %q", err, tt.wantError)
+			}
+			if tt.field == "" {
+				return
+			}
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || !errors.Is(err, hcerrors.ErrValidation) {
+				t.Fatalf("Translate() error = %v, want a validation error", err)
+			}
+			if field := he.Details.(map[string]string)["field"]; field != tt.field {
+				t.Errorf("error field = %q, want %q", field, tt.field)
+			}
+		})
+	}
+}
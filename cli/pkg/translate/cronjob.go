@@ -0,0 +1,181 @@
+package translate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// WorkloadTypeAnnotation set to WorkloadTypeCronJob runs the workload
+	// on a schedule, through the chart's cronJob values, instead of as a
+	// long-running controller.
+	WorkloadTypeAnnotation = "hctl.integratn.tech/workload-type"
+	// WorkloadTypeCronJob renders the workload as a CronJob.
+	WorkloadTypeCronJob = "cronjob"
+	// CronScheduleAnnotation is the five-field cron expression a cronjob
+	// workload runs on. Required for cronjob workloads.
+	CronScheduleAnnotation = "hctl.integratn.tech/schedule"
+	// ConcurrencyPolicyAnnotation is Allow, Forbid (default) or Replace.
+	ConcurrencyPolicyAnnotation = "hctl.integratn.tech/concurrency-policy"
+	// RestartPolicyAnnotation is OnFailure (default) or Never.
+	RestartPolicyAnnotation = "hctl.integratn.tech/restart-policy"
+	// BackoffLimitAnnotation is how often a failed run is retried.
+	// Defaults to 6, as in Kubernetes.
+	BackoffLimitAnnotation = "hctl.integratn.tech/backoff-limit"
+)
+
+// cronJobAnnotations only apply to cronjob workloads.
+var cronJobAnnotations = []string{CronScheduleAnnotation, ConcurrencyPolicyAnnotation, RestartPolicyAnnotation, BackoffLimitAnnotation}
+
+// cronJob is a validated cronjob workload type.
+type cronJob struct {
+	schedule          string
+	concurrencyPolicy string
+	restartPolicy     string
+	backoffLimit      int
+}
+
+// parseCronJob reads the workload-type annotation and the cronjob settings.
+// Returns nil when the workload is not a cronjob.
+func parseCronJob(w *score.Workload) (*cronJob, error) {
+	annotations := w.Metadata.Annotations
+	switch v := strings.TrimSpace(annotations[WorkloadTypeAnnotation]); v {
+	case WorkloadTypeCronJob:
+	case "":
+		for _, key := range cronJobAnnotations {
+			if _, ok := annotations[key]; ok {
+				return nil, annotationError(key, "annotation %s has no effect without %s: %s", key, WorkloadTypeAnnotation, WorkloadTypeCronJob)
+			}
+		}
+		return nil, nil
+	default:
+		return nil, annotationError(WorkloadTypeAnnotation, "annotation %s: unsupported type %q (expected %s)", WorkloadTypeAnnotation, v, WorkloadTypeCronJob)
+	}
+
+	c := &cronJob{
+		schedule:          strings.TrimSpace(annotations[CronScheduleAnnotation]),
+		concurrencyPolicy: "Forbid",
+		restartPolicy:     "OnFailure",
+		backoffLimit:      6,
+	}
+	if c.schedule == "" {
+		return nil, annotationError(CronScheduleAnnotation, "annotation %s is required for %s workloads", CronScheduleAnnotation, WorkloadTypeCronJob)
+	}
+	if _, err := parseCron(c.schedule); err != nil {
+		return nil, annotationError(CronScheduleAnnotation, "annotation %s: %v", CronScheduleAnnotation, err)
+	}
+	if v := strings.TrimSpace(annotations[ConcurrencyPolicyAnnotation]); v != "" {
+		if v != "Allow" && v != "Forbid" && v != "Replace" {
+			return nil, annotationError(ConcurrencyPolicyAnnotation, "annotation %s: invalid value %q (expected Allow, Forbid or Replace)", ConcurrencyPolicyAnnotation, v)
+		}
+		c.concurrencyPolicy = v
+	}
+	if v := strings.TrimSpace(annotations[RestartPolicyAnnotation]); v != "" {
+		if v != "OnFailure" && v != "Never" {
+			return nil, annotationError(RestartPolicyAnnotation, "annotation %s: invalid value %q (expected OnFailure or Never)", RestartPolicyAnnotation, v)
+		}
+		c.restartPolicy = v
+	}
+	if v := strings.TrimSpace(annotations[BackoffLimitAnnotation]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, annotationError(BackoffLimitAnnotation, "annotation %s: invalid value %q (expected a non-negative number)", BackoffLimitAnnotation, v)
+		}
+		c.backoffLimit = n
+	}
+	return c, nil
+}
+
+// checkCronJob rejects what a cronjob workload cannot have: a CronJob's
+// pods serve no traffic and run to completion, so there is nothing to
+// route to, scale, keep available or run beside the job.
+func checkCronJob(w *score.Workload) error {
+	if routes := routeNames(w); len(routes) > 0 {
+		return hcerrors.New(hcerrors.ErrValidation, "resource %q: %s workloads serve no traffic, so they cannot have route resources", routes[0], WorkloadTypeCronJob).
+			WithRemediation("remove the route, or drop the " + WorkloadTypeAnnotation + " annotation to run the workload as a Deployment").
+			WithDetails(map[string]string{"field": "resources." + routes[0]})
+	}
+	if len(w.Containers) > 1 {
+		names := make([]string, 0, len(w.Containers))
+		for name := range w.Containers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return hcerrors.New(hcerrors.ErrValidation, "%s workloads run a single container, but %d are declared (%s)", WorkloadTypeCronJob, len(names), strings.Join(names, ", ")).
+			WithDetails(map[string]string{"field": "containers"})
+	}
+	if _, ok := w.Metadata.Annotations[AlertsAnnotation]; ok {
+		return annotationError(AlertsAnnotation, "annotation %s cannot be combined with %s: %s", AlertsAnnotation, WorkloadTypeAnnotation, WorkloadTypeCronJob)
+	}
+	if strings.TrimSpace(w.Metadata.Annotations[OTelAnnotation]) == "true" {
+		return annotationError(OTelAnnotation, "annotation %s cannot be combined with %s: %s: the sidecar would keep each run from completing", OTelAnnotation, WorkloadTypeAnnotation, WorkloadTypeCronJob)
+	}
+	ext := w.Extensions
+	if ext == nil {
+		return nil
+	}
+	for _, c := range []struct {
+		set   bool
+		field string
+	}{
+		{ext.WorkloadKind != "" && ext.WorkloadKind != WorkloadKindDeployment, "x-hctl.workloadKind"},
+		{ext.Autoscaling != nil, "x-hctl.autoscaling"},
+		{ext.Schedule != nil, "x-hctl.schedule"},
+	} {
+		if c.set {
+			return hcerrors.New(hcerrors.ErrValidation, "%s cannot be combined with %s: %s", c.field, WorkloadTypeAnnotation, WorkloadTypeCronJob).
+				WithDetails(map[string]string{"field": c.field})
+		}
+	}
+	return nil
+}
+
+func annotationError(key, format string, args ...interface{}) error {
+	return hcerrors.New(hcerrors.ErrValidation, format, args...).
+		WithDetails(map[string]string{"field": "metadata.annotations." + key})
+}
+
+// cronJobPodKeys are the values of the primary container and its pod that
+// carry over from the deployment section to a cronJob job.
+var cronJobPodKeys = []string{"image", "command", "args", "env", "resources", "volumes", "volumeMounts", "nodeSelector", "affinity", "tolerations"}
+
+// values returns the chart's cronJob section, running the pod built under
+// the deployment section as the single job named after the workload.
+func (c *cronJob) values(deployment map[string]interface{}, workloadName string) map[string]interface{} {
+	job := map[string]interface{}{
+		"schedule":          c.schedule,
+		"concurrencyPolicy": c.concurrencyPolicy,
+		"restartPolicy":     c.restartPolicy,
+		"backoffLimit":      c.backoffLimit,
+	}
+	for _, key := range cronJobPodKeys {
+		if v, ok := deployment[key]; ok {
+			job[key] = v
+		}
+	}
+	return map[string]interface{}{
+		"enabled": true,
+		"jobs":    map[string]interface{}{workloadName: job},
+	}
+}
+
+// cronJobs returns the job values of an enabled cronJob section, by job
+// name.
+func cronJobs(values map[string]interface{}) map[string]map[string]interface{} {
+	section, ok := values["cronJob"].(map[string]interface{})
+	if !ok || section["enabled"] != true {
+		return nil
+	}
+	jobs, _ := section["jobs"].(map[string]interface{})
+	out := make(map[string]map[string]interface{}, len(jobs))
+	for name, j := range jobs {
+		if job, ok := j.(map[string]interface{}); ok {
+			out[name] = job
+		}
+	}
+	return out
+}
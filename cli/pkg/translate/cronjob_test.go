@@ -0,0 +1,146 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const cronWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: cleanup
+  annotations:
+    hctl.integratn.tech/cluster: dev
+    hctl.integratn.tech/workload-type: cronjob
+    hctl.integratn.tech/schedule: "0 3 * * *"
+containers:
+  job:
+    image: ghcr.io/example/cleanup:1.2
+    command: ["/bin/sh", "-c"]
+    args: ["cleanup --older-than=30d"]
+    variables:
+      DB_PASS: ${resources.db.password}
+    resources:
+      requests:
+        memory: 64Mi
+service:
+  ports:
+    metrics:
+      port: 9090
+resources:
+  db:
+    type: postgres
+`
+
+func TestCronJobValues(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, cronWorkload), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	values := decodedValues(t, result)
+	for _, key := range []string{"service", "httpRoute", "certificate", "statefulset"} {
+		if _, ok := values[key]; ok {
+			t.Errorf("values has a %s section: %v", key, values[key])
+		}
+	}
+	if d := values["deployment"]; !reflect.DeepEqual(d, map[string]interface{}{"enabled": false}) {
+		t.Errorf("deployment = %v, want disabled", d)
+	}
+
+	cron := values["cronJob"].(map[string]interface{})
+	if cron["enabled"] != true {
+		t.Errorf("cronJob.enabled = %v, want true", cron["enabled"])
+	}
+	job := cron["jobs"].(map[string]interface{})["cleanup"].(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"schedule":          "0 3 * * *",
+		"concurrencyPolicy": "Forbid",
+		"restartPolicy":     "OnFailure",
+		"backoffLimit":      6,
+		"image":             map[string]interface{}{"repository": "ghcr.io/example/cleanup", "tag": "1.2"},
+		"command":           []interface{}{"/bin/sh", "-c"},
+		"args":              []interface{}{"cleanup --older-than=30d"},
+	} {
+		if !reflect.DeepEqual(job[key], want) {
+			t.Errorf("job %s = %#v, want %#v", key, job[key], want)
+		}
+	}
+	env := job["env"].(map[string]interface{})
+	if _, ok := env["DB_PASS"].(map[string]interface{})["valueFrom"]; !ok {
+		t.Errorf("job env DB_PASS = %v, want a secret reference", env["DB_PASS"])
+	}
+	if labels := job["additionalLabels"].(map[string]interface{}); labels[WorkloadLabel] != "cleanup" {
+		t.Errorf("job additionalLabels = %v, want the ownership labels", labels)
+	}
+
+	var kinds []string
+	for _, o := range result.Inventory.Objects {
+		if o.Provisioner == InventoryChart {
+			kinds = append(kinds, o.Kind+"/"+o.Name)
+		}
+	}
+	if want := []string{"CronJob/cleanup"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("chart objects = %v, want %v", kinds, want)
+	}
+}
+
+func TestCronJobSettings(t *testing.T) {
+	spec := strings.Replace(cronWorkload, `    hctl.integratn.tech/schedule: "0 3 * * *"`, `    hctl.integratn.tech/schedule: "*/15 * * * *"
+    hctl.integratn.tech/concurrency-policy: Replace
+    hctl.integratn.tech/restart-policy: Never
+    hctl.integratn.tech/backoff-limit: "0"`, 1)
+	result, err := Translate(loadExtensionWorkload(t, spec), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	job := result.Values["cronJob"].(map[string]interface{})["jobs"].(map[string]interface{})["cleanup"].(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"schedule":          "*/15 * * * *",
+		"concurrencyPolicy": "Replace",
+		"restartPolicy":     "Never",
+		"backoffLimit":      0,
+	} {
+		if job[key] != want {
+			t.Errorf("job %s = %v, want %v", key, job[key], want)
+		}
+	}
+}
+
+func TestCronJobValidation(t *testing.T) {
+	const schedule = `    hctl.integratn.tech/schedule: "0 3 * * *"` + "\n"
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"route", "    type: postgres\n", "    type: postgres\n  web:\n    type: route\n    params:\n      host: cleanup.example.com\n      path: /\n      port: 9090\n", "resources.web"},
+		{"missing schedule", schedule, "", "metadata.annotations." + CronScheduleAnnotation},
+		{"bad schedule", schedule, `    hctl.integratn.tech/schedule: "0 3 * *"` + "\n", "metadata.annotations." + CronScheduleAnnotation},
+		{"unknown type", "workload-type: cronjob", "workload-type: job", "metadata.annotations." + WorkloadTypeAnnotation},
+		{"schedule without type", "    hctl.integratn.tech/workload-type: cronjob\n", "", "metadata.annotations." + CronScheduleAnnotation},
+		{"concurrency policy", schedule, schedule + "    hctl.integratn.tech/concurrency-policy: forbid\n", "metadata.annotations." + ConcurrencyPolicyAnnotation},
+		{"restart policy", schedule, schedule + "    hctl.integratn.tech/restart-policy: Always\n", "metadata.annotations." + RestartPolicyAnnotation},
+		{"backoff limit", schedule, schedule + "    hctl.integratn.tech/backoff-limit: \"-1\"\n", "metadata.annotations." + BackoffLimitAnnotation},
+		{"second container", "service:\n", "  sidecar:\n    image: busybox:1\nservice:\n", "containers"},
+		{"alerts", schedule, schedule + "    hctl.integratn.tech/alerts: basic\n", "metadata.annotations." + AlertsAnnotation},
+		{"statefulset", "containers:\n", "x-hctl:\n  workloadKind: statefulset\ncontainers:\n", "x-hctl.workloadKind"},
+		{"autoscaling", "containers:\n", "x-hctl:\n  autoscaling:\n    maxReplicas: 3\ncontainers:\n", "x-hctl.autoscaling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := strings.Replace(cronWorkload, tt.from, tt.to, 1)
+			if spec == cronWorkload {
+				t.Fatalf("%q not found in workload", tt.from)
+			}
+			_, err := Translate(loadExtensionWorkload(t, spec), Options{})
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || !errors.Is(err, hcerrors.ErrValidation) {
+				t.Fatalf("Translate() error = %v, want a validation error", err)
+			}
+			if field := he.Details.(map[string]string)["field"]; field != tt.want {
+				t.Errorf("error field = %q, want %q (%v)", field, tt.want, err)
+			}
+		})
+	}
+}
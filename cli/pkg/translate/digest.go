@@ -0,0 +1,111 @@
+package translate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const (
+	// PinDigestAnnotation opts a workload in ("true") or out ("false") of
+	// pinning its images to digests, overriding the caller's default.
+	PinDigestAnnotation = "hctl.integratn.tech/pin-digest"
+	// ImageTagAnnotation prefixes the Deployment annotations that record the
+	// tagged image a pinned container was resolved from, keyed by container
+	// name, e.g. hctl.integratn.tech/image-tag.web: ghcr.io/org/web:main.
+	ImageTagAnnotation = "hctl.integratn.tech/image-tag"
+)
+
+// PinDigest reports whether a workload's images should be pinned to
+// digests: the pin-digest annotation when set, otherwise def.
+func PinDigest(w *Workload, def bool) (bool, error) {
+	v := strings.TrimSpace(w.Metadata.Annotations[PinDigestAnnotation])
+	if v == "" {
+		return def, nil
+	}
+	pin, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, hcerrors.New(hcerrors.ErrValidation, "annotation %s: invalid value %q (expected \"true\" or \"false\")", PinDigestAnnotation, v).
+			WithDetails(map[string]string{"field": "metadata.annotations." + PinDigestAnnotation})
+	}
+	return pin, nil
+}
+
+// PinnableImages returns the distinct container images that digest pinning
+// would resolve, sorted. Images that already carry a digest and the "."
+// placeholder are left out.
+func PinnableImages(w *Workload) []string {
+	seen := map[string]bool{}
+	var images []string
+	for _, c := range w.Containers {
+		img := c.Image
+		if img == "" || img == "." || strings.Contains(img, "@") || seen[img] {
+			continue
+		}
+		seen[img] = true
+		images = append(images, img)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// imageName strips the tag from an image reference, leaving registry ports
+// in place.
+func imageName(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// pinContainers rewrites container images that have a digest in digests to
+// name@digest and records the tagged reference in a Deployment annotation.
+// The primary container's chart image gets a digest in place of its tag.
+func pinContainers(w *Workload, deployment map[string]interface{}, primary string, digests map[string]string) {
+	if len(digests) == 0 {
+		return
+	}
+	names := make([]string, 0, len(w.Containers))
+	for name := range w.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	annotations := map[string]interface{}{}
+	for _, name := range names {
+		c := w.Containers[name]
+		digest, ok := digests[c.Image]
+		if !ok {
+			continue
+		}
+		annotations[ImageTagAnnotation+"."+name] = c.Image
+		if name == primary {
+			deployment["image"] = map[string]interface{}{
+				"repository": imageName(c.Image),
+				"digest":     digest,
+			}
+			continue
+		}
+		containers, _ := deployment["additionalContainers"].([]map[string]interface{})
+		for _, spec := range containers {
+			if spec["name"] == name {
+				spec["image"] = imageName(c.Image) + "@" + digest
+			}
+		}
+		initContainers, _ := deployment["initContainers"].(map[string]interface{})
+		if spec, ok := initContainers[name].(map[string]interface{}); ok {
+			spec["image"] = imageName(c.Image) + "@" + digest
+		}
+	}
+	if len(annotations) == 0 {
+		return
+	}
+	if existing, ok := deployment["annotations"].(map[string]interface{}); ok {
+		for k, v := range annotations {
+			existing[k] = v
+		}
+		return
+	}
+	deployment["annotations"] = annotations
+}
@@ -0,0 +1,111 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func digestWorkload() *score.Workload {
+	return &score.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata: score.WorkloadMetadata{Name: "myapp", Annotations: map[string]string{
+			"hctl.integratn.tech/cluster": "dev",
+		}},
+		Containers: map[string]score.Container{
+			"app":     {Image: "localhost:5000/team/app:main"},
+			"sidecar": {Image: "busybox"},
+			"pinned":  {Image: "ghcr.io/org/proxy@" + testDigest},
+		},
+	}
+}
+
+func TestPinDigest(t *testing.T) {
+	tests := []struct {
+		annotation string
+		def        bool
+		want       bool
+	}{
+		{"", false, false},
+		{"", true, true},
+		{"true", false, true},
+		{"false", true, false},
+	}
+	for _, tt := range tests {
+		w := digestWorkload()
+		if tt.annotation != "" {
+			w.Metadata.Annotations[PinDigestAnnotation] = tt.annotation
+		}
+		got, err := PinDigest(w, tt.def)
+		if err != nil || got != tt.want {
+			t.Errorf("PinDigest(%q, %v) = %v, %v, want %v", tt.annotation, tt.def, got, err, tt.want)
+		}
+	}
+
+	w := digestWorkload()
+	w.Metadata.Annotations[PinDigestAnnotation] = "always"
+	if _, err := PinDigest(w, false); !errors.Is(err, hcerrors.ErrValidation) {
+		t.Errorf("PinDigest(always) error = %v, want validation error", err)
+	}
+}
+
+func TestPinnableImages(t *testing.T) {
+	want := []string{"busybox", "localhost:5000/team/app:main"}
+	if got := PinnableImages(digestWorkload()); !reflect.DeepEqual(got, want) {
+		t.Errorf("PinnableImages() = %v, want %v", got, want)
+	}
+}
+
+func TestTranslatePinsImages(t *testing.T) {
+	result, err := Translate(digestWorkload(), Options{ImageDigests: map[string]string{
+		"localhost:5000/team/app:main": testDigest,
+		"busybox":                      testDigest,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := result.Values["deployment"].(map[string]interface{})
+
+	wantImage := map[string]interface{}{"repository": "localhost:5000/team/app", "digest": testDigest}
+	if !reflect.DeepEqual(d["image"], wantImage) {
+		t.Errorf("image = %v, want %v", d["image"], wantImage)
+	}
+	images := map[string]interface{}{}
+	for _, c := range d["additionalContainers"].([]map[string]interface{}) {
+		images[c["name"].(string)] = c["image"]
+	}
+	wantImages := map[string]interface{}{
+		"pinned":  "ghcr.io/org/proxy@" + testDigest,
+		"sidecar": "busybox@" + testDigest,
+	}
+	if !reflect.DeepEqual(images, wantImages) {
+		t.Errorf("additional container images = %v, want %v", images, wantImages)
+	}
+	wantAnnotations := map[string]interface{}{
+		ImageTagAnnotation + ".app":     "localhost:5000/team/app:main",
+		ImageTagAnnotation + ".sidecar": "busybox",
+	}
+	if !reflect.DeepEqual(d["annotations"], wantAnnotations) {
+		t.Errorf("annotations = %v, want %v", d["annotations"], wantAnnotations)
+	}
+}
+
+func TestTranslateKeepsTagsByDefault(t *testing.T) {
+	result, err := Translate(digestWorkload(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := result.Values["deployment"].(map[string]interface{})
+	if _, ok := d["annotations"]; ok {
+		t.Errorf("unpinned translation added annotations: %v", d["annotations"])
+	}
+	image := d["image"].(map[string]interface{})
+	if _, ok := image["digest"]; ok {
+		t.Errorf("unpinned image has a digest: %v", image)
+	}
+}
@@ -0,0 +1,51 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// crossEnvironmentSecrets reports the 1Password items resource name reads
+// that are qualified with an environment other than cluster's, so a dev
+// workload cannot be pointed at prod credentials by naming their item.
+// Items without an environment suffix, and clusters without an
+// environment, are not checked.
+func crossEnvironmentSecrets(name string, res score.Resource, reqs []provisioners.SecretRequirement, cluster string, environments map[string]string) []Diagnostic {
+	env := environments[cluster]
+	if env == "" {
+		return nil
+	}
+	known := make([]string, 0, len(environments))
+	seen := map[string]bool{}
+	for _, e := range environments {
+		if !seen[e] {
+			seen[e] = true
+			known = append(known, e)
+		}
+	}
+	sort.Strings(known)
+
+	var diags []Diagnostic
+	for _, req := range reqs {
+		other := provisioners.ItemEnvironment(req.Item, known)
+		if other == "" || other == env {
+			continue
+		}
+		d := Diagnostic{
+			Severity: SeverityError,
+			Code:     DiagCrossEnvironmentSecret,
+			Field:    "resources." + name + ".params",
+			Message: fmt.Sprintf("1Password item %q belongs to environment %q, but cluster %q is in %q; set params.allowCrossEnvironment: true if it really must read it",
+				req.Item, other, cluster, env),
+		}
+		if provisioners.AllowsCrossEnvironment(res) {
+			d.Severity = SeverityWarning
+			d.Message = fmt.Sprintf("reads 1Password item %q of environment %q from cluster %q in %q (allowCrossEnvironment)", req.Item, other, cluster, env)
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
@@ -0,0 +1,54 @@
+package translate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTranslateSecretEnvironments(t *testing.T) {
+	environments := map[string]string{"media-dev": "dev", "media": "prod"}
+	translateDB := func(cluster, resource string) (*Result, error) {
+		t.Helper()
+		w, err := Load(strings.NewReader("apiVersion: score.dev/v1b1\nmetadata:\n  name: api\ncontainers:\n  main:\n    image: api:1\n    variables:\n      DB_HOST: ${resources.db.host}\nresources:\n  db:\n" + resource))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Translate(w, Options{Cluster: cluster, Environments: environments})
+	}
+
+	result, err := translateDB("media-dev", "    type: postgres\n    class: shared\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SecretRequirements) != 1 || result.SecretRequirements[0].Item != "shared-postgres-dev" {
+		t.Errorf("shared postgres on media-dev reads %+v, want shared-postgres-dev", result.SecretRequirements)
+	}
+
+	_, err = translateDB("media-dev", "    type: postgres\n    params:\n      item: shared-postgres-prod\n")
+	var diagErr *DiagnosticsError
+	if !errors.As(err, &diagErr) {
+		t.Fatalf("prod item on a dev cluster: err = %v, want a *DiagnosticsError", err)
+	}
+	d := diagErr.Diagnostics[0]
+	if d.Severity != SeverityError || d.Code != DiagCrossEnvironmentSecret || d.Field != "resources.db.params" || !strings.Contains(d.Message, `belongs to environment "prod"`) {
+		t.Errorf("first diagnostic = %+v, want a cross-environment error", d)
+	}
+
+	result, err = translateDB("media-dev", "    type: postgres\n    params:\n      item: shared-postgres-prod\n      allowCrossEnvironment: true\n")
+	if err != nil {
+		t.Fatalf("allowCrossEnvironment: %v", err)
+	}
+	var warned bool
+	for _, d := range result.Diagnostics {
+		warned = warned || (d.Code == DiagCrossEnvironmentSecret && d.Severity == SeverityWarning)
+	}
+	if !warned {
+		t.Errorf("allowCrossEnvironment should still warn, got %+v", result.Diagnostics)
+	}
+
+	// Without an environment for the target cluster nothing is checked.
+	if _, err := translateDB("other", "    type: postgres\n    params:\n      item: shared-postgres-prod\n"); err != nil {
+		t.Errorf("cluster without an environment: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package translate_test
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+func Example() {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: hello
+containers:
+  web:
+    image: nginx:1.27
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "dev",
+		Domain:   "cluster.integratn.tech",
+		Registry: provisioners.NewRegistry(),
+		Chart:    translate.DefaultChart(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	paths := make([]string, 0, len(result.Files))
+	for path := range result.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	fmt.Println(result.AddonsEntry["chartName"], result.AddonsEntry["defaultVersion"])
+	// Output:
+	// workloads/dev/addons/hello/.hctl-manifest.yaml
+	// workloads/dev/addons/hello/values.yaml
+	// application 6.14.0
+}
+
+func Example_customProvisioner() {
+	registry := provisioners.NewRegistry()
+	registry.Register(queueProvisioner{})
+
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: registry})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	env := result.Values["deployment"].(map[string]interface{})["env"].(map[string]interface{})
+	fmt.Println(env["QUEUE_URL"])
+	for _, d := range result.Diagnostics {
+		fmt.Println(d)
+	}
+	// Output:
+	// map[value:amqp://worker-jobs]
+	// warning: containers.main.variables.MISSING: reference ${resources.jobs.nope} is not resolved by hctl; it is passed through literally as hctl.integratn.tech/external-references allows
+}
@@ -0,0 +1,306 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// WorkloadKindDeployment renders the workload as a Deployment (default).
+	WorkloadKindDeployment = "deployment"
+	// WorkloadKindStatefulSet renders the workload through the chart's
+	// statefulset values, with per-replica volumes as volumeClaimTemplates.
+	WorkloadKindStatefulSet = "statefulset"
+
+	// perReplicaParam marks a volume resource as one claim per replica.
+	perReplicaParam = "perReplica"
+	// volumeStorageClass matches the storage class of shared volume PVCs.
+	volumeStorageClass = "democratic-csi-nfs"
+)
+
+// shape is the controller and Service layout selected by the x-hctl block.
+type shape struct {
+	kind           string
+	serviceEnabled bool
+	headless       bool
+	// perReplica holds the volume resources rendered as volumeClaimTemplates.
+	perReplica map[string]score.Resource
+	// autoscaling renders the chart's HorizontalPodAutoscaler when set.
+	autoscaling *score.AutoscalingExtension
+	// schedule and scaler render scheduled scaling when schedule is set.
+	schedule *Schedule
+	scaler   string
+	// cron renders the workload as a CronJob when set.
+	cron *cronJob
+}
+
+// parseShape validates the x-hctl extensions against the rest of the
+// workload: a disabled Service cannot back a route, route rules must target
+// declared service ports, a headless Service needs ports, per-replica volumes
+// need a StatefulSet, a schedule cannot be combined with autoscaling, and
+// a cronjob workload has none of them.
+func parseShape(w *score.Workload) (*shape, error) {
+	s := &shape{kind: WorkloadKindDeployment, serviceEnabled: true, perReplica: map[string]score.Resource{}}
+	var err error
+	if s.cron, err = parseCronJob(w); err != nil {
+		return nil, err
+	}
+	if s.cron != nil {
+		if err := checkCronJob(w); err != nil {
+			return nil, err
+		}
+	}
+	if ext := w.Extensions; ext != nil {
+		switch ext.WorkloadKind {
+		case "", WorkloadKindDeployment:
+		case WorkloadKindStatefulSet:
+			s.kind = WorkloadKindStatefulSet
+		default:
+			return nil, hcerrors.New(hcerrors.ErrValidation, "x-hctl.workloadKind: unsupported kind %q (expected deployment or statefulset)", ext.WorkloadKind).
+				WithDetails(map[string]string{"field": "x-hctl.workloadKind"})
+		}
+		if svc := ext.Service; svc != nil {
+			if svc.Enabled != nil {
+				s.serviceEnabled = *svc.Enabled
+			}
+			s.headless = svc.Headless
+		}
+		if err := s.parseScaling(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.headless && !s.serviceEnabled {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "x-hctl.service.headless cannot be combined with x-hctl.service.enabled: false").
+			WithDetails(map[string]string{"field": "x-hctl.service"})
+	}
+	if s.headless && (w.Service == nil || len(w.Service.Ports) == 0) {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "x-hctl.service.headless requires service.ports").
+			WithDetails(map[string]string{"field": "service.ports"})
+	}
+	if !s.serviceEnabled {
+		if routes := routeNames(w); len(routes) > 0 {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "resource %q: route resources need a Service, but x-hctl.service.enabled is false", routes[0]).
+				WithDetails(map[string]string{"field": "resources." + routes[0]})
+		}
+	}
+	if err := checkRoutePorts(w); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(w.Resources))
+	for name := range w.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res := w.Resources[name]
+		if res.Type != "volume" {
+			continue
+		}
+		perReplica, _ := res.Params[perReplicaParam].(bool)
+		if !perReplica {
+			continue
+		}
+		if s.kind != WorkloadKindStatefulSet {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "resource %q: params.perReplica requires x-hctl.workloadKind: statefulset", name).
+				WithDetails(map[string]string{"field": "resources." + name + ".params.perReplica"})
+		}
+		if shared, _ := res.Params["shared"].(bool); shared {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "resource %q: params.perReplica gives each pod its own claim, so it cannot be shared", name).
+				WithDetails(map[string]string{"field": "resources." + name + ".params.shared"})
+		}
+		s.perReplica[name] = res
+	}
+	return s, nil
+}
+
+// checkRoutePorts checks that every port a route's params.rules sends
+// traffic to is declared in service.ports. Invalid route params are left
+// for the provisioner to report.
+func checkRoutePorts(w *score.Workload) error {
+	declared := map[int]bool{}
+	ports := []string{}
+	if w.Service != nil {
+		for name, p := range w.Service.Ports {
+			declared[p.Port] = true
+			ports = append(ports, fmt.Sprintf("%s=%d", name, p.Port))
+		}
+	}
+	sort.Strings(ports)
+	for _, name := range routeNames(w) {
+		spec, err := provisioners.ParseRoute(name, w.Resources[name])
+		if err != nil {
+			continue
+		}
+		for _, r := range spec.PathRules {
+			if !declared[r.Port] {
+				return hcerrors.New(hcerrors.ErrValidation, "resource %q: the rule for %s targets port %d, which is not declared in service.ports", name, r.Path, r.Port).
+					WithDetails(map[string]string{"field": "resources." + name + ".params.rules"}).
+					WithRemediation("declare the port in service.ports (declared: " + strings.Join(ports, ", ") + ")")
+			}
+		}
+	}
+	return nil
+}
+
+// parseScaling validates the autoscaling and schedule blocks. Both set the
+// replica count, so only one may be used.
+func (s *shape) parseScaling(w *score.Workload) error {
+	ext := w.Extensions
+	if ext.Autoscaling != nil && ext.Schedule != nil {
+		return hcerrors.New(hcerrors.ErrValidation, "x-hctl.schedule cannot be combined with x-hctl.autoscaling: both set the replica count").
+			WithDetails(map[string]string{"field": "x-hctl.schedule"})
+	}
+
+	if a := ext.Autoscaling; a != nil {
+		if s.kind != WorkloadKindDeployment {
+			return hcerrors.New(hcerrors.ErrValidation, "x-hctl.autoscaling requires x-hctl.workloadKind: deployment").
+				WithDetails(map[string]string{"field": "x-hctl.autoscaling"})
+		}
+		scaling := *a
+		if scaling.MinReplicas == 0 {
+			scaling.MinReplicas = 1
+		}
+		if scaling.TargetCPUUtilizationPercentage == 0 {
+			scaling.TargetCPUUtilizationPercentage = 80
+		}
+		if scaling.MinReplicas < 1 || scaling.MaxReplicas < scaling.MinReplicas {
+			return hcerrors.New(hcerrors.ErrValidation, "x-hctl.autoscaling: need 1 <= minReplicas (%d) <= maxReplicas (%d)", scaling.MinReplicas, scaling.MaxReplicas).
+				WithDetails(map[string]string{"field": "x-hctl.autoscaling.maxReplicas"})
+		}
+		if scaling.TargetCPUUtilizationPercentage < 1 || scaling.TargetCPUUtilizationPercentage > 100 {
+			return hcerrors.New(hcerrors.ErrValidation, "x-hctl.autoscaling.targetCPUUtilizationPercentage: %d is outside 1-100", scaling.TargetCPUUtilizationPercentage).
+				WithDetails(map[string]string{"field": "x-hctl.autoscaling.targetCPUUtilizationPercentage"})
+		}
+		s.autoscaling = &scaling
+	}
+
+	if ext.Schedule != nil {
+		sched, err := ParseSchedule(ext.Schedule)
+		if err != nil {
+			return err
+		}
+		if s.scaler, err = parseScaler(w); err != nil {
+			return err
+		}
+		s.schedule = sched
+	}
+	return nil
+}
+
+// scaleTarget is the controller scheduled scaling acts on.
+func (s *shape) scaleTarget(workloadName string) scaleTarget {
+	if s.kind == WorkloadKindStatefulSet {
+		return scaleTarget{kind: "StatefulSet", name: workloadName}
+	}
+	return scaleTarget{kind: "Deployment", name: workloadName}
+}
+
+// scheduleObjects returns the scaler manifests for the schedule, if any.
+func (s *shape) scheduleObjects(workloadName string) []map[string]interface{} {
+	if s.schedule == nil {
+		return nil
+	}
+	return s.schedule.manifests(s.scaler, s.scaleTarget(workloadName))
+}
+
+// isPerReplica reports whether the named resource becomes a volumeClaimTemplate.
+func (s *shape) isPerReplica(name string) bool {
+	_, ok := s.perReplica[name]
+	return ok
+}
+
+// apply rewrites the generated values for the selected Service mode,
+// scaling and workload kind. For a StatefulSet, the pod spec built under "deployment"
+// moves to "statefulset" and the Deployment is disabled; for a cronjob it
+// becomes the single job of "cronJob".
+func (s *shape) apply(values map[string]interface{}, workloadName string) {
+	if s.cron != nil {
+		values["cronJob"] = s.cron.values(values["deployment"].(map[string]interface{}), workloadName)
+		values["deployment"] = map[string]interface{}{"enabled": false}
+		return
+	}
+
+	switch {
+	case !s.serviceEnabled:
+		values["service"] = map[string]interface{}{"enabled": false}
+	case s.headless:
+		if svc, ok := values["service"].(map[string]interface{}); ok {
+			svc["clusterIP"] = "None"
+			svc["publishNotReadyAddresses"] = true
+		}
+	}
+
+	if a := s.autoscaling; a != nil {
+		values["autoscaling"] = map[string]interface{}{
+			"enabled":     true,
+			"minReplicas": a.MinReplicas,
+			"maxReplicas": a.MaxReplicas,
+			"metrics": []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name": "cpu",
+						"target": map[string]interface{}{
+							"type":               "Utilization",
+							"averageUtilization": a.TargetCPUUtilizationPercentage,
+						},
+					},
+				},
+			},
+		}
+	}
+	if s.schedule != nil {
+		values["deployment"].(map[string]interface{})["replicas"] = s.schedule.Replicas
+	}
+
+	if s.kind != WorkloadKindStatefulSet {
+		return
+	}
+	sts := values["deployment"].(map[string]interface{})
+	sts["enabled"] = true
+	if s.serviceEnabled {
+		sts["serviceName"] = workloadName
+	}
+	if templates := s.volumeClaimTemplates(); len(templates) > 0 {
+		sts["volumeClaimTemplates"] = templates
+	}
+	values["statefulset"] = sts
+	values["deployment"] = map[string]interface{}{"enabled": false}
+}
+
+// volumeClaimTemplates derives one ReadWriteOnce claim template per
+// per-replica volume resource, named after the resource so container
+// volumeMounts bind to it.
+func (s *shape) volumeClaimTemplates() []map[string]interface{} {
+	names := make([]string, 0, len(s.perReplica))
+	for name := range s.perReplica {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var templates []map[string]interface{}
+	for _, name := range names {
+		size := "1Gi"
+		if v, ok := s.perReplica[name].Params["size"].(string); ok {
+			size = v
+		}
+		templates = append(templates, map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"accessModes":      []string{"ReadWriteOnce"},
+				"storageClassName": volumeStorageClass,
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"storage": size},
+				},
+			},
+		})
+	}
+	return templates
+}
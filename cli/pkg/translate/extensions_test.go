@@ -0,0 +1,289 @@
+package translate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func loadExtensionWorkload(t *testing.T, spec string) *Workload {
+	t.Helper()
+	w, err := Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return w
+}
+
+const natsWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: nats
+  annotations:
+    hctl.integratn.tech/cluster: dev
+x-hctl:
+  workloadKind: statefulset
+  service:
+    headless: true
+containers:
+  nats:
+    image: nats:2.10
+    volumes:
+      data:
+        source: data
+        path: /data
+      shared:
+        source: config
+        path: /etc/nats
+        readOnly: true
+service:
+  ports:
+    client:
+      port: 4222
+    cluster:
+      port: 6222
+resources:
+  data:
+    type: volume
+    params:
+      size: 5Gi
+      perReplica: true
+  config:
+    type: volume
+`
+
+func TestHeadlessService(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, natsWorkload), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	svc := result.Values["service"].(map[string]interface{})
+	if svc["clusterIP"] != "None" {
+		t.Errorf("service.clusterIP = %v, want None", svc["clusterIP"])
+	}
+	if svc["publishNotReadyAddresses"] != true {
+		t.Errorf("service.publishNotReadyAddresses = %v, want true", svc["publishNotReadyAddresses"])
+	}
+	if ports := svc["ports"].([]map[string]interface{}); len(ports) != 2 {
+		t.Errorf("service.ports = %v, want client and cluster", ports)
+	}
+}
+
+func TestStatefulSetVolumeClaimTemplates(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, natsWorkload), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+
+	if d := result.Values["deployment"]; !reflect.DeepEqual(d, map[string]interface{}{"enabled": false}) {
+		t.Errorf("deployment = %v, want only enabled: false", d)
+	}
+	sts := result.Values["statefulset"].(map[string]interface{})
+	if sts["enabled"] != true || sts["serviceName"] != "nats" {
+		t.Errorf("statefulset enabled/serviceName = %v/%v", sts["enabled"], sts["serviceName"])
+	}
+
+	wantTemplates := []map[string]interface{}{{
+		"metadata": map[string]interface{}{"name": "data"},
+		"spec": map[string]interface{}{
+			"accessModes":      []string{"ReadWriteOnce"},
+			"storageClassName": "democratic-csi-nfs",
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"storage": "5Gi"},
+			},
+		},
+	}}
+	if !reflect.DeepEqual(sts["volumeClaimTemplates"], wantTemplates) {
+		t.Errorf("volumeClaimTemplates = %v, want %v", sts["volumeClaimTemplates"], wantTemplates)
+	}
+
+	// The per-replica mount binds to the claim template by name; the shared
+	// volume keeps its PVC.
+	mounts := sts["volumeMounts"].(map[string]interface{})
+	if _, ok := mounts["data"]; !ok {
+		t.Errorf("volumeMounts = %v, want entry named after the claim template", mounts)
+	}
+	volumes := sts["volumes"].(map[string]interface{})
+	if _, ok := volumes["data"]; ok {
+		t.Error("per-replica volume should not be backed by a shared PVC")
+	}
+	if _, ok := volumes["shared"]; !ok {
+		t.Errorf("volumes = %v, want shared PVC volume", volumes)
+	}
+
+	var pvcs []string
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		if m["kind"] == "PersistentVolumeClaim" {
+			pvcs = append(pvcs, m["metadata"].(map[string]interface{})["name"].(string))
+		}
+	}
+	if !reflect.DeepEqual(pvcs, []string{"nats-config"}) {
+		t.Errorf("PVC extraObjects = %v, want only the shared nats-config", pvcs)
+	}
+}
+
+func TestDisabledServiceRejectsRoute(t *testing.T) {
+	w := loadExtensionWorkload(t, `apiVersion: score.dev/v1b1
+metadata:
+  name: worker
+  annotations:
+    hctl.integratn.tech/cluster: dev
+x-hctl:
+  service:
+    enabled: false
+containers:
+  main:
+    image: worker:1.0
+service:
+  ports:
+    health:
+      port: 8080
+resources:
+  web:
+    type: route
+    params:
+      host: worker.example.com
+`)
+	_, err := Translate(w, Options{})
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+	if !strings.Contains(err.Error(), `"web"`) {
+		t.Errorf("error %q should name the route resource", err)
+	}
+}
+
+const multiBackendWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  api:
+    image: shop-api:1.0
+  assets:
+    image: shop-assets:1.0
+service:
+  ports:
+    api:
+      port: 8080
+    assets:
+      port: 8081
+resources:
+  web:
+    type: route
+    params:
+      host: shop.example.com
+      rules:
+        - path: /
+          port: %d
+        - path: /api
+          port: 8080
+`
+
+func TestRouteRulesRenderOneHTTPRoute(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, fmt.Sprintf(multiBackendWorkload, 8081)), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	rules := result.Values["httpRoute"].(map[string]interface{})["rules"].([]interface{})
+	var got []string
+	for _, r := range rules {
+		rule := r.(map[string]interface{})
+		path := rule["matches"].([]interface{})[0].(map[string]interface{})["path"].(map[string]interface{})
+		ref := rule["backendRefs"].([]interface{})[0].(map[string]interface{})
+		got = append(got, fmt.Sprintf("%v->%v:%v", path["value"], ref["name"], ref["port"]))
+	}
+	if want := []string{"/api->shop:8080", "/->shop:8081"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("httpRoute rules = %v, want %v", got, want)
+	}
+}
+
+func TestRouteRulesRejectUndeclaredPort(t *testing.T) {
+	_, err := Translate(loadExtensionWorkload(t, fmt.Sprintf(multiBackendWorkload, 9090)), Options{})
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+	for _, want := range []string{`"web"`, "port 9090", "service.ports"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+}
+
+func TestDisabledServiceKeepsContainerPorts(t *testing.T) {
+	w := loadExtensionWorkload(t, `apiVersion: score.dev/v1b1
+metadata:
+  name: worker
+  annotations:
+    hctl.integratn.tech/cluster: dev
+x-hctl:
+  service:
+    enabled: false
+containers:
+  main:
+    image: worker:1.0
+service:
+  ports:
+    health:
+      port: 8080
+`)
+	result, err := Translate(w, Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if svc := result.Values["service"]; !reflect.DeepEqual(svc, map[string]interface{}{"enabled": false}) {
+		t.Errorf("service = %v, want enabled: false", svc)
+	}
+	d := result.Values["deployment"].(map[string]interface{})
+	if ports := d["ports"].([]map[string]interface{}); len(ports) != 1 || ports[0]["containerPort"] != 8080 {
+		t.Errorf("deployment.ports = %v, want the health port for probes", d["ports"])
+	}
+}
+
+func TestExtensionValidation(t *testing.T) {
+	cases := map[string]string{
+		"unknown kind": `x-hctl:
+  workloadKind: daemonset
+`,
+		"perReplica without statefulset": `resources:
+  data:
+    type: volume
+    params:
+      perReplica: true
+`,
+		"headless without ports": `x-hctl:
+  service:
+    headless: true
+`,
+		"headless and disabled": `x-hctl:
+  service:
+    headless: true
+    enabled: false
+service:
+  ports:
+    http:
+      port: 80
+`,
+	}
+	for name, extra := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := loadExtensionWorkload(t, `apiVersion: score.dev/v1b1
+metadata:
+  name: app
+  annotations:
+    hctl.integratn.tech/cluster: dev
+containers:
+  main:
+    image: app:1.0
+`+extra)
+			_, err := Translate(w, Options{})
+			if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+				t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+			}
+		})
+	}
+}
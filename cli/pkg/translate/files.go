@@ -0,0 +1,217 @@
+package translate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// invalidKeyChars matches the characters a ConfigMap key cannot hold.
+var invalidKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// invalidLabelChars matches the characters a volume name cannot hold.
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// containerFile is a Score file of one container, rendered as a key of the
+// workload's files ConfigMap and mounted at its path with subPath.
+type containerFile struct {
+	container string
+	path      string
+	key       string
+	volume    string
+	mode      int
+	content   string
+	noExpand  bool
+}
+
+// workloadFiles is the validated files of the workload's containers, by
+// container and then path.
+type workloadFiles struct {
+	files []containerFile
+}
+
+// parseFiles validates the files sections of the workload's containers. A
+// file needs an absolute path and inline content; binaryContent and source
+// are not supported. Returns nil when no container has files.
+func parseFiles(w *score.Workload) (*workloadFiles, error) {
+	var files []containerFile
+	volumes := map[string]string{}
+	for _, cname := range sortedKeys(w.Containers) {
+		c := w.Containers[cname]
+		for _, p := range sortedKeys(c.Files) {
+			f := c.Files[p]
+			field := "containers." + cname + ".files." + p
+			if !path.IsAbs(p) {
+				return nil, fileError(field, "file %q of container %q: the path must be absolute", p, cname)
+			}
+			if f.BinaryContent != "" || f.Source != "" {
+				return nil, fileError(field, "file %q of container %q: only inline content is supported, not binaryContent or source", p, cname).
+					WithRemediation("set the file's content in score.yaml")
+			}
+			cf := containerFile{
+				container: cname,
+				path:      p,
+				key:       strings.Trim(invalidKeyChars.ReplaceAllString(cname+"-"+strings.ReplaceAll(strings.Trim(p, "/"), "/", "-"), "-"), "-."),
+				content:   f.Content,
+				noExpand:  f.NoExpand,
+			}
+			cf.volume = strings.Trim(invalidLabelChars.ReplaceAllString(strings.ToLower("file-"+cf.key), "-"), "-")
+			if len(cf.volume) > 63 {
+				cf.volume = strings.TrimRight(cf.volume[:63], "-")
+			}
+			if other, ok := volumes[cf.volume]; ok {
+				return nil, fileError(field, "file %q of container %q and %s are both mounted as volume %q", p, cname, other, cf.volume).
+					WithRemediation("rename one of the files")
+			}
+			volumes[cf.volume] = fmt.Sprintf("file %q of container %q", p, cname)
+			if f.Mode != "" {
+				mode, err := strconv.ParseUint(f.Mode, 8, 32)
+				if err != nil || mode > 0o777 {
+					return nil, fileError(field+".mode", "file %q of container %q: invalid mode %q (expected octal permissions such as 0644)", p, cname, f.Mode)
+				}
+				cf.mode = int(mode)
+			}
+			files = append(files, cf)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return &workloadFiles{files: files}, nil
+}
+
+func fileError(field, format string, args ...interface{}) *hcerrors.HctlError {
+	return hcerrors.New(hcerrors.ErrValidation, format, args...).
+		WithDetails(map[string]string{"field": field})
+}
+
+// configMapName is the name of the ConfigMap holding the workload's files.
+func (f *workloadFiles) configMapName(workload string) string {
+	return workload + "-files"
+}
+
+// apply mounts each file into its container: the primary one through the
+// deployment section's volumeMounts, the others through their own. The
+// content has the references to plain resource outputs substituted, unless
+// the file sets noExpand. Returns the ConfigMap to add to extraObjects.
+func (f *workloadFiles) apply(deployment map[string]interface{}, pods *podLayout, workload, namespace string, allOutputs map[string]map[string]string) map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	volumes, _ := deployment["volumes"].(map[string]interface{})
+	if volumes == nil {
+		volumes = map[string]interface{}{}
+	}
+	data := map[string]interface{}{}
+	for _, file := range f.files {
+		content := file.content
+		if !file.noExpand {
+			content = interpolate(content, allOutputs)
+		}
+		data[file.key] = content
+
+		item := map[string]interface{}{"key": file.key, "path": file.key}
+		if file.mode != 0 {
+			item["mode"] = file.mode
+		}
+		volumes[file.volume] = map[string]interface{}{
+			"configMap": map[string]interface{}{
+				"name":  f.configMapName(workload),
+				"items": []interface{}{item},
+			},
+		}
+		mount := map[string]interface{}{"mountPath": file.path, "subPath": file.key, "readOnly": true}
+		if file.container == pods.primary {
+			mounts, _ := deployment["volumeMounts"].(map[string]interface{})
+			if mounts == nil {
+				mounts = map[string]interface{}{}
+			}
+			mounts[file.volume] = mount
+			deployment["volumeMounts"] = mounts
+			continue
+		}
+		mount["name"] = file.volume
+		if spec := containerValues(deployment, file.container); spec != nil {
+			mounts, _ := spec["volumeMounts"].([]map[string]interface{})
+			spec["volumeMounts"] = append(mounts, mount)
+		}
+	}
+	deployment["volumes"] = volumes
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      f.configMapName(workload),
+			"namespace": namespace,
+		},
+		"data": data,
+	}
+}
+
+// literalReferences returns the references in the content of noExpand
+// files, as resource.key, which reach the ConfigMap unresolved on purpose.
+func (f *workloadFiles) literalReferences() []string {
+	if f == nil {
+		return nil
+	}
+	var refs []string
+	for _, file := range f.files {
+		if !file.noExpand {
+			continue
+		}
+		for _, ref := range ResourceReferences(file.content) {
+			refs = append(refs, ref[0]+"."+ref[1])
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// containerValues returns the values of a container other than the primary
+// one: an additional container, or an init or sidecar container.
+func containerValues(deployment map[string]interface{}, name string) map[string]interface{} {
+	additional, _ := deployment["additionalContainers"].([]map[string]interface{})
+	for _, spec := range additional {
+		if spec["name"] == name {
+			return spec
+		}
+	}
+	initContainers, _ := deployment["initContainers"].(map[string]interface{})
+	spec, _ := initContainers[name].(map[string]interface{})
+	return spec
+}
+
+// fileDiagnostics checks the references in the content of the file at
+// field as referenceDiagnostics checks a variable's. A reference to a
+// secret or ConfigMap output is an error even as the whole content: its
+// value only exists in the cluster, so hctl cannot write it into the files
+// ConfigMap.
+func fileDiagnostics(field string, f score.File, allOutputs map[string]map[string]string, external map[string]bool) []Diagnostic {
+	if f.NoExpand {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, m := range scoreVarRegex.FindAllStringSubmatch(f.Content, -1) {
+		if kind := keyRefKind(allOutputs[m[1]][m[2]]); kind != "" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     DiagKeyRefInFile,
+				Field:    field,
+				Message:  fmt.Sprintf("reference %s is a %s, which hctl cannot write into a file; pass it to the container as a variable instead", m[0], kind),
+			})
+		}
+	}
+	for _, d := range referenceDiagnostics(field, f.Content, allOutputs, external) {
+		if d.Code != DiagSecretInString {
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
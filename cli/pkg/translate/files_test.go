@@ -0,0 +1,141 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+const filesWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: api
+containers:
+  main:
+    image: api:1
+    files:
+      /etc/api/config.yaml:
+        mode: "0600"
+        content: |
+          data: ${resources.data.source}
+          level: info
+  tools:
+    image: tools:1
+    files:
+      /opt/tools/run.sh:
+        noExpand: true
+        content: echo ${resources.data.source}
+resources:
+  data:
+    type: volume
+`
+
+func TestFilesValues(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, filesWorkload), Options{Cluster: "dev"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	values := decodedValues(t, result)
+	deployment := values["deployment"].(map[string]interface{})
+
+	mounts := deployment["volumeMounts"].(map[string]interface{})
+	want := map[string]interface{}{"mountPath": "/etc/api/config.yaml", "subPath": "main-etc-api-config.yaml", "readOnly": true}
+	if got := mounts["file-main-etc-api-config-yaml"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("primary mount = %v, want %v", got, want)
+	}
+	volumes := deployment["volumes"].(map[string]interface{})
+	wantVolume := map[string]interface{}{"configMap": map[string]interface{}{
+		"name":  "api-files",
+		"items": []interface{}{map[string]interface{}{"key": "main-etc-api-config.yaml", "path": "main-etc-api-config.yaml", "mode": 0o600}},
+	}}
+	if got := volumes["file-main-etc-api-config-yaml"]; !reflect.DeepEqual(got, wantVolume) {
+		t.Errorf("primary volume = %v, want %v", got, wantVolume)
+	}
+	if _, ok := volumes["file-tools-opt-tools-run-sh"]; !ok {
+		t.Errorf("volumes = %v, want one for the tools file", volumes)
+	}
+	tools := deployment["additionalContainers"].([]interface{})[0].(map[string]interface{})
+	wantMounts := []interface{}{map[string]interface{}{"name": "file-tools-opt-tools-run-sh", "mountPath": "/opt/tools/run.sh", "subPath": "tools-opt-tools-run.sh", "readOnly": true}}
+	if !reflect.DeepEqual(tools["volumeMounts"], wantMounts) {
+		t.Errorf("tools volumeMounts = %v, want %v", tools["volumeMounts"], wantMounts)
+	}
+
+	var data map[string]interface{}
+	for _, obj := range values["extraObjects"].([]interface{}) {
+		if m := obj.(map[string]interface{}); m["kind"] == "ConfigMap" {
+			data = m["data"].(map[string]interface{})
+		}
+	}
+	if got := data["main-etc-api-config.yaml"]; got != "data: api-data\nlevel: info\n" {
+		t.Errorf("config.yaml = %q, want the reference substituted", got)
+	}
+	if got := data["tools-opt-tools-run.sh"]; got != "echo ${resources.data.source}" {
+		t.Errorf("run.sh = %q, want the noExpand content as written", got)
+	}
+
+	var objects []string
+	for _, o := range result.Inventory.Objects {
+		if o.Provisioner == InventoryFiles {
+			objects = append(objects, o.Kind+"/"+o.Name)
+		}
+	}
+	if want := []string{"ConfigMap/api-files"}; !reflect.DeepEqual(objects, want) {
+		t.Errorf("files objects = %v, want %v", objects, want)
+	}
+}
+
+func TestFilesValidation(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"relative path", "/etc/api/config.yaml:", "config.yaml:", "containers.main.files.config.yaml"},
+		{"source", "        mode: \"0600\"\n", "        source: ./config.yaml\n", "containers.main.files./etc/api/config.yaml"},
+		{"binary content", "        mode: \"0600\"\n", "        binaryContent: aGk=\n", "containers.main.files./etc/api/config.yaml"},
+		{"mode", `mode: "0600"`, `mode: "rw"`, "containers.main.files./etc/api/config.yaml.mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := strings.Replace(filesWorkload, tt.from, tt.to, 1)
+			if spec == filesWorkload {
+				t.Fatalf("%q not found in workload", tt.from)
+			}
+			_, err := Translate(loadExtensionWorkload(t, spec), Options{Cluster: "dev"})
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || !errors.Is(err, hcerrors.ErrValidation) {
+				t.Fatalf("Translate() error = %v, want a validation error", err)
+			}
+			if field := he.Details.(map[string]string)["field"]; field != tt.want {
+				t.Errorf("error field = %q, want %q (%v)", field, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFilesRejectSecretReferences(t *testing.T) {
+	spec := strings.Replace(filesWorkload, "level: info", "password: ${resources.cache.password}", 1) + "  cache:\n    type: redis\n"
+	_, err := Translate(loadExtensionWorkload(t, spec), Options{Cluster: "dev"})
+	var de *DiagnosticsError
+	if !errors.As(err, &de) {
+		t.Fatalf("Translate() error = %v, want diagnostics", err)
+	}
+	if d := de.Diagnostics[0]; d.Code != DiagKeyRefInFile || d.Field != "containers.main.files./etc/api/config.yaml.content" {
+		t.Errorf("diagnostic = %+v", d)
+	}
+}
+
+func TestResolveVariableValueConfigMap(t *testing.T) {
+	outputs := map[string]map[string]string{"settings": {"level": "$(platform-settings::log-level)"}}
+	want := map[string]interface{}{"valueFrom": map[string]interface{}{
+		"configMapKeyRef": map[string]interface{}{"name": "platform-settings", "key": "log-level"},
+	}}
+	for _, val := range []string{"${resources.settings.level}", "$(platform-settings::log-level)"} {
+		if got := resolveVariableValue(val, outputs); !reflect.DeepEqual(got, want) {
+			t.Errorf("resolveVariableValue(%q) = %v, want %v", val, got, want)
+		}
+	}
+	if got := resolveVariableValue("level=${resources.settings.level}", outputs); !reflect.DeepEqual(got, map[string]interface{}{"value": "level=${resources.settings.level}"}) {
+		t.Errorf("resolveVariableValue(in a string) = %v, want the reference kept", got)
+	}
+}
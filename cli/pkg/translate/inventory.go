@@ -0,0 +1,213 @@
+package translate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InventoryFile is the manifest inventory written beside a workload's
+// values.yaml: every Kubernetes object the values render, so remove --purge
+// and deploy diff know what hctl generated even after the generation logic
+// changes.
+const InventoryFile = ".hctl-manifest.yaml"
+
+// Provisioners recorded for objects that no Score resource produced.
+const (
+	// InventoryChart is the application chart's own objects, from the
+	// values sections.
+	InventoryChart = "chart"
+	// InventorySchedule is the scaling objects of x-hctl.schedule.
+	InventorySchedule = "schedule"
+	// InventoryAlerts is the PrometheusRule of x-hctl.alerts.
+	InventoryAlerts = "alerts"
+	// InventoryObservability is the observability sidecar's ConfigMap.
+	InventoryObservability = "observability"
+	// InventoryFiles is the ConfigMap of the containers' files.
+	InventoryFiles = "files"
+	// InventorySet is extraObjects added by --set overrides.
+	InventorySet = "set"
+)
+
+// chartObjects are the kinds the application chart renders from each
+// enabled values section, and the suffix it adds to applicationName.
+var chartObjects = map[string]struct {
+	group, kind, suffix string
+}{
+	"deployment":  {"apps", "Deployment", ""},
+	"statefulset": {"apps", "StatefulSet", ""},
+	"service":     {"", "Service", ""},
+	"httpRoute":   {"gateway.networking.k8s.io", "HTTPRoute", ""},
+	"certificate": {"cert-manager.io", "Certificate", "-certificate"},
+	"autoscaling": {"autoscaling", "HorizontalPodAutoscaler", ""},
+}
+
+// Inventory lists the objects generated for a workload. Its content depends
+// only on the translation input and the hctl version, so an unchanged
+// workload writes the same file.
+type Inventory struct {
+	Workload  string `yaml:"workload"`
+	Cluster   string `yaml:"cluster"`
+	Namespace string `yaml:"namespace"`
+	// HctlVersion is the hctl that generated the objects.
+	HctlVersion string `yaml:"hctlVersion,omitempty"`
+	// ValuesHash is the ContentHash of the generated values.yaml body,
+	// before a commit annotation is stamped on it.
+	ValuesHash string            `yaml:"valuesHash"`
+	Objects    []InventoryObject `yaml:"objects"`
+}
+
+// InventoryObject is one generated object. Group is empty for the core API
+// group and Namespace for cluster-scoped kinds.
+type InventoryObject struct {
+	Group     string `yaml:"group,omitempty"`
+	Kind      string `yaml:"kind"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Name      string `yaml:"name"`
+	// Provisioner is the Score resource type whose provisioner produced the
+	// object, or one of the Inventory* sources.
+	Provisioner string `yaml:"provisioner"`
+	// Resource is the Score resource the provisioner ran for.
+	Resource string `yaml:"resource,omitempty"`
+}
+
+// Key identifies the object regardless of who produced it.
+func (o InventoryObject) Key() string {
+	return o.Group + "/" + o.Kind + "/" + o.Namespace + "/" + o.Name
+}
+
+// String is "Kind.group namespace/name", or "Kind name" for a core,
+// cluster-scoped object.
+func (o InventoryObject) String() string {
+	kind := o.Kind
+	if o.Group != "" {
+		kind += "." + o.Group
+	}
+	if o.Namespace == "" {
+		return kind + " " + o.Name
+	}
+	return kind + " " + o.Namespace + "/" + o.Name
+}
+
+// InventoryPath returns the repo-relative path of a workload's inventory,
+// in the directory of its values.yaml.
+func InventoryPath(cluster, workload string) string {
+	return path.Join(path.Dir(ValuesPath(cluster, workload)), InventoryFile)
+}
+
+// ParseInventory reads an inventory file, with or without its provenance
+// header.
+func ParseInventory(data []byte) (*Inventory, error) {
+	body, _, _ := ParseGenerated(data)
+	var inv Inventory
+	if err := yaml.Unmarshal(body, &inv); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", InventoryFile, err)
+	}
+	return &inv, nil
+}
+
+// Marshal returns the stamped inventory file.
+func (inv *Inventory) Marshal() ([]byte, error) {
+	body, err := yaml.Marshal(inv)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling %s: %w", InventoryFile, err)
+	}
+	return StampGenerated(body), nil
+}
+
+// Removed returns the objects of inv that next no longer lists, in inv's
+// order. Either may be nil.
+func (inv *Inventory) Removed(next *Inventory) []InventoryObject {
+	if inv == nil {
+		return nil
+	}
+	kept := map[string]bool{}
+	if next != nil {
+		for _, o := range next.Objects {
+			kept[o.Key()] = true
+		}
+	}
+	var removed []InventoryObject
+	for _, o := range inv.Objects {
+		if !kept[o.Key()] {
+			removed = append(removed, o)
+		}
+	}
+	return removed
+}
+
+// objectSources records which provisioner produced each extraObject, by
+// object key, while Translate assembles them.
+type objectSources map[string]InventoryObject
+
+// add records m as produced by provisioner for resource.
+func (s objectSources) add(m map[string]interface{}, provisioner, resource string) {
+	o := inventoryObject(m)
+	o.Provisioner, o.Resource = provisioner, resource
+	s[o.Key()] = o
+}
+
+// buildInventory lists the chart's objects from the enabled values sections
+// and cronJob jobs, and every extraObject, sorted by key. extraObjects that no provisioner
+// recorded in sources came from --set.
+func buildInventory(values map[string]interface{}, sources objectSources, workload, cluster, namespace, version string, valuesBody []byte) *Inventory {
+	inv := &Inventory{
+		Workload:    workload,
+		Cluster:     cluster,
+		Namespace:   namespace,
+		HctlVersion: version,
+		ValuesHash:  ContentHash(valuesBody),
+		Objects:     []InventoryObject{},
+	}
+	name, _ := values["applicationName"].(string)
+	for _, section := range chartSections {
+		s, ok := values[section].(map[string]interface{})
+		if !ok || s["enabled"] == false {
+			continue
+		}
+		c := chartObjects[section]
+		inv.Objects = append(inv.Objects, InventoryObject{
+			Group: c.group, Kind: c.kind, Namespace: namespace, Name: name + c.suffix, Provisioner: InventoryChart,
+		})
+	}
+	// The chart names each CronJob after its job.
+	for job := range cronJobs(values) {
+		inv.Objects = append(inv.Objects, InventoryObject{
+			Group: "batch", Kind: "CronJob", Namespace: namespace, Name: job, Provisioner: InventoryChart,
+		})
+	}
+	objects, _ := values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		o := inventoryObject(m)
+		if src, ok := sources[o.Key()]; ok {
+			o = src
+		} else {
+			o.Provisioner = InventorySet
+		}
+		inv.Objects = append(inv.Objects, o)
+	}
+	sort.SliceStable(inv.Objects, func(i, j int) bool { return inv.Objects[i].Key() < inv.Objects[j].Key() })
+	return inv
+}
+
+// inventoryObject identifies a manifest by its apiVersion group, kind,
+// namespace and name.
+func inventoryObject(m map[string]interface{}) InventoryObject {
+	apiVersion, _ := m["apiVersion"].(string)
+	kind, _ := m["kind"].(string)
+	meta, _ := m["metadata"].(map[string]interface{})
+	namespace, _ := meta["namespace"].(string)
+	name, _ := meta["name"].(string)
+	var group string
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+	}
+	return InventoryObject{Group: group, Kind: kind, Namespace: namespace, Name: name}
+}
@@ -0,0 +1,89 @@
+package translate_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+func translateShop(t *testing.T, spec string) *translate.Result {
+	t.Helper()
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", HctlVersion: "v1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestTranslateInventory(t *testing.T) {
+	result := translateShop(t, fullWorkload)
+
+	path := translate.InventoryPath("media", "shop")
+	if path != "workloads/media/addons/shop/.hctl-manifest.yaml" {
+		t.Errorf("InventoryPath = %s", path)
+	}
+	inv, err := translate.ParseInventory(result.Files[path])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(inv, result.Inventory) {
+		t.Errorf("written inventory = %+v, want %+v", inv, result.Inventory)
+	}
+	if inv.HctlVersion != "v1.2.3" || inv.Workload != "shop" || inv.Namespace != "media" {
+		t.Errorf("inventory header = %+v", inv)
+	}
+	body, _, _ := translate.ParseGenerated(result.Files[translate.ValuesPath("media", "shop")])
+	if inv.ValuesHash != translate.ContentHash(body) {
+		t.Errorf("valuesHash = %s, want the values body's hash", inv.ValuesHash)
+	}
+
+	var got []string
+	for _, o := range inv.Objects {
+		got = append(got, o.String()+" "+o.Provisioner+" "+o.Resource)
+	}
+	want := []string{
+		"PersistentVolumeClaim media/shop-data volume data",
+		"Service media/shop chart ",
+		"ServiceAccount media/shop rbac api",
+		"Deployment.apps media/shop chart ",
+		"Certificate.cert-manager.io media/shop-certificate chart ",
+		"ExternalSecret.external-secrets.io media/shop-cache-credentials redis cache",
+		"ExternalSecret.external-secrets.io media/shop-db-credentials postgres db",
+		"HTTPRoute.gateway.networking.k8s.io media/shop chart ",
+		"HTTPRoute.gateway.networking.k8s.io media/shop-www route www",
+		"Role.rbac.authorization.k8s.io media/shop rbac api",
+		"RoleBinding.rbac.authorization.k8s.io media/shop rbac api",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("objects:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	again := translateShop(t, fullWorkload)
+	if !bytes.Equal(again.Files[path], result.Files[path]) {
+		t.Errorf("inventory differs between runs:\n%s\nwant:\n%s", again.Files[path], result.Files[path])
+	}
+}
+
+func TestInventoryRemoved(t *testing.T) {
+	old := translateShop(t, fullWorkload).Inventory
+	without := strings.Replace(fullWorkload, "  cache:\n    type: redis\n", "", 1)
+	next := translateShop(t, without).Inventory
+
+	removed := old.Removed(next)
+	if len(removed) != 1 || removed[0].Name != "shop-cache-credentials" || removed[0].Provisioner != "redis" {
+		t.Errorf("Removed = %+v, want the cache ExternalSecret", removed)
+	}
+	if added := next.Removed(old); len(added) != 0 {
+		t.Errorf("objects only in the new render = %+v, want none", added)
+	}
+	if all := old.Removed(nil); len(all) != len(old.Objects) {
+		t.Errorf("Removed(nil) = %d objects, want all %d", len(all), len(old.Objects))
+	}
+}
@@ -0,0 +1,180 @@
+package translate
+
+import (
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// OTelAnnotation opts a workload in ("true") or out ("false") of the
+	// platform observability sidecar, overriding the cluster default.
+	OTelAnnotation = "hctl.integratn.tech/otel"
+
+	// DefaultSidecarName is the sidecar container name when the platform
+	// config leaves it empty.
+	DefaultSidecarName = "otel-agent"
+	// sidecarSharedMountPath is where the sidecar mounts the shared volume.
+	sidecarSharedMountPath = "/var/run/otel"
+	// sidecarConfigMountPath is where the sidecar mounts its config.
+	sidecarConfigMountPath = "/etc/otel-agent"
+	sidecarConfigKey       = "config.yaml"
+)
+
+// Observability is the platform-managed observability agent, as declared in
+// platform/observability/sidecar.yaml of the gitops repo.
+type Observability struct {
+	Sidecar ObservabilitySidecar `yaml:"sidecar" json:"sidecar"`
+	// Clusters holds the per-cluster settings, keyed by cluster name.
+	Clusters map[string]ObservabilityCluster `yaml:"clusters,omitempty" json:"clusters,omitempty"`
+}
+
+// ObservabilitySidecar is the container injected into opted-in workloads.
+type ObservabilitySidecar struct {
+	// Name is the container name; empty uses DefaultSidecarName.
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Image string `yaml:"image" json:"image"`
+	// Args are passed to the agent. The config flag is appended when
+	// Config is set.
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+	// Config is the agent's base config, rendered as a ConfigMap next to
+	// the workload and mounted into the sidecar.
+	Config string `yaml:"config,omitempty" json:"config,omitempty"`
+	// Resources are the sidecar's requests and limits.
+	Resources map[string]interface{} `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// ObservabilityCluster is a cluster's observability settings.
+type ObservabilityCluster struct {
+	// Endpoint is the OTLP endpoint of the cluster's collector.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Default injects the sidecar into workloads without the otel
+	// annotation.
+	Default bool `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// sidecar is the observability agent resolved for one workload.
+type sidecar struct {
+	spec     ObservabilitySidecar
+	endpoint string
+	cluster  string
+}
+
+// parseSidecar decides whether the workload gets the observability sidecar:
+// the otel annotation wins, then the cluster default. Returns nil when it
+// does not.
+func parseSidecar(w *score.Workload, obs *Observability, cluster string) (*sidecar, error) {
+	field := map[string]string{"field": "metadata.annotations." + OTelAnnotation}
+	var settings ObservabilityCluster
+	if obs != nil {
+		settings = obs.Clusters[cluster]
+	}
+	enabled := settings.Default
+	switch v := strings.TrimSpace(w.Metadata.Annotations[OTelAnnotation]); v {
+	case "":
+	case "true":
+		enabled = true
+	case "false":
+		enabled = false
+	default:
+		return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: invalid value %q (expected \"true\" or \"false\")", OTelAnnotation, v).
+			WithDetails(field)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	if obs == nil || obs.Sidecar.Image == "" {
+		return nil, hcerrors.NewUserError("annotation %s is set but no observability sidecar is configured", OTelAnnotation).
+			WithRemediation("declare the sidecar image in platform/observability/sidecar.yaml")
+	}
+	if settings.Endpoint == "" {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "observability sidecar: cluster %q has no collector endpoint", cluster).
+			WithRemediation("set clusters." + cluster + ".endpoint in platform/observability/sidecar.yaml").
+			WithDetails(field)
+	}
+	s := &sidecar{spec: obs.Sidecar, endpoint: settings.Endpoint, cluster: cluster}
+	if s.spec.Name == "" {
+		s.spec.Name = DefaultSidecarName
+	}
+	if _, ok := w.Containers[s.spec.Name]; ok {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "container %q clashes with the observability sidecar; rename it or set %s: \"false\"", s.spec.Name, OTelAnnotation).
+			WithDetails(map[string]string{"field": "containers." + s.spec.Name})
+	}
+	for _, name := range []string{s.spec.Name, s.spec.Name + "-config"} {
+		for cname, c := range w.Containers {
+			if _, ok := c.Volumes[name]; ok {
+				return nil, hcerrors.New(hcerrors.ErrValidation, "volume %q clashes with the observability sidecar's volumes; rename it or set %s: \"false\"", name, OTelAnnotation).
+					WithDetails(map[string]string{"field": "containers." + cname + ".volumes." + name})
+			}
+		}
+	}
+	return s, nil
+}
+
+// configMapName names the ConfigMap holding the sidecar config.
+func (s *sidecar) configMapName(workload string) string {
+	return workload + "-" + s.spec.Name
+}
+
+// apply appends the sidecar to the additional containers, after the
+// workload's own, and adds its volumes to the pod. Workload containers are
+// left untouched. The ConfigMap holding the agent config, if any, is
+// returned for extraObjects.
+func (s *sidecar) apply(deployment map[string]interface{}, workload, namespace string) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	sharedVolume := s.spec.Name
+	configVolume := s.spec.Name + "-config"
+
+	args := append([]string(nil), s.spec.Args...)
+	mounts := []map[string]interface{}{
+		{"name": sharedVolume, "mountPath": sidecarSharedMountPath},
+	}
+	volumes, _ := deployment["volumes"].(map[string]interface{})
+	if volumes == nil {
+		volumes = map[string]interface{}{}
+	}
+	volumes[sharedVolume] = map[string]interface{}{"emptyDir": map[string]interface{}{}}
+
+	var configMap map[string]interface{}
+	if s.spec.Config != "" {
+		args = append(args, "--config="+sidecarConfigMountPath+"/"+sidecarConfigKey)
+		mounts = append(mounts, map[string]interface{}{"name": configVolume, "mountPath": sidecarConfigMountPath, "readOnly": true})
+		volumes[configVolume] = map[string]interface{}{
+			"configMap": map[string]interface{}{"name": s.configMapName(workload)},
+		}
+		configMap = map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      s.configMapName(workload),
+				"namespace": namespace,
+			},
+			"data": map[string]interface{}{sidecarConfigKey: s.spec.Config},
+		}
+	}
+	deployment["volumes"] = volumes
+
+	container := map[string]interface{}{
+		"name":  s.spec.Name,
+		"image": s.spec.Image,
+		"env": []map[string]interface{}{
+			{"name": "OTEL_EXPORTER_OTLP_ENDPOINT", "value": s.endpoint},
+			{"name": "OTEL_SERVICE_NAME", "value": workload},
+			{"name": "OTEL_RESOURCE_ATTRIBUTES", "value": "k8s.cluster.name=" + s.cluster + ",k8s.namespace.name=" + namespace},
+		},
+		"volumeMounts": mounts,
+	}
+	if len(args) > 0 {
+		container["args"] = args
+	}
+	if len(s.spec.Resources) > 0 {
+		container["resources"] = s.spec.Resources
+	}
+	containers, _ := deployment["additionalContainers"].([]map[string]interface{})
+	deployment["additionalContainers"] = append(containers, container)
+	return configMap
+}
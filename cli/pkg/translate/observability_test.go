@@ -0,0 +1,197 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func testObservability(defaultOn bool) *Observability {
+	return &Observability{
+		Sidecar: ObservabilitySidecar{
+			Image:  "otel/opentelemetry-collector-contrib:0.110.0",
+			Config: "receivers:\n  otlp: {}\n",
+			Resources: map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": "200m", "memory": "128Mi"},
+			},
+		},
+		Clusters: map[string]ObservabilityCluster{
+			"dev": {Endpoint: "http://otel-collector.observability.svc:4317", Default: defaultOn},
+		},
+	}
+}
+
+func otelWorkload(annotation string) *score.Workload {
+	w := placementWorkload(nil)
+	if annotation != "" {
+		w.Metadata.Annotations[OTelAnnotation] = annotation
+	}
+	w.Containers["log-shipper"] = score.Container{Image: "fluent/fluent-bit:3.1"}
+	return w
+}
+
+// sidecarOf returns the injected sidecar container, or nil.
+func sidecarOf(values map[string]interface{}) map[string]interface{} {
+	containers, _ := values["deployment"].(map[string]interface{})["additionalContainers"].([]map[string]interface{})
+	for _, c := range containers {
+		if c["name"] == DefaultSidecarName {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestObservabilitySidecarInjection(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		defaultOn  bool
+		want       bool
+	}{
+		{"annotation opts in", "true", false, true},
+		{"cluster default", "", true, true},
+		{"explicit false beats the cluster default", "false", true, false},
+		{"off by default", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Translate(otelWorkload(tt.annotation), Options{Observability: testObservability(tt.defaultOn)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sidecarOf(result.Values) != nil; got != tt.want {
+				t.Fatalf("sidecar injected = %v, want %v", got, tt.want)
+			}
+			containers := result.Values["deployment"].(map[string]interface{})["additionalContainers"].([]map[string]interface{})
+			if containers[0]["name"] != "log-shipper" || containers[0]["image"] != "fluent/fluent-bit:3.1" {
+				t.Errorf("workload container changed: %v", containers[0])
+			}
+		})
+	}
+}
+
+func TestObservabilitySidecarSpec(t *testing.T) {
+	result, err := Translate(otelWorkload("true"), Options{Observability: testObservability(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := sidecarOf(result.Values)
+	if c["image"] != "otel/opentelemetry-collector-contrib:0.110.0" {
+		t.Errorf("image = %v", c["image"])
+	}
+	env := map[string]interface{}{}
+	for _, e := range c["env"].([]map[string]interface{}) {
+		env[e["name"].(string)] = e["value"]
+	}
+	if env["OTEL_EXPORTER_OTLP_ENDPOINT"] != "http://otel-collector.observability.svc:4317" || env["OTEL_SERVICE_NAME"] != "myapp" {
+		t.Errorf("env = %v", env)
+	}
+	if !reflect.DeepEqual(c["resources"], testObservability(false).Sidecar.Resources) {
+		t.Errorf("resources = %v", c["resources"])
+	}
+	if !reflect.DeepEqual(c["args"], []string{"--config=/etc/otel-agent/config.yaml"}) {
+		t.Errorf("args = %v", c["args"])
+	}
+
+	volumes := result.Values["deployment"].(map[string]interface{})["volumes"].(map[string]interface{})
+	if _, ok := volumes[DefaultSidecarName]; !ok {
+		t.Errorf("shared volume missing: %v", volumes)
+	}
+	cm := volumes[DefaultSidecarName+"-config"].(map[string]interface{})["configMap"].(map[string]interface{})
+	if cm["name"] != "myapp-otel-agent" {
+		t.Errorf("config volume = %v", cm)
+	}
+	var found bool
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		if m["kind"] == "ConfigMap" && m["metadata"].(map[string]interface{})["name"] == "myapp-otel-agent" {
+			found = m["data"].(map[string]interface{})["config.yaml"] == "receivers:\n  otlp: {}\n"
+		}
+	}
+	if !found {
+		t.Error("sidecar ConfigMap missing from extraObjects")
+	}
+}
+
+func TestObservabilityCentralConfigOverrides(t *testing.T) {
+	obs := testObservability(false)
+	obs.Sidecar.Name = "telemetry"
+	obs.Sidecar.Config = ""
+	obs.Sidecar.Args = []string{"--feature-gates=-pkg.translator"}
+	obs.Clusters["dev"] = ObservabilityCluster{Endpoint: "http://collector.dev:4317"}
+
+	result, err := Translate(otelWorkload("true"), Options{Observability: obs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers := result.Values["deployment"].(map[string]interface{})["additionalContainers"].([]map[string]interface{})
+	c := containers[len(containers)-1]
+	if c["name"] != "telemetry" || !reflect.DeepEqual(c["args"], obs.Sidecar.Args) {
+		t.Errorf("sidecar = %v", c)
+	}
+	if env := c["env"].([]map[string]interface{}); env[0]["value"] != "http://collector.dev:4317" {
+		t.Errorf("endpoint = %v", env[0])
+	}
+	if _, ok := result.Values["extraObjects"]; ok {
+		t.Error("ConfigMap rendered without a sidecar config")
+	}
+}
+
+// TestObservabilityVersionBump checks that a new sidecar image changes only
+// the sidecar's image line in values.yaml.
+func TestObservabilityVersionBump(t *testing.T) {
+	render := func(image string) []string {
+		obs := testObservability(true)
+		obs.Sidecar.Image = image
+		result, err := Translate(otelWorkload(""), Options{Observability: obs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(string(result.Files[ValuesPath("dev", "myapp")]), "\n")
+	}
+	before := render("otel/opentelemetry-collector-contrib:0.110.0")
+	after := render("otel/opentelemetry-collector-contrib:0.111.0")
+	if len(before) != len(after) {
+		t.Fatalf("values.yaml went from %d to %d lines", len(before), len(after))
+	}
+	var changed []string
+	for i := range before {
+		if before[i] != after[i] && !strings.HasPrefix(before[i], "# ") {
+			changed = append(changed, after[i])
+		}
+	}
+	if len(changed) != 1 || !strings.Contains(changed[0], "image: otel/opentelemetry-collector-contrib:0.111.0") {
+		t.Errorf("changed lines = %q, want only the sidecar image", changed)
+	}
+}
+
+func TestObservabilityErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		w    *score.Workload
+		obs  *Observability
+		want hcerrors.Category
+	}{
+		{"invalid annotation", otelWorkload("yes"), testObservability(false), hcerrors.ErrValidation},
+		{"no sidecar configured", otelWorkload("true"), nil, hcerrors.ErrUsage},
+		{"no endpoint for the cluster", otelWorkload("true"), &Observability{Sidecar: ObservabilitySidecar{Image: "otel:1"}}, hcerrors.ErrValidation},
+		{"container name clash", func() *score.Workload {
+			w := otelWorkload("true")
+			w.Containers[DefaultSidecarName] = score.Container{Image: "busybox"}
+			return w
+		}(), testObservability(false), hcerrors.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Translate(tt.w, Options{Observability: tt.obs})
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || he.Category != tt.want {
+				t.Errorf("err = %v, want category %v", err, tt.want)
+			}
+		})
+	}
+}
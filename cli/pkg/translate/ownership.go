@@ -0,0 +1,161 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ownership labels and annotations are stamped on every object hctl
+// generates, so any ExternalSecret or HTTPRoute in a cluster can be traced
+// back to the workload, app repo and gitops commit that produced it.
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "hctl"
+	WorkloadLabel  = "hctl.integratn.tech/workload"
+	ClusterLabel   = "hctl.integratn.tech/cluster"
+
+	// SourceRepoAnnotation is the origin URL of the repo holding score.yaml.
+	SourceRepoAnnotation = "hctl.integratn.tech/source-repo"
+	// CommitAnnotation is the gitops commit that last changed the workload,
+	// stamped after the deploy commit is made (see StampCommit).
+	CommitAnnotation = "hctl.integratn.tech/commit"
+)
+
+// OwnershipLabels returns the labels identifying a workload's objects.
+func OwnershipLabels(workload, cluster string) map[string]string {
+	return map[string]string{
+		ManagedByLabel: ManagedByValue,
+		WorkloadLabel:  workload,
+		ClusterLabel:   cluster,
+	}
+}
+
+// WorkloadSelector returns a label selector matching the objects hctl
+// generated for workload on cluster.
+func WorkloadSelector(workload, cluster string) string {
+	labels := OwnershipLabels(workload, cluster)
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%s", k, labels[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// StampCommit sets the commit annotation on every object in values,
+// replacing any earlier commit.
+func StampCommit(values map[string]interface{}, sha string) {
+	stampObjects(values, nil, map[string]string{CommitAnnotation: sha}, true)
+}
+
+// RecordedCommit returns the commit annotation stamped on values, or "".
+func RecordedCommit(values map[string]interface{}) string {
+	for _, section := range chartSections {
+		if s, ok := values[section].(map[string]interface{}); ok {
+			if sha := stringValue(s["annotations"], CommitAnnotation); sha != "" {
+				return sha
+			}
+		}
+	}
+	for _, job := range cronJobs(values) {
+		if sha := stringValue(job["annotations"], CommitAnnotation); sha != "" {
+			return sha
+		}
+	}
+	objects, _ := values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		if m, ok := obj.(map[string]interface{}); ok {
+			if meta, ok := m["metadata"].(map[string]interface{}); ok {
+				if sha := stringValue(meta["annotations"], CommitAnnotation); sha != "" {
+					return sha
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// chartSections are the values sections that render an object of their
+// own; each takes additionalLabels and annotations.
+var chartSections = []string{"deployment", "statefulset", "service", "httpRoute", "certificate", "autoscaling"}
+
+// podSections are the chart sections that render a pod template.
+var podSections = map[string]bool{"deployment": true, "statefulset": true}
+
+// stampObjects merges labels and annotations into every chart section that
+// is enabled, every cronJob job and the metadata of each extraObject. Keys
+// an object already sets are kept unless overwrite is true.
+func stampObjects(values map[string]interface{}, labels, annotations map[string]string, overwrite bool) {
+	for _, name := range chartSections {
+		section, ok := values[name].(map[string]interface{})
+		if !ok || section["enabled"] == false {
+			continue
+		}
+		mergeStrings(section, "additionalLabels", labels, overwrite)
+		if podSections[name] {
+			mergeStrings(section, "podLabels", labels, overwrite)
+		}
+		mergeStrings(section, "annotations", annotations, overwrite)
+	}
+	for _, job := range cronJobs(values) {
+		mergeStrings(job, "additionalLabels", labels, overwrite)
+		mergeStrings(job, "podLabels", labels, overwrite)
+		mergeStrings(job, "annotations", annotations, overwrite)
+	}
+
+	objects, _ := values["extraObjects"].([]interface{})
+	for _, obj := range objects {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		meta, ok := m["metadata"].(map[string]interface{})
+		if !ok {
+			meta = map[string]interface{}{}
+			m["metadata"] = meta
+		}
+		mergeStrings(meta, "labels", labels, overwrite)
+		mergeStrings(meta, "annotations", annotations, overwrite)
+	}
+}
+
+// mergeStrings merges add into the string map at obj[key], creating it when
+// add is not empty.
+func mergeStrings(obj map[string]interface{}, key string, add map[string]string, overwrite bool) {
+	if len(add) == 0 {
+		return
+	}
+	merged := map[string]interface{}{}
+	switch existing := obj[key].(type) {
+	case map[string]interface{}:
+		merged = existing
+	case map[string]string:
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range add {
+		if _, ok := merged[k]; ok && !overwrite {
+			continue
+		}
+		merged[k] = v
+	}
+	obj[key] = merged
+}
+
+// stringValue returns m[key] when m is a string map holding a string there.
+func stringValue(m interface{}, key string) string {
+	switch m := m.(type) {
+	case map[string]interface{}:
+		s, _ := m[key].(string)
+		return s
+	case map[string]string:
+		return m[key]
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+package translate_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+	"gopkg.in/yaml.v3"
+)
+
+const fullWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  web:
+    image: ghcr.io/example/shop:1
+    volumes:
+      /data:
+        source: ${resources.data}
+service:
+  ports:
+    http:
+      port: 8080
+resources:
+  db:
+    type: postgres
+  cache:
+    type: redis
+  data:
+    type: volume
+  www:
+    type: route
+    params:
+      host: shop.example.org
+      port: 8080
+  api:
+    type: rbac
+    params:
+      rules:
+        - apiGroups: [""]
+          resources: [configmaps]
+          verbs: [get]
+`
+
+const sourceRepo = "https://github.com/example/shop.git"
+
+// labeledProvisioner emits a manifest that already sets some ownership keys.
+type labeledProvisioner struct{}
+
+func (labeledProvisioner) Type() string { return "labeled" }
+
+func (labeledProvisioner) Provision(name string, _ score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	return &provisioners.ProvisionResult{
+		Manifests: []map[string]interface{}{{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": workload + "-" + name,
+				"labels": map[string]string{
+					translate.ManagedByLabel: "kustomize",
+					"team":                   "shop",
+				},
+				"annotations": map[string]interface{}{
+					translate.SourceRepoAnnotation: "https://example.org/other.git",
+				},
+			},
+		}},
+	}, nil
+}
+
+// wantOwnership reports keys of labels or annotations missing from got or
+// set to another value.
+func wantOwnership(t *testing.T, what string, got interface{}, want map[string]string) {
+	t.Helper()
+	m, _ := got.(map[string]interface{})
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s[%s] = %v, want %q", what, k, m[k], v)
+		}
+	}
+}
+
+func TestTranslateStampsEveryObject(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(fullWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", SourceRepo: sourceRepo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the written file, so the labels survive marshaling.
+	body, _, _ := translate.ParseGenerated(result.Files[translate.ValuesPath("media", "shop")])
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := translate.OwnershipLabels("shop", "media")
+	annotations := map[string]string{translate.SourceRepoAnnotation: sourceRepo}
+	for _, name := range []string{"deployment", "service", "httpRoute", "certificate"} {
+		section, ok := values[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("values have no %s section", name)
+			continue
+		}
+		wantOwnership(t, name+".additionalLabels", section["additionalLabels"], labels)
+		wantOwnership(t, name+".annotations", section["annotations"], annotations)
+	}
+	wantOwnership(t, "deployment.podLabels", values["deployment"].(map[string]interface{})["podLabels"], labels)
+	if _, ok := values["persistence"].(map[string]interface{})["additionalLabels"]; ok {
+		t.Error("disabled persistence section was labeled")
+	}
+
+	kinds := map[string]bool{}
+	for _, obj := range values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		kind := m["kind"].(string)
+		kinds[kind] = true
+		meta := m["metadata"].(map[string]interface{})
+		wantOwnership(t, kind+" labels", meta["labels"], labels)
+		wantOwnership(t, kind+" annotations", meta["annotations"], annotations)
+	}
+	for _, kind := range []string{"ExternalSecret", "PersistentVolumeClaim", "HTTPRoute", "ServiceAccount", "Role", "RoleBinding"} {
+		if !kinds[kind] {
+			t.Errorf("full render has no %s; kinds = %v", kind, kinds)
+		}
+	}
+
+	if !reflect.DeepEqual(result.AddonsEntry["labelsApp"], labels) {
+		t.Errorf("addons entry labelsApp = %v, want %v", result.AddonsEntry["labelsApp"], labels)
+	}
+}
+
+func TestTranslateMergesExistingLabels(t *testing.T) {
+	w := &score.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata:   score.WorkloadMetadata{Name: "shop"},
+		Containers: map[string]score.Container{"web": {Image: "web:1"}},
+		Resources:  map[string]score.Resource{"config": {Type: "labeled"}},
+	}
+	registry := provisioners.NewRegistry()
+	registry.Register(labeledProvisioner{})
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", SourceRepo: sourceRepo, Registry: registry})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := result.Values["extraObjects"].([]interface{})[0].(map[string]interface{})["metadata"].(map[string]interface{})
+	wantLabels := map[string]interface{}{
+		translate.ManagedByLabel: "kustomize",
+		"team":                   "shop",
+		translate.WorkloadLabel:  "shop",
+		translate.ClusterLabel:   "media",
+	}
+	if !reflect.DeepEqual(meta["labels"], wantLabels) {
+		t.Errorf("labels = %v, want %v", meta["labels"], wantLabels)
+	}
+	wantAnnotations := map[string]interface{}{translate.SourceRepoAnnotation: "https://example.org/other.git"}
+	if !reflect.DeepEqual(meta["annotations"], wantAnnotations) {
+		t.Errorf("annotations = %v, want %v", meta["annotations"], wantAnnotations)
+	}
+}
+
+func TestStampCommit(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(fullWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := translate.RecordedCommit(result.Values); got != "" {
+		t.Fatalf("RecordedCommit() before stamping = %q, want empty", got)
+	}
+
+	translate.StampCommit(result.Values, "aaaa")
+	translate.StampCommit(result.Values, "bbbb")
+	if got := translate.RecordedCommit(result.Values); got != "bbbb" {
+		t.Errorf("RecordedCommit() = %q, want bbbb", got)
+	}
+	commit := map[string]string{translate.CommitAnnotation: "bbbb"}
+	wantOwnership(t, "deployment.annotations", result.Values["deployment"].(map[string]interface{})["annotations"], commit)
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		wantOwnership(t, m["kind"].(string)+" annotations", m["metadata"].(map[string]interface{})["annotations"], commit)
+	}
+}
+
+func TestWorkloadSelector(t *testing.T) {
+	want := "app.kubernetes.io/managed-by=hctl,hctl.integratn.tech/cluster=media,hctl.integratn.tech/workload=shop"
+	if got := translate.WorkloadSelector("shop", "media"); got != want {
+		t.Errorf("WorkloadSelector() = %q, want %q", got, want)
+	}
+}
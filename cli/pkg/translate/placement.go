@@ -0,0 +1,126 @@
+package translate
+
+import (
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// ArchAnnotation pins a workload to one or more CPU architectures
+	// (comma-separated, e.g. "amd64" or "amd64,arm64").
+	ArchAnnotation = "hctl.integratn.tech/arch"
+	// NodePoolAnnotation pins a workload to nodes carrying the configured
+	// pool label with this value.
+	NodePoolAnnotation = "hctl.integratn.tech/node-pool"
+
+	archLabel = "kubernetes.io/arch"
+)
+
+// supportedArchs are the architectures accepted in the arch annotation.
+var supportedArchs = map[string]bool{
+	"amd64": true,
+	"arm64": true,
+}
+
+// placement is the scheduling constraint derived from workload annotations.
+type placement struct {
+	archs     []string
+	pool      string
+	poolLabel string
+}
+
+// parsePlacement reads the arch and node-pool annotations. Returns nil when
+// neither is set so no scheduling fields are emitted.
+func parsePlacement(w *score.Workload, poolLabel string) (*placement, error) {
+	archValue := strings.TrimSpace(w.Metadata.Annotations[ArchAnnotation])
+	pool := strings.TrimSpace(w.Metadata.Annotations[NodePoolAnnotation])
+	if archValue == "" && pool == "" {
+		return nil, nil
+	}
+
+	p := &placement{pool: pool, poolLabel: poolLabel}
+	seen := map[string]bool{}
+	for _, a := range strings.Split(archValue, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[a] {
+			continue
+		}
+		if !supportedArchs[a] {
+			return nil, hcerrors.New(hcerrors.ErrValidation, "annotation %s: unsupported architecture %q (expected amd64 or arm64)", ArchAnnotation, a).
+				WithDetails(map[string]string{"field": "metadata.annotations." + ArchAnnotation})
+		}
+		seen[a] = true
+		p.archs = append(p.archs, a)
+	}
+	sort.Strings(p.archs)
+
+	if pool != "" && poolLabel == "" {
+		return nil, hcerrors.NewUserError("annotation %s is set but platform.nodePoolLabel is empty", NodePoolAnnotation)
+	}
+	return p, nil
+}
+
+// apply writes nodeSelector, affinity, and tolerations into the Stakater
+// deployment values. A single arch uses nodeSelector; several archs use a
+// required nodeAffinity with the In operator.
+func (p *placement) apply(deployment map[string]interface{}) {
+	if p == nil {
+		return
+	}
+
+	nodeSelector := map[string]interface{}{}
+	var tolerations []map[string]interface{}
+
+	switch len(p.archs) {
+	case 0:
+	case 1:
+		nodeSelector[archLabel] = p.archs[0]
+	default:
+		deployment["affinity"] = map[string]interface{}{
+			"nodeAffinity": map[string]interface{}{
+				"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+					"nodeSelectorTerms": []map[string]interface{}{
+						{
+							"matchExpressions": []map[string]interface{}{
+								{
+									"key":      archLabel,
+									"operator": "In",
+									"values":   p.archs,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	for _, a := range p.archs {
+		tolerations = append(tolerations, noScheduleToleration(archLabel, a))
+	}
+
+	if p.pool != "" {
+		nodeSelector[p.poolLabel] = p.pool
+		tolerations = append(tolerations, noScheduleToleration(p.poolLabel, p.pool))
+	}
+
+	if len(nodeSelector) > 0 {
+		deployment["nodeSelector"] = nodeSelector
+	}
+	if len(tolerations) > 0 {
+		deployment["tolerations"] = tolerations
+	}
+}
+
+// noScheduleToleration tolerates a NoSchedule taint matching key=value, so
+// dedicated (tainted) pools accept workloads pinned to them.
+func noScheduleToleration(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"key":      key,
+		"operator": "Equal",
+		"value":    value,
+		"effect":   "NoSchedule",
+	}
+}
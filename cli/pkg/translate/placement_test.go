@@ -0,0 +1,97 @@
+package translate
+
+import (
+	"reflect"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func placementWorkload(annotations map[string]string) *score.Workload {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations["hctl.integratn.tech/cluster"] = "dev"
+	return &score.Workload{
+		APIVersion: "score.dev/v1b1",
+		Metadata:   score.WorkloadMetadata{Name: "myapp", Annotations: annotations},
+		Containers: map[string]score.Container{"app": {Image: "nginx:1.27"}},
+	}
+}
+
+func translateDeployment(t *testing.T, w *score.Workload) map[string]interface{} {
+	t.Helper()
+	result, err := Translate(w, Options{NodePoolLabel: "platform.integratn.tech/node-pool"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	return result.Values["deployment"].(map[string]interface{})
+}
+
+func TestPlacementSingleArch(t *testing.T) {
+	d := translateDeployment(t, placementWorkload(map[string]string{ArchAnnotation: "amd64"}))
+
+	want := map[string]interface{}{"kubernetes.io/arch": "amd64"}
+	if !reflect.DeepEqual(d["nodeSelector"], want) {
+		t.Errorf("nodeSelector = %v, want %v", d["nodeSelector"], want)
+	}
+	if _, ok := d["affinity"]; ok {
+		t.Error("single arch should not emit affinity")
+	}
+	tols := d["tolerations"].([]map[string]interface{})
+	if len(tols) != 1 || tols[0]["value"] != "amd64" {
+		t.Errorf("tolerations = %v", tols)
+	}
+}
+
+func TestPlacementMultiArchAffinity(t *testing.T) {
+	d := translateDeployment(t, placementWorkload(map[string]string{ArchAnnotation: "arm64, amd64"}))
+
+	if _, ok := d["nodeSelector"]; ok {
+		t.Error("multi arch should not emit nodeSelector")
+	}
+	affinity, ok := d["affinity"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected affinity")
+	}
+	required := affinity["nodeAffinity"].(map[string]interface{})["requiredDuringSchedulingIgnoredDuringExecution"].(map[string]interface{})
+	terms := required["nodeSelectorTerms"].([]map[string]interface{})
+	expr := terms[0]["matchExpressions"].([]map[string]interface{})[0]
+	if expr["key"] != "kubernetes.io/arch" || expr["operator"] != "In" {
+		t.Errorf("match expression = %v", expr)
+	}
+	if !reflect.DeepEqual(expr["values"], []string{"amd64", "arm64"}) {
+		t.Errorf("values = %v, want [amd64 arm64]", expr["values"])
+	}
+}
+
+func TestPlacementNodePool(t *testing.T) {
+	d := translateDeployment(t, placementWorkload(map[string]string{NodePoolAnnotation: "gpu"}))
+
+	want := map[string]interface{}{"platform.integratn.tech/node-pool": "gpu"}
+	if !reflect.DeepEqual(d["nodeSelector"], want) {
+		t.Errorf("nodeSelector = %v, want %v", d["nodeSelector"], want)
+	}
+	tols := d["tolerations"].([]map[string]interface{})
+	if len(tols) != 1 || tols[0]["key"] != "platform.integratn.tech/node-pool" || tols[0]["effect"] != "NoSchedule" {
+		t.Errorf("tolerations = %v", tols)
+	}
+}
+
+func TestPlacementDefaultNoSelector(t *testing.T) {
+	d := translateDeployment(t, placementWorkload(nil))
+
+	for _, key := range []string{"nodeSelector", "affinity", "tolerations"} {
+		if _, ok := d[key]; ok {
+			t.Errorf("%s should not be emitted without placement annotations", key)
+		}
+	}
+}
+
+func TestPlacementUnsupportedArch(t *testing.T) {
+	_, err := Translate(placementWorkload(map[string]string{ArchAnnotation: "riscv64"}), Options{})
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+}
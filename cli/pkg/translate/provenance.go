@@ -0,0 +1,49 @@
+package translate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Generated files carry a provenance header recording a hash of the content
+// hctl wrote. Comparing the on-disk body against that hash tells manual edits
+// apart from changes that come from score.yaml.
+const (
+	provenanceComment = "# Generated by hctl from score.yaml. Manual edits are detected and\n# will be overwritten only with 'hctl deploy run --overwrite-manual-changes'.\n"
+	provenanceHashKey = "# hctl-generated-sha256: "
+)
+
+// StampGenerated prefixes body with the provenance header.
+func StampGenerated(body []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(provenanceComment)
+	buf.WriteString(provenanceHashKey + ContentHash(body) + "\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// ParseGenerated splits a stamped file into its body and recorded hash.
+// ok is false when the file has no provenance header.
+func ParseGenerated(data []byte) (body []byte, hash string, ok bool) {
+	rest := data
+	for len(rest) > 0 && rest[0] == '#' {
+		nl := bytes.IndexByte(rest, '\n')
+		if nl < 0 {
+			break
+		}
+		line := string(rest[:nl])
+		rest = rest[nl+1:]
+		if strings.HasPrefix(line, provenanceHashKey) {
+			return rest, strings.TrimSpace(strings.TrimPrefix(line, provenanceHashKey)), true
+		}
+	}
+	return data, "", false
+}
+
+// ContentHash is the hash recorded in the provenance header for body.
+func ContentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+package translate
+
+import "testing"
+
+func TestStampParseRoundTrip(t *testing.T) {
+	body := []byte("image: nginx\nreplicas: 1\n")
+	got, hash, ok := ParseGenerated(StampGenerated(body))
+	if !ok {
+		t.Fatal("stamped file not recognised")
+	}
+	if string(got) != string(body) {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+	if hash != ContentHash(body) {
+		t.Errorf("hash = %q, want %q", hash, ContentHash(body))
+	}
+
+	if _, _, ok := ParseGenerated([]byte("# a comment\nimage: nginx\n")); ok {
+		t.Error("unstamped file reported as stamped")
+	}
+}
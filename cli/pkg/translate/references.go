@@ -0,0 +1,359 @@
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+)
+
+// Diagnostic codes.
+const (
+	// DiagUnknownResourceType is a resource whose type has no provisioner.
+	DiagUnknownResourceType = "unknown-resource-type"
+	// DiagUnresolvedReference is a ${resources.<name>.<key>} reference to
+	// a resource or output that does not exist.
+	DiagUnresolvedReference = "unresolved-reference"
+	// DiagExternalReference is an unresolved reference the workload lists
+	// in ExternalReferencesAnnotation, passed through literally.
+	DiagExternalReference = "external-reference"
+	// DiagSecretInString is a reference to a secret or ConfigMap output
+	// inside a longer string, which an env var cannot take from a
+	// secretKeyRef or configMapKeyRef.
+	DiagSecretInString = "secret-in-string"
+	// DiagKeyRefInFile is a reference to a secret or ConfigMap output in a
+	// container file, whose content hctl writes into a ConfigMap.
+	DiagKeyRefInFile = "key-ref-in-file"
+	// DiagUnresolvedPlaceholder is a ${resources...} placeholder left in the
+	// rendered values outside the container variables, where hctl does not
+	// resolve references.
+	DiagUnresolvedPlaceholder = "unresolved-placeholder"
+	// DiagRouteConflict is a route sending a path of its host that another
+	// route on the same host already sends.
+	DiagRouteConflict = "route-conflict"
+	// DiagExtraRoute was a route resource beyond the one the chart
+	// rendered. Every route is now rendered, so it is no longer reported.
+	//
+	// Deprecated: check for DiagRouteConflict instead.
+	DiagExtraRoute = "extra-route"
+	// DiagRouteHost is a route host outside the platform domain.
+	DiagRouteHost = "route-host-outside-domain"
+	// DiagRoutePort is a route sending traffic to a port the workload's
+	// service does not expose.
+	DiagRoutePort = "route-port"
+	// DiagRouteWithoutService is a route on a workload without service ports.
+	DiagRouteWithoutService = "route-without-service"
+	// DiagWildcardRBAC is cluster-wide wildcard access granted through
+	// allowWildcards.
+	DiagWildcardRBAC = "wildcard-rbac"
+	// DiagResourceDefaults notes a container given the resource policy
+	// defaults.
+	DiagResourceDefaults = "resource-defaults"
+	// DiagSidecarUnsupported is an x-hctl.role: sidecar container rendered
+	// as a plain container on a Kubernetes without native sidecars.
+	DiagSidecarUnsupported = "sidecar-unsupported"
+	// DiagUnusedAlertThreshold is an HTTP alert threshold override on a
+	// workload whose HTTP alerts are not generated.
+	DiagUnusedAlertThreshold = "unused-alert-threshold"
+	// DiagCrossEnvironmentSecret is a 1Password item qualified with an
+	// environment other than the target cluster's. It is an error unless
+	// the resource sets params.allowCrossEnvironment: true.
+	DiagCrossEnvironmentSecret = "cross-environment-secret"
+)
+
+// ExternalReferencesAnnotation lists, comma-separated, the references hctl
+// should pass through literally instead of failing on, for values another
+// system resolves: a resource name covers all of its keys, name.key one
+// key. They are reported as warnings.
+//
+//	metadata:
+//	  annotations:
+//	    hctl.integratn.tech/external-references: vault, legacy.url
+const ExternalReferencesAnnotation = "hctl.integratn.tech/external-references"
+
+// wholeRefRegex matches a value that is exactly one Score resource reference.
+var wholeRefRegex = regexp.MustCompile(`^\$\{resources\.([^.]+)\.([^}]+)\}$`)
+
+// externalReferences returns the references the workload's
+// ExternalReferencesAnnotation lists.
+func externalReferences(w *Workload) map[string]bool {
+	refs := map[string]bool{}
+	for _, ref := range strings.Split(w.Metadata.Annotations[ExternalReferencesAnnotation], ",") {
+		if ref = strings.TrimPrefix(strings.TrimSpace(ref), "resources."); ref != "" {
+			refs[ref] = true
+		}
+	}
+	return refs
+}
+
+// IsExternalReference reports whether the workload's
+// ExternalReferencesAnnotation lists the reference to key of resource name.
+func IsExternalReference(w *Workload, name, key string) bool {
+	return isExternal(externalReferences(w), name, key)
+}
+
+// ResourceReferences returns the resource name and output key of each
+// ${resources.<name>.<key>} reference in s, in order.
+func ResourceReferences(s string) [][2]string {
+	var refs [][2]string
+	for _, m := range scoreVarRegex.FindAllStringSubmatch(s, -1) {
+		refs = append(refs, [2]string{m[1], m[2]})
+	}
+	return refs
+}
+
+// isExternal reports whether the reference to key of resource name is
+// listed in external.
+func isExternal(external map[string]bool, name, key string) bool {
+	return external[name] || external[name+"."+key]
+}
+
+// unknownTypes reports the resources whose type no provisioner in registry
+// handles, with the registered types and the closest one.
+func unknownTypes(w *Workload, names []string, registry *provisioners.Registry) []Diagnostic {
+	var diags []Diagnostic
+	for _, name := range names {
+		typ := w.Resources[name].Type
+		if _, err := registry.Get(typ); err == nil {
+			continue
+		}
+		types := registry.Types()
+		msg := fmt.Sprintf("unknown resource type %q", typ)
+		if near := nearest(typ, types); near != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", near)
+		}
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Code:     DiagUnknownResourceType,
+			Field:    "resources." + name + ".type",
+			Message:  msg + "; registered types: " + strings.Join(types, ", "),
+		})
+	}
+	return diags
+}
+
+// nearest returns the candidate closest to s by edit distance, when it is
+// close enough to be a likely typo, and "" otherwise.
+func nearest(s string, candidates []string) string {
+	best, bestDist := "", len(s)/2+1
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(s), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// referenceDiagnostics checks the references in the variable value val at
+// field: each must name a resource output, be listed as external, and,
+// when the output is a secret, be the whole value.
+func referenceDiagnostics(field, val string, allOutputs map[string]map[string]string, external map[string]bool) []Diagnostic {
+	var diags []Diagnostic
+	for _, m := range scoreVarRegex.FindAllStringSubmatch(val, -1) {
+		ref, name, key := m[0], m[1], m[2]
+		outputs, declared := allOutputs[name]
+		if output, ok := outputs[key]; ok {
+			if kind := keyRefKind(output); ref != val && kind != "" {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     DiagSecretInString,
+					Field:    field,
+					Message:  fmt.Sprintf("reference %s is a %s, which can only be the whole value of a variable; use a separate variable for it", ref, kind),
+				})
+			}
+			continue
+		}
+		if isExternal(external, name, key) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagExternalReference,
+				Field:    field,
+				Message:  fmt.Sprintf("reference %s is not resolved by hctl; it is passed through literally as %s allows", ref, ExternalReferencesAnnotation),
+			})
+			continue
+		}
+		var msg string
+		if declared {
+			msg = fmt.Sprintf("reference %s: resource %q has no output %q (outputs: %s)", ref, name, key, strings.Join(sortedKeys(outputs), ", "))
+		} else if len(allOutputs) > 0 {
+			msg = fmt.Sprintf("reference %s: no resource %q is declared (resources: %s)", ref, name, strings.Join(sortedKeys(allOutputs), ", "))
+		} else {
+			msg = fmt.Sprintf("reference %s: no resource %q is declared; the workload declares no resources", ref, name)
+		}
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Code:     DiagUnresolvedReference,
+			Field:    field,
+			Message:  msg + "; list it in " + ExternalReferencesAnnotation + " if something else resolves it",
+		})
+	}
+	return diags
+}
+
+// placeholderDiagnostics reports the references left in the rendered values
+// that are not listed as external, such as ones in a container's command,
+// so no placeholder reaches a cluster unnoticed.
+func placeholderDiagnostics(values map[string]interface{}, external map[string]bool) []Diagnostic {
+	var diags []Diagnostic
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for _, k := range sortedKeys(v) {
+				walk(joinField(path, k), v[k])
+			}
+		case []interface{}:
+			for i, e := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), e)
+			}
+		case []map[string]interface{}:
+			for i, e := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), e)
+			}
+		case []string:
+			for i, e := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), e)
+			}
+		case string:
+			for _, m := range scoreVarRegex.FindAllStringSubmatch(v, -1) {
+				if isExternal(external, m[1], m[2]) {
+					continue
+				}
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     DiagUnresolvedPlaceholder,
+					Field:    "values." + path,
+					Message:  fmt.Sprintf("%s would be rendered literally; hctl resolves references only in container variables and files", m[0]),
+				})
+			}
+		}
+	}
+	walk("", values)
+	return diags
+}
+
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolveVariableValue translates a Score variable to Stakater env format:
+//   - ${resources.db.host} → secretKeyRef when the output is $(secret:key),
+//     configMapKeyRef when it is $(configmap::key), else its value
+//   - $(secret-name:key) → secretKeyRef, $(configmap-name::key) →
+//     configMapKeyRef
+//   - other values → { value: "..." }, with the references in them that
+//     resolve to plain outputs substituted
+//
+// References that do not resolve are left in place; referenceDiagnostics
+// reports them, failing the translation unless they are external.
+func resolveVariableValue(val string, allOutputs map[string]map[string]string) interface{} {
+	if m := wholeRefRegex.FindStringSubmatch(val); m != nil {
+		if output, ok := allOutputs[m[1]][m[2]]; ok {
+			if ref := keyRef(output); ref != nil {
+				return ref
+			}
+			return map[string]interface{}{"value": output}
+		}
+		return map[string]interface{}{"value": val}
+	}
+
+	if ref := keyRef(val); ref != nil {
+		return ref
+	}
+
+	return map[string]interface{}{"value": interpolate(val, allOutputs)}
+}
+
+// interpolate substitutes the references in s that resolve to plain
+// outputs, leaving secret and ConfigMap outputs, and references that do not
+// resolve, in place.
+func interpolate(s string, allOutputs map[string]map[string]string) string {
+	return scoreVarRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		m := scoreVarRegex.FindStringSubmatch(ref)
+		if output, ok := allOutputs[m[1]][m[2]]; ok && keyRefKind(output) == "" {
+			return output
+		}
+		return ref
+	})
+}
+
+// keyRef returns the env var source of a $(configmap::key) or
+// $(secret:key) output, and nil for any other value.
+func keyRef(output string) map[string]interface{} {
+	if ref := configMapRefRegex.FindStringSubmatch(output); len(ref) == 3 {
+		return configMapKeyRef(ref[1], ref[2])
+	}
+	if ref := secretRefRegex.FindStringSubmatch(output); len(ref) == 3 {
+		return secretKeyRef(ref[1], ref[2])
+	}
+	return nil
+}
+
+// keyRefKind describes what a $(configmap::key) or $(secret:key) output
+// refers to, and returns "" for any other value.
+func keyRefKind(output string) string {
+	switch {
+	case configMapRefRegex.MatchString(output):
+		return "ConfigMap key"
+	case secretRefRegex.MatchString(output):
+		return "secret"
+	}
+	return ""
+}
+
+func configMapKeyRef(name, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"valueFrom": map[string]interface{}{
+			"configMapKeyRef": map[string]interface{}{
+				"name": name,
+				"key":  key,
+			},
+		},
+	}
+}
+
+func secretKeyRef(name, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"valueFrom": map[string]interface{}{
+			"secretKeyRef": map[string]interface{}{
+				"name": name,
+				"key":  key,
+			},
+		},
+	}
+}
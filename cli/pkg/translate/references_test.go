@@ -0,0 +1,162 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+)
+
+func TestTranslateDiagnosticErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		code  string
+		field string
+		msg   string
+	}{
+		{
+			name:  "unknown resource type",
+			doc:   "resources:\n  db:\n    type: postgress\n",
+			code:  DiagUnknownResourceType,
+			field: "resources.db.type",
+			msg:   `unknown resource type "postgress" (did you mean "postgres"?); registered types: dns, mysql, postgres, rbac, redis, route, s3, volume`,
+		},
+		{
+			name:  "undeclared resource",
+			doc:   "resources:\n  db:\n    type: postgres\n",
+			code:  DiagUnresolvedReference,
+			field: "containers.main.variables.CACHE",
+			msg:   `no resource "cache" is declared (resources: db)`,
+		},
+		{
+			name:  "unknown output",
+			doc:   "resources:\n  cache:\n    type: redis\n",
+			code:  DiagUnresolvedReference,
+			field: "containers.main.variables.CACHE",
+			msg:   `resource "cache" has no output "url" (outputs: host, password, port)`,
+		},
+		{
+			name:  "secret inside a string",
+			doc:   "resources:\n  cache:\n    type: redis\n",
+			code:  DiagSecretInString,
+			field: "containers.main.variables.URL",
+			msg:   "reference ${resources.cache.password} is a secret",
+		},
+		{
+			name:  "placeholder in a command",
+			doc:   "resources:\n  cache:\n    type: redis\n",
+			code:  DiagUnresolvedPlaceholder,
+			field: "values.deployment.additionalContainers[0].command[1]",
+			msg:   "${resources.cache.host} would be rendered literally",
+		},
+	}
+	variables := map[string]string{
+		"undeclared resource":      "    variables:\n      CACHE: ${resources.cache.url}\n",
+		"unknown output":           "    variables:\n      CACHE: ${resources.cache.url}\n",
+		"secret inside a string":   "    variables:\n      URL: redis://:${resources.cache.password}@cache\n",
+		"placeholder in a command": "  tools:\n    image: redis:7\n    command: [redis-cli, '${resources.cache.host}']\n",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := "apiVersion: score.dev/v1b1\nmetadata:\n  name: api\ncontainers:\n  main:\n    image: api:1\n" + variables[tt.name] + tt.doc
+			w, err := Load(strings.NewReader(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Translate(w, Options{Cluster: "dev"})
+			var diagErr *DiagnosticsError
+			if !errors.As(err, &diagErr) {
+				t.Fatalf("Translate() error = %v, want a *DiagnosticsError", err)
+			}
+			if !errors.Is(err, hcerrors.ErrValidation) {
+				t.Errorf("error category = %v, want validation", hcerrors.CategoryOf(err))
+			}
+			d := diagErr.Diagnostics[0]
+			if d.Severity != SeverityError || d.Code != tt.code || d.Field != tt.field || !strings.Contains(d.Message, tt.msg) {
+				t.Errorf("first diagnostic = %+v, want %s at %s containing %q", d, tt.code, tt.field, tt.msg)
+			}
+		})
+	}
+}
+
+func TestTranslateResolvesReferencesInStrings(t *testing.T) {
+	w, err := Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: api
+  annotations:
+    hctl.integratn.tech/external-references: vault
+containers:
+  main:
+    image: api:1
+    variables:
+      PASSWORD: ${resources.cache.password}
+      URL: http://${resources.files.source}:${resources.vault.port}/x
+  tools:
+    image: tools:1
+    command: [run, '${resources.vault.token}']
+resources:
+  cache:
+    type: redis
+  files:
+    type: volume
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Translate(w, Options{Cluster: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := result.Values["deployment"].(map[string]interface{})["env"].(map[string]interface{})
+	if got := fmt.Sprint(env["URL"]); got != "map[value:http://api-files:${resources.vault.port}/x]" {
+		t.Errorf("URL = %s, want the volume substituted and the external reference kept", got)
+	}
+	if _, ok := env["PASSWORD"].(map[string]interface{})["valueFrom"]; !ok {
+		t.Errorf("PASSWORD = %v, want a secretKeyRef", env["PASSWORD"])
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Code != DiagExternalReference ||
+		result.Diagnostics[0].Field != "containers.main.variables.URL" {
+		t.Errorf("Diagnostics = %v, want one external reference warning", result.Diagnostics)
+	}
+}
+
+func TestTranslateRoutePortDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		params  string
+		code    string
+		msg     string
+	}{
+		{"default port", "service:\n  ports:\n    http:\n      port: 3000\n", "", DiagRoutePort,
+			"port 8080, which the service does not expose (http=3000); params.port is unset and defaults to 8080"},
+		{"explicit port", "service:\n  ports:\n    http:\n      port: 3000\n", "      port: 3000\n", "", ""},
+		{"no service", "", "", DiagRouteWithoutService, "no service ports"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := "apiVersion: score.dev/v1b1\nmetadata:\n  name: web\ncontainers:\n  web:\n    image: web:1\n" + tt.service +
+				"resources:\n  public:\n    type: route\n    params:\n      host: web.example.org\n" + tt.params
+			w, err := Load(strings.NewReader(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := Translate(w, Options{Cluster: "dev"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.code == "" {
+				if len(result.Diagnostics) != 0 {
+					t.Errorf("Diagnostics = %v, want none", result.Diagnostics)
+				}
+				return
+			}
+			if len(result.Diagnostics) != 1 || result.Diagnostics[0].Code != tt.code || !strings.Contains(result.Diagnostics[0].Message, tt.msg) {
+				t.Errorf("Diagnostics = %v, want %s containing %q", result.Diagnostics, tt.code, tt.msg)
+			}
+		})
+	}
+}
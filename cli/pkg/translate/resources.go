@@ -0,0 +1,320 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceExemptionAnnotation lifts the resource policy maximums for a
+// workload. Its value is the reason, which deploy records in the commit
+// message for review.
+const ResourceExemptionAnnotation = "hctl.integratn.tech/resource-exemption"
+
+// cappedResources are the resources ResourceRules.Maximums may cap.
+var cappedResources = []string{"cpu", "memory"}
+
+// ResourcePolicy is the platform's container resource policy, as declared in
+// platform/policies/resources.yaml of the gitops repo.
+type ResourcePolicy struct {
+	// Default applies to every cluster.
+	Default ResourceRules `yaml:"default,omitempty" json:"default,omitempty"`
+	// Environments holds rules by environment name, applied over Default
+	// to the clusters ClusterEnvironments places in that environment.
+	Environments map[string]ResourceRules `yaml:"environments,omitempty" json:"environments,omitempty"`
+	// Clusters holds rules by cluster name, applied over the environment's.
+	Clusters map[string]ResourceRules `yaml:"clusters,omitempty" json:"clusters,omitempty"`
+	// ClusterEnvironments maps cluster names to environments. It is not
+	// read from the policy file: the caller fills it, e.g. from the
+	// vCluster requests.
+	ClusterEnvironments map[string]string `yaml:"-" json:"-"`
+}
+
+// ResourceRules are the resource defaults and maximums for containers.
+type ResourceRules struct {
+	// Defaults are the requests and limits given to a container that
+	// declares no resources at all.
+	Defaults *score.ComputeResources `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+	// Maximums cap each container's cpu and memory, requests and limits
+	// alike.
+	Maximums map[string]string `yaml:"maximums,omitempty" json:"maximums,omitempty"`
+}
+
+// Rules returns the rules for cluster: Default, then its environment's, then
+// its own. A more specific layer's Defaults replace the less specific ones
+// as a whole; Maximums are merged by resource.
+func (p *ResourcePolicy) Rules(cluster string) ResourceRules {
+	var rules ResourceRules
+	if p == nil {
+		return rules
+	}
+	layers := []ResourceRules{p.Default}
+	if env := p.ClusterEnvironments[cluster]; env != "" {
+		layers = append(layers, p.Environments[env])
+	}
+	layers = append(layers, p.Clusters[cluster])
+	for _, l := range layers {
+		if l.Defaults != nil {
+			rules.Defaults = l.Defaults
+		}
+		for name, max := range l.Maximums {
+			if rules.Maximums == nil {
+				rules.Maximums = map[string]string{}
+			}
+			rules.Maximums[name] = max
+		}
+	}
+	return rules
+}
+
+// Validate checks that the policy's quantities parse, that it caps only
+// cpu and memory, and that no layer's defaults exceed the maximums that
+// apply with them.
+func (p *ResourcePolicy) Validate() error {
+	layers := map[string]ResourceRules{"default": p.Default}
+	for name, r := range p.Environments {
+		layers["environments."+name] = r
+	}
+	for name, r := range p.Clusters {
+		layers["clusters."+name] = r
+	}
+	fields := make([]string, 0, len(layers))
+	for field := range layers {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		r := layers[field]
+		for name, max := range r.Maximums {
+			if !isCapped(name) {
+				return fmt.Errorf("%s.maximums.%s: only %s can be capped", field, name, strings.Join(cappedResources, " and "))
+			}
+			if _, err := resource.ParseQuantity(max); err != nil {
+				return fmt.Errorf("%s.maximums.%s: invalid quantity %q", field, name, max)
+			}
+		}
+		if r.Defaults != nil {
+			for kind, list := range map[string]map[string]string{"requests": r.Defaults.Requests, "limits": r.Defaults.Limits} {
+				for name, v := range list {
+					if _, err := resource.ParseQuantity(v); err != nil {
+						return fmt.Errorf("%s.defaults.%s.%s: invalid quantity %q", field, kind, name, v)
+					}
+				}
+			}
+		}
+	}
+
+	clusters := []string{""} // clusters with no rules of their own
+	for name := range p.Clusters {
+		clusters = append(clusters, name)
+	}
+	for name := range p.ClusterEnvironments {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		rules := p.Rules(cluster)
+		if rules.Defaults == nil {
+			continue
+		}
+		if v, _ := exceeded(rules.Defaults, rules.Maximums); len(v) > 0 {
+			where := "default"
+			if cluster != "" {
+				where = "cluster " + cluster
+			}
+			return fmt.Errorf("%s: default %s.%s %s exceeds the maximum %s", where, v[0].kind, v[0].resource, v[0].value, v[0].max)
+		}
+	}
+	return nil
+}
+
+// ResourceExemption records a workload exempted from the resource policy
+// maximums.
+type ResourceExemption struct {
+	// Reason is the value of the resource-exemption annotation.
+	Reason string `json:"reason"`
+	// Exceeded lists the maximums the workload's containers exceed, e.g.
+	// "containers.main.resources.limits.memory 32Gi > 8Gi". Empty when the
+	// exemption is not needed.
+	Exceeded []string `json:"exceeded,omitempty"`
+}
+
+// String formats the exemption for a commit trailer: the reason, then the
+// maximums it lifts.
+func (e *ResourceExemption) String() string {
+	if len(e.Exceeded) == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s (%s)", e.Reason, strings.Join(e.Exceeded, "; "))
+}
+
+// applyResourcePolicy enforces rules on w's containers. Containers without
+// resources get rules.Defaults, noted in the returned diagnostics. A
+// request or limit over rules.Maximums fails, naming the container and the
+// maximum, unless the resource-exemption annotation is set; the exemption
+// is then returned. w is not modified: the returned workload shares
+// everything but its defaulted containers.
+func applyResourcePolicy(w *Workload, rules ResourceRules, cluster string) (*Workload, []Diagnostic, *ResourceExemption, error) {
+	names := make([]string, 0, len(w.Containers))
+	for name := range w.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		diags    []Diagnostic
+		over     []violation
+		defaults map[string]score.Container
+	)
+	for _, name := range names {
+		c := w.Containers[name]
+		field := "containers." + name + ".resources"
+		if c.Resources == nil && rules.Defaults != nil {
+			c.Resources = &score.ComputeResources{
+				Requests: copyStrings(rules.Defaults.Requests),
+				Limits:   copyStrings(rules.Defaults.Limits),
+			}
+			if defaults == nil {
+				defaults = map[string]score.Container{}
+			}
+			defaults[name] = c
+			diags = append(diags, Diagnostic{
+				Severity: SeverityInfo,
+				Code:     DiagResourceDefaults,
+				Field:    field,
+				Message:  fmt.Sprintf("no resources declared; applied the platform defaults for cluster %s (%s)", cluster, describeResources(c.Resources)),
+			})
+			continue // the policy's defaults are checked against its maximums when loaded
+		}
+		if c.Resources == nil {
+			continue
+		}
+		v, err := exceeded(c.Resources, rules.Maximums)
+		if err != nil {
+			return nil, nil, nil, hcerrors.New(hcerrors.ErrValidation, "container %q: %v", name, err).
+				WithDetails(map[string]string{"field": field})
+		}
+		for i := range v {
+			v[i].container = name
+		}
+		over = append(over, v...)
+	}
+
+	var exemption *ResourceExemption
+	if reason := strings.TrimSpace(w.Metadata.Annotations[ResourceExemptionAnnotation]); reason != "" {
+		exemption = &ResourceExemption{Reason: reason}
+		for _, v := range over {
+			exemption.Exceeded = append(exemption.Exceeded, v.String())
+		}
+	} else if len(over) > 0 {
+		v := over[0]
+		return nil, nil, nil, hcerrors.New(hcerrors.ErrValidation, "container %q: %s.%s %s exceeds the platform maximum of %s for cluster %s",
+			v.container, v.kind, v.resource, v.value, v.max, cluster).
+			WithRemediation("lower it, or set the " + ResourceExemptionAnnotation + " annotation to the reason an exemption is needed").
+			WithDetails(map[string]string{"field": v.field()})
+	}
+
+	if defaults == nil {
+		return w, diags, exemption, nil
+	}
+	out := *w
+	out.Containers = make(map[string]score.Container, len(w.Containers))
+	for name, c := range w.Containers {
+		if d, ok := defaults[name]; ok {
+			c = d
+		}
+		out.Containers[name] = c
+	}
+	return &out, diags, exemption, nil
+}
+
+// violation is a request or limit over its maximum.
+type violation struct {
+	container, kind, resource, value, max string
+}
+
+// field is the Score path of the violating value.
+func (v violation) field() string {
+	return "containers." + v.container + ".resources." + v.kind + "." + v.resource
+}
+
+func (v violation) String() string {
+	return fmt.Sprintf("%s %s > %s", v.field(), v.value, v.max)
+}
+
+// exceeded returns the cpu and memory requests and limits in r over
+// maximums, requests first. It fails on a quantity that does not parse.
+func exceeded(r *score.ComputeResources, maximums map[string]string) ([]violation, error) {
+	var out []violation
+	for _, list := range []struct {
+		kind   string
+		values map[string]string
+	}{{"requests", r.Requests}, {"limits", r.Limits}} {
+		for _, res := range cappedResources {
+			v, ok := list.values[res]
+			if !ok {
+				continue
+			}
+			q, err := resource.ParseQuantity(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s.%s quantity %q", list.kind, res, v)
+			}
+			max, ok := maximums[res]
+			if !ok {
+				continue
+			}
+			if q.Cmp(resource.MustParse(max)) > 0 {
+				out = append(out, violation{kind: list.kind, resource: res, value: v, max: max})
+			}
+		}
+	}
+	return out, nil
+}
+
+// describeResources formats r as "requests cpu=100m,memory=128Mi; limits
+// memory=256Mi".
+func describeResources(r *score.ComputeResources) string {
+	var parts []string
+	for _, list := range []struct {
+		kind   string
+		values map[string]string
+	}{{"requests", r.Requests}, {"limits", r.Limits}} {
+		if len(list.values) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(list.values))
+		for k := range list.values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + "=" + list.values[k]
+		}
+		parts = append(parts, list.kind+" "+strings.Join(keys, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func isCapped(name string) bool {
+	for _, r := range cappedResources {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package translate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func testResourcePolicy() *ResourcePolicy {
+	return &ResourcePolicy{
+		Default: ResourceRules{
+			Defaults: &score.ComputeResources{
+				Requests: map[string]string{"cpu": "100m", "memory": "128Mi"},
+				Limits:   map[string]string{"memory": "256Mi"},
+			},
+			Maximums: map[string]string{"cpu": "2", "memory": "4Gi"},
+		},
+		Environments: map[string]ResourceRules{
+			"production": {Maximums: map[string]string{"memory": "8Gi"}},
+		},
+		Clusters: map[string]ResourceRules{
+			"media": {Maximums: map[string]string{"memory": "16Gi"}},
+		},
+		ClusterEnvironments: map[string]string{"dev": "development", "media": "production", "prod": "production"},
+	}
+}
+
+func TestResourcePolicyRules(t *testing.T) {
+	p := testResourcePolicy()
+	for cluster, want := range map[string]map[string]string{
+		"dev":   {"cpu": "2", "memory": "4Gi"},
+		"prod":  {"cpu": "2", "memory": "8Gi"},
+		"media": {"cpu": "2", "memory": "16Gi"},
+	} {
+		rules := p.Rules(cluster)
+		if !reflect.DeepEqual(rules.Maximums, want) {
+			t.Errorf("Rules(%q).Maximums = %v, want %v", cluster, rules.Maximums, want)
+		}
+		if rules.Defaults != p.Default.Defaults {
+			t.Errorf("Rules(%q).Defaults = %+v, want the default layer's", cluster, rules.Defaults)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	var none *ResourcePolicy
+	if rules := none.Rules("dev"); rules.Defaults != nil || rules.Maximums != nil {
+		t.Errorf("nil policy Rules = %+v, want none", rules)
+	}
+}
+
+func TestResourcePolicyValidate(t *testing.T) {
+	for name, mutate := range map[string]func(*ResourcePolicy){
+		"uncapped resource": func(p *ResourcePolicy) { p.Default.Maximums["nvidia.com/gpu"] = "1" },
+		"bad maximum": func(p *ResourcePolicy) {
+			p.Clusters["media"] = ResourceRules{Maximums: map[string]string{"memory": "lots"}}
+		},
+		"bad default": func(p *ResourcePolicy) { p.Default.Defaults.Requests["cpu"] = "1 core" },
+		"default over a cluster maximum": func(p *ResourcePolicy) {
+			p.Clusters["tiny"] = ResourceRules{Maximums: map[string]string{"memory": "64Mi"}}
+		},
+	} {
+		p := testResourcePolicy()
+		mutate(p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: Validate passed", name)
+		}
+	}
+}
+
+func TestResourcePolicyDefaults(t *testing.T) {
+	w := placementWorkload(nil)
+	w.Containers["sidecar"] = score.Container{
+		Image:     "busybox:1.36",
+		Resources: &score.ComputeResources{Requests: map[string]string{"cpu": "50m"}},
+	}
+
+	result, err := Translate(w, Options{ResourcePolicy: testResourcePolicy()})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	d := result.Values["deployment"].(map[string]interface{})
+	want := map[string]interface{}{
+		"requests": map[string]string{"cpu": "100m", "memory": "128Mi"},
+		"limits":   map[string]string{"memory": "256Mi"},
+	}
+	if !reflect.DeepEqual(d["resources"], want) {
+		t.Errorf("app resources = %v, want the platform defaults %v", d["resources"], want)
+	}
+	sidecar := d["additionalContainers"].([]map[string]interface{})[0]
+	if got := sidecar["resources"].(map[string]interface{})["requests"]; !reflect.DeepEqual(got, map[string]string{"cpu": "50m"}) {
+		t.Errorf("sidecar requests = %v, want its own, undefaulted", got)
+	}
+	if w.Containers["app"].Resources != nil {
+		t.Error("Translate modified the caller's workload")
+	}
+
+	want1 := Diagnostic{
+		Severity: SeverityInfo,
+		Code:     DiagResourceDefaults,
+		Field:    "containers.app.resources",
+		Message:  "no resources declared; applied the platform defaults for cluster dev (requests cpu=100m,memory=128Mi; limits memory=256Mi)",
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0] != want1 {
+		t.Errorf("Diagnostics = %v, want [%v]", result.Diagnostics, want1)
+	}
+	if result.ResourceExemption != nil {
+		t.Errorf("ResourceExemption = %+v, want none", result.ResourceExemption)
+	}
+}
+
+func TestResourcePolicyMaximums(t *testing.T) {
+	w := placementWorkload(nil)
+	w.Containers["transcoder"] = score.Container{
+		Image: "jellyfin/ffmpeg:6",
+		Resources: &score.ComputeResources{
+			Requests: map[string]string{"memory": "4Gi"},
+			Limits:   map[string]string{"memory": "32Gi"},
+		},
+	}
+
+	_, err := Translate(w, Options{Cluster: "prod", ResourcePolicy: testResourcePolicy()})
+	if got := hcerrors.ExitCode(err); got != hcerrors.ExitValidation {
+		t.Fatalf("ExitCode = %d, want %d (err: %v)", got, hcerrors.ExitValidation, err)
+	}
+	for _, want := range []string{`container "transcoder"`, "limits.memory 32Gi", "maximum of 8Gi", "cluster prod"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// The cluster layer raises the environment's cap.
+	w.Containers["transcoder"] = score.Container{
+		Image:     "jellyfin/ffmpeg:6",
+		Resources: &score.ComputeResources{Limits: map[string]string{"memory": "12Gi"}},
+	}
+	if _, err := Translate(w, Options{Cluster: "media", ResourcePolicy: testResourcePolicy()}); err != nil {
+		t.Errorf("12Gi on media (cap 16Gi): %v", err)
+	}
+
+	// Quantities compare by value, not by text.
+	w.Containers["transcoder"] = score.Container{
+		Image:     "jellyfin/ffmpeg:6",
+		Resources: &score.ComputeResources{Requests: map[string]string{"cpu": "2500m"}},
+	}
+	if _, err := Translate(w, Options{Cluster: "prod", ResourcePolicy: testResourcePolicy()}); err == nil {
+		t.Error("cpu 2500m passed a cap of 2")
+	}
+}
+
+func TestResourcePolicyExemption(t *testing.T) {
+	w := placementWorkload(map[string]string{ResourceExemptionAnnotation: "transcoding 4K needs the memory"})
+	w.Containers["app"] = score.Container{
+		Image:     "jellyfin/jellyfin:10.9",
+		Resources: &score.ComputeResources{Limits: map[string]string{"cpu": "4", "memory": "32Gi"}},
+	}
+
+	result, err := Translate(w, Options{ResourcePolicy: testResourcePolicy()})
+	if err != nil {
+		t.Fatalf("Translate with an exemption: %v", err)
+	}
+	want := &ResourceExemption{
+		Reason: "transcoding 4K needs the memory",
+		Exceeded: []string{
+			"containers.app.resources.limits.cpu 4 > 2",
+			"containers.app.resources.limits.memory 32Gi > 4Gi",
+		},
+	}
+	if !reflect.DeepEqual(result.ResourceExemption, want) {
+		t.Errorf("ResourceExemption = %+v, want %+v", result.ResourceExemption, want)
+	}
+	if got := result.ResourceExemption.String(); got != "transcoding 4K needs the memory (containers.app.resources.limits.cpu 4 > 2; containers.app.resources.limits.memory 32Gi > 4Gi)" {
+		t.Errorf("String() = %q", got)
+	}
+	limits := result.Values["deployment"].(map[string]interface{})["resources"].(map[string]interface{})["limits"]
+	if !reflect.DeepEqual(limits, map[string]string{"cpu": "4", "memory": "32Gi"}) {
+		t.Errorf("limits = %v, want them kept as declared", limits)
+	}
+}
+
+func TestResourcePolicyNone(t *testing.T) {
+	w := placementWorkload(nil)
+	w.Containers["app"] = score.Container{
+		Image:     "nginx:1.27",
+		Resources: &score.ComputeResources{Limits: map[string]string{"memory": "32Gi"}},
+	}
+	w.Containers["bare"] = score.Container{Image: "busybox:1.36"}
+
+	result, err := Translate(w, Options{})
+	if err != nil {
+		t.Fatalf("Translate without a policy: %v", err)
+	}
+	if len(result.Diagnostics) != 0 || result.ResourceExemption != nil {
+		t.Errorf("Diagnostics = %v, ResourceExemption = %v; want none", result.Diagnostics, result.ResourceExemption)
+	}
+	bare := result.Values["deployment"].(map[string]interface{})["additionalContainers"].([]map[string]interface{})[0]
+	if _, ok := bare["resources"]; ok {
+		t.Errorf("bare container got resources %v without a policy", bare["resources"])
+	}
+}
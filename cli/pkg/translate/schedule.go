@@ -0,0 +1,484 @@
+package translate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone validation must not depend on the host's zoneinfo
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+const (
+	// ScalerAnnotation selects how x-hctl.schedule is rendered: "cronjob"
+	// (default) or "keda".
+	ScalerAnnotation = "hctl.integratn.tech/scaler"
+	// ScalerCronJob renders two CronJobs that run kubectl scale.
+	ScalerCronJob = "cronjob"
+	// ScalerKEDA renders a KEDA ScaledObject with a cron trigger.
+	ScalerKEDA = "keda"
+
+	// schedulerImage runs kubectl scale in the CronJob scaler.
+	schedulerImage = "ghcr.io/jamesatintegratnio/gitops_homelab_2_0/kubectl:latest"
+	// scheduleLookback bounds the search for a cron's previous and next run.
+	scheduleLookback = 366 * 24 * time.Hour
+)
+
+// Schedule is a validated x-hctl.schedule block.
+type Schedule struct {
+	Downscale        string
+	Upscale          string
+	Timezone         string
+	DowntimeReplicas int
+	Replicas         int
+
+	loc      *time.Location
+	down, up *cronSpec
+}
+
+// ParseSchedule validates a schedule block and fills in its defaults.
+func ParseSchedule(ext *score.ScheduleExtension) (*Schedule, error) {
+	s := &Schedule{
+		Downscale:        strings.TrimSpace(ext.Downscale),
+		Upscale:          strings.TrimSpace(ext.Upscale),
+		Timezone:         ext.Timezone,
+		DowntimeReplicas: ext.DowntimeReplicas,
+		Replicas:         ext.Replicas,
+	}
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	if s.Replicas == 0 {
+		s.Replicas = 1
+	}
+
+	var err error
+	if s.down, err = parseCron(s.Downscale); err != nil {
+		return nil, scheduleError("downscale", "%v", err)
+	}
+	if s.up, err = parseCron(s.Upscale); err != nil {
+		return nil, scheduleError("upscale", "%v", err)
+	}
+	// "Local" would make the rendered schedule depend on the machine running hctl.
+	if s.Timezone == "Local" {
+		return nil, scheduleError("timezone", "unknown time zone %q (use an IANA name such as Europe/Berlin)", s.Timezone)
+	}
+	if s.loc, err = time.LoadLocation(s.Timezone); err != nil {
+		return nil, scheduleError("timezone", "unknown time zone %q (use an IANA name such as Europe/Berlin)", s.Timezone)
+	}
+	if s.DowntimeReplicas < 0 || s.Replicas < 0 {
+		return nil, scheduleError("replicas", "replica counts cannot be negative")
+	}
+	if s.DowntimeReplicas >= s.Replicas {
+		return nil, scheduleError("downtimeReplicas", "downtimeReplicas (%d) must be lower than replicas (%d)", s.DowntimeReplicas, s.Replicas)
+	}
+	return s, nil
+}
+
+func scheduleError(field, format string, args ...interface{}) error {
+	return hcerrors.New(hcerrors.ErrValidation, "x-hctl.schedule.%s: "+format, append([]interface{}{field}, args...)...).
+		WithDetails(map[string]string{"field": "x-hctl.schedule." + field})
+}
+
+// ScheduleState is where a schedule stands at a point in time.
+type ScheduleState struct {
+	// Down is true between a downscale and the following upscale.
+	Down bool
+	// Replicas is the count the schedule last scaled to.
+	Replicas int
+	// Next is when the schedule next changes state; zero if not within a year.
+	Next time.Time
+}
+
+// State reports whether the workload is scaled down at now, by which of
+// the two crons ran last, and when the other one runs next.
+func (s *Schedule) State(now time.Time) ScheduleState {
+	now = now.In(s.loc)
+	lastDown := s.down.prev(now)
+	lastUp := s.up.prev(now)
+	st := ScheduleState{Replicas: s.Replicas}
+	if !lastDown.IsZero() && lastDown.After(lastUp) {
+		st.Down = true
+		st.Replicas = s.DowntimeReplicas
+		st.Next = s.up.next(now)
+	} else {
+		st.Next = s.down.next(now)
+	}
+	return st
+}
+
+// String describes the schedule, e.g.
+// "0 at 0 20 * * 1-5, 1 at 0 7 * * 1-5 (Europe/Berlin)".
+func (s *Schedule) String() string {
+	return fmt.Sprintf("%d at %s, %d at %s (%s)", s.DowntimeReplicas, s.Downscale, s.Replicas, s.Upscale, s.Timezone)
+}
+
+// scaleTarget is the controller a schedule scales.
+type scaleTarget struct {
+	kind, name string
+}
+
+// manifests renders the scaler for the target: a KEDA ScaledObject, or a
+// ServiceAccount, Role and RoleBinding plus downscale and upscale CronJobs.
+func (s *Schedule) manifests(scaler string, target scaleTarget) []map[string]interface{} {
+	if scaler == ScalerKEDA {
+		return []map[string]interface{}{{
+			"apiVersion": "keda.sh/v1alpha1",
+			"kind":       "ScaledObject",
+			"metadata":   map[string]interface{}{"name": target.name + "-schedule"},
+			"spec": map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       target.kind,
+					"name":       target.name,
+				},
+				"minReplicaCount": s.DowntimeReplicas,
+				"maxReplicaCount": s.Replicas,
+				// The cron trigger holds desiredReplicas between start and
+				// end; outside it KEDA scales to minReplicaCount.
+				"triggers": []interface{}{map[string]interface{}{
+					"type": "cron",
+					"metadata": map[string]interface{}{
+						"timezone":        s.Timezone,
+						"start":           s.Upscale,
+						"end":             s.Downscale,
+						"desiredReplicas": strconv.Itoa(s.Replicas),
+					},
+				}},
+			},
+		}}
+	}
+
+	sa := target.name + "-scheduler"
+	resource := strings.ToLower(target.kind) + "s"
+	return []map[string]interface{}{
+		{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   map[string]interface{}{"name": sa},
+		},
+		{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "Role",
+			"metadata":   map[string]interface{}{"name": sa},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"apiGroups":     []interface{}{"apps"},
+					"resources":     []interface{}{resource, resource + "/scale"},
+					"resourceNames": []interface{}{target.name},
+					"verbs":         []interface{}{"get", "patch"},
+				},
+			},
+		},
+		{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "RoleBinding",
+			"metadata":   map[string]interface{}{"name": sa},
+			"roleRef": map[string]interface{}{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "Role",
+				"name":     sa,
+			},
+			"subjects": []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": sa},
+			},
+		},
+		s.cronJob(target, sa, "downscale", s.Downscale, s.DowntimeReplicas),
+		s.cronJob(target, sa, "upscale", s.Upscale, s.Replicas),
+	}
+}
+
+// cronJob renders a CronJob that scales target to replicas on schedule.
+func (s *Schedule) cronJob(target scaleTarget, serviceAccount, action, schedule string, replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"name": target.name + "-" + action},
+		"spec": map[string]interface{}{
+			"schedule":                   schedule,
+			"timeZone":                   s.Timezone,
+			"concurrencyPolicy":          "Forbid",
+			"successfulJobsHistoryLimit": 1,
+			"failedJobsHistoryLimit":     1,
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"backoffLimit": 2,
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"serviceAccountName": serviceAccount,
+							"restartPolicy":      "OnFailure",
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name":  "kubectl",
+									"image": schedulerImage,
+									"args": []interface{}{
+										"scale", strings.ToLower(target.kind) + "/" + target.name,
+										fmt.Sprintf("--replicas=%d", replicas),
+									},
+									"securityContext": map[string]interface{}{
+										"runAsNonRoot":             true,
+										"allowPrivilegeEscalation": false,
+										"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ignoreReplicas is the addons.yaml ignoreDifferences entry that stops
+// ArgoCD reporting the scheduled replica count as drift.
+func (t scaleTarget) ignoreReplicas() map[string]interface{} {
+	return map[string]interface{}{
+		"group":        "apps",
+		"kind":         t.kind,
+		"name":         t.name,
+		"jsonPointers": []interface{}{"/spec/replicas"},
+	}
+}
+
+// parseScaler reads the scaler annotation.
+func parseScaler(w *score.Workload) (string, error) {
+	switch v := strings.TrimSpace(w.Metadata.Annotations[ScalerAnnotation]); v {
+	case "", ScalerCronJob:
+		return ScalerCronJob, nil
+	case ScalerKEDA:
+		return ScalerKEDA, nil
+	default:
+		return "", hcerrors.New(hcerrors.ErrValidation, "annotation %s: unsupported scaler %q (expected cronjob or keda)", ScalerAnnotation, v).
+			WithDetails(map[string]string{"field": "metadata.annotations." + ScalerAnnotation})
+	}
+}
+
+// ScheduleFromValues recovers the schedule from generated chart values, by
+// the ScaledObject or CronJobs Translate added to extraObjects. It returns
+// nil when the workload has no schedule.
+func ScheduleFromValues(values map[string]interface{}) (*Schedule, error) {
+	app, _ := values["applicationName"].(string)
+	objects, _ := values["extraObjects"].([]interface{})
+	byName := map[string]map[string]interface{}{}
+	for _, obj := range objects {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		meta, _ := m["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		kind, _ := m["kind"].(string)
+		byName[kind+"/"+name] = m
+	}
+
+	ext := &score.ScheduleExtension{}
+	if so, ok := byName["ScaledObject/"+app+"-schedule"]; ok {
+		spec, _ := so["spec"].(map[string]interface{})
+		triggers, _ := spec["triggers"].([]interface{})
+		if len(triggers) == 0 {
+			return nil, fmt.Errorf("ScaledObject %s-schedule has no trigger", app)
+		}
+		trigger, _ := triggers[0].(map[string]interface{})
+		meta, _ := trigger["metadata"].(map[string]interface{})
+		ext.Downscale, _ = meta["end"].(string)
+		ext.Upscale, _ = meta["start"].(string)
+		ext.Timezone, _ = meta["timezone"].(string)
+		ext.DowntimeReplicas = intValue(spec["minReplicaCount"])
+		ext.Replicas = intValue(spec["maxReplicaCount"])
+		return ParseSchedule(ext)
+	}
+
+	down, ok := byName["CronJob/"+app+"-downscale"]
+	if !ok {
+		return nil, nil
+	}
+	up, ok := byName["CronJob/"+app+"-upscale"]
+	if !ok {
+		return nil, fmt.Errorf("CronJob %s-downscale has no matching %s-upscale", app, app)
+	}
+	ext.Downscale, ext.Timezone, ext.DowntimeReplicas = cronJobSchedule(down)
+	ext.Upscale, _, ext.Replicas = cronJobSchedule(up)
+	return ParseSchedule(ext)
+}
+
+// cronJobSchedule reads the schedule, time zone and --replicas argument of a
+// scaler CronJob.
+func cronJobSchedule(cj map[string]interface{}) (schedule, timezone string, replicas int) {
+	spec, _ := cj["spec"].(map[string]interface{})
+	schedule, _ = spec["schedule"].(string)
+	timezone, _ = spec["timeZone"].(string)
+	job, _ := spec["jobTemplate"].(map[string]interface{})
+	jobSpec, _ := job["spec"].(map[string]interface{})
+	tmpl, _ := jobSpec["template"].(map[string]interface{})
+	podSpec, _ := tmpl["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	if len(containers) == 0 {
+		return schedule, timezone, 0
+	}
+	c, _ := containers[0].(map[string]interface{})
+	args, _ := c["args"].([]interface{})
+	for _, a := range args {
+		if s, ok := a.(string); ok && strings.HasPrefix(s, "--replicas=") {
+			replicas, _ = strconv.Atoi(strings.TrimPrefix(s, "--replicas="))
+		}
+	}
+	return schedule, timezone, replicas
+}
+
+// intValue reads an integer from generated values, in memory or decoded.
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// cronSpec is a parsed five-field cron expression.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted field: cron matches either
+	// day field when both are restricted, and both otherwise.
+	domStar, dowStar bool
+}
+
+// cronField describes the range and names of one cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // index i names value min+i
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// parseCron parses a standard five-field cron expression: each field is a
+// comma-separated list of *, n, n-m, with an optional /step. Months and
+// weekdays also accept three-letter names; 7 is Sunday like 0.
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSpec{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*" || rng == "?":
+		default:
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses one number or name within the field's range.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s: %d is outside %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// matches reports whether the cron fires at t's minute.
+func (c *cronSpec) matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// prev returns the latest time at or before t the cron fired, or zero.
+func (c *cronSpec) prev(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for end := t.Add(-scheduleLookback); t.After(end); t = t.Add(-time.Minute) {
+		if c.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// next returns the first time after t the cron fires, or zero.
+func (c *cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(scheduleLookback); t.Before(end); t = t.Add(time.Minute) {
+		if c.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
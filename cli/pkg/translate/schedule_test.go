@@ -0,0 +1,286 @@
+package translate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const scheduledWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: wiki
+  annotations:
+    hctl.integratn.tech/cluster: dev
+x-hctl:
+  schedule:
+    downscale: "0 20 * * mon-fri"
+    upscale: "0 7 * * 1-5"
+    timezone: Europe/Berlin
+containers:
+  wiki:
+    image: ghcr.io/example/wiki:1
+`
+
+// extraObjectsByKind indexes a result's extraObjects by kind and name.
+func extraObjectsByKind(t *testing.T, values map[string]interface{}) map[string]map[string]interface{} {
+	t.Helper()
+	objects := map[string]map[string]interface{}{}
+	for _, obj := range values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		meta := m["metadata"].(map[string]interface{})
+		objects[m["kind"].(string)+"/"+meta["name"].(string)] = m
+	}
+	return objects
+}
+
+// decodedValues returns the values as written to values.yaml.
+func decodedValues(t *testing.T, result *Result) map[string]interface{} {
+	t.Helper()
+	body, _, _ := ParseGenerated(result.Files[ValuesPath(result.TargetCluster, result.WorkloadName)])
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestScheduleCronJobs(t *testing.T) {
+	result, err := Translate(loadExtensionWorkload(t, scheduledWorkload), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+
+	objects := extraObjectsByKind(t, result.Values)
+	for _, key := range []string{"ServiceAccount/wiki-scheduler", "Role/wiki-scheduler", "RoleBinding/wiki-scheduler", "CronJob/wiki-downscale", "CronJob/wiki-upscale"} {
+		obj, ok := objects[key]
+		if !ok {
+			t.Fatalf("extraObjects have no %s; got %v", key, reflect.ValueOf(objects).MapKeys())
+		}
+		if ns := obj["metadata"].(map[string]interface{})["namespace"]; ns != "dev" {
+			t.Errorf("%s namespace = %v, want dev", key, ns)
+		}
+	}
+	if _, ok := objects["ScaledObject/wiki-schedule"]; ok {
+		t.Error("cronjob scaler also rendered a ScaledObject")
+	}
+
+	down := objects["CronJob/wiki-downscale"]["spec"].(map[string]interface{})
+	if down["schedule"] != "0 20 * * mon-fri" || down["timeZone"] != "Europe/Berlin" {
+		t.Errorf("downscale schedule = %v %v", down["schedule"], down["timeZone"])
+	}
+	container := down["jobTemplate"].(map[string]interface{})["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	wantArgs := []interface{}{"scale", "deployment/wiki", "--replicas=0"}
+	if !reflect.DeepEqual(container["args"], wantArgs) {
+		t.Errorf("downscale args = %v, want %v", container["args"], wantArgs)
+	}
+
+	rule := objects["Role/wiki-scheduler"]["rules"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(rule["resourceNames"], []interface{}{"wiki"}) {
+		t.Errorf("Role resourceNames = %v, want only the workload", rule["resourceNames"])
+	}
+
+	if r := result.Values["deployment"].(map[string]interface{})["replicas"]; r != 1 {
+		t.Errorf("deployment.replicas = %v, want 1", r)
+	}
+	wantIgnore := []interface{}{map[string]interface{}{
+		"group": "apps", "kind": "Deployment", "name": "wiki", "jsonPointers": []interface{}{"/spec/replicas"},
+	}}
+	if !reflect.DeepEqual(result.AddonsEntry["ignoreDifferences"], wantIgnore) {
+		t.Errorf("ignoreDifferences = %v, want %v", result.AddonsEntry["ignoreDifferences"], wantIgnore)
+	}
+
+	sched, err := ScheduleFromValues(decodedValues(t, result))
+	if err != nil || sched == nil {
+		t.Fatalf("ScheduleFromValues() = %v, %v", sched, err)
+	}
+	if got := sched.String(); got != "0 at 0 20 * * mon-fri, 1 at 0 7 * * 1-5 (Europe/Berlin)" {
+		t.Errorf("recovered schedule = %q", got)
+	}
+}
+
+func TestScheduleKEDA(t *testing.T) {
+	spec := strings.Replace(scheduledWorkload, "    hctl.integratn.tech/cluster: dev\n",
+		"    hctl.integratn.tech/cluster: dev\n    hctl.integratn.tech/scaler: keda\n", 1)
+	spec = strings.Replace(spec, "    timezone: Europe/Berlin\n", "    timezone: Europe/Berlin\n    replicas: 3\n    downtimeReplicas: 1\n", 1)
+	result, err := Translate(loadExtensionWorkload(t, spec), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+
+	objects := extraObjectsByKind(t, result.Values)
+	if len(objects) != 1 {
+		t.Errorf("keda scaler rendered %d objects, want only the ScaledObject", len(objects))
+	}
+	so, ok := objects["ScaledObject/wiki-schedule"]
+	if !ok {
+		t.Fatal("no ScaledObject wiki-schedule")
+	}
+	soSpec := so["spec"].(map[string]interface{})
+	if soSpec["minReplicaCount"] != 1 || soSpec["maxReplicaCount"] != 3 {
+		t.Errorf("replica counts = %v..%v, want 1..3", soSpec["minReplicaCount"], soSpec["maxReplicaCount"])
+	}
+	trigger := soSpec["triggers"].([]interface{})[0].(map[string]interface{})
+	wantMeta := map[string]interface{}{
+		"timezone": "Europe/Berlin", "start": "0 7 * * 1-5", "end": "0 20 * * mon-fri", "desiredReplicas": "3",
+	}
+	if trigger["type"] != "cron" || !reflect.DeepEqual(trigger["metadata"], wantMeta) {
+		t.Errorf("trigger = %v, want cron %v", trigger, wantMeta)
+	}
+
+	sched, err := ScheduleFromValues(decodedValues(t, result))
+	if err != nil || sched == nil {
+		t.Fatalf("ScheduleFromValues() = %v, %v", sched, err)
+	}
+	if sched.DowntimeReplicas != 1 || sched.Replicas != 3 {
+		t.Errorf("recovered replicas = %d/%d, want 1/3", sched.DowntimeReplicas, sched.Replicas)
+	}
+}
+
+func TestScheduleStatefulSetTarget(t *testing.T) {
+	spec := strings.Replace(scheduledWorkload, "x-hctl:\n", "x-hctl:\n  workloadKind: statefulset\n", 1)
+	result, err := Translate(loadExtensionWorkload(t, spec), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if r := result.Values["statefulset"].(map[string]interface{})["replicas"]; r != 1 {
+		t.Errorf("statefulset.replicas = %v, want 1", r)
+	}
+	rule := extraObjectsByKind(t, result.Values)["Role/wiki-scheduler"]["rules"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(rule["resources"], []interface{}{"statefulsets", "statefulsets/scale"}) {
+		t.Errorf("Role resources = %v, want statefulsets", rule["resources"])
+	}
+}
+
+func TestScheduleValidation(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"bad cron field count", `downscale: "0 20 * * mon-fri"`, `downscale: "0 20 * *"`, "x-hctl.schedule.downscale"},
+		{"cron value out of range", `upscale: "0 7 * * 1-5"`, `upscale: "0 24 * * 1-5"`, "x-hctl.schedule.upscale"},
+		{"bad cron step", `upscale: "0 7 * * 1-5"`, `upscale: "*/0 7 * * 1-5"`, "x-hctl.schedule.upscale"},
+		{"backwards range", `upscale: "0 7 * * 1-5"`, `upscale: "0 7 * * fri-mon"`, "x-hctl.schedule.upscale"},
+		{"unknown timezone", "timezone: Europe/Berlin", "timezone: Europe/Atlantis", "x-hctl.schedule.timezone"},
+		{"local timezone", "timezone: Europe/Berlin", "timezone: Local", "x-hctl.schedule.timezone"},
+		{"downtime not lower", "timezone: Europe/Berlin", "timezone: Europe/Berlin\n    downtimeReplicas: 1", "x-hctl.schedule.downtimeReplicas"},
+		{"autoscaling conflict", "x-hctl:\n", "x-hctl:\n  autoscaling:\n    maxReplicas: 4\n", "x-hctl.schedule"},
+		{"unknown scaler", "    hctl.integratn.tech/cluster: dev\n", "    hctl.integratn.tech/cluster: dev\n    hctl.integratn.tech/scaler: cron\n", "metadata.annotations." + ScalerAnnotation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := strings.Replace(scheduledWorkload, tt.from, tt.to, 1)
+			if spec == scheduledWorkload {
+				t.Fatalf("%q not found in workload", tt.from)
+			}
+			_, err := Translate(loadExtensionWorkload(t, spec), Options{})
+			var he *hcerrors.HctlError
+			if !errors.As(err, &he) || !errors.Is(err, hcerrors.ErrValidation) {
+				t.Fatalf("Translate() error = %v, want a validation error", err)
+			}
+			if field := he.Details.(map[string]string)["field"]; field != tt.want {
+				t.Errorf("error field = %q, want %q (%v)", field, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestScheduleState(t *testing.T) {
+	w := loadExtensionWorkload(t, scheduledWorkload)
+	sched, err := ParseSchedule(w.Extensions.Schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(s string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04", s, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		now      string
+		down     bool
+		replicas int
+		next     string
+	}{
+		{"2026-03-04 12:00", false, 1, "2026-03-04 20:00"}, // Wednesday midday
+		{"2026-03-04 20:00", true, 0, "2026-03-05 07:00"},  // downscale just ran
+		{"2026-03-05 06:59", true, 0, "2026-03-05 07:00"},
+		{"2026-03-07 12:00", true, 0, "2026-03-09 07:00"}, // Saturday: down since Friday evening
+		{"2026-03-09 07:00", false, 1, "2026-03-09 20:00"},
+	}
+	for _, tt := range tests {
+		st := sched.State(at(tt.now))
+		if st.Down != tt.down || st.Replicas != tt.replicas || !st.Next.Equal(at(tt.next)) {
+			t.Errorf("State(%s) = down %v, %d replicas, next %s; want %v, %d, %s",
+				tt.now, st.Down, st.Replicas, st.Next.In(berlin).Format("2006-01-02 15:04"), tt.down, tt.replicas, tt.next)
+		}
+	}
+}
+
+func TestCronDayMatching(t *testing.T) {
+	// With both day fields restricted, either one matching is enough.
+	c, err := parseCron("0 0 1 * sun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		day  string
+		want bool
+	}{
+		{"2026-03-01", true}, // 1st, a Sunday
+		{"2026-04-01", true}, // 1st, a Wednesday
+		{"2026-03-08", true}, // Sunday
+		{"2026-03-09", false},
+	} {
+		ts, _ := time.Parse("2006-01-02", tt.day)
+		if got := c.matches(ts); got != tt.want {
+			t.Errorf("matches(%s) = %v, want %v", tt.day, got, tt.want)
+		}
+	}
+
+	// 7 is Sunday as well as 0.
+	c, err = parseCron("30 6 * * 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := time.Date(2026, 3, 8, 6, 30, 0, 0, time.UTC); !c.matches(ts) {
+		t.Error("day-of-week 7 does not match Sunday")
+	}
+}
+
+func TestAutoscaling(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: api
+x-hctl:
+  autoscaling:
+    maxReplicas: 5
+containers:
+  api:
+    image: api:1
+`
+	result, err := Translate(loadExtensionWorkload(t, spec), Options{Cluster: "dev"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	as := result.Values["autoscaling"].(map[string]interface{})
+	if as["enabled"] != true || as["minReplicas"] != 1 || as["maxReplicas"] != 5 {
+		t.Errorf("autoscaling = %v, want enabled 1..5", as)
+	}
+	target := as["metrics"].([]interface{})[0].(map[string]interface{})["resource"].(map[string]interface{})["target"].(map[string]interface{})
+	if target["averageUtilization"] != 80 {
+		t.Errorf("averageUtilization = %v, want default 80", target["averageUtilization"])
+	}
+
+	bad := strings.Replace(spec, "maxReplicas: 5", "minReplicas: 3\n    maxReplicas: 2", 1)
+	if _, err := Translate(loadExtensionWorkload(t, bad), Options{Cluster: "dev"}); !errors.Is(err, hcerrors.ErrValidation) {
+		t.Errorf("minReplicas > maxReplicas: error = %v, want validation error", err)
+	}
+}
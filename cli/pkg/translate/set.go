@@ -0,0 +1,64 @@
+package translate
+
+import (
+	"fmt"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseSet splits a path=value values override, as given to --set, into
+// its dot-separated path and its value. The value is read as a YAML
+// scalar, so "3" is a number and "true" a boolean; quote it ("'3'") to
+// keep a string.
+func ParseSet(set string) ([]string, interface{}, error) {
+	path, raw, ok := strings.Cut(set, "=")
+	if !ok {
+		return nil, nil, fmt.Errorf("%q is not a path=value override", set)
+	}
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, nil, fmt.Errorf("%q: the path must be dot-separated keys, e.g. deployment.replicas", set)
+		}
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, nil, fmt.Errorf("%q: invalid value: %v", set, err)
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, nil, fmt.Errorf("%q: the value must be a scalar", set)
+	}
+	return keys, value, nil
+}
+
+// applySets applies path=value overrides to the chart values in order, so
+// a later override of the same path wins. Missing maps along a path are
+// created; a path through a value that is not a map is an error.
+func applySets(values map[string]interface{}, sets []string) error {
+	for _, set := range sets {
+		keys, value, err := ParseSet(set)
+		if err != nil {
+			return hcerrors.NewUserError("--set %v", err)
+		}
+		m := values
+		for i, key := range keys[:len(keys)-1] {
+			next, ok := m[key]
+			if !ok || next == nil {
+				child := map[string]interface{}{}
+				m[key] = child
+				m = child
+				continue
+			}
+			child, ok := next.(map[string]interface{})
+			if !ok {
+				return hcerrors.NewUserError("--set %s: %s is not a map in the generated values", set, strings.Join(keys[:i+1], "."))
+			}
+			m = child
+		}
+		m[keys[len(keys)-1]] = value
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package translate
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/smoke"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+// SmokeTest is an HTTP GET of URL that must answer ExpectStatus, with
+// ExpectBodyContains in the body when set, within Timeout.
+type SmokeTest struct {
+	URL                string        `json:"url"`
+	ExpectStatus       int           `json:"expectStatus"`
+	ExpectBodyContains string        `json:"expectBodyContains,omitempty"`
+	Timeout            time.Duration `json:"timeout"`
+}
+
+// parseSmokeTests validates x-hctl.smokeTests and resolves each to an https
+// check against the host of the route the chart renders. Smoke tests need
+// a route to reach the workload through.
+func parseSmokeTests(w *score.Workload) ([]SmokeTest, error) {
+	if w.Extensions == nil || len(w.Extensions.SmokeTests) == 0 {
+		return nil, nil
+	}
+	routes := routeNames(w)
+	if len(routes) == 0 {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "x-hctl.smokeTests need a route resource to reach the workload through").
+			WithDetails(map[string]string{"field": "x-hctl.smokeTests"})
+	}
+	host, _ := w.Resources[routes[0]].Params["host"].(string)
+	if host == "" {
+		return nil, nil // the route provisioner reports the missing host
+	}
+
+	checks := make([]SmokeTest, 0, len(w.Extensions.SmokeTests))
+	for i, t := range w.Extensions.SmokeTests {
+		field := fmt.Sprintf("x-hctl.smokeTests[%d]", i)
+		invalid := func(key, format string, args ...interface{}) error {
+			return hcerrors.New(hcerrors.ErrValidation, "%s.%s: "+format, append([]interface{}{field, key}, args...)...).
+				WithDetails(map[string]string{"field": field + "." + key})
+		}
+		if !strings.HasPrefix(t.Path, "/") {
+			return nil, invalid("path", "%q must start with /", t.Path)
+		}
+		u, err := url.Parse("https://" + host + t.Path)
+		if err != nil {
+			return nil, invalid("path", "%q is not a valid URL path: %v", t.Path, err)
+		}
+		c := SmokeTest{URL: u.String(), ExpectStatus: t.ExpectStatus, ExpectBodyContains: t.ExpectBodyContains, Timeout: smoke.DefaultTimeout}
+		if c.ExpectStatus == 0 {
+			c.ExpectStatus = smoke.DefaultStatus
+		}
+		if c.ExpectStatus < 100 || c.ExpectStatus > 599 {
+			return nil, invalid("expectStatus", "%d is not an HTTP status", t.ExpectStatus)
+		}
+		if t.Timeout != "" {
+			d, err := time.ParseDuration(t.Timeout)
+			if err != nil || d <= 0 {
+				return nil, invalid("timeout", "%q is not a positive duration such as 30s", t.Timeout)
+			}
+			c.Timeout = d
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
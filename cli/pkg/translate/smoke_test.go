@@ -0,0 +1,63 @@
+package translate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+)
+
+func smokeWorkload(tests ...score.SmokeTest) *score.Workload {
+	w := placementWorkload(nil)
+	w.Resources = map[string]score.Resource{
+		"route": {Type: "route", Params: map[string]interface{}{"host": "myapp.integratn.tech", "path": "/", "port": 80}},
+	}
+	w.Extensions = &score.Extensions{SmokeTests: tests}
+	return w
+}
+
+func TestSmokeTests(t *testing.T) {
+	result, err := Translate(smokeWorkload(
+		score.SmokeTest{Path: "/healthz"},
+		score.SmokeTest{Path: "/api/version?full=1", ExpectStatus: 204, ExpectBodyContains: "v1", Timeout: "2m"},
+	), Options{})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	want := []SmokeTest{
+		{URL: "https://myapp.integratn.tech/healthz", ExpectStatus: 200, Timeout: 30 * time.Second},
+		{URL: "https://myapp.integratn.tech/api/version?full=1", ExpectStatus: 204, ExpectBodyContains: "v1", Timeout: 2 * time.Minute},
+	}
+	if len(result.SmokeTests) != len(want) {
+		t.Fatalf("smoke tests = %+v, want %+v", result.SmokeTests, want)
+	}
+	for i := range want {
+		if result.SmokeTests[i] != want[i] {
+			t.Errorf("smoke test %d = %+v, want %+v", i, result.SmokeTests[i], want[i])
+		}
+	}
+}
+
+func TestSmokeTestsInvalid(t *testing.T) {
+	tests := []struct {
+		test score.SmokeTest
+		want string
+	}{
+		{score.SmokeTest{Path: "healthz"}, "x-hctl.smokeTests[0].path"},
+		{score.SmokeTest{Path: "/", ExpectStatus: 42}, "x-hctl.smokeTests[0].expectStatus"},
+		{score.SmokeTest{Path: "/", Timeout: "soon"}, "x-hctl.smokeTests[0].timeout"},
+		{score.SmokeTest{Path: "/", Timeout: "-1s"}, "x-hctl.smokeTests[0].timeout"},
+	}
+	for _, tt := range tests {
+		if _, err := Translate(smokeWorkload(tt.test), Options{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: err = %v, want one naming %s", tt.test, err, tt.want)
+		}
+	}
+
+	w := smokeWorkload(score.SmokeTest{Path: "/"})
+	w.Resources = nil
+	if _, err := Translate(w, Options{}); err == nil || !strings.Contains(err.Error(), "route") {
+		t.Errorf("no route: err = %v, want one asking for a route", err)
+	}
+}
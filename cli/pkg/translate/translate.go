@@ -0,0 +1,1019 @@
+// Package translate converts Score workloads into the platform's Stakater
+// Application chart values and supporting resources. It is the public API
+// behind 'hctl deploy' and can be embedded by other tools.
+//
+// Translate takes every input explicitly through Options: it never reads hctl
+// configuration, the environment, or the working directory, so the same
+// workload and options always produce byte-identical output.
+//
+// Compatibility: within a major version of the hctl module, exported
+// identifiers in this package are not removed or changed incompatibly, and
+// new Options fields default to the previous behavior when left zero. The
+// generated values themselves may gain keys as the platform evolves.
+package translate
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	hcerrors "github.com/jamesatintegratnio/hctl/internal/errors"
+	"github.com/jamesatintegratnio/hctl/internal/layout"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"gopkg.in/yaml.v3"
+)
+
+// Workload is a parsed Score workload specification (score.dev/v1b1).
+type Workload = score.Workload
+
+// Load parses and validates a Score workload from r.
+func Load(r io.Reader) (*Workload, error) {
+	return score.Decode(r)
+}
+
+// ChartConfig identifies the Helm chart the generated values target.
+type ChartConfig struct {
+	// Repository is the Helm repository URL.
+	Repository string
+	// Name is the chart name within the repository.
+	Name string
+	// Version is the chart version pinned in the addons.yaml entry.
+	Version string
+}
+
+// DefaultChart returns the Stakater Application chart used by the platform.
+func DefaultChart() ChartConfig {
+	return ChartConfig{
+		Repository: "https://stakater.github.io/stakater-charts",
+		Name:       "application",
+		Version:    "6.14.0",
+	}
+}
+
+// Options carries every input to Translate.
+type Options struct {
+	// Cluster is the target vCluster. When empty, the workload's
+	// hctl.integratn.tech/cluster annotation is used, then DefaultCluster.
+	Cluster string
+	// DefaultCluster is the fallback when neither Cluster nor the annotation
+	// is set.
+	DefaultCluster string
+	// Namespace overrides the deployment namespace. When empty, the
+	// hctl.integratn.tech/namespace annotation is used, then the cluster name.
+	Namespace string
+	// Set are path=value overrides of the generated chart values (see
+	// ParseSet), applied in order once the values are built.
+	Set []string
+	// Domain is the platform base domain. When set, route hosts outside it
+	// produce a warning diagnostic.
+	Domain string
+	// NodePoolLabel is the node label key matched by the
+	// hctl.integratn.tech/node-pool annotation.
+	NodePoolLabel string
+	// ImageDigests maps container images, as written in the workload, to
+	// the digests they are pinned to (see PinDigest and PinnableImages).
+	// Images without an entry keep their tag. Translate never contacts a
+	// registry itself.
+	ImageDigests map[string]string
+	// SourceRepo is the origin URL of the repo holding score.yaml, recorded
+	// in the source-repo annotation on every generated object. Empty omits it.
+	SourceRepo string
+	// HctlVersion is recorded in the manifest inventory (see Inventory).
+	// Empty omits it.
+	HctlVersion string
+	// Registry resolves Score resource types. Nil uses provisioners.NewRegistry().
+	Registry *provisioners.Registry
+	// Mode tells provisioners whether this is a render, which must have no
+	// side effects, or a deploy. Empty means provisioners.ModeDeploy.
+	Mode provisioners.Mode
+	// Observability is the platform observability sidecar, injected into
+	// workloads opted in by the otel annotation or their cluster's default.
+	// Nil injects nothing; the otel annotation then fails.
+	Observability *Observability
+	// ResourcePolicy sets default container resources and caps them, by
+	// cluster or environment. Nil applies no defaults and no caps.
+	ResourcePolicy *ResourcePolicy
+	// Environments maps cluster names to their environment. The target
+	// cluster's is passed to provisioners, which name shared-class
+	// 1Password items after it, and items qualified with any other
+	// environment fail the translation (DiagCrossEnvironmentSecret). Nil
+	// leaves every cluster without an environment.
+	Environments map[string]string
+	// SharedVolumes maps the shared volumes already on the target cluster
+	// (params.shared: true) to the workload owning each, as committed in
+	// the repo. A workload renders the PVC of a shared volume it owns, or
+	// claims with params.size when nobody owns it yet, and only mounts the
+	// others; claiming a volume another workload owns, or mounting one
+	// nobody owns, fails the translation. Nil means there are none.
+	SharedVolumes map[string]string
+	// KubernetesVersion is the target cluster's Kubernetes version, such as
+	// "v1.30.2". Containers with x-hctl.role: sidecar render as native
+	// sidecars from 1.29 and as plain containers, with a warning, before
+	// it. Empty assumes a version with native sidecars.
+	KubernetesVersion string
+	// Chart is the Helm chart referenced by the addons.yaml entry. The zero
+	// value uses DefaultChart().
+	Chart ChartConfig
+	// Concurrency bounds how many provisioners run at once. Zero uses
+	// GOMAXPROCS; 1 provisions resources one at a time.
+	Concurrency int
+	// OnProvision, when set, is called as each provisioner starts with the
+	// resource name and type; the func it returns is called when the
+	// provisioner returns. Calls may be concurrent. It does not affect the
+	// output.
+	OnProvision func(resource, resourceType string) func()
+	// Cache, when set, stores provisioner results in ModeRender so repeated
+	// renders of an unchanged resource skip its provisioner. Deploys never
+	// read it: their provisioners run, and their requirements are
+	// verified, every time. Results holding secret values are not stored,
+	// nor are those of a Registry provisioner from outside the provisioners
+	// package that does not implement provisioners.Versioned.
+	Cache ResultCache
+	// Logger receives debug records of the resolved target and of each
+	// provisioner's input and output. Nil uses slog.Default().
+	Logger *slog.Logger
+
+	// sem is the provisioner pool shared across workloads by TranslateAll.
+	sem chan struct{}
+}
+
+// logger returns opts.Logger, or slog.Default() when it is nil.
+func (o Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
+}
+
+// Severity classifies a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError marks a workload that cannot be translated as written.
+	// Translate returns a *DiagnosticsError instead of a Result.
+	SeverityError Severity = "error"
+	// SeverityWarning marks output that was generated but probably not what
+	// the workload author intended.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks informational notes about the translation.
+	SeverityInfo Severity = "info"
+)
+
+// Diagnostic is a finding from translation.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	// Code identifies the kind of finding, e.g. DiagUnresolvedReference,
+	// for tools to match on; messages may change.
+	Code string `json:"code"`
+	// Field is the Score path the finding refers to, e.g.
+	// "containers.app.variables.DB_HOST".
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Field == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Field, d.Message)
+}
+
+// DiagnosticsError is the error Translate returns when a finding is an
+// error. It holds every diagnostic of the translation, errors first, so
+// they can be reported together.
+type DiagnosticsError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *DiagnosticsError) Error() string {
+	var errs []Diagnostic
+	for _, d := range e.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	if len(errs) == 0 {
+		return "translation failed"
+	}
+	msg := errs[0].Message
+	if errs[0].Field != "" {
+		msg = errs[0].Field + ": " + msg
+	}
+	if len(errs) > 1 {
+		msg += fmt.Sprintf(" (and %d more errors)", len(errs)-1)
+	}
+	return msg
+}
+
+// diagnosticsError returns the error for diags when one of them is an
+// error, and nil otherwise.
+func diagnosticsError(diags []Diagnostic) error {
+	sorted := make([]Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			sorted = append(sorted, d)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	for _, d := range diags {
+		if d.Severity != SeverityError {
+			sorted = append(sorted, d)
+		}
+	}
+	return hcerrors.New(hcerrors.ErrValidation, "%w", &DiagnosticsError{Diagnostics: sorted}).
+		WithDetails(map[string]any{"field": sorted[0].Field, "code": sorted[0].Code, "diagnostics": sorted})
+}
+
+// Result holds the output of a Score-to-Stakater translation.
+type Result struct {
+	// WorkloadName is the name of the workload from score.yaml metadata.
+	WorkloadName string
+	// TargetCluster is the vCluster this workload targets.
+	TargetCluster string
+	// Namespace is the deployment namespace.
+	Namespace string
+	// Values is the Stakater Application chart values.yaml content.
+	Values map[string]interface{}
+	// AddonsEntry is the entry for workloads/<cluster>/addons.yaml.
+	AddonsEntry map[string]interface{}
+	// Files maps slash-separated paths, relative to the gitops repo root, to
+	// their content. values.yaml carries a provenance header (see
+	// StampGenerated).
+	Files map[string][]byte
+	// SecretRequirements lists the 1Password items and fields the generated
+	// ExternalSecrets depend on, sorted by item.
+	SecretRequirements []provisioners.SecretRequirement
+	// Requirements are the external preconditions provisioners declared,
+	// merged and sorted by kind and ref, for the deploy path to verify.
+	Requirements []provisioners.Requirement
+	// Diagnostics are non-fatal findings, in a stable order. They include
+	// an info note for each container given the resource policy defaults.
+	Diagnostics []Diagnostic
+	// ResourceExemption is set when the workload's resource-exemption
+	// annotation lifted the resource policy maximums, for deploy to record.
+	ResourceExemption *ResourceExemption
+	// InjectedEnv names the ${env.NAME} variables substituted into the
+	// workload when it was loaded, for deploy to record.
+	InjectedEnv []string
+	// SmokeTests are the x-hctl.smokeTests checks, against the route's
+	// host, for 'hctl deploy run' to make once the workload is up.
+	SmokeTests []SmokeTest
+	// Inventory lists every object the values render. Files holds it, at
+	// InventoryPath, too.
+	Inventory *Inventory
+}
+
+// ValuesPath returns the repo-relative path of a workload's values.yaml in
+// the active repo layout.
+func ValuesPath(cluster, workload string) string {
+	return layout.Active().ValuesFile(cluster, workload)
+}
+
+// secretRefRegex matches provisioner output patterns like $(secret-name:key).
+var secretRefRegex = regexp.MustCompile(`^\$\(([^:]+):([^)]+)\)$`)
+
+// configMapRefRegex matches provisioner output patterns like
+// $(configmap-name::key), for values the platform keeps in a ConfigMap.
+var configMapRefRegex = regexp.MustCompile(`^\$\(([^:]+)::([^:)]+)\)$`)
+
+// scoreVarRegex matches Score resource reference patterns like ${resources.db.host}.
+var scoreVarRegex = regexp.MustCompile(`\$\{resources\.([^.]+)\.([^}]+)\}`)
+
+// Translate converts a Score workload into platform resources.
+func Translate(workload *Workload, opts Options) (*Result, error) {
+	cluster := opts.Cluster
+	if cluster == "" {
+		cluster = workload.TargetCluster()
+	}
+	if cluster == "" {
+		cluster = opts.DefaultCluster
+	}
+	if cluster == "" {
+		return nil, hcerrors.NewUserError("no target cluster specified").
+			WithRemediation("use --cluster, set the hctl.integratn.tech/cluster annotation, or configure defaultCluster")
+	}
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = cluster // workload namespace defaults to cluster name
+		if ns, ok := workload.Metadata.Annotations["hctl.integratn.tech/namespace"]; ok && ns != "" {
+			namespace = ns
+		}
+	}
+
+	var policyDiags []Diagnostic
+	var exemption *ResourceExemption
+	if opts.ResourcePolicy != nil {
+		var err error
+		workload, policyDiags, exemption, err = applyResourcePolicy(workload, opts.ResourcePolicy.Rules(cluster), cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	place, err := parsePlacement(workload, opts.NodePoolLabel)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := parseShape(workload)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := parseContainers(workload, opts.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+	// A sidecar would keep a cronjob's runs from completing.
+	var agent *sidecar
+	if sh.cron == nil {
+		if agent, err = parseSidecar(workload, opts.Observability, cluster); err != nil {
+			return nil, err
+		}
+	}
+	alerting, err := parseAlerts(workload)
+	if err != nil {
+		return nil, err
+	}
+	smokeTests, err := parseSmokeTests(workload)
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseFiles(workload)
+	if err != nil {
+		return nil, err
+	}
+	if rbac := rbacNames(workload); len(rbac) > 1 {
+		return nil, hcerrors.New(hcerrors.ErrValidation, "%d rbac resources declared (%s); a workload has one ServiceAccount, so declare all rules in a single rbac resource",
+			len(rbac), strings.Join(rbac, ", ")).
+			WithDetails(map[string]string{"field": "resources"})
+	}
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = provisioners.NewRegistry()
+	}
+	chart := opts.Chart
+	if chart == (ChartConfig{}) {
+		chart = DefaultChart()
+	}
+
+	// Run provisioners for all resources concurrently, then assemble their
+	// results in name order so manifests and errors are deterministic.
+	resNames := make([]string, 0, len(workload.Resources))
+	for name := range workload.Resources {
+		resNames = append(resNames, name)
+	}
+	sort.Strings(resNames)
+	if err := diagnosticsError(unknownTypes(workload, resNames, registry)); err != nil {
+		return nil, err
+	}
+
+	log := opts.logger()
+	log.Debug("translating", "workload", workload.Metadata.Name, "cluster", cluster, "namespace", namespace, "resources", resNames)
+	pctx := provisioners.Context{Mode: opts.Mode, Workload: workload.Metadata.Name, Cluster: cluster, Environment: opts.Environments[cluster], SharedVolumes: opts.SharedVolumes, Logger: log}
+	provisioned, err := provisionAll(workload, resNames, registry, pctx, sh.isPerReplica, opts.slots(), opts.Cache, opts.OnProvision)
+	if err != nil {
+		return nil, err
+	}
+
+	allOutputs := make(map[string]map[string]string) // resource-name → key → value
+	var extraObjects []map[string]interface{}
+	sources := objectSources{}
+	var secretReqs []provisioners.SecretRequirement
+	var requirements []provisioners.Requirement
+	var envDiags []Diagnostic
+
+	for i, resName := range resNames {
+		if sh.isPerReplica(resName) {
+			// Rendered as a volumeClaimTemplate, not a shared PVC.
+			allOutputs[resName] = map[string]string{"source": resName}
+			continue
+		}
+		result := provisioned[i]
+		allOutputs[resName] = result.Outputs
+		secretReqs = append(secretReqs, result.SecretRequirements()...)
+		envDiags = append(envDiags, crossEnvironmentSecrets(resName, workload.Resources[resName], result.SecretRequirements(), cluster, opts.Environments)...)
+		requirements = append(requirements, result.Requirements...)
+
+		for _, m := range result.Manifests {
+			setNamespace(m, namespace)
+			sources.add(m, workload.Resources[resName].Type, resName)
+			extraObjects = append(extraObjects, m)
+		}
+	}
+
+	for _, m := range sh.scheduleObjects(workload.Metadata.Name) {
+		setNamespace(m, namespace)
+		sources.add(m, InventorySchedule, "")
+		extraObjects = append(extraObjects, m)
+	}
+	if m := alerting.rule(workload.Metadata.Name, cluster, namespace, sh.scaleTarget(workload.Metadata.Name)); m != nil {
+		setNamespace(m, namespace)
+		sources.add(m, InventoryAlerts, "")
+		extraObjects = append(extraObjects, m)
+	}
+
+	diags := append(append(append(append(policyDiags, pods.diags...), alerting.diags(workload)...), envDiags...), diagnose(workload, allOutputs, opts.Domain)...)
+	if err := diagnosticsError(diags); err != nil {
+		return nil, err
+	}
+
+	// Build Stakater values
+	values := buildStakaterValues(workload, allOutputs, namespace, extraObjects, sh, pods)
+	place.apply(values["deployment"].(map[string]interface{}))
+	if cm := files.apply(values["deployment"].(map[string]interface{}), pods, workload.Metadata.Name, namespace, allOutputs); cm != nil {
+		sources.add(cm, InventoryFiles, "")
+		extras, _ := values["extraObjects"].([]interface{})
+		values["extraObjects"] = append(extras, cm)
+	}
+	if cm := agent.apply(values["deployment"].(map[string]interface{}), workload.Metadata.Name, namespace); cm != nil {
+		sources.add(cm, InventoryObservability, "")
+		extras, _ := values["extraObjects"].([]interface{})
+		values["extraObjects"] = append(extras, cm)
+	}
+	pinContainers(workload, values["deployment"].(map[string]interface{}), pods.primary, opts.ImageDigests)
+	sh.apply(values, workload.Metadata.Name)
+	if err := applySets(values, opts.Set); err != nil {
+		return nil, err
+	}
+	ownership := OwnershipLabels(workload.Metadata.Name, cluster)
+	var provenance map[string]string
+	if opts.SourceRepo != "" {
+		provenance = map[string]string{SourceRepoAnnotation: opts.SourceRepo}
+	}
+	stampObjects(values, ownership, provenance, false)
+	// The references in noExpand files are meant to reach the cluster as
+	// written.
+	external := externalReferences(workload)
+	for _, ref := range files.literalReferences() {
+		external[ref] = true
+	}
+	if err := diagnosticsError(placeholderDiagnostics(values, external)); err != nil {
+		return nil, err
+	}
+
+	// Build addons.yaml entry
+	addonsEntry := map[string]interface{}{
+		"enabled":         true,
+		"namespace":       namespace,
+		"chartRepository": chart.Repository,
+		"chartName":       chart.Name,
+		"defaultVersion":  chart.Version,
+		"labelsApp":       ownership,
+	}
+	if sh.schedule != nil {
+		addonsEntry["ignoreDifferences"] = []interface{}{sh.scaleTarget(workload.Metadata.Name).ignoreReplicas()}
+	}
+
+	requirements = provisioners.SortRequirements(requirements)
+	secretReqs = declaredSecrets(secretReqs, requirements)
+	sort.SliceStable(secretReqs, func(i, j int) bool { return secretReqs[i].Item < secretReqs[j].Item })
+
+	result := &Result{
+		WorkloadName:       workload.Metadata.Name,
+		TargetCluster:      cluster,
+		Namespace:          namespace,
+		Values:             values,
+		AddonsEntry:        addonsEntry,
+		Files:              make(map[string][]byte),
+		SecretRequirements: secretReqs,
+		Requirements:       requirements,
+		Diagnostics:        diags,
+		ResourceExemption:  exemption,
+		InjectedEnv:        workload.InjectedEnv,
+		SmokeTests:         smokeTests,
+	}
+
+	valuesData, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling values: %w", err)
+	}
+	result.Files[ValuesPath(cluster, workload.Metadata.Name)] = StampGenerated(valuesData)
+
+	result.Inventory = buildInventory(values, sources, workload.Metadata.Name, cluster, namespace, opts.HctlVersion, valuesData)
+	inventoryData, err := result.Inventory.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	result.Files[InventoryPath(cluster, workload.Metadata.Name)] = inventoryData
+
+	return result, nil
+}
+
+// declaredSecrets adds the secret requirements provisioners declared without
+// rendering an ExternalSecret for them, so deploy checks those items too.
+func declaredSecrets(secretReqs []provisioners.SecretRequirement, requirements []provisioners.Requirement) []provisioners.SecretRequirement {
+	listed := make(map[string]bool, len(secretReqs))
+	for _, s := range secretReqs {
+		listed[s.Item] = true
+	}
+	for _, req := range requirements {
+		if req.Kind == provisioners.RequireSecret && !listed[req.Ref] {
+			listed[req.Ref] = true
+			secretReqs = append(secretReqs, provisioners.SecretRequirement{Item: req.Ref, Fields: req.Fields})
+		}
+	}
+	return secretReqs
+}
+
+// diagnose reports the workload's resource references that do not resolve,
+// as errors unless they are external, routes claiming a path another route
+// on the same host already serves, and constructs that translate but are
+// likely mistakes: routes to ports the service does not expose, route hosts
+// outside the platform domain, and opted-in cluster-wide wildcard RBAC.
+func diagnose(w *Workload, allOutputs map[string]map[string]string, domain string) []Diagnostic {
+	var diags []Diagnostic
+	external := externalReferences(w)
+
+	containerNames := make([]string, 0, len(w.Containers))
+	for name := range w.Containers {
+		containerNames = append(containerNames, name)
+	}
+	sort.Strings(containerNames)
+	for _, cname := range containerNames {
+		c := w.Containers[cname]
+		varNames := make([]string, 0, len(c.Variables))
+		for name := range c.Variables {
+			varNames = append(varNames, name)
+		}
+		sort.Strings(varNames)
+		for _, vname := range varNames {
+			field := fmt.Sprintf("containers.%s.variables.%s", cname, vname)
+			diags = append(diags, referenceDiagnostics(field, c.Variables[vname], allOutputs, external)...)
+		}
+		for _, p := range sortedKeys(c.Files) {
+			field := fmt.Sprintf("containers.%s.files.%s.content", cname, p)
+			diags = append(diags, fileDiagnostics(field, c.Files[p], allOutputs, external)...)
+		}
+	}
+
+	routes := routeNames(w)
+	diags = append(diags, routeConflicts(w)...)
+	for _, name := range routes {
+		diags = append(diags, routePortDiagnostics(w, name)...)
+	}
+	if domain != "" {
+		for _, name := range routes {
+			host, _ := w.Resources[name].Params["host"].(string)
+			if host != "" && host != domain && !strings.HasSuffix(host, "."+domain) {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     DiagRouteHost,
+					Field:    "resources." + name + ".params.host",
+					Message:  fmt.Sprintf("host %q is outside the platform domain %q", host, domain),
+				})
+			}
+		}
+	}
+
+	for _, name := range rbacNames(w) {
+		spec, err := provisioners.ParseRBAC(name, w.Resources[name])
+		if err != nil {
+			continue // already rejected by the provisioner
+		}
+		if wild := spec.ClusterWildcards(); len(wild) > 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagWildcardRBAC,
+				Field:    "resources." + name + ".params.rules",
+				Message:  fmt.Sprintf("cluster-wide wildcard access granted via allowWildcards (%s)", strings.Join(wild, "; ")),
+			})
+		}
+	}
+
+	return diags
+}
+
+// routePortDiagnostics reports the rules of the route the chart renders that
+// send traffic to the workload's own Service on a port it does not expose.
+func routePortDiagnostics(w *Workload, name string) []Diagnostic {
+	spec, err := provisioners.ParseRoute(name, w.Resources[name])
+	if err != nil || spec.Redirect != nil {
+		return nil // rejected by the provisioner, or not forwarded
+	}
+	ports := map[int]bool{}
+	var exposed []string
+	if w.Service != nil {
+		for _, pname := range sortedKeys(w.Service.Ports) {
+			ports[w.Service.Ports[pname].Port] = true
+			exposed = append(exposed, fmt.Sprintf("%s=%d", pname, w.Service.Ports[pname].Port))
+		}
+	}
+	if len(ports) == 0 {
+		return []Diagnostic{{
+			Severity: SeverityWarning,
+			Code:     DiagRouteWithoutService,
+			Field:    "resources." + name,
+			Message:  "route declared but the workload has no service ports; declare service.ports so the route has a backend",
+		}}
+	}
+	var diags []Diagnostic
+	for _, rule := range spec.Backends() {
+		if rule.Service != "" || ports[rule.Port] {
+			continue
+		}
+		msg := fmt.Sprintf("route sends %s to port %d, which the service does not expose (%s)", rule.Path, rule.Port, strings.Join(exposed, ", "))
+		if _, set := w.Resources[name].Params["port"]; !set && len(spec.PathRules) == 0 {
+			msg += "; params.port is unset and defaults to 8080"
+		}
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Code:     DiagRoutePort,
+			Field:    "resources." + name + ".params",
+			Message:  msg,
+		})
+	}
+	return diags
+}
+
+// clusterScopedKinds are the provisioner manifest kinds that take no
+// metadata.namespace.
+var clusterScopedKinds = map[string]bool{
+	"ClusterRole":        true,
+	"ClusterRoleBinding": true,
+}
+
+// setNamespace places a provisioner manifest in the workload namespace,
+// along with any ServiceAccount subjects of a binding that leave it unset.
+func setNamespace(m map[string]interface{}, namespace string) {
+	kind, _ := m["kind"].(string)
+	if meta, ok := m["metadata"].(map[string]interface{}); ok && !clusterScopedKinds[kind] {
+		if _, hasNs := meta["namespace"]; !hasNs {
+			meta["namespace"] = namespace
+		}
+	}
+	subjects, _ := m["subjects"].([]interface{})
+	for _, s := range subjects {
+		if subj, ok := s.(map[string]interface{}); ok && subj["kind"] == "ServiceAccount" {
+			if _, hasNs := subj["namespace"]; !hasNs {
+				subj["namespace"] = namespace
+			}
+		}
+	}
+}
+
+// rbacNames returns the names of the workload's rbac resources, sorted.
+func rbacNames(w *Workload) []string {
+	var names []string
+	for name, res := range w.Resources {
+		if res.Type == "rbac" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// routeSpecs returns the parsed params of the workload's route resources,
+// by name. Routes with invalid params are left out; the provisioner has
+// already rejected them.
+func routeSpecs(w *Workload) []*provisioners.RouteSpec {
+	var specs []*provisioners.RouteSpec
+	for _, name := range routeNames(w) {
+		if spec, err := provisioners.ParseRoute(name, w.Resources[name]); err == nil {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// routeConflicts reports routes that send the same path of a host as an
+// earlier route (by name). Routes on one host share the chart's HTTPRoute,
+// where only one of the two rules could match.
+func routeConflicts(w *Workload) []Diagnostic {
+	var diags []Diagnostic
+	owner := map[string]string{}
+	for _, spec := range routeSpecs(w) {
+		for _, b := range spec.Backends() {
+			key := spec.Host + " " + b.PathType + " " + b.Path
+			if other, ok := owner[key]; ok {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     DiagRouteConflict,
+					Field:    "resources." + spec.Name + ".params",
+					Message:  fmt.Sprintf("%s %s on %s is already routed by %q; give the routes different paths or hosts", b.PathType, b.Path, spec.Host, other),
+				})
+				continue
+			}
+			owner[key] = spec.Name
+		}
+	}
+	return diags
+}
+
+// routeNames returns the names of the workload's route resources, sorted.
+func routeNames(w *Workload) []string {
+	var names []string
+	for name, res := range w.Resources {
+		if res.Type == "route" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildStakaterValues creates the Stakater Application chart values.
+func buildStakaterValues(w *Workload, allOutputs map[string]map[string]string, namespace string, extraObjects []map[string]interface{}, sh *shape, pods *podLayout) map[string]interface{} {
+	values := map[string]interface{}{
+		"applicationName": w.Metadata.Name,
+	}
+
+	// --- Deployment section ---
+	deployment := map[string]interface{}{}
+
+	// The primary container is the chart's main one; other main containers
+	// are additional containers, in name order.
+	primaryContainer := w.Containers[pods.primary]
+	var additionalContainers []map[string]interface{}
+	for _, name := range pods.additional {
+		additionalContainers = append(additionalContainers, buildContainerSpec(name, w.Containers[name], allOutputs))
+	}
+
+	// Image
+	if primaryContainer.Image != "" && primaryContainer.Image != "." {
+		parts := strings.SplitN(primaryContainer.Image, ":", 2)
+		deployment["image"] = map[string]interface{}{
+			"repository": parts[0],
+		}
+		if len(parts) == 2 {
+			deployment["image"].(map[string]interface{})["tag"] = parts[1]
+		} else {
+			deployment["image"].(map[string]interface{})["tag"] = "latest"
+		}
+	}
+
+	// Ports from service
+	if w.Service != nil && len(w.Service.Ports) > 0 {
+		var ports []map[string]interface{}
+		portNames := make([]string, 0, len(w.Service.Ports))
+		for name := range w.Service.Ports {
+			portNames = append(portNames, name)
+		}
+		sort.Strings(portNames)
+
+		for _, name := range portNames {
+			p := w.Service.Ports[name]
+			port := map[string]interface{}{
+				"name":          name,
+				"containerPort": p.Port,
+				"protocol":      "TCP",
+			}
+			if p.Protocol != "" {
+				port["protocol"] = p.Protocol
+			}
+			ports = append(ports, port)
+		}
+		deployment["ports"] = ports
+	}
+
+	// Environment variables — resolve Score resource references
+	if len(primaryContainer.Variables) > 0 {
+		env := map[string]interface{}{}
+		varNames := make([]string, 0, len(primaryContainer.Variables))
+		for name := range primaryContainer.Variables {
+			varNames = append(varNames, name)
+		}
+		sort.Strings(varNames)
+
+		for _, name := range varNames {
+			val := primaryContainer.Variables[name]
+			resolved := resolveVariableValue(val, allOutputs)
+			env[name] = resolved
+		}
+		deployment["env"] = env
+	}
+
+	// Resources
+	if primaryContainer.Resources != nil {
+		deployment["resources"] = containerResources(primaryContainer.Resources)
+	}
+
+	// Volume mounts
+	if len(primaryContainer.Volumes) > 0 {
+		volumes := map[string]interface{}{}
+		volumeMounts := map[string]interface{}{}
+
+		volNames := make([]string, 0, len(primaryContainer.Volumes))
+		for name := range primaryContainer.Volumes {
+			volNames = append(volNames, name)
+		}
+		sort.Strings(volNames)
+
+		for _, name := range volNames {
+			vol := primaryContainer.Volumes[name]
+			mount := map[string]interface{}{
+				"mountPath": vol.Path,
+			}
+			if vol.ReadOnly {
+				mount["readOnly"] = true
+			}
+			if sh.isPerReplica(vol.Source) {
+				// The StatefulSet supplies the volume from its claim
+				// template, which the mount must be named after.
+				volumeMounts[vol.Source] = mount
+				continue
+			}
+			// source refers to a Score resource, resolve to PVC name
+			pvcName := vol.Source
+			if outputs, ok := allOutputs[vol.Source]; ok {
+				if src, ok := outputs["source"]; ok {
+					pvcName = src
+				}
+			}
+			volumes[name] = map[string]interface{}{
+				"persistentVolumeClaim": map[string]interface{}{
+					"claimName": pvcName,
+				},
+			}
+			volumeMounts[name] = mount
+		}
+		if len(volumes) > 0 {
+			deployment["volumes"] = volumes
+		}
+		deployment["volumeMounts"] = volumeMounts
+	}
+
+	// A CronJob runs the container's command rather than the image's
+	// long-running default.
+	if sh.cron != nil {
+		if len(primaryContainer.Command) > 0 {
+			deployment["command"] = primaryContainer.Command
+		}
+		if len(primaryContainer.Args) > 0 {
+			deployment["args"] = primaryContainer.Args
+		}
+	}
+
+	// Additional containers
+	if len(additionalContainers) > 0 {
+		deployment["additionalContainers"] = additionalContainers
+	}
+	pods.apply(deployment, w, allOutputs)
+
+	values["deployment"] = deployment
+
+	// --- Service section ---
+	// A cronjob serves no traffic, so its ports get no Service.
+	if w.Service != nil && len(w.Service.Ports) > 0 && sh.cron == nil {
+		var servicePorts []map[string]interface{}
+		portNames := make([]string, 0, len(w.Service.Ports))
+		for name := range w.Service.Ports {
+			portNames = append(portNames, name)
+		}
+		sort.Strings(portNames)
+
+		for _, name := range portNames {
+			p := w.Service.Ports[name]
+			sp := map[string]interface{}{
+				"name":       name,
+				"port":       p.Port,
+				"targetPort": p.Port,
+				"protocol":   "TCP",
+			}
+			if p.TargetPort > 0 {
+				sp["targetPort"] = p.TargetPort
+			}
+			if p.Protocol != "" {
+				sp["protocol"] = p.Protocol
+			}
+			servicePorts = append(servicePorts, sp)
+		}
+		values["service"] = map[string]interface{}{
+			"ports": servicePorts,
+		}
+	}
+
+	// --- Persistence (disabled, managed via extraObjects) ---
+	values["persistence"] = map[string]interface{}{
+		"enabled": false,
+	}
+
+	// --- HTTPRoute and Certificate from route resources ---
+	// The chart's HTTPRoute serves the host of the first route (by name)
+	// with the rules of every route on that host; routes on other hosts are
+	// served by their own provisioned HTTPRoutes. The certificate covers
+	// every host.
+	if routes := routeSpecs(w); len(routes) > 0 {
+		host := routes[0].Host
+		var rules []interface{}
+		var hosts []string
+		for _, spec := range routes {
+			if !slices.Contains(hosts, spec.Host) {
+				hosts = append(hosts, spec.Host)
+			}
+			if spec.Host == host {
+				rules = append(rules, spec.Rules(w.Metadata.Name)...)
+			}
+		}
+		values["httpRoute"] = map[string]interface{}{
+			"enabled": true,
+			"parentRefs": []map[string]interface{}{
+				{
+					"name":        "nginx-gateway",
+					"namespace":   "nginx-gateway",
+					"sectionName": "https-public",
+				},
+			},
+			"hostnames": []string{host},
+			"rules":     rules,
+		}
+
+		// Auto-generate certificate
+		values["certificate"] = map[string]interface{}{
+			"enabled":    true,
+			"secretName": w.Metadata.Name + "-tls",
+			"dnsNames":   hosts,
+			"commonName": host,
+			"usages":     []string{"digital signature", "key encipherment", "server auth"},
+			"issuerRef": map[string]interface{}{
+				"name": "letsencrypt-prod",
+				"kind": "ClusterIssuer",
+			},
+		}
+	}
+
+	// --- ServiceAccount from the rbac resource ---
+	// The ServiceAccount itself is an extraObject; the chart only needs its
+	// name to set the pods' serviceAccountName.
+	if rbac := rbacNames(w); len(rbac) > 0 {
+		if sa := allOutputs[rbac[0]]["serviceAccountName"]; sa != "" {
+			values["rbac"] = map[string]interface{}{
+				"serviceAccount": map[string]interface{}{
+					"enabled": false,
+					"name":    sa,
+				},
+			}
+		}
+	}
+
+	// --- Extra objects (provisioner manifests: ExternalSecrets, PVCs, RBAC) ---
+	if len(extraObjects) > 0 {
+		var extras []interface{}
+		for _, obj := range extraObjects {
+			extras = append(extras, obj)
+		}
+		values["extraObjects"] = extras
+	}
+
+	return values
+}
+
+// buildContainerSpec converts a Score container to a Stakater additional container spec.
+func buildContainerSpec(name string, c score.Container, allOutputs map[string]map[string]string) map[string]interface{} {
+	spec := map[string]interface{}{
+		"name":  name,
+		"image": c.Image,
+	}
+	if len(c.Command) > 0 {
+		spec["command"] = c.Command
+	}
+	if len(c.Args) > 0 {
+		spec["args"] = c.Args
+	}
+	if len(c.Variables) > 0 {
+		var envList []map[string]interface{}
+		varNames := make([]string, 0, len(c.Variables))
+		for name := range c.Variables {
+			varNames = append(varNames, name)
+		}
+		sort.Strings(varNames)
+		for _, name := range varNames {
+			val := c.Variables[name]
+			envEntry := map[string]interface{}{"name": name}
+			resolved := resolveVariableValue(val, allOutputs)
+			if valMap, ok := resolved.(map[string]interface{}); ok {
+				if vf, ok := valMap["valueFrom"]; ok {
+					envEntry["valueFrom"] = vf
+				} else if v, ok := valMap["value"]; ok {
+					envEntry["value"] = v
+				}
+			}
+			envList = append(envList, envEntry)
+		}
+		spec["env"] = envList
+	}
+	if c.Resources != nil {
+		spec["resources"] = containerResources(c.Resources)
+	}
+	return spec
+}
+
+// containerResources renders a container's requests and limits.
+func containerResources(r *score.ComputeResources) map[string]interface{} {
+	resources := map[string]interface{}{}
+	if r.Requests != nil {
+		resources["requests"] = r.Requests
+	}
+	if r.Limits != nil {
+		resources["limits"] = r.Limits
+	}
+	return resources
+}
@@ -0,0 +1,609 @@
+package translate_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners"
+	"github.com/jamesatintegratnio/hctl/pkg/provisioners/provisionerstest"
+	"github.com/jamesatintegratnio/hctl/pkg/score"
+	"github.com/jamesatintegratnio/hctl/pkg/translate"
+)
+
+const queueWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: worker
+  annotations:
+    hctl.integratn.tech/external-references: jobs.nope
+containers:
+  main:
+    image: ghcr.io/example/worker:1.2.3
+    variables:
+      QUEUE_URL: ${resources.jobs.url}
+      MISSING: ${resources.jobs.nope}
+resources:
+  jobs:
+    type: queue
+`
+
+// queueProvisioner is a custom resource type an embedding tool might add.
+type queueProvisioner struct{}
+
+func (queueProvisioner) Type() string { return "queue" }
+
+func (queueProvisioner) Provision(name string, _ score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	return &provisioners.ProvisionResult{
+		Outputs: map[string]string{"url": fmt.Sprintf("amqp://%s-%s", workload, name)},
+		Manifests: []map[string]interface{}{{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": workload + "-" + name},
+		}},
+	}, nil
+}
+
+func customRegistry() *provisioners.Registry {
+	r := provisioners.NewRegistry()
+	r.Register(queueProvisioner{})
+	return r
+}
+
+func TestTranslateCustomRegistry(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "media",
+		Registry: customRegistry(),
+		Chart:    translate.ChartConfig{Repository: "oci://charts.example.com", Name: "app", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+
+	if result.TargetCluster != "media" || result.Namespace != "media" {
+		t.Errorf("cluster/namespace = %s/%s, want media/media", result.TargetCluster, result.Namespace)
+	}
+	if result.AddonsEntry["chartRepository"] != "oci://charts.example.com" || result.AddonsEntry["defaultVersion"] != "1.0.0" {
+		t.Errorf("addons entry ignores chart options: %v", result.AddonsEntry)
+	}
+
+	env := result.Values["deployment"].(map[string]interface{})["env"].(map[string]interface{})
+	if got := env["QUEUE_URL"]; fmt.Sprint(got) != "map[value:amqp://worker-jobs]" {
+		t.Errorf("QUEUE_URL = %v", got)
+	}
+	extras := result.Values["extraObjects"].([]interface{})
+	meta := extras[0].(map[string]interface{})["metadata"].(map[string]interface{})
+	if meta["namespace"] != "media" {
+		t.Errorf("manifest namespace = %v, want media", meta["namespace"])
+	}
+
+	if _, ok := result.Files[translate.ValuesPath("media", "worker")]; !ok {
+		t.Errorf("files = %v, want %s", result.Files, translate.ValuesPath("media", "worker"))
+	}
+
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Field != "containers.main.variables.MISSING" ||
+		result.Diagnostics[0].Code != translate.DiagExternalReference {
+		t.Errorf("diagnostics = %v, want the external reference", result.Diagnostics)
+	}
+}
+
+func TestTranslateDefaultRegistryRejectsCustomType(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := translate.Translate(w, translate.Options{Cluster: "media"}); err == nil {
+		t.Error("expected error for unregistered resource type")
+	}
+}
+
+func TestTranslateDeterministic(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: api
+containers:
+  api:
+    image: api:1
+resources:
+  db:
+    type: postgres
+  cache:
+    type: redis
+  data:
+    type: volume
+`
+	var first []byte
+	for i := 0; i < 10; i++ {
+		w, err := translate.Load(strings.NewReader(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := translate.Translate(w, translate.Options{Cluster: "dev"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := result.Files[translate.ValuesPath("dev", "api")]
+		if first == nil {
+			first = data
+		} else if !bytes.Equal(first, data) {
+			t.Fatal("translation output differs between runs")
+		}
+	}
+}
+
+func TestTranslateClusterResolution(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: app
+  annotations:
+    hctl.integratn.tech/cluster: annotated
+containers:
+  app:
+    image: app:1
+`
+	tests := []struct {
+		name string
+		opts translate.Options
+		want string
+	}{
+		{"explicit wins", translate.Options{Cluster: "explicit", DefaultCluster: "fallback"}, "explicit"},
+		{"annotation before default", translate.Options{DefaultCluster: "fallback"}, "annotated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := translate.Load(strings.NewReader(spec))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := translate.Translate(w, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.TargetCluster != tt.want {
+				t.Errorf("TargetCluster = %q, want %q", result.TargetCluster, tt.want)
+			}
+		})
+	}
+
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := translate.Translate(w, translate.Options{Registry: customRegistry()}); err == nil {
+		t.Error("expected error when no cluster can be resolved")
+	}
+}
+
+func TestTranslateRouteDiagnostics(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  web:
+    image: web:1
+service:
+  ports:
+    http:
+      port: 8080
+resources:
+  public:
+    type: route
+    params:
+      host: web.example.org
+  internal:
+    type: route
+    params:
+      host: web.cluster.integratn.tech
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "dev", Domain: "cluster.integratn.tech"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostnames := result.Values["httpRoute"].(map[string]interface{})["hostnames"].([]string)
+	if hostnames[0] != "web.cluster.integratn.tech" {
+		t.Errorf("httpRoute host = %s, want the first route by name (internal)", hostnames[0])
+	}
+	var fields []string
+	for _, d := range result.Diagnostics {
+		fields = append(fields, d.Field)
+	}
+	if got := strings.Join(fields, ","); got != "resources.public.params.host" {
+		t.Errorf("diagnostic fields = %s", got)
+	}
+}
+
+func TestTranslateMultipleRoutes(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  web:
+    image: web:1
+service:
+  ports:
+    http:
+      port: 8080
+    admin:
+      port: 9090
+resources:
+  app:
+    type: route
+    params:
+      host: web.example.org
+  admin:
+    type: route
+    params:
+      host: web.example.org
+      path: /admin
+      port: 9090
+  internal:
+    type: route
+    params:
+      host: web.cluster.integratn.tech
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route := result.Values["httpRoute"].(map[string]interface{})
+	if got := fmt.Sprint(route["hostnames"]); got != "[web.example.org]" {
+		t.Errorf("httpRoute hostnames = %s, want the host of admin, the first route by name", got)
+	}
+	var rules []string
+	for _, r := range route["rules"].([]interface{}) {
+		rule := r.(map[string]interface{})
+		path := rule["matches"].([]interface{})[0].(map[string]interface{})["path"].(map[string]interface{})["value"]
+		port := rule["backendRefs"].([]interface{})[0].(map[string]interface{})["port"]
+		rules = append(rules, fmt.Sprintf("%v->%v", path, port))
+	}
+	if got := strings.Join(rules, ","); got != "/admin->9090,/->8080" {
+		t.Errorf("httpRoute rules = %s, want both routes on web.example.org with their own ports", got)
+	}
+
+	cert := result.Values["certificate"].(map[string]interface{})
+	if got := fmt.Sprint(cert["dnsNames"]); got != "[web.example.org web.cluster.integratn.tech]" {
+		t.Errorf("certificate dnsNames = %s, want every route host", got)
+	}
+
+	hosts := map[string]bool{}
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		if m["kind"] == "HTTPRoute" {
+			for _, h := range m["spec"].(map[string]interface{})["hostnames"].([]string) {
+				hosts[h] = true
+			}
+		}
+	}
+	if !hosts["web.example.org"] || !hosts["web.cluster.integratn.tech"] {
+		t.Errorf("provisioned HTTPRoute hosts = %v, want every route host", hosts)
+	}
+}
+
+func TestTranslateRouteConflict(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  web:
+    image: web:1
+service:
+  ports:
+    http:
+      port: 8080
+resources:
+  a:
+    type: route
+    params:
+      host: web.example.org
+  b:
+    type: route
+    params:
+      host: web.example.org
+      port: 8080
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = translate.Translate(w, translate.Options{Cluster: "dev"})
+	var diagErr *translate.DiagnosticsError
+	if !errors.As(err, &diagErr) || diagErr.Diagnostics[0].Code != translate.DiagRouteConflict || diagErr.Diagnostics[0].Field != "resources.b.params" {
+		t.Errorf("err = %v, want a route-conflict on resources.b.params", err)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	if _, err := translate.Load(strings.NewReader("apiVersion: score.dev/v1b1\nmetadata:\n  name: x\n")); err == nil {
+		t.Error("expected error for workload without containers")
+	}
+	if _, err := translate.Load(strings.NewReader("apiVersion: v2\n")); err == nil {
+		t.Error("expected error for unsupported apiVersion")
+	}
+}
+
+func TestTranslateRBAC(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: controller
+containers:
+  main:
+    image: ghcr.io/example/controller:1
+resources:
+  api:
+    type: rbac
+    params:
+      allowWildcards: true
+      rules:
+        - apiGroups: [platform.integratn.tech]
+          resources: [vclusterorchestratorv2s]
+          verbs: [get, list, watch]
+        - apiGroups: [""]
+          resources: ["*"]
+          verbs: [get]
+          scope: cluster
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", Namespace: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sa := result.Values["rbac"].(map[string]interface{})["serviceAccount"].(map[string]interface{})
+	if sa["name"] != "controller" || sa["enabled"] != false {
+		t.Errorf("rbac.serviceAccount = %v, want the provisioned ServiceAccount without a chart-created one", sa)
+	}
+
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		ns, hasNs := m["metadata"].(map[string]interface{})["namespace"]
+		switch m["kind"] {
+		case "ClusterRole", "ClusterRoleBinding":
+			if hasNs {
+				t.Errorf("%s should be cluster-scoped, got namespace %v", m["kind"], ns)
+			}
+		default:
+			if ns != "ops" {
+				t.Errorf("%s namespace = %v, want ops", m["kind"], ns)
+			}
+		}
+		if subjects, ok := m["subjects"].([]interface{}); ok {
+			if got := subjects[0].(map[string]interface{})["namespace"]; got != "ops" {
+				t.Errorf("%s subject namespace = %v, want ops", m["kind"], got)
+			}
+		}
+	}
+
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Field != "resources.api.params.rules" {
+		t.Errorf("Diagnostics = %v, want a cluster wildcard warning", result.Diagnostics)
+	}
+}
+
+func TestTranslateRBACRejectsWildcardsWithoutOptIn(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: controller
+containers:
+  main:
+    image: ghcr.io/example/controller:1
+resources:
+  api:
+    type: rbac
+    params:
+      rules:
+        - resources: [secrets]
+          verbs: ["*"]
+          scope: cluster
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := translate.Translate(w, translate.Options{Cluster: "media"}); err == nil || !strings.Contains(err.Error(), "allowWildcards") {
+		t.Errorf("err = %v, want wildcard guardrail", err)
+	}
+}
+
+func TestTranslateWithoutRBACLeavesServiceAccountUnset(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: customRegistry()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.Values["rbac"]; ok {
+		t.Error("rbac values should only be set when the workload declares an rbac resource")
+	}
+}
+
+func TestTranslateRouteFilters(t *testing.T) {
+	spec := `apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  web:
+    image: web:1
+resources:
+  www:
+    type: route
+    params:
+      host: www.example.org
+      redirectTo: https://example.org
+`
+	w, err := translate.Load(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The chart's HTTPRoute carries the same redirect as the provisioned one.
+	rule := result.Values["httpRoute"].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+	if _, ok := rule["backendRefs"]; ok {
+		t.Error("redirect rule should have no backendRefs")
+	}
+	filter := rule["filters"].([]interface{})[0].(map[string]interface{})
+	if filter["type"] != "RequestRedirect" {
+		t.Errorf("filter type = %v, want RequestRedirect", filter["type"])
+	}
+}
+
+const renderWorkload = `apiVersion: score.dev/v1b1
+metadata:
+  name: shop
+containers:
+  main:
+    image: ghcr.io/example/shop:2.0.0
+    variables:
+      DB_HOST: ${resources.db.host}
+      CACHE_HOST: ${resources.cache.host}
+resources:
+  db:
+    type: postgres
+  cache:
+    type: redis
+  data:
+    type: volume
+  dns:
+    type: dns
+  web:
+    type: route
+    params:
+      host: shop.example.com
+      port: 8080
+`
+
+func TestTranslateRenderModeHasNoSideEffects(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(renderWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := provisioners.NewRegistry()
+	sandbox := provisionerstest.GuardRender(t, registry)
+
+	result, err := translate.Translate(w, translate.Options{
+		Cluster:  "media",
+		Registry: registry,
+		Mode:     provisioners.ModeRender,
+	})
+	if err != nil {
+		t.Fatalf("Translate in render mode: %v", err)
+	}
+	if calls := sandbox.Calls(); len(calls) > 0 {
+		t.Errorf("network calls = %v", calls)
+	}
+
+	var got []string
+	for _, req := range result.Requirements {
+		got = append(got, fmt.Sprintf("%s:%s@%s", req.Kind, req.Ref, req.Resource))
+	}
+	want := "secret:shop-cache-redis@cache secret:shop-db-db@db"
+	if strings.Join(got, " ") != want {
+		t.Errorf("requirements = %v, want %s", got, want)
+	}
+}
+
+// vaultProvisioner declares the item it reads instead of looking it up.
+type vaultProvisioner struct{}
+
+func (vaultProvisioner) Type() string { return "queue" }
+
+func (p vaultProvisioner) Provision(name string, res score.Resource, workload string) (*provisioners.ProvisionResult, error) {
+	return p.ProvisionContext(provisioners.Context{Workload: workload}, name, res)
+}
+
+func (vaultProvisioner) ProvisionContext(ctx provisioners.Context, name string, _ score.Resource) (*provisioners.ProvisionResult, error) {
+	return &provisioners.ProvisionResult{
+		Outputs: map[string]string{"url": "amqp://" + ctx.Workload},
+		Requirements: []provisioners.Requirement{
+			{Kind: provisioners.RequireSecret, Ref: ctx.Workload + "-" + name, Fields: []string{"password"}},
+			{Kind: provisioners.RequireImage, Ref: "ghcr.io/example/broker:3.12"},
+		},
+	}, nil
+}
+
+func TestTranslateDeclaredRequirements(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(queueWorkload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := provisioners.NewRegistry()
+	registry.Register(vaultProvisioner{})
+
+	result, err := translate.Translate(w, translate.Options{Cluster: "media", Registry: registry, Mode: provisioners.ModeRender})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Requirements) != 2 || result.Requirements[0].Kind != provisioners.RequireImage || result.Requirements[1].Resource != "jobs" {
+		t.Errorf("requirements = %+v", result.Requirements)
+	}
+	if len(result.SecretRequirements) != 1 || result.SecretRequirements[0].Item != "worker-jobs" {
+		t.Errorf("secret requirements = %+v, want the declared worker-jobs item", result.SecretRequirements)
+	}
+}
+
+func TestTranslateMySQL(t *testing.T) {
+	w, err := translate.Load(strings.NewReader(`apiVersion: score.dev/v1b1
+metadata:
+  name: wiki
+containers:
+  wiki:
+    image: bookstack:1
+    variables:
+      DB_HOST: ${resources.db.host}
+      DB_DATABASE: ${resources.db.name}
+      DB_PASSWORD: ${resources.db.password}
+resources:
+  db:
+    type: mysql
+    class: shared
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := translate.Translate(w, translate.Options{Cluster: "dev", Environments: map[string]string{"dev": "dev"}, Mode: provisioners.ModeRender})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := result.Values["deployment"].(map[string]interface{})["env"].(map[string]interface{})
+	for name, key := range map[string]string{"DB_HOST": "host", "DB_DATABASE": "database", "DB_PASSWORD": "password"} {
+		if got, want := fmt.Sprint(env[name]), fmt.Sprintf("map[valueFrom:map[secretKeyRef:map[key:%s name:wiki-db-mysql]]]", key); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+	var found bool
+	for _, obj := range result.Values["extraObjects"].([]interface{}) {
+		m := obj.(map[string]interface{})
+		if m["kind"] == "ExternalSecret" && m["metadata"].(map[string]interface{})["name"] == "wiki-db-mysql" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("extraObjects = %v, want the wiki-db-mysql ExternalSecret", result.Values["extraObjects"])
+	}
+	if len(result.SecretRequirements) != 1 || result.SecretRequirements[0].Item != "shared-mysql-dev" {
+		t.Errorf("secret requirements = %+v, want shared-mysql-dev", result.SecretRequirements)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+const (
+	appTypeAddon    = "addon"
+	appTypeWorkload = "workload"
+	// otherApp is the app label of the series aggregating the apps of a
+	// cluster beyond metrics.appCardinalityBudget.
+	otherApp = "_other"
+)
+
+// appState is one ArgoCD Application's state for the per-app gauges.
+type appState struct {
+	cluster string
+	app     string
+	kind    string // appTypeAddon or appTypeWorkload
+	synced  bool
+	healthy bool
+}
+
+// appStateFrom converts an extracted ArgoCD status to an appState.
+func appStateFrom(status ArgoAppStatus, kind string) appState {
+	name := status.AddonName
+	if name == "" {
+		name = status.Name
+	}
+	return appState{
+		cluster: status.ClusterName,
+		app:     name,
+		kind:    kind,
+		synced:  status.SyncStatus == "Synced",
+		healthy: status.HealthStatus == "Healthy",
+	}
+}
+
+// appSeriesKey is the label set of one per-app series.
+type appSeriesKey struct {
+	cluster, app, kind string
+}
+
+// appSeries exports the per-app health and sync gauges. It remembers the
+// label sets it exported in the last cycle, so the series of apps that
+// have disappeared are deleted, and apps already exported keep their own
+// series when the budget is exceeded.
+type appSeries struct {
+	exported map[appSeriesKey]bool
+}
+
+func newAppSeries() *appSeries {
+	return &appSeries{exported: map[appSeriesKey]bool{}}
+}
+
+// publish sets the gauges from one cycle's states. Each cluster exports at
+// most budget apps by name: those exported last cycle first, then the rest
+// in name order. The excess is collapsed into one app="_other" series per
+// type, healthy or synced only when all of its apps are, and counted in
+// the overflow counter.
+func (s *appSeries) publish(states []appState, budget int) {
+	byCluster := map[string]map[appSeriesKey]appState{}
+	for _, st := range states {
+		key := appSeriesKey{cluster: st.cluster, app: st.app, kind: st.kind}
+		if byCluster[st.cluster] == nil {
+			byCluster[st.cluster] = map[appSeriesKey]appState{}
+		}
+		byCluster[st.cluster][key] = st
+	}
+
+	exported := map[appSeriesKey]bool{}
+	for cluster, apps := range byCluster {
+		keys := make([]appSeriesKey, 0, len(apps))
+		for key := range apps {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if s.exported[keys[i]] != s.exported[keys[j]] {
+				return s.exported[keys[i]]
+			}
+			if keys[i].app != keys[j].app {
+				return keys[i].app < keys[j].app
+			}
+			return keys[i].kind < keys[j].kind
+		})
+
+		others := map[string]*appState{}
+		for i, key := range keys {
+			st := apps[key]
+			if i < budget {
+				exported[key] = true
+				setAppGauges(key, st.synced, st.healthy)
+				continue
+			}
+			other := others[key.kind]
+			if other == nil {
+				other = &appState{synced: true, healthy: true}
+				others[key.kind] = other
+			}
+			other.synced = other.synced && st.synced
+			other.healthy = other.healthy && st.healthy
+		}
+		if overflow := len(keys) - budget; overflow > 0 {
+			log.Printf("  %s: %d apps over the metrics budget of %d, exported as app=%q", cluster, overflow, budget, otherApp)
+			appMetricsOverflow.WithLabelValues(cluster).Add(float64(overflow))
+		}
+		for kind, other := range others {
+			key := appSeriesKey{cluster: cluster, app: otherApp, kind: kind}
+			exported[key] = true
+			setAppGauges(key, other.synced, other.healthy)
+		}
+	}
+
+	for key := range s.exported {
+		if !exported[key] {
+			appArgoHealthy.DeleteLabelValues(key.cluster, key.app, key.kind)
+			appArgoSynced.DeleteLabelValues(key.cluster, key.app, key.kind)
+		}
+	}
+	s.exported = exported
+}
+
+func setAppGauges(key appSeriesKey, synced, healthy bool) {
+	appArgoSynced.WithLabelValues(key.cluster, key.app, key.kind).Set(boolGauge(synced))
+	appArgoHealthy.WithLabelValues(key.cluster, key.app, key.kind).Set(boolGauge(healthy))
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
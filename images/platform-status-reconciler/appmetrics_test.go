@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// scrapeApps gathers the default registry and returns the value of each
+// series of metric, keyed cluster/app/type.
+func scrapeApps(t *testing.T, metric string) map[string]float64 {
+	t.Helper()
+	RegisterMetrics()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	series := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != metric {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			series[labels["cluster"]+"/"+labels["app"]+"/"+labels["type"]] = m.GetGauge().GetValue()
+		}
+	}
+	return series
+}
+
+func resetAppMetrics() {
+	appArgoHealthy.Reset()
+	appArgoSynced.Reset()
+	appMetricsOverflow.Reset()
+}
+
+func TestAppSeriesLifecycle(t *testing.T) {
+	resetAppMetrics()
+	s := newAppSeries()
+
+	// Appear.
+	s.publish([]appState{
+		{cluster: "vcluster-media", app: "sonarr", kind: appTypeWorkload, synced: true, healthy: true},
+		{cluster: "vcluster-media", app: "radarr", kind: appTypeWorkload, synced: true},
+		{cluster: "the-cluster", app: "cert-manager", kind: appTypeAddon, synced: true, healthy: true},
+	}, 200)
+	want := map[string]float64{
+		"vcluster-media/sonarr/workload": 1,
+		"vcluster-media/radarr/workload": 0,
+		"the-cluster/cert-manager/addon": 1,
+	}
+	assertSeries(t, "appear", scrapeApps(t, "platform_argocd_app_health"), want)
+
+	// Update.
+	s.publish([]appState{
+		{cluster: "vcluster-media", app: "sonarr", kind: appTypeWorkload, synced: true, healthy: true},
+		{cluster: "vcluster-media", app: "radarr", kind: appTypeWorkload, synced: true, healthy: true},
+		{cluster: "the-cluster", app: "cert-manager", kind: appTypeAddon, synced: true, healthy: true},
+	}, 200)
+	want["vcluster-media/radarr/workload"] = 1
+	assertSeries(t, "update", scrapeApps(t, "platform_argocd_app_health"), want)
+
+	// Cleanup: radarr and the addon are gone.
+	s.publish([]appState{
+		{cluster: "vcluster-media", app: "sonarr", kind: appTypeWorkload, synced: false, healthy: true},
+	}, 200)
+	assertSeries(t, "cleanup", scrapeApps(t, "platform_argocd_app_health"), map[string]float64{"vcluster-media/sonarr/workload": 1})
+	assertSeries(t, "cleanup", scrapeApps(t, "platform_argocd_app_sync"), map[string]float64{"vcluster-media/sonarr/workload": 0})
+}
+
+func TestAppSeriesOverflow(t *testing.T) {
+	resetAppMetrics()
+	s := newAppSeries()
+
+	preview := func(n int, healthy bool) appState {
+		return appState{cluster: "vcluster-dev", app: fmt.Sprintf("preview-%d", n), kind: appTypeWorkload, synced: true, healthy: healthy}
+	}
+	s.publish([]appState{preview(3, true), preview(4, true)}, 2)
+
+	// New apps over the budget collapse; the apps already exported keep
+	// their series even though the new ones sort first.
+	s.publish([]appState{preview(1, true), preview(2, false), preview(3, true), preview(4, true)}, 2)
+	assertSeries(t, "overflow", scrapeApps(t, "platform_argocd_app_health"), map[string]float64{
+		"vcluster-dev/preview-3/workload": 1,
+		"vcluster-dev/preview-4/workload": 1,
+		"vcluster-dev/_other/workload":    0,
+	})
+	assertSeries(t, "overflow", scrapeApps(t, "platform_argocd_app_sync"), map[string]float64{
+		"vcluster-dev/preview-3/workload": 1,
+		"vcluster-dev/preview-4/workload": 1,
+		"vcluster-dev/_other/workload":    1,
+	})
+	if got := testutil.ToFloat64(appMetricsOverflow.WithLabelValues("vcluster-dev")); got != 2 {
+		t.Errorf("overflow counter = %v, want 2", got)
+	}
+
+	// Back under the budget, the aggregate is removed.
+	s.publish([]appState{preview(1, true), preview(3, true)}, 2)
+	assertSeries(t, "under budget", scrapeApps(t, "platform_argocd_app_health"), map[string]float64{
+		"vcluster-dev/preview-1/workload": 1,
+		"vcluster-dev/preview-3/workload": 1,
+	})
+	if got := testutil.ToFloat64(appMetricsOverflow.WithLabelValues("vcluster-dev")); got != 2 {
+		t.Errorf("overflow counter = %v, want still 2", got)
+	}
+}
+
+func TestRegisterMetricsIdempotent(t *testing.T) {
+	RegisterMetrics()
+	RegisterMetrics()
+}
+
+func assertSeries(t *testing.T, step string, got, want map[string]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: series = %v, want %v", step, got, want)
+		return
+	}
+	for k, v := range want {
+		if g, ok := got[k]; !ok || g != v {
+			t.Errorf("%s: series = %v, want %v", step, got, want)
+			return
+		}
+	}
+}
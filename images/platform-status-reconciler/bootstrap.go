@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// checkBootstrap builds the BootstrapApplied condition from the Job the
+// pipeline renders for spec.bootstrap.manifests, named in
+// status.bootstrapJob, and false when the vcluster has none. The Job
+// writes "<object>: <error>" as the termination message of a failed pod,
+// which a False condition repeats.
+func (r *Reconciler) checkBootstrap(ctx context.Context, vcr *unstructured.Unstructured, namespace string) (Condition, bool) {
+	name, _, _ := unstructured.NestedString(vcr.Object, "status", "bootstrapJob")
+	if name == "" {
+		return Condition{}, false
+	}
+	job, err := r.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return NewCondition(phase.ConditionBootstrapApplied, "Unknown", "JobPending",
+			fmt.Sprintf("Bootstrap Job %s has not been created yet", name)), true
+	}
+	if err != nil {
+		log.Printf("WARN: Failed to get bootstrap Job %s/%s: %v", namespace, name, err)
+		return NewCondition(phase.ConditionBootstrapApplied, "Unknown", "JobUnreadable",
+			fmt.Sprintf("Bootstrap Job %s could not be read", name)), true
+	}
+
+	switch {
+	case jobCondition(job, batchv1.JobComplete):
+		return NewCondition(phase.ConditionBootstrapApplied, "True", "Applied",
+			fmt.Sprintf("Bootstrap Job %s applied every manifest", name)), true
+	case jobCondition(job, batchv1.JobFailed):
+		msg := fmt.Sprintf("Bootstrap Job %s failed", name)
+		if failure := r.bootstrapFailure(ctx, job); failure != "" {
+			msg += ": " + failure
+		}
+		return NewCondition(phase.ConditionBootstrapApplied, "False", "ApplyFailed", msg), true
+	}
+	msg := fmt.Sprintf("Bootstrap Job %s is applying the manifests", name)
+	if job.Status.Failed > 0 {
+		msg = fmt.Sprintf("Bootstrap Job %s is retrying after %d failed attempt(s)", name, job.Status.Failed)
+		if failure := r.bootstrapFailure(ctx, job); failure != "" {
+			msg += "; last: " + failure
+		}
+	}
+	return NewCondition(phase.ConditionBootstrapApplied, "Unknown", "Applying", msg), true
+}
+
+// jobCondition reports whether job has condition t set True.
+func jobCondition(job *batchv1.Job, t batchv1.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == t && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// bootstrapFailure returns the termination message of the Job's most
+// recently failed container, empty when no pod has one.
+func (r *Reconciler) bootstrapFailure(ctx context.Context, job *batchv1.Job) string {
+	pods, err := r.clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + job.Name,
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to list pods of bootstrap Job %s/%s: %v", job.Namespace, job.Name, err)
+		return ""
+	}
+	var last *corev1.ContainerStateTerminated
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			t := cs.State.Terminated
+			if t == nil || t.ExitCode == 0 || t.Message == "" {
+				continue
+			}
+			if last == nil || last.FinishedAt.Before(&t.FinishedAt) {
+				last = t
+			}
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return strings.TrimSpace(last.Message)
+}
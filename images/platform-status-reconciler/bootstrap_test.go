@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// bootstrapVCR is a vcluster request whose pipeline rendered bootstrap Job
+// job, none when empty.
+func bootstrapVCR(job string) *unstructured.Unstructured {
+	vcr := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{}}}
+	if job != "" {
+		vcr.Object["status"].(map[string]interface{})["bootstrapJob"] = job
+	}
+	return vcr
+}
+
+func bootstrapJob(failed int32, conditions ...batchv1.JobConditionType) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "media-bootstrap-e332dc5a", Namespace: "vcluster-media"},
+		Status:     batchv1.JobStatus{Failed: failed},
+	}
+	for _, c := range conditions {
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: c, Status: corev1.ConditionTrue})
+	}
+	return job
+}
+
+// failedBootstrapPod is an attempt of the bootstrap Job that ended with
+// message, finished minutes after a fixed time.
+func failedBootstrapPod(name, message string, minutes int) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "vcluster-media",
+			Labels:    map[string]string{"job-name": "media-bootstrap-e332dc5a"},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name: "apply",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode:   1,
+				Message:    message,
+				FinishedAt: metav1.NewTime(time.Date(2026, 3, 1, 8, minutes, 0, 0, time.UTC)),
+			}},
+		}}},
+	}
+}
+
+func TestCheckBootstrap(t *testing.T) {
+	forbidden := "ClusterRoleBinding team-api-admins: Error from server (Forbidden): clusterrolebindings is forbidden\n"
+	tests := []struct {
+		name          string
+		vcr           *unstructured.Unstructured
+		objs          []runtime.Object
+		status        string
+		reason        string
+		message       string
+		wantCondition bool
+	}{
+		{name: "no bootstrap manifests", vcr: bootstrapVCR("")},
+		{
+			name: "job not synced yet", vcr: bootstrapVCR("media-bootstrap-e332dc5a"), wantCondition: true,
+			status: "Unknown", reason: "JobPending", message: "Bootstrap Job media-bootstrap-e332dc5a has not been created yet",
+		},
+		{
+			name: "applying", vcr: bootstrapVCR("media-bootstrap-e332dc5a"), wantCondition: true,
+			objs:   []runtime.Object{bootstrapJob(0)},
+			status: "Unknown", reason: "Applying", message: "Bootstrap Job media-bootstrap-e332dc5a is applying the manifests",
+		},
+		{
+			name: "retrying", vcr: bootstrapVCR("media-bootstrap-e332dc5a"), wantCondition: true,
+			objs:   []runtime.Object{bootstrapJob(1), failedBootstrapPod("a", "Namespace team-api: connection refused", 1)},
+			status: "Unknown", reason: "Applying",
+			message: "Bootstrap Job media-bootstrap-e332dc5a is retrying after 1 failed attempt(s); last: Namespace team-api: connection refused",
+		},
+		{
+			name: "applied", vcr: bootstrapVCR("media-bootstrap-e332dc5a"), wantCondition: true,
+			objs:   []runtime.Object{bootstrapJob(1, batchv1.JobComplete)},
+			status: "True", reason: "Applied", message: "Bootstrap Job media-bootstrap-e332dc5a applied every manifest",
+		},
+		{
+			name: "failed names the last failing object", vcr: bootstrapVCR("media-bootstrap-e332dc5a"), wantCondition: true,
+			objs: []runtime.Object{
+				bootstrapJob(4, batchv1.JobFailed),
+				failedBootstrapPod("b", forbidden, 9),
+				failedBootstrapPod("a", "Namespace team-api: connection refused", 1),
+			},
+			status: "False", reason: "ApplyFailed",
+			message: "Bootstrap Job media-bootstrap-e332dc5a failed: ClusterRoleBinding team-api-admins: Error from server (Forbidden): clusterrolebindings is forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReconciler(fake.NewSimpleClientset(tt.objs...), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+			c, ok := r.checkBootstrap(context.Background(), tt.vcr, "vcluster-media")
+			if ok != tt.wantCondition {
+				t.Fatalf("checkBootstrap reported a condition: %v, want %v (%+v)", ok, tt.wantCondition, c)
+			}
+			if !ok {
+				return
+			}
+			if c.Type != "BootstrapApplied" || c.Status != tt.status || c.Reason != tt.reason || c.Message != tt.message {
+				t.Errorf("condition = %s %s %s %q, want BootstrapApplied %s %s %q", c.Type, c.Status, c.Reason, c.Message, tt.status, tt.reason, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// defaultCertWarningWindow is how long before expiry CertificatesValid
+// flips to False. Override with CERT_WARNING_WINDOW.
+const defaultCertWarningWindow = 14 * 24 * time.Hour
+
+// certSource names one PEM certificate inside a Secret.
+type certSource struct {
+	Label  string // metric/condition name, e.g. "etcd-server"
+	Secret string
+	Key    string
+}
+
+// mergedEtcdKeys maps keys in the merged <name>-etcd-certs Secret to the
+// cert-manager Secret they were copied from.
+var mergedEtcdKeys = []struct {
+	Key, Source string
+}{
+	{"etcd-ca.crt", "etcd-ca"},
+	{"etcd-server.crt", "etcd-server"},
+	{"etcd-peer.crt", "etcd-peer"},
+}
+
+// kubeconfigCertSources returns the certificates embedded in the kubeconfig
+// Secret vcluster exports.
+func kubeconfigCertSources(secret string) []certSource {
+	return []certSource{
+		{Label: "kubeconfig-ca", Secret: secret, Key: "certificate-authority"},
+		{Label: "kubeconfig-client", Secret: secret, Key: "client-certificate"},
+	}
+}
+
+// apiServerCertSources returns the certificates vcluster generates for its
+// API server. The serving cert carries the SANs, so it drifts when those
+// change without a rotation.
+func apiServerCertSources(name string) []certSource {
+	secret := fmt.Sprintf("%s-certs", name)
+	return []certSource{
+		{Label: "apiserver", Secret: secret, Key: "apiserver.crt"},
+		{Label: "apiserver-ca", Secret: secret, Key: "ca.crt"},
+	}
+}
+
+// etcdCertSources returns the cert-manager issued etcd certificates and
+// the copies the merge Job wrote into <name>-etcd-certs, which is what
+// etcd actually mounts.
+func etcdCertSources(name string) []certSource {
+	var sources []certSource
+	for _, m := range mergedEtcdKeys {
+		sources = append(sources, certSource{Label: m.Source, Secret: fmt.Sprintf("%s-%s", name, m.Source), Key: "tls.crt"})
+	}
+	for _, m := range mergedEtcdKeys {
+		sources = append(sources, certSource{Label: "merged-" + m.Source, Secret: fmt.Sprintf("%s-etcd-certs", name), Key: m.Key})
+	}
+	return sources
+}
+
+// checkCertificates reads the vcluster's certificate Secrets from the target
+// namespace and records each certificate's expiry. etcd Secrets are only
+// expected when the spec deploys etcd; a missing one is reported. Other
+// Secrets are optional and skipped when absent.
+func (r *Reconciler) checkCertificates(ctx context.Context, vcr *unstructured.Unstructured, name, namespace, kubeconfigSecret string) CertificateHealth {
+	secrets := map[string]*corev1.Secret{}
+	var health CertificateHealth
+
+	get := func(secretName string, required bool) *corev1.Secret {
+		if s, ok := secrets[secretName]; ok {
+			return s
+		}
+		s, err := r.clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Printf("WARN: Failed to get secret %s/%s: %v", namespace, secretName, err)
+			} else if required {
+				health.Missing = append(health.Missing, secretName)
+			}
+			s = nil
+		}
+		secrets[secretName] = s
+		return s
+	}
+
+	read := func(sources []certSource, required bool) {
+		for _, src := range sources {
+			s := get(src.Secret, required)
+			if s == nil {
+				continue
+			}
+			cert, err := parseCertificate(s.Data[src.Key])
+			if err != nil {
+				if required || len(s.Data[src.Key]) > 0 {
+					health.Invalid = append(health.Invalid, fmt.Sprintf("%s (%s/%s): %v", src.Label, src.Secret, src.Key, err))
+				}
+				continue
+			}
+			health.Certificates = append(health.Certificates, CertExpiry{
+				Name:     src.Label,
+				Secret:   src.Secret,
+				NotAfter: cert.NotAfter.UTC(),
+			})
+		}
+	}
+
+	if kubeconfigSecret == "" {
+		kubeconfigSecret = fmt.Sprintf("vc-%s", name)
+	}
+	read(kubeconfigCertSources(kubeconfigSecret), false)
+	read(apiServerCertSources(name), false)
+
+	if etcdDeployed(vcr) {
+		read(etcdCertSources(name), true)
+		health.StaleMerged = staleMergedEtcdCerts(secrets, name)
+	}
+
+	if r.cfg.Probes.Certificates.ScanWorkloadTLS {
+		read(r.workloadTLSSources(ctx, namespace), false)
+	}
+
+	sort.Slice(health.Certificates, func(i, j int) bool {
+		return health.Certificates[i].NotAfter.Before(health.Certificates[j].NotAfter)
+	})
+	return health
+}
+
+// workloadTLSSources lists kubernetes.io/tls Secrets named *-tls* in the
+// namespace — the ingress certificates of workloads synced from the vcluster.
+func (r *Reconciler) workloadTLSSources(ctx context.Context, namespace string) []certSource {
+	list, err := r.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to list TLS secrets in %s: %v", namespace, err)
+		return nil
+	}
+	var sources []certSource
+	for _, s := range list.Items {
+		if s.Type != corev1.SecretTypeTLS || !strings.Contains(s.Name, "-tls") {
+			continue
+		}
+		sources = append(sources, certSource{Label: s.Name, Secret: s.Name, Key: corev1.TLSCertKey})
+	}
+	return sources
+}
+
+// staleMergedEtcdCerts compares the certificates in <name>-etcd-certs with
+// the cert-manager Secrets they were copied from. The merge Job only runs at
+// provision time, so once cert-manager renews a source the merged copy falls
+// behind and etcd keeps serving the old certificate until it expires.
+// Returns the merged keys that no longer match their source.
+func staleMergedEtcdCerts(secrets map[string]*corev1.Secret, name string) []string {
+	merged := secrets[fmt.Sprintf("%s-etcd-certs", name)]
+	if merged == nil {
+		return nil
+	}
+	var stale []string
+	for _, m := range mergedEtcdKeys {
+		source := secrets[fmt.Sprintf("%s-%s", name, m.Source)]
+		if source == nil {
+			continue
+		}
+		want, err := parseCertificate(source.Data[corev1.TLSCertKey])
+		if err != nil {
+			continue
+		}
+		got, err := parseCertificate(merged.Data[m.Key])
+		if err != nil || !got.Equal(want) {
+			stale = append(stale, m.Key)
+		}
+	}
+	return stale
+}
+
+// etcdDeployed reports whether the spec deploys a dedicated etcd, in which
+// case the pipeline generates the etcd certificate Secrets.
+func etcdDeployed(vcr *unstructured.Unstructured) bool {
+	enabled, _, _ := unstructured.NestedBool(vcr.Object, "spec", "vcluster", "backingStore", "etcd", "deploy", "enabled")
+	return enabled
+}
+
+// parseCertificate decodes the first PEM certificate in data.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no certificate data")
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// certificatesCondition builds the CertificatesValid condition. It is False
+// when any certificate has expired or expires within window, or when the
+// merged etcd Secret is stale; Unknown when expected Secrets are missing or
+// no certificates were found.
+func certificatesCondition(health CertificateHealth, now time.Time, window time.Duration) Condition {
+	soonest := ""
+	if len(health.Certificates) > 0 {
+		c := health.Certificates[0]
+		soonest = fmt.Sprintf("%s (%s) expires %s", c.Name, c.Secret, c.NotAfter.Format(time.RFC3339))
+	}
+
+	switch {
+	case len(health.Certificates) > 0 && !health.Certificates[0].NotAfter.After(now):
+		c := health.Certificates[0]
+		return NewCondition(phase.ConditionCertificatesValid, "False", "CertificateExpired",
+			fmt.Sprintf("%s (%s) expired at %s", c.Name, c.Secret, c.NotAfter.Format(time.RFC3339)))
+	case len(health.Certificates) > 0 && health.Certificates[0].NotAfter.Sub(now) < window:
+		c := health.Certificates[0]
+		return NewCondition(phase.ConditionCertificatesValid, "False", "CertificateExpiringSoon",
+			fmt.Sprintf("%s (%s) expires in %s at %s", c.Name, c.Secret, humanDuration(c.NotAfter.Sub(now)), c.NotAfter.Format(time.RFC3339)))
+	case len(health.StaleMerged) > 0:
+		return NewCondition(phase.ConditionCertificatesValid, "False", "EtcdMergedCertsStale",
+			fmt.Sprintf("merged etcd Secret is older than its sources (%s differ); re-run the etcd-certs-merge Job and restart etcd; soonest: %s",
+				strings.Join(health.StaleMerged, ", "), soonest))
+	case len(health.Invalid) > 0:
+		return NewCondition(phase.ConditionCertificatesValid, "False", "CertificateInvalid",
+			fmt.Sprintf("unparseable certificate: %s", strings.Join(health.Invalid, "; ")))
+	case len(health.Missing) > 0:
+		return NewCondition(phase.ConditionCertificatesValid, "Unknown", "CertificatesMissing",
+			fmt.Sprintf("certificate Secrets not found: %s", strings.Join(health.Missing, ", ")))
+	case len(health.Certificates) == 0:
+		return NewCondition(phase.ConditionCertificatesValid, "Unknown", "NoCertificates", "No certificate Secrets found")
+	}
+	return NewCondition(phase.ConditionCertificatesValid, "True", "CertificatesValid",
+		fmt.Sprintf("All %d certificates valid; soonest: %s", len(health.Certificates), soonest))
+}
+
+// humanDuration formats d as days, or hours when under a day.
+func humanDuration(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
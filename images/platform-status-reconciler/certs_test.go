@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var serial int64
+
+// makeCert returns a self-signed PEM certificate expiring in expiresIn.
+func makeCert(t *testing.T, cn string, expiresIn time.Duration) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(expiresIn),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func secret(name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "vcluster-media"},
+		Data:       data,
+	}
+}
+
+func makeEtcdVCR(etcd bool) *unstructured.Unstructured {
+	vcr := makeVCR("", time.Hour)
+	vcr.Object["spec"] = map[string]interface{}{
+		"vcluster": map[string]interface{}{
+			"backingStore": map[string]interface{}{
+				"etcd": map[string]interface{}{
+					"deploy": map[string]interface{}{"enabled": etcd},
+				},
+			},
+		},
+	}
+	return vcr
+}
+
+// etcdSecrets returns the cert-manager etcd Secrets and a merged Secret
+// built from them.
+func etcdSecrets(t *testing.T, expiresIn time.Duration) []runtime.Object {
+	ca := makeCert(t, "media-etcd-ca", 5*365*24*time.Hour)
+	server := makeCert(t, "media-etcd-server", expiresIn)
+	peer := makeCert(t, "media-etcd-peer", expiresIn)
+	return []runtime.Object{
+		secret("media-etcd-ca", map[string][]byte{"tls.crt": ca}),
+		secret("media-etcd-server", map[string][]byte{"tls.crt": server}),
+		secret("media-etcd-peer", map[string][]byte{"tls.crt": peer}),
+		secret("media-etcd-certs", map[string][]byte{
+			"etcd-ca.crt":     ca,
+			"etcd-server.crt": server,
+			"etcd-peer.crt":   peer,
+		}),
+	}
+}
+
+func TestCheckCertificates(t *testing.T) {
+	objs := append(etcdSecrets(t, 90*24*time.Hour),
+		secret("vc-media", map[string][]byte{
+			"certificate-authority": makeCert(t, "vcluster-ca", 10*365*24*time.Hour),
+			"client-certificate":    makeCert(t, "vcluster-admin", 30*24*time.Hour),
+		}),
+	)
+	r := NewReconciler(fake.NewSimpleClientset(objs...), nil)
+
+	health := r.checkCertificates(context.Background(), makeEtcdVCR(true), "media", "vcluster-media", "")
+	if len(health.Certificates) != 8 {
+		t.Fatalf("got %d certificates, want 8: %+v", len(health.Certificates), health.Certificates)
+	}
+	if got := health.Certificates[0]; got.Name != "kubeconfig-client" || got.Secret != "vc-media" {
+		t.Errorf("soonest = %+v, want kubeconfig-client from vc-media", got)
+	}
+	for i := 1; i < len(health.Certificates); i++ {
+		if health.Certificates[i].NotAfter.Before(health.Certificates[i-1].NotAfter) {
+			t.Errorf("certificates not sorted by expiry: %+v", health.Certificates)
+		}
+	}
+	if len(health.Missing) != 0 || len(health.StaleMerged) != 0 || len(health.Invalid) != 0 {
+		t.Errorf("unexpected problems: %+v", health)
+	}
+}
+
+func TestCheckCertificatesStaleMerged(t *testing.T) {
+	objs := etcdSecrets(t, 90*24*time.Hour)
+	// cert-manager renewed the server cert after the merge Job ran.
+	objs[1] = secret("media-etcd-server", map[string][]byte{"tls.crt": makeCert(t, "media-etcd-server", 90*24*time.Hour)})
+	r := NewReconciler(fake.NewSimpleClientset(objs...), nil)
+
+	health := r.checkCertificates(context.Background(), makeEtcdVCR(true), "media", "vcluster-media", "")
+	if len(health.StaleMerged) != 1 || health.StaleMerged[0] != "etcd-server.crt" {
+		t.Errorf("StaleMerged = %v, want [etcd-server.crt]", health.StaleMerged)
+	}
+}
+
+func TestCheckCertificatesMissingEtcd(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+
+	health := r.checkCertificates(context.Background(), makeEtcdVCR(true), "media", "vcluster-media", "")
+	if len(health.Missing) != 4 {
+		t.Errorf("Missing = %v, want the 4 etcd secrets", health.Missing)
+	}
+
+	health = r.checkCertificates(context.Background(), makeEtcdVCR(false), "media", "vcluster-media", "")
+	if len(health.Missing) != 0 {
+		t.Errorf("Missing = %v, want none without etcd", health.Missing)
+	}
+}
+
+func TestCheckCertificatesWorkloadTLS(t *testing.T) {
+	tls := secret("sonarr-tls-x-media-x-media", map[string][]byte{"tls.crt": makeCert(t, "sonarr", 20*24*time.Hour)})
+	tls.Type = corev1.SecretTypeTLS
+	r := NewReconciler(fake.NewSimpleClientset(tls), nil)
+
+	health := r.checkCertificates(context.Background(), makeEtcdVCR(false), "media", "vcluster-media", "")
+	if len(health.Certificates) != 0 {
+		t.Errorf("workload certs checked without scanWorkloadTLS: %+v", health.Certificates)
+	}
+
+	r.cfg.Probes.Certificates.ScanWorkloadTLS = true
+	health = r.checkCertificates(context.Background(), makeEtcdVCR(false), "media", "vcluster-media", "")
+	if len(health.Certificates) != 1 || health.Certificates[0].Name != tls.Name {
+		t.Errorf("Certificates = %+v, want %s", health.Certificates, tls.Name)
+	}
+}
+
+func TestCertificatesCondition(t *testing.T) {
+	now := time.Now()
+	window := 14 * 24 * time.Hour
+	certs := func(days ...int) []CertExpiry {
+		var out []CertExpiry
+		for i, d := range days {
+			out = append(out, CertExpiry{
+				Name:     []string{"etcd-server", "kubeconfig-client", "apiserver"}[i],
+				Secret:   "s",
+				NotAfter: now.Add(time.Duration(d) * 24 * time.Hour),
+			})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name        string
+		health      CertificateHealth
+		wantStatus  string
+		wantReason  string
+		wantMessage string
+	}{
+		{"all valid", CertificateHealth{Certificates: certs(60, 90, 365)}, "True", "CertificatesValid", "etcd-server"},
+		{"expiring soon", CertificateHealth{Certificates: certs(5, 90)}, "False", "CertificateExpiringSoon", "etcd-server (s) expires in 5d"},
+		{"just outside window", CertificateHealth{Certificates: certs(15)}, "True", "CertificatesValid", "etcd-server"},
+		{"expired", CertificateHealth{Certificates: certs(-1, 90)}, "False", "CertificateExpired", "etcd-server (s) expired"},
+		{"stale merged", CertificateHealth{Certificates: certs(60), StaleMerged: []string{"etcd-server.crt"}}, "False", "EtcdMergedCertsStale", "etcd-server.crt"},
+		{"invalid", CertificateHealth{Certificates: certs(60), Invalid: []string{"apiserver (x/y): bad"}}, "False", "CertificateInvalid", "apiserver"},
+		{"missing", CertificateHealth{Missing: []string{"media-etcd-ca"}}, "Unknown", "CertificatesMissing", "media-etcd-ca"},
+		{"none", CertificateHealth{}, "Unknown", "NoCertificates", ""},
+	}
+	for _, tt := range tests {
+		c := certificatesCondition(tt.health, now, window)
+		if c.Type != "CertificatesValid" || c.Status != tt.wantStatus || c.Reason != tt.wantReason {
+			t.Errorf("%s: got %s=%s (%s), want %s (%s)", tt.name, c.Type, c.Status, c.Reason, tt.wantStatus, tt.wantReason)
+		}
+		if !strings.Contains(c.Message, tt.wantMessage) {
+			t.Errorf("%s: message %q should contain %q", tt.name, c.Message, tt.wantMessage)
+		}
+	}
+}
+
+func TestParseCertificateSkipsNonCertBlocks(t *testing.T) {
+	data := append(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("x")}), makeCert(t, "leaf", time.Hour)...)
+	cert, err := parseCertificate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "leaf" {
+		t.Errorf("CommonName = %q, want leaf", cert.Subject.CommonName)
+	}
+	if _, err := parseCertificate([]byte("not pem")); err == nil {
+		t.Error("expected error for non-PEM data")
+	}
+}
+
+func TestUpdateMetricsCertExpiry(t *testing.T) {
+	defer vclusterCertExpiry.Reset()
+	notAfter := time.Date(2027, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &StatusResult{Health: Health{Certificates: CertificateHealth{
+		Certificates: []CertExpiry{{Name: "etcd-server", Secret: "media-etcd-server", NotAfter: notAfter}},
+	}}}
+	updateMetrics("media", "vcluster-media", result)
+	if got := testutil.ToFloat64(vclusterCertExpiry.WithLabelValues("media", "vcluster-media", "etcd-server")); got != float64(notAfter.Unix()) {
+		t.Errorf("expiry gauge = %v, want %d", got, notAfter.Unix())
+	}
+
+	// A cert that disappears drops its series.
+	result.Health.Certificates.Certificates = nil
+	updateMetrics("media", "vcluster-media", result)
+	if n := testutil.CollectAndCount(vclusterCertExpiry); n != 0 {
+		t.Errorf("got %d expiry series after certs removed, want 0", n)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// configMapName is the ConfigMap, in the reconciler's namespace, that
+	// holds its settings.
+	configMapName = "platform-status-reconciler-config"
+	// configMapKey is the ConfigMap key holding the YAML Config.
+	configMapKey = "config.yaml"
+)
+
+// Config is the reconciler's tunable behavior. It is read from the
+// config.yaml key of the platform-status-reconciler-config ConfigMap and
+// reloaded when the ConfigMap changes; fields the ConfigMap leaves out keep
+// their defaults. Environment variables override the ConfigMap:
+//
+//	RECONCILE_INTERVAL      interval
+//	STATUS_HEARTBEAT        statusHeartbeat
+//	CERT_WARNING_WINDOW     probes.certificates.warningWindow
+//	CERT_SCAN_WORKLOAD_TLS  probes.certificates.scanWorkloadTLS
+//
+// Leader election settings stay env-only; they cannot change while the
+// replica holds the Lease. So does CLEAR_VCLUSTER_FINALIZERS: removing
+// finalizers from tenant resources is a deploy-time decision, not one a
+// ConfigMap edit should make.
+type Config struct {
+	// Interval is the pause between reconcile cycles.
+	Interval metav1.Duration `json:"interval"`
+	// Namespaces limits the VClusterOrchestratorV2 namespaces reconciled;
+	// empty means all.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// StatusHeartbeat is how often an unchanged status is still patched to
+	// refresh lastReconciled.
+	StatusHeartbeat metav1.Duration `json:"statusHeartbeat"`
+	Thresholds      Thresholds      `json:"thresholds"`
+	Probes          Probes          `json:"probes"`
+	Features        Features        `json:"features"`
+	Metrics         MetricsConfig   `json:"metrics"`
+
+	// ClearVClusterFinalizers removes the vcluster's own finalizers from the
+	// resources holding up a target namespace that has been Terminating for
+	// longer than thresholds.finalizerGracePeriod. Env-only.
+	ClearVClusterFinalizers bool `json:"-"`
+}
+
+// Thresholds tune the phase computation.
+type Thresholds struct {
+	// FailedAfter is how old a degraded vcluster must be to report Failed.
+	FailedAfter metav1.Duration `json:"failedAfter"`
+	// DegradedReadyRatio is the fraction of ready pods below which a
+	// vcluster is degraded.
+	DegradedReadyRatio float64 `json:"degradedReadyRatio"`
+	// NamespaceStuckAfter is how long a target namespace may be Terminating
+	// before NamespaceStuck turns True.
+	NamespaceStuckAfter metav1.Duration `json:"namespaceStuckAfter"`
+	// FinalizerGracePeriod is how long a target namespace must have been
+	// Terminating before the vcluster's finalizers are cleared, when that
+	// is enabled.
+	FinalizerGracePeriod metav1.Duration `json:"finalizerGracePeriod"`
+}
+
+// Probes toggle and tune the optional health checks.
+type Probes struct {
+	// SubApps checks the ArgoCD Applications deployed into the vcluster.
+	SubApps      bool      `json:"subApps"`
+	Certificates CertProbe `json:"certificates"`
+	// WorkloadRepo checks the workloads ApplicationSet inside the vcluster
+	// for repository errors.
+	WorkloadRepo bool `json:"workloadRepo"`
+}
+
+// CertProbe configures the certificate expiry check.
+type CertProbe struct {
+	Enabled bool `json:"enabled"`
+	// WarningWindow is how long before expiry CertificatesValid turns False.
+	WarningWindow metav1.Duration `json:"warningWindow"`
+	// ScanWorkloadTLS also checks *-tls Secrets in the vcluster namespace.
+	ScanWorkloadTLS bool `json:"scanWorkloadTLS"`
+}
+
+// Features toggle the metrics exported besides the vcluster ones.
+type Features struct {
+	WorkloadMetrics bool `json:"workloadMetrics"`
+	AddonMetrics    bool `json:"addonMetrics"`
+}
+
+// MetricsConfig controls exported series.
+type MetricsConfig struct {
+	// Retention is how long the series of a vcluster that is no longer
+	// listed are kept; 0 keeps them until restart.
+	Retention metav1.Duration `json:"retention"`
+	// AppCardinalityBudget is how many apps per cluster get their own
+	// platform_argocd_app_* series; the rest share app="_other".
+	AppCardinalityBudget int `json:"appCardinalityBudget"`
+}
+
+// defaultConfig returns the settings used when neither the ConfigMap nor
+// the environment sets them.
+func defaultConfig() Config {
+	return Config{
+		Interval:        metav1.Duration{Duration: 60 * time.Second},
+		StatusHeartbeat: metav1.Duration{Duration: defaultStatusHeartbeat},
+		Thresholds: Thresholds{
+			FailedAfter:          metav1.Duration{Duration: 15 * time.Minute},
+			DegradedReadyRatio:   0.5,
+			NamespaceStuckAfter:  metav1.Duration{Duration: 10 * time.Minute},
+			FinalizerGracePeriod: metav1.Duration{Duration: 30 * time.Minute},
+		},
+		Probes: Probes{
+			SubApps:      true,
+			WorkloadRepo: true,
+			Certificates: CertProbe{
+				Enabled:       true,
+				WarningWindow: metav1.Duration{Duration: defaultCertWarningWindow},
+			},
+		},
+		Features: Features{WorkloadMetrics: true, AddonMetrics: true},
+		Metrics: MetricsConfig{
+			Retention:            metav1.Duration{Duration: time.Hour},
+			AppCardinalityBudget: 200,
+		},
+	}
+}
+
+// parseConfig reads a config.yaml document over the defaults. Unknown keys
+// are rejected so a typo does not silently keep a default.
+func parseConfig(data string) (Config, error) {
+	cfg := defaultConfig()
+	if strings.TrimSpace(data) == "" {
+		return cfg, nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(data), &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", configMapKey, err)
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides cfg with the environment variables that are set.
+// Invalid values are logged and ignored.
+func applyEnv(cfg *Config, getenv func(string) string) {
+	for _, d := range []struct {
+		key   string
+		field *metav1.Duration
+	}{
+		{"RECONCILE_INTERVAL", &cfg.Interval},
+		{"STATUS_HEARTBEAT", &cfg.StatusHeartbeat},
+		{"CERT_WARNING_WINDOW", &cfg.Probes.Certificates.WarningWindow},
+	} {
+		v := getenv(d.key)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("WARN: invalid %s=%q, ignoring it", d.key, v)
+			continue
+		}
+		d.field.Duration = parsed
+	}
+	for _, b := range []struct {
+		key   string
+		field *bool
+	}{
+		{"CERT_SCAN_WORKLOAD_TLS", &cfg.Probes.Certificates.ScanWorkloadTLS},
+		{"CLEAR_VCLUSTER_FINALIZERS", &cfg.ClearVClusterFinalizers},
+	} {
+		v := getenv(b.key)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("WARN: invalid %s=%q, ignoring it", b.key, v)
+			continue
+		}
+		*b.field = parsed
+	}
+}
+
+// validate reports every setting that is out of range.
+func (c Config) validate() error {
+	var problems []string
+	positive := func(name string, d metav1.Duration) {
+		if d.Duration <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive, got %s", name, d.Duration))
+		}
+	}
+	positive("interval", c.Interval)
+	positive("statusHeartbeat", c.StatusHeartbeat)
+	positive("thresholds.failedAfter", c.Thresholds.FailedAfter)
+	positive("thresholds.namespaceStuckAfter", c.Thresholds.NamespaceStuckAfter)
+	if c.Thresholds.FinalizerGracePeriod.Duration < c.Thresholds.NamespaceStuckAfter.Duration {
+		problems = append(problems, fmt.Sprintf("thresholds.finalizerGracePeriod (%s) must not be shorter than thresholds.namespaceStuckAfter (%s)",
+			c.Thresholds.FinalizerGracePeriod.Duration, c.Thresholds.NamespaceStuckAfter.Duration))
+	}
+	positive("probes.certificates.warningWindow", c.Probes.Certificates.WarningWindow)
+	if r := c.Thresholds.DegradedReadyRatio; r <= 0 || r > 1 {
+		problems = append(problems, fmt.Sprintf("thresholds.degradedReadyRatio must be in (0, 1], got %v", r))
+	}
+	if c.Metrics.Retention.Duration < 0 {
+		problems = append(problems, fmt.Sprintf("metrics.retention must not be negative, got %s", c.Metrics.Retention.Duration))
+	}
+	if c.Metrics.AppCardinalityBudget < 1 {
+		problems = append(problems, fmt.Sprintf("metrics.appCardinalityBudget must be at least 1, got %d", c.Metrics.AppCardinalityBudget))
+	}
+	for _, ns := range c.Namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("namespaces: %q %s", ns, strings.Join(errs, "; ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ConfigStore holds the active Config. A reload builds and validates the
+// new Config first and swaps it in under the lock, so a reconcile cycle
+// never sees a partial or invalid update.
+type ConfigStore struct {
+	getenv func(string) string
+
+	mu      sync.RWMutex
+	current Config
+}
+
+// NewConfigStore returns a store holding the defaults with the environment
+// overrides from getenv applied.
+func NewConfigStore(getenv func(string) string) *ConfigStore {
+	cfg := defaultConfig()
+	applyEnv(&cfg, getenv)
+	if err := cfg.validate(); err != nil {
+		log.Printf("WARN: environment overrides rejected (%v), using defaults", err)
+		cfg = defaultConfig()
+	}
+	return &ConfigStore{getenv: getenv, current: cfg}
+}
+
+// Get returns the active Config.
+func (s *ConfigStore) Get() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Load makes data, a config.yaml document, the active Config once the
+// environment overrides are applied and it validates. On error the
+// active Config is kept.
+func (s *ConfigStore) Load(data string) error {
+	cfg, err := parseConfig(data)
+	if err == nil {
+		applyEnv(&cfg, s.getenv)
+		err = cfg.validate()
+	}
+	if err != nil {
+		configReloads.WithLabelValues("rejected").Inc()
+		return err
+	}
+	s.mu.Lock()
+	s.current = cfg
+	s.mu.Unlock()
+	configReloads.WithLabelValues("applied").Inc()
+	return nil
+}
+
+// WatchConfigMap loads the config ConfigMap in namespace and reloads it on
+// every change until ctx is cancelled. It returns once the initial state
+// has been read, so the first reconcile cycle already uses it. A deleted
+// ConfigMap reverts to the defaults.
+func WatchConfigMap(ctx context.Context, clientset kubernetes.Interface, namespace string, store *ConfigStore) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", configMapName).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+
+	reload := func(obj interface{}) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok || cm.Name != configMapName {
+			return
+		}
+		if err := store.Load(cm.Data[configMapKey]); err != nil {
+			log.Printf("ERROR: ConfigMap %s/%s rejected, keeping the current config: %v", namespace, configMapName, err)
+			return
+		}
+		log.Printf("Loaded config from ConfigMap %s/%s (resourceVersion %s)", namespace, configMapName, cm.ResourceVersion)
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    reload,
+		UpdateFunc: func(_, obj interface{}) { reload(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tomb, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tomb.Obj
+			}
+			if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == configMapName {
+				log.Printf("ConfigMap %s/%s deleted, reverting to the default config", namespace, configMapName)
+				_ = store.Load("")
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("watching ConfigMap %s/%s: cache did not sync", namespace, configMapName)
+	}
+	return nil
+}
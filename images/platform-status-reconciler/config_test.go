@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func envMap(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		check   func(Config) bool
+		wantErr string
+	}{
+		{
+			name:  "empty keeps defaults",
+			data:  "",
+			check: func(c Config) bool { return c.Interval.Duration == time.Minute && c.Probes.Certificates.Enabled },
+		},
+		{
+			name: "partial document overrides only the set fields",
+			data: "interval: 2m\nthresholds:\n  failedAfter: 30m\nfeatures:\n  addonMetrics: false\n",
+			check: func(c Config) bool {
+				return c.Interval.Duration == 2*time.Minute && c.Thresholds.FailedAfter.Duration == 30*time.Minute &&
+					c.Thresholds.DegradedReadyRatio == 0.5 && !c.Features.AddonMetrics && c.Features.WorkloadMetrics
+			},
+		},
+		{
+			name:    "unknown key",
+			data:    "intervl: 2m\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "malformed duration",
+			data:    "interval: soon\n",
+			wantErr: "parsing config.yaml",
+		},
+		{
+			name:    "zero interval",
+			data:    "interval: 0s\n",
+			wantErr: "interval must be positive",
+		},
+		{
+			name:    "ratio out of range",
+			data:    "thresholds:\n  degradedReadyRatio: 1.5\n",
+			wantErr: "degradedReadyRatio",
+		},
+		{
+			name:    "negative retention",
+			data:    "metrics:\n  retention: -1h\n",
+			wantErr: "metrics.retention",
+		},
+		{
+			name:    "zero app budget",
+			data:    "metrics:\n  appCardinalityBudget: 0\n",
+			wantErr: "metrics.appCardinalityBudget",
+		},
+		{
+			name:    "invalid namespace",
+			data:    "namespaces: [Platform_Requests]\n",
+			wantErr: "namespaces",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(tt.data)
+			if err == nil {
+				err = cfg.validate()
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("unexpected config: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestConfigEnvOverridesConfigMap(t *testing.T) {
+	store := NewConfigStore(envMap(map[string]string{
+		"RECONCILE_INTERVAL":     "30s",
+		"CERT_SCAN_WORKLOAD_TLS": "true",
+		"STATUS_HEARTBEAT":       "bogus",
+	}))
+	if err := store.Load("interval: 2m\nstatusHeartbeat: 20m\nprobes:\n  certificates:\n    scanWorkloadTLS: false\n"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := store.Get()
+	if cfg.Interval.Duration != 30*time.Second {
+		t.Errorf("interval = %s, want the env value 30s", cfg.Interval.Duration)
+	}
+	if !cfg.Probes.Certificates.ScanWorkloadTLS {
+		t.Error("scanWorkloadTLS = false, want the env value true")
+	}
+	if cfg.StatusHeartbeat.Duration != 20*time.Minute {
+		t.Errorf("statusHeartbeat = %s, want the ConfigMap value 20m when the env value is invalid", cfg.StatusHeartbeat.Duration)
+	}
+}
+
+func TestConfigStoreRejectsInvalidUpdate(t *testing.T) {
+	store := NewConfigStore(envMap(nil))
+	if err := store.Load("interval: 2m\n"); err != nil {
+		t.Fatal(err)
+	}
+	rejected := testutil.ToFloat64(configReloads.WithLabelValues("rejected"))
+
+	if err := store.Load("interval: [2m\n"); err == nil {
+		t.Fatal("malformed YAML loaded")
+	}
+	if err := store.Load("interval: -2m\n"); err == nil {
+		t.Fatal("negative interval loaded")
+	}
+	if got := store.Get().Interval.Duration; got != 2*time.Minute {
+		t.Errorf("interval = %s after rejected updates, want 2m kept", got)
+	}
+	if got := testutil.ToFloat64(configReloads.WithLabelValues("rejected")) - rejected; got != 2 {
+		t.Errorf("config_reloads_total{result=rejected} rose by %v, want 2", got)
+	}
+}
+
+// eventually polls cond until it holds or a second passes.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func configMap(data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "platform-status-reconciler"},
+		Data:       map[string]string{configMapKey: data},
+	}
+}
+
+func TestWatchConfigMapReloads(t *testing.T) {
+	clientset := fake.NewSimpleClientset(configMap("interval: 2m\n"))
+	store := NewConfigStore(envMap(nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := WatchConfigMap(ctx, clientset, "platform-status-reconciler", store); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the initial ConfigMap", func() bool { return store.Get().Interval.Duration == 2*time.Minute })
+
+	cms := clientset.CoreV1().ConfigMaps("platform-status-reconciler")
+	rejected := testutil.ToFloat64(configReloads.WithLabelValues("rejected"))
+	if _, err := cms.Update(ctx, configMap("interval: 5m\nthresholds:\n  degradedReadyRatio: 2\n"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the invalid update to be rejected", func() bool {
+		return testutil.ToFloat64(configReloads.WithLabelValues("rejected")) > rejected
+	})
+	if got := store.Get().Interval.Duration; got != 2*time.Minute {
+		t.Errorf("interval = %s after an invalid update, want 2m kept", got)
+	}
+
+	if _, err := cms.Update(ctx, configMap("interval: 5m\n"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the valid update", func() bool { return store.Get().Interval.Duration == 5*time.Minute })
+
+	if err := cms.Delete(ctx, configMapName, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the defaults after deletion", func() bool { return store.Get().Interval.Duration == time.Minute })
+}
+
+// addonListings counts the ArgoCD Application lists issued for addon metrics.
+func addonListings(client *dynamicfake.FakeDynamicClient) int {
+	n := 0
+	for _, action := range client.Actions() {
+		list, ok := action.(k8stesting.ListAction)
+		if ok && list.GetResource() == argoAppGVR && list.GetListRestrictions().Labels.String() == "addon=true" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestConfigToggleAppliesNextCycle(t *testing.T) {
+	vcr := makeVCR("", time.Hour)
+	vcr.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	vcr.SetKind("VClusterOrchestratorV2")
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		vclusterGVR:   "VClusterOrchestratorV2List",
+		argoAppGVR:    "ApplicationList",
+		kratixWorkGVR: "WorkList",
+	}, vcr)
+	r := NewReconciler(fake.NewSimpleClientset(), dynClient)
+	ctx := context.Background()
+
+	r.ReconcileAll(ctx)
+	if got := addonListings(dynClient); got != 1 {
+		t.Fatalf("addon metrics listed %d times with the default config, want 1", got)
+	}
+
+	if err := r.config.Load("features:\n  addonMetrics: false\n"); err != nil {
+		t.Fatal(err)
+	}
+	r.ReconcileAll(ctx)
+	if got := addonListings(dynClient); got != 1 {
+		t.Errorf("addon metrics listed %d times after disabling them, want still 1", got)
+	}
+
+	if err := r.config.Load(""); err != nil {
+		t.Fatal(err)
+	}
+	r.ReconcileAll(ctx)
+	if got := addonListings(dynClient); got != 2 {
+		t.Errorf("addon metrics listed %d times after re-enabling them, want 2", got)
+	}
+}
+
+func TestComputePhaseThresholds(t *testing.T) {
+	result := &StatusResult{
+		Health: Health{
+			ArgoCD:    ArgoCDHealth{SyncStatus: "Synced", HealthStatus: "Healthy"},
+			Workloads: WorkloadHealth{Ready: 6, Total: 10},
+		},
+	}
+	vcr := makeVCR("", 30*time.Minute)
+	th := defaultConfig().Thresholds
+	if phase := computePhase(result, vcr, true, th); phase != "Progressing" {
+		t.Errorf("phase = %s with the default ratio, want Progressing", phase)
+	}
+	th.DegradedReadyRatio = 0.8
+	if phase := computePhase(result, vcr, true, th); phase != "Failed" {
+		t.Errorf("phase = %s with ratio 0.8 after failedAfter, want Failed", phase)
+	}
+	th.FailedAfter = metav1.Duration{Duration: time.Hour}
+	if phase := computePhase(result, vcr, true, th); phase != "Degraded" {
+		t.Errorf("phase = %s with ratio 0.8 within failedAfter, want Degraded", phase)
+	}
+}
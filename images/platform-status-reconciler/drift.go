@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SpecDrift is one field where the vcluster's ArgoCD Application no longer
+// carries what the orchestrator pipeline renders from the CR spec, usually
+// because someone edited the Application by hand.
+type SpecDrift struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (d SpecDrift) String() string {
+	return fmt.Sprintf("%s is %s, spec wants %s", d.Field, d.Actual, d.Expected)
+}
+
+// driftField maps a CR spec field to where the pipeline renders it in the
+// Application. values is the path under spec.source.helm.valuesObject, for
+// fields a spec.vcluster.helmOverrides entry at the same path overrides.
+type driftField struct {
+	name   string
+	spec   []string
+	source []string
+	values []string
+	// skipPaused leaves the field out for a paused vcluster, whose values
+	// the pipeline scales to zero.
+	skipPaused bool
+}
+
+// driftFields are the Application fields derivable from the CR spec. Only
+// fields the CR sets are compared: the pipeline's defaults for the rest
+// may change between pipeline versions without a spec change.
+var driftFields = []driftField{
+	{name: "chart", spec: []string{"spec", "argocdApplication", "chart"}, source: []string{"chart"}},
+	{name: "targetRevision", spec: []string{"spec", "argocdApplication", "targetRevision"}, source: []string{"targetRevision"}},
+	{name: "k8sVersion", spec: []string{"spec", "vcluster", "k8sVersion"}, values: []string{"controlPlane", "distro", "k8s", "version"}},
+	{name: "replicas", spec: []string{"spec", "vcluster", "replicas"}, values: []string{"controlPlane", "statefulSet", "highAvailability", "replicas"}, skipPaused: true},
+}
+
+// specDrift compares the fields of driftFields the CR sets with the live
+// Application, returning those that differ in driftFields order.
+func specDrift(vcr, app *unstructured.Unstructured) []SpecDrift {
+	paused := vcr.GetAnnotations()[pausedAnnotation] == "true"
+	var drift []SpecDrift
+	for _, f := range driftFields {
+		if f.skipPaused && paused {
+			continue
+		}
+		expected, ok := nestedScalar(vcr.Object, f.spec...)
+		if !ok {
+			continue
+		}
+		var actual string
+		var found bool
+		if f.values != nil {
+			override := append([]string{"spec", "vcluster", "helmOverrides"}, f.values...)
+			if v, ok := nestedScalar(vcr.Object, override...); ok {
+				expected = v
+			}
+			actual, found = nestedScalar(app.Object, append([]string{"spec", "source", "helm", "valuesObject"}, f.values...)...)
+		} else {
+			actual, found = nestedScalar(app.Object, append([]string{"spec", "source"}, f.source...)...)
+		}
+		if !found {
+			actual = "unset"
+		}
+		if actual != expected {
+			drift = append(drift, SpecDrift{Field: f.name, Expected: expected, Actual: actual})
+		}
+	}
+	return drift
+}
+
+// specDriftCondition reports drift between the CR spec and the vcluster's
+// Application. It is Unknown while the Application is missing or the
+// pipeline has not rendered the current generation yet, when a difference
+// is only the new spec on its way.
+func specDriftCondition(vcr, app *unstructured.Unstructured, drift []SpecDrift) Condition {
+	if app == nil {
+		return NewCondition(phase.ConditionSpecDrift, "Unknown", "ApplicationMissing", "The vcluster's ArgoCD Application was not found")
+	}
+	if observed, found, _ := unstructured.NestedFieldNoCopy(vcr.Object, "status", "observedGeneration"); found && jsonInt(observed) < vcr.GetGeneration() {
+		return NewCondition(phase.ConditionSpecDrift, "Unknown", "PipelinePending", "The pipeline has not rendered the current spec yet")
+	}
+	if len(drift) == 0 {
+		return NewCondition(phase.ConditionSpecDrift, "False", "InSync", "ArgoCD Application matches the CR spec")
+	}
+	parts := make([]string, len(drift))
+	for i, d := range drift {
+		parts[i] = d.String()
+	}
+	return NewCondition(phase.ConditionSpecDrift, "True", "ApplicationDrifted",
+		fmt.Sprintf("ArgoCD Application differs from the CR spec: %s", strings.Join(parts, "; ")))
+}
+
+// nestedScalar returns the string, number or bool at path as a string, so
+// values decoded as int64 and float64 compare equal.
+func nestedScalar(obj map[string]interface{}, path ...string) (string, bool) {
+	v, found, _ := unstructured.NestedFieldNoCopy(obj, path...)
+	if !found || v == nil {
+		return "", false
+	}
+	switch x := v.(type) {
+	case string:
+		return x, x != ""
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	}
+	return "", false
+}
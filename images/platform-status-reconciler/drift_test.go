@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// driftVCR is a vcluster CR pinning the chart version, k8s version and
+// replicas, as the orchestrator pipeline renders them.
+func driftVCR() *unstructured.Unstructured {
+	vcr := makeVCR("", 30*time.Minute)
+	vcr.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	vcr.SetKind("VClusterOrchestratorV2")
+	vcr.Object["spec"] = map[string]interface{}{
+		"argocdApplication": map[string]interface{}{"targetRevision": "0.30.4"},
+		"vcluster": map[string]interface{}{
+			"k8sVersion": "v1.34.3",
+			"replicas":   int64(3),
+		},
+	}
+	return vcr
+}
+
+// driftApp is the vcluster's Application as the pipeline renders it for
+// driftVCR, with the defaults the pipeline adds; edit modifies it.
+func driftApp(edit func(source map[string]interface{})) *unstructured.Unstructured {
+	source := map[string]interface{}{
+		"repoURL":        "https://charts.loft.sh",
+		"chart":          "vcluster",
+		"targetRevision": "0.30.4",
+		"helm": map[string]interface{}{
+			"releaseName": "test-vc",
+			"valuesObject": map[string]interface{}{
+				"controlPlane": map[string]interface{}{
+					"distro": map[string]interface{}{"k8s": map[string]interface{}{"enabled": true, "version": "v1.34.3"}},
+					"statefulSet": map[string]interface{}{
+						"highAvailability": map[string]interface{}{"replicas": int64(3)},
+						"imagePullPolicy":  "Always",
+					},
+					"coredns": map[string]interface{}{"deployment": map[string]interface{}{"replicas": int64(2)}},
+				},
+			},
+		},
+	}
+	if edit != nil {
+		edit(source)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": "vcluster-test-vc", "namespace": "argocd"},
+		"spec":       map[string]interface{}{"source": source},
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "Synced"},
+			"health": map[string]interface{}{"status": "Healthy"},
+		},
+	}}
+}
+
+func TestSpecDrift(t *testing.T) {
+	values := func(source map[string]interface{}) map[string]interface{} {
+		return source["helm"].(map[string]interface{})["valuesObject"].(map[string]interface{})["controlPlane"].(map[string]interface{})
+	}
+	tests := []struct {
+		name   string
+		vcr    func(*unstructured.Unstructured)
+		edit   func(source map[string]interface{})
+		want   []SpecDrift
+		reason string
+	}{
+		{
+			name:   "matching",
+			reason: "InSync",
+		},
+		{
+			name: "chart version edited",
+			edit: func(source map[string]interface{}) {
+				source["targetRevision"] = "0.31.0"
+			},
+			want:   []SpecDrift{{Field: "targetRevision", Expected: "0.30.4", Actual: "0.31.0"}},
+			reason: "ApplicationDrifted",
+		},
+		{
+			name: "values edited",
+			edit: func(source map[string]interface{}) {
+				cp := values(source)
+				cp["distro"].(map[string]interface{})["k8s"].(map[string]interface{})["version"] = "v1.33.1"
+				cp["statefulSet"].(map[string]interface{})["highAvailability"] = map[string]interface{}{"replicas": float64(1)}
+			},
+			want: []SpecDrift{
+				{Field: "k8sVersion", Expected: "v1.34.3", Actual: "v1.33.1"},
+				{Field: "replicas", Expected: "3", Actual: "1"},
+			},
+			reason: "ApplicationDrifted",
+		},
+		{
+			name: "values path removed",
+			edit: func(source map[string]interface{}) {
+				delete(values(source)["distro"].(map[string]interface{})["k8s"].(map[string]interface{}), "version")
+			},
+			want:   []SpecDrift{{Field: "k8sVersion", Expected: "v1.34.3", Actual: "unset"}},
+			reason: "ApplicationDrifted",
+		},
+		{
+			name: "spec fields left to pipeline defaults are not compared",
+			vcr: func(vcr *unstructured.Unstructured) {
+				vcr.Object["spec"] = map[string]interface{}{}
+			},
+			edit: func(source map[string]interface{}) {
+				source["targetRevision"] = "0.31.0"
+			},
+			reason: "InSync",
+		},
+		{
+			name: "helmOverrides win over the spec",
+			vcr: func(vcr *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(vcr.Object, int64(1), "spec", "vcluster", "helmOverrides", "controlPlane", "statefulSet", "highAvailability", "replicas")
+			},
+			edit: func(source map[string]interface{}) {
+				values(source)["statefulSet"].(map[string]interface{})["highAvailability"] = map[string]interface{}{"replicas": int64(1)}
+			},
+			reason: "InSync",
+		},
+		{
+			name: "paused replicas are not compared",
+			vcr: func(vcr *unstructured.Unstructured) {
+				vcr.SetAnnotations(map[string]string{pausedAnnotation: "true"})
+			},
+			edit: func(source map[string]interface{}) {
+				values(source)["statefulSet"].(map[string]interface{})["highAvailability"] = map[string]interface{}{"replicas": int64(0)}
+			},
+			reason: "InSync",
+		},
+		{
+			name: "pipeline has not rendered the new spec",
+			vcr: func(vcr *unstructured.Unstructured) {
+				vcr.SetGeneration(3)
+				vcr.Object["status"] = map[string]interface{}{"observedGeneration": int64(2)}
+			},
+			edit: func(source map[string]interface{}) {
+				source["targetRevision"] = "0.29.0"
+			},
+			want:   []SpecDrift{{Field: "targetRevision", Expected: "0.30.4", Actual: "0.29.0"}},
+			reason: "PipelinePending",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcr := driftVCR()
+			if tt.vcr != nil {
+				tt.vcr(vcr)
+			}
+			app := driftApp(tt.edit)
+			drift := specDrift(vcr, app)
+			if len(drift) != len(tt.want) {
+				t.Fatalf("specDrift = %v, want %v", drift, tt.want)
+			}
+			for i := range drift {
+				if drift[i] != tt.want[i] {
+					t.Errorf("specDrift[%d] = %+v, want %+v", i, drift[i], tt.want[i])
+				}
+			}
+			if c := specDriftCondition(vcr, app, drift); c.Reason != tt.reason {
+				t.Errorf("condition = %+v, want reason %s", c, tt.reason)
+			}
+		})
+	}
+
+	if c := specDriftCondition(driftVCR(), nil, nil); c.Status != "Unknown" || c.Reason != "ApplicationMissing" {
+		t.Errorf("no Application: condition = %+v", c)
+	}
+}
+
+func TestReconcileAllReportsSpecDrift(t *testing.T) {
+	vcr := driftVCR()
+	app := driftApp(func(source map[string]interface{}) {
+		source["targetRevision"] = "0.31.0"
+	})
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		vclusterGVR:   "VClusterOrchestratorV2List",
+		argoAppGVR:    "ApplicationList",
+		kratixWorkGVR: "WorkList",
+	}, vcr, app)
+	r := NewReconciler(fake.NewSimpleClientset(pod("api-0", true)), dynClient)
+	ctx := context.Background()
+
+	r.ReconcileAll(ctx)
+	got, err := dynClient.Resource(vclusterGVR).Namespace("platform-requests").Get(ctx, "test-vc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := findCondition(existingConditions(got), phase.ConditionSpecDrift)
+	if !ok || c.Status != "True" || !strings.Contains(c.Message, "targetRevision is 0.31.0, spec wants 0.30.4") {
+		t.Errorf("SpecDrift condition = %+v, found %v", c, ok)
+	}
+	if v := testutil.ToFloat64(vclusterSpecDrift.WithLabelValues("test-vc", "platform-requests")); v != 1 {
+		t.Errorf("spec_drift = %v, want 1", v)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// LeaderConfig controls Lease-based leader election between replicas.
+type LeaderConfig struct {
+	// Enabled turns leader election on. When off, the reconcile loop runs unconditionally.
+	Enabled bool
+	// Namespace holds the Lease object (the reconciler's own namespace).
+	Namespace string
+	// LeaseName is the name of the Lease object.
+	LeaseName string
+	// Identity uniquely identifies this replica (POD_NAME).
+	Identity string
+	// LeaseDuration is how long followers wait before taking over an unrenewed lease.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries renewing before giving up leadership.
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval between acquire/renew attempts.
+	RetryPeriod time.Duration
+}
+
+// leaderConfigFromEnv builds a LeaderConfig from environment variables:
+//
+//	LEADER_ELECTION       "false" disables election (default enabled)
+//	POD_NAMESPACE         Lease namespace (default: service account namespace)
+//	POD_NAME              replica identity (default: hostname)
+//	LEASE_NAME            Lease name (default platform-status-reconciler)
+//	LEASE_DURATION        default 15s
+//	LEASE_RENEW_DEADLINE  default 10s
+//	LEASE_RETRY_PERIOD    default 2s
+func leaderConfigFromEnv() LeaderConfig {
+	cfg := LeaderConfig{
+		Enabled:       os.Getenv("LEADER_ELECTION") != "false",
+		Namespace:     os.Getenv("POD_NAMESPACE"),
+		LeaseName:     envOr("LEASE_NAME", "platform-status-reconciler"),
+		Identity:      os.Getenv("POD_NAME"),
+		LeaseDuration: envDuration("LEASE_DURATION", 15*time.Second),
+		RenewDeadline: envDuration("LEASE_RENEW_DEADLINE", 10*time.Second),
+		RetryPeriod:   envDuration("LEASE_RETRY_PERIOD", 2*time.Second),
+	}
+	if cfg.Namespace == "" {
+		if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+			cfg.Namespace = strings.TrimSpace(string(data))
+		} else {
+			cfg.Namespace = "platform-status-reconciler"
+		}
+	}
+	if cfg.Identity == "" {
+		cfg.Identity, _ = os.Hostname()
+	}
+	return cfg
+}
+
+// runWithLeaderElection runs fn only while this replica holds the Lease.
+// fn receives a context that is cancelled the moment leadership is lost, so
+// an in-flight reconcile cycle stops between resources. After losing the
+// lease the replica rejoins as a candidate until ctx is done. The lease is
+// released on ctx cancellation so a successor takes over without waiting
+// for LeaseDuration to expire.
+func runWithLeaderElection(ctx context.Context, clientset kubernetes.Interface, cfg LeaderConfig, fn func(ctx context.Context)) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaseName,
+			Namespace: cfg.Namespace,
+		},
+		Client: clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: cfg.Identity,
+		},
+	}
+
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   cfg.LeaseDuration,
+			RenewDeadline:   cfg.RenewDeadline,
+			RetryPeriod:     cfg.RetryPeriod,
+			ReleaseOnCancel: true,
+			Name:            cfg.LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					log.Printf("Acquired leadership (%s/%s) as %s", cfg.Namespace, cfg.LeaseName, cfg.Identity)
+					reconcilerIsLeader.Set(1)
+					fn(leaderCtx)
+				},
+				OnStoppedLeading: func() {
+					log.Printf("Lost leadership as %s", cfg.Identity)
+					reconcilerIsLeader.Set(0)
+					// Followers must not export stale per-resource gauges
+					// alongside the new leader's.
+					resetResourceMetrics()
+				},
+				OnNewLeader: func(identity string) {
+					if identity != cfg.Identity {
+						log.Printf("Current leader: %s", identity)
+					}
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		elector.Run(ctx)
+	}
+	return nil
+}
+
+// runReconcileLoop runs ReconcileAll immediately and then after each
+// configured interval until ctx is cancelled. The interval is re-read after
+// every cycle, so a reloaded config changes it without a restart.
+func runReconcileLoop(ctx context.Context, reconciler *Reconciler) {
+	for {
+		reconciler.ReconcileAll(ctx)
+
+		timer := time.NewTimer(reconciler.config.Get().Interval.Duration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Reconcile loop stopped")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		log.Printf("WARN: invalid %s=%q, using %s", key, v, fallback)
+	}
+	return fallback
+}
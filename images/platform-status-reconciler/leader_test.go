@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testLeaderConfig(identity string) LeaderConfig {
+	return LeaderConfig{
+		Enabled:       true,
+		Namespace:     "platform-status-reconciler",
+		LeaseName:     "platform-status-reconciler",
+		Identity:      identity,
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: 1 * time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+}
+
+// candidate runs one replica's election loop and records when it leads.
+type candidate struct {
+	cancel  context.CancelFunc
+	started chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+func startCandidate(t *testing.T, clientset kubernetes.Interface, identity string) *candidate {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &candidate{
+		cancel:  cancel,
+		started: make(chan struct{}),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	var once sync.Once
+	go func() {
+		defer close(c.done)
+		err := runWithLeaderElection(ctx, clientset, testLeaderConfig(identity), func(leaderCtx context.Context) {
+			once.Do(func() { close(c.started) })
+			<-leaderCtx.Done()
+			close(c.stopped)
+		})
+		if err != nil {
+			t.Errorf("runWithLeaderElection(%s): %v", identity, err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-c.done
+	})
+	return c
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, timeout time.Duration, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(timeout):
+		t.Fatalf("timed out after %s waiting for %s", timeout, what)
+	}
+}
+
+func leaseHolder(t *testing.T, clientset kubernetes.Interface) (string, time.Time) {
+	t.Helper()
+	lease, err := clientset.CoordinationV1().Leases("platform-status-reconciler").
+		Get(context.Background(), "platform-status-reconciler", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting lease: %v", err)
+	}
+	var holder string
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	var renewed time.Time
+	if lease.Spec.RenewTime != nil {
+		renewed = lease.Spec.RenewTime.Time
+	}
+	return holder, renewed
+}
+
+func isLeaderGauge(t *testing.T) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := reconcilerIsLeader.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestLeaderElectionAcquire(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	a := startCandidate(t, clientset, "replica-a")
+	waitFor(t, a.started, 3*time.Second, "replica-a to lead")
+
+	if holder, _ := leaseHolder(t, clientset); holder != "replica-a" {
+		t.Errorf("lease holder = %q, want replica-a", holder)
+	}
+	if got := isLeaderGauge(t); got != 1 {
+		t.Errorf("is_leader gauge = %v, want 1", got)
+	}
+}
+
+func TestLeaderElectionRenew(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	a := startCandidate(t, clientset, "replica-a")
+	waitFor(t, a.started, 3*time.Second, "replica-a to lead")
+
+	_, first := leaseHolder(t, clientset)
+	time.Sleep(500 * time.Millisecond)
+	holder, renewed := leaseHolder(t, clientset)
+
+	if holder != "replica-a" {
+		t.Errorf("lease holder = %q, want replica-a", holder)
+	}
+	if !renewed.After(first) {
+		t.Errorf("lease not renewed: first=%s latest=%s", first, renewed)
+	}
+}
+
+func TestLeaderElectionHandover(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	a := startCandidate(t, clientset, "replica-a")
+	waitFor(t, a.started, 3*time.Second, "replica-a to lead")
+
+	b := startCandidate(t, clientset, "replica-b")
+	select {
+	case <-b.started:
+		t.Fatal("replica-b led while replica-a held the lease")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Cancelling the leader must stop its loop and release the lease, so the
+	// follower takes over well before LeaseDuration expires.
+	a.cancel()
+	waitFor(t, a.stopped, time.Second, "replica-a loop to stop")
+	waitFor(t, b.started, 1500*time.Millisecond, "replica-b to take over")
+
+	if holder, _ := leaseHolder(t, clientset); holder != "replica-b" {
+		t.Errorf("lease holder = %q, want replica-b", holder)
+	}
+}
+
+func TestReconcileAllStopsWhenContextCancelled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	vcr := makeVCR("Ready", time.Hour)
+	vcr.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	vcr.SetKind("VClusterOrchestratorV2")
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		vclusterGVR:   "VClusterOrchestratorV2List",
+		argoAppGVR:    "ApplicationList",
+		kratixWorkGVR: "WorkList",
+	}, vcr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewReconciler(fake.NewSimpleClientset(), dynClient)
+	r.ReconcileAll(ctx)
+
+	for _, action := range dynClient.Actions() {
+		if action.GetVerb() != "list" || action.GetResource() != vclusterGVR {
+			t.Errorf("unexpected %s %s after leadership loss", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
@@ -36,6 +36,7 @@ func main() {
 	}
 
 	reconciler := NewReconciler(clientset, dynClient)
+	reconciler.config = NewConfigStore(os.Getenv)
 
 	// Register Prometheus metrics
 	RegisterMetrics()
@@ -66,38 +67,40 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle shutdown signals
+	// Handle shutdown signals. Cancelling ctx stops the reconcile loop and
+	// releases the leader Lease so another replica takes over promptly.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
 		log.Println("Received shutdown signal")
 		cancel()
-		server.Shutdown(context.Background())
 	}()
 
-	interval := 60 * time.Second
-	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			interval = d
-		}
-	}
-
-	log.Printf("Reconcile interval: %s", interval)
-
-	// Initial reconcile
-	reconciler.ReconcileAll(ctx)
+	leaderCfg := leaderConfigFromEnv()
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Shutting down")
-			return
-		case <-ticker.C:
-			reconciler.ReconcileAll(ctx)
+	// Settings come from the config ConfigMap, overridden by env vars, and
+	// are reloaded whenever the ConfigMap changes.
+	if err := WatchConfigMap(ctx, clientset, leaderCfg.Namespace, reconciler.config); err != nil {
+		log.Printf("WARN: %v; using the default config", err)
+	}
+	log.Printf("Reconcile interval: %s", reconciler.config.Get().Interval.Duration)
+
+	if leaderCfg.Enabled {
+		log.Printf("Leader election enabled: lease %s/%s, identity %s", leaderCfg.Namespace, leaderCfg.LeaseName, leaderCfg.Identity)
+		if err := runWithLeaderElection(ctx, clientset, leaderCfg, func(leaderCtx context.Context) {
+			runReconcileLoop(leaderCtx, reconciler)
+		}); err != nil {
+			log.Fatalf("Leader election: %v", err)
 		}
+	} else {
+		log.Println("Leader election disabled")
+		reconcilerIsLeader.Set(1)
+		runReconcileLoop(ctx, reconciler)
 	}
+
+	log.Println("Shutting down")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	server.Shutdown(shutdownCtx)
 }
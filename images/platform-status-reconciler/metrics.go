@@ -1,6 +1,9 @@
 package main
 
 import (
+	"sync"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -61,6 +64,43 @@ var (
 		Help:      "Total sub-apps for vcluster",
 	}, []string{"name", "namespace"})
 
+	vclusterCertExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "vcluster",
+		Name:      "certificate_expiry_timestamp_seconds",
+		Help:      "Unix time at which a vcluster certificate expires (notAfter)",
+	}, []string{"name", "namespace", "cert"})
+
+	vclusterEtcdMergedStale = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "vcluster",
+		Name:      "etcd_merged_certs_stale",
+		Help:      "Whether the merged etcd cert Secret no longer matches its cert-manager sources (1=stale, 0=not)",
+	}, []string{"name", "namespace"})
+
+	vclusterSpecDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "vcluster",
+		Name:      "spec_drift",
+		Help:      "Number of CR spec fields the vcluster's ArgoCD Application no longer matches (0=in sync)",
+	}, []string{"name", "namespace"})
+
+	vclusterTimeToReady = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "platform",
+		Subsystem: "vcluster",
+		Name:      "time_to_ready_seconds",
+		Help:      "Time from a vcluster request (or spec change) to its first Ready phase",
+		Buckets:   []float64{60, 120, 180, 300, 450, 600, 900, 1200, 1800, 3600},
+	})
+
+	vclusterRecoveryDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "platform",
+		Subsystem: "vcluster",
+		Name:      "recovery_duration_seconds",
+		Help:      "Time a provisioned vcluster spent out of Ready before it recovered",
+		Buckets:   []float64{60, 120, 300, 600, 900, 1800, 3600, 7200, 21600},
+	})
+
 	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "platform",
 		Subsystem: "status_reconciler",
@@ -83,6 +123,34 @@ var (
 		Help:      "Total number of reconcile cycles completed",
 	})
 
+	reconcileSkippedNoChange = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "platform",
+		Subsystem: "status_reconciler",
+		Name:      "reconcile_skipped_nochange_total",
+		Help:      "Total vcluster status patches skipped because the status was unchanged",
+	})
+
+	reconcilerIsLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "status_reconciler",
+		Name:      "is_leader",
+		Help:      "Whether this replica holds the leader Lease (1=leader, 0=follower)",
+	})
+
+	configReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "platform",
+		Subsystem: "status_reconciler",
+		Name:      "config_reloads_total",
+		Help:      "Config loads from the ConfigMap by result (applied, rejected)",
+	}, []string{"result"})
+
+	finalizersCleared = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "platform",
+		Subsystem: "status_reconciler",
+		Name:      "finalizers_cleared_total",
+		Help:      "Total vcluster finalizers cleared from resources in namespaces stuck Terminating",
+	})
+
 	// --- Workload metrics ---
 
 	workloadPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -128,10 +196,40 @@ var (
 		Name:      "argocd_healthy",
 		Help:      "Whether addon ArgoCD app is healthy (1=healthy, 0=not)",
 	}, []string{"name", "cluster", "environment", "namespace"})
+
+	// --- Per-app metrics, bounded by metrics.appCardinalityBudget ---
+
+	appArgoHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "argocd_app",
+		Name:      "health",
+		Help:      "Whether an addon or workload ArgoCD app is healthy (1=healthy, 0=not); app=\"_other\" is 1 only when every app over the budget is",
+	}, []string{"cluster", "app", "type"})
+
+	appArgoSynced = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "platform",
+		Subsystem: "argocd_app",
+		Name:      "sync",
+		Help:      "Whether an addon or workload ArgoCD app is synced (1=synced, 0=not); app=\"_other\" is 1 only when every app over the budget is",
+	}, []string{"cluster", "app", "type"})
+
+	appMetricsOverflow = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "platform",
+		Subsystem: "status_reconciler",
+		Name:      "app_metrics_overflow_total",
+		Help:      "Apps exported as app=\"_other\" because their cluster exceeded the per-app metrics budget, summed over cycles",
+	}, []string{"cluster"})
 )
 
-// RegisterMetrics registers all Prometheus metrics.
+var registerOnce sync.Once
+
+// RegisterMetrics registers all Prometheus metrics. Calls after the first
+// do nothing.
 func RegisterMetrics() {
+	registerOnce.Do(registerMetrics)
+}
+
+func registerMetrics() {
 	prometheus.MustRegister(
 		vclusterPhase,
 		vclusterReady,
@@ -141,25 +239,82 @@ func RegisterMetrics() {
 		vclusterArgoHealthy,
 		vclusterSubAppsHealthy,
 		vclusterSubAppsTotal,
+		vclusterCertExpiry,
+		vclusterEtcdMergedStale,
+		vclusterSpecDrift,
+		vclusterTimeToReady,
+		vclusterRecoveryDuration,
 		reconcileDuration,
 		reconcileErrors,
 		reconcileTotal,
+		reconcileSkippedNoChange,
+		reconcilerIsLeader,
+		configReloads,
+		finalizersCleared,
 		workloadPhase,
 		workloadArgoSynced,
 		workloadArgoHealthy,
 		addonPhase,
 		addonArgoSynced,
 		addonArgoHealthy,
+		appArgoHealthy,
+		appArgoSynced,
+		appMetricsOverflow,
 	)
 }
 
-// allPhases used for resetting phase gauge (only one phase should be 1 at a time).
-var allPhases = []string{"Scheduled", "Progressing", "Ready", "Degraded", "Failed", "Deleting", "Unknown"}
+// resetResourceMetrics clears all per-vcluster, workload, and addon gauges.
+// Called when leadership is lost so only the leader exports resource state.
+func resetResourceMetrics() {
+	for _, g := range []*prometheus.GaugeVec{
+		vclusterPhase,
+		vclusterReady,
+		vclusterPodsReady,
+		vclusterPodsTotal,
+		vclusterArgoSynced,
+		vclusterArgoHealthy,
+		vclusterSubAppsHealthy,
+		vclusterSubAppsTotal,
+		vclusterCertExpiry,
+		vclusterEtcdMergedStale,
+		vclusterSpecDrift,
+		workloadPhase,
+		workloadArgoSynced,
+		workloadArgoHealthy,
+		addonPhase,
+		addonArgoSynced,
+		addonArgoHealthy,
+		appArgoHealthy,
+		appArgoSynced,
+	} {
+		g.Reset()
+	}
+}
+
+// deleteVClusterMetrics drops every series of one vcluster.
+func deleteVClusterMetrics(name, namespace string) {
+	labels := prometheus.Labels{"name": name, "namespace": namespace}
+	for _, g := range []*prometheus.GaugeVec{
+		vclusterPhase,
+		vclusterReady,
+		vclusterPodsReady,
+		vclusterPodsTotal,
+		vclusterArgoSynced,
+		vclusterArgoHealthy,
+		vclusterSubAppsHealthy,
+		vclusterSubAppsTotal,
+		vclusterCertExpiry,
+		vclusterEtcdMergedStale,
+		vclusterSpecDrift,
+	} {
+		g.DeletePartialMatch(labels)
+	}
+}
 
 // updateMetrics sets Prometheus gauges for a reconciled vcluster.
 func updateMetrics(name, namespace string, result *StatusResult) {
 	// Phase — set active phase to 1, all others to 0
-	for _, p := range allPhases {
+	for _, p := range phase.All {
 		val := float64(0)
 		if p == result.Phase {
 			val = 1
@@ -169,7 +324,7 @@ func updateMetrics(name, namespace string, result *StatusResult) {
 
 	// Ready boolean
 	ready := float64(0)
-	if result.Phase == "Ready" {
+	if result.Phase == phase.Ready {
 		ready = 1
 	}
 	vclusterReady.WithLabelValues(name, namespace).Set(ready)
@@ -194,10 +349,22 @@ func updateMetrics(name, namespace string, result *StatusResult) {
 	// Sub-app counts
 	vclusterSubAppsHealthy.WithLabelValues(name, namespace).Set(float64(result.Health.SubApps.Healthy))
 	vclusterSubAppsTotal.WithLabelValues(name, namespace).Set(float64(result.Health.SubApps.Total))
+
+	// Certificate expiry — drop series for certs that are gone
+	vclusterCertExpiry.DeletePartialMatch(prometheus.Labels{"name": name, "namespace": namespace})
+	for _, c := range result.Health.Certificates.Certificates {
+		vclusterCertExpiry.WithLabelValues(name, namespace, c.Name).Set(float64(c.NotAfter.Unix()))
+	}
+	stale := float64(0)
+	if len(result.Health.Certificates.StaleMerged) > 0 {
+		stale = 1
+	}
+	vclusterEtcdMergedStale.WithLabelValues(name, namespace).Set(stale)
+	vclusterSpecDrift.WithLabelValues(name, namespace).Set(float64(len(result.SpecDrift)))
 }
 
 // allArgoPhases used for resetting workload/addon phase gauges.
-var allArgoPhases = []string{"Ready", "Progressing", "Degraded", "Suspended", "Unknown"}
+var allArgoPhases = []string{phase.Ready, phase.Progressing, phase.Degraded, phase.Suspended, phase.Unknown}
 
 // updateWorkloadMetrics sets Prometheus gauges for a workload ArgoCD app.
 func updateWorkloadMetrics(status ArgoAppStatus) {
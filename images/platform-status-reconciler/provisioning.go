@@ -0,0 +1,166 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Provisioning records how long the vcluster took to become Ready, for
+// the spec generation it was last provisioned for.
+type Provisioning struct {
+	// Generation is the metadata.generation being provisioned. A spec
+	// change starts a new provisioning.
+	Generation int64 `json:"generation"`
+	// StartedAt is when provisioning began: the creationTimestamp for the
+	// first one, when the reconciler first saw the new generation for a
+	// re-provisioning.
+	StartedAt string `json:"startedAt"`
+	// ReadyAt is when the vcluster first became Ready after StartedAt;
+	// empty, or before StartedAt, while provisioning is still running.
+	ReadyAt string `json:"readyAt,omitempty"`
+	// DurationSeconds is ReadyAt - StartedAt.
+	DurationSeconds int64 `json:"durationSeconds,omitempty"`
+}
+
+// readyTransition is what a cycle observed of the vcluster becoming Ready.
+// Its durations go to the histograms only once the status patch recording
+// them lands, so a failed patch is not counted twice.
+type readyTransition struct {
+	// TimeToReady is set on the first Ready of a provisioning.
+	TimeToReady *time.Duration
+	// Recovery is set when a provisioned vcluster is Ready again, and is
+	// how long it was not.
+	Recovery *time.Duration
+}
+
+// observe records the transition in the histograms.
+func (t readyTransition) observe() {
+	if t.TimeToReady != nil {
+		vclusterTimeToReady.Observe(t.TimeToReady.Seconds())
+	}
+	if t.Recovery != nil {
+		vclusterRecoveryDuration.Observe(t.Recovery.Seconds())
+	}
+}
+
+// trackProvisioning computes the provisioning record for result.Phase at
+// now from the one already on the CR. The first Ready of a provisioning
+// stamps ReadyAt and its duration; a later Ready after the vcluster left
+// it is a recovery, measured from the Ready condition's last transition,
+// and leaves the record alone. A vcluster that was Ready before the
+// reconciler tracked provisioning gets a record without an observation.
+// Durations are clamped at zero against clock skew, and a missing
+// creationTimestamp counts from now.
+func trackProvisioning(vcr *unstructured.Unstructured, result *StatusResult, now time.Time) (Provisioning, readyTransition) {
+	prov, found := existingProvisioning(vcr)
+	generation := vcr.GetGeneration()
+	wasReady, leftReadyAt := previousReady(vcr)
+
+	if !found {
+		started := vcr.GetCreationTimestamp().Time
+		if started.IsZero() {
+			started = now
+		}
+		prov = Provisioning{Generation: generation, StartedAt: formatTime(started)}
+		// Ready before provisioning was tracked: backfill from the Ready
+		// condition, but it is not a transition this cycle saw.
+		if readyAt, ok := parseTime(leftReadyAt); ok && wasReady {
+			prov.ReadyAt = formatTime(readyAt)
+			prov.DurationSeconds = int64(clampDuration(readyAt.Sub(started)).Seconds())
+			return prov, readyTransition{}
+		}
+	} else if prov.Generation != generation {
+		prov.Generation = generation
+		prov.StartedAt = formatTime(now)
+	}
+
+	if result.Phase != phase.Ready {
+		return prov, readyTransition{}
+	}
+
+	started, ok := parseTime(prov.StartedAt)
+	if !ok {
+		started = now
+		prov.StartedAt = formatTime(now)
+	}
+	readyAt, ready := parseTime(prov.ReadyAt)
+	if !ready || readyAt.Before(started) {
+		d := clampDuration(now.Sub(started))
+		prov.ReadyAt = formatTime(now)
+		prov.DurationSeconds = int64(d.Seconds())
+		return prov, readyTransition{TimeToReady: &d}
+	}
+
+	if !wasReady {
+		if left, ok := parseTime(leftReadyAt); ok {
+			d := clampDuration(now.Sub(left))
+			return prov, readyTransition{Recovery: &d}
+		}
+	}
+	return prov, readyTransition{}
+}
+
+// existingProvisioning reads status.provisioning from the CR.
+func existingProvisioning(vcr *unstructured.Unstructured) (Provisioning, bool) {
+	m, found, _ := unstructured.NestedMap(vcr.Object, "status", "provisioning")
+	if !found {
+		return Provisioning{}, false
+	}
+	prov := Provisioning{}
+	prov.StartedAt, _ = m["startedAt"].(string)
+	prov.ReadyAt, _ = m["readyAt"].(string)
+	prov.Generation = jsonInt(m["generation"])
+	prov.DurationSeconds = jsonInt(m["durationSeconds"])
+	return prov, true
+}
+
+// previousReady reports whether the CR's Ready condition was True and
+// when it last changed status.
+func previousReady(vcr *unstructured.Unstructured) (bool, string) {
+	ready, ok := findCondition(existingConditions(vcr), phase.ConditionReady)
+	if !ok {
+		return false, ""
+	}
+	return ready.Status == "True", ready.LastTransitionTime
+}
+
+// provisioningStatus renders p for the status patch.
+func provisioningStatus(p Provisioning) map[string]interface{} {
+	m := map[string]interface{}{
+		"generation": p.Generation,
+		"startedAt":  p.StartedAt,
+	}
+	if p.ReadyAt != "" {
+		m["readyAt"] = p.ReadyAt
+		m["durationSeconds"] = p.DurationSeconds
+	}
+	return m
+}
+
+func jsonInt(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+func clampDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func histogramCount(t *testing.T, h prometheus.Histogram) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestReconcileAllRecordsTimeToReady(t *testing.T) {
+	vcr := makeVCR("", 10*time.Minute)
+	vcr.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	vcr.SetKind("VClusterOrchestratorV2")
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": "vcluster-test-vc", "namespace": "argocd"},
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "Synced"},
+			"health": map[string]interface{}{"status": "Healthy"},
+		},
+	}}
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		vclusterGVR:   "VClusterOrchestratorV2List",
+		argoAppGVR:    "ApplicationList",
+		kratixWorkGVR: "WorkList",
+	}, vcr, app)
+	kubeconfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "vc-test-vc", Namespace: "platform-requests"}}
+	clientset := fake.NewSimpleClientset(pod("api-0", false), kubeconfig)
+	r := NewReconciler(clientset, dynClient)
+	ctx := context.Background()
+
+	setPodReady := func(ready bool) {
+		t.Helper()
+		if _, err := clientset.CoreV1().Pods("platform-requests").UpdateStatus(ctx, pod("api-0", ready), metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provisioning := func() map[string]interface{} {
+		t.Helper()
+		got, err := dynClient.Resource(vclusterGVR).Namespace("platform-requests").Get(ctx, "test-vc", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _, _ := unstructured.NestedMap(got.Object, "status", "provisioning")
+		return p
+	}
+	timeToReady := histogramCount(t, vclusterTimeToReady)
+	recoveries := histogramCount(t, vclusterRecoveryDuration)
+
+	// Still provisioning for a few cycles: nothing to record yet.
+	for i := 0; i < 3; i++ {
+		r.ReconcileAll(ctx)
+	}
+	if p := provisioning(); p["startedAt"] == nil || p["readyAt"] != nil {
+		t.Fatalf("while provisioning: status.provisioning = %v, want startedAt only", p)
+	}
+
+	setPodReady(true)
+	r.ReconcileAll(ctx)
+	p := provisioning()
+	duration, _ := p["durationSeconds"].(int64)
+	if duration < 600 || duration > 660 || p["readyAt"] == nil {
+		t.Fatalf("first Ready: status.provisioning = %v, want about 600s since creation", p)
+	}
+	if got := histogramCount(t, vclusterTimeToReady) - timeToReady; got != 1 {
+		t.Errorf("time_to_ready_seconds observed %d times, want 1", got)
+	}
+
+	// Degrading and recovering records a recovery, not a second
+	// provisioning.
+	setPodReady(false)
+	r.ReconcileAll(ctx)
+	setPodReady(true)
+	r.ReconcileAll(ctx)
+	r.ReconcileAll(ctx)
+	if got := histogramCount(t, vclusterRecoveryDuration) - recoveries; got != 1 {
+		t.Errorf("recovery_duration_seconds observed %d times, want 1", got)
+	}
+	if got := histogramCount(t, vclusterTimeToReady) - timeToReady; got != 1 {
+		t.Errorf("time_to_ready_seconds observed %d times after the recovery, want still 1", got)
+	}
+	after := provisioning()
+	if after["readyAt"] != p["readyAt"] || after["durationSeconds"] != p["durationSeconds"] {
+		t.Errorf("recovery overwrote status.provisioning: %v, was %v", after, p)
+	}
+}
+
+func TestTrackProvisioning(t *testing.T) {
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	ready := &StatusResult{Phase: phase.Ready}
+	progressing := &StatusResult{Phase: phase.Progressing}
+	withStatus := func(generation int64, prov Provisioning, readyCond *Condition) *unstructured.Unstructured {
+		vcr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		vcr.SetCreationTimestamp(metav1.NewTime(created))
+		vcr.SetGeneration(generation)
+		status := map[string]interface{}{"provisioning": provisioningStatus(prov)}
+		if readyCond != nil {
+			status["conditions"] = []interface{}{map[string]interface{}{
+				"type":               readyCond.Type,
+				"status":             readyCond.Status,
+				"lastTransitionTime": readyCond.LastTransitionTime,
+			}}
+		}
+		vcr.Object["status"] = normalizeJSON(status)
+		return vcr
+	}
+	provisioned := Provisioning{Generation: 1, StartedAt: "2026-03-01T08:00:00Z", ReadyAt: "2026-03-01T08:07:00Z", DurationSeconds: 420}
+
+	t.Run("spec change re-provisions", func(t *testing.T) {
+		vcr := withStatus(2, provisioned, &Condition{Type: phase.ConditionReady, Status: "True", LastTransitionTime: "2026-03-01T08:07:00Z"})
+		now := created.Add(time.Hour)
+		prov, tr := trackProvisioning(vcr, progressing, now)
+		if prov.Generation != 2 || prov.StartedAt != "2026-03-01T09:00:00Z" || prov.ReadyAt != provisioned.ReadyAt || tr.TimeToReady != nil {
+			t.Fatalf("generation 2 seen: %+v %+v", prov, tr)
+		}
+		vcr = withStatus(2, prov, &Condition{Type: phase.ConditionReady, Status: "False", LastTransitionTime: "2026-03-01T09:00:00Z"})
+		prov, tr = trackProvisioning(vcr, ready, now.Add(3*time.Minute))
+		if tr.TimeToReady == nil || *tr.TimeToReady != 3*time.Minute || tr.Recovery != nil || prov.DurationSeconds != 180 {
+			t.Errorf("re-provisioned: %+v %+v, want a 3m time to ready", prov, tr)
+		}
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		vcr := withStatus(1, provisioned, &Condition{Type: phase.ConditionReady, Status: "False", LastTransitionTime: "2026-03-01T10:00:00Z"})
+		prov, tr := trackProvisioning(vcr, ready, created.Add(2*time.Hour+5*time.Minute))
+		if tr.Recovery == nil || *tr.Recovery != 5*time.Minute || tr.TimeToReady != nil || prov != provisioned {
+			t.Errorf("recovered: %+v %+v, want a 5m recovery and the record unchanged", prov, tr)
+		}
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		vcr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		vcr.SetCreationTimestamp(metav1.NewTime(created))
+		_, tr := trackProvisioning(vcr, ready, created.Add(-time.Minute))
+		if tr.TimeToReady == nil || *tr.TimeToReady != 0 {
+			t.Errorf("creationTimestamp in the future: %+v, want a zero duration", tr)
+		}
+	})
+
+	t.Run("missing creationTimestamp", func(t *testing.T) {
+		vcr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		prov, tr := trackProvisioning(vcr, ready, created)
+		if tr.TimeToReady == nil || *tr.TimeToReady != 0 || prov.StartedAt != "2026-03-01T08:00:00Z" {
+			t.Errorf("no creationTimestamp: %+v %+v, want to count from now", prov, tr)
+		}
+	})
+
+	t.Run("ready before tracking", func(t *testing.T) {
+		vcr := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+				"type": phase.ConditionReady, "status": "True", "lastTransitionTime": "2026-03-01T08:12:00Z",
+			}}},
+		}}
+		vcr.SetCreationTimestamp(metav1.NewTime(created))
+		prov, tr := trackProvisioning(vcr, ready, created.Add(30*24*time.Hour))
+		if tr.TimeToReady != nil || prov.DurationSeconds != 720 {
+			t.Errorf("backfill: %+v %+v, want 720s from the Ready condition and no observation", prov, tr)
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -40,33 +41,62 @@ var (
 type Reconciler struct {
 	clientset kubernetes.Interface
 	dynClient dynamic.Interface
+
+	// config supplies the settings. ReconcileAll copies it into cfg at the
+	// start of each cycle, so a reload takes effect on the next cycle.
+	config *ConfigStore
+	cfg    Config
+	// lastSeen records when each vcluster was last listed, to expire the
+	// series of removed ones after cfg.Metrics.Retention.
+	lastSeen map[types.NamespacedName]time.Time
+	// apps exports the per-app gauges and remembers last cycle's series.
+	apps *appSeries
+	// vclusterClient connects to a vcluster's API server; tests replace it.
+	vclusterClient vclusterClientFunc
 }
 
-// NewReconciler creates a reconciler with the given clients.
+// NewReconciler creates a reconciler with the given clients and the default
+// config.
 func NewReconciler(clientset kubernetes.Interface, dynClient dynamic.Interface) *Reconciler {
-	return &Reconciler{
+	r := &Reconciler{
 		clientset: clientset,
 		dynClient: dynClient,
+		config:    NewConfigStore(func(string) string { return "" }),
+		cfg:       defaultConfig(),
+		lastSeen:  map[types.NamespacedName]time.Time{},
+		apps:      newAppSeries(),
 	}
+	r.vclusterClient = r.kubeconfigClient
+	return r
 }
 
 // ReconcileAll lists all VClusterOrchestratorV2 resources and reconciles each.
 func (r *Reconciler) ReconcileAll(ctx context.Context) {
 	reconcileTotal.Inc()
 	log.Println("Starting reconcile cycle")
+	r.cfg = r.config.Get()
 
-	list, err := r.dynClient.Resource(vclusterGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	list, err := r.listVClusters(ctx)
 	if err != nil {
 		log.Printf("ERROR: Failed to list VClusterOrchestratorV2: %v", err)
 		return
 	}
 
 	log.Printf("Found %d VClusterOrchestratorV2 resources", len(list.Items))
+	r.expireMetrics(list.Items, time.Now())
 
 	// Collect vcluster names for workload/addon classification
 	var vclusterNames []string
 
 	for i := range list.Items {
+		// Stop between resources when leadership is lost or on shutdown.
+		// Nothing is left half-patched: each resource is a single status
+		// patch, and the next leader's full cycle covers the remainder.
+		if ctx.Err() != nil {
+			log.Printf("Reconcile cycle interrupted: %d of %d resources not reconciled", len(list.Items)-i, len(list.Items))
+			return
+		}
+
 		vcr := &list.Items[i]
 		name := vcr.GetName()
 		ns := vcr.GetNamespace()
@@ -86,12 +116,19 @@ func (r *Reconciler) ReconcileAll(ctx context.Context) {
 		// Update Prometheus metrics
 		updateMetrics(name, ns, result)
 
-		// Patch .status on the CR
-		if err := r.patchStatus(ctx, vcr, result); err != nil {
+		// Patch .status on the CR, unless that would change nothing
+		status := statusPatch(result)
+		if !statusChanged(vcr, status, time.Now(), r.cfg.StatusHeartbeat.Duration) {
+			reconcileSkippedNoChange.Inc()
+			log.Printf("Unchanged %s/%s: phase=%s, status patch skipped", ns, name, result.Phase)
+			continue
+		}
+		if err := r.patchStatus(ctx, vcr, status); err != nil {
 			log.Printf("ERROR: Failed to patch status for %s/%s: %v", ns, name, err)
 			reconcileErrors.WithLabelValues(name).Inc()
 			continue
 		}
+		result.Transition.observe()
 
 		log.Printf("Reconciled %s/%s: phase=%s pods=%d/%d argocd=%s/%s",
 			ns, name, result.Phase,
@@ -99,13 +136,60 @@ func (r *Reconciler) ReconcileAll(ctx context.Context) {
 			result.Health.ArgoCD.SyncStatus, result.Health.ArgoCD.HealthStatus)
 	}
 
+	if ctx.Err() != nil {
+		log.Println("Reconcile cycle interrupted before workload/addon metrics")
+		return
+	}
+
 	// Reconcile workload and addon ArgoCD Applications
-	r.ReconcileWorkloads(ctx, vclusterNames)
-	r.ReconcileAddons(ctx, vclusterNames)
+	var apps []appState
+	if r.cfg.Features.WorkloadMetrics {
+		apps = append(apps, r.ReconcileWorkloads(ctx, vclusterNames)...)
+	}
+	if r.cfg.Features.AddonMetrics {
+		apps = append(apps, r.ReconcileAddons(ctx, vclusterNames)...)
+	}
+	r.apps.publish(apps, r.cfg.Metrics.AppCardinalityBudget)
 
 	log.Println("Reconcile cycle complete")
 }
 
+// listVClusters lists the VClusterOrchestratorV2 resources in the configured
+// namespaces, or in all namespaces when none are configured.
+func (r *Reconciler) listVClusters(ctx context.Context) (*unstructured.UnstructuredList, error) {
+	if len(r.cfg.Namespaces) == 0 {
+		return r.dynClient.Resource(vclusterGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	}
+	all := &unstructured.UnstructuredList{}
+	for _, ns := range r.cfg.Namespaces {
+		list, err := r.dynClient.Resource(vclusterGVR).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", ns, err)
+		}
+		all.Items = append(all.Items, list.Items...)
+	}
+	return all, nil
+}
+
+// expireMetrics marks the listed vclusters as seen and drops the series of
+// those not listed for longer than the metrics retention.
+func (r *Reconciler) expireMetrics(listed []unstructured.Unstructured, now time.Time) {
+	for i := range listed {
+		r.lastSeen[types.NamespacedName{Namespace: listed[i].GetNamespace(), Name: listed[i].GetName()}] = now
+	}
+	retention := r.cfg.Metrics.Retention.Duration
+	if retention == 0 {
+		return
+	}
+	for key, seen := range r.lastSeen {
+		if now.Sub(seen) > retention {
+			log.Printf("Dropping metrics of %s, not seen for %s", key, retention)
+			deleteVClusterMetrics(key.Name, key.Namespace)
+			delete(r.lastSeen, key)
+		}
+	}
+}
+
 // reconcileOne gathers health data and computes status for a single VClusterOrchestratorV2 resource.
 func (r *Reconciler) reconcileOne(ctx context.Context, vcr *unstructured.Unstructured) (*StatusResult, error) {
 	name := vcr.GetName()
@@ -118,7 +202,7 @@ func (r *Reconciler) reconcileOne(ctx context.Context, vcr *unstructured.Unstruc
 	}
 
 	result := &StatusResult{
-		Phase:          "Unknown",
+		Phase:          phase.Unknown,
 		LastReconciled: time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -138,33 +222,64 @@ func (r *Reconciler) reconcileOne(ctx context.Context, vcr *unstructured.Unstruc
 
 	// 1. Check ArgoCD Application for the vcluster
 	argoAppName := fmt.Sprintf("vcluster-%s", name)
-	argoHealth := r.checkArgoCDApp(ctx, argoAppName, "argocd")
+	argoApp, argoHealth := r.checkArgoCDApp(ctx, argoAppName, "argocd")
 	result.Health.ArgoCD = argoHealth
+	if argoApp != nil {
+		result.SpecDrift = specDrift(vcr, argoApp)
+	}
 
 	// 2. Check pod readiness in the target namespace
 	result.Health.Workloads = r.checkPodReadiness(ctx, targetNS)
 
 	// 3. Check sub-apps (ArgoCD apps registered to the vcluster's server)
-	result.Health.SubApps = r.checkSubApps(ctx, name)
+	if r.cfg.Probes.SubApps {
+		result.Health.SubApps = r.checkSubApps(ctx, name)
+	}
 
 	// 4. Check kubeconfig secret existence
 	kubeconfigExists := r.secretExists(ctx, targetNS, fmt.Sprintf("vc-%s", name))
 
-	// 5. Compute phase from all health signals
-	result.Phase = computePhase(result, vcr, kubeconfigExists)
+	// 5. Check certificate expiry (kubeconfig, API server, etcd)
+	certs := r.cfg.Probes.Certificates
+	if certs.Enabled {
+		result.Health.Certificates = r.checkCertificates(ctx, vcr, name, targetNS, result.Credentials.KubeconfigSecret)
+	}
+
+	// 6. Compute phase from all health signals
+	result.Phase = computePhase(result, vcr, kubeconfigExists, r.cfg.Thresholds)
 	result.Message = phaseMessage(result.Phase, name)
+	result.Provisioning, result.Transition = trackProvisioning(vcr, result, time.Now())
 
-	// 6. Build conditions
+	// 7. Build conditions
 	result.Conditions = buildConditions(result, kubeconfigExists)
+	certsCondition := NewCondition(phase.ConditionCertificatesValid, "Unknown", "ProbeDisabled", "Certificate checks are disabled in the reconciler config")
+	if certs.Enabled {
+		certsCondition = certificatesCondition(result.Health.Certificates, time.Now(), certs.WarningWindow.Duration)
+	}
+	result.Conditions = append(result.Conditions, certsCondition)
+	repoCondition := NewCondition(phase.ConditionWorkloadRepoAccessible, "Unknown", "ProbeDisabled", "Workload repo checks are disabled in the reconciler config")
+	if r.cfg.Probes.WorkloadRepo {
+		repoCondition = r.checkWorkloadRepo(ctx, name, targetNS, result.Credentials.KubeconfigSecret)
+	}
+	result.Conditions = append(result.Conditions, repoCondition)
+	result.Conditions = append(result.Conditions, specDriftCondition(vcr, argoApp, result.SpecDrift))
+	if c, ok := r.checkBootstrap(ctx, vcr, targetNS); ok {
+		result.Conditions = append(result.Conditions, c)
+	}
+	if c, ok := r.checkNamespaceTeardown(ctx, targetNS, time.Now()); ok {
+		result.Conditions = append(result.Conditions, c)
+	}
+	result.Conditions = mergeConditions(existingConditions(vcr), result.Conditions, vcr.GetGeneration())
 
 	return result, nil
 }
 
-// checkArgoCDApp retrieves health/sync status from an ArgoCD Application.
-func (r *Reconciler) checkArgoCDApp(ctx context.Context, name, namespace string) ArgoCDHealth {
+// checkArgoCDApp retrieves an ArgoCD Application and its health/sync
+// status. The Application is nil when it cannot be read.
+func (r *Reconciler) checkArgoCDApp(ctx context.Context, name, namespace string) (*unstructured.Unstructured, ArgoCDHealth) {
 	app, err := r.dynClient.Resource(argoAppGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return ArgoCDHealth{SyncStatus: "Unknown", HealthStatus: "Missing"}
+		return nil, ArgoCDHealth{SyncStatus: "Unknown", HealthStatus: "Missing"}
 	}
 
 	syncStatus, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
@@ -177,7 +292,7 @@ func (r *Reconciler) checkArgoCDApp(ctx context.Context, name, namespace string)
 		healthStatus = "Unknown"
 	}
 
-	return ArgoCDHealth{
+	return app, ArgoCDHealth{
 		SyncStatus:   syncStatus,
 		HealthStatus: healthStatus,
 	}
@@ -276,12 +391,24 @@ func (r *Reconciler) secretExists(ctx context.Context, namespace, name string) b
 	return err == nil
 }
 
+// pausedAnnotation marks a vcluster the orchestrator pipeline renders
+// scaled to zero.
+const pausedAnnotation = "platform.integratn.tech/paused"
+
 // computePhase determines the aggregate phase from all health signals.
-func computePhase(result *StatusResult, vcr *unstructured.Unstructured, kubeconfigExists bool) string {
-	// Check if currently in Deleting state
-	currentPhase, _, _ := unstructured.NestedString(vcr.Object, "status", "phase")
-	if currentPhase == "Deleting" {
-		return "Deleting"
+func computePhase(result *StatusResult, vcr *unstructured.Unstructured, kubeconfigExists bool, th Thresholds) string {
+	// Check if currently in Deleting state. The delete pipeline reports it
+	// on the Ready condition; phase is the older signal.
+	if ready, ok := findCondition(existingConditions(vcr), phase.ConditionReady); ok && phase.Normalize(ready.Reason) == phase.Deleting {
+		return phase.Deleting
+	}
+	if currentPhase, _, _ := unstructured.NestedString(vcr.Object, "status", "phase"); phase.Normalize(currentPhase) == phase.Deleting {
+		return phase.Deleting
+	}
+	// A paused vcluster is scaled to zero on purpose ('hctl vcluster
+	// pause'); its missing pods are not a health problem.
+	if vcr.GetAnnotations()[pausedAnnotation] == "true" {
+		return phase.Paused
 	}
 
 	argoHealthy := result.Health.ArgoCD.HealthStatus == "Healthy"
@@ -294,12 +421,12 @@ func computePhase(result *StatusResult, vcr *unstructured.Unstructured, kubeconf
 
 	// Fully healthy
 	if argoHealthy && argoSynced && allPodsReady && subAppsOK && kubeconfigExists {
-		return "Ready"
+		return phase.Ready
 	}
 
 	// ArgoCD hasn't picked it up yet
 	if result.Health.ArgoCD.HealthStatus == "Missing" {
-		return "Scheduled"
+		return phase.Scheduled
 	}
 
 	// Calculate age for timeout-based transitions
@@ -307,35 +434,43 @@ func computePhase(result *StatusResult, vcr *unstructured.Unstructured, kubeconf
 
 	// Check for degradation signals
 	podsDown := result.Health.Workloads.Total > 0 &&
-		float64(result.Health.Workloads.Ready)/float64(result.Health.Workloads.Total) < 0.5
+		float64(result.Health.Workloads.Ready)/float64(result.Health.Workloads.Total) < th.DegradedReadyRatio
 	argoFailed := result.Health.ArgoCD.HealthStatus == "Degraded"
 
 	if argoFailed || podsDown {
-		if age > 15*time.Minute {
-			return "Failed"
+		if age > th.FailedAfter.Duration {
+			return phase.Failed
 		}
-		return "Degraded"
+		return phase.Degraded
 	}
 
 	// Not fully ready but not degraded — still progressing
-	return "Progressing"
+	return phase.Progressing
 }
 
-// phaseMessage returns a human-readable message for the phase.
-func phaseMessage(phase, name string) string {
-	switch phase {
-	case "Ready":
+// phaseMessage returns a human-readable message for the phase. Every phase
+// but Unknown has its own; TestPhaseMessagesExhaustive fails when a new one
+// falls through to the default.
+func phaseMessage(p, name string) string {
+	switch p {
+	case phase.Ready:
 		return fmt.Sprintf("VCluster %s is fully operational", name)
-	case "Scheduled":
+	case phase.Scheduled:
 		return fmt.Sprintf("VCluster %s resources have been scheduled, waiting for ArgoCD to sync", name)
-	case "Progressing":
+	case phase.Configured:
+		return fmt.Sprintf("VCluster %s resources have been rendered", name)
+	case phase.Progressing:
 		return fmt.Sprintf("VCluster %s is being provisioned", name)
-	case "Degraded":
+	case phase.Degraded:
 		return fmt.Sprintf("VCluster %s is running but some components are unhealthy", name)
-	case "Failed":
+	case phase.Failed:
 		return fmt.Sprintf("VCluster %s has failed — components are unhealthy for an extended period", name)
-	case "Deleting":
+	case phase.Deleting:
 		return fmt.Sprintf("VCluster %s is being deleted", name)
+	case phase.Paused:
+		return fmt.Sprintf("VCluster %s is paused; resume it with 'hctl vcluster resume %s'", name, name)
+	case phase.Suspended:
+		return fmt.Sprintf("VCluster %s is suspended in ArgoCD", name)
 	default:
 		return fmt.Sprintf("VCluster %s is in an unknown state", name)
 	}
@@ -346,41 +481,112 @@ func buildConditions(result *StatusResult, kubeconfigExists bool) []Condition {
 	conditions := []Condition{}
 
 	// Ready condition (aggregate)
-	if result.Phase == "Ready" {
-		conditions = append(conditions, NewCondition("Ready", "True", "AllHealthy", "All components healthy"))
+	if result.Phase == phase.Ready {
+		conditions = append(conditions, NewCondition(phase.ConditionReady, "True", "AllHealthy", "All components healthy"))
 	} else {
-		conditions = append(conditions, NewCondition("Ready", "False", result.Phase, result.Message))
+		conditions = append(conditions, NewCondition(phase.ConditionReady, "False", result.Phase, result.Message))
 	}
 
 	// ArgoSynced condition
 	if result.Health.ArgoCD.SyncStatus == "Synced" {
-		conditions = append(conditions, NewCondition("ArgoSynced", "True", "Synced", "ArgoCD application is synced"))
+		conditions = append(conditions, NewCondition(phase.ConditionArgoSynced, "True", "Synced", "ArgoCD application is synced"))
 	} else {
-		conditions = append(conditions, NewCondition("ArgoSynced", "False", result.Health.ArgoCD.SyncStatus,
+		conditions = append(conditions, NewCondition(phase.ConditionArgoSynced, "False", result.Health.ArgoCD.SyncStatus,
 			fmt.Sprintf("ArgoCD sync status: %s", result.Health.ArgoCD.SyncStatus)))
 	}
 
 	// PodsReady condition
 	if result.Health.Workloads.Ready == result.Health.Workloads.Total && result.Health.Workloads.Total > 0 {
-		conditions = append(conditions, NewCondition("PodsReady", "True", "AllPodsRunning",
+		conditions = append(conditions, NewCondition(phase.ConditionPodsReady, "True", "AllPodsRunning",
 			fmt.Sprintf("All %d pods are ready", result.Health.Workloads.Total)))
 	} else {
-		conditions = append(conditions, NewCondition("PodsReady", "False", "PodsNotReady",
+		conditions = append(conditions, NewCondition(phase.ConditionPodsReady, "False", "PodsNotReady",
 			fmt.Sprintf("%d/%d pods ready", result.Health.Workloads.Ready, result.Health.Workloads.Total)))
 	}
 
 	// KubeconfigAvailable condition
 	if kubeconfigExists {
-		conditions = append(conditions, NewCondition("KubeconfigAvailable", "True", "SecretExists", "Kubeconfig secret is available"))
+		conditions = append(conditions, NewCondition(phase.ConditionKubeconfigAvailable, "True", "SecretExists", "Kubeconfig secret is available"))
 	} else {
-		conditions = append(conditions, NewCondition("KubeconfigAvailable", "False", "SecretMissing", "Kubeconfig secret not found"))
+		conditions = append(conditions, NewCondition(phase.ConditionKubeconfigAvailable, "False", "SecretMissing", "Kubeconfig secret not found"))
 	}
 
 	return conditions
 }
 
-// patchStatus applies a strategic merge patch to the CR's .status subresource.
-func (r *Reconciler) patchStatus(ctx context.Context, vcr *unstructured.Unstructured, result *StatusResult) error {
+// existingConditions reads the conditions already on the CR, skipping
+// malformed entries.
+func existingConditions(vcr *unstructured.Unstructured) []Condition {
+	list, _, _ := unstructured.NestedSlice(vcr.Object, "status", "conditions")
+	var conditions []Condition
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := Condition{}
+		c.Type, _ = m["type"].(string)
+		if c.Type == "" {
+			continue
+		}
+		c.Status, _ = m["status"].(string)
+		c.Reason, _ = m["reason"].(string)
+		c.Message, _ = m["message"].(string)
+		c.LastTransitionTime, _ = m["lastTransitionTime"].(string)
+		switch g := m["observedGeneration"].(type) {
+		case int64:
+			c.ObservedGeneration = g
+		case float64:
+			c.ObservedGeneration = int64(g)
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+// findCondition returns the condition of the given type.
+func findCondition(conditions []Condition, condType string) (Condition, bool) {
+	for _, c := range conditions {
+		if c.Type == condType {
+			return c, true
+		}
+	}
+	return Condition{}, false
+}
+
+// mergeConditions stamps computed with generation and merges it into the
+// CR's existing conditions. A condition whose status has not changed keeps
+// its lastTransitionTime. Conditions the reconciler does not own, such as
+// Validated and ResourcesRendered from the pipeline or Kratix's own, are
+// kept: a merge patch replaces the whole list.
+func mergeConditions(existing, computed []Condition, generation int64) []Condition {
+	for i := range computed {
+		computed[i].ObservedGeneration = generation
+		if old, ok := findCondition(existing, computed[i].Type); ok && old.Status == computed[i].Status && old.LastTransitionTime != "" {
+			computed[i].LastTransitionTime = old.LastTransitionTime
+		}
+	}
+
+	merged := make([]Condition, 0, len(existing)+len(computed))
+	set := map[string]bool{}
+	for _, old := range existing {
+		if c, ok := findCondition(computed, old.Type); ok {
+			merged = append(merged, c)
+			set[c.Type] = true
+			continue
+		}
+		merged = append(merged, old)
+	}
+	for _, c := range computed {
+		if !set[c.Type] {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
+
+// statusPatch builds the .status fields the reconciler owns from result.
+func statusPatch(result *StatusResult) map[string]interface{} {
 	// Build status patch preserving existing status fields from the pipeline
 	statusMap := map[string]interface{}{
 		"phase":          result.Phase,
@@ -419,23 +625,34 @@ func (r *Reconciler) patchStatus(ctx context.Context, vcr *unstructured.Unstruct
 			"total":     result.Health.SubApps.Total,
 			"unhealthy": result.Health.SubApps.Unhealthy,
 		},
+		"certificates": certificatesStatus(result.Health.Certificates),
 	}
 
 	// Conditions
 	condList := []interface{}{}
 	for _, c := range result.Conditions {
-		condList = append(condList, map[string]interface{}{
+		cond := map[string]interface{}{
 			"type":               c.Type,
 			"status":             c.Status,
 			"reason":             c.Reason,
 			"message":            c.Message,
 			"lastTransitionTime": c.LastTransitionTime,
-		})
+		}
+		if c.ObservedGeneration != 0 {
+			cond["observedGeneration"] = c.ObservedGeneration
+		}
+		condList = append(condList, cond)
 	}
 	statusMap["conditions"] = condList
+	statusMap["provisioning"] = provisioningStatus(result.Provisioning)
 
+	return statusMap
+}
+
+// patchStatus applies a merge patch of status to the CR's .status subresource.
+func (r *Reconciler) patchStatus(ctx context.Context, vcr *unstructured.Unstructured, status map[string]interface{}) error {
 	patch := map[string]interface{}{
-		"status": statusMap,
+		"status": status,
 	}
 
 	patchBytes, err := json.Marshal(patch)
@@ -458,6 +675,31 @@ func (r *Reconciler) patchStatus(ctx context.Context, vcr *unstructured.Unstruct
 	return nil
 }
 
+// certificatesStatus renders certificate health for the status patch.
+// Lists are always present so a merge patch clears stale entries.
+func certificatesStatus(health CertificateHealth) map[string]interface{} {
+	certs := []interface{}{}
+	for _, c := range health.Certificates {
+		certs = append(certs, map[string]interface{}{
+			"name":     c.Name,
+			"secret":   c.Secret,
+			"notAfter": c.NotAfter.Format(time.RFC3339),
+		})
+	}
+	orEmpty := func(s []string) []string {
+		if s == nil {
+			return []string{}
+		}
+		return s
+	}
+	return map[string]interface{}{
+		"certificates": certs,
+		"missing":      orEmpty(health.Missing),
+		"invalid":      orEmpty(health.Invalid),
+		"staleMerged":  orEmpty(health.StaleMerged),
+	}
+}
+
 // contains checks if s contains substr.
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -33,7 +34,7 @@ func TestComputePhaseReady(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 30*time.Minute)
-	phase := computePhase(result, vcr, true)
+	phase := computePhase(result, vcr, true, defaultConfig().Thresholds)
 	if phase != "Ready" {
 		t.Errorf("expected Ready, got %s", phase)
 	}
@@ -47,7 +48,7 @@ func TestComputePhaseScheduled(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 2*time.Minute)
-	phase := computePhase(result, vcr, false)
+	phase := computePhase(result, vcr, false, defaultConfig().Thresholds)
 	if phase != "Scheduled" {
 		t.Errorf("expected Scheduled, got %s", phase)
 	}
@@ -60,12 +61,33 @@ func TestComputePhaseDeleting(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("Deleting", 5*time.Minute)
-	phase := computePhase(result, vcr, true)
+	phase := computePhase(result, vcr, true, defaultConfig().Thresholds)
 	if phase != "Deleting" {
 		t.Errorf("expected Deleting, got %s", phase)
 	}
 }
 
+func TestComputePhasePaused(t *testing.T) {
+	// Scaled to zero: no pods, and ArgoCD may report the app Degraded
+	// while the control plane is gone.
+	result := &StatusResult{
+		Health: Health{
+			ArgoCD:    ArgoCDHealth{SyncStatus: "Synced", HealthStatus: "Degraded"},
+			Workloads: WorkloadHealth{Ready: 0, Total: 0},
+		},
+	}
+	vcr := makeVCR("Ready", time.Hour)
+	vcr.SetAnnotations(map[string]string{pausedAnnotation: "true"})
+	if phase := computePhase(result, vcr, true, defaultConfig().Thresholds); phase != "Paused" {
+		t.Errorf("expected Paused, got %s", phase)
+	}
+
+	vcr.SetAnnotations(nil)
+	if phase := computePhase(result, vcr, true, defaultConfig().Thresholds); phase != "Failed" {
+		t.Errorf("expected Failed once resumed with the app still degraded, got %s", phase)
+	}
+}
+
 func TestComputePhaseDegraded(t *testing.T) {
 	result := &StatusResult{
 		Health: Health{
@@ -74,7 +96,7 @@ func TestComputePhaseDegraded(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 5*time.Minute) // younger than 15min
-	phase := computePhase(result, vcr, true)
+	phase := computePhase(result, vcr, true, defaultConfig().Thresholds)
 	if phase != "Degraded" {
 		t.Errorf("expected Degraded, got %s", phase)
 	}
@@ -88,7 +110,7 @@ func TestComputePhaseFailed(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 20*time.Minute) // older than 15min
-	phase := computePhase(result, vcr, true)
+	phase := computePhase(result, vcr, true, defaultConfig().Thresholds)
 	if phase != "Failed" {
 		t.Errorf("expected Failed, got %s", phase)
 	}
@@ -102,7 +124,7 @@ func TestComputePhaseProgressing(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 3*time.Minute)
-	phase := computePhase(result, vcr, false)
+	phase := computePhase(result, vcr, false, defaultConfig().Thresholds)
 	if phase != "Progressing" {
 		t.Errorf("expected Progressing, got %s", phase)
 	}
@@ -118,7 +140,7 @@ func TestComputePhaseNoSubApps(t *testing.T) {
 		},
 	}
 	vcr := makeVCR("", 10*time.Minute)
-	phase := computePhase(result, vcr, true)
+	phase := computePhase(result, vcr, true, defaultConfig().Thresholds)
 	if phase != "Ready" {
 		t.Errorf("expected Ready with no sub-apps, got %s", phase)
 	}
@@ -193,6 +215,7 @@ func TestPhaseMessage(t *testing.T) {
 		{"Degraded", "test", "VCluster test is running but"},
 		{"Failed", "test", "VCluster test has failed"},
 		{"Deleting", "test", "VCluster test is being deleted"},
+		{"Paused", "test", "VCluster test is paused"},
 		{"Unknown", "test", "VCluster test is in an unknown state"},
 	}
 	for _, tt := range tests {
@@ -203,6 +226,17 @@ func TestPhaseMessage(t *testing.T) {
 	}
 }
 
+func TestPhaseMessagesExhaustive(t *testing.T) {
+	seen := map[string]string{}
+	for _, p := range phase.All {
+		msg := phaseMessage(p, "test")
+		if other, ok := seen[msg]; ok {
+			t.Errorf("phaseMessage gives %s and %s the same message %q; add a case for the new phase", p, other, msg)
+		}
+		seen[msg] = p
+	}
+}
+
 func TestAggregateAppHealth(t *testing.T) {
 	apps := []unstructured.Unstructured{
 		{Object: map[string]interface{}{
@@ -256,6 +290,25 @@ func TestPhaseFromArgoCD(t *testing.T) {
 	}
 }
 
+// TestArgoPhasesCovered checks the workload and addon phase gauges reset
+// every phase phaseFromArgoCD can return.
+func TestArgoPhasesCovered(t *testing.T) {
+	listed := map[string]bool{}
+	for _, p := range allArgoPhases {
+		listed[p] = true
+	}
+	syncs := []string{"Synced", "OutOfSync", "Unknown", ""}
+	healths := []string{"Healthy", "Progressing", "Degraded", "Suspended", "Missing", "Unknown", ""}
+	for _, s := range syncs {
+		for _, h := range healths {
+			got := phaseFromArgoCD(s, h)
+			if !phase.Known(got) || !listed[got] {
+				t.Errorf("phaseFromArgoCD(%q, %q) = %q, which allArgoPhases does not list", s, h, got)
+			}
+		}
+	}
+}
+
 func TestExtractAppStatus(t *testing.T) {
 	app := unstructured.Unstructured{Object: map[string]interface{}{
 		"metadata": map[string]interface{}{
@@ -322,3 +375,68 @@ func TestContainsHelper(t *testing.T) {
 		}
 	}
 }
+
+func TestComputePhaseDeletingFromCondition(t *testing.T) {
+	result := &StatusResult{
+		Health: Health{
+			ArgoCD: ArgoCDHealth{SyncStatus: "Synced", HealthStatus: "Healthy"},
+		},
+	}
+	vcr := makeVCR("", 5*time.Minute)
+	vcr.Object["status"] = map[string]interface{}{
+		"phase": "Ready",
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "reason": "Deleting"},
+		},
+	}
+	if phase := computePhase(result, vcr, true, defaultConfig().Thresholds); phase != "Deleting" {
+		t.Errorf("expected Deleting from Ready condition, got %s", phase)
+	}
+}
+
+func TestMergeConditions(t *testing.T) {
+	const earlier = "2026-03-01T08:00:00Z"
+	vcr := makeVCR("", time.Hour)
+	vcr.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Validated", "status": "True", "reason": "SpecValid", "lastTransitionTime": earlier, "observedGeneration": int64(2)},
+			map[string]interface{}{"type": "Ready", "status": "False", "reason": "Scheduled", "lastTransitionTime": earlier},
+			map[string]interface{}{"type": "PodsReady", "status": "True", "reason": "AllPodsRunning", "lastTransitionTime": earlier},
+		},
+	}
+
+	computed := []Condition{
+		NewCondition("Ready", "True", "AllHealthy", "All components healthy"),
+		NewCondition("PodsReady", "True", "AllPodsRunning", "All 3 pods are ready"),
+		NewCondition("KubeconfigAvailable", "True", "SecretExists", "Kubeconfig secret is available"),
+	}
+	merged := mergeConditions(existingConditions(vcr), computed, 3)
+
+	wantOrder := []string{"Validated", "Ready", "PodsReady", "KubeconfigAvailable"}
+	if len(merged) != len(wantOrder) {
+		t.Fatalf("got %d conditions, want %d: %+v", len(merged), len(wantOrder), merged)
+	}
+	for i, c := range merged {
+		if c.Type != wantOrder[i] {
+			t.Errorf("conditions[%d] = %s, want %s", i, c.Type, wantOrder[i])
+		}
+	}
+
+	// The pipeline's condition is carried over untouched.
+	if merged[0].ObservedGeneration != 2 || merged[0].LastTransitionTime != earlier {
+		t.Errorf("Validated changed: %+v", merged[0])
+	}
+	// Ready flipped, so it transitions now.
+	if merged[1].LastTransitionTime == earlier {
+		t.Error("Ready flipped but kept its old lastTransitionTime")
+	}
+	// PodsReady is unchanged, so its transition time is kept.
+	if merged[2].LastTransitionTime != earlier {
+		t.Errorf("PodsReady lastTransitionTime = %s, want %s", merged[2].LastTransitionTime, earlier)
+	}
+	for _, c := range merged[1:] {
+		if c.ObservedGeneration != 3 {
+			t.Errorf("%s observedGeneration = %d, want 3", c.Type, c.ObservedGeneration)
+		}
+	}
+}
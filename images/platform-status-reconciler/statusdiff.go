@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// defaultStatusHeartbeat is how often lastReconciled is rewritten when
+// nothing else in .status changed. Override with STATUS_HEARTBEAT.
+const defaultStatusHeartbeat = 10 * time.Minute
+
+// statusChanged reports whether merging status into the CR's current
+// .status would change it. lastReconciled is ignored unless the recorded
+// one is older than heartbeat (or missing), so an unchanged vcluster is
+// patched once per heartbeat rather than every cycle.
+func statusChanged(vcr *unstructured.Unstructured, status map[string]interface{}, now time.Time, heartbeat time.Duration) bool {
+	current, found, _ := unstructured.NestedMap(vcr.Object, "status")
+	if !found {
+		return true
+	}
+
+	last, _ := current["lastReconciled"].(string)
+	at, err := time.Parse(time.RFC3339, last)
+	if err != nil || now.Sub(at) >= heartbeat {
+		return true
+	}
+
+	desired := make(map[string]interface{}, len(status))
+	for k, v := range status {
+		if k != "lastReconciled" {
+			desired[k] = v
+		}
+	}
+	return !mergeNoop(normalizeJSON(current), normalizeJSON(desired))
+}
+
+// normalizeJSON round-trips v through JSON so typed values (int, int64,
+// []string, structs) compare equal to what the API server returns: numbers
+// become float64 and slices []interface{}.
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// mergeNoop reports whether a JSON merge patch of patch onto current would
+// leave current as it is. Maps merge key by key, so keys only current has
+// are fine; a null in patch deletes the key; anything else replaces.
+func mergeNoop(current, patch interface{}) bool {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return valuesEqual(current, patch)
+	}
+	cm, ok := current.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, pv := range pm {
+		cv, found := cm[k]
+		if pv == nil {
+			if found {
+				return false
+			}
+			continue
+		}
+		if !found || !mergeNoop(cv, pv) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual compares normalized JSON values. Timestamps are compared as
+// instants, so "2026-03-01T08:00:00Z" equals "2026-03-01T09:00:00+01:00"
+// and a sub-second fraction the API server dropped is not a change.
+func valuesEqual(a, b interface{}) bool {
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			if as == bs {
+				return true
+			}
+			at, aErr := time.Parse(time.RFC3339, as)
+			bt, bErr := time.Parse(time.RFC3339, bs)
+			return aErr == nil && bErr == nil && at.Truncate(time.Second).Equal(bt.Truncate(time.Second))
+		}
+		return false
+	}
+	al, aok := a.([]interface{})
+	bl, bok := b.([]interface{})
+	if aok && bok {
+		if len(al) != len(bl) {
+			return false
+		}
+		for i := range al {
+			if !valuesEqual(al[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok && bok {
+		if len(am) != len(bm) {
+			return false
+		}
+		for k, av := range am {
+			bv, found := bm[k]
+			if !found || !valuesEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
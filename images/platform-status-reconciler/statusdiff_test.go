@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pod(name string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "platform-requests"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+// statusPatches counts the status patches issued on the fake client.
+func statusPatches(client *dynamicfake.FakeDynamicClient) int {
+	n := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" && action.GetResource() == vclusterGVR && action.GetSubresource() == "status" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReconcileAllSkipsUnchangedStatus(t *testing.T) {
+	vcr := makeVCR("", time.Hour)
+	vcr.SetAPIVersion("platform.integratn.tech/v1alpha1")
+	vcr.SetKind("VClusterOrchestratorV2")
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		vclusterGVR:   "VClusterOrchestratorV2List",
+		argoAppGVR:    "ApplicationList",
+		kratixWorkGVR: "WorkList",
+	}, vcr)
+	clientset := fake.NewSimpleClientset(pod("api-0", true), pod("api-1", false))
+	r := NewReconciler(clientset, dynClient)
+	ctx := context.Background()
+
+	skipped := testutil.ToFloat64(reconcileSkippedNoChange)
+	r.ReconcileAll(ctx)
+	if got := statusPatches(dynClient); got != 1 {
+		t.Fatalf("first cycle issued %d status patches, want 1", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		r.ReconcileAll(ctx)
+	}
+	if got := statusPatches(dynClient); got != 1 {
+		t.Errorf("identical cycles issued %d status patches, want still 1", got)
+	}
+	if got := testutil.ToFloat64(reconcileSkippedNoChange) - skipped; got != 3 {
+		t.Errorf("reconcile_skipped_nochange_total rose by %v, want 3", got)
+	}
+
+	// A pod becoming ready is a real health change and patches right away.
+	if _, err := clientset.CoreV1().Pods("platform-requests").UpdateStatus(ctx, pod("api-1", true), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	r.ReconcileAll(ctx)
+	if got := statusPatches(dynClient); got != 2 {
+		t.Fatalf("health change issued %d status patches in total, want 2", got)
+	}
+	got, err := dynClient.Resource(vclusterGVR).Namespace("platform-requests").Get(ctx, "test-vc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready, _, _ := unstructured.NestedInt64(got.Object, "status", "health", "workloads", "ready"); ready != 2 {
+		t.Errorf("status.health.workloads.ready = %d, want 2", ready)
+	}
+}
+
+func TestStatusChanged(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Minute).Format(time.RFC3339)
+
+	current := func(last string) *unstructured.Unstructured {
+		vcr := makeVCR("", time.Hour)
+		vcr.Object["status"] = map[string]interface{}{
+			"phase":          "Ready",
+			"lastReconciled": last,
+			"endpoints":      map[string]interface{}{"api": "https://media.integratn.tech:443"},
+			"health": map[string]interface{}{
+				"workloads": map[string]interface{}{"ready": int64(3), "total": float64(3)},
+			},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "2026-02-01T09:00:00+01:00", "observedGeneration": int64(2)},
+			},
+		}
+		return vcr
+	}
+	desired := func() map[string]interface{} {
+		return map[string]interface{}{
+			"phase":          "Ready",
+			"lastReconciled": now.Format(time.RFC3339),
+			"health": map[string]interface{}{
+				"workloads": map[string]interface{}{"ready": 3, "total": 3},
+			},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "2026-02-01T08:00:00Z", "observedGeneration": int64(2)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		vcr    *unstructured.Unstructured
+		mutate func(map[string]interface{})
+		want   bool
+	}{
+		{"identical apart from lastReconciled", current(recent), nil, false},
+		{"heartbeat due", current(now.Add(-11 * time.Minute).Format(time.RFC3339)), nil, true},
+		{"lastReconciled missing", current(""), nil, true},
+		{"no status yet", makeVCR("", time.Hour), nil, true},
+		{"pod count changed", current(recent), func(s map[string]interface{}) {
+			s["health"].(map[string]interface{})["workloads"] = map[string]interface{}{"ready": 2, "total": 3}
+		}, true},
+		{"condition transitioned", current(recent), func(s map[string]interface{}) {
+			s["conditions"].([]interface{})[0].(map[string]interface{})["lastTransitionTime"] = "2026-03-01T08:00:00Z"
+		}, true},
+		{"new field", current(recent), func(s map[string]interface{}) {
+			s["message"] = "VCluster test-vc is fully operational"
+		}, true},
+	}
+	for _, tt := range tests {
+		status := desired()
+		if tt.mutate != nil {
+			tt.mutate(status)
+		}
+		if got := statusChanged(tt.vcr, status, now, defaultStatusHeartbeat); got != tt.want {
+			t.Errorf("%s: statusChanged() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeNoopNull(t *testing.T) {
+	current := map[string]interface{}{"unhealthy": []interface{}{"app1"}}
+	if mergeNoop(current, map[string]interface{}{"unhealthy": nil}) {
+		t.Error("null patch over a present key is not a no-op")
+	}
+	if !mergeNoop(map[string]interface{}{}, map[string]interface{}{"unhealthy": nil}) {
+		t.Error("null patch over a missing key is a no-op")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// vclusterFinalizerPrefix marks the finalizers the vcluster syncer adds to
+// what it syncs to the host. Once the vcluster is deleted nothing removes
+// them, so they are the only ones safe to clear.
+const vclusterFinalizerPrefix = "vcluster.loft.sh/"
+
+// maxListedHolders caps the resources named in the NamespaceStuck message.
+const maxListedHolders = 10
+
+// teardownResources are the namespaced resources a vcluster namespace
+// holds, searched for finalizers when it is stuck Terminating. A resource
+// whose API is not installed is skipped.
+var teardownResources = []struct {
+	gvr  schema.GroupVersionResource
+	kind string
+}{
+	{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "Pod"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, "PersistentVolumeClaim"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "services"}, "Service"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "Secret"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, "ServiceAccount"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, "StatefulSet"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "Deployment"},
+	{schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, "Job"},
+	{schema.GroupVersionResource{Group: "external-secrets.io", Version: "v1beta1", Resource: "externalsecrets"}, "ExternalSecret"},
+	{schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, "Certificate"},
+	{schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}, "Issuer"},
+}
+
+// finalizerHolder is a resource in a Terminating namespace that still has
+// finalizers.
+type finalizerHolder struct {
+	gvr             schema.GroupVersionResource
+	kind            string
+	name            string
+	resourceVersion string
+	finalizers      []string
+}
+
+func (h finalizerHolder) String() string {
+	return fmt.Sprintf("%s/%s (%s)", h.kind, h.name, strings.Join(h.finalizers, ", "))
+}
+
+// checkNamespaceTeardown builds the NamespaceStuck condition for a target
+// namespace being deleted, and false when it is not being deleted. Past
+// thresholds.namespaceStuckAfter the condition turns True and names the
+// resources still holding finalizers. Past thresholds.finalizerGracePeriod,
+// and only with CLEAR_VCLUSTER_FINALIZERS set, the vcluster's own
+// finalizers are removed from them first.
+func (r *Reconciler) checkNamespaceTeardown(ctx context.Context, namespace string, now time.Time) (Condition, bool) {
+	ns, err := r.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil || ns.DeletionTimestamp == nil {
+		return Condition{}, false
+	}
+	terminating := now.Sub(ns.DeletionTimestamp.Time)
+	th := r.cfg.Thresholds
+	if terminating < th.NamespaceStuckAfter.Duration {
+		return NewCondition(phase.ConditionNamespaceStuck, "False", "Terminating",
+			fmt.Sprintf("Namespace %s has been Terminating since %s", namespace, ns.DeletionTimestamp.UTC().Format(time.RFC3339))), true
+	}
+
+	holders := r.finalizerHolders(ctx, namespace)
+	if r.cfg.ClearVClusterFinalizers && terminating >= th.FinalizerGracePeriod.Duration {
+		holders = r.clearVClusterFinalizers(ctx, namespace, holders)
+	}
+	return stuckNamespaceCondition(ns, holders), true
+}
+
+// finalizerHolders lists the resources in namespace that have finalizers,
+// sorted by kind and name.
+func (r *Reconciler) finalizerHolders(ctx context.Context, namespace string) []finalizerHolder {
+	var holders []finalizerHolder
+	for _, res := range teardownResources {
+		list, err := r.dynClient.Resource(res.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Printf("WARN: Failed to list %s in terminating namespace %s: %v", res.gvr.Resource, namespace, err)
+			}
+			continue
+		}
+		for _, item := range list.Items {
+			if finalizers := item.GetFinalizers(); len(finalizers) > 0 {
+				holders = append(holders, finalizerHolder{
+					gvr:             res.gvr,
+					kind:            res.kind,
+					name:            item.GetName(),
+					resourceVersion: item.GetResourceVersion(),
+					finalizers:      finalizers,
+				})
+			}
+		}
+	}
+	sort.SliceStable(holders, func(i, j int) bool {
+		if holders[i].kind != holders[j].kind {
+			return holders[i].kind < holders[j].kind
+		}
+		return holders[i].name < holders[j].name
+	})
+	return holders
+}
+
+// clearVClusterFinalizers removes the vcluster's finalizers from holders
+// and returns those still holding others, or whose patch failed. The
+// patch carries the resourceVersion the finalizers were read at, so a
+// resource changed since is left for the next cycle.
+func (r *Reconciler) clearVClusterFinalizers(ctx context.Context, namespace string, holders []finalizerHolder) []finalizerHolder {
+	var remaining []finalizerHolder
+	for _, h := range holders {
+		keep := []string{}
+		for _, f := range h.finalizers {
+			if !strings.HasPrefix(f, vclusterFinalizerPrefix) {
+				keep = append(keep, f)
+			}
+		}
+		cleared := len(h.finalizers) - len(keep)
+		if cleared == 0 {
+			remaining = append(remaining, h)
+			continue
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"finalizers":      keep,
+				"resourceVersion": h.resourceVersion,
+			},
+		})
+		if err == nil {
+			_, err = r.dynClient.Resource(h.gvr).Namespace(namespace).Patch(ctx, h.name, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+		if err != nil {
+			log.Printf("WARN: Failed to clear vcluster finalizers from %s/%s in %s: %v", h.kind, h.name, namespace, err)
+			remaining = append(remaining, h)
+			continue
+		}
+		log.Printf("Cleared %d vcluster finalizer(s) from %s/%s in terminating namespace %s", cleared, h.kind, h.name, namespace)
+		finalizersCleared.Add(float64(cleared))
+		if len(keep) > 0 {
+			h.finalizers = keep
+			remaining = append(remaining, h)
+		}
+	}
+	return remaining
+}
+
+// stuckNamespaceCondition is the True NamespaceStuck condition, naming the
+// resources still holding finalizers. When none of teardownResources does,
+// it falls back to what the namespace controller reports. The message
+// names the deletion time rather than an age, so an unchanged namespace
+// does not re-patch the status every cycle.
+func stuckNamespaceCondition(ns *corev1.Namespace, holders []finalizerHolder) Condition {
+	prefix := fmt.Sprintf("Namespace %s has been Terminating since %s", ns.Name, ns.DeletionTimestamp.UTC().Format(time.RFC3339))
+	if len(holders) == 0 {
+		for _, c := range ns.Status.Conditions {
+			if c.Status == corev1.ConditionTrue &&
+				(c.Type == corev1.NamespaceFinalizersRemaining || c.Type == corev1.NamespaceContentRemaining) {
+				return NewCondition(phase.ConditionNamespaceStuck, "True", string(c.Type), prefix+": "+c.Message)
+			}
+		}
+		return NewCondition(phase.ConditionNamespaceStuck, "True", "ContentRemaining", prefix+"; no resource checked holds a finalizer")
+	}
+
+	names := make([]string, 0, maxListedHolders)
+	for i, h := range holders {
+		if i == maxListedHolders {
+			names = append(names, fmt.Sprintf("and %d more", len(holders)-maxListedHolders))
+			break
+		}
+		names = append(names, h.String())
+	}
+	return NewCondition(phase.ConditionNamespaceStuck, "True", "FinalizersRemaining",
+		fmt.Sprintf("%s; finalizers remain on %s", prefix, strings.Join(names, ", ")))
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// terminatingNamespace is vcluster-media, deleted deletedAgo before now.
+func terminatingNamespace(now time.Time, deletedAgo time.Duration) *corev1.Namespace {
+	deleted := metav1.NewTime(now.Add(-deletedAgo))
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "vcluster-media", DeletionTimestamp: &deleted},
+		Status: corev1.NamespaceStatus{
+			Phase: corev1.NamespaceTerminating,
+			Conditions: []corev1.NamespaceCondition{{
+				Type:    corev1.NamespaceFinalizersRemaining,
+				Status:  corev1.ConditionTrue,
+				Message: "Some content in the namespace has finalizers remaining: kubernetes.io/pvc-protection in 1 resource instances",
+			}},
+		},
+	}
+}
+
+// withFinalizers is a fixture object in vcluster-media holding finalizers.
+func withFinalizers(apiVersion, kind, name string, finalizers ...string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("vcluster-media")
+	obj.SetResourceVersion("7")
+	obj.SetFinalizers(finalizers)
+	return obj
+}
+
+// teardownReconciler returns a reconciler over ns and the fixture objects,
+// with every resource in teardownResources listable.
+func teardownReconciler(ns *corev1.Namespace, objs ...runtime.Object) (*Reconciler, *dynamicfake.FakeDynamicClient) {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, res := range teardownResources {
+		listKinds[res.gvr] = res.kind + "List"
+	}
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+	return NewReconciler(fake.NewSimpleClientset(ns), dynClient), dynClient
+}
+
+func fixtureHolders() []runtime.Object {
+	return []runtime.Object{
+		withFinalizers("v1", "PersistentVolumeClaim", "data-media-0", "kubernetes.io/pvc-protection"),
+		withFinalizers("v1", "Pod", "coredns-x-kube-system-x-media", "vcluster.loft.sh/cleanup"),
+		withFinalizers("v1", "Service", "media", "vcluster.loft.sh/cleanup", "service.kubernetes.io/load-balancer-cleanup"),
+		withFinalizers("v1", "Secret", "vc-media"),
+	}
+}
+
+func TestNamespaceTeardownNotTerminating(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "vcluster-media"}}
+	r, _ := teardownReconciler(ns)
+	if c, ok := r.checkNamespaceTeardown(context.Background(), "vcluster-media", time.Now()); ok {
+		t.Errorf("active namespace reported %+v", c)
+	}
+	if c, ok := r.checkNamespaceTeardown(context.Background(), "vcluster-gone", time.Now()); ok {
+		t.Errorf("missing namespace reported %+v", c)
+	}
+}
+
+func TestNamespaceTeardownWithinThreshold(t *testing.T) {
+	now := time.Now()
+	r, dynClient := teardownReconciler(terminatingNamespace(now, 2*time.Minute), fixtureHolders()...)
+	c, ok := r.checkNamespaceTeardown(context.Background(), "vcluster-media", now)
+	if !ok || c.Type != "NamespaceStuck" || c.Status != "False" || c.Reason != "Terminating" {
+		t.Fatalf("condition = %+v, %v; want NamespaceStuck False/Terminating", c, ok)
+	}
+	if n := len(dynClient.Actions()); n != 0 {
+		t.Errorf("issued %d API calls for a namespace within the threshold, want none", n)
+	}
+}
+
+func TestNamespaceTeardownStuck(t *testing.T) {
+	now := time.Now()
+	r, dynClient := teardownReconciler(terminatingNamespace(now, 20*time.Minute), fixtureHolders()...)
+	c, ok := r.checkNamespaceTeardown(context.Background(), "vcluster-media", now)
+	if !ok || c.Status != "True" || c.Reason != "FinalizersRemaining" {
+		t.Fatalf("condition = %+v, %v; want NamespaceStuck True/FinalizersRemaining", c, ok)
+	}
+	want := "finalizers remain on PersistentVolumeClaim/data-media-0 (kubernetes.io/pvc-protection), " +
+		"Pod/coredns-x-kube-system-x-media (vcluster.loft.sh/cleanup), " +
+		"Service/media (vcluster.loft.sh/cleanup, service.kubernetes.io/load-balancer-cleanup)"
+	if !strings.HasSuffix(c.Message, want) {
+		t.Errorf("message = %q, want it to end with %q", c.Message, want)
+	}
+	if strings.Contains(c.Message, "vc-media") {
+		t.Errorf("message names a resource without finalizers: %q", c.Message)
+	}
+	for _, action := range dynClient.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("patched %s with finalizer clearing disabled", action.GetResource().Resource)
+		}
+	}
+}
+
+func TestNamespaceTeardownFallsBackToNamespaceConditions(t *testing.T) {
+	now := time.Now()
+	r, _ := teardownReconciler(terminatingNamespace(now, time.Hour))
+	c, _ := r.checkNamespaceTeardown(context.Background(), "vcluster-media", now)
+	if c.Status != "True" || c.Reason != string(corev1.NamespaceFinalizersRemaining) || !strings.Contains(c.Message, "pvc-protection in 1 resource instances") {
+		t.Errorf("condition = %+v, want the namespace controller's report", c)
+	}
+}
+
+func TestNamespaceTeardownClearsVClusterFinalizers(t *testing.T) {
+	now := time.Now()
+	ctx := context.Background()
+
+	// Stuck, but within the grace period: nothing is cleared yet.
+	r, dynClient := teardownReconciler(terminatingNamespace(now, 20*time.Minute), fixtureHolders()...)
+	r.cfg.ClearVClusterFinalizers = true
+	r.checkNamespaceTeardown(ctx, "vcluster-media", now)
+	for _, action := range dynClient.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("patched %s within the grace period", action.GetResource().Resource)
+		}
+	}
+
+	// Past it, only the vcluster's finalizers go.
+	r, dynClient = teardownReconciler(terminatingNamespace(now, 45*time.Minute), fixtureHolders()...)
+	r.cfg.ClearVClusterFinalizers = true
+	cleared := testutil.ToFloat64(finalizersCleared)
+	c, _ := r.checkNamespaceTeardown(ctx, "vcluster-media", now)
+
+	for gvr, want := range map[schema.GroupVersionResource]map[string][]string{
+		{Version: "v1", Resource: "pods"}:                   {"coredns-x-kube-system-x-media": nil},
+		{Version: "v1", Resource: "services"}:               {"media": {"service.kubernetes.io/load-balancer-cleanup"}},
+		{Version: "v1", Resource: "persistentvolumeclaims"}: {"data-media-0": {"kubernetes.io/pvc-protection"}},
+	} {
+		for name, finalizers := range want {
+			obj, err := dynClient.Resource(gvr).Namespace("vcluster-media").Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := obj.GetFinalizers(); strings.Join(got, ",") != strings.Join(finalizers, ",") {
+				t.Errorf("%s/%s finalizers = %v, want %v", gvr.Resource, name, got, finalizers)
+			}
+		}
+	}
+	if got := testutil.ToFloat64(finalizersCleared) - cleared; got != 2 {
+		t.Errorf("finalizers_cleared_total rose by %v, want 2", got)
+	}
+	if strings.Contains(c.Message, "vcluster.loft.sh/") || !strings.Contains(c.Message, "data-media-0") {
+		t.Errorf("message = %q, want only the finalizers left after clearing", c.Message)
+	}
+}
+
+func TestNamespaceTeardownConfig(t *testing.T) {
+	cfg := defaultConfig()
+	applyEnv(&cfg, envMap(map[string]string{"CLEAR_VCLUSTER_FINALIZERS": "true"}))
+	if !cfg.ClearVClusterFinalizers {
+		t.Error("CLEAR_VCLUSTER_FINALIZERS=true did not enable clearing")
+	}
+	if _, err := parseConfig("clearVClusterFinalizers: true\n"); err == nil {
+		t.Error("the ConfigMap enabled finalizer clearing, want it env-only")
+	}
+	cfg, err := parseConfig("thresholds:\n  namespaceStuckAfter: 1h\n  finalizerGracePeriod: 30m\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("a grace period shorter than namespaceStuckAfter validated")
+	}
+}
@@ -13,6 +13,14 @@ type StatusResult struct {
 	Credentials    Credentials    `json:"credentials,omitempty"`
 	Health         Health         `json:"health"`
 	Conditions     []Condition    `json:"conditions"`
+	Provisioning   Provisioning   `json:"provisioning"`
+	// SpecDrift lists where the ArgoCD Application differs from the CR
+	// spec, for the SpecDrift condition and the spec_drift gauge.
+	SpecDrift []SpecDrift `json:"-"`
+
+	// Transition is the Ready transition this cycle observed, recorded in
+	// the histograms once the status patch lands.
+	Transition readyTransition `json:"-"`
 }
 
 // Endpoints holds discoverable URLs for the vcluster.
@@ -29,9 +37,10 @@ type Credentials struct {
 
 // Health aggregates health checks across the lifecycle chain.
 type Health struct {
-	ArgoCD    ArgoCDHealth    `json:"argocd"`
-	Workloads WorkloadHealth  `json:"workloads"`
-	SubApps   SubAppHealth    `json:"subApps"`
+	ArgoCD       ArgoCDHealth      `json:"argocd"`
+	Workloads    WorkloadHealth    `json:"workloads"`
+	SubApps      SubAppHealth      `json:"subApps"`
+	Certificates CertificateHealth `json:"certificates"`
 }
 
 // ArgoCDHealth reflects the parent ArgoCD Application status.
@@ -53,10 +62,27 @@ type SubAppHealth struct {
 	Unhealthy []string `json:"unhealthy,omitempty"`
 }
 
+// CertificateHealth reflects the expiry of the vcluster's certificates,
+// soonest-expiring first.
+type CertificateHealth struct {
+	Certificates []CertExpiry `json:"certificates,omitempty"`
+	Missing      []string     `json:"missing,omitempty"`
+	Invalid      []string     `json:"invalid,omitempty"`
+	StaleMerged  []string     `json:"staleMerged,omitempty"`
+}
+
+// CertExpiry records when one certificate expires.
+type CertExpiry struct {
+	Name     string    `json:"name"`
+	Secret   string    `json:"secret"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
 // Condition follows the Kubernetes metav1.Condition convention.
 type Condition struct {
 	Type               string `json:"type"`
 	Status             string `json:"status"`     // "True", "False", "Unknown"
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
 	Reason             string `json:"reason"`
 	Message            string `json:"message"`
 	LastTransitionTime string `json:"lastTransitionTime"`
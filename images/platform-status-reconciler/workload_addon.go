@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -22,23 +23,28 @@ type ArgoAppStatus struct {
 }
 
 // ReconcileWorkloads discovers ArgoCD Applications targeting vClusters (workloads)
-// and emits Prometheus metrics for each.
-func (r *Reconciler) ReconcileWorkloads(ctx context.Context, vclusterNames []string) {
+// and emits Prometheus metrics for each. It returns their states for the
+// per-app gauges.
+func (r *Reconciler) ReconcileWorkloads(ctx context.Context, vclusterNames []string) []appState {
 	log.Println("Reconciling workloads")
 
+	var states []appState
 	for _, vcName := range vclusterNames {
 		apps := r.listAddonApps(ctx, fmt.Sprintf("addon=true,clusterName=%s", vcName))
 		for _, app := range apps {
 			status := extractAppStatus(app)
 			updateWorkloadMetrics(status)
+			states = append(states, appStateFrom(status, appTypeWorkload))
 		}
 		log.Printf("  workloads for %s: %d apps", vcName, len(apps))
 	}
+	return states
 }
 
 // ReconcileAddons discovers infrastructure addon ArgoCD Applications
 // (those targeting the host cluster, not vClusters) and emits Prometheus metrics.
-func (r *Reconciler) ReconcileAddons(ctx context.Context, vclusterNames []string) {
+// It returns their states for the per-app gauges.
+func (r *Reconciler) ReconcileAddons(ctx context.Context, vclusterNames []string) []appState {
 	log.Println("Reconciling addons")
 
 	// Build set of vcluster names for exclusion
@@ -48,7 +54,7 @@ func (r *Reconciler) ReconcileAddons(ctx context.Context, vclusterNames []string
 	}
 
 	apps := r.listAddonApps(ctx, "addon=true")
-	addonCount := 0
+	var states []appState
 	for _, app := range apps {
 		status := extractAppStatus(app)
 		// Skip apps targeting vClusters — those are workloads, not addons
@@ -56,9 +62,10 @@ func (r *Reconciler) ReconcileAddons(ctx context.Context, vclusterNames []string
 			continue
 		}
 		updateAddonMetrics(status)
-		addonCount++
+		states = append(states, appStateFrom(status, appTypeAddon))
 	}
-	log.Printf("  infrastructure addons: %d apps", addonCount)
+	log.Printf("  infrastructure addons: %d apps", len(states))
+	return states
 }
 
 // listAddonApps lists ArgoCD Applications matching a label selector.
@@ -103,16 +110,16 @@ func extractAppStatus(app unstructured.Unstructured) ArgoAppStatus {
 func phaseFromArgoCD(syncStatus, healthStatus string) string {
 	switch {
 	case syncStatus == "Synced" && healthStatus == "Healthy":
-		return "Ready"
+		return phase.Ready
 	case healthStatus == "Degraded":
-		return "Degraded"
+		return phase.Degraded
 	case healthStatus == "Missing" || syncStatus == "Unknown":
-		return "Unknown"
+		return phase.Unknown
 	case healthStatus == "Progressing" || syncStatus == "OutOfSync":
-		return "Progressing"
+		return phase.Progressing
 	case healthStatus == "Suspended":
-		return "Suspended"
+		return phase.Suspended
 	default:
-		return "Progressing"
+		return phase.Progressing
 	}
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// workloadAppSetName is the ApplicationSet the vcluster's own ArgoCD renders
+// the workload repo with (argocd-vcluster in
+// addons/cluster-roles/vcluster/addons/addons.yaml). It and the
+// Applications it generates live in the vcluster, not on the host.
+const workloadAppSetName = "workloads"
+
+// vclusterAPITimeout bounds each request to a vcluster API server.
+const vclusterAPITimeout = 10 * time.Second
+
+var applicationSetGVR = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "applicationsets",
+}
+
+// vclusterClientFunc returns a client for a vcluster's API server from its
+// kubeconfig Secret.
+type vclusterClientFunc func(ctx context.Context, namespace, secret string) (dynamic.Interface, error)
+
+// kubeconfigClient builds a vcluster client from the config key of the
+// kubeconfig Secret vcluster exports.
+func (r *Reconciler) kubeconfigClient(ctx context.Context, namespace, secret string) (dynamic.Interface, error) {
+	s, err := r.clientset.CoreV1().Secrets(namespace).Get(ctx, secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig secret %s/%s: %w", namespace, secret, err)
+	}
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(s.Data["config"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig secret %s/%s: %w", namespace, secret, err)
+	}
+	cfg.Timeout = vclusterAPITimeout
+	return dynamic.NewForConfig(cfg)
+}
+
+// checkWorkloadRepo builds the WorkloadRepoAccessible condition from the
+// workloads ApplicationSet inside the vcluster and the Applications in the
+// vcluster's argocd namespace. It is Unknown when the vcluster API or the
+// ApplicationSet cannot be read.
+func (r *Reconciler) checkWorkloadRepo(ctx context.Context, name, namespace, kubeconfigSecret string) Condition {
+	if kubeconfigSecret == "" {
+		kubeconfigSecret = fmt.Sprintf("vc-%s", name)
+	}
+	client, err := r.vclusterClient(ctx, namespace, kubeconfigSecret)
+	if err != nil {
+		return NewCondition(phase.ConditionWorkloadRepoAccessible, "Unknown", "VClusterUnreachable", err.Error())
+	}
+
+	appSet, err := client.Resource(applicationSetGVR).Namespace("argocd").Get(ctx, workloadAppSetName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return NewCondition(phase.ConditionWorkloadRepoAccessible, "Unknown", "ApplicationSetNotFound",
+			fmt.Sprintf("ApplicationSet argocd/%s not found in the vcluster", workloadAppSetName))
+	case err != nil:
+		return NewCondition(phase.ConditionWorkloadRepoAccessible, "Unknown", "VClusterUnreachable",
+			fmt.Sprintf("reading ApplicationSet argocd/%s: %v", workloadAppSetName, err))
+	}
+
+	var apps []unstructured.Unstructured
+	if list, err := client.Resource(argoAppGVR).Namespace("argocd").List(ctx, metav1.ListOptions{}); err != nil {
+		log.Printf("WARN: Failed to list ArgoCD apps in vcluster %s: %v", name, err)
+	} else {
+		apps = list.Items
+	}
+	return workloadRepoCondition(appSet, apps)
+}
+
+// workloadRepoCondition is False when the ApplicationSet reports an error,
+// or an Application a ComparisonError, that says the repository, revision
+// or path cannot be read. Other render errors are left to the sub-app
+// health.
+func workloadRepoCondition(appSet *unstructured.Unstructured, apps []unstructured.Unstructured) Condition {
+	conditions, _, _ := unstructured.NestedSlice(appSet.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, _ := c.(map[string]interface{})
+		if m["type"] != "ErrorOccurred" || m["status"] != "True" {
+			continue
+		}
+		msg, _ := m["message"].(string)
+		if reason := repoErrorReason(msg); reason != "" {
+			return NewCondition(phase.ConditionWorkloadRepoAccessible, "False", reason,
+				fmt.Sprintf("ApplicationSet %s: %s", appSet.GetName(), msg))
+		}
+	}
+
+	for _, app := range apps {
+		conditions, _, _ := unstructured.NestedSlice(app.Object, "status", "conditions")
+		for _, c := range conditions {
+			m, _ := c.(map[string]interface{})
+			if m["type"] != "ComparisonError" {
+				continue
+			}
+			msg, _ := m["message"].(string)
+			if reason := repoErrorReason(msg); reason != "" {
+				return NewCondition(phase.ConditionWorkloadRepoAccessible, "False", reason,
+					fmt.Sprintf("Application %s: %s", app.GetName(), msg))
+			}
+		}
+	}
+
+	return NewCondition(phase.ConditionWorkloadRepoAccessible, "True", "RepoAccessible",
+		fmt.Sprintf("ApplicationSet %s and %d Application(s) report no repository errors", appSet.GetName(), len(apps)))
+}
+
+// repoErrorReason classifies an ArgoCD error message that means the
+// workload repo cannot be read, or returns "".
+func repoErrorReason(message string) string {
+	m := strings.ToLower(message)
+	switch {
+	case strings.Contains(m, "repository not found"):
+		return "RepositoryNotFound"
+	case strings.Contains(m, "path does not exist"):
+		return "PathNotFound"
+	case strings.Contains(m, "unable to resolve"):
+		return "RevisionNotFound"
+	case strings.Contains(m, "authentication required"), strings.Contains(m, "authentication failed"):
+		return "AuthenticationFailed"
+	}
+	return ""
+}
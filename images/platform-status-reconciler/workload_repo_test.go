@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// applicationSet returns the workloads ApplicationSet with the given
+// status conditions.
+func applicationSet(conditions ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "ApplicationSet",
+		"metadata":   map[string]interface{}{"name": workloadAppSetName, "namespace": "argocd"},
+		"status":     map[string]interface{}{"conditions": conditions},
+	}}
+}
+
+// application returns an Application in argocd with the given status
+// conditions.
+func application(name string, conditions ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "argocd"},
+		"status":     map[string]interface{}{"conditions": conditions},
+	}}
+}
+
+func appSetCondition(condType, status, message string) map[string]interface{} {
+	return map[string]interface{}{"type": condType, "status": status, "message": message}
+}
+
+func comparisonError(message string) map[string]interface{} {
+	return map[string]interface{}{"type": "ComparisonError", "message": message}
+}
+
+func TestWorkloadRepoCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		appSet     *unstructured.Unstructured
+		apps       []unstructured.Unstructured
+		wantStatus string
+		wantReason string
+		wantIn     string
+	}{
+		{
+			name: "healthy",
+			appSet: applicationSet(
+				appSetCondition("ErrorOccurred", "False", "Successfully generated parameters for all Applications"),
+				appSetCondition("ResourcesUpToDate", "True", "All applications have been generated successfully"),
+			),
+			apps:       []unstructured.Unstructured{*application("workload-production")},
+			wantStatus: "True",
+			wantReason: "RepoAccessible",
+			wantIn:     "1 Application(s)",
+		},
+		{
+			name: "application set cannot read the repo",
+			appSet: applicationSet(appSetCondition("ErrorOccurred", "True",
+				"rpc error: code = NotFound desc = repository not found")),
+			wantStatus: "False",
+			wantReason: "RepositoryNotFound",
+			wantIn:     "ApplicationSet workloads: rpc error: code = NotFound desc = repository not found",
+		},
+		{
+			name:   "generated app path missing",
+			appSet: applicationSet(),
+			apps: []unstructured.Unstructured{
+				*application("workload-production", comparisonError(
+					"Failed to load target state: failed to generate manifest for source 2 of 2: rpc error: code = Unknown desc = workloads/vcluster-media: app path does not exist")),
+			},
+			wantStatus: "False",
+			wantReason: "PathNotFound",
+			wantIn:     "Application workload-production:",
+		},
+		{
+			name:   "revision missing",
+			appSet: applicationSet(),
+			apps: []unstructured.Unstructured{
+				*application("workload-production", comparisonError("Unable to resolve 'develop' to a commit SHA")),
+			},
+			wantStatus: "False",
+			wantReason: "RevisionNotFound",
+		},
+		{
+			name:   "private repo",
+			appSet: applicationSet(),
+			apps: []unstructured.Unstructured{
+				*application("workload-production", comparisonError("rpc error: code = Unknown desc = authentication required")),
+			},
+			wantStatus: "False",
+			wantReason: "AuthenticationFailed",
+		},
+		{
+			name: "other errors are not repo errors",
+			appSet: applicationSet(appSetCondition("ErrorOccurred", "True",
+				"failed to execute go template: map has no entry for key \"cluster_name\"")),
+			apps: []unstructured.Unstructured{
+				*application("workload-production", comparisonError("helm template: values.yaml: parse error")),
+			},
+			wantStatus: "True",
+			wantReason: "RepoAccessible",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := workloadRepoCondition(tt.appSet, tt.apps)
+			if c.Type != "WorkloadRepoAccessible" || c.Status != tt.wantStatus || c.Reason != tt.wantReason {
+				t.Errorf("condition = %+v, want %s/%s", c, tt.wantStatus, tt.wantReason)
+			}
+			if !strings.Contains(c.Message, tt.wantIn) {
+				t.Errorf("message = %q, want it to contain %q", c.Message, tt.wantIn)
+			}
+		})
+	}
+}
+
+func TestCheckWorkloadRepo(t *testing.T) {
+	vcluster := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		applicationSetGVR: "ApplicationSetList",
+		argoAppGVR:        "ApplicationList",
+	},
+		applicationSet(),
+		application("workload-production", comparisonError("repository not found")),
+	)
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	var gotNamespace, gotSecret string
+	r.vclusterClient = func(_ context.Context, namespace, secret string) (dynamic.Interface, error) {
+		gotNamespace, gotSecret = namespace, secret
+		return vcluster, nil
+	}
+
+	c := r.checkWorkloadRepo(context.Background(), "media", "vcluster-media", "")
+	if c.Status != "False" || c.Reason != "RepositoryNotFound" {
+		t.Errorf("condition = %+v, want False/RepositoryNotFound", c)
+	}
+	if gotNamespace != "vcluster-media" || gotSecret != "vc-media" {
+		t.Errorf("connected with %s/%s, want vcluster-media/vc-media", gotNamespace, gotSecret)
+	}
+
+	empty := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		applicationSetGVR: "ApplicationSetList",
+		argoAppGVR:        "ApplicationList",
+	})
+	r.vclusterClient = func(context.Context, string, string) (dynamic.Interface, error) { return empty, nil }
+	if c := r.checkWorkloadRepo(context.Background(), "media", "vcluster-media", ""); c.Status != "Unknown" || c.Reason != "ApplicationSetNotFound" {
+		t.Errorf("without the ApplicationSet: condition = %+v, want Unknown/ApplicationSetNotFound", c)
+	}
+
+	r.vclusterClient = func(context.Context, string, string) (dynamic.Interface, error) {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	if c := r.checkWorkloadRepo(context.Background(), "media", "vcluster-media", ""); c.Status != "Unknown" || c.Reason != "VClusterUnreachable" {
+		t.Errorf("unreachable vcluster: condition = %+v, want Unknown/VClusterUnreachable", c)
+	}
+}
+
+func TestKubeconfigClientMissingSecret(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	c := r.checkWorkloadRepo(context.Background(), "media", "vcluster-media", "")
+	if c.Status != "Unknown" || c.Reason != "VClusterUnreachable" || !strings.Contains(c.Message, "vcluster-media/vc-media") {
+		t.Errorf("condition = %+v, want Unknown/VClusterUnreachable naming the secret", c)
+	}
+}
@@ -1,5 +1,7 @@
 package kratixutil
 
+import "strconv"
+
 // ============================================================================
 // Resource Construction Helpers
 // ============================================================================
@@ -35,3 +37,18 @@ func DeleteResource(apiVersion, kind, name, namespace string) Resource {
 		},
 	}
 }
+
+// SyncWaveAnnotation orders how ArgoCD syncs an application's resources:
+// lower waves are applied first, and pruned last.
+const SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+
+// InDeleteWave annotates the delete output r with an ArgoCD sync wave.
+// ArgoCD removes resources from the highest wave down, waiting for each
+// wave to be gone before starting the next, so a dependent belongs in a
+// higher wave than what it depends on.
+func InDeleteWave(r Resource, wave int) Resource {
+	annotations := MergeStringMap(nil, r.Metadata.Annotations)
+	annotations[SyncWaveAnnotation] = strconv.Itoa(wave)
+	r.Metadata.Annotations = annotations
+	return r
+}
@@ -0,0 +1,146 @@
+package kratixutil
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+// ============================================================================
+// Pipeline Execution
+// ============================================================================
+
+// Steps a pipeline run goes through. The step a run fails in names the
+// Ready condition's reason (e.g. RenderFailed) and the failedStep status
+// field.
+const (
+	StepReadInput   = "ReadInput"
+	StepBuildConfig = "BuildConfig"
+	StepValidate    = "Validate"
+	StepRender      = "Render"
+	StepCleanup     = "Cleanup"
+	StepWriteStatus = "WriteStatus"
+)
+
+// Execution tracks one pipeline run: the resource request, the step the run
+// is in and the outputs it has written. Pipelines write through it so that
+// a failure can be reported on the resource request.
+type Execution struct {
+	SDK      *kratix.KratixSDK
+	Resource kratix.Resource
+
+	step    string
+	written []string
+}
+
+// Execute runs fn and, if it returns an error or panics, writes a Failed
+// status before returning the error: the message, the step that failed and
+// the outputs written before it. The caller still exits non-zero; the
+// status only tells the resource request's owner what happened.
+func Execute(sdk *kratix.KratixSDK, fn func(*Execution) error) (err error) {
+	x := &Execution{SDK: sdk, step: StepReadInput}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("panic in step %s: %v\n%s", x.step, p, debug.Stack())
+			err = fmt.Errorf("panic: %v", p)
+		}
+		if err == nil {
+			return
+		}
+		status := FailedStatus(x.Resource, x.step, err, x.written)
+		if serr := sdk.WriteStatus(status.Build()); serr != nil {
+			log.Printf("⚠ Warning: could not write failure status: %v", serr)
+		}
+	}()
+	return fn(x)
+}
+
+// ReadInput reads the resource request and keeps it for the failure status.
+func (x *Execution) ReadInput() (kratix.Resource, error) {
+	x.Step(StepReadInput)
+	resource, err := x.SDK.ReadResourceInput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource input: %w", err)
+	}
+	x.Resource = resource
+	return resource, nil
+}
+
+// Step records that the run has moved on to step.
+func (x *Execution) Step(step string) {
+	x.step = step
+}
+
+// Written returns the output paths written so far, in order.
+func (x *Execution) Written() []string {
+	return append([]string(nil), x.written...)
+}
+
+// WriteOutput writes content to path in the output directory and records
+// it, for pipelines with their own marshalling.
+func (x *Execution) WriteOutput(path string, content []byte) error {
+	if err := x.SDK.WriteOutput(path, content); err != nil {
+		return fmt.Errorf("write output %s: %w", path, err)
+	}
+	x.written = append(x.written, path)
+	return nil
+}
+
+// WriteYAML is WriteYAML that records path once written.
+func (x *Execution) WriteYAML(path string, obj interface{}) error {
+	if err := WriteYAML(x.SDK, path, obj); err != nil {
+		return err
+	}
+	x.written = append(x.written, path)
+	return nil
+}
+
+// WriteYAMLDocuments is WriteYAMLDocuments that records path once written.
+// Nothing is written or recorded for an empty docs.
+func (x *Execution) WriteYAMLDocuments(path string, docs []Resource) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := WriteYAMLDocuments(x.SDK, path, docs); err != nil {
+		return err
+	}
+	x.written = append(x.written, path)
+	return nil
+}
+
+// WriteStatus writes the run's final status.
+func (x *Execution) WriteStatus(status *StatusBuilder) error {
+	x.Step(StepWriteStatus)
+	if err := x.SDK.WriteStatus(status.Build()); err != nil {
+		return fmt.Errorf("failed to write status: %w", err)
+	}
+	return nil
+}
+
+// FailedStatus is the status of a run that failed in step: phase Failed
+// with the error, the outputs written before the failure, and Ready false
+// with reason <step>Failed. Validated or ResourcesRendered is set false too
+// when the run failed while validating or rendering. resource may be nil
+// when the input could not be read.
+func FailedStatus(resource kratix.Resource, step string, err error, written []string) *StatusBuilder {
+	if written == nil {
+		written = []string{}
+	}
+	message := err.Error()
+	reason := step + "Failed"
+	b := NewStatusBuilder(resource).
+		Summary(phase.Failed, message).
+		Set("failedStep", step).
+		Set("outputsWritten", written)
+	switch step {
+	case StepBuildConfig, StepValidate:
+		b.Condition(ConditionValidated, ConditionFalse, reason, message)
+	case StepRender:
+		b.Condition(ConditionResourcesRendered, ConditionFalse, reason,
+			fmt.Sprintf("%d resource(s) rendered before: %s", len(written), message))
+	}
+	return b.Condition(ConditionReady, ConditionFalse, reason, message)
+}
@@ -0,0 +1,95 @@
+package kratixutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kratix "github.com/syntasso/kratix-go"
+	"sigs.k8s.io/yaml"
+)
+
+// newSDK returns an SDK whose input holds object, and its metadata dir.
+func newSDK(t *testing.T, object string) (*kratix.KratixSDK, string) {
+	t.Helper()
+	input, output, metadata := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "object.yaml"), []byte(object), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(
+		kratix.WithInputDir(input),
+		kratix.WithOutputDir(output),
+		kratix.WithMetadataDir(metadata),
+	)
+	return sdk, metadata
+}
+
+func readStatusFile(t *testing.T, metadata string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(metadata, "status.yaml"))
+	if err != nil {
+		t.Fatalf("no status written: %v", err)
+	}
+	var status map[string]interface{}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestExecuteReportsFailedStep(t *testing.T) {
+	sdk, metadata := newSDK(t, asObject(t, 2, []byte("{}")))
+
+	err := Execute(sdk, func(x *Execution) error {
+		if _, err := x.ReadInput(); err != nil {
+			return err
+		}
+		x.Step(StepRender)
+		if err := x.WriteYAML("resources/a.yaml", map[string]string{"a": "1"}); err != nil {
+			return err
+		}
+		return errors.New("disk full")
+	})
+	if err == nil || err.Error() != "disk full" {
+		t.Fatalf("Execute() error = %v, want the pipeline's error", err)
+	}
+
+	status := readStatusFile(t, metadata)
+	if status["phase"] != "Failed" || status["message"] != "disk full" || status["failedStep"] != StepRender {
+		t.Errorf("status = %v, want phase Failed in step Render", status)
+	}
+	if written, _ := status["outputsWritten"].([]interface{}); len(written) != 1 || written[0] != "resources/a.yaml" {
+		t.Errorf("outputsWritten = %v, want [resources/a.yaml]", status["outputsWritten"])
+	}
+	conditions := parseConditions(status["conditions"])
+	for _, condType := range []string{ConditionReady, ConditionResourcesRendered} {
+		c, ok := findCondition(conditions, condType)
+		if !ok || c.Status != ConditionFalse || c.Reason != "RenderFailed" || c.ObservedGeneration != 2 {
+			t.Errorf("%s = %+v, want False/RenderFailed at generation 2", condType, c)
+		}
+	}
+}
+
+func TestExecuteRecoversPanics(t *testing.T) {
+	sdk, metadata := newSDK(t, "not: [valid")
+
+	err := Execute(sdk, func(x *Execution) error {
+		x.ReadInput()
+		x.Step(StepBuildConfig)
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Execute() returned nil after a panic")
+	}
+
+	status := readStatusFile(t, metadata)
+	if status["phase"] != "Failed" || status["failedStep"] != StepBuildConfig {
+		t.Errorf("status = %v, want phase Failed in step BuildConfig", status)
+	}
+	if written, ok := status["outputsWritten"].([]interface{}); !ok || len(written) != 0 {
+		t.Errorf("outputsWritten = %v, want an empty list", status["outputsWritten"])
+	}
+}
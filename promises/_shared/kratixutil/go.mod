@@ -3,7 +3,10 @@ module github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixu
 go 1.24.5
 
 require (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase v0.0.0
 	github.com/syntasso/kratix-go v0.1.0
+	k8s.io/apimachinery v0.33.3
+	k8s.io/client-go v0.32.1
 	sigs.k8s.io/yaml v1.6.0
 )
 
@@ -63,8 +66,6 @@ require (
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	k8s.io/api v0.32.1 // indirect
 	k8s.io/apiextensions-apiserver v0.32.1 // indirect
-	k8s.io/apimachinery v0.33.3 // indirect
-	k8s.io/client-go v0.32.1 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20250318190949-c8a335a9a2ff // indirect
 	k8s.io/utils v0.0.0-20241104100929-3ea5e8cea738 // indirect
@@ -74,3 +75,5 @@ require (
 	sigs.k8s.io/randfill v1.0.0 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.6.0 // indirect
 )
+
+replace github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase => ../phase
@@ -0,0 +1,138 @@
+package kratixutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Defaults of the 1Password Connect server and vault the kubeconfig sync
+// Jobs write through, when a request sets neither.
+const (
+	DefaultOnePasswordConnectHost = "https://connect.integratn.tech"
+	DefaultOnePasswordVault       = "homelab"
+)
+
+// ReadSecretKey reads key of the Secret namespace/name through the
+// pipeline pod's ServiceAccount, such as the Connect token a kubeconfig
+// sync Job writes with.
+func ReadSecretKey(ctx context.Context, namespace, name, key string) (string, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to get in-cluster config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	}
+	return string(value), nil
+}
+
+// OnePasswordConnect is a client of the 1Password Connect API at Host,
+// authenticated with Token. Pipelines use it for what the Kratix state
+// store cannot express, such as deleting an item when a request is deleted.
+type OnePasswordConnect struct {
+	Host  string
+	Token string
+	// Client sends the requests; nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+// DeleteItem deletes the item titled title from vault, a vault ID or name.
+// A vault or item that is already gone counts as deleted.
+func (c OnePasswordConnect) DeleteItem(ctx context.Context, vault, title string) error {
+	var vaults []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/vaults", &vaults); err != nil {
+		return err
+	}
+	// An ID match wins over a name match, as in the sync Job's lookup.
+	vaultID := ""
+	for _, v := range vaults {
+		if v.ID == vault {
+			vaultID = v.ID
+			break
+		}
+		if v.Name == vault && vaultID == "" {
+			vaultID = v.ID
+		}
+	}
+	if vaultID == "" {
+		log.Printf("1Password vault %s not found; nothing to delete", vault)
+		return nil
+	}
+
+	var items []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	filter := url.QueryEscape(fmt.Sprintf("title eq %q", title))
+	if err := c.do(ctx, http.MethodGet, "/vaults/"+vaultID+"/items?filter="+filter, &items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Title != title {
+			continue
+		}
+		if err := c.do(ctx, http.MethodDelete, "/vaults/"+vaultID+"/items/"+item.ID, nil); err != nil {
+			return err
+		}
+		log.Printf("✓ Deleted 1Password item %s from vault %s", title, vault)
+		return nil
+	}
+	log.Printf("1Password item %s not found in vault %s; nothing to delete", title, vault)
+	return nil
+}
+
+// do sends a request to the Connect API and decodes the response into out,
+// when set. A 404 on DELETE means the item is already gone.
+func (c OnePasswordConnect) do(ctx context.Context, method, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.Host, "/")+"/v1"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.Token))
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("1Password Connect %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("1Password Connect %s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("1Password Connect %s %s: %w", method, path, err)
+	}
+	return nil
+}
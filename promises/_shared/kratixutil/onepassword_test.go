@@ -0,0 +1,73 @@
+package kratixutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeConnect serves a Connect API holding vault homelab (ID v1) with the
+// items in items, by title, and records the items deleted.
+func fakeConnect(t *testing.T, items map[string]string, deleteStatus int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/vaults", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id":"v1","name":"homelab"},{"id":"v2","name":"v1"}]`))
+	})
+	mux.HandleFunc("GET /v1/vaults/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		for title, id := range items {
+			if r.URL.Query().Get("filter") == `title eq "`+title+`"` {
+				w.Write([]byte(`[{"id":"` + id + `","title":"` + title + `"}]`))
+				return
+			}
+		}
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("DELETE /v1/vaults/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.PathValue("id"))
+		w.WriteHeader(deleteStatus)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &deleted
+}
+
+func TestOnePasswordConnectDeleteItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		vault, title string
+		deleteStatus int
+		wantDeleted  []string
+		wantErr      bool
+	}{
+		{name: "by vault name", vault: "homelab", title: "vcluster-media-kubeconfig", deleteStatus: http.StatusNoContent, wantDeleted: []string{"i1"}},
+		{name: "by vault ID", vault: "v1", title: "vcluster-media-kubeconfig", deleteStatus: http.StatusNoContent, wantDeleted: []string{"i1"}},
+		{name: "item already deleted", vault: "homelab", title: "vcluster-media-kubeconfig", deleteStatus: http.StatusNotFound, wantDeleted: []string{"i1"}},
+		{name: "item absent", vault: "homelab", title: "vcluster-other-kubeconfig", deleteStatus: http.StatusNoContent},
+		{name: "vault absent", vault: "homelab-prod", title: "vcluster-media-kubeconfig", deleteStatus: http.StatusNoContent},
+		{name: "delete fails", vault: "homelab", title: "vcluster-media-kubeconfig", deleteStatus: http.StatusForbidden, wantDeleted: []string{"i1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, deleted := fakeConnect(t, map[string]string{"vcluster-media-kubeconfig": "i1"}, tt.deleteStatus)
+			err := OnePasswordConnect{Host: srv.URL + "/", Token: "token\n"}.DeleteItem(context.Background(), tt.vault, tt.title)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteItem() error = %v, want error %t", err, tt.wantErr)
+			}
+			if len(*deleted) != len(tt.wantDeleted) || (len(tt.wantDeleted) > 0 && (*deleted)[0] != tt.wantDeleted[0]) {
+				t.Errorf("deleted = %v, want %v", *deleted, tt.wantDeleted)
+			}
+		})
+	}
+
+	srv, _ := fakeConnect(t, nil, http.StatusNoContent)
+	if err := (OnePasswordConnect{Host: srv.URL, Token: "wrong"}).DeleteItem(context.Background(), "homelab", "x"); err == nil {
+		t.Error("DeleteItem() with a bad token succeeded")
+	}
+}
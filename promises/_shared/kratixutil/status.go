@@ -0,0 +1,219 @@
+package kratixutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+// ============================================================================
+// Resource Status
+// ============================================================================
+
+// Condition types every pipeline reports. Ready is what
+// `kubectl wait --for=condition=Ready` waits on.
+const (
+	ConditionReady             = phase.ConditionReady
+	ConditionValidated         = phase.ConditionValidated
+	ConditionResourcesRendered = phase.ConditionResourcesRendered
+)
+
+// ConditionStatus is the status of a Condition.
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition has the shape of metav1.Condition. Reason must be CamelCase.
+type Condition struct {
+	Type               string          `json:"type"`
+	Status             ConditionStatus `json:"status"`
+	ObservedGeneration int64           `json:"observedGeneration,omitempty"`
+	LastTransitionTime string          `json:"lastTransitionTime"`
+	Reason             string          `json:"reason"`
+	Message            string          `json:"message"`
+}
+
+// StatusBuilder assembles a resource request's status: the summary fields
+// (phase, message and promise-specific values), observedGeneration, and
+// conditions merged with those already on the resource.
+type StatusBuilder struct {
+	generation int64
+	prior      []Condition
+	conditions []Condition
+	fields     map[string]interface{}
+	now        func() time.Time
+}
+
+// NewStatusBuilder starts a status for resource, reading its
+// metadata.generation and current conditions. A nil resource starts an
+// empty status.
+func NewStatusBuilder(resource kratix.Resource) *StatusBuilder {
+	b := &StatusBuilder{
+		fields: map[string]interface{}{},
+		now:    time.Now,
+	}
+	if resource == nil {
+		return b
+	}
+	// The SDK decodes the input object via JSON, so numbers arrive as
+	// float64 and unstructured's int64 accessors do not see them.
+	if g, err := resource.GetValue("metadata.generation"); err == nil {
+		b.generation = toInt64(g)
+	}
+	if status, err := resource.GetValue("status"); err == nil {
+		if m, ok := status.(map[string]interface{}); ok {
+			b.prior = parseConditions(m["conditions"])
+		}
+	}
+	return b
+}
+
+// Summary sets the phase and message fields kept for clients that predate
+// conditions.
+func (b *StatusBuilder) Summary(phase, message string) *StatusBuilder {
+	b.fields["phase"] = phase
+	b.fields["message"] = message
+	return b
+}
+
+// Set sets a top-level status field.
+func (b *StatusBuilder) Set(key string, value interface{}) *StatusBuilder {
+	b.fields[key] = value
+	return b
+}
+
+// Condition sets a condition for the current generation. Its
+// lastTransitionTime is carried over from the resource when the status has
+// not changed, so repeated runs do not look like transitions.
+func (b *StatusBuilder) Condition(condType string, status ConditionStatus, reason, message string) *StatusBuilder {
+	c := Condition{
+		Type:               condType,
+		Status:             status,
+		ObservedGeneration: b.generation,
+		LastTransitionTime: b.now().UTC().Format(time.RFC3339),
+		Reason:             reason,
+		Message:            message,
+	}
+	if old, ok := findCondition(b.prior, condType); ok && old.Status == status && old.LastTransitionTime != "" {
+		c.LastTransitionTime = old.LastTransitionTime
+	}
+	for i := range b.conditions {
+		if b.conditions[i].Type == condType {
+			b.conditions[i] = c
+			return b
+		}
+	}
+	b.conditions = append(b.conditions, c)
+	return b
+}
+
+// InitCondition sets a condition only if the resource does not already
+// report it for the current generation. Pipelines use it for conditions a
+// controller takes over once the request is scheduled, such as Ready for
+// vclusters.
+func (b *StatusBuilder) InitCondition(condType string, status ConditionStatus, reason, message string) *StatusBuilder {
+	if old, ok := findCondition(b.prior, condType); ok && old.ObservedGeneration == b.generation {
+		return b
+	}
+	return b.Condition(condType, status, reason, message)
+}
+
+// Build returns the status to write. Conditions the builder did not set,
+// such as those of Kratix or the status reconciler, are kept as they are;
+// the builder's replace their namesakes in place or are appended.
+func (b *StatusBuilder) Build() kratix.Status {
+	data := make(map[string]interface{}, len(b.fields)+2)
+	for k, v := range b.fields {
+		data[k] = v
+	}
+	data["observedGeneration"] = b.generation
+
+	conditions := make([]Condition, 0, len(b.prior)+len(b.conditions))
+	set := map[string]bool{}
+	for _, old := range b.prior {
+		if c, ok := findCondition(b.conditions, old.Type); ok {
+			conditions = append(conditions, c)
+			set[c.Type] = true
+			continue
+		}
+		conditions = append(conditions, old)
+	}
+	for _, c := range b.conditions {
+		if !set[c.Type] {
+			conditions = append(conditions, c)
+		}
+	}
+	data["conditions"] = conditions
+	return kratix.NewStatusFromMap(data)
+}
+
+// ConfiguredStatus starts the status of a successful configure run: the
+// phase and message, and Validated and ResourcesRendered true. The caller
+// adds Ready and any promise-specific fields.
+func ConfiguredStatus(resource kratix.Resource, phase, message string, rendered int) *StatusBuilder {
+	return NewStatusBuilder(resource).
+		Summary(phase, message).
+		Condition(ConditionValidated, ConditionTrue, "SpecValid", "Spec passed validation").
+		Condition(ConditionResourcesRendered, ConditionTrue, "Rendered", fmt.Sprintf("%d resource(s) rendered", rendered))
+}
+
+// DeletingStatus is the status of a delete run: phase Deleting and Ready
+// false.
+func DeletingStatus(resource kratix.Resource, message string) *StatusBuilder {
+	return NewStatusBuilder(resource).
+		Summary(phase.Deleting, message).
+		Condition(ConditionReady, ConditionFalse, phase.Deleting, message)
+}
+
+func findCondition(conditions []Condition, condType string) (Condition, bool) {
+	for _, c := range conditions {
+		if c.Type == condType {
+			return c, true
+		}
+	}
+	return Condition{}, false
+}
+
+// parseConditions reads a status.conditions list from an unstructured
+// object, skipping malformed entries.
+func parseConditions(v interface{}) []Condition {
+	list, _ := v.([]interface{})
+	var conditions []Condition
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := Condition{}
+		c.Type, _ = m["type"].(string)
+		if c.Type == "" {
+			continue
+		}
+		status, _ := m["status"].(string)
+		c.Status = ConditionStatus(status)
+		c.LastTransitionTime, _ = m["lastTransitionTime"].(string)
+		c.Reason, _ = m["reason"].(string)
+		c.Message, _ = m["message"].(string)
+		c.ObservedGeneration = toInt64(m["observedGeneration"])
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,211 @@
+package kratixutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	kratix "github.com/syntasso/kratix-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// readResource loads object through the SDK, as a pipeline would.
+func readResource(t *testing.T, object string) kratix.Resource {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "object.yaml"), []byte(object), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resource, err := kratix.New(kratix.WithInputDir(dir)).ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resource
+}
+
+// writeStatus writes status through the SDK and returns status.yaml.
+func writeStatus(t *testing.T, status kratix.Status) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	if err := kratix.New(kratix.WithMetadataDir(dir)).WriteStatus(status); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "status.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// asObject wraps a written status in a resource request for the next run.
+func asObject(t *testing.T, generation int, status []byte) string {
+	t.Helper()
+	var s map[string]interface{}
+	if err := yaml.Unmarshal(status, &s); err != nil {
+		t.Fatal(err)
+	}
+	out, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "platform.integratn.tech/v1alpha1",
+		"kind":       "HTTPService",
+		"metadata":   map[string]interface{}{"name": "web", "generation": generation},
+		"status":     s,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func configured(resource kratix.Resource, now time.Time, ready ConditionStatus) kratix.Status {
+	b := NewStatusBuilder(resource)
+	b.now = func() time.Time { return now }
+	return b.Summary("Configured", "web configured").
+		Set("namespace", "apps").
+		Condition(ConditionValidated, ConditionTrue, "SpecValid", "spec is valid").
+		Condition(ConditionResourcesRendered, ConditionTrue, "Rendered", "3 resources rendered").
+		Condition(ConditionReady, ready, "Configured", "web configured").
+		Build()
+}
+
+func conditionsOf(t *testing.T, status []byte) map[string]metav1.Condition {
+	t.Helper()
+	var s struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	}
+	if err := yaml.Unmarshal(status, &s); err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]metav1.Condition{}
+	for _, c := range s.Conditions {
+		out[c.Type] = c
+	}
+	return out
+}
+
+func TestStatusBuilderPreservesTransitionTime(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	first := writeStatus(t, configured(readResource(t, asObject(t, 1, nil)), t0, ConditionTrue))
+	if c := conditionsOf(t, first)[ConditionReady]; !c.LastTransitionTime.Time.Equal(t0) {
+		t.Fatalf("first run: Ready lastTransitionTime = %v, want %v", c.LastTransitionTime, t0)
+	}
+
+	// Re-running later with the same outcome keeps the transition time, even
+	// after the spec changes.
+	second := writeStatus(t, configured(readResource(t, asObject(t, 2, first)), t0.Add(time.Hour), ConditionTrue))
+	for condType, c := range conditionsOf(t, second) {
+		if !c.LastTransitionTime.Time.Equal(t0) {
+			t.Errorf("%s: lastTransitionTime = %v, want unchanged %v", condType, c.LastTransitionTime, t0)
+		}
+		if c.ObservedGeneration != 2 {
+			t.Errorf("%s: observedGeneration = %d, want 2", condType, c.ObservedGeneration)
+		}
+	}
+
+	// A status change is a transition.
+	t2 := t0.Add(2 * time.Hour)
+	third := writeStatus(t, configured(readResource(t, asObject(t, 2, second)), t2, ConditionFalse))
+	conds := conditionsOf(t, third)
+	if c := conds[ConditionReady]; !c.LastTransitionTime.Time.Equal(t2) {
+		t.Errorf("Ready flipped: lastTransitionTime = %v, want %v", c.LastTransitionTime, t2)
+	}
+	if c := conds[ConditionValidated]; !c.LastTransitionTime.Time.Equal(t0) {
+		t.Errorf("Validated unchanged: lastTransitionTime = %v, want %v", c.LastTransitionTime, t0)
+	}
+}
+
+func TestStatusBuilderKeepsForeignConditions(t *testing.T) {
+	object := asObject(t, 3, []byte(`
+phase: Ready
+conditions:
+- type: ConfigureWorkflowCompleted
+  status: "True"
+  reason: PipelinesExecutedSuccessfully
+  message: Pipelines completed
+  lastTransitionTime: "2026-03-01T08:00:00Z"
+- type: Ready
+  status: "True"
+  observedGeneration: 3
+  reason: AllHealthy
+  message: All components healthy
+  lastTransitionTime: "2026-03-01T08:30:00Z"
+`))
+	b := NewStatusBuilder(readResource(t, object))
+	status := b.Summary("Scheduled", "scheduled").
+		Condition(ConditionResourcesRendered, ConditionTrue, "Rendered", "rendered").
+		InitCondition(ConditionReady, ConditionFalse, "Scheduled", "waiting for ArgoCD").
+		Build()
+	conds := conditionsOf(t, writeStatus(t, status))
+
+	if _, ok := conds["ConfigureWorkflowCompleted"]; !ok {
+		t.Error("Kratix's condition was dropped")
+	}
+	if c := conds[ConditionReady]; c.Reason != "AllHealthy" {
+		t.Errorf("InitCondition overwrote Ready for the same generation: %+v", c)
+	}
+
+	// A new generation resets it.
+	status = NewStatusBuilder(readResource(t, asObject(t, 4, writeStatus(t, status)))).
+		InitCondition(ConditionReady, ConditionFalse, "Scheduled", "waiting for ArgoCD").
+		Build()
+	if c := conditionsOf(t, writeStatus(t, status))[ConditionReady]; c.Reason != "Scheduled" || c.ObservedGeneration != 4 {
+		t.Errorf("InitCondition on a new generation: %+v", c)
+	}
+}
+
+// TestStatusConditionShape checks the written status decodes strictly into
+// metav1.Condition with valid values, which is what kubectl wait reads.
+func TestStatusConditionShape(t *testing.T) {
+	data := writeStatus(t, configured(readResource(t, asObject(t, 7, nil)), time.Now(), ConditionTrue))
+
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		ObservedGeneration int64              `json:"observedGeneration"`
+		Phase              string             `json:"phase"`
+		Message            string             `json:"message"`
+		Namespace          string             `json:"namespace"`
+		Conditions         []metav1.Condition `json:"conditions"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		t.Fatalf("status does not decode strictly: %v\n%s", err, data)
+	}
+	if s.ObservedGeneration != 7 || s.Phase != "Configured" || s.Namespace != "apps" {
+		t.Errorf("summary fields: %+v", s)
+	}
+
+	// Validation rules from metav1.Condition.
+	reason := regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+	want := []string{ConditionValidated, ConditionResourcesRendered, ConditionReady}
+	if len(s.Conditions) != len(want) {
+		t.Fatalf("got %d conditions, want %d", len(s.Conditions), len(want))
+	}
+	for i, c := range s.Conditions {
+		if c.Type != want[i] {
+			t.Errorf("conditions[%d].type = %q, want %q", i, c.Type, want[i])
+		}
+		switch c.Status {
+		case metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown:
+		default:
+			t.Errorf("%s: status %q", c.Type, c.Status)
+		}
+		if !reason.MatchString(c.Reason) {
+			t.Errorf("%s: reason %q is not CamelCase", c.Type, c.Reason)
+		}
+		if c.LastTransitionTime.IsZero() {
+			t.Errorf("%s: lastTransitionTime missing", c.Type)
+		}
+		if c.ObservedGeneration != 7 {
+			t.Errorf("%s: observedGeneration = %d", c.Type, c.ObservedGeneration)
+		}
+	}
+}
@@ -140,6 +140,20 @@ type ArgoCDClusterRegistrationSpec struct {
 	ClusterLabels       map[string]string `json:"clusterLabels,omitempty"`
 	ClusterAnnotations  map[string]string `json:"clusterAnnotations,omitempty"`
 	SyncJobName         string            `json:"syncJobName,omitempty"`
+	OnePassword         *OnePasswordRef   `json:"onePassword,omitempty"`
+	// OnePasswordConnectHost is the Connect server the sync job writes the
+	// kubeconfig item through; empty keeps the promise default.
+	OnePasswordConnectHost string `json:"onePasswordConnectHost,omitempty"`
+}
+
+// OnePasswordRef selects the 1Password vault a promise reads and writes, and
+// the ClusterSecretStore scoped to it. Vault is a name or ID.
+// DeleteItemOnDestroy, when set, decides whether deleting the request
+// deletes the item it wrote.
+type OnePasswordRef struct {
+	Vault               string `json:"vault,omitempty"`
+	SecretStore         string `json:"secretStore,omitempty"`
+	DeleteItemOnDestroy *bool  `json:"deleteItemOnDestroy,omitempty"`
 }
 
 // ============================================================================
@@ -0,0 +1,3 @@
+module github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase
+
+go 1.24.5
@@ -0,0 +1,115 @@
+// Package phase is the vocabulary of resource phases and condition types
+// the promise pipelines write, the platform status reconciler computes and
+// hctl displays.
+//
+// It is its own module so that cli, images/platform-status-reconciler and
+// promises/_shared/kratixutil all require the same copy, through a replace
+// directive.
+package phase
+
+import "strings"
+
+// Phases a resource reports in status.phase, and as the reason of a False
+// Ready condition.
+const (
+	// Scheduled: a pipeline rendered the resources and ArgoCD has not
+	// picked them up yet.
+	Scheduled = "Scheduled"
+	// Configured: a pipeline rendered the resources of a promise no
+	// reconciler follows up on.
+	Configured = "Configured"
+	// Progressing: the resources are syncing or starting.
+	Progressing = "Progressing"
+	// Ready: everything is synced and healthy.
+	Ready = "Ready"
+	// Degraded: running, with some components unhealthy.
+	Degraded = "Degraded"
+	// Failed: a pipeline failed, or components stayed unhealthy past the
+	// reconciler's threshold.
+	Failed = "Failed"
+	// Paused: scaled to zero on purpose ('hctl vcluster pause').
+	Paused = "Paused"
+	// Suspended: ArgoCD reports the Application suspended.
+	Suspended = "Suspended"
+	// Deleting: the delete pipeline ran.
+	Deleting = "Deleting"
+	// Unknown: no signal, or a phase this vocabulary does not know.
+	Unknown = "Unknown"
+)
+
+// All is every phase, in lifecycle order.
+var All = []string{Scheduled, Configured, Progressing, Ready, Degraded, Failed, Paused, Suspended, Deleting, Unknown}
+
+// Condition types in status.conditions.
+const (
+	// ConditionReady is what `kubectl wait --for=condition=Ready` waits
+	// on. Pipelines set it; the reconciler owns it for vclusters.
+	ConditionReady = "Ready"
+	// ConditionValidated and ConditionResourcesRendered are set by every
+	// pipeline run.
+	ConditionValidated         = "Validated"
+	ConditionResourcesRendered = "ResourcesRendered"
+	// The rest are set by the reconciler on vclusters.
+	ConditionArgoSynced             = "ArgoSynced"
+	ConditionPodsReady              = "PodsReady"
+	ConditionKubeconfigAvailable    = "KubeconfigAvailable"
+	ConditionCertificatesValid      = "CertificatesValid"
+	ConditionWorkloadRepoAccessible = "WorkloadRepoAccessible"
+	ConditionNamespaceStuck         = "NamespaceStuck"
+	ConditionSpecDrift              = "SpecDrift"
+	ConditionBootstrapApplied       = "BootstrapApplied"
+)
+
+// AllConditions is every condition type.
+var AllConditions = []string{
+	ConditionReady, ConditionValidated, ConditionResourcesRendered,
+	ConditionArgoSynced, ConditionPodsReady, ConditionKubeconfigAvailable,
+	ConditionCertificatesValid, ConditionWorkloadRepoAccessible, ConditionNamespaceStuck,
+	ConditionSpecDrift, ConditionBootstrapApplied,
+}
+
+// Legacy maps phase strings found in existing resource statuses, written
+// by older pipelines and by hand, to the phase they mean. Keys are lower
+// case.
+var Legacy = map[string]string{
+	"invalid":      Failed,
+	"error":        Failed,
+	"pending":      Scheduled,
+	"provisioning": Progressing,
+	"healthy":      Ready,
+	"unhealthy":    Degraded,
+	"terminating":  Deleting,
+}
+
+// Normalize returns the phase p means: p itself when it is a phase, the
+// phase a legacy string or differently cased phase stands for, Failed for
+// a pipeline's <Step>Failed Ready reason, and Unknown for anything else.
+// An empty p stays empty, so callers can tell an unreported phase apart.
+func Normalize(p string) string {
+	if p == "" || Known(p) {
+		return p
+	}
+	lower := strings.ToLower(strings.TrimSpace(p))
+	for _, known := range All {
+		if strings.ToLower(known) == lower {
+			return known
+		}
+	}
+	if mapped, ok := Legacy[lower]; ok {
+		return mapped
+	}
+	if strings.HasSuffix(p, "Failed") {
+		return Failed
+	}
+	return Unknown
+}
+
+// Known reports whether p is a phase of this vocabulary.
+func Known(p string) bool {
+	for _, known := range All {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package phase
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestListsComplete checks every constant is listed in All or
+// AllConditions, so code ranging over them covers a new one.
+func TestListsComplete(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "phase.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				value, err := strconv.Unquote(vs.Values[i].(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				list, listName := All, "All"
+				if strings.HasPrefix(name.Name, "Condition") {
+					list, listName = AllConditions, "AllConditions"
+				}
+				if !contains(list, value) {
+					t.Errorf("%s (%q) is missing from %s", name.Name, value, listName)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, p := range All {
+		if got := Normalize(p); got != p {
+			t.Errorf("Normalize(%q) = %q, want it unchanged", p, got)
+		}
+	}
+	for legacy, want := range Legacy {
+		if !Known(want) {
+			t.Errorf("Legacy[%q] = %q, which is not a phase", legacy, want)
+		}
+		if got := Normalize(strings.ToUpper(legacy[:1]) + legacy[1:]); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", legacy, got, want)
+		}
+	}
+	tests := map[string]string{
+		"":               "",
+		"ready":          Ready,
+		"DELETING":       Deleting,
+		"ValidateFailed": Failed,
+		"RenderFailed":   Failed,
+		"Exploded":       Unknown,
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,8 @@ module github.com/jamesatintegratnio/gitops_homelab_2_0/promises/argocd-applicat
 go 1.24.5
 
 require (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil v0.0.0
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase v0.0.0
 	github.com/syntasso/kratix-go v0.1.0
 	sigs.k8s.io/yaml v1.6.0
 )
@@ -74,3 +76,8 @@ require (
 	sigs.k8s.io/randfill v1.0.0 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.6.0 // indirect
 )
+
+replace (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase => ../../../../_shared/phase
+)
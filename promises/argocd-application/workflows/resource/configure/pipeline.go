@@ -0,0 +1,356 @@
+// Package argocdapplication is the Kratix pipeline for the
+// argocd-application promise, built into the platform-pipelines binary.
+package argocdapplication
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+// Run executes the pipeline for the workflow action Kratix set (configure
+// or delete). A failed or panicking run still writes a Failed status.
+func Run(sdk *kratix.KratixSDK) error {
+	return u.Execute(sdk, run)
+}
+
+func run(x *u.Execution) error {
+	sdk := x.SDK
+	log.Printf("=== ArgoCD Application Promise Pipeline ===")
+	log.Printf("Action: %s", sdk.WorkflowAction())
+
+	resource, err := x.ReadInput()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Processing resource: %s/%s",
+		resource.GetNamespace(), resource.GetName())
+
+	x.Step(u.StepBuildConfig)
+	if sdk.WorkflowAction() == "configure" {
+		if err := handleConfigure(x); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
+		}
+	} else if sdk.WorkflowAction() == "delete" {
+		if err := handleDelete(x); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
+		}
+	} else {
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
+	}
+
+	log.Println("=== Pipeline completed successfully ===")
+	return nil
+}
+
+func handleConfigure(x *u.Execution) error {
+	resource := x.Resource
+	name, err := getStringValue(resource, "spec.name")
+	if err != nil {
+		return fmt.Errorf("spec.name is required: %w", err)
+	}
+
+	namespace, _ := getStringValueWithDefault(resource, "spec.namespace", "argocd")
+	project, err := getStringValue(resource, "spec.project")
+	if err != nil {
+		return fmt.Errorf("spec.project is required: %w", err)
+	}
+
+	annotations := extractStringMap(resource, "spec.annotations")
+	labels := extractStringMap(resource, "spec.labels")
+	finalizers := extractStringSlice(resource, "spec.finalizers")
+
+	// Extract sources
+	source, sources, err := extractSources(resource)
+	if err != nil {
+		return err
+	}
+
+	// Extract destination
+	destServer, err := getStringValue(resource, "spec.destination.server")
+	if err != nil {
+		return fmt.Errorf("spec.destination.server is required: %w", err)
+	}
+	destNamespace, err := getStringValue(resource, "spec.destination.namespace")
+	if err != nil {
+		return fmt.Errorf("spec.destination.namespace is required: %w", err)
+	}
+
+	// Extract sync policy
+	syncPolicy, _ := resource.GetValue("spec.syncPolicy")
+
+	ignoreDifferences, err := extractIgnoreDifferences(resource)
+	if err != nil {
+		return err
+	}
+	revisionHistoryLimit, err := extractRevisionHistoryLimit(resource)
+	if err != nil {
+		return err
+	}
+
+	// Build ArgoCD Application
+	app := Resource{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+			Finalizers:  finalizers,
+		},
+		Spec: ApplicationSpec{
+			Project: project,
+			Source:  source,
+			Sources: sources,
+			Destination: Destination{
+				Server:    destServer,
+				Namespace: destNamespace,
+			},
+			SyncPolicy:           syncPolicy,
+			IgnoreDifferences:    ignoreDifferences,
+			RevisionHistoryLimit: revisionHistoryLimit,
+		},
+	}
+
+	x.Step(u.StepRender)
+	if err := writeYAML(x, "resources/application.yaml", app); err != nil {
+		return fmt.Errorf("write application: %w", err)
+	}
+	log.Printf("✓ Rendered ArgoCD Application: %s", name)
+
+	message := fmt.Sprintf("Application %s configured", name)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, 1).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("applicationName", name).
+		Set("namespace", namespace).
+		Set("project", project)
+
+	if err := x.WriteStatus(status); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func handleDelete(x *u.Execution) error {
+	resource := x.Resource
+	name, err := getStringValue(resource, "spec.name")
+	if err != nil {
+		return fmt.Errorf("spec.name is required: %w", err)
+	}
+
+	namespace, _ := getStringValueWithDefault(resource, "spec.namespace", "argocd")
+
+	deleteObj := Resource{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	x.Step(u.StepRender)
+	if err := writeYAML(x, "resources/delete-application.yaml", deleteObj); err != nil {
+		return fmt.Errorf("write delete application: %w", err)
+	}
+	log.Printf("✓ Delete scheduled for Application: %s", name)
+
+	status := u.DeletingStatus(resource, fmt.Sprintf("Application %s scheduled for deletion", name))
+
+	if err := x.WriteStatus(status); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// extractSources reads spec.source, or spec.sources for a multi-source
+// Application. Exactly one of the two must be set.
+func extractSources(resource kratix.Resource) (*AppSource, []AppSource, error) {
+	single, _ := resource.GetValue("spec.source")
+	multi, _ := resource.GetValue("spec.sources")
+	switch {
+	case single != nil && multi != nil:
+		return nil, nil, fmt.Errorf("spec.source and spec.sources are mutually exclusive")
+	case single != nil:
+		source, err := parseSource(single, "spec.source", false)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &source, nil, nil
+	case multi != nil:
+		arr, ok := multi.([]interface{})
+		if !ok || len(arr) == 0 {
+			return nil, nil, fmt.Errorf("spec.sources must be a non-empty list of sources")
+		}
+		sources := make([]AppSource, 0, len(arr))
+		for i, item := range arr {
+			source, err := parseSource(item, fmt.Sprintf("spec.sources[%d]", i), true)
+			if err != nil {
+				return nil, nil, err
+			}
+			sources = append(sources, source)
+		}
+		return nil, sources, nil
+	}
+	return nil, nil, fmt.Errorf("spec.source or spec.sources is required")
+}
+
+// parseSource decodes the source at field. A source sets exactly one of
+// chart and path; in spec.sources it may instead set only ref, to serve
+// value files to the other sources. kustomize and directory apply to a path.
+func parseSource(val interface{}, field string, multi bool) (AppSource, error) {
+	var source AppSource
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return source, fmt.Errorf("%s must be an object", field)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return source, fmt.Errorf("%s: %w", field, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&source); err != nil {
+		return source, fmt.Errorf("%s is invalid: %w", field, err)
+	}
+
+	if source.RepoURL == "" {
+		return source, fmt.Errorf("%s.repoURL is required", field)
+	}
+	if source.TargetRevision == "" {
+		return source, fmt.Errorf("%s.targetRevision is required", field)
+	}
+	if source.Ref != "" && !multi {
+		return source, fmt.Errorf("%s.ref only applies to spec.sources", field)
+	}
+	switch {
+	case source.Chart != "" && source.Path != "":
+		return source, fmt.Errorf("%s sets both chart and path; set exactly one", field)
+	case source.Chart == "" && source.Path == "" && multi && source.Ref == "":
+		return source, fmt.Errorf("%s needs a chart, a path or a ref", field)
+	case source.Chart == "" && source.Path == "" && !multi:
+		return source, fmt.Errorf("%s needs a chart or a path", field)
+	}
+	if source.Path == "" && (source.Kustomize != nil || source.Directory != nil) {
+		return source, fmt.Errorf("%s: kustomize and directory need a path", field)
+	}
+	types := 0
+	for _, set := range []bool{source.Helm != nil, source.Kustomize != nil, source.Directory != nil} {
+		if set {
+			types++
+		}
+	}
+	if types > 1 {
+		return source, fmt.Errorf("%s sets more than one of helm, kustomize and directory", field)
+	}
+	return source, nil
+}
+
+// extractIgnoreDifferences reads spec.ignoreDifferences. Each entry needs a
+// kind.
+func extractIgnoreDifferences(resource kratix.Resource) ([]ResourceIgnoreDifferences, error) {
+	val, err := resource.GetValue("spec.ignoreDifferences")
+	if err != nil || val == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("spec.ignoreDifferences: %w", err)
+	}
+	var ignores []ResourceIgnoreDifferences
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&ignores); err != nil {
+		return nil, fmt.Errorf("spec.ignoreDifferences is invalid: %w", err)
+	}
+
+	for i, ignore := range ignores {
+		if ignore.Kind == "" {
+			return nil, fmt.Errorf("spec.ignoreDifferences[%d].kind is required", i)
+		}
+	}
+	return ignores, nil
+}
+
+// extractRevisionHistoryLimit reads spec.revisionHistoryLimit, or returns
+// nil to keep ArgoCD's default.
+func extractRevisionHistoryLimit(resource kratix.Resource) (*int64, error) {
+	if val, err := resource.GetValue("spec.revisionHistoryLimit"); err != nil || val == nil {
+		return nil, nil
+	}
+	limit, err := u.GetIntValue(resource, "spec.revisionHistoryLimit")
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("spec.revisionHistoryLimit must be a non-negative integer")
+	}
+	n := int64(limit)
+	return &n, nil
+}
+
+// Helper functions
+
+func getStringValue(resource kratix.Resource, path string) (string, error) {
+	val, err := resource.GetValue(path)
+	if err != nil {
+		return "", err
+	}
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return "", fmt.Errorf("%s is not a string", path)
+}
+
+func getStringValueWithDefault(resource kratix.Resource, path, defaultValue string) (string, error) {
+	val, err := getStringValue(resource, path)
+	if err != nil || val == "" {
+		return defaultValue, nil
+	}
+	return val, nil
+}
+
+func extractStringMap(resource kratix.Resource, path string) map[string]string {
+	val, err := resource.GetValue(path)
+	if err != nil {
+		return nil
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string)
+	for k, v := range m {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func extractStringSlice(resource kratix.Resource, path string) []string {
+	val, err := resource.GetValue(path)
+	if err != nil {
+		return nil
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(arr))
+	for _, v := range arr {
+		if str, ok := v.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
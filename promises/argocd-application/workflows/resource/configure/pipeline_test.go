@@ -0,0 +1,265 @@
+package argocdapplication
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	kratix "github.com/syntasso/kratix-go"
+	"sigs.k8s.io/yaml"
+)
+
+const applicationSpec = `apiVersion: platform.integratn.tech/v1alpha1
+kind: ArgoCDApplication
+metadata:
+  name: media-apps
+  namespace: platform-requests
+spec:
+  name: media-apps
+  project: vcluster-media
+  destination:
+    server: https://media.integratn.tech:443
+    namespace: media
+`
+
+// configure runs handleConfigure for applicationSpec plus extra spec lines
+// and returns the spec of the rendered Application.
+func configure(t *testing.T, extra string) (map[string]interface{}, error) {
+	t.Helper()
+	return configureInput(t, []byte(applicationSpec+extra))
+}
+
+// configureInput runs handleConfigure against input and returns the spec of
+// the rendered Application.
+func configureInput(t *testing.T, input []byte) (map[string]interface{}, error) {
+	t.Helper()
+	t.Setenv("KRATIX_WORKFLOW_ACTION", "configure")
+	t.Setenv("KRATIX_WORKFLOW_TYPE", "resource")
+	t.Setenv("KRATIX_PROMISE_NAME", "argocd-application")
+
+	inputDir, outputDir, metadataDir := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "object.yaml"), input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(
+		kratix.WithInputDir(inputDir),
+		kratix.WithOutputDir(outputDir),
+		kratix.WithMetadataDir(metadataDir),
+	)
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handleConfigure(&u.Execution{SDK: sdk, Resource: resource}); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "resources", "application.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var app map[string]interface{}
+	if err := yaml.Unmarshal(data, &app); err != nil {
+		t.Fatal(err)
+	}
+	return app["spec"].(map[string]interface{}), nil
+}
+
+// decode parses the YAML of an expected value.
+func decode(t *testing.T, doc string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestKustomizeSource(t *testing.T) {
+	spec, err := configure(t, `  source:
+    repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git
+    targetRevision: main
+    path: apps/media/overlays/prod
+    kustomize:
+      namePrefix: prod-
+      images:
+        - ghcr.io/example/api:1.4.2
+      patches:
+        - target:
+            kind: Deployment
+            name: api
+          patch: |-
+            - op: replace
+              path: /spec/replicas
+              value: 2
+`)
+	if err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	want := decode(t, `repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git
+targetRevision: main
+path: apps/media/overlays/prod
+kustomize:
+  namePrefix: prod-
+  images:
+    - ghcr.io/example/api:1.4.2
+  patches:
+    - target:
+        kind: Deployment
+        name: api
+      patch: |-
+        - op: replace
+          path: /spec/replicas
+          value: 2
+`)
+	if !reflect.DeepEqual(spec["source"], want) {
+		t.Errorf("source = %v, want %v", spec["source"], want)
+	}
+	if _, ok := spec["sources"]; ok {
+		t.Errorf("sources = %v, want unset", spec["sources"])
+	}
+}
+
+func TestDirectorySource(t *testing.T) {
+	spec, err := configure(t, `  source:
+    repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git
+    targetRevision: main
+    path: apps/media/manifests
+    directory:
+      recurse: true
+`)
+	if err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	if got := spec["source"].(map[string]interface{})["directory"]; !reflect.DeepEqual(got, map[string]interface{}{"recurse": true}) {
+		t.Errorf("source.directory = %v, want recurse", got)
+	}
+}
+
+func TestMultipleSources(t *testing.T) {
+	spec, err := configure(t, `  sources:
+    - repoURL: https://charts.bitnami.com/bitnami
+      chart: redis
+      targetRevision: 20.1.0
+      helm:
+        releaseName: media-redis
+        valueFiles:
+          - $values/apps/media/redis-values.yaml
+    - repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git
+      targetRevision: main
+      ref: values
+`)
+	if err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	want := decode(t, `- repoURL: https://charts.bitnami.com/bitnami
+  chart: redis
+  targetRevision: 20.1.0
+  helm:
+    releaseName: media-redis
+    valueFiles:
+      - $values/apps/media/redis-values.yaml
+- repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git
+  targetRevision: main
+  ref: values
+`)
+	if !reflect.DeepEqual(spec["sources"], want) {
+		t.Errorf("sources = %v, want %v", spec["sources"], want)
+	}
+	if _, ok := spec["source"]; ok {
+		t.Errorf("source = %v, want unset", spec["source"])
+	}
+}
+
+func TestSourceValidation(t *testing.T) {
+	const git = "    repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git\n    targetRevision: main\n"
+	const gitItem = "    - repoURL: https://github.com/jamesatintegratnio/gitops_homelab_2_0.git\n      targetRevision: main\n"
+	tests := []struct {
+		name  string
+		extra string
+		want  string
+	}{
+		{"no source", "", "spec.source or spec.sources is required"},
+		{"neither chart nor path", "  source:\n" + git, "spec.source needs a chart or a path"},
+		{"chart and path", "  source:\n" + git + "    chart: redis\n    path: apps/media\n", "spec.source sets both chart and path"},
+		{"missing revision", "  source:\n    repoURL: https://charts.loft.sh\n    chart: vcluster\n", "spec.source.targetRevision is required"},
+		{"kustomize on a chart", "  source:\n" + git + "    chart: redis\n    kustomize:\n      namePrefix: prod-\n", "kustomize and directory need a path"},
+		{"helm and kustomize", "  source:\n" + git + "    path: apps/media\n    helm:\n      releaseName: media\n    kustomize:\n      namePrefix: prod-\n", "more than one of helm, kustomize and directory"},
+		{"ref on a single source", "  source:\n" + git + "    path: apps/media\n    ref: values\n", "spec.source.ref only applies to spec.sources"},
+		{"source and sources", "  source:\n" + git + "    path: apps/media\n  sources:\n" + gitItem + "      path: apps/media\n", "mutually exclusive"},
+		{"empty sources", "  sources: []\n", "spec.sources must be a non-empty list"},
+		{"source without chart, path or ref", "  sources:\n" + gitItem + "      path: apps/media\n" + gitItem, "spec.sources[1] needs a chart, a path or a ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := configure(t, tt.extra)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("handleConfigure() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreDifferences(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "ignore-differences.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := configureInput(t, input)
+	if err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	want := decode(t, `- group: apps
+  kind: Deployment
+  name: api
+  namespace: media
+  jsonPointers:
+    - /spec/replicas
+- group: admissionregistration.k8s.io
+  kind: MutatingWebhookConfiguration
+  jqPathExpressions:
+    - .webhooks[]?.clientConfig.caBundle
+`)
+	if !reflect.DeepEqual(spec["ignoreDifferences"], want) {
+		t.Errorf("ignoreDifferences = %v, want %v", spec["ignoreDifferences"], want)
+	}
+	if got := spec["revisionHistoryLimit"]; got != float64(3) {
+		t.Errorf("revisionHistoryLimit = %v, want 3", got)
+	}
+
+	spec, err = configure(t, "  source:\n    repoURL: https://charts.loft.sh\n    chart: vcluster\n    targetRevision: 0.31.0\n")
+	if err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	for _, key := range []string{"ignoreDifferences", "revisionHistoryLimit"} {
+		if v, ok := spec[key]; ok {
+			t.Errorf("%s = %v, want unset", key, v)
+		}
+	}
+}
+
+func TestIgnoreDifferencesValidation(t *testing.T) {
+	const source = "  source:\n    repoURL: https://charts.loft.sh\n    chart: vcluster\n    targetRevision: 0.31.0\n"
+	tests := []struct {
+		name  string
+		extra string
+		want  string
+	}{
+		{"not a list", "  ignoreDifferences:\n    kind: Deployment\n", "spec.ignoreDifferences is invalid"},
+		{"missing kind", "  ignoreDifferences:\n    - group: apps\n      jsonPointers: [/spec/replicas]\n", "spec.ignoreDifferences[0].kind is required"},
+		{"pointers not a list", "  ignoreDifferences:\n    - kind: Deployment\n      jsonPointers: /spec/replicas\n", "spec.ignoreDifferences is invalid: json: cannot unmarshal string into"},
+		{"expression not a string", "  ignoreDifferences:\n    - kind: Deployment\n      jqPathExpressions: [{path: .spec}]\n", "spec.ignoreDifferences is invalid: json: cannot unmarshal object into"},
+		{"negative history limit", "  revisionHistoryLimit: -1\n", "spec.revisionHistoryLimit must be a non-negative integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := configure(t, source+tt.extra)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("handleConfigure() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
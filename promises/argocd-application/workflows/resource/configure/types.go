@@ -1,4 +1,4 @@
-package main
+package argocdapplication
 
 // Resource is a generic Kubernetes resource.
 type Resource struct {
@@ -17,26 +17,63 @@ type ObjectMeta struct {
 	Finalizers  []string          `json:"finalizers,omitempty"`
 }
 
-// ApplicationSpec is the ArgoCD Application spec.
+// ApplicationSpec is the ArgoCD Application spec. Exactly one of Source
+// and Sources is set.
 type ApplicationSpec struct {
 	Project     string      `json:"project"`
-	Source      AppSource   `json:"source"`
+	Source      *AppSource  `json:"source,omitempty"`
+	Sources     []AppSource `json:"sources,omitempty"`
 	Destination Destination `json:"destination"`
 	SyncPolicy  interface{} `json:"syncPolicy,omitempty"`
+
+	IgnoreDifferences    []ResourceIgnoreDifferences `json:"ignoreDifferences,omitempty"`
+	RevisionHistoryLimit *int64                      `json:"revisionHistoryLimit,omitempty"`
+}
+
+// ResourceIgnoreDifferences selects the fields of matching resources ArgoCD
+// leaves out of the sync status, such as replicas managed by an HPA.
+type ResourceIgnoreDifferences struct {
+	Group             string   `json:"group,omitempty"`
+	Kind              string   `json:"kind"`
+	Name              string   `json:"name,omitempty"`
+	Namespace         string   `json:"namespace,omitempty"`
+	JSONPointers      []string `json:"jsonPointers,omitempty"`
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty"`
 }
 
+// AppSource is a Helm chart, or a path in a git repository rendered as
+// plain manifests, with kustomize or with Helm. Ref names a source of a
+// multi-source Application whose files the others read as $<ref>/<path>.
 type AppSource struct {
-	RepoURL        string      `json:"repoURL"`
-	Chart          string      `json:"chart,omitempty"`
-	TargetRevision string      `json:"targetRevision"`
-	Helm           *HelmSource `json:"helm,omitempty"`
+	RepoURL        string           `json:"repoURL"`
+	Chart          string           `json:"chart,omitempty"`
+	Path           string           `json:"path,omitempty"`
+	TargetRevision string           `json:"targetRevision"`
+	Ref            string           `json:"ref,omitempty"`
+	Helm           *HelmSource      `json:"helm,omitempty"`
+	Kustomize      *KustomizeSource `json:"kustomize,omitempty"`
+	Directory      *DirectorySource `json:"directory,omitempty"`
 }
 
 type HelmSource struct {
 	ReleaseName  string      `json:"releaseName,omitempty"`
+	ValueFiles   []string    `json:"valueFiles,omitempty"`
 	ValuesObject interface{} `json:"valuesObject,omitempty"`
 }
 
+// KustomizeSource overrides the kustomization at the source's path.
+// Patches are passed through as written.
+type KustomizeSource struct {
+	NamePrefix string        `json:"namePrefix,omitempty"`
+	Images     []string      `json:"images,omitempty"`
+	Patches    []interface{} `json:"patches,omitempty"`
+}
+
+// DirectorySource renders the plain manifests at the source's path.
+type DirectorySource struct {
+	Recurse bool `json:"recurse,omitempty"`
+}
+
 type Destination struct {
 	Server    string `json:"server"`
 	Namespace string `json:"namespace"`
@@ -1,4 +1,4 @@
-package main
+package argocdclusterregistration
 
 import "fmt"
 
@@ -19,7 +19,7 @@ func buildKubeconfigExternalSecret(config *RegistrationConfig) Resource {
 		),
 		Spec: ExternalSecretSpec{
 			SecretStoreRef: SecretStoreRef{
-				Name: "onepassword-store",
+				Name: config.SecretStore,
 				Kind: "ClusterSecretStore",
 			},
 			Target: ExternalSecretTarget{
@@ -62,7 +62,7 @@ func buildKubeconfigSyncRBAC(config *RegistrationConfig) []Resource {
 		),
 		Spec: ExternalSecretSpec{
 			SecretStoreRef: SecretStoreRef{
-				Name: "onepassword-store",
+				Name: config.SecretStore,
 				Kind: "ClusterSecretStore",
 			},
 			Target: ExternalSecretTarget{
@@ -76,13 +76,6 @@ func buildKubeconfigSyncRBAC(config *RegistrationConfig) []Resource {
 						Property: "credential",
 					},
 				},
-				{
-					SecretKey: "vault",
-					RemoteRef: RemoteRef{
-						Key:      "onepassword-access-token",
-						Property: "vault",
-					},
-				},
 			},
 		},
 	}
@@ -134,6 +127,11 @@ func buildKubeconfigSyncRBAC(config *RegistrationConfig) []Resource {
 	return []Resource{externalSecret, serviceAccount, role, roleBinding}
 }
 
+// vaultIDQuery is the jq filter the sync job runs over the Connect API's
+// vault list to resolve $v, a vault ID or name. An ID match wins over a name
+// match, so an ID is passed through even if another vault is named after it.
+const vaultIDQuery = `map(select(.id==$v)) + map(select(.name==$v)) | .[0].id // empty`
+
 func buildKubeconfigSyncJob(config *RegistrationConfig) Resource {
 	labels := mergeStringMap(map[string]string{
 		"app.kubernetes.io/name": "kubeconfig-sync",
@@ -163,14 +161,10 @@ OP_CONNECT_TOKEN_CLEAN=$(printf '%s' "$OP_CONNECT_TOKEN" | tr -d '\r\n')
 API_BASE="${OP_CONNECT_HOST_CLEAN%/}/v1"
 AUTH_HEADER="Authorization: Bearer ${OP_CONNECT_TOKEN_CLEAN}"
 
-VAULT_ID="${OP_VAULT:-}"
-VAULT_ID=$(printf '%s' "$VAULT_ID" | tr -d '\r\n')
-if [ -z "$VAULT_ID" ]; then
-	VAULT_ID=$(curl -fsS -H "$AUTH_HEADER" "$API_BASE/vaults" | jq -r --arg name "homelab" '.[] | select(.name==$name) | .id' | head -n1)
-fi
-VAULT_ID=$(printf '%s' "$VAULT_ID" | tr -d '\r\n')
+VAULT=$(printf '%s' "$OP_VAULT" | tr -d '\r\n')
+VAULT_ID=$(curl -fsS -H "$AUTH_HEADER" "$API_BASE/vaults" | jq -r --arg v "$VAULT" '` + vaultIDQuery + `')
 if [ -z "$VAULT_ID" ]; then
-	echo "Vault not found: homelab"
+	echo "Vault not found: $VAULT"
 	exit 1
 fi
 
@@ -334,15 +328,7 @@ echo "✓ Kubeconfig synced to 1Password successfully"`
 										},
 									},
 								},
-								{
-									Name: "OP_VAULT",
-									ValueFrom: &EnvVarSource{
-										SecretKeyRef: &SecretKeySelector{
-											Name: onePasswordTokenName,
-											Key:  "vault",
-										},
-									},
-								},
+								{Name: "OP_VAULT", Value: config.OnePasswordVault},
 								{Name: "CLUSTER_NAME", Value: config.Name},
 								{Name: "KUBECONFIG_KEY", Value: config.KubeconfigKey},
 								{Name: "OP_ITEM_NAME", Value: config.OnePasswordItem},
@@ -416,7 +402,7 @@ func buildArgoCDClusterExternalSecret(config *RegistrationConfig) Resource {
 		Metadata:   resourceMeta(esName, "argocd", labels, metadataAnnotations),
 		Spec: ExternalSecretSpec{
 			SecretStoreRef: SecretStoreRef{
-				Name: "onepassword-store",
+				Name: config.SecretStore,
 				Kind: "ClusterSecretStore",
 			},
 			Target: ExternalSecretTarget{
@@ -3,6 +3,9 @@ module github.com/jamesatintegratnio/gitops_homelab_2_0/promises/argocd-cluster-
 go 1.24.5
 
 require (
+	github.com/itchyny/gojq v0.12.17
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil v0.0.0
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase v0.0.0
 	github.com/syntasso/kratix-go v0.1.0
 	sigs.k8s.io/yaml v1.6.0
 )
@@ -28,7 +31,6 @@ require (
 	github.com/google/gnostic-models v0.6.9 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/itchyny/gojq v0.12.17 // indirect
 	github.com/itchyny/timefmt-go v0.1.6 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
@@ -74,3 +76,8 @@ require (
 	sigs.k8s.io/randfill v1.0.0 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.6.0 // indirect
 )
+
+replace (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase => ../../../../_shared/phase
+)
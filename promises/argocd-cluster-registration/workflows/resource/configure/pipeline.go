@@ -0,0 +1,334 @@
+// Package argocdclusterregistration is the Kratix pipeline for the
+// argocd-cluster-registration promise, built into the platform-pipelines binary.
+package argocdclusterregistration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+// Defaults for spec.onePassword. The store must be scoped to the vault,
+// since ExternalSecrets read whichever vaults their store is given.
+const (
+	defaultOnePasswordVault = u.DefaultOnePasswordVault
+	defaultSecretStore      = "onepassword-store"
+)
+
+// Run executes the pipeline for the workflow action Kratix set (configure
+// or delete). A failed or panicking run still writes a Failed status.
+func Run(sdk *kratix.KratixSDK) error {
+	return u.Execute(sdk, run)
+}
+
+func run(x *u.Execution) error {
+	sdk := x.SDK
+	log.Printf("=== ArgoCD Cluster Registration Promise Pipeline ===")
+	log.Printf("Action: %s", sdk.WorkflowAction())
+
+	resource, err := x.ReadInput()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Processing resource: %s/%s",
+		resource.GetNamespace(), resource.GetName())
+
+	x.Step(u.StepBuildConfig)
+	config, err := buildConfig(sdk, resource)
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+
+	if sdk.WorkflowAction() == "configure" {
+		if err := handleConfigure(x, config); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
+		}
+	} else if sdk.WorkflowAction() == "delete" {
+		if err := handleDelete(x, config); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
+		}
+	} else {
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
+	}
+
+	log.Println("=== Pipeline completed successfully ===")
+	return nil
+}
+
+func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*RegistrationConfig, error) {
+	name, err := getStringValue(resource, "spec.name")
+	if err != nil {
+		return nil, fmt.Errorf("spec.name is required: %w", err)
+	}
+
+	targetNamespace, err := getStringValue(resource, "spec.targetNamespace")
+	if err != nil {
+		return nil, fmt.Errorf("spec.targetNamespace is required: %w", err)
+	}
+
+	kubeconfigSecret, err := getStringValue(resource, "spec.kubeconfigSecret")
+	if err != nil {
+		return nil, fmt.Errorf("spec.kubeconfigSecret is required: %w", err)
+	}
+
+	externalServerURL, err := getStringValue(resource, "spec.externalServerURL")
+	if err != nil {
+		return nil, fmt.Errorf("spec.externalServerURL is required: %w", err)
+	}
+	if err := validateServerURL(externalServerURL); err != nil {
+		return nil, fmt.Errorf("spec.externalServerURL: %w", err)
+	}
+
+	kubeconfigKey, _ := getStringValueWithDefault(resource, "spec.kubeconfigKey", "config")
+	onePasswordItem, _ := getStringValueWithDefault(resource, "spec.onePasswordItem", fmt.Sprintf("%s-kubeconfig", name))
+	onePasswordConnectHost, _ := getStringValueWithDefault(resource, "spec.onePasswordConnectHost", u.DefaultOnePasswordConnectHost)
+	onePasswordVault, _ := getStringValueWithDefault(resource, "spec.onePassword.vault", defaultOnePasswordVault)
+	secretStore, _ := getStringValueWithDefault(resource, "spec.onePassword.secretStore", defaultSecretStore)
+	deleteItemOnDestroy := true
+	if val, err := resource.GetValue("spec.onePassword.deleteItemOnDestroy"); err == nil && val != nil {
+		b, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf("spec.onePassword.deleteItemOnDestroy must be a boolean")
+		}
+		deleteItemOnDestroy = b
+	}
+	environment, _ := getStringValueWithDefault(resource, "spec.environment", "development")
+	baseDomain, _ := getStringValueWithDefault(resource, "spec.baseDomain", "integratn.tech")
+
+	baseDomainSanitized, _ := getStringValue(resource, "spec.baseDomainSanitized")
+	if baseDomainSanitized == "" {
+		baseDomainSanitized = strings.ReplaceAll(baseDomain, ".", "-")
+	}
+
+	syncJobName, _ := getStringValue(resource, "spec.syncJobName")
+	if syncJobName == "" {
+		syncJobName = fmt.Sprintf("%s-kubeconfig-sync", name)
+	}
+
+	clusterLabels := extractStringMap(resource, "spec.clusterLabels")
+	clusterAnnotations := extractStringMap(resource, "spec.clusterAnnotations")
+
+	return &RegistrationConfig{
+		Name:                   name,
+		TargetNamespace:        targetNamespace,
+		KubeconfigSecret:       kubeconfigSecret,
+		KubeconfigKey:          kubeconfigKey,
+		ExternalServerURL:      externalServerURL,
+		OnePasswordItem:        onePasswordItem,
+		OnePasswordConnectHost: onePasswordConnectHost,
+		OnePasswordVault:       onePasswordVault,
+		SecretStore:            secretStore,
+		DeleteItemOnDestroy:    deleteItemOnDestroy,
+		Environment:            environment,
+		BaseDomain:             baseDomain,
+		BaseDomainSanitized:    baseDomainSanitized,
+		ClusterLabels:          clusterLabels,
+		ClusterAnnotations:     clusterAnnotations,
+		SyncJobName:            syncJobName,
+		PromiseName:            sdk.PromiseName(),
+	}, nil
+}
+
+func handleConfigure(x *u.Execution, config *RegistrationConfig) error {
+	resource := x.Resource
+	log.Println("--- Rendering cluster registration resources ---")
+	x.Step(u.StepRender)
+
+	// 1. Kubeconfig sync RBAC (ExternalSecret for 1Password token, SA, Role, RoleBinding)
+	rbacResources := buildKubeconfigSyncRBAC(config)
+	if err := writeYAMLDocuments(x, "resources/kubeconfig-sync-rbac.yaml", rbacResources); err != nil {
+		return fmt.Errorf("write kubeconfig sync rbac: %w", err)
+	}
+	log.Printf("✓ Rendered: kubeconfig-sync-rbac.yaml (%d resources)", len(rbacResources))
+
+	// 2. Kubeconfig sync Job
+	syncJob := buildKubeconfigSyncJob(config)
+	if err := writeYAML(x, "resources/kubeconfig-sync-job.yaml", syncJob); err != nil {
+		return fmt.Errorf("write kubeconfig sync job: %w", err)
+	}
+	log.Printf("✓ Rendered: kubeconfig-sync-job.yaml")
+
+	// 3. Kubeconfig ExternalSecret (reads kubeconfig from 1Password)
+	kubeconfigES := buildKubeconfigExternalSecret(config)
+	if err := writeYAML(x, "resources/kubeconfig-external-secret.yaml", kubeconfigES); err != nil {
+		return fmt.Errorf("write kubeconfig external secret: %w", err)
+	}
+	log.Printf("✓ Rendered: kubeconfig-external-secret.yaml")
+
+	// 4. ArgoCD Cluster ExternalSecret (creates ArgoCD cluster secret from 1Password)
+	clusterES := buildArgoCDClusterExternalSecret(config)
+	if err := writeYAML(x, "resources/argocd-cluster-external-secret.yaml", clusterES); err != nil {
+		return fmt.Errorf("write argocd cluster external secret: %w", err)
+	}
+	log.Printf("✓ Rendered: argocd-cluster-external-secret.yaml")
+
+	message := fmt.Sprintf("Cluster %s registration resources configured", config.Name)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, len(rbacResources)+3).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("clusterName", config.Name).
+		Set("targetNamespace", config.TargetNamespace).
+		Set("externalServerURL", config.ExternalServerURL).
+		Set("environment", config.Environment)
+
+	if err := x.WriteStatus(status); err != nil {
+		return err
+	}
+
+	log.Println("✓ Status updated")
+	return nil
+}
+
+// Teardown waves: ArgoCD removes the higher wave first, so the sync Job and
+// the ExternalSecrets are gone before the RBAC the Job runs under.
+const (
+	waveWorkload = 1
+	waveRBAC     = 0
+)
+
+// buildDeleteOutputs returns the delete outputs for everything
+// handleConfigure rendered, keyed by output path and annotated with their
+// teardown wave.
+func buildDeleteOutputs(config *RegistrationConfig) map[string]Resource {
+	created := []Resource{
+		buildKubeconfigExternalSecret(config),
+		buildArgoCDClusterExternalSecret(config),
+		buildKubeconfigSyncJob(config),
+	}
+	created = append(created, buildKubeconfigSyncRBAC(config)...)
+
+	outputs := map[string]Resource{}
+	for _, r := range created {
+		wave := waveRBAC
+		if r.Kind == "Job" || r.Kind == "ExternalSecret" {
+			wave = waveWorkload
+		}
+		outputs[deleteOutputPath("resources", r)] = inDeleteWave(deleteFromResource(r), wave)
+	}
+	return outputs
+}
+
+func handleDelete(x *u.Execution, config *RegistrationConfig) error {
+	resource := x.Resource
+	log.Printf("--- Handling delete for cluster registration: %s ---", config.Name)
+
+	status := u.DeletingStatus(resource, fmt.Sprintf("Cluster %s registration resources scheduled for deletion", config.Name)).
+		Set("clusterName", config.Name)
+
+	if err := x.WriteStatus(status); err != nil {
+		return err
+	}
+
+	// The 1Password item is not in the Kratix state store, so it is deleted
+	// through the Connect API before any delete output is written, while
+	// the token Secret is still there. Nothing else would delete it, so a
+	// failure fails the run, recorded as a Cleanup failure on the request,
+	// before anything is torn down.
+	if config.DeleteItemOnDestroy {
+		x.Step(u.StepCleanup)
+		if err := deleteOnePasswordItem(config); err != nil {
+			return fmt.Errorf("delete 1Password item %s: %w", config.OnePasswordItem, err)
+		}
+	}
+	x.Step(u.StepRender)
+
+	outputs := buildDeleteOutputs(config)
+
+	for path, obj := range outputs {
+		if err := writeYAML(x, path, obj); err != nil {
+			return fmt.Errorf("write delete output %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// readSecretKey reads the Connect token; tests replace it.
+var readSecretKey = u.ReadSecretKey
+
+// deleteOnePasswordItem deletes the kubeconfig item from 1Password with the
+// Connect token the sync Job writes it with. An item or vault that is
+// already gone counts as deleted.
+func deleteOnePasswordItem(config *RegistrationConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	tokenSecret := fmt.Sprintf("%s-onepassword-token", config.Name)
+	token, err := readSecretKey(ctx, config.TargetNamespace, tokenSecret, "token")
+	if err != nil {
+		return fmt.Errorf("read 1Password Connect token %s/%s: %w", config.TargetNamespace, tokenSecret, err)
+	}
+	log.Printf("Deleting 1Password item %s from vault %s", config.OnePasswordItem, config.OnePasswordVault)
+	connect := u.OnePasswordConnect{Host: config.OnePasswordConnectHost, Token: token}
+	return connect.DeleteItem(ctx, config.OnePasswordVault, config.OnePasswordItem)
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+// validateServerURL checks that raw is an absolute http(s) URL. An IPv6
+// host must be bracketed, as in https://[fd00:4::c8]:443; unbracketed, its
+// last group would be read as the port.
+func validateServerURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("%q is not an absolute URL such as https://media.integratn.tech:443", raw)
+	}
+	if strings.HasPrefix(parsed.Host, "[") {
+		if addr, err := netip.ParseAddr(parsed.Hostname()); err != nil || !addr.Is6() {
+			return fmt.Errorf("%q: only an IPv6 address may be bracketed", raw)
+		}
+	} else if strings.Count(parsed.Host, ":") > 1 {
+		return fmt.Errorf("%q: bracket the IPv6 host, e.g. https://[%s]:443", raw, parsed.Host)
+	}
+	return nil
+}
+
+func getStringValue(resource kratix.Resource, path string) (string, error) {
+	val, err := resource.GetValue(path)
+	if err != nil {
+		return "", err
+	}
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return "", fmt.Errorf("%s is not a string", path)
+}
+
+func getStringValueWithDefault(resource kratix.Resource, path, defaultValue string) (string, error) {
+	val, err := getStringValue(resource, path)
+	if err != nil || val == "" {
+		return defaultValue, nil
+	}
+	return val, nil
+}
+
+func extractStringMap(resource kratix.Resource, path string) map[string]string {
+	val, err := resource.GetValue(path)
+	if err != nil {
+		return nil
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string)
+	for k, v := range m {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
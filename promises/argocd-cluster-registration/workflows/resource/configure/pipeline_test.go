@@ -0,0 +1,295 @@
+package argocdclusterregistration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itchyny/gojq"
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+const registrationSpec = `apiVersion: platform.integratn.tech/v1alpha1
+kind: ArgoCDClusterRegistration
+metadata:
+  name: media-cluster-registration
+  namespace: platform-requests
+spec:
+  name: vcluster-media
+  targetNamespace: vcluster-media
+  kubeconfigSecret: vc-vcluster-media
+  externalServerURL: https://media.integratn.tech:443
+`
+
+// testConfig builds the config for registrationSpec plus extra spec lines.
+func testConfig(t *testing.T, extra string) *RegistrationConfig {
+	t.Helper()
+	t.Setenv("KRATIX_PROMISE_NAME", "argocd-cluster-registration")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "object.yaml"), []byte(registrationSpec+extra), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(kratix.WithInputDir(dir))
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := buildConfig(sdk, resource)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	return config
+}
+
+func TestOnePasswordVaultConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra string
+		vault string
+		store string
+		host  string
+	}{
+		{name: "default", vault: "homelab", store: "onepassword-store", host: "https://connect.integratn.tech"},
+		{
+			name:  "configured",
+			extra: "  onePasswordConnectHost: https://connect.prod.example.com\n  onePassword:\n    vault: homelab-prod\n    secretStore: onepassword-prod\n",
+			vault: "homelab-prod",
+			store: "onepassword-prod",
+			host:  "https://connect.prod.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t, tt.extra)
+
+			env := map[string]EnvVar{}
+			for _, e := range buildKubeconfigSyncJob(config).Spec.(JobSpec).Template.Spec.Containers[0].Env {
+				env[e.Name] = e
+			}
+			if e := env["OP_VAULT"]; e.Value != tt.vault || e.ValueFrom != nil {
+				t.Errorf("OP_VAULT = %+v, want value %q", e, tt.vault)
+			}
+			if e := env["OP_CONNECT_HOST"]; e.Value != tt.host || e.ValueFrom != nil {
+				t.Errorf("OP_CONNECT_HOST = %+v, want value %q", e, tt.host)
+			}
+
+			for _, es := range []Resource{
+				buildKubeconfigExternalSecret(config),
+				buildArgoCDClusterExternalSecret(config),
+				buildKubeconfigSyncRBAC(config)[0],
+			} {
+				if got := es.Spec.(ExternalSecretSpec).SecretStoreRef.Name; got != tt.store {
+					t.Errorf("%s: secretStoreRef = %q, want %q", es.Metadata.Name, got, tt.store)
+				}
+			}
+		})
+	}
+}
+
+func TestVaultIDQuery(t *testing.T) {
+	query, err := gojq.Parse(vaultIDQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := gojq.Compile(query, gojq.WithVariables([]string{"$v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaults := []interface{}{
+		map[string]interface{}{"id": "4s2kvm3xq7nqbfcfhkqjvrv5aq", "name": "homelab"},
+		map[string]interface{}{"id": "x7p3lq2bnd5ynqfkh6jw4crvte", "name": "homelab-prod"},
+		// A vault named after another's ID must not shadow it.
+		map[string]interface{}{"id": "m2tq6ubk4jc3rxzy7wnoaf5dei", "name": "x7p3lq2bnd5ynqfkh6jw4crvte"},
+	}
+	tests := []struct {
+		vault string
+		want  interface{}
+	}{
+		{"homelab", "4s2kvm3xq7nqbfcfhkqjvrv5aq"},
+		{"homelab-prod", "x7p3lq2bnd5ynqfkh6jw4crvte"},
+		{"x7p3lq2bnd5ynqfkh6jw4crvte", "x7p3lq2bnd5ynqfkh6jw4crvte"},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		iter := code.Run(vaults, tt.vault)
+		got, ok := iter.Next()
+		if err, isErr := got.(error); isErr {
+			t.Fatalf("%s: %v", tt.vault, err)
+		}
+		if tt.want == nil {
+			if ok {
+				t.Errorf("%s: resolved to %v, want no vault", tt.vault, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: resolved to %v, want %v", tt.vault, got, tt.want)
+		}
+	}
+}
+
+func TestValidateServerURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://media.integratn.tech:443", true},
+		{"https://10.0.4.200:443", true},
+		{"https://[fd00:4::c8]:443", true},
+		{"https://[fd00:4::c8]", true},
+		{"https://fd00:4::c8:443", false},
+		{"https://[10.0.4.200]:443", false},
+		{"media.integratn.tech:443", false},
+		{"ftp://media.integratn.tech", false},
+	}
+	for _, tt := range tests {
+		if err := validateServerURL(tt.url); (err == nil) != tt.valid {
+			t.Errorf("validateServerURL(%s) = %v, want valid %v", tt.url, err, tt.valid)
+		}
+	}
+}
+
+func TestDeleteOutputsWaveOrder(t *testing.T) {
+	config := testConfig(t, "")
+
+	waves := map[string][]string{}
+	for path, r := range buildDeleteOutputs(config) {
+		wave := r.Metadata.Annotations["argocd.argoproj.io/sync-wave"]
+		waves[r.Kind] = append(waves[r.Kind], wave)
+		if r.Spec != nil {
+			t.Errorf("%s carries a spec", path)
+		}
+	}
+	for kind, want := range map[string]string{
+		"Job":            "1",
+		"ExternalSecret": "1",
+		"ServiceAccount": "0",
+		"Role":           "0",
+		"RoleBinding":    "0",
+	} {
+		if len(waves[kind]) == 0 {
+			t.Errorf("no %s delete output", kind)
+		}
+		for _, got := range waves[kind] {
+			if got != want {
+				t.Errorf("%s sync wave = %q, want %q (Jobs and ExternalSecrets go before RBAC)", kind, got, want)
+			}
+		}
+	}
+}
+
+// runDelete runs handleDelete for registrationSpec plus extra spec lines,
+// writing to outputDir, with readSecretKey serving token.
+func runDelete(t *testing.T, outputDir, extra, token string) (metadataDir string, err error) {
+	t.Helper()
+	t.Setenv("KRATIX_WORKFLOW_ACTION", "delete")
+	t.Setenv("KRATIX_WORKFLOW_TYPE", "resource")
+	t.Setenv("KRATIX_PROMISE_NAME", "argocd-cluster-registration")
+	readSecretKey = func(_ context.Context, namespace, name, key string) (string, error) {
+		if namespace != "vcluster-media" || name != "vcluster-media-onepassword-token" || key != "token" {
+			return "", fmt.Errorf("unexpected secret %s/%s key %s", namespace, name, key)
+		}
+		return token, nil
+	}
+
+	inputDir := t.TempDir()
+	metadataDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "object.yaml"), []byte(registrationSpec+extra), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(kratix.WithInputDir(inputDir), kratix.WithOutputDir(outputDir), kratix.WithMetadataDir(metadataDir))
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := buildConfig(sdk, resource)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	// Through Execute, which records a failure on the request.
+	return metadataDir, u.Execute(sdk, func(x *u.Execution) error {
+		x.Resource = resource
+		return handleDelete(x, config)
+	})
+}
+
+func TestDeleteRemovesOnePasswordItemFirst(t *testing.T) {
+	defer func(orig func(context.Context, string, string, string) (string, error)) { readSecretKey = orig }(readSecretKey)
+
+	outputDir := t.TempDir()
+	var deleted []string
+	var outputsAtDelete []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/vaults", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"v1","name":"homelab-prod"}]`))
+	})
+	mux.HandleFunc("GET /v1/vaults/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"i1","title":"vcluster-media-kubeconfig"}]`))
+	})
+	mux.HandleFunc("DELETE /v1/vaults/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer connect-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		deleted = append(deleted, r.PathValue("id"))
+		outputsAtDelete, _ = filepath.Glob(filepath.Join(outputDir, "resources", "*"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	spec := "  onePasswordConnectHost: " + srv.URL + "\n  onePassword:\n    vault: homelab-prod\n"
+
+	metadataDir, err := runDelete(t, outputDir, spec, "connect-token")
+	if err != nil {
+		t.Fatalf("handleDelete: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "i1" {
+		t.Fatalf("deleted items = %v, want [i1]", deleted)
+	}
+	if len(outputsAtDelete) != 0 {
+		t.Errorf("delete outputs written before the item was deleted: %v", outputsAtDelete)
+	}
+	written, _ := filepath.Glob(filepath.Join(outputDir, "resources", "delete-*.yaml"))
+	if len(written) != len(buildDeleteOutputs(testConfig(t, spec))) {
+		t.Errorf("delete outputs = %v", written)
+	}
+
+	// A failed delete fails the run before any delete output, and the
+	// request says why.
+	deleted = nil
+	outputDir = t.TempDir()
+	metadataDir, err = runDelete(t, outputDir, spec, "wrong-token")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("handleDelete with a rejected token: err = %v, want the 401", err)
+	}
+	if written, _ := filepath.Glob(filepath.Join(outputDir, "resources", "*")); len(written) != 0 {
+		t.Errorf("delete outputs written after the item delete failed: %v", written)
+	}
+	if status := readStatus(t, metadataDir); !strings.Contains(status, "failedStep: Cleanup\n") || !strings.Contains(status, "reason: CleanupFailed\n") {
+		t.Errorf("status does not record the failed delete:\n%s", status)
+	}
+
+	// Opting out leaves the item alone.
+	if _, err := runDelete(t, t.TempDir(), spec+"    deleteItemOnDestroy: false\n", "connect-token"); err != nil {
+		t.Fatalf("handleDelete: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("deleteItemOnDestroy: false deleted %v", deleted)
+	}
+}
+
+// readStatus returns the status.yaml a pipeline wrote to metadataDir.
+func readStatus(t *testing.T, metadataDir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(metadataDir, "status.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -1,8 +1,11 @@
-package main
+package argocdclusterregistration
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
 )
 
 // ============================================================================
@@ -181,6 +184,9 @@ type RegistrationConfig struct {
 	ExternalServerURL      string
 	OnePasswordItem        string
 	OnePasswordConnectHost string
+	OnePasswordVault       string // vault name or ID the sync job writes to
+	SecretStore            string // ClusterSecretStore scoped to OnePasswordVault
+	DeleteItemOnDestroy    bool   // delete the kubeconfig item with the registration
 	Environment            string
 	BaseDomain             string
 	BaseDomainSanitized    string
@@ -205,6 +211,13 @@ func deleteFromResource(r Resource) Resource {
 	}
 }
 
+// inDeleteWave annotates a delete output with the ArgoCD sync wave it is
+// removed in; ArgoCD removes the highest wave first.
+func inDeleteWave(r Resource, wave int) Resource {
+	r.Metadata.Annotations = map[string]string{u.SyncWaveAnnotation: strconv.Itoa(wave)}
+	return r
+}
+
 func deleteOutputPath(prefix string, r Resource) string {
 	if prefix == "" {
 		prefix = "resources/"
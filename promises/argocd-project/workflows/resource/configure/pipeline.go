@@ -1,42 +1,54 @@
-package main
+// Package argocdproject is the Kratix pipeline for the
+// argocd-project promise, built into the platform-pipelines binary.
+package argocdproject
 
 import (
 	"fmt"
 	"log"
 
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 	kratix "github.com/syntasso/kratix-go"
 )
 
-func main() {
-	sdk := kratix.New()
+// Run executes the pipeline for the workflow action Kratix set (configure
+// or delete). A failed or panicking run still writes a Failed status.
+func Run(sdk *kratix.KratixSDK) error {
+	return u.Execute(sdk, run)
+}
 
+func run(x *u.Execution) error {
+	sdk := x.SDK
 	log.Printf("=== ArgoCD Project Promise Pipeline ===")
 	log.Printf("Action: %s", sdk.WorkflowAction())
 
-	resource, err := sdk.ReadResourceInput()
+	resource, err := x.ReadInput()
 	if err != nil {
-		log.Fatalf("ERROR: Failed to read resource input: %v", err)
+		return err
 	}
 
 	log.Printf("Processing resource: %s/%s",
 		resource.GetNamespace(), resource.GetName())
 
+	x.Step(u.StepBuildConfig)
 	if sdk.WorkflowAction() == "configure" {
-		if err := handleConfigure(sdk, resource); err != nil {
-			log.Fatalf("ERROR: Configure failed: %v", err)
+		if err := handleConfigure(x); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
 		}
 	} else if sdk.WorkflowAction() == "delete" {
-		if err := handleDelete(sdk, resource); err != nil {
-			log.Fatalf("ERROR: Delete failed: %v", err)
+		if err := handleDelete(x); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
 		}
 	} else {
-		log.Fatalf("ERROR: Unknown workflow action: %s", sdk.WorkflowAction())
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
 	}
 
 	log.Println("=== Pipeline completed successfully ===")
+	return nil
 }
 
-func handleConfigure(sdk *kratix.KratixSDK, resource kratix.Resource) error {
+func handleConfigure(x *u.Execution) error {
+	resource := x.Resource
 	name, err := getStringValue(resource, "spec.name")
 	if err != nil {
 		return fmt.Errorf("spec.name is required: %w", err)
@@ -71,25 +83,27 @@ func handleConfigure(sdk *kratix.KratixSDK, resource kratix.Resource) error {
 		},
 	}
 
-	if err := writeYAML(sdk, "resources/appproject.yaml", project); err != nil {
+	x.Step(u.StepRender)
+	if err := writeYAML(x, "resources/appproject.yaml", project); err != nil {
 		return fmt.Errorf("write appproject: %w", err)
 	}
 	log.Printf("✓ Rendered ArgoCD AppProject: %s", name)
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Configured")
-	status.Set("message", fmt.Sprintf("AppProject %s configured", name))
-	status.Set("projectName", name)
-	status.Set("namespace", namespace)
+	message := fmt.Sprintf("AppProject %s configured", name)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, 1).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("projectName", name).
+		Set("namespace", namespace)
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("failed to write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func handleDelete(sdk *kratix.KratixSDK, resource kratix.Resource) error {
+func handleDelete(x *u.Execution) error {
+	resource := x.Resource
 	name, err := getStringValue(resource, "spec.name")
 	if err != nil {
 		return fmt.Errorf("spec.name is required: %w", err)
@@ -106,17 +120,16 @@ func handleDelete(sdk *kratix.KratixSDK, resource kratix.Resource) error {
 		},
 	}
 
-	if err := writeYAML(sdk, "resources/delete-appproject.yaml", deleteObj); err != nil {
+	x.Step(u.StepRender)
+	if err := writeYAML(x, "resources/delete-appproject.yaml", deleteObj); err != nil {
 		return fmt.Errorf("write delete appproject: %w", err)
 	}
 	log.Printf("✓ Delete scheduled for AppProject: %s", name)
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Deleting")
-	status.Set("message", fmt.Sprintf("AppProject %s scheduled for deletion", name))
+	status := u.DeletingStatus(resource, fmt.Sprintf("AppProject %s scheduled for deletion", name))
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("failed to write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
@@ -1,4 +1,4 @@
-package main
+package argocdproject
 
 // Resource is a generic Kubernetes resource.
 type Resource struct {
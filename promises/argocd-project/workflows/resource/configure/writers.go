@@ -1,19 +1,16 @@
-package main
+package argocdproject
 
 import (
 	"fmt"
 
-	kratix "github.com/syntasso/kratix-go"
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
 	"sigs.k8s.io/yaml"
 )
 
-func writeYAML(sdk *kratix.KratixSDK, path string, obj interface{}) error {
+func writeYAML(x *u.Execution, path string, obj interface{}) error {
 	data, err := yaml.Marshal(obj)
 	if err != nil {
 		return fmt.Errorf("marshal %s: %w", path, err)
 	}
-	if err := sdk.WriteOutput(path, data); err != nil {
-		return fmt.Errorf("write output %s: %w", path, err)
-	}
-	return nil
+	return x.WriteOutput(path, data)
 }
@@ -4,6 +4,7 @@ go 1.24.5
 
 require (
 	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil v0.0.0
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase v0.0.0
 	github.com/syntasso/kratix-go v0.1.0
 )
 
@@ -76,4 +77,7 @@ require (
 	sigs.k8s.io/yaml v1.6.0 // indirect
 )
 
-replace github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
+replace (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase => ../../../../_shared/phase
+)
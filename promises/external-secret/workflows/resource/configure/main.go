@@ -7,6 +7,7 @@ import (
 	kratix "github.com/syntasso/kratix-go"
 
 	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 )
 
 const (
@@ -25,37 +26,47 @@ type ExternalSecretConfig struct {
 }
 
 func main() {
-	sdk := kratix.New()
+	if err := u.Execute(kratix.New(), run); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// run executes the pipeline for the workflow action Kratix set (configure
+// or delete). Execute turns its errors and panics into a Failed status.
+func run(x *u.Execution) error {
+	sdk := x.SDK
 
 	log.Printf("=== External Secret Promise Pipeline ===")
 	log.Printf("Action: %s", sdk.WorkflowAction())
 
-	resource, err := sdk.ReadResourceInput()
+	resource, err := x.ReadInput()
 	if err != nil {
-		log.Fatalf("ERROR: Failed to read resource input: %v", err)
+		return err
 	}
 
 	log.Printf("Processing resource: %s/%s",
 		resource.GetNamespace(), resource.GetName())
 
+	x.Step(u.StepBuildConfig)
 	config, err := buildConfig(resource)
 	if err != nil {
-		log.Fatalf("ERROR: Failed to build config: %v", err)
+		return fmt.Errorf("failed to build config: %w", err)
 	}
 
 	if sdk.WorkflowAction() == "configure" {
-		if err := handleConfigure(sdk, config); err != nil {
-			log.Fatalf("ERROR: Configure failed: %v", err)
+		if err := handleConfigure(x, config); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
 		}
 	} else if sdk.WorkflowAction() == "delete" {
-		if err := handleDelete(sdk, config); err != nil {
-			log.Fatalf("ERROR: Delete failed: %v", err)
+		if err := handleDelete(x, config); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
 		}
 	} else {
-		log.Fatalf("ERROR: Unknown workflow action: %s", sdk.WorkflowAction())
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
 	}
 
 	log.Println("=== Pipeline completed successfully ===")
+	return nil
 }
 
 func buildConfig(resource kratix.Resource) (*ExternalSecretConfig, error) {
@@ -90,28 +101,32 @@ func buildConfig(resource kratix.Resource) (*ExternalSecretConfig, error) {
 	return config, nil
 }
 
-func handleConfigure(sdk *kratix.KratixSDK, config *ExternalSecretConfig) error {
+func handleConfigure(x *u.Execution, config *ExternalSecretConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	externalSecrets := buildExternalSecrets(config)
-	if err := u.WriteYAMLDocuments(sdk, "resources/external-secrets.yaml", externalSecrets); err != nil {
+	if err := x.WriteYAMLDocuments("resources/external-secrets.yaml", externalSecrets); err != nil {
 		return fmt.Errorf("write ExternalSecrets: %w", err)
 	}
 	log.Printf("✓ Rendered %d ExternalSecret(s)", len(externalSecrets))
 
 	// Write status
-	status := kratix.NewStatus()
-	status.Set("phase", "Configured")
-	status.Set("message", fmt.Sprintf("Rendered %d ExternalSecret(s) in namespace %s", len(config.Secrets), config.Namespace))
-	status.Set("namespace", config.Namespace)
-	status.Set("secretCount", len(config.Secrets))
-
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	message := fmt.Sprintf("Rendered %d ExternalSecret(s) in namespace %s", len(config.Secrets), config.Namespace)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, len(externalSecrets)).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("namespace", config.Namespace).
+		Set("secretCount", len(config.Secrets))
+
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func handleDelete(sdk *kratix.KratixSDK, config *ExternalSecretConfig) error {
+func handleDelete(x *u.Execution, config *ExternalSecretConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	// Emit minimal resources for Kratix to know what to clean up
 	for _, s := range config.Secrets {
 		secretName := s.Name
@@ -127,17 +142,15 @@ func handleDelete(sdk *kratix.KratixSDK, config *ExternalSecretConfig) error {
 		)
 
 		path := fmt.Sprintf("resources/delete-externalsecret-%s.yaml", secretName)
-		if err := u.WriteYAML(sdk, path, deleteObj); err != nil {
+		if err := x.WriteYAML(path, deleteObj); err != nil {
 			return fmt.Errorf("write delete ExternalSecret %s: %w", secretName, err)
 		}
 	}
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Deleting")
-	status.Set("message", fmt.Sprintf("ExternalSecrets in %s scheduled for deletion", config.Namespace))
+	status := u.DeletingStatus(resource, fmt.Sprintf("ExternalSecrets in %s scheduled for deletion", config.Namespace))
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
@@ -7,6 +7,7 @@ import (
 	kratix "github.com/syntasso/kratix-go"
 
 	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 )
 
 const (
@@ -33,37 +34,47 @@ type GatewayRouteConfig struct {
 }
 
 func main() {
-	sdk := kratix.New()
+	if err := u.Execute(kratix.New(), run); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// run executes the pipeline for the workflow action Kratix set (configure
+// or delete). Execute turns its errors and panics into a Failed status.
+func run(x *u.Execution) error {
+	sdk := x.SDK
 
 	log.Printf("=== Gateway Route Promise Pipeline ===")
 	log.Printf("Action: %s", sdk.WorkflowAction())
 
-	resource, err := sdk.ReadResourceInput()
+	resource, err := x.ReadInput()
 	if err != nil {
-		log.Fatalf("ERROR: Failed to read resource input: %v", err)
+		return err
 	}
 
 	log.Printf("Processing resource: %s/%s",
 		resource.GetNamespace(), resource.GetName())
 
+	x.Step(u.StepBuildConfig)
 	config, err := buildConfig(resource)
 	if err != nil {
-		log.Fatalf("ERROR: Failed to build config: %v", err)
+		return fmt.Errorf("failed to build config: %w", err)
 	}
 
 	if sdk.WorkflowAction() == "configure" {
-		if err := handleConfigure(sdk, config); err != nil {
-			log.Fatalf("ERROR: Configure failed: %v", err)
+		if err := handleConfigure(x, config); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
 		}
 	} else if sdk.WorkflowAction() == "delete" {
-		if err := handleDelete(sdk, config); err != nil {
-			log.Fatalf("ERROR: Delete failed: %v", err)
+		if err := handleDelete(x, config); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
 		}
 	} else {
-		log.Fatalf("ERROR: Unknown workflow action: %s", sdk.WorkflowAction())
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
 	}
 
 	log.Println("=== Pipeline completed successfully ===")
+	return nil
 }
 
 func buildConfig(resource kratix.Resource) (*GatewayRouteConfig, error) {
@@ -123,7 +134,9 @@ func buildConfig(resource kratix.Resource) (*GatewayRouteConfig, error) {
 	return config, nil
 }
 
-func handleConfigure(sdk *kratix.KratixSDK, config *GatewayRouteConfig) error {
+func handleConfigure(x *u.Execution, config *GatewayRouteConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	labels := map[string]string{
 		"app.kubernetes.io/managed-by": "kratix",
 		"kratix.io/promise-name":       config.OwnerPromise,
@@ -132,38 +145,42 @@ func handleConfigure(sdk *kratix.KratixSDK, config *GatewayRouteConfig) error {
 
 	// 1. HTTPS HTTPRoute (primary route)
 	httpsRoute := buildHTTPSRoute(config, labels)
-	if err := u.WriteYAML(sdk, "resources/httproute.yaml", httpsRoute); err != nil {
+	if err := x.WriteYAML("resources/httproute.yaml", httpsRoute); err != nil {
 		return fmt.Errorf("write HTTPRoute: %w", err)
 	}
 	log.Printf("✓ Rendered HTTPS HTTPRoute: %s", config.Name)
 
 	// 2. HTTP→HTTPS redirect route
+	rendered := 1
 	if config.HTTPRedirect {
+		rendered++
 		redirectRoute := buildHTTPRedirect(config, labels)
-		if err := u.WriteYAML(sdk, "resources/http-redirect.yaml", redirectRoute); err != nil {
+		if err := x.WriteYAML("resources/http-redirect.yaml", redirectRoute); err != nil {
 			return fmt.Errorf("write HTTP redirect: %w", err)
 		}
 		log.Printf("✓ Rendered HTTP→HTTPS redirect route: %s-http-redirect", config.Name)
 	}
 
 	// Write status
-	status := kratix.NewStatus()
-	status.Set("phase", "Configured")
-	status.Set("hostname", config.Hostname)
-	status.Set("url", fmt.Sprintf("https://%s%s", config.Hostname, config.Path))
+	message := fmt.Sprintf("Gateway route configured for %s", config.Hostname)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, rendered).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("hostname", config.Hostname).
+		Set("url", fmt.Sprintf("https://%s%s", config.Hostname, config.Path))
 	if config.HTTPRedirect {
 		status.Set("httpRedirect", "enabled")
 	}
-	status.Set("message", fmt.Sprintf("Gateway route configured for %s", config.Hostname))
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func handleDelete(sdk *kratix.KratixSDK, config *GatewayRouteConfig) error {
+func handleDelete(x *u.Execution, config *GatewayRouteConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	// HTTPS route
 	httpsDelete := u.DeleteResource(
 		"gateway.networking.k8s.io/v1",
@@ -171,7 +188,7 @@ func handleDelete(sdk *kratix.KratixSDK, config *GatewayRouteConfig) error {
 		config.Name,
 		config.Namespace,
 	)
-	if err := u.WriteYAML(sdk, "resources/delete-httproute-"+config.Name+".yaml", httpsDelete); err != nil {
+	if err := x.WriteYAML("resources/delete-httproute-"+config.Name+".yaml", httpsDelete); err != nil {
 		return fmt.Errorf("write delete HTTPRoute: %w", err)
 	}
 
@@ -183,17 +200,15 @@ func handleDelete(sdk *kratix.KratixSDK, config *GatewayRouteConfig) error {
 			fmt.Sprintf("%s-http-redirect", config.Name),
 			config.Namespace,
 		)
-		if err := u.WriteYAML(sdk, "resources/delete-httproute-"+config.Name+"-redirect.yaml", redirectDelete); err != nil {
+		if err := x.WriteYAML("resources/delete-httproute-"+config.Name+"-redirect.yaml", redirectDelete); err != nil {
 			return fmt.Errorf("write delete redirect HTTPRoute: %w", err)
 		}
 	}
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Deleting")
-	status.Set("message", fmt.Sprintf("Gateway routes for %s scheduled for deletion", config.Hostname))
+	status := u.DeletingStatus(resource, fmt.Sprintf("Gateway routes for %s scheduled for deletion", config.Hostname))
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
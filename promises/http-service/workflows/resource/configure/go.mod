@@ -2,10 +2,9 @@ module github.com/jamesatintegratnio/gitops_homelab_2_0/promises/http-service
 
 go 1.24.5
 
-replace github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
-
 require (
 	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil v0.0.0
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase v0.0.0
 	github.com/syntasso/kratix-go v0.1.0
 )
 
@@ -77,3 +76,8 @@ require (
 	sigs.k8s.io/structured-merge-diff/v4 v4.6.0 // indirect
 	sigs.k8s.io/yaml v1.6.0 // indirect
 )
+
+replace (
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil => ../../../../_shared/kratixutil
+	github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase => ../../../../_shared/phase
+)
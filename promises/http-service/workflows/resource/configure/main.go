@@ -7,6 +7,7 @@ import (
 	kratix "github.com/syntasso/kratix-go"
 
 	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 )
 
 // Platform-wide defaults — baked into every HTTP service.
@@ -88,37 +89,47 @@ type HTTPServiceConfig struct {
 }
 
 func main() {
-	sdk := kratix.New()
+	if err := u.Execute(kratix.New(), run); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// run executes the pipeline for the workflow action Kratix set (configure
+// or delete). Execute turns its errors and panics into a Failed status.
+func run(x *u.Execution) error {
+	sdk := x.SDK
 
 	log.Printf("=== HTTP Service Promise Pipeline ===")
 	log.Printf("Action: %s", sdk.WorkflowAction())
 
-	resource, err := sdk.ReadResourceInput()
+	resource, err := x.ReadInput()
 	if err != nil {
-		log.Fatalf("ERROR: Failed to read resource input: %v", err)
+		return err
 	}
 
 	log.Printf("Processing resource: %s/%s",
 		resource.GetNamespace(), resource.GetName())
 
+	x.Step(u.StepBuildConfig)
 	config, err := buildConfig(resource)
 	if err != nil {
-		log.Fatalf("ERROR: Failed to build config: %v", err)
+		return fmt.Errorf("failed to build config: %w", err)
 	}
 
 	if sdk.WorkflowAction() == "configure" {
-		if err := handleConfigure(sdk, config); err != nil {
-			log.Fatalf("ERROR: Configure failed: %v", err)
+		if err := handleConfigure(x, config); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
 		}
 	} else if sdk.WorkflowAction() == "delete" {
-		if err := handleDelete(sdk, config); err != nil {
-			log.Fatalf("ERROR: Delete failed: %v", err)
+		if err := handleDelete(x, config); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
 		}
 	} else {
-		log.Fatalf("ERROR: Unknown workflow action: %s", sdk.WorkflowAction())
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
 	}
 
 	log.Println("=== Pipeline completed successfully ===")
+	return nil
 }
 
 // buildConfig extracts all fields from the CR with sensible defaults.
@@ -215,7 +226,9 @@ func buildConfig(resource kratix.Resource) (*HTTPServiceConfig, error) {
 }
 
 // handleConfigure generates the Namespace + ArgoCD app + sub-ResourceRequests + NetworkPolicies.
-func handleConfigure(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
+func handleConfigure(x *u.Execution, config *HTTPServiceConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	// 0. Create the target Namespace first (low sync-wave so it exists before everything else)
 	ns := u.Resource{
 		APIVersion: "v1",
@@ -234,7 +247,7 @@ func handleConfigure(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
 			},
 		},
 	}
-	if err := u.WriteYAML(sdk, "resources/namespace.yaml", ns); err != nil {
+	if err := x.WriteYAML("resources/namespace.yaml", ns); err != nil {
 		return fmt.Errorf("write Namespace: %w", err)
 	}
 	log.Printf("✓ Rendered Namespace: %s", config.Namespace)
@@ -304,54 +317,60 @@ func handleConfigure(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
 		},
 	}
 
-	if err := u.WriteYAML(sdk, "resources/argocd-application-request.yaml", appRequest); err != nil {
+	if err := x.WriteYAML("resources/argocd-application-request.yaml", appRequest); err != nil {
 		return fmt.Errorf("write ArgoCDApplication request: %w", err)
 	}
 	log.Printf("✓ Rendered ArgoCDApplication sub-ResourceRequest: %s", config.Name)
+	rendered := 2 // Namespace and ArgoCDApplication
 
 	// 4. Emit PlatformExternalSecret sub-ResourceRequest (delegates to external-secret promise)
 	if len(config.Secrets) > 0 {
 		esRequest := buildExternalSecretRequest(config)
-		if err := u.WriteYAML(sdk, "resources/external-secret-request.yaml", esRequest); err != nil {
+		if err := x.WriteYAML("resources/external-secret-request.yaml", esRequest); err != nil {
 			return fmt.Errorf("write PlatformExternalSecret request: %w", err)
 		}
 		log.Printf("✓ Rendered PlatformExternalSecret sub-ResourceRequest (%d secret(s))", len(config.Secrets))
+		rendered++
 	}
 
 	// 5. Build NetworkPolicies (remain inline — too variable for a sub-promise)
 	netpols := buildNetworkPolicies(config)
-	if err := u.WriteYAMLDocuments(sdk, "resources/network-policies.yaml", netpols); err != nil {
+	if err := x.WriteYAMLDocuments("resources/network-policies.yaml", netpols); err != nil {
 		return fmt.Errorf("write NetworkPolicies: %w", err)
 	}
 	log.Printf("✓ Rendered NetworkPolicies")
+	rendered += len(netpols)
 
 	// 6. Emit GatewayRoute sub-ResourceRequest (delegates to gateway-route promise)
 	if config.IngressEnabled {
 		gwRequest := buildGatewayRouteRequest(config)
-		if err := u.WriteYAML(sdk, "resources/gateway-route-request.yaml", gwRequest); err != nil {
+		if err := x.WriteYAML("resources/gateway-route-request.yaml", gwRequest); err != nil {
 			return fmt.Errorf("write GatewayRoute request: %w", err)
 		}
 		log.Printf("✓ Rendered GatewayRoute sub-ResourceRequest")
+		rendered++
 	}
 
 	// 7. Write status
-	status := kratix.NewStatus()
-	status.Set("phase", "Configured")
-	status.Set("message", fmt.Sprintf("HTTP Service %s configured", config.Name))
-	status.Set("namespace", config.Namespace)
+	message := fmt.Sprintf("HTTP Service %s configured", config.Name)
+	status := u.ConfiguredStatus(resource, phase.Configured, message, rendered).
+		Condition(u.ConditionReady, u.ConditionTrue, phase.Configured, message).
+		Set("namespace", config.Namespace)
 	if config.IngressEnabled {
 		status.Set("url", fmt.Sprintf("https://%s%s", config.IngressHostname, config.IngressPath))
 	}
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 // handleDelete cleans up sub-ResourceRequests.
-func handleDelete(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
+func handleDelete(x *u.Execution, config *HTTPServiceConfig) error {
+	resource := x.Resource
+	x.Step(u.StepRender)
 	// Delete ArgoCDApplication sub-ResourceRequest
 	appRequest := u.Resource{
 		APIVersion: "platform.integratn.tech/v1alpha1",
@@ -361,7 +380,7 @@ func handleDelete(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
 			Namespace: "platform-requests",
 		},
 	}
-	if err := u.WriteYAML(sdk, "resources/delete-argocdapplication-"+config.Name+".yaml", appRequest); err != nil {
+	if err := x.WriteYAML("resources/delete-argocdapplication-"+config.Name+".yaml", appRequest); err != nil {
 		return fmt.Errorf("write delete ArgoCDApplication request: %w", err)
 	}
 	log.Printf("✓ Delete scheduled for ArgoCDApplication: %s", config.Name)
@@ -376,7 +395,7 @@ func handleDelete(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
 				Namespace: "platform-requests",
 			},
 		}
-		if err := u.WriteYAML(sdk, "resources/delete-externalsecret-"+config.Name+".yaml", esRequest); err != nil {
+		if err := x.WriteYAML("resources/delete-externalsecret-"+config.Name+".yaml", esRequest); err != nil {
 			return fmt.Errorf("write delete PlatformExternalSecret request: %w", err)
 		}
 		log.Printf("✓ Delete scheduled for PlatformExternalSecret: %s", config.Name)
@@ -392,18 +411,16 @@ func handleDelete(sdk *kratix.KratixSDK, config *HTTPServiceConfig) error {
 				Namespace: "platform-requests",
 			},
 		}
-		if err := u.WriteYAML(sdk, "resources/delete-gatewayroute-"+config.Name+".yaml", gwRequest); err != nil {
+		if err := x.WriteYAML("resources/delete-gatewayroute-"+config.Name+".yaml", gwRequest); err != nil {
 			return fmt.Errorf("write delete GatewayRoute request: %w", err)
 		}
 		log.Printf("✓ Delete scheduled for GatewayRoute: %s", config.Name)
 	}
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Deleting")
-	status.Set("message", fmt.Sprintf("HTTP Service %s scheduled for deletion", config.Name))
+	status := u.DeletingStatus(resource, fmt.Sprintf("HTTP Service %s scheduled for deletion", config.Name))
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	return nil
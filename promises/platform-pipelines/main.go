@@ -0,0 +1,82 @@
+// Command platform-pipelines runs the Kratix pipeline of every Go promise
+// from one binary and image, so pipeline pods share a single image pull.
+//
+// The pipeline is picked by, in order: the --pipeline flag, the PIPELINE
+// environment variable, then the name the binary was invoked as. The image
+// links each pipeline name to the binary, so a promise.yaml selects its
+// pipeline with command: ["/usr/local/bin/<pipeline>"].
+//
+// To add a promise: give its configure package a Run(*kratix.KratixSDK)
+// error that runs inside kratixutil.Execute, so failures still write a
+// status, add it to pipelines below, require and replace it in go.mod, and
+// COPY its directory in the Dockerfile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	argocdapplication "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/argocd-application"
+	argocdclusterregistration "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/argocd-cluster-registration"
+	argocdproject "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/argocd-project"
+	vclusterorchestratorv2 "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/vcluster-orchestrator-v2"
+	kratix "github.com/syntasso/kratix-go"
+)
+
+// pipelines maps each promise name to its pipeline.
+var pipelines = map[string]func(*kratix.KratixSDK) error{
+	"argocd-application":          argocdapplication.Run,
+	"argocd-cluster-registration": argocdclusterregistration.Run,
+	"argocd-project":              argocdproject.Run,
+	"vcluster-orchestrator-v2":    vclusterorchestratorv2.Run,
+}
+
+func main() {
+	name := flag.String("pipeline", "", "pipeline to run (default: $PIPELINE, then the binary name)")
+	list := flag.Bool("list", false, "print the pipeline names and exit")
+	flag.Parse()
+
+	if *list {
+		for _, n := range names() {
+			fmt.Println(n)
+		}
+		return
+	}
+
+	pipeline, run, err := resolve(*name, os.Getenv("PIPELINE"), os.Args[0])
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	if err := run(kratix.New()); err != nil {
+		log.Fatalf("ERROR: %s: %v", pipeline, err)
+	}
+}
+
+// resolve picks the pipeline from the flag, the environment, then argv[0].
+func resolve(flagName, envName, argv0 string) (string, func(*kratix.KratixSDK) error, error) {
+	name := flagName
+	if name == "" {
+		name = envName
+	}
+	if name == "" {
+		name = filepath.Base(argv0)
+	}
+	run, ok := pipelines[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown pipeline %q (known: %v); set --pipeline or PIPELINE", name, names())
+	}
+	return name, run, nil
+}
+
+func names() []string {
+	out := make([]string, 0, len(pipelines))
+	for n := range pipelines {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	kratix "github.com/syntasso/kratix-go"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden from the current pipelines")
+
+// transitionTime masks condition timestamps, the only output that varies
+// between runs.
+var transitionTime = regexp.MustCompile(`lastTransitionTime: "[^"]*"`)
+
+// TestGolden runs each pipeline's configure and delete actions against
+// testdata/<pipeline>.yaml and compares every file written to the Kratix
+// output and metadata directories with testdata/golden, byte for byte.
+// Golden files end in .golden so the promise Secret scan skips them.
+func TestGolden(t *testing.T) {
+	// Delete cleanups talk to the cluster when run in one; keep them local.
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	for _, name := range names() {
+		for _, action := range []string{"configure", "delete"} {
+			t.Run(name+"/"+action, func(t *testing.T) {
+				got := runPipeline(t, name, action)
+				dir := filepath.Join("testdata", "golden", name, action)
+				if *update {
+					writeTree(t, dir, got)
+				}
+				want := readTree(t, dir)
+				for path, data := range got {
+					if w, ok := want[path]; !ok {
+						t.Errorf("unexpected file %s", path)
+					} else if w != data {
+						t.Errorf("%s differs from golden:\n--- got\n%s\n--- want\n%s", path, data, w)
+					}
+				}
+				for path := range want {
+					if _, ok := got[path]; !ok {
+						t.Errorf("missing file %s", path)
+					}
+				}
+			})
+		}
+	}
+}
+
+// runPipeline runs a pipeline on its fixture in sandboxed Kratix
+// directories and returns the files it wrote, keyed by path under output/
+// or metadata/.
+func runPipeline(t *testing.T, name, action string) map[string]string {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", name+".yaml"))
+	if err != nil {
+		t.Fatalf("every pipeline needs a fixture: %v", err)
+	}
+	return runInput(t, name, action, input)
+}
+
+// runInput is runPipeline on the given object.yaml.
+func runInput(t *testing.T, name, action string, input []byte) map[string]string {
+	t.Helper()
+	t.Setenv("KRATIX_WORKFLOW_ACTION", action)
+	t.Setenv("KRATIX_WORKFLOW_TYPE", "resource")
+	t.Setenv("KRATIX_PROMISE_NAME", name)
+
+	root := t.TempDir()
+	for _, dir := range []string{"input", "output", "metadata"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "input", "object.yaml"), input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(
+		kratix.WithInputDir(filepath.Join(root, "input")),
+		kratix.WithOutputDir(filepath.Join(root, "output")),
+		kratix.WithMetadataDir(filepath.Join(root, "metadata")),
+	)
+	if err := pipelines[name](sdk); err != nil {
+		t.Fatalf("%s %s: %v", name, action, err)
+	}
+
+	files := map[string]string{}
+	for _, dir := range []string{"output", "metadata"} {
+		for path, data := range readTree(t, filepath.Join(root, dir)) {
+			files[filepath.ToSlash(filepath.Join(dir, path))] = transitionTime.ReplaceAllString(data, `lastTransitionTime: "<time>"`)
+		}
+	}
+	return files
+}
+
+// readTree returns the files under dir by slash-separated relative path,
+// without any .golden suffix.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(filepath.ToSlash(rel), ".golden")] = string(data)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(path)+".golden")
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// cliFixtures holds the requests hctl writes for each vcluster preset,
+// with what the vcluster pipeline renders for them. hctl's
+// --dry-run-pipeline tests replay these runs, so they must match the
+// pipeline; -update rewrites them here.
+const cliFixtures = "../../cli/cmd/vcluster/testdata/pipeline"
+
+// TestCLIFixtures runs the vcluster pipeline on each request in cliFixtures
+// and compares the output and metadata written beside it. Unlike the
+// goldens, the files carry no .golden suffix: hctl copies them verbatim.
+func TestCLIFixtures(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	presets, err := os.ReadDir(cliFixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, preset := range presets {
+		t.Run(preset.Name(), func(t *testing.T) {
+			dir := filepath.Join(cliFixtures, preset.Name())
+			input, err := os.ReadFile(filepath.Join(dir, "object.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := runInput(t, "vcluster-orchestrator-v2", "configure", input)
+			if *update {
+				for _, sub := range []string{"output", "metadata"} {
+					if err := os.RemoveAll(filepath.Join(dir, sub)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for path, data := range got {
+					p := filepath.Join(dir, filepath.FromSlash(path))
+					if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			want := map[string]string{}
+			for _, sub := range []string{"output", "metadata"} {
+				for path, data := range readTree(t, filepath.Join(dir, sub)) {
+					want[sub+"/"+path] = data
+				}
+			}
+			for path, data := range got {
+				if w, ok := want[path]; !ok {
+					t.Errorf("unexpected file %s", path)
+				} else if w != data {
+					t.Errorf("%s differs from the CLI fixture:\n--- got\n%s\n--- want\n%s", path, data, w)
+				}
+			}
+			for path := range want {
+				if _, ok := got[path]; !ok {
+					t.Errorf("missing file %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		flag, env, argv0 string
+		want             string
+	}{
+		{"argocd-project", "argocd-application", "/usr/local/bin/vcluster-orchestrator-v2", "argocd-project"},
+		{"", "argocd-application", "/usr/local/bin/vcluster-orchestrator-v2", "argocd-application"},
+		{"", "", "/usr/local/bin/vcluster-orchestrator-v2", "vcluster-orchestrator-v2"},
+	}
+	for _, tt := range tests {
+		got, run, err := resolve(tt.flag, tt.env, tt.argv0)
+		if err != nil || got != tt.want || run == nil {
+			t.Errorf("resolve(%q, %q, %q) = %q, %v, want %q", tt.flag, tt.env, tt.argv0, got, err, tt.want)
+		}
+	}
+	if _, _, err := resolve("", "", "/usr/local/bin/platform-pipelines"); err == nil {
+		t.Error("resolve() accepted the bare binary name")
+	}
+}
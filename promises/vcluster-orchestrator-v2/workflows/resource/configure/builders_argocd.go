@@ -1,4 +1,4 @@
-package main
+package vclusterorchestratorv2
 
 import (
 	"fmt"
@@ -39,7 +39,7 @@ func buildArgoCDProjectRequest(config *VClusterConfig) u.Resource {
 			Destinations: []u.ProjectDestination{
 				{
 					Namespace: config.TargetNamespace,
-					Server:    "https://kubernetes.default.svc",
+					Server:    config.ArgoCDDestServer,
 				},
 			},
 			ClusterResourceWhitelist: []u.ResourceFilter{
@@ -100,16 +100,18 @@ func buildArgoCDClusterRegistrationRequest(config *VClusterConfig) u.Resource {
 	}, u.BaseLabels(config.WorkflowContext.PromiseName, config.Name))
 
 	spec := u.ArgoCDClusterRegistrationSpec{
-		Name:              config.Name,
-		TargetNamespace:   config.TargetNamespace,
-		KubeconfigSecret:  fmt.Sprintf("vc-%s", config.Name),
-		ExternalServerURL: config.ExternalServerURL,
-		Environment:       config.ArgoCDEnvironment,
-		BaseDomain:        config.BaseDomain,
-		BaseDomainSanitized: config.BaseDomainSanitized,
-		ClusterLabels:     config.ArgoCDClusterLabels,
-		ClusterAnnotations: config.ArgoCDClusterAnnotations,
-		SyncJobName:       config.KubeconfigSyncJobName,
+		Name:                   config.Name,
+		TargetNamespace:        config.TargetNamespace,
+		KubeconfigSecret:       config.KubeconfigSecret,
+		ExternalServerURL:      config.ExternalServerURL,
+		Environment:            config.ArgoCDEnvironment,
+		BaseDomain:             config.BaseDomain,
+		BaseDomainSanitized:    config.BaseDomainSanitized,
+		ClusterLabels:          config.ArgoCDClusterLabels,
+		ClusterAnnotations:     config.ArgoCDClusterAnnotations,
+		SyncJobName:            config.KubeconfigSyncJobName,
+		OnePassword:            config.OnePassword,
+		OnePasswordConnectHost: config.OnePasswordConnectHost,
 	}
 
 	return u.Resource{
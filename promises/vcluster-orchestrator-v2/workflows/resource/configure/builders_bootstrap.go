@@ -0,0 +1,220 @@
+package vclusterorchestratorv2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kratix "github.com/syntasso/kratix-go"
+	"sigs.k8s.io/yaml"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+)
+
+// bootstrapKubeconfigKey is the key vcluster exports the kubeconfig under in
+// config.KubeconfigSecret.
+const bootstrapKubeconfigKey = "config"
+
+// BootstrapManifest is one object of spec.bootstrap.manifests, applied
+// inside the vcluster by the bootstrap Job.
+type BootstrapManifest struct {
+	// Ref is "Kind namespace/name", or "Kind name" for cluster-scoped
+	// objects, as reported in the BootstrapApplied condition.
+	Ref  string
+	Wave int
+	// Key is the object's file in the bootstrap ConfigMap, which sorts in
+	// apply order.
+	Key  string
+	YAML string
+}
+
+// extractBootstrapManifests reads spec.bootstrap.manifests, ordered by each
+// object's own sync-wave annotation and otherwise as listed.
+func extractBootstrapManifests(resource kratix.Resource) ([]BootstrapManifest, error) {
+	val, err := resource.GetValue("spec.bootstrap.manifests")
+	if err != nil || val == nil {
+		return nil, nil
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("spec.bootstrap.manifests must be a list of objects")
+	}
+
+	var manifests []BootstrapManifest
+	for i, item := range arr {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("spec.bootstrap.manifests[%d] is not an object", i)
+		}
+		apiVersion, _ := obj["apiVersion"].(string)
+		kind, _ := obj["kind"].(string)
+		meta, _ := obj["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		if apiVersion == "" || kind == "" || name == "" {
+			return nil, fmt.Errorf("spec.bootstrap.manifests[%d] needs apiVersion, kind and metadata.name", i)
+		}
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("spec.bootstrap.manifests[%d]: %w", i, err)
+		}
+		m := BootstrapManifest{Ref: kind + " " + name, Key: strings.ToLower(kind) + "-" + name + ".yaml", YAML: string(doc)}
+		if ns, _ := meta["namespace"].(string); ns != "" {
+			m.Ref = kind + " " + ns + "/" + name
+		}
+		annotations, _ := meta["annotations"].(map[string]interface{})
+		if wave, _ := annotations[u.SyncWaveAnnotation].(string); wave != "" {
+			if m.Wave, err = strconv.Atoi(wave); err != nil {
+				return nil, fmt.Errorf("spec.bootstrap.manifests[%d] (%s): sync-wave %q is not an integer", i, m.Ref, wave)
+			}
+		}
+		manifests = append(manifests, m)
+	}
+	sort.SliceStable(manifests, func(i, j int) bool { return manifests[i].Wave < manifests[j].Wave })
+	for i := range manifests {
+		manifests[i].Key = fmt.Sprintf("%03d-%s", i, manifests[i].Key)
+	}
+	return manifests, nil
+}
+
+// bootstrapName names the bootstrap ConfigMap and Job after a hash of the
+// manifests: a Job's pod template cannot change, so a new set of manifests
+// gets a new Job, and an unchanged set is not applied again.
+func bootstrapName(config *VClusterConfig) string {
+	h := sha256.New()
+	for _, m := range config.Bootstrap {
+		fmt.Fprintf(h, "%s\n%s\n", m.Key, m.YAML)
+	}
+	suffix := "-bootstrap-" + hex.EncodeToString(h.Sum(nil))[:8]
+	// The Job name is also the job-name label of its pods.
+	name := config.Name
+	if len(name)+len(suffix) > 63 {
+		name = strings.TrimRight(name[:63-len(suffix)], "-")
+	}
+	return name + suffix
+}
+
+// buildBootstrap renders spec.bootstrap.manifests as a ConfigMap and a Job
+// that applies them inside the vcluster, in sync-wave order, with the
+// kubeconfig vcluster exports. Like the kubeconfig sync Job, an init
+// container waits for the kubeconfig Secret to be mounted. The first object
+// that fails to apply ends the Job, with "<ref>: <error>" as its pod's
+// termination message, which the platform-status-reconciler reports in the
+// BootstrapApplied condition.
+func buildBootstrap(config *VClusterConfig) []u.Resource {
+	if len(config.Bootstrap) == 0 {
+		return nil
+	}
+	name := bootstrapName(config)
+	data := map[string]string{}
+	for _, m := range config.Bootstrap {
+		data[m.Key] = m.YAML
+	}
+	labels := u.MergeStringMap(map[string]string{
+		"app.kubernetes.io/name":     "vcluster-bootstrap",
+		"app.kubernetes.io/instance": config.Name,
+	}, u.BaseLabels(config.WorkflowContext.PromiseName, config.Name))
+
+	configMap := u.Resource{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   u.ResourceMeta(name, config.TargetNamespace, labels, nil),
+		Data:       data,
+	}
+
+	job := u.Resource{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata:   u.ResourceMeta(name, config.TargetNamespace, labels, nil),
+		Spec: JobSpec{
+			BackoffLimit: 3,
+			Template: PodTemplateSpec{
+				Metadata: &ObjectMetaLocal{
+					Labels: map[string]string{
+						"app.kubernetes.io/name":     "vcluster-bootstrap",
+						"app.kubernetes.io/instance": config.Name,
+					},
+				},
+				Spec: PodSpec{
+					// Never, so each failed attempt keeps its pod and
+					// termination message
+					RestartPolicy: "Never",
+					InitContainers: []Container{
+						{
+							Name:    "wait-for-kubeconfig",
+							Image:   "busybox:1.36",
+							Command: []string{"sh", "-c", buildBootstrapWaitScript()},
+							VolumeMounts: []VolumeMount{
+								{Name: "kubeconfig", MountPath: "/kubeconfig"},
+							},
+						},
+					},
+					Containers: []Container{
+						{
+							Name:    "apply",
+							Image:   "bitnami/kubectl:latest",
+							Command: []string{"/bin/bash", "-c", buildBootstrapApplyScript(config)},
+							Env: []EnvVar{
+								{Name: "KUBECONFIG", Value: "/kubeconfig/" + bootstrapKubeconfigKey},
+							},
+							VolumeMounts: []VolumeMount{
+								{Name: "kubeconfig", MountPath: "/kubeconfig", ReadOnly: true},
+								{Name: "manifests", MountPath: "/manifests", ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []Volume{
+						{
+							Name:   "kubeconfig",
+							Secret: &SecretVolume{SecretName: config.KubeconfigSecret},
+						},
+						{
+							Name:      "manifests",
+							ConfigMap: &ConfigMapVolume{Name: name},
+						},
+					},
+				},
+			},
+		},
+	}
+	return []u.Resource{configMap, job}
+}
+
+func buildBootstrapWaitScript() string {
+	return fmt.Sprintf(`echo "Waiting for the vcluster kubeconfig secret to be mounted..."
+until [ -f /kubeconfig/%s ]; do
+  echo "Kubeconfig not found yet, sleeping..."
+  sleep 5
+done
+echo "Kubeconfig found!"`, bootstrapKubeconfigKey)
+}
+
+// buildBootstrapApplyScript applies the manifests one at a time, in order,
+// so a failure names its object.
+func buildBootstrapApplyScript(config *VClusterConfig) string {
+	var b strings.Builder
+	b.WriteString(`set -u
+echo "Waiting for the vcluster API server..."
+until kubectl get --raw /readyz >/dev/null 2>&1; do
+  sleep 5
+done
+
+apply() {
+  echo "Applying $1"
+  if ! out=$(kubectl apply --server-side --force-conflicts -f "/manifests/$2" 2>&1); then
+    echo "$out"
+    printf '%s: %s' "$1" "$out" > /dev/termination-log
+    exit 1
+  fi
+  echo "$out"
+}
+
+`)
+	for _, m := range config.Bootstrap {
+		fmt.Fprintf(&b, "apply '%s' %s\n", m.Ref, m.Key)
+	}
+	fmt.Fprintf(&b, `echo "Applied %d bootstrap objects"`, len(config.Bootstrap))
+	return b.String()
+}
@@ -1,4 +1,28 @@
-package main
+package vclusterorchestratorv2
+
+// Namespace placement for everything this pipeline renders. config.Namespace
+// is the namespace of the VClusterOrchestratorV2 request; config.TargetNamespace
+// is where the vcluster runs (spec.targetNamespace, defaulting to Namespace).
+// Sub-requests stay beside the parent request so their promises reconcile them
+// under the same tenancy; everything the vcluster itself touches lives in
+// TargetNamespace.
+//
+//	Resource                               metadata.namespace  Namespaced references
+//	ArgoCDProject request                  Namespace           destinations → TargetNamespace on ArgoCDDestServer
+//	ArgoCDApplication request              Namespace           destination → TargetNamespace on ArgoCDDestServer
+//	ArgoCDClusterRegistration request      Namespace           targetNamespace, kubeconfigSecret → TargetNamespace
+//	Namespace                              (cluster-scoped)    name = TargetNamespace
+//	CoreDNS ConfigMap                      TargetNamespace
+//	etcd Issuers, Certificates             TargetNamespace     issuerRefs are namespaced Issuers in TargetNamespace
+//	etcd merge SA, Role, RoleBinding, Job  TargetNamespace     RoleBinding subjects → TargetNamespace
+//	NetworkPolicies                        TargetNamespace
+//	bootstrap ConfigMap, Job               TargetNamespace     mounts the kubeconfig Secret in TargetNamespace
+//	vcluster ClusterRole(Binding) deletes  (cluster-scoped)    name embeds TargetNamespace
+//	host PV cleanup (direct API)           (cluster-scoped)    managed-by label embeds TargetNamespace
+//
+// The kubeconfig Secret exported by vcluster must live in TargetNamespace:
+// the cluster-registration sync Job mounts it and its Role grants access to it
+// by name there.
 
 func etcdEnabled(config *VClusterConfig) bool {
 	if config.BackingStore == nil {
@@ -15,3 +39,15 @@ func etcdEnabled(config *VClusterConfig) bool {
 	enabled, ok := deploy["enabled"].(bool)
 	return ok && enabled
 }
+
+// exportedKubeconfigSecret returns the name and namespace vcluster writes its
+// kubeconfig Secret to, as set by exportKubeConfig.secret in the final Helm
+// values. Empty strings mean the chart default (vc-<release> in the release
+// namespace).
+func exportedKubeconfigSecret(values map[string]interface{}) (name, namespace string) {
+	export, _ := values["exportKubeConfig"].(map[string]interface{})
+	secret, _ := export["secret"].(map[string]interface{})
+	name, _ = secret["name"].(string)
+	namespace, _ = secret["namespace"].(string)
+	return name, namespace
+}
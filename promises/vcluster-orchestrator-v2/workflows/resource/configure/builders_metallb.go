@@ -0,0 +1,247 @@
+package vclusterorchestratorv2
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// MetalLB inside the vcluster. The chart's deploy.metallb installs MetalLB;
+// the pool and L2 advertisement are applied into the vcluster through
+// experimental.deploy.vcluster.manifests, so LoadBalancer Services created
+// there draw from spec.vcluster.networking.loadBalancer.addressPool instead
+// of whatever pool happens to exist.
+
+const (
+	metallbNamespace = "metallb-system"
+	metallbPoolName  = "vcluster"
+)
+
+// addressRange is an inclusive range of IPv4 or IPv6 addresses.
+type addressRange struct {
+	first, last netip.Addr
+}
+
+// overlaps reports whether r and o share an address; ranges of different
+// families never do.
+func (r addressRange) overlaps(o addressRange) bool {
+	return r.first.Is4() == o.first.Is4() && r.first.Compare(o.last) <= 0 && o.first.Compare(r.last) <= 0
+}
+
+func (r addressRange) String() string {
+	return fmt.Sprintf("%s-%s", r.first, r.last)
+}
+
+// prefixRange is the range of addresses in p.
+func prefixRange(p netip.Prefix) addressRange {
+	first := p.Masked().Addr()
+	last := first.AsSlice()
+	for bit := p.Bits(); bit < len(last)*8; bit++ {
+		last[bit/8] |= 0x80 >> (bit % 8)
+	}
+	l, _ := netip.AddrFromSlice(last)
+	return addressRange{first: first, last: l}
+}
+
+// parseAddressPool parses a MetalLB address pool: a CIDR (10.0.5.0/28,
+// fd00:5::/120) or an inclusive range (10.0.5.10-10.0.5.20), or one of each
+// family separated by a comma for dual-stack.
+func parseAddressPool(pool string) ([]addressRange, error) {
+	var ranges []addressRange
+	for _, part := range strings.Split(pool, ",") {
+		r, err := parseAddressRange(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("address pool %q: %w", pool, err)
+		}
+		for _, prev := range ranges {
+			if prev.first.Is4() == r.first.Is4() {
+				return nil, fmt.Errorf("address pool %q: give at most one %s and one %s entry", pool, ipFamilyIPv4, ipFamilyIPv6)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func parseAddressRange(s string) (addressRange, error) {
+	if strings.Contains(s, "/") {
+		p, err := parsePrefix(s)
+		if err != nil {
+			return addressRange{}, fmt.Errorf("%q is not a CIDR or range", s)
+		}
+		return prefixRange(p), nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return addressRange{}, fmt.Errorf("%q is not a CIDR or range", s)
+	}
+	start, err1 := parseAddr(from)
+	end, err2 := parseAddr(to)
+	if err1 != nil || err2 != nil {
+		return addressRange{}, fmt.Errorf("%q is not a CIDR or range", s)
+	}
+	if addrFamily(start) != addrFamily(end) {
+		return addressRange{}, fmt.Errorf("range %s starts with an %s address but ends with an %s one", s, addrFamily(start), addrFamily(end))
+	}
+	r := addressRange{first: start, last: end}
+	if r.first.Compare(r.last) > 0 {
+		return addressRange{}, fmt.Errorf("range %s ends before it starts", s)
+	}
+	return r, nil
+}
+
+// rangesOverlap reports whether any range of a overlaps one of b.
+func rangesOverlap(a, b []addressRange) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.overlaps(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// declaredPool is the address pool another vcluster request declares.
+type declaredPool struct {
+	Namespace string
+	Name      string
+	Pool      string
+}
+
+// validateLoadBalancerPool checks the vcluster's pool against its exposure
+// subnet, where the API VIP lives, and against every pool other vclusters
+// declare. Two vclusters announcing the same address would fight over it.
+func validateLoadBalancerPool(config *VClusterConfig, others []declaredPool) error {
+	lb := config.LoadBalancer
+	if lb == nil {
+		return nil
+	}
+	pool, err := parseAddressPool(lb.AddressPool)
+	if err != nil {
+		return fmt.Errorf("spec.vcluster.networking.loadBalancer: %w", err)
+	}
+	if config.Subnet != "" {
+		if subnet, err := parseAddressPool(config.Subnet); err == nil && rangesOverlap(pool, subnet) {
+			return fmt.Errorf("spec.vcluster.networking.loadBalancer.addressPool %s overlaps the exposure subnet %s; pick addresses outside it", lb.AddressPool, config.Subnet)
+		}
+	}
+	var clashes []string
+	for _, other := range others {
+		if other.Namespace == config.Namespace && other.Name == config.Name {
+			continue
+		}
+		r, err := parseAddressPool(other.Pool)
+		if err != nil {
+			continue
+		}
+		if rangesOverlap(pool, r) {
+			clashes = append(clashes, fmt.Sprintf("%s/%s (%s)", other.Namespace, other.Name, other.Pool))
+		}
+	}
+	if len(clashes) > 0 {
+		return fmt.Errorf("spec.vcluster.networking.loadBalancer.addressPool %s overlaps the pool of vcluster %s", lb.AddressPool, strings.Join(clashes, ", "))
+	}
+	return nil
+}
+
+// listDeclaredPools reads the address pools of every VClusterOrchestratorV2
+// in the cluster, using the pipeline's ServiceAccount.
+func listDeclaredPools() ([]declaredPool, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	gvr := schema.GroupVersionResource{Group: "platform.integratn.tech", Version: "v1alpha1", Resource: "vclusterorchestratorv2s"}
+	list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vclusterorchestratorv2s: %w", err)
+	}
+
+	var pools []declaredPool
+	for _, item := range list.Items {
+		spec, _ := item.Object["spec"].(map[string]interface{})
+		name, _ := spec["name"].(string)
+		vc, _ := spec["vcluster"].(map[string]interface{})
+		networking, _ := vc["networking"].(map[string]interface{})
+		lb, _ := networking["loadBalancer"].(map[string]interface{})
+		pool, _ := lb["addressPool"].(string)
+		if name != "" && pool != "" {
+			pools = append(pools, declaredPool{Namespace: item.GetNamespace(), Name: name, Pool: pool})
+		}
+	}
+	return pools, nil
+}
+
+// buildMetalLBManifests returns the IPAddressPool and L2Advertisement applied
+// inside the vcluster.
+func buildMetalLBManifests(config *VClusterConfig) []u.Resource {
+	lb := config.LoadBalancer
+	if lb == nil {
+		return nil
+	}
+	l2 := map[string]interface{}{
+		"ipAddressPools": []string{metallbPoolName},
+	}
+	if len(lb.L2Interfaces) > 0 {
+		l2["interfaces"] = lb.L2Interfaces
+	}
+	return []u.Resource{
+		{
+			APIVersion: "metallb.io/v1beta1",
+			Kind:       "IPAddressPool",
+			Metadata:   u.ResourceMeta(metallbPoolName, metallbNamespace, nil, nil),
+			Spec: map[string]interface{}{
+				"addresses":  poolAddresses(lb.AddressPool),
+				"autoAssign": lb.AutoAssign,
+			},
+		},
+		{
+			APIVersion: "metallb.io/v1beta1",
+			Kind:       "L2Advertisement",
+			Metadata:   u.ResourceMeta(metallbPoolName, metallbNamespace, nil, nil),
+			Spec:       l2,
+		},
+	}
+}
+
+// poolAddresses splits a dual-stack pool into the IPAddressPool addresses.
+func poolAddresses(pool string) []string {
+	var out []string
+	for _, part := range strings.Split(pool, ",") {
+		out = append(out, strings.TrimSpace(part))
+	}
+	return out
+}
+
+// metalLBManifestsValue renders the manifests as the multi-document string
+// experimental.deploy.vcluster.manifests expects.
+func metalLBManifestsValue(config *VClusterConfig) (string, error) {
+	var docs []string
+	for _, r := range buildMetalLBManifests(config) {
+		data, err := yaml.Marshal(r)
+		if err != nil {
+			return "", fmt.Errorf("marshal %s: %w", r.Kind, err)
+		}
+		docs = append(docs, string(data))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
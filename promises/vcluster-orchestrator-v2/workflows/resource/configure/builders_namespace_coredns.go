@@ -1,4 +1,4 @@
-package main
+package vclusterorchestratorv2
 
 import (
 	"fmt"
@@ -1,4 +1,4 @@
-package main
+package vclusterorchestratorv2
 
 import (
 	"fmt"
@@ -25,9 +25,13 @@ func buildNetworkPolicies(config *VClusterConfig) []u.Resource {
 		buildCorednsHostDNSPolicy(config),
 		buildIntraNamespacePolicy(config),
 		buildVClusterExternalPolicy(config),
-		buildVClusterLBSNATPolicy(config),
 	)
 
+	// SNAT'd traffic to the API LoadBalancer (none in ingress exposure mode)
+	if config.ExposureMode != exposureModeIngress {
+		policies = append(policies, buildVClusterLBSNATPolicy(config))
+	}
+
 	// --- Optional policies ---
 
 	// NFS egress (opt-in)
@@ -241,7 +245,8 @@ func buildVClusterExternalPolicy(config *VClusterConfig) u.Resource {
 						{"protocol": "TCP", "port": 8443},
 					},
 				},
-				// nginx-gateway routes traffic to vCluster workloads
+				// nginx-gateway routes traffic to vCluster workloads and, in
+				// ingress exposure mode, passes the API's TLS through to 8443
 				{
 					"from": []map[string]interface{}{
 						{
@@ -0,0 +1,146 @@
+package vclusterorchestratorv2
+
+import (
+	"fmt"
+
+	kratix "github.com/syntasso/kratix-go"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+)
+
+const (
+	// defaultQuotaMultiplier sizes the room left for synced workload pods,
+	// as a multiple of the control plane's requests, when
+	// spec.vcluster.quota.workloadMultiplier is not set.
+	defaultQuotaMultiplier = 4
+	// quotaPodsPerMultiplier is the workload pods allowed per unit of the
+	// multiplier when spec.vcluster.quota.pods is not set.
+	quotaPodsPerMultiplier = 10
+
+	// LimitRange defaults for containers synced without requests or
+	// limits, which the quota would otherwise reject.
+	limitRangeCPURequest    = "100m"
+	limitRangeMemoryRequest = "128Mi"
+	limitRangeCPULimit      = "500m"
+	limitRangeMemoryLimit   = "512Mi"
+)
+
+// resolveQuota returns the target namespace's quota: in strict isolation,
+// or whenever spec.vcluster.quota is set. Values not set explicitly are
+// derived from the control plane's requests plus workloadMultiplier times
+// as much again for synced workload pods. Explicit values below what the
+// control plane itself requests are an error, since the vcluster could
+// then never schedule.
+func resolveQuota(config *VClusterConfig, res kratix.Resource) (*QuotaConfig, error) {
+	if explicit, _ := res.GetValue("spec.vcluster.quota"); explicit == nil && config.IsolationMode != "strict" {
+		return nil, nil
+	}
+
+	multiplier := defaultQuotaMultiplier
+	if v, err := u.GetIntValue(res, "spec.vcluster.quota.workloadMultiplier"); err == nil {
+		if v < 1 {
+			return nil, fmt.Errorf("workloadMultiplier %d must be at least 1", v)
+		}
+		multiplier = v
+	}
+
+	cpuRequest, err := resource.ParseQuantity(config.CPURequest)
+	if err != nil {
+		return nil, fmt.Errorf("control plane cpu request %q: %w", config.CPURequest, err)
+	}
+	memoryRequest, err := resource.ParseQuantity(config.MemoryRequest)
+	if err != nil {
+		return nil, fmt.Errorf("control plane memory request %q: %w", config.MemoryRequest, err)
+	}
+	cpCPU := resource.NewMilliQuantity(cpuRequest.MilliValue()*int64(config.Replicas), resource.DecimalSI)
+	cpMemory := resource.NewQuantity(memoryRequest.Value()*int64(config.Replicas), resource.BinarySI)
+	cpPods := config.Replicas + config.CorednsReplicas
+
+	quota := &QuotaConfig{
+		CPU:    resource.NewMilliQuantity(cpCPU.MilliValue()*int64(1+multiplier), resource.DecimalSI).String(),
+		Memory: resource.NewQuantity(cpMemory.Value()*int64(1+multiplier), resource.BinarySI).String(),
+		Pods:   cpPods + multiplier*quotaPodsPerMultiplier,
+	}
+
+	for _, q := range []struct {
+		name  string
+		value *string
+		min   *resource.Quantity
+		each  string
+	}{
+		{"cpu", &quota.CPU, cpCPU, config.CPURequest},
+		{"memory", &quota.Memory, cpMemory, config.MemoryRequest},
+	} {
+		v, _ := u.GetStringValue(res, "spec.vcluster.quota."+q.name)
+		if v == "" {
+			continue
+		}
+		parsed, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q is not a quantity", q.name, v)
+		}
+		if parsed.Cmp(*q.min) < 0 {
+			return nil, fmt.Errorf("%s %s is less than the control plane requests (%d × %s = %s), so the vcluster could not schedule",
+				q.name, v, config.Replicas, q.each, q.min.String())
+		}
+		*q.value = v
+	}
+	if v, err := u.GetIntValue(res, "spec.vcluster.quota.pods"); err == nil {
+		if v < cpPods {
+			return nil, fmt.Errorf("pods %d is less than the control plane's %d (%d control plane, %d CoreDNS), so the vcluster could not schedule",
+				v, cpPods, config.Replicas, config.CorednsReplicas)
+		}
+		quota.Pods = v
+	}
+	return quota, nil
+}
+
+// buildQuota renders the target namespace's ResourceQuota and the
+// LimitRange that gives requests and limits to pods synced without them.
+// Nil without a quota.
+func buildQuota(config *VClusterConfig) []u.Resource {
+	if config.Quota == nil {
+		return nil
+	}
+	labels := u.MergeStringMap(map[string]string{
+		"app.kubernetes.io/name":       "vcluster-quota",
+		"platform.integratn.tech/type": "vcluster-policy",
+	}, u.BaseLabels(config.WorkflowContext.PromiseName, config.Name))
+	name := fmt.Sprintf("vcluster-%s", config.Name)
+
+	return []u.Resource{
+		{
+			APIVersion: "v1",
+			Kind:       "ResourceQuota",
+			Metadata:   u.ResourceMeta(name, config.TargetNamespace, labels, nil),
+			Spec: map[string]interface{}{
+				"hard": map[string]interface{}{
+					"requests.cpu":    config.Quota.CPU,
+					"requests.memory": config.Quota.Memory,
+					"pods":            config.Quota.Pods,
+				},
+			},
+		},
+		{
+			APIVersion: "v1",
+			Kind:       "LimitRange",
+			Metadata:   u.ResourceMeta(name, config.TargetNamespace, labels, nil),
+			Spec: map[string]interface{}{
+				"limits": []map[string]interface{}{
+					{
+						"type": "Container",
+						"defaultRequest": map[string]string{
+							"cpu":    limitRangeCPURequest,
+							"memory": limitRangeMemoryRequest,
+						},
+						"default": map[string]string{
+							"cpu":    limitRangeCPULimit,
+							"memory": limitRangeMemoryLimit,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,43 @@
+package vclusterorchestratorv2
+
+import (
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+)
+
+// buildAPIRoute returns, in ingress exposure mode, the TLSRoute that serves
+// the vcluster API through the host's nginx-gateway. The listener passes
+// TLS through by SNI, so the vcluster keeps terminating it with its own
+// certificate, and the route's hostname is what external-dns publishes.
+// The chart names the API Service after the release, on port 443.
+func buildAPIRoute(config *VClusterConfig) []u.Resource {
+	if config.ExposureMode != exposureModeIngress {
+		return nil
+	}
+	labels := u.MergeStringMap(map[string]string{
+		"app.kubernetes.io/name":      "vcluster-api",
+		"app.kubernetes.io/component": "api-route",
+	}, u.BaseLabels(config.WorkflowContext.PromiseName, config.Name))
+
+	return []u.Resource{{
+		APIVersion: "gateway.networking.k8s.io/v1alpha2",
+		Kind:       "TLSRoute",
+		Metadata:   u.ResourceMeta(config.Name+"-api", config.TargetNamespace, labels, nil),
+		Spec: map[string]interface{}{
+			"parentRefs": []map[string]interface{}{
+				{
+					"name":        apiGatewayName,
+					"namespace":   apiGatewayNamespace,
+					"sectionName": apiGatewayListener,
+				},
+			},
+			"hostnames": []string{config.Hostname},
+			"rules": []map[string]interface{}{
+				{
+					"backendRefs": []map[string]interface{}{
+						{"name": config.Name, "port": 443},
+					},
+				},
+			},
+		},
+	}}
+}
@@ -0,0 +1,290 @@
+package vclusterorchestratorv2
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IP utilities for spec.exposure and the MetalLB pool. Addresses may be
+// IPv4 or IPv6; a dual-stack subnet, VIP or pool is written the way
+// Kubernetes writes dual-stack ranges, one entry per family separated by a
+// comma (10.0.4.0/24,fd00:4::/64).
+
+const (
+	ipFamilyIPv4 = "IPv4"
+	ipFamilyIPv6 = "IPv6"
+)
+
+// Service spec.ipFamilyPolicy values.
+const (
+	ipFamilyPolicySingleStack      = "SingleStack"
+	ipFamilyPolicyPreferDualStack  = "PreferDualStack"
+	ipFamilyPolicyRequireDualStack = "RequireDualStack"
+)
+
+// metallbLoadBalancerIPsAnnotation requests addresses from MetalLB, one
+// per family for a dual-stack Service.
+const metallbLoadBalancerIPsAnnotation = "metallb.io/loadBalancerIPs"
+
+// defaultVIPOffset is where the VIP is auto-selected in the exposure subnet
+// without spec.exposure.vipOffset; 200 aligns with the host MetalLB pool
+// 10.0.4.200-253.
+const defaultVIPOffset = 200
+
+// addrFamily names the family of a, treating IPv4-mapped IPv6 as IPv4.
+func addrFamily(a netip.Addr) string {
+	if a.Unmap().Is4() {
+		return ipFamilyIPv4
+	}
+	return ipFamilyIPv6
+}
+
+// parseAddr parses an IP address without a zone, unmapping IPv4-mapped
+// IPv6 so that it compares equal to its IPv4 form.
+func parseAddr(s string) (netip.Addr, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil || a.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("%q is not an IP address", s)
+	}
+	return a.Unmap(), nil
+}
+
+// parsePrefix parses a CIDR and masks it to its network address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not a CIDR", s)
+	}
+	if p.Addr().Is4In6() {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		if !p.IsValid() {
+			return netip.Prefix{}, fmt.Errorf("%q is not a CIDR", s)
+		}
+	}
+	return p.Masked(), nil
+}
+
+// splitFamilies splits a comma-separated dual-stack value and checks it
+// holds at most one entry per family.
+func splitFamilies[T any](value string, parse func(string) (T, error), family func(T) string) ([]T, error) {
+	var out []T
+	seen := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		v, err := parse(part)
+		if err != nil {
+			return nil, err
+		}
+		f := family(v)
+		if prev, ok := seen[f]; ok {
+			return nil, fmt.Errorf("%s and %s are both %s; give at most one %s and one %s entry", prev, strings.TrimSpace(part), f, ipFamilyIPv4, ipFamilyIPv6)
+		}
+		seen[f] = strings.TrimSpace(part)
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// parseSubnets parses spec.exposure.subnet: a CIDR, or an IPv4 and an IPv6
+// CIDR for dual-stack.
+func parseSubnets(value string) ([]netip.Prefix, error) {
+	return splitFamilies(value, parsePrefix, func(p netip.Prefix) string { return addrFamily(p.Addr()) })
+}
+
+// parseVIPs parses spec.exposure.vip: an address, or an IPv4 and an IPv6
+// address for dual-stack.
+func parseVIPs(value string) ([]netip.Addr, error) {
+	return splitFamilies(value, parseAddr, addrFamily)
+}
+
+// addrAdd returns a plus n, or false when that overflows its family.
+func addrAdd(a netip.Addr, n uint64) (netip.Addr, bool) {
+	b := a.AsSlice()
+	sum := new(big.Int).Add(new(big.Int).SetBytes(b), new(big.Int).SetUint64(n))
+	if sum.BitLen() > len(b)*8 {
+		return netip.Addr{}, false
+	}
+	out, _ := netip.AddrFromSlice(sum.FillBytes(make([]byte, len(b))))
+	return out, true
+}
+
+// defaultVIPFromCIDR returns the address offset hosts into cidr, for IPv4
+// and IPv6 alike. It fails when the subnet is too small to hold it, or when
+// the offset lands on the subnet's network or broadcast address.
+func defaultVIPFromCIDR(cidr string, offset int) (string, error) {
+	prefix, err := parsePrefix(cidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid CIDR: %w", err)
+	}
+	if offset < 0 {
+		return "", fmt.Errorf("VIP offset %d is negative", offset)
+	}
+	vip, ok := addrAdd(prefix.Addr(), uint64(offset))
+	if !ok || !prefix.Contains(vip) {
+		return "", fmt.Errorf("subnet %s is too small for a VIP at offset %d; set spec.exposure.vip or vipOffset", prefix, offset)
+	}
+	if reserved := reservedAddr(vip, prefix); reserved != "" {
+		return "", fmt.Errorf("offset %d is the %s address of subnet %s; set spec.exposure.vip or vipOffset", offset, reserved, prefix)
+	}
+	return vip.String(), nil
+}
+
+// reservedAddr names what a is in subnet when it cannot be a host address:
+// "network" for the network address, or "broadcast" for the last IPv4
+// address. Point-to-point subnets (/31 and /32, /127 and /128 in IPv6) have
+// neither. subnet must be masked.
+func reservedAddr(a netip.Addr, subnet netip.Prefix) string {
+	hostBits := a.BitLen() - subnet.Bits()
+	if hostBits <= 1 {
+		return ""
+	}
+	if a == subnet.Addr() {
+		return "network"
+	}
+	if broadcast, _ := addrAdd(subnet.Addr(), 1<<hostBits-1); a.Is4() && a == broadcast {
+		return "broadcast"
+	}
+	return ""
+}
+
+// checkVIPInSubnet reports why vip cannot be the VIP for subnet: a family
+// mismatch, an address outside it, or its network or broadcast address.
+func checkVIPInSubnet(vip netip.Addr, subnet netip.Prefix) error {
+	if vf, sf := addrFamily(vip), addrFamily(subnet.Addr()); vf != sf {
+		return fmt.Errorf("VIP %s is an %s address but subnet %s is %s", vip, vf, subnet, sf)
+	}
+	if !subnet.Contains(vip) {
+		return fmt.Errorf("VIP %s is not within subnet %s", vip, subnet)
+	}
+	if reserved := reservedAddr(vip, subnet); reserved != "" {
+		return fmt.Errorf("VIP %s is the %s address of subnet %s", vip, reserved, subnet)
+	}
+	return nil
+}
+
+// sanFor formats an address as a certificate SAN: the bare address, never
+// bracketed.
+func sanFor(a netip.Addr) string {
+	return a.Unmap().WithZone("").String()
+}
+
+// serverURL formats the https URL of host and port, bracketing an IPv6
+// address.
+func serverURL(host string, port int) string {
+	return "https://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// resolveExposure checks the IP families of spec.exposure and fills in the
+// VIPs: a subnet without a VIP of its family gets one at config.VIPOffset,
+// and each VIP must lie in the subnet of its family. Without
+// spec.exposure.ipFamilies the families follow the VIPs, so an IPv6 subnet
+// yields an IPv6 Service; IPv4 alone is left to the cluster default.
+func resolveExposure(config *VClusterConfig) error {
+	var subnets []netip.Prefix
+	var vips []netip.Addr
+	var err error
+	if config.Subnet != "" {
+		if subnets, err = parseSubnets(config.Subnet); err != nil {
+			return fmt.Errorf("subnet: %w", err)
+		}
+	}
+	if config.VIP != "" {
+		if vips, err = parseVIPs(config.VIP); err != nil {
+			return fmt.Errorf("vip: %w", err)
+		}
+	}
+	if err := checkIPFamilies(config.IPFamilies, config.IPFamilyPolicy); err != nil {
+		return err
+	}
+
+	byFamily := map[string]netip.Addr{}
+	var order []string
+	for _, vip := range vips {
+		f := addrFamily(vip)
+		byFamily[f] = vip
+		order = append(order, f)
+		for i, subnet := range subnets {
+			if addrFamily(subnet.Addr()) == f || i == len(subnets)-1 {
+				if err := checkVIPInSubnet(vip, subnet); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	for _, subnet := range subnets {
+		f := addrFamily(subnet.Addr())
+		if _, ok := byFamily[f]; ok {
+			continue
+		}
+		vip, err := defaultVIPFromCIDR(subnet.String(), config.VIPOffset)
+		if err != nil {
+			return err
+		}
+		byFamily[f] = netip.MustParseAddr(vip)
+		order = append(order, f)
+		log.Printf("Calculated default %s VIP: %s (the host MetalLB pool must include it)", f, vip)
+	}
+
+	if len(config.IPFamilies) == 0 && !(len(order) == 1 && order[0] == ipFamilyIPv4) {
+		config.IPFamilies = order
+	}
+	for _, f := range order {
+		if len(config.IPFamilies) > 0 && !slices.Contains(config.IPFamilies, f) {
+			return fmt.Errorf("VIP %s is an %s address but ipFamilies is [%s]", byFamily[f], f, strings.Join(config.IPFamilies, ", "))
+		}
+	}
+	if len(config.IPFamilies) == 2 && config.IPFamilyPolicy == "" {
+		config.IPFamilyPolicy = ipFamilyPolicyPreferDualStack
+		if len(byFamily) == 2 {
+			config.IPFamilyPolicy = ipFamilyPolicyRequireDualStack
+		}
+	}
+
+	config.VIPs = nil
+	families := config.IPFamilies
+	if len(families) == 0 {
+		families = order
+	}
+	for _, f := range families {
+		if vip, ok := byFamily[f]; ok {
+			config.VIPs = append(config.VIPs, sanFor(vip))
+		}
+	}
+	config.VIP = ""
+	if len(config.VIPs) > 0 {
+		config.VIP = config.VIPs[0]
+	}
+	return nil
+}
+
+// checkIPFamilies validates spec.exposure.ipFamilies and ipFamilyPolicy as
+// the Service API would.
+func checkIPFamilies(families []string, policy string) error {
+	if len(families) > 2 {
+		return fmt.Errorf("ipFamilies lists %d families; at most %s and %s", len(families), ipFamilyIPv4, ipFamilyIPv6)
+	}
+	for i, f := range families {
+		if f != ipFamilyIPv4 && f != ipFamilyIPv6 {
+			return fmt.Errorf("ipFamilies: unknown family %q (expected %s or %s)", f, ipFamilyIPv4, ipFamilyIPv6)
+		}
+		if i > 0 && families[0] == f {
+			return fmt.Errorf("ipFamilies lists %s twice", f)
+		}
+	}
+	switch policy {
+	case "", ipFamilyPolicySingleStack, ipFamilyPolicyPreferDualStack, ipFamilyPolicyRequireDualStack:
+	default:
+		return fmt.Errorf("ipFamilyPolicy: unknown policy %q (expected %s, %s or %s)", policy,
+			ipFamilyPolicySingleStack, ipFamilyPolicyPreferDualStack, ipFamilyPolicyRequireDualStack)
+	}
+	if policy == ipFamilyPolicySingleStack && len(families) > 1 {
+		return fmt.Errorf("ipFamilyPolicy %s cannot serve both families in ipFamilies", policy)
+	}
+	return nil
+}
@@ -1,22 +1,48 @@
-package main
+// Package vclusterorchestratorv2 is the Kratix pipeline for the
+// vcluster-orchestrator-v2 promise, built into the platform-pipelines binary.
+package vclusterorchestratorv2
 
 import (
 	"context"
 	"fmt"
 	"log"
-	"net"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	kratix "github.com/syntasso/kratix-go"
 
 	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	"github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/phase"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
+// defaultSecretFromHost is the 1Password token External Secrets in every
+// vcluster authenticates with, synced from the host.
+const defaultSecretFromHost = "external-secrets/eso-onepassword-token"
+
+// spec.exposure.mode values: the API behind its own MetalLB VIP, or behind
+// the host's nginx-gateway with TLS passthrough.
+const (
+	exposureModeLoadBalancer = "loadbalancer"
+	exposureModeIngress      = "ingress"
+)
+
+// The host Gateway listener a TLSRoute attaches the API to in ingress
+// exposure mode. The listener only serves names directly under
+// apiGatewayDomain.<base domain>, so that it does not overlap the HTTPS
+// listener; the README lists what the host needs for it.
+const (
+	apiGatewayName      = "nginx-gateway"
+	apiGatewayNamespace = "nginx-gateway"
+	apiGatewayListener  = "tls-passthrough"
+	apiGatewayDomain    = "vcluster"
+)
+
 // VClusterConfig holds all configuration for template rendering
 type VClusterConfig struct {
 	// Basic identity
@@ -44,17 +70,40 @@ type VClusterConfig struct {
 	HelmOverrides       map[string]interface{}
 	ValuesObject        map[string]interface{}
 	ProxyExtraSANs      []string
+	// Paused is set by the platform.integratn.tech/paused annotation
+	// ('hctl vcluster pause'): the vcluster is rendered scaled to zero.
+	Paused bool
+	// RetainNamespace (spec.retainNamespace) keeps the target namespace
+	// when the vcluster is deleted.
+	RetainNamespace bool
+	// Upgrade is the upgrade whose backup is rendered, set by planUpgrade;
+	// UpgradeStarted when this run detected it. UpgradeHistory is
+	// status.upgradeHistory, with any new upgrade appended.
+	Upgrade        *UpgradeRecord
+	UpgradeStarted bool
+	UpgradeHistory []UpgradeRecord
 
 	// Exposure configuration
-	Hostname         string
+	Hostname string
+	// ExposureMode is exposureModeLoadBalancer or exposureModeIngress; in
+	// ingress mode the API Service is ClusterIP and there are no VIPs.
+	ExposureMode string
+	// VIP is the primary VIP; VIPs holds one per IP family, in IPFamilies
+	// order, for dual-stack. A subnet without a VIP gets one at VIPOffset.
 	VIP              string
+	VIPs             []string
+	VIPOffset        int
 	Subnet           string
+	IPFamilies       []string
+	IPFamilyPolicy   string
 	APIPort          int
 	ExternalServerURL string
 
 	// Integration configuration
 	CertManagerIssuerLabels        map[string]string
 	ExternalSecretsStoreLabels     map[string]string
+	OnePassword                    *u.OnePasswordRef
+	OnePasswordConnectHost         string
 	ArgoCDEnvironment              string
 	ArgoCDClusterLabels            map[string]string
 	ArgoCDClusterAnnotations       map[string]string
@@ -74,8 +123,22 @@ type VClusterConfig struct {
 	EnableNFS   bool
 	ExtraEgress []ExtraEgressRule
 
+	// MetalLB pool inside the vcluster; nil keeps MetalLB without a pool
+	LoadBalancer *LoadBalancerConfig
+
+	// Quota of the target namespace; nil renders no ResourceQuota or
+	// LimitRange
+	Quota *QuotaConfig
+
+	// Bootstrap is spec.bootstrap.manifests in apply order
+	Bootstrap []BootstrapManifest
+
+	// Host secrets synced into the vcluster besides the 1Password token
+	SyncSecretsFromHost []SecretFromHost
+
 	// Derived values
 	OnePasswordItem     string
+	KubeconfigSecret    string
 	KubeconfigSyncJobName string
 	BaseDomain          string
 	BaseDomainSanitized string
@@ -90,40 +153,60 @@ type WorkflowContext struct {
 	PipelineName   string
 }
 
-func main() {
-	sdk := kratix.New()
+// Run executes the pipeline for the workflow action Kratix set (configure
+// or delete). A failed or panicking run still writes a Failed status.
+func Run(sdk *kratix.KratixSDK) error {
+	return u.Execute(sdk, run)
+}
 
+func run(x *u.Execution) error {
+	sdk := x.SDK
 	log.Printf("=== VCluster Orchestrator V2 Pipeline ===")
 	log.Printf("Action: %s", sdk.WorkflowAction())
 	log.Printf("Type: %s", sdk.WorkflowType())
 	log.Printf("Promise: %s", sdk.PromiseName())
 
-	resource, err := sdk.ReadResourceInput()
+	resource, err := x.ReadInput()
 	if err != nil {
-		log.Fatalf("ERROR: Failed to read resource input: %v", err)
+		return err
 	}
 
 	log.Printf("Processing resource: %s in namespace: %s",
 		resource.GetName(), resource.GetNamespace())
 
+	x.Step(u.StepBuildConfig)
 	config, err := buildConfig(sdk, resource)
 	if err != nil {
-		log.Fatalf("ERROR: Failed to build config: %v", err)
+		return fmt.Errorf("failed to build config: %w", err)
 	}
 
 	if sdk.WorkflowAction() == "configure" {
-		if err := handleConfigure(sdk, config); err != nil {
-			log.Fatalf("ERROR: Configure failed: %v", err)
+		x.Step(u.StepValidate)
+		if err := planUpgrade(config, resource, time.Now()); err != nil {
+			return err
+		}
+		if config.LoadBalancer != nil {
+			others, err := listDeclaredPools()
+			if err != nil {
+				return fmt.Errorf("cannot check the load balancer pool against other vclusters: %w", err)
+			}
+			if err := validateLoadBalancerPool(config, others); err != nil {
+				return err
+			}
+		}
+		if err := handleConfigure(x, config); err != nil {
+			return fmt.Errorf("configure failed: %w", err)
 		}
 	} else if sdk.WorkflowAction() == "delete" {
-		if err := handleDelete(sdk, config); err != nil {
-			log.Fatalf("ERROR: Delete failed: %v", err)
+		if err := handleDelete(x, config); err != nil {
+			return fmt.Errorf("delete failed: %w", err)
 		}
 	} else {
-		log.Fatalf("ERROR: Unknown workflow action: %s", sdk.WorkflowAction())
+		return fmt.Errorf("unknown workflow action: %s", sdk.WorkflowAction())
 	}
 
 	log.Println("=== Pipeline completed successfully ===")
+	return nil
 }
 
 func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConfig, error) {
@@ -161,9 +244,24 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	config.ClusterDomain, _ = u.GetStringValueWithDefault(resource, "spec.vcluster.networking.clusterDomain", "cluster.local")
 	config.PersistenceClass, _ = u.GetStringValue(resource, "spec.vcluster.persistence.storageClass")
 
+	if pool, _ := u.GetStringValue(resource, "spec.vcluster.networking.loadBalancer.addressPool"); pool != "" {
+		config.LoadBalancer = &LoadBalancerConfig{
+			AddressPool:  pool,
+			L2Interfaces: u.ExtractStringSlice(resource, "spec.vcluster.networking.loadBalancer.l2Interfaces"),
+		}
+		config.LoadBalancer.AutoAssign, _ = u.GetBoolValueWithDefault(resource, "spec.vcluster.networking.loadBalancer.autoAssign", true)
+		if _, err := parseAddressPool(pool); err != nil {
+			return nil, fmt.Errorf("spec.vcluster.networking.loadBalancer: %w", err)
+		}
+	}
+
 	// Apply preset defaults
 	applyPresetDefaults(config, resource)
 
+	if config.Quota, err = resolveQuota(config, resource); err != nil {
+		return nil, fmt.Errorf("spec.vcluster.quota: %w", err)
+	}
+
 	// Extract backing store and helm overrides
 	if val, err := resource.GetValue("spec.vcluster.backingStore"); err == nil && val != nil {
 		if m, ok := val.(map[string]interface{}); ok {
@@ -187,23 +285,37 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	config.Hostname, _ = u.GetStringValue(resource, "spec.exposure.hostname")
 	config.Subnet, _ = u.GetStringValue(resource, "spec.exposure.subnet")
 	config.VIP, _ = u.GetStringValue(resource, "spec.exposure.vip")
-	config.APIPort, _ = u.GetIntValueWithDefault(resource, "spec.exposure.apiPort", 443)
-
-	// Calculate VIP if needed (offset 200 aligns with MetalLB pool 10.0.4.200-253)
-	if config.Subnet != "" && config.VIP == "" {
-		vip, err := defaultVIPFromCIDR(config.Subnet, 200)
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate VIP: %w", err)
+	// Not GetIntValueWithDefault: it would turn a bad offset, or an explicit
+	// 0, into the default and give the cluster an unexpected VIP.
+	config.VIPOffset = defaultVIPOffset
+	if val, err := resource.GetValue("spec.exposure.vipOffset"); err == nil && val != nil {
+		if config.VIPOffset, err = u.GetIntValue(resource, "spec.exposure.vipOffset"); err != nil {
+			return nil, fmt.Errorf("spec.exposure.vipOffset: %w", err)
 		}
-		config.VIP = vip
-		log.Printf("Calculated default VIP: %s", vip)
 	}
+	config.IPFamilies = u.ExtractStringSlice(resource, "spec.exposure.ipFamilies")
+	config.IPFamilyPolicy, _ = u.GetStringValue(resource, "spec.exposure.ipFamilyPolicy")
+	config.APIPort, _ = u.GetIntValueWithDefault(resource, "spec.exposure.apiPort", 443)
 
-	// Validate VIP is in subnet
-	if config.VIP != "" && config.Subnet != "" {
-		if !ipInCIDR(config.VIP, config.Subnet) {
-			return nil, fmt.Errorf("VIP %s is not within subnet %s", config.VIP, config.Subnet)
+	config.ExposureMode, _ = u.GetStringValueWithDefault(resource, "spec.exposure.mode", exposureModeLoadBalancer)
+	switch config.ExposureMode {
+	case exposureModeLoadBalancer:
+		// Select missing VIPs from the subnets and check the families agree
+		if err := resolveExposure(config); err != nil {
+			return nil, fmt.Errorf("spec.exposure: %w", err)
 		}
+	case exposureModeIngress:
+		if config.VIP != "" {
+			return nil, fmt.Errorf("spec.exposure.vip only applies to mode %s, not %s", exposureModeLoadBalancer, exposureModeIngress)
+		}
+		if config.APIPort != 443 {
+			return nil, fmt.Errorf("spec.exposure.apiPort only applies to mode %s; the gateway serves the API on 443", exposureModeLoadBalancer)
+		}
+		if err := checkIPFamilies(config.IPFamilies, config.IPFamilyPolicy); err != nil {
+			return nil, fmt.Errorf("spec.exposure: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("spec.exposure.mode: unknown mode %q (expected %s or %s)", config.ExposureMode, exposureModeLoadBalancer, exposureModeIngress)
 	}
 
 	// Set hostname if not specified
@@ -211,16 +323,25 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	if config.BaseDomain == "" || config.BaseDomain == "null" {
 		config.BaseDomain = "integratn.tech"
 	}
+	apiDomain := fmt.Sprintf("%s.%s", apiGatewayDomain, config.BaseDomain)
 	if config.Hostname == "" {
 		config.Hostname = fmt.Sprintf("%s.%s", config.Name, config.BaseDomain)
+		if config.ExposureMode == exposureModeIngress {
+			config.Hostname = fmt.Sprintf("%s.%s", config.Name, apiDomain)
+		}
+	}
+	if config.ExposureMode == exposureModeIngress {
+		if label, ok := strings.CutSuffix(config.Hostname, "."+apiDomain); !ok || label == "" || strings.Contains(label, ".") {
+			return nil, fmt.Errorf("spec.exposure.hostname %q: in mode %s it must be a name directly under %s, the domain the gateway's %s listener serves", config.Hostname, exposureModeIngress, apiDomain, apiGatewayListener)
+		}
 	}
 	config.BaseDomainSanitized = strings.ReplaceAll(config.BaseDomain, ".", "-")
 
 	// Calculate external server URL
 	if config.Hostname != "" {
-		config.ExternalServerURL = fmt.Sprintf("https://%s:%d", config.Hostname, config.APIPort)
+		config.ExternalServerURL = serverURL(config.Hostname, config.APIPort)
 	} else if config.VIP != "" {
-		config.ExternalServerURL = fmt.Sprintf("https://%s:%d", config.VIP, config.APIPort)
+		config.ExternalServerURL = serverURL(config.VIP, config.APIPort)
 	}
 
 	defaultExport := map[string]interface{}{}
@@ -237,9 +358,7 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	if config.Hostname != "" {
 		config.ProxyExtraSANs = append(config.ProxyExtraSANs, config.Hostname)
 	}
-	if config.VIP != "" {
-		config.ProxyExtraSANs = append(config.ProxyExtraSANs, config.VIP)
-	}
+	config.ProxyExtraSANs = append(config.ProxyExtraSANs, config.VIPs...)
 
 	// Extract integration configuration
 	config.CertManagerIssuerLabels = u.ExtractStringMap(resource, "spec.integrations.certManager.clusterIssuerSelectorLabels")
@@ -251,6 +370,8 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	if len(config.ExternalSecretsStoreLabels) == 0 {
 		config.ExternalSecretsStoreLabels = map[string]string{"integratn.tech/cluster-secret-store": "onepassword-store"}
 	}
+	config.OnePassword = extractOnePassword(resource)
+	config.OnePasswordConnectHost, _ = u.GetStringValue(resource, "spec.integrations.onePassword.connectHost")
 
 	config.ArgoCDEnvironment, _ = u.GetStringValue(resource, "spec.integrations.argocd.environment")
 	if config.ArgoCDEnvironment == "" {
@@ -352,6 +473,18 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 	}
 	config.ExtraEgress = extractExtraEgress(resource)
 
+	if config.SyncSecretsFromHost, err = extractSyncSecretsFromHost(resource); err != nil {
+		return nil, err
+	}
+
+	if config.Bootstrap, err = extractBootstrapManifests(resource); err != nil {
+		return nil, err
+	}
+
+	if val, err := u.GetBoolValue(resource, "spec.retainNamespace"); err == nil {
+		config.RetainNamespace = val
+	}
+
 	// Set derived values
 	config.OnePasswordItem = fmt.Sprintf("vcluster-%s-kubeconfig", config.Name)
 	
@@ -373,12 +506,28 @@ func buildConfig(sdk *kratix.KratixSDK, resource kratix.Resource) (*VClusterConf
 		config.KubeconfigSyncJobName = fmt.Sprintf("vcluster-%s-kubeconfig-sync", config.Name)
 	}
 
-	config.ValuesObject = buildValuesObject(config)
+	config.Paused = resource.GetAnnotations()["platform.integratn.tech/paused"] == "true"
+
+	config.ValuesObject, err = buildValuesObject(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The release name is config.Name, so the chart's default kubeconfig
+	// Secret is vc-<name>; honor an exportKubeConfig.secret override.
+	secretName, secretNamespace := exportedKubeconfigSecret(config.ValuesObject)
+	if secretNamespace != "" && secretNamespace != config.TargetNamespace {
+		return nil, fmt.Errorf("exportKubeConfig.secret.namespace %q must match the target namespace %q (the kubeconfig sync job reads it there)", secretNamespace, config.TargetNamespace)
+	}
+	config.KubeconfigSecret = secretName
+	if config.KubeconfigSecret == "" {
+		config.KubeconfigSecret = fmt.Sprintf("vc-%s", config.Name)
+	}
 
 	return config, nil
 }
 
-func buildValuesObject(config *VClusterConfig) map[string]interface{} {
+func buildValuesObject(config *VClusterConfig) (map[string]interface{}, error) {
 	cp := ControlPlane{
 		Distro: DistroConfig{
 			K8s: K8sDistro{
@@ -471,8 +620,23 @@ func buildValuesObject(config *VClusterConfig) map[string]interface{} {
 		cp.Proxy = &ProxyConfig{ExtraSANs: config.ProxyExtraSANs}
 	}
 
-	if config.VIP != "" {
+	// loadBalancerIP holds a single address; MetalLB takes both families of
+	// a dual-stack VIP from its annotation instead.
+	if len(config.VIPs) == 1 {
 		cp.Service.Spec.LoadBalancerIP = config.VIP
+	} else if len(config.VIPs) > 1 {
+		cp.Service.Annotations[metallbLoadBalancerIPsAnnotation] = strings.Join(config.VIPs, ",")
+	}
+	cp.Service.Spec.IPFamilies = config.IPFamilies
+	cp.Service.Spec.IPFamilyPolicy = config.IPFamilyPolicy
+
+	// In ingress mode the API is reached through the TLSRoute of
+	// buildAPIRoute, which passes TLS through so the vcluster keeps
+	// terminating it with its own certificate; external-dns takes the
+	// hostname from the route.
+	if config.ExposureMode == exposureModeIngress {
+		cp.Service.Spec.Type = "ClusterIP"
+		delete(cp.Service.Annotations, "external-dns.alpha.kubernetes.io/hostname")
 	}
 
 	if config.APIPort != 443 {
@@ -543,7 +707,7 @@ func buildValuesObject(config *VClusterConfig) map[string]interface{} {
 					Enabled: true,
 					Mappings: SecretMappings{
 						ByName: map[string]string{
-							"external-secrets/eso-onepassword-token": "external-secrets/eso-onepassword-token",
+							defaultSecretFromHost: defaultSecretFromHost,
 						},
 					},
 				},
@@ -564,17 +728,82 @@ func buildValuesObject(config *VClusterConfig) map[string]interface{} {
 		},
 	}
 
+	addSecretsFromHost(&values, config.SyncSecretsFromHost)
+
 	if len(config.ExportKubeConfig) > 0 {
 		values.ExportKubeConfig = config.ExportKubeConfig
 	}
 
+	if config.LoadBalancer != nil {
+		manifests, err := metalLBManifestsValue(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render MetalLB manifests: %w", err)
+		}
+		values.Experimental = &ExperimentalConfig{
+			Deploy: ExperimentalDeploy{VCluster: VClusterManifests{Manifests: manifests}},
+		}
+	}
+
 	// Convert typed struct to map for merging with HelmOverrides
 	valuesMap, err := u.ToMap(values)
 	if err != nil {
-		log.Fatalf("ERROR: Failed to convert values to map: %v", err)
+		return nil, fmt.Errorf("failed to convert values to map: %w", err)
+	}
+
+	valuesMap = u.DeepMerge(valuesMap, config.HelmOverrides)
+	if config.Paused {
+		valuesMap = u.DeepMerge(valuesMap, pausedValues(config))
+	}
+	return valuesMap, nil
+}
+
+// addSecretsFromHost adds the secrets to the fromHost mappings and their
+// names to the resourceNames of the ClusterRole rule that lets the vcluster
+// read host secrets.
+func addSecretsFromHost(values *VClusterValues, secrets []SecretFromHost) {
+	mappings := values.Sync.FromHost.Secrets.Mappings.ByName
+	rule := &values.RBAC.ClusterRole.ExtraRules[0]
+	for _, secret := range secrets {
+		mappings[secret.From] = secret.To
+		name := secret.From[strings.Index(secret.From, "/")+1:]
+		if !slices.Contains(rule.ResourceNames, name) {
+			rule.ResourceNames = append(rule.ResourceNames, name)
+		}
 	}
+}
 
-	return u.DeepMerge(valuesMap, config.HelmOverrides)
+// pausedValues scales a paused vcluster to zero: the control plane,
+// CoreDNS and, when deployed, etcd. They override helmOverrides. PVCs and
+// secrets are left alone, so resuming brings the cluster back as it was.
+// The paused annotation on the StatefulSet is what 'vcluster pause' sets,
+// so the vcluster CLI also sees the cluster as paused.
+func pausedValues(config *VClusterConfig) map[string]interface{} {
+	controlPlane := map[string]interface{}{
+		"statefulSet": map[string]interface{}{
+			"highAvailability": map[string]interface{}{"replicas": 0},
+			"annotations":      map[string]interface{}{"loft.sh/paused": "true"},
+		},
+		"coredns": map[string]interface{}{
+			"deployment": map[string]interface{}{"replicas": 0},
+		},
+		// A PDB requiring one available pod would block draining nodes
+		// while nothing runs.
+		"advanced": map[string]interface{}{
+			"podDisruptionBudget": map[string]interface{}{"enabled": false},
+		},
+	}
+	if etcdEnabled(config) {
+		controlPlane["backingStore"] = map[string]interface{}{
+			"etcd": map[string]interface{}{
+				"deploy": map[string]interface{}{
+					"statefulSet": map[string]interface{}{
+						"highAvailability": map[string]interface{}{"replicas": 0},
+					},
+				},
+			},
+		}
+	}
+	return map[string]interface{}{"controlPlane": controlPlane}
 }
 
 func applyPresetDefaults(config *VClusterConfig, resource kratix.Resource) {
@@ -636,65 +865,80 @@ func applyPresetDefaults(config *VClusterConfig, resource kratix.Resource) {
 	}
 }
 
-func handleConfigure(sdk *kratix.KratixSDK, config *VClusterConfig) error {
+// handleConfigure renders the vcluster's resources. They are written in
+// the order they matter: the namespace and the ArgoCD project and
+// application that create the vcluster first, then what the vcluster
+// needs once running, and the cluster registration and network policies
+// last. A run that fails part-way leaves the earlier ones in place.
+func handleConfigure(x *u.Execution, config *VClusterConfig) error {
 	log.Println("--- Rendering orchestrator resources ---")
-
-	resourceRequests := map[string]u.Resource{
-		"resources/argocd-project-request.yaml":              buildArgoCDProjectRequest(config),
-		"resources/argocd-application-request.yaml":          buildArgoCDApplicationRequest(config),
-		"resources/argocd-cluster-registration-request.yaml": buildArgoCDClusterRegistrationRequest(config),
-	}
-
-	for path, obj := range resourceRequests {
-		if err := u.WriteYAML(sdk, path, obj); err != nil {
-			return fmt.Errorf("write %s: %w", path, err)
+	x.Step(u.StepRender)
+
+	resourceRequests := 0
+	directResources := 0
+	outputs := []struct {
+		path    string
+		docs    []u.Resource
+		request bool
+	}{
+		{"resources/namespace.yaml", []u.Resource{buildNamespace(config)}, false},
+		// Quota before anything is scheduled into the namespace
+		{"resources/resource-quota.yaml", buildQuota(config), false},
+		// Backs up the datastore before the application request upgrades it
+		{"resources/upgrade-backup.yaml", buildUpgradeBackup(config), false},
+		{"resources/argocd-project-request.yaml", []u.Resource{buildArgoCDProjectRequest(config)}, true},
+		{"resources/argocd-application-request.yaml", []u.Resource{buildArgoCDApplicationRequest(config)}, true},
+		{"resources/etcd-certificates.yaml", buildEtcdCertificates(config), false},
+		{"resources/coredns-configmap.yaml", []u.Resource{buildCorednsConfigMap(config)}, false},
+		// TLS passthrough to the API through nginx-gateway (ingress mode)
+		{"resources/api-route.yaml", buildAPIRoute(config), false},
+		{"resources/argocd-cluster-registration-request.yaml", []u.Resource{buildArgoCDClusterRegistrationRequest(config)}, true},
+		// Per-vcluster network policies (NFS, extra egress)
+		{"resources/network-policies.yaml", buildNetworkPolicies(config), false},
+		// Applied inside the vcluster once its kubeconfig is exported
+		{"resources/bootstrap.yaml", buildBootstrap(config), false},
+	}
+	for _, out := range outputs {
+		if len(out.docs) == 0 {
+			continue
 		}
-		log.Printf("✓ Rendered: %s", path)
-	}
-
-	if err := u.WriteYAML(sdk, "resources/namespace.yaml", buildNamespace(config)); err != nil {
-		return fmt.Errorf("write namespace: %w", err)
-	}
-	log.Printf("✓ Rendered: %s", "resources/namespace.yaml")
-
-	if docs := buildEtcdCertificates(config); len(docs) > 0 {
-		if err := u.WriteYAMLDocuments(sdk, "resources/etcd-certificates.yaml", docs); err != nil {
-			return fmt.Errorf("write etcd certificates: %w", err)
+		if err := x.WriteYAMLDocuments(out.path, out.docs); err != nil {
+			return fmt.Errorf("write %s: %w", out.path, err)
 		}
-		log.Printf("✓ Rendered: %s", "resources/etcd-certificates.yaml")
-	}
-
-	if err := u.WriteYAML(sdk, "resources/coredns-configmap.yaml", buildCorednsConfigMap(config)); err != nil {
-		return fmt.Errorf("write coredns configmap: %w", err)
-	}
-	log.Printf("✓ Rendered: %s", "resources/coredns-configmap.yaml")
-
-	// Per-vcluster network policies (NFS, extra egress)
-	netPolicies := buildNetworkPolicies(config)
-	if len(netPolicies) > 0 {
-		if err := u.WriteYAMLDocuments(sdk, "resources/network-policies.yaml", netPolicies); err != nil {
-			return fmt.Errorf("write network policies: %w", err)
+		if out.request {
+			resourceRequests++
+		} else {
+			directResources++
 		}
-		log.Printf("✓ Rendered: resources/network-policies.yaml (%d policies)", len(netPolicies))
+		log.Printf("✓ Rendered: %s", out.path)
 	}
 
-	directResources := 2 // namespace + coredns configmap
-	if etcdEnabled(config) {
-		directResources++
+	// Ready belongs to the platform-status-reconciler once it has seen this
+	// generation; the pipeline only resets it when the spec changes.
+	summary, message := phase.Scheduled, "VCluster resources scheduled for creation"
+	if config.Paused {
+		summary, message = phase.Paused, "VCluster paused: control plane scaled to zero"
+	} else if config.UpgradeStarted {
+		message = upgradeMessage(config)
 	}
-	if len(netPolicies) > 0 {
-		directResources++
-	}
-
-	status := kratix.NewStatus()
-	status.Set("phase", "Scheduled")
-	status.Set("message", "VCluster resources scheduled for creation")
-	status.Set("resourceRequestsGenerated", len(resourceRequests))
+	status := u.ConfiguredStatus(x.Resource, summary, message, resourceRequests+directResources).
+		InitCondition(u.ConditionReady, u.ConditionFalse, summary, message)
+	status.Set("resourceRequestsGenerated", resourceRequests)
 	status.Set("directResourcesGenerated", directResources)
 	status.Set("vclusterName", config.Name)
 	status.Set("targetNamespace", config.TargetNamespace)
 	status.Set("hostname", config.Hostname)
 	status.Set("environment", config.ArgoCDEnvironment)
+	// The versions rendered, which the next run compares to detect an upgrade
+	status.Set("k8sVersion", config.K8sVersion)
+	status.Set("chartVersion", config.ArgoCDTargetRevision)
+	if len(config.UpgradeHistory) > 0 {
+		status.Set("upgradeHistory", config.UpgradeHistory)
+	}
+	// The Job the platform-status-reconciler reports BootstrapApplied from
+	if len(config.Bootstrap) > 0 {
+		status.Set("bootstrapJob", bootstrapName(config))
+	}
 
 	// Platform Status Contract — endpoint and credential references
 	status.Set("endpoints", map[string]string{
@@ -706,8 +950,8 @@ func handleConfigure(sdk *kratix.KratixSDK, config *VClusterConfig) error {
 		"onePasswordItem":  config.OnePasswordItem,
 	})
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("failed to write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
 
 	log.Println("✓ Status updated")
@@ -774,51 +1018,61 @@ func cleanupHostPVs(config *VClusterConfig) error {
 	return nil
 }
 
-// cleanupNamespace deletes the vcluster target namespace, which cascade-deletes
-// all namespace-scoped resources (PVCs, pods, services, etc.).
-// This ensures no orphaned resources remain after vcluster deletion.
-func cleanupNamespace(config *VClusterConfig) error {
-	log.Printf("Cleaning up namespace: %s", config.TargetNamespace)
-
-	restConfig, err := rest.InClusterConfig()
-	if err != nil {
-		return fmt.Errorf("failed to get in-cluster config: %w", err)
-	}
+// readSecretKey reads the Connect token; tests replace it.
+var readSecretKey = u.ReadSecretKey
 
-	clientset, err := kubernetes.NewForConfig(restConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create kubernetes client: %w", err)
-	}
+// deleteOnePasswordItemOnDestroy reports whether deleting the vcluster
+// deletes its kubeconfig item, as spec.onePassword.deleteItemOnDestroy
+// (default true) decides.
+func deleteOnePasswordItemOnDestroy(config *VClusterConfig) bool {
+	return config.OnePassword == nil || config.OnePassword.DeleteItemOnDestroy == nil || *config.OnePassword.DeleteItemOnDestroy
+}
 
+// deleteOnePasswordItem deletes the kubeconfig item from 1Password through
+// the Connect API, with the token Secret of the vcluster's cluster
+// registration. The vault and Connect server are the ones the
+// registration's sync Job writes with: its defaults unless set here. An
+// item or vault that is already gone counts as deleted.
+func deleteOnePasswordItem(config *VClusterConfig) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	// Check if namespace exists before trying to delete
-	_, err = clientset.CoreV1().Namespaces().Get(ctx, config.TargetNamespace, metav1.GetOptions{})
-	if err != nil {
-		log.Printf("Namespace %s not found or already deleted, skipping", config.TargetNamespace)
-		return nil
+	vault := u.DefaultOnePasswordVault
+	if config.OnePassword != nil && config.OnePassword.Vault != "" {
+		vault = config.OnePassword.Vault
 	}
+	host := config.OnePasswordConnectHost
+	if host == "" {
+		host = u.DefaultOnePasswordConnectHost
+	}
+	item := onePasswordItemTitle(config)
 
-	if err := clientset.CoreV1().Namespaces().Delete(ctx, config.TargetNamespace, metav1.DeleteOptions{}); err != nil {
-		return fmt.Errorf("failed to delete namespace %s: %w", config.TargetNamespace, err)
+	tokenSecret := fmt.Sprintf("%s-onepassword-token", config.Name)
+	token, err := readSecretKey(ctx, config.TargetNamespace, tokenSecret, "token")
+	if err != nil {
+		return fmt.Errorf("read 1Password Connect token %s/%s: %w", config.TargetNamespace, tokenSecret, err)
 	}
+	log.Printf("Deleting 1Password item %s from vault %s", item, vault)
+	connect := u.OnePasswordConnect{Host: host, Token: token}
+	return connect.DeleteItem(ctx, vault, item)
+}
 
-	log.Printf("✓ Namespace %s scheduled for deletion", config.TargetNamespace)
-	return nil
+// onePasswordItemTitle is the title of the kubeconfig item the
+// registration's sync Job writes: its spec.name plus "-kubeconfig".
+func onePasswordItemTitle(config *VClusterConfig) string {
+	return fmt.Sprintf("%s-kubeconfig", config.Name)
 }
 
-func handleDelete(sdk *kratix.KratixSDK, config *VClusterConfig) error {
+func handleDelete(x *u.Execution, config *VClusterConfig) error {
 	log.Printf("--- Handling delete for vcluster: %s ---", config.Name)
 
-	status := kratix.NewStatus()
-	status.Set("phase", "Deleting")
-	status.Set("message", "VCluster resources scheduled for deletion")
-	status.Set("vclusterName", config.Name)
+	status := u.DeletingStatus(x.Resource, "VCluster resources scheduled for deletion").
+		Set("vclusterName", config.Name)
 
-	if err := sdk.WriteStatus(status); err != nil {
-		return fmt.Errorf("failed to write status: %w", err)
+	if err := x.WriteStatus(status); err != nil {
+		return err
 	}
+	x.Step(u.StepCleanup)
 
 	// --- Direct API cleanup for resources NOT in the Kratix state store ---
 	// These resources are created by the vcluster syncer directly on the host
@@ -831,86 +1085,35 @@ func handleDelete(sdk *kratix.KratixSDK, config *VClusterConfig) error {
 		log.Printf("⚠ Warning: PV cleanup encountered errors: %v", err)
 	}
 
-	// Clean up the vcluster namespace (cascade-deletes PVCs, pods, etc.)
-	if err := cleanupNamespace(config); err != nil {
-		log.Printf("⚠ Warning: Namespace cleanup encountered errors: %v", err)
+	// Delete the kubeconfig item the registration's sync Job wrote, while
+	// the registration and its token Secret are still there. Its own delete
+	// pipeline does the same and then finds the item gone. Unlike the PVs,
+	// nothing else would ever delete the item, so a failure fails the run,
+	// recorded as a Cleanup failure on the request, before any delete
+	// output is written.
+	if deleteOnePasswordItemOnDestroy(config) {
+		if err := deleteOnePasswordItem(config); err != nil {
+			return fmt.Errorf("delete 1Password item %s: %w", onePasswordItemTitle(config), err)
+		}
 	}
 
 	// --- Kratix state store cleanup (removes manifests → ArgoCD deletes from cluster) ---
+	// The namespace is one of these outputs, in the last wave, so ArgoCD
+	// removes it only once everything in it has gone.
+	x.Step(u.StepRender)
 
-	outputs := map[string]u.Resource{}
-
-	// Delete all created resources
-	allResources := []u.Resource{
-		buildArgoCDProjectRequest(config),
-		buildArgoCDApplicationRequest(config),
-		buildArgoCDClusterRegistrationRequest(config),
-		buildCorednsConfigMap(config),
-	}
-
-	for _, obj := range allResources {
-		deleteObj := u.DeleteFromResource(obj)
-		path := u.DeleteOutputPathForResource("resources", obj)
-		outputs[path] = deleteObj
+	outputs := buildDeleteOutputs(config)
+	if retainNamespace(config) {
+		log.Printf("Retaining namespace %s", config.TargetNamespace)
 	}
 
-	// Delete per-vcluster network policies
-	for _, obj := range buildNetworkPolicies(config) {
-		deleteObj := u.DeleteFromResource(obj)
-		path := u.DeleteOutputPathForResource("resources", obj)
-		outputs[path] = deleteObj
+	paths := make([]string, 0, len(outputs))
+	for path := range outputs {
+		paths = append(paths, path)
 	}
-
-	if etcdEnabled(config) {
-		for _, obj := range buildEtcdCertificates(config) {
-			deleteObj := u.DeleteFromResource(obj)
-			path := u.DeleteOutputPathForResource("resources", obj)
-			outputs[path] = deleteObj
-		}
-	}
-
-	outputs["resources/delete-vcluster-clusterrole.yaml"] = u.DeleteResource(
-		"rbac.authorization.k8s.io/v1",
-		"ClusterRole",
-		fmt.Sprintf("vc-%s-v-%s", config.Name, config.TargetNamespace),
-		"",
-	)
-	outputs["resources/delete-vcluster-clusterrolebinding.yaml"] = u.DeleteResource(
-		"rbac.authorization.k8s.io/v1",
-		"ClusterRoleBinding",
-		fmt.Sprintf("vc-%s-v-%s", config.Name, config.TargetNamespace),
-		"",
-	)
-
-	if etcdEnabled(config) {
-		outputs["resources/delete-etcd-ca-secret.yaml"] = u.DeleteResource(
-			"v1",
-			"Secret",
-			fmt.Sprintf("%s-etcd-ca", config.Name),
-			config.TargetNamespace,
-		)
-		outputs["resources/delete-etcd-server-secret.yaml"] = u.DeleteResource(
-			"v1",
-			"Secret",
-			fmt.Sprintf("%s-etcd-server", config.Name),
-			config.TargetNamespace,
-		)
-		outputs["resources/delete-etcd-peer-secret.yaml"] = u.DeleteResource(
-			"v1",
-			"Secret",
-			fmt.Sprintf("%s-etcd-peer", config.Name),
-			config.TargetNamespace,
-		)
-		outputs["resources/delete-etcd-merged-secret.yaml"] = u.DeleteResource(
-			"v1",
-			"Secret",
-			fmt.Sprintf("%s-etcd-certs", config.Name),
-			config.TargetNamespace,
-		)
-	}
-
-	for path, obj := range outputs {
-		if err := u.WriteYAML(sdk, path, obj); err != nil {
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := x.WriteYAML(path, outputs[path]); err != nil {
 			return fmt.Errorf("write delete output %s: %w", path, err)
 		}
 	}
@@ -918,45 +1121,70 @@ func handleDelete(sdk *kratix.KratixSDK, config *VClusterConfig) error {
 	return nil
 }
 
-// IP utility functions
-func defaultVIPFromCIDR(cidr string, offset int) (string, error) {
-	_, ipNet, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return "", fmt.Errorf("invalid CIDR: %w", err)
+// extractOnePassword reads the 1Password vault and store for the kubeconfig
+// item, and whether deleting the vcluster deletes the item. spec.onePassword
+// overrides the platform default in spec.integrations.onePassword field by
+// field; nil leaves all three to the argocd-cluster-registration promise's
+// defaults.
+func extractOnePassword(resource kratix.Resource) *u.OnePasswordRef {
+	ref := &u.OnePasswordRef{}
+	ref.Vault, _ = u.GetStringValue(resource, "spec.onePassword.vault")
+	if ref.Vault == "" {
+		ref.Vault, _ = u.GetStringValue(resource, "spec.integrations.onePassword.vault")
+	}
+	ref.SecretStore, _ = u.GetStringValue(resource, "spec.onePassword.secretStore")
+	if ref.SecretStore == "" {
+		ref.SecretStore, _ = u.GetStringValue(resource, "spec.integrations.onePassword.secretStore")
+	}
+	for _, path := range []string{"spec.onePassword.deleteItemOnDestroy", "spec.integrations.onePassword.deleteItemOnDestroy"} {
+		if val, err := u.GetBoolValue(resource, path); err == nil {
+			ref.DeleteItemOnDestroy = &val
+			break
+		}
 	}
-
-	networkIP := ipNet.IP.Mask(ipNet.Mask)
-	networkInt := ipToInt(networkIP)
-	vipInt := networkInt + uint32(offset)
-	vip := intToIP(vipInt)
-
-	return vip.String(), nil
+	if ref.Vault == "" && ref.SecretStore == "" && ref.DeleteItemOnDestroy == nil {
+		return nil
+	}
+	return ref
 }
 
-func ipInCIDR(ipStr, cidr string) bool {
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return false
+// extractSyncSecretsFromHost reads spec.vcluster.syncSecretsFromHost. Both
+// from and to must be namespace/name, and a host secret can be synced only
+// once.
+func extractSyncSecretsFromHost(resource kratix.Resource) ([]SecretFromHost, error) {
+	val, err := resource.GetValue("spec.vcluster.syncSecretsFromHost")
+	if err != nil || val == nil {
+		return nil, nil
 	}
-
-	_, ipNet, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return false
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("spec.vcluster.syncSecretsFromHost must be a list of objects")
 	}
 
-	return ipNet.Contains(ip)
-}
-
-func ipToInt(ip net.IP) uint32 {
-	ip = ip.To4()
-	if ip == nil {
-		return 0
+	var secrets []SecretFromHost
+	seen := map[string]string{}
+	for i, item := range arr {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("spec.vcluster.syncSecretsFromHost[%d] is not an object", i)
+		}
+		from, _ := obj["from"].(string)
+		to, _ := obj["to"].(string)
+		for _, ref := range []struct{ field, value string }{{"from", from}, {"to", to}} {
+			if ns, name, ok := strings.Cut(ref.value, "/"); !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+				return nil, fmt.Errorf("spec.vcluster.syncSecretsFromHost[%d].%s must be namespace/name, got %q", i, ref.field, ref.value)
+			}
+		}
+		if other, ok := seen[from]; ok && other != to {
+			return nil, fmt.Errorf("spec.vcluster.syncSecretsFromHost[%d]: %s is already synced as %s", i, from, other)
+		}
+		if from == defaultSecretFromHost && to != defaultSecretFromHost {
+			return nil, fmt.Errorf("spec.vcluster.syncSecretsFromHost[%d]: %s is always synced as itself", i, from)
+		}
+		seen[from] = to
+		secrets = append(secrets, SecretFromHost{From: from, To: to})
 	}
-	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
-}
-
-func intToIP(n uint32) net.IP {
-	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return secrets, nil
 }
 
 func extractExtraEgress(resource kratix.Resource) []ExtraEgressRule {
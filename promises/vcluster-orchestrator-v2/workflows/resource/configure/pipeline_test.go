@@ -0,0 +1,1629 @@
+package vclusterorchestratorv2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+	kratix "github.com/syntasso/kratix-go"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	fixtureNamespace       = "platform-requests"
+	fixtureTargetNamespace = "vcluster-media"
+)
+
+// fixtureSDK returns a Kratix SDK sandboxed in temporary directories, with
+// input as the resource request of a configure run, and its output and
+// metadata directories.
+func fixtureSDK(t *testing.T, input []byte) (*kratix.KratixSDK, string, string) {
+	t.Helper()
+	t.Setenv("KRATIX_WORKFLOW_ACTION", "configure")
+	t.Setenv("KRATIX_WORKFLOW_TYPE", "resource")
+	t.Setenv("KRATIX_PROMISE_NAME", "vcluster-orchestrator-v2")
+
+	inputDir, outputDir, metadataDir := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "object.yaml"), input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sdk := kratix.New(
+		kratix.WithInputDir(inputDir),
+		kratix.WithOutputDir(outputDir),
+		kratix.WithMetadataDir(metadataDir),
+	)
+	return sdk, outputDir, metadataDir
+}
+
+// fixtureConfig loads a VClusterOrchestratorV2 object through a sandboxed
+// Kratix SDK and builds its config.
+func fixtureConfig(t *testing.T, input []byte) (*kratix.KratixSDK, string, *VClusterConfig, error) {
+	t.Helper()
+	sdk, outputDir, _ := fixtureSDK(t, input)
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := buildConfig(sdk, resource)
+	return sdk, outputDir, config, err
+}
+
+// renderFixture runs the configure pipeline against testdata/<name> and
+// returns every rendered document.
+func renderFixture(t *testing.T, name string) []map[string]interface{} {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return renderInput(t, input)
+}
+
+// renderInput runs the configure pipeline against input and returns every
+// rendered document.
+func renderInput(t *testing.T, input []byte) []map[string]interface{} {
+	t.Helper()
+	sdk, outputDir, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handleConfigure(&u.Execution{SDK: sdk, Resource: resource}, config); err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+
+	var docs []map[string]interface{}
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, raw := range bytes.Split(data, []byte("\n---\n")) {
+			var doc map[string]interface{}
+			if err := yaml.Unmarshal(raw, &doc); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			if doc != nil {
+				docs = append(docs, doc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return docs
+}
+
+func field(obj interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = m[key]
+	}
+	return obj
+}
+
+func str(obj interface{}, path string) string {
+	s, _ := field(obj, path).(string)
+	return s
+}
+
+func list(obj interface{}, path string) []interface{} {
+	l, _ := field(obj, path).([]interface{})
+	return l
+}
+
+func TestTargetNamespaceDiffersFromResourceNamespace(t *testing.T) {
+	docs := renderFixture(t, "cross-namespace.yaml")
+
+	kinds := map[string]int{}
+	for _, doc := range docs {
+		kind := str(doc, "kind")
+		name := str(doc, "metadata.name")
+		ns := str(doc, "metadata.namespace")
+		kinds[kind]++
+
+		switch kind {
+		case "ArgoCDProject", "ArgoCDApplication", "ArgoCDClusterRegistration":
+			if ns != fixtureNamespace {
+				t.Errorf("%s/%s namespace = %q, want request namespace %q", kind, name, ns, fixtureNamespace)
+			}
+		case "Namespace":
+			if ns != "" || name != fixtureTargetNamespace {
+				t.Errorf("Namespace = %s (ns %q), want %s", name, ns, fixtureTargetNamespace)
+			}
+		default:
+			if ns != fixtureTargetNamespace {
+				t.Errorf("%s/%s namespace = %q, want target namespace %q", kind, name, ns, fixtureTargetNamespace)
+			}
+		}
+
+		for _, s := range list(doc, "subjects") {
+			if got := str(s, "namespace"); got != fixtureTargetNamespace {
+				t.Errorf("%s/%s subject %s namespace = %q, want %q", kind, name, str(s, "name"), got, fixtureTargetNamespace)
+			}
+		}
+		for _, c := range list(doc, "spec.template.spec.volumes") {
+			if secret := str(c, "secret.secretName"); secret != "" && !strings.HasPrefix(secret, "media-") {
+				t.Errorf("%s/%s mounts unexpected secret %q", kind, name, secret)
+			}
+		}
+	}
+
+	for _, kind := range []string{"ArgoCDProject", "ArgoCDApplication", "ArgoCDClusterRegistration", "Namespace", "ConfigMap", "Certificate", "Issuer", "RoleBinding", "Job", "NetworkPolicy"} {
+		if kinds[kind] == 0 {
+			t.Errorf("fixture rendered no %s", kind)
+		}
+	}
+
+	for _, doc := range docs {
+		switch str(doc, "kind") {
+		case "ArgoCDProject":
+			for _, d := range list(doc, "spec.destinations") {
+				if str(d, "namespace") != fixtureTargetNamespace || str(d, "server") != "https://host.example.internal:6443" {
+					t.Errorf("project destination = %v, want %s on the application's server", d, fixtureTargetNamespace)
+				}
+			}
+		case "ArgoCDApplication":
+			if got := str(doc, "spec.destination.namespace"); got != fixtureTargetNamespace {
+				t.Errorf("application destination namespace = %q", got)
+			}
+			if got := str(doc, "spec.project"); got != "vcluster-media" {
+				t.Errorf("application project = %q", got)
+			}
+		case "ArgoCDClusterRegistration":
+			if got := str(doc, "spec.targetNamespace"); got != fixtureTargetNamespace {
+				t.Errorf("registration targetNamespace = %q", got)
+			}
+			if got := str(doc, "spec.kubeconfigSecret"); got != "vc-media" {
+				t.Errorf("registration kubeconfigSecret = %q, want vc-media", got)
+			}
+		case "Certificate":
+			if kind := str(doc, "spec.issuerRef.kind"); kind != "Issuer" {
+				t.Errorf("certificate %s issuerRef kind = %q, want namespaced Issuer", str(doc, "metadata.name"), kind)
+			}
+		}
+	}
+}
+
+// withKubeconfigSecret adds an exportKubeConfig.secret helm override to the
+// cross-namespace fixture.
+func withKubeconfigSecret(t *testing.T, secret string) []byte {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	override := "    helmOverrides:\n      exportKubeConfig:\n        secret:\n" + secret + "    backingStore:"
+	return []byte(strings.Replace(string(input), "    backingStore:", override, 1))
+}
+
+func TestKubeconfigSecretOverride(t *testing.T) {
+	_, _, config, err := fixtureConfig(t, withKubeconfigSecret(t, "          name: media-admin\n"))
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if got := buildArgoCDClusterRegistrationRequest(config).Spec.(u.ArgoCDClusterRegistrationSpec).KubeconfigSecret; got != "media-admin" {
+		t.Errorf("registration kubeconfigSecret = %q, want media-admin", got)
+	}
+
+	_, _, _, err = fixtureConfig(t, withKubeconfigSecret(t, "          name: media-admin\n          namespace: argocd\n"))
+	if err == nil {
+		t.Error("expected an error when the kubeconfig secret is exported outside the target namespace")
+	}
+}
+
+// withLoadBalancer adds spec.vcluster.networking.loadBalancer to the
+// cross-namespace fixture.
+func withLoadBalancer(t *testing.T, lb string) []byte {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := "    networking:\n      loadBalancer:\n" + lb + "    backingStore:"
+	return []byte(strings.Replace(string(input), "    backingStore:", block, 1))
+}
+
+func TestLoadBalancerPoolManifests(t *testing.T) {
+	_, _, config, err := fixtureConfig(t, withLoadBalancer(t, "        addressPool: 10.0.5.16/28\n        autoAssign: false\n        l2Interfaces: [eth0]\n"))
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	want := `apiVersion: metallb.io/v1beta1
+kind: IPAddressPool
+metadata:
+  name: vcluster
+  namespace: metallb-system
+spec:
+  addresses:
+  - 10.0.5.16/28
+  autoAssign: false
+---
+apiVersion: metallb.io/v1beta1
+kind: L2Advertisement
+metadata:
+  name: vcluster
+  namespace: metallb-system
+spec:
+  interfaces:
+  - eth0
+  ipAddressPools:
+  - vcluster
+`
+	if got := str(config.ValuesObject, "experimental.deploy.vcluster.manifests"); got != want {
+		t.Errorf("vcluster manifests:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without a pool nothing is applied inside the vcluster.
+	_, _, config, err = fixtureConfig(t, withKubeconfigSecret(t, "          name: media-admin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field(config.ValuesObject, "experimental") != nil {
+		t.Errorf("experimental values rendered without a load balancer pool: %v", config.ValuesObject["experimental"])
+	}
+}
+
+func TestLoadBalancerPoolValidation(t *testing.T) {
+	others := []declaredPool{
+		{Namespace: fixtureNamespace, Name: "media", Pool: "10.0.6.0/28"}, // this request's own, older pool
+		{Namespace: fixtureNamespace, Name: "games", Pool: "10.0.5.20-10.0.5.40"},
+	}
+	tests := []struct {
+		pool string
+		want string // error substring; "" when valid
+	}{
+		{"10.0.5.0/28", ""},
+		{"10.0.6.0/28", ""},
+		{"10.0.4.240-10.0.4.250", "overlaps the exposure subnet 10.0.4.0/24"},
+		{"10.0.5.16/28", "overlaps the pool of vcluster platform-requests/games (10.0.5.20-10.0.5.40)"},
+		{"10.0.5.40-10.0.5.50", "platform-requests/games"},
+	}
+	for _, tt := range tests {
+		_, _, config, err := fixtureConfig(t, withLoadBalancer(t, "        addressPool: "+tt.pool+"\n"))
+		if err != nil {
+			t.Fatalf("buildConfig(%s): %v", tt.pool, err)
+		}
+		err = validateLoadBalancerPool(config, others)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("pool %s: unexpected error %v", tt.pool, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("pool %s: error = %v, want it to contain %q", tt.pool, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.5.0/33", "10.0.5.9-10.0.5.1", "pool"} {
+		if _, _, _, err := fixtureConfig(t, withLoadBalancer(t, "        addressPool: "+bad+"\n")); err == nil {
+			t.Errorf("buildConfig accepted address pool %q", bad)
+		}
+	}
+}
+
+func TestOnePasswordVault(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	integrations := "  integrations:\n    onePassword:\n      vault: homelab-prod\n      secretStore: onepassword-prod\n      connectHost: https://connect.prod.example.com\n"
+	tests := []struct {
+		name  string
+		extra string
+		want  *u.OnePasswordRef
+		host  string
+	}{
+		{name: "default", want: nil},
+		{name: "integration default", extra: integrations, want: &u.OnePasswordRef{Vault: "homelab-prod", SecretStore: "onepassword-prod"}, host: "https://connect.prod.example.com"},
+		{
+			name:  "request override",
+			extra: integrations + "  onePassword:\n    vault: 4s2kvm3xq7nqbfcfhkqjvrv5aq\n",
+			want:  &u.OnePasswordRef{Vault: "4s2kvm3xq7nqbfcfhkqjvrv5aq", SecretStore: "onepassword-prod"},
+			host:  "https://connect.prod.example.com",
+		},
+		{
+			name:  "keep item on destroy",
+			extra: "  integrations:\n    onePassword:\n      deleteItemOnDestroy: false\n",
+			want:  &u.OnePasswordRef{DeleteItemOnDestroy: new(bool)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, config, err := fixtureConfig(t, append(append([]byte(nil), input...), tt.extra...))
+			if err != nil {
+				t.Fatalf("buildConfig: %v", err)
+			}
+			spec := buildArgoCDClusterRegistrationRequest(config).Spec.(u.ArgoCDClusterRegistrationSpec)
+			if got := spec.OnePassword; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("registration onePassword = %+v, want %+v", got, tt.want)
+			}
+			if spec.OnePasswordConnectHost != tt.host {
+				t.Errorf("registration onePasswordConnectHost = %q, want %q", spec.OnePasswordConnectHost, tt.host)
+			}
+		})
+	}
+}
+
+func TestPartialFailureStatus(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdk, outputDir, metadataDir := fixtureSDK(t, input)
+	// A directory where the third output goes makes its write fail.
+	blocked := filepath.Join(outputDir, "resources", "argocd-application-request.yaml")
+	if err := os.MkdirAll(blocked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Run(sdk); err == nil {
+		t.Fatal("Run succeeded with an unwritable output")
+	}
+
+	data, err := os.ReadFile(filepath.Join(metadataDir, "status.yaml"))
+	if err != nil {
+		t.Fatalf("no status written: %v", err)
+	}
+	var status map[string]interface{}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+	if str(status, "phase") != "Failed" || str(status, "failedStep") != u.StepRender {
+		t.Errorf("status = %v, want phase Failed in step Render", status)
+	}
+	if !strings.Contains(str(status, "message"), "argocd-application-request.yaml") {
+		t.Errorf("message = %q, want the failed output", str(status, "message"))
+	}
+	want := []interface{}{"resources/namespace.yaml", "resources/argocd-project-request.yaml"}
+	if got := list(status, "outputsWritten"); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("outputsWritten = %v, want %v", got, want)
+	}
+	var ready map[string]interface{}
+	for _, c := range list(status, "conditions") {
+		if str(c, "type") == u.ConditionReady {
+			ready, _ = c.(map[string]interface{})
+		}
+	}
+	if str(ready, "status") != "False" || str(ready, "reason") != "RenderFailed" {
+		t.Errorf("Ready = %v, want False/RenderFailed", ready)
+	}
+}
+
+func TestPausedValues(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	override := "    helmOverrides:\n      controlPlane:\n        statefulSet:\n          highAvailability:\n            replicas: 5\n    backingStore:"
+	input = []byte(strings.Replace(string(input), "    backingStore:", override, 1))
+	replicas := "controlPlane.statefulSet.highAvailability.replicas"
+
+	_, _, running, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if running.Paused || fmt.Sprint(field(running.ValuesObject, replicas)) != "5" {
+		t.Fatalf("unpaused replicas = %v, want the helm override 5", field(running.ValuesObject, replicas))
+	}
+
+	annotated := strings.Replace(string(input), "  namespace: platform-requests\n",
+		"  namespace: platform-requests\n  annotations:\n    platform.integratn.tech/paused: \"true\"\n", 1)
+	_, _, paused, err := fixtureConfig(t, []byte(annotated))
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if !paused.Paused {
+		t.Fatal("paused annotation not read")
+	}
+	for path, want := range map[string]string{
+		replicas: "0",
+		"controlPlane.coredns.deployment.replicas":                                    "0",
+		"controlPlane.advanced.podDisruptionBudget.enabled":                           "false",
+		"controlPlane.backingStore.etcd.deploy.statefulSet.highAvailability.replicas": "0",
+		"controlPlane.backingStore.etcd.deploy.enabled":                               "true",
+		"controlPlane.statefulSet.persistence.volumeClaim.enabled":                    "true",
+	} {
+		if got := fmt.Sprint(field(paused.ValuesObject, path)); got != want {
+			t.Errorf("%s = %s, want %s", path, got, want)
+		}
+	}
+	annotations, _ := field(paused.ValuesObject, "controlPlane.statefulSet.annotations").(map[string]interface{})
+	if annotations["loft.sh/paused"] != "true" {
+		t.Errorf("statefulSet annotations = %v, want loft.sh/paused", annotations)
+	}
+	// The request's own backing store is left as written.
+	if field(paused.BackingStore, "etcd.deploy.statefulSet") != nil {
+		t.Errorf("backing store modified: %v", paused.BackingStore)
+	}
+}
+
+func TestDefaultVIPFromCIDR(t *testing.T) {
+	tests := []struct {
+		cidr   string
+		offset int
+		want   string
+		err    string
+	}{
+		{"10.0.4.0/24", defaultVIPOffset, "10.0.4.200", ""},
+		{"10.0.4.17/24", defaultVIPOffset, "10.0.4.200", ""}, // host bits are masked
+		{"fd00:4::/64", defaultVIPOffset, "fd00:4::c8", ""},
+		{"2001:db8:0:4::/120", defaultVIPOffset, "2001:db8:0:4::c8", ""},
+		{"::ffff:10.0.4.0/120", defaultVIPOffset, "10.0.4.200", ""},
+		{"10.0.4.0/25", defaultVIPOffset, "", "too small"},
+		{"fd00:4::/121", defaultVIPOffset, "", "too small"},
+		{"10.0.4.0", defaultVIPOffset, "", "invalid CIDR"},
+		{"10.0.4.0/24", 0, "", "network address"},
+		{"10.0.4.0/24", 255, "", "broadcast address"},
+		{"10.0.4.0/24", -1, "", "negative"},
+		{"10.0.4.16/28", 10, "10.0.4.26", ""},
+		{"10.0.4.16/28", 15, "", "broadcast address"},
+		{"10.0.4.16/28", 16, "", "too small"},
+		{"10.0.4.16/31", 0, "10.0.4.16", ""},
+		{"10.0.4.16/31", 1, "10.0.4.17", ""},
+		{"10.0.4.16/31", 2, "", "too small"},
+		{"10.0.4.16/32", 0, "10.0.4.16", ""},
+		{"fd00:4::/64", 0, "", "network address"},
+		{"fd00:4::/120", 255, "fd00:4::ff", ""}, // IPv6 has no broadcast
+		{"fd00:4::/127", 0, "fd00:4::", ""},
+	}
+	for _, tt := range tests {
+		got, err := defaultVIPFromCIDR(tt.cidr, tt.offset)
+		switch {
+		case tt.err == "" && (err != nil || got != tt.want):
+			t.Errorf("defaultVIPFromCIDR(%s, %d) = %q, %v, want %q", tt.cidr, tt.offset, got, err, tt.want)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("defaultVIPFromCIDR(%s, %d) error = %v, want it to contain %q", tt.cidr, tt.offset, err, tt.err)
+		}
+	}
+
+	// spec.exposure.vipOffset moves the VIP selected for each subnet.
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input = []byte(strings.Replace(string(input), "    subnet: 10.0.4.0/24\n", "    subnet: 10.0.4.0/24,fd00:4::/64\n    vipOffset: 10\n", 1))
+	_, _, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if got := strings.Join(config.VIPs, ","); got != "10.0.4.10,fd00:4::a" {
+		t.Errorf("VIPs = %s, want 10.0.4.10,fd00:4::a", got)
+	}
+}
+
+func TestCheckVIPInSubnet(t *testing.T) {
+	tests := []struct {
+		vip, subnet string
+		err         string
+	}{
+		{"10.0.4.210", "10.0.4.0/24", ""},
+		{"fd00:4::10", "fd00:4::/64", ""},
+		{"::ffff:10.0.4.210", "10.0.4.0/24", ""},
+		{"10.0.5.210", "10.0.4.0/24", "is not within subnet 10.0.4.0/24"},
+		{"fd00:5::10", "fd00:4::/64", "is not within subnet fd00:4::/64"},
+		{"fd00:4::10", "10.0.4.0/24", "VIP fd00:4::10 is an IPv6 address but subnet 10.0.4.0/24 is IPv4"},
+		{"10.0.4.210", "fd00:4::/64", "VIP 10.0.4.210 is an IPv4 address but subnet fd00:4::/64 is IPv6"},
+		{"10.0.4.0", "10.0.4.0/24", "VIP 10.0.4.0 is the network address of subnet 10.0.4.0/24"},
+		{"10.0.4.31", "10.0.4.16/28", "VIP 10.0.4.31 is the broadcast address of subnet 10.0.4.16/28"},
+		{"10.0.4.17", "10.0.4.16/31", ""},
+	}
+	for _, tt := range tests {
+		vip, err := parseAddr(tt.vip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subnet, err := parsePrefix(tt.subnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = checkVIPInSubnet(vip, subnet)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("checkVIPInSubnet(%s, %s) = %v", tt.vip, tt.subnet, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("checkVIPInSubnet(%s, %s) = %v, want it to contain %q", tt.vip, tt.subnet, err, tt.err)
+		}
+	}
+}
+
+func TestAddressRangeOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"10.0.5.0/28", "10.0.5.10-10.0.5.20", true},
+		{"10.0.5.0/28", "10.0.5.16-10.0.5.20", false},
+		{"fd00:5::/120", "fd00:5::f0-fd00:5::1:0", true},
+		{"fd00:5::/120", "fd00:5::100-fd00:5::1ff", false},
+		{"10.0.5.0/24", "::ffff:a00:500/120", true},
+		{"10.0.5.0/24", "fd00:5::/120", false},
+		{"10.0.5.0/28,fd00:5::/120", "fd00:5::80/121", true},
+	}
+	for _, tt := range tests {
+		a, err := parseAddressPool(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parseAddressPool(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rangesOverlap(a, b); got != tt.want {
+			t.Errorf("overlap(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.5.0/28,10.0.6.0/28", "10.0.5.1-fd00::1", "fd00::9-fd00::1"} {
+		if _, err := parseAddressPool(bad); err == nil {
+			t.Errorf("parseAddressPool(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestServerURLAndSANs(t *testing.T) {
+	tests := []struct {
+		host   string
+		url    string
+		san    string
+		isAddr bool
+	}{
+		{"media.integratn.tech", "https://media.integratn.tech:443", "", false},
+		{"10.0.4.200", "https://10.0.4.200:443", "10.0.4.200", true},
+		{"fd00:4::c8", "https://[fd00:4::c8]:443", "fd00:4::c8", true},
+		{"::ffff:10.0.4.200", "https://[::ffff:10.0.4.200]:443", "10.0.4.200", true},
+	}
+	for _, tt := range tests {
+		if got := serverURL(tt.host, 443); got != tt.url {
+			t.Errorf("serverURL(%s) = %s, want %s", tt.host, got, tt.url)
+		}
+		if !tt.isAddr {
+			continue
+		}
+		a, err := netip.ParseAddr(tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sanFor(a); got != tt.san {
+			t.Errorf("sanFor(%s) = %s, want %s", tt.host, got, tt.san)
+		}
+	}
+}
+
+// withExposure replaces spec.exposure of the cross-namespace fixture.
+func withExposure(t *testing.T, exposure string) []byte {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(strings.Replace(string(input), "  exposure:\n    subnet: 10.0.4.0/24\n", "  exposure:\n"+exposure, 1))
+}
+
+func TestExposureIPFamilies(t *testing.T) {
+	tests := []struct {
+		name     string
+		exposure string
+		vips     []string
+		families []string
+		policy   string
+		err      string
+	}{
+		{
+			name:     "ipv4",
+			exposure: "    subnet: 10.0.4.0/24\n",
+			vips:     []string{"10.0.4.200"},
+		},
+		{
+			name:     "ipv6 auto-selected",
+			exposure: "    subnet: fd00:4::/64\n",
+			vips:     []string{"fd00:4::c8"},
+			families: []string{"IPv6"},
+		},
+		{
+			name:     "dual-stack",
+			exposure: "    subnet: 10.0.4.0/24,fd00:4::/64\n    vip: fd00:4::10\n    ipFamilies: [IPv6, IPv4]\n",
+			vips:     []string{"fd00:4::10", "10.0.4.200"},
+			families: []string{"IPv6", "IPv4"},
+			policy:   "RequireDualStack",
+		},
+		{
+			name:     "dual-stack families with one vip",
+			exposure: "    subnet: 10.0.4.0/24\n    ipFamilies: [IPv4, IPv6]\n",
+			vips:     []string{"10.0.4.200"},
+			families: []string{"IPv4", "IPv6"},
+			policy:   "PreferDualStack",
+		},
+		{
+			name:     "family mismatch",
+			exposure: "    subnet: 10.0.4.0/24\n    vip: fd00:4::10\n",
+			err:      "VIP fd00:4::10 is an IPv6 address but subnet 10.0.4.0/24 is IPv4",
+		},
+		{
+			name:     "vip outside families",
+			exposure: "    subnet: fd00:4::/64\n    ipFamilies: [IPv4]\n",
+			err:      "VIP fd00:4::c8 is an IPv6 address but ipFamilies is [IPv4]",
+		},
+		{
+			name:     "two subnets of one family",
+			exposure: "    subnet: 10.0.4.0/24,10.0.5.0/24\n",
+			err:      "both IPv4",
+		},
+		{
+			name:     "single stack with two families",
+			exposure: "    ipFamilies: [IPv4, IPv6]\n    ipFamilyPolicy: SingleStack\n",
+			err:      "cannot serve both families",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, config, err := fixtureConfig(t, withExposure(t, tt.exposure))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("buildConfig error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildConfig: %v", err)
+			}
+			if fmt.Sprint(config.VIPs) != fmt.Sprint(tt.vips) {
+				t.Errorf("VIPs = %v, want %v", config.VIPs, tt.vips)
+			}
+			if got := list(config.ValuesObject, "controlPlane.service.spec.ipFamilies"); fmt.Sprint(got) != fmt.Sprint(tt.families) && (len(got) > 0 || len(tt.families) > 0) {
+				t.Errorf("service ipFamilies = %v, want %v", field(config.ValuesObject, "controlPlane.service.spec.ipFamilies"), tt.families)
+			}
+			if got := str(config.ValuesObject, "controlPlane.service.spec.ipFamilyPolicy"); got != tt.policy {
+				t.Errorf("service ipFamilyPolicy = %q, want %q", got, tt.policy)
+			}
+			sans := map[interface{}]bool{}
+			for _, san := range list(config.ValuesObject, "controlPlane.proxy.extraSANs") {
+				sans[san] = true
+			}
+			for _, vip := range tt.vips {
+				if !sans[vip] {
+					t.Errorf("extraSANs = %v, want the bare VIP %s", list(config.ValuesObject, "controlPlane.proxy.extraSANs"), vip)
+				}
+			}
+
+			lbIP := str(config.ValuesObject, "controlPlane.service.spec.loadBalancerIP")
+			annotations, _ := field(config.ValuesObject, "controlPlane.service.annotations").(map[string]interface{})
+			if len(tt.vips) > 1 {
+				if lbIP != "" || annotations[metallbLoadBalancerIPsAnnotation] != strings.Join(tt.vips, ",") {
+					t.Errorf("dual-stack VIPs: loadBalancerIP %q, annotations %v", lbIP, annotations)
+				}
+			} else if lbIP != tt.vips[0] {
+				t.Errorf("loadBalancerIP = %q, want %s", lbIP, tt.vips[0])
+			}
+		})
+	}
+}
+
+func TestExposureModeValues(t *testing.T) {
+	for _, tt := range []struct {
+		mode, exposure string
+	}{
+		{exposureModeLoadBalancer, "    subnet: 10.0.4.0/24\n"},
+		{exposureModeIngress, "    mode: ingress\n"},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			_, _, config, err := fixtureConfig(t, withExposure(t, tt.exposure))
+			if err != nil {
+				t.Fatalf("buildConfig: %v", err)
+			}
+			values := config.ValuesObject
+			ingress := tt.mode == exposureModeIngress
+
+			// In ingress mode the hostname defaults to one the listener serves.
+			hostname := "media.integratn.tech"
+			if ingress {
+				hostname = "media.vcluster.integratn.tech"
+			}
+			if config.ExternalServerURL != "https://"+hostname+":443" {
+				t.Errorf("ExternalServerURL = %s, want it from the hostname %s", config.ExternalServerURL, hostname)
+			}
+			wantSANs := "[media.integratn.tech 10.0.4.200]"
+			wantType, wantIP := "LoadBalancer", "10.0.4.200"
+			if ingress {
+				wantSANs, wantType, wantIP = "["+hostname+"]", "ClusterIP", ""
+			}
+			if got := fmt.Sprint(field(values, "controlPlane.proxy.extraSANs")); got != wantSANs {
+				t.Errorf("extraSANs = %s, want %s", got, wantSANs)
+			}
+			if got := str(values, "controlPlane.service.spec.type"); got != wantType {
+				t.Errorf("service type = %s, want %s", got, wantType)
+			}
+			if got := str(values, "controlPlane.service.spec.loadBalancerIP"); got != wantIP {
+				t.Errorf("loadBalancerIP = %q, want %q", got, wantIP)
+			}
+			annotations, _ := field(values, "controlPlane.service.annotations").(map[string]interface{})
+			if _, ok := annotations["external-dns.alpha.kubernetes.io/hostname"]; ok == ingress {
+				t.Errorf("service annotations = %v", annotations)
+			}
+
+			// The chart's Ingress stays off: nginx-gateway serves only
+			// Gateway API routes.
+			if got := field(values, "controlPlane.ingress.enabled"); got != false {
+				t.Errorf("ingress.enabled = %v, want false", got)
+			}
+			routes := buildAPIRoute(config)
+			if !ingress {
+				if len(routes) != 0 {
+					t.Errorf("buildAPIRoute() = %v, want none", routes)
+				}
+			} else {
+				if len(routes) != 1 || routes[0].Kind != "TLSRoute" || routes[0].Metadata.Namespace != config.TargetNamespace {
+					t.Fatalf("buildAPIRoute() = %v, want one TLSRoute in %s", routes, config.TargetNamespace)
+				}
+				want := map[string]interface{}{
+					"parentRefs": []interface{}{map[string]interface{}{"name": "nginx-gateway", "namespace": "nginx-gateway", "sectionName": "tls-passthrough"}},
+					"hostnames":  []interface{}{hostname},
+					"rules":      []interface{}{map[string]interface{}{"backendRefs": []interface{}{map[string]interface{}{"name": config.Name, "port": float64(443)}}}},
+				}
+				raw, err := yaml.Marshal(routes[0].Spec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got map[string]interface{}
+				if err := yaml.Unmarshal(raw, &got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("TLSRoute spec = %v, want %v", got, want)
+				}
+			}
+
+			var snat bool
+			var external u.Resource
+			for _, p := range buildNetworkPolicies(config) {
+				switch p.Metadata.Name {
+				case "allow-vcluster-lb-snat":
+					snat = true
+				case "allow-vcluster-external":
+					external = p
+				}
+			}
+			if snat == ingress {
+				t.Errorf("allow-vcluster-lb-snat rendered = %t", snat)
+			}
+			rules := external.Spec.(map[string]interface{})["ingress"].([]map[string]interface{})
+			if !strings.Contains(fmt.Sprint(rules), "kubernetes.io/metadata.name:nginx-gateway") {
+				t.Errorf("allow-vcluster-external does not admit nginx-gateway: %v", rules)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		name, exposure, err string
+	}{
+		{"ingress with vip", "    mode: ingress\n    vip: 10.0.4.10\n", "spec.exposure.vip only applies to mode loadbalancer"},
+		{"ingress with api port", "    mode: ingress\n    apiPort: 6443\n", "spec.exposure.apiPort only applies to mode loadbalancer"},
+		{"ingress outside the listener domain", "    mode: ingress\n    hostname: media.integratn.tech\n", "must be a name directly under vcluster.integratn.tech"},
+		{"ingress nested in the listener domain", "    mode: ingress\n    hostname: a.media.vcluster.integratn.tech\n", "must be a name directly under vcluster.integratn.tech"},
+		{"non-integer vip offset", "    subnet: 10.0.4.0/24\n    vipOffset: ten\n", "spec.exposure.vipOffset: strconv.Atoi"},
+		{"list vip offset", "    subnet: 10.0.4.0/24\n    vipOffset: [10]\n", "spec.exposure.vipOffset: value at spec.exposure.vipOffset is not an integer"},
+		{"zero vip offset is not the default", "    subnet: 10.0.4.0/24\n    vipOffset: 0\n", "network address"},
+		{"unknown mode", "    mode: nodeport\n", `unknown mode "nodeport"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := fixtureConfig(t, withExposure(t, tt.exposure))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("buildConfig error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDeleteOutputsWaveOrder(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+
+	waves := map[string]int{}
+	for path, obj := range buildDeleteOutputs(config) {
+		if obj.Spec != nil || obj.Data != nil {
+			t.Errorf("%s carries more than the resource identity", path)
+		}
+		wave, err := strconv.Atoi(obj.Metadata.Annotations[u.SyncWaveAnnotation])
+		if err != nil {
+			t.Errorf("%s: sync wave %q: %v", path, obj.Metadata.Annotations[u.SyncWaveAnnotation], err)
+			continue
+		}
+		key := obj.Kind + "/" + obj.Metadata.Name
+		waves[key] = wave
+	}
+
+	// Each pair is removed before the next: dependents first.
+	order := []string{
+		"ArgoCDClusterRegistration/media-cluster-registration",
+		"Job/media-etcd-certs-merge",
+		"ArgoCDApplication/vcluster-media",
+		"Secret/media-etcd-certs",
+		"ClusterRoleBinding/vc-media-v-vcluster-media",
+		"ArgoCDProject/vcluster-media",
+		"Namespace/vcluster-media",
+	}
+	for i, key := range order {
+		if _, ok := waves[key]; !ok {
+			t.Fatalf("no delete output for %s; have %v", key, waves)
+		}
+		if i > 0 && waves[order[i-1]] <= waves[key] {
+			t.Errorf("%s (wave %d) is not removed before %s (wave %d)", order[i-1], waves[order[i-1]], key, waves[key])
+		}
+	}
+	for key, wave := range waves {
+		if key != "Namespace/vcluster-media" && wave <= waves["Namespace/vcluster-media"] {
+			t.Errorf("%s (wave %d) is not removed before the namespace", key, wave)
+		}
+	}
+
+	// The namespace stays when asked, and always when it holds the request.
+	config.RetainNamespace = true
+	for path, obj := range buildDeleteOutputs(config) {
+		if obj.Kind == "Namespace" {
+			t.Errorf("retainNamespace: %s deletes the namespace", path)
+		}
+	}
+	config.RetainNamespace, config.TargetNamespace = false, fixtureNamespace
+	for path, obj := range buildDeleteOutputs(config) {
+		if obj.Kind == "Namespace" {
+			t.Errorf("target namespace = request namespace: %s deletes it", path)
+		}
+	}
+}
+
+func TestRetainNamespaceConfig(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input = []byte(strings.Replace(string(input), "  targetNamespace: vcluster-media\n", "  targetNamespace: vcluster-media\n  retainNamespace: true\n", 1))
+	_, _, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if !config.RetainNamespace {
+		t.Error("spec.retainNamespace: true was not read")
+	}
+}
+
+// withVCluster adds lines under spec.vcluster of the cross-namespace
+// fixture (prod: 3 replicas requesting 500m and 1Gi, 2 CoreDNS).
+func withVCluster(t *testing.T, lines string) []byte {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(strings.Replace(string(input), "    preset: prod\n", "    preset: prod\n"+lines, 1))
+}
+
+// quotaDocs renders the fixture and returns its ResourceQuota and
+// LimitRange, nil when absent.
+func quotaDocs(t *testing.T, input []byte) (quota, limits map[string]interface{}) {
+	t.Helper()
+	sdk, outputDir, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handleConfigure(&u.Execution{SDK: sdk, Resource: resource}, config); err != nil {
+		t.Fatalf("handleConfigure: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "resources", "resource-quota.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range bytes.Split(data, []byte("\n---\n")) {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		switch str(doc, "kind") {
+		case "ResourceQuota":
+			quota = doc
+		case "LimitRange":
+			limits = doc
+		}
+	}
+	return quota, limits
+}
+
+// quotaHard returns a ResourceQuota's spec.hard, whose keys hold dots.
+func quotaHard(quota map[string]interface{}) map[string]interface{} {
+	hard, _ := field(quota, "spec.hard").(map[string]interface{})
+	return hard
+}
+
+func TestQuotaDerivedDefaults(t *testing.T) {
+	if quota, limits := quotaDocs(t, withVCluster(t, "")); quota != nil || limits != nil {
+		t.Errorf("standard isolation rendered a quota: %v %v", quota, limits)
+	}
+
+	quota, limits := quotaDocs(t, withVCluster(t, "    isolationMode: strict\n"))
+	if quota == nil || limits == nil {
+		t.Fatalf("strict isolation: quota = %v, limit range = %v", quota, limits)
+	}
+	if ns := str(quota, "metadata.namespace"); ns != fixtureTargetNamespace {
+		t.Errorf("quota namespace = %q, want %q", ns, fixtureTargetNamespace)
+	}
+	// 3 × 500m and 3 × 1Gi for the control plane, and 4 times as much for
+	// workloads; 5 control plane pods and 40 for workloads.
+	for key, want := range map[string]interface{}{"requests.cpu": "7500m", "requests.memory": "15Gi", "pods": float64(45)} {
+		if got := quotaHard(quota)[key]; got != want {
+			t.Errorf("quota %s = %v, want %v", key, got, want)
+		}
+	}
+	limit := list(limits, "spec.limits")[0]
+	if str(limit, "defaultRequest.cpu") != limitRangeCPURequest || str(limit, "default.memory") != limitRangeMemoryLimit {
+		t.Errorf("limit range = %v, want the platform defaults", limit)
+	}
+
+	// A multiplier alone sizes the workload share.
+	quota, _ = quotaDocs(t, withVCluster(t, "    quota:\n      workloadMultiplier: 1\n"))
+	if got := quotaHard(quota)["requests.cpu"]; got != "3" {
+		t.Errorf("multiplier 1: requests.cpu = %v, want 3", got)
+	}
+}
+
+func TestIsolationModeResources(t *testing.T) {
+	isolation := []string{
+		"ResourceQuota/vcluster-media",
+		"LimitRange/vcluster-media",
+	}
+	// Every vcluster gets these, strict or not.
+	baseline := []string{
+		"NetworkPolicy/default-deny-all",
+		"NetworkPolicy/allow-dns",
+		"CiliumNetworkPolicy/allow-kube-api",
+	}
+	for _, mode := range []string{"standard", "strict"} {
+		t.Run(mode, func(t *testing.T) {
+			input := withVCluster(t, "    isolationMode: "+mode+"\n")
+			rendered := map[string]bool{}
+			for _, doc := range renderInput(t, input) {
+				rendered[str(doc, "kind")+"/"+str(doc, "metadata.name")] = true
+			}
+			_, _, config, err := fixtureConfig(t, input)
+			if err != nil {
+				t.Fatalf("buildConfig: %v", err)
+			}
+			deleted := map[string]bool{}
+			for _, obj := range buildDeleteOutputs(config) {
+				deleted[obj.Kind+"/"+obj.Metadata.Name] = true
+			}
+
+			for _, key := range baseline {
+				if !rendered[key] || !deleted[key] {
+					t.Errorf("%s: rendered %t, deleted %t, want both", key, rendered[key], deleted[key])
+				}
+			}
+			for _, key := range isolation {
+				if want := mode == "strict"; rendered[key] != want || deleted[key] != want {
+					t.Errorf("%s: rendered %t, deleted %t, want %t", key, rendered[key], deleted[key], want)
+				}
+			}
+			if got := field(config.ValuesObject, "sync.toHost.networkPolicies.enabled"); got != true {
+				t.Errorf("sync.toHost.networkPolicies.enabled = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestQuotaExplicitOverrides(t *testing.T) {
+	quota, limits := quotaDocs(t, withVCluster(t, "    quota:\n      cpu: \"4\"\n      memory: 8Gi\n      pods: 20\n"))
+	if quota == nil || limits == nil {
+		t.Fatal("an explicit quota in standard isolation rendered no quota")
+	}
+	for key, want := range map[string]interface{}{"requests.cpu": "4", "requests.memory": "8Gi", "pods": float64(20)} {
+		if got := quotaHard(quota)[key]; got != want {
+			t.Errorf("quota %s = %v, want %v", key, got, want)
+		}
+	}
+
+	_, _, config, err := fixtureConfig(t, withVCluster(t, "    isolationMode: strict\n    quota:\n      memory: 6Gi\n"))
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+	if config.Quota.Memory != "6Gi" || config.Quota.CPU != "7500m" {
+		t.Errorf("quota = %+v, want memory 6Gi and derived cpu 7500m", config.Quota)
+	}
+	outputs := buildDeleteOutputs(config)
+	for _, path := range []string{"resources/delete-resourcequota-vcluster-media.yaml", "resources/delete-limitrange-vcluster-media.yaml"} {
+		if _, ok := outputs[path]; !ok {
+			t.Errorf("no delete output %s", path)
+		}
+	}
+}
+
+func TestQuotaBelowControlPlane(t *testing.T) {
+	for _, tt := range []struct{ quota, want string }{
+		{"      cpu: 1200m\n", "cpu 1200m is less than the control plane requests (3 × 500m = 1500m)"},
+		{"      memory: 2Gi\n", "memory 2Gi is less than the control plane requests (3 × 1Gi = 3Gi)"},
+		{"      pods: 4\n", "pods 4 is less than the control plane's 5"},
+		{"      cpu: lots\n", `cpu "lots" is not a quantity`},
+	} {
+		_, _, _, err := fixtureConfig(t, withVCluster(t, "    isolationMode: strict\n    quota:\n"+tt.quota))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("quota %q: error = %v, want it to contain %q", strings.TrimSpace(tt.quota), err, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionSkew(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  string
+	}{
+		{"v1.33.2", "v1.34.3", ""},
+		{"v1.34.3", "v1.34.1", ""},
+		{"1.33", "v1.33.4", ""},
+		{"v1.32.1", "v1.34.3", "upgrade to 1.33 first"},
+		{"v1.34.3", "1.33", "cannot downgrade"},
+		{"v1.34.3", "v2.0.0", "major version"},
+		{"v1.34.3", "latest", "not <major>.<minor>"},
+	}
+	for _, tt := range tests {
+		err := checkVersionSkew(tt.from, tt.to)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s → %s: %v", tt.from, tt.to, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s → %s: error %v, want %q", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
+
+// runUpgrade runs the configure pipeline on the cross-namespace fixture,
+// edited by replacements and with status appended, and returns the written
+// status and every rendered document keyed kind/name.
+func runUpgrade(t *testing.T, status string, replacements ...string) (map[string]interface{}, map[string]map[string]interface{}, error) {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := strings.NewReplacer(replacements...).Replace(string(input)) + "status:\n" + status
+	sdk, outputDir, metadataDir := fixtureSDK(t, []byte(fixture))
+	runErr := Run(sdk)
+
+	data, err := os.ReadFile(filepath.Join(metadataDir, "status.yaml"))
+	if err != nil {
+		t.Fatalf("no status written: %v", err)
+	}
+	var written map[string]interface{}
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]map[string]interface{}{}
+	data, err = os.ReadFile(filepath.Join(outputDir, "resources", "upgrade-backup.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	for _, raw := range bytes.Split(data, []byte("\n---\n")) {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc != nil {
+			docs[str(doc, "kind")+"/"+str(doc, "metadata.name")] = doc
+		}
+	}
+	return written, docs, runErr
+}
+
+func TestUpgradeBackupEtcd(t *testing.T) {
+	status, docs, err := runUpgrade(t, "  k8sVersion: v1.33.2\n  chartVersion: 0.30.4\n")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	history := list(status, "upgradeHistory")
+	if len(history) != 1 || str(history[0], "fromK8sVersion") != "v1.33.2" || str(history[0], "toK8sVersion") != "v1.34.3" || str(history[0], "startedAt") == "" {
+		t.Fatalf("upgradeHistory = %v", history)
+	}
+	jobName := "media-pre-upgrade-v1-34-3-0-30-4"
+	if str(history[0], "backupJob") != jobName {
+		t.Errorf("backupJob = %q, want %s", str(history[0], "backupJob"), jobName)
+	}
+	if str(status, "k8sVersion") != "v1.34.3" || str(status, "chartVersion") != "0.30.4" {
+		t.Errorf("recorded versions = %s, %s", str(status, "k8sVersion"), str(status, "chartVersion"))
+	}
+	if !strings.Contains(str(status, "message"), "after backup Job "+jobName) {
+		t.Errorf("message = %q", str(status, "message"))
+	}
+
+	job := docs["Job/"+jobName]
+	pvc := docs["PersistentVolumeClaim/media-upgrade-backups"]
+	if job == nil || pvc == nil {
+		t.Fatalf("backup outputs = %v, want the Job and its volume", docs)
+	}
+	for _, doc := range []map[string]interface{}{job, pvc} {
+		annotations, _ := field(doc, "metadata.annotations").(map[string]interface{})
+		if annotations[u.SyncWaveAnnotation] != "-1" {
+			t.Errorf("%s sync wave = %v, want -1: after the namespace, before the application request", str(doc, "kind"), annotations[u.SyncWaveAnnotation])
+		}
+	}
+	container := list(job, "spec.template.spec.containers")[0]
+	script := fmt.Sprint(list(container, "command")...)
+	if !strings.Contains(script, "snapshot save") || !strings.Contains(script, "https://media-etcd.vcluster-media.svc:2379") {
+		t.Errorf("backup script = %s, want an etcd snapshot", script)
+	}
+	volumes := fmt.Sprint(list(job, "spec.template.spec.volumes"))
+	if !strings.Contains(volumes, "media-etcd-certs") || !strings.Contains(volumes, "media-upgrade-backups") {
+		t.Errorf("volumes = %s", volumes)
+	}
+}
+
+func TestUpgradeBackupSqlite(t *testing.T) {
+	etcd := "    backingStore:\n      etcd:\n        deploy:\n          enabled: true\n"
+	_, docs, err := runUpgrade(t, "  k8sVersion: v1.34.3\n  chartVersion: 0.30.1\n", etcd, "")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	job := docs["Job/media-pre-upgrade-v1-34-3-0-30-4"]
+	if job == nil {
+		t.Fatalf("backup outputs = %v, want a Job for the chart upgrade", docs)
+	}
+	container := list(job, "spec.template.spec.containers")[0]
+	if script := fmt.Sprint(list(container, "command")...); !strings.Contains(script, "sqlite3 /data/state.db") {
+		t.Errorf("backup script = %s, want a sqlite backup", script)
+	}
+	if volumes := fmt.Sprint(list(job, "spec.template.spec.volumes")); !strings.Contains(volumes, "data-media-0") {
+		t.Errorf("volumes = %s, want the vcluster's data volume", volumes)
+	}
+	affinity := list(job, "spec.template.spec.affinity.podAffinity.requiredDuringSchedulingIgnoredDuringExecution")
+	if len(affinity) != 1 || str(affinity[0], "labelSelector.matchLabels.release") != "media" {
+		t.Errorf("pod affinity = %v, want the vcluster pod's node", affinity)
+	}
+
+	// Without persistence there is nothing to back up; the upgrade says so.
+	status, docs, err := runUpgrade(t, "  k8sVersion: v1.33.2\n  chartVersion: 0.30.4\n", etcd, "", "preset: prod", "preset: dev")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(docs) != 0 {
+		t.Errorf("rendered %v without persistence", docs)
+	}
+	history := list(status, "upgradeHistory")
+	if len(history) != 1 || str(history[0], "backupJob") != "" || !strings.Contains(str(history[0], "backup"), "persistence is disabled") {
+		t.Errorf("upgradeHistory = %v", history)
+	}
+	if !strings.Contains(str(status, "message"), "without a backup") {
+		t.Errorf("message = %q", str(status, "message"))
+	}
+}
+
+func TestUpgradeRejectsVersionSkew(t *testing.T) {
+	status, docs, err := runUpgrade(t, "  k8sVersion: v1.32.4\n  chartVersion: 0.30.4\n")
+	if err == nil {
+		t.Fatal("Run accepted an upgrade across two minor versions")
+	}
+	if str(status, "phase") != "Failed" || str(status, "failedStep") != u.StepValidate {
+		t.Errorf("status = %v, want phase Failed in step Validate", status)
+	}
+	if !strings.Contains(str(status, "message"), "upgrade to 1.33 first") {
+		t.Errorf("message = %q", str(status, "message"))
+	}
+	if len(docs) != 0 {
+		t.Errorf("rendered %v for a rejected upgrade", docs)
+	}
+}
+
+func TestUpgradeInProgress(t *testing.T) {
+	// A later run at the same versions keeps the backup Job, so ArgoCD does
+	// not prune it mid-upgrade, and starts no new upgrade.
+	history := `  upgradeHistory:
+    - fromK8sVersion: v1.33.2
+      toK8sVersion: v1.34.3
+      fromChartVersion: 0.30.4
+      toChartVersion: 0.30.4
+      startedAt: "2026-10-01T10:00:00Z"
+      backupJob: media-pre-upgrade-v1-34-3-0-30-4
+      backup: etcd snapshot on PVC media-upgrade-backups
+`
+	status, docs, err := runUpgrade(t, "  k8sVersion: v1.34.3\n  chartVersion: 0.30.4\n"+history)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if docs["Job/media-pre-upgrade-v1-34-3-0-30-4"] == nil {
+		t.Errorf("backup outputs = %v, want the current upgrade's Job", docs)
+	}
+	if got := list(status, "upgradeHistory"); len(got) != 1 {
+		t.Errorf("upgradeHistory = %v, want the one upgrade", got)
+	}
+	if str(status, "message") != "VCluster resources scheduled for creation" {
+		t.Errorf("message = %q", str(status, "message"))
+	}
+
+	// A first run has nothing to compare against.
+	status, docs, err = runUpgrade(t, "  phase: Pending\n")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(docs) != 0 || list(status, "upgradeHistory") != nil {
+		t.Errorf("first run rendered %v, history %v", docs, list(status, "upgradeHistory"))
+	}
+}
+
+// withBootstrap appends spec.bootstrap.manifests, given as YAML list items
+// indented under it, to the cross-namespace fixture.
+func withBootstrap(t *testing.T, items string) []byte {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(input, []byte("  bootstrap:\n    manifests:\n"+items)...)
+}
+
+const bootstrapItems = `      - apiVersion: rbac.authorization.k8s.io/v1
+        kind: ClusterRoleBinding
+        metadata:
+          name: team-api-admins
+        roleRef: {apiGroup: rbac.authorization.k8s.io, kind: ClusterRole, name: cluster-admin}
+        subjects: [{apiGroup: rbac.authorization.k8s.io, kind: Group, name: oidc:team-api}]
+      - apiVersion: networking.k8s.io/v1
+        kind: NetworkPolicy
+        metadata:
+          name: default-deny
+          namespace: team-api
+        spec: {podSelector: {}, policyTypes: [Ingress]}
+      - apiVersion: v1
+        kind: Namespace
+        metadata:
+          name: team-api
+          annotations:
+            argocd.argoproj.io/sync-wave: "-1"
+      - apiVersion: scheduling.k8s.io/v1
+        kind: PriorityClass
+        metadata:
+          name: platform-critical
+          annotations:
+            argocd.argoproj.io/sync-wave: "5"
+        value: 1000000
+`
+
+func TestBootstrapManifests(t *testing.T) {
+	sdk, outputDir, metadataDir := fixtureSDK(t, withBootstrap(t, bootstrapItems))
+	if err := Run(sdk); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "resources", "bootstrap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]map[string]interface{}{}
+	for _, raw := range bytes.Split(data, []byte("\n---\n")) {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		docs[str(doc, "kind")] = doc
+	}
+	cm, job := docs["ConfigMap"], docs["Job"]
+	if cm == nil || job == nil {
+		t.Fatalf("bootstrap.yaml:\n%s\nwant a ConfigMap and a Job", data)
+	}
+	name := str(job, "metadata.name")
+	if !strings.HasPrefix(name, "media-bootstrap-") || str(cm, "metadata.name") != name {
+		t.Errorf("ConfigMap %q and Job %q, want both media-bootstrap-<hash>", str(cm, "metadata.name"), name)
+	}
+	for _, doc := range []map[string]interface{}{cm, job} {
+		if ns := str(doc, "metadata.namespace"); ns != fixtureTargetNamespace {
+			t.Errorf("%s namespace = %q, want %s", str(doc, "kind"), ns, fixtureTargetNamespace)
+		}
+	}
+
+	// Sync waves first, then as listed.
+	wantKeys := []string{
+		"000-namespace-team-api.yaml",
+		"001-clusterrolebinding-team-api-admins.yaml",
+		"002-networkpolicy-default-deny.yaml",
+		"003-priorityclass-platform-critical.yaml",
+	}
+	cmData, _ := field(cm, "data").(map[string]interface{})
+	if len(cmData) != len(wantKeys) {
+		t.Errorf("ConfigMap keys = %v, want %v", cmData, wantKeys)
+	}
+	for _, key := range wantKeys {
+		if _, ok := cmData[key]; !ok {
+			t.Errorf("ConfigMap has no %s", key)
+		}
+	}
+	if !strings.Contains(cmData["003-priorityclass-platform-critical.yaml"].(string), "value: 1000000\n") {
+		t.Errorf("PriorityClass lost its integer value:\n%s", cmData["003-priorityclass-platform-critical.yaml"])
+	}
+
+	pod := field(job, "spec.template.spec")
+	if policy := str(pod, "restartPolicy"); policy != "Never" {
+		t.Errorf("restartPolicy = %q, want Never to keep failed pods' termination messages", policy)
+	}
+	if init := list(pod, "initContainers"); len(init) != 1 || str(init[0], "name") != "wait-for-kubeconfig" ||
+		!strings.Contains(fmt.Sprint(field(init[0], "command")), "/kubeconfig/config") {
+		t.Errorf("initContainers = %v, want a wait for /kubeconfig/config", init)
+	}
+	volumes := map[string]string{}
+	for _, v := range list(pod, "volumes") {
+		volumes[str(v, "name")] = str(v, "secret.secretName") + str(v, "configMap.name")
+	}
+	if volumes["kubeconfig"] != "vc-media" || volumes["manifests"] != name {
+		t.Errorf("volumes = %v, want the vc-media kubeconfig Secret and the %s ConfigMap", volumes, name)
+	}
+	script := fmt.Sprint(list(list(pod, "containers")[0], "command")[2])
+	var order []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(line, "apply '") {
+			order = append(order, line)
+		}
+	}
+	wantOrder := []string{
+		"apply 'Namespace team-api' 000-namespace-team-api.yaml",
+		"apply 'ClusterRoleBinding team-api-admins' 001-clusterrolebinding-team-api-admins.yaml",
+		"apply 'NetworkPolicy team-api/default-deny' 002-networkpolicy-default-deny.yaml",
+		"apply 'PriorityClass platform-critical' 003-priorityclass-platform-critical.yaml",
+	}
+	if strings.Join(order, "\n") != strings.Join(wantOrder, "\n") {
+		t.Errorf("apply order:\n%s\nwant:\n%s", strings.Join(order, "\n"), strings.Join(wantOrder, "\n"))
+	}
+
+	status, err := os.ReadFile(filepath.Join(metadataDir, "status.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(status), "bootstrapJob: "+name+"\n") {
+		t.Errorf("status has no bootstrapJob %s:\n%s", name, status)
+	}
+
+	// The delete pipeline removes both.
+	resource, err := sdk.ReadResourceInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := buildConfig(sdk, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := map[string]bool{}
+	for _, obj := range buildDeleteOutputs(config) {
+		deleted[obj.Kind+"/"+obj.Metadata.Name] = true
+	}
+	if !deleted["ConfigMap/"+name] || !deleted["Job/"+name] {
+		t.Errorf("delete outputs miss the bootstrap ConfigMap or Job %s", name)
+	}
+
+	// Changed manifests get a new Job, since a Job's template is immutable.
+	_, _, changed, err := fixtureConfig(t, withBootstrap(t, strings.Replace(bootstrapItems, "value: 1000000", "value: 2000000", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other := bootstrapName(changed); other == name {
+		t.Errorf("changed manifests kept the Job name %s", name)
+	}
+}
+
+func TestBootstrapManifestsValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name, items, want string
+	}{
+		{"no kind", "      - apiVersion: v1\n        metadata: {name: x}\n", "spec.bootstrap.manifests[0] needs apiVersion, kind and metadata.name"},
+		{"bad wave", "      - apiVersion: v1\n        kind: Namespace\n        metadata:\n          name: x\n          annotations: {argocd.argoproj.io/sync-wave: first}\n", `sync-wave "first" is not an integer`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := fixtureConfig(t, withBootstrap(t, tt.items))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("buildConfig error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Without manifests nothing is rendered.
+	_, _, config, err := fixtureConfig(t, withBootstrap(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := buildBootstrap(config); docs != nil {
+		t.Errorf("empty spec.bootstrap rendered %d resources", len(docs))
+	}
+}
+
+func TestSecretsFromHostValues(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, config, err := fixtureConfig(t, input)
+	if err != nil {
+		t.Fatalf("buildConfig: %v", err)
+	}
+
+	registry := SecretFromHost{From: "registry/pull-secret", To: "default/pull-secret"}
+	ca := SecretFromHost{From: "cert-manager/ca-bundle", To: "kube-system/ca-bundle"}
+	// The same name in another namespace needs no second resourceNames entry.
+	mirror := SecretFromHost{From: "mirror/pull-secret", To: "mirror/pull-secret"}
+	for _, tt := range []struct {
+		name     string
+		secrets  []SecretFromHost
+		mappings map[string]interface{}
+		names    string
+	}{
+		{"none", nil, map[string]interface{}{}, "[eso-onepassword-token]"},
+		{"one", []SecretFromHost{registry}, map[string]interface{}{"registry/pull-secret": "default/pull-secret"}, "[eso-onepassword-token pull-secret]"},
+		{"multiple", []SecretFromHost{registry, ca, mirror}, map[string]interface{}{
+			"registry/pull-secret":   "default/pull-secret",
+			"cert-manager/ca-bundle": "kube-system/ca-bundle",
+			"mirror/pull-secret":     "mirror/pull-secret",
+		}, "[eso-onepassword-token pull-secret ca-bundle]"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SyncSecretsFromHost = tt.secrets
+			values, err := buildValuesObject(config)
+			if err != nil {
+				t.Fatalf("buildValuesObject: %v", err)
+			}
+			byName, _ := field(values, "sync.fromHost.secrets.mappings.byName").(map[string]interface{})
+			tt.mappings[defaultSecretFromHost] = defaultSecretFromHost
+			if fmt.Sprint(byName) != fmt.Sprint(tt.mappings) {
+				t.Errorf("byName = %v, want %v", byName, tt.mappings)
+			}
+			rule, _ := list(values, "rbac.clusterRole.extraRules")[0].(map[string]interface{})
+			if got := fmt.Sprint(rule["resourceNames"]); got != tt.names {
+				t.Errorf("resourceNames = %s, want %s", got, tt.names)
+			}
+		})
+	}
+}
+
+func TestSecretsFromHostValidation(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name, items, want string
+	}{
+		{"empty", "      - {}\n", `syncSecretsFromHost[0].from must be namespace/name, got ""`},
+		{"no namespace", "      - {from: pull-secret, to: default/pull-secret}\n", `syncSecretsFromHost[0].from must be namespace/name, got "pull-secret"`},
+		{"bad to", "      - {from: registry/pull-secret, to: a/b/c}\n", `syncSecretsFromHost[0].to must be namespace/name, got "a/b/c"`},
+		{"not an object", "      - registry/pull-secret\n", "syncSecretsFromHost[0] is not an object"},
+		{"conflict", "      - {from: registry/pull-secret, to: default/pull-secret}\n      - {from: registry/pull-secret, to: apps/pull-secret}\n", "syncSecretsFromHost[1]: registry/pull-secret is already synced as default/pull-secret"},
+		{"default", "      - {from: external-secrets/eso-onepassword-token, to: default/token}\n", "always synced as itself"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := strings.Replace(string(input), "    preset: prod\n", "    preset: prod\n    syncSecretsFromHost:\n"+tt.items, 1)
+			_, _, _, err := fixtureConfig(t, []byte(spec))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("buildConfig error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	spec := strings.Replace(string(input), "    preset: prod\n", "    preset: prod\n    syncSecretsFromHost:\n      - {from: registry/pull-secret, to: default/pull-secret}\n", 1)
+	_, _, config, err := fixtureConfig(t, []byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(field(config.ValuesObject, "sync.fromHost.secrets.mappings.byName")); !strings.Contains(got, "registry/pull-secret:default/pull-secret") {
+		t.Errorf("byName = %s, want the requested mapping", got)
+	}
+}
+
+func TestDeleteRemovesOnePasswordItemFirst(t *testing.T) {
+	defer func(orig func(context.Context, string, string, string) (string, error)) { readSecretKey = orig }(readSecretKey)
+	readSecretKey = func(_ context.Context, namespace, name, key string) (string, error) {
+		if namespace != "vcluster-media" || name != "media-onepassword-token" || key != "token" {
+			return "", fmt.Errorf("unexpected secret %s/%s key %s", namespace, name, key)
+		}
+		return "connect-token", nil
+	}
+
+	var outputDir string
+	var deleted, outputsAtDelete []string
+	deleteStatus := http.StatusNoContent
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/vaults", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"v1","name":"homelab-prod"}]`))
+	})
+	mux.HandleFunc("GET /v1/vaults/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		// The title the registration's sync Job writes
+		if r.URL.Query().Get("filter") == `title eq "media-kubeconfig"` {
+			w.Write([]byte(`[{"id":"i1","title":"media-kubeconfig"}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("DELETE /v1/vaults/v1/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.PathValue("id"))
+		outputsAtDelete, _ = filepath.Glob(filepath.Join(outputDir, "resources", "*"))
+		w.WriteHeader(deleteStatus)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	input, err := os.ReadFile(filepath.Join("testdata", "cross-namespace.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	onePassword := "  integrations:\n    onePassword:\n      vault: homelab-prod\n      connectHost: " + srv.URL + "\n"
+	for _, tt := range []struct {
+		name, extra  string
+		deleteStatus int
+		want         []string
+		wantErr      bool
+	}{
+		{"default", "", http.StatusNoContent, []string{"i1"}, false},
+		{"keep item", "      deleteItemOnDestroy: false\n", http.StatusNoContent, nil, false},
+		// Nothing else deletes the item, so the run fails before any
+		// delete output and the request says why.
+		{"delete fails", "", http.StatusForbidden, []string{"i1"}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted, outputsAtDelete, deleteStatus = nil, nil, tt.deleteStatus
+			sdk, dir, metadataDir := fixtureSDK(t, append(append([]byte{}, input...), onePassword+tt.extra...))
+			outputDir = dir
+			resource, err := sdk.ReadResourceInput()
+			if err != nil {
+				t.Fatal(err)
+			}
+			config, err := buildConfig(sdk, resource)
+			if err != nil {
+				t.Fatalf("buildConfig: %v", err)
+			}
+			err = u.Execute(sdk, func(x *u.Execution) error {
+				x.Resource = resource
+				return handleDelete(x, config)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleDelete() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(deleted, tt.want) {
+				t.Errorf("deleted items = %v, want %v", deleted, tt.want)
+			}
+			if len(outputsAtDelete) != 0 {
+				t.Errorf("delete outputs written before the item was deleted: %v", outputsAtDelete)
+			}
+			wantOutputs := len(buildDeleteOutputs(config))
+			if tt.wantErr {
+				wantOutputs = 0
+			}
+			if written, _ := filepath.Glob(filepath.Join(dir, "resources", "*")); len(written) != wantOutputs {
+				t.Errorf("delete outputs = %v, want %d", written, wantOutputs)
+			}
+			status, err := os.ReadFile(filepath.Join(metadataDir, "status.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if failed := strings.Contains(string(status), "reason: CleanupFailed\n"); failed != tt.wantErr {
+				t.Errorf("status records a Cleanup failure: %t, want %t:\n%s", failed, tt.wantErr, status)
+			}
+		})
+	}
+}
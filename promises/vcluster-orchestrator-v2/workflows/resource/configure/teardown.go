@@ -0,0 +1,108 @@
+package vclusterorchestratorv2
+
+import (
+	"fmt"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+)
+
+// Teardown order. Every delete output carries the sync wave it is removed
+// in; ArgoCD removes the highest wave first and waits for it to be gone
+// before the next, so each resource is deleted before what it depends on:
+//
+//	50  ArgoCDClusterRegistration  its kubeconfig sync Job mounts the
+//	                               vcluster's kubeconfig Secret and its
+//	                               ExternalSecrets write from it
+//	40  Jobs, ExternalSecrets      e.g. the etcd certificate merge and
+//	                               bootstrap Jobs
+//	30  ArgoCDApplication          the vcluster itself
+//	20  everything else            CoreDNS config, bootstrap ConfigMap,
+//	                               network policies, quota and limit
+//	                               range, API TLSRoute, etcd
+//	                               certificates, Issuers and Secrets
+//	10  RBAC                       once no Job or vcluster runs under it
+//	 0  ArgoCDProject              once its Application is gone
+//	-10 Namespace                  last, unless spec.retainNamespace
+const (
+	waveClusterRegistration = 50
+	waveJobs                = 40
+	waveApplication         = 30
+	waveDefault             = 20
+	waveRBAC                = 10
+	waveProject             = 0
+	waveNamespace           = -10
+)
+
+// deleteWave returns the teardown wave for a resource of kind.
+func deleteWave(kind string) int {
+	switch kind {
+	case "ArgoCDClusterRegistration":
+		return waveClusterRegistration
+	case "Job", "ExternalSecret":
+		return waveJobs
+	case "ArgoCDApplication":
+		return waveApplication
+	case "ServiceAccount", "Role", "RoleBinding", "ClusterRole", "ClusterRoleBinding":
+		return waveRBAC
+	case "ArgoCDProject":
+		return waveProject
+	case "Namespace":
+		return waveNamespace
+	}
+	return waveDefault
+}
+
+// retainNamespace reports whether the delete pipeline leaves the target
+// namespace in place: when spec.retainNamespace asks for it, or when the
+// vcluster runs in the namespace of its own request, which holds other
+// requests.
+func retainNamespace(config *VClusterConfig) bool {
+	return config.RetainNamespace || config.TargetNamespace == config.Namespace
+}
+
+// buildDeleteOutputs returns the delete outputs for everything the
+// configure pipeline rendered, keyed by output path and each annotated
+// with its teardown wave.
+func buildDeleteOutputs(config *VClusterConfig) map[string]u.Resource {
+	created := []u.Resource{
+		buildArgoCDProjectRequest(config),
+		buildArgoCDApplicationRequest(config),
+		buildArgoCDClusterRegistrationRequest(config),
+		buildCorednsConfigMap(config),
+	}
+	created = append(created, buildNetworkPolicies(config)...)
+	created = append(created, buildQuota(config)...)
+	created = append(created, buildAPIRoute(config)...)
+	created = append(created, buildBootstrap(config)...)
+	if etcdEnabled(config) {
+		created = append(created, buildEtcdCertificates(config)...)
+	}
+	if !retainNamespace(config) {
+		created = append(created, buildNamespace(config))
+	}
+
+	outputs := map[string]u.Resource{}
+	for _, obj := range created {
+		outputs[u.DeleteOutputPathForResource("resources", obj)] = u.DeleteFromResource(obj)
+	}
+
+	// Created by the vcluster chart rather than this pipeline.
+	rbacName := fmt.Sprintf("vc-%s-v-%s", config.Name, config.TargetNamespace)
+	outputs["resources/delete-vcluster-clusterrole.yaml"] = u.DeleteResource("rbac.authorization.k8s.io/v1", "ClusterRole", rbacName, "")
+	outputs["resources/delete-vcluster-clusterrolebinding.yaml"] = u.DeleteResource("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", rbacName, "")
+	if etcdEnabled(config) {
+		for path, secret := range map[string]string{
+			"resources/delete-etcd-ca-secret.yaml":     "%s-etcd-ca",
+			"resources/delete-etcd-server-secret.yaml": "%s-etcd-server",
+			"resources/delete-etcd-peer-secret.yaml":   "%s-etcd-peer",
+			"resources/delete-etcd-merged-secret.yaml": "%s-etcd-certs",
+		} {
+			outputs[path] = u.DeleteResource("v1", "Secret", fmt.Sprintf(secret, config.Name), config.TargetNamespace)
+		}
+	}
+
+	for path, obj := range outputs {
+		outputs[path] = u.InDeleteWave(obj, deleteWave(obj.Kind))
+	}
+	return outputs
+}
@@ -1,4 +1,4 @@
-package main
+package vclusterorchestratorv2
 
 // ============================================================================
 // RBAC Types (PolicyRule kept for VCluster RBAC config)
@@ -41,6 +41,22 @@ type PodSpec struct {
 	InitContainers     []Container `json:"initContainers,omitempty"`
 	Containers         []Container `json:"containers"`
 	Volumes            []Volume    `json:"volumes,omitempty"`
+	Affinity           *Affinity   `json:"affinity,omitempty"`
+}
+
+// Affinity holds the pod affinity the sqlite backup Job needs to share a
+// node, and so a ReadWriteOnce volume, with the vcluster pod.
+type Affinity struct {
+	PodAffinity *PodAffinity `json:"podAffinity,omitempty"`
+}
+
+type PodAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution []PodAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution"`
+}
+
+type PodAffinityTerm struct {
+	LabelSelector LabelSelector `json:"labelSelector"`
+	TopologyKey   string        `json:"topologyKey"`
 }
 
 type Container struct {
@@ -73,8 +89,29 @@ type VolumeMount struct {
 }
 
 type Volume struct {
-	Name   string        `json:"name"`
-	Secret *SecretVolume `json:"secret,omitempty"`
+	Name                  string                       `json:"name"`
+	Secret                *SecretVolume                `json:"secret,omitempty"`
+	PersistentVolumeClaim *PersistentVolumeClaimVolume `json:"persistentVolumeClaim,omitempty"`
+	ConfigMap             *ConfigMapVolume             `json:"configMap,omitempty"`
+}
+
+type ConfigMapVolume struct {
+	Name string `json:"name"`
+}
+
+type PersistentVolumeClaimVolume struct {
+	ClaimName string `json:"claimName"`
+}
+
+// PersistentVolumeClaimSpec is the spec of the pre-upgrade backup volume.
+type PersistentVolumeClaimSpec struct {
+	AccessModes      []string                   `json:"accessModes"`
+	StorageClassName string                     `json:"storageClassName,omitempty"`
+	Resources        VolumeResourceRequirements `json:"resources"`
+}
+
+type VolumeResourceRequirements struct {
+	Requests map[string]string `json:"requests"`
 }
 
 type SecretVolume struct {
@@ -138,6 +175,7 @@ type VClusterValues struct {
 	Sync             SyncConfig      `json:"sync"`
 	RBAC             RBACConfig      `json:"rbac"`
 	ExportKubeConfig interface{}     `json:"exportKubeConfig,omitempty"`
+	Experimental     *ExperimentalConfig `json:"experimental,omitempty"`
 }
 
 type EnabledFlag struct {
@@ -241,6 +279,8 @@ type ServiceSpecConfig struct {
 	Type           string        `json:"type"`
 	Ports          []ServicePort `json:"ports"`
 	LoadBalancerIP string        `json:"loadBalancerIP,omitempty"`
+	IPFamilies     []string      `json:"ipFamilies,omitempty"`
+	IPFamilyPolicy string        `json:"ipFamilyPolicy,omitempty"`
 }
 
 type ServicePort struct {
@@ -258,6 +298,19 @@ type DeployConfig struct {
 	MetalLB EnabledFlag `json:"metallb"`
 }
 
+// ExperimentalConfig carries manifests the vcluster applies inside itself.
+type ExperimentalConfig struct {
+	Deploy ExperimentalDeploy `json:"deploy"`
+}
+
+type ExperimentalDeploy struct {
+	VCluster VClusterManifests `json:"vcluster"`
+}
+
+type VClusterManifests struct {
+	Manifests string `json:"manifests,omitempty"`
+}
+
 type Integrations struct {
 	ExternalSecrets IntegrationExternalSecrets `json:"externalSecrets"`
 	MetricsServer   EnabledFlag               `json:"metricsServer"`
@@ -355,6 +408,13 @@ type SecretMappings struct {
 	ByName map[string]string `json:"byName"`
 }
 
+// SecretFromHost is one entry of spec.vcluster.syncSecretsFromHost: the host
+// secret From, as namespace/name, synced into the vcluster as To.
+type SecretFromHost struct {
+	From string
+	To   string
+}
+
 type RBACConfig struct {
 	ClusterRole ClusterRoleConfig `json:"clusterRole"`
 }
@@ -376,6 +436,27 @@ type ExtraEgressRule struct {
 	Protocol string `json:"protocol"`
 }
 
+// ============================================================================
+// Load Balancer Types
+// ============================================================================
+
+// LoadBalancerConfig is the MetalLB address pool for LoadBalancer Services
+// inside the vcluster (spec.vcluster.networking.loadBalancer).
+type LoadBalancerConfig struct {
+	// AddressPool is a CIDR or an inclusive range such as 10.0.5.10-10.0.5.20.
+	AddressPool  string
+	AutoAssign   bool
+	L2Interfaces []string
+}
+
+// QuotaConfig is the resolved ResourceQuota of the target namespace
+// (spec.vcluster.quota): requested CPU and memory, and the pod count.
+type QuotaConfig struct {
+	CPU    string
+	Memory string
+	Pods   int
+}
+
 // ============================================================================
 // Preset Types
 // ============================================================================
@@ -0,0 +1,336 @@
+package vclusterorchestratorv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	kratix "github.com/syntasso/kratix-go"
+
+	u "github.com/jamesatintegratnio/gitops_homelab_2_0/promises/_shared/kratixutil"
+)
+
+// Upgrades. Each configure run records the Kubernetes and chart versions
+// it rendered in status.k8sVersion and status.chartVersion. A run that
+// requests other versions is an upgrade: the Kubernetes version must stay
+// within vcluster's skew policy, and a backup Job for the backing store is
+// rendered in sync wave -1, so ArgoCD runs it to completion before the
+// ArgoCDApplication request (wave 0) rolls the vcluster. The upgrade is
+// appended to status.upgradeHistory, and its backup Job is rendered for as
+// long as the versions stay the same.
+
+// maxUpgradeHistory caps status.upgradeHistory, oldest dropped first.
+const maxUpgradeHistory = 10
+
+// upgradeBackupWave is the sync wave of the backup volume and Job: after
+// the namespace (-2), before the ArgoCDApplication request (0).
+const upgradeBackupWave = "-1"
+
+// Backup images. Both ship a shell.
+const (
+	etcdBackupImage   = "bitnami/etcd:3.5"
+	sqliteBackupImage = "keinos/sqlite3:3.46.1"
+)
+
+// UpgradeRecord is one entry of status.upgradeHistory.
+type UpgradeRecord struct {
+	FromK8sVersion   string `json:"fromK8sVersion"`
+	ToK8sVersion     string `json:"toK8sVersion"`
+	FromChartVersion string `json:"fromChartVersion"`
+	ToChartVersion   string `json:"toChartVersion"`
+	StartedAt        string `json:"startedAt"`
+	// BackupJob names the pre-upgrade backup Job; empty when the backing
+	// store is not backed up, as Backup then explains.
+	BackupJob string `json:"backupJob,omitempty"`
+	Backup    string `json:"backup"`
+}
+
+// planUpgrade compares the requested versions with those recorded in the
+// resource's status. For a new upgrade it checks the version skew and
+// appends a record to config.UpgradeHistory; config.Upgrade is set to the
+// record whose backup is rendered, new or still current.
+func planUpgrade(config *VClusterConfig, resource kratix.Resource, now time.Time) error {
+	recordedK8s, _ := u.GetStringValue(resource, "status.k8sVersion")
+	recordedChart, _ := u.GetStringValue(resource, "status.chartVersion")
+	config.UpgradeHistory = upgradeHistory(resource)
+
+	k8sChanged := recordedK8s != "" && recordedK8s != config.K8sVersion
+	chartChanged := recordedChart != "" && recordedChart != config.ArgoCDTargetRevision
+	if !k8sChanged && !chartChanged {
+		if n := len(config.UpgradeHistory); n > 0 {
+			last := config.UpgradeHistory[n-1]
+			if last.ToK8sVersion == config.K8sVersion && last.ToChartVersion == config.ArgoCDTargetRevision {
+				config.Upgrade = &last
+			}
+		}
+		return nil
+	}
+
+	if k8sChanged {
+		if err := checkVersionSkew(recordedK8s, config.K8sVersion); err != nil {
+			return fmt.Errorf("spec.vcluster.k8sVersion: %w", err)
+		}
+	}
+	record := UpgradeRecord{
+		FromK8sVersion:   orRecorded(recordedK8s, config.K8sVersion),
+		ToK8sVersion:     config.K8sVersion,
+		FromChartVersion: orRecorded(recordedChart, config.ArgoCDTargetRevision),
+		ToChartVersion:   config.ArgoCDTargetRevision,
+		StartedAt:        now.UTC().Format(time.RFC3339),
+	}
+	if reason := backupSkipped(config); reason != "" {
+		record.Backup = reason
+	} else {
+		record.BackupJob = upgradeBackupJobName(config, record)
+		record.Backup = fmt.Sprintf("%s snapshot on PVC %s", backingStoreKind(config), upgradeBackupPVCName(config))
+	}
+	config.UpgradeHistory = append(config.UpgradeHistory, record)
+	if n := len(config.UpgradeHistory); n > maxUpgradeHistory {
+		config.UpgradeHistory = config.UpgradeHistory[n-maxUpgradeHistory:]
+	}
+	config.Upgrade = &record
+	config.UpgradeStarted = true
+	return nil
+}
+
+// orRecorded is the recorded version, or the requested one when only the
+// other version changed and this one was not recorded.
+func orRecorded(recorded, requested string) string {
+	if recorded == "" {
+		return requested
+	}
+	return recorded
+}
+
+// upgradeHistory reads status.upgradeHistory, skipping malformed entries.
+func upgradeHistory(resource kratix.Resource) []UpgradeRecord {
+	val, err := resource.GetValue("status.upgradeHistory")
+	if err != nil || val == nil {
+		return nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+	var records []UpgradeRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil
+	}
+	return records
+}
+
+// checkVersionSkew rejects a Kubernetes version change vcluster cannot
+// apply in place: a major version change, a minor downgrade, or a jump of
+// more than one minor version. Patch changes are allowed either way.
+func checkVersionSkew(from, to string) error {
+	fromMajor, fromMinor, err := parseMinorVersion(from)
+	if err != nil {
+		return fmt.Errorf("recorded version: %w", err)
+	}
+	toMajor, toMinor, err := parseMinorVersion(to)
+	if err != nil {
+		return err
+	}
+	switch {
+	case fromMajor != toMajor:
+		return fmt.Errorf("cannot change the Kubernetes major version from %s to %s", from, to)
+	case toMinor < fromMinor:
+		return fmt.Errorf("cannot downgrade from %s to %s: the vcluster's datastore is already migrated to %d.%d",
+			from, to, fromMajor, fromMinor)
+	case toMinor > fromMinor+1:
+		return fmt.Errorf("cannot upgrade from %s to %s: vcluster upgrades one minor version at a time, so upgrade to %d.%d first",
+			from, to, fromMajor, fromMinor+1)
+	}
+	return nil
+}
+
+// parseMinorVersion reads the major and minor of a version such as
+// v1.34.3 or 1.33.
+func parseMinorVersion(v string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("version %q is not <major>.<minor>[.<patch>]", v)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("version %q is not <major>.<minor>[.<patch>]", v)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("version %q is not <major>.<minor>[.<patch>]", v)
+	}
+	return major, minor, nil
+}
+
+// backingStoreKind names the vcluster's backing store: etcd (deployed by
+// the chart), embedded-etcd, external-database or sqlite (the default).
+func backingStoreKind(config *VClusterConfig) string {
+	enabled := func(path ...string) bool {
+		var v interface{} = config.BackingStore
+		for _, key := range path {
+			m, _ := v.(map[string]interface{})
+			v = m[key]
+		}
+		b, _ := v.(bool)
+		return b
+	}
+	switch {
+	case etcdEnabled(config):
+		return "etcd"
+	case enabled("etcd", "embedded", "enabled"):
+		return "embedded-etcd"
+	case enabled("database", "external", "enabled"):
+		return "external-database"
+	}
+	return "sqlite"
+}
+
+// backupSkipped explains why an upgrade gets no backup Job, or returns ""
+// when it does.
+func backupSkipped(config *VClusterConfig) string {
+	switch kind := backingStoreKind(config); {
+	case config.Paused:
+		return "none: the vcluster is paused"
+	case kind == "embedded-etcd":
+		return "none: embedded etcd is not backed up by the pipeline; take a snapshot with vcluster snapshot"
+	case kind == "external-database":
+		return "none: back up the external database at its source"
+	case kind == "sqlite" && !config.PersistenceEnabled:
+		return "none: persistence is disabled, so the sqlite database is not on a volume"
+	}
+	return ""
+}
+
+func upgradeBackupPVCName(config *VClusterConfig) string {
+	return fmt.Sprintf("%s-upgrade-backups", config.Name)
+}
+
+// upgradeBackupJobName is unique per target versions, as a Job's pod
+// template cannot be changed.
+func upgradeBackupJobName(config *VClusterConfig, record UpgradeRecord) string {
+	slug := strings.NewReplacer(".", "-", "+", "-", "_", "-").Replace(
+		strings.ToLower(record.ToK8sVersion + "-" + record.ToChartVersion))
+	name := fmt.Sprintf("%s-pre-upgrade-%s", config.Name, slug)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// buildUpgradeBackup renders the backup volume and Job for config.Upgrade,
+// or nothing when there is no upgrade or its backing store is not backed
+// up. The volume is never pruned, so backups outlive the Job.
+func buildUpgradeBackup(config *VClusterConfig) []u.Resource {
+	if config.Upgrade == nil || config.Upgrade.BackupJob == "" || backupSkipped(config) != "" {
+		return nil
+	}
+	labels := u.MergeStringMap(map[string]string{
+		"app.kubernetes.io/instance": config.Name,
+		"app.kubernetes.io/name":     "vcluster-upgrade-backup",
+	}, u.BaseLabels(config.WorkflowContext.PromiseName, config.Name))
+
+	pvc := u.Resource{
+		APIVersion: "v1",
+		Kind:       "PersistentVolumeClaim",
+		Metadata: u.ResourceMeta(upgradeBackupPVCName(config), config.TargetNamespace, labels, map[string]string{
+			u.SyncWaveAnnotation:              upgradeBackupWave,
+			"argocd.argoproj.io/sync-options": "Prune=false",
+		}),
+		Spec: PersistentVolumeClaimSpec{
+			AccessModes:      []string{"ReadWriteOnce"},
+			StorageClassName: config.PersistenceClass,
+			Resources:        VolumeResourceRequirements{Requests: map[string]string{"storage": config.PersistenceSize}},
+		},
+	}
+
+	file := fmt.Sprintf("/backup/%s-$(date -u +%%Y%%m%%dT%%H%%M%%SZ)", strings.TrimPrefix(config.Upgrade.BackupJob, config.Name+"-"))
+	backupVolume := Volume{Name: "backup", PersistentVolumeClaim: &PersistentVolumeClaimVolume{ClaimName: upgradeBackupPVCName(config)}}
+	var pod PodSpec
+	if backingStoreKind(config) == "etcd" {
+		pod = PodSpec{
+			Containers: []Container{{
+				Name:    "etcd-snapshot",
+				Image:   etcdBackupImage,
+				Command: []string{"/bin/bash", "-c", buildEtcdSnapshotScript(config, file+".db")},
+				Env:     []EnvVar{{Name: "ETCDCTL_API", Value: "3"}},
+				VolumeMounts: []VolumeMount{
+					{Name: "backup", MountPath: "/backup"},
+					{Name: "etcd-certs", MountPath: "/certs", ReadOnly: true},
+				},
+			}},
+			Volumes: []Volume{
+				backupVolume,
+				{Name: "etcd-certs", Secret: &SecretVolume{SecretName: fmt.Sprintf("%s-etcd-certs", config.Name)}},
+			},
+		}
+	} else {
+		// The database volume is ReadWriteOnce, so the Job runs beside
+		// the vcluster pod that mounts it. sqlite's online backup copies
+		// a consistent database while the vcluster writes to it.
+		pod = PodSpec{
+			Containers: []Container{{
+				Name:    "sqlite-backup",
+				Image:   sqliteBackupImage,
+				Command: []string{"/bin/sh", "-c", fmt.Sprintf("set -e\nf=%s.db\nsqlite3 /data/state.db \".backup '$f'\"\necho \"Backed up the vcluster database to $f\"", file)},
+				VolumeMounts: []VolumeMount{
+					{Name: "backup", MountPath: "/backup"},
+					{Name: "data", MountPath: "/data"},
+				},
+			}},
+			Volumes: []Volume{
+				backupVolume,
+				{Name: "data", PersistentVolumeClaim: &PersistentVolumeClaimVolume{ClaimName: fmt.Sprintf("data-%s-0", config.Name)}},
+			},
+			Affinity: &Affinity{PodAffinity: &PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []PodAffinityTerm{{
+					LabelSelector: LabelSelector{MatchLabels: map[string]string{"app": "vcluster", "release": config.Name}},
+					TopologyKey:   "kubernetes.io/hostname",
+				}},
+			}},
+		}
+	}
+	pod.RestartPolicy = "OnFailure"
+
+	job := u.Resource{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata: u.ResourceMeta(config.Upgrade.BackupJob, config.TargetNamespace, labels, map[string]string{
+			u.SyncWaveAnnotation: upgradeBackupWave,
+		}),
+		Spec: JobSpec{
+			BackoffLimit: 3,
+			Template: PodTemplateSpec{
+				Metadata: &ObjectMetaLocal{Labels: map[string]string{"app": "vcluster-upgrade-backup"}},
+				Spec:     pod,
+			},
+		},
+	}
+	return []u.Resource{pvc, job}
+}
+
+// buildEtcdSnapshotScript saves and verifies an etcd snapshot to file.
+func buildEtcdSnapshotScript(config *VClusterConfig, file string) string {
+	return fmt.Sprintf(`set -e
+f=%s
+etcdctl --endpoints=https://%s-etcd.%s.svc:2379 \
+  --cacert=/certs/etcd-ca.crt --cert=/certs/etcd-server.crt --key=/certs/etcd-server.key \
+  snapshot save "$f"
+etcdutl snapshot status "$f" -w table 2>/dev/null || etcdctl snapshot status "$f" -w table
+echo "Saved the vcluster etcd snapshot to $f"`,
+		file, config.Name, config.TargetNamespace)
+}
+
+// upgradeMessage is the status message for an upgrade this run started.
+func upgradeMessage(config *VClusterConfig) string {
+	r := config.Upgrade
+	from, to := r.FromK8sVersion, r.ToK8sVersion
+	if from == to {
+		from, to = "chart "+r.FromChartVersion, "chart "+r.ToChartVersion
+	}
+	if r.BackupJob == "" {
+		return fmt.Sprintf("VCluster upgrade from %s to %s scheduled without a backup (%s)", from, to, strings.TrimPrefix(r.Backup, "none: "))
+	}
+	return fmt.Sprintf("VCluster upgrade from %s to %s scheduled after backup Job %s", from, to, r.BackupJob)
+}